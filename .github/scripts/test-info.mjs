@@ -272,6 +272,23 @@ const components = {
     'crypto.jwks': {
         conformance: true,
     },
+    'lock.etcd': {
+        conformance: true,
+        conformanceSetup: 'docker-compose.sh etcd',
+        sourcePkg: ['lock/etcd', 'state/etcd'],
+    },
+    'lock.postgresql': {
+        conformance: true,
+        conformanceSetup: 'docker-compose.sh postgresql',
+        sourcePkg: [
+            'lock/postgresql',
+            'common/authentication/postgresql',
+            'common/component/postgresql/interfaces',
+            'common/component/postgresql/transactions',
+            'common/component/sql',
+            'common/component/sql/migrations',
+        ],
+    },
     'lock.redis.v6': {
         conformance: true,
         conformanceSetup: 'docker-compose.sh redisjson redis',
@@ -417,6 +434,11 @@ const components = {
         conformanceSetup: 'docker-compose.sh vernemq',
         sourcePkg: ['pubsub/mqtt3'],
     },
+    'pubsub.mqtt5-emqx': {
+        conformance: true,
+        conformanceSetup: 'docker-compose.sh emqx',
+        sourcePkg: ['pubsub/mqtt5'],
+    },
     'pubsub.pulsar': {
         conformance: true,
         certification: true,
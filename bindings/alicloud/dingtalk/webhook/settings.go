@@ -20,10 +20,13 @@ package webhook
 import (
 	"errors"
 
+	"github.com/dapr/components-contrib/common/httputils"
 	kitmd "github.com/dapr/kit/metadata"
 )
 
 type Settings struct {
+	httputils.ProxyMetadata `mapstructure:",squash"`
+
 	ID     string `mapstructure:"id"`
 	URL    string `mapstructure:"url"`
 	Secret string `mapstructure:"secret"`
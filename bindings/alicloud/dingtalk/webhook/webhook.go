@@ -22,7 +22,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -31,6 +30,7 @@ import (
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/common/httputils"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
@@ -61,23 +61,8 @@ var webhooks = struct { //nolint:gochecknoglobals
 }{m: make(map[string]*outgoingWebhook)}
 
 func NewDingTalkWebhook(l logger.Logger) bindings.InputOutputBinding {
-	// See guidance on proper HTTP client settings here:
-	// https://medium.com/@nate510/don-t-use-go-s-default-http-client-4804cb19f779
-	dialer := &net.Dialer{ //nolint:exhaustivestruct
-		Timeout: 5 * time.Second,
-	}
-	netTransport := &http.Transport{ //nolint:exhaustivestruct
-		DialContext:         dialer.DialContext,
-		TLSHandshakeTimeout: 5 * time.Second,
-	}
-	httpClient := &http.Client{ //nolint:exhaustivestruct
-		Timeout:   defaultHTTPClientTimeout,
-		Transport: netTransport,
-	}
-
 	return &DingTalkWebhook{ //nolint:exhaustivestruct
-		logger:     l,
-		httpClient: httpClient,
+		logger: l,
 	}
 }
 
@@ -91,6 +76,18 @@ func (t *DingTalkWebhook) Init(_ context.Context, metadata bindings.Metadata) er
 		return fmt.Errorf("dingtalk configuration error: %w", err)
 	}
 
+	// See guidance on proper HTTP client settings here:
+	// https://medium.com/@nate510/don-t-use-go-s-default-http-client-4804cb19f779
+	t.httpClient, err = httputils.ClientConfig{
+		Proxy:               t.settings.ProxyMetadata,
+		Timeout:             defaultHTTPClientTimeout,
+		DialTimeout:         5 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}.Client()
+	if err != nil {
+		return fmt.Errorf("dingtalk configuration error: %w", err)
+	}
+
 	return nil
 }
 
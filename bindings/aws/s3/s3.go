@@ -14,6 +14,7 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	b64 "encoding/base64"
@@ -24,11 +25,15 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
@@ -49,12 +54,22 @@ const (
 	metadataFilePath     = "filePath"
 	metadataPresignTTL   = "presignTTL"
 	metadataStorageClass = "storageClass"
+	metadataPartSize     = "partSize"
+	metadataConcurrency  = "concurrency"
+	metadataPartRetries  = "maxPartRetries"
+
+	metadataServerSideEncryption = "serverSideEncryption"
+	metadataKMSKeyID             = "kmsKeyID"
+	metadataCustomerKey          = "customerKey"
+	metadataContentIntegrity     = "contentIntegrity"
 
 	metatadataContentType = "Content-Type"
 	metadataKey           = "key"
 
-	defaultMaxResults = 1000
-	presignOperation  = "presign"
+	defaultMaxResults     = 1000
+	contentIntegrityMD5   = "md5"
+	presignOperation      = "presign"
+	getPresignedOperation = "getPresigned"
 )
 
 // AWSS3 is a binding for an AWS S3 storage bucket.
@@ -80,7 +95,18 @@ type s3Metadata struct {
 	InsecureSSL    bool   `json:"insecureSSL,string" mapstructure:"insecureSSL"`
 	FilePath       string `json:"filePath" mapstructure:"filePath"   mdignore:"true"`
 	PresignTTL     string `json:"presignTTL" mapstructure:"presignTTL"  mdignore:"true"`
-	StorageClass   string `json:"storageClass" mapstructure:"storageClass"  mdignore:"true"`
+	StorageClass   string `json:"storageClass" mapstructure:"storageClass"`
+	PartSize       int64  `json:"partSize,string" mapstructure:"partSize"`
+	Concurrency    int    `json:"concurrency,string" mapstructure:"concurrency"`
+	MaxPartRetries int    `json:"maxPartRetries,string" mapstructure:"maxPartRetries"`
+
+	// Server-side encryption of the uploaded objects
+	ServerSideEncryption string `json:"serverSideEncryption" mapstructure:"serverSideEncryption"`
+	KMSKeyID             string `json:"kmsKeyID" mapstructure:"kmsKeyID"`
+	// Base64-encoded 256-bit key for server-side encryption with customer-provided keys (SSE-C)
+	CustomerKey string `json:"customerKey" mapstructure:"customerKey"`
+	// Checksum sent with the uploads, verified by S3
+	ContentIntegrity string `json:"contentIntegrity" mapstructure:"contentIntegrity"`
 }
 
 type createResponse struct {
@@ -93,6 +119,12 @@ type presignResponse struct {
 	PresignURL string `json:"presignURL"`
 }
 
+type getPresignedResponse struct {
+	DownloadURL string    `json:"downloadURL"`
+	UploadURL   string    `json:"uploadURL"`
+	Expiration  time.Time `json:"expiration"`
+}
+
 type listPayload struct {
 	Marker     string `json:"marker"`
 	Prefix     string `json:"prefix"`
@@ -166,6 +198,7 @@ func (s *AWSS3) Operations() []bindings.OperationKind {
 		bindings.DeleteOperation,
 		bindings.ListOperation,
 		presignOperation,
+		getPresignedOperation,
 	}
 }
 
@@ -191,31 +224,43 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 	if contentTypeStr != "" {
 		contentType = &contentTypeStr
 	}
+	// The uploader streams the body, sending it in multiple parts when it's larger than the part size
 	var r io.Reader
 	if metadata.FilePath != "" {
-		r, err = os.Open(metadata.FilePath)
-		if err != nil {
-			return nil, fmt.Errorf("s3 binding error: file read error: %w", err)
+		f, fErr := os.Open(metadata.FilePath)
+		if fErr != nil {
+			return nil, fmt.Errorf("s3 binding error: file read error: %w", fErr)
 		}
-	} else {
+		defer f.Close()
+		r = f
+	} else if bytes.HasPrefix(bytes.TrimSpace(req.Data), []byte{'"'}) {
 		r = strings.NewReader(commonutils.Unquote(req.Data))
+	} else {
+		// Avoid unmarshalling (and copying) payloads that are not JSON strings, which are uploaded as-is
+		r = bytes.NewReader(req.Data)
 	}
 
 	if metadata.DecodeBase64 {
 		r = b64.NewDecoder(b64.StdEncoding, r)
 	}
 
-	var storageClass *string
+	input := &s3manager.UploadInput{
+		Bucket:      ptr.Of(metadata.Bucket),
+		Key:         ptr.Of(key),
+		Body:        r,
+		ContentType: contentType,
+	}
 	if metadata.StorageClass != "" {
-		storageClass = aws.String(metadata.StorageClass)
-	}
-	resultUpload, err := s.authProvider.S3().Uploader.UploadWithContext(ctx, &s3manager.UploadInput{
-		Bucket:       ptr.Of(metadata.Bucket),
-		Key:          ptr.Of(key),
-		Body:         r,
-		ContentType:  contentType,
-		StorageClass: storageClass,
-	})
+		input.StorageClass = aws.String(metadata.StorageClass)
+	}
+	if sse := metadata.serverSideEncryption(); sse != "" {
+		input.ServerSideEncryption = aws.String(sse)
+	}
+	if metadata.KMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(metadata.KMSKeyID)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = metadata.sseCustomerKey()
+	resultUpload, err := s.authProvider.S3().Uploader.UploadWithContext(ctx, input, metadata.uploaderOptions)
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error: uploading failed: %w", err)
 	}
@@ -279,6 +324,62 @@ func (s *AWSS3) presign(ctx context.Context, req *bindings.InvokeRequest) (*bind
 	}, nil
 }
 
+// getPresigned returns presigned URLs to download and to upload the object.
+func (s *AWSS3) getPresigned(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := s.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: error merging metadata: %w", err)
+	}
+
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		return nil, fmt.Errorf("s3 binding error: required metadata '%s' missing", metadataKey)
+	}
+
+	if metadata.PresignTTL == "" {
+		return nil, fmt.Errorf("s3 binding error: required metadata '%s' missing", metadataPresignTTL)
+	}
+	d, err := time.ParseDuration(metadata.PresignTTL)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: cannot parse duration %s: %w", metadata.PresignTTL, err)
+	}
+
+	downloadURL, err := s.presignObject(ctx, metadata.Bucket, key, metadata.PresignTTL)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+
+	// If a content type is set, uploads must be made with the same Content-Type header
+	putInput := &s3.PutObjectInput{
+		Bucket: ptr.Of(metadata.Bucket),
+		Key:    ptr.Of(key),
+	}
+	if contentType := strings.TrimSpace(req.Metadata[metatadataContentType]); contentType != "" {
+		putInput.ContentType = ptr.Of(contentType)
+	}
+	putReq, _ := s.authProvider.S3().S3.PutObjectRequest(putInput)
+	uploadURL, err := putReq.Presign(d)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: failed to presign upload URL: %w", err)
+	}
+
+	jsonResponse, err := json.Marshal(getPresignedResponse{
+		DownloadURL: downloadURL,
+		UploadURL:   uploadURL,
+		Expiration:  time.Now().Add(d).UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: error marshalling getPresigned response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+		Metadata: map[string]string{
+			metadataKey: key,
+		},
+	}, nil
+}
+
 func (s *AWSS3) presignObject(ctx context.Context, bucket, key, ttl string) (string, error) {
 	d, err := time.ParseDuration(ttl)
 	if err != nil {
@@ -307,20 +408,22 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 		return nil, fmt.Errorf("s3 binding error: required metadata '%s' missing", metadataKey)
 	}
 
+	input := &s3.GetObjectInput{
+		Bucket: ptr.Of(s.metadata.Bucket),
+		Key:    ptr.Of(key),
+	}
+	// Objects encrypted with a customer-provided key can only be read with the same key
+	input.SSECustomerAlgorithm, input.SSECustomerKey = metadata.sseCustomerKey()
+
+	// If a file path is set in the request, the object is streamed to the file rather than returned in the response
+	if filePath := req.Metadata[metadataFilePath]; filePath != "" {
+		return s.getToFile(ctx, input, filePath, metadata)
+	}
+
 	buff := &aws.WriteAtBuffer{}
-	_, err = s.authProvider.S3().Downloader.DownloadWithContext(ctx,
-		buff,
-		&s3.GetObjectInput{
-			Bucket: ptr.Of(s.metadata.Bucket),
-			Key:    ptr.Of(key),
-		},
-	)
+	_, err = s.authProvider.S3().Downloader.DownloadWithContext(ctx, buff, input, metadata.downloaderOptions)
 	if err != nil {
-		var awsErr awserr.Error
-		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
-			return nil, errors.New("object not found")
-		}
-		return nil, fmt.Errorf("s3 binding error: error downloading S3 object: %w", err)
+		return nil, downloadError(err)
 	}
 
 	var data []byte
@@ -337,6 +440,38 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 	}, nil
 }
 
+// getToFile downloads the object to a file, writing the parts as they are received.
+func (s *AWSS3) getToFile(ctx context.Context, input *s3.GetObjectInput, filePath string, metadata s3Metadata) (*bindings.InvokeResponse, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: file write error: %w", err)
+	}
+	defer f.Close()
+
+	n, err := s.authProvider.S3().Downloader.DownloadWithContext(ctx, f, input, metadata.downloaderOptions)
+	if err != nil {
+		// Do not leave partially-written files
+		f.Close()
+		os.Remove(filePath)
+		return nil, downloadError(err)
+	}
+
+	return &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			metadataFilePath: filePath,
+			"size":           strconv.FormatInt(n, 10),
+		},
+	}, nil
+}
+
+func downloadError(err error) error {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return errors.New("object not found")
+	}
+	return fmt.Errorf("s3 binding error: error downloading S3 object: %w", err)
+}
+
 func (s *AWSS3) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	key := req.Metadata[metadataKey]
 	if key == "" {
@@ -404,6 +539,8 @@ func (s *AWSS3) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		return s.list(ctx, req)
 	case presignOperation:
 		return s.presign(ctx, req)
+	case getPresignedOperation:
+		return s.getPresigned(ctx, req)
 	default:
 		return nil, fmt.Errorf("s3 binding error: unsupported operation %s", req.Operation)
 	}
@@ -415,6 +552,14 @@ func (s *AWSS3) parseMetadata(md bindings.Metadata) (*s3Metadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = m.validateTransferOptions()
+	if err != nil {
+		return nil, err
+	}
+	err = m.validateEncryptionOptions()
+	if err != nil {
+		return nil, err
+	}
 	return &m, nil
 }
 
@@ -442,7 +587,147 @@ func (metadata s3Metadata) mergeWithRequestMetadata(req *bindings.InvokeRequest)
 		merged.StorageClass = val
 	}
 
-	return merged, nil
+	if val, ok := req.Metadata[metadataServerSideEncryption]; ok && val != "" {
+		merged.ServerSideEncryption = val
+	}
+
+	if val, ok := req.Metadata[metadataKMSKeyID]; ok && val != "" {
+		merged.KMSKeyID = val
+	}
+
+	if val, ok := req.Metadata[metadataCustomerKey]; ok && val != "" {
+		merged.CustomerKey = val
+	}
+
+	if val, ok := req.Metadata[metadataContentIntegrity]; ok && val != "" {
+		merged.ContentIntegrity = val
+	}
+
+	var err error
+	if val, ok := req.Metadata[metadataPartSize]; ok && val != "" {
+		merged.PartSize, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s: %w", metadataPartSize, err)
+		}
+	}
+
+	if val, ok := req.Metadata[metadataConcurrency]; ok && val != "" {
+		merged.Concurrency, err = strconv.Atoi(val)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s: %w", metadataConcurrency, err)
+		}
+	}
+
+	if val, ok := req.Metadata[metadataPartRetries]; ok && val != "" {
+		merged.MaxPartRetries, err = strconv.Atoi(val)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s: %w", metadataPartRetries, err)
+		}
+	}
+
+	err = merged.validateTransferOptions()
+	if err != nil {
+		return merged, err
+	}
+	return merged, merged.validateEncryptionOptions()
+}
+
+func (metadata s3Metadata) validateTransferOptions() error {
+	if metadata.PartSize != 0 && metadata.PartSize < s3manager.MinUploadPartSize {
+		return fmt.Errorf("invalid %s: must be at least %d bytes", metadataPartSize, s3manager.MinUploadPartSize)
+	}
+	if metadata.Concurrency < 0 {
+		return fmt.Errorf("invalid %s: must not be negative", metadataConcurrency)
+	}
+	if metadata.MaxPartRetries < 0 {
+		return fmt.Errorf("invalid %s: must not be negative", metadataPartRetries)
+	}
+	return nil
+}
+
+func (metadata s3Metadata) validateEncryptionOptions() error {
+	if metadata.StorageClass != "" && !slices.Contains(s3.StorageClass_Values(), metadata.StorageClass) {
+		return fmt.Errorf("invalid %s: %s; allowed: %s", metadataStorageClass, metadata.StorageClass, s3.StorageClass_Values())
+	}
+	if metadata.ServerSideEncryption != "" && !slices.Contains(s3.ServerSideEncryption_Values(), metadata.ServerSideEncryption) {
+		return fmt.Errorf("invalid %s: %s; allowed: %s", metadataServerSideEncryption, metadata.ServerSideEncryption, s3.ServerSideEncryption_Values())
+	}
+	if metadata.KMSKeyID != "" && metadata.serverSideEncryption() == s3.ServerSideEncryptionAes256 {
+		return fmt.Errorf("invalid %s: a KMS key requires %s to be %s or %s", metadataKMSKeyID, metadataServerSideEncryption, s3.ServerSideEncryptionAwsKms, s3.ServerSideEncryptionAwsKmsDsse)
+	}
+	if metadata.CustomerKey != "" {
+		if metadata.serverSideEncryption() != "" {
+			return fmt.Errorf("invalid %s: customer-provided keys can't be used with %s", metadataCustomerKey, metadataServerSideEncryption)
+		}
+		key, err := b64.StdEncoding.DecodeString(metadata.CustomerKey)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid %s: must be a base64-encoded 256-bit key", metadataCustomerKey)
+		}
+	}
+	switch metadata.ContentIntegrity {
+	case "", contentIntegrityMD5:
+	default:
+		return fmt.Errorf("invalid %s: %s; allowed: %s", metadataContentIntegrity, metadata.ContentIntegrity, contentIntegrityMD5)
+	}
+	return nil
+}
+
+// serverSideEncryption returns the server-side encryption to use, which is aws:kms when only a KMS key is set.
+func (metadata s3Metadata) serverSideEncryption() string {
+	if metadata.ServerSideEncryption == "" && metadata.KMSKeyID != "" {
+		return s3.ServerSideEncryptionAwsKms
+	}
+	return metadata.ServerSideEncryption
+}
+
+// sseCustomerKey returns the algorithm and the raw value of the customer-provided key, if any.
+func (metadata s3Metadata) sseCustomerKey() (algorithm *string, key *string) {
+	if metadata.CustomerKey == "" {
+		return nil, nil
+	}
+	// The key was validated when parsing the metadata
+	raw, _ := b64.StdEncoding.DecodeString(metadata.CustomerKey)
+	return aws.String(s3.ServerSideEncryptionAes256), aws.String(string(raw))
+}
+
+// partRequestOptions returns the options for the requests of each part, which are retried individually.
+func (metadata s3Metadata) partRequestOptions() []request.Option {
+	var opts []request.Option
+	if metadata.MaxPartRetries != 0 {
+		opts = append(opts, func(r *request.Request) {
+			r.Retryer = client.DefaultRetryer{NumMaxRetries: metadata.MaxPartRetries}
+		})
+	}
+	if metadata.ContentIntegrity == contentIntegrityMD5 {
+		// The SDK sends the Content-MD5 header of each part unless disabled in the config
+		opts = append(opts, func(r *request.Request) {
+			r.Config.S3DisableContentMD5Validation = aws.Bool(false)
+		})
+	}
+	return opts
+}
+
+// uploaderOptions configures the multipart uploads.
+// Failed parts are retried without restarting the upload; if a part can't be uploaded, the upload is aborted.
+func (metadata s3Metadata) uploaderOptions(u *s3manager.Uploader) {
+	if metadata.PartSize > 0 {
+		u.PartSize = metadata.PartSize
+	}
+	if metadata.Concurrency > 0 {
+		u.Concurrency = metadata.Concurrency
+	}
+	u.RequestOptions = append(u.RequestOptions, metadata.partRequestOptions()...)
+}
+
+// downloaderOptions configures the downloads, which are made in parts too.
+func (metadata s3Metadata) downloaderOptions(d *s3manager.Downloader) {
+	if metadata.PartSize > 0 {
+		d.PartSize = metadata.PartSize
+	}
+	if metadata.Concurrency > 0 {
+		d.Concurrency = metadata.Concurrency
+	}
+	d.RequestOptions = append(d.RequestOptions, metadata.partRequestOptions()...)
 }
 
 // GetComponentMetadata returns the metadata of the component.
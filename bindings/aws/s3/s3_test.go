@@ -14,13 +14,28 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	b64 "encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -170,6 +185,294 @@ func TestMergeWithRequestMetadata(t *testing.T) {
 	})
 }
 
+func TestTransferOptions(t *testing.T) {
+	t.Run("parses transfer options", func(t *testing.T) {
+		s3 := AWSS3{}
+		meta, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"bucket":         "test",
+			"partSize":       "10485760",
+			"concurrency":    "3",
+			"maxPartRetries": "7",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(10485760), meta.PartSize)
+		assert.Equal(t, 3, meta.Concurrency)
+		assert.Equal(t, 7, meta.MaxPartRetries)
+
+		merged, err := meta.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"partSize":    "20971520",
+			"concurrency": "1",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(20971520), merged.PartSize)
+		assert.Equal(t, 1, merged.Concurrency)
+		assert.Equal(t, 7, merged.MaxPartRetries)
+
+		u := &s3manager.Uploader{PartSize: s3manager.DefaultUploadPartSize, Concurrency: s3manager.DefaultUploadConcurrency}
+		merged.uploaderOptions(u)
+		assert.Equal(t, int64(20971520), u.PartSize)
+		assert.Equal(t, 1, u.Concurrency)
+		assert.Len(t, u.RequestOptions, 1)
+	})
+
+	t.Run("rejects part size smaller than the minimum", func(t *testing.T) {
+		s3 := AWSS3{}
+		_, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"bucket":   "test",
+			"partSize": "1024",
+		}}})
+		require.Error(t, err)
+
+		_, err = (&s3Metadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"partSize": "1024",
+		}})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects invalid request options", func(t *testing.T) {
+		_, err := (&s3Metadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"concurrency": "many",
+		}})
+		require.Error(t, err)
+
+		_, err = (&s3Metadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"maxPartRetries": "-1",
+		}})
+		require.Error(t, err)
+	})
+}
+
+// fakeS3Server is a minimal S3 server that stores the objects in memory.
+type fakeS3Server struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+	// Headers of the last upload
+	headers http.Header
+}
+
+func (f *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.headers = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Range", "bytes 0-"+strconv.Itoa(len(body)-1)+"/"+strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T, endpoint string) *AWSS3 {
+	t.Helper()
+
+	s3 := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
+	err := s3.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"bucket":         "test",
+		"region":         "us-east-1",
+		"accessKey":      "key",
+		"secretKey":      "secret",
+		"endpoint":       endpoint,
+		"forcePathStyle": "true",
+		"disableSSL":     "true",
+	}}})
+	require.NoError(t, err)
+	t.Cleanup(func() { s3.Close() })
+
+	return s3
+}
+
+func TestEncryptionOptions(t *testing.T) {
+	customerKey := b64.StdEncoding.EncodeToString(bytes.Repeat([]byte{'k'}, 32))
+
+	t.Run("parses encryption options", func(t *testing.T) {
+		s3 := AWSS3{}
+		meta, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"bucket":           "test",
+			"kmsKeyID":         "alias/my-key",
+			"storageClass":     "GLACIER_IR",
+			"contentIntegrity": "md5",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "alias/my-key", meta.KMSKeyID)
+		assert.Equal(t, "aws:kms", meta.serverSideEncryption())
+		assert.Equal(t, "GLACIER_IR", meta.StorageClass)
+		assert.Len(t, meta.partRequestOptions(), 1)
+
+		merged, err := meta.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"serverSideEncryption": "aws:kms:dsse",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "aws:kms:dsse", merged.serverSideEncryption())
+	})
+
+	t.Run("customer-provided key", func(t *testing.T) {
+		meta, err := (&s3Metadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"customerKey": customerKey,
+		}})
+		require.NoError(t, err)
+		algorithm, key := meta.sseCustomerKey()
+		assert.Equal(t, "AES256", *algorithm)
+		assert.Equal(t, strings.Repeat("k", 32), *key)
+
+		algorithm, key = (&s3Metadata{}).sseCustomerKey()
+		assert.Nil(t, algorithm)
+		assert.Nil(t, key)
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{"storageClass": "COLD"},
+			{"serverSideEncryption": "aes"},
+			{"serverSideEncryption": "AES256", "kmsKeyID": "alias/my-key"},
+			{"customerKey": "a2V5"},
+			{"customerKey": customerKey, "kmsKeyID": "alias/my-key"},
+			{"contentIntegrity": "crc32c"},
+		} {
+			_, err := (&s3Metadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: md})
+			require.Errorf(t, err, "expected error for %v", md)
+		}
+	})
+}
+
+func TestCreateAndGet(t *testing.T) {
+	server := &fakeS3Server{objects: map[string][]byte{}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	s3 := newTestS3(t, ts.URL)
+
+	t.Run("uploads data that is not a JSON string as-is", func(t *testing.T) {
+		_, err := s3.create(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte(`{"hello":"world"}`),
+			Metadata: map[string]string{"key": "obj.json"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"hello":"world"}`, string(server.objects["/test/obj.json"]))
+	})
+
+	t.Run("unquotes JSON strings", func(t *testing.T) {
+		_, err := s3.create(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte(`"hello world"`),
+			Metadata: map[string]string{"key": "obj.txt"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(server.objects["/test/obj.txt"]))
+	})
+
+	t.Run("uploads from file", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "src")
+		require.NoError(t, os.WriteFile(src, []byte("from file"), 0o600))
+
+		_, err := s3.create(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"key": "file.txt", "filePath": src},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "from file", string(server.objects["/test/file.txt"]))
+	})
+
+	t.Run("uploads with encryption and integrity options", func(t *testing.T) {
+		_, err := s3.create(context.Background(), &bindings.InvokeRequest{
+			Data: []byte(`"encrypted"`),
+			Metadata: map[string]string{
+				"key":              "kms.txt",
+				"kmsKeyID":         "alias/my-key",
+				"storageClass":     "STANDARD_IA",
+				"contentIntegrity": "md5",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "encrypted", string(server.objects["/test/kms.txt"]))
+		assert.Equal(t, "aws:kms", server.headers.Get("X-Amz-Server-Side-Encryption"))
+		assert.Equal(t, "alias/my-key", server.headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+		assert.Equal(t, "STANDARD_IA", server.headers.Get("X-Amz-Storage-Class"))
+		sum := md5.Sum([]byte("encrypted"))
+		assert.Equal(t, b64.StdEncoding.EncodeToString(sum[:]), server.headers.Get("Content-Md5"))
+	})
+
+	t.Run("downloads to file", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+
+		res, err := s3.get(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"key": "obj.txt", "filePath": dst},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Equal(t, dst, res.Metadata["filePath"])
+		assert.Equal(t, "11", res.Metadata["size"])
+
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+	})
+
+	t.Run("does not leave a file if the object does not exist", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+
+		_, err := s3.get(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"key": "missing", "filePath": dst},
+		})
+		require.Error(t, err)
+		assert.NoFileExists(t, dst)
+	})
+}
+
+func TestGetPresigned(t *testing.T) {
+	s3 := newTestS3(t, "http://localhost:9000")
+
+	t.Run("returns download and upload URLs", func(t *testing.T) {
+		res, err := s3.getPresigned(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{
+				"key":          "obj.txt",
+				"presignTTL":   "15m",
+				"Content-Type": "text/plain",
+			},
+		})
+		require.NoError(t, err)
+
+		var presigned getPresignedResponse
+		require.NoError(t, json.Unmarshal(res.Data, &presigned))
+
+		download, err := url.Parse(presigned.DownloadURL)
+		require.NoError(t, err)
+		assert.Equal(t, "/test/obj.txt", download.Path)
+		assert.Equal(t, "900", download.Query().Get("X-Amz-Expires"))
+
+		upload, err := url.Parse(presigned.UploadURL)
+		require.NoError(t, err)
+		assert.Equal(t, "/test/obj.txt", upload.Path)
+		assert.True(t, strings.Contains(upload.Query().Get("X-Amz-SignedHeaders"), "content-type"))
+		assert.NotEqual(t, download.Query().Get("X-Amz-Signature"), upload.Query().Get("X-Amz-Signature"))
+		assert.False(t, presigned.Expiration.IsZero())
+	})
+
+	t.Run("return error if key is missing", func(t *testing.T) {
+		_, err := s3.getPresigned(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"presignTTL": "15m"},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("return error if TTL is missing", func(t *testing.T) {
+		_, err := s3.getPresigned(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"key": "obj.txt"},
+		})
+		require.Error(t, err)
+	})
+}
+
 func TestGetOption(t *testing.T) {
 	s3 := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
 	s3.metadata = &s3Metadata{}
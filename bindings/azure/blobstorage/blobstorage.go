@@ -14,7 +14,10 @@ limitations under the License.
 package blobstorage
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
 	b64 "encoding/base64"
 	"encoding/json"
 	"errors"
@@ -22,12 +25,17 @@ import (
 	"io"
 	"reflect"
 	"strconv"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/appendblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/lease"
 	"github.com/google/uuid"
 
 	"github.com/dapr/components-contrib/bindings"
@@ -54,14 +62,51 @@ const (
 	// Defines the delete snapshots option for the delete operation.
 	// See: https://docs.microsoft.com/en-us/rest/api/storageservices/delete-blob#request-headers
 	metadataKeyDeleteSnapshots = "deleteSnapshots"
+	// Defines the ID of the lease on the blob. Writing to or deleting a leased blob requires the ID of its lease.
+	// See: https://learn.microsoft.com/rest/api/storageservices/lease-blob
+	metadataKeyLeaseID = "leaseID"
+	// Defines the duration in seconds of the lease acquired with the acquireLease operation.
+	// It must be between 15 and 60 seconds, or -1 for a lease that never expires.
+	metadataKeyLeaseDuration = "leaseDuration"
+	// Defines the size the blob must have for the append operation to succeed, to detect concurrent appends.
+	// See: https://learn.microsoft.com/rest/api/storageservices/append-block#request-headers
+	metadataKeyAppendPosition = "appendPosition"
+	// Defines the response metadata key for the offset at which the block was appended.
+	metadataKeyAppendOffset = "appendOffset"
+	// Defines the response metadata key for the number of blocks committed to the append blob.
+	metadataKeyCommittedBlockCount = "committedBlockCount"
+	// Defines the access tier of the uploaded block blobs.
+	// See: https://learn.microsoft.com/azure/storage/blobs/access-tiers-overview
+	metadataKeyStorageClass = "storageClass"
+	// Defines the encryption scope used to encrypt the uploaded blobs, for example with a customer-managed key.
+	// See: https://learn.microsoft.com/azure/storage/blobs/encryption-scope-overview
+	metadataKeyEncryptionScope = "encryptionScope"
+	// Defines the base64-encoded AES-256 key used to encrypt the blobs, which is required to read them too.
+	// See: https://learn.microsoft.com/azure/storage/blobs/encryption-customer-provided-keys
+	metadataKeyCustomerKey = "customerKey"
+	// Defines the checksum sent with the uploads and verified by the service.
+	metadataKeyContentIntegrity = "contentIntegrity"
+	contentIntegrityMD5         = "md5"
 	// Specifies the maximum number of blobs to return, including all BlobPrefix elements. If the request does not
 	// specify maxresults the server will return up to 5,000 items.
 	// See: https://docs.microsoft.com/en-us/rest/api/storageservices/list-blobs#uri-parameters
 	maxResults  int32 = 5000
 	endpointKey       = "endpoint"
+	// Duration of the leases acquired without the leaseDuration metadata, in seconds.
+	defaultLeaseDuration int32 = 60
 )
 
-var ErrMissingBlobName = errors.New("blobName is a required attribute")
+const (
+	AppendOperation       bindings.OperationKind = "append"
+	AcquireLeaseOperation bindings.OperationKind = "acquireLease"
+	RenewLeaseOperation   bindings.OperationKind = "renewLease"
+	ReleaseLeaseOperation bindings.OperationKind = "releaseLease"
+)
+
+var (
+	ErrMissingBlobName = errors.New("blobName is a required attribute")
+	ErrMissingLeaseID  = errors.New("leaseID is a required attribute")
+)
 
 // AzureBlobStorage allows saving blobs to an Azure Blob Storage account.
 type AzureBlobStorage struct {
@@ -71,6 +116,14 @@ type AzureBlobStorage struct {
 	logger logger.Logger
 }
 
+// blobOptions contains the access tier, encryption and integrity options of a request.
+type blobOptions struct {
+	accessTier       *blob.AccessTier
+	cpkInfo          *blob.CPKInfo
+	cpkScopeInfo     *blob.CPKScopeInfo
+	contentIntegrity string
+}
+
 type createResponse struct {
 	BlobURL  string `json:"blobURL"`
 	BlobName string `json:"blobName"`
@@ -103,6 +156,11 @@ func (a *AzureBlobStorage) Init(ctx context.Context, metadata bindings.Metadata)
 	if err != nil {
 		return err
 	}
+	// Validate the default options of the requests
+	_, err = a.blobOptionsFromRequest(&bindings.InvokeRequest{})
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -112,6 +170,10 @@ func (a *AzureBlobStorage) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.DeleteOperation,
 		bindings.ListOperation,
+		AppendOperation,
+		AcquireLeaseOperation,
+		RenewLeaseOperation,
+		ReleaseLeaseOperation,
 	}
 }
 
@@ -128,32 +190,37 @@ func (a *AzureBlobStorage) create(ctx context.Context, req *bindings.InvokeReque
 		blobName = id.String()
 	}
 
-	blobHTTPHeaders, err := storagecommon.CreateBlobHTTPHeadersFromRequest(req.Metadata, nil, a.logger)
+	accessConditions := accessConditionsFromRequest(req)
+	blobOpts, err := a.blobOptionsFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
-
-	d, err := strconv.Unquote(string(req.Data))
-	if err == nil {
-		req.Data = []byte(d)
+	blobHTTPHeaders, err := storagecommon.CreateBlobHTTPHeadersFromRequest(req.Metadata, nil, a.logger)
+	if err != nil {
+		return nil, err
 	}
 
-	if a.metadata.DecodeBase64 {
-		decoded, decodeError := b64.StdEncoding.DecodeString(string(req.Data))
-		if decodeError != nil {
-			return nil, decodeError
-		}
-		req.Data = decoded
+	req.Data, err = a.decodeData(req.Data)
+	if err != nil {
+		return nil, err
 	}
 
 	uploadOptions := azblob.UploadBufferOptions{
 		Metadata:                storagecommon.SanitizeMetadata(a.logger, req.Metadata),
 		HTTPHeaders:             &blobHTTPHeaders,
 		TransactionalContentMD5: blobHTTPHeaders.BlobContentMD5,
+		AccessConditions:        accessConditions,
+		AccessTier:              blobOpts.accessTier,
+		CPKInfo:                 blobOpts.cpkInfo,
+		CPKScopeInfo:            blobOpts.cpkScopeInfo,
 	}
 
 	blockBlobClient := a.containerClient.NewBlockBlobClient(blobName)
-	_, err = blockBlobClient.UploadBuffer(ctx, req.Data, &uploadOptions)
+	if blobOpts.contentIntegrity == contentIntegrityMD5 {
+		err = uploadWithMD5(ctx, blockBlobClient, req.Data, &uploadOptions)
+	} else {
+		_, err = blockBlobClient.UploadBuffer(ctx, req.Data, &uploadOptions)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error uploading az blob: %w", err)
 	}
@@ -184,8 +251,15 @@ func (a *AzureBlobStorage) get(ctx context.Context, req *bindings.InvokeRequest)
 		return nil, ErrMissingBlobName
 	}
 
+	// Blobs encrypted with a customer-provided key can only be read with the same key
+	blobOpts, err := a.blobOptionsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
 	downloadOptions := azblob.DownloadStreamOptions{
 		AccessConditions: &blob.AccessConditions{},
+		CPKInfo:          blobOpts.cpkInfo,
 	}
 
 	blobDownloadResponse, err := blockBlobClient.DownloadStream(ctx, &downloadOptions)
@@ -210,6 +284,7 @@ func (a *AzureBlobStorage) get(ctx context.Context, req *bindings.InvokeRequest)
 
 	getPropertiesOptions := blob.GetPropertiesOptions{
 		AccessConditions: &blob.AccessConditions{},
+		CPKInfo:          blobOpts.cpkInfo,
 	}
 
 	if fetchMetadata {
@@ -253,7 +328,7 @@ func (a *AzureBlobStorage) delete(ctx context.Context, req *bindings.InvokeReque
 
 	deleteOptions := blob.DeleteOptions{
 		DeleteSnapshots:  &deleteSnapshotsOptions,
-		AccessConditions: &blob.AccessConditions{},
+		AccessConditions: accessConditionsFromRequest(req),
 	}
 
 	blockBlobClient = a.containerClient.NewBlockBlobClient(val)
@@ -344,6 +419,309 @@ func (a *AzureBlobStorage) list(ctx context.Context, req *bindings.InvokeRequest
 	}, nil
 }
 
+// append appends the data of the request as a new block of an append blob, creating the blob if it doesn't exist.
+func (a *AzureBlobStorage) append(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	blobName := req.Metadata[metadataKeyBlobName]
+	if blobName == "" {
+		return nil, ErrMissingBlobName
+	}
+	delete(req.Metadata, metadataKeyBlobName)
+
+	appendOptions := appendblob.AppendBlockOptions{
+		AccessConditions: accessConditionsFromRequest(req),
+	}
+	if val, ok := req.Metadata[metadataKeyAppendPosition]; ok {
+		delete(req.Metadata, metadataKeyAppendPosition)
+		if val != "" {
+			position, err := strconv.ParseInt(val, 10, 64)
+			if err != nil || position < 0 {
+				return nil, fmt.Errorf("invalid %s: %s", metadataKeyAppendPosition, val)
+			}
+			appendOptions.AppendPositionAccessConditions = &appendblob.AppendPositionAccessConditions{
+				AppendPosition: &position,
+			}
+		}
+	}
+
+	// Append blobs don't have access tiers
+	blobOpts, err := a.blobOptionsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	appendOptions.CPKInfo = blobOpts.cpkInfo
+	appendOptions.CPKScopeInfo = blobOpts.cpkScopeInfo
+
+	blobHTTPHeaders, err := storagecommon.CreateBlobHTTPHeadersFromRequest(req.Metadata, nil, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := a.decodeData(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if blobOpts.contentIntegrity == contentIntegrityMD5 {
+		sum := md5.Sum(data) //nolint:gosec
+		appendOptions.TransactionalValidation = blob.TransferValidationTypeMD5(sum[:])
+	}
+
+	appendBlobClient := a.containerClient.NewAppendBlobClient(blobName)
+	appendResponse, err := appendBlobClient.AppendBlock(ctx, streaming.NopCloser(bytes.NewReader(data)), &appendOptions)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		// The headers and metadata of the request are only set when the blob is created
+		createOptions := appendblob.CreateOptions{
+			HTTPHeaders: &blobHTTPHeaders,
+			Metadata:    storagecommon.SanitizeMetadata(a.logger, req.Metadata),
+			AccessConditions: &blob.AccessConditions{
+				ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfNoneMatch: ptr.Of(azcore.ETagAny)},
+			},
+			CPKInfo:      blobOpts.cpkInfo,
+			CPKScopeInfo: blobOpts.cpkScopeInfo,
+		}
+		_, err = appendBlobClient.Create(ctx, &createOptions)
+		if err != nil && !bloberror.HasCode(err, bloberror.BlobAlreadyExists) {
+			return nil, fmt.Errorf("error creating az append blob: %w", err)
+		}
+		appendResponse, err = appendBlobClient.AppendBlock(ctx, streaming.NopCloser(bytes.NewReader(data)), &appendOptions)
+	}
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.AppendPositionConditionNotMet) {
+			return nil, fmt.Errorf("blob size does not match %s", metadataKeyAppendPosition)
+		}
+		return nil, fmt.Errorf("error appending to az blob: %w", err)
+	}
+
+	b, err := json.Marshal(createResponse{
+		BlobURL: appendBlobClient.URL(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling append response for azure blob: %w", err)
+	}
+
+	appendResponseMetadata := map[string]string{
+		metadataKeyBlobName: blobName,
+	}
+	if appendResponse.BlobAppendOffset != nil {
+		appendResponseMetadata[metadataKeyAppendOffset] = *appendResponse.BlobAppendOffset
+	}
+	if appendResponse.BlobCommittedBlockCount != nil {
+		appendResponseMetadata[metadataKeyCommittedBlockCount] = strconv.FormatInt(int64(*appendResponse.BlobCommittedBlockCount), 10)
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     b,
+		Metadata: appendResponseMetadata,
+	}, nil
+}
+
+// acquireLease acquires a lease on a blob, using the lease ID of the request if any.
+func (a *AzureBlobStorage) acquireLease(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	blobName := req.Metadata[metadataKeyBlobName]
+	if blobName == "" {
+		return nil, ErrMissingBlobName
+	}
+
+	duration := defaultLeaseDuration
+	if val := req.Metadata[metadataKeyLeaseDuration]; val != "" {
+		d, err := strconv.ParseInt(val, 10, 32)
+		if err != nil || (d != -1 && (d < 15 || d > 60)) {
+			return nil, fmt.Errorf("invalid %s: %s; must be between 15 and 60 seconds, or -1", metadataKeyLeaseDuration, val)
+		}
+		duration = int32(d)
+	}
+
+	leaseOptions := lease.BlobClientOptions{}
+	if val := req.Metadata[metadataKeyLeaseID]; val != "" {
+		leaseOptions.LeaseID = &val
+	}
+	leaseClient, err := lease.NewBlobClient(a.containerClient.NewBlobClient(blobName), &leaseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseResponse, err := leaseClient.AcquireLease(ctx, duration, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, errors.New("blob not found")
+		}
+		if bloberror.HasCode(err, bloberror.LeaseAlreadyPresent) {
+			return nil, errors.New("blob is already leased")
+		}
+		return nil, fmt.Errorf("error acquiring lease on az blob: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			metadataKeyBlobName: blobName,
+			metadataKeyLeaseID:  *leaseResponse.LeaseID,
+		},
+	}, nil
+}
+
+// renewLease renews the lease on a blob, resetting its duration.
+func (a *AzureBlobStorage) renewLease(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	blobName, leaseClient, err := a.leaseClientFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseResponse, err := leaseClient.RenewLease(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error renewing lease on az blob: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			metadataKeyBlobName: blobName,
+			metadataKeyLeaseID:  *leaseResponse.LeaseID,
+		},
+	}, nil
+}
+
+// releaseLease releases the lease on a blob, so another lease can be acquired immediately.
+func (a *AzureBlobStorage) releaseLease(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	_, leaseClient, err := a.leaseClientFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = leaseClient.ReleaseLease(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error releasing lease on az blob: %w", err)
+	}
+
+	return nil, nil
+}
+
+// leaseClientFromRequest returns the name of the blob and a client for the lease of the request.
+func (a *AzureBlobStorage) leaseClientFromRequest(req *bindings.InvokeRequest) (string, *lease.BlobClient, error) {
+	blobName := req.Metadata[metadataKeyBlobName]
+	if blobName == "" {
+		return "", nil, ErrMissingBlobName
+	}
+	leaseID := req.Metadata[metadataKeyLeaseID]
+	if leaseID == "" {
+		return "", nil, ErrMissingLeaseID
+	}
+
+	leaseClient, err := lease.NewBlobClient(a.containerClient.NewBlobClient(blobName), &lease.BlobClientOptions{
+		LeaseID: &leaseID,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return blobName, leaseClient, nil
+}
+
+// decodeData returns the data of a request to upload, unquoted and decoded from base64 if configured.
+func (a *AzureBlobStorage) decodeData(data []byte) ([]byte, error) {
+	d, err := strconv.Unquote(string(data))
+	if err == nil {
+		data = []byte(d)
+	}
+
+	if a.metadata.DecodeBase64 {
+		decoded, decodeError := b64.StdEncoding.DecodeString(string(data))
+		if decodeError != nil {
+			return nil, decodeError
+		}
+		data = decoded
+	}
+
+	return data, nil
+}
+
+// accessConditionsFromRequest returns the access conditions of a write with the lease ID of the request, if any.
+// The lease ID is removed from the request metadata, so it is not stored as blob metadata.
+func accessConditionsFromRequest(req *bindings.InvokeRequest) *blob.AccessConditions {
+	accessConditions := &blob.AccessConditions{}
+	if val, ok := req.Metadata[metadataKeyLeaseID]; ok {
+		delete(req.Metadata, metadataKeyLeaseID)
+		if val != "" {
+			accessConditions.LeaseAccessConditions = &blob.LeaseAccessConditions{LeaseID: &val}
+		}
+	}
+	return accessConditions
+}
+
+// blobOptionsFromRequest returns the access tier, encryption and integrity options of a request, which default to the
+// options of the component. The options are removed from the request metadata, so they are not stored as blob metadata.
+func (a *AzureBlobStorage) blobOptionsFromRequest(req *bindings.InvokeRequest) (blobOptions, error) {
+	option := func(key string, defaultValue string) string {
+		if val, ok := req.Metadata[key]; ok {
+			delete(req.Metadata, key)
+			if val != "" {
+				return val
+			}
+		}
+		return defaultValue
+	}
+
+	opts := blobOptions{
+		contentIntegrity: option(metadataKeyContentIntegrity, a.metadata.ContentIntegrity),
+	}
+	if opts.contentIntegrity != "" && opts.contentIntegrity != contentIntegrityMD5 {
+		return blobOptions{}, fmt.Errorf("invalid %s: %s; allowed: %s", metadataKeyContentIntegrity, opts.contentIntegrity, contentIntegrityMD5)
+	}
+
+	if val := option(metadataKeyStorageClass, a.metadata.StorageClass); val != "" {
+		for _, tier := range blob.PossibleAccessTierValues() {
+			if strings.EqualFold(val, string(tier)) {
+				opts.accessTier = &tier
+				break
+			}
+		}
+		if opts.accessTier == nil {
+			return blobOptions{}, fmt.Errorf("invalid %s: %s; allowed: %s", metadataKeyStorageClass, val, blob.PossibleAccessTierValues())
+		}
+	}
+
+	if val := option(metadataKeyEncryptionScope, a.metadata.EncryptionScope); val != "" {
+		opts.cpkScopeInfo = &blob.CPKScopeInfo{EncryptionScope: &val}
+	}
+
+	if val := option(metadataKeyCustomerKey, a.metadata.CustomerKey); val != "" {
+		if opts.cpkScopeInfo != nil {
+			return blobOptions{}, fmt.Errorf("invalid %s: customer-provided keys can't be used with %s", metadataKeyCustomerKey, metadataKeyEncryptionScope)
+		}
+		key, err := b64.StdEncoding.DecodeString(val)
+		if err != nil || len(key) != 32 {
+			return blobOptions{}, fmt.Errorf("invalid %s: must be a base64-encoded 256-bit key", metadataKeyCustomerKey)
+		}
+		sum := sha256.Sum256(key)
+		opts.cpkInfo = &blob.CPKInfo{
+			EncryptionKey:       &val,
+			EncryptionKeySHA256: ptr.Of(b64.StdEncoding.EncodeToString(sum[:])),
+			EncryptionAlgorithm: ptr.Of(blob.EncryptionAlgorithmTypeAES256),
+		}
+	}
+
+	return opts, nil
+}
+
+// uploadWithMD5 uploads a block blob in a single request with the MD5 hash of the data, which is verified by the service.
+// The hash is also stored as the Content-MD5 of the blob, unless set in the request.
+func uploadWithMD5(ctx context.Context, client *blockblob.Client, data []byte, o *azblob.UploadBufferOptions) error {
+	if len(data) > blockblob.MaxUploadBlobBytes {
+		return fmt.Errorf("blobs larger than %d bytes can't be uploaded with %s %s", blockblob.MaxUploadBlobBytes, metadataKeyContentIntegrity, contentIntegrityMD5)
+	}
+
+	sum := md5.Sum(data) //nolint:gosec
+	if o.HTTPHeaders.BlobContentMD5 == nil {
+		o.HTTPHeaders.BlobContentMD5 = sum[:]
+	}
+	_, err := client.Upload(ctx, streaming.NopCloser(bytes.NewReader(data)), &blockblob.UploadOptions{
+		Metadata:                o.Metadata,
+		Tier:                    o.AccessTier,
+		HTTPHeaders:             o.HTTPHeaders,
+		AccessConditions:        o.AccessConditions,
+		CPKInfo:                 o.CPKInfo,
+		CPKScopeInfo:            o.CPKScopeInfo,
+		TransactionalValidation: blob.TransferValidationTypeMD5(sum[:]),
+	})
+	return err
+}
+
 func (a *AzureBlobStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	switch req.Operation {
 	case bindings.CreateOperation:
@@ -354,6 +732,14 @@ func (a *AzureBlobStorage) Invoke(ctx context.Context, req *bindings.InvokeReque
 		return a.delete(ctx, req)
 	case bindings.ListOperation:
 		return a.list(ctx, req)
+	case AppendOperation:
+		return a.append(ctx, req)
+	case AcquireLeaseOperation:
+		return a.acquireLease(ctx, req)
+	case RenewLeaseOperation:
+		return a.renewLease(ctx, req)
+	case ReleaseLeaseOperation:
+		return a.releaseLease(ctx, req)
 	default:
 		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
 	}
@@ -14,12 +14,18 @@ limitations under the License.
 package blobstorage
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	storagecommon "github.com/dapr/components-contrib/common/component/azure/blobstorage"
 	"github.com/dapr/kit/logger"
 )
 
@@ -54,3 +60,133 @@ func TestDeleteOption(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestAppendOption(t *testing.T) {
+	blobStorage := NewAzureBlobStorage(logger.NewLogger("test")).(*AzureBlobStorage)
+
+	t.Run("return error if blobName is missing", func(t *testing.T) {
+		r := bindings.InvokeRequest{}
+		_, err := blobStorage.append(context.Background(), &r)
+		require.ErrorIs(t, err, ErrMissingBlobName)
+	})
+
+	t.Run("return error for invalid appendPosition", func(t *testing.T) {
+		r := bindings.InvokeRequest{}
+		r.Metadata = map[string]string{
+			"blobName":       "foo",
+			"appendPosition": "-1",
+		}
+		_, err := blobStorage.append(context.Background(), &r)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "appendPosition")
+	})
+}
+
+func TestLeaseOptions(t *testing.T) {
+	blobStorage := NewAzureBlobStorage(logger.NewLogger("test")).(*AzureBlobStorage)
+
+	t.Run("return error if blobName is missing", func(t *testing.T) {
+		for _, invoke := range []func(context.Context, *bindings.InvokeRequest) (*bindings.InvokeResponse, error){
+			blobStorage.acquireLease, blobStorage.renewLease, blobStorage.releaseLease,
+		} {
+			r := bindings.InvokeRequest{}
+			_, err := invoke(context.Background(), &r)
+			require.ErrorIs(t, err, ErrMissingBlobName)
+		}
+	})
+
+	t.Run("return error if leaseID is missing", func(t *testing.T) {
+		for _, invoke := range []func(context.Context, *bindings.InvokeRequest) (*bindings.InvokeResponse, error){
+			blobStorage.renewLease, blobStorage.releaseLease,
+		} {
+			r := bindings.InvokeRequest{}
+			r.Metadata = map[string]string{"blobName": "foo"}
+			_, err := invoke(context.Background(), &r)
+			require.ErrorIs(t, err, ErrMissingLeaseID)
+		}
+	})
+
+	t.Run("return error for invalid leaseDuration", func(t *testing.T) {
+		for _, d := range []string{"0", "10", "61", "-2", "forever"} {
+			r := bindings.InvokeRequest{}
+			r.Metadata = map[string]string{
+				"blobName":      "foo",
+				"leaseDuration": d,
+			}
+			_, err := blobStorage.acquireLease(context.Background(), &r)
+			require.Error(t, err, d)
+			assert.Contains(t, err.Error(), "leaseDuration")
+		}
+	})
+}
+
+func TestAccessConditionsFromRequest(t *testing.T) {
+	t.Run("lease ID", func(t *testing.T) {
+		r := bindings.InvokeRequest{
+			Metadata: map[string]string{"leaseID": "abc", "custom": "value"},
+		}
+		accessConditions := accessConditionsFromRequest(&r)
+		require.NotNil(t, accessConditions.LeaseAccessConditions)
+		assert.Equal(t, "abc", *accessConditions.LeaseAccessConditions.LeaseID)
+		assert.Equal(t, map[string]string{"custom": "value"}, r.Metadata)
+	})
+
+	t.Run("no lease ID", func(t *testing.T) {
+		r := bindings.InvokeRequest{}
+		accessConditions := accessConditionsFromRequest(&r)
+		assert.Nil(t, accessConditions.LeaseAccessConditions)
+	})
+}
+
+func TestBlobOptionsFromRequest(t *testing.T) {
+	customerKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{'k'}, 32))
+	blobStorage := &AzureBlobStorage{
+		metadata: &storagecommon.BlobStorageMetadata{
+			StorageClass:    "Cool",
+			EncryptionScope: "scope",
+		},
+	}
+
+	t.Run("defaults to the component options", func(t *testing.T) {
+		opts, err := blobStorage.blobOptionsFromRequest(&bindings.InvokeRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, blob.AccessTierCool, *opts.accessTier)
+		assert.Equal(t, "scope", *opts.cpkScopeInfo.EncryptionScope)
+		assert.Nil(t, opts.cpkInfo)
+		assert.Empty(t, opts.contentIntegrity)
+	})
+
+	t.Run("request options override the component options", func(t *testing.T) {
+		r := &bindings.InvokeRequest{Metadata: map[string]string{
+			"storageClass":     "archive",
+			"encryptionScope":  "",
+			"customerKey":      customerKey,
+			"contentIntegrity": "md5",
+			"custom":           "value",
+		}}
+		opts, err := (&AzureBlobStorage{metadata: &storagecommon.BlobStorageMetadata{StorageClass: "Cool"}}).blobOptionsFromRequest(r)
+		require.NoError(t, err)
+		assert.Equal(t, blob.AccessTierArchive, *opts.accessTier)
+		assert.Nil(t, opts.cpkScopeInfo)
+		assert.Equal(t, customerKey, *opts.cpkInfo.EncryptionKey)
+		sum := sha256.Sum256(bytes.Repeat([]byte{'k'}, 32))
+		assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), *opts.cpkInfo.EncryptionKeySHA256)
+		assert.Equal(t, blob.EncryptionAlgorithmTypeAES256, *opts.cpkInfo.EncryptionAlgorithm)
+		assert.Equal(t, "md5", opts.contentIntegrity)
+
+		// The options are not stored as blob metadata
+		assert.Equal(t, map[string]string{"custom": "value"}, r.Metadata)
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{"storageClass": "frozen"},
+			{"customerKey": "a2V5"},
+			{"customerKey": customerKey, "encryptionScope": "scope"},
+			{"contentIntegrity": "crc32c"},
+		} {
+			_, err := (&AzureBlobStorage{metadata: &storagecommon.BlobStorageMetadata{}}).blobOptionsFromRequest(&bindings.InvokeRequest{Metadata: md})
+			require.Errorf(t, err, "expected error for %v", md)
+		}
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,6 +36,7 @@ const (
 	correlationID = "correlationID"
 	label         = "label"
 	id            = "id"
+	deliveryCount = "deliveryCount"
 )
 
 // AzureServiceBusQueues is an input/output binding reading from and sending events to Azure Service Bus queues.
@@ -185,6 +187,9 @@ func (a *AzureServiceBusQueues) getHandlerFn(handler bindings.Handler) impl.Hand
 			}
 		}
 
+		// Number of times the message was delivered, so apps can detect poison messages
+		metadata[deliveryCount] = strconv.FormatUint(uint64(msg.DeliveryCount), 10)
+
 		_, err := handler(ctx, &bindings.ReadResponse{
 			Data:     msg.Body,
 			Metadata: metadata,
@@ -193,6 +198,11 @@ func (a *AzureServiceBusQueues) getHandlerFn(handler bindings.Handler) impl.Hand
 	}
 }
 
+// Ping checks the connection to the Service Bus namespace.
+func (a *AzureServiceBusQueues) Ping(ctx context.Context) error {
+	return a.client.Ping(ctx)
+}
+
 func (a *AzureServiceBusQueues) Close() (err error) {
 	if a.closed.CompareAndSwap(false, true) {
 		close(a.closeCh)
@@ -45,6 +45,10 @@ const (
 	nextVisibleTime          = "nextVisibleTime"
 	popReceipt               = "popReceipt"
 	messageID                = "messageID"
+	// Request metadata key for how long a new message stays invisible in the queue before it can be read.
+	initialVisibilityDelay = "initialVisibilityDelay"
+	// Maximum visibility timeout of Storage Queues messages.
+	maxVisibilityTimeout = 7 * 24 * time.Hour
 )
 
 type consumer struct {
@@ -54,7 +58,7 @@ type consumer struct {
 // QueueHelper enables injection for testnig.
 type QueueHelper interface {
 	Init(ctx context.Context, metadata bindings.Metadata) (*storageQueuesMetadata, error)
-	Write(ctx context.Context, data []byte, ttl *time.Duration) error
+	Write(ctx context.Context, data []byte, ttl *time.Duration, visibilityDelay *time.Duration) error
 	Read(ctx context.Context, consumer *consumer) error
 	Close() error
 }
@@ -90,6 +94,11 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 		},
 	}
 
+	queueURL, err := m.GetQueueURL(azEnvSettings)
+	if err != nil {
+		return nil, err
+	}
+
 	var queueServiceClient *azqueue.ServiceClient
 	if m.AccountKey != "" && m.AccountName != "" {
 		var credential *azqueue.SharedKeyCredential
@@ -97,7 +106,7 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 		if err != nil {
 			return nil, fmt.Errorf("invalid shared key credentials with error: %w", err)
 		}
-		queueServiceClient, err = azqueue.NewServiceClientWithSharedKeyCredential(m.GetQueueURL(azEnvSettings), credential, &options)
+		queueServiceClient, err = azqueue.NewServiceClientWithSharedKeyCredential(queueURL, credential, &options)
 		if err != nil {
 			return nil, fmt.Errorf("cannot init storage queue client with shared key: %w", err)
 		}
@@ -107,7 +116,7 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 			return nil, fmt.Errorf("invalid token credentials with error: %w", tokenErr)
 		}
 		var clientErr error
-		queueServiceClient, clientErr = azqueue.NewServiceClient(m.GetQueueURL(azEnvSettings), credential, &options)
+		queueServiceClient, clientErr = azqueue.NewServiceClient(queueURL, credential, &options)
 		if clientErr != nil {
 			return nil, fmt.Errorf("cannot init storage queue client with Azure AD token: %w", clientErr)
 		}
@@ -129,7 +138,7 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 	return m, nil
 }
 
-func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, ttl *time.Duration) error {
+func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, ttl *time.Duration, visibilityDelay *time.Duration) error {
 	var ttlSeconds *int32
 	if ttl != nil {
 		ttlSeconds = ptr.Of(int32(ttl.Seconds()))
@@ -146,8 +155,14 @@ func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, ttl *time.Dur
 		s = base64.StdEncoding.EncodeToString([]byte(s))
 	}
 
+	var visibilityTimeoutSeconds *int32
+	if visibilityDelay != nil {
+		visibilityTimeoutSeconds = ptr.Of(int32(visibilityDelay.Seconds()))
+	}
+
 	_, err = d.queueClient.EnqueueMessage(ctx, s, &azqueue.EnqueueMessageOptions{
-		TimeToLive: ttlSeconds,
+		TimeToLive:        ttlSeconds,
+		VisibilityTimeout: visibilityTimeoutSeconds,
 	})
 
 	return err
@@ -259,14 +274,15 @@ type storageQueuesMetadata struct {
 	VisibilityTimeout *time.Duration
 }
 
-func (m *storageQueuesMetadata) GetQueueURL(azEnvSettings azauth.EnvironmentSettings) string {
-	var URL string
+func (m *storageQueuesMetadata) GetQueueURL(azEnvSettings azauth.EnvironmentSettings) (string, error) {
 	if m.QueueEndpoint != "" {
-		URL = fmt.Sprintf("%s/%s/", m.QueueEndpoint, m.AccountName)
-	} else {
-		URL = fmt.Sprintf("https://%s.queue.%s/", m.AccountName, azEnvSettings.EndpointSuffix(azauth.ServiceAzureStorage))
+		return fmt.Sprintf("%s/%s/", m.QueueEndpoint, m.AccountName), nil
 	}
-	return URL
+	suffix, err := azEnvSettings.GetEndpointSuffix(azauth.ServiceAzureStorage)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.queue.%s/", m.AccountName, suffix), nil
 }
 
 // NewAzureStorageQueues returns a new AzureStorageQueues instance.
@@ -335,6 +351,28 @@ func parseMetadata(meta bindings.Metadata) (*storageQueuesMetadata, error) {
 	return &m, nil
 }
 
+// parseInitialVisibilityDelay returns the initial visibility delay of the request, as a duration or a number of seconds.
+func parseInitialVisibilityDelay(reqMetadata map[string]string) (*time.Duration, error) {
+	val := reqMetadata[initialVisibilityDelay]
+	if val == "" {
+		return nil, nil
+	}
+
+	delay, err := time.ParseDuration(val)
+	if err != nil {
+		seconds, secondsErr := strconv.ParseInt(val, 10, 64)
+		if secondsErr != nil {
+			return nil, fmt.Errorf("invalid value for '%s': %s", initialVisibilityDelay, val)
+		}
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay < 0 || delay > maxVisibilityTimeout {
+		return nil, fmt.Errorf("invalid value for '%s': must be between 0 and 7 days", initialVisibilityDelay)
+	}
+
+	return &delay, nil
+}
+
 func (a *AzureStorageQueues) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
@@ -350,7 +388,22 @@ func (a *AzureStorageQueues) Invoke(ctx context.Context, req *bindings.InvokeReq
 		ttlToUse = &ttl
 	}
 
-	err = a.helper.Write(ctx, req.Data, ttlToUse)
+	visibilityDelay, err := parseInitialVisibilityDelay(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if visibilityDelay != nil {
+		// Messages must become visible before they expire
+		expiry := defaultTTL
+		if ttlToUse != nil {
+			expiry = *ttlToUse
+		}
+		if *visibilityDelay >= expiry {
+			return nil, fmt.Errorf("invalid value for '%s': must be lower than the message TTL", initialVisibilityDelay)
+		}
+	}
+
+	err = a.helper.Write(ctx, req.Data, ttlToUse, visibilityDelay)
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +45,9 @@ func (m *MockHelper) Init(ctx context.Context, metadata bindings.Metadata) (*sto
 	return m.metadata, err
 }
 
-func (m *MockHelper) Write(ctx context.Context, data []byte, ttl *time.Duration) error {
+func (m *MockHelper) Write(ctx context.Context, data []byte, ttl *time.Duration, visibilityDelay *time.Duration) error {
 	m.messages <- data
-	retvals := m.Called(data, ttl)
+	retvals := m.Called(data, ttl, visibilityDelay)
 	return retvals.Error(0)
 }
 
@@ -89,7 +89,7 @@ func TestWriteQueue(t *testing.T) {
 	mm := new(MockHelper)
 	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
 		return in == nil
-	})).Return(nil)
+	}), mock.AnythingOfType("*time.Duration")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
 
@@ -111,7 +111,7 @@ func TestWriteWithTTLInQueue(t *testing.T) {
 	mm := new(MockHelper)
 	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
 		return in != nil && *in == time.Second
-	})).Return(nil)
+	}), mock.AnythingOfType("*time.Duration")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
 
@@ -133,7 +133,7 @@ func TestWriteWithTTLInWrite(t *testing.T) {
 	mm := new(MockHelper)
 	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
 		return in != nil && *in == time.Second
-	})).Return(nil)
+	}), mock.AnythingOfType("*time.Duration")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
 
@@ -154,6 +154,72 @@ func TestWriteWithTTLInWrite(t *testing.T) {
 	require.NoError(t, a.Close())
 }
 
+func TestWriteWithInitialVisibilityDelay(t *testing.T) {
+	mm := new(MockHelper)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration"), mock.MatchedBy(func(in *time.Duration) bool {
+		return in != nil && *in == 30*time.Second
+	})).Return(nil)
+
+	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
+
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{"storageAccessKey": "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==", "queue": "queue1", "storageAccount": "devstoreaccount1"}
+
+	err := a.Init(context.Background(), m)
+	require.NoError(t, err)
+
+	t.Run("delay is passed to the queue", func(t *testing.T) {
+		r := bindings.InvokeRequest{
+			Data:     []byte("This is my message"),
+			Metadata: map[string]string{"initialVisibilityDelay": "30s"},
+		}
+		_, err = a.Invoke(context.Background(), &r)
+		require.NoError(t, err)
+		mm.AssertNumberOfCalls(t, "Write", 1)
+	})
+
+	t.Run("delay must be lower than the TTL", func(t *testing.T) {
+		r := bindings.InvokeRequest{
+			Data:     []byte("This is my message"),
+			Metadata: map[string]string{"initialVisibilityDelay": "30", metadata.TTLMetadataKey: "30"},
+		}
+		_, err = a.Invoke(context.Background(), &r)
+		require.Error(t, err)
+		mm.AssertNumberOfCalls(t, "Write", 1)
+	})
+
+	require.NoError(t, a.Close())
+}
+
+func TestParseInitialVisibilityDelay(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected *time.Duration
+		err      bool
+	}{
+		{value: "", expected: nil},
+		{value: "1m", expected: ptr.Of(time.Minute)},
+		{value: "90", expected: ptr.Of(90 * time.Second)},
+		{value: "0", expected: ptr.Of(time.Duration(0))},
+		{value: "-1s", err: true},
+		{value: "8d", err: true},
+		{value: "200h", err: true},
+		{value: "soon", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			delay, err := parseInitialVisibilityDelay(map[string]string{"initialVisibilityDelay": tc.value})
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, delay)
+		})
+	}
+}
+
 // Uncomment this function to write a message to local storage queue
 /* func TestWriteLocalQueue(t *testing.T) {
 
@@ -174,7 +240,7 @@ func TestWriteWithTTLInWrite(t *testing.T) {
 
 func TestReadQueue(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration"), mock.AnythingOfType("*time.Duration")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
 
@@ -215,7 +281,7 @@ func TestReadQueue(t *testing.T) {
 
 func TestReadQueueDecode(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration"), mock.AnythingOfType("*time.Duration")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -286,7 +352,7 @@ func TestReadQueueDecode(t *testing.T) {
 */
 func TestReadQueueNoMessage(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration"), mock.AnythingOfType("*time.Duration")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
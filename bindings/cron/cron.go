@@ -15,9 +15,12 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,18 +36,33 @@ import (
 
 // Binding represents Cron input binding.
 type Binding struct {
-	logger   logger.Logger
-	name     string
-	schedule string
-	parser   cron.Parser
-	clk      clock.Clock
-	closed   atomic.Bool
-	closeCh  chan struct{}
-	wg       sync.WaitGroup
+	logger    logger.Logger
+	name      string
+	schedules []namedSchedule
+	jitter    time.Duration
+	location  *time.Location
+	parser    cron.Parser
+	clk       clock.Clock
+	closed    atomic.Bool
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
 }
 
 type metadata struct {
 	Schedule string
+	// JSON object with the name of each schedule as key and the schedule as value.
+	Schedules string
+	// Maximum random delay added to each trigger.
+	Jitter time.Duration
+	// Time zone the schedules are evaluated in, as IANA name; defaults to the local time zone.
+	TimeZone string `mapstructure:"timezone"`
+}
+
+// namedSchedule is a schedule of the binding.
+// The name is empty for the schedule set in the "schedule" metadata property.
+type namedSchedule struct {
+	name     string
+	schedule string
 }
 
 // NewCron returns a new Cron event input binding.
@@ -68,6 +86,10 @@ func NewCronWithClock(logger logger.Logger, clk clock.Clock) bindings.InputBindi
 //
 //	"15 * * * * *" - Every 15 sec
 //	"0 30 * * * *" - Every 30 min
+//
+// Multiple schedules can be set in the "schedules" property, as a JSON object with the name of each schedule as key:
+//
+//	{"hourly": "@every 1h", "nightly": "0 0 * * *"}
 func (b *Binding) Init(ctx context.Context, meta bindings.Metadata) error {
 	b.name = meta.Name
 	m := metadata{}
@@ -75,14 +97,51 @@ func (b *Binding) Init(ctx context.Context, meta bindings.Metadata) error {
 	if err != nil {
 		return err
 	}
-	if m.Schedule == "" {
+
+	b.schedules = b.schedules[:0]
+	if m.Schedule != "" {
+		b.schedules = append(b.schedules, namedSchedule{schedule: m.Schedule})
+	}
+	if m.Schedules != "" {
+		named := map[string]string{}
+		err = json.Unmarshal([]byte(m.Schedules), &named)
+		if err != nil {
+			return fmt.Errorf("invalid schedules, must be a JSON object with the name of each schedule as key: %w", err)
+		}
+		names := make([]string, 0, len(named))
+		for name := range named {
+			if name == "" {
+				return errors.New("invalid schedules: schedule name must not be empty")
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.schedules = append(b.schedules, namedSchedule{name: name, schedule: named[name]})
+		}
+	}
+	if len(b.schedules) == 0 {
 		return errors.New("schedule not set")
 	}
-	_, err = b.parser.Parse(m.Schedule)
-	if err != nil {
-		return fmt.Errorf("invalid schedule format '%s': %w", m.Schedule, err)
+	for _, s := range b.schedules {
+		_, err = b.parser.Parse(s.schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule format '%s': %w", s.schedule, err)
+		}
+	}
+
+	if m.Jitter < 0 {
+		return errors.New("jitter must not be negative")
+	}
+	b.jitter = m.Jitter
+
+	b.location = time.Local
+	if m.TimeZone != "" {
+		b.location, err = time.LoadLocation(m.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone '%s': %w", m.TimeZone, err)
+		}
 	}
-	b.schedule = m.Schedule
 
 	return nil
 }
@@ -93,21 +152,34 @@ func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 		return errors.New("binding is closed")
 	}
 
-	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk))
-	id, err := c.AddFunc(b.schedule, func() {
-		b.logger.Debugf("name: %s, schedule fired: %v", b.name, time.Now())
-		handler(ctx, &bindings.ReadResponse{
-			Metadata: map[string]string{
+	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk), cron.WithLocation(b.location))
+	ids := make([]cron.EntryID, len(b.schedules))
+	for i, s := range b.schedules {
+		id, err := c.AddFunc(s.schedule, func() {
+			if !b.waitJitter(ctx) {
+				return
+			}
+			b.logger.Debugf("name: %s, schedule %s fired: %v", b.name, s.name, time.Now())
+			md := map[string]string{
 				"timeZone":    c.Location().String(),
 				"readTimeUTC": time.Now().UTC().String(),
-			},
+			}
+			if s.name != "" {
+				md["schedule"] = s.name
+			}
+			handler(ctx, &bindings.ReadResponse{
+				Metadata: md,
+			})
 		})
-	})
-	if err != nil {
-		return fmt.Errorf("name: %s, error scheduling %s: %w", b.name, b.schedule, err)
+		if err != nil {
+			return fmt.Errorf("name: %s, error scheduling %s: %w", b.name, s.schedule, err)
+		}
+		ids[i] = id
 	}
 	c.Start()
-	b.logger.Debugf("name: %s, next run: %v", b.name, time.Until(c.Entry(id).Next))
+	for i, id := range ids {
+		b.logger.Debugf("name: %s, schedule %s next run: %v", b.name, b.schedules[i].name, time.Until(c.Entry(id).Next))
+	}
 
 	b.wg.Add(1)
 	go func() {
@@ -117,13 +189,32 @@ func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 		case <-ctx.Done():
 		case <-b.closeCh:
 		}
-		b.logger.Debugf("name: %s, stopping schedule: %s", b.name, b.schedule)
+		b.logger.Debugf("name: %s, stopping schedules", b.name)
 		c.Stop()
 	}()
 
 	return nil
 }
 
+// waitJitter waits for a random delay up to the configured jitter.
+// It returns false if the context is canceled or the component is closed while waiting.
+func (b *Binding) waitJitter(ctx context.Context) bool {
+	if b.jitter <= 0 {
+		return true
+	}
+
+	t := b.clk.NewTimer(rand.N(b.jitter))
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	case <-b.closeCh:
+		return false
+	}
+}
+
 func (b *Binding) Close() error {
 	if b.closed.CompareAndSwap(false, true) {
 		close(b.closeCh)
@@ -156,3 +156,122 @@ func TestCronReadWithContextCancellation(t *testing.T) {
 	require.NoErrorf(t, err, "error on read")
 	require.NoError(t, c.Close())
 }
+
+func TestCronInitSchedules(t *testing.T) {
+	t.Run("named schedules", func(t *testing.T) {
+		c := getNewCron()
+		m := getTestMetadata("@every 1s")
+		m.Properties["schedules"] = `{"nightly": "0 0 * * *", "hourly": "@every 1h"}`
+		require.NoError(t, c.Init(context.Background(), m))
+		assert.Equal(t, []namedSchedule{
+			{schedule: "@every 1s"},
+			{name: "hourly", schedule: "@every 1h"},
+			{name: "nightly", schedule: "0 0 * * *"},
+		}, c.schedules)
+	})
+
+	t.Run("schedules without schedule", func(t *testing.T) {
+		c := getNewCron()
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"schedules": `{"hourly": "@every 1h"}`,
+		}
+		require.NoError(t, c.Init(context.Background(), m))
+		assert.Len(t, c.schedules, 1)
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		for _, schedules := range []string{`not json`, `{"": "@every 1h"}`, `{"bad": "INVALID_SCHEDULE"}`, `{}`} {
+			c := getNewCron()
+			m := bindings.Metadata{}
+			m.Properties = map[string]string{
+				"schedules": schedules,
+			}
+			require.Errorf(t, c.Init(context.Background(), m), "expected error for schedules %s", schedules)
+		}
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		c := getNewCron()
+		m := getTestMetadata("@every 1s")
+		m.Properties["timezone"] = "Europe/Berlin"
+		require.NoError(t, c.Init(context.Background(), m))
+		assert.Equal(t, "Europe/Berlin", c.location.String())
+
+		m.Properties["timezone"] = "Mars/Olympus_Mons"
+		require.Error(t, c.Init(context.Background(), m))
+	})
+
+	t.Run("invalid jitter", func(t *testing.T) {
+		c := getNewCron()
+		m := getTestMetadata("@every 1s")
+		m.Properties["jitter"] = "-1s"
+		require.Error(t, c.Init(context.Background(), m))
+	})
+}
+
+func TestCronReadSchedules(t *testing.T) {
+	clk := clocktesting.NewFakeClock(time.Now())
+	c := getNewCronWithClock(clk)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"schedules": `{"fast": "@every 1s", "slow": "@every 2s"}`,
+		"timezone":  "UTC",
+	}
+	require.NoError(t, c.Init(context.Background(), m))
+
+	var fast, slow atomic.Int32
+	err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		assert.Equal(t, "UTC", res.Metadata["timeZone"])
+		switch res.Metadata["schedule"] {
+		case "fast":
+			fast.Add(1)
+		case "slow":
+			slow.Add(1)
+		default:
+			assert.Failf(t, "unexpected schedule", "schedule: %s", res.Metadata["schedule"])
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	for range 4 {
+		clk.Step(time.Second)
+		runtime.Gosched()
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Eventually(t, func() bool {
+		return fast.Load() == 4 && slow.Load() == 2
+	}, time.Second, time.Millisecond*10,
+		"Cron did not trigger expected number of times, got fast=%d slow=%d", fast.Load(), slow.Load())
+	require.NoError(t, c.Close())
+}
+
+func TestCronReadWithJitter(t *testing.T) {
+	clk := clocktesting.NewFakeClock(time.Now())
+	c := getNewCronWithClock(clk)
+	m := getTestMetadata("@every 10s")
+	m.Properties["jitter"] = "5s"
+	require.NoError(t, c.Init(context.Background(), m))
+
+	var observedCount atomic.Int32
+	err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		observedCount.Add(1)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// Schedule fires, then the trigger is delayed by up to 5s
+	clk.Step(10 * time.Second)
+	time.Sleep(100 * time.Millisecond)
+	for range 5 {
+		clk.Step(time.Second)
+		runtime.Gosched()
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Eventually(t, func() bool {
+		return observedCount.Load() == 1
+	}, time.Second, time.Millisecond*10,
+		"Cron did not trigger expected number of times, expected 1, got %d", observedCount.Load())
+	require.NoError(t, c.Close())
+}
@@ -16,10 +16,12 @@ package bucket
 import (
 	"bytes"
 	"context"
+	"crypto/md5" //nolint:gosec
 	b64 "encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"net/http"
 	"net/url"
@@ -46,6 +48,14 @@ const (
 	metadataEncodeBase64 = "encodeBase64"
 	metadataSignTTL      = "signTTL"
 
+	metadataStorageClass     = "storageClass"
+	metadataKMSKeyID         = "kmsKeyID"
+	metadataCustomerKey      = "customerKey"
+	metadataContentIntegrity = "contentIntegrity"
+
+	contentIntegrityMD5    = "md5"
+	contentIntegrityCRC32C = "crc32c"
+
 	metadataKey = "key"
 	maxResults  = 1000
 
@@ -77,6 +87,14 @@ type gcpMetadata struct {
 	DecodeBase64 bool   `json:"decodeBase64,string" mapstructure:"decodeBase64"`
 	EncodeBase64 bool   `json:"encodeBase64,string" mapstructure:"encodeBase64"`
 	SignTTL      string `json:"signTTL" mapstructure:"signTTL"  mdignore:"true"`
+
+	// Options of the uploads, which are not part of the credentials
+	StorageClass string `json:"-" mapstructure:"storageClass"`
+	KMSKeyID     string `json:"-" mapstructure:"kmsKeyID"`
+	// Base64-encoded 256-bit customer-supplied encryption key
+	CustomerKey string `json:"-" mapstructure:"customerKey"`
+	// Checksum sent with the uploads, verified by Cloud Storage
+	ContentIntegrity string `json:"-" mapstructure:"contentIntegrity"`
 }
 
 type listPayload struct {
@@ -127,6 +145,10 @@ func (g *GCPStorage) parseMetadata(meta bindings.Metadata) (*gcpMetadata, error)
 	if err != nil {
 		return nil, err
 	}
+	err = m.validateUploadOptions()
+	if err != nil {
+		return nil, err
+	}
 
 	return &m, nil
 }
@@ -185,11 +207,26 @@ func (g *GCPStorage) create(ctx context.Context, req *bindings.InvokeRequest) (*
 		r = b64.NewDecoder(b64.StdEncoding, r)
 	}
 
-	h := g.client.Bucket(g.metadata.Bucket).Object(name).NewWriter(ctx)
+	// The checksum is sent before the data, so it's computed on the decoded data first
+	var data []byte
+	if metadata.ContentIntegrity != "" {
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error. error decoding data: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	h := metadata.object(g.client.Bucket(g.metadata.Bucket), name).NewWriter(ctx)
 	defer h.Close()
+	metadata.setWriterOptions(h, data)
 	if _, err = io.Copy(h, r); err != nil {
 		return nil, fmt.Errorf("gcp bucket binding error. Uploading: %w", err)
 	}
+	// The upload is only completed, and the checksum verified, when the writer is closed
+	if err = h.Close(); err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. Uploading: %w", err)
+	}
 
 	objectURL, err := url.Parse(fmt.Sprintf(objectURLBase, g.metadata.Bucket, name))
 	if err != nil {
@@ -224,7 +261,7 @@ func (g *GCPStorage) get(ctx context.Context, req *bindings.InvokeRequest) (*bin
 	}
 
 	var rc io.ReadCloser
-	rc, err = g.client.Bucket(g.metadata.Bucket).Object(key).NewReader(ctx)
+	rc, err = metadata.object(g.client.Bucket(g.metadata.Bucket), key).NewReader(ctx)
 	if err != nil {
 		var apiErr *googleapi.Error
 		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
@@ -325,7 +362,63 @@ func (metadata gcpMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest
 	if val, ok := req.Metadata[metadataSignTTL]; ok && val != "" {
 		merged.SignTTL = val
 	}
-	return merged, nil
+	if val, ok := req.Metadata[metadataStorageClass]; ok && val != "" {
+		merged.StorageClass = val
+	}
+	if val, ok := req.Metadata[metadataKMSKeyID]; ok && val != "" {
+		merged.KMSKeyID = val
+	}
+	if val, ok := req.Metadata[metadataCustomerKey]; ok && val != "" {
+		merged.CustomerKey = val
+	}
+	if val, ok := req.Metadata[metadataContentIntegrity]; ok && val != "" {
+		merged.ContentIntegrity = val
+	}
+	return merged, merged.validateUploadOptions()
+}
+
+func (metadata gcpMetadata) validateUploadOptions() error {
+	if metadata.CustomerKey != "" {
+		if metadata.KMSKeyID != "" {
+			return fmt.Errorf("invalid %s: customer-supplied keys can't be used with %s", metadataCustomerKey, metadataKMSKeyID)
+		}
+		key, err := b64.StdEncoding.DecodeString(metadata.CustomerKey)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid %s: must be a base64-encoded 256-bit key", metadataCustomerKey)
+		}
+	}
+	switch metadata.ContentIntegrity {
+	case "", contentIntegrityMD5, contentIntegrityCRC32C:
+	default:
+		return fmt.Errorf("invalid %s: %s; allowed: %s, %s", metadataContentIntegrity, metadata.ContentIntegrity, contentIntegrityMD5, contentIntegrityCRC32C)
+	}
+	return nil
+}
+
+// object returns the handle of an object, using the customer-supplied encryption key if any.
+func (metadata gcpMetadata) object(bucket *storage.BucketHandle, name string) *storage.ObjectHandle {
+	obj := bucket.Object(name)
+	if metadata.CustomerKey != "" {
+		// The key was validated when parsing the metadata
+		key, _ := b64.StdEncoding.DecodeString(metadata.CustomerKey)
+		obj = obj.Key(key)
+	}
+	return obj
+}
+
+// setWriterOptions sets the storage class, the KMS key and the checksum of the data of an upload.
+func (metadata gcpMetadata) setWriterOptions(w *storage.Writer, data []byte) {
+	w.StorageClass = metadata.StorageClass
+	w.KMSKeyName = metadata.KMSKeyID
+	switch metadata.ContentIntegrity {
+	case contentIntegrityMD5:
+		// MD5 is only used as a checksum here
+		sum := md5.Sum(data) //nolint:gosec
+		w.MD5 = sum[:]
+	case contentIntegrityCRC32C:
+		w.CRC32C = crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+		w.SendCRC32C = true
+	}
 }
 
 // Add backward compatibility. 'key' replace 'name'.
@@ -14,10 +14,13 @@ limitations under the License.
 package bucket
 
 import (
+	"bytes"
 	"context"
+	b64 "encoding/base64"
 	"encoding/json"
 	"testing"
 
+	"cloud.google.com/go/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -235,6 +238,70 @@ func TestMergeWithRequestMetadata(t *testing.T) {
 	})
 }
 
+func TestUploadOptions(t *testing.T) {
+	customerKey := b64.StdEncoding.EncodeToString(bytes.Repeat([]byte{'k'}, 32))
+
+	t.Run("parses upload options", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"bucket":           "my_bucket",
+			"storageClass":     "NEARLINE",
+			"kmsKeyID":         "projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			"contentIntegrity": "crc32c",
+		}
+		gs := GCPStorage{logger: logger.NewLogger("test")}
+		meta, err := gs.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, "NEARLINE", meta.StorageClass)
+		assert.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k", meta.KMSKeyID)
+
+		// Upload options are not part of the credentials
+		b, err := json.Marshal(meta)
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), "NEARLINE")
+
+		merged, err := meta.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"storageClass":     "ARCHIVE",
+			"contentIntegrity": "md5",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "ARCHIVE", merged.StorageClass)
+		assert.Equal(t, "md5", merged.ContentIntegrity)
+	})
+
+	t.Run("sets writer options", func(t *testing.T) {
+		data := []byte("hello world")
+
+		w := &storage.Writer{}
+		gcpMetadata{StorageClass: "COLDLINE", KMSKeyID: "key", ContentIntegrity: "crc32c"}.setWriterOptions(w, data)
+		assert.Equal(t, "COLDLINE", w.StorageClass)
+		assert.Equal(t, "key", w.KMSKeyName)
+		assert.True(t, w.SendCRC32C)
+		assert.Equal(t, uint32(0xc99465aa), w.CRC32C)
+
+		w = &storage.Writer{}
+		gcpMetadata{ContentIntegrity: "md5"}.setWriterOptions(w, data)
+		assert.Equal(t, "XrY7u+Ae7tCTyyK7j1rNww==", b64.StdEncoding.EncodeToString(w.MD5))
+		assert.False(t, w.SendCRC32C)
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{"customerKey": "a2V5"},
+			{"customerKey": customerKey, "kmsKeyID": "key"},
+			{"contentIntegrity": "sha256"},
+		} {
+			_, err := (&gcpMetadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: md})
+			require.Errorf(t, err, "expected error for %v", md)
+		}
+
+		_, err := (&gcpMetadata{}).mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"customerKey": customerKey,
+		}})
+		require.NoError(t, err)
+	})
+}
+
 func TestGetOption(t *testing.T) {
 	gs := GCPStorage{logger: logger.NewLogger("test")}
 	gs.metadata = &gcpMetadata{}
@@ -22,7 +22,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"reflect"
@@ -31,6 +30,7 @@ import (
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/common/httputils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	kitmd "github.com/dapr/kit/metadata"
@@ -47,6 +47,7 @@ const (
 	TraceMetadataKey                = "traceHeaders"
 	securityToken                   = "securityToken"
 	securityTokenHeader             = "securityTokenHeader"
+	responseTimeoutKey              = "responseTimeout"
 	defaultMaxResponseBodySizeBytes = 100 << 20 // 100 MB
 )
 
@@ -61,6 +62,8 @@ type HTTPSource struct {
 }
 
 type httpMetadata struct {
+	httputils.ProxyMetadata `mapstructure:",squash"`
+
 	URL                 string         `mapstructure:"url"`
 	MTLSClientCert      string         `mapstructure:"mtlsClientCert"`
 	MTLSClientKey       string         `mapstructure:"mtlsClientKey"`
@@ -102,6 +105,9 @@ func (h *HTTPSource) Init(_ context.Context, meta bindings.Metadata) error {
 	if tlsConfig == nil {
 		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 	}
+	if (h.metadata.MTLSClientCert == "") != (h.metadata.MTLSClientKey == "") {
+		return errors.New("both MTLSClientCert and MTLSClientKey must be set to use a client certificate")
+	}
 	if h.metadata.MTLSClientCert != "" && h.metadata.MTLSClientKey != "" {
 		err = h.readMTLSClientCertificates(tlsConfig)
 		if err != nil {
@@ -122,17 +128,15 @@ func (h *HTTPSource) Init(_ context.Context, meta bindings.Metadata) error {
 
 	// See guidance on proper HTTP client settings here:
 	// https://medium.com/@nate510/don-t-use-go-s-default-http-client-4804cb19f779
-	dialer := &net.Dialer{
-		Timeout: 15 * time.Second,
-	}
-	netTransport := http.DefaultTransport.(*http.Transport).Clone()
-	netTransport.DialContext = dialer.DialContext
-	netTransport.TLSHandshakeTimeout = 15 * time.Second
-	netTransport.TLSClientConfig = tlsConfig
-
-	h.client = &http.Client{
-		Timeout:   0, // no time out here, we use request timeouts instead
-		Transport: netTransport,
+	h.client, err = httputils.ClientConfig{
+		Proxy:               h.metadata.ProxyMetadata,
+		TLSConfig:           tlsConfig,
+		Timeout:             0, // no time out here, we use request timeouts instead
+		DialTimeout:         15 * time.Second,
+		TLSHandshakeTimeout: 15 * time.Second,
+	}.Client()
+	if err != nil {
+		return err
 	}
 
 	if val := meta.Properties["errorIfNot2XX"]; val != "" {
@@ -238,9 +242,85 @@ func (h *HTTPSource) Operations() []bindings.OperationKind {
 
 // Invoke performs an HTTP request to the configured HTTP endpoint.
 func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	resp, cancel, errorIfNot2XX, err := h.sendRequest(parentCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer func() {
+		// Drain before closing
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	var respBody io.Reader = resp.Body
+	if h.metadata.maxResponseBodySizeBytes > 0 {
+		respBody = io.LimitReader(resp.Body, h.metadata.maxResponseBodySizeBytes)
+	}
+
+	// Read the response body. For empty responses (e.g. 204 No Content)
+	// `b` will be an empty slice.
+	b, err := io.ReadAll(respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     b,
+		Metadata: responseMetadata(resp),
+	}, statusError(resp, errorIfNot2XX)
+}
+
+// InvokeStream performs an HTTP request to the configured HTTP endpoint, returning the response body as a stream.
+// Because the body is not buffered, maxResponseBodySize does not apply; the response timeout includes reading the body.
+func (h *HTTPSource) InvokeStream(parentCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeStreamResponse, error) {
+	resp, cancel, errorIfNot2XX, err := h.sendRequest(parentCtx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = statusError(resp, errorIfNot2XX)
+	if err != nil {
+		// Error responses are buffered so the connection can be released right away
+		defer cancel()
+		defer resp.Body.Close()
+		var respBody io.Reader = resp.Body
+		if h.metadata.maxResponseBodySizeBytes > 0 {
+			respBody = io.LimitReader(resp.Body, h.metadata.maxResponseBodySizeBytes)
+		}
+		b, _ := io.ReadAll(respBody)
+		return &bindings.InvokeStreamResponse{
+			Data:     io.NopCloser(bytes.NewReader(b)),
+			Metadata: responseMetadata(resp),
+		}, err
+	}
+
+	return &bindings.InvokeStreamResponse{
+		Data: &responseStream{
+			ReadCloser: resp.Body,
+			cancel:     cancel,
+		},
+		Metadata: responseMetadata(resp),
+	}, nil
+}
+
+// responseStream is the body of a streamed response, which releases the request's context when closed.
+type responseStream struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *responseStream) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// sendRequest sends the HTTP request for the invocation and returns the response.
+// The returned cancel function must be called after the response body has been read.
+func (h *HTTPSource) sendRequest(parentCtx context.Context, req *bindings.InvokeRequest) (resp *http.Response, cancel context.CancelFunc, errorIfNot2XX bool, err error) {
 	u := h.metadata.URL
 
-	errorIfNot2XX := h.errorIfNot2XX // Default to the component config (default is true)
+	errorIfNot2XX = h.errorIfNot2XX // Default to the component config (default is true)
 
 	if req.Metadata == nil {
 		// Prevent things below from failing if req.Metadata is nil.
@@ -265,19 +345,29 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		body = bytes.NewBuffer(req.Data)
 	case "GET", "HEAD", "DELETE", "OPTIONS", "TRACE":
 	default:
-		return nil, fmt.Errorf("invalid operation: %s", req.Operation)
+		return nil, nil, false, fmt.Errorf("invalid operation: %s", req.Operation)
+	}
+
+	// The timeout can be overridden for each request; a value of 0 disables it
+	timeout := h.metadata.ResponseTimeout
+	if val := req.Metadata[responseTimeoutKey]; val != "" {
+		d, parseErr := time.ParseDuration(val)
+		if parseErr != nil || d < 0 {
+			return nil, nil, false, fmt.Errorf("invalid value for %s in request metadata: %s", responseTimeoutKey, val)
+		}
+		timeout = &d
 	}
 
 	ctx := parentCtx
-	if h.metadata.ResponseTimeout != nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(parentCtx, *h.metadata.ResponseTimeout)
-		defer cancel()
+	cancel = func() {}
+	if timeout != nil && *timeout > 0 {
+		ctx, cancel = context.WithTimeout(parentCtx, *timeout)
 	}
 
 	request, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, nil, false, err
 	}
 
 	// Set default values for Content-Type and Accept headers.
@@ -320,28 +410,16 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 	}
 
 	// Send the question
-	resp, err := h.client.Do(request)
+	resp, err = h.client.Do(request)
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, nil, false, err
 	}
-	defer func() {
-		// Drain before closing
-		_, _ = io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
-	}()
 
-	var respBody io.Reader = resp.Body
-	if h.metadata.maxResponseBodySizeBytes > 0 {
-		respBody = io.LimitReader(resp.Body, h.metadata.maxResponseBodySizeBytes)
-	}
-
-	// Read the response body. For empty responses (e.g. 204 No Content)
-	// `b` will be an empty slice.
-	b, err := io.ReadAll(respBody)
-	if err != nil {
-		return nil, err
-	}
+	return resp, cancel, errorIfNot2XX, nil
+}
 
+func responseMetadata(resp *http.Response) map[string]string {
 	metadata := make(map[string]string, len(resp.Header)+2)
 	// Include status code & desc
 	metadata["statusCode"] = strconv.Itoa(resp.StatusCode)
@@ -353,15 +431,15 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		metadata[key] = strings.Join(values, ", ")
 	}
 
-	// Create an error for non-200 status codes unless suppressed.
+	return metadata
+}
+
+// statusError returns an error for non-200 status codes unless suppressed.
+func statusError(resp *http.Response, errorIfNot2XX bool) error {
 	if errorIfNot2XX && resp.StatusCode/100 != 2 {
-		err = fmt.Errorf("received status code %d", resp.StatusCode)
+		return fmt.Errorf("received status code %d", resp.StatusCode)
 	}
-
-	return &bindings.InvokeResponse{
-		Data:     b,
-		Metadata: metadata,
-	}, err
+	return nil
 }
 
 // GetComponentMetadata returns the metadata of the component.
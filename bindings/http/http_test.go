@@ -1005,3 +1005,127 @@ func TestMaxBodySizeHonored(t *testing.T) {
 	// Should have only read 1KB
 	assert.Len(t, response.Data, 1<<10)
 }
+
+func TestRequestTimeoutOverride(t *testing.T) {
+	handler := NewHTTPHandler()
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	t.Run("request timeout longer than component timeout", func(t *testing.T) {
+		hs, err := InitBinding(s, map[string]string{"responseTimeout": "1s"})
+		require.NoError(t, err)
+
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			metadata:   map[string]string{"X-Delay-Seconds": "2", "responseTimeout": "5s"},
+			path:       "/",
+			statusCode: 200,
+		}.ToInvokeRequest()
+		response, err := hs.Invoke(context.Background(), &req)
+		require.NoError(t, err)
+		assert.Equal(t, "GET", string(response.Data))
+	})
+
+	t.Run("request timeout without component timeout", func(t *testing.T) {
+		hs, err := InitBinding(s, nil)
+		require.NoError(t, err)
+
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			metadata:   map[string]string{"X-Delay-Seconds": "2", "responseTimeout": "500ms"},
+			path:       "/",
+			statusCode: 200,
+		}.ToInvokeRequest()
+		_, err = hs.Invoke(context.Background(), &req)
+		require.ErrorContains(t, err, "context deadline exceeded")
+	})
+
+	t.Run("invalid request timeout", func(t *testing.T) {
+		hs, err := InitBinding(s, nil)
+		require.NoError(t, err)
+
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			metadata:   map[string]string{"responseTimeout": "soon"},
+			path:       "/",
+			statusCode: 200,
+		}.ToInvokeRequest()
+		_, err = hs.Invoke(context.Background(), &req)
+		require.ErrorContains(t, err, "invalid value for responseTimeout")
+	})
+}
+
+func TestInvokeStream(t *testing.T) {
+	handler := NewHTTPHandler()
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	hs, err := InitBinding(s, map[string]string{"maxResponseBodySize": "1Ki", "responseTimeout": "5s"})
+	require.NoError(t, err)
+	streaming, ok := hs.(bindings.StreamingOutputBinding)
+	require.True(t, ok)
+
+	t.Run("streams the whole body", func(t *testing.T) {
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			path:       "/large",
+			statusCode: 200,
+		}.ToInvokeRequest()
+		response, err := streaming.InvokeStream(context.Background(), &req)
+		require.NoError(t, err)
+		defer response.Data.Close()
+
+		b, err := io.ReadAll(response.Data)
+		require.NoError(t, err)
+		// The max body size does not apply to streams
+		assert.Len(t, b, 5<<10)
+		assert.Equal(t, "200", response.Metadata["statusCode"])
+		require.NoError(t, response.Data.Close())
+	})
+
+	t.Run("error status code", func(t *testing.T) {
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			path:       "/",
+			statusCode: 500,
+		}.ToInvokeRequest()
+		response, err := streaming.InvokeStream(context.Background(), &req)
+		require.ErrorContains(t, err, "received status code 500")
+		require.NotNil(t, response)
+		defer response.Data.Close()
+
+		b, err := io.ReadAll(response.Data)
+		require.NoError(t, err)
+		assert.Equal(t, "GET", string(b))
+		assert.Equal(t, "500", response.Metadata["statusCode"])
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		req := TestCase{
+			operation: "invalid",
+		}.ToInvokeRequest()
+		_, err := streaming.InvokeStream(context.Background(), &req)
+		require.Error(t, err)
+	})
+}
+
+func TestMTLSClientCertRequiresKey(t *testing.T) {
+	handler := NewHTTPHandler()
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	_, err := InitBinding(s, map[string]string{
+		"MTLSClientCert": filepath.Join(".", "testdata", "client.pem"),
+	})
+	require.Error(t, err)
+
+	_, err = InitBinding(s, map[string]string{
+		"MTLSClientKey": filepath.Join(".", "testdata", "client.key"),
+	})
+	require.Error(t, err)
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/dapr/components-contrib/health"
 	"github.com/dapr/components-contrib/metadata"
@@ -35,6 +36,16 @@ type InputBinding interface {
 	io.Closer
 }
 
+// GracefulCloser is the interface for input bindings that can drain the events being processed when closing.
+type GracefulCloser interface {
+	// CloseWithContext stops receiving new events and waits for the ones being processed to be handled and acknowledged, then closes the binding.
+	// When ctx is done, the binding is closed without waiting further.
+	CloseWithContext(ctx context.Context) error
+}
+
+// DefaultCloseTimeout is the maximum time bindings that implement GracefulCloser wait for the events being processed when Close is invoked.
+const DefaultCloseTimeout = 5 * time.Second
+
 // Handler is the handler used to invoke the app handler.
 type Handler func(context.Context, *ReadResponse) ([]byte, error)
 
@@ -16,6 +16,7 @@ package kafka
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"sync"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/common/component/kafka"
+	"github.com/dapr/components-contrib/common/metrics"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
@@ -55,7 +57,13 @@ func NewKafka(logger logger.Logger) bindings.InputOutputBinding {
 }
 
 func (b *Binding) Init(ctx context.Context, metadata bindings.Metadata) error {
-	err := b.kafka.Init(ctx, metadata.Properties)
+	var err error
+	b.kafka.Metrics, err = metrics.NewRecorder("bindings.kafka", metadata.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create metrics recorder: %w", err)
+	}
+
+	err = b.kafka.Init(ctx, metadata.Properties)
 	if err != nil {
 		return err
 	}
@@ -77,12 +85,26 @@ func (b *Binding) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
 
+// Ping checks the connection to the Kafka cluster.
+func (b *Binding) Ping(ctx context.Context) error {
+	return b.kafka.Ping(ctx)
+}
+
 func (b *Binding) Close() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bindings.DefaultCloseTimeout)
+	defer cancel()
+	return b.CloseWithContext(ctx)
+}
+
+// CloseWithContext waits until ctx is done for the events being processed, then closes the binding.
+func (b *Binding) CloseWithContext(ctx context.Context) (err error) {
+	defer b.wg.Wait()
+	// The events are drained before the subscriptions are canceled, which would interrupt the handlers
+	err = b.kafka.CloseWithContext(ctx)
 	if b.closed.CompareAndSwap(false, true) {
 		close(b.closeCh)
 	}
-	defer b.wg.Wait()
-	return b.kafka.Close()
+	return err
 }
 
 func (b *Binding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
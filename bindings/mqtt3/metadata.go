@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/common/tlsconfig"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/metadata"
 )
@@ -59,6 +60,8 @@ type tlsCfg struct {
 	CaCert     string `mapstructure:"caCert"`
 	ClientCert string `mapstructure:"clientCert"`
 	ClientKey  string `mapstructure:"clientKey"`
+
+	tlsconfig.Metadata `mapstructure:",squash"`
 }
 
 func parseMQTTMetaData(md bindings.Metadata, log logger.Logger) (mqtt3Metadata, error) {
@@ -16,7 +16,6 @@ package mqtt
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/url"
@@ -29,6 +28,7 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/common/tlsconfig"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -230,24 +230,16 @@ func (m *MQTT) connect(clientID string, isSubscriber bool) (mqtt.Client, error)
 }
 
 func (m *MQTT) newTLSConfig() *tls.Config {
-	tlsConfig := new(tls.Config)
-
-	if m.metadata.ClientCert != "" && m.metadata.ClientKey != "" {
-		cert, err := tls.X509KeyPair([]byte(m.metadata.ClientCert), []byte(m.metadata.ClientKey))
-		if err != nil {
-			m.logger.Warnf("Unable to load client certificate and key pair. Err: %v", err)
-			return tlsConfig
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
-
-	if m.metadata.CaCert != "" {
-		tlsConfig.RootCAs = x509.NewCertPool()
-		if ok := tlsConfig.RootCAs.AppendCertsFromPEM([]byte(m.metadata.CaCert)); !ok {
-			m.logger.Warnf("Unable to load CA certificate.")
-		}
+	tlsConfig, err := tlsconfig.Config{
+		Metadata:   m.metadata.tlsCfg.Metadata,
+		CACert:     m.metadata.CaCert,
+		ClientCert: m.metadata.ClientCert,
+		ClientKey:  m.metadata.ClientKey,
+	}.Build()
+	if err != nil {
+		m.logger.Warnf("Unable to load TLS configuration. Err: %v", err)
+		return new(tls.Config)
 	}
-
 	return tlsConfig
 }
 
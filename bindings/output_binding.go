@@ -32,6 +32,11 @@ type OutputBinding interface {
 	io.Closer
 }
 
+// StreamingOutputBinding is implemented by output bindings that can return the response data as a stream, instead of buffering it in memory.
+type StreamingOutputBinding interface {
+	InvokeStream(ctx context.Context, req *InvokeRequest) (*InvokeStreamResponse, error)
+}
+
 func PingOutBinding(ctx context.Context, outputBinding OutputBinding) error {
 	// checks if this output binding has the ping option then executes
 	if outputBindingWithPing, ok := outputBinding.(health.Pinger); ok {
@@ -0,0 +1,358 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// Operations in the change events.
+const (
+	operationInsert   = "insert"
+	operationUpdate   = "update"
+	operationDelete   = "delete"
+	operationTruncate = "truncate"
+)
+
+// PostgresCDC is an input binding that streams the changes made to a PostgreSQL database, using logical replication.
+type PostgresCDC struct {
+	logger   logger.Logger
+	metadata cdcMetadata
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// changeEvent is the payload of the events sent to the application, one for each changed row.
+type changeEvent struct {
+	Operation  string         `json:"op"`
+	Schema     string         `json:"schema"`
+	Table      string         `json:"table"`
+	Before     map[string]any `json:"before,omitempty"`
+	After      map[string]any `json:"after,omitempty"`
+	LSN        string         `json:"lsn"`
+	XID        uint32         `json:"xid,omitempty"`
+	CommitTime *time.Time     `json:"commitTime,omitempty"`
+}
+
+// decoder decodes the messages of an output plugin.
+type decoder interface {
+	// pluginArgs returns the options for the output plugin, for the START_REPLICATION command.
+	pluginArgs() string
+	// decode returns the change events contained in the message, and whether the message ends a transaction.
+	decode(data []byte) ([]changeEvent, bool, error)
+	// inTransaction returns true if the last message decoded is part of a transaction that didn't end yet.
+	inTransaction() bool
+}
+
+// transaction contains the details of the transaction being decoded.
+type transaction struct {
+	xid        uint32
+	commitTime time.Time
+}
+
+// annotate adds the details of the transaction to the event.
+func (tx *transaction) annotate(ev *changeEvent) {
+	if tx == nil {
+		return
+	}
+	ev.XID = tx.xid
+	if !tx.commitTime.IsZero() {
+		ev.CommitTime = &tx.commitTime
+	}
+}
+
+// NewPostgresCDC returns a new PostgreSQL CDC input binding.
+func NewPostgresCDC(logger logger.Logger) bindings.InputBinding {
+	return &PostgresCDC{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init initializes the binding, creating the publication and the replication slot if needed.
+func (p *PostgresCDC) Init(ctx context.Context, meta bindings.Metadata) error {
+	if p.closed.Load() {
+		return errors.New("cannot initialize a previously-closed component")
+	}
+
+	err := p.metadata.InitWithMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+
+	connCtx, connCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	defer connCancel()
+	conn, err := connectReplication(connCtx, p.metadata.ConnectionString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if p.metadata.Plugin == pluginPgoutput {
+		err = p.ensurePublication(connCtx, conn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.ensureSlot(connCtx, conn)
+}
+
+// ensurePublication creates the publication for the tables, if it doesn't exist.
+func (p *PostgresCDC) ensurePublication(ctx context.Context, conn *pgconn.PgConn) error {
+	// Names are validated when parsing the metadata, so they are safe to use in queries
+	rows, err := execQuery(ctx, conn, "SELECT 1 FROM pg_publication WHERE pubname = '"+p.metadata.Publication+"'")
+	if err != nil {
+		return fmt.Errorf("failed to look up publication: %w", err)
+	}
+	if len(rows) > 0 {
+		return nil
+	}
+	if len(p.metadata.tables) == 0 {
+		return fmt.Errorf("publication '%s' does not exist: create it, or set 'tables' to create it automatically", p.metadata.Publication)
+	}
+
+	tables := make([]string, len(p.metadata.tables))
+	for i, t := range p.metadata.tables {
+		tables[i] = pgx.Identifier(strings.Split(t, ".")).Sanitize()
+	}
+	_, err = execQuery(ctx, conn, "CREATE PUBLICATION "+p.metadata.Publication+" FOR TABLE "+strings.Join(tables, ", "))
+	if err != nil {
+		return fmt.Errorf("failed to create publication '%s': %w", p.metadata.Publication, err)
+	}
+	p.logger.Infof("Created publication '%s'", p.metadata.Publication)
+	return nil
+}
+
+// ensureSlot creates the replication slot, if it doesn't exist.
+func (p *PostgresCDC) ensureSlot(ctx context.Context, conn *pgconn.PgConn) error {
+	rows, err := execQuery(ctx, conn, "SELECT plugin FROM pg_replication_slots WHERE slot_name = '"+p.metadata.SlotName+"'")
+	if err != nil {
+		return fmt.Errorf("failed to look up replication slot: %w", err)
+	}
+	if len(rows) > 0 {
+		if plugin := string(rows[0][0]); plugin != p.metadata.Plugin {
+			return fmt.Errorf("replication slot '%s' uses plugin '%s' instead of '%s'", p.metadata.SlotName, plugin, p.metadata.Plugin)
+		}
+		return nil
+	}
+	if !p.metadata.CreateSlot {
+		return fmt.Errorf("replication slot '%s' does not exist", p.metadata.SlotName)
+	}
+
+	_, err = execQuery(ctx, conn, "SELECT pg_create_logical_replication_slot('"+p.metadata.SlotName+"', '"+p.metadata.Plugin+"')")
+	if err != nil {
+		return fmt.Errorf("failed to create replication slot '%s': %w", p.metadata.SlotName, err)
+	}
+	p.logger.Infof("Created replication slot '%s'", p.metadata.SlotName)
+	return nil
+}
+
+func (p *PostgresCDC) newDecoder() decoder {
+	if p.metadata.Plugin == pluginWal2JSON {
+		return newWal2JSONDecoder(p.metadata.tables)
+	}
+	return newPgoutputDecoder(p.metadata.Publication)
+}
+
+// Read starts streaming the changes to the handler.
+// Changes are delivered at least once: the position of the slot is advanced after all the changes of a transaction are processed successfully.
+// If the handler returns an error, the stream is restarted from the last transaction that was processed.
+func (p *PostgresCDC) Read(ctx context.Context, handler bindings.Handler) error {
+	if p.closed.Load() {
+		return errors.New("binding is closed")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		defer cancel()
+		select {
+		case <-ctx.Done():
+		case <-p.closeCh:
+		}
+	}()
+	go func() {
+		defer p.wg.Done()
+		for {
+			err := p.stream(ctx, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Errorf("Error streaming changes from replication slot '%s', retrying in %v: %v", p.metadata.SlotName, p.metadata.RetryInterval, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.metadata.RetryInterval):
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stream consumes the replication slot until the context is canceled or an error occurs.
+func (p *PostgresCDC) stream(ctx context.Context, handler bindings.Handler) error {
+	connCtx, connCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	defer connCancel()
+	conn, err := connectReplication(connCtx, p.metadata.ConnectionString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	dec := p.newDecoder()
+	err = startReplication(connCtx, conn, p.metadata.SlotName, dec.pluginArgs())
+	if err != nil {
+		return err
+	}
+	p.logger.Infof("Started streaming changes from replication slot '%s'", p.metadata.SlotName)
+
+	// Position up to which the changes have been processed
+	// It's unknown until the first transaction is processed; the server ignores an invalid position of 0
+	var confirmed lsn
+	defer func() {
+		// Report the last position before closing the connection, so it's not lost
+		if confirmed > 0 && !conn.IsClosed() {
+			_ = sendStandbyStatus(conn, confirmed, time.Now())
+		}
+	}()
+
+	nextStatus := time.Now().Add(p.metadata.StatusInterval)
+	for {
+		if !time.Now().Before(nextStatus) {
+			err = sendStandbyStatus(conn, confirmed, time.Now())
+			if err != nil {
+				return err
+			}
+			nextStatus = time.Now().Add(p.metadata.StatusInterval)
+		}
+
+		recvCtx, recvCancel := context.WithDeadline(ctx, nextStatus)
+		msg, err := conn.ReceiveMessage(recvCtx)
+		recvCancel()
+		if err != nil {
+			if pgconn.Timeout(err) && ctx.Err() == nil {
+				// Time to send the status update
+				continue
+			}
+			return err
+		}
+
+		var data *pgproto3.CopyData
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			data = msg
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		default:
+			return fmt.Errorf("%w: %T", errUnexpectedMessage, msg)
+		}
+		if len(data.Data) == 0 {
+			continue
+		}
+
+		switch data.Data[0] {
+		case primaryKeepaliveMessageByteID:
+			ka, err := parsePrimaryKeepalive(data.Data[1:])
+			if err != nil {
+				return err
+			}
+			// When no transaction is in progress, all the changes up to the position of the server have been received
+			// Advancing the position allows the server to discard WAL for changes that were not published
+			if !dec.inTransaction() && ka.walEnd > confirmed {
+				confirmed = ka.walEnd
+			}
+			if ka.replyRequested {
+				nextStatus = time.Time{}
+			}
+		case xLogDataByteID:
+			xld, err := parseXLogData(data.Data[1:])
+			if err != nil {
+				return err
+			}
+			events, commit, err := dec.decode(xld.data)
+			if err != nil {
+				return fmt.Errorf("failed to decode change at LSN %s: %w", xld.walStart, err)
+			}
+			for i := range events {
+				err = p.deliver(ctx, handler, &events[i], xld.walStart)
+				if err != nil {
+					return err
+				}
+			}
+			// The position of the commit message is the end of the transaction
+			if commit && xld.walStart > confirmed {
+				confirmed = xld.walStart
+			}
+		}
+	}
+}
+
+func (p *PostgresCDC) deliver(ctx context.Context, handler bindings.Handler, ev *changeEvent, pos lsn) error {
+	ev.LSN = pos.String()
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to serialize change at LSN %s: %w", ev.LSN, err)
+	}
+
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Data: data,
+		Metadata: map[string]string{
+			"lsn":    ev.LSN,
+			"op":     ev.Operation,
+			"schema": ev.Schema,
+			"table":  ev.Table,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to process change at LSN %s: %w", ev.LSN, err)
+	}
+	return nil
+}
+
+// Close stops streaming changes.
+// The replication slot is not dropped, so the binding resumes from the last position when started again.
+func (p *PostgresCDC) Close() error {
+	if p.closed.CompareAndSwap(false, true) {
+		close(p.closeCh)
+	}
+	p.wg.Wait()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (p *PostgresCDC) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := cdcMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return
+}
@@ -0,0 +1,372 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeServer is a minimal PostgreSQL server that supports simple queries and the replication protocol.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	// Rows returned by queries, by prefix of the query
+	results map[string][]string
+	// Messages to send after the replication starts
+	stream [][]byte
+
+	lock     sync.Mutex
+	queries  []string
+	statuses []lsn
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{
+		t:        t,
+		listener: l,
+		results:  map[string][]string{},
+	}
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeServer) connString() string {
+	return "postgres://dapr@" + s.listener.Addr().String() + "/dapr?sslmode=disable"
+}
+
+func (s *fakeServer) serve() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	backend := pgproto3.NewBackend(conn, conn)
+
+	startup, err := backend.ReceiveStartupMessage()
+	if err != nil {
+		return
+	}
+	assert.Equal(s.t, "database", startup.(*pgproto3.StartupMessage).Parameters["replication"])
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if backend.Flush() != nil {
+		return
+	}
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			s.lock.Lock()
+			s.queries = append(s.queries, msg.String)
+			s.lock.Unlock()
+			if strings.HasPrefix(msg.String, "START_REPLICATION") {
+				backend.Send(&pgproto3.CopyBothResponse{})
+				for _, data := range s.stream {
+					backend.Send(&pgproto3.CopyData{Data: data})
+				}
+				backend.Flush()
+				continue
+			}
+			backend.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+				{Name: []byte("result"), DataTypeOID: pgtype.TextOID, DataTypeSize: -1, TypeModifier: -1},
+			}})
+			for prefix, rows := range s.results {
+				if strings.HasPrefix(msg.String, prefix) {
+					for _, row := range rows {
+						backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte(row)}})
+					}
+				}
+			}
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT")})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			backend.Flush()
+		case *pgproto3.CopyData:
+			if len(msg.Data) == 34 && msg.Data[0] == standbyStatusUpdateByteID {
+				s.lock.Lock()
+				s.statuses = append(s.statuses, lsn(binary.BigEndian.Uint64(msg.Data[9:])))
+				s.lock.Unlock()
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+func (s *fakeServer) getQueries() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func (s *fakeServer) getStatuses() []lsn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]lsn(nil), s.statuses...)
+}
+
+func xLogDataMsg(pos lsn, data []byte) []byte {
+	buf := []byte{xLogDataByteID}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(pos))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(pos))
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	return append(buf, data...)
+}
+
+func keepaliveMsg(walEnd lsn, replyRequested bool) []byte {
+	buf := []byte{primaryKeepaliveMessageByteID}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(walEnd))
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	if replyRequested {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+func newTestBinding(t *testing.T, props map[string]string) *PostgresCDC {
+	t.Helper()
+
+	p := NewPostgresCDC(logger.NewLogger("test")).(*PostgresCDC)
+	err := p.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	return p
+}
+
+func TestLSN(t *testing.T) {
+	assert.Equal(t, "0/0", lsn(0).String())
+	assert.Equal(t, "16/B374D848", lsn(0x16B374D848).String())
+}
+
+func TestInit(t *testing.T) {
+	t.Run("creates publication and slot", func(t *testing.T) {
+		s := newFakeServer(t)
+		s.serve()
+
+		newTestBinding(t, map[string]string{
+			"connectionString": s.connString(),
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+			"tables":           "public.orders,Customers",
+		})
+		assert.Equal(t, []string{
+			"SELECT 1 FROM pg_publication WHERE pubname = 'dapr_pub'",
+			`CREATE PUBLICATION dapr_pub FOR TABLE "public"."orders", "Customers"`,
+			"SELECT plugin FROM pg_replication_slots WHERE slot_name = 'dapr_slot'",
+			"SELECT pg_create_logical_replication_slot('dapr_slot', 'pgoutput')",
+		}, s.getQueries())
+	})
+
+	t.Run("uses existing publication and slot", func(t *testing.T) {
+		s := newFakeServer(t)
+		s.results["SELECT 1 FROM pg_publication"] = []string{"1"}
+		s.results["SELECT plugin FROM pg_replication_slots"] = []string{"pgoutput"}
+		s.serve()
+
+		newTestBinding(t, map[string]string{
+			"connectionString": s.connString(),
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+		})
+		assert.Len(t, s.getQueries(), 2)
+	})
+
+	t.Run("slot with a different plugin", func(t *testing.T) {
+		s := newFakeServer(t)
+		s.results["SELECT plugin FROM pg_replication_slots"] = []string{"pgoutput"}
+		s.serve()
+
+		p := NewPostgresCDC(logger.NewLogger("test")).(*PostgresCDC)
+		err := p.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": s.connString(),
+			"slotName":         "dapr_slot",
+			"plugin":           "wal2json",
+		}}})
+		require.ErrorContains(t, err, "uses plugin 'pgoutput'")
+	})
+
+	t.Run("missing publication without tables", func(t *testing.T) {
+		s := newFakeServer(t)
+		s.serve()
+
+		p := NewPostgresCDC(logger.NewLogger("test")).(*PostgresCDC)
+		err := p.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": s.connString(),
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+		}}})
+		require.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("missing slot without createSlot", func(t *testing.T) {
+		s := newFakeServer(t)
+		s.results["SELECT 1 FROM pg_publication"] = []string{"1"}
+		s.serve()
+
+		p := NewPostgresCDC(logger.NewLogger("test")).(*PostgresCDC)
+		err := p.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": s.connString(),
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+			"createSlot":       "false",
+		}}})
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestStream(t *testing.T) {
+	columns := []pgoutputColumn{
+		{name: "id", typeOID: pgtype.Int4OID},
+	}
+	commitTime := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+
+	s := newFakeServer(t)
+	s.results["SELECT 1 FROM pg_publication"] = []string{"1"}
+	s.results["SELECT plugin FROM pg_replication_slots"] = []string{"pgoutput"}
+	s.stream = [][]byte{
+		xLogDataMsg(0x10, pgoutputBegin(1, commitTime)),
+		xLogDataMsg(0x10, pgoutputRelationMsg(1, "public", "orders", columns...)),
+		xLogDataMsg(0x20, pgoutputInsert(1, str("1"))),
+		xLogDataMsg(0x30, pgoutputCommit()),
+		// The server is idle: all changes up to its position have been received
+		keepaliveMsg(0x40, true),
+		xLogDataMsg(0x50, pgoutputBegin(2, commitTime)),
+		xLogDataMsg(0x60, pgoutputInsert(1, str("2"))),
+		xLogDataMsg(0x70, pgoutputInsert(1, str("3"))),
+		xLogDataMsg(0x80, pgoutputCommit()),
+	}
+	s.serve()
+
+	p := newTestBinding(t, map[string]string{
+		"connectionString": s.connString(),
+		"slotName":         "dapr_slot",
+		"publication":      "dapr_pub",
+	})
+
+	var received []*bindings.ReadResponse
+	err := p.stream(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		received = append(received, res)
+		if len(received) == 3 {
+			return nil, errors.New("handler failed")
+		}
+		// Wait for the status update requested by the keepalive
+		if len(received) == 2 {
+			assert.Eventually(t, func() bool {
+				return len(s.getStatuses()) > 0
+			}, time.Second, 10*time.Millisecond)
+		}
+		return nil, nil
+	})
+	require.ErrorContains(t, err, "handler failed")
+
+	queries := s.getQueries()
+	assert.Equal(t, "START_REPLICATION SLOT dapr_slot LOGICAL 0/0 (proto_version '1', publication_names 'dapr_pub')", queries[len(queries)-1])
+
+	require.Len(t, received, 3)
+	assert.Equal(t, map[string]string{
+		"lsn":    "0/20",
+		"op":     "insert",
+		"schema": "public",
+		"table":  "orders",
+	}, received[0].Metadata)
+	var ev map[string]any
+	require.NoError(t, json.Unmarshal(received[0].Data, &ev))
+	assert.Equal(t, map[string]any{
+		"op":         "insert",
+		"schema":     "public",
+		"table":      "orders",
+		"after":      map[string]any{"id": float64(1)},
+		"lsn":        "0/20",
+		"xid":        float64(1),
+		"commitTime": "2024-05-01T10:00:00Z",
+	}, ev)
+
+	// The position is not advanced past the transaction that failed, so it's delivered again when the stream restarts
+	assert.Eventually(t, func() bool {
+		statuses := s.getStatuses()
+		return len(statuses) == 2 && statuses[0] == 0x40 && statuses[1] == 0x40
+	}, time.Second, 10*time.Millisecond, "unexpected status updates: %v", s.getStatuses())
+}
+
+func TestReadClose(t *testing.T) {
+	s := newFakeServer(t)
+	s.results["SELECT plugin FROM pg_replication_slots"] = []string{"wal2json"}
+	s.stream = [][]byte{
+		xLogDataMsg(0x10, []byte(`{"action":"B","xid":1}`)),
+		xLogDataMsg(0x20, []byte(`{"action":"I","schema":"public","table":"orders","columns":[{"name":"id","value":1}]}`)),
+		xLogDataMsg(0x30, []byte(`{"action":"C","xid":1}`)),
+	}
+	s.serve()
+
+	p := newTestBinding(t, map[string]string{
+		"connectionString": s.connString(),
+		"slotName":         "dapr_slot",
+		"plugin":           "wal2json",
+	})
+
+	received := make(chan *bindings.ReadResponse, 1)
+	err := p.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		received <- res
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	select {
+	case res := <-received:
+		assert.Equal(t, "0/20", res.Metadata["lsn"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive change")
+	}
+
+	require.NoError(t, p.Close())
+	// The last position is reported when the stream is stopped
+	assert.Eventually(t, func() bool {
+		statuses := s.getStatuses()
+		return len(statuses) > 0 && statuses[len(statuses)-1] == 0x30
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, p.Read(context.Background(), nil))
+}
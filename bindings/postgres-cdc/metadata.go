@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	pluginPgoutput = "pgoutput"
+	pluginWal2JSON = "wal2json"
+
+	defaultTimeout        = 20 * time.Second
+	defaultStatusInterval = 10 * time.Second
+	defaultRetryInterval  = 5 * time.Second
+)
+
+var (
+	// Replication slot names can only contain lower-case letters, numbers, and underscores.
+	slotNameRegex = regexp.MustCompile(`^[a-z0-9_]{1,63}$`)
+	// Publication names are unquoted identifiers, which PostgreSQL folds to lower-case.
+	publicationRegex = regexp.MustCompile(`^[a-z_][a-z0-9_$]{0,62}$`)
+	// Tables are in the format "table" or "schema.table".
+	tableRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_$]*\.)?[A-Za-z_][A-Za-z0-9_$]*$`)
+)
+
+type cdcMetadata struct {
+	ConnectionString string        `mapstructure:"connectionString" mapstructurealiases:"url"`
+	SlotName         string        `mapstructure:"slotName"`
+	Plugin           string        `mapstructure:"plugin"`
+	Publication      string        `mapstructure:"publication"`
+	Tables           string        `mapstructure:"tables"`
+	CreateSlot       bool          `mapstructure:"createSlot"`
+	StatusInterval   time.Duration `mapstructure:"statusInterval"`
+	RetryInterval    time.Duration `mapstructure:"retryInterval"`
+	Timeout          time.Duration `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
+
+	tables []string
+}
+
+func (m *cdcMetadata) InitWithMetadata(meta map[string]string) error {
+	// Reset the object
+	*m = cdcMetadata{
+		Plugin:         pluginPgoutput,
+		CreateSlot:     true,
+		StatusInterval: defaultStatusInterval,
+		RetryInterval:  defaultRetryInterval,
+		Timeout:        defaultTimeout,
+	}
+
+	err := kitmd.DecodeMetadata(meta, &m)
+	if err != nil {
+		return err
+	}
+
+	// Validate and sanitize input
+	if m.ConnectionString == "" {
+		return errors.New("missing connection string")
+	}
+	if !slotNameRegex.MatchString(m.SlotName) {
+		return errors.New("invalid value for 'slotName': must contain only lower-case letters, numbers, and underscores")
+	}
+
+	for _, t := range strings.Split(m.Tables, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !tableRegex.MatchString(t) {
+			return fmt.Errorf("invalid table name '%s' in 'tables'", t)
+		}
+		m.tables = append(m.tables, t)
+	}
+
+	m.Plugin = strings.ToLower(m.Plugin)
+	switch m.Plugin {
+	case pluginPgoutput:
+		if !publicationRegex.MatchString(m.Publication) {
+			return errors.New("invalid value for 'publication': must be set to a name containing only lower-case letters, numbers, and underscores when using the pgoutput plugin")
+		}
+	case pluginWal2JSON:
+		if m.Publication != "" {
+			return errors.New("'publication' is only supported with the pgoutput plugin")
+		}
+	default:
+		return fmt.Errorf("invalid value for 'plugin': must be '%s' or '%s'", pluginPgoutput, pluginWal2JSON)
+	}
+
+	if m.Timeout < 1*time.Second {
+		return errors.New("invalid value for 'timeout': must be greater than 1s")
+	}
+	if m.StatusInterval < 1*time.Second {
+		return errors.New("invalid value for 'statusInterval': must be greater than 1s")
+	}
+	if m.RetryInterval <= 0 {
+		return errors.New("invalid value for 'retryInterval': must be greater than 0")
+	}
+
+	return nil
+}
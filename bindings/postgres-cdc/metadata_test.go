@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := cdcMetadata{}
+		err := m.InitWithMetadata(map[string]string{
+			"connectionString": "postgres://localhost/db",
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, pluginPgoutput, m.Plugin)
+		assert.True(t, m.CreateSlot)
+		assert.Equal(t, defaultStatusInterval, m.StatusInterval)
+		assert.Equal(t, defaultRetryInterval, m.RetryInterval)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Empty(t, m.tables)
+	})
+
+	t.Run("all properties", func(t *testing.T) {
+		m := cdcMetadata{}
+		err := m.InitWithMetadata(map[string]string{
+			"url":            "postgres://localhost/db",
+			"slotName":       "dapr_slot",
+			"plugin":         "WAL2JSON",
+			"tables":         "public.orders, customers",
+			"createSlot":     "false",
+			"statusInterval": "30s",
+			"retryInterval":  "1m",
+			"timeout":        "5s",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://localhost/db", m.ConnectionString)
+		assert.Equal(t, pluginWal2JSON, m.Plugin)
+		assert.Equal(t, []string{"public.orders", "customers"}, m.tables)
+		assert.False(t, m.CreateSlot)
+		assert.Equal(t, 30*time.Second, m.StatusInterval)
+		assert.Equal(t, time.Minute, m.RetryInterval)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+	})
+
+	t.Run("invalid properties", func(t *testing.T) {
+		valid := map[string]string{
+			"connectionString": "postgres://localhost/db",
+			"slotName":         "dapr_slot",
+			"publication":      "dapr_pub",
+		}
+		tests := map[string]map[string]string{
+			"missing connection string":     {"connectionString": ""},
+			"missing slot name":             {"slotName": ""},
+			"invalid slot name":             {"slotName": "Slot'; DROP TABLE x; --"},
+			"missing publication":           {"publication": ""},
+			"invalid publication":           {"publication": "pub name"},
+			"publication with wal2json":     {"plugin": "wal2json"},
+			"invalid plugin":                {"plugin": "test_decoding"},
+			"invalid table":                 {"tables": "public.orders,a.b.c"},
+			"timeout too short":             {"timeout": "10ms"},
+			"status interval too short":     {"statusInterval": "10ms"},
+			"negative retry interval":       {"retryInterval": "-1s"},
+			"invalid status interval value": {"statusInterval": "soon"},
+		}
+		for name, props := range tests {
+			t.Run(name, func(t *testing.T) {
+				meta := map[string]string{}
+				for k, v := range valid {
+					meta[k] = v
+				}
+				for k, v := range props {
+					meta[k] = v
+				}
+				m := cdcMetadata{}
+				require.Error(t, m.InitWithMetadata(meta))
+			})
+		}
+	})
+}
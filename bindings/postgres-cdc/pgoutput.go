@@ -0,0 +1,309 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pgoutputDecoder decodes the messages of the pgoutput plugin, using version 1 of the protocol.
+// See https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html
+type pgoutputDecoder struct {
+	publication string
+	// Relations are sent by the server before the first change to each table, and after their schema changes
+	relations map[uint32]*pgoutputRelation
+	tx        *transaction
+}
+
+type pgoutputRelation struct {
+	schema  string
+	table   string
+	columns []pgoutputColumn
+}
+
+type pgoutputColumn struct {
+	name    string
+	typeOID uint32
+}
+
+func newPgoutputDecoder(publication string) *pgoutputDecoder {
+	return &pgoutputDecoder{
+		publication: publication,
+		relations:   map[uint32]*pgoutputRelation{},
+	}
+}
+
+func (d *pgoutputDecoder) pluginArgs() string {
+	return "proto_version '1', publication_names '" + d.publication + "'"
+}
+
+func (d *pgoutputDecoder) inTransaction() bool {
+	return d.tx != nil
+}
+
+func (d *pgoutputDecoder) decode(data []byte) ([]changeEvent, bool, error) {
+	if len(data) == 0 {
+		return nil, false, errors.New("empty pgoutput message")
+	}
+	r := &msgReader{buf: data[1:]}
+
+	var (
+		events []changeEvent
+		commit bool
+	)
+	switch data[0] {
+	case 'B':
+		// Begin: final LSN, commit timestamp, xid
+		r.uint64()
+		commitTime := pgTime(int64(r.uint64())) //nolint:gosec
+		d.tx = &transaction{
+			xid:        r.uint32(),
+			commitTime: commitTime,
+		}
+	case 'C':
+		// Commit: the position of the transaction is the start of the XLogData message
+		d.tx = nil
+		commit = true
+	case 'R':
+		id := r.uint32()
+		rel := &pgoutputRelation{
+			schema: r.cstring(),
+			table:  r.cstring(),
+		}
+		// Replica identity
+		r.uint8()
+		n := int(r.uint16())
+		rel.columns = make([]pgoutputColumn, 0, n)
+		for range n {
+			// Flags
+			r.uint8()
+			col := pgoutputColumn{
+				name:    r.cstring(),
+				typeOID: r.uint32(),
+			}
+			// Type modifier
+			r.uint32()
+			rel.columns = append(rel.columns, col)
+		}
+		if r.err == nil {
+			d.relations[id] = rel
+		}
+	case 'I':
+		rel, err := d.relation(r.uint32())
+		if err != nil {
+			return nil, false, err
+		}
+		if r.uint8() != 'N' {
+			return nil, false, errors.New("invalid pgoutput insert message")
+		}
+		ev := d.newEvent(operationInsert, rel)
+		ev.After = r.tuple(rel)
+		events = append(events, ev)
+	case 'U':
+		rel, err := d.relation(r.uint32())
+		if err != nil {
+			return nil, false, err
+		}
+		ev := d.newEvent(operationUpdate, rel)
+		kind := r.uint8()
+		// The old key ('K') or the old row ('O') are included when the replica identity changed or is full
+		if kind == 'K' || kind == 'O' {
+			ev.Before = r.tuple(rel)
+			kind = r.uint8()
+		}
+		if kind != 'N' {
+			return nil, false, errors.New("invalid pgoutput update message")
+		}
+		ev.After = r.tuple(rel)
+		events = append(events, ev)
+	case 'D':
+		rel, err := d.relation(r.uint32())
+		if err != nil {
+			return nil, false, err
+		}
+		kind := r.uint8()
+		if kind != 'K' && kind != 'O' {
+			return nil, false, errors.New("invalid pgoutput delete message")
+		}
+		ev := d.newEvent(operationDelete, rel)
+		ev.Before = r.tuple(rel)
+		events = append(events, ev)
+	case 'T':
+		n := int(r.uint32())
+		// Options
+		r.uint8()
+		for range n {
+			rel, err := d.relation(r.uint32())
+			if err != nil {
+				return nil, false, err
+			}
+			events = append(events, d.newEvent(operationTruncate, rel))
+		}
+	default:
+		// Other messages, such as origin and type, are not needed
+	}
+
+	if r.err != nil {
+		return nil, false, fmt.Errorf("invalid pgoutput message '%c': %w", data[0], r.err)
+	}
+	return events, commit, nil
+}
+
+func (d *pgoutputDecoder) relation(id uint32) (*pgoutputRelation, error) {
+	rel, ok := d.relations[id]
+	if !ok {
+		return nil, fmt.Errorf("received change for unknown relation %d", id)
+	}
+	return rel, nil
+}
+
+func (d *pgoutputDecoder) newEvent(op string, rel *pgoutputRelation) changeEvent {
+	ev := changeEvent{
+		Operation: op,
+		Schema:    rel.schema,
+		Table:     rel.table,
+	}
+	d.tx.annotate(&ev)
+	return ev
+}
+
+// msgReader reads the fields of a message, remembering the first error.
+type msgReader struct {
+	buf []byte
+	err error
+}
+
+func (r *msgReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errors.New("message too short")
+		r.buf = nil
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *msgReader) uint8() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *msgReader) uint16() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *msgReader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *msgReader) uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (r *msgReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.buf {
+		if c == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	r.err = errors.New("unterminated string")
+	r.buf = nil
+	return ""
+}
+
+// tuple reads the values of a row.
+// Unchanged TOASTed values, which the server does not send, are omitted.
+func (r *msgReader) tuple(rel *pgoutputRelation) map[string]any {
+	n := int(r.uint16())
+	if r.err != nil {
+		return nil
+	}
+	if n > len(rel.columns) {
+		r.err = fmt.Errorf("row has %d columns, but relation %s.%s has %d", n, rel.schema, rel.table, len(rel.columns))
+		return nil
+	}
+	values := make(map[string]any, n)
+	for i := range n {
+		col := rel.columns[i]
+		switch r.uint8() {
+		case 'n':
+			values[col.name] = nil
+		case 'u':
+			// Unchanged TOAST value
+		case 't':
+			l := r.uint32()
+			values[col.name] = textValue(col.typeOID, r.next(int(l)))
+		default:
+			if r.err == nil {
+				r.err = errors.New("unsupported tuple data format")
+			}
+		}
+	}
+	return values
+}
+
+// textValue converts a value in the text format to the closest JSON type.
+// Types without an equivalent in JSON are returned as strings.
+func textValue(oid uint32, data []byte) any {
+	switch oid {
+	case pgtype.BoolOID:
+		return string(data) == "t"
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID:
+		return json.Number(data)
+	case pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		// NaN and infinity can't be represented in JSON
+		f, err := strconv.ParseFloat(string(data), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return string(data)
+		}
+		return json.Number(data)
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		if json.Valid(data) {
+			// Copy the data, as the buffer is reused for the next messages
+			return append(json.RawMessage(nil), data...)
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Builders for pgoutput messages.
+
+func pgoutputBegin(xid uint32, commitTime time.Time) []byte {
+	buf := []byte{'B'}
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(commitTime.Sub(postgresEpoch).Microseconds())) //nolint:gosec
+	return binary.BigEndian.AppendUint32(buf, xid)
+}
+
+func pgoutputCommit() []byte {
+	buf := []byte{'C', 0}
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	return binary.BigEndian.AppendUint64(buf, 0)
+}
+
+func pgoutputRelationMsg(id uint32, schema, table string, columns ...pgoutputColumn) []byte {
+	buf := []byte{'R'}
+	buf = binary.BigEndian.AppendUint32(buf, id)
+	buf = append(append(buf, schema...), 0)
+	buf = append(append(buf, table...), 0)
+	buf = append(buf, 'd')
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(columns))) //nolint:gosec
+	for _, col := range columns {
+		buf = append(buf, 0)
+		buf = append(append(buf, col.name...), 0)
+		buf = binary.BigEndian.AppendUint32(buf, col.typeOID)
+		buf = binary.BigEndian.AppendUint32(buf, 0xFFFFFFFF)
+	}
+	return buf
+}
+
+// pgoutputTuple encodes a row: nil values are null, and "\x00" is an unchanged TOAST value.
+func pgoutputTuple(buf []byte, values ...*string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(values))) //nolint:gosec
+	for _, v := range values {
+		switch {
+		case v == nil:
+			buf = append(buf, 'n')
+		case *v == "\x00":
+			buf = append(buf, 'u')
+		default:
+			buf = append(buf, 't')
+			buf = binary.BigEndian.AppendUint32(buf, uint32(len(*v))) //nolint:gosec
+			buf = append(buf, *v...)
+		}
+	}
+	return buf
+}
+
+func pgoutputInsert(rel uint32, values ...*string) []byte {
+	buf := binary.BigEndian.AppendUint32([]byte{'I'}, rel)
+	return pgoutputTuple(append(buf, 'N'), values...)
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func TestPgoutputDecoder(t *testing.T) {
+	commitTime := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []pgoutputColumn{
+		{name: "id", typeOID: pgtype.Int4OID},
+		{name: "name", typeOID: pgtype.TextOID},
+		{name: "active", typeOID: pgtype.BoolOID},
+		{name: "price", typeOID: pgtype.NumericOID},
+		{name: "attrs", typeOID: pgtype.JSONBOID},
+	}
+
+	newDecoder := func(t *testing.T) *pgoutputDecoder {
+		d := newPgoutputDecoder("pub")
+		events, commit, err := d.decode(pgoutputRelationMsg(16384, "public", "orders", columns...))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.False(t, commit)
+		return d
+	}
+
+	t.Run("plugin args", func(t *testing.T) {
+		assert.Equal(t, "proto_version '1', publication_names 'pub'", newPgoutputDecoder("pub").pluginArgs())
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		d := newDecoder(t)
+
+		events, commit, err := d.decode(pgoutputBegin(742, commitTime))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.False(t, commit)
+		assert.True(t, d.inTransaction())
+
+		events, commit, err = d.decode(pgoutputInsert(16384, str("1"), str("widget"), str("t"), str("NaN"), str(`{"color":"red"}`)))
+		require.NoError(t, err)
+		assert.False(t, commit)
+		require.Len(t, events, 1)
+		assert.Equal(t, operationInsert, events[0].Operation)
+		assert.Equal(t, "public", events[0].Schema)
+		assert.Equal(t, "orders", events[0].Table)
+		assert.Equal(t, uint32(742), events[0].XID)
+		require.NotNil(t, events[0].CommitTime)
+		assert.Equal(t, commitTime, events[0].CommitTime.UTC())
+		assert.Nil(t, events[0].Before)
+
+		data, err := json.Marshal(events[0].After)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"name":"widget","active":true,"price":"NaN","attrs":{"color":"red"}}`, string(data))
+
+		events, commit, err = d.decode(pgoutputCommit())
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.True(t, commit)
+		assert.False(t, d.inTransaction())
+	})
+
+	t.Run("update with old key and unchanged toast", func(t *testing.T) {
+		d := newDecoder(t)
+
+		msg := binary.BigEndian.AppendUint32([]byte{'U'}, 16384)
+		msg = pgoutputTuple(append(msg, 'K'), str("1"), nil, nil, nil, nil)
+		msg = pgoutputTuple(append(msg, 'N'), str("2"), str("gadget"), str("f"), str("9.99"), str("\x00"))
+		events, _, err := d.decode(msg)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, operationUpdate, events[0].Operation)
+
+		data, err := json.Marshal(events[0])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"op": "update",
+			"schema": "public",
+			"table": "orders",
+			"before": {"id":1,"name":null,"active":null,"price":null,"attrs":null},
+			"after": {"id":2,"name":"gadget","active":false,"price":9.99},
+			"lsn": ""
+		}`, string(data))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		d := newDecoder(t)
+
+		msg := binary.BigEndian.AppendUint32([]byte{'D'}, 16384)
+		msg = pgoutputTuple(append(msg, 'O'), str("1"), str("widget"), str("t"), str("1"), nil)
+		events, _, err := d.decode(msg)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, operationDelete, events[0].Operation)
+		assert.Nil(t, events[0].After)
+		assert.Equal(t, json.Number("1"), events[0].Before["id"])
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		d := newDecoder(t)
+		_, _, err := d.decode(pgoutputRelationMsg(16385, "public", "customers", columns[0]))
+		require.NoError(t, err)
+
+		msg := binary.BigEndian.AppendUint32([]byte{'T'}, 2)
+		msg = append(msg, 0)
+		msg = binary.BigEndian.AppendUint32(msg, 16384)
+		msg = binary.BigEndian.AppendUint32(msg, 16385)
+		events, _, err := d.decode(msg)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, operationTruncate, events[0].Operation)
+		assert.Equal(t, "orders", events[0].Table)
+		assert.Equal(t, "customers", events[1].Table)
+	})
+
+	t.Run("unknown relation", func(t *testing.T) {
+		d := newDecoder(t)
+		_, _, err := d.decode(pgoutputInsert(1, str("1")))
+		require.Error(t, err)
+	})
+
+	t.Run("truncated message", func(t *testing.T) {
+		d := newDecoder(t)
+		msg := pgoutputInsert(16384, str("1"), str("widget"), str("t"), str("1"), nil)
+		_, _, err := d.decode(msg[:len(msg)-3])
+		require.Error(t, err)
+	})
+
+	t.Run("ignores other messages", func(t *testing.T) {
+		d := newDecoder(t)
+		events, commit, err := d.decode([]byte{'Y', 0, 0})
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.False(t, commit)
+	})
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// Identifiers of the messages exchanged in the streaming replication protocol.
+// See https://www.postgresql.org/docs/current/protocol-replication.html
+const (
+	xLogDataByteID                = 'w'
+	primaryKeepaliveMessageByteID = 'k'
+	standbyStatusUpdateByteID     = 'r'
+)
+
+// postgresEpoch is the epoch of the timestamps in the replication protocol.
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// lsn is a position in the PostgreSQL write-ahead log.
+type lsn uint64
+
+// String returns the LSN in the format used by PostgreSQL, such as "16/B374D848".
+func (l lsn) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l)) //nolint:gosec
+}
+
+func pgTime(microsec int64) time.Time {
+	return postgresEpoch.Add(time.Duration(microsec) * time.Microsecond)
+}
+
+// xLogData is a message carrying data from the output plugin.
+type xLogData struct {
+	walStart lsn
+	walEnd   lsn
+	data     []byte
+}
+
+func parseXLogData(buf []byte) (xLogData, error) {
+	if len(buf) < 24 {
+		return xLogData{}, fmt.Errorf("XLogData message too short: %d bytes", len(buf))
+	}
+	return xLogData{
+		walStart: lsn(binary.BigEndian.Uint64(buf)),
+		walEnd:   lsn(binary.BigEndian.Uint64(buf[8:])),
+		data:     buf[24:],
+	}, nil
+}
+
+// primaryKeepalive is a message sent periodically by the server.
+type primaryKeepalive struct {
+	walEnd         lsn
+	replyRequested bool
+}
+
+func parsePrimaryKeepalive(buf []byte) (primaryKeepalive, error) {
+	if len(buf) < 17 {
+		return primaryKeepalive{}, fmt.Errorf("primary keepalive message too short: %d bytes", len(buf))
+	}
+	return primaryKeepalive{
+		walEnd:         lsn(binary.BigEndian.Uint64(buf)),
+		replyRequested: buf[16] != 0,
+	}, nil
+}
+
+// connectReplication opens a connection to the database in logical replication mode.
+func connectReplication(ctx context.Context, connString string) (*pgconn.PgConn, error) {
+	config, err := pgconn.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	config.RuntimeParams["replication"] = "database"
+
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the DB: %w", err)
+	}
+	return conn, nil
+}
+
+// execQuery runs a query with the simple protocol, which is the only one supported by replication connections, and returns the rows of the result.
+func execQuery(ctx context.Context, conn *pgconn.PgConn, sql string) ([][][]byte, error) {
+	results, err := conn.Exec(ctx, sql).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results[0].Rows, results[0].Err
+}
+
+// startReplication starts streaming changes from the slot, from the position confirmed last.
+func startReplication(ctx context.Context, conn *pgconn.PgConn, slotName string, pluginArgs string) error {
+	// The position 0/0 makes the server start from the confirmed position of the slot
+	sql := "START_REPLICATION SLOT " + slotName + " LOGICAL 0/0"
+	if pluginArgs != "" {
+		sql += " (" + pluginArgs + ")"
+	}
+	conn.Frontend().Send(&pgproto3.Query{String: sql})
+	err := conn.Frontend().Flush()
+	if err != nil {
+		return fmt.Errorf("failed to send START_REPLICATION: %w", err)
+	}
+
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to start replication: %w", err)
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			// Streaming has started
+			return nil
+		case *pgproto3.ErrorResponse:
+			return fmt.Errorf("failed to start replication: %w", pgconn.ErrorResponseToPgError(msg))
+		case *pgproto3.NoticeResponse:
+			// Ignore
+		default:
+			return fmt.Errorf("unexpected message while starting replication: %T", msg)
+		}
+	}
+}
+
+// sendStandbyStatus reports to the server the position up to which changes have been processed.
+// The server can then discard the WAL up to that position, and resumes the replication from it.
+func sendStandbyStatus(conn *pgconn.PgConn, pos lsn, now time.Time) error {
+	buf := make([]byte, 0, 34)
+	buf = append(buf, standbyStatusUpdateByteID)
+	// Written, flushed, and applied positions
+	buf = binary.BigEndian.AppendUint64(buf, uint64(pos))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(pos))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(pos))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(now.Sub(postgresEpoch).Microseconds())) //nolint:gosec
+	// Do not request a reply
+	buf = append(buf, 0)
+
+	conn.Frontend().Send(&pgproto3.CopyData{Data: buf})
+	err := conn.Frontend().Flush()
+	if err != nil {
+		return fmt.Errorf("failed to send standby status update: %w", err)
+	}
+	return nil
+}
+
+// errUnexpectedMessage is returned when the server sends a message that is not part of the replication stream.
+var errUnexpectedMessage = errors.New("unexpected message in replication stream")
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formats of the timestamps sent by wal2json, depending on the offset of the time zone of the server.
+var wal2jsonTimeFormats = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+}
+
+// wal2jsonDecoder decodes the messages of the wal2json plugin, using version 2 of its format.
+// Each message contains a single change.
+// See https://github.com/eulerto/wal2json
+type wal2jsonDecoder struct {
+	tables []string
+	tx     *transaction
+}
+
+type wal2jsonMessage struct {
+	Action    string           `json:"action"`
+	XID       uint32           `json:"xid"`
+	Timestamp string           `json:"timestamp"`
+	Schema    string           `json:"schema"`
+	Table     string           `json:"table"`
+	Columns   []wal2jsonColumn `json:"columns"`
+	Identity  []wal2jsonColumn `json:"identity"`
+}
+
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+func newWal2JSONDecoder(tables []string) *wal2jsonDecoder {
+	return &wal2jsonDecoder{
+		tables: tables,
+	}
+}
+
+func (d *wal2jsonDecoder) pluginArgs() string {
+	args := `"format-version" '2', "include-xids" '1', "include-timestamp" '1', "include-types" '0'`
+	if len(d.tables) > 0 {
+		args += `, "add-tables" '` + strings.Join(d.tables, ",") + `'`
+	}
+	return args
+}
+
+func (d *wal2jsonDecoder) inTransaction() bool {
+	return d.tx != nil
+}
+
+func (d *wal2jsonDecoder) decode(data []byte) ([]changeEvent, bool, error) {
+	var msg wal2jsonMessage
+	err := json.Unmarshal(data, &msg)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid wal2json message: %w", err)
+	}
+
+	var op string
+	switch msg.Action {
+	case "B":
+		d.tx = &transaction{
+			xid:        msg.XID,
+			commitTime: parseWal2JSONTime(msg.Timestamp),
+		}
+		return nil, false, nil
+	case "C":
+		d.tx = nil
+		return nil, true, nil
+	case "I":
+		op = operationInsert
+	case "U":
+		op = operationUpdate
+	case "D":
+		op = operationDelete
+	case "T":
+		op = operationTruncate
+	default:
+		// Logical decoding messages are not needed
+		return nil, false, nil
+	}
+
+	ev := changeEvent{
+		Operation: op,
+		Schema:    msg.Schema,
+		Table:     msg.Table,
+		Before:    wal2jsonValues(msg.Identity),
+		After:     wal2jsonValues(msg.Columns),
+	}
+	d.tx.annotate(&ev)
+	return []changeEvent{ev}, false, nil
+}
+
+func wal2jsonValues(columns []wal2jsonColumn) map[string]any {
+	if len(columns) == 0 {
+		return nil
+	}
+	values := make(map[string]any, len(columns))
+	for _, col := range columns {
+		values[col.Name] = col.Value
+	}
+	return values
+}
+
+func parseWal2JSONTime(val string) time.Time {
+	for _, format := range wal2jsonTimeFormats {
+		t, err := time.Parse(format, val)
+		if err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
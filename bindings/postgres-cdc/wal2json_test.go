@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWal2JSONDecoder(t *testing.T) {
+	t.Run("plugin args", func(t *testing.T) {
+		assert.Equal(t, `"format-version" '2', "include-xids" '1', "include-timestamp" '1', "include-types" '0'`, newWal2JSONDecoder(nil).pluginArgs())
+		assert.Contains(t, newWal2JSONDecoder([]string{"public.orders", "customers"}).pluginArgs(), `"add-tables" 'public.orders,customers'`)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		d := newWal2JSONDecoder(nil)
+
+		events, commit, err := d.decode([]byte(`{"action":"B","xid":742,"timestamp":"2024-05-01 12:00:00.123456+02"}`))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.False(t, commit)
+		assert.True(t, d.inTransaction())
+
+		events, commit, err = d.decode([]byte(`{"action":"U","xid":742,"schema":"public","table":"orders","columns":[{"name":"id","value":2},{"name":"attrs","value":{"color":"red"}}],"identity":[{"name":"id","value":1}]}`))
+		require.NoError(t, err)
+		assert.False(t, commit)
+		require.Len(t, events, 1)
+		assert.Equal(t, uint32(742), events[0].XID)
+		require.NotNil(t, events[0].CommitTime)
+		assert.Equal(t, time.Date(2024, time.May, 1, 10, 0, 0, 123456000, time.UTC), *events[0].CommitTime)
+
+		data, err := json.Marshal(events[0])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"op": "update",
+			"schema": "public",
+			"table": "orders",
+			"before": {"id":1},
+			"after": {"id":2,"attrs":{"color":"red"}},
+			"lsn": "",
+			"xid": 742,
+			"commitTime": "2024-05-01T10:00:00.123456Z"
+		}`, string(data))
+
+		events, commit, err = d.decode([]byte(`{"action":"C","xid":742}`))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		assert.True(t, commit)
+		assert.False(t, d.inTransaction())
+	})
+
+	t.Run("operations", func(t *testing.T) {
+		d := newWal2JSONDecoder(nil)
+		for action, op := range map[string]string{"I": operationInsert, "D": operationDelete, "T": operationTruncate} {
+			events, _, err := d.decode([]byte(`{"action":"` + action + `","schema":"public","table":"orders"}`))
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, op, events[0].Operation)
+		}
+
+		events, _, err := d.decode([]byte(`{"action":"M","prefix":"test","content":"hello"}`))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		_, _, err := newWal2JSONDecoder(nil).decode([]byte(`{"action":`))
+		require.Error(t, err)
+	})
+}
@@ -16,7 +16,6 @@ package rabbitmq
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -31,6 +30,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/common/tlsconfig"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	kitmd "github.com/dapr/kit/metadata"
@@ -92,6 +92,8 @@ type rabbitMQMetadata struct {
 	ClientCert       string         `mapstructure:"clientCert"`
 	ClientKey        string         `mapstructure:"clientKey"`
 	ExternalSasl     bool           `mapstructure:"externalSasl"`
+
+	tlsconfig.Metadata `mapstructure:",squash"`
 }
 
 // NewRabbitMQ returns a new rabbitmq instance.
@@ -430,26 +432,26 @@ func (r *RabbitMQ) Read(ctx context.Context, handler bindings.Handler) error {
 }
 
 func (r *RabbitMQ) newTLSConfig() *tls.Config {
-	tlsConfig := new(tls.Config)
-
-	if r.metadata.ClientCert != "" && r.metadata.ClientKey != "" {
-		cert, err := tls.X509KeyPair([]byte(r.metadata.ClientCert), []byte(r.metadata.ClientKey))
-		if err != nil {
-			r.logger.Warnf("Unable to load client certificate and key pair. Err: %v", err)
-			return tlsConfig
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
-
-	if r.metadata.CaCert != "" {
-		tlsConfig.RootCAs = x509.NewCertPool()
-		if ok := tlsConfig.RootCAs.AppendCertsFromPEM([]byte(r.metadata.CaCert)); !ok {
-			r.logger.Warnf("Unable to load CA certificate.")
-		}
+	tlsConfig, err := tlsconfig.Config{
+		Metadata:   r.metadata.Metadata,
+		CACert:     r.metadata.CaCert,
+		ClientCert: r.metadata.ClientCert,
+		ClientKey:  r.metadata.ClientKey,
+	}.Build()
+	if err != nil {
+		r.logger.Warnf("Unable to load TLS configuration. Err: %v", err)
+		return new(tls.Config)
 	}
 	return tlsConfig
 }
 
+// tlsEnabled returns true if the client certificate, key and CA certificate are set, either inline or as files.
+func (m *rabbitMQMetadata) tlsEnabled() bool {
+	return (m.ClientCert != "" || m.ClientCertFile != "") &&
+		(m.ClientKey != "" || m.ClientKeyFile != "") &&
+		(m.CaCert != "" || m.CACertFile != "")
+}
+
 // isValidPEM validates the provided input has PEM formatted block.
 func isValidPEM(val string) bool {
 	block, _ := pem.Decode([]byte(val))
@@ -510,7 +512,7 @@ func (r *RabbitMQ) connect() error {
 	var conn *amqp.Connection
 	var ch *amqp.Channel
 	var err error
-	if r.metadata.ClientCert != "" && r.metadata.ClientKey != "" && r.metadata.CaCert != "" {
+	if r.metadata.tlsEnabled() {
 		tlsConfig := r.newTLSConfig()
 		conn, ch, err = dialTLS(r.metadata.Host, tlsConfig, r.metadata.ExternalSasl)
 	} else {
@@ -14,6 +14,8 @@ limitations under the License.
 package bindings
 
 import (
+	"io"
+
 	"github.com/dapr/components-contrib/state"
 )
 
@@ -39,3 +41,11 @@ type InvokeResponse struct {
 	Metadata    map[string]string `json:"metadata"`
 	ContentType *string           `json:"contentType,omitempty"`
 }
+
+// InvokeStreamResponse is the response object returned from a streaming output binding.
+// The caller must close Data after reading it.
+type InvokeStreamResponse struct {
+	Data        io.ReadCloser
+	Metadata    map[string]string
+	ContentType *string
+}
@@ -6,7 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	sftpClient "github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
@@ -21,6 +26,11 @@ import (
 const (
 	metadataRootPath = "rootPath"
 	metadataFileName = "fileName"
+
+	listRecursiveOperation bindings.OperationKind = "listRecursive"
+	deleteDirOperation     bindings.OperationKind = "deleteDir"
+
+	defaultPollInterval = 30 * time.Second
 )
 
 // Sftp is a binding for file operations on sftp server.
@@ -28,6 +38,9 @@ type Sftp struct {
 	metadata   *sftpMetadata
 	logger     logger.Logger
 	sftpClient *sftpClient.Client
+	closed     atomic.Bool
+	closeCh    chan struct{}
+	wg         sync.WaitGroup
 }
 
 // sftpMetadata defines the sftp metadata.
@@ -41,6 +54,14 @@ type sftpMetadata struct {
 	HostPublicKey         []byte `json:"hostPublicKey"`
 	KnownHostsFile        string `json:"knownHostsFile"`
 	InsecureIgnoreHostKey bool   `json:"insecureIgnoreHostKey"`
+
+	// Options for the input binding, which watches the files in the root path
+	PollInterval   time.Duration `json:"pollInterval"`
+	WatchRecursive bool          `json:"watchRecursive"`
+	WatchPatterns  string        `json:"watchPatterns"`
+	ProcessedPath  string        `json:"processedPath"`
+
+	watchPatterns []string
 }
 
 type createResponse struct {
@@ -52,8 +73,18 @@ type listResponse struct {
 	IsDirectory bool   `json:"isDirectory"`
 }
 
-func NewSftp(logger logger.Logger) bindings.OutputBinding {
-	return &Sftp{logger: logger}
+type listRecursiveResponse struct {
+	FileName    string    `json:"fileName"`
+	IsDirectory bool      `json:"isDirectory"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+}
+
+func NewSftp(logger logger.Logger) bindings.InputOutputBinding {
+	return &Sftp{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
 }
 
 func (sftp *Sftp) Init(_ context.Context, metadata bindings.Metadata) error {
@@ -138,6 +169,24 @@ func (sftp *Sftp) parseMetadata(meta bindings.Metadata) (*sftpMetadata, error) {
 		return nil, err
 	}
 
+	if m.PollInterval == 0 {
+		m.PollInterval = defaultPollInterval
+	} else if m.PollInterval < 0 {
+		return nil, errors.New("pollInterval must be greater than 0")
+	}
+
+	for _, p := range strings.Split(m.WatchPatterns, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		_, err = path.Match(p, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s' in watchPatterns: %w", p, err)
+		}
+		m.watchPatterns = append(m.watchPatterns, p)
+	}
+
 	return &m, nil
 }
 
@@ -147,6 +196,8 @@ func (sftp *Sftp) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.DeleteOperation,
 		bindings.ListOperation,
+		listRecursiveOperation,
+		deleteDirOperation,
 	}
 }
 
@@ -228,6 +279,47 @@ func (sftp *Sftp) list(_ context.Context, req *bindings.InvokeRequest) (*binding
 	}, nil
 }
 
+// listRecursive lists all the files and directories under the path, with their path relative to it.
+func (sftp *Sftp) listRecursive(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := sftp.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: error merging metadata: %w", err)
+	}
+
+	root, err := metadata.getPath(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: %w", err)
+	}
+
+	resp := []listRecursiveResponse{}
+	walker := sftp.sftpClient.Walk(root)
+	for walker.Step() {
+		if walker.Err() != nil {
+			return nil, fmt.Errorf("sftp binding error: error read dir %s: %w", walker.Path(), walker.Err())
+		}
+		if walker.Path() == root {
+			continue
+		}
+
+		info := walker.Stat()
+		resp = append(resp, listRecursiveResponse{
+			FileName:    relativePath(root, walker.Path()),
+			IsDirectory: info.IsDir(),
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+		})
+	}
+
+	jsonResponse, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: cannot marshal list to json: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
+
 func (sftp *Sftp) get(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	metadata, err := sftp.metadata.mergeWithRequestMetadata(req)
 	if err != nil {
@@ -273,6 +365,39 @@ func (sftp *Sftp) delete(_ context.Context, req *bindings.InvokeRequest) (*bindi
 	return nil, nil
 }
 
+// deleteDir deletes a directory and all its contents.
+func (sftp *Sftp) deleteDir(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := sftp.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: error merging metadata: %w", err)
+	}
+
+	// Require the directory to be set explicitly, to avoid deleting the root path by mistake
+	if val, _ := kitmd.GetMetadataProperty(req.Metadata, metadataFileName); val == "" {
+		return nil, fmt.Errorf("sftp binding error: required metadata %s missing", metadataFileName)
+	}
+
+	path, err := metadata.getPath(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: %w", err)
+	}
+
+	info, err := sftp.sftpClient.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: error stat dir %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("sftp binding error: %s is not a directory", path)
+	}
+
+	err = sftp.sftpClient.RemoveAll(path)
+	if err != nil {
+		return nil, fmt.Errorf("sftp binding error: error remove dir %s: %w", path, err)
+	}
+
+	return nil, nil
+}
+
 func (sftp *Sftp) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	switch req.Operation {
 	case bindings.CreateOperation:
@@ -283,12 +408,24 @@ func (sftp *Sftp) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bin
 		return sftp.delete(ctx, req)
 	case bindings.ListOperation:
 		return sftp.list(ctx, req)
+	case listRecursiveOperation:
+		return sftp.listRecursive(ctx, req)
+	case deleteDirOperation:
+		return sftp.deleteDir(ctx, req)
 	default:
 		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
 	}
 }
 
 func (sftp *Sftp) Close() error {
+	if sftp.closed.CompareAndSwap(false, true) {
+		close(sftp.closeCh)
+	}
+	sftp.wg.Wait()
+
+	if sftp.sftpClient == nil {
+		return nil
+	}
 	return sftp.sftpClient.Close()
 }
 
@@ -1,12 +1,22 @@
 package sftp
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
+	sftpClient "github.com/pkg/sftp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
 )
 
 func TestParseMeta(t *testing.T) {
@@ -100,3 +110,280 @@ func TestGetPath(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestParseWatchMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		sftp := Sftp{}
+		meta, err := sftp.parseMetadata(bindings.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultPollInterval, meta.PollInterval)
+		assert.Empty(t, meta.watchPatterns)
+	})
+
+	t.Run("watch options", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"pollInterval":   "5s",
+			"watchRecursive": "true",
+			"watchPatterns":  "*.csv, incoming/*.json",
+			"processedPath":  "done",
+		}
+		sftp := Sftp{}
+		meta, err := sftp.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, meta.PollInterval)
+		assert.True(t, meta.WatchRecursive)
+		assert.Equal(t, []string{"*.csv", "incoming/*.json"}, meta.watchPatterns)
+		assert.Equal(t, "done", meta.ProcessedPath)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"watchPatterns": "[",
+		}
+		sftp := Sftp{}
+		_, err := sftp.parseMetadata(m)
+		require.Error(t, err)
+	})
+}
+
+func TestMatchesWatchPatterns(t *testing.T) {
+	m := sftpMetadata{watchPatterns: []string{"*.csv", "incoming/*.json"}}
+	assert.True(t, m.matchesWatchPatterns("a.csv"))
+	assert.True(t, m.matchesWatchPatterns("sub/dir/a.csv"))
+	assert.True(t, m.matchesWatchPatterns("incoming/a.json"))
+	assert.False(t, m.matchesWatchPatterns("a.json"))
+	assert.False(t, m.matchesWatchPatterns("other/incoming/a.json"))
+	assert.True(t, sftpMetadata{}.matchesWatchPatterns("anything"))
+}
+
+func TestProcessedDir(t *testing.T) {
+	assert.Equal(t, "", sftpMetadata{RootPath: "/data"}.processedDir())
+	assert.Equal(t, "/data/done", sftpMetadata{RootPath: "/data", ProcessedPath: "done"}.processedDir())
+	assert.Equal(t, "/archive", sftpMetadata{RootPath: "/data", ProcessedPath: "/archive/"}.processedDir())
+}
+
+// newTestSftp returns a binding connected to an in-memory sftp server.
+func newTestSftp(t *testing.T, props map[string]string) *Sftp {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	server := sftpClient.NewRequestServer(serverConn, sftpClient.InMemHandler())
+	go server.Serve()
+
+	client, err := sftpClient.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+
+	sftp := NewSftp(logger.NewLogger("sftp")).(*Sftp)
+	sftp.metadata, err = sftp.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	sftp.sftpClient = client
+	t.Cleanup(func() {
+		sftp.Close()
+		server.Close()
+	})
+	return sftp
+}
+
+func writeTestFile(t *testing.T, sftp *Sftp, name string, data string) {
+	t.Helper()
+
+	_, err := sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(data),
+		Metadata:  map[string]string{metadataFileName: name},
+	})
+	require.NoError(t, err)
+}
+
+func TestRecursiveOperations(t *testing.T) {
+	sftp := newTestSftp(t, map[string]string{"rootPath": "/data"})
+	writeTestFile(t, sftp, "a.txt", "a")
+	writeTestFile(t, sftp, "dir/b.txt", "bb")
+	writeTestFile(t, sftp, "dir/sub/c.txt", "ccc")
+
+	t.Run("listRecursive", func(t *testing.T) {
+		res, err := sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: listRecursiveOperation,
+		})
+		require.NoError(t, err)
+
+		var list []listRecursiveResponse
+		require.NoError(t, json.Unmarshal(res.Data, &list))
+		files := map[string]int64{}
+		for _, f := range list {
+			if f.IsDirectory {
+				files[f.FileName+"/"] = 0
+			} else {
+				files[f.FileName] = f.Size
+			}
+		}
+		assert.Equal(t, map[string]int64{
+			"a.txt":         1,
+			"dir/":          0,
+			"dir/b.txt":     2,
+			"dir/sub/":      0,
+			"dir/sub/c.txt": 3,
+		}, files)
+	})
+
+	t.Run("deleteDir requires a directory", func(t *testing.T) {
+		_, err := sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: deleteDirOperation,
+		})
+		require.Error(t, err)
+
+		_, err = sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: deleteDirOperation,
+			Metadata:  map[string]string{metadataFileName: "a.txt"},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("deleteDir", func(t *testing.T) {
+		_, err := sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: deleteDirOperation,
+			Metadata:  map[string]string{metadataFileName: "dir"},
+		})
+		require.NoError(t, err)
+
+		_, err = sftp.sftpClient.Stat("/data/dir")
+		require.ErrorIs(t, err, os.ErrNotExist)
+		_, err = sftp.sftpClient.Stat("/data/a.txt")
+		require.NoError(t, err)
+	})
+}
+
+// testHandler records the events sent by the input binding.
+type testHandler struct {
+	lock   sync.Mutex
+	events []watchEvent
+	fail   map[string]bool
+}
+
+func (h *testHandler) handle(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var ev watchEvent
+	err := json.Unmarshal(res.Data, &ev)
+	if err != nil {
+		return nil, err
+	}
+	if h.fail[ev.FileName] {
+		return nil, io.ErrUnexpectedEOF
+	}
+	h.events = append(h.events, ev)
+	return nil, nil
+}
+
+func (h *testHandler) take() map[string]string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	res := map[string]string{}
+	for _, ev := range h.events {
+		res[ev.FileName] = ev.Event
+	}
+	h.events = nil
+	return res
+}
+
+func TestPoll(t *testing.T) {
+	t.Run("new and modified files", func(t *testing.T) {
+		sftp := newTestSftp(t, map[string]string{
+			"rootPath":      "/data",
+			"watchPatterns": "*.csv",
+		})
+		writeTestFile(t, sftp, "a.csv", "a")
+		writeTestFile(t, sftp, "b.txt", "b")
+		writeTestFile(t, sftp, "sub/c.csv", "c")
+
+		h := &testHandler{}
+		seen := map[string]watchedFile{}
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.Equal(t, map[string]string{"a.csv": watchEventCreated}, h.take())
+
+		// Nothing changed
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.Empty(t, h.take())
+
+		writeTestFile(t, sftp, "a.csv", "changed")
+		writeTestFile(t, sftp, "d.csv", "d")
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.Equal(t, map[string]string{"a.csv": watchEventModified, "d.csv": watchEventCreated}, h.take())
+
+		// Deleted files are forgotten
+		require.NoError(t, sftp.sftpClient.Remove("/data/d.csv"))
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.NotContains(t, seen, "d.csv")
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		sftp := newTestSftp(t, map[string]string{
+			"rootPath":       "/data",
+			"watchRecursive": "true",
+		})
+		writeTestFile(t, sftp, "a.csv", "a")
+		writeTestFile(t, sftp, "sub/c.csv", "c")
+
+		h := &testHandler{}
+		require.NoError(t, sftp.poll(context.Background(), h.handle, map[string]watchedFile{}))
+		assert.Equal(t, map[string]string{"a.csv": watchEventCreated, "sub/c.csv": watchEventCreated}, h.take())
+	})
+
+	t.Run("move to processed", func(t *testing.T) {
+		sftp := newTestSftp(t, map[string]string{
+			"rootPath":       "/data",
+			"watchRecursive": "true",
+			"processedPath":  "processed",
+		})
+		writeTestFile(t, sftp, "a.csv", "a")
+		writeTestFile(t, sftp, "sub/c.csv", "c")
+		writeTestFile(t, sftp, "fail.csv", "f")
+		// A file with the same name processed before is replaced
+		writeTestFile(t, sftp, "processed/a.csv", "old")
+
+		h := &testHandler{fail: map[string]bool{"fail.csv": true}}
+		seen := map[string]watchedFile{}
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.Equal(t, map[string]string{"a.csv": watchEventCreated, "sub/c.csv": watchEventCreated}, h.take())
+
+		res, err := sftp.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{metadataFileName: "processed/a.csv"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(res.Data))
+		_, err = sftp.sftpClient.Stat("/data/processed/sub/c.csv")
+		require.NoError(t, err)
+		_, err = sftp.sftpClient.Stat("/data/a.csv")
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		// Files that failed are sent again
+		h.fail = nil
+		require.NoError(t, sftp.poll(context.Background(), h.handle, seen))
+		assert.Equal(t, map[string]string{"fail.csv": watchEventCreated}, h.take())
+	})
+}
+
+func TestRead(t *testing.T) {
+	sftp := newTestSftp(t, map[string]string{
+		"rootPath":     "/data",
+		"pollInterval": "10ms",
+	})
+
+	h := &testHandler{}
+	require.NoError(t, sftp.Read(context.Background(), h.handle))
+	writeTestFile(t, sftp, "a.csv", "a")
+
+	assert.Eventually(t, func() bool {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		return len(h.events) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, sftp.Close())
+	require.Error(t, sftp.Read(context.Background(), h.handle))
+}
@@ -0,0 +1,222 @@
+package sftp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	watchEventCreated  = "created"
+	watchEventModified = "modified"
+
+	posixRenameExtension = "posix-rename@openssh.com"
+)
+
+// watchEvent is the payload of the events sent by the input binding.
+type watchEvent struct {
+	FileName string    `json:"fileName"`
+	Event    string    `json:"event"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+}
+
+// watchedFile is the state of a file when it was last processed.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// Read polls the root path and sends an event for each file that is new or was modified since the previous poll.
+// Files that exist when the binding starts are considered new.
+// If processedPath is set, files are moved there after the handler processes them successfully;
+// files whose handler fails are sent again at the next poll.
+func (sftp *Sftp) Read(ctx context.Context, handler bindings.Handler) error {
+	if sftp.closed.Load() {
+		return errors.New("binding is closed")
+	}
+	if sftp.metadata.RootPath == "" {
+		return errors.New("sftp binding error: required metadata rootPath missing")
+	}
+
+	sftp.wg.Add(1)
+	go func() {
+		defer sftp.wg.Done()
+
+		seen := map[string]watchedFile{}
+		ticker := time.NewTicker(sftp.metadata.PollInterval)
+		defer ticker.Stop()
+		for {
+			err := sftp.poll(ctx, handler, seen)
+			if err != nil {
+				sftp.logger.Errorf("sftp binding error: error polling %s: %v", sftp.metadata.RootPath, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-sftp.closeCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// poll lists the watched files and sends the events for the ones that changed.
+// seen contains the state of the files at the previous poll, and it's updated.
+func (sftp *Sftp) poll(ctx context.Context, handler bindings.Handler, seen map[string]watchedFile) error {
+	root := sftp.metadata.RootPath
+	processed := sftp.metadata.processedDir()
+
+	found := make(map[string]struct{}, len(seen))
+	walker := sftp.sftpClient.Walk(root)
+	for walker.Step() {
+		if ctx.Err() != nil || sftp.closed.Load() {
+			return nil
+		}
+		if walker.Err() != nil {
+			if walker.Path() == root {
+				return walker.Err()
+			}
+			sftp.logger.Warnf("sftp binding error: error read dir %s: %v", walker.Path(), walker.Err())
+			continue
+		}
+
+		p := walker.Path()
+		info := walker.Stat()
+		if info.IsDir() {
+			if p != root && (!sftp.metadata.WatchRecursive || p == processed) {
+				walker.SkipDir()
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		rel := relativePath(root, p)
+		if !sftp.metadata.matchesWatchPatterns(rel) {
+			continue
+		}
+		found[rel] = struct{}{}
+
+		state := watchedFile{size: info.Size(), modTime: info.ModTime()}
+		prev, ok := seen[rel]
+		if ok && prev == state {
+			continue
+		}
+		ev := watchEvent{
+			FileName: rel,
+			Event:    watchEventCreated,
+			Size:     state.size,
+			ModTime:  state.modTime,
+		}
+		if ok {
+			ev.Event = watchEventModified
+		}
+
+		err := sftp.handleWatchEvent(ctx, handler, ev)
+		if err != nil {
+			// Not recording the file so it's sent again at the next poll
+			sftp.logger.Errorf("sftp binding error: error processing file %s: %v", p, err)
+			continue
+		}
+
+		if processed != "" {
+			err = sftp.moveToProcessed(p, path.Join(processed, rel))
+			if err != nil {
+				sftp.logger.Errorf("sftp binding error: error moving processed file %s: %v", p, err)
+			} else {
+				delete(found, rel)
+				continue
+			}
+		}
+		seen[rel] = state
+	}
+
+	// Forget files that were deleted, so they are sent again if they are re-created
+	for rel := range seen {
+		if _, ok := found[rel]; !ok {
+			delete(seen, rel)
+		}
+	}
+
+	return nil
+}
+
+func (sftp *Sftp) handleWatchEvent(ctx context.Context, handler bindings.Handler, ev watchEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Data: data,
+		Metadata: map[string]string{
+			metadataFileName: ev.FileName,
+			"event":          ev.Event,
+		},
+	})
+	return err
+}
+
+// moveToProcessed moves a file, replacing the destination if it exists.
+func (sftp *Sftp) moveToProcessed(src string, dst string) error {
+	err := sftp.sftpClient.MkdirAll(path.Dir(dst))
+	if err != nil {
+		return fmt.Errorf("error create dir %s: %w", path.Dir(dst), err)
+	}
+
+	// The standard rename fails if the destination exists
+	if _, ok := sftp.sftpClient.HasExtension(posixRenameExtension); ok {
+		return sftp.sftpClient.PosixRename(src, dst)
+	}
+	err = sftp.sftpClient.Remove(dst)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error remove file %s: %w", dst, err)
+	}
+	return sftp.sftpClient.Rename(src, dst)
+}
+
+// processedDir returns the absolute path of the directory where processed files are moved, if any.
+func (metadata sftpMetadata) processedDir() string {
+	if metadata.ProcessedPath == "" {
+		return ""
+	}
+	if path.IsAbs(metadata.ProcessedPath) {
+		return path.Clean(metadata.ProcessedPath)
+	}
+	return path.Join(metadata.RootPath, metadata.ProcessedPath)
+}
+
+// matchesWatchPatterns returns true if the file matches any of the patterns, or if there are no patterns.
+// Patterns containing "/" are matched against the path relative to the root path, the others against the file name.
+func (metadata sftpMetadata) matchesWatchPatterns(rel string) bool {
+	if len(metadata.watchPatterns) == 0 {
+		return true
+	}
+	for _, p := range metadata.watchPatterns {
+		name := path.Base(rel)
+		if strings.Contains(p, "/") {
+			name = rel
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func relativePath(root string, p string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	defaultDaprHTTPPort        = "3500"
+	defaultAttachmentOperation = "get"
+	attachmentFetchTimeout     = 30 * time.Second
+)
+
+// attachment is a file attached to an email, listed in the "attachments" request metadata.
+type attachment struct {
+	// Name of the file in the email
+	Name string `json:"name"`
+	// MIME type of the file; if empty, it's determined from the extension of the name
+	ContentType string `json:"contentType,omitempty"`
+	// Content of the file, base64 encoded
+	Data string `json:"data,omitempty"`
+	// Name of a Dapr binding the content of the file is fetched from, if there is no data
+	Binding string `json:"binding,omitempty"`
+	// Operation invoked on the binding; defaults to "get"
+	Operation string `json:"operation,omitempty"`
+	// Metadata of the binding request, such as the name of the blob to get
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// bindingRequest is the body of a request to the Dapr bindings API.
+type bindingRequest struct {
+	Operation string            `json:"operation"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// parseAttachments parses the attachments request metadata, a JSON array of attachments.
+func parseAttachments(val string) ([]attachment, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	var attachments []attachment
+	err := json.Unmarshal([]byte(val), &attachments)
+	if err != nil {
+		return nil, fmt.Errorf("smtp binding error: invalid attachments: %w", err)
+	}
+	for _, a := range attachments {
+		if a.Name == "" {
+			return nil, errors.New("smtp binding error: attachments must have a name")
+		}
+		if a.Data == "" && a.Binding == "" {
+			return nil, fmt.Errorf("smtp binding error: attachment '%s' must have either data or a binding", a.Name)
+		}
+	}
+
+	return attachments, nil
+}
+
+// attach adds the attachments to the message, fetching the ones from bindings.
+func (s *Mailer) attach(ctx context.Context, msg *gomail.Message, attachments []attachment) error {
+	for _, a := range attachments {
+		var (
+			data []byte
+			err  error
+		)
+		if a.Data != "" {
+			data, err = base64.StdEncoding.DecodeString(a.Data)
+			if err != nil {
+				return fmt.Errorf("smtp binding error: failed to decode attachment '%s': %w", a.Name, err)
+			}
+		} else {
+			data, err = s.fetchAttachment(ctx, a)
+			if err != nil {
+				return fmt.Errorf("smtp binding error: failed to get attachment '%s' from binding '%s': %w", a.Name, a.Binding, err)
+			}
+		}
+
+		settings := []gomail.FileSetting{
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		}
+		if a.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{
+				"Content-Type": {a.ContentType},
+			}))
+		}
+		msg.Attach(a.Name, settings...)
+	}
+
+	return nil
+}
+
+// fetchAttachment returns the content of an attachment, invoking its binding through the Dapr API.
+func (s *Mailer) fetchAttachment(parentCtx context.Context, a attachment) ([]byte, error) {
+	operation := a.Operation
+	if operation == "" {
+		operation = defaultAttachmentOperation
+	}
+	body, err := json.Marshal(bindingRequest{
+		Operation: operation,
+		Metadata:  a.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, attachmentFetchTimeout)
+	defer cancel()
+	u := s.daprHTTPEndpoint() + "/v1.0/bindings/" + url.PathEscape(a.Binding)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status code %d", res.StatusCode)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+func (s *Mailer) daprHTTPEndpoint() string {
+	if s.metadata.DaprHTTPEndpoint != "" {
+		return s.metadata.DaprHTTPEndpoint
+	}
+
+	port := os.Getenv("DAPR_HTTP_PORT")
+	if port == "" {
+		port = defaultDaprHTTPPort
+	}
+	return "http://localhost:" + port
+}
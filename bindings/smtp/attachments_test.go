@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseAttachments(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		attachments, err := parseAttachments("")
+		require.NoError(t, err)
+		assert.Empty(t, attachments)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		attachments, err := parseAttachments(`[{"name":"a.txt","data":"aGVsbG8="},{"name":"b.pdf","binding":"blobs","metadata":{"blobName":"b.pdf"}}]`)
+		require.NoError(t, err)
+		require.Len(t, attachments, 2)
+		assert.Equal(t, "aGVsbG8=", attachments[0].Data)
+		assert.Equal(t, "blobs", attachments[1].Binding)
+		assert.Equal(t, map[string]string{"blobName": "b.pdf"}, attachments[1].Metadata)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, val := range []string{
+			`{"name":"a.txt"}`,
+			`[{"data":"aGVsbG8="}]`,
+			`[{"name":"a.txt"}]`,
+		} {
+			_, err := parseAttachments(val)
+			require.Error(t, err, val)
+		}
+	})
+}
+
+func TestComposeMessage(t *testing.T) {
+	var bindingReq bindingRequest
+	dapr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0/bindings/blobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&bindingReq)
+		w.Write([]byte("from binding"))
+	}))
+	defer dapr.Close()
+
+	s := NewSMTP(logger.NewLogger("test")).(*Mailer)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"host":             "mailserver.dapr.io",
+		"port":             "25",
+		"daprHTTPEndpoint": dapr.URL,
+	}
+	err := s.Init(context.Background(), m)
+	require.NoError(t, err)
+	defer s.Close()
+
+	meta := s.metadata
+	meta.EmailFrom = "from@dapr.io"
+	meta.EmailTo = "to@dapr.io"
+	meta.Subject = "Test email"
+
+	render := func(t *testing.T, req *bindings.InvokeRequest) string {
+		t.Helper()
+		msg, err := s.composeMessage(context.Background(), req, meta)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		_, err = msg.WriteTo(&buf)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	t.Run("html template", func(t *testing.T) {
+		out := render(t, &bindings.InvokeRequest{
+			Data:     []byte(`{"name":"<Dapr>"}`),
+			Metadata: map[string]string{"htmlTemplate": "<p>Hello {{.name}}</p>"},
+		})
+		assert.Contains(t, out, "<p>Hello &lt;Dapr&gt;</p>")
+	})
+
+	t.Run("html template requires JSON data", func(t *testing.T) {
+		_, err := s.composeMessage(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte(`not json`),
+			Metadata: map[string]string{"htmlTemplate": "<p>Hello {{.name}}</p>"},
+		}, meta)
+		require.Error(t, err)
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		out := render(t, &bindings.InvokeRequest{
+			Data: []byte("<p>See attached</p>"),
+			Metadata: map[string]string{
+				"attachments": `[
+					{"name":"a.txt","contentType":"text/plain","data":"` + base64.StdEncoding.EncodeToString([]byte("inline data")) + `"},
+					{"name":"b.bin","binding":"blobs","metadata":{"blobName":"b.bin"}}
+				]`,
+			},
+		})
+		assert.Contains(t, out, "multipart/mixed")
+		assert.Contains(t, out, `filename="a.txt"`)
+		assert.Contains(t, out, base64.StdEncoding.EncodeToString([]byte("inline data")))
+		assert.Contains(t, out, `filename="b.bin"`)
+		assert.Contains(t, out, base64.StdEncoding.EncodeToString([]byte("from binding")))
+		assert.Equal(t, bindingRequest{Operation: "get", Metadata: map[string]string{"blobName": "b.bin"}}, bindingReq)
+	})
+
+	t.Run("attachment from missing binding", func(t *testing.T) {
+		_, err := s.composeMessage(context.Background(), &bindings.InvokeRequest{
+			Metadata: map[string]string{"attachments": `[{"name":"b.bin","binding":"other"}]`},
+		}, meta)
+		require.ErrorContains(t, err, "404")
+	})
+}
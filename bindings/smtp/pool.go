@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// pooledConn is an idle connection to the SMTP server.
+type pooledConn struct {
+	sender   gomail.SendCloser
+	lastUsed time.Time
+}
+
+// connPool keeps connections to the SMTP server open between sends, so the handshake, STARTTLS and authentication are not repeated for each email.
+type connPool struct {
+	dial        func() (gomail.SendCloser, error)
+	maxIdle     int
+	idleTimeout time.Duration
+
+	lock sync.Mutex
+	idle []*pooledConn
+}
+
+func newConnPool(dial func() (gomail.SendCloser, error), maxIdle int, idleTimeout time.Duration) *connPool {
+	return &connPool{
+		dial:        dial,
+		maxIdle:     maxIdle,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// send sends the message on an idle connection if any, or on a new one.
+func (p *connPool) send(msg *gomail.Message) error {
+	conn := p.get()
+	if conn != nil {
+		err := gomail.Send(conn.sender, msg)
+		if err == nil {
+			p.put(conn)
+			return nil
+		}
+
+		// The server may have closed the idle connection, so the message is sent on a new one
+		conn.sender.Close()
+	}
+
+	sender, err := p.dial()
+	if err != nil {
+		return err
+	}
+	err = gomail.Send(sender, msg)
+	if err != nil {
+		sender.Close()
+		return err
+	}
+	p.put(&pooledConn{sender: sender})
+
+	return nil
+}
+
+// get returns the most recently used idle connection, closing the ones idle for longer than the idle timeout.
+func (p *connPool) get() *pooledConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if time.Since(conn.lastUsed) < p.idleTimeout {
+			return conn
+		}
+		conn.sender.Close()
+	}
+
+	return nil
+}
+
+// put returns a connection to the pool, closing it if the pool is full.
+func (p *connPool) put(conn *pooledConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.idle) >= p.maxIdle {
+		conn.sender.Close()
+		return
+	}
+	conn.lastUsed = time.Now()
+	p.idle = append(p.idle, conn)
+}
+
+// close closes all the idle connections.
+func (p *connPool) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, conn := range p.idle {
+		conn.sender.Close()
+	}
+	p.idle = nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/gomail.v2"
+)
+
+type fakeSender struct {
+	sent   int
+	closed bool
+	err    error
+}
+
+func (f *fakeSender) Send(from string, to []string, msg io.WriterTo) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent++
+	return nil
+}
+
+func (f *fakeSender) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestConnPool(t *testing.T) {
+	newMessage := func() *gomail.Message {
+		msg := gomail.NewMessage()
+		msg.SetHeader("From", "from@dapr.io")
+		msg.SetHeader("To", "to@dapr.io")
+		return msg
+	}
+
+	var dialed []*fakeSender
+	dial := func() (gomail.SendCloser, error) {
+		sender := &fakeSender{}
+		dialed = append(dialed, sender)
+		return sender, nil
+	}
+
+	t.Run("reuses idle connections", func(t *testing.T) {
+		dialed = nil
+		p := newConnPool(dial, 2, time.Minute)
+		for range 3 {
+			require.NoError(t, p.send(newMessage()))
+		}
+		require.Len(t, dialed, 1)
+		assert.Equal(t, 3, dialed[0].sent)
+		assert.False(t, dialed[0].closed)
+
+		p.close()
+		assert.True(t, dialed[0].closed)
+	})
+
+	t.Run("sends on a new connection if an idle one fails", func(t *testing.T) {
+		dialed = nil
+		p := newConnPool(dial, 2, time.Minute)
+		require.NoError(t, p.send(newMessage()))
+		dialed[0].err = errors.New("connection closed")
+
+		require.NoError(t, p.send(newMessage()))
+		require.Len(t, dialed, 2)
+		assert.True(t, dialed[0].closed)
+		assert.Equal(t, 1, dialed[1].sent)
+	})
+
+	t.Run("closes expired connections", func(t *testing.T) {
+		dialed = nil
+		p := newConnPool(dial, 2, time.Minute)
+		require.NoError(t, p.send(newMessage()))
+		p.idle[0].lastUsed = time.Now().Add(-2 * time.Minute)
+
+		require.NoError(t, p.send(newMessage()))
+		require.Len(t, dialed, 2)
+		assert.True(t, dialed[0].closed)
+	})
+
+	t.Run("no idle connections", func(t *testing.T) {
+		dialed = nil
+		p := newConnPool(dial, 0, time.Minute)
+		require.NoError(t, p.send(newMessage()))
+		require.NoError(t, p.send(newMessage()))
+		require.Len(t, dialed, 2)
+		assert.True(t, dialed[0].closed)
+		assert.True(t, dialed[1].closed)
+	})
+
+	t.Run("dial error", func(t *testing.T) {
+		p := newConnPool(func() (gomail.SendCloser, error) {
+			return nil, errors.New("refused")
+		}, 2, time.Minute)
+		require.ErrorContains(t, p.send(newMessage()), "refused")
+	})
+}
@@ -14,13 +14,18 @@ limitations under the License.
 package smtp
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/gomail.v2"
 
@@ -35,12 +40,18 @@ const (
 	lowestPriority  = 1
 	highestPriority = 5
 	mailSeparator   = ";"
+
+	defaultMaxIdleConnections    = 2
+	defaultConnectionIdleTimeout = time.Minute
 )
 
 // Mailer allows sending of emails using the Simple Mail Transfer Protocol.
 type Mailer struct {
-	metadata Metadata
-	logger   logger.Logger
+	metadata   Metadata
+	template   *template.Template
+	pool       *connPool
+	httpClient *http.Client
+	logger     logger.Logger
 }
 
 // Metadata holds standard email properties.
@@ -56,6 +67,14 @@ type Metadata struct {
 	EmailBCC      string `mapstructure:"emailBCC"`
 	Subject       string `mapstructure:"subject"`
 	Priority      int    `mapstructure:"priority"`
+	// HTML template of the body, rendered with the JSON data of the request
+	HTMLTemplate string `mapstructure:"htmlTemplate"`
+	// Address of the Dapr HTTP API, used to get attachments from bindings; defaults to localhost on the DAPR_HTTP_PORT
+	DaprHTTPEndpoint string `mapstructure:"daprHTTPEndpoint"`
+	// Maximum number of connections to the server kept open between sends; if zero, connections are closed after each send
+	MaxIdleConnections int `mapstructure:"maxIdleConnections"`
+	// How long connections are kept open without sending any email
+	ConnectionIdleTimeout time.Duration `mapstructure:"connectionIdleTimeout"`
 }
 
 // NewSMTP returns a new smtp binding instance.
@@ -72,6 +91,21 @@ func (s *Mailer) Init(_ context.Context, metadata bindings.Metadata) error {
 	}
 	s.metadata = meta
 
+	if meta.HTMLTemplate != "" {
+		s.template, err = template.New("body").Parse(meta.HTMLTemplate)
+		if err != nil {
+			return fmt.Errorf("smtp binding error: invalid htmlTemplate: %w", err)
+		}
+	}
+
+	dialer := gomail.NewDialer(meta.Host, meta.Port, meta.User, meta.Password)
+	if meta.SkipTLSVerify {
+		/* #nosec */
+		dialer.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	s.pool = newConnPool(dialer.Dial, meta.MaxIdleConnections, meta.ConnectionIdleTimeout)
+	s.httpClient = &http.Client{}
+
 	return nil
 }
 
@@ -81,7 +115,7 @@ func (s *Mailer) Operations() []bindings.OperationKind {
 }
 
 // Invoke sends an email message.
-func (s *Mailer) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+func (s *Mailer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	// Merge config metadata with request metadata
 	metadata, err := s.metadata.mergeWithRequestMetadata(req)
 	if err != nil {
@@ -97,7 +131,24 @@ func (s *Mailer) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindin
 		return nil, errors.New("smtp binding error: subject property not supplied in configuration- or request-metadata")
 	}
 
-	// Compose message
+	msg, err := s.composeMessage(ctx, req, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send message
+	if err := s.pool.send(msg); err != nil {
+		return nil, fmt.Errorf("error from smtp binding, sending email failed: %+v", err)
+	}
+
+	// Log success
+	s.logger.Debug("smtp binding: sent email successfully")
+
+	return nil, nil
+}
+
+// composeMessage returns the email message of the request.
+func (s *Mailer) composeMessage(ctx context.Context, req *bindings.InvokeRequest, metadata Metadata) (*gomail.Message, error) {
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", metadata.EmailFrom)
 	msg.SetHeader("To", metadata.parseAddresses(metadata.EmailTo)...)
@@ -111,36 +162,71 @@ func (s *Mailer) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindin
 	msg.SetHeader("Subject", metadata.Subject)
 	msg.SetHeader("X-priority", strconv.Itoa(metadata.Priority))
 
-	body, err := strconv.Unquote(string(req.Data))
-
-	if err != nil {
-		// When data arrives over gRPC it's not quoted. Unquoting the original data will result in an error.
-		// Instead of unquoting it we'll just use the raw string as that one's already in the right format.
+	tmpl := s.template
+	if val := req.Metadata["htmlTemplate"]; val != "" {
+		var err error
+		tmpl, err = template.New("body").Parse(val)
+		if err != nil {
+			return nil, fmt.Errorf("smtp binding error: invalid htmlTemplate: %w", err)
+		}
+	}
 
-		msg.SetBody("text/html", string(req.Data))
-	} else {
+	if tmpl != nil {
+		body, err := renderTemplate(tmpl, req.Data)
+		if err != nil {
+			return nil, err
+		}
 		msg.SetBody("text/html", body)
+	} else {
+		body, err := strconv.Unquote(string(req.Data))
+
+		if err != nil {
+			// When data arrives over gRPC it's not quoted. Unquoting the original data will result in an error.
+			// Instead of unquoting it we'll just use the raw string as that one's already in the right format.
+
+			msg.SetBody("text/html", string(req.Data))
+		} else {
+			msg.SetBody("text/html", body)
+		}
 	}
 
-	// Send message
-	dialer := gomail.NewDialer(metadata.Host, metadata.Port, metadata.User, metadata.Password)
-	if metadata.SkipTLSVerify {
-		/* #nosec */
-		dialer.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	attachments, err := parseAttachments(req.Metadata["attachments"])
+	if err != nil {
+		return nil, err
 	}
-	if err := dialer.DialAndSend(msg); err != nil {
-		return nil, fmt.Errorf("error from smtp binding, sending email failed: %+v", err)
+	err = s.attach(ctx, msg, attachments)
+	if err != nil {
+		return nil, err
 	}
 
-	// Log success
-	s.logger.Debug("smtp binding: sent email successfully")
+	return msg, nil
+}
 
-	return nil, nil
+// renderTemplate renders the HTML template of the body with the JSON data of the request.
+func renderTemplate(tmpl *template.Template, data []byte) (string, error) {
+	var templateData any
+	if len(data) > 0 {
+		err := json.Unmarshal(data, &templateData)
+		if err != nil {
+			return "", fmt.Errorf("smtp binding error: data of requests with an htmlTemplate must be JSON: %w", err)
+		}
+	}
+
+	var body bytes.Buffer
+	err := tmpl.Execute(&body, templateData)
+	if err != nil {
+		return "", fmt.Errorf("smtp binding error: failed to render htmlTemplate: %w", err)
+	}
+
+	return body.String(), nil
 }
 
 // Helper to parse metadata.
 func (s *Mailer) parseMetadata(meta bindings.Metadata) (Metadata, error) {
-	smtpMeta := Metadata{}
+	smtpMeta := Metadata{
+		MaxIdleConnections:    defaultMaxIdleConnections,
+		ConnectionIdleTimeout: defaultConnectionIdleTimeout,
+	}
 	err := kitmd.DecodeMetadata(meta.Properties, &smtpMeta)
 	if err != nil {
 		return smtpMeta, err
@@ -172,6 +258,10 @@ func (s *Mailer) parseMetadata(meta bindings.Metadata) (Metadata, error) {
 		return smtpMeta, err
 	}
 
+	if smtpMeta.MaxIdleConnections < 0 {
+		return smtpMeta, errors.New("smtp binding error: maxIdleConnections must not be negative")
+	}
+
 	return smtpMeta, nil
 }
 
@@ -238,5 +328,8 @@ func (s *Mailer) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (s *Mailer) Close() error {
+	if s.pool != nil {
+		s.pool.close()
+	}
 	return nil
 }
@@ -54,6 +54,8 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, "bcc@dapr.io", smtpMeta.EmailBCC)
 		assert.Equal(t, "Test email", smtpMeta.Subject)
 		assert.Equal(t, 3, smtpMeta.Priority)
+		assert.Equal(t, defaultMaxIdleConnections, smtpMeta.MaxIdleConnections)
+		assert.Equal(t, defaultConnectionIdleTimeout, smtpMeta.ConnectionIdleTimeout)
 	})
 	t.Run("Has correct metadata (no default value for priority)", func(t *testing.T) {
 		m := bindings.Metadata{}
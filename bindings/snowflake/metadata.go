@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snowflake
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultTimeout = 20 * time.Second // Default timeout for network requests
+)
+
+type snowflakeMetadata struct {
+	// Account is the identifier of the Snowflake account, for example "myorg-myaccount".
+	Account string `mapstructure:"account"`
+
+	// User is the name of the user, which must have a public key assigned.
+	User string `mapstructure:"user"`
+
+	// PrivateKey is the PEM-encoded, unencrypted private key used for key-pair authentication.
+	PrivateKey string `mapstructure:"privateKey"`
+
+	// PrivateKeyFile is the path to a file with the PEM-encoded private key, used instead of PrivateKey.
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
+
+	// Database, Schema, Warehouse and Role are the defaults for the session.
+	Database  string `mapstructure:"database"`
+	Schema    string `mapstructure:"schema"`
+	Warehouse string `mapstructure:"warehouse"`
+	Role      string `mapstructure:"role"`
+
+	// Host overrides the host name derived from the account.
+	Host string `mapstructure:"host"`
+
+	// Timeout is the timeout for logging in.
+	Timeout time.Duration `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
+
+	// MaxOpenConns is the maximum number of open connections to the database.
+	MaxOpenConns int `mapstructure:"maxOpenConns"`
+
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle.
+	ConnMaxIdleTime time.Duration `mapstructure:"connMaxIdleTime"`
+
+	privateKey *rsa.PrivateKey
+}
+
+func (m *snowflakeMetadata) InitWithMetadata(meta map[string]string) error {
+	// Reset the object
+	*m = snowflakeMetadata{
+		Timeout: defaultTimeout,
+	}
+
+	err := kitmd.DecodeMetadata(meta, &m)
+	if err != nil {
+		return err
+	}
+
+	if m.Account == "" {
+		return errors.New("missing required metadata property 'account'")
+	}
+	if m.User == "" {
+		return errors.New("missing required metadata property 'user'")
+	}
+	if m.Timeout < 1*time.Second {
+		return errors.New("invalid value for 'timeout': must be greater than 1s")
+	}
+
+	keyPEM := []byte(m.PrivateKey)
+	switch {
+	case m.PrivateKey != "" && m.PrivateKeyFile != "":
+		return errors.New("only one of 'privateKey' and 'privateKeyFile' can be set")
+	case m.PrivateKeyFile != "":
+		keyPEM, err = os.ReadFile(m.PrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key file: %w", err)
+		}
+	case m.PrivateKey == "":
+		return errors.New("missing required metadata property 'privateKey' or 'privateKeyFile'")
+	}
+
+	m.privateKey, err = parsePrivateKey(keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	return nil
+}
+
+// parsePrivateKey parses a PEM-encoded RSA private key, in either PKCS#8 or PKCS#1 form.
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, errors.New("encrypted private keys are not supported")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		// Fall back to PKCS#1
+		rsaKey, pkcs1Err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if pkcs1Err != nil {
+			return nil, err
+		}
+		return rsaKey, nil
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key must be a RSA key")
+	}
+	return rsaKey, nil
+}
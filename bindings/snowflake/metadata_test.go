@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snowflake
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateKeyPEM(t *testing.T, pkcs8 bool) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return key, string(pem.EncodeToMemory(block))
+}
+
+func TestMetadata(t *testing.T) {
+	key, keyPEM := generateKeyPEM(t, true)
+
+	t.Run("valid metadata", func(t *testing.T) {
+		m := snowflakeMetadata{}
+		err := m.InitWithMetadata(map[string]string{
+			"account":    "myorg-myaccount",
+			"user":       "dapr",
+			"privateKey": keyPEM,
+			"database":   "db",
+			"warehouse":  "wh",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "myorg-myaccount", m.Account)
+		assert.Equal(t, "dapr", m.User)
+		assert.Equal(t, "db", m.Database)
+		assert.Equal(t, "wh", m.Warehouse)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.True(t, key.Equal(m.privateKey))
+	})
+
+	t.Run("private key file", func(t *testing.T) {
+		pkcs1Key, pkcs1PEM := generateKeyPEM(t, false)
+		keyFile := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(keyFile, []byte(pkcs1PEM), 0o600))
+
+		m := snowflakeMetadata{}
+		err := m.InitWithMetadata(map[string]string{
+			"account":        "myorg-myaccount",
+			"user":           "dapr",
+			"privateKeyFile": keyFile,
+			"timeout":        "1m",
+		})
+		require.NoError(t, err)
+		assert.True(t, pkcs1Key.Equal(m.privateKey))
+		assert.Equal(t, time.Minute, m.Timeout)
+	})
+
+	t.Run("missing properties", func(t *testing.T) {
+		m := snowflakeMetadata{}
+		err := m.InitWithMetadata(map[string]string{"user": "dapr", "privateKey": keyPEM})
+		require.ErrorContains(t, err, "'account'")
+
+		err = m.InitWithMetadata(map[string]string{"account": "myorg-myaccount", "privateKey": keyPEM})
+		require.ErrorContains(t, err, "'user'")
+
+		err = m.InitWithMetadata(map[string]string{"account": "myorg-myaccount", "user": "dapr"})
+		require.ErrorContains(t, err, "'privateKey' or 'privateKeyFile'")
+	})
+
+	t.Run("invalid private key", func(t *testing.T) {
+		m := snowflakeMetadata{}
+		err := m.InitWithMetadata(map[string]string{"account": "myorg-myaccount", "user": "dapr", "privateKey": "not a key"})
+		require.ErrorContains(t, err, "invalid private key")
+
+		encrypted := string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("x")}))
+		err = m.InitWithMetadata(map[string]string{"account": "myorg-myaccount", "user": "dapr", "privateKey": encrypted})
+		require.ErrorContains(t, err, "encrypted private keys are not supported")
+	})
+}
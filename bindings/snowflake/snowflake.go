@@ -0,0 +1,429 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snowflake
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	sf "github.com/snowflakedb/gosnowflake"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// list of operations.
+	execOperation     bindings.OperationKind = "exec"
+	queryOperation    bindings.OperationKind = "query"
+	bulkLoadOperation bindings.OperationKind = "bulkLoad"
+	closeOperation    bindings.OperationKind = "close"
+
+	// keys from request's metadata.
+	commandSQLKey    = "sql"
+	commandParamsKey = "params"
+	tableKey         = "table"
+	stageKey         = "stage"
+	fileNameKey      = "fileName"
+	fileFormatKey    = "fileFormat"
+	copyOptionsKey   = "copyOptions"
+
+	// keys from response's metadata.
+	respOpKey           = "operation"
+	respSQLKey          = "sql"
+	respStartTimeKey    = "start-time"
+	respRowsAffectedKey = "rows-affected"
+	respRowsLoadedKey   = "rows-loaded"
+	respFileKey         = "file"
+	respEndTimeKey      = "end-time"
+	respDurationKey     = "duration"
+
+	// Data is loaded as newline-delimited JSON unless a different file format is requested
+	defaultFileFormat = "TYPE = JSON"
+)
+
+var (
+	// Table names, optionally qualified with the database and schema; each part can be quoted.
+	tableNameRegex = regexp.MustCompile(`^((?:[A-Za-z_][\w$]*|"[^"]+")\.){0,2}(?:[A-Za-z_][\w$]*|"[^"]+")$`)
+	// Stages: user (@~), table (@%table), or named stages, optionally followed by a path.
+	stageNameRegex = regexp.MustCompile(`^@(~|%?(?:(?:[A-Za-z_][\w$]*|"[^"]+")\.){0,2}(?:[A-Za-z_][\w$]*|"[^"]+"))(/[\w\-./]*)?$`)
+	fileNameRegex  = regexp.MustCompile(`^[\w\-.]+$`)
+)
+
+// Snowflake represents the Snowflake output binding.
+type Snowflake struct {
+	db     *sql.DB
+	logger logger.Logger
+	closed atomic.Bool
+}
+
+// NewSnowflake returns a new Snowflake output binding.
+func NewSnowflake(logger logger.Logger) bindings.OutputBinding {
+	return &Snowflake{
+		logger: logger,
+	}
+}
+
+// Init initializes the Snowflake binding.
+func (s *Snowflake) Init(ctx context.Context, md bindings.Metadata) error {
+	if s.closed.Load() {
+		return errors.New("cannot initialize a previously-closed component")
+	}
+
+	// Parse metadata
+	meta := snowflakeMetadata{}
+	err := meta.InitWithMetadata(md.Properties)
+	if err != nil {
+		return err
+	}
+
+	cfg := sf.Config{
+		Account:       meta.Account,
+		User:          meta.User,
+		Database:      meta.Database,
+		Schema:        meta.Schema,
+		Warehouse:     meta.Warehouse,
+		Role:          meta.Role,
+		Host:          meta.Host,
+		Authenticator: sf.AuthTypeJwt,
+		PrivateKey:    meta.privateKey,
+		LoginTimeout:  meta.Timeout,
+	}
+	s.db = sql.OpenDB(sf.NewConnector(sf.SnowflakeDriver{}, cfg))
+
+	if meta.MaxOpenConns > 0 {
+		s.db.SetMaxOpenConns(meta.MaxOpenConns)
+	}
+	if meta.ConnMaxIdleTime > 0 {
+		s.db.SetConnMaxIdleTime(meta.ConnMaxIdleTime)
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(ctx, meta.Timeout)
+	defer pingCancel()
+	err = s.db.PingContext(pingCtx)
+	if err != nil {
+		return fmt.Errorf("unable to connect to Snowflake: %w", err)
+	}
+
+	return nil
+}
+
+// Invoke handles all invoke operations.
+func (s *Snowflake) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req == nil {
+		return nil, errors.New("invoke request required")
+	}
+
+	// We let the "close" operation here succeed even if the component has been closed already
+	if req.Operation == closeOperation {
+		return nil, s.Close()
+	}
+
+	if s.closed.Load() {
+		return nil, errors.New("component is closed")
+	}
+
+	if req.Metadata == nil {
+		return nil, errors.New("metadata required")
+	}
+
+	startTime := time.Now().UTC()
+	resp := &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			respOpKey:        string(req.Operation),
+			respStartTimeKey: startTime.Format(time.RFC3339Nano),
+		},
+	}
+
+	switch req.Operation {
+	case execOperation, queryOperation:
+		q, params, err := sqlFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Metadata[respSQLKey] = q
+
+		if req.Operation == execOperation {
+			r, err := s.exec(ctx, q, params...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		} else {
+			resp.Data, err = s.query(ctx, q, params...)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case bulkLoadOperation:
+		opts, err := bulkLoadOptionsFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		d, rowsLoaded, err := s.bulkLoad(ctx, opts, req.Data)
+		if err != nil {
+			return nil, err
+		}
+		resp.Data = d
+		resp.Metadata[respFileKey] = opts.stage + "/" + opts.fileName
+		resp.Metadata[respRowsLoadedKey] = strconv.FormatInt(rowsLoaded, 10)
+
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, bulkLoadOperation, closeOperation)
+	}
+
+	endTime := time.Now().UTC()
+	resp.Metadata[respEndTimeKey] = endTime.Format(time.RFC3339Nano)
+	resp.Metadata[respDurationKey] = endTime.Sub(startTime).String()
+
+	return resp, nil
+}
+
+// sqlFromRequest returns the statement and its parameters for the exec and query operations.
+func sqlFromRequest(req *bindings.InvokeRequest) (string, []any, error) {
+	q := req.Metadata[commandSQLKey]
+	if q == "" {
+		return "", nil, fmt.Errorf("required metadata not set: %s", commandSQLKey)
+	}
+
+	// Metadata property "params" contains JSON-encoded parameters, and it's optional
+	// If present, it must be unserializable into a []any object
+	var params []any
+	if paramsStr := req.Metadata[commandParamsKey]; paramsStr != "" {
+		err := json.Unmarshal([]byte(paramsStr), &params)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid metadata property %s: failed to unserialize into an array: %w", commandParamsKey, err)
+		}
+	}
+
+	return q, params, nil
+}
+
+type bulkLoadOptions struct {
+	table       string
+	stage       string
+	fileName    string
+	fileFormat  string
+	copyOptions string
+}
+
+func bulkLoadOptionsFromRequest(req *bindings.InvokeRequest) (bulkLoadOptions, error) {
+	opts := bulkLoadOptions{
+		table:       req.Metadata[tableKey],
+		stage:       req.Metadata[stageKey],
+		fileName:    req.Metadata[fileNameKey],
+		fileFormat:  req.Metadata[fileFormatKey],
+		copyOptions: req.Metadata[copyOptionsKey],
+	}
+
+	if opts.table == "" {
+		return opts, fmt.Errorf("required metadata not set: %s", tableKey)
+	}
+	if !tableNameRegex.MatchString(opts.table) {
+		return opts, fmt.Errorf("invalid metadata property %s: '%s' is not a valid table name", tableKey, opts.table)
+	}
+	if len(req.Data) == 0 {
+		return opts, errors.New("data to load is empty")
+	}
+
+	// Use the stage of the table by default
+	if opts.stage == "" {
+		opts.stage = "@%" + opts.table
+	}
+	opts.stage = strings.TrimSuffix(opts.stage, "/")
+	if !stageNameRegex.MatchString(opts.stage) {
+		return opts, fmt.Errorf("invalid metadata property %s: '%s' is not a valid stage", stageKey, opts.stage)
+	}
+
+	// Data is compressed before being uploaded
+	if opts.fileName == "" {
+		opts.fileName = "dapr-" + uuid.NewString()
+	}
+	if !fileNameRegex.MatchString(opts.fileName) {
+		return opts, fmt.Errorf("invalid metadata property %s: '%s' is not a valid file name", fileNameKey, opts.fileName)
+	}
+	if !strings.HasSuffix(opts.fileName, ".gz") {
+		opts.fileName += ".gz"
+	}
+
+	if opts.fileFormat == "" {
+		opts.fileFormat = defaultFileFormat
+	}
+
+	return opts, nil
+}
+
+// Operations returns list of operations supported by Snowflake binding.
+func (s *Snowflake) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		execOperation,
+		queryOperation,
+		bulkLoadOperation,
+		closeOperation,
+	}
+}
+
+// Close will close the DB.
+func (s *Snowflake) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		// If this failed, the component has already been closed
+		// We allow multiple calls to close
+		return nil
+	}
+
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+
+	return nil
+}
+
+func (s *Snowflake) query(ctx context.Context, sql string, params ...any) ([]byte, error) {
+	rows, err := s.db.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	result, err := readRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error reading query result: %w", err)
+	}
+
+	return json.Marshal(result)
+}
+
+func (s *Snowflake) exec(ctx context.Context, sql string, params ...any) (int64, error) {
+	res, err := s.db.ExecContext(ctx, sql, params...)
+	if err != nil {
+		return 0, fmt.Errorf("error executing query: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// bulkLoad uploads the data to the stage with PUT, then loads it into the table with COPY INTO.
+// The staged file is removed once it has been loaded.
+// It returns the result of COPY INTO and the total number of rows loaded.
+func (s *Snowflake) bulkLoad(ctx context.Context, opts bulkLoadOptions, data []byte) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error compressing data: %w", err)
+	}
+
+	// The file is read from the stream in the context: the path in the statement only sets the name of the staged file
+	putSQL := fmt.Sprintf("PUT 'file://%s' %s AUTO_COMPRESS = FALSE SOURCE_COMPRESSION = GZIP OVERWRITE = TRUE", opts.fileName, opts.stage)
+	putRows, err := s.db.QueryContext(sf.WithFileStream(ctx, &buf), putSQL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error uploading data to stage %s: %w", opts.stage, err)
+	}
+	putRows.Close()
+
+	copySQL := fmt.Sprintf("COPY INTO %s FROM %s FILES = ('%s') FILE_FORMAT = (%s) PURGE = TRUE", opts.table, opts.stage, opts.fileName, opts.fileFormat)
+	if opts.copyOptions != "" {
+		copySQL += " " + opts.copyOptions
+	}
+	rows, err := s.db.QueryContext(ctx, copySQL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error loading data into table %s: %w", opts.table, err)
+	}
+	defer rows.Close()
+
+	result, err := readRows(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading result of loading data: %w", err)
+	}
+
+	// COPY INTO returns a row for each file, with the number of rows loaded from it
+	var rowsLoaded int64
+	for _, r := range result {
+		for k, v := range r {
+			if !strings.EqualFold(k, "rows_loaded") {
+				continue
+			}
+			n, _ := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+			rowsLoaded += n
+		}
+	}
+
+	d, err := json.Marshal(result)
+	if err != nil {
+		return nil, 0, err
+	}
+	return d, rowsLoaded, nil
+}
+
+// readRows returns the rows as a list of objects keyed by column name.
+func readRows(rows *sql.Rows) ([]map[string]any, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		err = rows.Scan(ptrs...)
+		if err != nil {
+			return nil, err
+		}
+
+		r := make(map[string]any, len(columns))
+		for i, col := range columns {
+			switch v := values[i].(type) {
+			case nil:
+				continue
+			case []byte:
+				r[col] = string(v)
+			default:
+				r[col] = v
+			}
+		}
+		ret = append(ret, r)
+	}
+
+	return ret, rows.Err()
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (s *Snowflake) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := snowflakeMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return
+}
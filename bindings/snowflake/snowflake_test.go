@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snowflake
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func mockDatabase(t *testing.T) (*Snowflake, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	s := NewSnowflake(logger.NewLogger("test")).(*Snowflake)
+	s.db = db
+	t.Cleanup(func() {
+		s.Close()
+	})
+
+	return s, mock
+}
+
+func TestInvoke(t *testing.T) {
+	t.Run("exec", func(t *testing.T) {
+		s, mock := mockDatabase(t)
+		mock.ExpectExec("INSERT INTO foo VALUES").WithArgs(1.0, "bar").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: execOperation,
+			Metadata: map[string]string{
+				commandSQLKey:    "INSERT INTO foo VALUES (?, ?)",
+				commandParamsKey: `[1, "bar"]`,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1", res.Metadata[respRowsAffectedKey])
+		assert.Equal(t, "INSERT INTO foo VALUES (?, ?)", res.Metadata[respSQLKey])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query", func(t *testing.T) {
+		s, mock := mockDatabase(t)
+		rows := sqlmock.NewRows([]string{"ID", "NAME"}).
+			AddRow("1", []byte("one")).
+			AddRow("2", nil)
+		mock.ExpectQuery("SELECT ID, NAME FROM foo").WillReturnRows(rows)
+
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{commandSQLKey: "SELECT ID, NAME FROM foo"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"ID":"1","NAME":"one"},{"ID":"2"}]`, string(res.Data))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing sql", func(t *testing.T) {
+		s, _ := mockDatabase(t)
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{},
+		})
+		require.ErrorContains(t, err, "required metadata not set: sql")
+	})
+
+	t.Run("bulk load", func(t *testing.T) {
+		s, mock := mockDatabase(t)
+		mock.ExpectQuery(`^PUT 'file://orders\.json\.gz' @%ORDERS AUTO_COMPRESS = FALSE SOURCE_COMPRESSION = GZIP OVERWRITE = TRUE$`).
+			WillReturnRows(sqlmock.NewRows([]string{"source", "status"}).AddRow("orders.json.gz", "UPLOADED"))
+		mock.ExpectQuery(`^COPY INTO ORDERS FROM @%ORDERS FILES = \('orders\.json\.gz'\) FILE_FORMAT = \(TYPE = JSON\) PURGE = TRUE MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE$`).
+			WillReturnRows(sqlmock.NewRows([]string{"file", "status", "rows_parsed", "rows_loaded"}).AddRow("orders.json.gz", "LOADED", "2", "2"))
+
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bulkLoadOperation,
+			Data:      []byte("{\"id\":1}\n{\"id\":2}\n"),
+			Metadata: map[string]string{
+				tableKey:       "ORDERS",
+				fileNameKey:    "orders.json",
+				copyOptionsKey: "MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2", res.Metadata[respRowsLoadedKey])
+		assert.Equal(t, "@%ORDERS/orders.json.gz", res.Metadata[respFileKey])
+
+		var result []map[string]any
+		require.NoError(t, json.Unmarshal(res.Data, &result))
+		require.Len(t, result, 1)
+		assert.Equal(t, "LOADED", result[0]["status"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("bulk load to named stage", func(t *testing.T) {
+		s, mock := mockDatabase(t)
+		mock.ExpectQuery(`^PUT 'file://dapr-[a-f0-9-]+\.gz' @mydb\.public\.loads/daily `).
+			WillReturnRows(sqlmock.NewRows([]string{"source", "status"}))
+		mock.ExpectQuery(`^COPY INTO mydb\.public\.orders FROM @mydb\.public\.loads/daily FILES = \('dapr-[a-f0-9-]+\.gz'\) FILE_FORMAT = \(TYPE = CSV SKIP_HEADER = 1\) PURGE = TRUE$`).
+			WillReturnRows(sqlmock.NewRows([]string{"file", "status", "rows_parsed", "rows_loaded"}))
+
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bulkLoadOperation,
+			Data:      []byte("id\n1\n"),
+			Metadata: map[string]string{
+				tableKey:      "mydb.public.orders",
+				stageKey:      "@mydb.public.loads/daily/",
+				fileFormatKey: "TYPE = CSV SKIP_HEADER = 1",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("bulk load with invalid options", func(t *testing.T) {
+		s, _ := mockDatabase(t)
+		tests := map[string]struct {
+			metadata map[string]string
+			data     []byte
+			err      string
+		}{
+			"missing table":     {metadata: map[string]string{}, data: []byte("x"), err: "required metadata not set: table"},
+			"invalid table":     {metadata: map[string]string{tableKey: "orders; DROP TABLE x"}, data: []byte("x"), err: "not a valid table name"},
+			"invalid stage":     {metadata: map[string]string{tableKey: "orders", stageKey: "mystage"}, data: []byte("x"), err: "not a valid stage"},
+			"invalid file name": {metadata: map[string]string{tableKey: "orders", fileNameKey: "../x"}, data: []byte("x"), err: "not a valid file name"},
+			"empty data":        {metadata: map[string]string{tableKey: "orders"}, err: "data to load is empty"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+					Operation: bulkLoadOperation,
+					Data:      tt.data,
+					Metadata:  tt.metadata,
+				})
+				require.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		s, _ := mockDatabase(t)
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: "delete",
+			Metadata:  map[string]string{},
+		})
+		require.ErrorContains(t, err, "invalid operation type")
+	})
+
+	t.Run("close", func(t *testing.T) {
+		s, mock := mockDatabase(t)
+		mock.ExpectClose()
+
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{Operation: closeOperation})
+		require.NoError(t, err)
+		_, err = s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{commandSQLKey: "SELECT 1"},
+		})
+		require.ErrorContains(t, err, "component is closed")
+	})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook contains the HTTP server receiving the webhooks of the Twilio input bindings.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Maximum size of the body of webhook requests.
+const MaxBodySize = 1 << 20
+
+// Server is an HTTP server receiving webhooks on a path.
+type Server struct {
+	srv      *http.Server
+	listener net.Listener
+	logger   logger.Logger
+}
+
+// Listen starts listening for webhooks on the port, serving the requests to the path with handler.
+// Requests are served once Serve is called.
+func Listen(port string, path string, handler http.Handler, logger logger.Logger) (*Server, error) {
+	if path == "" {
+		path = "/"
+	}
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %s: %w", port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, http.MaxBytesHandler(handler, MaxBodySize))
+	logger.Infof("Listening for webhooks at http://localhost:%s%s", port, path)
+
+	return &Server{
+		srv: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		listener: listener,
+		logger:   logger,
+	}, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve serves the requests until the server is closed.
+func (s *Server) Serve() {
+	err := s.srv.Serve(s.listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Errorf("Error serving webhooks: %v", err)
+	}
+}
+
+// Close shuts down the server, waiting for the requests in progress.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
@@ -15,12 +15,15 @@ package sendgrid
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -35,6 +38,9 @@ import (
 type SendGrid struct {
 	metadata sendGridMetadata
 	logger   logger.Logger
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
 }
 
 // Our metadata holds standard email properties.
@@ -50,7 +56,15 @@ type sendGridMetadata struct {
 	DynamicTemplateData string `mapstructure:"dynamicTemplateData"`
 	DynamicTemplateID   string `mapstructure:"dynamicTemplateId"`
 
-	dynamicTemplateDataCache map[string]any // Cache the unmarshalled dynamic template data
+	// Port of the HTTP server receiving the Event Webhook, when used as input binding.
+	WebhookPort string `mapstructure:"webhookPort"`
+	// Path of the Event Webhook. Defaults to "/".
+	WebhookPath string `mapstructure:"webhookPath"`
+	// Base64-encoded verification key of the Signed Event Webhook.
+	WebhookPublicKey string `mapstructure:"webhookPublicKey"`
+
+	dynamicTemplateDataCache map[string]any   // Cache the unmarshalled dynamic template data
+	webhookKey               *ecdsa.PublicKey // Parsed verification key of the Event Webhook
 }
 
 // Wrapper to help decode SendGrid API errors.
@@ -63,8 +77,11 @@ type sendGridRestError struct {
 }
 
 // NewSendGrid returns a new SendGrid bindings instance.
-func NewSendGrid(logger logger.Logger) bindings.OutputBinding {
-	return &SendGrid{logger: logger}
+func NewSendGrid(logger logger.Logger) bindings.InputOutputBinding {
+	return &SendGrid{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
 }
 
 // Helper to parse metadata.
@@ -89,6 +106,13 @@ func (sg *SendGrid) parseMetadata(meta bindings.Metadata) (sendGridMetadata, err
 		}
 	}
 
+	if sgMeta.WebhookPublicKey != "" {
+		sgMeta.webhookKey, err = parseWebhookPublicKey(sgMeta.WebhookPublicKey)
+		if err != nil {
+			return sgMeta, err
+		}
+	}
+
 	return sgMeta, nil
 }
 
@@ -272,6 +296,10 @@ func (sg *SendGrid) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (sg *SendGrid) Close() error {
+	if sg.closed.CompareAndSwap(false, true) {
+		close(sg.closeCh)
+	}
+	sg.wg.Wait()
 	return nil
 }
 
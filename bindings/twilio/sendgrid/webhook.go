@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sendgrid
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/bindings/twilio/internal/webhook"
+)
+
+const (
+	signatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	timestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// Read starts an HTTP server receiving the events sent by the Signed Event Webhook of SendGrid.
+func (sg *SendGrid) Read(ctx context.Context, handler bindings.Handler) error {
+	if sg.closed.Load() {
+		return errors.New("binding is closed")
+	}
+	if sg.metadata.WebhookPort == "" {
+		return errors.New("webhookPort field is required in metadata to use the binding as input binding")
+	}
+	if sg.metadata.webhookKey == nil {
+		return errors.New("webhookPublicKey field is required in metadata to verify the signature of the events")
+	}
+
+	srv, err := webhook.Listen(sg.metadata.WebhookPort, sg.metadata.WebhookPath, sg.webhookHandler(handler), sg.logger)
+	if err != nil {
+		return err
+	}
+
+	sg.wg.Add(2)
+	go func() {
+		defer sg.wg.Done()
+		srv.Serve()
+	}()
+	go func() {
+		defer sg.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-sg.closeCh:
+		}
+		if err := srv.Close(); err != nil {
+			sg.logger.Errorf("Error shutting down the webhook server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// webhookHandler returns the HTTP handler verifying the events and delivering them to handler.
+// The events are delivered as the JSON array sent by SendGrid.
+func (sg *SendGrid) webhookHandler(handler bindings.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !validSignature(sg.metadata.webhookKey, r.Header.Get(timestampHeader), body, r.Header.Get(signatureHeader)) {
+			sg.logger.Warn("Rejected SendGrid event webhook with an invalid signature")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_, err = handler(r.Context(), &bindings.ReadResponse{
+			Data: body,
+			Metadata: map[string]string{
+				"timestamp": r.Header.Get(timestampHeader),
+			},
+		})
+		if err != nil {
+			sg.logger.Errorf("Error handling SendGrid events: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// parseWebhookPublicKey parses the verification key shown in the SendGrid settings, a base64-encoded ECDSA public key.
+func parseWebhookPublicKey(value string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("webhookPublicKey is not valid base64: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("webhookPublicKey is not a valid public key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("webhookPublicKey is not an ECDSA public key")
+	}
+	return ecKey, nil
+}
+
+// validSignature verifies the signature of the events, computed by SendGrid over the timestamp followed by the payload.
+func validSignature(key *ecdsa.PublicKey, timestamp string, payload []byte, signature string) bool {
+	if signature == "" || timestamp == "" {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(timestamp))
+	h.Write(payload)
+	return ecdsa.VerifyASN1(key, h.Sum(nil), sig)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sendgrid
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+const testEvents = `[{"email":"test@example.net","event":"delivered","sg_event_id":"1"}]`
+
+func newSignedRequest(t *testing.T, key *ecdsa.PrivateKey, timestamp string, payload []byte) *http.Request {
+	t.Helper()
+
+	digest := sha256.Sum256(append([]byte(timestamp), payload...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(payload))
+	req.Header.Set(signatureHeader, base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set(timestampHeader, timestamp)
+	return req
+}
+
+func TestParseWebhookPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	t.Run("valid key", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"apiKey": "123", "webhookPublicKey": base64.StdEncoding.EncodeToString(der)}
+		r := SendGrid{logger: logger.NewLogger("test")}
+		sgMeta, err := r.parseMetadata(m)
+		require.NoError(t, err)
+		require.NotNil(t, sgMeta.webhookKey)
+		assert.True(t, key.PublicKey.Equal(sgMeta.webhookKey))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := parseWebhookPublicKey("not base64!")
+		require.Error(t, err)
+		_, err = parseWebhookPublicKey(base64.StdEncoding.EncodeToString([]byte("not a key")))
+		require.Error(t, err)
+	})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sg := NewSendGrid(logger.NewLogger("test")).(*SendGrid)
+	sg.metadata.webhookKey = &key.PublicKey
+
+	t.Run("delivers signed events", func(t *testing.T) {
+		var received *bindings.ReadResponse
+		handler := sg.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			received = res
+			return nil, nil
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, newSignedRequest(t, key, "1700000000", []byte(testEvents)))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, received)
+		assert.JSONEq(t, testEvents, string(received.Data))
+		assert.Equal(t, "1700000000", received.Metadata["timestamp"])
+	})
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		handler := sg.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			t.Fatal("handler should not be called")
+			return nil, nil
+		})
+
+		// Payload changed after signing
+		req := newSignedRequest(t, key, "1700000000", []byte(testEvents))
+		req.Body = http.NoBody
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		// Signed with another key
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		handler(rec, newSignedRequest(t, otherKey, "1700000000", []byte(testEvents)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		// Missing headers
+		req = httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(testEvents)))
+		rec = httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		handler := sg.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			return nil, errors.New("handler error")
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, newSignedRequest(t, key, "1700000000", []byte(testEvents)))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestRead(t *testing.T) {
+	sg := NewSendGrid(logger.NewLogger("test")).(*SendGrid)
+	handler := func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		return nil, nil
+	}
+
+	err := sg.Read(context.Background(), handler)
+	require.ErrorContains(t, err, "webhookPort")
+
+	sg.metadata.WebhookPort = "0"
+	err = sg.Read(context.Background(), handler)
+	require.ErrorContains(t, err, "webhookPublicKey")
+}
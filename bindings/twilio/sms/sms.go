@@ -22,6 +22,8 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
@@ -44,6 +46,9 @@ type SMS struct {
 	metadata   twilioMetadata
 	logger     logger.Logger
 	httpClient *http.Client
+	closed     atomic.Bool
+	closeCh    chan struct{}
+	wg         sync.WaitGroup
 }
 
 type twilioMetadata struct {
@@ -52,11 +57,19 @@ type twilioMetadata struct {
 	AccountSid string        `mapstructure:"accountSid"`
 	AuthToken  string        `mapstructure:"authToken"`
 	Timeout    time.Duration `mapstructure:"timeout"`
+
+	// Port of the HTTP server receiving the webhooks of incoming messages, when used as input binding.
+	WebhookPort string `mapstructure:"webhookPort"`
+	// Path of the webhooks of incoming messages. Defaults to "/".
+	WebhookPath string `mapstructure:"webhookPath"`
+	// Public URL configured in Twilio for the webhooks of incoming messages, used to verify their signature.
+	WebhookURL string `mapstructure:"webhookURL"`
 }
 
-func NewSMS(logger logger.Logger) bindings.OutputBinding {
+func NewSMS(logger logger.Logger) bindings.InputOutputBinding {
 	return &SMS{
-		logger: logger,
+		logger:  logger,
+		closeCh: make(chan struct{}),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -143,5 +156,9 @@ func (t *SMS) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (t *SMS) Close() error {
+	if t.closed.CompareAndSwap(false, true) {
+		close(t.closeCh)
+	}
+	t.wg.Wait()
 	return nil
 }
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/bindings/twilio/internal/webhook"
+)
+
+const (
+	signatureHeader = "X-Twilio-Signature"
+	emptyTwiML      = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+)
+
+// Read starts an HTTP server receiving the webhooks Twilio sends for incoming messages.
+func (t *SMS) Read(ctx context.Context, handler bindings.Handler) error {
+	if t.closed.Load() {
+		return errors.New("binding is closed")
+	}
+	if t.metadata.WebhookPort == "" {
+		return errors.New(`"webhookPort" is required to use the binding as input binding`)
+	}
+	if t.metadata.WebhookURL == "" {
+		return errors.New(`"webhookURL" is required to verify the signature of the webhooks`)
+	}
+
+	srv, err := webhook.Listen(t.metadata.WebhookPort, t.metadata.WebhookPath, t.webhookHandler(handler), t.logger)
+	if err != nil {
+		return err
+	}
+
+	t.wg.Add(2)
+	go func() {
+		defer t.wg.Done()
+		srv.Serve()
+	}()
+	go func() {
+		defer t.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-t.closeCh:
+		}
+		if err := srv.Close(); err != nil {
+			t.logger.Errorf("Error shutting down the webhook server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// webhookHandler returns the HTTP handler verifying the webhooks and delivering the incoming messages to handler.
+// The message is delivered as a JSON object with the parameters sent by Twilio.
+func (t *SMS) webhookHandler(handler bindings.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !validSignature(t.metadata.AuthToken, t.metadata.WebhookURL, r.PostForm, r.Header.Get(signatureHeader)) {
+			t.logger.Warn("Rejected Twilio webhook with an invalid signature")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		params := make(map[string]string, len(r.PostForm))
+		for k := range r.PostForm {
+			params[k] = r.PostForm.Get(k)
+		}
+		data, err := json.Marshal(params)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, err = handler(r.Context(), &bindings.ReadResponse{
+			Data: data,
+			Metadata: map[string]string{
+				"messageSid": params["MessageSid"],
+				"from":       params["From"],
+				"to":         params["To"],
+			},
+		})
+		if err != nil {
+			t.logger.Errorf("Error handling incoming message: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Reply with an empty TwiML document so Twilio doesn't send any response message
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(emptyTwiML))
+	}
+}
+
+// validSignature verifies the signature of a webhook, computed by Twilio as the base64-encoded HMAC-SHA1 of the URL
+// followed by the POST parameters sorted by name, keyed with the auth token.
+func validSignature(authToken string, webhookURL string, params url.Values, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	expected, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(computeSignature(authToken, webhookURL, params), expected)
+}
+
+func computeSignature(authToken string, webhookURL string, params url.Values) []byte {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(webhookURL)
+	for _, k := range keys {
+		values := append([]string(nil), params[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestComputeSignature(t *testing.T) {
+	// Example from the Twilio documentation
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	signature := computeSignature("12345", "https://mycompany.com/myapp.php?foo=1&bar=2", params)
+	assert.Equal(t, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=", base64.StdEncoding.EncodeToString(signature))
+}
+
+func newWebhookRequest(t *testing.T, sms *SMS, params url.Values, sign bool) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if sign {
+		signature := computeSignature(sms.metadata.AuthToken, sms.metadata.WebhookURL, params)
+		req.Header.Set(signatureHeader, base64.StdEncoding.EncodeToString(signature))
+	}
+	return req
+}
+
+func TestWebhookHandler(t *testing.T) {
+	sms := NewSMS(logger.NewLogger("test")).(*SMS)
+	sms.metadata = twilioMetadata{
+		AuthToken:  "token",
+		WebhookURL: "https://example.com/sms",
+	}
+	params := url.Values{
+		"MessageSid": {"SM123"},
+		"From":       {"+15551234567"},
+		"To":         {"+15557654321"},
+		"Body":       {"hello"},
+	}
+
+	t.Run("delivers signed messages", func(t *testing.T) {
+		var received *bindings.ReadResponse
+		handler := sms.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			received = res
+			return nil, nil
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, newWebhookRequest(t, sms, params, true))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/xml", rec.Header().Get("Content-Type"))
+		assert.Equal(t, emptyTwiML, rec.Body.String())
+		require.NotNil(t, received)
+		assert.Equal(t, map[string]string{
+			"messageSid": "SM123",
+			"from":       "+15551234567",
+			"to":         "+15557654321",
+		}, received.Metadata)
+		data := map[string]string{}
+		require.NoError(t, json.Unmarshal(received.Data, &data))
+		assert.Equal(t, "hello", data["Body"])
+	})
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		handler := sms.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			t.Fatal("handler should not be called")
+			return nil, nil
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, newWebhookRequest(t, sms, params, false))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		req := newWebhookRequest(t, sms, params, true)
+		req.Header.Set(signatureHeader, base64.StdEncoding.EncodeToString([]byte("invalid")))
+		rec = httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("rejects non-POST requests", func(t *testing.T) {
+		handler := sms.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			return nil, nil
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/sms", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		handler := sms.webhookHandler(func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			return nil, errors.New("handler error")
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, newWebhookRequest(t, sms, params, true))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestRead(t *testing.T) {
+	t.Run("requires webhook metadata", func(t *testing.T) {
+		sms := NewSMS(logger.NewLogger("test")).(*SMS)
+		handler := func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			return nil, nil
+		}
+
+		err := sms.Read(context.Background(), handler)
+		require.ErrorContains(t, err, "webhookPort")
+
+		sms.metadata.WebhookPort = "0"
+		err = sms.Read(context.Background(), handler)
+		require.ErrorContains(t, err, "webhookURL")
+	})
+
+	t.Run("receives webhooks until closed", func(t *testing.T) {
+		port := freePort(t)
+		sms := NewSMS(logger.NewLogger("test")).(*SMS)
+		sms.metadata = twilioMetadata{
+			AuthToken:   "token",
+			WebhookPort: port,
+			WebhookPath: "/sms",
+			WebhookURL:  "https://example.com/sms",
+		}
+
+		received := make(chan *bindings.ReadResponse, 1)
+		err := sms.Read(context.Background(), func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+			received <- res
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		params := url.Values{"MessageSid": {"SM123"}, "Body": {"hello"}}
+		req := newWebhookRequest(t, sms, params, true)
+		req.RequestURI = ""
+		req.URL, _ = url.Parse("http://localhost:" + port + "/sms")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		select {
+		case r := <-received:
+			assert.Equal(t, "SM123", r.Metadata["messageSid"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+
+		require.NoError(t, sms.Close())
+		_, err = http.DefaultClient.Do(req)
+		require.Error(t, err)
+	})
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer l.Close()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
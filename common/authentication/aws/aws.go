@@ -106,6 +106,9 @@ type Provider interface {
 	ParameterStore() *ParameterStoreClients
 	Kinesis() *KinesisClients
 	Ses() *SesClients
+	AppConfigData() *AppConfigDataClients
+	CloudMap() *CloudMapClients
+	KMS() *KMSClients
 
 	Kafka(KafkaOptions) (*KafkaClients, error)
 
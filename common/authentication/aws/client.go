@@ -26,14 +26,20 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/appconfigdata"
+	"github.com/aws/aws-sdk-go/service/appconfigdata/appconfigdataiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicediscovery/servicediscoveryiface"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
@@ -56,6 +62,9 @@ type Clients struct {
 	ParameterStore *ParameterStoreClients
 	kinesis        *KinesisClients
 	ses            *SesClients
+	AppConfigData  *AppConfigDataClients
+	CloudMap       *CloudMapClients
+	KMS            *KMSClients
 	kafka          *KafkaClients
 }
 
@@ -85,6 +94,12 @@ func (c *Clients) refresh(session *session.Session) error {
 		c.kinesis.New(session)
 	case c.ses != nil:
 		c.ses.New(session)
+	case c.AppConfigData != nil:
+		c.AppConfigData.New(session)
+	case c.CloudMap != nil:
+		c.CloudMap.New(session)
+	case c.KMS != nil:
+		c.KMS.New(session)
 	case c.kafka != nil:
 		// Note: we pass in nil for token provider
 		// as there are no special fields for x509 auth for it.
@@ -139,6 +154,23 @@ type SesClients struct {
 	Ses *ses.SES
 }
 
+type AppConfigDataClients struct {
+	AppConfigData appconfigdataiface.AppConfigDataAPI
+}
+
+type CloudMapClients struct {
+	ServiceDiscovery servicediscoveryiface.ServiceDiscoveryAPI
+}
+
+type KMSClients struct {
+	KMS kmsiface.KMSAPI
+	// Clients for regions other than the default one, created on demand by ForRegion.
+	Regional map[string]kmsiface.KMSAPI
+
+	session *session.Session
+	lock    sync.Mutex
+}
+
 type KafkaClients struct {
 	config          *sarama.Config
 	consumerGroup   *string
@@ -233,6 +265,43 @@ func (c *SesClients) New(session *session.Session) {
 	c.Ses = ses.New(session, session.Config)
 }
 
+func (c *AppConfigDataClients) New(session *session.Session) {
+	c.AppConfigData = appconfigdata.New(session, session.Config)
+}
+
+func (c *CloudMapClients) New(session *session.Session) {
+	c.ServiceDiscovery = servicediscovery.New(session, session.Config)
+}
+
+func (c *KMSClients) New(session *session.Session) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.KMS = kms.New(session, session.Config)
+	c.Regional = nil
+	c.session = session
+}
+
+// ForRegion returns the client for the given region, to access keys by an ARN in that region, such as replicas of multi-Region keys.
+// It returns the default client if the region is empty or the default one, or if a custom endpoint is configured.
+func (c *KMSClients) ForRegion(region string) kmsiface.KMSAPI {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if client, ok := c.Regional[region]; ok {
+		return client
+	}
+	if region == "" || c.session == nil || aws.StringValue(c.session.Config.Endpoint) != "" || region == aws.StringValue(c.session.Config.Region) {
+		return c.KMS
+	}
+
+	client := kms.New(c.session, c.session.Config.Copy().WithRegion(region))
+	if c.Regional == nil {
+		c.Regional = make(map[string]kmsiface.KMSAPI)
+	}
+	c.Regional[region] = client
+	return client
+}
+
 type KafkaOptions struct {
 	Config          *sarama.Config
 	ConsumerGroup   string
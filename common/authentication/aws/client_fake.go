@@ -18,10 +18,16 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/appconfigdata"
+	"github.com/aws/aws-sdk-go/service/appconfigdata/appconfigdataiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicediscovery/servicediscoveryiface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 )
@@ -41,7 +47,9 @@ func (m *MockParameterStore) DescribeParametersWithContext(ctx context.Context,
 }
 
 type MockSecretManager struct {
-	GetSecretValueFn func(context.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+	GetSecretValueFn      func(context.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+	ListSecretsFn         func(context.Context, *secretsmanager.ListSecretsInput, ...request.Option) (*secretsmanager.ListSecretsOutput, error)
+	BatchGetSecretValueFn func(context.Context, *secretsmanager.BatchGetSecretValueInput, ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error)
 	secretsmanageriface.SecretsManagerAPI
 }
 
@@ -49,10 +57,19 @@ func (m *MockSecretManager) GetSecretValueWithContext(ctx context.Context, input
 	return m.GetSecretValueFn(ctx, input, option...)
 }
 
+func (m *MockSecretManager) ListSecretsWithContext(ctx context.Context, input *secretsmanager.ListSecretsInput, option ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+	return m.ListSecretsFn(ctx, input, option...)
+}
+
+func (m *MockSecretManager) BatchGetSecretValueWithContext(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, option ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	return m.BatchGetSecretValueFn(ctx, input, option...)
+}
+
 type MockDynamoDB struct {
 	GetItemWithContextFn            func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error)
 	PutItemWithContextFn            func(ctx context.Context, input *dynamodb.PutItemInput, op ...request.Option) (*dynamodb.PutItemOutput, error)
 	DeleteItemWithContextFn         func(ctx context.Context, input *dynamodb.DeleteItemInput, op ...request.Option) (*dynamodb.DeleteItemOutput, error)
+	UpdateItemWithContextFn         func(ctx context.Context, input *dynamodb.UpdateItemInput, op ...request.Option) (*dynamodb.UpdateItemOutput, error)
 	BatchWriteItemWithContextFn     func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error)
 	TransactWriteItemsWithContextFn func(aws.Context, *dynamodb.TransactWriteItemsInput, ...request.Option) (*dynamodb.TransactWriteItemsOutput, error)
 	dynamodbiface.DynamoDBAPI
@@ -70,6 +87,10 @@ func (m *MockDynamoDB) DeleteItemWithContext(ctx context.Context, input *dynamod
 	return m.DeleteItemWithContextFn(ctx, input, op...)
 }
 
+func (m *MockDynamoDB) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput, op ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	return m.UpdateItemWithContextFn(ctx, input, op...)
+}
+
 func (m *MockDynamoDB) BatchWriteItemWithContext(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
 	return m.BatchWriteItemWithContextFn(ctx, input, op...)
 }
@@ -77,3 +98,60 @@ func (m *MockDynamoDB) BatchWriteItemWithContext(ctx context.Context, input *dyn
 func (m *MockDynamoDB) TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
 	return m.TransactWriteItemsWithContextFn(ctx, input, op...)
 }
+
+type MockAppConfigData struct {
+	StartConfigurationSessionFn func(context.Context, *appconfigdata.StartConfigurationSessionInput, ...request.Option) (*appconfigdata.StartConfigurationSessionOutput, error)
+	GetLatestConfigurationFn    func(context.Context, *appconfigdata.GetLatestConfigurationInput, ...request.Option) (*appconfigdata.GetLatestConfigurationOutput, error)
+	appconfigdataiface.AppConfigDataAPI
+}
+
+func (m *MockAppConfigData) StartConfigurationSessionWithContext(ctx context.Context, input *appconfigdata.StartConfigurationSessionInput, option ...request.Option) (*appconfigdata.StartConfigurationSessionOutput, error) {
+	return m.StartConfigurationSessionFn(ctx, input, option...)
+}
+
+func (m *MockAppConfigData) GetLatestConfigurationWithContext(ctx context.Context, input *appconfigdata.GetLatestConfigurationInput, option ...request.Option) (*appconfigdata.GetLatestConfigurationOutput, error) {
+	return m.GetLatestConfigurationFn(ctx, input, option...)
+}
+
+type MockServiceDiscovery struct {
+	DiscoverInstancesFn func(context.Context, *servicediscovery.DiscoverInstancesInput, ...request.Option) (*servicediscovery.DiscoverInstancesOutput, error)
+	servicediscoveryiface.ServiceDiscoveryAPI
+}
+
+func (m *MockServiceDiscovery) DiscoverInstancesWithContext(ctx context.Context, input *servicediscovery.DiscoverInstancesInput, option ...request.Option) (*servicediscovery.DiscoverInstancesOutput, error) {
+	return m.DiscoverInstancesFn(ctx, input, option...)
+}
+
+type MockKMS struct {
+	GetPublicKeyFn    func(context.Context, *kms.GetPublicKeyInput, ...request.Option) (*kms.GetPublicKeyOutput, error)
+	EncryptFn         func(context.Context, *kms.EncryptInput, ...request.Option) (*kms.EncryptOutput, error)
+	DecryptFn         func(context.Context, *kms.DecryptInput, ...request.Option) (*kms.DecryptOutput, error)
+	GenerateDataKeyFn func(context.Context, *kms.GenerateDataKeyInput, ...request.Option) (*kms.GenerateDataKeyOutput, error)
+	SignFn            func(context.Context, *kms.SignInput, ...request.Option) (*kms.SignOutput, error)
+	VerifyFn          func(context.Context, *kms.VerifyInput, ...request.Option) (*kms.VerifyOutput, error)
+	kmsiface.KMSAPI
+}
+
+func (m *MockKMS) GetPublicKeyWithContext(ctx context.Context, input *kms.GetPublicKeyInput, option ...request.Option) (*kms.GetPublicKeyOutput, error) {
+	return m.GetPublicKeyFn(ctx, input, option...)
+}
+
+func (m *MockKMS) EncryptWithContext(ctx context.Context, input *kms.EncryptInput, option ...request.Option) (*kms.EncryptOutput, error) {
+	return m.EncryptFn(ctx, input, option...)
+}
+
+func (m *MockKMS) DecryptWithContext(ctx context.Context, input *kms.DecryptInput, option ...request.Option) (*kms.DecryptOutput, error) {
+	return m.DecryptFn(ctx, input, option...)
+}
+
+func (m *MockKMS) GenerateDataKeyWithContext(ctx context.Context, input *kms.GenerateDataKeyInput, option ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	return m.GenerateDataKeyFn(ctx, input, option...)
+}
+
+func (m *MockKMS) SignWithContext(ctx context.Context, input *kms.SignInput, option ...request.Option) (*kms.SignOutput, error) {
+	return m.SignFn(ctx, input, option...)
+}
+
+func (m *MockKMS) VerifyWithContext(ctx context.Context, input *kms.VerifyInput, option ...request.Option) (*kms.VerifyOutput, error) {
+	return m.VerifyFn(ctx, input, option...)
+}
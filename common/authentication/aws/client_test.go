@@ -24,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/stretchr/testify/assert"
@@ -68,6 +69,23 @@ func TestS3Clients_New(t *testing.T) {
 	}
 }
 
+func TestKMSClients_ForRegion(t *testing.T) {
+	c := &KMSClients{}
+	c.New(session.Must(session.NewSession(aws.NewConfig().WithRegion("us-west-2"))))
+
+	assert.Same(t, c.KMS, c.ForRegion(""))
+	assert.Same(t, c.KMS, c.ForRegion("us-west-2"))
+
+	regional := c.ForRegion("eu-west-1")
+	assert.NotSame(t, c.KMS, regional)
+	assert.Equal(t, "eu-west-1", aws.StringValue(regional.(*kms.KMS).Config.Region))
+	assert.Same(t, regional, c.ForRegion("eu-west-1"))
+
+	// With a custom endpoint, all requests use the default client
+	c.New(session.Must(session.NewSession(aws.NewConfig().WithRegion("us-west-2").WithEndpoint("http://localhost:4566"))))
+	assert.Same(t, c.KMS, c.ForRegion("eu-west-1"))
+}
+
 func TestSqsClients_QueueURL(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -227,6 +227,48 @@ func (a *StaticAuth) Ses() *SesClients {
 	return a.clients.ses
 }
 
+func (a *StaticAuth) AppConfigData() *AppConfigDataClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.AppConfigData != nil {
+		return a.clients.AppConfigData
+	}
+
+	clients := AppConfigDataClients{}
+	a.clients.AppConfigData = &clients
+	a.clients.AppConfigData.New(a.session)
+	return a.clients.AppConfigData
+}
+
+func (a *StaticAuth) CloudMap() *CloudMapClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.CloudMap != nil {
+		return a.clients.CloudMap
+	}
+
+	clients := CloudMapClients{}
+	a.clients.CloudMap = &clients
+	a.clients.CloudMap.New(a.session)
+	return a.clients.CloudMap
+}
+
+func (a *StaticAuth) KMS() *KMSClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.KMS != nil {
+		return a.clients.KMS
+	}
+
+	clients := KMSClients{}
+	a.clients.KMS = &clients
+	a.clients.KMS.New(a.session)
+	return a.clients.KMS
+}
+
 func (a *StaticAuth) Kafka(opts KafkaOptions) (*KafkaClients, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -296,6 +296,48 @@ func (a *x509) Ses() *SesClients {
 	return a.clients.ses
 }
 
+func (a *x509) AppConfigData() *AppConfigDataClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.AppConfigData != nil {
+		return a.clients.AppConfigData
+	}
+
+	clients := AppConfigDataClients{}
+	a.clients.AppConfigData = &clients
+	a.clients.AppConfigData.New(a.session)
+	return a.clients.AppConfigData
+}
+
+func (a *x509) CloudMap() *CloudMapClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.CloudMap != nil {
+		return a.clients.CloudMap
+	}
+
+	clients := CloudMapClients{}
+	a.clients.CloudMap = &clients
+	a.clients.CloudMap.New(a.session)
+	return a.clients.CloudMap
+}
+
+func (a *x509) KMS() *KMSClients {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients.KMS != nil {
+		return a.clients.KMS
+	}
+
+	clients := KMSClients{}
+	a.clients.KMS = &clients
+	a.clients.KMS.New(a.session)
+	return a.clients.KMS
+}
+
 func (a *x509) Kafka(opts KafkaOptions) (*KafkaClients, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
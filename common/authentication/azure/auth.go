@@ -15,6 +15,9 @@ package azure
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -31,6 +34,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"golang.org/x/crypto/pkcs12"
 
+	"github.com/dapr/components-contrib/common/credentials"
 	"github.com/dapr/components-contrib/metadata"
 )
 
@@ -38,6 +42,12 @@ import (
 type EnvironmentSettings struct {
 	Metadata map[string]string
 	Cloud    *cloud.Configuration
+
+	// If true, GetTokenCredential returns a credential backed by a process-wide token cache, shared with all components that use the same identity.
+	// Cached tokens are refreshed in background before they expire.
+	SharedTokenCache bool
+	// Optional function invoked when the shared token cache requests a new token, for example to record metrics about failures.
+	OnTokenAcquired TokenAcquiredFn
 }
 
 const (
@@ -91,18 +101,14 @@ func NewEnvironmentSettings(md map[string]string) (EnvironmentSettings, error) {
 }
 
 // GetAzureEnvironment returns the Azure environment for a given name.
+// Besides the well-known clouds, the name can refer to a cloud added with RegisterCloud.
+// If the metadata contains custom endpoints, such as the authority host, a cloud configuration with those endpoints is returned.
 func (s EnvironmentSettings) GetAzureEnvironment() (*cloud.Configuration, error) {
 	envName, _ := s.GetEnvironment("AzureEnvironment")
-	switch strings.ToLower(envName) {
-	case "", "azurepubliccloud", "azurepublic": // Default value if envName is empty
-		return &cloud.AzurePublic, nil
-	case "azurechinacloud", "azurechina":
-		return &cloud.AzureChina, nil
-	case "azureusgovernmentcloud", "azureusgovernment":
-		return &cloud.AzureGovernment, nil
-	default:
-		return nil, fmt.Errorf("invalid Azure cloud: %v", envName)
+	if s.hasCustomEndpoints() {
+		return s.customCloud(envName)
 	}
+	return lookupCloud(envName)
 }
 
 func (s EnvironmentSettings) addClientCredentialsProvider(creds *[]azcore.TokenCredential, errs *[]error) {
@@ -130,8 +136,13 @@ func (s EnvironmentSettings) addClientCertificateProvider(creds *[]azcore.TokenC
 func (s EnvironmentSettings) addWorkloadIdentityProvider(creds *[]azcore.TokenCredential, errs *[]error) {
 	// workload identity requires values for AZURE_AUTHORITY_HOST, AZURE_CLIENT_ID, AZURE_FEDERATED_TOKEN_FILE, AZURE_TENANT_ID
 	// The workload identity mutating admissions webhook in Kubernetes injects these values into the pod.
-	// These environment variables are read using the default WorkloadIdentityCredentialOptions
-	workloadCred, err := azidentity.NewWorkloadIdentityCredential(nil)
+	// Values set in the component's metadata take precedence over the environment variables.
+	c, err := s.GetWorkloadIdentity()
+	if err != nil {
+		*errs = append(*errs, err)
+		return
+	}
+	workloadCred, err := c.GetTokenCredential()
 	if err == nil {
 		*creds = append(*creds, workloadCred)
 	} else {
@@ -196,7 +207,17 @@ func getAzureAuthMethods() []string {
 // 3. Workload identity
 // 4. MSI (we use a timeout of 1 second when no compatible managed identity implementation is available)
 // 5. Azure CLI
+//
+// If SharedTokenCache is set, the credential shares its tokens with the other components that use the same identity.
 func (s EnvironmentSettings) GetTokenCredential() (azcore.TokenCredential, error) {
+	if s.SharedTokenCache {
+		return s.getSharedTokenCredential()
+	}
+	return s.newTokenCredential()
+}
+
+// newTokenCredential creates the chain of credentials for GetTokenCredential.
+func (s EnvironmentSettings) newTokenCredential() (azcore.TokenCredential, error) {
 	// Create a chain
 	var creds []azcore.TokenCredential
 	errs := make([]error, 0, 3)
@@ -298,6 +319,24 @@ func (s EnvironmentSettings) GetClientCert() (config CertConfig, err error) {
 	return config, nil
 }
 
+// GetWorkloadIdentity creates a workload identity config object from the available metadata.
+// Values that are not set in the metadata are read from the environment variables injected by the workload identity webhook.
+func (s EnvironmentSettings) GetWorkloadIdentity() (config WorkloadIdentityConfig, err error) {
+	config.ClientID, _ = s.GetEnvironment("ClientID")
+	config.TenantID, _ = s.GetEnvironment("TenantID")
+	config.TokenFilePath, _ = s.GetEnvironment("FederatedTokenFile")
+
+	// Only set the cloud when configured explicitly, so AZURE_AUTHORITY_HOST is honored otherwise
+	if envName, _ := s.GetEnvironment("AzureEnvironment"); envName != "" || s.hasCustomEndpoints() {
+		config.AzureCloud, err = s.GetAzureEnvironment()
+		if err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
+
 // GetMSI creates a MSI config object from the available client ID.
 func (s EnvironmentSettings) GetMSI() (config MSIConfig) {
 	// This is optional and it's ok if value is empty
@@ -338,18 +377,22 @@ type CertConfig struct {
 }
 
 // GetTokenCredential returns the azcore.TokenCredential object from client certificate.
+// When the certificate is loaded from a file, the file is read again when it changes, so the certificate can be rotated without restarting the component.
 func (c CertConfig) GetTokenCredential() (token azcore.TokenCredential, err error) {
-	// Certificate data - may be empty here
-	data := c.CertificateData
-
 	// If we have a certificate path, load it
 	if c.CertificatePath != "" {
-		var errB error
-		data, errB = os.ReadFile(c.CertificatePath)
-		if errB != nil {
-			return nil, fmt.Errorf("failed to read the certificate file (%s): %v", c.CertificatePath, errB)
+		file, errF := credentials.NewFile(c.CertificatePath)
+		if errF != nil {
+			return nil, fmt.Errorf("failed to read the certificate file (%s): %v", c.CertificatePath, errF)
 		}
+		return newReloadingCertCredential(c, file)
 	}
+
+	return c.getTokenCredentialFromData(c.CertificateData)
+}
+
+// getTokenCredentialFromData returns the azcore.TokenCredential object from the certificate data.
+func (c CertConfig) getTokenCredentialFromData(data []byte) (azcore.TokenCredential, error) {
 	if len(data) == 0 {
 		return nil, errors.New("certificate is not given")
 	}
@@ -360,6 +403,11 @@ func (c CertConfig) GetTokenCredential() (token azcore.TokenCredential, err erro
 		return nil, fmt.Errorf("failed to decode pkcs12 certificate while creating spt: %v", err)
 	}
 
+	// The Azure SDK only supports RSA keys, so other keys sign the client assertion themselves
+	if _, isRSA := key.(*rsa.PrivateKey); !isRSA {
+		return newCertificateAssertionCredential(c, cert, key)
+	}
+
 	// Create the azcore.TokenCredential object
 	certs := []*x509.Certificate{cert}
 	var opts *azidentity.ClientCertificateCredentialOptions
@@ -375,7 +423,8 @@ func (c CertConfig) GetTokenCredential() (token azcore.TokenCredential, err erro
 
 // Decode a certificate, either as a PKCS#12 (PFX) bundle, or as a single file with both certificate and key encoded in PEM blocks.
 // The password is only used for PFX (and could be empty).
-func (c CertConfig) decodeCertificate(data []byte, password string) (certificate *x509.Certificate, privateKey *rsa.PrivateKey, err error) {
+// The private key can be RSA, ECDSA or Ed25519.
+func (c CertConfig) decodeCertificate(data []byte, password string) (certificate *x509.Certificate, privateKey crypto.PrivateKey, err error) {
 	// First, try to decode the certificate as PKCS#12
 	certificate, privateKey, err = c.decodePkcs12(data, password)
 	if err == nil && certificate != nil {
@@ -391,26 +440,25 @@ func (c CertConfig) decodeCertificate(data []byte, password string) (certificate
 	return nil, nil, errors.New("certificate is not valid")
 }
 
-func (c CertConfig) decodePkcs12(pkcs []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
+func (c CertConfig) decodePkcs12(pkcs []byte, password string) (*x509.Certificate, crypto.PrivateKey, error) {
 	privateKey, certificate, err := pkcs12.Decode(pkcs, password)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	rsaPrivateKey, isRsaKey := privateKey.(*rsa.PrivateKey)
-	if !isRsaKey {
-		return nil, nil, errors.New("PKCS#12 certificate must contain an RSA private key")
+	key, err := supportedPrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("PKCS#12 certificate must contain an RSA, ECDSA or Ed25519 private key: %w", err)
 	}
 
-	return certificate, rsaPrivateKey, nil
+	return certificate, key, nil
 }
 
-func (c CertConfig) decodePEM(data []byte) (certificate *x509.Certificate, privateKey *rsa.PrivateKey, err error) {
+func (c CertConfig) decodePEM(data []byte) (certificate *x509.Certificate, privateKey crypto.PrivateKey, err error) {
 	// We should have 2 PEM blocks: a certificate and a key
 	var (
 		block     *pem.Block
 		parsedKey any
-		ok        bool
 	)
 	for range 2 {
 		block, data = pem.Decode(data)
@@ -428,31 +476,25 @@ func (c CertConfig) decodePEM(data []byte) (certificate *x509.Certificate, priva
 			if err != nil {
 				return nil, nil, err
 			}
-		case "PRIVATE KEY": // PKCS#8
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY": // PKCS#8, PKCS#1, SEC 1
 			// If we already have a key decoded, return an error
 			if privateKey != nil {
 				return nil, nil, errors.New("invalid certificate")
 			}
-			parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
-			if err != nil {
-				return nil, nil, err
-			}
-			privateKey, ok = parsedKey.(*rsa.PrivateKey)
-			if !ok || privateKey == nil {
-				return nil, nil, errors.New("certificate must contain an RSA private key")
-			}
-		case "RSA PRIVATE KEY": // PKCS#1
-			// If we already have a key decoded, return an error
-			if privateKey != nil {
-				return nil, nil, errors.New("invalid certificate")
+			switch block.Type {
+			case "PRIVATE KEY":
+				parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+			case "RSA PRIVATE KEY":
+				parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			case "EC PRIVATE KEY":
+				parsedKey, err = x509.ParseECPrivateKey(block.Bytes)
 			}
-			parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
 			if err != nil {
 				return nil, nil, err
 			}
-			privateKey, ok = parsedKey.(*rsa.PrivateKey)
-			if !ok || privateKey == nil {
-				return nil, nil, errors.New("certificate must contain an RSA private key")
+			privateKey, err = supportedPrivateKey(parsedKey)
+			if err != nil {
+				return nil, nil, fmt.Errorf("certificate must contain an RSA, ECDSA or Ed25519 private key: %w", err)
 			}
 		}
 	}
@@ -464,6 +506,51 @@ func (c CertConfig) decodePEM(data []byte) (certificate *x509.Certificate, priva
 	return certificate, privateKey, nil
 }
 
+// supportedPrivateKey returns the key if it's of a type supported for client certificates.
+func supportedPrivateKey(key any) (crypto.PrivateKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k == nil {
+			return nil, errors.New("key is empty")
+		}
+		return k, nil
+	case *ecdsa.PrivateKey:
+		if k == nil {
+			return nil, errors.New("key is empty")
+		}
+		return k, nil
+	case ed25519.PrivateKey:
+		if len(k) == 0 {
+			return nil, errors.New("key is empty")
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// WorkloadIdentityConfig provides the options to get a bearer authorizer through workload identity federation.
+type WorkloadIdentityConfig struct {
+	ClientID      string
+	TenantID      string
+	TokenFilePath string
+	AzureCloud    *cloud.Configuration
+}
+
+// GetTokenCredential returns the azcore.TokenCredential object from workload identity.
+// The federated token file is read again every time a new token is requested, so it can be rotated.
+func (c WorkloadIdentityConfig) GetTokenCredential() (token azcore.TokenCredential, err error) {
+	opts := &azidentity.WorkloadIdentityCredentialOptions{
+		ClientID:      c.ClientID,
+		TenantID:      c.TenantID,
+		TokenFilePath: c.TokenFilePath,
+	}
+	if c.AzureCloud != nil {
+		opts.ClientOptions.Cloud = *c.AzureCloud
+	}
+	return azidentity.NewWorkloadIdentityCredential(opts)
+}
+
 // MSIConfig provides the options to get a bearer authorizer through MSI.
 type MSIConfig struct {
 	ClientID string
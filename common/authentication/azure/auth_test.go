@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/base64"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -352,3 +353,59 @@ func TestFallbackToCLI(t *testing.T) {
 		t.Skip("Skipping test as Azure CLI is not installed or logged in. This test would fall through to MSI which is not available in the test environment.")
 	}
 }
+
+func TestGetWorkloadIdentity(t *testing.T) {
+	t.Run("from metadata", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("token"), 0o600))
+
+		settings, err := NewEnvironmentSettings(
+			map[string]string{
+				"azureClientId":           fakeClientID,
+				"azureTenantId":           fakeTenantID,
+				"azureFederatedTokenFile": tokenFile,
+				"azureEnvironment":        "AzureChinaCloud",
+			},
+		)
+		require.NoError(t, err)
+
+		config, err := settings.GetWorkloadIdentity()
+		require.NoError(t, err)
+		assert.Equal(t, fakeClientID, config.ClientID)
+		assert.Equal(t, fakeTenantID, config.TenantID)
+		assert.Equal(t, tokenFile, config.TokenFilePath)
+		require.NotNil(t, config.AzureCloud)
+		assert.Equal(t, cloud.AzureChina.ActiveDirectoryAuthorityHost, config.AzureCloud.ActiveDirectoryAuthorityHost)
+
+		cred, err := config.GetTokenCredential()
+		require.NoError(t, err)
+		assert.IsType(t, &azidentity.WorkloadIdentityCredential{}, cred)
+	})
+
+	t.Run("cloud from environment when not set", func(t *testing.T) {
+		settings, err := NewEnvironmentSettings(map[string]string{})
+		require.NoError(t, err)
+
+		config, err := settings.GetWorkloadIdentity()
+		require.NoError(t, err)
+		assert.Nil(t, config.AzureCloud)
+	})
+
+	t.Run("missing token file", func(t *testing.T) {
+		// Restored when the test completes
+		t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+		os.Unsetenv("AZURE_FEDERATED_TOKEN_FILE")
+		settings, err := NewEnvironmentSettings(
+			map[string]string{
+				"azureClientId": fakeClientID,
+				"azureTenantId": fakeTenantID,
+			},
+		)
+		require.NoError(t, err)
+
+		config, err := settings.GetWorkloadIdentity()
+		require.NoError(t, err)
+		_, err = config.GetTokenCredential()
+		require.Error(t, err)
+	})
+}
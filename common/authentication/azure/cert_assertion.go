@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Validity of the client assertions signed with non-RSA keys.
+const clientAssertionValidity = 10 * time.Minute
+
+// newCertificateAssertionCredential returns a credential that authenticates with a client certificate whose private key is not RSA.
+// The Azure SDK only supports RSA keys for client certificates, so the client assertion is signed here and exchanged for tokens
+// using a client assertion credential.
+func newCertificateAssertionCredential(c CertConfig, certificate *x509.Certificate, key crypto.PrivateKey) (azcore.TokenCredential, error) {
+	alg, err := signatureAlgorithmForKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the key belongs to the certificate
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("invalid private key")
+	}
+	pub, ok := certificate.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(signer.Public()) {
+		return nil, errors.New("private key doesn't match the certificate")
+	}
+
+	azureCloud := cloud.AzurePublic
+	if c.AzureCloud != nil {
+		azureCloud = *c.AzureCloud
+	}
+	audience := strings.TrimSuffix(azureCloud.ActiveDirectoryAuthorityHost, "/") + "/" + c.TenantID + "/oauth2/v2.0/token"
+
+	//nolint:gosec
+	thumbprint := sha1.Sum(certificate.Raw)
+	headers := jws.NewHeaders()
+	err = headers.Set("x5t", base64.RawURLEncoding.EncodeToString(thumbprint[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	getAssertion := func(context.Context) (string, error) {
+		return signClientAssertion(c.ClientID, audience, alg, key, headers)
+	}
+
+	opts := &azidentity.ClientAssertionCredentialOptions{
+		ClientOptions: azcore.ClientOptions{
+			Cloud: azureCloud,
+		},
+	}
+	return azidentity.NewClientAssertionCredential(c.TenantID, c.ClientID, getAssertion, opts)
+}
+
+// signClientAssertion returns a client assertion for the application, signed with the certificate's private key.
+func signClientAssertion(clientID string, audience string, alg jwa.SignatureAlgorithm, key crypto.PrivateKey, headers jws.Headers) (string, error) {
+	now := time.Now()
+	token, err := jwt.NewBuilder().
+		Issuer(clientID).
+		Subject(clientID).
+		Audience([]string{audience}).
+		JwtID(uuid.NewString()).
+		IssuedAt(now).
+		NotBefore(now).
+		Expiration(now.Add(clientAssertionValidity)).
+		Build()
+	if err != nil {
+		return "", fmt.Errorf("failed to build the client assertion: %w", err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(alg, key, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the client assertion: %w", err)
+	}
+	return string(signed), nil
+}
+
+// signatureAlgorithmForKey returns the JWS algorithm used to sign client assertions with a non-RSA key.
+func signatureAlgorithmForKey(key crypto.PrivateKey) (jwa.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwa.ES256, nil
+		case elliptic.P384():
+			return jwa.ES384, nil
+		case elliptic.P521():
+			return jwa.ES512, nil
+		default:
+			return "", errors.New("unsupported elliptic curve for the certificate's private key")
+		}
+	case ed25519.PrivateKey:
+		return jwa.EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", key)
+	}
+}
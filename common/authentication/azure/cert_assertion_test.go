@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificatePEM returns a self-signed certificate and its key, PEM-encoded.
+func newTestCertificatePEM(t *testing.T, key crypto.Signer, keyBlock *pem.Block) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dapr"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(data, pem.EncodeToMemory(keyBlock)...)
+}
+
+func TestNonRSACertificates(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8 := func(key crypto.PrivateKey) *pem.Block {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	tests := map[string][]byte{
+		"ECDSA PKCS#8":   newTestCertificatePEM(t, ecKey, pkcs8(ecKey)),
+		"ECDSA SEC 1":    newTestCertificatePEM(t, ecKey, &pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+		"Ed25519 PKCS#8": newTestCertificatePEM(t, edKey, pkcs8(edKey)),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := CertConfig{
+				ClientID: fakeClientID,
+				TenantID: fakeTenantID,
+			}
+			cert, key, err := config.decodeCertificate(data, "")
+			require.NoError(t, err)
+			require.NotNil(t, cert)
+			require.NotNil(t, key)
+
+			cred, err := config.getTokenCredentialFromData(data)
+			require.NoError(t, err)
+			assert.IsType(t, &azidentity.ClientAssertionCredential{}, cred)
+		})
+	}
+
+	t.Run("key not matching the certificate", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		data := newTestCertificatePEM(t, ecKey, pkcs8(otherKey))
+
+		_, err = CertConfig{ClientID: fakeClientID, TenantID: fakeTenantID}.getTokenCredentialFromData(data)
+		require.Error(t, err)
+	})
+}
+
+func TestSignClientAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	alg, err := signatureAlgorithmForKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, jwa.ES384, alg)
+
+	headers := jws.NewHeaders()
+	require.NoError(t, headers.Set("x5t", "thumbprint"))
+	audience := "https://login.microsoftonline.com/" + fakeTenantID + "/oauth2/v2.0/token"
+	signed, err := signClientAssertion(fakeClientID, audience, alg, key, headers)
+	require.NoError(t, err)
+
+	token, err := jwt.ParseString(signed, jwt.WithKey(alg, key.Public()), jwt.WithAudience(audience))
+	require.NoError(t, err)
+	assert.Equal(t, fakeClientID, token.Issuer())
+	assert.Equal(t, fakeClientID, token.Subject())
+	assert.NotEmpty(t, token.JwtID())
+
+	msg, err := jws.ParseString(signed)
+	require.NoError(t, err)
+	x5t, ok := msg.Signatures()[0].ProtectedHeaders().Get("x5t")
+	require.True(t, ok)
+	assert.Equal(t, "thumbprint", x5t)
+}
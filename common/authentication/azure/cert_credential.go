@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/dapr/components-contrib/common/credentials"
+)
+
+// reloadingCertCredential is a client certificate credential that is rebuilt when the certificate file changes.
+type reloadingCertCredential struct {
+	config CertConfig
+	file   *credentials.File
+
+	lock    sync.Mutex
+	cred    azcore.TokenCredential
+	version uint64
+}
+
+func newReloadingCertCredential(config CertConfig, file *credentials.File) (*reloadingCertCredential, error) {
+	data, version, _ := file.Load()
+	cred, err := config.getTokenCredentialFromData(data)
+	if err != nil {
+		return nil, err
+	}
+	return &reloadingCertCredential{
+		config:  config,
+		file:    file,
+		cred:    cred,
+		version: version,
+	}, nil
+}
+
+// GetToken implements azcore.TokenCredential.
+func (c *reloadingCertCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return c.credential().GetToken(ctx, opts)
+}
+
+// credential returns the credential for the current certificate.
+// If the certificate can't be read or decoded after a change, the previous one keeps being used.
+func (c *reloadingCertCredential) credential() azcore.TokenCredential {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, version, err := c.file.Load()
+	if err != nil || version == c.version {
+		return c.cred
+	}
+	c.version = version
+	cred, err := c.config.getTokenCredentialFromData(data)
+	if err != nil {
+		return c.cred
+	}
+	c.cred = cred
+	return c.cred
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadingCertCredential(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "cert.pfx")
+	require.NoError(t, os.WriteFile(certFile, getTestCert(), 0o600))
+
+	cred, err := CertConfig{
+		ClientID:        fakeClientID,
+		TenantID:        fakeTenantID,
+		CertificatePath: certFile,
+	}.GetTokenCredential()
+	require.NoError(t, err)
+	require.IsType(t, &reloadingCertCredential{}, cred)
+	rc := cred.(*reloadingCertCredential)
+	initial := rc.credential()
+
+	// An invalid certificate is ignored and the previous one keeps being used
+	require.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0o600))
+	time.Sleep(1100 * time.Millisecond)
+	assert.Same(t, initial, rc.credential())
+
+	// A valid certificate replaces the credential
+	require.NoError(t, os.WriteFile(certFile, getTestCert(), 0o600))
+	time.Sleep(1100 * time.Millisecond)
+	assert.NotSame(t, initial, rc.credential())
+}
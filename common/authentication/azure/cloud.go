@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// Clouds registered with RegisterCloud, keyed by their lowercase name.
+var customClouds = struct { //nolint:gochecknoglobals
+	lock   sync.RWMutex
+	clouds map[string]*cloud.Configuration
+}{
+	clouds: map[string]*cloud.Configuration{},
+}
+
+// RegisterCloud makes a custom cloud configuration available to all Azure components, which can use it by setting the azureEnvironment metadata property to its name.
+// This allows targeting clouds such as Azure Stack Hub or air-gapped private clouds, whose endpoints are not known in advance.
+func RegisterCloud(name string, config cloud.Configuration) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return errors.New("cloud name is empty")
+	}
+	if config.ActiveDirectoryAuthorityHost == "" {
+		return errors.New("cloud configuration must include the authority host")
+	}
+	if _, err := lookupWellKnownCloud(name); err == nil {
+		return fmt.Errorf("cloud name '%s' is reserved", name)
+	}
+
+	// Copy the services so changes made by the caller after registering the cloud are not visible
+	config.Services = maps.Clone(config.Services)
+
+	customClouds.lock.Lock()
+	defer customClouds.lock.Unlock()
+	customClouds.clouds[name] = &config
+	return nil
+}
+
+// lookupCloud returns the configuration of a well-known or registered cloud.
+func lookupCloud(name string) (*cloud.Configuration, error) {
+	name = strings.ToLower(name)
+	if c, err := lookupWellKnownCloud(name); err == nil {
+		return c, nil
+	}
+
+	customClouds.lock.RLock()
+	defer customClouds.lock.RUnlock()
+	if c, ok := customClouds.clouds[name]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("invalid Azure cloud: %v", name)
+}
+
+func lookupWellKnownCloud(name string) (*cloud.Configuration, error) {
+	switch name {
+	case "", "azurepubliccloud", "azurepublic": // Default value if name is empty
+		return &cloud.AzurePublic, nil
+	case "azurechinacloud", "azurechina":
+		return &cloud.AzureChina, nil
+	case "azureusgovernmentcloud", "azureusgovernment":
+		return &cloud.AzureGovernment, nil
+	default:
+		return nil, fmt.Errorf("invalid Azure cloud: %v", name)
+	}
+}
+
+// hasCustomEndpoints returns true if the metadata contains endpoints that override the ones of the cloud.
+func (s EnvironmentSettings) hasCustomEndpoints() bool {
+	for _, k := range []string{"AuthorityHost", "ResourceManagerEndpoint", "ResourceManagerAudience"} {
+		if v, _ := s.GetEnvironment(k); v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// customCloud returns a cloud configuration with the endpoints set in the metadata.
+// If an Azure environment is set too, it's used as base for the endpoints that are not set, otherwise the configuration contains only the endpoints from the metadata.
+func (s EnvironmentSettings) customCloud(envName string) (*cloud.Configuration, error) {
+	config := cloud.Configuration{}
+	if envName != "" {
+		base, err := lookupCloud(envName)
+		if err != nil {
+			return nil, err
+		}
+		config.ActiveDirectoryAuthorityHost = base.ActiveDirectoryAuthorityHost
+		config.Services = maps.Clone(base.Services)
+	}
+	if config.Services == nil {
+		config.Services = map[cloud.ServiceName]cloud.ServiceConfiguration{}
+	}
+
+	if authorityHost, _ := s.GetEnvironment("AuthorityHost"); authorityHost != "" {
+		err := validateEndpoint(authorityHost)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure authority host: %w", err)
+		}
+		config.ActiveDirectoryAuthorityHost = authorityHost
+	}
+	if config.ActiveDirectoryAuthorityHost == "" {
+		return nil, errors.New("the Azure authority host is required when using custom endpoints")
+	}
+
+	rm := config.Services[cloud.ResourceManager]
+	if endpoint, _ := s.GetEnvironment("ResourceManagerEndpoint"); endpoint != "" {
+		err := validateEndpoint(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure Resource Manager endpoint: %w", err)
+		}
+		rm.Endpoint = endpoint
+		// Unless set explicitly, the audience is the same as the endpoint
+		rm.Audience = endpoint
+	}
+	if audience, _ := s.GetEnvironment("ResourceManagerAudience"); audience != "" {
+		rm.Audience = audience
+	}
+	if rm.Endpoint != "" || rm.Audience != "" {
+		config.Services[cloud.ResourceManager] = rm
+	}
+
+	return &config, nil
+}
+
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an absolute URL with https scheme")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomCloud(t *testing.T) {
+	t.Run("custom endpoints", func(t *testing.T) {
+		settings, err := NewEnvironmentSettings(map[string]string{
+			"azureAuthorityHost":           "https://login.contoso.local/",
+			"azureResourceManagerEndpoint": "https://management.contoso.local/",
+			"azureStorageEndpointSuffix":   "contoso.local",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, settings.Cloud)
+		assert.Equal(t, "https://login.contoso.local/", settings.Cloud.ActiveDirectoryAuthorityHost)
+		rm := settings.Cloud.Services[cloud.ResourceManager]
+		assert.Equal(t, "https://management.contoso.local/", rm.Endpoint)
+		assert.Equal(t, "https://management.contoso.local/", rm.Audience)
+
+		suffix, err := settings.GetEndpointSuffix(ServiceAzureStorage)
+		require.NoError(t, err)
+		assert.Equal(t, "contoso.local", suffix)
+
+		// Key Vault suffix is not configured and can't be inferred
+		_, err = settings.GetEndpointSuffix(ServiceAzureKeyVault)
+		require.ErrorContains(t, err, "azureKeyVaultEndpointSuffix")
+
+		// Workload identity uses the custom cloud too
+		wi, err := settings.GetWorkloadIdentity()
+		require.NoError(t, err)
+		require.NotNil(t, wi.AzureCloud)
+		assert.Equal(t, "https://login.contoso.local/", wi.AzureCloud.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("custom endpoints based on a well-known cloud", func(t *testing.T) {
+		settings, err := NewEnvironmentSettings(map[string]string{
+			"azureEnvironment":             "AzureChinaCloud",
+			"azureResourceManagerEndpoint": "https://management.contoso.local/",
+			"azureResourceManagerAudience": "https://management.core.contoso.local/",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, cloud.AzureChina.ActiveDirectoryAuthorityHost, settings.Cloud.ActiveDirectoryAuthorityHost)
+		rm := settings.Cloud.Services[cloud.ResourceManager]
+		assert.Equal(t, "https://management.contoso.local/", rm.Endpoint)
+		assert.Equal(t, "https://management.core.contoso.local/", rm.Audience)
+		assert.Equal(t, "vault.azure.cn", settings.EndpointSuffix(ServiceAzureKeyVault))
+
+		// The base cloud is not modified
+		assert.NotEqual(t, "https://management.contoso.local/", cloud.AzureChina.Services[cloud.ResourceManager].Endpoint)
+	})
+
+	t.Run("invalid endpoints", func(t *testing.T) {
+		_, err := NewEnvironmentSettings(map[string]string{
+			"azureAuthorityHost": "http://login.contoso.local/",
+		})
+		require.Error(t, err)
+
+		_, err = NewEnvironmentSettings(map[string]string{
+			"azureResourceManagerEndpoint": "https://management.contoso.local/",
+		})
+		require.ErrorContains(t, err, "authority host is required")
+	})
+
+	t.Run("registered cloud", func(t *testing.T) {
+		err := RegisterCloud("AzureChina", cloud.Configuration{ActiveDirectoryAuthorityHost: "https://login.contoso.local/"})
+		require.Error(t, err)
+		err = RegisterCloud("ContosoStack", cloud.Configuration{})
+		require.Error(t, err)
+
+		err = RegisterCloud("ContosoStack", cloud.Configuration{
+			ActiveDirectoryAuthorityHost: "https://login.contoso.local/",
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				ServiceAzureSQL: {Audience: "https://database.contoso.local"},
+			},
+		})
+		require.NoError(t, err)
+
+		settings, err := NewEnvironmentSettings(map[string]string{
+			"azureEnvironment":            "contosostack",
+			"azureKeyVaultEndpointSuffix": "vault.contoso.local",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://login.contoso.local/", settings.Cloud.ActiveDirectoryAuthorityHost)
+		assert.Equal(t, "https://database.contoso.local", settings.Cloud.Services[ServiceAzureSQL].Audience)
+		assert.Equal(t, "vault.contoso.local", settings.EndpointSuffix(ServiceAzureKeyVault))
+		_, err = settings.GetEndpointSuffix(ServiceAzureStorage)
+		require.Error(t, err)
+	})
+}
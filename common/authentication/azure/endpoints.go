@@ -14,6 +14,9 @@ limitations under the License.
 package azure
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 )
 
@@ -24,34 +27,68 @@ var (
 	ServiceAzureKeyVault azureService = "azurekeyvault"
 )
 
+// Metadata keys for the endpoint suffix of each service.
+var endpointSuffixKeys = map[azureService]string{ //nolint:gochecknoglobals
+	ServiceAzureStorage:  "StorageEndpointSuffix",
+	ServiceAzureKeyVault: "KeyVaultEndpointSuffix",
+}
+
 // EndpointSuffix returns the suffix for the endpoint depending on the cloud used.
+// It panics if the suffix is not known, which can happen with custom clouds: in that case, use GetEndpointSuffix.
 func (s EnvironmentSettings) EndpointSuffix(service azureService) string {
-	// Note we are panicking in case the service or cloud doesn't exist, because that should be a development-time error only.
-	switch s.Cloud {
-	case nil, &cloud.AzurePublic:
+	suffix, err := s.GetEndpointSuffix(service)
+	if err != nil {
+		panic(err.Error())
+	}
+	return suffix
+}
+
+// GetEndpointSuffix returns the suffix for the endpoint depending on the cloud used.
+// A suffix set in the metadata takes precedence, and it's required for custom clouds that are not based on a well-known one.
+func (s EnvironmentSettings) GetEndpointSuffix(service azureService) (string, error) {
+	key, ok := endpointSuffixKeys[service]
+	if !ok {
+		// Note we are panicking in case the service doesn't exist, because that should be a development-time error only.
+		panic("Invalid service: " + service)
+	}
+	if suffix, _ := s.GetEnvironment(key); suffix != "" {
+		return suffix, nil
+	}
+
+	azureCloud := s.Cloud
+	if azureCloud == nil {
+		azureCloud = &cloud.AzurePublic
+	}
+	if s.hasCustomEndpoints() {
+		// Custom endpoints based on a well-known cloud keep its suffixes
+		azureCloud = nil
+		if envName, _ := s.GetEnvironment("AzureEnvironment"); envName != "" {
+			azureCloud, _ = lookupWellKnownCloud(strings.ToLower(envName))
+		}
+	}
+
+	switch azureCloud {
+	case &cloud.AzurePublic:
 		switch service {
 		case ServiceAzureStorage:
-			return "core.windows.net"
+			return "core.windows.net", nil
 		case ServiceAzureKeyVault:
-			return "vault.azure.net"
+			return "vault.azure.net", nil
 		}
-		panic("Invalid service: " + service)
 	case &cloud.AzureChina:
 		switch service {
 		case ServiceAzureStorage:
-			return "core.chinacloudapi.cn"
+			return "core.chinacloudapi.cn", nil
 		case ServiceAzureKeyVault:
-			return "vault.azure.cn"
+			return "vault.azure.cn", nil
 		}
-		panic("Invalid service: " + service)
 	case &cloud.AzureGovernment:
 		switch service {
 		case ServiceAzureStorage:
-			return "core.usgovcloudapi.net"
+			return "core.usgovcloudapi.net", nil
 		case ServiceAzureKeyVault:
-			return "vault.usgovcloudapi.net"
+			return "vault.usgovcloudapi.net", nil
 		}
-		panic("Invalid service: " + service)
 	}
-	panic("Invalid cloud environment")
+	return "", fmt.Errorf("the endpoint suffix for %s is not known for the custom Azure cloud: set the metadata property %s", service, MetadataKeys[key][0])
 }
@@ -32,9 +32,21 @@ var MetadataKeys = map[string][]string{ //nolint:gochecknoglobals
 	// Tenant ID for the Service Principal
 	// The "tenantId" alias is supported for backwards-compatibility as it's used by some components, but should be considered deprecated
 	"TenantID": {"azureTenantId", "spnTenantId", "tenantId"},
+	// Path to the file containing the federated token used with workload identity
+	"FederatedTokenFile": {"azureFederatedTokenFile"},
 	// Identifier for the Azure environment
-	// Allowed values (case-insensitive): AzurePublicCloud/AzurePublic, AzureChinaCloud/AzureChina, AzureUSGovernmentCloud/AzureUSGovernment
+	// Allowed values (case-insensitive): AzurePublicCloud/AzurePublic, AzureChinaCloud/AzureChina, AzureUSGovernmentCloud/AzureUSGovernment, or the name of a cloud added with RegisterCloud
 	"AzureEnvironment": {"azureEnvironment", "azureCloud"},
+	// Authority host (Microsoft Entra ID endpoint) for custom clouds, such as Azure Stack Hub or private clouds
+	"AuthorityHost": {"azureAuthorityHost"},
+	// Azure Resource Manager endpoint for custom clouds
+	"ResourceManagerEndpoint": {"azureResourceManagerEndpoint"},
+	// Audience of the Azure Resource Manager for custom clouds; defaults to the endpoint
+	"ResourceManagerAudience": {"azureResourceManagerAudience"},
+	// DNS suffix of the storage endpoints for custom clouds
+	"StorageEndpointSuffix": {"azureStorageEndpointSuffix"},
+	// DNS suffix of the Key Vault endpoints for custom clouds
+	"KeyVaultEndpointSuffix": {"azureKeyVaultEndpointSuffix"},
 	// Identifier for the Azure authentication methods to try (in order), comma-separated
 	// Allowed values (case-insensitive): ClientCredentials, creds, ClientCertificate, cert, WorkloadIdentity, wi, ManagedIdentity, mi, CommandLineInterface, cli, None
 	"AzureAuthMethods": {"azureAuthMethods", "azureADAuthMethods", "entraIDAuthMethods", "microsoftEntraIDAuthMethods"},
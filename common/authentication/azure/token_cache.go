@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Tokens in the shared cache are refreshed in background when they are this close to expiring.
+	tokenRefreshWindow = 5 * time.Minute
+	// Timeout for refreshing a token in background.
+	tokenRefreshTimeout = time.Minute
+)
+
+// Metadata keys that determine the identity used to authenticate, and so which components can share their tokens.
+var tokenCacheMetadataKeys = []string{ //nolint:gochecknoglobals
+	"Certificate", "CertificateFile", "CertificatePassword",
+	"ClientID", "ClientSecret", "TenantID",
+	"FederatedTokenFile", "AzureEnvironment", "AuthorityHost", "AzureAuthMethods",
+}
+
+// TokenAcquiredFn is invoked every time the shared token cache requests a new token, for example to record metrics.
+// err is non-nil if the token could not be acquired.
+type TokenAcquiredFn func(ctx context.Context, duration time.Duration, err error)
+
+// Process-wide cache of credentials, keyed by the identity they authenticate as.
+var sharedTokenCache = struct { //nolint:gochecknoglobals
+	lock  sync.Mutex
+	creds map[string]*sharedTokenCredential
+}{
+	creds: map[string]*sharedTokenCredential{},
+}
+
+// getSharedTokenCredential returns a credential backed by the process-wide token cache.
+// The underlying credential is created once per identity and shared by all components using it.
+func (s EnvironmentSettings) getSharedTokenCredential() (azcore.TokenCredential, error) {
+	key := s.tokenCacheKey()
+
+	sharedTokenCache.lock.Lock()
+	defer sharedTokenCache.lock.Unlock()
+
+	shared, ok := sharedTokenCache.creds[key]
+	if !ok {
+		cred, err := s.newTokenCredential()
+		if err != nil {
+			return nil, err
+		}
+		shared = newSharedTokenCredential(cred)
+		sharedTokenCache.creds[key] = shared
+	}
+
+	return &cachedTokenCredential{
+		shared:          shared,
+		onTokenAcquired: s.OnTokenAcquired,
+	}, nil
+}
+
+// tokenCacheKey returns the key of the identity configured in the metadata, without including any secret in clear text.
+func (s EnvironmentSettings) tokenCacheKey() string {
+	h := sha256.New()
+	for _, k := range tokenCacheMetadataKeys {
+		v, _ := s.GetEnvironment(k)
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.Itoa(len(v))))
+		h.Write([]byte{0})
+		h.Write([]byte(v))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedTokenCredential is the azcore.TokenCredential returned to each component using the shared token cache.
+type cachedTokenCredential struct {
+	shared          *sharedTokenCredential
+	onTokenAcquired TokenAcquiredFn
+}
+
+// GetToken returns a token from the shared cache, requesting a new one if needed.
+func (c *cachedTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return c.shared.getToken(ctx, opts, c.onTokenAcquired)
+}
+
+// sharedTokenCredential caches the tokens of a credential, refreshing them before they expire.
+type sharedTokenCredential struct {
+	cred   azcore.TokenCredential
+	lock   sync.Mutex
+	tokens map[string]*cachedToken
+	group  singleflight.Group
+	now    func() time.Time
+}
+
+type cachedToken struct {
+	token      azcore.AccessToken
+	refreshing bool
+}
+
+func newSharedTokenCredential(cred azcore.TokenCredential) *sharedTokenCredential {
+	return &sharedTokenCredential{
+		cred:   cred,
+		tokens: map[string]*cachedToken{},
+		now:    time.Now,
+	}
+}
+
+func (c *sharedTokenCredential) getToken(ctx context.Context, opts policy.TokenRequestOptions, onTokenAcquired TokenAcquiredFn) (azcore.AccessToken, error) {
+	// Tokens requested with claims are a response to a claims challenge, so the cached ones are not valid
+	if opts.Claims != "" {
+		return c.requestToken(ctx, opts, onTokenAcquired)
+	}
+
+	key := tokenRequestKey(opts)
+	now := c.now()
+
+	c.lock.Lock()
+	cached, ok := c.tokens[key]
+	if ok && now.Before(cached.token.ExpiresOn) {
+		// Refresh the token in background if it's about to expire, returning the current one in the meanwhile
+		if !cached.refreshing && cached.token.ExpiresOn.Sub(now) <= tokenRefreshWindow {
+			cached.refreshing = true
+			go c.refresh(key, opts, onTokenAcquired)
+		}
+		token := cached.token
+		c.lock.Unlock()
+		return token, nil
+	}
+	c.lock.Unlock()
+
+	return c.acquire(ctx, key, opts, onTokenAcquired)
+}
+
+func (c *sharedTokenCredential) refresh(key string, opts policy.TokenRequestOptions, onTokenAcquired TokenAcquiredFn) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	// Errors are ignored here: the current token remains valid until it expires, and the next request will try again
+	_, _ = c.acquire(ctx, key, opts, onTokenAcquired)
+}
+
+// acquire requests a new token and stores it in the cache.
+// Concurrent requests for the same token are coalesced into a single one.
+func (c *sharedTokenCredential) acquire(ctx context.Context, key string, opts policy.TokenRequestOptions, onTokenAcquired TokenAcquiredFn) (azcore.AccessToken, error) {
+	resCh := c.group.DoChan(key, func() (any, error) {
+		token, err := c.requestToken(ctx, opts, onTokenAcquired)
+
+		c.lock.Lock()
+		if err == nil {
+			c.tokens[key] = &cachedToken{token: token}
+		} else if cached, ok := c.tokens[key]; ok {
+			cached.refreshing = false
+		}
+		c.lock.Unlock()
+
+		return token, err
+	})
+
+	select {
+	case res := <-resCh:
+		if res.Err != nil {
+			return azcore.AccessToken{}, res.Err
+		}
+		return res.Val.(azcore.AccessToken), nil
+	case <-ctx.Done():
+		return azcore.AccessToken{}, ctx.Err()
+	}
+}
+
+func (c *sharedTokenCredential) requestToken(ctx context.Context, opts policy.TokenRequestOptions, onTokenAcquired TokenAcquiredFn) (azcore.AccessToken, error) {
+	start := time.Now()
+	token, err := c.cred.GetToken(ctx, opts)
+	if onTokenAcquired != nil {
+		onTokenAcquired(ctx, time.Since(start), err)
+	}
+	return token, err
+}
+
+// tokenRequestKey returns the key of a token in the cache.
+func tokenRequestKey(opts policy.TokenRequestOptions) string {
+	scopes := slices.Clone(opts.Scopes)
+	slices.Sort(scopes)
+	return strings.Join(scopes, " ") + "|" + opts.TenantID + "|" + strconv.FormatBool(opts.EnableCAE)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenCredential returns tokens valid for one hour, counting the requests.
+type fakeTokenCredential struct {
+	calls atomic.Int32
+	err   error
+	now   func() time.Time
+	block chan struct{}
+}
+
+func (f *fakeTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	n := f.calls.Add(1)
+	if f.block != nil {
+		<-f.block
+	}
+	if f.err != nil {
+		return azcore.AccessToken{}, f.err
+	}
+	return azcore.AccessToken{
+		Token:     "token" + string(rune('0'+n)),
+		ExpiresOn: f.now().Add(time.Hour),
+	}, nil
+}
+
+func TestSharedTokenCredential(t *testing.T) {
+	opts := policy.TokenRequestOptions{Scopes: []string{"https://storage.azure.com/.default"}}
+
+	t.Run("caches tokens", func(t *testing.T) {
+		fake := &fakeTokenCredential{now: time.Now}
+		shared := newSharedTokenCredential(fake)
+
+		tk, err := shared.getToken(context.Background(), opts, nil)
+		require.NoError(t, err)
+		tk2, err := shared.getToken(context.Background(), opts, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tk, tk2)
+		assert.Equal(t, int32(1), fake.calls.Load())
+
+		// Different scopes get different tokens
+		_, err = shared.getToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"other"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), fake.calls.Load())
+
+		// Requests with claims bypass the cache
+		_, err = shared.getToken(context.Background(), policy.TokenRequestOptions{Scopes: opts.Scopes, Claims: "claims"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), fake.calls.Load())
+	})
+
+	t.Run("refreshes tokens before expiring", func(t *testing.T) {
+		var now atomic.Pointer[time.Time]
+		start := time.Now()
+		now.Store(&start)
+		nowFn := func() time.Time { return *now.Load() }
+
+		fake := &fakeTokenCredential{now: nowFn}
+		shared := newSharedTokenCredential(fake)
+		shared.now = nowFn
+
+		tk, err := shared.getToken(context.Background(), opts, nil)
+		require.NoError(t, err)
+
+		// Within the refresh window the current token is returned while a new one is requested in background
+		later := start.Add(time.Hour - tokenRefreshWindow + time.Second)
+		now.Store(&later)
+		tk2, err := shared.getToken(context.Background(), opts, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tk, tk2)
+
+		assert.Eventually(t, func() bool {
+			tk3, err := shared.getToken(context.Background(), opts, nil)
+			return err == nil && tk3.Token != tk.Token
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), fake.calls.Load())
+
+		// Expired tokens are requested again synchronously
+		expired := later.Add(2 * time.Hour)
+		now.Store(&expired)
+		tk4, err := shared.getToken(context.Background(), opts, nil)
+		require.NoError(t, err)
+		assert.True(t, tk4.ExpiresOn.After(expired))
+		assert.Equal(t, int32(3), fake.calls.Load())
+	})
+
+	t.Run("coalesces concurrent requests", func(t *testing.T) {
+		fake := &fakeTokenCredential{now: time.Now, block: make(chan struct{})}
+		shared := newSharedTokenCredential(fake)
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := shared.getToken(context.Background(), opts, nil)
+				assert.NoError(t, err)
+			}()
+		}
+		assert.Eventually(t, func() bool { return fake.calls.Load() == 1 }, time.Second, 10*time.Millisecond)
+		close(fake.block)
+		wg.Wait()
+		assert.Equal(t, int32(1), fake.calls.Load())
+	})
+
+	t.Run("invokes hook on failures", func(t *testing.T) {
+		fake := &fakeTokenCredential{now: time.Now, err: errors.New("simulated")}
+		shared := newSharedTokenCredential(fake)
+
+		var hookErr error
+		_, err := shared.getToken(context.Background(), opts, func(ctx context.Context, duration time.Duration, err error) {
+			hookErr = err
+		})
+		require.Error(t, err)
+		require.ErrorIs(t, hookErr, fake.err)
+	})
+}
+
+func TestGetSharedTokenCredential(t *testing.T) {
+	t.Setenv("MSI_ENDPOINT", "test")
+
+	newSettings := func(clientID string) EnvironmentSettings {
+		settings, err := NewEnvironmentSettings(map[string]string{
+			"azureClientId":    clientID,
+			"azureAuthMethods": "mi",
+		})
+		require.NoError(t, err)
+		settings.SharedTokenCache = true
+		return settings
+	}
+
+	cred1, err := newSettings(fakeClientID).GetTokenCredential()
+	require.NoError(t, err)
+	cred2, err := newSettings(fakeClientID).GetTokenCredential()
+	require.NoError(t, err)
+	cred3, err := newSettings(fakeTenantID).GetTokenCredential()
+	require.NoError(t, err)
+
+	require.IsType(t, &cachedTokenCredential{}, cred1)
+	assert.Same(t, cred1.(*cachedTokenCredential).shared, cred2.(*cachedTokenCredential).shared)
+	assert.NotSame(t, cred1.(*cachedTokenCredential).shared, cred3.(*cachedTokenCredential).shared)
+}
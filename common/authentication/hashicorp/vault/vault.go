@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/net/http2"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// DefaultAddress is the address of the Vault server used when none is configured.
+	DefaultAddress = "https://127.0.0.1:8200"
+
+	// TokenHeader is the name of the header containing the Vault token.
+	TokenHeader = "X-Vault-Token"
+	// RequestHeader is the name of the header Vault requires on requests, as protection against SSRF.
+	RequestHeader = "X-Vault-Request"
+)
+
+// TLSConfig is the TLS configuration to interact with HashiCorp Vault.
+type TLSConfig struct {
+	CAPem      string
+	CACert     string
+	CAPath     string
+	SkipVerify bool
+	ServerName string
+}
+
+// ReadToken returns the token to authenticate with Vault.
+// Exactly one of token and tokenMountPath must be set; in the latter case, the token is read from the file.
+func ReadToken(token string, tokenMountPath string) (string, error) {
+	// Test that at least one of them are set if not return error
+	if token == "" && tokenMountPath == "" {
+		return "", errors.New("token mount path and token not set")
+	}
+
+	// Test that both are not set. If so return error
+	if token != "" && tokenMountPath != "" {
+		return "", errors.New("token mount path and token both set")
+	}
+
+	if token != "" {
+		return token, nil
+	}
+
+	data, err := os.ReadFile(tokenMountPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read vault token from mount path %s err: %s", tokenMountPath, err)
+	}
+
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// NewHTTPClient returns a HTTP client to interact with Vault, using the TLS and proxy configuration.
+func NewHTTPClient(config TLSConfig, proxyConfig httputils.ProxyMetadata, log logger.Logger) (*http.Client, error) {
+	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.SkipVerify {
+		log.Infof("hashicorp vault: you are using 'skipVerify' to skip server config verify which is unsafe!")
+	}
+
+	tlsClientConfig.InsecureSkipVerify = config.SkipVerify
+	if !config.SkipVerify {
+		rootCAPools, err := getRootCAsPools(config.CAPem, config.CAPath, config.CACert)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsClientConfig.RootCAs = rootCAPools
+
+		if config.ServerName != "" {
+			tlsClientConfig.ServerName = config.ServerName
+		}
+	}
+
+	proxy, err := proxyConfig.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup http transport
+	transport := &http.Transport{
+		TLSClientConfig: tlsClientConfig,
+		Proxy:           proxy,
+	}
+
+	// Configure http2 client
+	err = http2.ConfigureTransport(transport)
+	if err != nil {
+		return nil, errors.New("failed to configure http2")
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// getRootCAsPools returns root CAs when you give it CA Pem file, CA path, and CA Certificate. Default is system certificates.
+func getRootCAsPools(vaultCAPem string, vaultCAPath string, vaultCACert string) (*x509.CertPool, error) {
+	if vaultCAPem != "" {
+		certPool := x509.NewCertPool()
+		cert := []byte(vaultCAPem)
+		if ok := certPool.AppendCertsFromPEM(cert); !ok {
+			return nil, errors.New("couldn't read PEM")
+		}
+
+		return certPool, nil
+	}
+
+	if vaultCAPath != "" {
+		certPool := x509.NewCertPool()
+		if err := readCertificateFolder(certPool, vaultCAPath); err != nil {
+			return nil, err
+		}
+
+		return certPool, nil
+	}
+
+	if vaultCACert != "" {
+		certPool := x509.NewCertPool()
+		if err := readCertificateFile(certPool, vaultCACert); err != nil {
+			return nil, err
+		}
+
+		return certPool, nil
+	}
+
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read system certs: %s", err)
+	}
+
+	return certPool, nil
+}
+
+// readCertificateFile reads the certificate at given path.
+func readCertificateFile(certPool *x509.CertPool, path string) error {
+	// Read certificate file
+	pemFile, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read CA file from disk: %s", err)
+	}
+
+	if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
+		return errors.New("couldn't read PEM")
+	}
+
+	return nil
+}
+
+// readCertificateFolder scans a folder for certificates.
+func readCertificateFolder(certPool *x509.CertPool, path string) error {
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		}
+
+		return readCertificateFile(certPool, p)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't read certificates at %s: %s", path, err)
+	}
+
+	return nil
+}
@@ -21,10 +21,13 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/dapr/components-contrib/common/authentication/aws"
 	"github.com/dapr/components-contrib/common/authentication/azure"
+	"github.com/dapr/components-contrib/common/credentials"
+	"github.com/dapr/components-contrib/common/tlsconfig"
 	"github.com/dapr/components-contrib/metadata"
 )
 
@@ -36,6 +39,10 @@ type PostgresAuthMetadata struct {
 	UseAzureAD            bool          `mapstructure:"useAzureAD"`
 	UseAWSIAM             bool          `mapstructure:"useAWSIAM"`
 	QueryExecMode         string        `mapstructure:"queryExecMode"`
+	PasswordFile          string        `mapstructure:"passwordFile"`
+
+	// TLS options, which override the certificates set with "sslrootcert", "sslcert" and "sslkey" in the connection string
+	tlsconfig.Metadata `mapstructure:",squash"`
 
 	azureEnv azure.EnvironmentSettings
 	awsEnv   aws.EnvironmentSettings
@@ -49,6 +56,8 @@ func (m *PostgresAuthMetadata) Reset() {
 	m.UseAzureAD = false
 	m.UseAWSIAM = false
 	m.QueryExecMode = ""
+	m.PasswordFile = ""
+	m.Metadata = tlsconfig.Metadata{}
 }
 
 type InitWithMetadataOpts struct {
@@ -127,6 +136,11 @@ func (m *PostgresAuthMetadata) GetPgxPoolConfig() (*pgxpool.Config, error) {
 		}
 	}
 
+	err = m.setTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
 	switch {
 	case m.UseAzureAD:
 		// Use Azure AD
@@ -175,7 +189,71 @@ func (m *PostgresAuthMetadata) GetPgxPoolConfig() (*pgxpool.Config, error) {
 			err = fmt.Errorf("failed to initiate AWS IAM authentication rotation: %w", err)
 			return nil, err
 		}
+	case m.PasswordFile != "":
+		// Read the password from the file, such as a mounted secret
+		passwordFile, errFile := credentials.NewFile(m.PasswordFile)
+		if errFile != nil {
+			return nil, errFile
+		}
+
+		// The file is checked every time a new connection is established, so the password can be rotated
+		// without restarting the component; existing connections are not affected
+		config.BeforeConnect = func(ctx context.Context, cc *pgx.ConnConfig) error {
+			password, _, errLoad := passwordFile.LoadString()
+			if errLoad != nil && password == "" {
+				return errLoad
+			}
+			cc.Password = password
+			return nil
+		}
 	}
 
 	return config, nil
 }
+
+// setTLSConfig replaces the TLS configuration that pgx builds from the connection string with the one from the TLS options, if any are set.
+// The "sslmode" in the connection string still determines whether TLS is used and, unless "caCertFile" is set, how the server certificate is verified.
+// When "caCertFile" is set, the server certificate and host name are always verified.
+func (m *PostgresAuthMetadata) setTLSConfig(config *pgxpool.Config) error {
+	if m.Metadata == (tlsconfig.Metadata{}) {
+		return nil
+	}
+
+	tlsConfig, err := tlsconfig.Config{Metadata: m.Metadata}.Build()
+	if err != nil {
+		return err
+	}
+
+	// Fallbacks are used when connecting to multiple hosts, or with sslmode "prefer" and "allow"
+	connConfigs := make([]*pgconn.FallbackConfig, 0, len(config.ConnConfig.Fallbacks)+1)
+	connConfigs = append(connConfigs, &pgconn.FallbackConfig{
+		Host:      config.ConnConfig.Host,
+		TLSConfig: config.ConnConfig.TLSConfig,
+	})
+	connConfigs = append(connConfigs, config.ConnConfig.Fallbacks...)
+
+	found := false
+	for _, cc := range connConfigs {
+		if cc.TLSConfig == nil {
+			continue
+		}
+		found = true
+
+		hostConfig := tlsConfig.Clone()
+		if hostConfig.ServerName == "" {
+			hostConfig.ServerName = cc.Host
+		}
+		if m.CACertFile == "" {
+			hostConfig.InsecureSkipVerify = cc.TLSConfig.InsecureSkipVerify //nolint:gosec
+			hostConfig.RootCAs = cc.TLSConfig.RootCAs
+			hostConfig.VerifyPeerCertificate = cc.TLSConfig.VerifyPeerCertificate
+		}
+		cc.TLSConfig = hostConfig
+	}
+	if !found {
+		return errors.New("TLS options are set but TLS is disabled by the 'sslmode' in the connection string")
+	}
+
+	config.ConnConfig.TLSConfig = connConfigs[0].TLSConfig
+	return nil
+}
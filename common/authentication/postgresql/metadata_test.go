@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/common/tlsconfig"
+)
+
+func TestGetPgxPoolConfigTLS(t *testing.T) {
+	getConfig := func(t *testing.T, connString string, tlsMd tlsconfig.Metadata) (*tls.Config, []*tls.Config, error) {
+		t.Helper()
+		m := &PostgresAuthMetadata{
+			ConnectionString: connString,
+			Metadata:         tlsMd,
+		}
+		config, err := m.GetPgxPoolConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		fallbacks := make([]*tls.Config, len(config.ConnConfig.Fallbacks))
+		for i, fb := range config.ConnConfig.Fallbacks {
+			fallbacks[i] = fb.TLSConfig
+		}
+		return config.ConnConfig.TLSConfig, fallbacks, nil
+	}
+
+	t.Run("no TLS options keep the config from the connection string", func(t *testing.T) {
+		tlsConfig, _, err := getConfig(t, "host=db sslmode=require", tlsconfig.Metadata{})
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Zero(t, tlsConfig.MinVersion)
+	})
+
+	t.Run("sslmode require", func(t *testing.T) {
+		tlsConfig, _, err := getConfig(t, "host=db sslmode=require", tlsconfig.Metadata{MinVersion: "1.3"})
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Equal(t, "db", tlsConfig.ServerName)
+	})
+
+	t.Run("sslmode verify-full", func(t *testing.T) {
+		tlsConfig, _, err := getConfig(t, "host=db sslmode=verify-full", tlsconfig.Metadata{MinVersion: "1.3", ServerName: "db.example.com"})
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		assert.False(t, tlsConfig.InsecureSkipVerify)
+		assert.Equal(t, "db.example.com", tlsConfig.ServerName)
+	})
+
+	t.Run("sslmode prefer keeps the plaintext fallback", func(t *testing.T) {
+		tlsConfig, fallbacks, err := getConfig(t, "host=db sslmode=prefer", tlsconfig.Metadata{MinVersion: "1.3"})
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		require.Len(t, fallbacks, 1)
+		assert.Nil(t, fallbacks[0])
+	})
+
+	t.Run("multiple hosts", func(t *testing.T) {
+		tlsConfig, fallbacks, err := getConfig(t, "postgres://db1,db2/test?sslmode=verify-full", tlsconfig.Metadata{MinVersion: "1.3"})
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+		assert.Equal(t, "db1", tlsConfig.ServerName)
+		require.Len(t, fallbacks, 1)
+		require.NotNil(t, fallbacks[0])
+		assert.Equal(t, uint16(tls.VersionTLS13), fallbacks[0].MinVersion)
+		assert.Equal(t, "db2", fallbacks[0].ServerName)
+	})
+
+	t.Run("sslmode disable", func(t *testing.T) {
+		_, _, err := getConfig(t, "host=db sslmode=disable", tlsconfig.Metadata{MinVersion: "1.3"})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, _, err := getConfig(t, "host=db sslmode=require", tlsconfig.Metadata{MinVersion: "1.1"})
+		require.Error(t, err)
+	})
+}
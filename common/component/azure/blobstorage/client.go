@@ -93,7 +93,10 @@ func (opts *ContainerClientOpts) setCustomEndpoint(log logger.Logger, meta map[s
 	}
 
 	// Check if the custom endpoint is set to an Azure Blob Storage public endpoint
-	azbURL := opts.getAzureBlobStorageContainerURL(azEnvSettings)
+	azbURL, err := opts.getAzureBlobStorageContainerURL(azEnvSettings)
+	if err != nil {
+		return err
+	}
 	if endpointURL.Hostname() == azbURL.Hostname() && azbURL.Path == endpointURL.Path {
 		log.Warn("Metadata property endpoint is set to an Azure Blob Storage endpoint and will be ignored")
 	} else {
@@ -112,14 +115,21 @@ func (opts *ContainerClientOpts) GetContainerURL(azEnvSettings azauth.Environmen
 			return nil, errors.New("failed to get container's URL with custom endpoint")
 		}
 	} else {
-		u = opts.getAzureBlobStorageContainerURL(azEnvSettings)
+		u, err = opts.getAzureBlobStorageContainerURL(azEnvSettings)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return u, nil
 }
 
-func (opts *ContainerClientOpts) getAzureBlobStorageContainerURL(azEnvSettings azauth.EnvironmentSettings) *url.URL {
-	u, _ := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s", opts.AccountName, azEnvSettings.EndpointSuffix(azauth.ServiceAzureStorage), opts.ContainerName))
-	return u
+func (opts *ContainerClientOpts) getAzureBlobStorageContainerURL(azEnvSettings azauth.EnvironmentSettings) (*url.URL, error) {
+	suffix, err := azEnvSettings.GetEndpointSuffix(azauth.ServiceAzureStorage)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s", opts.AccountName, suffix, opts.ContainerName))
+	return u, nil
 }
 
 // InitContainerClient returns a new container.Client object from the given options.
@@ -29,6 +29,12 @@ type BlobStorageMetadata struct {
 	DecodeBase64            bool `json:"decodeBase64,string" mapstructure:"decodeBase64" mdonly:"bindings"`
 	PublicAccessLevel       azblob.PublicAccessType
 	DisableEntityManagement bool `json:"disableEntityManagement,string" mapstructure:"disableEntityManagement"`
+
+	// Options of the uploads, which can be overridden per request
+	StorageClass     string `json:"storageClass" mapstructure:"storageClass" mdonly:"bindings"`
+	EncryptionScope  string `json:"encryptionScope" mapstructure:"encryptionScope" mdonly:"bindings"`
+	CustomerKey      string `json:"customerKey" mapstructure:"customerKey" mdonly:"bindings"`
+	ContentIntegrity string `json:"contentIntegrity" mapstructure:"contentIntegrity" mdonly:"bindings"`
 }
 
 type ContainerClientOpts struct {
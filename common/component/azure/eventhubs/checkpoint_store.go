@@ -0,0 +1,329 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/google/uuid"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Types of checkpoint stores.
+const (
+	CheckpointStoreTypeBlob     = "blob"
+	CheckpointStoreTypeMemory   = "memory"
+	CheckpointStoreTypeCosmosDB = "cosmosdb"
+)
+
+// memoryCheckpointStore is a checkpoint store that keeps ownerships and checkpoints in memory.
+// It is lost when the process exits and it can't be shared by multiple instances, so it is only suitable for a single replica.
+type memoryCheckpointStore struct {
+	lock        sync.Mutex
+	checkpoints map[string]azeventhubs.Checkpoint
+	ownerships  map[string]azeventhubs.Ownership
+}
+
+func newMemoryCheckpointStore() *memoryCheckpointStore {
+	return &memoryCheckpointStore{
+		checkpoints: map[string]azeventhubs.Checkpoint{},
+		ownerships:  map[string]azeventhubs.Ownership{},
+	}
+}
+
+// ClaimOwnership implements azeventhubs.CheckpointStore.
+func (s *memoryCheckpointStore) ClaimOwnership(_ context.Context, partitionOwnership []azeventhubs.Ownership, _ *azeventhubs.ClaimOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	claimed := make([]azeventhubs.Ownership, 0, len(partitionOwnership))
+	for _, po := range partitionOwnership {
+		key := checkpointStoreKey(po.FullyQualifiedNamespace, po.EventHubName, po.ConsumerGroup, po.PartitionID)
+		current, exists := s.ownerships[key]
+		// Same conditions as the blob store: new ownerships can only be created, and existing ones can only be updated with a matching ETag
+		if exists != (po.ETag != nil) || (exists && *current.ETag != *po.ETag) {
+			continue
+		}
+
+		etag := azcore.ETag(uuid.NewString())
+		po.ETag = &etag
+		po.LastModifiedTime = time.Now().UTC()
+		s.ownerships[key] = po
+		claimed = append(claimed, po)
+	}
+	return claimed, nil
+}
+
+// ListCheckpoints implements azeventhubs.CheckpointStore.
+func (s *memoryCheckpointStore) ListCheckpoints(_ context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListCheckpointsOptions) ([]azeventhubs.Checkpoint, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prefix := checkpointStoreKey(fullyQualifiedNamespace, eventHubName, consumerGroup, "")
+	checkpoints := []azeventhubs.Checkpoint{}
+	for key, cp := range s.checkpoints {
+		if strings.HasPrefix(key, prefix) {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	return checkpoints, nil
+}
+
+// ListOwnership implements azeventhubs.CheckpointStore.
+func (s *memoryCheckpointStore) ListOwnership(_ context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prefix := checkpointStoreKey(fullyQualifiedNamespace, eventHubName, consumerGroup, "")
+	ownerships := []azeventhubs.Ownership{}
+	for key, o := range s.ownerships {
+		if strings.HasPrefix(key, prefix) {
+			ownerships = append(ownerships, o)
+		}
+	}
+	return ownerships, nil
+}
+
+// SetCheckpoint implements azeventhubs.CheckpointStore.
+func (s *memoryCheckpointStore) SetCheckpoint(_ context.Context, checkpoint azeventhubs.Checkpoint, _ *azeventhubs.SetCheckpointOptions) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkpoints[checkpointStoreKey(checkpoint.FullyQualifiedNamespace, checkpoint.EventHubName, checkpoint.ConsumerGroup, checkpoint.PartitionID)] = checkpoint
+	return nil
+}
+
+func checkpointStoreKey(fullyQualifiedNamespace, eventHubName, consumerGroup, partitionID string) string {
+	return strings.ToLower(fullyQualifiedNamespace+"/"+eventHubName+"/"+consumerGroup) + "/" + partitionID
+}
+
+// Types of documents in the Cosmos DB checkpoint store.
+const (
+	cosmosDocumentTypeOwnership  = "ownership"
+	cosmosDocumentTypeCheckpoint = "checkpoint"
+)
+
+// cosmosCheckpointStore is a checkpoint store backed by a Cosmos DB container.
+// All documents of a consumer group are stored in the same logical partition; the container must use "/partitionKey" as partition key path.
+type cosmosCheckpointStore struct {
+	client *azcosmos.ContainerClient
+}
+
+// cosmosCheckpointDocument is a document in the Cosmos DB checkpoint store, containing either an ownership or a checkpoint.
+type cosmosCheckpointDocument struct {
+	ID             string `json:"id"`
+	PartitionKey   string `json:"partitionKey"`
+	Type           string `json:"type"`
+	PartitionID    string `json:"partitionId"`
+	OwnerID        string `json:"ownerId,omitempty"`
+	Offset         *int64 `json:"offset,omitempty"`
+	SequenceNumber *int64 `json:"sequenceNumber,omitempty"`
+
+	// System properties, set by Cosmos DB
+	Timestamp int64  `json:"_ts,omitempty"`
+	ETag      string `json:"_etag,omitempty"`
+}
+
+func newCosmosCheckpointStore(md *AzureEventHubsMetadata) (*cosmosCheckpointStore, error) {
+	opts := &azcosmos.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Telemetry: policy.TelemetryOptions{
+				ApplicationID: "dapr-" + logger.DaprVersion,
+			},
+		},
+	}
+
+	var (
+		client *azcosmos.Client
+		err    error
+	)
+	if md.CosmosDBMasterKey != "" {
+		var cred azcosmos.KeyCredential
+		cred, err = azcosmos.NewKeyCredential(md.CosmosDBMasterKey)
+		if err != nil {
+			return nil, err
+		}
+		client, err = azcosmos.NewClientWithKey(md.CosmosDBURL, cred, opts)
+	} else {
+		// Fallback to using Azure AD
+		var token azcore.TokenCredential
+		token, err = md.azEnvSettings.GetTokenCredential()
+		if err != nil {
+			return nil, err
+		}
+		client, err = azcosmos.NewClient(md.CosmosDBURL, token, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating Cosmos DB client: %w", err)
+	}
+
+	container, err := client.NewContainer(md.CosmosDBDatabase, md.CosmosDBContainer)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Cosmos DB container client: %w", err)
+	}
+	return &cosmosCheckpointStore{client: container}, nil
+}
+
+// ClaimOwnership implements azeventhubs.CheckpointStore.
+func (s *cosmosCheckpointStore) ClaimOwnership(ctx context.Context, partitionOwnership []azeventhubs.Ownership, _ *azeventhubs.ClaimOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	claimed := make([]azeventhubs.Ownership, 0, len(partitionOwnership))
+	for _, po := range partitionOwnership {
+		doc := cosmosCheckpointDocument{
+			ID:           cosmosDocumentTypeOwnership + "-" + po.PartitionID,
+			PartitionKey: cosmosPartitionKey(po.FullyQualifiedNamespace, po.EventHubName, po.ConsumerGroup),
+			Type:         cosmosDocumentTypeOwnership,
+			PartitionID:  po.PartitionID,
+			OwnerID:      po.OwnerID,
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		pk := azcosmos.NewPartitionKeyString(doc.PartitionKey)
+		opts := &azcosmos.ItemOptions{EnableContentResponseOnWrite: true}
+		var res azcosmos.ItemResponse
+		if po.ETag == nil {
+			res, err = s.client.CreateItem(ctx, pk, data, opts)
+		} else {
+			opts.IfMatchEtag = po.ETag
+			res, err = s.client.ReplaceItem(ctx, pk, doc.ID, data, opts)
+		}
+		if err != nil {
+			// Skip partitions claimed or updated by another processor in the meanwhile
+			var respErr *azcore.ResponseError
+			if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusConflict || respErr.StatusCode == http.StatusPreconditionFailed || respErr.StatusCode == http.StatusNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("error claiming ownership of partition %s: %w", po.PartitionID, err)
+		}
+
+		var stored cosmosCheckpointDocument
+		err = json.Unmarshal(res.Value, &stored)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding ownership of partition %s: %w", po.PartitionID, err)
+		}
+		claimed = append(claimed, stored.ownership(po.FullyQualifiedNamespace, po.EventHubName, po.ConsumerGroup))
+	}
+	return claimed, nil
+}
+
+// ListCheckpoints implements azeventhubs.CheckpointStore.
+func (s *cosmosCheckpointStore) ListCheckpoints(ctx context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListCheckpointsOptions) ([]azeventhubs.Checkpoint, error) {
+	docs, err := s.list(ctx, cosmosPartitionKey(fullyQualifiedNamespace, eventHubName, consumerGroup), cosmosDocumentTypeCheckpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error listing checkpoints: %w", err)
+	}
+	checkpoints := make([]azeventhubs.Checkpoint, len(docs))
+	for i, doc := range docs {
+		checkpoints[i] = azeventhubs.Checkpoint{
+			ConsumerGroup:           consumerGroup,
+			EventHubName:            eventHubName,
+			FullyQualifiedNamespace: fullyQualifiedNamespace,
+			PartitionID:             doc.PartitionID,
+			Offset:                  doc.Offset,
+			SequenceNumber:          doc.SequenceNumber,
+		}
+	}
+	return checkpoints, nil
+}
+
+// ListOwnership implements azeventhubs.CheckpointStore.
+func (s *cosmosCheckpointStore) ListOwnership(ctx context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	docs, err := s.list(ctx, cosmosPartitionKey(fullyQualifiedNamespace, eventHubName, consumerGroup), cosmosDocumentTypeOwnership)
+	if err != nil {
+		return nil, fmt.Errorf("error listing ownerships: %w", err)
+	}
+	ownerships := make([]azeventhubs.Ownership, len(docs))
+	for i, doc := range docs {
+		ownerships[i] = doc.ownership(fullyQualifiedNamespace, eventHubName, consumerGroup)
+	}
+	return ownerships, nil
+}
+
+// SetCheckpoint implements azeventhubs.CheckpointStore.
+func (s *cosmosCheckpointStore) SetCheckpoint(ctx context.Context, checkpoint azeventhubs.Checkpoint, _ *azeventhubs.SetCheckpointOptions) error {
+	doc := cosmosCheckpointDocument{
+		ID:             cosmosDocumentTypeCheckpoint + "-" + checkpoint.PartitionID,
+		PartitionKey:   cosmosPartitionKey(checkpoint.FullyQualifiedNamespace, checkpoint.EventHubName, checkpoint.ConsumerGroup),
+		Type:           cosmosDocumentTypeCheckpoint,
+		PartitionID:    checkpoint.PartitionID,
+		Offset:         checkpoint.Offset,
+		SequenceNumber: checkpoint.SequenceNumber,
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.UpsertItem(ctx, azcosmos.NewPartitionKeyString(doc.PartitionKey), data, nil)
+	if err != nil {
+		return fmt.Errorf("error setting checkpoint of partition %s: %w", checkpoint.PartitionID, err)
+	}
+	return nil
+}
+
+// list returns the documents of a type in the partition.
+func (s *cosmosCheckpointStore) list(ctx context.Context, partitionKey string, docType string) ([]cosmosCheckpointDocument, error) {
+	pager := s.client.NewQueryItemsPager("SELECT * FROM c WHERE c.type = @type", azcosmos.NewPartitionKeyString(partitionKey), &azcosmos.QueryOptions{
+		QueryParameters: []azcosmos.QueryParameter{
+			{Name: "@type", Value: docType},
+		},
+	})
+
+	docs := []cosmosCheckpointDocument{}
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			var doc cosmosCheckpointDocument
+			err = json.Unmarshal(item, &doc)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func (d cosmosCheckpointDocument) ownership(fullyQualifiedNamespace, eventHubName, consumerGroup string) azeventhubs.Ownership {
+	etag := azcore.ETag(d.ETag)
+	return azeventhubs.Ownership{
+		ConsumerGroup:           consumerGroup,
+		EventHubName:            eventHubName,
+		FullyQualifiedNamespace: fullyQualifiedNamespace,
+		PartitionID:             d.PartitionID,
+		OwnerID:                 d.OwnerID,
+		LastModifiedTime:        time.Unix(d.Timestamp, 0).UTC(),
+		ETag:                    &etag,
+	}
+}
+
+func cosmosPartitionKey(fullyQualifiedNamespace, eventHubName, consumerGroup string) string {
+	return strings.ToLower(fullyQualifiedNamespace + "/" + eventHubName + "/" + consumerGroup)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/ptr"
+)
+
+func TestMemoryCheckpointStore(t *testing.T) {
+	const (
+		ns  = "fake.servicebus.windows.net"
+		hub = "hub"
+		cg  = "$Default"
+	)
+	ctx := context.Background()
+
+	t.Run("ownership", func(t *testing.T) {
+		store := newMemoryCheckpointStore()
+		po := azeventhubs.Ownership{
+			FullyQualifiedNamespace: ns,
+			EventHubName:            hub,
+			ConsumerGroup:           cg,
+			PartitionID:             "0",
+			OwnerID:                 "owner1",
+		}
+
+		claimed, err := store.ClaimOwnership(ctx, []azeventhubs.Ownership{po}, nil)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		require.NotNil(t, claimed[0].ETag)
+		assert.False(t, claimed[0].LastModifiedTime.IsZero())
+
+		// Creating it again fails, as it already exists
+		claimed2, err := store.ClaimOwnership(ctx, []azeventhubs.Ownership{po}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, claimed2)
+
+		// Updating requires the current ETag
+		stale := claimed[0]
+		stale.OwnerID = "owner2"
+		claimed2, err = store.ClaimOwnership(ctx, []azeventhubs.Ownership{stale}, nil)
+		require.NoError(t, err)
+		require.Len(t, claimed2, 1)
+		assert.NotEqual(t, *claimed[0].ETag, *claimed2[0].ETag)
+
+		claimed3, err := store.ClaimOwnership(ctx, []azeventhubs.Ownership{stale}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, claimed3)
+
+		ownerships, err := store.ListOwnership(ctx, ns, hub, cg, nil)
+		require.NoError(t, err)
+		require.Len(t, ownerships, 1)
+		assert.Equal(t, "owner2", ownerships[0].OwnerID)
+
+		ownerships, err = store.ListOwnership(ctx, ns, hub, "other", nil)
+		require.NoError(t, err)
+		assert.Empty(t, ownerships)
+	})
+
+	t.Run("checkpoints", func(t *testing.T) {
+		store := newMemoryCheckpointStore()
+		for i, partitionID := range []string{"0", "1", "0"} {
+			err := store.SetCheckpoint(ctx, azeventhubs.Checkpoint{
+				FullyQualifiedNamespace: ns,
+				EventHubName:            hub,
+				ConsumerGroup:           cg,
+				PartitionID:             partitionID,
+				SequenceNumber:          ptr.Of(int64(i)),
+			}, nil)
+			require.NoError(t, err)
+		}
+
+		checkpoints, err := store.ListCheckpoints(ctx, ns, hub, cg, nil)
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 2)
+		sequenceNumbers := map[string]int64{}
+		for _, cp := range checkpoints {
+			sequenceNumbers[cp.PartitionID] = *cp.SequenceNumber
+		}
+		assert.Equal(t, map[string]int64{"0": 2, "1": 1}, sequenceNumbers)
+	})
+}
+
+func TestCosmosCheckpointDocument(t *testing.T) {
+	var doc cosmosCheckpointDocument
+	err := json.Unmarshal([]byte(`{"id":"ownership-1","partitionKey":"ns/hub/cg","type":"ownership","partitionId":"1","ownerId":"owner1","_ts":1700000000,"_etag":"\"abc\""}`), &doc)
+	require.NoError(t, err)
+
+	o := doc.ownership("ns", "hub", "cg")
+	assert.Equal(t, "1", o.PartitionID)
+	assert.Equal(t, "owner1", o.OwnerID)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), o.LastModifiedTime)
+	require.NotNil(t, o.ETag)
+	assert.Equal(t, `"abc"`, string(*o.ETag))
+
+	// System properties are not sent when writing documents
+	data, err := json.Marshal(cosmosCheckpointDocument{ID: "checkpoint-1", Type: cosmosDocumentTypeCheckpoint})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "_ts")
+	assert.NotContains(t, string(data), "_etag")
+}
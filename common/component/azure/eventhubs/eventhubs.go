@@ -31,6 +31,7 @@ import (
 	"github.com/dapr/components-contrib/bindings"
 	azauth "github.com/dapr/components-contrib/common/authentication/azure"
 	"github.com/dapr/components-contrib/common/component/azure/blobstorage"
+	"github.com/dapr/components-contrib/common/resiliency"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -50,12 +51,21 @@ type AzureEventHubs struct {
 	isBinding bool
 
 	backOffConfig        retry.Config
+	checkpointPolicy     *resiliency.Policy
 	producersLock        *sync.RWMutex
 	producers            map[string]*azeventhubs.ProducerClient
 	checkpointStoreCache azeventhubs.CheckpointStore
 	checkpointStoreLock  *sync.RWMutex
 
 	managementCreds azcore.TokenCredential
+
+	// Canceled when the component starts draining before closing, to stop receiving new events
+	drainCtx    context.Context
+	drainCancel context.CancelFunc
+	// Context for the partitions to wait for the events being processed while draining
+	drainWaitCtx context.Context
+	drainLock    sync.Mutex
+	partitionsWG sync.WaitGroup
 }
 
 // HandlerResponseItem represents a response from the handler for each message.
@@ -76,12 +86,15 @@ type SubscribeConfig struct {
 
 // NewAzureEventHubs returns a new Azure Event hubs instance.
 func NewAzureEventHubs(logger logger.Logger, isBinding bool) *AzureEventHubs {
+	drainCtx, drainCancel := context.WithCancel(context.Background())
 	return &AzureEventHubs{
 		logger:              logger,
 		isBinding:           isBinding,
 		producersLock:       &sync.RWMutex{},
 		producers:           make(map[string]*azeventhubs.ProducerClient, 1),
 		checkpointStoreLock: &sync.RWMutex{},
+		drainCtx:            drainCtx,
+		drainCancel:         drainCancel,
 	}
 }
 
@@ -97,6 +110,8 @@ func (aeh *AzureEventHubs) Init(metadata map[string]string) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize Azure environment: %w", err)
 	}
+	// Credentials are created for each Event Hub and for the checkpoint storage, so they share their tokens
+	aeh.metadata.azEnvSettings.SharedTokenCache = true
 
 	if aeh.metadata.ConnectionString == "" {
 		aeh.logger.Info("connecting to Azure Event Hub using Azure AD; the connection will be established on first publish/subscribe and req.Topic field in incoming requests will be honored")
@@ -118,6 +133,11 @@ func (aeh *AzureEventHubs) Init(metadata map[string]string) error {
 		return errors.New("failed to decode backoff configuration")
 	}
 
+	aeh.checkpointPolicy, err = aeh.metadata.PolicyMetadata.NewPolicy("checkpoint", aeh.logger)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -295,7 +315,15 @@ func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, config Subscr
 			aeh.logger.Debugf("Received client for partition %s", partitionClient.PartitionID())
 
 			// Once we get a partition client, process the events in a separate goroutine
+			if !aeh.startPartition() {
+				closeCtx, closeCancel := context.WithTimeout(context.Background(), resourceGetTimeout)
+				partitionClient.Close(closeCtx)
+				closeCancel()
+				subscriptionLoopFinished <- true
+				return
+			}
 			go func() {
+				defer aeh.partitionsWG.Done()
 				processErr := aeh.processEvents(subscribeCtx, partitionClient, retryConfig)
 				// Do not log context.Canceled which happens at shutdown
 				if processErr != nil && !errors.Is(processErr, context.Canceled) {
@@ -370,19 +398,29 @@ func (aeh *AzureEventHubs) processEvents(subscribeCtx context.Context, partition
 
 	// Loop to receive messages
 	var (
-		events []*azeventhubs.ReceivedEventData
-		err    error
+		events    []*azeventhubs.ReceivedEventData
+		lastEvent *azeventhubs.ReceivedEventData
+		handlers  sync.WaitGroup
+		err       error
 	)
 	counter := 0
 	for {
+		if aeh.drainCtx.Err() != nil {
+			return aeh.drainPartition(partitionClient, config, &handlers, lastEvent)
+		}
+
 		// Maximum duration to wait till bulk message is sent to app is `maxBulkSubAwaitDurationMs`
 		ctx, cancel := context.WithTimeout(subscribeCtx, time.Duration(config.MaxBulkSubAwaitDurationMs)*time.Millisecond)
+		// Stop waiting for events when the component starts draining
+		stop := context.AfterFunc(aeh.drainCtx, cancel)
 		// Receive events with batchsize of `maxBulkSubCount`
 		events, err = partitionClient.ReceiveEvents(ctx, config.MaxBulkSubCount, nil)
+		stop()
 		cancel()
 
 		// A DeadlineExceeded error means that the context timed out before we received the full batch of messages, and that's fine
-		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		// Same for a Canceled error when draining, as the events received so far are processed before stopping
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !(errors.Is(err, context.Canceled) && aeh.drainCtx.Err() != nil && subscribeCtx.Err() == nil) {
 			// If we get an error like ErrorCodeOwnershipLost, it means that the partition was rebalanced and we lost it
 			// We'll just stop this subscription and return
 			eventHubError := (*azeventhubs.Error)(nil)
@@ -397,11 +435,17 @@ func (aeh *AzureEventHubs) processEvents(subscribeCtx context.Context, partition
 		aeh.logger.Debugf("Received batch with %d events on topic %s, partition %s", len(events), config.Topic, partitionClient.PartitionID())
 
 		if len(events) != 0 {
+			lastEvent = events[len(events)-1]
+
 			// Handle received message
 			if aeh.metadata.EnableInOrderMessageDelivery {
 				aeh.handleAsync(subscribeCtx, config.Topic, events, config.Handler)
 			} else {
-				go aeh.handleAsync(subscribeCtx, config.Topic, events, config.Handler)
+				handlers.Add(1)
+				go func(events []*azeventhubs.ReceivedEventData) {
+					defer handlers.Done()
+					aeh.handleAsync(subscribeCtx, config.Topic, events, config.Handler)
+				}(events)
 			}
 
 			// Checkpointing disabled for CheckPointFrequencyPerPartition == 0
@@ -411,7 +455,7 @@ func (aeh *AzureEventHubs) processEvents(subscribeCtx context.Context, partition
 					// Update the checkpoint with the last event received. If we lose ownership of this partition or have to restart the next owner will start from this point.
 					// This context inherits from the background one in case subscriptionCtx gets canceled
 					ctx, cancel = context.WithTimeout(context.Background(), resourceCreationTimeout)
-					err = partitionClient.UpdateCheckpoint(ctx, events[len(events)-1], nil)
+					err = aeh.updateCheckpoint(ctx, partitionClient, events[len(events)-1])
 					cancel()
 					if err != nil {
 						return fmt.Errorf("failed to update checkpoint: %w", err)
@@ -424,7 +468,81 @@ func (aeh *AzureEventHubs) processEvents(subscribeCtx context.Context, partition
 	}
 }
 
+// drainPartition waits for the events of the partition being processed, then checkpoints the last one received.
+func (aeh *AzureEventHubs) drainPartition(partitionClient *azeventhubs.ProcessorPartitionClient, config SubscribeConfig, handlers *sync.WaitGroup, lastEvent *azeventhubs.ReceivedEventData) error {
+	done := make(chan struct{})
+	go func() {
+		handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-aeh.drainWaitCtx.Done():
+		aeh.logger.Warnf("Timed out waiting for the events being processed on topic %s, partition %s", config.Topic, partitionClient.PartitionID())
+		return nil
+	}
+
+	if lastEvent == nil || config.CheckPointFrequencyPerPartition <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(aeh.drainWaitCtx, resourceCreationTimeout)
+	defer cancel()
+	err := aeh.updateCheckpoint(ctx, partitionClient, lastEvent)
+	if err != nil {
+		return fmt.Errorf("failed to update checkpoint: %w", err)
+	}
+	return nil
+}
+
+// updateCheckpoint writes the checkpoint of the event, applying the resiliency policy configured for checkpoints.
+func (aeh *AzureEventHubs) updateCheckpoint(ctx context.Context, partitionClient *azeventhubs.ProcessorPartitionClient, event *azeventhubs.ReceivedEventData) error {
+	return aeh.checkpointPolicy.Run(ctx, func(ctx context.Context) error {
+		return partitionClient.UpdateCheckpoint(ctx, event, nil)
+	})
+}
+
+// startPartition registers a partition being processed, returning false if the component is draining.
+func (aeh *AzureEventHubs) startPartition() bool {
+	aeh.drainLock.Lock()
+	defer aeh.drainLock.Unlock()
+	if aeh.drainCtx.Err() != nil {
+		return false
+	}
+	aeh.partitionsWG.Add(1)
+	return true
+}
+
+// Close closes the component, waiting up to pubsub.DefaultCloseTimeout for the events being processed.
 func (aeh *AzureEventHubs) Close() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return aeh.CloseWithContext(ctx)
+}
+
+// CloseWithContext stops receiving new events and waits until the events being processed are handled and checkpointed, or until ctx is done.
+// Then it closes the clients.
+func (aeh *AzureEventHubs) CloseWithContext(ctx context.Context) (err error) {
+	aeh.drainLock.Lock()
+	draining := aeh.drainCtx.Err() == nil
+	if draining {
+		aeh.drainWaitCtx = ctx
+		aeh.drainCancel()
+	}
+	aeh.drainLock.Unlock()
+
+	if draining {
+		done := make(chan struct{})
+		go func() {
+			aeh.partitionsWG.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			aeh.logger.Warnf("Timed out waiting for the Event Hubs partitions to be drained: %v", ctx.Err())
+		}
+	}
+
 	// Acquire locks
 	aeh.checkpointStoreLock.Lock()
 	defer aeh.checkpointStoreLock.Unlock()
@@ -611,6 +729,13 @@ func (aeh *AzureEventHubs) getCheckpointStore(ctx context.Context) (azeventhubs.
 
 // Initializes a new checkpoint store
 func (aeh *AzureEventHubs) createCheckpointStore(ctx context.Context) (checkpointStore azeventhubs.CheckpointStore, err error) {
+	switch aeh.metadata.CheckpointStoreType {
+	case CheckpointStoreTypeMemory:
+		return newMemoryCheckpointStore(), nil
+	case CheckpointStoreTypeCosmosDB:
+		return newCosmosCheckpointStore(aeh.metadata)
+	}
+
 	if aeh.metadata.StorageAccountName == "" {
 		return nil, errors.New("property storageAccountName is required to subscribe to an Event Hub topic")
 	}
@@ -84,6 +84,54 @@ func TestParseEventHubsMetadata(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, m.EnableInOrderMessageDelivery)
 	})
+
+	t.Run("test default checkpoint store", func(t *testing.T) {
+		m, err := parseEventHubsMetadata(map[string]string{"connectionString": "fake"}, false, testLogger)
+
+		require.NoError(t, err)
+		assert.Equal(t, CheckpointStoreTypeBlob, m.CheckpointStoreType)
+	})
+
+	t.Run("test memory checkpoint store", func(t *testing.T) {
+		metadata := map[string]string{
+			"connectionString":    "fake",
+			"checkpointStoreType": "Memory",
+		}
+
+		m, err := parseEventHubsMetadata(metadata, false, testLogger)
+
+		require.NoError(t, err)
+		assert.Equal(t, CheckpointStoreTypeMemory, m.CheckpointStoreType)
+	})
+
+	t.Run("test cosmosdb checkpoint store", func(t *testing.T) {
+		metadata := map[string]string{
+			"connectionString":    "fake",
+			"checkpointStoreType": "cosmosdb",
+			"cosmosDbUrl":         "https://fake.documents.azure.com:443/",
+			"cosmosDbDatabase":    "db",
+		}
+
+		_, err := parseEventHubsMetadata(metadata, false, testLogger)
+		require.ErrorContains(t, err, "properties cosmosDbUrl, cosmosDbDatabase and cosmosDbContainer are required")
+
+		metadata["cosmosDbContainer"] = "checkpoints"
+		m, err := parseEventHubsMetadata(metadata, false, testLogger)
+		require.NoError(t, err)
+		assert.Equal(t, CheckpointStoreTypeCosmosDB, m.CheckpointStoreType)
+		assert.Equal(t, "checkpoints", m.CosmosDBContainer)
+	})
+
+	t.Run("test invalid checkpoint store", func(t *testing.T) {
+		metadata := map[string]string{
+			"connectionString":    "fake",
+			"checkpointStoreType": "redis",
+		}
+
+		_, err := parseEventHubsMetadata(metadata, false, testLogger)
+
+		require.ErrorContains(t, err, "invalid checkpointStoreType 'redis'")
+	})
 }
 
 func TestConstructConnectionStringFromTopic(t *testing.T) {
@@ -43,6 +43,9 @@ const (
 	sysPropIotHubConnectionAuthMethod = "iothub-connection-auth-method"
 	sysPropIotHubConnectionModuleID   = "iothub-connection-module-id"
 	sysPropIotHubEnqueuedTime         = "iothub-enqueuedtime"
+	sysPropIotHubMessageSource        = "iothub-message-source"
+	sysPropIotHubDigitalTwinSchema    = "dt-dataschema"
+	sysPropIotHubDigitalTwinSubject   = "dt-subject"
 	sysPropMessageID                  = "message-id"
 )
 
@@ -71,7 +74,10 @@ func getMetadataFromEventData(e *azeventhubs.ReceivedEventData, getAllProperties
 			sysPropIotHubAuthGenerationID,
 			sysPropIotHubConnectionAuthMethod,
 			sysPropIotHubConnectionModuleID,
-			sysPropIotHubEnqueuedTime:
+			sysPropIotHubEnqueuedTime,
+			sysPropIotHubMessageSource,
+			sysPropIotHubDigitalTwinSchema,
+			sysPropIotHubDigitalTwinSubject:
 			addPropertyToMetadata(k, v, md)
 		default:
 			// nop
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetadataFromEventDataIotHub(t *testing.T) {
+	enqueued := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := &azeventhubs.ReceivedEventData{
+		SystemProperties: map[string]any{
+			"iothub-connection-device-id": "device1",
+			"iothub-connection-module-id": "module1",
+			"iothub-enqueuedtime":         enqueued,
+			"iothub-message-source":       "Telemetry",
+			"dt-dataschema":               "dtmi:com:example:Thermostat;1",
+			"dt-subject":                  "thermostat1",
+			"x-opt-unrelated":             "ignored",
+		},
+	}
+
+	md := getMetadataFromEventData(e, false)
+	assert.Equal(t, "device1", md["iothub-connection-device-id"])
+	assert.Equal(t, "module1", md["iothub-connection-module-id"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", md["iothub-enqueuedtime"])
+	assert.Equal(t, "Telemetry", md["iothub-message-source"])
+	assert.Equal(t, "dtmi:com:example:Thermostat;1", md["dt-dataschema"])
+	assert.Equal(t, "thermostat1", md["dt-subject"])
+	assert.NotContains(t, md, "x-opt-unrelated")
+}
@@ -21,11 +21,15 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
 
 	azauth "github.com/dapr/components-contrib/common/authentication/azure"
+	"github.com/dapr/components-contrib/common/resiliency"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/metadata"
 )
 
 type AzureEventHubsMetadata struct {
+	// Resiliency policy for writing checkpoints
+	resiliency.PolicyMetadata `mapstructure:",squash"`
+
 	ConnectionString             string `json:"connectionString" mapstructure:"connectionString"`
 	EventHubNamespace            string `json:"eventHubNamespace" mapstructure:"eventHubNamespace"`
 	ConsumerID                   string `json:"consumerID" mapstructure:"consumerID"`
@@ -41,6 +45,13 @@ type AzureEventHubsMetadata struct {
 	EnableInOrderMessageDelivery bool   `json:"enableInOrderMessageDelivery,string" mapstructure:"enableInOrderMessageDelivery"`
 	GetAllMessageProperties      bool   `json:"getAllMessageProperties,string" mapstructure:"getAllMessageProperties"`
 
+	// Checkpoint store: "blob" (default), "memory" or "cosmosdb"
+	CheckpointStoreType string `json:"checkpointStoreType" mapstructure:"checkpointStoreType"`
+	CosmosDBURL         string `json:"cosmosDbUrl" mapstructure:"cosmosDbUrl"`
+	CosmosDBMasterKey   string `json:"cosmosDbMasterKey" mapstructure:"cosmosDbMasterKey"`
+	CosmosDBDatabase    string `json:"cosmosDbDatabase" mapstructure:"cosmosDbDatabase"`
+	CosmosDBContainer   string `json:"cosmosDbContainer" mapstructure:"cosmosDbContainer"`
+
 	// Binding only
 	EventHub      string `json:"eventHub" mapstructure:"eventHub" mdonly:"bindings"`
 	ConsumerGroup string `json:"consumerGroup" mapstructure:"consumerGroup" mdonly:"bindings"` // Alias for ConsumerID
@@ -109,6 +120,21 @@ func parseEventHubsMetadata(meta map[string]string, isBinding bool, log logger.L
 		}
 	}
 
+	switch strings.ToLower(m.CheckpointStoreType) {
+	case "", CheckpointStoreTypeBlob:
+		m.CheckpointStoreType = CheckpointStoreTypeBlob
+	case CheckpointStoreTypeMemory:
+		m.CheckpointStoreType = CheckpointStoreTypeMemory
+		log.Warn("Checkpoints are stored in memory: they are lost on restart and partitions can't be balanced across multiple instances")
+	case CheckpointStoreTypeCosmosDB:
+		m.CheckpointStoreType = CheckpointStoreTypeCosmosDB
+		if m.CosmosDBURL == "" || m.CosmosDBDatabase == "" || m.CosmosDBContainer == "" {
+			return nil, errors.New("properties cosmosDbUrl, cosmosDbDatabase and cosmosDbContainer are required when checkpointStoreType is cosmosdb")
+		}
+	default:
+		return nil, fmt.Errorf("invalid checkpointStoreType '%s': must be one of blob, memory or cosmosdb", m.CheckpointStoreType)
+	}
+
 	// If both storageConnectionString and storageAccountName are specified, show a warning because the connection string will take priority
 	if m.StorageConnectionString != "" && m.StorageAccountName != "" {
 		log.Warn("Property storageAccountName is ignored when storageConnectionString is present")
@@ -75,6 +75,8 @@ func NewClient(metadata *Metadata, rawMetadata map[string]string) (*Client, erro
 		if err != nil {
 			return nil, err
 		}
+		// Share tokens with the other Service Bus components using the same identity
+		settings.SharedTokenCache = true
 
 		token, err := settings.GetTokenCredential()
 		if err != nil {
@@ -291,6 +293,23 @@ func (c *Client) EnsureQueue(ctx context.Context, queue string) error {
 	return nil
 }
 
+// Ping checks the connection to the Service Bus namespace by fetching its properties.
+// This requires the Manage right, so the check is skipped when entity management is disabled.
+func (c *Client) Ping(parentCtx context.Context) error {
+	if c.adminClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+
+	_, err := c.adminClient.GetNamespaceProperties(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not get the namespace properties: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) shouldCreateTopic(parentCtx context.Context, topic string) (bool, error) {
 	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
 	defer cancel()
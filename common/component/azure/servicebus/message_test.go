@@ -77,6 +77,20 @@ func TestAddMetadataToMessage(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Maps aliases to azure service bus message.",
+			metadata: map[string]string{
+				MessageKeyMessageIDAlias:               testMessageID,
+				MessageKeyCorrelationIDAlias:           testCorrelationID,
+				MessageKeyScheduledEnqueueTimeUtcAlias: nowUtc.Format(time.RFC3339),
+			},
+			expectedAzServiceBusMessage: azservicebus.Message{
+				MessageID:            &testMessageID,
+				CorrelationID:        &testCorrelationID,
+				ScheduledEnqueueTime: &nowUtc,
+			},
+			expectError: false,
+		},
 		{
 			name: "Errors when partition key and session id set but not equal.",
 			metadata: map[string]string{
@@ -15,6 +15,7 @@ package servicebus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -105,29 +106,104 @@ func (c *Client) PublishPubSubBulk(ctx context.Context, req *pubsub.BulkPublishR
 		return pubsub.NewBulkPublishResponse(req.Entries, err), err
 	}
 
-	// Create a new batch of messages with batch options.
+	// Create new batches of messages with batch options.
 	batchOpts := &servicebus.MessageBatchOptions{
 		MaxBytes: commonutils.GetElemOrDefaultFromMap(req.Metadata, contribMetadata.MaxBulkPubBytesKey, defaultMaxBulkPubBytes),
 	}
 
-	batchMsg, err := sender.NewMessageBatch(ctx, batchOpts)
-	if err != nil {
-		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	res := publishInBatches(req.Entries,
+		func() (messageBatch, error) {
+			return sender.NewMessageBatch(ctx, batchOpts)
+		},
+		func(batch messageBatch) error {
+			// Azure Service Bus does not return individual status for each message in the batch.
+			return sender.SendMessageBatch(ctx, batch.(*servicebus.MessageBatch), nil)
+		},
+	)
+	if len(res.FailedEntries) > 0 {
+		err = fmt.Errorf("failed to publish %d of %d messages: %w", len(res.FailedEntries), len(req.Entries), res.FailedEntries[0].Error)
+		return res, err
 	}
 
-	// Add messages from the bulk publish request to the batch.
-	err = UpdateASBBatchMessageWithBulkPublishRequest(batchMsg, req)
-	if err != nil {
-		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	return pubsub.BulkPublishResponse{}, nil
+}
+
+// messageBatch is the subset of the methods of *servicebus.MessageBatch used when publishing in bulk.
+type messageBatch interface {
+	AddMessage(m *servicebus.Message, options *servicebus.AddMessageOptions) error
+	NumMessages() int32
+}
+
+// publishInBatches adds the entries to as many batches as needed to honor the maximum size of a batch, sending each batch once it is full.
+// Entries that cannot be converted or that do not fit in an empty batch, as well as all entries of a batch that could not be sent, are returned as failed.
+func publishInBatches(entries []pubsub.BulkMessageEntry, newBatch func() (messageBatch, error), send func(messageBatch) error) pubsub.BulkPublishResponse {
+	res := pubsub.BulkPublishResponse{}
+	fail := func(err error, entryIDs ...string) {
+		for _, id := range entryIDs {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: id,
+				Error:   err,
+			})
+		}
 	}
 
-	// Azure Service Bus does not return individual status for each message in the request.
-	err = sender.SendMessageBatch(ctx, batchMsg, nil)
-	if err != nil {
-		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	failRemaining := func(remaining []pubsub.BulkMessageEntry, err error) {
+		for _, e := range remaining {
+			fail(err, e.EntryId)
+		}
 	}
 
-	return pubsub.BulkPublishResponse{}, nil
+	var (
+		batch    messageBatch
+		batchIDs []string
+	)
+	flush := func() {
+		if batch != nil && batch.NumMessages() > 0 {
+			err := send(batch)
+			if err != nil {
+				fail(err, batchIDs...)
+			}
+		}
+		batch = nil
+		batchIDs = nil
+	}
+
+	for i, entry := range entries {
+		asbMsg, err := NewASBMessageFromBulkMessageEntry(entry)
+		if err != nil {
+			fail(err, entry.EntryId)
+			continue
+		}
+
+		if batch == nil {
+			batch, err = newBatch()
+			if err != nil {
+				// The remaining entries cannot be published either
+				failRemaining(entries[i:], err)
+				return res
+			}
+		}
+
+		err = batch.AddMessage(asbMsg, nil)
+		if errors.Is(err, servicebus.ErrMessageTooLarge) && batch.NumMessages() > 0 {
+			// The batch is full: send it and add the message to a new one
+			flush()
+			batch, err = newBatch()
+			if err != nil {
+				failRemaining(entries[i:], err)
+				return res
+			}
+			err = batch.AddMessage(asbMsg, nil)
+		}
+		if err != nil {
+			fail(err, entry.EntryId)
+			continue
+		}
+		batchIDs = append(batchIDs, entry.EntryId)
+	}
+	flush()
+
+	return res
 }
 
 // PublishBinding is used by binding components to publish messages. It includes a retry logic that can also cause reconnections.
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"errors"
+	"testing"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// fakeBatch is a messageBatch that holds up to a maximum number of bytes.
+type fakeBatch struct {
+	maxBytes int
+	size     int
+	ids      []string
+}
+
+func (b *fakeBatch) AddMessage(m *azservicebus.Message, _ *azservicebus.AddMessageOptions) error {
+	if b.size+len(m.Body) > b.maxBytes {
+		return azservicebus.ErrMessageTooLarge
+	}
+	b.size += len(m.Body)
+	b.ids = append(b.ids, string(m.Body))
+	return nil
+}
+
+func (b *fakeBatch) NumMessages() int32 {
+	return int32(len(b.ids))
+}
+
+func bulkEntries(data ...string) []pubsub.BulkMessageEntry {
+	entries := make([]pubsub.BulkMessageEntry, len(data))
+	for i, d := range data {
+		entries[i] = pubsub.BulkMessageEntry{
+			EntryId: d,
+			Event:   []byte(d),
+		}
+	}
+	return entries
+}
+
+func failedIDs(res pubsub.BulkPublishResponse) []string {
+	ids := make([]string, len(res.FailedEntries))
+	for i, e := range res.FailedEntries {
+		ids[i] = e.EntryId
+	}
+	return ids
+}
+
+func TestPublishInBatches(t *testing.T) {
+	newBatch := func() (messageBatch, error) {
+		return &fakeBatch{maxBytes: 4}, nil
+	}
+
+	t.Run("splits entries across batches", func(t *testing.T) {
+		var sent [][]string
+		res := publishInBatches(bulkEntries("a1", "b1", "c1", "d1", "e1"), newBatch, func(b messageBatch) error {
+			sent = append(sent, b.(*fakeBatch).ids)
+			return nil
+		})
+
+		assert.Empty(t, res.FailedEntries)
+		assert.Equal(t, [][]string{{"a1", "b1"}, {"c1", "d1"}, {"e1"}}, sent)
+	})
+
+	t.Run("message larger than a batch fails alone", func(t *testing.T) {
+		var sent [][]string
+		res := publishInBatches(bulkEntries("a1", "toolarge", "b1"), newBatch, func(b messageBatch) error {
+			sent = append(sent, b.(*fakeBatch).ids)
+			return nil
+		})
+
+		require.Len(t, res.FailedEntries, 1)
+		assert.Equal(t, "toolarge", res.FailedEntries[0].EntryId)
+		require.ErrorIs(t, res.FailedEntries[0].Error, azservicebus.ErrMessageTooLarge)
+		assert.Equal(t, [][]string{{"a1"}, {"b1"}}, sent)
+	})
+
+	t.Run("failed batch only fails its entries", func(t *testing.T) {
+		sendErr := errors.New("send failed")
+		calls := 0
+		res := publishInBatches(bulkEntries("a1", "b1", "c1", "d1", "e1"), newBatch, func(b messageBatch) error {
+			calls++
+			if calls == 2 {
+				return sendErr
+			}
+			return nil
+		})
+
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []string{"c1", "d1"}, failedIDs(res))
+		for _, e := range res.FailedEntries {
+			require.ErrorIs(t, e.Error, sendErr)
+		}
+	})
+
+	t.Run("batch creation error fails remaining entries", func(t *testing.T) {
+		createErr := errors.New("cannot create batch")
+		created := 0
+		res := publishInBatches(bulkEntries("a1", "b1", "c1", "d1"), func() (messageBatch, error) {
+			created++
+			if created == 2 {
+				return nil, createErr
+			}
+			return &fakeBatch{maxBytes: 4}, nil
+		}, func(b messageBatch) error {
+			return nil
+		})
+
+		assert.Equal(t, []string{"c1", "d1"}, failedIDs(res))
+	})
+
+	t.Run("empty entries", func(t *testing.T) {
+		res := publishInBatches(nil, newBatch, func(b messageBatch) error {
+			t.Fatal("no batch should be sent")
+			return nil
+		})
+
+		assert.Empty(t, res.FailedEntries)
+	})
+}
@@ -273,8 +273,13 @@ func (s *Subscription) ReceiveBlocking(parentCtx context.Context, handler Handle
 			continue
 		}
 
-		// Handle the messages in background
-		go s.handleAsync(ctx, msgs, handler, receiver)
+		if s.requireSessions {
+			// Messages within a session are handled serially to preserve their order; sessions are handled concurrently by their own receivers
+			s.handleAsync(ctx, msgs, handler, receiver)
+		} else {
+			// Handle the messages in background
+			go s.handleAsync(ctx, msgs, handler, receiver)
+		}
 	}
 }
 
@@ -490,6 +495,7 @@ func (s *Subscription) handleAsync(ctx context.Context, msgs []*azservicebus.Rec
 				finalizeCancel()
 			}(i)
 		}
+		wg.Wait()
 		return
 	}
 
@@ -519,6 +525,7 @@ func (s *Subscription) handleAsync(ctx context.Context, msgs []*azservicebus.Rec
 				finalizeCancel()
 			}(msg)
 		}
+		wg.Wait()
 	}
 }
 
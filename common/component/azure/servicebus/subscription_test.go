@@ -14,7 +14,16 @@ limitations under the License.
 package servicebus
 
 import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
@@ -91,3 +100,82 @@ func TestNewSubscription(t *testing.T) {
 		})
 	}
 }
+
+// fakeReceiver returns the messages one at a time, then an error once they are all received.
+type fakeReceiver struct {
+	lock      sync.Mutex
+	msgs      []*azservicebus.ReceivedMessage
+	completed []string
+}
+
+func (r *fakeReceiver) ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.msgs) == 0 {
+		return nil, context.Canceled
+	}
+	msg := r.msgs[0]
+	r.msgs = r.msgs[1:]
+	return []*azservicebus.ReceivedMessage{msg}, nil
+}
+
+func (r *fakeReceiver) CompleteMessage(ctx context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.CompleteMessageOptions) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.completed = append(r.completed, m.MessageID)
+	return nil
+}
+
+func (r *fakeReceiver) AbandonMessage(ctx context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.AbandonMessageOptions) error {
+	return nil
+}
+
+func (r *fakeReceiver) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestReceiveBlockingSessionOrder(t *testing.T) {
+	receiver := &fakeReceiver{}
+	expected := make([]string, 10)
+	for i := range expected {
+		expected[i] = strconv.Itoa(i)
+		receiver.msgs = append(receiver.msgs, &azservicebus.ReceivedMessage{
+			MessageID:      expected[i],
+			SessionID:      ptr.Of("session"),
+			SequenceNumber: ptr.Of(int64(i)),
+		})
+	}
+
+	sub := NewSubscription(
+		SubscriptionOptions{
+			MaxActiveMessages:     100,
+			TimeoutInSec:          1,
+			MaxRetriableEPS:       10,
+			MaxConcurrentHandlers: 10,
+			Entity:                "test",
+			RequireSessions:       true,
+		},
+		logger.NewLogger("test"),
+	)
+
+	var (
+		lock     sync.Mutex
+		handled  []string
+		inFlight atomic.Int32
+	)
+	handler := func(ctx context.Context, msgs []*azservicebus.ReceivedMessage) ([]HandlerResponseItem, error) {
+		assert.Equal(t, int32(1), inFlight.Add(1), "messages in a session must be handled serially")
+		defer inFlight.Add(-1)
+		time.Sleep(5 * time.Millisecond)
+
+		lock.Lock()
+		handled = append(handled, msgs[0].MessageID)
+		lock.Unlock()
+		return nil, nil
+	}
+
+	err := sub.ReceiveBlocking(context.Background(), handler, receiver, nil, "test")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, expected, handled)
+	assert.Equal(t, expected, receiver.completed)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("no certificates", func(t *testing.T) {
+		config, err := NewTLSConfig("", "", "")
+		require.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("invalid client certificate", func(t *testing.T) {
+		_, err := NewTLSConfig("not a certificate", "not a key", "")
+		require.ErrorContains(t, err, "error parse X509KeyPair")
+	})
+
+	t.Run("CA certificate only", func(t *testing.T) {
+		config, err := NewTLSConfig("", "", "not a certificate")
+		require.NoError(t, err)
+		require.NotNil(t, config)
+		assert.NotNil(t, config.RootCAs)
+		assert.Empty(t, config.Certificates)
+	})
+}
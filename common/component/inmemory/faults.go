@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inmemory contains the fault-injection and persistence helpers shared by the in-memory components.
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrInjectedFault is returned by operations that fail because of fault injection.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultMetadata contains the fault-injection options of the in-memory components.
+// They are meant to simulate flaky backends in tests and local development.
+type FaultMetadata struct {
+	// Probability, between 0 and 1, that an operation fails with an injected error.
+	FaultErrorRate float64 `mapstructure:"faultErrorRate"`
+	// Latency added to every operation.
+	FaultLatency time.Duration `mapstructure:"faultLatency"`
+}
+
+// Validate returns an error if the fault-injection options are invalid.
+func (m FaultMetadata) Validate() error {
+	if err := ValidateRate("faultErrorRate", m.FaultErrorRate); err != nil {
+		return err
+	}
+	if m.FaultLatency < 0 {
+		return errors.New("faultLatency must not be negative")
+	}
+	return nil
+}
+
+// Faults injects errors and latency in the operations of a component.
+// The zero value injects no faults.
+type Faults struct {
+	FaultMetadata
+
+	// Returns a random number in [0, 1); can be overridden for tests.
+	random func() float64
+}
+
+// NewFaults returns a Faults object for the given options.
+func NewFaults(md FaultMetadata) (*Faults, error) {
+	err := md.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Faults{
+		FaultMetadata: md,
+		random:        rand.Float64,
+	}, nil
+}
+
+// Inject waits for the configured latency, then fails with ErrInjectedFault with the configured error rate.
+// It returns the context's error if the context is canceled while waiting.
+func (f *Faults) Inject(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	if f.FaultLatency > 0 {
+		select {
+		case <-time.After(f.FaultLatency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.Happens(f.FaultErrorRate) {
+		return ErrInjectedFault
+	}
+	return nil
+}
+
+// Happens returns true with the given probability.
+func (f *Faults) Happens(rate float64) bool {
+	if f == nil || rate <= 0 {
+		return false
+	}
+	return rate >= 1 || f.random() < rate
+}
+
+// ValidateRate returns an error if the rate of the named option is not between 0 and 1.
+func ValidateRate(name string, rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("%s must be between 0 and 1, got %v", name, rate)
+	}
+	return nil
+}
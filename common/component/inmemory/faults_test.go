@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFaults(t *testing.T) {
+	for _, md := range []FaultMetadata{
+		{FaultErrorRate: -0.1},
+		{FaultErrorRate: 1.5},
+		{FaultLatency: -time.Second},
+	} {
+		_, err := NewFaults(md)
+		require.Errorf(t, err, "expected error for %+v", md)
+	}
+
+	f, err := NewFaults(FaultMetadata{FaultErrorRate: 0.5, FaultLatency: time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, f.FaultErrorRate)
+}
+
+func TestFaultsInject(t *testing.T) {
+	t.Run("nil injects no faults", func(t *testing.T) {
+		var f *Faults
+		require.NoError(t, f.Inject(context.Background()))
+		assert.False(t, f.Happens(1))
+	})
+
+	t.Run("error rate", func(t *testing.T) {
+		f, err := NewFaults(FaultMetadata{FaultErrorRate: 0.3})
+		require.NoError(t, err)
+
+		f.random = func() float64 { return 0.2 }
+		require.ErrorIs(t, f.Inject(context.Background()), ErrInjectedFault)
+
+		f.random = func() float64 { return 0.3 }
+		require.NoError(t, f.Inject(context.Background()))
+	})
+
+	t.Run("always and never", func(t *testing.T) {
+		f := &Faults{random: func() float64 { return 0.99 }}
+		assert.True(t, f.Happens(1))
+		assert.False(t, f.Happens(0))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		f, err := NewFaults(FaultMetadata{FaultLatency: 50 * time.Millisecond})
+		require.NoError(t, err)
+
+		start := time.Now()
+		require.NoError(t, f.Inject(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("latency is interrupted by the context", func(t *testing.T) {
+		f, err := NewFaults(FaultMetadata{FaultLatency: time.Hour})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, f.Inject(ctx), context.Canceled)
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PersistenceMetadata contains the options to persist the data of the in-memory components to disk.
+type PersistenceMetadata struct {
+	// Path of the file where the snapshots are saved. Persistence is disabled if empty.
+	PersistencePath string `mapstructure:"persistencePath"`
+	// Interval between snapshots. If 0, a snapshot is saved only when the component is closed.
+	SnapshotInterval time.Duration `mapstructure:"snapshotInterval"`
+}
+
+// Validate returns an error if the persistence options are invalid.
+func (m PersistenceMetadata) Validate() error {
+	if m.SnapshotInterval < 0 {
+		return errors.New("snapshotInterval must not be negative")
+	}
+	return nil
+}
+
+// Enabled returns true if persistence is enabled.
+func (m PersistenceMetadata) Enabled() bool {
+	return m.PersistencePath != ""
+}
+
+// ReadSnapshot decodes the JSON snapshot stored at path into v.
+// It returns false if there's no snapshot at path.
+func ReadSnapshot(path string, v any) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(v)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// WriteSnapshot saves v as a JSON snapshot at path.
+// The snapshot is written to a temporary file that is then renamed, so an existing snapshot is never left partially written.
+func WriteSnapshot(path string, v any) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	tmpPath := f.Name()
+
+	err = json.NewEncoder(f).Encode(v)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return nil
+}
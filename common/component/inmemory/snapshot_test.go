@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	t.Run("missing snapshot", func(t *testing.T) {
+		var v map[string]string
+		found, err := ReadSnapshot(path, &v)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("write and read", func(t *testing.T) {
+		require.NoError(t, WriteSnapshot(path, map[string]string{"a": "1"}))
+		require.NoError(t, WriteSnapshot(path, map[string]string{"b": "2"}))
+
+		var v map[string]string
+		found, err := ReadSnapshot(path, &v)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, map[string]string{"b": "2"}, v)
+
+		// No temporary files are left behind
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		var v map[string]string
+		_, err := ReadSnapshot(path, &v)
+		require.Error(t, err)
+	})
+
+	t.Run("directory does not exist", func(t *testing.T) {
+		err := WriteSnapshot(filepath.Join(path+"-missing", "snapshot.json"), "x")
+		require.Error(t, err)
+	})
+}
@@ -14,12 +14,13 @@ limitations under the License.
 package kafka
 
 import (
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 
 	"github.com/IBM/sarama"
+
+	"github.com/dapr/components-contrib/common/tlsconfig"
+	"github.com/dapr/kit/logger"
 )
 
 func updatePasswordAuthInfo(config *sarama.Config, metadata *KafkaMetadata, saslUsername, saslPassword string) {
@@ -41,11 +42,7 @@ func updateMTLSAuthInfo(config *sarama.Config, metadata *KafkaMetadata) error {
 	if metadata.TLSDisable {
 		return errors.New("kafka: cannot configure mTLS authentication when TLSDisable is 'true'")
 	}
-	cert, err := tls.X509KeyPair([]byte(metadata.TLSClientCert), []byte(metadata.TLSClientKey))
-	if err != nil {
-		return fmt.Errorf("unable to load client certificate and key pair. Err: %w", err)
-	}
-	config.Net.TLS.Config.Certificates = []tls.Certificate{cert}
+	// The client certificate is added to the TLS configuration by updateTLSConfig
 	return nil
 }
 
@@ -56,25 +53,36 @@ func updateTLSConfig(config *sarama.Config, metadata *KafkaMetadata) error {
 	}
 	config.Net.TLS.Enable = true
 
-	if !metadata.TLSSkipVerify && metadata.TLSCaCert == "" {
-		return nil
+	cfg := tlsconfig.Config{
+		Metadata:           metadata.Metadata,
+		CACert:             metadata.TLSCaCert,
+		InsecureSkipVerify: metadata.TLSSkipVerify,
 	}
-	//nolint:gosec
-	config.Net.TLS.Config = &tls.Config{InsecureSkipVerify: metadata.TLSSkipVerify, MinVersion: tls.VersionTLS12}
-	if metadata.TLSCaCert != "" {
-		caCertPool := x509.NewCertPool()
-		if ok := caCertPool.AppendCertsFromPEM([]byte(metadata.TLSCaCert)); !ok {
-			return errors.New("kafka error: unable to load ca certificate")
-		}
-		config.Net.TLS.Config.RootCAs = caCertPool
+	// Client certificates are used only with mTLS authentication
+	if metadata.AuthType == mtlsAuthType {
+		cfg.ClientCert = metadata.TLSClientCert
+		cfg.ClientKey = metadata.TLSClientKey
+	} else {
+		cfg.ClientCertFile, cfg.ClientKeyFile = "", ""
+	}
+	tlsConfig, err := cfg.Build()
+	if err != nil {
+		return fmt.Errorf("kafka error: %w", err)
 	}
+	config.Net.TLS.Config = tlsConfig
 
 	return nil
 }
 
-func updateOidcAuthInfo(config *sarama.Config, metadata *KafkaMetadata) error {
+func updateOidcAuthInfo(config *sarama.Config, metadata *KafkaMetadata, log logger.Logger) error {
 	tokenProvider := metadata.getOAuthTokenSource()
 
+	var err error
+	tokenProvider.policy, err = metadata.PolicyMetadata.NewPolicy("token refresh", log)
+	if err != nil {
+		return fmt.Errorf("kafka: error configuring oauth token requests: %w", err)
+	}
+
 	if metadata.TLSCaCert != "" {
 		err := tokenProvider.addCa(metadata.TLSCaCert)
 		if err != nil {
@@ -21,11 +21,18 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/IBM/sarama"
+	saramamocks "github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/common/credentials"
+	"github.com/dapr/kit/logger"
 )
 
 func getAuthBaseMetadata() map[string]string {
@@ -123,3 +130,35 @@ func TestAuth(t *testing.T) {
 		require.Nil(t, mockConfig.Net.TLS.Config)
 	})
 }
+
+func TestCheckSASLPasswordFile(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password1"), 0o600))
+	file, err := credentials.NewFile(passwordFile)
+	require.NoError(t, err)
+
+	config := sarama.NewConfig()
+	updatePasswordAuthInfo(config, &KafkaMetadata{}, "user", "password1")
+	k := &Kafka{
+		logger:              logger.NewLogger("kafka_test"),
+		config:              config,
+		saslPasswordFile:    file,
+		saslPasswordVersion: 1,
+		clients: &clients{
+			producer: saramamocks.NewSyncProducer(t, saramamocks.NewTestConfig()),
+		},
+	}
+
+	// Clients are kept while the password doesn't change
+	k.checkSASLPasswordFile()
+	require.NotNil(t, k.clients)
+
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password-2"), 0o600))
+	time.Sleep(1100 * time.Millisecond)
+	k.checkSASLPasswordFile()
+	assert.Nil(t, k.clients)
+	assert.Equal(t, "password-2", k.config.Net.SASL.Password)
+	assert.Equal(t, "user", k.config.Net.SASL.User)
+	// The previous configuration is not modified
+	assert.Equal(t, "password1", config.Net.SASL.Password)
+}
@@ -41,6 +41,10 @@ func (k *Kafka) latestClients() (*clients, error) {
 
 	// case 2: normal static auth profile clients
 	default:
+		k.clientsLock.Lock()
+		defer k.clientsLock.Unlock()
+
+		k.checkSASLPasswordFile()
 		if k.clients != nil {
 			return k.clients, nil
 		}
@@ -62,3 +66,40 @@ func (k *Kafka) latestClients() (*clients, error) {
 		return k.clients, nil
 	}
 }
+
+// checkSASLPasswordFile updates the SASL password if the file it's read from changed, discarding the clients
+// so new ones are created with it. Closing the consumer group makes the consumer reconnect with the new clients.
+// The caller must hold clientsLock.
+func (k *Kafka) checkSASLPasswordFile() {
+	if k.saslPasswordFile == nil || k.closed.Load() {
+		return
+	}
+	password, version, err := k.saslPasswordFile.LoadString()
+	if err != nil {
+		k.logger.Warnf("Failed to read the SASL password file, using the previous password: %v", err)
+	}
+	if version == k.saslPasswordVersion {
+		return
+	}
+
+	k.logger.Info("SASL password file changed, reconnecting to the brokers")
+	config := *k.config
+	config.Net.SASL.Password = password
+	k.config = &config
+	k.saslPassword = password
+	k.saslPasswordVersion = version
+
+	if k.clients != nil {
+		if k.clients.producer != nil {
+			if err = k.clients.producer.Close(); err != nil {
+				k.logger.Warnf("Error closing the Kafka producer: %v", err)
+			}
+		}
+		if k.clients.consumerGroup != nil {
+			if err = k.clients.consumerGroup.Close(); err != nil {
+				k.logger.Warnf("Error closing the Kafka consumer group: %v", err)
+			}
+		}
+		k.clients = nil
+	}
+}
@@ -39,6 +39,7 @@ func notifyRecover(consumer *consumer, message *sarama.ConsumerMessage, session
 			return consumer.doCallback(session, message)
 		}, b, func(err error, d time.Duration) {
 			consumer.k.logger.Warnf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+			consumer.k.Metrics.RecordRetry(session.Context(), "consume")
 		}, func() {
 			consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
 		}); err != nil {
@@ -57,6 +58,11 @@ func notifyRecover(consumer *consumer, message *sarama.ConsumerMessage, session
 }
 
 func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if !consumer.k.startClaim() {
+		return nil
+	}
+	defer consumer.k.claimsWG.Done()
+
 	b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
 	isBulkSubscribe := consumer.k.checkBulkSubscribe(claim.Topic())
 
@@ -69,12 +75,20 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		defer ticker.Stop()
 		messages := make([]*sarama.ConsumerMessage, 0, handlerConfig.SubscribeConfig.MaxMessagesCount)
 		for {
+			if consumer.isDraining() {
+				err = consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
+				session.Commit()
+				return err
+			}
 			select {
 			case <-session.Context().Done():
 				return consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
+			case <-consumer.k.drainCh:
+				// Handled at the beginning of the loop
 			case message := <-claim.Messages():
 				consumer.mutex.Lock()
 				if message != nil {
+					consumer.recordLag(claim, message)
 					messages = append(messages, message)
 					if len(messages) >= handlerConfig.SubscribeConfig.MaxMessagesCount {
 						consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
@@ -91,7 +105,14 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		}
 	} else {
 		for {
+			// When draining, the message being processed is handled, then the offsets are committed before the consumer is stopped
+			if consumer.isDraining() {
+				session.Commit()
+				return nil
+			}
 			select {
+			case <-consumer.k.drainCh:
+				// Handled at the beginning of the loop
 			// Should return when `session.Context()` is done.
 			// If not, will raise `ErrRebalanceInProgress` or `read tcp <ip>:<port>: i/o timeout` when kafka rebalance. see:
 			// https://github.com/IBM/sarama/issues/1192
@@ -105,15 +126,18 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 				if !ok {
 					return nil
 				}
+				consumer.recordLag(claim, message)
 
 				if consumer.k.consumeRetryEnabled {
 					if err := notifyRecover(consumer, message, session, b); err != nil {
 						consumer.k.logger.Errorf("Too many failed attempts at processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+						consumer.deadLetter(session, message, err)
 					}
 				} else {
 					err := consumer.doCallback(session, message)
 					if err != nil {
 						consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+						consumer.deadLetter(session, message, err)
 					}
 				}
 			}
@@ -121,6 +145,37 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 	}
 }
 
+// deadLetter sends a message that failed processing to the dead-letter topic, if one is configured, and marks it as consumed.
+// Messages are not dead-lettered when processing was interrupted because the session ended.
+func (consumer *consumer) deadLetter(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, reason error) {
+	if consumer.k.deadLetterTopic == "" || session.Context().Err() != nil {
+		return
+	}
+
+	err := consumer.k.sendToDeadLetter(session.Context(), message, reason)
+	if err != nil {
+		consumer.k.logger.Errorf("Failed to send Kafka message %s/%d/%d [key=%s] to dead-letter topic %s. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), consumer.k.deadLetterTopic, err)
+		return
+	}
+	consumer.k.logger.Warnf("Sent Kafka message %s/%d/%d [key=%s] to dead-letter topic %s", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), consumer.k.deadLetterTopic)
+	session.MarkMessage(message, "")
+}
+
+// isDraining returns true if the component is draining before closing.
+func (consumer *consumer) isDraining() bool {
+	select {
+	case <-consumer.k.drainCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordLag records the number of messages in the partition after the one being processed.
+func (consumer *consumer) recordLag(claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage) {
+	consumer.k.Metrics.RecordLag(claim.Topic(), claim.Partition(), claim.HighWaterMarkOffset()-message.Offset-1)
+}
+
 func (consumer *consumer) flushBulkMessages(claim sarama.ConsumerGroupClaim,
 	messages []*sarama.ConsumerMessage, session sarama.ConsumerGroupSession,
 	handler BulkEventHandler, b backoff.BackOff,
@@ -131,6 +186,7 @@ func (consumer *consumer) flushBulkMessages(claim sarama.ConsumerGroupClaim,
 				return consumer.doBulkCallback(session, messages, handler, claim.Topic())
 			}, b, func(err error, d time.Duration) {
 				consumer.k.logger.Warnf("Error processing Kafka bulk messages: %s. Error: %v. Retrying...", claim.Topic(), err)
+				consumer.k.Metrics.RecordRetry(session.Context(), "bulkConsume")
 			}, func() {
 				consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s", claim.Topic())
 			}); err != nil {
@@ -176,7 +232,9 @@ func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession,
 		Topic:   topic,
 		Entries: messageValues,
 	}
+	done := consumer.k.Metrics.StartOperation(session.Context(), "bulkConsume")
 	responses, err := handler(session.Context(), &event)
+	done(err)
 
 	if err != nil {
 		for i, resp := range responses {
@@ -217,7 +275,9 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 	}
 	event.Metadata = GetEventMetadata(message, consumer.k.escapeHeaders)
 
+	done := consumer.k.Metrics.StartOperation(session.Context(), "consume")
 	err = handlerConfig.Handler(session.Context(), &event)
+	done(err)
 	if err == nil {
 		session.MarkMessage(message, "")
 	}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers added to messages sent to the dead-letter topic.
+const (
+	deadLetterReasonHeader            = "dapr-dead-letter-reason"
+	deadLetterOriginalTopicHeader     = "dapr-dead-letter-original-topic"
+	deadLetterOriginalPartitionHeader = "dapr-dead-letter-original-partition"
+	deadLetterOriginalOffsetHeader    = "dapr-dead-letter-original-offset"
+)
+
+// sendToDeadLetter publishes a message that could not be processed to the dead-letter topic.
+// The key, value and headers of the original message are preserved, and headers recording the failure reason and where the message came from are added.
+func (k *Kafka) sendToDeadLetter(ctx context.Context, message *sarama.ConsumerMessage, reason error) error {
+	clients, err := k.latestClients()
+	if err != nil || clients == nil {
+		return fmt.Errorf("failed to get latest Kafka clients: %w", err)
+	}
+	if clients.producer == nil {
+		return errors.New("component is closed")
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+4)
+	for _, h := range message.Headers {
+		if h != nil {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(deadLetterReasonHeader), Value: []byte(reason.Error())},
+		sarama.RecordHeader{Key: []byte(deadLetterOriginalTopicHeader), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(deadLetterOriginalPartitionHeader), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(deadLetterOriginalOffsetHeader), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+	)
+
+	msg := &sarama.ProducerMessage{
+		Topic:   k.deadLetterTopic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	done := k.Metrics.StartOperation(ctx, "deadLetter")
+	_, _, err = clients.producer.SendMessage(msg)
+	done(err)
+	return err
+}
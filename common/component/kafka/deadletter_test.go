@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	saramamocks "github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+type fakeConsumerGroupSession struct {
+	sarama.ConsumerGroupSession
+
+	ctx    context.Context
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *fakeConsumerGroupSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg)
+}
+
+func TestDeadLetter(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte("mykey"),
+		Value:     []byte("poison"),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("h1"), Value: []byte("v1")},
+		},
+	}
+
+	t.Run("message is sent to the dead-letter topic and marked", func(t *testing.T) {
+		mockP := saramamocks.NewSyncProducer(t, saramamocks.NewTestConfig())
+		mockP.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, "orders-dlq", msg.Topic)
+			assert.Equal(t, sarama.ByteEncoder("mykey"), msg.Key)
+			assert.Equal(t, sarama.ByteEncoder("poison"), msg.Value)
+			assert.ElementsMatch(t, []sarama.RecordHeader{
+				{Key: []byte("h1"), Value: []byte("v1")},
+				{Key: []byte(deadLetterReasonHeader), Value: []byte("failed to process")},
+				{Key: []byte(deadLetterOriginalTopicHeader), Value: []byte("orders")},
+				{Key: []byte(deadLetterOriginalPartitionHeader), Value: []byte("3")},
+				{Key: []byte(deadLetterOriginalOffsetHeader), Value: []byte("42")},
+			}, msg.Headers)
+			return nil
+		})
+		c := &consumer{k: &Kafka{
+			mockProducer:    mockP,
+			logger:          logger.NewLogger("kafka_test"),
+			deadLetterTopic: "orders-dlq",
+		}}
+		session := &fakeConsumerGroupSession{ctx: context.Background()}
+
+		c.deadLetter(session, message, errors.New("failed to process"))
+		require.Equal(t, []*sarama.ConsumerMessage{message}, session.marked)
+		require.NoError(t, mockP.Close())
+	})
+
+	t.Run("message is not marked if it can't be sent", func(t *testing.T) {
+		mockP := saramamocks.NewSyncProducer(t, saramamocks.NewTestConfig())
+		mockP.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+		c := &consumer{k: &Kafka{
+			mockProducer:    mockP,
+			logger:          logger.NewLogger("kafka_test"),
+			deadLetterTopic: "orders-dlq",
+		}}
+		session := &fakeConsumerGroupSession{ctx: context.Background()}
+
+		c.deadLetter(session, message, errors.New("failed to process"))
+		require.Empty(t, session.marked)
+		require.NoError(t, mockP.Close())
+	})
+
+	t.Run("nothing is sent without a dead-letter topic or when the session ended", func(t *testing.T) {
+		mockP := saramamocks.NewSyncProducer(t, saramamocks.NewTestConfig())
+		k := &Kafka{
+			mockProducer: mockP,
+			logger:       logger.NewLogger("kafka_test"),
+		}
+		c := &consumer{k: k}
+		session := &fakeConsumerGroupSession{ctx: context.Background()}
+		c.deadLetter(session, message, errors.New("failed to process"))
+		require.Empty(t, session.marked)
+
+		k.deadLetterTopic = "orders-dlq"
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		session = &fakeConsumerGroupSession{ctx: ctx}
+		c.deadLetter(session, message, errors.New("failed to process"))
+		require.Empty(t, session.marked)
+		require.NoError(t, mockP.Close())
+	})
+}
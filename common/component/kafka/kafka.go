@@ -29,6 +29,8 @@ import (
 	"github.com/riferrei/srclient"
 
 	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/common/credentials"
+	"github.com/dapr/components-contrib/common/metrics"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	kitmd "github.com/dapr/kit/metadata"
@@ -54,6 +56,11 @@ type Kafka struct {
 	escapeHeaders   bool
 	awsAuthProvider awsAuth.Provider
 
+	// Set when the SASL password is read from a file, to replace the clients when the password is rotated
+	saslPasswordFile    *credentials.File
+	saslPasswordVersion uint64
+	clientsLock         sync.Mutex
+
 	subscribeTopics TopicHandlerConfig
 	subscribeLock   sync.Mutex
 	consumerCancel  context.CancelFunc
@@ -62,6 +69,12 @@ type Kafka struct {
 	closed          atomic.Bool
 	wg              sync.WaitGroup
 
+	// Closed when the component starts draining before closing, to stop consuming new messages
+	drainCh   chan struct{}
+	drainLock sync.Mutex
+	draining  bool
+	claimsWG  sync.WaitGroup
+
 	// schema registry settings
 	srClient                   srclient.ISchemaRegistryClient
 	schemaCachingEnabled       bool
@@ -81,6 +94,11 @@ type Kafka struct {
 	DefaultConsumeRetryEnabled bool
 	consumeRetryEnabled        bool
 	consumeRetryInterval       time.Duration
+	deadLetterTopic            string
+	pingTimeout                time.Duration
+
+	// Records the metrics of the component; if nil, no metrics are recorded
+	Metrics *metrics.Recorder
 }
 
 type SchemaType int
@@ -90,6 +108,12 @@ const (
 	Avro
 )
 
+// Default maximum time to wait for the cluster when pinging it
+const defaultPingTimeout = 10 * time.Second
+
+// Maximum time Close waits for the messages being processed
+const defaultCloseTimeout = 5 * time.Second
+
 type SchemaCacheEntry struct {
 	schema         *srclient.Schema
 	codec          *goavro.Codec
@@ -121,6 +145,7 @@ func NewKafka(logger logger.Logger) *Kafka {
 		logger:          logger,
 		subscribeTopics: make(TopicHandlerConfig),
 		closeCh:         make(chan struct{}),
+		drainCh:         make(chan struct{}),
 	}
 }
 
@@ -148,11 +173,11 @@ func (k *Kafka) Init(ctx context.Context, metadata map[string]string) error {
 	config := sarama.NewConfig()
 	config.Version = meta.internalVersion
 	config.Consumer.Offsets.Initial = k.initialOffset
-	config.Consumer.Fetch.Min = meta.consumerFetchMin
-	config.Consumer.Fetch.Default = meta.consumerFetchDefault
+	config.Consumer.Fetch.Min = meta.ConsumerFetchMin
+	config.Consumer.Fetch.Default = meta.ConsumerFetchDefault
 	config.Consumer.Group.Heartbeat.Interval = meta.HeartbeatInterval
 	config.Consumer.Group.Session.Timeout = meta.SessionTimeout
-	config.ChannelBufferSize = meta.channelBufferSize
+	config.ChannelBufferSize = meta.ChannelBufferSize
 
 	config.Net.KeepAlive = meta.ClientConnectionKeepAliveInterval
 	config.Metadata.RefreshFrequency = meta.ClientConnectionTopicMetadataRefreshInterval
@@ -169,7 +194,7 @@ func (k *Kafka) Init(ctx context.Context, metadata map[string]string) error {
 	switch strings.ToLower(k.authType) {
 	case oidcAuthType:
 		k.logger.Info("Configuring SASL OAuth2/OIDC authentication")
-		err = updateOidcAuthInfo(config, meta)
+		err = updateOidcAuthInfo(config, meta, k.logger)
 		if err != nil {
 			return err
 		}
@@ -177,6 +202,13 @@ func (k *Kafka) Init(ctx context.Context, metadata map[string]string) error {
 		k.logger.Info("Configuring SASL Password authentication")
 		k.saslUsername = meta.SaslUsername
 		k.saslPassword = meta.SaslPassword
+		if meta.SaslPasswordFile != "" {
+			k.saslPasswordFile, err = credentials.NewFile(meta.SaslPasswordFile)
+			if err != nil {
+				return fmt.Errorf("kafka error: %w", err)
+			}
+			k.saslPassword, k.saslPasswordVersion, _ = k.saslPasswordFile.LoadString()
+		}
 		updatePasswordAuthInfo(config, meta, k.saslUsername, k.saslPassword)
 	case mtlsAuthType:
 		k.logger.Info("Configuring mTLS authentcation")
@@ -223,8 +255,18 @@ func (k *Kafka) Init(ctx context.Context, metadata map[string]string) error {
 		"backOff"); rerr != nil {
 		return rerr
 	}
+	// maxRetries and retryBackoff take precedence over the backOff properties
+	if meta.MaxRetries >= 0 {
+		k.backOffConfig.MaxRetries = int64(meta.MaxRetries)
+	}
+	if meta.RetryBackoff > 0 {
+		k.backOffConfig.Policy = retry.PolicyConstant
+		k.backOffConfig.Duration = meta.RetryBackoff
+	}
 	k.consumeRetryEnabled = meta.ConsumeRetryEnabled
 	k.consumeRetryInterval = meta.ConsumeRetryInterval
+	k.deadLetterTopic = meta.DeadLetterTopic
+	k.pingTimeout = meta.PingTimeout
 
 	if meta.SchemaRegistryURL != "" {
 		k.logger.Infof("Schema registry URL '%s' provided. Configuring the Schema Registry client.", meta.SchemaRegistryURL)
@@ -282,11 +324,100 @@ func (k *Kafka) ValidateAWS(metadata map[string]string) (*awsAuth.DeprecatedKafk
 	}, nil
 }
 
+// Ping checks the connection to the Kafka cluster by fetching its metadata.
+func (k *Kafka) Ping(ctx context.Context) error {
+	k.clientsLock.Lock()
+	config := k.config
+	k.clientsLock.Unlock()
+	if config == nil {
+		return errors.New("kafka: component is not initialized")
+	}
+
+	timeout := k.pingTimeout
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Sarama doesn't accept a context, so the request is abandoned if it doesn't complete in time
+	errCh := make(chan error, 1)
+	go func() {
+		client, err := sarama.NewClient(k.brokers, config)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer client.Close()
+		errCh <- client.RefreshMetadata()
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("kafka: error connecting to the brokers: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the component, waiting up to defaultCloseTimeout for the messages being processed.
 func (k *Kafka) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return k.CloseWithContext(ctx)
+}
+
+// CloseWithContext stops consuming new messages and waits until the messages being processed are handled and their offsets committed, or until ctx is done.
+// Then it closes the connections.
+func (k *Kafka) CloseWithContext(ctx context.Context) error {
+	k.drain(ctx)
+	return k.close()
+}
+
+// drain stops the consumers from reading new messages and waits for the claims being processed to complete.
+func (k *Kafka) drain(ctx context.Context) {
+	k.drainLock.Lock()
+	if k.draining || k.drainCh == nil {
+		k.drainLock.Unlock()
+		return
+	}
+	k.draining = true
+	close(k.drainCh)
+	k.drainLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		k.claimsWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		k.logger.Warnf("Timed out waiting for Kafka messages being processed: %v", ctx.Err())
+	}
+}
+
+// startClaim registers a claim being processed, returning false if the component is draining.
+func (k *Kafka) startClaim() bool {
+	k.drainLock.Lock()
+	defer k.drainLock.Unlock()
+	if k.draining {
+		return false
+	}
+	k.claimsWG.Add(1)
+	return true
+}
+
+func (k *Kafka) close() error {
 	defer k.wg.Wait()
 	defer k.consumerWG.Wait()
 
-	errs := make([]error, 3)
+	errs := make([]error, 4)
 	if k.closed.CompareAndSwap(false, true) {
 		if k.closeCh != nil {
 			close(k.closeCh)
@@ -301,6 +432,7 @@ func (k *Kafka) Close() error {
 		}
 		k.subscribeLock.Unlock()
 
+		k.clientsLock.Lock()
 		if k.clients != nil {
 			if k.clients.producer != nil {
 				errs[0] = k.clients.producer.Close()
@@ -311,10 +443,12 @@ func (k *Kafka) Close() error {
 				k.clients.consumerGroup = nil
 			}
 		}
+		k.clientsLock.Unlock()
 		if k.awsAuthProvider != nil {
 			errs[2] = k.awsAuthProvider.Close()
 			k.awsAuthProvider = nil
 		}
+		errs[3] = k.Metrics.Close()
 	}
 
 	return errors.Join(errs...)
@@ -1,9 +1,12 @@
 package kafka
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"io"
+	"net"
 	"testing"
 	"time"
 
@@ -432,3 +435,64 @@ func TestValidateAWS(t *testing.T) {
 		})
 	}
 }
+
+func TestPing(t *testing.T) {
+	t.Run("broker reachable", func(t *testing.T) {
+		broker := sarama.NewMockBroker(t, 1)
+		defer broker.Close()
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetController(broker.BrokerID()),
+		})
+
+		k := &Kafka{
+			brokers: []string{broker.Addr()},
+			config:  sarama.NewConfig(),
+		}
+		require.NoError(t, k.Ping(context.Background()))
+	})
+
+	t.Run("broker unreachable", func(t *testing.T) {
+		config := sarama.NewConfig()
+		config.Metadata.Retry.Max = 0
+		k := &Kafka{
+			brokers: []string{"127.0.0.1:1"},
+			config:  config,
+		}
+		require.Error(t, k.Ping(context.Background()))
+	})
+
+	t.Run("broker not responding", func(t *testing.T) {
+		// Accepts connections but never replies
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					_, _ = io.Copy(io.Discard, conn)
+				}()
+			}
+		}()
+
+		k := &Kafka{
+			brokers:     []string{listener.Addr().String()},
+			config:      sarama.NewConfig(),
+			pingTimeout: 100 * time.Millisecond,
+		}
+		start := time.Now()
+		require.ErrorIs(t, k.Ping(context.Background()), context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("not initialized", func(t *testing.T) {
+		k := &Kafka{}
+		require.Error(t, k.Ping(context.Background()))
+	})
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/IBM/sarama"
 
+	"github.com/dapr/components-contrib/common/resiliency"
+	"github.com/dapr/components-contrib/common/tlsconfig"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/metadata"
 )
 
@@ -62,17 +65,22 @@ const (
 )
 
 type KafkaMetadata struct {
-	Brokers                string              `mapstructure:"brokers"`
+	tlsconfig.Metadata `mapstructure:",squash"`
+	// Resiliency policy for requesting OAuth2 tokens
+	resiliency.PolicyMetadata `mapstructure:",squash"`
+
+	Brokers                string              `mapstructure:"brokers" mdrequired:"true"`
 	internalBrokers        []string            `mapstructure:"-"`
 	ConsumerGroup          string              `mapstructure:"consumerGroup"`
 	ClientID               string              `mapstructure:"clientId"`
-	AuthType               string              `mapstructure:"authType"`
+	AuthType               string              `mapstructure:"authType" mdrequired:"true" mdallowedvalues:"none,password,oidc,mtls,certificate,awsiam"`
 	SaslUsername           string              `mapstructure:"saslUsername"`
 	SaslPassword           string              `mapstructure:"saslPassword"`
+	SaslPasswordFile       string              `mapstructure:"saslPasswordFile"`
 	SaslMechanism          string              `mapstructure:"saslMechanism"`
 	InitialOffset          string              `mapstructure:"initialOffset"`
 	internalInitialOffset  int64               `mapstructure:"-"`
-	MaxMessageBytes        int                 `mapstructure:"maxMessageBytes"`
+	MaxMessageBytes        int                 `mapstructure:"maxMessageBytes" mdbytesize:"true"`
 	OidcTokenEndpoint      string              `mapstructure:"oidcTokenEndpoint"`
 	OidcClientID           string              `mapstructure:"oidcClientID"`
 	OidcClientSecret       string              `mapstructure:"oidcClientSecret"`
@@ -86,10 +94,14 @@ type KafkaMetadata struct {
 	TLSClientKey           string              `mapstructure:"clientKey"`
 	ConsumeRetryEnabled    bool                `mapstructure:"consumeRetryEnabled"`
 	ConsumeRetryInterval   time.Duration       `mapstructure:"consumeRetryInterval"`
+	MaxRetries             int                 `mapstructure:"maxRetries"`
+	RetryBackoff           time.Duration       `mapstructure:"retryBackoff"`
+	DeadLetterTopic        string              `mapstructure:"deadLetterTopic"`
 	HeartbeatInterval      time.Duration       `mapstructure:"heartbeatInterval"`
 	SessionTimeout         time.Duration       `mapstructure:"sessionTimeout"`
 	Version                string              `mapstructure:"version"`
 	EscapeHeaders          bool                `mapstructure:"escapeHeaders"`
+	PingTimeout            time.Duration       `mapstructure:"pingTimeout"`
 	internalVersion        sarama.KafkaVersion `mapstructure:"-"`
 	internalOidcExtensions map[string]string   `mapstructure:"-"`
 
@@ -97,10 +109,10 @@ type KafkaMetadata struct {
 	ClientConnectionTopicMetadataRefreshInterval time.Duration `mapstructure:"clientConnectionTopicMetadataRefreshInterval"`
 	ClientConnectionKeepAliveInterval            time.Duration `mapstructure:"clientConnectionKeepAliveInterval"`
 
-	channelBufferSize int `mapstructure:"-"`
+	ChannelBufferSize int `mapstructure:"channelBufferSize" mdmin:"0"`
 
-	consumerFetchMin     int32 `mapstructure:"-"`
-	consumerFetchDefault int32 `mapstructure:"-"`
+	ConsumerFetchMin     int32 `mapstructure:"consumerFetchMin" mdbytesize:"true" mdmin:"1"`
+	ConsumerFetchDefault int32 `mapstructure:"consumerFetchDefault" mdbytesize:"true" mdmin:"1"`
 
 	// schema registry
 	SchemaRegistryURL           string        `mapstructure:"schemaRegistryURL"`
@@ -118,6 +130,9 @@ func (k *Kafka) upgradeMetadata(meta map[string]string) (map[string]string, erro
 	}
 	authReqVal, authReqOk := metadata.GetMetadataProperty(meta, "authRequired")
 	saslPassVal, saslPassOk := metadata.GetMetadataProperty(meta, "saslPassword")
+	if !saslPassOk || saslPassVal == "" {
+		saslPassVal, saslPassOk = metadata.GetMetadataProperty(meta, "saslPasswordFile")
+	}
 
 	// If authType is not set, derive it from authRequired.
 	if (!authTypeOk || authTypeVal == "") && authReqOk && authReqVal != "" {
@@ -146,12 +161,13 @@ func (k *Kafka) upgradeMetadata(meta map[string]string) (map[string]string, erro
 // getKafkaMetadata returns new Kafka metadata.
 func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error) {
 	m := KafkaMetadata{
-		ConsumeRetryEnabled:                          k.DefaultConsumeRetryEnabled,
-		ConsumeRetryInterval:                         100 * time.Millisecond,
-		internalVersion:                              sarama.V2_0_0_0, //nolint:nosnakecase
-		channelBufferSize:                            256,
-		consumerFetchMin:                             1,
-		consumerFetchDefault:                         1024 * 1024,
+		ConsumeRetryEnabled:  k.DefaultConsumeRetryEnabled,
+		ConsumeRetryInterval: 100 * time.Millisecond,
+		MaxRetries:           -1,
+		internalVersion:      sarama.V2_0_0_0, //nolint:nosnakecase
+		ChannelBufferSize:    256,
+		ConsumerFetchMin:     1,
+		ConsumerFetchDefault: 1024 * 1024,
 		ClientConnectionTopicMetadataRefreshInterval: defaultClientConnectionTopicMetadataRefreshInterval,
 		ClientConnectionKeepAliveInterval:            defaultClientConnectionKeepAliveInterval,
 		HeartbeatInterval:                            3 * time.Second,
@@ -159,11 +175,12 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		SchemaCachingEnabled:                         true,
 		SchemaLatestVersionCacheTTL:                  5 * time.Minute,
 		EscapeHeaders:                                false,
+		PingTimeout:                                  defaultPingTimeout,
 	}
 
-	err := metadata.DecodeMetadata(meta, &m)
+	err := contribMetadata.DecodeAndValidate(meta, &m)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("kafka error: %w", err)
 	}
 
 	// If ConsumerGroup is not set, use the 'consumerID' (which can be set by the runtime) as the consumer group so that each dapr runtime creates its own consumergroup
@@ -184,11 +201,7 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 	}
 	m.internalInitialOffset = initialOffset
 
-	if m.Brokers != "" {
-		m.internalBrokers = strings.Split(m.Brokers, ",")
-	} else {
-		return nil, errors.New("kafka error: missing 'brokers' attribute")
-	}
+	m.internalBrokers = strings.Split(m.Brokers, ",")
 
 	k.logger.Debugf("Found brokers: %v", m.internalBrokers)
 
@@ -199,17 +212,13 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		m.TLSCaCert = val
 	}
 
-	if m.AuthType == "" {
-		return nil, errors.New("kafka error: 'authType' attribute was missing or empty")
-	}
-
 	switch strings.ToLower(m.AuthType) {
 	case passwordAuthType:
 		if m.SaslUsername == "" {
 			return nil, errors.New("kafka error: missing SASL Username for authType 'password'")
 		}
 
-		if m.SaslPassword == "" {
+		if m.SaslPassword == "" && m.SaslPasswordFile == "" {
 			return nil, errors.New("kafka error: missing SASL Password for authType 'password'")
 		}
 		k.logger.Debug("Configuring SASL password authentication.")
@@ -294,33 +303,6 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		m.internalVersion = version
 	}
 
-	if val, ok := meta[channelBufferSize]; ok && val != "" {
-		v, err := strconv.Atoi(val)
-		if err != nil {
-			return nil, err
-		}
-
-		m.channelBufferSize = v
-	}
-
-	if val, ok := meta[consumerFetchDefault]; ok && val != "" {
-		v, err := strconv.ParseInt(val, 10, 32)
-		if err != nil {
-			return nil, err
-		}
-
-		m.consumerFetchDefault = int32(v)
-	}
-
-	if val, ok := meta[consumerFetchMin]; ok && val != "" {
-		v, err := strconv.ParseInt(val, 10, 32)
-		if err != nil {
-			return nil, err
-		}
-
-		m.consumerFetchMin = int32(v)
-	}
-
 	// confirm client connection fields are valid
 	if m.ClientConnectionTopicMetadataRefreshInterval <= 0 {
 		m.ClientConnectionTopicMetadataRefreshInterval = defaultClientConnectionTopicMetadataRefreshInterval
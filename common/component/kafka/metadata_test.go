@@ -132,9 +132,9 @@ func assertMetadata(t *testing.T, meta *KafkaMetadata) {
 	require.Equal(t, clientKeyMock, meta.TLSClientKey)
 	require.Equal(t, caCertMock, meta.TLSCaCert)
 	require.Equal(t, 200*time.Millisecond, meta.ConsumeRetryInterval)
-	require.Equal(t, int32(1024*1024), meta.consumerFetchDefault)
-	require.Equal(t, int32(1), meta.consumerFetchMin)
-	require.Equal(t, 256, meta.channelBufferSize)
+	require.Equal(t, int32(1024*1024), meta.ConsumerFetchDefault)
+	require.Equal(t, int32(1), meta.ConsumerFetchMin)
+	require.Equal(t, 256, meta.ChannelBufferSize)
 	require.Equal(t, 2*time.Second, meta.HeartbeatInterval)
 	require.Equal(t, 30*time.Second, meta.SessionTimeout)
 	require.Equal(t, 8*time.Minute, defaultClientConnectionTopicMetadataRefreshInterval)
@@ -148,7 +148,7 @@ func TestMissingBrokers(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, meta)
 
-	require.Equal(t, "kafka error: missing 'brokers' attribute", err.Error())
+	require.Equal(t, "kafka error: invalid metadata: 'brokers': required property is missing; 'authType': required property is missing", err.Error())
 }
 
 func TestMissingAuthType(t *testing.T) {
@@ -158,7 +158,7 @@ func TestMissingAuthType(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, meta)
 
-	require.Equal(t, "kafka error: 'authType' attribute was missing or empty", err.Error())
+	require.Equal(t, "kafka error: invalid metadata: 'authType': required property is missing", err.Error())
 }
 
 func TestMetadataUpgradeNoAuth(t *testing.T) {
@@ -384,8 +384,35 @@ func TestMetadataConsumerFetchValues(t *testing.T) {
 
 	meta, err := k.getKafkaMetadata(m)
 	require.NoError(t, err)
-	require.Equal(t, int32(3), meta.consumerFetchMin)
-	require.Equal(t, int32(2048), meta.consumerFetchDefault)
+	require.Equal(t, int32(3), meta.ConsumerFetchMin)
+	require.Equal(t, int32(2048), meta.ConsumerFetchDefault)
+}
+
+func TestMetadataByteSizes(t *testing.T) {
+	k := getKafka()
+
+	t.Run("sizes with units", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["consumerFetchDefault"] = "2MiB"
+		m["maxMessageBytes"] = "1MB"
+
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.Equal(t, int32(2*1024*1024), meta.ConsumerFetchDefault)
+		require.Equal(t, 1_000_000, meta.MaxMessageBytes)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["consumerFetchMin"] = "0"
+		m["consumerFetchDefault"] = "lots"
+		m["channelBufferSize"] = "-1"
+
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "'consumerFetchMin': value must be at least 1")
+		require.ErrorContains(t, err, "'consumerFetchDefault': invalid size 'lots'")
+		require.ErrorContains(t, err, "'channelBufferSize': value must be at least 0")
+	})
 }
 
 func TestMetadataProducerValues(t *testing.T) {
@@ -431,7 +458,7 @@ func TestMetadataChannelBufferSize(t *testing.T) {
 
 	meta, err := k.getKafkaMetadata(m)
 	require.NoError(t, err)
-	require.Equal(t, 128, meta.channelBufferSize)
+	require.Equal(t, 128, meta.ChannelBufferSize)
 }
 
 func TestMetadataHeartbeartInterval(t *testing.T) {
@@ -492,6 +519,35 @@ func TestMetadataSessionTimeout(t *testing.T) {
 	})
 }
 
+func TestMetadataPingTimeout(t *testing.T) {
+	k := getKafka()
+
+	t.Run("default ping timeout", func(t *testing.T) {
+		// arrange
+		m := getBaseMetadata()
+
+		// act
+		meta, err := k.getKafkaMetadata(m)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, defaultPingTimeout, meta.PingTimeout)
+	})
+
+	t.Run("with ping timeout set", func(t *testing.T) {
+		// arrange
+		m := getBaseMetadata()
+		m["pingTimeout"] = "2s"
+
+		// act
+		meta, err := k.getKafkaMetadata(m)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 2*time.Second, meta.PingTimeout)
+	})
+}
+
 func TestGetEventMetadata(t *testing.T) {
 	ts := time.Now()
 
@@ -48,7 +48,7 @@ func GetSyncProducer(config sarama.Config, brokers []string, maxMessageBytes int
 }
 
 // Publish message to Kafka cluster.
-func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata map[string]string) error {
+func (k *Kafka) Publish(ctx context.Context, topic string, data []byte, metadata map[string]string) error {
 	clients, err := k.latestClients()
 	if err != nil || clients == nil {
 		return fmt.Errorf("failed to get latest Kafka clients: %w", err)
@@ -84,7 +84,9 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 		})
 	}
 
+	done := k.Metrics.StartOperation(ctx, "publish")
 	partition, offset, err := clients.producer.SendMessage(msg)
+	done(err)
 
 	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
 
@@ -95,7 +97,7 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 	return nil
 }
 
-func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
+func (k *Kafka) BulkPublish(ctx context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
 	clients, err := k.latestClients()
 	if err != nil || clients == nil {
 		err = fmt.Errorf("failed to get latest Kafka clients: %w", err)
@@ -150,7 +152,10 @@ func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.Bu
 		msgs = append(msgs, msg)
 	}
 
-	if err := clients.producer.SendMessages(msgs); err != nil {
+	done := k.Metrics.StartOperation(ctx, "bulkPublish")
+	err = clients.producer.SendMessages(msgs)
+	done(err)
+	if err != nil {
 		// map the returned error to different entries
 		return k.mapKafkaProducerErrors(err, entries), err
 	}
@@ -21,11 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 	"golang.org/x/oauth2"
 	ccred "golang.org/x/oauth2/clientcredentials"
+
+	"github.com/dapr/components-contrib/common/resiliency"
 )
 
 type OAuthTokenSource struct {
@@ -38,6 +41,9 @@ type OAuthTokenSource struct {
 	httpClient    *http.Client
 	trustedCas    []*x509.Certificate
 	skipCaVerify  bool
+	policy        *resiliency.Policy
+	// Token is called concurrently by the connections to the brokers
+	lock sync.Mutex
 }
 
 func (m KafkaMetadata) getOAuthTokenSource() *OAuthTokenSource {
@@ -104,7 +110,11 @@ func (ts *OAuthTokenSource) configureClient() {
 	}
 }
 
+// Token returns the cached access token, requesting a new one from the token endpoint if it is about to expire.
 func (ts *OAuthTokenSource) Token() (*sarama.AccessToken, error) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
 	if ts.CachedToken.Valid() {
 		return ts.asSaramaToken(), nil
 	}
@@ -122,7 +132,9 @@ func (ts *OAuthTokenSource) Token() (*sarama.AccessToken, error) {
 
 	timeoutCtx = ctx.WithValue(timeoutCtx, oauth2.HTTPClient, ts.httpClient)
 
-	token, err := oidcCfg.Token(timeoutCtx)
+	token, err := resiliency.RunWithData(timeoutCtx, ts.policy, func(c ctx.Context) (*oauth2.Token, error) {
+		return oidcCfg.Token(c)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error generating oauth2 token: %w", err)
 	}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthTokenSource(t *testing.T) {
+	newTokenSource := func(t *testing.T, expiresIn int) (*OAuthTokenSource, *atomic.Int32) {
+		requests := &atomic.Int32{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := requests.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+		}))
+		t.Cleanup(server.Close)
+
+		ts := KafkaMetadata{
+			OidcTokenEndpoint:      server.URL,
+			OidcClientID:           "client",
+			OidcClientSecret:       "secret",
+			internalOidcScopes:     []string{"openid"},
+			internalOidcExtensions: map[string]string{"ext": "value"},
+		}.getOAuthTokenSource()
+		return ts, requests
+	}
+
+	t.Run("token is cached until it expires", func(t *testing.T) {
+		ts, requests := newTokenSource(t, 3600)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := ts.Token()
+				assert.NoError(t, err)
+				assert.Equal(t, "token-1", token.Token)
+				assert.Equal(t, map[string]string{"ext": "value"}, token.Extensions)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("token is refreshed when it is about to expire", func(t *testing.T) {
+		// Tokens expiring in less than 10 seconds are not valid anymore
+		ts, requests := newTokenSource(t, 5)
+
+		token, err := ts.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token.Token)
+
+		token, err = ts.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token.Token)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
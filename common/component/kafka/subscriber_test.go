@@ -520,3 +520,35 @@ func Test_Subscribe(t *testing.T) {
 		assert.Equal(t, int64(199), consumeCalled.Load())
 	})
 }
+
+func Test_CloseWithContext(t *testing.T) {
+	t.Run("waits for the claims being processed", func(t *testing.T) {
+		k := NewKafka(logger.NewLogger("kafka_test"))
+		require.True(t, k.startClaim())
+
+		var claimDone atomic.Bool
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			claimDone.Store(true)
+			k.claimsWG.Done()
+		}()
+
+		require.NoError(t, k.CloseWithContext(context.Background()))
+		assert.True(t, claimDone.Load())
+
+		// New claims are not started after draining
+		assert.False(t, k.startClaim())
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		k := NewKafka(logger.NewLogger("kafka_test"))
+		require.True(t, k.startClaim())
+		t.Cleanup(k.claimsWG.Done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		require.NoError(t, k.CloseWithContext(ctx))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
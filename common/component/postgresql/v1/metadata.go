@@ -19,8 +19,8 @@ import (
 
 	"github.com/dapr/components-contrib/common/authentication/aws"
 	pgauth "github.com/dapr/components-contrib/common/authentication/postgresql"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
-	"github.com/dapr/kit/metadata"
 	"github.com/dapr/kit/ptr"
 )
 
@@ -36,9 +36,16 @@ type pgMetadata struct {
 
 	TableName         string         `mapstructure:"tableName"`         // Could be in the format "schema.table" or just "table"
 	MetadataTableName string         `mapstructure:"metadataTableName"` // Could be in the format "schema.table" or just "table"
-	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
+	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds" mdmin:"1s"`
 	CleanupInterval   *time.Duration `mapstructure:"cleanupInterval" mapstructurealiases:"cleanupIntervalInSeconds"`
 
+	// Outbox: when a pubsub is set, transactional Set operations store an event in the outbox table, which is then published to the pubsub in background
+	OutboxPubsub           string        `mapstructure:"outboxPubsub"`
+	OutboxTopic            string        `mapstructure:"outboxTopic"`
+	OutboxTableName        string        `mapstructure:"outboxTableName"` // Could be in the format "schema.table" or just "table"
+	OutboxPollInterval     time.Duration `mapstructure:"outboxPollInterval"`
+	OutboxDaprHTTPEndpoint string        `mapstructure:"outboxDaprHTTPEndpoint"`
+
 	aws.AWSIAM `mapstructure:",squash"`
 }
 
@@ -49,9 +56,14 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 	m.MetadataTableName = defaultMetadataTableName
 	m.CleanupInterval = ptr.Of(defaultCleanupInternal)
 	m.Timeout = defaultTimeout
+	m.OutboxPubsub = ""
+	m.OutboxTopic = ""
+	m.OutboxTableName = defaultOutboxTableName
+	m.OutboxPollInterval = defaultOutboxPollInterval
+	m.OutboxDaprHTTPEndpoint = ""
 
 	// Decode the metadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err := metadata.DecodeAndValidate(meta.Properties, &m)
 	if err != nil {
 		return err
 	}
@@ -62,11 +74,6 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 		return err
 	}
 
-	// Timeout
-	if m.Timeout < 1*time.Second {
-		return errors.New("invalid value for 'timeout': must be greater than 1s")
-	}
-
 	// Cleanup interval
 	// Non-positive value from meta means disable auto cleanup.
 	// We need to do this check because an empty string and "0" are treated differently by DecodeMetadata
@@ -78,5 +85,20 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 		m.CleanupInterval = nil
 	}
 
+	// Outbox
+	if m.OutboxPubsub != "" {
+		if m.OutboxTopic == "" {
+			return errors.New("metadata property 'outboxTopic' is required when 'outboxPubsub' is set")
+		}
+		if m.OutboxPollInterval <= 0 {
+			return errors.New("metadata property 'outboxPollInterval' must be greater than 0")
+		}
+	}
+
 	return nil
 }
+
+// OutboxEnabled returns true if transactional Set operations are stored in the outbox.
+func (m *pgMetadata) OutboxEnabled() bool {
+	return m.OutboxPubsub != ""
+}
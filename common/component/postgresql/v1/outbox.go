@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	pginterfaces "github.com/dapr/components-contrib/common/component/postgresql/interfaces"
+	pgtransactions "github.com/dapr/components-contrib/common/component/postgresql/transactions"
+	"github.com/dapr/components-contrib/state"
+	stateutils "github.com/dapr/components-contrib/state/utils"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultOutboxTableName    = "dapr_outbox"
+	defaultOutboxPollInterval = time.Second
+	defaultDaprHTTPPort       = "3500"
+
+	// Maximum number of events published by the relay in a single transaction
+	outboxBatchSize = 100
+)
+
+// outboxEvent is an event stored in the outbox table, waiting to be published.
+type outboxEvent struct {
+	id          int64
+	topic       string
+	data        []byte
+	contentType string
+}
+
+// outboxRelay publishes the events stored in the outbox table to a pubsub component, using the Dapr HTTP API.
+type outboxRelay struct {
+	db         pginterfaces.PGXPoolConn
+	logger     logger.Logger
+	tableName  string
+	interval   time.Duration
+	timeout    time.Duration
+	client     *http.Client
+	publishURL string
+	apiToken   string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newOutboxRelay(db pginterfaces.PGXPoolConn, meta *pgMetadata, log logger.Logger) *outboxRelay {
+	endpoint := meta.OutboxDaprHTTPEndpoint
+	if endpoint == "" {
+		port := os.Getenv("DAPR_HTTP_PORT")
+		if port == "" {
+			port = defaultDaprHTTPPort
+		}
+		endpoint = "http://localhost:" + port
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &outboxRelay{
+		db:         db,
+		logger:     log,
+		tableName:  meta.OutboxTableName,
+		interval:   meta.OutboxPollInterval,
+		timeout:    meta.Timeout,
+		client:     &http.Client{Timeout: meta.Timeout},
+		publishURL: endpoint + "/v1.0/publish/" + url.PathEscape(meta.OutboxPubsub) + "/",
+		apiToken:   os.Getenv("DAPR_API_TOKEN"),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// createOutboxTable creates the outbox table if it doesn't exist.
+func createOutboxTable(ctx context.Context, db pginterfaces.DBQuerier, tableName string, timeout time.Duration) error {
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err := db.Exec(queryCtx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			key text NOT NULL,
+			topic text NOT NULL,
+			data bytea NOT NULL,
+			content_type text NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		tableName,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create outbox table '%s': %w", tableName, err)
+	}
+	return nil
+}
+
+// enqueueOutboxEvent stores the event for a set operation in the outbox table.
+// It must be invoked in the same transaction as the operation itself.
+func (p *PostgreSQL) enqueueOutboxEvent(ctx context.Context, db pginterfaces.DBQuerier, req *state.SetRequest) error {
+	var (
+		data        []byte
+		contentType string
+	)
+	if b, ok := req.Value.([]byte); ok {
+		data = b
+		contentType = "application/octet-stream"
+	} else {
+		var err error
+		data, err = stateutils.Marshal(req.Value, json.Marshal)
+		if err != nil {
+			return fmt.Errorf("failed to serialize outbox event for key '%s': %w", req.Key, err)
+		}
+		contentType = "application/json"
+	}
+	if req.ContentType != nil && *req.ContentType != "" {
+		contentType = *req.ContentType
+	}
+
+	_, err := db.Exec(ctx,
+		"INSERT INTO "+p.metadata.OutboxTableName+" (key, topic, data, content_type) VALUES ($1, $2, $3, $4)",
+		req.Key, p.metadata.OutboxTopic, data, contentType,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store outbox event for key '%s': %w", req.Key, err)
+	}
+	return nil
+}
+
+// Start the relay in background.
+func (r *outboxRelay) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run()
+	}()
+}
+
+// Close stops the relay and waits for the background goroutine to return.
+func (r *outboxRelay) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+func (r *outboxRelay) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		// Keep relaying as long as there are full batches to process
+		for {
+			n, err := r.relayBatch(r.ctx)
+			if err != nil {
+				if r.ctx.Err() == nil {
+					r.logger.Warnf("Failed to relay outbox events: %v", err)
+				}
+				break
+			}
+			if n < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes a batch of events from the outbox table, in order, and deletes the ones that were published.
+// Rows are locked until the transaction ends, so multiple instances of the component don't publish the same events.
+// If an event can't be published, the relay stops at it to preserve the order, and it's retried at the next iteration.
+// Events are delivered at least once: if the transaction fails to commit after publishing, they are published again.
+// It returns the number of events that were published.
+func (r *outboxRelay) relayBatch(parentCtx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, r.timeout)
+	defer cancel()
+
+	var publishErr error
+	n, err := pgtransactions.ExecuteInTransaction[int](ctx, r.logger, r.db, r.timeout, func(ctx context.Context, tx pgx.Tx) (int, error) {
+		rows, err := tx.Query(ctx,
+			"SELECT id, topic, data, content_type FROM "+r.tableName+" ORDER BY id LIMIT $1 FOR UPDATE",
+			outboxBatchSize,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read events: %w", err)
+		}
+		events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (e outboxEvent, err error) {
+			err = row.Scan(&e.id, &e.topic, &e.data, &e.contentType)
+			return e, err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to read events: %w", err)
+		}
+
+		published := make([]int64, 0, len(events))
+		for _, e := range events {
+			publishErr = r.publish(ctx, e)
+			if publishErr != nil {
+				break
+			}
+			published = append(published, e.id)
+		}
+		if len(published) == 0 {
+			return 0, nil
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM "+r.tableName+" WHERE id = ANY($1)", published)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete published events: %w", err)
+		}
+		return len(published), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return n, publishErr
+	}
+	return n, nil
+}
+
+// publish sends an event to the pubsub component.
+func (r *outboxRelay) publish(ctx context.Context, e outboxEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.publishURL+url.PathEscape(e.topic), bytes.NewReader(e.data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", e.contentType)
+	if r.apiToken != "" {
+		req.Header.Set("dapr-api-token", r.apiToken)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish event %d: %w", e.id, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to publish event %d: unexpected response status code %d", e.id, res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestOutboxMultiSet(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pg.metadata.OutboxPubsub = "mypubsub"
+	m.pg.metadata.OutboxTopic = "mytopic"
+	m.pg.metadata.OutboxTableName = defaultOutboxTableName
+
+	setReq := createSetRequest()
+	val, _ := json.Marshal(setReq.Value)
+
+	t.Run("single op is executed in a transaction", func(t *testing.T) {
+		m.db.ExpectBegin()
+		m.db.ExpectExec("INSERT INTO state").
+			WithArgs(setReq.Key, string(val), false).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectExec("INSERT INTO dapr_outbox").
+			WithArgs(setReq.Key, "mytopic", val, "application/json").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectCommit()
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{setReq},
+		})
+		require.NoError(t, err)
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+
+	t.Run("events are stored for set operations only", func(t *testing.T) {
+		delReq := createDeleteRequest()
+		binReq := state.SetRequest{
+			Key:         randomKey(),
+			Value:       []byte("hello"),
+			ContentType: ptr.Of("text/plain"),
+		}
+
+		m.db.ExpectBegin()
+		m.db.ExpectExec("DELETE FROM").
+			WithArgs(delReq.Key).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		m.db.ExpectExec("INSERT INTO state").
+			WithArgs(binReq.Key, `"aGVsbG8="`, true).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectExec("INSERT INTO dapr_outbox").
+			WithArgs(binReq.Key, "mytopic", []byte("hello"), "text/plain").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectCommit()
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{delReq, binReq},
+		})
+		require.NoError(t, err)
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+
+	t.Run("transaction is rolled back if the event can't be stored", func(t *testing.T) {
+		m.db.ExpectBegin()
+		m.db.ExpectExec("INSERT INTO state").
+			WithArgs(setReq.Key, string(val), false).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectExec("INSERT INTO dapr_outbox").
+			WithArgs(setReq.Key, "mytopic", val, "application/json").
+			WillReturnError(assert.AnError)
+		m.db.ExpectRollback()
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{setReq},
+		})
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+}
+
+func TestOutboxRelayBatch(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	type received struct {
+		path        string
+		contentType string
+		apiToken    string
+		body        string
+	}
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			apiToken:    r.Header.Get("dapr-api-token"),
+			body:        string(body),
+		})
+		if len(requests) > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Setenv("DAPR_API_TOKEN", "mytoken")
+	relay := newOutboxRelay(m.db, &pgMetadata{
+		Timeout:                30 * time.Second,
+		OutboxPubsub:           "mypubsub",
+		OutboxTableName:        defaultOutboxTableName,
+		OutboxPollInterval:     time.Second,
+		OutboxDaprHTTPEndpoint: server.URL,
+	}, logger.NewLogger("test"))
+
+	t.Run("stops at the first event that can't be published", func(t *testing.T) {
+		m.db.ExpectBegin()
+		m.db.ExpectQuery("SELECT id, topic, data, content_type FROM dapr_outbox").
+			WithArgs(outboxBatchSize).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "topic", "data", "content_type"}).
+				AddRow(int64(1), "mytopic", []byte(`{"a":1}`), "application/json").
+				AddRow(int64(2), "mytopic", []byte(`{"a":2}`), "application/json").
+				AddRow(int64(3), "mytopic", []byte(`{"a":3}`), "application/json"),
+			)
+		m.db.ExpectExec("DELETE FROM dapr_outbox").
+			WithArgs([]int64{1}).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		m.db.ExpectCommit()
+
+		n, err := relay.relayBatch(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, 1, n)
+		require.NoError(t, m.db.ExpectationsWereMet())
+
+		require.Len(t, requests, 2)
+		assert.Equal(t, received{
+			path:        "/v1.0/publish/mypubsub/mytopic",
+			contentType: "application/json",
+			apiToken:    "mytoken",
+			body:        `{"a":1}`,
+		}, requests[0])
+		assert.Equal(t, `{"a":2}`, requests[1].body)
+	})
+
+	t.Run("nothing is deleted if no event is published", func(t *testing.T) {
+		requests = requests[:0]
+
+		m.db.ExpectBegin()
+		m.db.ExpectQuery("SELECT id, topic, data, content_type FROM dapr_outbox").
+			WithArgs(outboxBatchSize).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "topic", "data", "content_type"}))
+		m.db.ExpectCommit()
+
+		n, err := relay.relayBatch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		require.NoError(t, m.db.ExpectationsWereMet())
+		assert.Empty(t, requests)
+	})
+}
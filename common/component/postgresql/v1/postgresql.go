@@ -23,6 +23,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -47,13 +49,19 @@ type PostgreSQL struct {
 	metadata pgMetadata
 	db       pginterfaces.PGXPoolConn
 
-	gc commonsql.GarbageCollector
+	gc     commonsql.GarbageCollector
+	outbox *outboxRelay
 
 	migrateFn     func(context.Context, pginterfaces.PGXPoolConn, MigrateOptions) error
 	setQueryFn    func(*state.SetRequest, SetQueryOptions) string
 	etagColumn    string
 	enableAzureAD bool
 	enableAWSIAM  bool
+	enableOutbox  bool
+
+	followerReads         bool
+	disableGC             bool
+	maxTransactionRetries uint64
 }
 
 type Options struct {
@@ -62,6 +70,14 @@ type Options struct {
 	ETagColumn    string
 	EnableAzureAD bool
 	EnableAWSIAM  bool
+	EnableOutbox  bool
+
+	// If true, reads with eventual consistency use AS OF SYSTEM TIME follower_read_timestamp(), which is specific to CockroachDB
+	FollowerReads bool
+	// If true, expired rows are deleted by the database itself, so the garbage collector is never started
+	DisableGC bool
+	// Number of times transactions failing with a serialization failure are retried; if zero, they are not retried
+	MaxTransactionRetries uint64
 }
 
 type MigrateOptions struct {
@@ -84,6 +100,11 @@ func NewPostgreSQLStateStore(logger logger.Logger, opts Options) state.Store {
 		etagColumn:    opts.ETagColumn,
 		enableAzureAD: opts.EnableAzureAD,
 		enableAWSIAM:  opts.EnableAWSIAM,
+		enableOutbox:  opts.EnableOutbox,
+
+		followerReads:         opts.FollowerReads,
+		disableGC:             opts.DisableGC,
+		maxTransactionRetries: opts.MaxTransactionRetries,
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
 	return s
@@ -100,6 +121,9 @@ func (p *PostgreSQL) Init(ctx context.Context, meta state.Metadata) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse metadata: %w", err)
 	}
+	if p.metadata.OutboxEnabled() && !p.enableOutbox {
+		return errors.New("failed to parse metadata: the outbox is not supported by this component")
+	}
 
 	config, err := p.metadata.GetPgxPoolConfig()
 	if err != nil {
@@ -129,7 +153,7 @@ func (p *PostgreSQL) Init(ctx context.Context, meta state.Metadata) error {
 		return err
 	}
 
-	if p.metadata.CleanupInterval != nil {
+	if p.metadata.CleanupInterval != nil && !p.disableGC {
 		gc, err := commonsql.ScheduleGarbageCollector(commonsql.GCOptions{
 			Logger: p.logger,
 			UpdateLastCleanupQuery: func(arg any) (string, any) {
@@ -155,6 +179,15 @@ func (p *PostgreSQL) Init(ctx context.Context, meta state.Metadata) error {
 		p.gc = gc
 	}
 
+	if p.metadata.OutboxEnabled() {
+		err = createOutboxTable(ctx, p.db, p.metadata.OutboxTableName, p.metadata.Timeout)
+		if err != nil {
+			return err
+		}
+		p.outbox = newOutboxRelay(p.db, &p.metadata, p.logger)
+		p.outbox.Start()
+	}
+
 	return nil
 }
 
@@ -167,6 +200,22 @@ func (p *PostgreSQL) Features() []state.Feature {
 	}
 }
 
+// Ping verifies that a connection to the database can be established.
+func (p *PostgreSQL) Ping(parentCtx context.Context) error {
+	if p.db == nil {
+		return errors.New("database connection is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+	err := p.db.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping the database: %w", err)
+	}
+
+	return nil
+}
+
 func (p *PostgreSQL) GetDB() *pgxpool.Pool {
 	// We can safely cast to *pgxpool.Pool because this method is never used in unit tests where we mock the DB
 	return p.db.(*pgxpool.Pool)
@@ -256,7 +305,7 @@ func (p *PostgreSQL) Get(parentCtx context.Context, req *state.GetRequest) (*sta
 
 	query := `SELECT
 			key, value, isbinary, ` + p.etagColumn + ` AS etag, expiredate
-		FROM ` + p.metadata.TableName + `
+		FROM ` + p.metadata.TableName + p.asOfSystemTime(req.Options.Consistency) + `
 			WHERE
 				key = $1
 				AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)`
@@ -292,15 +341,20 @@ func (p *PostgreSQL) BulkGet(parentCtx context.Context, req []state.GetRequest,
 	}
 
 	// Get all keys
+	// Follower reads are used only if all requests allow eventual consistency
 	keys := make([]string, len(req))
+	consistency := state.Eventual
 	for i, r := range req {
 		keys[i] = r.Key
+		if r.Options.Consistency != state.Eventual {
+			consistency = r.Options.Consistency
+		}
 	}
 
 	// Execute the query
 	query := `SELECT
 			key, value, isbinary, ` + p.etagColumn + ` AS etag, expiredate
-		FROM ` + p.metadata.TableName + `
+		FROM ` + p.metadata.TableName + p.asOfSystemTime(consistency) + `
 			WHERE
 				key = ANY($1)
 				AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)`
@@ -362,6 +416,14 @@ func (p *PostgreSQL) BulkGet(parentCtx context.Context, req []state.GetRequest,
 	return res[:n], nil
 }
 
+// asOfSystemTime returns the clause to read from the nearest replica for requests with eventual consistency, if follower reads are enabled.
+func (p *PostgreSQL) asOfSystemTime(consistency string) string {
+	if p.followerReads && consistency == state.Eventual {
+		return " AS OF SYSTEM TIME follower_read_timestamp()"
+	}
+	return ""
+}
+
 func readRow(row pgx.Row) (key string, value []byte, etagS *string, expireTime *time.Time, err error) {
 	var (
 		isBinary bool
@@ -445,15 +507,22 @@ func (p *PostgreSQL) Multi(parentCtx context.Context, request *state.Transaction
 		return nil
 	}
 
-	// If there's only 1 operation, skip starting a transaction
-	switch len(request.Operations) {
-	case 0:
+	// If there's only 1 operation, skip starting a transaction, unless events need to be stored in the outbox with it
+	switch {
+	case len(request.Operations) == 0:
 		return nil
-	case 1:
+	case len(request.Operations) == 1 && !p.metadata.OutboxEnabled():
 		return p.execMultiOperation(parentCtx, request.Operations[0], p.db)
 	default:
+		return p.execMultiTransaction(parentCtx, request.Operations)
+	}
+}
+
+// execMultiTransaction executes the operations in a transaction, retrying it on serialization failures if configured.
+func (p *PostgreSQL) execMultiTransaction(parentCtx context.Context, operations []state.TransactionalStateOperation) error {
+	execTx := func() error {
 		_, err := pgtransactions.ExecuteInTransaction[struct{}](parentCtx, p.logger, p.db, p.metadata.Timeout, func(ctx context.Context, tx pgx.Tx) (res struct{}, err error) {
-			for _, op := range request.Operations {
+			for _, op := range operations {
 				err = p.execMultiOperation(ctx, op, tx)
 				if err != nil {
 					return res, err
@@ -464,12 +533,35 @@ func (p *PostgreSQL) Multi(parentCtx context.Context, request *state.Transaction
 		})
 		return err
 	}
+
+	if p.maxTransactionRetries == 0 {
+		return execTx()
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = 50 * time.Millisecond
+	bo.MaxInterval = 2 * time.Second
+	b := backoff.WithContext(backoff.WithMaxRetries(bo, p.maxTransactionRetries), parentCtx)
+	return backoff.RetryNotify(func() error {
+		err := execTx()
+		var pgErr *pgconn.PgError
+		if err != nil && !(errors.As(err, &pgErr) && pgErr.Code == pgerrcode.SerializationFailure) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, b, func(err error, d time.Duration) {
+		p.logger.Debugf("Transaction failed with a serialization failure, retrying in %v: %v", d, err)
+	})
 }
 
 func (p *PostgreSQL) execMultiOperation(ctx context.Context, op state.TransactionalStateOperation, db pginterfaces.DBQuerier) error {
 	switch x := op.(type) {
 	case state.SetRequest:
-		return p.doSet(ctx, db, &x)
+		err := p.doSet(ctx, db, &x)
+		if err != nil || !p.metadata.OutboxEnabled() {
+			return err
+		}
+		return p.enqueueOutboxEvent(ctx, db, &x)
 	case state.DeleteRequest:
 		return p.doDelete(ctx, db, &x)
 	default:
@@ -486,6 +578,12 @@ func (p *PostgreSQL) CleanupExpired() error {
 
 // Close implements io.Close.
 func (p *PostgreSQL) Close() error {
+	// Stop the outbox relay before closing the connection it uses
+	if p.outbox != nil {
+		p.outbox.Close()
+		p.outbox = nil
+	}
+
 	if p.db != nil {
 		p.db.Close()
 		p.db = nil
@@ -41,6 +41,10 @@ func NewPostgreSQLQueryStateStore(logger logger.Logger, opts Options) state.Stor
 			setQueryFn:    opts.SetQueryFn,
 			etagColumn:    opts.ETagColumn,
 			enableAzureAD: opts.EnableAzureAD,
+
+			followerReads:         opts.FollowerReads,
+			disableGC:             opts.DisableGC,
+			maxTransactionRetries: opts.MaxTransactionRetries,
 		},
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
@@ -18,10 +18,14 @@ package postgresql
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	pgxmock "github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/require"
 
@@ -188,6 +192,33 @@ func TestInvalidMultiDeleteRequestNoKey(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPing(t *testing.T) {
+	db, err := pgxmock.NewPool(pgxmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	pg := &PostgreSQL{
+		metadata: pgMetadata{
+			Timeout: 30 * time.Second,
+		},
+		logger: logger.NewLogger("test"),
+		db:     db,
+	}
+
+	t.Run("database reachable", func(t *testing.T) {
+		db.ExpectPing()
+		require.NoError(t, pg.Ping(context.Background()))
+		require.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("database unreachable", func(t *testing.T) {
+		db.ExpectPing().WillReturnError(errors.New("connection refused"))
+		err := pg.Ping(context.Background())
+		require.ErrorContains(t, err, "connection refused")
+		require.NoError(t, db.ExpectationsWereMet())
+	})
+}
+
 func TestMultiOperationOrder(t *testing.T) {
 	// Arrange
 	m, _ := mockDatabase(t)
@@ -218,6 +249,112 @@ func TestMultiOperationOrder(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMultiRetriesSerializationFailures(t *testing.T) {
+	operations := []state.TransactionalStateOperation{
+		state.SetRequest{Key: "key1", Value: "value1"},
+		state.DeleteRequest{Key: "key2"},
+	}
+	serializationFailure := &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+
+	t.Run("retries serialization failures", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.db.Close()
+		m.pg.maxTransactionRetries = 2
+
+		m.db.ExpectBegin()
+		m.db.ExpectExec("INSERT INTO").
+			WithArgs("key1", `"value1"`, false).
+			WillReturnError(serializationFailure)
+		m.db.ExpectRollback()
+		m.db.ExpectBegin()
+		m.db.ExpectExec("INSERT INTO").
+			WithArgs("key1", `"value1"`, false).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		m.db.ExpectExec("DELETE FROM").
+			WithArgs("key2").
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		m.db.ExpectCommit()
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: operations,
+		})
+		require.NoError(t, err)
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+
+	t.Run("stops after the max retries", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.db.Close()
+		m.pg.maxTransactionRetries = 1
+
+		for range 2 {
+			m.db.ExpectBegin()
+			m.db.ExpectExec("INSERT INTO").
+				WithArgs("key1", `"value1"`, false).
+				WillReturnError(serializationFailure)
+			m.db.ExpectRollback()
+		}
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: operations,
+		})
+		require.ErrorIs(t, err, serializationFailure)
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.db.Close()
+		m.pg.maxTransactionRetries = 2
+
+		m.db.ExpectBegin()
+		m.db.ExpectExec("INSERT INTO").
+			WithArgs("key1", `"value1"`, false).
+			WillReturnError(errors.New("failed"))
+		m.db.ExpectRollback()
+
+		err := m.pg.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: operations,
+		})
+		require.EqualError(t, err, "failed")
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+}
+
+func TestFollowerReads(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pg.etagColumn = "etag"
+
+	columns := []string{"key", "value", "isbinary", "etag", "expiredate"}
+
+	t.Run("disabled", func(t *testing.T) {
+		m.pg.followerReads = false
+		require.Empty(t, m.pg.asOfSystemTime(state.Eventual))
+	})
+
+	m.pg.followerReads = true
+
+	t.Run("eventual consistency", func(t *testing.T) {
+		m.db.ExpectQuery(regexp.QuoteMeta("FROM state AS OF SYSTEM TIME follower_read_timestamp()")).
+			WithArgs("key1").
+			WillReturnRows(pgxmock.NewRows(columns).AddRow("key1", []byte(`"value1"`), false, int64(1), nil))
+
+		res, err := m.pg.Get(context.Background(), &state.GetRequest{
+			Key:     "key1",
+			Options: state.GetStateOption{Consistency: state.Eventual},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `"value1"`, string(res.Data))
+		require.NoError(t, m.db.ExpectationsWereMet())
+	})
+
+	t.Run("strong consistency", func(t *testing.T) {
+		require.Empty(t, m.pg.asOfSystemTime(state.Strong))
+		require.Empty(t, m.pg.asOfSystemTime(""))
+	})
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
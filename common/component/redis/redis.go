@@ -91,6 +91,9 @@ type RedisClient interface {
 	TxPipeline() RedisPipeliner
 	TTLResult(ctx context.Context, key string) (time.Duration, error)
 	AuthACL(ctx context.Context, username, password string) error
+	// Subscribe subscribes to a pub/sub channel, returning once the subscription is active.
+	// The returned channel receives a value when messages are published, without blocking the subscription; the returned function closes the subscription.
+	Subscribe(ctx context.Context, channel string) (<-chan struct{}, func() error, error)
 }
 
 type ConfigurationSubscribeArgs struct {
@@ -112,6 +115,8 @@ func ParseClientFromProperties(properties map[string]string, componentType metad
 		settings.RedisMaxRetries = 3
 		settings.RedisMaxRetryInterval = Duration(2 * time.Second)
 		settings.RedisMinRetryInterval = Duration(8 * time.Millisecond)
+		settings.SubscribeMode = "auto"
+		settings.SubscribePollInterval = Duration(10 * time.Second)
 	case metadata.StateStoreType, metadata.LockStoreType:
 		// Apply legacy defaults
 		settings.RedisMaxRetries = 3
@@ -165,6 +170,11 @@ func ParseClientFromProperties(properties map[string]string, componentType metad
 			// if there was an error we would try to interpret it as a duration string, which was already done in Decode()
 		}
 	}
+	if settings.SharedConnection && settings.UseEntraID {
+		// The Entra ID token is refreshed by each component, so the connection can't be shared
+		return nil, nil, errors.New("redis client configuration error: sharedConnection can't be used with Entra ID authentication")
+	}
+
 	var tokenExpires *time.Time
 	var tokenCredential *azcore.TokenCredential
 	if settings.UseEntraID {
@@ -174,21 +184,45 @@ func ParseClientFromProperties(properties map[string]string, componentType metad
 		}
 	}
 
-	var c RedisClient
+	if settings.SharedConnection {
+		c, err := sharedClients.acquire(settings.connectionKey(), func() (RedisClient, error) {
+			return newClient(&settings)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
+		}
+		return c, &settings, nil
+	}
+
+	c, err := newClient(&settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
+	}
+
+	// start the token refresh goroutine
+
+	if settings.UseEntraID {
+		StartEntraIDTokenRefreshBackgroundRoutine(c, settings.Username, *tokenExpires, tokenCredential, ctx, logger)
+	}
+	return c, &settings, nil
+}
+
+// newClient returns a client for the settings, using the go-redis v9 client if the server is Redis 7 or higher.
+func newClient(settings *Settings) (RedisClient, error) {
 	newClientFunc := newV8Client
 	if settings.Failover {
 		newClientFunc = newV8FailoverClient
 	}
 
-	c, err = newClientFunc(&settings)
+	c, err := newClientFunc(settings)
 	if err != nil {
-		return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
+		return nil, err
 	}
 
 	version, err := GetServerVersion(c)
 	closeErr := c.Close() // close the client to avoid leaking connections
 	if closeErr != nil {
-		return nil, nil, closeErr
+		return nil, closeErr
 	}
 
 	useNewClient := false
@@ -206,17 +240,7 @@ func ParseClientFromProperties(properties map[string]string, componentType metad
 			newClientFunc = newV9FailoverClient
 		}
 	}
-	c, err = newClientFunc(&settings)
-	if err != nil {
-		return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
-	}
-
-	// start the token refresh goroutine
-
-	if settings.UseEntraID {
-		StartEntraIDTokenRefreshBackgroundRoutine(c, settings.Username, *tokenExpires, tokenCredential, ctx, logger)
-	}
-	return c, &settings, nil
+	return newClientFunc(settings)
 }
 
 func StartEntraIDTokenRefreshBackgroundRoutine(client RedisClient, username string, nextExpiration time.Time, cred *azcore.TokenCredential, parentCtx context.Context, logger *kitlogger.Logger) {
@@ -19,7 +19,8 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/dapr/kit/config"
+	"github.com/dapr/components-contrib/common/tlsconfig"
+	"github.com/dapr/components-contrib/metadata"
 )
 
 type Settings struct {
@@ -32,7 +33,7 @@ type Settings struct {
 	// Database to be selected after connecting to the server.
 	DB int `mapstructure:"redisDB"`
 	// The redis type node or cluster
-	RedisType string `mapstructure:"redisType"`
+	RedisType string `mapstructure:"redisType" mdallowedvalues:"node,cluster"`
 	// Maximum number of retries before giving up.
 	// A value of -1 (not 0) disables retries
 	// Default is 3 retries
@@ -71,22 +72,42 @@ type Settings struct {
 	// but idle connections are still discarded by the client
 	// if IdleTimeout is set.
 	IdleCheckFrequency Duration `mapstructure:"idleCheckFrequency"`
+	// Maximum number of idle connections.
+	// Only used with Redis 7 and higher.
+	MaxIdleConns int `mapstructure:"maxIdleConns"`
+	// RESP protocol version: 2 or 3.
+	// Only used with Redis 7 and higher, where the default is 3.
+	Protocol int `mapstructure:"redisProtocol" mdallowedvalues:"2,3"`
 	// The master name
 	SentinelMasterName string `mapstructure:"sentinelMasterName"`
+	// Username and password for ACL authentication with Redis Sentinel, if different from the Redis ones.
+	SentinelUsername string `mapstructure:"sentinelUsername"`
+	SentinelPassword string `mapstructure:"sentinelPassword"`
 	// Use Redis Sentinel for automatic failover.
 	Failover bool `mapstructure:"failover"`
+	// Share the connection pool with the other components in the sidecar that connect using the same settings.
+	SharedConnection bool `mapstructure:"sharedConnection"`
 
-	// A flag to enables TLS by setting InsecureSkipVerify to true
+	// A flag to enable TLS; the server certificate is verified only when a CA certificate file is set
 	EnableTLS bool `mapstructure:"enableTLS"`
 
 	// Client certificate and key
 	ClientCert string `mapstructure:"clientCert"`
 	ClientKey  string `mapstructure:"clientKey"`
 
+	// Certificate files, minimum TLS version and server name
+	tlsconfig.Metadata `mapstructure:",squash"`
+
 	// == state only properties ==
 	TTLInSeconds *int   `mapstructure:"ttlInSeconds" mdonly:"state"`
 	QueryIndexes string `mapstructure:"queryIndexes" mdonly:"state"`
 
+	// == configuration only properties ==
+	// How Subscribe is notified of changes: "auto", "notifications" or "polling"
+	SubscribeMode string `mapstructure:"subscribeMode" mdonly:"configuration"`
+	// The interval between reads when Subscribe falls back to polling
+	SubscribePollInterval Duration `mapstructure:"subscribePollInterval" mdonly:"configuration"`
+
 	// == pubsub only properties ==
 	// The consumer identifier
 	ConsumerID string `mapstructure:"consumerID" mdonly:"pubsub"`
@@ -107,14 +128,29 @@ type Settings struct {
 	UseEntraID bool `mapstructure:"useEntraID" mapstructurealiases:"useAzureAD"`
 }
 
-func (s *Settings) Decode(in interface{}) error {
-	if err := config.Decode(in, s); err != nil {
+// Decode decodes and validates the metadata properties into the settings.
+func (s *Settings) Decode(in map[string]string) error {
+	if err := metadata.DecodeAndValidate(in, s); err != nil {
 		return fmt.Errorf("decode failed. %w", err)
 	}
 
 	return nil
 }
 
+// TLSConfig returns the TLS configuration used when TLS is enabled.
+func (s *Settings) TLSConfig() (*tls.Config, error) {
+	return tlsconfig.Config{
+		Metadata:   s.Metadata,
+		ClientCert: s.ClientCert,
+		ClientKey:  s.ClientKey,
+		// Enabling TLS skips the verification of the server certificate, unless a CA certificate is provided
+		InsecureSkipVerify: s.CACertFile == "",
+	}.Build()
+}
+
+// SetCertificate invokes fn with the client certificate, if one is configured.
+//
+// Deprecated: use TLSConfig.
 func (s *Settings) SetCertificate(fn func(cert *tls.Certificate)) error {
 	if s.ClientCert == "" || s.ClientKey == "" {
 		return nil
@@ -3,7 +3,9 @@ package redis
 import (
 	"crypto/tls"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +42,40 @@ func TestSettings(t *testing.T) {
 		require.NotNil(t, c)
 	})
 }
+
+func TestSettingsDecode(t *testing.T) {
+	t.Run("durations", func(t *testing.T) {
+		settings := &Settings{}
+		err := settings.Decode(map[string]string{
+			"redisMinRetryInterval": "100",
+			"redisMaxRetryInterval": "-1",
+			"dialTimeout":           "5s",
+			"processingTimeout":     "30s",
+		})
+		require.NoError(t, err)
+		// Numbers are milliseconds
+		assert.Equal(t, Duration(100*time.Millisecond), settings.RedisMinRetryInterval)
+		assert.Equal(t, Duration(-1), settings.RedisMaxRetryInterval)
+		assert.Equal(t, Duration(5*time.Second), settings.DialTimeout)
+		assert.Equal(t, 30*time.Second, settings.ProcessingTimeout)
+	})
+
+	t.Run("redis type", func(t *testing.T) {
+		settings := &Settings{}
+		require.NoError(t, settings.Decode(map[string]string{"redisType": "Cluster"}))
+		assert.Equal(t, ClusterType, settings.RedisType)
+
+		settings = &Settings{}
+		require.ErrorContains(t, settings.Decode(map[string]string{"redisType": "sentinel"}), "'redisType': invalid value 'sentinel'")
+	})
+
+	t.Run("all errors are returned", func(t *testing.T) {
+		settings := &Settings{}
+		err := settings.Decode(map[string]string{
+			"dialTimeout":   "soon",
+			"redisProtocol": "4",
+		})
+		require.ErrorContains(t, err, "'dialTimeout'")
+		require.ErrorContains(t, err, "'redisProtocol'")
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// sharedClients contains the clients shared by the components in the sidecar.
+var sharedClients = &sharedClientRegistry{
+	clients: make(map[string]*sharedClientEntry),
+}
+
+type sharedClientRegistry struct {
+	lock    sync.Mutex
+	clients map[string]*sharedClientEntry
+}
+
+type sharedClientEntry struct {
+	client RedisClient
+	refs   int
+}
+
+// acquire returns a reference to the client for the key, creating it if needed.
+// The client is closed when all references are closed.
+func (r *sharedClientRegistry) acquire(key string, create func() (RedisClient, error)) (RedisClient, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry, ok := r.clients[key]
+	if !ok {
+		client, err := create()
+		if err != nil {
+			return nil, err
+		}
+		entry = &sharedClientEntry{client: client}
+		r.clients[key] = entry
+	}
+	entry.refs++
+
+	return &sharedClientRef{
+		RedisClient: entry.client,
+		registry:    r,
+		key:         key,
+	}, nil
+}
+
+func (r *sharedClientRegistry) release(key string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry, ok := r.clients[key]
+	if !ok {
+		return nil
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return nil
+	}
+	delete(r.clients, key)
+	return entry.client.Close()
+}
+
+// sharedClientRef is a reference to a shared client held by a component.
+type sharedClientRef struct {
+	RedisClient
+
+	registry *sharedClientRegistry
+	key      string
+	closed   atomic.Bool
+}
+
+// Close releases the reference, closing the client if it's not used by other components.
+func (c *sharedClientRef) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return c.registry.release(c.key)
+}
+
+// connectionKey returns a key identifying the settings used to connect, ignoring the properties specific to a type of component.
+func (s *Settings) connectionKey() string {
+	h := sha256.New()
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Tag.Get("mdonly") != "" {
+			continue
+		}
+		fmt.Fprintf(h, "%s=%v\n", field.Name, v.Field(i).Interface())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
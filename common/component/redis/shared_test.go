@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestSharedConnection(t *testing.T) {
+	s := miniredis.RunT(t)
+	log := logger.NewLogger("test")
+	props := func(extra map[string]string) map[string]string {
+		p := map[string]string{
+			"redisHost":        s.Addr(),
+			"sharedConnection": "true",
+		}
+		for k, v := range extra {
+			p[k] = v
+		}
+		return p
+	}
+
+	c1, _, err := ParseClientFromProperties(props(map[string]string{"ttlInSeconds": "10"}), metadata.StateStoreType, context.Background(), &log)
+	require.NoError(t, err)
+	c2, _, err := ParseClientFromProperties(props(map[string]string{"queryIndexes": ""}), metadata.StateStoreType, context.Background(), &log)
+	require.NoError(t, err)
+	c3, _, err := ParseClientFromProperties(props(map[string]string{"redisDB": "1"}), metadata.StateStoreType, context.Background(), &log)
+	require.NoError(t, err)
+
+	// Component-specific properties don't change the connection
+	assert.Equal(t, c1.(*sharedClientRef).RedisClient, c2.(*sharedClientRef).RedisClient)
+	assert.NotEqual(t, c1.(*sharedClientRef).RedisClient, c3.(*sharedClientRef).RedisClient)
+
+	// The client stays open until all references are closed
+	require.NoError(t, c1.Close())
+	require.NoError(t, c1.Close())
+	_, err = c2.PingResult(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, c2.Close())
+	require.NoError(t, c3.Close())
+	sharedClients.lock.Lock()
+	assert.Empty(t, sharedClients.clients)
+	sharedClients.lock.Unlock()
+}
+
+func TestSettingsProtocol(t *testing.T) {
+	settings := Settings{}
+	require.NoError(t, settings.Decode(map[string]string{"redisProtocol": "2"}))
+	assert.Equal(t, 2, settings.Protocol)
+
+	settings = Settings{}
+	require.ErrorContains(t, settings.Decode(map[string]string{"redisProtocol": "4"}), "redisProtocol")
+}
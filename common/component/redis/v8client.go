@@ -15,7 +15,6 @@ package redis
 
 import (
 	"context"
-	"crypto/tls"
 	"strings"
 	"time"
 
@@ -314,6 +313,28 @@ func (c v8Client) TTLResult(ctx context.Context, key string) (time.Duration, err
 	return c.client.TTL(writeCtx, key).Result()
 }
 
+func (c v8Client) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func() error, error) {
+	p := c.client.Subscribe(ctx, channel)
+	// Wait for the confirmation of the subscription
+	_, err := p.Receive(ctx)
+	if err != nil {
+		p.Close()
+		return nil, nil, err
+	}
+
+	notifyCh := make(chan struct{}, 1)
+	go func() {
+		// The channel is closed when the subscription is closed
+		for range p.Channel() {
+			select {
+			case notifyCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notifyCh, p.Close, nil
+}
+
 func (c v8Client) AuthACL(ctx context.Context, username, password string) error {
 	pipeline := c.client.Pipeline()
 	statusCmd := pipeline.AuthACL(ctx, username, password)
@@ -328,6 +349,8 @@ func newV8FailoverClient(s *Settings) (RedisClient, error) {
 		DB:                 s.DB,
 		MasterName:         s.SentinelMasterName,
 		SentinelAddrs:      []string{s.Host},
+		SentinelUsername:   s.SentinelUsername,
+		SentinelPassword:   s.SentinelPassword,
 		Password:           s.Password,
 		Username:           s.Username,
 		MaxRetries:         s.RedisMaxRetries,
@@ -345,15 +368,11 @@ func newV8FailoverClient(s *Settings) (RedisClient, error) {
 	}
 
 	if s.EnableTLS {
-		opts.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS, //nolint:gosec
-		}
-		err := s.SetCertificate(func(cert *tls.Certificate) {
-			opts.TLSConfig.Certificates = []tls.Certificate{*cert}
-		})
+		tlsConfig, err := s.TLSConfig()
 		if err != nil {
 			return nil, err
 		}
+		opts.TLSConfig = tlsConfig
 	}
 
 	if s.RedisType == ClusterType {
@@ -399,15 +418,11 @@ func newV8Client(s *Settings) (RedisClient, error) {
 		}
 		/* #nosec */
 		if s.EnableTLS {
-			options.TLSConfig = &tls.Config{
-				InsecureSkipVerify: s.EnableTLS,
-			}
-			err := s.SetCertificate(func(cert *tls.Certificate) {
-				options.TLSConfig.Certificates = []tls.Certificate{*cert}
-			})
+			tlsConfig, err := s.TLSConfig()
 			if err != nil {
 				return nil, err
 			}
+			options.TLSConfig = tlsConfig
 		}
 
 		return v8Client{
@@ -439,15 +454,11 @@ func newV8Client(s *Settings) (RedisClient, error) {
 
 	/* #nosec */
 	if s.EnableTLS {
-		options.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS,
-		}
-		err := s.SetCertificate(func(cert *tls.Certificate) {
-			options.TLSConfig.Certificates = []tls.Certificate{*cert}
-		})
+		tlsConfig, err := s.TLSConfig()
 		if err != nil {
 			return nil, err
 		}
+		options.TLSConfig = tlsConfig
 	}
 
 	return v8Client{
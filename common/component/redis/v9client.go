@@ -15,7 +15,6 @@ package redis
 
 import (
 	"context"
-	"crypto/tls"
 	"strings"
 	"time"
 
@@ -315,6 +314,28 @@ func (c v9Client) TTLResult(ctx context.Context, key string) (time.Duration, err
 	return c.client.TTL(writeCtx, key).Result()
 }
 
+func (c v9Client) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func() error, error) {
+	p := c.client.Subscribe(ctx, channel)
+	// Wait for the confirmation of the subscription
+	_, err := p.Receive(ctx)
+	if err != nil {
+		p.Close()
+		return nil, nil, err
+	}
+
+	notifyCh := make(chan struct{}, 1)
+	go func() {
+		// The channel is closed when the subscription is closed
+		for range p.Channel() {
+			select {
+			case notifyCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notifyCh, p.Close, nil
+}
+
 func (c v9Client) AuthACL(ctx context.Context, username, password string) error {
 	pipeline := c.client.Pipeline()
 	statusCmd := pipeline.AuthACL(ctx, username, password)
@@ -329,6 +350,8 @@ func newV9FailoverClient(s *Settings) (RedisClient, error) {
 		DB:                    s.DB,
 		MasterName:            s.SentinelMasterName,
 		SentinelAddrs:         []string{s.Host},
+		SentinelUsername:      s.SentinelUsername,
+		SentinelPassword:      s.SentinelPassword,
 		Password:              s.Password,
 		Username:              s.Username,
 		MaxRetries:            s.RedisMaxRetries,
@@ -343,19 +366,17 @@ func newV9FailoverClient(s *Settings) (RedisClient, error) {
 		PoolTimeout:           time.Duration(s.PoolTimeout),
 		ConnMaxIdleTime:       time.Duration(s.IdleTimeout),
 		ContextTimeoutEnabled: true,
+		Protocol:              s.Protocol,
+		MaxIdleConns:          s.MaxIdleConns,
 	}
 
 	/* #nosec */
 	if s.EnableTLS {
-		opts.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS,
-		}
-		err := s.SetCertificate(func(cert *tls.Certificate) {
-			opts.TLSConfig.Certificates = []tls.Certificate{*cert}
-		})
+		tlsConfig, err := s.TLSConfig()
 		if err != nil {
 			return nil, err
 		}
+		opts.TLSConfig = tlsConfig
 	}
 
 	if s.RedisType == ClusterType {
@@ -399,19 +420,17 @@ func newV9Client(s *Settings) (RedisClient, error) {
 			PoolTimeout:           time.Duration(s.PoolTimeout),
 			ConnMaxIdleTime:       time.Duration(s.IdleTimeout),
 			ContextTimeoutEnabled: true,
+			Protocol:              s.Protocol,
+			MaxIdleConns:          s.MaxIdleConns,
 		}
 		/* #nosec */
 		if s.EnableTLS {
 			/* #nosec */
-			options.TLSConfig = &tls.Config{
-				InsecureSkipVerify: s.EnableTLS,
-			}
-			err := s.SetCertificate(func(cert *tls.Certificate) {
-				options.TLSConfig.Certificates = []tls.Certificate{*cert}
-			})
+			tlsConfig, err := s.TLSConfig()
 			if err != nil {
 				return nil, err
 			}
+			options.TLSConfig = tlsConfig
 		}
 
 		return v9Client{
@@ -439,19 +458,17 @@ func newV9Client(s *Settings) (RedisClient, error) {
 		PoolTimeout:           time.Duration(s.PoolTimeout),
 		ConnMaxIdleTime:       time.Duration(s.IdleTimeout),
 		ContextTimeoutEnabled: true,
+		Protocol:              s.Protocol,
+		MaxIdleConns:          s.MaxIdleConns,
 	}
 
 	if s.EnableTLS {
 		/* #nosec */
-		options.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS,
-		}
-		err := s.SetCertificate(func(cert *tls.Certificate) {
-			options.TLSConfig.Certificates = []tls.Certificate{*cert}
-		})
+		tlsConfig, err := s.TLSConfig()
 		if err != nil {
 			return nil, err
 		}
+		options.TLSConfig = tlsConfig
 	}
 
 	return v9Client{
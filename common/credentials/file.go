@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentials contains helpers for components to use credentials that can be rotated without restarting them.
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval is the minimum time between checks of a file for changes.
+const checkInterval = time.Second
+
+// File is a credential stored in a file, such as a secret mounted in a Kubernetes volume.
+// The file is read again when it changes, so the credential can be rotated while the component is running.
+// It is safe for concurrent use.
+type File struct {
+	path string
+
+	lock      sync.Mutex
+	value     []byte
+	version   uint64
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+	now       func() time.Time
+}
+
+// NewFile returns a File for the credential at path, failing if it can't be read.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("credential file path is empty")
+	}
+	f := &File{
+		path: path,
+		now:  time.Now,
+	}
+	err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Load returns the content of the file, reading it again if it changed since the last call.
+// The version is incremented every time the content changes, so callers can detect when to rebuild their clients.
+// If the file can't be read after a change, the previous content is returned together with the error.
+func (f *File) Load() (value []byte, version uint64, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := f.now()
+	if now.Sub(f.checkedAt) >= checkInterval {
+		f.checkedAt = now
+		info, statErr := os.Stat(f.path)
+		switch {
+		case statErr != nil:
+			err = fmt.Errorf("failed to check credential file '%s': %w", f.path, statErr)
+		case !info.ModTime().Equal(f.modTime) || info.Size() != f.size:
+			err = f.read()
+		}
+	}
+
+	return f.value, f.version, err
+}
+
+// LoadString returns the content of the file as a string, with leading and trailing whitespace removed.
+// This is the format used for passwords and API keys.
+func (f *File) LoadString() (value string, version uint64, err error) {
+	b, version, err := f.Load()
+	return string(bytes.TrimSpace(b)), version, err
+}
+
+// read reads the file; the caller must hold the lock, unless f is not shared yet.
+func (f *File) read() error {
+	// Stat before reading, so a change while reading is detected at the next check
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read credential file '%s': %w", f.path, err)
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read credential file '%s': %w", f.path, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("credential file '%s' is empty", f.path)
+	}
+
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	if f.value == nil || !bytes.Equal(data, f.value) {
+		f.value = data
+		f.version++
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("secret1\n"), 0o600))
+
+	f, err := NewFile(path)
+	require.NoError(t, err)
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	value, version, err := f.LoadString()
+	require.NoError(t, err)
+	assert.Equal(t, "secret1", value)
+	assert.EqualValues(t, 1, version)
+
+	// Rotate the credential
+	require.NoError(t, os.WriteFile(path, []byte("secret-2\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, now, now.Add(time.Minute)))
+
+	// The file is not checked again until the interval elapsed
+	value, version, err = f.LoadString()
+	require.NoError(t, err)
+	assert.Equal(t, "secret1", value)
+	assert.EqualValues(t, 1, version)
+
+	now = now.Add(checkInterval)
+	value, version, err = f.LoadString()
+	require.NoError(t, err)
+	assert.Equal(t, "secret-2", value)
+	assert.EqualValues(t, 2, version)
+
+	// The previous value is kept if the file can't be read
+	require.NoError(t, os.Remove(path))
+	now = now.Add(checkInterval)
+	value, version, err = f.LoadString()
+	require.Error(t, err)
+	assert.Equal(t, "secret-2", value)
+	assert.EqualValues(t, 2, version)
+
+	// Content is unchanged when the file is recreated with the same value
+	require.NoError(t, os.WriteFile(path, []byte("secret-2\n"), 0o600))
+	now = now.Add(checkInterval)
+	_, version, err = f.LoadString()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, version)
+}
+
+func TestNewFileErrors(t *testing.T) {
+	_, err := NewFile("")
+	require.Error(t, err)
+
+	_, err = NewFile(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+	_, err = NewFile(path)
+	require.Error(t, err)
+}
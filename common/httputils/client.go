@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyMetadata contains the metadata properties to send outbound requests through a proxy.
+// Components embed it in their metadata struct with `mapstructure:",squash"`.
+type ProxyMetadata struct {
+	// URL of the proxy, for example "http://proxy.corp.local:3128". Schemes "http", "https" and "socks5" are supported.
+	// If empty, the proxy is configured from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string `json:"proxyURL" mapstructure:"proxyURL"`
+	// Comma-separated list of hosts, domains, IP addresses or CIDRs that are reached without the proxy.
+	// Only used together with proxyURL.
+	NoProxy string `json:"noProxy" mapstructure:"noProxy"`
+	// Username to authenticate with the proxy.
+	ProxyUsername string `json:"proxyUsername" mapstructure:"proxyUsername"`
+	// Password to authenticate with the proxy.
+	ProxyPassword string `json:"proxyPassword" mapstructure:"proxyPassword"`
+}
+
+// ProxyFunc returns the function to use as Proxy in a http.Transport.
+func (m ProxyMetadata) ProxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if m.ProxyURL == "" {
+		if m.ProxyUsername == "" {
+			return http.ProxyFromEnvironment, nil
+		}
+		// Set the credentials on the proxy from the environment
+		return func(r *http.Request) (*url.URL, error) {
+			u, err := http.ProxyFromEnvironment(r)
+			if u == nil || err != nil {
+				return u, err
+			}
+			u.User = url.UserPassword(m.ProxyUsername, m.ProxyPassword)
+			return u, nil
+		}, nil
+	}
+
+	u, err := url.Parse(m.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL '%s': scheme must be one of http, https, socks5", m.ProxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL '%s': host is missing", m.ProxyURL)
+	}
+	if m.ProxyUsername != "" {
+		u.User = url.UserPassword(m.ProxyUsername, m.ProxyPassword)
+	}
+
+	proxyFn := (&httpproxy.Config{
+		HTTPProxy:  u.String(),
+		HTTPSProxy: u.String(),
+		NoProxy:    m.NoProxy,
+	}).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxyFn(r.URL)
+	}, nil
+}
+
+// ClientConfig contains the options to build the HTTP client used by components to send outbound requests.
+type ClientConfig struct {
+	// Proxy configuration.
+	Proxy ProxyMetadata
+	// TLS configuration; if nil, the default one is used.
+	TLSConfig *tls.Config
+	// Timeout for the requests, including reading the response body; 0 means no timeout.
+	Timeout time.Duration
+	// Timeout for establishing connections; if 0, the default one is used.
+	DialTimeout time.Duration
+	// Timeout for the TLS handshake; if 0, the default one is used.
+	TLSHandshakeTimeout time.Duration
+}
+
+// Transport returns a HTTP transport based on http.DefaultTransport with the configured options.
+func (c ClientConfig) Transport() (*http.Transport, error) {
+	proxy, err := c.Proxy.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if c.TLSConfig != nil {
+		transport.TLSClientConfig = c.TLSConfig
+	}
+	if c.DialTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   c.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if c.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+	return transport, nil
+}
+
+// Client returns a HTTP client with the configured options.
+func (c ClientConfig) Client() (*http.Client, error) {
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout:   c.Timeout,
+		Transport: transport,
+	}, nil
+}
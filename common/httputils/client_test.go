@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Run("proxy URL with no-proxy list and credentials", func(t *testing.T) {
+		proxy, err := ProxyMetadata{
+			ProxyURL:      "http://proxy.local:3128",
+			NoProxy:       "internal.local,10.0.0.0/8",
+			ProxyUsername: "user",
+			ProxyPassword: "p@ss",
+		}.ProxyFunc()
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		require.NotNil(t, u)
+		assert.Equal(t, "proxy.local:3128", u.Host)
+		assert.Equal(t, "user", u.User.Username())
+		password, _ := u.User.Password()
+		assert.Equal(t, "p@ss", password)
+
+		for _, target := range []string{"http://svc.internal.local/", "http://10.1.2.3:8080/"} {
+			req, _ = http.NewRequest(http.MethodGet, target, nil)
+			u, err = proxy(req)
+			require.NoError(t, err)
+			assert.Nil(t, u, target)
+		}
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("HTTP_PROXY", "")
+		t.Setenv("HTTPS_PROXY", "")
+		proxy, err := ProxyMetadata{}.ProxyFunc()
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("invalid proxy URLs", func(t *testing.T) {
+		for _, proxyURL := range []string{"ftp://proxy.local", "proxy.local:3128", "http://"} {
+			_, err := ProxyMetadata{ProxyURL: proxyURL}.ProxyFunc()
+			require.Error(t, err, proxyURL)
+		}
+	})
+}
+
+func TestClientConfig(t *testing.T) {
+	client, err := ClientConfig{
+		Proxy:   ProxyMetadata{ProxyURL: "socks5://proxy.local:1080"},
+		Timeout: 10,
+	}.Client()
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+	u, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "socks5://proxy.local:1080", u.String())
+
+	_, err = ClientConfig{Proxy: ProxyMetadata{ProxyURL: "ftp://proxy.local"}}.Client()
+	require.Error(t, err)
+}
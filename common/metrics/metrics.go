@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains OpenTelemetry instruments that components can use to report their operations.
+// Measurements are recorded with the context of the operation, so exemplars link them to the active trace when the meter provider supports it.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/dapr/components-contrib/common/metrics"
+
+// Attribute keys added to the measurements.
+const (
+	ComponentTypeKey = attribute.Key("dapr.component.type")
+	ComponentNameKey = attribute.Key("dapr.component.name")
+	OperationKey     = attribute.Key("dapr.component.operation")
+	SuccessKey       = attribute.Key("dapr.component.success")
+	TopicKey         = attribute.Key("dapr.component.topic")
+	PartitionKey     = attribute.Key("dapr.component.partition")
+)
+
+// Recorder records the metrics of a component.
+// A nil Recorder is valid and records nothing, so components don't need to check whether metrics are enabled.
+type Recorder struct {
+	attrs []attribute.KeyValue
+
+	latency metric.Float64Histogram
+	errors  metric.Int64Counter
+	retries metric.Int64Counter
+
+	lagLock sync.Mutex
+	lag     map[attribute.Set]int64
+	lagReg  metric.Registration
+}
+
+// Option configures a Recorder.
+type Option func(*options)
+
+type options struct {
+	meterProvider metric.MeterProvider
+}
+
+// WithMeterProvider sets the meter provider used to create the instruments.
+// By default, the global meter provider is used.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = mp
+	}
+}
+
+// NewRecorder creates the instruments for a component.
+// componentType is the type of the component, such as "pubsub.kafka", and name is the name of the component resource.
+func NewRecorder(componentType string, name string, opts ...Option) (*Recorder, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.meterProvider == nil {
+		o.meterProvider = otel.GetMeterProvider()
+	}
+	meter := o.meterProvider.Meter(instrumentationName)
+
+	r := &Recorder{
+		attrs: []attribute.KeyValue{
+			ComponentTypeKey.String(componentType),
+			ComponentNameKey.String(name),
+		},
+		lag: map[attribute.Set]int64{},
+	}
+
+	var err error
+	r.latency, err = meter.Float64Histogram("dapr.component.operation.duration",
+		metric.WithDescription("Duration of the operations performed by the component"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.errors, err = meter.Int64Counter("dapr.component.operation.errors",
+		metric.WithDescription("Number of operations performed by the component that failed"),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.retries, err = meter.Int64Counter("dapr.component.operation.retries",
+		metric.WithDescription("Number of times operations performed by the component were retried"),
+		metric.WithUnit("{retry}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// There's no synchronous gauge, so the last lag reported for each topic and partition is observed
+	lag, err := meter.Int64ObservableGauge("dapr.component.broker.lag",
+		metric.WithDescription("Number of messages in the broker not yet consumed by the component"),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.lagReg, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		r.lagLock.Lock()
+		defer r.lagLock.Unlock()
+		for set, v := range r.lag {
+			o.ObserveInt64(lag, v, metric.WithAttributeSet(set))
+		}
+		return nil
+	}, lag)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// StartOperation starts timing an operation.
+// The returned function must be invoked with the result of the operation once it completes.
+func (r *Recorder) StartOperation(ctx context.Context, operation string) func(err error) {
+	if r == nil {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	return func(err error) {
+		r.RecordOperation(ctx, operation, time.Since(start), err)
+	}
+}
+
+// RecordOperation records the duration and the result of an operation.
+func (r *Recorder) RecordOperation(ctx context.Context, operation string, duration time.Duration, err error) {
+	if r == nil {
+		return
+	}
+
+	attrs := r.attributes(OperationKey.String(operation), SuccessKey.Bool(err == nil))
+	r.latency.Record(ctx, duration.Seconds(), attrs)
+	if err != nil {
+		r.errors.Add(ctx, 1, attrs)
+	}
+}
+
+// RecordRetry records that an operation is being retried.
+func (r *Recorder) RecordRetry(ctx context.Context, operation string) {
+	if r == nil {
+		return
+	}
+
+	r.retries.Add(ctx, 1, r.attributes(OperationKey.String(operation)))
+}
+
+// RecordLag records the number of messages not yet consumed from a partition of a topic.
+func (r *Recorder) RecordLag(topic string, partition int32, lag int64) {
+	if r == nil {
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(r.attrs)+2)
+	attrs = append(attrs, r.attrs...)
+	attrs = append(attrs, TopicKey.String(topic), PartitionKey.Int64(int64(partition)))
+	set := attribute.NewSet(attrs...)
+	r.lagLock.Lock()
+	r.lag[set] = lag
+	r.lagLock.Unlock()
+}
+
+// Close unregisters the instruments that are observed asynchronously.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	return r.lagReg.Unregister()
+}
+
+func (r *Recorder) attributes(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	all := make([]attribute.KeyValue, 0, len(r.attrs)+len(attrs))
+	all = append(all, r.attrs...)
+	all = append(all, attrs...)
+	return metric.WithAttributes(all...)
+}
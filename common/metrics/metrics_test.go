@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	t.Helper()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	res := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			res[m.Name] = m
+		}
+	}
+	return res
+}
+
+func TestRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	r, err := NewRecorder("pubsub.kafka", "mykafka", WithMeterProvider(mp))
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx := context.Background()
+	r.StartOperation(ctx, "publish")(nil)
+	r.RecordOperation(ctx, "publish", 20*time.Millisecond, errors.New("failed"))
+	r.RecordRetry(ctx, "consume")
+	r.RecordRetry(ctx, "consume")
+	r.RecordLag("orders", 0, 12)
+	r.RecordLag("orders", 0, 5)
+	r.RecordLag("orders", 1, 3)
+
+	metrics := collect(t, reader)
+
+	latency := metrics["dapr.component.operation.duration"].Data.(metricdata.Histogram[float64])
+	require.Len(t, latency.DataPoints, 2)
+	for _, dp := range latency.DataPoints {
+		assert.Equal(t, uint64(1), dp.Count)
+		v, _ := dp.Attributes.Value(ComponentNameKey)
+		assert.Equal(t, "mykafka", v.AsString())
+		v, _ = dp.Attributes.Value(ComponentTypeKey)
+		assert.Equal(t, "pubsub.kafka", v.AsString())
+	}
+
+	errs := metrics["dapr.component.operation.errors"].Data.(metricdata.Sum[int64])
+	require.Len(t, errs.DataPoints, 1)
+	assert.Equal(t, int64(1), errs.DataPoints[0].Value)
+	success, _ := errs.DataPoints[0].Attributes.Value(SuccessKey)
+	assert.False(t, success.AsBool())
+
+	retries := metrics["dapr.component.operation.retries"].Data.(metricdata.Sum[int64])
+	require.Len(t, retries.DataPoints, 1)
+	assert.Equal(t, int64(2), retries.DataPoints[0].Value)
+
+	lag := metrics["dapr.component.broker.lag"].Data.(metricdata.Gauge[int64])
+	values := map[int64]int64{}
+	for _, dp := range lag.DataPoints {
+		partition, _ := dp.Attributes.Value(PartitionKey)
+		values[partition.AsInt64()] = dp.Value
+	}
+	assert.Equal(t, map[int64]int64{0: 5, 1: 3}, values)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+
+	ctx := context.Background()
+	r.StartOperation(ctx, "publish")(nil)
+	r.RecordOperation(ctx, "publish", time.Second, nil)
+	r.RecordRetry(ctx, "publish")
+	r.RecordLag("orders", 0, 1)
+	require.NoError(t, r.Close())
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resiliency contains the retry and circuit breaker policies that components can apply to their backend calls.
+// These complement the resiliency policies of the Dapr runtime, which cannot see the calls made by components internally,
+// for example to write checkpoints or to refresh tokens.
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sony/gobreaker"
+
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+const (
+	defaultRetryInterval             = time.Second
+	defaultRetryMaxInterval          = time.Minute
+	defaultCircuitBreakerTimeout     = time.Minute
+	defaultCircuitBreakerMaxRequests = 1
+)
+
+// ErrCircuitOpen is returned when a call is not performed because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// PolicyMetadata contains the metadata properties to configure the resiliency policy of backend calls.
+// Components embed it in their metadata struct with `mapstructure:",squash"`.
+// By default, calls are neither retried nor protected by a circuit breaker.
+type PolicyMetadata struct {
+	// Maximum number of times a failed call is retried. 0 (the default) disables retries; -1 retries until the context is canceled.
+	MaxRetries int64 `mapstructure:"backendMaxRetries"`
+	// Backoff policy between retries: "constant" (the default) or "exponential".
+	RetryPolicy string `mapstructure:"backendRetryPolicy"`
+	// Delay between retries with the constant policy, or delay before the first retry with the exponential policy.
+	// Default: 1s.
+	RetryInterval time.Duration `mapstructure:"backendRetryInterval"`
+	// Maximum delay between retries with the exponential policy.
+	// Default: 1m.
+	RetryMaxInterval time.Duration `mapstructure:"backendRetryMaxInterval"`
+	// Number of consecutive failed calls after which the circuit breaker opens. 0 (the default) disables the circuit breaker.
+	CircuitBreakerFailures uint32 `mapstructure:"backendCircuitBreakerFailures"`
+	// Time the circuit breaker stays open before allowing calls to probe the backend again.
+	// Default: 1m.
+	CircuitBreakerTimeout time.Duration `mapstructure:"backendCircuitBreakerTimeout"`
+	// Number of calls allowed while the circuit breaker is half-open; if they all succeed, the circuit breaker closes.
+	// Default: 1.
+	CircuitBreakerMaxRequests uint32 `mapstructure:"backendCircuitBreakerMaxRequests"`
+}
+
+// Policy applies retries and a circuit breaker to backend calls.
+// A nil Policy performs calls once, without any protection.
+type Policy struct {
+	retry   retry.Config
+	breaker *gobreaker.CircuitBreaker
+	logger  logger.Logger
+	name    string
+}
+
+// NewPolicy returns the policy configured in the metadata.
+// The name identifies the backend calls in logs, for example "checkpoint".
+func (m PolicyMetadata) NewPolicy(name string, log logger.Logger) (*Policy, error) {
+	p := &Policy{
+		retry:  retry.DefaultConfigWithNoRetry(),
+		logger: log,
+		name:   name,
+	}
+
+	if m.MaxRetries < -1 {
+		return nil, fmt.Errorf("invalid value for backendMaxRetries: %d", m.MaxRetries)
+	}
+	p.retry.MaxRetries = m.MaxRetries
+	if m.RetryPolicy != "" {
+		err := p.retry.Policy.DecodeString(m.RetryPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for backendRetryPolicy: %w", err)
+		}
+	}
+	interval := defaultRetryInterval
+	if m.RetryInterval > 0 {
+		interval = m.RetryInterval
+	}
+	p.retry.Duration = interval
+	p.retry.InitialInterval = interval
+	p.retry.MaxInterval = defaultRetryMaxInterval
+	if m.RetryMaxInterval > 0 {
+		p.retry.MaxInterval = m.RetryMaxInterval
+	}
+	// Retries are limited by the number of attempts and the context only
+	p.retry.MaxElapsedTime = 0
+
+	if m.CircuitBreakerFailures > 0 {
+		settings := gobreaker.Settings{
+			Name:        name,
+			MaxRequests: defaultCircuitBreakerMaxRequests,
+			Timeout:     defaultCircuitBreakerTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= m.CircuitBreakerFailures
+			},
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				log.Infof("Circuit breaker for %s calls changed from %s to %s", name, from, to)
+			},
+			// Calls interrupted by the caller are not failures of the backend
+			IsSuccessful: func(err error) bool {
+				return err == nil || errors.Is(err, context.Canceled)
+			},
+		}
+		if m.CircuitBreakerMaxRequests > 0 {
+			settings.MaxRequests = m.CircuitBreakerMaxRequests
+		}
+		if m.CircuitBreakerTimeout > 0 {
+			settings.Timeout = m.CircuitBreakerTimeout
+		}
+		p.breaker = gobreaker.NewCircuitBreaker(settings)
+	}
+
+	return p, nil
+}
+
+// Run performs the call, applying the policy.
+// The call can return an error wrapped with backoff.Permanent to stop retrying.
+func (p *Policy) Run(ctx context.Context, op func(ctx context.Context) error) error {
+	_, err := RunWithData(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	})
+	return err
+}
+
+// RunWithData performs the call that returns a value, applying the policy.
+// The call can return an error wrapped with backoff.Permanent to stop retrying.
+func RunWithData[T any](ctx context.Context, p *Policy, op func(ctx context.Context) (T, error)) (T, error) {
+	if p == nil {
+		res, err := op(ctx)
+		var permanent *backoff.PermanentError
+		if errors.As(err, &permanent) {
+			err = permanent.Err
+		}
+		return res, err
+	}
+
+	attempt := func() (T, error) {
+		if p.breaker == nil {
+			return op(ctx)
+		}
+		res, err := p.breaker.Execute(func() (any, error) {
+			return op(ctx)
+		})
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			var zero T
+			return zero, backoff.Permanent(fmt.Errorf("%s: %w", p.name, ErrCircuitOpen))
+		}
+		// res is nil when the call fails or returns a nil value
+		v, _ := res.(T)
+		return v, err
+	}
+
+	return backoff.RetryNotifyWithData(attempt, p.retry.NewBackOffWithContext(ctx), func(err error, d time.Duration) {
+		p.logger.Warnf("Failed %s call, retrying in %v: %v", p.name, d, err)
+	})
+}
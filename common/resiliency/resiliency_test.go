@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+var errBackend = errors.New("backend error")
+
+func failingOp(calls *int, failures int) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		*calls++
+		if *calls <= failures {
+			return "", errBackend
+		}
+		return "ok", nil
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	t.Run("defaults", func(t *testing.T) {
+		p, err := PolicyMetadata{}.NewPolicy("test", log)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, p.retry.MaxRetries)
+		assert.Equal(t, defaultRetryInterval, p.retry.Duration)
+		assert.Nil(t, p.breaker)
+	})
+
+	t.Run("invalid retry policy", func(t *testing.T) {
+		_, err := PolicyMetadata{RetryPolicy: "linear"}.NewPolicy("test", log)
+		require.ErrorContains(t, err, "backendRetryPolicy")
+	})
+
+	t.Run("invalid max retries", func(t *testing.T) {
+		_, err := PolicyMetadata{MaxRetries: -2}.NewPolicy("test", log)
+		require.ErrorContains(t, err, "backendMaxRetries")
+	})
+}
+
+func TestRun(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	t.Run("nil policy calls once", func(t *testing.T) {
+		var p *Policy
+		calls := 0
+		_, err := RunWithData(context.Background(), p, failingOp(&calls, 1))
+		require.ErrorIs(t, err, errBackend)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("retries until success", func(t *testing.T) {
+		p, err := PolicyMetadata{MaxRetries: 3, RetryInterval: time.Millisecond}.NewPolicy("test", log)
+		require.NoError(t, err)
+		calls := 0
+		res, err := RunWithData(context.Background(), p, failingOp(&calls, 2))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops after max retries", func(t *testing.T) {
+		p, err := PolicyMetadata{MaxRetries: 2, RetryPolicy: "exponential", RetryInterval: time.Millisecond}.NewPolicy("test", log)
+		require.NoError(t, err)
+		calls := 0
+		_, err = RunWithData(context.Background(), p, failingOp(&calls, 10))
+		require.ErrorIs(t, err, errBackend)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		p, err := PolicyMetadata{MaxRetries: 3, RetryInterval: time.Millisecond}.NewPolicy("test", log)
+		require.NoError(t, err)
+		calls := 0
+		err = p.Run(context.Background(), func(context.Context) error {
+			calls++
+			return backoff.Permanent(errBackend)
+		})
+		require.ErrorIs(t, err, errBackend)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("circuit breaker opens and recovers", func(t *testing.T) {
+		p, err := PolicyMetadata{
+			CircuitBreakerFailures: 2,
+			CircuitBreakerTimeout:  50 * time.Millisecond,
+		}.NewPolicy("test", log)
+		require.NoError(t, err)
+
+		calls := 0
+		op := failingOp(&calls, 2)
+		for range 2 {
+			_, err = RunWithData(context.Background(), p, op)
+			require.ErrorIs(t, err, errBackend)
+		}
+
+		// The breaker is open, so the backend is not called
+		_, err = RunWithData(context.Background(), p, op)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, calls)
+
+		// After the timeout, a call is allowed and closes the breaker
+		time.Sleep(60 * time.Millisecond)
+		res, err := RunWithData(context.Background(), p, op)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+		res, err = RunWithData(context.Background(), p, op)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+	})
+}
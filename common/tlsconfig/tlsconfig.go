@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tlsconfig builds the TLS configuration used by components to connect to their services.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metadata contains the TLS metadata properties shared by all components.
+// Components embed it in their metadata struct with `mapstructure:",squash"`.
+type Metadata struct {
+	// Path to a file with the PEM-encoded CA certificates used to verify the server.
+	CACertFile string `mapstructure:"caCertFile"`
+	// Path to a file with the PEM-encoded client certificate.
+	ClientCertFile string `mapstructure:"clientCertFile"`
+	// Path to a file with the PEM-encoded client key.
+	ClientKeyFile string `mapstructure:"clientKeyFile"`
+	// Minimum TLS version: "1.2" (the default) or "1.3".
+	MinVersion string `mapstructure:"tlsMinVersion"`
+	// Server name used for SNI and to verify the server certificate, overriding the one from the address.
+	ServerName string `mapstructure:"tlsServerName"`
+}
+
+// Config contains the options to build a TLS configuration.
+// Certificates loaded from files are reloaded when the files change, so they can be rotated without restarting the component.
+type Config struct {
+	Metadata
+
+	// PEM-encoded CA certificates used to verify the server.
+	CACert string
+	// PEM-encoded client certificate.
+	ClientCert string
+	// PEM-encoded client key.
+	ClientKey string
+	// If true, the server certificate is not verified.
+	InsecureSkipVerify bool
+}
+
+// Build returns the TLS configuration.
+func (c Config) Build() (*tls.Config, error) {
+	minVersion, err := parseMinVersion(c.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server certificate is verified by the standard library unless the CA certificates are loaded from a file and need to be reloaded
+	//nolint:gosec
+	tlsConfig := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	err = c.setClientCertificate(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.setRootCAs(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsConfig, nil
+}
+
+func (c Config) setClientCertificate(tlsConfig *tls.Config) error {
+	if (c.ClientCert != "" || c.ClientKey != "") && (c.ClientCertFile != "" || c.ClientKeyFile != "") {
+		return errors.New("client certificate and key must be set either inline or as files, not both")
+	}
+
+	switch {
+	case c.ClientCert != "" || c.ClientKey != "":
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return errors.New("client certificate and key must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return fmt.Errorf("unable to load client certificate and key pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+
+	case c.ClientCertFile != "" || c.ClientKeyFile != "":
+		if c.ClientCertFile == "" || c.ClientKeyFile == "" {
+			return errors.New("client certificate and key files must be set together")
+		}
+		r := newReloader([]string{c.ClientCertFile, c.ClientKeyFile}, func() (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load client certificate and key pair: %w", err)
+			}
+			return &cert, nil
+		})
+		_, err := r.get()
+		if err != nil {
+			return err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.get()
+		}
+	}
+
+	return nil
+}
+
+func (c Config) setRootCAs(tlsConfig *tls.Config) error {
+	if c.CACert == "" && c.CACertFile == "" {
+		return nil
+	}
+
+	loadPool := func() (*x509.CertPool, error) {
+		pool := x509.NewCertPool()
+		if c.CACert != "" && !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+			return nil, errors.New("unable to load CA certificate")
+		}
+		if c.CACertFile != "" {
+			data, err := os.ReadFile(c.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read CA certificate file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("unable to load CA certificate from file '%s'", c.CACertFile)
+			}
+		}
+		return pool, nil
+	}
+
+	if c.CACertFile == "" {
+		pool, err := loadPool()
+		if err != nil {
+			return err
+		}
+		tlsConfig.RootCAs = pool
+		return nil
+	}
+
+	r := newReloader([]string{c.CACertFile}, loadPool)
+	pool, err := r.get()
+	if err != nil {
+		return err
+	}
+	tlsConfig.RootCAs = pool
+	if c.InsecureSkipVerify {
+		return nil
+	}
+
+	// The CA certificates can change after the connection is configured, so the server certificate is verified here against the current ones
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("server did not provide a certificate")
+		}
+		// The server name in the connection state is empty when connecting to an IP address, as it's not sent with SNI
+		serverName := c.ServerName
+		if serverName == "" {
+			serverName = cs.ServerName
+		}
+		if serverName == "" {
+			return errors.New("unable to verify the server certificate without a server name: set 'tlsServerName'")
+		}
+		roots, err := r.get()
+		if err != nil {
+			return err
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			DNSName:       serverName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err = cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+
+	return nil
+}
+
+func parseMinVersion(val string) (uint16, error) {
+	switch strings.TrimSpace(val) {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid value for 'tlsMinVersion': '%s' (allowed values: 1.2, 1.3)", val)
+	}
+}
+
+// reloader loads a value from files, loading it again when any of the files is modified.
+type reloader[T any] struct {
+	files    []string
+	load     func() (T, error)
+	lock     sync.Mutex
+	value    T
+	loaded   bool
+	modTimes []time.Time
+}
+
+func newReloader[T any](files []string, load func() (T, error)) *reloader[T] {
+	return &reloader[T]{
+		files:    files,
+		load:     load,
+		modTimes: make([]time.Time, len(files)),
+	}
+}
+
+// get returns the current value.
+// If the files changed but the value can't be loaded, for example because they are being written, the previous value is returned.
+func (r *reloader[T]) get() (T, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	changed := !r.loaded
+	modTimes := make([]time.Time, len(r.files))
+	for i, f := range r.files {
+		info, err := os.Stat(f)
+		if err != nil {
+			if r.loaded {
+				return r.value, nil
+			}
+			return r.value, fmt.Errorf("unable to read file '%s': %w", f, err)
+		}
+		modTimes[i] = info.ModTime()
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return r.value, nil
+	}
+
+	value, err := r.load()
+	if err != nil {
+		if r.loaded {
+			return r.value, nil
+		}
+		return value, err
+	}
+	r.value = value
+	r.loaded = true
+	r.modTimes = modTimes
+	return r.value, nil
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func createCert(t *testing.T, name string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestBuild(t *testing.T) {
+	ca := createCert(t, "ca", nil)
+	client := createCert(t, "client", ca)
+
+	t.Run("defaults", func(t *testing.T) {
+		tlsConfig, err := Config{}.Build()
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+		assert.Nil(t, tlsConfig.RootCAs)
+		assert.Empty(t, tlsConfig.Certificates)
+		assert.False(t, tlsConfig.InsecureSkipVerify)
+	})
+
+	t.Run("inline certificates", func(t *testing.T) {
+		tlsConfig, err := Config{
+			CACert:     string(ca.certPEM),
+			ClientCert: string(client.certPEM),
+			ClientKey:  string(client.keyPEM),
+			Metadata: Metadata{
+				MinVersion: "1.3",
+				ServerName: "example.com",
+			},
+		}.Build()
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		assert.Equal(t, "example.com", tlsConfig.ServerName)
+		require.NotNil(t, tlsConfig.RootCAs)
+		require.Len(t, tlsConfig.Certificates, 1)
+	})
+
+	t.Run("invalid configurations", func(t *testing.T) {
+		dir := t.TempDir()
+		tests := map[string]Config{
+			"invalid min version": {Metadata: Metadata{MinVersion: "1.1"}},
+			"cert without key":    {ClientCert: string(client.certPEM)},
+			"cert file without key file": {
+				Metadata: Metadata{ClientCertFile: filepath.Join(dir, "cert.pem")},
+			},
+			"inline and file": {
+				ClientCert: string(client.certPEM),
+				ClientKey:  string(client.keyPEM),
+				Metadata:   Metadata{ClientCertFile: filepath.Join(dir, "cert.pem")},
+			},
+			"invalid CA":       {CACert: "not a certificate"},
+			"missing CA file":  {Metadata: Metadata{CACertFile: filepath.Join(dir, "missing.pem")}},
+			"invalid key pair": {ClientCert: string(client.certPEM), ClientKey: string(ca.keyPEM)},
+		}
+		for name, cfg := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := cfg.Build()
+				require.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("client certificate is reloaded", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := filepath.Join(dir, "cert.pem")
+		keyFile := filepath.Join(dir, "key.pem")
+		modTime := time.Now().Add(-time.Hour)
+		writeFile(t, certFile, client.certPEM, modTime)
+		writeFile(t, keyFile, client.keyPEM, modTime)
+
+		tlsConfig, err := Config{
+			Metadata: Metadata{ClientCertFile: certFile, ClientKeyFile: keyFile},
+		}.Build()
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig.GetClientCertificate)
+
+		cert, err := tlsConfig.GetClientCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, client.cert.Raw, cert.Certificate[0])
+
+		// Rotate the certificate
+		rotated := createCert(t, "rotated", ca)
+		modTime = modTime.Add(time.Minute)
+		writeFile(t, certFile, rotated.certPEM, modTime)
+		writeFile(t, keyFile, rotated.keyPEM, modTime)
+
+		cert, err = tlsConfig.GetClientCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, rotated.cert.Raw, cert.Certificate[0])
+
+		// An invalid file keeps the previous certificate
+		writeFile(t, keyFile, []byte("invalid"), modTime.Add(time.Minute))
+		cert, err = tlsConfig.GetClientCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, rotated.cert.Raw, cert.Certificate[0])
+	})
+
+	t.Run("CA certificate file is reloaded", func(t *testing.T) {
+		server := createCert(t, "server", ca)
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		srv.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{server.cert.Raw},
+				PrivateKey:  server.key,
+			}},
+		}
+		srv.StartTLS()
+		defer srv.Close()
+
+		// Start with a CA that didn't sign the server certificate
+		otherCA := createCert(t, "other", nil)
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		modTime := time.Now().Add(-time.Hour)
+		writeFile(t, caFile, otherCA.certPEM, modTime)
+
+		tlsConfig, err := Config{
+			Metadata: Metadata{CACertFile: caFile, ServerName: "localhost"},
+		}.Build()
+		require.NoError(t, err)
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		_, err = httpClient.Get(srv.URL)
+		require.Error(t, err)
+
+		writeFile(t, caFile, ca.certPEM, modTime.Add(time.Minute))
+		httpClient.CloseIdleConnections()
+		res, err := httpClient.Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
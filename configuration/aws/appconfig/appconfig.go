@@ -0,0 +1,290 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/appconfigdata"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+// ConfigurationStore is an AWS AppConfig configuration store.
+// It reads the deployed version of a configuration profile using the AppConfig Data API.
+type ConfigurationStore struct {
+	authProvider awsAuth.Provider
+	metadata     metadata
+
+	// Poll tokens can only be used once, so access to the session is serialized
+	sessionLock  sync.Mutex
+	token        *string
+	items        map[string]*configuration.Item
+	lastPoll     time.Time
+	pollInterval time.Duration
+
+	subscriptions configuration.Subscriptions
+
+	logger logger.Logger
+}
+
+// NewAWSAppConfigStore returns a new AWS AppConfig configuration store.
+func NewAWSAppConfigStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(ctx context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	opts := awsAuth.Options{
+		Logger:       r.logger,
+		Properties:   md.Properties,
+		Region:       r.metadata.Region,
+		Endpoint:     r.metadata.Endpoint,
+		AccessKey:    r.metadata.AccessKey,
+		SecretKey:    r.metadata.SecretKey,
+		SessionToken: r.metadata.SessionToken,
+	}
+	r.authProvider, err = awsAuth.NewProvider(ctx, opts, awsAuth.GetConfig(opts))
+	if err != nil {
+		return err
+	}
+	r.pollInterval = r.metadata.PollInterval
+
+	// Start the session and fetch the configuration to validate the identifiers
+	_, err = r.refresh(ctx, true)
+	return err
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	items, err := r.refresh(ctx, false)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	return &configuration.GetResponse{
+		Items: configuration.FilterItems(items, req.Keys),
+	}, nil
+}
+
+// refresh returns the latest configuration, polling AppConfig if the poll interval has elapsed or if force is true.
+// The returned map must not be modified.
+func (r *ConfigurationStore) refresh(parentCtx context.Context, force bool) (map[string]*configuration.Item, error) {
+	r.sessionLock.Lock()
+	defer r.sessionLock.Unlock()
+
+	if !force && r.items != nil && time.Since(r.lastPoll) < r.pollInterval {
+		return r.items, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, r.metadata.RequestTimeout)
+	defer cancel()
+
+	client := r.authProvider.AppConfigData().AppConfigData
+	if r.token == nil {
+		session, err := client.StartConfigurationSessionWithContext(ctx, &appconfigdata.StartConfigurationSessionInput{
+			ApplicationIdentifier:                ptr.Of(r.metadata.Application),
+			EnvironmentIdentifier:                ptr.Of(r.metadata.Environment),
+			ConfigurationProfileIdentifier:       ptr.Of(r.metadata.ConfigurationProfile),
+			RequiredMinimumPollIntervalInSeconds: ptr.Of(int64(r.metadata.PollInterval / time.Second)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to start AppConfig configuration session: %w", err)
+		}
+		r.token = session.InitialConfigurationToken
+	}
+
+	res, err := client.GetLatestConfigurationWithContext(ctx, &appconfigdata.GetLatestConfigurationInput{
+		ConfigurationToken: r.token,
+	})
+	if err != nil {
+		// Tokens expire after 24 hours without use, so start a new session on the next attempt
+		r.token = nil
+		return nil, fmt.Errorf("failed to get latest configuration from AppConfig: %w", err)
+	}
+
+	r.token = res.NextPollConfigurationToken
+	r.lastPoll = time.Now()
+	r.pollInterval = r.metadata.PollInterval
+	if res.NextPollIntervalInSeconds != nil {
+		if next := time.Duration(*res.NextPollIntervalInSeconds) * time.Second; next > r.pollInterval {
+			r.pollInterval = next
+		}
+	}
+
+	// An empty configuration means that nothing changed since the last poll
+	if len(res.Configuration) > 0 || r.items == nil {
+		var contentType, version string
+		if res.ContentType != nil {
+			contentType = *res.ContentType
+		}
+		if res.VersionLabel != nil {
+			version = *res.VersionLabel
+		}
+		r.items, err = parseConfiguration(r.metadata.ConfigurationProfile, contentType, version, res.Configuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r.items, nil
+}
+
+// parseConfiguration converts a configuration document into items.
+// JSON objects, including feature flag profiles, produce an item for each top-level property; other documents produce a single item named after the configuration profile.
+func parseConfiguration(profile string, contentType string, version string, data []byte) (map[string]*configuration.Item, error) {
+	if !strings.HasPrefix(contentType, "application/json") {
+		return map[string]*configuration.Item{
+			profile: {
+				Value:   string(data),
+				Version: version,
+				Metadata: map[string]string{
+					"contentType": contentType,
+				},
+			},
+		}, nil
+	}
+
+	var doc map[string]json.RawMessage
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration profile '%s' as a JSON object: %w", profile, err)
+	}
+
+	items := make(map[string]*configuration.Item, len(doc))
+	for key, raw := range doc {
+		item := &configuration.Item{
+			Version:  version,
+			Metadata: map[string]string{},
+		}
+
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			item.Value = str
+		} else {
+			buf := &bytes.Buffer{}
+			if json.Compact(buf, raw) == nil {
+				item.Value = buf.String()
+			} else {
+				item.Value = string(raw)
+			}
+		}
+
+		// Feature flags are objects with an "enabled" property
+		var flag struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if json.Unmarshal(raw, &flag) == nil && flag.Enabled != nil {
+			item.Metadata["enabled"] = strconv.FormatBool(*flag.Enabled)
+		}
+
+		items[key] = item
+	}
+
+	return items, nil
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	items, err := r.refresh(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := configuration.FilterItems(items, req.Keys)
+	return r.subscriptions.Start(ctx, func(ctx context.Context, id string) {
+		r.doSubscribe(ctx, req, handler, snapshot, id)
+	})
+}
+
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, snapshot map[string]*configuration.Item, id string) {
+	for {
+		r.sessionLock.Lock()
+		wait := r.pollInterval
+		r.sessionLock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		items, err := r.refresh(ctx, false)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("Failed to get configuration changes: %s", err)
+			continue
+		}
+
+		current := configuration.FilterItems(items, req.Keys)
+		changed := configuration.DiffItems(snapshot, current, true)
+		snapshot = current
+		if len(changed) > 0 {
+			r.handleSubscribedChange(ctx, handler, changed, id)
+		}
+	}
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	return r.subscriptions.Stop(req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	r.subscriptions.Close()
+
+	if r.authProvider != nil {
+		return r.authProvider.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appconfig
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/appconfigdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func newTestStore(t *testing.T, mock *awsAuth.MockAppConfigData) *ConfigurationStore {
+	s := NewAWSAppConfigStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err := s.metadata.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"application":          "app",
+		"environment":          "prod",
+		"configurationProfile": "profile",
+	}}})
+	require.NoError(t, err)
+
+	mockAuthProvider := &awsAuth.StaticAuth{}
+	mockAuthProvider.WithMockClients(&awsAuth.Clients{
+		AppConfigData: &awsAuth.AppConfigDataClients{
+			AppConfigData: mock,
+		},
+	})
+	s.authProvider = mockAuthProvider
+	s.pollInterval = s.metadata.PollInterval
+	return s
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"application":          "app",
+			"environment":          "prod",
+			"configurationProfile": "profile",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultPollInterval, m.PollInterval)
+		assert.Equal(t, defaultRequestTimeout, m.RequestTimeout)
+	})
+
+	t.Run("missing identifiers", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"application": "app",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("poll interval too short", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"application":          "app",
+			"environment":          "prod",
+			"configurationProfile": "profile",
+			"pollInterval":         "5s",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestParseConfiguration(t *testing.T) {
+	t.Run("JSON document", func(t *testing.T) {
+		items, err := parseConfiguration("profile", "application/json", "v1", []byte(`{"str": "hello", "num": 42, "obj": {"a": 1}}`))
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, "hello", items["str"].Value)
+		assert.Equal(t, "42", items["num"].Value)
+		assert.Equal(t, `{"a":1}`, items["obj"].Value)
+		assert.Equal(t, "v1", items["str"].Version)
+	})
+
+	t.Run("feature flags", func(t *testing.T) {
+		items, err := parseConfiguration("profile", "application/json", "", []byte(`{"darkMode": {"enabled": true, "color": "black"}, "beta": {"enabled": false}}`))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "true", items["darkMode"].Metadata["enabled"])
+		assert.Equal(t, `{"enabled":true,"color":"black"}`, items["darkMode"].Value)
+		assert.Equal(t, "false", items["beta"].Metadata["enabled"])
+	})
+
+	t.Run("text document", func(t *testing.T) {
+		items, err := parseConfiguration("profile", "application/x-yaml", "", []byte("a: b"))
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "a: b", items["profile"].Value)
+		assert.Equal(t, "application/x-yaml", items["profile"].Metadata["contentType"])
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseConfiguration("profile", "application/json", "", []byte(`[1, 2]`))
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	sessions := 0
+	polls := 0
+	mock := &awsAuth.MockAppConfigData{
+		StartConfigurationSessionFn: func(ctx context.Context, input *appconfigdata.StartConfigurationSessionInput, option ...request.Option) (*appconfigdata.StartConfigurationSessionOutput, error) {
+			sessions++
+			assert.Equal(t, "app", *input.ApplicationIdentifier)
+			assert.Equal(t, "prod", *input.EnvironmentIdentifier)
+			assert.Equal(t, "profile", *input.ConfigurationProfileIdentifier)
+			return &appconfigdata.StartConfigurationSessionOutput{
+				InitialConfigurationToken: ptr.Of("token-0"),
+			}, nil
+		},
+		GetLatestConfigurationFn: func(ctx context.Context, input *appconfigdata.GetLatestConfigurationInput, option ...request.Option) (*appconfigdata.GetLatestConfigurationOutput, error) {
+			polls++
+			switch *input.ConfigurationToken {
+			case "token-0":
+				return &appconfigdata.GetLatestConfigurationOutput{
+					Configuration:              []byte(`{"key1": "value1", "key2": "value2"}`),
+					ContentType:                ptr.Of("application/json"),
+					VersionLabel:               ptr.Of("1"),
+					NextPollConfigurationToken: ptr.Of("token-1"),
+					NextPollIntervalInSeconds:  ptr.Of(int64(60)),
+				}, nil
+			case "token-1":
+				// No changes
+				return &appconfigdata.GetLatestConfigurationOutput{
+					NextPollConfigurationToken: ptr.Of("token-2"),
+				}, nil
+			default:
+				return nil, errors.New("expired token")
+			}
+		},
+	}
+	s := newTestStore(t, mock)
+
+	res, err := s.Get(context.Background(), &configuration.GetRequest{
+		Keys: []string{"key1", "notfound"},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Items, 1)
+	assert.Equal(t, "value1", res.Items["key1"].Value)
+	assert.Equal(t, "1", res.Items["key1"].Version)
+	assert.Equal(t, 60*time.Second, s.pollInterval)
+
+	// Served from the cache until the poll interval elapses
+	res, err = s.Get(context.Background(), &configuration.GetRequest{})
+	require.NoError(t, err)
+	assert.Len(t, res.Items, 2)
+	assert.Equal(t, 1, polls)
+
+	// Unchanged configuration keeps the previous items
+	_, err = s.refresh(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, polls)
+	assert.Len(t, s.items, 2)
+
+	// Errors reset the session
+	_, err = s.refresh(context.Background(), true)
+	require.Error(t, err)
+	assert.Nil(t, s.token)
+	_, err = s.refresh(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sessions)
+}
+
+func TestSubscribe(t *testing.T) {
+	var polls atomic.Int32
+	mock := &awsAuth.MockAppConfigData{
+		StartConfigurationSessionFn: func(ctx context.Context, input *appconfigdata.StartConfigurationSessionInput, option ...request.Option) (*appconfigdata.StartConfigurationSessionOutput, error) {
+			return &appconfigdata.StartConfigurationSessionOutput{
+				InitialConfigurationToken: ptr.Of("token"),
+			}, nil
+		},
+		GetLatestConfigurationFn: func(ctx context.Context, input *appconfigdata.GetLatestConfigurationInput, option ...request.Option) (*appconfigdata.GetLatestConfigurationOutput, error) {
+			out := &appconfigdata.GetLatestConfigurationOutput{
+				NextPollConfigurationToken: ptr.Of("token"),
+			}
+			switch polls.Add(1) {
+			case 1:
+				out.Configuration = []byte(`{"key1": "value1", "key2": "value2", "key3": "value3"}`)
+				out.ContentType = ptr.Of("application/json")
+				out.VersionLabel = ptr.Of("1")
+			case 3:
+				// Deployment of a new version
+				out.Configuration = []byte(`{"key1": "value1", "key2": "updated", "key4": "value4"}`)
+				out.ContentType = ptr.Of("application/json")
+				out.VersionLabel = ptr.Of("2")
+			}
+			return out, nil
+		},
+	}
+	s := newTestStore(t, mock)
+	s.metadata.PollInterval = 10 * time.Millisecond
+	s.pollInterval = s.metadata.PollInterval
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Keys: []string{"key2", "key3", "key4"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		require.Len(t, e.Items, 3)
+		assert.Equal(t, "updated", e.Items["key2"].Value)
+		assert.Equal(t, "2", e.Items["key2"].Version)
+		assert.Equal(t, "value4", e.Items["key4"].Value)
+		// Deleted keys are notified with an empty item
+		assert.Empty(t, e.Items["key3"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the update event")
+	}
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.NoError(t, s.Close())
+
+	_, err = s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		return nil
+	})
+	require.Error(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appconfig
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	// AppConfig does not allow polling more frequently than every 15 seconds.
+	minPollInterval       = 15 * time.Second
+	defaultPollInterval   = 30 * time.Second
+	defaultRequestTimeout = 15 * time.Second
+)
+
+type metadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	AccessKey    string `json:"accessKey" mapstructure:"accessKey" mdignore:"true"`
+	SecretKey    string `json:"secretKey" mapstructure:"secretKey" mdignore:"true"`
+	SessionToken string `json:"sessionToken" mapstructure:"sessionToken" mdignore:"true"`
+	Region       string `json:"region" mapstructure:"region" mapstructurealiases:"awsRegion" mdignore:"true"`
+	Endpoint     string `json:"endpoint" mapstructure:"endpoint"`
+
+	// Name or ID of the AppConfig application.
+	Application string `json:"application" mapstructure:"application"`
+	// Name or ID of the AppConfig environment.
+	Environment string `json:"environment" mapstructure:"environment"`
+	// Name or ID of the configuration profile.
+	ConfigurationProfile string `json:"configurationProfile" mapstructure:"configurationProfile"`
+	// Minimum interval between polls for the latest deployed configuration.
+	PollInterval time.Duration `json:"pollInterval" mapstructure:"pollInterval"`
+	// Timeout for requests to AppConfig.
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.PollInterval = defaultPollInterval
+	m.RequestTimeout = defaultRequestTimeout
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.Application == "" {
+		return errors.New("aws appconfig error: missing required metadata property 'application'")
+	}
+	if m.Environment == "" {
+		return errors.New("aws appconfig error: missing required metadata property 'environment'")
+	}
+	if m.ConfigurationProfile == "" {
+		return errors.New("aws appconfig error: missing required metadata property 'configurationProfile'")
+	}
+	if m.PollInterval < minPollInterval {
+		return errors.New("aws appconfig error: 'pollInterval' must be at least 15s")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
@@ -15,9 +15,13 @@ package appconfig
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,6 +31,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/google/uuid"
 
 	azauth "github.com/dapr/components-contrib/common/authentication/azure"
@@ -51,11 +56,20 @@ const (
 	defaultMaxRetryDelay         = time.Second * 120
 	defaultSubscribePollInterval = time.Hour * 24
 	defaultRequestTimeout        = time.Second * 15
+	featureFlagPrefix            = ".appconfig.featureflag/"
+	featureFlagContentType       = "application/vnd.microsoft.appconfig.ff+json"
+	keyVaultRefContentType       = "application/vnd.microsoft.appconfig.keyvaultref+json"
+	keyVaultRefMetadataKey       = "keyVaultReference"
 )
 
 type azAppConfigClient interface {
 	GetSetting(ctx context.Context, key string, options *azappconfig.GetSettingOptions) (azappconfig.GetSettingResponse, error)
 	NewListSettingsPager(selector azappconfig.SettingSelector, options *azappconfig.ListSettingsOptions) *runtime.Pager[azappconfig.ListSettingsPageResponse]
+	NewListSettingsForSnapshotPager(snapshotName string, options *azappconfig.ListSettingsForSnapshotOptions) *runtime.Pager[azappconfig.ListSettingsForSnapshotResponse]
+}
+
+type azSecretsClient interface {
+	GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
 }
 
 // ConfigurationStore is a Azure App Configuration store.
@@ -63,6 +77,11 @@ type ConfigurationStore struct {
 	client   azAppConfigClient
 	metadata metadata
 
+	// Clients for the Key Vaults referenced by settings, indexed by vault URL
+	newSecretsClient func(vaultURL string) (azSecretsClient, error)
+	secretsClients   map[string]azSecretsClient
+	secretsLock      sync.Mutex
+
 	cancelMap sync.Map
 	wg        sync.WaitGroup
 	closed    atomic.Bool
@@ -103,24 +122,36 @@ func (r *ConfigurationStore) Init(_ context.Context, md configuration.Metadata)
 		ClientOptions: coreClientOpts,
 	}
 
-	if r.metadata.ConnectionString != "" {
-		r.client, err = azappconfig.NewClientFromConnectionString(r.metadata.ConnectionString, &options)
-		if err != nil {
-			return err
+	// The Azure AD credential is also used to resolve Key Vault references, even when connecting with a connection string:
+	// in that case, errors are only returned when a reference needs to be resolved
+	var cred azcore.TokenCredential
+	settings, credErr := azauth.NewEnvironmentSettings(md.Properties)
+	if credErr == nil {
+		cred, credErr = settings.GetTokenCredential()
+	}
+	if credErr != nil && r.metadata.ConnectionString == "" {
+		return credErr
+	}
+	r.secretsClients = map[string]azSecretsClient{}
+	r.newSecretsClient = func(vaultURL string) (azSecretsClient, error) {
+		if credErr != nil {
+			return nil, credErr
 		}
-	} else {
-		var settings azauth.EnvironmentSettings
-		settings, err = azauth.NewEnvironmentSettings(md.Properties)
+		client, err := azsecrets.NewClient(vaultURL, cred, &azsecrets.ClientOptions{
+			ClientOptions: coreClientOpts,
+		})
 		if err != nil {
-			return err
+			return nil, err
 		}
+		return client, nil
+	}
 
-		var cred azcore.TokenCredential
-		cred, err = settings.GetTokenCredential()
+	if r.metadata.ConnectionString != "" {
+		r.client, err = azappconfig.NewClientFromConnectionString(r.metadata.ConnectionString, &options)
 		if err != nil {
 			return err
 		}
-
+	} else {
 		r.client, err = azappconfig.NewClient(r.metadata.Host, cred, &options)
 		if err != nil {
 			return err
@@ -131,82 +162,296 @@ func (r *ConfigurationStore) Init(_ context.Context, md configuration.Metadata)
 }
 
 func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	opts, err := r.getRequestOptionsFromMetadata(req.Metadata)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	// When reading feature flags, keys are the names of the flags
 	keys := req.Keys
-	var items map[string]*configuration.Item
+	if opts.FeatureFlags {
+		keys = make([]string, len(req.Keys))
+		for i, k := range req.Keys {
+			keys[i] = featureFlagPrefix + k
+		}
+	}
 
-	if len(keys) == 0 {
-		var err error
-		if items, err = r.getAll(ctx, req); err != nil {
-			return &configuration.GetResponse{}, err
+	var items map[string]*configuration.Item
+	switch {
+	case opts.Snapshot != "":
+		items, err = r.getSnapshot(ctx, opts.Snapshot, keys)
+	case len(keys) == 0:
+		keyFilter := opts.KeyFilter
+		if keyFilter == "" {
+			keyFilter = "*"
+			if opts.FeatureFlags {
+				keyFilter = featureFlagPrefix + "*"
+			}
 		}
-	} else {
+		labelFilter := opts.Label
+		if labelFilter == "" {
+			labelFilter = "*"
+		}
+		items, err = r.listSettings(ctx, keyFilter, labelFilter)
+	case isFilter(opts.Label):
 		items = make(map[string]*configuration.Item, len(keys))
 		for _, key := range keys {
-			resp, err := r.getSettings(
-				ctx,
-				key,
-				&azappconfig.GetSettingOptions{
-					Label: r.getLabelFromMetadata(req.Metadata),
-				},
-			)
+			var res map[string]*configuration.Item
+			res, err = r.listSettings(ctx, escapeFilter(key), opts.Label)
 			if err != nil {
-				return &configuration.GetResponse{}, err
-			}
-
-			item := &configuration.Item{
-				Metadata: map[string]string{},
+				break
 			}
-			item.Value = *resp.Value
-			if resp.Label != nil {
-				item.Metadata["label"] = *resp.Label
+			for k, v := range res {
+				items[k] = v
 			}
+		}
+	default:
+		items, err = r.getKeys(ctx, keys, opts.Label)
+	}
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
 
-			items[key] = item
+	resolve := r.metadata.ResolveKeyVaultReferences
+	if opts.ResolveKeyVaultReferences != nil {
+		resolve = *opts.ResolveKeyVaultReferences
+	}
+	if resolve {
+		err = r.resolveKeyVaultReferences(ctx, items)
+		if err != nil {
+			return &configuration.GetResponse{}, err
 		}
 	}
+
+	if opts.FeatureFlags {
+		flags := make(map[string]*configuration.Item, len(items))
+		for k, v := range items {
+			if name, ok := strings.CutPrefix(k, featureFlagPrefix); ok {
+				flags[name] = v
+			}
+		}
+		items = flags
+	}
+
 	return &configuration.GetResponse{
 		Items: items,
 	}, nil
 }
 
-func (r *ConfigurationStore) getAll(ctx context.Context, req *configuration.GetRequest) (map[string]*configuration.Item, error) {
-	items := make(map[string]*configuration.Item, 0)
+func (r *ConfigurationStore) getKeys(ctx context.Context, keys []string, label string) (map[string]*configuration.Item, error) {
+	var labelPtr *string
+	if label != "" {
+		labelPtr = to.Ptr(label)
+	}
 
-	labelFilter := r.getLabelFromMetadata(req.Metadata)
-	if labelFilter == nil {
-		labelFilter = to.Ptr("*")
+	items := make(map[string]*configuration.Item, len(keys))
+	for _, key := range keys {
+		resp, err := r.getSettings(
+			ctx,
+			key,
+			&azappconfig.GetSettingOptions{
+				Label: labelPtr,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items[key] = settingToItem(resp.Setting)
 	}
+	return items, nil
+}
+
+func (r *ConfigurationStore) listSettings(ctx context.Context, keyFilter string, labelFilter string) (map[string]*configuration.Item, error) {
+	items := make(map[string]*configuration.Item, 0)
 
 	allSettingsPgr := r.client.NewListSettingsPager(
 		azappconfig.SettingSelector{
-			KeyFilter:   to.Ptr("*"),
-			LabelFilter: labelFilter,
+			KeyFilter:   to.Ptr(keyFilter),
+			LabelFilter: to.Ptr(labelFilter),
 			Fields:      azappconfig.AllSettingFields(),
 		},
 		nil)
 
 	for allSettingsPgr.More() {
 		timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.RequestTimeout)
-		defer cancel()
-		if revResp, err := allSettingsPgr.NextPage(timeoutContext); err == nil {
-			for _, setting := range revResp.Settings {
-				item := &configuration.Item{
-					Metadata: map[string]string{},
-				}
-				item.Value = *setting.Value
-				if setting.Label != nil {
-					item.Metadata["label"] = *setting.Label
-				}
+		revResp, err := allSettingsPgr.NextPage(timeoutContext)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load all keys, error is %w", err)
+		}
+		for _, setting := range revResp.Settings {
+			items[*setting.Key] = settingToItem(setting)
+		}
+	}
+	return items, nil
+}
 
-				items[*setting.Key] = item
+// getSnapshot reads the settings in a snapshot, which are consistent with each other.
+func (r *ConfigurationStore) getSnapshot(ctx context.Context, snapshot string, keys []string) (map[string]*configuration.Item, error) {
+	var filter map[string]struct{}
+	if len(keys) > 0 {
+		filter = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			filter[k] = struct{}{}
+		}
+	}
+
+	items := make(map[string]*configuration.Item, len(keys))
+	pgr := r.client.NewListSettingsForSnapshotPager(snapshot, nil)
+	for pgr.More() {
+		timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.RequestTimeout)
+		resp, err := pgr.NextPage(timeoutContext)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keys from snapshot %s, error is %w", snapshot, err)
+		}
+		for _, setting := range resp.Settings {
+			if filter != nil {
+				if _, ok := filter[*setting.Key]; !ok {
+					continue
+				}
 			}
-		} else {
-			return nil, fmt.Errorf("failed to load all keys, error is %w", err)
+			items[*setting.Key] = settingToItem(setting)
 		}
 	}
 	return items, nil
 }
 
+// settingToItem converts a setting to a configuration item.
+// Feature flags are parsed, and their state is included in the "enabled" metadata property.
+func settingToItem(setting azappconfig.Setting) *configuration.Item {
+	item := &configuration.Item{
+		Metadata: map[string]string{},
+	}
+	if setting.Value != nil {
+		item.Value = *setting.Value
+	}
+	if setting.Label != nil {
+		item.Metadata["label"] = *setting.Label
+	}
+	if setting.ContentType != nil && strings.HasPrefix(*setting.ContentType, keyVaultRefContentType) {
+		item.Metadata[keyVaultRefMetadataKey] = "true"
+	}
+	if setting.ContentType != nil && strings.HasPrefix(*setting.ContentType, featureFlagContentType) {
+		var flag struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := json.Unmarshal([]byte(item.Value), &flag); err == nil {
+			item.Metadata["featureFlag"] = "true"
+			item.Metadata["enabled"] = strconv.FormatBool(flag.Enabled)
+		}
+	}
+	return item
+}
+
+// resolveKeyVaultReferences replaces the value of the items that are Key Vault references with the value of the secret.
+func (r *ConfigurationStore) resolveKeyVaultReferences(ctx context.Context, items map[string]*configuration.Item) error {
+	for key, item := range items {
+		if item.Metadata[keyVaultRefMetadataKey] != "true" {
+			continue
+		}
+
+		vaultURL, name, version, err := parseKeyVaultReference(item.Value)
+		if err != nil {
+			return fmt.Errorf("invalid Key Vault reference in key %s: %w", key, err)
+		}
+		client, err := r.getSecretsClient(vaultURL)
+		if err != nil {
+			return fmt.Errorf("failed to create Key Vault client for %s: %w", vaultURL, err)
+		}
+
+		timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.RequestTimeout)
+		resp, err := client.GetSecret(timeoutContext, name, version, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to resolve Key Vault reference in key %s: %w", key, err)
+		}
+		item.Value = ""
+		if resp.Value != nil {
+			item.Value = *resp.Value
+		}
+	}
+	return nil
+}
+
+func (r *ConfigurationStore) getSecretsClient(vaultURL string) (azSecretsClient, error) {
+	r.secretsLock.Lock()
+	defer r.secretsLock.Unlock()
+
+	if client, ok := r.secretsClients[vaultURL]; ok {
+		return client, nil
+	}
+	client, err := r.newSecretsClient(vaultURL)
+	if err != nil {
+		return nil, err
+	}
+	r.secretsClients[vaultURL] = client
+	return client, nil
+}
+
+// parseKeyVaultReference parses the value of a Key Vault reference, such as {"uri":"https://myvault.vault.azure.net/secrets/mysecret"}.
+// The secret identifier may include a version; if it doesn't, the latest version is returned.
+func parseKeyVaultReference(value string) (vaultURL string, name string, version string, err error) {
+	var ref struct {
+		URI string `json:"uri"`
+	}
+	err = json.Unmarshal([]byte(value), &ref)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	u, err := url.Parse(ref.URI)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return "", "", "", fmt.Errorf("secret identifier must be an https URL: %s", ref.URI)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "secrets" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid secret identifier: %s", ref.URI)
+	}
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	return u.Scheme + "://" + u.Host, parts[1], version, nil
+}
+
+// isFilter returns true if the label contains a wildcard or a list of labels, which can't be used with GetSetting.
+func isFilter(label string) bool {
+	return strings.ContainsAny(label, "*,")
+}
+
+// escapeFilter escapes the reserved characters in a key so it can be used as an exact match in a filter.
+func escapeFilter(key string) string {
+	return filterEscaper.Replace(key)
+}
+
+var filterEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `,`, `\,`)
+
+type requestOptions = struct {
+	Label        string `mapstructure:"label"`
+	KeyFilter    string `mapstructure:"keyFilter"`
+	Snapshot     string `mapstructure:"snapshot"`
+	FeatureFlags bool   `mapstructure:"featureFlags"`
+	// Overrides the resolveKeyVaultReferences component metadata property
+	ResolveKeyVaultReferences *bool `mapstructure:"resolveKeyVaultReferences"`
+}
+
+func (r *ConfigurationStore) getRequestOptionsFromMetadata(metadata map[string]string) (requestOptions, error) {
+	var opts requestOptions
+	err := kitmd.DecodeMetadata(metadata, &opts)
+	if err != nil {
+		return requestOptions{}, fmt.Errorf("failed to parse request metadata: %w", err)
+	}
+	// Snapshots are read as a whole, so filters would be silently ignored
+	if opts.Snapshot != "" && (opts.Label != "" || opts.KeyFilter != "") {
+		return requestOptions{}, errors.New("failed to parse request metadata: 'snapshot' can't be combined with 'label' or 'keyFilter'")
+	}
+	return opts, nil
+}
+
 func (r *ConfigurationStore) getLabelFromMetadata(metadata map[string]string) *string {
 	type labelMetadata = struct {
 		Label string `mapstructure:"label"`
@@ -233,6 +478,10 @@ func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.S
 	if sentinelKey == "" {
 		return "", errors.New("sentinel key is not provided in metadata")
 	}
+	filter, err := configuration.ParseKeyFilter(req.Metadata)
+	if err != nil {
+		return "", err
+	}
 	uuid, err := uuid.NewRandom()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate uuid, error is %w", err)
@@ -244,12 +493,22 @@ func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.S
 	go func() {
 		defer r.wg.Done()
 		defer r.cancelMap.Delete(subscribeID)
-		r.doSubscribe(childContext, req, handler, sentinelKey, subscribeID)
+		r.doSubscribe(childContext, req, handler, sentinelKey, filter, subscribeID)
 	}()
 	return subscribeID, nil
 }
 
-func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, sentinelKey string, id string) {
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, sentinelKey string, filter *configuration.KeyFilter, id string) {
+	// When subscribing to all keys, the filter's prefix is sent to App Configuration as a key filter
+	getMetadata := req.Metadata
+	if filter != nil && filter.Prefix != "" && len(req.Keys) == 0 {
+		getMetadata = make(map[string]string, len(req.Metadata)+1)
+		for k, v := range req.Metadata {
+			getMetadata[k] = v
+		}
+		getMetadata["keyFilter"] = escapeFilter(filter.Prefix) + "*"
+	}
+
 	var etagVal *azcore.ETag
 	for {
 		// get sentinel key changes.
@@ -271,14 +530,14 @@ func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration
 			etagVal = resp.ETag
 			items, err := r.Get(ctx, &configuration.GetRequest{
 				Keys:     req.Keys,
-				Metadata: req.Metadata,
+				Metadata: getMetadata,
 			})
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
 					return
 				}
 				r.logger.Errorf("Failed to get configuration key changes: %s", err)
-			} else {
+			} else if items.Items = filter.FilterItems(items.Items); len(items.Items) > 0 || filter == nil {
 				r.handleSubscribedChange(ctx, handler, items, id)
 			}
 		}
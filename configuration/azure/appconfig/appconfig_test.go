@@ -15,12 +15,14 @@ package appconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -77,6 +79,62 @@ func (m *MockConfigurationStore) NewListSettingsPager(selector azappconfig.Setti
 	})
 }
 
+func (m *MockConfigurationStore) NewListSettingsForSnapshotPager(snapshotName string, options *azappconfig.ListSettingsForSnapshotOptions) *runtime.Pager[azappconfig.ListSettingsForSnapshotResponse] {
+	settings := []azappconfig.Setting{
+		{Key: ptr.Of("testKey-1"), Value: ptr.Of("snapshotValue-1")},
+		{Key: ptr.Of("testKey-2"), Value: ptr.Of("snapshotValue-2")},
+	}
+
+	return runtime.NewPager(runtime.PagingHandler[azappconfig.ListSettingsForSnapshotResponse]{
+		More: func(azappconfig.ListSettingsForSnapshotResponse) bool {
+			return false
+		},
+		Fetcher: func(ctx context.Context, cur *azappconfig.ListSettingsForSnapshotResponse) (azappconfig.ListSettingsForSnapshotResponse, error) {
+			if snapshotName != "snap1" {
+				return azappconfig.ListSettingsForSnapshotResponse{}, fmt.Errorf("snapshot %s not found", snapshotName)
+			}
+			return azappconfig.ListSettingsForSnapshotResponse{
+				Settings: settings,
+			}, nil
+		},
+	})
+}
+
+// MockFilterConfigurationStore records the selectors it's invoked with and returns feature flags.
+type MockFilterConfigurationStore struct {
+	MockConfigurationStore
+
+	selectors []azappconfig.SettingSelector
+}
+
+func (m *MockFilterConfigurationStore) NewListSettingsPager(selector azappconfig.SettingSelector, options *azappconfig.ListSettingsOptions) *runtime.Pager[azappconfig.ListSettingsPageResponse] {
+	m.selectors = append(m.selectors, selector)
+
+	settings := []azappconfig.Setting{
+		{
+			Key:         ptr.Of(".appconfig.featureflag/beta"),
+			Value:       ptr.Of(`{"id":"beta","enabled":true,"conditions":{"client_filters":[]}}`),
+			ContentType: ptr.Of("application/vnd.microsoft.appconfig.ff+json;charset=utf-8"),
+			Label:       ptr.Of("prod"),
+		},
+		{
+			Key:   ptr.Of("plainKey"),
+			Value: ptr.Of("plainValue"),
+		},
+	}
+
+	return runtime.NewPager(runtime.PagingHandler[azappconfig.ListSettingsPageResponse]{
+		More: func(azappconfig.ListSettingsPageResponse) bool {
+			return false
+		},
+		Fetcher: func(ctx context.Context, cur *azappconfig.ListSettingsPageResponse) (azappconfig.ListSettingsPageResponse, error) {
+			return azappconfig.ListSettingsPageResponse{
+				Settings: settings,
+			}, nil
+		},
+	})
+}
+
 func TestNewAzureAppConfigurationStore(t *testing.T) {
 	type args struct {
 		logger logger.Logger
@@ -277,6 +335,7 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, want.MaxRetryDelay, m.MaxRetryDelay)
 		assert.Equal(t, want.SubscribePollInterval, m.SubscribePollInterval)
 		assert.Equal(t, want.RequestTimeout, m.RequestTimeout)
+		assert.False(t, m.ResolveKeyVaultReferences)
 	})
 
 	t.Run("parse metadata with "+connectionString, func(t *testing.T) {
@@ -351,6 +410,179 @@ func TestParseMetadata(t *testing.T) {
 	})
 }
 
+func Test_getConfigurationFromSnapshot(t *testing.T) {
+	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	s.client = &MockConfigurationStore{}
+	s.metadata.RequestTimeout = defaultRequestTimeout
+
+	t.Run("all keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Metadata: map[string]string{"snapshot": "snap1"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "snapshotValue-1", res.Items["testKey-1"].Value)
+	})
+
+	t.Run("provided keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Keys:     []string{"testKey-2"},
+			Metadata: map[string]string{"snapshot": "snap1"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "snapshotValue-2", res.Items["testKey-2"].Value)
+	})
+
+	t.Run("snapshot not found", func(t *testing.T) {
+		_, err := s.Get(context.Background(), &configuration.GetRequest{
+			Metadata: map[string]string{"snapshot": "notfound"},
+		})
+		require.Error(t, err)
+	})
+}
+
+func Test_getConfigurationWithFilters(t *testing.T) {
+	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	mock := &MockFilterConfigurationStore{}
+	s.client = mock
+	s.metadata.RequestTimeout = defaultRequestTimeout
+
+	t.Run("feature flags", func(t *testing.T) {
+		mock.selectors = nil
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Metadata: map[string]string{"featureFlags": "true"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		require.Contains(t, res.Items, "beta")
+		assert.Equal(t, "true", res.Items["beta"].Metadata["enabled"])
+		assert.Equal(t, "true", res.Items["beta"].Metadata["featureFlag"])
+		assert.Equal(t, "prod", res.Items["beta"].Metadata["label"])
+
+		require.Len(t, mock.selectors, 1)
+		assert.Equal(t, ".appconfig.featureflag/*", *mock.selectors[0].KeyFilter)
+		assert.Equal(t, "*", *mock.selectors[0].LabelFilter)
+	})
+
+	t.Run("label wildcard with provided keys", func(t *testing.T) {
+		mock.selectors = nil
+		_, err := s.Get(context.Background(), &configuration.GetRequest{
+			Keys:     []string{"my*key"},
+			Metadata: map[string]string{"label": "prod*"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, mock.selectors, 1)
+		assert.Equal(t, `my\*key`, *mock.selectors[0].KeyFilter)
+		assert.Equal(t, "prod*", *mock.selectors[0].LabelFilter)
+	})
+
+	t.Run("key filter", func(t *testing.T) {
+		mock.selectors = nil
+		_, err := s.Get(context.Background(), &configuration.GetRequest{
+			Metadata: map[string]string{"keyFilter": "app1:*", "label": "dev,prod"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, mock.selectors, 1)
+		assert.Equal(t, "app1:*", *mock.selectors[0].KeyFilter)
+		assert.Equal(t, "dev,prod", *mock.selectors[0].LabelFilter)
+	})
+
+	t.Run("malformed metadata", func(t *testing.T) {
+		mock.selectors = nil
+		_, err := s.Get(context.Background(), &configuration.GetRequest{
+			Metadata: map[string]string{"snapshot": "release", "label": "prod"},
+		})
+		require.ErrorContains(t, err, "failed to parse request metadata")
+		assert.Empty(t, mock.selectors)
+	})
+}
+
+type mockSecretsClient struct {
+	requests []string
+}
+
+func (m *mockSecretsClient) GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	m.requests = append(m.requests, name+"/"+version)
+	if name == "missing" {
+		return azsecrets.GetSecretResponse{}, errors.New("secret not found")
+	}
+	resp := azsecrets.GetSecretResponse{}
+	resp.Value = ptr.Of("secret-" + name)
+	return resp, nil
+}
+
+func Test_resolveKeyVaultReferences(t *testing.T) {
+	secrets := &mockSecretsClient{}
+	vaults := []string{}
+	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	s.metadata.RequestTimeout = defaultRequestTimeout
+	s.secretsClients = map[string]azSecretsClient{}
+	s.newSecretsClient = func(vaultURL string) (azSecretsClient, error) {
+		vaults = append(vaults, vaultURL)
+		return secrets, nil
+	}
+
+	newItems := func() map[string]*configuration.Item {
+		return map[string]*configuration.Item{
+			"plain": settingToItem(azappconfig.Setting{Key: ptr.Of("plain"), Value: ptr.Of("value")}),
+			"ref1": settingToItem(azappconfig.Setting{
+				Key:         ptr.Of("ref1"),
+				Value:       ptr.Of(`{"uri":"https://myvault.vault.azure.net/secrets/db-password"}`),
+				ContentType: ptr.Of("application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8"),
+			}),
+			"ref2": settingToItem(azappconfig.Setting{
+				Key:         ptr.Of("ref2"),
+				Value:       ptr.Of(`{"uri":"https://myvault.vault.azure.net/secrets/api-key/abc123"}`),
+				ContentType: ptr.Of("application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8"),
+			}),
+		}
+	}
+
+	items := newItems()
+	err := s.resolveKeyVaultReferences(context.Background(), items)
+	require.NoError(t, err)
+	assert.Equal(t, "value", items["plain"].Value)
+	assert.Equal(t, "secret-db-password", items["ref1"].Value)
+	assert.Equal(t, "true", items["ref1"].Metadata[keyVaultRefMetadataKey])
+	assert.Equal(t, "secret-api-key", items["ref2"].Value)
+	assert.ElementsMatch(t, []string{"db-password/", "api-key/abc123"}, secrets.requests)
+	// The client for the vault is reused
+	assert.Equal(t, []string{"https://myvault.vault.azure.net"}, vaults)
+
+	t.Run("unresolvable reference", func(t *testing.T) {
+		items := map[string]*configuration.Item{
+			"ref": settingToItem(azappconfig.Setting{
+				Key:         ptr.Of("ref"),
+				Value:       ptr.Of(`{"uri":"https://myvault.vault.azure.net/secrets/missing"}`),
+				ContentType: ptr.Of("application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8"),
+			}),
+		}
+		err := s.resolveKeyVaultReferences(context.Background(), items)
+		require.Error(t, err)
+	})
+}
+
+func Test_parseKeyVaultReference(t *testing.T) {
+	vaultURL, name, version, err := parseKeyVaultReference(`{"uri":"https://myvault.vault.azure.net/secrets/mysecret/v1"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "https://myvault.vault.azure.net", vaultURL)
+	assert.Equal(t, "mysecret", name)
+	assert.Equal(t, "v1", version)
+
+	for _, value := range []string{
+		`not json`,
+		`{"uri":"http://myvault.vault.azure.net/secrets/mysecret"}`,
+		`{"uri":"https://myvault.vault.azure.net/keys/mykey"}`,
+		`{"uri":"https://myvault.vault.azure.net/secrets/"}`,
+	} {
+		_, _, _, err = parseKeyVaultReference(value)
+		require.Error(t, err, value)
+	}
+}
+
 func updateEventHandler(ctx context.Context, e *configuration.UpdateEvent) error {
 	return nil
 }
@@ -30,6 +30,8 @@ type metadata struct {
 	RetryDelay            time.Duration `mapstructure:"retryDelay"`
 	SubscribePollInterval time.Duration `mapstructure:"subscribePollInterval"`
 	RequestTimeout        time.Duration `mapstructure:"requestTimeout"`
+	// If true, Key Vault references are replaced with the value of the secret they reference.
+	ResolveKeyVaultReferences bool `mapstructure:"resolveKeyVaultReferences"`
 }
 
 func (m *metadata) Parse(log logger.Logger, meta configuration.Metadata) error {
@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+type consulKV interface {
+	Get(key string, q *consul.QueryOptions) (*consul.KVPair, *consul.QueryMeta, error)
+	List(prefix string, q *consul.QueryOptions) (consul.KVPairs, *consul.QueryMeta, error)
+}
+
+// ConfigurationStore is a Consul KV configuration store.
+type ConfigurationStore struct {
+	kv       consulKV
+	metadata metadata
+
+	subscriptions configuration.Subscriptions
+
+	logger logger.Logger
+}
+
+// NewConsulConfigurationStore returns a new Consul KV configuration store.
+func NewConsulConfigurationStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(_ context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	cfg := consul.DefaultConfig()
+	if r.metadata.Address != "" {
+		cfg.Address = r.metadata.Address
+	}
+	if r.metadata.Scheme != "" {
+		cfg.Scheme = r.metadata.Scheme
+	}
+	cfg.Datacenter = r.metadata.Datacenter
+	if r.metadata.Token != "" {
+		cfg.Token = r.metadata.Token
+	}
+	cfg.TLSConfig = consul.TLSConfig{
+		CAFile:             r.metadata.CAFile,
+		CertFile:           r.metadata.CertFile,
+		KeyFile:            r.metadata.KeyFile,
+		InsecureSkipVerify: r.metadata.InsecureSkipVerify,
+	}
+
+	client, err := consul.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create consul client: %w", err)
+	}
+	r.kv = client.KV()
+
+	return nil
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	if len(req.Keys) == 0 {
+		timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.RequestTimeout)
+		defer cancel()
+		pairs, _, err := r.kv.List(r.metadata.KeyPrefix, r.queryOptions(timeoutContext, 0))
+		if err != nil {
+			return &configuration.GetResponse{}, fmt.Errorf("failed to list keys with prefix '%s': %w", r.metadata.KeyPrefix, err)
+		}
+
+		items := make(map[string]*configuration.Item, len(pairs))
+		for _, pair := range pairs {
+			key, ok := r.trimKey(pair.Key)
+			if !ok {
+				continue
+			}
+			items[key] = pairToItem(pair)
+		}
+
+		return &configuration.GetResponse{
+			Items: items,
+		}, nil
+	}
+
+	items := make(map[string]*configuration.Item, len(req.Keys))
+	for _, key := range req.Keys {
+		timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.RequestTimeout)
+		pair, _, err := r.kv.Get(r.metadata.KeyPrefix+key, r.queryOptions(timeoutContext, 0))
+		cancel()
+		if err != nil {
+			return &configuration.GetResponse{}, fmt.Errorf("failed to get key '%s': %w", key, err)
+		}
+		if pair == nil {
+			r.logger.Debugf("Consul key %s does not exist, ignoring it", key)
+			continue
+		}
+		items[key] = pairToItem(pair)
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	filter, err := configuration.ParseKeyFilter(req.Metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return r.subscriptions.Start(ctx, func(ctx context.Context, id string) {
+		r.doSubscribe(ctx, req, handler, filter, id)
+	})
+}
+
+// doSubscribe watches the key prefix using Consul blocking queries, and notifies the handler of every change to the subscribed keys.
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, filter *configuration.KeyFilter, id string) {
+	// Only watch the keys with the filter's prefix
+	prefix := r.metadata.KeyPrefix
+	if filter != nil {
+		prefix += filter.Prefix
+	}
+
+	var (
+		waitIndex uint64
+		// Items last sent to the subscriber; nil until the first query completes
+		snapshot map[string]*configuration.Item
+	)
+	for {
+		pairs, meta, err := r.kv.List(prefix, r.queryOptions(ctx, waitIndex))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Warnf("Failed to watch Consul keys with prefix '%s', retrying in %v: %v", prefix, r.metadata.RetryDelay, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.metadata.RetryDelay):
+			}
+			continue
+		}
+
+		// Per Consul's guidance, reset the index if it goes backwards, and never block with an index of 0
+		switch {
+		case meta.LastIndex < waitIndex:
+			waitIndex = 0
+		case meta.LastIndex == 0:
+			waitIndex = 1
+		default:
+			waitIndex = meta.LastIndex
+		}
+
+		// The version of the items is their ModifyIndex, so every change is notified even if the value is the same
+		items := r.pairsToItems(pairs, req.Keys, filter)
+		if snapshot != nil {
+			changed := configuration.DiffItems(snapshot, items, true)
+			if len(changed) > 0 {
+				r.handleSubscribedChange(ctx, handler, changed, id)
+			}
+		}
+		snapshot = items
+	}
+}
+
+// pairsToItems returns the items for the pairs returned by a blocking query, only including the subscribed keys.
+func (r *ConfigurationStore) pairsToItems(pairs consul.KVPairs, keys []string, keyFilter *configuration.KeyFilter) map[string]*configuration.Item {
+	var filter map[string]struct{}
+	if len(keys) > 0 {
+		filter = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			filter[k] = struct{}{}
+		}
+	}
+
+	items := make(map[string]*configuration.Item, len(pairs))
+	for _, pair := range pairs {
+		key, ok := r.trimKey(pair.Key)
+		if !ok {
+			continue
+		}
+		if filter != nil {
+			if _, ok = filter[key]; !ok {
+				continue
+			}
+		}
+		if !keyFilter.Match(key) {
+			continue
+		}
+
+		items[key] = pairToItem(pair)
+	}
+	return items
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) queryOptions(ctx context.Context, waitIndex uint64) *consul.QueryOptions {
+	q := &consul.QueryOptions{
+		Datacenter: r.metadata.Datacenter,
+	}
+	if waitIndex > 0 {
+		q.WaitIndex = waitIndex
+		q.WaitTime = r.metadata.WaitTime
+	}
+	return q.WithContext(ctx)
+}
+
+// trimKey removes the key prefix from a key returned by Consul.
+// It returns false for keys that represent "folders".
+func (r *ConfigurationStore) trimKey(key string) (string, bool) {
+	key = strings.TrimPrefix(key, r.metadata.KeyPrefix)
+	if key == "" || strings.HasSuffix(key, "/") {
+		return "", false
+	}
+	return key, true
+}
+
+func pairToItem(pair *consul.KVPair) *configuration.Item {
+	item := &configuration.Item{
+		Value:    string(pair.Value),
+		Version:  strconv.FormatUint(pair.ModifyIndex, 10),
+		Metadata: map[string]string{},
+	}
+	if pair.Flags != 0 {
+		item.Metadata["flags"] = strconv.FormatUint(pair.Flags, 10)
+	}
+	return item
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	return r.subscriptions.Stop(req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	r.subscriptions.Close()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// mockKV is an in-memory implementation of consulKV that supports blocking queries.
+type mockKV struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	index   uint64
+	pairs   map[string]*consul.KVPair
+	queries []*consul.QueryOptions
+}
+
+func newMockKV() *mockKV {
+	m := &mockKV{
+		pairs: map[string]*consul.KVPair{},
+	}
+	m.cond = sync.NewCond(&m.lock)
+	return m
+}
+
+func (m *mockKV) put(key string, value string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.index++
+	m.pairs[key] = &consul.KVPair{Key: key, Value: []byte(value), ModifyIndex: m.index}
+	m.cond.Broadcast()
+}
+
+func (m *mockKV) delete(key string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.index++
+	delete(m.pairs, key)
+	m.cond.Broadcast()
+}
+
+func (m *mockKV) Get(key string, q *consul.QueryOptions) (*consul.KVPair, *consul.QueryMeta, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.queries = append(m.queries, q)
+	return m.pairs[key], &consul.QueryMeta{LastIndex: m.index}, nil
+}
+
+func (m *mockKV) List(prefix string, q *consul.QueryOptions) (consul.KVPairs, *consul.QueryMeta, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.queries = append(m.queries, q)
+
+	if q.WaitIndex > 0 {
+		stop := context.AfterFunc(q.Context(), func() {
+			m.lock.Lock()
+			m.cond.Broadcast()
+			m.lock.Unlock()
+		})
+		defer stop()
+		for m.index <= q.WaitIndex && q.Context().Err() == nil {
+			m.cond.Wait()
+		}
+		if err := q.Context().Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res := consul.KVPairs{}
+	for k, v := range m.pairs {
+		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
+			res = append(res, v)
+		}
+	}
+	return res, &consul.QueryMeta{LastIndex: m.index}, nil
+}
+
+func newTestStore(t *testing.T, kv consulKV, props map[string]string) *ConfigurationStore {
+	s := NewConsulConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err := s.metadata.Parse(configuration.Metadata{Base: mdata.Base{Properties: props}})
+	require.NoError(t, err)
+	s.kv = kv
+	return s
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultWaitTime, m.WaitTime)
+		assert.Equal(t, defaultRequestTimeout, m.RequestTimeout)
+		assert.Empty(t, m.KeyPrefix)
+	})
+
+	t.Run("key prefix is normalized", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"keyPrefix":  "/myapp/config",
+			"datacenter": "dc2",
+			"waitTime":   "30s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "myapp/config/", m.KeyPrefix)
+		assert.Equal(t, "dc2", m.Datacenter)
+		assert.Equal(t, 30*time.Second, m.WaitTime)
+	})
+
+	t.Run("invalid scheme", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"scheme": "ftp",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	kv := newMockKV()
+	kv.put("myapp/key1", "value1")
+	kv.put("myapp/key2", "value2")
+	kv.put("myapp/folder/", "")
+	kv.put("other/key3", "value3")
+
+	s := newTestStore(t, kv, map[string]string{
+		"keyPrefix":  "myapp",
+		"datacenter": "dc2",
+	})
+
+	t.Run("get all keys under the prefix", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "value1", res.Items["key1"].Value)
+		assert.Equal(t, "1", res.Items["key1"].Version)
+		assert.Equal(t, "value2", res.Items["key2"].Value)
+	})
+
+	t.Run("get specific keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Keys: []string{"key2", "notfound"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "value2", res.Items["key2"].Value)
+	})
+
+	t.Run("queries use the configured datacenter", func(t *testing.T) {
+		kv.lock.Lock()
+		defer kv.lock.Unlock()
+		for _, q := range kv.queries {
+			assert.Equal(t, "dc2", q.Datacenter)
+		}
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	kv := newMockKV()
+	kv.put("myapp/key1", "value1")
+	kv.put("myapp/key2", "value2")
+
+	s := newTestStore(t, kv, map[string]string{
+		"keyPrefix": "myapp",
+	})
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Keys: []string{"key1"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	// Wait for the initial snapshot to be taken
+	require.Eventually(t, func() bool {
+		kv.lock.Lock()
+		defer kv.lock.Unlock()
+		for _, q := range kv.queries {
+			if q.WaitIndex > 0 {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Changes to keys that are not subscribed are ignored
+	kv.put("myapp/key2", "updated2")
+	kv.put("myapp/key1", "updated1")
+
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "updated1", e.Items["key1"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive update event")
+	}
+
+	kv.delete("myapp/key1")
+	select {
+	case e := <-events:
+		require.Len(t, e.Items, 1)
+		assert.Empty(t, e.Items["key1"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive delete event")
+	}
+
+	err = s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id})
+	require.NoError(t, err)
+
+	err = s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id})
+	require.Error(t, err)
+}
+
+func TestSubscribeWithKeyFilter(t *testing.T) {
+	kv := newMockKV()
+	kv.put("myapp/flags/a.enabled", "true")
+	kv.put("myapp/other", "value")
+
+	s := newTestStore(t, kv, map[string]string{
+		"keyPrefix": "myapp",
+	})
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	_, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Metadata: map[string]string{
+			configuration.KeyPrefixMetadataKey: "flags/",
+			configuration.KeyRegexMetadataKey:  `\.enabled$`,
+		},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Wait for the initial snapshot to be taken
+	require.Eventually(t, func() bool {
+		kv.lock.Lock()
+		defer kv.lock.Unlock()
+		for _, q := range kv.queries {
+			if q.WaitIndex > 0 {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Changes to keys outside of the filter are ignored
+	kv.put("myapp/other", "updated")
+	kv.put("myapp/flags/a.value", "1")
+	kv.put("myapp/flags/a.enabled", "false")
+
+	select {
+	case e := <-events:
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "false", e.Items["flags/a.enabled"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive update event")
+	}
+}
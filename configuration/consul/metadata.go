@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultWaitTime       = 5 * time.Minute
+	defaultRequestTimeout = 15 * time.Second
+	defaultRetryDelay     = 5 * time.Second
+)
+
+type metadata struct {
+	// Address of the Consul agent, as "host:port".
+	Address string `mapstructure:"address"`
+	// URI scheme for the Consul agent: "http" or "https".
+	Scheme string `mapstructure:"scheme"`
+	// Datacenter to query. If empty, the datacenter of the agent is used.
+	Datacenter string `mapstructure:"datacenter"`
+	// ACL token.
+	Token string `mapstructure:"token"`
+	// Optional prefix for all keys. It's removed from the keys returned to the application.
+	KeyPrefix string `mapstructure:"keyPrefix"`
+	// Maximum duration a blocking query can wait for changes.
+	WaitTime time.Duration `mapstructure:"waitTime"`
+	// Timeout for non-blocking requests.
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+	// Delay before retrying a blocking query that failed.
+	RetryDelay time.Duration `mapstructure:"retryDelay"`
+	// TLS options.
+	CAFile             string `mapstructure:"caFile"`
+	CertFile           string `mapstructure:"certFile"`
+	KeyFile            string `mapstructure:"keyFile"`
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"`
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.WaitTime = defaultWaitTime
+	m.RequestTimeout = defaultRequestTimeout
+	m.RetryDelay = defaultRetryDelay
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.Scheme != "" && m.Scheme != "http" && m.Scheme != "https" {
+		return errors.New("consul configuration error: scheme must be 'http' or 'https'")
+	}
+	if m.WaitTime <= 0 {
+		return errors.New("consul configuration error: waitTime must be greater than 0")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+	if m.RetryDelay <= 0 {
+		m.RetryDelay = defaultRetryDelay
+	}
+
+	// Normalize the prefix so it always ends with a "/" if set
+	m.KeyPrefix = strings.TrimPrefix(m.KeyPrefix, "/")
+	if m.KeyPrefix != "" && !strings.HasSuffix(m.KeyPrefix, "/") {
+		m.KeyPrefix += "/"
+	}
+
+	return nil
+}
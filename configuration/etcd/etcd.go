@@ -0,0 +1,327 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	etcdcomponent "github.com/dapr/components-contrib/common/component/etcd"
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// RevisionMetadataKey is the subscribe request metadata key used to receive the changes made after the given etcd revision.
+// Item versions are the revisions of their last change, so subscribers can resume from the last version they received.
+const RevisionMetadataKey = "revision"
+
+type etcdClient interface {
+	clientv3.KV
+	clientv3.Watcher
+}
+
+// ConfigurationStore is an etcd configuration store.
+// Each key under the prefix is a configuration item, whose version is the revision of its last modification.
+type ConfigurationStore struct {
+	client   etcdClient
+	metadata metadata
+
+	cancelMap sync.Map
+	wg        sync.WaitGroup
+	closed    atomic.Bool
+	lock      sync.RWMutex
+
+	logger logger.Logger
+}
+
+// NewEtcdConfigurationStore returns a new etcd configuration store.
+func NewEtcdConfigurationStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(_ context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	config := clientv3.Config{
+		Endpoints:   r.metadata.endpoints(),
+		DialTimeout: r.metadata.Timeout,
+	}
+	if r.metadata.TLSEnable {
+		config.TLS, err = etcdcomponent.NewTLSConfig(r.metadata.Cert, r.metadata.Key, r.metadata.CA)
+		if err != nil {
+			return fmt.Errorf("tls authentication error: %w", err)
+		}
+	}
+
+	client, err := clientv3.New(config)
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %w", err)
+	}
+	r.client = client
+
+	return nil
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	items, _, err := r.read(ctx, req.Keys)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+// read returns the items for the keys, or for every key under the prefix if keys is empty, and the revision they were read at.
+// Multiple keys are read in a single transaction, so they're consistent with each other.
+func (r *ConfigurationStore) read(parentCtx context.Context, keys []string) (map[string]*configuration.Item, int64, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, r.metadata.Timeout)
+	defer cancel()
+
+	var kvs []*mvccpb.KeyValue
+	var revision int64
+	if len(keys) == 0 {
+		res, err := r.client.Get(ctx, r.metadata.KeyPrefixPath+"/", clientv3.WithPrefix())
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get keys under '%s': %w", r.metadata.KeyPrefixPath, err)
+		}
+		kvs = res.Kvs
+		revision = res.Header.GetRevision()
+	} else {
+		ops := make([]clientv3.Op, len(keys))
+		for i, key := range keys {
+			ops[i] = clientv3.OpGet(r.etcdKey(key))
+		}
+		res, err := r.client.Txn(ctx).Then(ops...).Commit()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get keys: %w", err)
+		}
+		for _, op := range res.Responses {
+			kvs = append(kvs, op.GetResponseRange().GetKvs()...)
+		}
+		revision = res.Header.GetRevision()
+	}
+
+	items := make(map[string]*configuration.Item, len(kvs))
+	for _, kv := range kvs {
+		items[r.itemKey(kv.Key)] = newItem(kv)
+	}
+	return items, revision, nil
+}
+
+func newItem(kv *mvccpb.KeyValue) *configuration.Item {
+	return &configuration.Item{
+		Value:   string(kv.Value),
+		Version: strconv.FormatInt(kv.ModRevision, 10),
+		Metadata: map[string]string{
+			"createRevision": strconv.FormatInt(kv.CreateRevision, 10),
+		},
+	}
+}
+
+func (r *ConfigurationStore) etcdKey(key string) string {
+	return r.metadata.KeyPrefixPath + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (r *ConfigurationStore) itemKey(key []byte) string {
+	return strings.TrimPrefix(string(key), r.metadata.KeyPrefixPath+"/")
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.closed.Load() {
+		return "", errors.New("store is closed")
+	}
+
+	filter, err := configuration.ParseKeyFilter(req.Metadata)
+	if err != nil {
+		return "", err
+	}
+	var revision int64
+	if val := req.Metadata[RevisionMetadataKey]; val != "" {
+		revision, err = strconv.ParseInt(val, 10, 64)
+		if err != nil || revision < 0 {
+			return "", fmt.Errorf("invalid value for metadata key '%s': %s", RevisionMetadataKey, val)
+		}
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate uuid, error is %w", err)
+	}
+	subscribeID := uuid.String()
+	childContext, cancel := context.WithCancel(ctx)
+	r.cancelMap.Store(subscribeID, cancel)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.cancelMap.Delete(subscribeID)
+		r.doSubscribe(childContext, req, filter, revision, handler, subscribeID)
+	}()
+	return subscribeID, nil
+}
+
+// doSubscribe watches the subscribed keys with a single etcd watch on their prefix, and notifies the handler of every change.
+// All changes of a transaction are delivered together. After an error, the watch resumes from the last revision that was received;
+// if that revision was compacted in the meanwhile, the current value of all subscribed items is sent instead.
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, filter *configuration.KeyFilter, revision int64, handler configuration.UpdateHandler, id string) {
+	keys := make(map[string]struct{}, len(req.Keys))
+	for _, k := range req.Keys {
+		keys[strings.TrimPrefix(k, "/")] = struct{}{}
+	}
+	match := func(key string) bool {
+		if len(keys) > 0 {
+			if _, ok := keys[key]; !ok {
+				return false
+			}
+		}
+		return filter.Match(key)
+	}
+
+	// The prefix of the filter is pushed down to the watch
+	prefix := r.metadata.KeyPrefixPath + "/"
+	if filter != nil {
+		prefix += filter.Prefix
+	}
+
+	for {
+		opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithProgressNotify()}
+		if revision > 0 {
+			opts = append(opts, clientv3.WithRev(revision+1))
+		}
+		// Require a leader so the watch fails when the member is partitioned, rather than waiting silently
+		watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+		for res := range r.client.Watch(watchCtx, prefix, opts...) {
+			if res.CompactRevision != 0 {
+				r.logger.Warnf("etcd revision %d was compacted, sending the current configuration", revision+1)
+				items, rev, err := r.read(ctx, req.Keys)
+				if err != nil {
+					r.logger.Warnf("Failed to read configuration from etcd: %v", err)
+					break
+				}
+				revision = rev
+				items = filter.FilterItems(items)
+				if len(items) > 0 {
+					r.handleSubscribedChange(ctx, handler, items, id)
+				}
+				break
+			}
+			if err := res.Err(); err != nil {
+				r.logger.Warnf("Failed to watch etcd keys under '%s': %v", prefix, err)
+				break
+			}
+			if res.IsProgressNotify() {
+				revision = res.Header.GetRevision()
+				continue
+			}
+
+			changed := map[string]*configuration.Item{}
+			for _, ev := range res.Events {
+				revision = max(revision, ev.Kv.ModRevision)
+				key := r.itemKey(ev.Kv.Key)
+				if !match(key) {
+					continue
+				}
+				if ev.Type == clientv3.EventTypeDelete {
+					changed[key] = &configuration.Item{
+						Version: strconv.FormatInt(ev.Kv.ModRevision, 10),
+					}
+				} else {
+					changed[key] = newItem(ev.Kv)
+				}
+			}
+			if len(changed) > 0 {
+				r.handleSubscribedChange(ctx, handler, changed, id)
+			}
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.metadata.RetryDelay):
+		}
+	}
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if cancelContext, ok := r.cancelMap.LoadAndDelete(req.ID); ok {
+		cancelContext.(context.CancelFunc)()
+		return nil
+	}
+	return fmt.Errorf("subscription with id %s does not exist", req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	defer r.wg.Wait()
+	r.closed.Store(true)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cancelMap.Range(func(id any, cancel any) bool {
+		cancel.(context.CancelFunc)()
+		return true
+	})
+	r.cancelMap.Clear()
+
+	if r.client != nil {
+		return r.client.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
@@ -0,0 +1,477 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeClient is an in-memory implementation of etcdClient, which keeps the history of changes for watches.
+type fakeClient struct {
+	clientv3.KV
+
+	lock      sync.Mutex
+	revision  int64
+	compacted int64
+	kvs       map[string]*mvccpb.KeyValue
+	history   []clientv3.WatchResponse
+	watches   []*fakeWatch
+}
+
+type fakeWatch struct {
+	prefix string
+	ch     chan clientv3.WatchResponse
+	done   bool
+}
+
+// fakeOp is a change committed to the fake client; a nil value deletes the key.
+type fakeOp struct {
+	key   string
+	value *string
+}
+
+func put(key, value string) fakeOp {
+	return fakeOp{key: key, value: &value}
+}
+
+func del(key string) fakeOp {
+	return fakeOp{key: key}
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		kvs: map[string]*mvccpb.KeyValue{},
+	}
+}
+
+// commit applies the changes in a single revision, like a transaction.
+func (f *fakeClient) commit(ops ...fakeOp) int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.revision++
+	res := clientv3.WatchResponse{Header: etcdserverpb.ResponseHeader{Revision: f.revision}}
+	for _, op := range ops {
+		if op.value == nil {
+			delete(f.kvs, op.key)
+			res.Events = append(res.Events, &clientv3.Event{
+				Type: mvccpb.DELETE,
+				Kv:   &mvccpb.KeyValue{Key: []byte(op.key), ModRevision: f.revision},
+			})
+			continue
+		}
+		kv := &mvccpb.KeyValue{Key: []byte(op.key), Value: []byte(*op.value), ModRevision: f.revision, CreateRevision: f.revision}
+		if old, ok := f.kvs[op.key]; ok {
+			kv.CreateRevision = old.CreateRevision
+		}
+		f.kvs[op.key] = kv
+		res.Events = append(res.Events, &clientv3.Event{Type: mvccpb.PUT, Kv: kv})
+	}
+	f.history = append(f.history, res)
+	for _, w := range f.watches {
+		f.send(w, res)
+	}
+	return f.revision
+}
+
+// send sends the events of the response that match the prefix of the watch.
+func (f *fakeClient) send(w *fakeWatch, res clientv3.WatchResponse) {
+	if w.done {
+		return
+	}
+	filtered := clientv3.WatchResponse{Header: res.Header}
+	for _, ev := range res.Events {
+		if strings.HasPrefix(string(ev.Kv.Key), w.prefix) {
+			filtered.Events = append(filtered.Events, ev)
+		}
+	}
+	if len(filtered.Events) > 0 {
+		w.ch <- filtered
+	}
+}
+
+// compact removes the history up to the current revision.
+func (f *fakeClient) compact() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.compacted = f.revision
+	f.history = nil
+}
+
+// dropWatches closes all watches, like when the connection is lost.
+func (f *fakeClient) dropWatches() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, w := range f.watches {
+		if !w.done {
+			w.done = true
+			close(w.ch)
+		}
+	}
+	f.watches = nil
+}
+
+// watchPrefixes returns the prefixes of the active watches.
+func (f *fakeClient) watchPrefixes() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	res := []string{}
+	for _, w := range f.watches {
+		if !w.done {
+			res = append(res, w.prefix)
+		}
+	}
+	return res
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.get(clientv3.OpGet(key, opts...)), nil
+}
+
+func (f *fakeClient) get(op clientv3.Op) *clientv3.GetResponse {
+	res := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.revision}}
+	key := string(op.KeyBytes())
+	for k, kv := range f.kvs {
+		if k == key || (op.RangeBytes() != nil && strings.HasPrefix(k, key)) {
+			res.Kvs = append(res.Kvs, kv)
+		}
+	}
+	return res
+}
+
+func (f *fakeClient) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeTxn{client: f}
+}
+
+func (f *fakeClient) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	w := &fakeWatch{prefix: key, ch: make(chan clientv3.WatchResponse, 100)}
+	if rev := clientv3.OpGet(key, opts...).Rev(); rev > 0 {
+		if rev <= f.compacted {
+			w.ch <- clientv3.WatchResponse{CompactRevision: f.compacted, Canceled: true}
+			close(w.ch)
+			return w.ch
+		}
+		for _, res := range f.history {
+			if res.Header.Revision >= rev {
+				f.send(w, res)
+			}
+		}
+	}
+	f.watches = append(f.watches, w)
+
+	go func() {
+		<-ctx.Done()
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if !w.done {
+			w.done = true
+			close(w.ch)
+		}
+	}()
+	return w.ch
+}
+
+func (f *fakeClient) RequestProgress(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeClient) Close() error {
+	return nil
+}
+
+type fakeTxn struct {
+	client *fakeClient
+	ops    []clientv3.Op
+}
+
+func (t *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	return t
+}
+
+func (t *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.ops = ops
+	return t
+}
+
+func (t *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	return t
+}
+
+func (t *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	t.client.lock.Lock()
+	defer t.client.lock.Unlock()
+
+	res := &clientv3.TxnResponse{Header: &etcdserverpb.ResponseHeader{Revision: t.client.revision}, Succeeded: true}
+	for _, op := range t.ops {
+		get := t.client.get(op)
+		res.Responses = append(res.Responses, &etcdserverpb.ResponseOp{
+			Response: &etcdserverpb.ResponseOp_ResponseRange{
+				ResponseRange: &etcdserverpb.RangeResponse{Header: get.Header, Kvs: get.Kvs},
+			},
+		})
+	}
+	return res, nil
+}
+
+func newTestStore(t *testing.T, client *fakeClient) *ConfigurationStore {
+	s := NewEtcdConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err := s.metadata.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"endpoints":     "localhost:2379",
+		"keyPrefixPath": "config/",
+		"retryDelay":    "10ms",
+	}}})
+	require.NoError(t, err)
+	s.client = client
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// subscribe starts a subscription and returns the channel receiving its events.
+func subscribe(t *testing.T, s *ConfigurationStore, req *configuration.SubscribeRequest) (string, <-chan *configuration.UpdateEvent) {
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), req, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+	return id, events
+}
+
+func nextEvent(t *testing.T, events <-chan *configuration.UpdateEvent) *configuration.UpdateEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the update event")
+		return nil
+	}
+}
+
+func waitForWatch(t *testing.T, client *fakeClient) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(client.watchPrefixes()) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"endpoints": "etcd-0:2379, etcd-1:2379",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"etcd-0:2379", "etcd-1:2379"}, m.endpoints())
+		assert.Equal(t, defaultKeyPrefixPath, m.KeyPrefixPath)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, defaultRetryDelay, m.RetryDelay)
+	})
+
+	t.Run("missing endpoints", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{})
+		require.ErrorContains(t, err, "endpoints")
+	})
+
+	t.Run("empty key prefix", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"endpoints":     "localhost:2379",
+			"keyPrefixPath": "/",
+		}}})
+		require.ErrorContains(t, err, "keyPrefixPath")
+	})
+
+	t.Run("incomplete tls", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"tlsEnable": "true",
+			"ca":        "ca",
+		}}})
+		require.ErrorContains(t, err, "tls")
+	})
+}
+
+func TestGet(t *testing.T) {
+	client := newFakeClient()
+	client.commit(put("config/key1", "value1"), put("config/key2", "value2"))
+	client.commit(put("config/key2", "updated"), put("other/key3", "value3"))
+	s := newTestStore(t, client)
+
+	t.Run("all keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "value1", res.Items["key1"].Value)
+		assert.Equal(t, "1", res.Items["key1"].Version)
+		assert.Equal(t, "updated", res.Items["key2"].Value)
+		assert.Equal(t, "2", res.Items["key2"].Version)
+		assert.Equal(t, "1", res.Items["key2"].Metadata["createRevision"])
+	})
+
+	t.Run("explicit keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Keys: []string{"key2", "/key1", "notfound"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "updated", res.Items["key2"].Value)
+		assert.Equal(t, "value1", res.Items["key1"].Value)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client)
+
+	id, events := subscribe(t, s, &configuration.SubscribeRequest{
+		Keys: []string{"key1", "key2"},
+	})
+	waitForWatch(t, client)
+	assert.Equal(t, []string{"config/"}, client.watchPrefixes())
+
+	// Changes of a transaction are delivered together
+	client.commit(put("config/key1", "value1"), put("config/key2", "value2"), put("config/key3", "value3"))
+	e := nextEvent(t, events)
+	assert.Equal(t, id, e.ID)
+	require.Len(t, e.Items, 2)
+	assert.Equal(t, "value1", e.Items["key1"].Value)
+	assert.Equal(t, "1", e.Items["key1"].Version)
+	assert.Equal(t, "value2", e.Items["key2"].Value)
+
+	// Changes of other keys are ignored
+	client.commit(put("config/key3", "updated"))
+	client.commit(del("config/key1"))
+	e = nextEvent(t, events)
+	require.Len(t, e.Items, 1)
+	assert.Empty(t, e.Items["key1"].Value)
+	assert.Equal(t, "3", e.Items["key1"].Version)
+
+	// After the connection is lost, the watch resumes from the last revision received
+	client.dropWatches()
+	client.commit(put("config/key2", "updated"))
+	e = nextEvent(t, events)
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "updated", e.Items["key2"].Value)
+	assert.Equal(t, "4", e.Items["key2"].Version)
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.Eventually(t, func() bool {
+		return len(client.watchPrefixes()) == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Empty(t, events)
+}
+
+func TestSubscribeKeyPrefix(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client)
+
+	_, events := subscribe(t, s, &configuration.SubscribeRequest{
+		Metadata: map[string]string{configuration.KeyPrefixMetadataKey: "flags/"},
+	})
+	waitForWatch(t, client)
+	assert.Equal(t, []string{"config/flags/"}, client.watchPrefixes())
+
+	client.commit(put("config/key1", "value1"))
+	client.commit(put("config/flags/a", "true"), put("config/flags/b", "false"))
+	e := nextEvent(t, events)
+	require.Len(t, e.Items, 2)
+	assert.Equal(t, "true", e.Items["flags/a"].Value)
+	assert.Equal(t, "false", e.Items["flags/b"].Value)
+}
+
+func TestSubscribeRevision(t *testing.T) {
+	client := newFakeClient()
+	client.commit(put("config/key1", "value1"))
+	client.commit(put("config/key1", "value2"))
+	client.commit(put("config/key2", "value3"))
+	s := newTestStore(t, client)
+
+	t.Run("resume from revision", func(t *testing.T) {
+		_, events := subscribe(t, s, &configuration.SubscribeRequest{
+			Metadata: map[string]string{RevisionMetadataKey: "1"},
+		})
+
+		e := nextEvent(t, events)
+		assert.Equal(t, "value2", e.Items["key1"].Value)
+		assert.Equal(t, "2", e.Items["key1"].Version)
+		e = nextEvent(t, events)
+		assert.Equal(t, "value3", e.Items["key2"].Value)
+		assert.Equal(t, "3", e.Items["key2"].Version)
+	})
+
+	t.Run("compacted revision", func(t *testing.T) {
+		client.compact()
+		_, events := subscribe(t, s, &configuration.SubscribeRequest{
+			Keys:     []string{"key1"},
+			Metadata: map[string]string{RevisionMetadataKey: "1"},
+		})
+
+		// The current value is sent instead
+		e := nextEvent(t, events)
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "value2", e.Items["key1"].Value)
+
+		// Then changes are watched from the current revision
+		client.commit(put("config/key1", "value4"))
+		e = nextEvent(t, events)
+		assert.Equal(t, "value4", e.Items["key1"].Value)
+		assert.Equal(t, "4", e.Items["key1"].Version)
+	})
+
+	t.Run("invalid revision", func(t *testing.T) {
+		_, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+			Metadata: map[string]string{RevisionMetadataKey: "foo"},
+		}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+			return nil
+		})
+		require.ErrorContains(t, err, "revision")
+	})
+}
+
+func TestClose(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client)
+
+	subscribe(t, s, &configuration.SubscribeRequest{})
+	waitForWatch(t, client)
+	require.NoError(t, s.Close())
+
+	_, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		return nil
+	})
+	require.Error(t, err)
+}
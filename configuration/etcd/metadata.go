@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultKeyPrefixPath = "dapr/config"
+	defaultTimeout       = 5 * time.Second
+	defaultRetryDelay    = 5 * time.Second
+)
+
+type metadata struct {
+	// Comma-separated list of etcd endpoints.
+	Endpoints string `mapstructure:"endpoints"`
+	// Prefix for all keys holding configuration items. Keys are relative to this prefix. Defaults to "dapr/config".
+	KeyPrefixPath string `mapstructure:"keyPrefixPath"`
+	// Timeout for operations against etcd. Defaults to 5s.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Delay before watching again after an error. Defaults to 5s.
+	RetryDelay time.Duration `mapstructure:"retryDelay"`
+
+	// TLS
+	TLSEnable bool   `mapstructure:"tlsEnable"`
+	CA        string `mapstructure:"ca"`
+	Cert      string `mapstructure:"cert"`
+	Key       string `mapstructure:"key"`
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.KeyPrefixPath = defaultKeyPrefixPath
+	m.Timeout = defaultTimeout
+	m.RetryDelay = defaultRetryDelay
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if strings.TrimSpace(m.Endpoints) == "" {
+		return errors.New("etcd configuration error: missing required metadata property 'endpoints'")
+	}
+	m.KeyPrefixPath = strings.TrimSuffix(m.KeyPrefixPath, "/")
+	if m.KeyPrefixPath == "" {
+		return errors.New("etcd configuration error: metadata property 'keyPrefixPath' must not be empty")
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+	if m.RetryDelay <= 0 {
+		m.RetryDelay = defaultRetryDelay
+	}
+	if m.TLSEnable && (m.CA == "" || m.Cert == "" || m.Key == "") {
+		return errors.New("etcd configuration error: tls authentication information is incomplete")
+	}
+
+	return nil
+}
+
+func (m *metadata) endpoints() []string {
+	var res []string
+	for _, e := range strings.Split(m.Endpoints, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			res = append(res, e)
+		}
+	}
+	return res
+}
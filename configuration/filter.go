@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// KeyPrefixMetadataKey is the subscribe request metadata key used to only receive changes for keys with the given prefix.
+	KeyPrefixMetadataKey = "keyPrefix"
+	// KeyRegexMetadataKey is the subscribe request metadata key used to only receive changes for keys matching the given regular expression.
+	KeyRegexMetadataKey = "keyRegex"
+)
+
+// KeyFilter selects the keys a subscription receives changes for.
+// Stores push the prefix down to the backend where possible, and evaluate the whole filter for every change.
+// A nil KeyFilter matches every key.
+type KeyFilter struct {
+	Prefix string
+	Regex  *regexp.Regexp
+}
+
+// ParseKeyFilter returns the key filter included in the request metadata, or nil if there's none.
+func ParseKeyFilter(md map[string]string) (*KeyFilter, error) {
+	var f KeyFilter
+	for k, v := range md {
+		switch {
+		case strings.EqualFold(k, KeyPrefixMetadataKey):
+			f.Prefix = v
+		case strings.EqualFold(k, KeyRegexMetadataKey):
+			if v == "" {
+				continue
+			}
+			var err error
+			f.Regex, err = regexp.Compile(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for metadata key '%s': %w", KeyRegexMetadataKey, err)
+			}
+		}
+	}
+	if f.Prefix == "" && f.Regex == nil {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// Match returns true if the key is selected by the filter.
+func (f *KeyFilter) Match(key string) bool {
+	if f == nil {
+		return true
+	}
+	if !strings.HasPrefix(key, f.Prefix) {
+		return false
+	}
+	return f.Regex == nil || f.Regex.MatchString(key)
+}
+
+// FilterItems returns the items whose key is selected by the filter.
+func (f *KeyFilter) FilterItems(items map[string]*Item) map[string]*Item {
+	if f == nil {
+		return items
+	}
+	res := make(map[string]*Item, len(items))
+	for k, v := range items {
+		if f.Match(k) {
+			res[k] = v
+		}
+	}
+	return res
+}
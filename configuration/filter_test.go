@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyFilter(t *testing.T) {
+	t.Run("no filter", func(t *testing.T) {
+		f, err := ParseKeyFilter(map[string]string{"foo": "bar"})
+		require.NoError(t, err)
+		assert.Nil(t, f)
+		assert.True(t, f.Match("anything"))
+	})
+
+	t.Run("prefix and regex", func(t *testing.T) {
+		f, err := ParseKeyFilter(map[string]string{
+			"keyPrefix": "app/",
+			"KeyRegex":  "\\.enabled$",
+		})
+		require.NoError(t, err)
+		assert.True(t, f.Match("app/feature.enabled"))
+		assert.False(t, f.Match("app/feature.value"))
+		assert.False(t, f.Match("other/feature.enabled"))
+
+		items := f.FilterItems(map[string]*Item{
+			"app/a.enabled": {Value: "true"},
+			"app/b":         {Value: "1"},
+		})
+		assert.Len(t, items, 1)
+		assert.Contains(t, items, "app/a.enabled")
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := ParseKeyFilter(map[string]string{"keyRegex": "("})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultEndpoint       = "https://firebaseremoteconfig.googleapis.com"
+	defaultPollInterval   = 30 * time.Second
+	defaultRequestTimeout = 15 * time.Second
+)
+
+type metadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	Type                string `json:"type" mapstructure:"type" mdignore:"true"`
+	ProjectID           string `json:"project_id" mapstructure:"projectID" mdignore:"true" mapstructurealiases:"project_id"`
+	PrivateKeyID        string `json:"private_key_id" mapstructure:"privateKeyID" mdignore:"true" mapstructurealiases:"private_key_id"`
+	PrivateKey          string `json:"private_key" mapstructure:"privateKey" mdignore:"true" mapstructurealiases:"private_key"`
+	ClientEmail         string `json:"client_email" mapstructure:"clientEmail" mdignore:"true" mapstructurealiases:"client_email"`
+	ClientID            string `json:"client_id" mapstructure:"clientID" mdignore:"true" mapstructurealiases:"client_id"`
+	AuthURI             string `json:"auth_uri" mapstructure:"authURI" mdignore:"true" mapstructurealiases:"auth_uri"`
+	TokenURI            string `json:"token_uri" mapstructure:"tokenURI" mdignore:"true" mapstructurealiases:"token_uri"`
+	AuthProviderCertURL string `json:"auth_provider_x509_cert_url" mapstructure:"authProviderX509CertURL" mdignore:"true" mapstructurealiases:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `json:"client_x509_cert_url" mapstructure:"clientX509CertURL" mdignore:"true" mapstructurealiases:"client_x509_cert_url"`
+
+	// Base URL of the Firebase Remote Config API.
+	Endpoint string `json:"-" mapstructure:"endpoint"`
+	// Interval between polls for a new version of the Remote Config template.
+	PollInterval time.Duration `json:"-" mapstructure:"pollInterval"`
+	// Timeout for requests to the Remote Config API.
+	RequestTimeout time.Duration `json:"-" mapstructure:"requestTimeout"`
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.Endpoint = defaultEndpoint
+	m.PollInterval = defaultPollInterval
+	m.RequestTimeout = defaultRequestTimeout
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.ProjectID == "" {
+		return errors.New("firebase remote config error: missing required metadata property 'projectID'")
+	}
+	u, err := url.Parse(m.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("firebase remote config error: invalid endpoint '%s'", m.Endpoint)
+	}
+	m.Endpoint = strings.TrimSuffix(m.Endpoint, "/")
+	if m.PollInterval <= 0 {
+		return errors.New("firebase remote config error: 'pollInterval' must be greater than zero")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
+
+// hasExplicitCredentials returns true if a service account key is included in the metadata.
+// Otherwise, Application Default Credentials are used.
+func (m *metadata) hasExplicitCredentials() bool {
+	return m.PrivateKey != ""
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const remoteConfigScope = "https://www.googleapis.com/auth/firebase.remoteconfig"
+
+// ConfigurationStore is a Firebase Remote Config configuration store.
+// Each parameter of the project's Remote Config template is returned as a configuration item.
+type ConfigurationStore struct {
+	metadata   metadata
+	httpClient *http.Client
+
+	// Cached template, identified by its ETag
+	templateLock sync.Mutex
+	etag         string
+	items        map[string]*configuration.Item
+
+	subscriptions configuration.Subscriptions
+
+	logger logger.Logger
+}
+
+// remoteConfigTemplate is the subset of the Remote Config template used by the component.
+// See: https://firebase.google.com/docs/reference/remote-config/rest/v1/RemoteConfig
+type remoteConfigTemplate struct {
+	Parameters      map[string]remoteConfigParameter `json:"parameters"`
+	ParameterGroups map[string]struct {
+		Parameters map[string]remoteConfigParameter `json:"parameters"`
+	} `json:"parameterGroups"`
+	Version struct {
+		VersionNumber string `json:"versionNumber"`
+	} `json:"version"`
+}
+
+type remoteConfigParameter struct {
+	DefaultValue      *remoteConfigValue           `json:"defaultValue"`
+	ConditionalValues map[string]remoteConfigValue `json:"conditionalValues"`
+	Description       string                       `json:"description"`
+	ValueType         string                       `json:"valueType"`
+}
+
+type remoteConfigValue struct {
+	Value           *string `json:"value,omitempty"`
+	UseInAppDefault bool    `json:"useInAppDefault,omitempty"`
+}
+
+// NewFirebaseRemoteConfigStore returns a new Firebase Remote Config configuration store.
+func NewFirebaseRemoteConfigStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(ctx context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	opts := []option.ClientOption{option.WithScopes(remoteConfigScope)}
+	if r.metadata.hasExplicitCredentials() {
+		b, _ := json.Marshal(r.metadata)
+		opts = append(opts, option.WithCredentialsJSON(b))
+	} else {
+		r.logger.Debug("Using Application Default Credentials for Firebase Remote Config")
+	}
+	r.httpClient, _, err = htransport.NewClient(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create Firebase Remote Config client: %w", err)
+	}
+
+	// Fetch the template to validate the project and credentials
+	_, err = r.refresh(ctx)
+	return err
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	items, err := r.refresh(ctx)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	return &configuration.GetResponse{
+		Items: configuration.FilterItems(items, req.Keys),
+	}, nil
+}
+
+// refresh returns the items of the latest Remote Config template.
+// The template is downloaded and parsed again only if its ETag changed. The returned map must not be modified.
+func (r *ConfigurationStore) refresh(parentCtx context.Context) (map[string]*configuration.Item, error) {
+	r.templateLock.Lock()
+	defer r.templateLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(parentCtx, r.metadata.RequestTimeout)
+	defer cancel()
+
+	u := r.metadata.Endpoint + "/v1/projects/" + url.PathEscape(r.metadata.ProjectID) + "/remoteConfig"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Remote Config template: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return r.items, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		return nil, fmt.Errorf("failed to get Remote Config template: status code %d: %s", res.StatusCode, string(body))
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag != "" && etag == r.etag {
+		return r.items, nil
+	}
+
+	var template remoteConfigTemplate
+	err = json.NewDecoder(res.Body).Decode(&template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Remote Config template: %w", err)
+	}
+
+	r.items = parseTemplate(&template)
+	r.etag = etag
+	return r.items, nil
+}
+
+// parseTemplate converts the parameters of a Remote Config template, including the ones in parameter groups, into items.
+func parseTemplate(template *remoteConfigTemplate) map[string]*configuration.Item {
+	items := make(map[string]*configuration.Item, len(template.Parameters))
+	for name, param := range template.Parameters {
+		items[name] = parameterToItem(param, template.Version.VersionNumber, "")
+	}
+	for group, g := range template.ParameterGroups {
+		for name, param := range g.Parameters {
+			items[name] = parameterToItem(param, template.Version.VersionNumber, group)
+		}
+	}
+	return items
+}
+
+func parameterToItem(param remoteConfigParameter, version string, group string) *configuration.Item {
+	item := &configuration.Item{
+		Version:  version,
+		Metadata: map[string]string{},
+	}
+	if param.DefaultValue != nil {
+		if param.DefaultValue.Value != nil {
+			item.Value = *param.DefaultValue.Value
+		}
+		if param.DefaultValue.UseInAppDefault {
+			item.Metadata["useInAppDefault"] = "true"
+		}
+	}
+	if param.ValueType != "" {
+		item.Metadata["valueType"] = param.ValueType
+	}
+	if param.Description != "" {
+		item.Metadata["description"] = param.Description
+	}
+	if group != "" {
+		item.Metadata["parameterGroup"] = group
+	}
+	if len(param.ConditionalValues) > 0 {
+		// Conditions are evaluated by the Firebase client SDKs, so they're returned as-is
+		b, err := json.Marshal(param.ConditionalValues)
+		if err == nil {
+			item.Metadata["conditionalValues"] = string(b)
+		}
+	}
+	return item
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	items, err := r.refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := configuration.FilterItems(items, req.Keys)
+	return r.subscriptions.Start(ctx, func(ctx context.Context, id string) {
+		r.doSubscribe(ctx, req, handler, snapshot, id)
+	})
+}
+
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, snapshot map[string]*configuration.Item, id string) {
+	ticker := time.NewTicker(r.metadata.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		items, err := r.refresh(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("Failed to get configuration changes: %s", err)
+			continue
+		}
+
+		// Versions are not compared because every publish of the template bumps the version of all parameters
+		current := configuration.FilterItems(items, req.Keys)
+		changed := configuration.DiffItems(snapshot, current, false)
+		snapshot = current
+		if len(changed) > 0 {
+			r.handleSubscribedChange(ctx, handler, changed, id)
+		}
+	}
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	return r.subscriptions.Stop(req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	r.subscriptions.Close()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const testTemplate = `{
+	"parameters": {
+		"welcome_message": {
+			"defaultValue": {"value": "Hello"},
+			"conditionalValues": {"ios": {"value": "Hello iOS"}},
+			"description": "Greeting",
+			"valueType": "STRING"
+		},
+		"in_app": {
+			"defaultValue": {"useInAppDefault": true}
+		}
+	},
+	"parameterGroups": {
+		"checkout": {
+			"parameters": {
+				"discount": {"defaultValue": {"value": "10"}, "valueType": "NUMBER"}
+			}
+		}
+	},
+	"version": {"versionNumber": "7"}
+}`
+
+// fakeRemoteConfig serves a template and honors If-None-Match.
+type fakeRemoteConfig struct {
+	lock     sync.Mutex
+	template string
+	etag     string
+	requests int
+	notMod   int
+}
+
+func (f *fakeRemoteConfig) set(template string, etag string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.template = template
+	f.etag = etag
+}
+
+func (f *fakeRemoteConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests++
+	if r.URL.Path != "/v1/projects/my-project/remoteConfig" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Header.Get("If-None-Match") == f.etag {
+		f.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(f.template))
+}
+
+func newTestStore(t *testing.T, fake *fakeRemoteConfig, pollInterval string) *ConfigurationStore {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s := NewFirebaseRemoteConfigStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err := s.metadata.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"projectID":    "my-project",
+		"endpoint":     srv.URL + "/",
+		"pollInterval": pollInterval,
+	}}})
+	require.NoError(t, err)
+	s.httpClient = srv.Client()
+	return s
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"projectID": "my-project",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultEndpoint, m.Endpoint)
+		assert.Equal(t, defaultPollInterval, m.PollInterval)
+		assert.Equal(t, defaultRequestTimeout, m.RequestTimeout)
+		assert.False(t, m.hasExplicitCredentials())
+	})
+
+	t.Run("missing project", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"projectID": "my-project",
+			"endpoint":  "ftp://example.com",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	fake := &fakeRemoteConfig{}
+	fake.set(testTemplate, `"etag-1"`)
+	s := newTestStore(t, fake, "30s")
+
+	t.Run("all parameters", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 3)
+
+		welcome := res.Items["welcome_message"]
+		assert.Equal(t, "Hello", welcome.Value)
+		assert.Equal(t, "7", welcome.Version)
+		assert.Equal(t, "STRING", welcome.Metadata["valueType"])
+		assert.Equal(t, "Greeting", welcome.Metadata["description"])
+		assert.JSONEq(t, `{"ios":{"value":"Hello iOS"}}`, welcome.Metadata["conditionalValues"])
+
+		assert.Equal(t, "", res.Items["in_app"].Value)
+		assert.Equal(t, "true", res.Items["in_app"].Metadata["useInAppDefault"])
+
+		assert.Equal(t, "10", res.Items["discount"].Value)
+		assert.Equal(t, "checkout", res.Items["discount"].Metadata["parameterGroup"])
+	})
+
+	t.Run("selected keys use cached template", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{Keys: []string{"discount", "missing"}})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "10", res.Items["discount"].Value)
+
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		assert.Equal(t, 1, fake.notMod)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	fake := &fakeRemoteConfig{}
+	fake.set(testTemplate, `"etag-1"`)
+	s := newTestStore(t, fake, "10ms")
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Keys: []string{"welcome_message", "discount"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Publishing a new version bumps the version of every parameter, but only changed values are reported
+	fake.set(`{
+		"parameters": {
+			"welcome_message": {"defaultValue": {"value": "Hi"}, "description": "Greeting", "valueType": "STRING", "conditionalValues": {"ios": {"value": "Hello iOS"}}},
+			"in_app": {"defaultValue": {"value": "changed"}}
+		},
+		"version": {"versionNumber": "8"}
+	}`, `"etag-2"`)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		require.Len(t, e.Items, 2)
+		assert.Equal(t, "Hi", e.Items["welcome_message"].Value)
+		assert.Equal(t, "8", e.Items["welcome_message"].Version)
+		assert.Equal(t, &configuration.Item{}, e.Items["discount"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for update event")
+	}
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"maps"
+)
+
+// FilterItems returns the items with the given keys, or all of them if keys is empty.
+// The result is a new map, so it can be kept as a snapshot while items is replaced.
+func FilterItems(items map[string]*Item, keys []string) map[string]*Item {
+	if len(keys) == 0 {
+		return maps.Clone(items)
+	}
+
+	res := make(map[string]*Item, len(keys))
+	for _, k := range keys {
+		if item, ok := items[k]; ok {
+			res[k] = item
+		}
+	}
+	return res
+}
+
+// DiffItems returns the items in current that are different from the ones in previous, and an empty item for every deleted key.
+// Items are compared by value and metadata, and also by version if compareVersions is true.
+// Stores whose backend bumps the version of all items on every change should not compare versions, to only notify of the items that changed.
+func DiffItems(previous map[string]*Item, current map[string]*Item, compareVersions bool) map[string]*Item {
+	changed := map[string]*Item{}
+	for k, item := range current {
+		old, ok := previous[k]
+		if !ok || old.Value != item.Value || !maps.Equal(old.Metadata, item.Metadata) || (compareVersions && old.Version != item.Version) {
+			changed[k] = item
+		}
+	}
+	for k := range previous {
+		if _, ok := current[k]; !ok {
+			changed[k] = &Item{}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterItems(t *testing.T) {
+	items := map[string]*Item{
+		"a": {Value: "1"},
+		"b": {Value: "2"},
+	}
+
+	t.Run("all keys", func(t *testing.T) {
+		res := FilterItems(items, nil)
+		assert.Equal(t, items, res)
+
+		// The result is a copy
+		res["c"] = &Item{}
+		assert.Len(t, items, 2)
+	})
+
+	t.Run("some keys", func(t *testing.T) {
+		res := FilterItems(items, []string{"a", "missing"})
+		assert.Equal(t, map[string]*Item{"a": {Value: "1"}}, res)
+	})
+}
+
+func TestDiffItems(t *testing.T) {
+	previous := map[string]*Item{
+		"same":     {Value: "1", Version: "1"},
+		"value":    {Value: "2", Version: "1"},
+		"version":  {Value: "3", Version: "1"},
+		"metadata": {Value: "4", Version: "1", Metadata: map[string]string{"foo": "bar"}},
+		"deleted":  {Value: "5", Version: "1"},
+	}
+	current := map[string]*Item{
+		"same":     {Value: "1", Version: "1"},
+		"value":    {Value: "20", Version: "2"},
+		"version":  {Value: "3", Version: "2"},
+		"metadata": {Value: "4", Version: "1", Metadata: map[string]string{"foo": "baz"}},
+		"added":    {Value: "6", Version: "2"},
+	}
+
+	t.Run("compare versions", func(t *testing.T) {
+		changed := DiffItems(previous, current, true)
+		require.Len(t, changed, 5)
+		assert.Equal(t, "20", changed["value"].Value)
+		assert.Equal(t, "2", changed["version"].Version)
+		assert.Equal(t, "baz", changed["metadata"].Metadata["foo"])
+		assert.Equal(t, "6", changed["added"].Value)
+		assert.Equal(t, &Item{}, changed["deleted"])
+	})
+
+	t.Run("ignore versions", func(t *testing.T) {
+		changed := DiffItems(previous, current, false)
+		require.Len(t, changed, 4)
+		assert.NotContains(t, changed, "version")
+	})
+
+	t.Run("nil and empty metadata are equal", func(t *testing.T) {
+		changed := DiffItems(
+			map[string]*Item{"a": {Value: "1"}},
+			map[string]*Item{"a": {Value: "1", Metadata: map[string]string{}}},
+			true,
+		)
+		assert.Empty(t, changed)
+	})
+}
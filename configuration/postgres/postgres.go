@@ -25,12 +25,16 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	pginterfaces "github.com/dapr/components-contrib/common/component/postgresql/interfaces"
+	pgtransactions "github.com/dapr/components-contrib/common/component/postgresql/transactions"
 	"github.com/dapr/components-contrib/configuration"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
@@ -38,7 +42,8 @@ import (
 
 type ConfigurationStore struct {
 	metadata            metadata
-	client              *pgxpool.Pool
+	client              pginterfaces.PGXPoolConn
+	acquireListenConn   func(ctx context.Context) (listenConn, error)
 	logger              logger.Logger
 	configLock          sync.RWMutex
 	ActiveSubscriptions map[string]*subscription
@@ -52,6 +57,31 @@ type ConfigurationStore struct {
 type subscription struct {
 	channel string
 	keys    []string
+	filter  *configuration.KeyFilter
+}
+
+// listenConn is a connection used to listen for notifications.
+type listenConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	// Release returns the connection to the pool, or closes it if it's broken.
+	Release(broken bool)
+}
+
+type poolListenConn struct {
+	*pgxpool.Conn
+}
+
+func (c poolListenConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	return c.Conn.Conn().WaitForNotification(ctx)
+}
+
+func (c poolListenConn) Release(broken bool) {
+	if broken {
+		// Close the connection so it's not returned to the pool
+		c.Conn.Conn().Close(context.Background())
+	}
+	c.Conn.Release()
 }
 
 type pgResponse struct {
@@ -70,6 +100,8 @@ var (
 	allowedTableNameChars = regexp.MustCompile(`^[a-z0-9./_]*$`)
 )
 
+var _ configuration.WritableStore = (*ConfigurationStore)(nil)
+
 func NewPostgresConfigurationStore(logger logger.Logger) configuration.Store {
 	return &ConfigurationStore{
 		logger: logger,
@@ -84,10 +116,18 @@ func (p *ConfigurationStore) Init(ctx context.Context, metadata configuration.Me
 	}
 
 	p.ActiveSubscriptions = make(map[string]*subscription)
-	p.client, err = p.connectDB(ctx)
+	pool, err := p.connectDB(ctx)
 	if err != nil {
 		return fmt.Errorf("error connecting to configuration store: '%w'", err)
 	}
+	p.client = pool
+	p.acquireListenConn = func(ctx context.Context) (listenConn, error) {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return poolListenConn{Conn: conn}, nil
+	}
 
 	err = p.client.Ping(ctx)
 	if err != nil {
@@ -134,12 +174,48 @@ func getUniqueItemPerKey(res []pgResponse) map[string]*configuration.Item {
 	return items
 }
 
+// Returns the items matching the version options, listing the versions of each item if requested
+func selectVersions(res []pgResponse, opts configuration.VersionOptions) map[string]*configuration.Item {
+	var items map[string]*configuration.Item
+	if opts.Version == "" {
+		items = getUniqueItemPerKey(res)
+	} else {
+		items = make(map[string]*configuration.Item)
+		for _, r := range res {
+			if r.item.Version == opts.Version {
+				items[r.key] = r.item
+			}
+		}
+	}
+
+	if opts.History > 0 {
+		versions := make(map[string][]string)
+		for _, r := range res {
+			versions[r.key] = append(versions[r.key], r.item.Version)
+		}
+		for key, item := range items {
+			md := make(map[string]string, len(item.Metadata)+1)
+			for k, v := range item.Metadata {
+				md[k] = v
+			}
+			md[configuration.VersionsItemMetadataKey] = configuration.LatestVersions(versions[key], opts.History)
+			item.Metadata = md
+		}
+	}
+	return items
+}
+
 func (p *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
 	if err := validateInput(req.Keys); err != nil {
 		p.logger.Error(err)
 		return nil, err
 	}
-	query, params, err := buildQuery(req, p.metadata.ConfigTable)
+	// Every version of an item is stored as a separate row: the version options are applied to the rows that are read
+	versionOpts, md, err := configuration.ParseVersionOptions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	query, params, err := buildQuery(&configuration.GetRequest{Keys: req.Keys, Metadata: md}, p.metadata.ConfigTable)
 	if err != nil {
 		p.logger.Error(err)
 		return nil, fmt.Errorf("error in configuration store query: '%w' ", err)
@@ -164,12 +240,139 @@ func (p *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse response from configuration store - %w", err)
 	}
-	result := getUniqueItemPerKey(items)
+	result := selectVersions(items, versionOpts)
 	return &configuration.GetResponse{
 		Items: result,
 	}, nil
 }
 
+// Set creates or updates the items in a single transaction.
+// Every write inserts a new row for the item, with the next numeric version, so previous versions are kept as history.
+func (p *ConfigurationStore) Set(ctx context.Context, req *configuration.SetRequest) error {
+	keys := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		keys[i] = item.Key
+	}
+	if err := validateWriteKeys(keys); err != nil {
+		return err
+	}
+
+	return p.writeItems(ctx, keys, func(ctx context.Context, tx pgx.Tx, versions map[string][]string) error {
+		for _, item := range req.Items {
+			if err := checkVersion(item.Key, versions[item.Key], item.ExpectedVersion); err != nil {
+				return err
+			}
+		}
+		for _, item := range req.Items {
+			next := strconv.Itoa(latestNumericVersion(versions[item.Key]) + 1)
+			_, err := tx.Exec(ctx, "INSERT INTO "+p.metadata.ConfigTable+" (KEY, VALUE, VERSION, METADATA) VALUES ($1, $2, $3, $4)",
+				item.Key, item.Value, next, item.Metadata)
+			if err != nil {
+				return fmt.Errorf("error writing configuration item '%s': %w", item.Key, err)
+			}
+			versions[item.Key] = append(versions[item.Key], next)
+		}
+		return nil
+	})
+}
+
+// Delete deletes the items, including all their versions, in a single transaction.
+func (p *ConfigurationStore) Delete(ctx context.Context, req *configuration.DeleteRequest) error {
+	keys := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		keys[i] = item.Key
+	}
+	if err := validateWriteKeys(keys); err != nil {
+		return err
+	}
+
+	return p.writeItems(ctx, keys, func(ctx context.Context, tx pgx.Tx, versions map[string][]string) error {
+		for _, item := range req.Items {
+			if err := checkVersion(item.Key, versions[item.Key], item.ExpectedVersion); err != nil {
+				return err
+			}
+		}
+		for _, item := range req.Items {
+			_, err := tx.Exec(ctx, "DELETE FROM "+p.metadata.ConfigTable+" WHERE KEY = $1", item.Key)
+			if err != nil {
+				return fmt.Errorf("error deleting configuration item '%s': %w", item.Key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// writeItems runs fn in a transaction, after locking the keys and reading the versions they currently have.
+func (p *ConfigurationStore) writeItems(ctx context.Context, keys []string, fn func(ctx context.Context, tx pgx.Tx, versions map[string][]string) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := pgtransactions.ExecuteInTransaction[struct{}](ctx, p.logger, p.client, p.metadata.Timeout, func(ctx context.Context, tx pgx.Tx) (struct{}, error) {
+		// Lock the keys in a consistent order to avoid deadlocks; advisory locks also cover keys that don't exist yet
+		sorted := slices.Clone(keys)
+		slices.Sort(sorted)
+		for _, key := range slices.Compact(sorted) {
+			_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", p.metadata.ConfigTable+"/"+key)
+			if err != nil {
+				return struct{}{}, fmt.Errorf("error locking configuration item '%s': %w", key, err)
+			}
+		}
+
+		rows, err := tx.Query(ctx, "SELECT KEY, VERSION FROM "+p.metadata.ConfigTable+" WHERE KEY = ANY($1)", keys)
+		if err != nil {
+			return struct{}{}, fmt.Errorf("error reading configuration items versions: %w", err)
+		}
+		versions := make(map[string][]string, len(keys))
+		var key, version string
+		_, err = pgx.ForEachRow(rows, []any{&key, &version}, func() error {
+			versions[key] = append(versions[key], version)
+			return nil
+		})
+		if err != nil {
+			return struct{}{}, fmt.Errorf("error reading configuration items versions: %w", err)
+		}
+
+		return struct{}{}, fn(ctx, tx, versions)
+	})
+	return err
+}
+
+// Returns an error wrapping configuration.ErrVersionMismatch if the latest version isn't the expected one
+func checkVersion(key string, versions []string, expected *string) error {
+	if expected == nil {
+		return nil
+	}
+	if len(versions) == 0 {
+		if *expected == "" {
+			return nil
+		}
+	} else if *expected != "" && strconv.Itoa(latestNumericVersion(versions)) == *expected {
+		return nil
+	}
+	return fmt.Errorf("%w: key %s", configuration.ErrVersionMismatch, key)
+}
+
+// Returns the latest numeric version, or 0 if there's none
+func latestNumericVersion(versions []string) int {
+	latest := 0
+	for _, v := range versions {
+		if n := getNumericVersion(v); n > latest {
+			latest = n
+		}
+	}
+	return latest
+}
+
+func validateWriteKeys(keys []string) error {
+	for _, key := range keys {
+		if key == "" {
+			return errors.New("configuration item key is empty")
+		}
+	}
+	return validateInput(keys)
+}
+
 func (p *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -188,7 +391,15 @@ func (p *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.S
 	if pgNotifyChannel == "" {
 		return "", fmt.Errorf("unable to subscribe to '%s'. pgNotifyChannel attribute cannot be empty", p.metadata.ConfigTable)
 	}
-	return p.subscribeToChannel(ctx, pgNotifyChannel, req, handler)
+	if err := validateInput(req.Keys); err != nil {
+		return "", err
+	}
+	// The filter is evaluated for every notification; triggers can also restrict the keys they notify about to reduce traffic
+	filter, err := configuration.ParseKeyFilter(req.Metadata)
+	if err != nil {
+		return "", err
+	}
+	return p.subscribeToChannel(ctx, pgNotifyChannel, req, handler, filter)
 }
 
 func (p *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
@@ -207,49 +418,212 @@ func (p *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration
 		return fmt.Errorf("unable to find subscription with ID : %v", req.ID)
 	}
 
+	// The subscription stops listening on its own connection when it's canceled
 	if cancelContext, ok := p.cancelMap.Load(req.ID); ok {
 		cancelContext.(context.CancelFunc)()
 	}
+	return nil
+}
+
+func (p *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, command string, channel string, subscription string) {
+	// Latest version of each item sent to the subscriber, used to replay the changes missed while the LISTEN connection was down
+	var versions map[string]int
 
-	pgChannel := "UNLISTEN " + sub.channel
-	conn, err := p.client.Acquire(ctx)
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxElapsedTime = 0
+	for {
+		err := p.listen(ctx, req, handler, command, channel, subscription, &versions, bo)
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := bo.NextBackOff()
+		p.logger.Warnf("Connection for subscription %s lost, reconnecting in %v: %v", subscription, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listen acquires a connection, starts listening on the channel, and delivers notifications until the connection fails or the context is canceled.
+func (p *ConfigurationStore) listen(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, command string, channel string, subscription string, versions *map[string]int, bo backoff.BackOff) error {
+	conn, err := p.acquireListenConn(ctx)
 	if err != nil {
-		p.logger.Error("error acquiring connection:", err)
-		return fmt.Errorf("error acquiring connection: %w ", err)
+		return fmt.Errorf("error acquiring connection: %w", err)
+	}
+	broken := false
+	defer func() {
+		if !broken {
+			// The connection goes back to the pool, so it must stop listening
+			unlistenCtx, cancel := context.WithTimeout(context.Background(), p.metadata.Timeout)
+			_, unlistenErr := conn.Exec(unlistenCtx, "UNLISTEN "+channel)
+			cancel()
+			broken = unlistenErr != nil
+		}
+		conn.Release(broken)
+	}()
+
+	if _, err = conn.Exec(ctx, command); err != nil {
+		return fmt.Errorf("error listening to channel: %w", err)
 	}
-	defer conn.Release()
-	_, err = conn.Exec(ctx, pgChannel)
+
+	// Now that LISTEN is active, replay changes that happened while we were not listening
+	err = p.catchUp(ctx, req, handler, subscription, versions)
 	if err != nil {
-		p.logger.Error("error un-listening to channel:", err)
-		return fmt.Errorf("error un-listening to channel: %w", err)
+		return err
+	}
+	bo.Reset()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			broken = true
+			return fmt.Errorf("error waiting for notification: %w", err)
+		}
+		p.handleSubscribedChange(ctx, handler, notification, channel, subscription, *versions)
+	}
+}
+
+// catchUp replays the rows of the subscribed keys with a version greater than the one last sent to the subscriber, and notifies it of the keys that were deleted.
+// On the first invocation, it only records the latest versions.
+func (p *ConfigurationStore) catchUp(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, subscriptionID string, versions *map[string]int) error {
+	var filter *configuration.KeyFilter
+	p.configLock.RLock()
+	if sub := p.ActiveSubscriptions[subscriptionID]; sub != nil {
+		filter = sub.filter
+	}
+	p.configLock.RUnlock()
+
+	latest, err := p.latestVersions(ctx, req.Keys, filter)
+	if err != nil {
+		return fmt.Errorf("error reading configuration to catch up with missed notifications: %w", err)
+	}
+	if *versions == nil {
+		*versions = latest
+		return nil
+	}
+
+	last := *versions
+	deleted := make(map[string]*configuration.Item)
+	for key, version := range last {
+		l, ok := latest[key]
+		switch {
+		case !ok:
+			deleted[key] = &configuration.Item{}
+			delete(last, key)
+		case l < version:
+			// The item was deleted and created again, so all its versions are new
+			delete(last, key)
+		}
+	}
+
+	rows, err := p.rowsAfterVersions(ctx, req.Keys, last)
+	if err != nil {
+		return fmt.Errorf("error reading configuration to catch up with missed notifications: %w", err)
+	}
+
+	if len(deleted) > 0 || len(rows) > 0 {
+		p.logger.Infof("Subscription %s catching up with %d deleted items and %d changes after reconnecting", subscriptionID, len(deleted), len(rows))
+	}
+	if len(deleted) > 0 {
+		p.notify(ctx, handler, subscriptionID, deleted)
+	}
+	for _, r := range rows {
+		if !filter.Match(r.key) {
+			continue
+		}
+		if n := getNumericVersion(r.item.Version); n > last[r.key] {
+			last[r.key] = n
+		}
+		p.notify(ctx, handler, subscriptionID, map[string]*configuration.Item{r.key: r.item})
 	}
 	return nil
 }
 
-func (p *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, command string, channel string, subscription string) {
-	conn, err := p.client.Acquire(ctx)
+// latestVersions returns the latest numeric version of each key that matches the filter.
+func (p *ConfigurationStore) latestVersions(ctx context.Context, keys []string, filter *configuration.KeyFilter) (map[string]int, error) {
+	query := "SELECT KEY, VERSION FROM " + p.metadata.ConfigTable
+	var params []any
+	if len(keys) > 0 {
+		query += " WHERE KEY = ANY($1)"
+		params = append(params, keys)
+	}
+	rows, err := p.client.Query(ctx, query, params...)
 	if err != nil {
-		p.logger.Errorf("error acquiring connection:", err)
-		return
+		return nil, err
 	}
-	defer conn.Release()
-	if _, err = conn.Exec(ctx, command); err != nil {
-		p.logger.Errorf("error listening to channel:", err)
-		return
+	latest := make(map[string]int)
+	var key, version string
+	_, err = pgx.ForEachRow(rows, []any{&key, &version}, func() error {
+		if !filter.Match(key) {
+			return nil
+		}
+		n := max(getNumericVersion(version), 0)
+		if l, ok := latest[key]; !ok || n > l {
+			latest[key] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	for {
-		notification, err := conn.Conn().WaitForNotification(ctx)
-		if err != nil {
-			if !pgconn.Timeout(err) && !errors.Is(err, context.Canceled) {
-				p.logger.Errorf("error waiting for notification:", err)
-			}
-			return
+	return latest, nil
+}
+
+// rowsAfterVersions returns the rows of the keys with a numeric version greater than the one in versions, or all of them if the key isn't there.
+// Rows are sorted by version, so changes are replayed in the order they were made; rows with a non-numeric version can't be ordered and are skipped.
+func (p *ConfigurationStore) rowsAfterVersions(ctx context.Context, keys []string, versions map[string]int) ([]pgResponse, error) {
+	lastKeys := make([]string, 0, len(versions))
+	for key := range versions {
+		lastKeys = append(lastKeys, key)
+	}
+	slices.Sort(lastKeys)
+	lastVersions := make([]int64, len(lastKeys))
+	for i, key := range lastKeys {
+		lastVersions[i] = int64(versions[key])
+	}
+
+	query := "SELECT t.KEY, t.VALUE, t.VERSION, t.METADATA FROM (SELECT *, CASE WHEN VERSION ~ '^[0-9]{1,18}$' THEN VERSION::bigint END AS NUM FROM " + p.metadata.ConfigTable + ") t" +
+		" LEFT JOIN unnest($1::text[], $2::bigint[]) AS l(KEY, VERSION) ON t.KEY = l.KEY" +
+		" WHERE t.NUM > COALESCE(l.VERSION, 0)"
+	params := []any{lastKeys, lastVersions}
+	if len(keys) > 0 {
+		query += " AND t.KEY = ANY($3)"
+		params = append(params, keys)
+	}
+	query += " ORDER BY t.NUM, t.KEY"
+
+	rows, err := p.client.Query(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (pgResponse, error) {
+		res := pgResponse{
+			item: new(configuration.Item),
 		}
-		p.handleSubscribedChange(ctx, handler, notification, channel, subscription)
+		if innerErr := row.Scan(&res.key, &res.item.Value, &res.item.Version, &res.item.Metadata); innerErr != nil {
+			return pgResponse{}, innerErr
+		}
+		return res, nil
+	})
+}
+
+func (p *ConfigurationStore) notify(ctx context.Context, handler configuration.UpdateHandler, subscriptionID string, items map[string]*configuration.Item) {
+	err := handler(ctx, &configuration.UpdateEvent{
+		Items: items,
+		ID:    subscriptionID,
+	})
+	if err != nil {
+		p.logger.Errorf("failed to call notify event handler : %v", err)
 	}
 }
 
-func (p *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, msg *pgconn.Notification, channel string, subscriptionID string) {
+func (p *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, msg *pgconn.Notification, channel string, subscriptionID string, versions map[string]int) {
 	payload := make(map[string]interface{})
 	err := json.Unmarshal([]byte(msg.Payload), &payload)
 	if err != nil {
@@ -285,13 +659,17 @@ func (p *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler
 				}
 			}
 		}
+		item := &configuration.Item{
+			Value:    value,
+			Version:  version,
+			Metadata: m,
+		}
+		if n := getNumericVersion(version); versions != nil && n > versions[key] {
+			versions[key] = n
+		}
 		e := &configuration.UpdateEvent{
 			Items: map[string]*configuration.Item{
-				key: {
-					Value:    value,
-					Version:  version,
-					Metadata: m,
-				},
+				key: item,
 			},
 			ID: subscriptionID,
 		}
@@ -365,7 +743,7 @@ func (p *ConfigurationStore) isSubscribed(subscriptionID string, channel string,
 	p.configLock.RLock()
 	defer p.configLock.RUnlock()
 	val := p.ActiveSubscriptions[subscriptionID]
-	if val != nil && val.channel == channel && (slices.Contains(val.keys, key) || len(val.keys) == 0) {
+	if val != nil && val.channel == channel && (slices.Contains(val.keys, key) || len(val.keys) == 0) && val.filter.Match(key) {
 		return true
 	}
 	return false
@@ -380,7 +758,7 @@ func validateInput(keys []string) error {
 	return nil
 }
 
-func (p *ConfigurationStore) subscribeToChannel(ctx context.Context, pgNotifyChannel string, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+func (p *ConfigurationStore) subscribeToChannel(ctx context.Context, pgNotifyChannel string, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, filter *configuration.KeyFilter) (string, error) {
 	p.configLock.Lock()
 	defer p.configLock.Unlock()
 
@@ -398,6 +776,7 @@ func (p *ConfigurationStore) subscribeToChannel(ctx context.Context, pgNotifyCha
 	p.ActiveSubscriptions[subscribeID] = &subscription{
 		channel: pgNotifyChannel,
 		keys:    req.Keys,
+		filter:  filter,
 	}
 
 	p.wg.Add(1)
@@ -15,15 +15,21 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	pgauth "github.com/dapr/components-contrib/common/authentication/postgresql"
 	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/kit/logger"
 )
 
 func TestSelectAllQuery(t *testing.T) {
@@ -116,3 +122,301 @@ func TestValidateInput(t *testing.T) {
 	keys3 := []string{"Name 1=1"}
 	require.Error(t, validateInput(keys3), "invalid key : 'Name 1=1'")
 }
+
+func TestSelectVersions(t *testing.T) {
+	rows := []pgResponse{
+		{key: "k1", item: &configuration.Item{Value: "a", Version: "1"}},
+		{key: "k1", item: &configuration.Item{Value: "b", Version: "3"}},
+		{key: "k1", item: &configuration.Item{Value: "c", Version: "2"}},
+		{key: "k2", item: &configuration.Item{Value: "d", Version: "1", Metadata: map[string]string{"foo": "bar"}}},
+	}
+
+	t.Run("latest", func(t *testing.T) {
+		items := selectVersions(rows, configuration.VersionOptions{})
+		require.Len(t, items, 2)
+		assert.Equal(t, "b", items["k1"].Value)
+		assert.Equal(t, "d", items["k2"].Value)
+	})
+
+	t.Run("specific version", func(t *testing.T) {
+		items := selectVersions(rows, configuration.VersionOptions{Version: "2"})
+		require.Len(t, items, 1)
+		assert.Equal(t, "c", items["k1"].Value)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		items := selectVersions(rows, configuration.VersionOptions{History: 2})
+		require.Len(t, items, 2)
+		assert.Equal(t, "3,2", items["k1"].Metadata[configuration.VersionsItemMetadataKey])
+		assert.Equal(t, "1", items["k2"].Metadata[configuration.VersionsItemMetadataKey])
+		assert.Equal(t, "bar", items["k2"].Metadata["foo"])
+	})
+}
+
+func TestCheckVersion(t *testing.T) {
+	newVersion := ""
+	v2 := "2"
+
+	require.NoError(t, checkVersion("k", nil, nil))
+	require.NoError(t, checkVersion("k", []string{"1", "2"}, nil))
+	require.NoError(t, checkVersion("k", nil, &newVersion))
+	require.NoError(t, checkVersion("k", []string{"1", "2"}, &v2))
+	require.ErrorIs(t, checkVersion("k", []string{"1"}, &v2), configuration.ErrVersionMismatch)
+	require.ErrorIs(t, checkVersion("k", []string{"1"}, &newVersion), configuration.ErrVersionMismatch)
+	require.ErrorIs(t, checkVersion("k", nil, &v2), configuration.ErrVersionMismatch)
+}
+
+func TestLatestNumericVersion(t *testing.T) {
+	assert.Equal(t, 0, latestNumericVersion(nil))
+	assert.Equal(t, 0, latestNumericVersion([]string{"abc"}))
+	assert.Equal(t, 10, latestNumericVersion([]string{"2", "10", "abc", "1"}))
+}
+
+// fakeListenConn is a listenConn that delivers the notifications sent on its channel, and fails when the channel is closed.
+type fakeListenConn struct {
+	notifications chan *pgconn.Notification
+	released      chan bool
+
+	lock  sync.Mutex
+	execs []string
+}
+
+func newFakeListenConn() *fakeListenConn {
+	return &fakeListenConn{
+		notifications: make(chan *pgconn.Notification),
+		released:      make(chan bool, 1),
+	}
+}
+
+func (c *fakeListenConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.execs = append(c.execs, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *fakeListenConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case n, ok := <-c.notifications:
+		if !ok {
+			return nil, errors.New("connection lost")
+		}
+		return n, nil
+	}
+}
+
+func (c *fakeListenConn) Release(broken bool) {
+	c.released <- broken
+}
+
+func (c *fakeListenConn) getExecs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.execs...)
+}
+
+const (
+	latestVersionsQuery    = "SELECT KEY, VERSION FROM cfgtbl"
+	rowsAfterVersionsQuery = "SELECT t.KEY, t.VALUE, t.VERSION, t.METADATA FROM"
+)
+
+func newSubscriptionTestStore(t *testing.T) (*ConfigurationStore, pgxmock.PgxPoolIface) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	return &ConfigurationStore{
+		metadata: metadata{
+			ConfigTable: "cfgtbl",
+			Timeout:     time.Second,
+		},
+		client: mock,
+		logger: logger.NewLogger("test"),
+		ActiveSubscriptions: map[string]*subscription{
+			"sub1": {channel: "cfgchannel"},
+		},
+	}, mock
+}
+
+func newEventHandler() (configuration.UpdateHandler, chan *configuration.UpdateEvent) {
+	events := make(chan *configuration.UpdateEvent, 10)
+	return func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	}, events
+}
+
+func receiveEvent(t *testing.T, events chan *configuration.UpdateEvent) map[string]*configuration.Item {
+	t.Helper()
+	select {
+	case e := <-events:
+		assert.Equal(t, "sub1", e.ID)
+		return e.Items
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for an event")
+		return nil
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	p, mock := newSubscriptionTestStore(t)
+	handler, events := newEventHandler()
+	req := &configuration.SubscribeRequest{}
+
+	// The first catch-up only records the latest versions
+	mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "version"}).
+			AddRow("k1", "1").
+			AddRow("k1", "2").
+			AddRow("k2", "1").
+			AddRow("k3", "3"))
+	var versions map[string]int
+	require.NoError(t, p.catchUp(context.Background(), req, handler, "sub1", &versions))
+	assert.Equal(t, map[string]int{"k1": 2, "k2": 1, "k3": 3}, versions)
+	assert.Empty(t, events)
+
+	// k2 was deleted, k3 was deleted and created again, and k4 is new: only k1 has versions that were already sent
+	mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "version"}).
+			AddRow("k1", "4").
+			AddRow("k3", "1").
+			AddRow("k4", "1"))
+	mock.ExpectQuery(regexp.QuoteMeta(rowsAfterVersionsQuery)).
+		WithArgs([]string{"k1"}, []int64{2}).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "value", "version", "metadata"}).
+			AddRow("k3", "c1", "1", map[string]string(nil)).
+			AddRow("k4", "d1", "1", map[string]string(nil)).
+			AddRow("k1", "a3", "3", map[string]string(nil)).
+			AddRow("k1", "a4", "4", map[string]string(nil)))
+	require.NoError(t, p.catchUp(context.Background(), req, handler, "sub1", &versions))
+	assert.Equal(t, map[string]int{"k1": 4, "k3": 1, "k4": 1}, versions)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, map[string]*configuration.Item{"k2": {}}, receiveEvent(t, events))
+	for _, expected := range []struct{ key, value, version string }{
+		{"k3", "c1", "1"},
+		{"k4", "d1", "1"},
+		{"k1", "a3", "3"},
+		{"k1", "a4", "4"},
+	} {
+		items := receiveEvent(t, events)
+		require.Len(t, items, 1)
+		require.Contains(t, items, expected.key)
+		assert.Equal(t, expected.value, items[expected.key].Value)
+		assert.Equal(t, expected.version, items[expected.key].Version)
+	}
+	assert.Empty(t, events)
+}
+
+func TestListen(t *testing.T) {
+	t.Run("delivers notifications until the connection fails", func(t *testing.T) {
+		p, mock := newSubscriptionTestStore(t)
+		handler, events := newEventHandler()
+		conn := newFakeListenConn()
+		p.acquireListenConn = func(ctx context.Context) (listenConn, error) {
+			return conn, nil
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+			WillReturnRows(pgxmock.NewRows([]string{"key", "version"}).AddRow("k1", "1"))
+
+		var versions map[string]int
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- p.listen(context.Background(), &configuration.SubscribeRequest{}, handler, "listen cfgchannel", "cfgchannel", "sub1", &versions, backoff.NewExponentialBackOff())
+		}()
+
+		conn.notifications <- &pgconn.Notification{
+			Channel: "cfgchannel",
+			Payload: `{"data": {"key": "k1", "value": "a2", "version": "2"}}`,
+		}
+		items := receiveEvent(t, events)
+		require.Contains(t, items, "k1")
+		assert.Equal(t, "a2", items["k1"].Value)
+
+		close(conn.notifications)
+		require.ErrorContains(t, <-errCh, "connection lost")
+		assert.True(t, <-conn.released)
+		assert.Equal(t, []string{"listen cfgchannel"}, conn.getExecs())
+		assert.Equal(t, map[string]int{"k1": 2}, versions)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stops listening when canceled", func(t *testing.T) {
+		p, mock := newSubscriptionTestStore(t)
+		handler, _ := newEventHandler()
+		conn := newFakeListenConn()
+		p.acquireListenConn = func(ctx context.Context) (listenConn, error) {
+			return conn, nil
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+			WillReturnRows(pgxmock.NewRows([]string{"key", "version"}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var versions map[string]int
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- p.listen(ctx, &configuration.SubscribeRequest{}, handler, "listen cfgchannel", "cfgchannel", "sub1", &versions, backoff.NewExponentialBackOff())
+		}()
+
+		require.Eventually(t, func() bool {
+			return mock.ExpectationsWereMet() == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-errCh, context.Canceled)
+		assert.False(t, <-conn.released)
+		assert.Equal(t, []string{"listen cfgchannel", "UNLISTEN cfgchannel"}, conn.getExecs())
+	})
+}
+
+func TestDoSubscribeReconnects(t *testing.T) {
+	p, mock := newSubscriptionTestStore(t)
+	handler, events := newEventHandler()
+	conns := []*fakeListenConn{newFakeListenConn(), newFakeListenConn()}
+	attempts := 0
+	p.acquireListenConn = func(ctx context.Context) (listenConn, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return nil, errors.New("database unavailable")
+		case 2:
+			return conns[0], nil
+		default:
+			return conns[1], nil
+		}
+	}
+
+	// After reconnecting, the change made while the connection was down is replayed
+	mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "version"}).AddRow("k1", "1"))
+	mock.ExpectQuery(regexp.QuoteMeta(latestVersionsQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "version"}).AddRow("k1", "2"))
+	mock.ExpectQuery(regexp.QuoteMeta(rowsAfterVersionsQuery)).
+		WithArgs([]string{"k1"}, []int64{1}).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "value", "version", "metadata"}).
+			AddRow("k1", "a2", "2", map[string]string(nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.doSubscribe(ctx, &configuration.SubscribeRequest{}, handler, "listen cfgchannel", "cfgchannel", "sub1")
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(conns[0].getExecs()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	close(conns[0].notifications)
+	assert.True(t, <-conns[0].released)
+
+	items := receiveEvent(t, events)
+	require.Contains(t, items, "k1")
+	assert.Equal(t, "a2", items["k1"].Value)
+	assert.Equal(t, "2", items["k1"].Version)
+
+	cancel()
+	<-done
+	assert.False(t, <-conns[1].released)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -22,6 +22,7 @@ import (
 const (
 	keySpacePrefix = "__keyspace@"
 	separator      = "||"
+	historySuffix  = separator + "history"
 )
 
 func GetRedisValueAndVersion(redisValue string) (string, string) {
@@ -49,3 +50,29 @@ func GetRedisChannelFromKey(key string, redisDB int) string {
 	redisEvent := keySpacePrefix + strconv.Itoa(redisDB) + "__:" + key
 	return redisEvent
 }
+
+// GetRedisHistoryKey returns the name of the hash that stores the previous versions of a key, indexed by version.
+// The name has the same hash tag as the key, so both are in the same slot with Redis Cluster and can be used in the same script.
+// Keys that contain a "}" but no hash tag can't be wrapped in one, so their history is in a different slot with Redis Cluster.
+func GetRedisHistoryKey(key string) string {
+	if hasHashTag(key) || strings.ContainsRune(key, '}') {
+		// The hash tag of the key is also the first one in the name of the history hash
+		return key + historySuffix
+	}
+	return "{" + key + "}" + historySuffix
+}
+
+// hasHashTag returns true if only part of the key, in curly braces, is hashed to determine its slot with Redis Cluster.
+func hasHashTag(key string) bool {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return false
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	return end > 0
+}
+
+// IsRedisHistoryKey returns true if the key is a hash storing the previous versions of another key.
+func IsRedisHistoryKey(key string) bool {
+	return strings.HasSuffix(key, historySuffix)
+}
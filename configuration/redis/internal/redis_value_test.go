@@ -13,7 +13,12 @@ limitations under the License.
 
 package internal
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestGetRedisValueAndVersion(t *testing.T) {
 	type args struct {
@@ -157,3 +162,34 @@ func TestGetRedisChannelFromKey(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRedisHistoryKey(t *testing.T) {
+	// hashedPart returns the part of the key that Redis Cluster hashes to determine its slot
+	hashedPart := func(key string) string {
+		if hasHashTag(key) {
+			start := strings.IndexByte(key, '{')
+			end := strings.IndexByte(key[start+1:], '}')
+			return key[start+1 : start+1+end]
+		}
+		return key
+	}
+
+	tests := map[string]string{
+		"key":     "{key}||history",
+		"a{b}c":   "a{b}c||history",
+		"a{b":     "{a{b}||history",
+		"{}key":   "{}key||history",
+		"a}b":     "a}b||history",
+		"app||db": "{app||db}||history",
+	}
+	for key, want := range tests {
+		t.Run(key, func(t *testing.T) {
+			got := GetRedisHistoryKey(key)
+			assert.Equal(t, want, got)
+			assert.True(t, IsRedisHistoryKey(got))
+			if !strings.ContainsRune(key, '}') || hasHashTag(key) {
+				assert.Equal(t, hashedPart(key), hashedPart(got))
+			}
+		})
+	}
+}
@@ -15,11 +15,13 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -38,8 +40,65 @@ const (
 	defaultBase               = 10
 	defaultBitSize            = 0
 	redisWrongTypeIdentifyStr = "WRONGTYPE"
+
+	subscribeModeAuto          = "auto"
+	subscribeModeNotifications = "notifications"
+	subscribeModePolling       = "polling"
+	notifyKeyspaceEventsKey    = "notify-keyspace-events"
+
+	// Scripts used to write items atomically.
+	// KEYS contains the keys of the items, followed by the keys of their history hashes.
+	// The previous version of each item is saved in its history hash before being overwritten.
+	readVersionsScript = `
+	local n = #KEYS / 2
+	local current = {}
+	for i = 1, n do
+	  local raw = redis.call("GET", KEYS[i])
+	  local value, version = "", ""
+	  if raw then
+	    local sep = string.find(raw, "||", 1, true)
+	    if sep then
+	      value = string.sub(raw, 1, sep - 1)
+	      version = string.sub(raw, sep + 2)
+	    else
+	      value = raw
+	    end
+	  end
+	  if ARGV[argc * i - argc + 1] == "1" then
+	    local expected = ARGV[argc * i - argc + 2]
+	    if (expected == "" and raw) or (expected ~= "" and (not raw or version ~= expected)) then
+	      return redis.error_reply("VERSION_MISMATCH " .. KEYS[i])
+	    end
+	  end
+	  current[i] = {raw = raw, value = value, version = version}
+	  if raw and version ~= "" then
+	    current[i].save = true
+	  end
+	end`
+	// ARGV: for each item, whether to check the version ("1" or "0"), the expected version, and the new value.
+	setItemsScript = "local argc = 3" + readVersionsScript + `
+	for i = 1, n do
+	  if current[i].save then
+	    redis.call("HSET", KEYS[n + i], current[i].version, current[i].value)
+	  end
+	  local next = (tonumber(current[i].version) or 0) + 1
+	  redis.call("SET", KEYS[i], ARGV[3 * i] .. "||" .. next)
+	end
+	return n`
+	// ARGV: for each item, whether to check the version ("1" or "0") and the expected version.
+	deleteItemsScript = "local argc = 2" + readVersionsScript + `
+	for i = 1, n do
+	  if current[i].save then
+	    redis.call("HSET", KEYS[n + i], current[i].version, current[i].value)
+	  end
+	  redis.call("DEL", KEYS[i])
+	end
+	return n`
+	versionMismatchReply = "VERSION_MISMATCH"
 )
 
+var _ configuration.WritableStore = (*ConfigurationStore)(nil)
+
 // ConfigurationStore is a Redis configuration store.
 type ConfigurationStore struct {
 	client         rediscomponent.RedisClient
@@ -76,11 +135,84 @@ func (r *ConfigurationStore) Init(ctx context.Context, metadata configuration.Me
 		return fmt.Errorf("redis store: error connecting to redis at %s: %s", r.clientSettings.Host, err)
 	}
 
+	switch strings.ToLower(r.clientSettings.SubscribeMode) {
+	case "", subscribeModeAuto:
+		r.clientSettings.SubscribeMode = subscribeModeAuto
+	case subscribeModeNotifications, subscribeModePolling:
+		r.clientSettings.SubscribeMode = strings.ToLower(r.clientSettings.SubscribeMode)
+	default:
+		return fmt.Errorf("redis store: invalid subscribeMode %q, must be one of: auto, notifications, polling", r.clientSettings.SubscribeMode)
+	}
+	if r.clientSettings.SubscribePollInterval <= 0 {
+		return fmt.Errorf("redis store: subscribePollInterval must be greater than zero")
+	}
+
 	r.replicas, err = r.getConnectedSlaves(ctx)
 
 	return err
 }
 
+// usePolling returns true if subscriptions must poll for changes because
+// keyspace notifications are not available on the server.
+func (r *ConfigurationStore) usePolling(ctx context.Context) bool {
+	switch r.clientSettings.SubscribeMode {
+	case subscribeModePolling:
+		return true
+	case subscribeModeNotifications:
+		return false
+	}
+
+	// Only read the server's setting: the component must not change the configuration of the whole server.
+	// Managed Redis offerings often disable the CONFIG command altogether, in which case we poll as well.
+	res, err := r.client.DoRead(ctx, "CONFIG", "GET", notifyKeyspaceEventsKey)
+	if err != nil {
+		r.logger.Warnf("redis store: unable to determine whether keyspace notifications are enabled, falling back to polling every %v: %v", time.Duration(r.clientSettings.SubscribePollInterval), err)
+		return true
+	}
+	if !keyspaceNotificationsEnabled(parseConfigGetValue(res, notifyKeyspaceEventsKey)) {
+		r.logger.Warnf("redis store: keyspace notifications are not enabled on the server (set %s to include \"Kg$\" or \"KA\"), falling back to polling every %v", notifyKeyspaceEventsKey, time.Duration(r.clientSettings.SubscribePollInterval))
+		return true
+	}
+
+	return false
+}
+
+// parseConfigGetValue extracts the value of a parameter from a CONFIG GET response,
+// which is a flat list in RESP2 and a map in RESP3.
+func parseConfigGetValue(res interface{}, key string) string {
+	switch v := res.(type) {
+	case []interface{}:
+		for i := 0; i+1 < len(v); i += 2 {
+			if fmt.Sprint(v[i]) == key {
+				return fmt.Sprint(v[i+1])
+			}
+		}
+	case map[interface{}]interface{}:
+		if val, ok := v[key]; ok {
+			return fmt.Sprint(val)
+		}
+	case map[string]interface{}:
+		if val, ok := v[key]; ok {
+			return fmt.Sprint(val)
+		}
+	}
+
+	return ""
+}
+
+// keyspaceNotificationsEnabled returns true if the notify-keyspace-events flags
+// publish keyspace events for both string commands and generic commands (e.g. DEL).
+func keyspaceNotificationsEnabled(flags string) bool {
+	if !strings.ContainsRune(flags, 'K') {
+		return false
+	}
+	if strings.ContainsRune(flags, 'A') {
+		return true
+	}
+
+	return strings.ContainsRune(flags, 'g') && strings.ContainsRune(flags, '$')
+}
+
 func (r *ConfigurationStore) getConnectedSlaves(ctx context.Context) (int, error) {
 	res, err := r.client.DoRead(ctx, "INFO", "replication")
 	if err != nil {
@@ -110,8 +242,12 @@ func (r *ConfigurationStore) parseConnectedSlaves(res string) int {
 }
 
 func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	versionOpts, _, err := configuration.ParseVersionOptions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	keys := req.Keys
-	var err error
 	if len(keys) == 0 {
 		var res interface{}
 		if res, err = r.client.DoRead(ctx, "KEYS", "*"); err != nil {
@@ -120,7 +256,9 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 		}
 		keyList := res.([]interface{})
 		for _, key := range keyList {
-			keys = append(keys, fmt.Sprint(key))
+			if k := fmt.Sprint(key); !internal.IsRedisHistoryKey(k) {
+				keys = append(keys, k)
+			}
 		}
 	}
 
@@ -148,6 +286,21 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 		item.Version = version
 		item.Value = val
 
+		if versionOpts.Version != "" && versionOpts.Version != version {
+			item.Version = versionOpts.Version
+			item.Value, err = r.getHistoricalValue(ctx, redisKey, versionOpts.Version)
+			if err != nil {
+				return &configuration.GetResponse{}, err
+			}
+		}
+		if versionOpts.History > 0 && item.Value != "" {
+			versions, err := r.listVersions(ctx, redisKey, version)
+			if err != nil {
+				return &configuration.GetResponse{}, err
+			}
+			item.Metadata[configuration.VersionsItemMetadataKey] = configuration.LatestVersions(versions, versionOpts.History)
+		}
+
 		if item.Value != "" {
 			items[redisKey] = item
 		}
@@ -158,19 +311,159 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 	}, nil
 }
 
+// getHistoricalValue returns the value of a previous version of a key, or an empty string if that version doesn't exist.
+func (r *ConfigurationStore) getHistoricalValue(ctx context.Context, key string, version string) (string, error) {
+	res, err := r.client.DoRead(ctx, "HGET", internal.GetRedisHistoryKey(key), version)
+	if err != nil {
+		if err.Error() == redis.Nil.Error() {
+			return "", nil
+		}
+		return "", fmt.Errorf("fail to get version %s of configuration for redis key=%s, error is %s", version, key, err)
+	}
+	if res == nil {
+		return "", nil
+	}
+	return fmt.Sprint(res), nil
+}
+
+// listVersions returns the versions stored in the history of a key, as well as its current version.
+func (r *ConfigurationStore) listVersions(ctx context.Context, key string, current string) ([]string, error) {
+	res, err := r.client.DoRead(ctx, "HKEYS", internal.GetRedisHistoryKey(key))
+	if err != nil && err.Error() != redis.Nil.Error() {
+		return nil, fmt.Errorf("fail to list versions of configuration for redis key=%s, error is %s", key, err)
+	}
+
+	versions := []string{}
+	if current != "" {
+		versions = append(versions, current)
+	}
+	fields, _ := res.([]interface{})
+	for _, f := range fields {
+		if v := fmt.Sprint(f); v != current {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// Set creates or updates the items atomically. Versions are numbers incremented on every write.
+// Item metadata is not stored.
+func (r *ConfigurationStore) Set(ctx context.Context, req *configuration.SetRequest) error {
+	if len(req.Items) == 0 {
+		return nil
+	}
+
+	n := len(req.Items)
+	args := make([]interface{}, 0, 3+5*n)
+	args = append(args, "EVAL", setItemsScript, 2*n)
+	for _, item := range req.Items {
+		if item.Key == "" {
+			return errors.New("configuration item key is empty")
+		}
+		args = append(args, item.Key)
+	}
+	for _, item := range req.Items {
+		args = append(args, internal.GetRedisHistoryKey(item.Key))
+	}
+	for _, item := range req.Items {
+		args = append(args, versionCheckArgs(item.ExpectedVersion)...)
+		args = append(args, item.Value)
+	}
+
+	return r.writeItems(ctx, args)
+}
+
+// Delete deletes the items atomically. Their last version is kept in the history.
+func (r *ConfigurationStore) Delete(ctx context.Context, req *configuration.DeleteRequest) error {
+	if len(req.Items) == 0 {
+		return nil
+	}
+
+	n := len(req.Items)
+	args := make([]interface{}, 0, 3+4*n)
+	args = append(args, "EVAL", deleteItemsScript, 2*n)
+	for _, item := range req.Items {
+		if item.Key == "" {
+			return errors.New("configuration item key is empty")
+		}
+		args = append(args, item.Key)
+	}
+	for _, item := range req.Items {
+		args = append(args, internal.GetRedisHistoryKey(item.Key))
+	}
+	for _, item := range req.Items {
+		args = append(args, versionCheckArgs(item.ExpectedVersion)...)
+	}
+
+	return r.writeItems(ctx, args)
+}
+
+func versionCheckArgs(expectedVersion *string) []interface{} {
+	if expectedVersion == nil {
+		return []interface{}{"0", ""}
+	}
+	return []interface{}{"1", *expectedVersion}
+}
+
+func (r *ConfigurationStore) writeItems(ctx context.Context, args []interface{}) error {
+	err := r.client.DoWrite(ctx, args...)
+	if err != nil {
+		if _, key, ok := strings.Cut(err.Error(), versionMismatchReply+" "); ok {
+			return fmt.Errorf("%w: key %s", configuration.ErrVersionMismatch, key)
+		}
+		return fmt.Errorf("fail to write configuration items to redis, error is %s", err)
+	}
+	return nil
+}
+
 func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
+	filter, err := configuration.ParseKeyFilter(req.Metadata)
+	if err != nil {
+		return "", err
+	}
+	if filter != nil && len(req.Keys) > 0 {
+		keys := make([]string, 0, len(req.Keys))
+		for _, k := range req.Keys {
+			if filter.Match(k) {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return "", errors.New("none of the requested keys match the key filter")
+		}
+		req = &configuration.SubscribeRequest{Keys: keys, Metadata: req.Metadata}
+	}
+	handleSubscribedChange := func(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, redisChannel string, id string) {
+		r.handleSubscribedChange(ctx, req, handler, redisChannel, id, filter)
+	}
+
 	subscribeID := uuid.New().String()
 	ctx, cancel := context.WithCancel(ctx)
 	r.cancelMap.Store(subscribeID, cancel)
 
+	if r.usePolling(ctx) {
+		r.wg.Add(1)
+		go func() {
+			r.poll(ctx, req, handler, subscribeID, filter)
+			cancel()
+			r.cancelMap.Delete(subscribeID)
+			r.wg.Done()
+		}()
+		return subscribeID, nil
+	}
+
 	if len(req.Keys) == 0 {
-		// subscribe all keys
-		allKeysChannel := internal.GetRedisChannelFromKey("*", r.clientSettings.DB)
+		// subscribe all keys, only listening to the keys with the filter's prefix if any
+		pattern := "*"
+		if filter != nil {
+			pattern = escapeGlob(filter.Prefix) + "*"
+		}
+		allKeysChannel := internal.GetRedisChannelFromKey(pattern, r.clientSettings.DB)
 		subscribeArgs := &rediscomponent.ConfigurationSubscribeArgs{
-			HandleSubscribedChange: r.handleSubscribedChange,
+			HandleSubscribedChange: handleSubscribedChange,
 			Req:                    req,
 			Handler:                handler,
 			RedisChannel:           allKeysChannel,
@@ -192,7 +485,7 @@ func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.S
 		// subscribe single key
 		redisChannel := internal.GetRedisChannelFromKey(k, r.clientSettings.DB)
 		subscribeArgs := &rediscomponent.ConfigurationSubscribeArgs{
-			HandleSubscribedChange: r.handleSubscribedChange,
+			HandleSubscribedChange: handleSubscribedChange,
 			Req:                    req,
 			Handler:                handler,
 			RedisChannel:           redisChannel,
@@ -224,12 +517,22 @@ func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration
 	return fmt.Errorf("subscription with id %s does not exist", req.ID)
 }
 
-func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, redisChannel string, id string) {
+// escapeGlob escapes the characters that have a special meaning in Redis glob-style patterns.
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, redisChannel string, id string, filter *configuration.KeyFilter) {
 	targetKey, err := internal.ParseRedisKeyFromChannel(redisChannel, r.clientSettings.DB)
 	if err != nil {
 		r.logger.Errorf("parse redis key failed: %s", err)
 		return
 	}
+	if !filter.Match(targetKey) {
+		return
+	}
 
 	var items map[string]*configuration.Item
 
@@ -259,6 +562,75 @@ func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *co
 	}
 }
 
+// poll periodically reads the subscribed keys and notifies the handler of any changes.
+func (r *ConfigurationStore) poll(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, id string, filter *configuration.KeyFilter) {
+	var snapshot map[string]*configuration.Item
+	if res, err := r.Get(ctx, &configuration.GetRequest{Keys: req.Keys, Metadata: req.Metadata}); err != nil {
+		r.logger.Errorf("redis store: failed to read initial configuration for subscription %s: %v", id, err)
+	} else {
+		snapshot = filter.FilterItems(res.Items)
+	}
+
+	ticker := time.NewTicker(time.Duration(r.clientSettings.SubscribePollInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		res, err := r.Get(ctx, &configuration.GetRequest{Keys: req.Keys, Metadata: req.Metadata})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("redis store: failed to poll configuration for subscription %s: %v", id, err)
+			continue
+		}
+
+		current := filter.FilterItems(res.Items)
+		if snapshot == nil {
+			snapshot = current
+			continue
+		}
+
+		changed := diffItems(snapshot, current)
+		snapshot = current
+		if len(changed) == 0 {
+			continue
+		}
+
+		err = handler(ctx, &configuration.UpdateEvent{
+			Items: changed,
+			ID:    id,
+		})
+		if err != nil {
+			r.logger.Errorf("fail to call handler to notify event for configuration update subscribe: %s", err)
+		}
+	}
+}
+
+// diffItems returns the items that were added, updated or removed between two reads.
+// Removed items are reported as empty items, like keyspace notifications for deleted keys.
+func diffItems(prev, cur map[string]*configuration.Item) map[string]*configuration.Item {
+	changed := make(map[string]*configuration.Item)
+	for k, item := range cur {
+		old, ok := prev[k]
+		if !ok || old.Value != item.Value || old.Version != item.Version {
+			changed[k] = item
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			changed[k] = &configuration.Item{}
+		}
+	}
+
+	return changed
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := rediscomponent.Settings{}
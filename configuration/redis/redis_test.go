@@ -15,6 +15,9 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +31,7 @@ import (
 
 	"github.com/dapr/components-contrib/configuration"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 func TestConfigurationStore_Get(t *testing.T) {
@@ -322,6 +326,350 @@ func Test_parseRedisMetadata(t *testing.T) {
 	}
 }
 
+func TestKeyspaceNotificationsEnabled(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"Ex":    false,
+		"K$":    false,
+		"Kg":    false,
+		"Kg$xe": true,
+		"KA":    true,
+		"AKE":   true,
+		"Eg$":   false,
+	}
+	for flags, want := range tests {
+		t.Run(flags, func(t *testing.T) {
+			assert.Equal(t, want, keyspaceNotificationsEnabled(flags))
+		})
+	}
+}
+
+func TestParseConfigGetValue(t *testing.T) {
+	t.Run("RESP2 list", func(t *testing.T) {
+		res := []interface{}{"notify-keyspace-events", "KA"}
+		assert.Equal(t, "KA", parseConfigGetValue(res, notifyKeyspaceEventsKey))
+	})
+
+	t.Run("RESP3 map", func(t *testing.T) {
+		res := map[interface{}]interface{}{"notify-keyspace-events": "Kg$"}
+		assert.Equal(t, "Kg$", parseConfigGetValue(res, notifyKeyspaceEventsKey))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		assert.Equal(t, "", parseConfigGetValue([]interface{}{}, notifyKeyspaceEventsKey))
+	})
+}
+
+// configClient is a Redis client that answers CONFIG GET with a fixed value and records the CONFIG commands it receives.
+type configClient struct {
+	redisComponent.RedisClient
+	flags    string
+	err      error
+	commands []string
+}
+
+func (c *configClient) DoRead(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if args[0] == "CONFIG" {
+		c.commands = append(c.commands, strings.TrimSpace(fmt.Sprintln(args...)))
+		if c.err != nil {
+			return nil, c.err
+		}
+		return []interface{}{notifyKeyspaceEventsKey, c.flags}, nil
+	}
+	return c.RedisClient.DoRead(ctx, args...)
+}
+
+func (c *configClient) DoWrite(ctx context.Context, args ...interface{}) error {
+	if args[0] == "CONFIG" {
+		c.commands = append(c.commands, strings.TrimSpace(fmt.Sprintln(args...)))
+		return nil
+	}
+	return c.RedisClient.DoWrite(ctx, args...)
+}
+
+func TestUsePolling(t *testing.T) {
+	for name, tc := range map[string]struct {
+		flags string
+		err   error
+		want  bool
+	}{
+		"notifications enabled":  {flags: "KA", want: false},
+		"notifications disabled": {flags: "", want: true},
+		"missing flags":          {flags: "Kx", want: true},
+		"CONFIG denied":          {err: errors.New("NOPERM"), want: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, c := setupMiniredis()
+			defer s.Close()
+			client := &configClient{RedisClient: c, flags: tc.flags, err: tc.err}
+			store := &ConfigurationStore{
+				client: client,
+				clientSettings: &redisComponent.Settings{
+					SubscribeMode: subscribeModeAuto,
+				},
+				logger: logger.NewLogger("test"),
+			}
+
+			assert.Equal(t, tc.want, store.usePolling(context.Background()))
+
+			// The server configuration is only read, never changed
+			assert.Equal(t, []string{"CONFIG GET " + notifyKeyspaceEventsKey}, client.commands)
+		})
+	}
+}
+
+func TestDiffItems(t *testing.T) {
+	prev := map[string]*configuration.Item{
+		"same":    {Value: "a", Version: "1"},
+		"updated": {Value: "b", Version: "1"},
+		"deleted": {Value: "c", Version: "1"},
+	}
+	cur := map[string]*configuration.Item{
+		"same":    {Value: "a", Version: "1"},
+		"updated": {Value: "b2", Version: "2"},
+		"added":   {Value: "d", Version: "1"},
+	}
+
+	changed := diffItems(prev, cur)
+	assert.Len(t, changed, 3)
+	assert.Equal(t, "b2", changed["updated"].Value)
+	assert.Equal(t, "d", changed["added"].Value)
+	assert.Equal(t, &configuration.Item{}, changed["deleted"])
+	assert.NotContains(t, changed, "same")
+}
+
+func TestSubscribeFallsBackToPolling(t *testing.T) {
+	// miniredis doesn't support CONFIG, so "auto" mode must fall back to polling
+	s, c := setupMiniredis()
+	defer s.Close()
+	require.NoError(t, s.Set("testKey", "testValue"))
+
+	store := &ConfigurationStore{
+		client: c,
+		clientSettings: &redisComponent.Settings{
+			SubscribeMode:         subscribeModeAuto,
+			SubscribePollInterval: redisComponent.Duration(10 * time.Millisecond),
+		},
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := store.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Keys: []string{"testKey"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Wait for the initial snapshot before changing the value
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, s.Set("testKey", "newValue"))
+
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		assert.Equal(t, "newValue", e.Items["testKey"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for update event")
+	}
+
+	s.Del("testKey")
+	select {
+	case e := <-events:
+		assert.Equal(t, &configuration.Item{}, e.Items["testKey"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delete event")
+	}
+
+	require.NoError(t, store.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	store.wg.Wait()
+}
+
+func TestConfigurationStore_GetVersions(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+	require.NoError(t, s.Set("testKey", "value3||3"))
+	s.HSet("{testKey}||history", "1", "value1", "2", "value2")
+
+	store := &ConfigurationStore{
+		client:         c,
+		clientSettings: &redisComponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	t.Run("latest version", func(t *testing.T) {
+		res, err := store.Get(context.Background(), &configuration.GetRequest{Keys: []string{"testKey"}})
+		require.NoError(t, err)
+		assert.Equal(t, "value3", res.Items["testKey"].Value)
+		assert.Equal(t, "3", res.Items["testKey"].Version)
+	})
+
+	t.Run("historical version", func(t *testing.T) {
+		res, err := store.Get(context.Background(), &configuration.GetRequest{
+			Keys:     []string{"testKey"},
+			Metadata: map[string]string{configuration.VersionMetadataKey: "2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "value2", res.Items["testKey"].Value)
+		assert.Equal(t, "2", res.Items["testKey"].Version)
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		res, err := store.Get(context.Background(), &configuration.GetRequest{
+			Keys:     []string{"testKey"},
+			Metadata: map[string]string{configuration.VersionMetadataKey: "9"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, res.Items)
+	})
+
+	t.Run("list versions", func(t *testing.T) {
+		res, err := store.Get(context.Background(), &configuration.GetRequest{
+			Keys:     []string{"testKey"},
+			Metadata: map[string]string{configuration.HistoryMetadataKey: "2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "3,2", res.Items["testKey"].Metadata[configuration.VersionsItemMetadataKey])
+	})
+
+	t.Run("history keys are not returned", func(t *testing.T) {
+		res, err := store.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		assert.Len(t, res.Items, 1)
+		assert.Contains(t, res.Items, "testKey")
+	})
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, "app/", escapeGlob("app/"))
+	assert.Equal(t, `a\*b\?c\[d\]\\`, escapeGlob(`a*b?c[d]\`))
+}
+
+func TestSubscribePollingWithKeyFilter(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+	require.NoError(t, s.Set("app/a", "1"))
+	require.NoError(t, s.Set("other", "1"))
+
+	store := &ConfigurationStore{
+		client: c,
+		clientSettings: &redisComponent.Settings{
+			SubscribeMode:         subscribeModePolling,
+			SubscribePollInterval: redisComponent.Duration(10 * time.Millisecond),
+		},
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := store.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Metadata: map[string]string{configuration.KeyPrefixMetadataKey: "app/"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Wait for the initial snapshot before changing the values
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, s.Set("other", "2"))
+	require.NoError(t, s.Set("app/a", "2"))
+
+	select {
+	case e := <-events:
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "2", e.Items["app/a"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for update event")
+	}
+
+	require.NoError(t, store.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	store.wg.Wait()
+}
+
+func TestConfigurationStore_SetAndDelete(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	store := &ConfigurationStore{
+		client:         c,
+		clientSettings: &redisComponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+	ctx := context.Background()
+
+	t.Run("create items", func(t *testing.T) {
+		err := store.Set(ctx, &configuration.SetRequest{Items: []*configuration.SetItem{
+			{Key: "key1", Value: "a", ExpectedVersion: ptr.Of("")},
+			{Key: "key2", Value: "b"},
+		}})
+		require.NoError(t, err)
+
+		v, err := s.Get("key1")
+		require.NoError(t, err)
+		assert.Equal(t, "a||1", v)
+		v, err = s.Get("key2")
+		require.NoError(t, err)
+		assert.Equal(t, "b||1", v)
+	})
+
+	t.Run("update items and keep history", func(t *testing.T) {
+		err := store.Set(ctx, &configuration.SetRequest{Items: []*configuration.SetItem{
+			{Key: "key1", Value: "a2", ExpectedVersion: ptr.Of("1")},
+		}})
+		require.NoError(t, err)
+
+		res, err := store.Get(ctx, &configuration.GetRequest{Keys: []string{"key1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "a2", res.Items["key1"].Value)
+		assert.Equal(t, "2", res.Items["key1"].Version)
+
+		res, err = store.Get(ctx, &configuration.GetRequest{
+			Keys:     []string{"key1"},
+			Metadata: map[string]string{configuration.VersionMetadataKey: "1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "a", res.Items["key1"].Value)
+	})
+
+	t.Run("version mismatch writes nothing", func(t *testing.T) {
+		err := store.Set(ctx, &configuration.SetRequest{Items: []*configuration.SetItem{
+			{Key: "key2", Value: "b2"},
+			{Key: "key1", Value: "a3", ExpectedVersion: ptr.Of("1")},
+		}})
+		require.ErrorIs(t, err, configuration.ErrVersionMismatch)
+
+		v, err := s.Get("key2")
+		require.NoError(t, err)
+		assert.Equal(t, "b||1", v)
+
+		err = store.Set(ctx, &configuration.SetRequest{Items: []*configuration.SetItem{
+			{Key: "key1", Value: "a3", ExpectedVersion: ptr.Of("")},
+		}})
+		require.ErrorIs(t, err, configuration.ErrVersionMismatch)
+	})
+
+	t.Run("delete items", func(t *testing.T) {
+		err := store.Delete(ctx, &configuration.DeleteRequest{Items: []*configuration.DeleteItem{
+			{Key: "key1", ExpectedVersion: ptr.Of("1")},
+		}})
+		require.ErrorIs(t, err, configuration.ErrVersionMismatch)
+
+		err = store.Delete(ctx, &configuration.DeleteRequest{Items: []*configuration.DeleteItem{
+			{Key: "key1", ExpectedVersion: ptr.Of("2")},
+			{Key: "key2"},
+		}})
+		require.NoError(t, err)
+		assert.False(t, s.Exists("key1"))
+		assert.False(t, s.Exists("key2"))
+		assert.Equal(t, "a2", s.HGet("{key1}||history", "2"))
+	})
+}
+
 func setupMiniredis() (*miniredis.Miniredis, redisComponent.RedisClient) {
 	ctx := context.Background()
 	log := logger.NewLogger("dapr.components")
@@ -32,6 +32,34 @@ type SubscribeRequest struct {
 	Metadata map[string]string `json:"metadata"`
 }
 
+// SetRequest is the object describing a request to create or update configuration items.
+type SetRequest struct {
+	Items    []*SetItem        `json:"items"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// SetItem is a configuration item to create or update.
+type SetItem struct {
+	Key      string            `json:"key"`
+	Value    string            `json:"value"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// If set, the item is only written if its current version matches. An empty string means that the item must not exist.
+	ExpectedVersion *string `json:"expectedVersion,omitempty"`
+}
+
+// DeleteRequest is the object describing a request to delete configuration items.
+type DeleteRequest struct {
+	Items    []*DeleteItem     `json:"items"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// DeleteItem is a configuration item to delete.
+type DeleteItem struct {
+	Key string `json:"key"`
+	// If set, the item is only deleted if its current version matches.
+	ExpectedVersion *string `json:"expectedVersion,omitempty"`
+}
+
 // UnsubscribeRequest is the object describing a request to unsubscribe configuration.
 type UnsubscribeRequest struct {
 	ID string `json:"id"`
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	ccreds "golang.org/x/oauth2/clientcredentials"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+// environment is the response of the Spring Cloud Config Server's "/{application}/{profile}/{label}" endpoint.
+type environment struct {
+	Name            string           `json:"name"`
+	Profiles        []string         `json:"profiles"`
+	Label           string           `json:"label"`
+	Version         string           `json:"version"`
+	PropertySources []propertySource `json:"propertySources"`
+}
+
+type propertySource struct {
+	Name   string         `json:"name"`
+	Source map[string]any `json:"source"`
+}
+
+// ConfigurationStore is a configuration store that reads from a Spring Cloud Config Server.
+type ConfigurationStore struct {
+	client   *http.Client
+	metadata metadata
+
+	subscriptions configuration.Subscriptions
+
+	logger logger.Logger
+}
+
+// NewSpringCloudConfigStore returns a new Spring Cloud Config Server configuration store.
+func NewSpringCloudConfigStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(ctx context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	r.client = &http.Client{
+		Timeout: r.metadata.RequestTimeout,
+	}
+	if r.metadata.OAuth2TokenURL != "" {
+		conf := &ccreds.Config{
+			ClientID:     r.metadata.OAuth2ClientID,
+			ClientSecret: r.metadata.OAuth2ClientSecret,
+			TokenURL:     r.metadata.OAuth2TokenURL,
+			Scopes:       r.metadata.OAuth2Scopes,
+		}
+		if len(r.metadata.OAuth2Audiences) > 0 {
+			conf.EndpointParams = url.Values{"audience": r.metadata.OAuth2Audiences}
+		}
+		// The token source is refreshed automatically, so it must not be bound to the Init context
+		r.client = conf.Client(context.Background())
+		r.client.Timeout = r.metadata.RequestTimeout
+	}
+
+	return nil
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	items, err := r.getAll(ctx, req.Metadata)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	return &configuration.GetResponse{
+		Items: configuration.FilterItems(items, req.Keys),
+	}, nil
+}
+
+// getAll returns all properties for the application, merging the property sources by their precedence.
+func (r *ConfigurationStore) getAll(ctx context.Context, reqMetadata map[string]string) (map[string]*configuration.Item, error) {
+	env, err := r.fetchEnvironment(ctx, reqMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Property sources are sorted from the highest precedence to the lowest, so iterate in reverse
+	items := map[string]*configuration.Item{}
+	for i := len(env.PropertySources) - 1; i >= 0; i-- {
+		ps := env.PropertySources[i]
+		for key, val := range ps.Source {
+			items[key] = &configuration.Item{
+				Value:   formatValue(val),
+				Version: env.Version,
+				Metadata: map[string]string{
+					"propertySource": ps.Name,
+					"label":          env.Label,
+				},
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ConfigurationStore) fetchEnvironment(ctx context.Context, reqMetadata map[string]string) (*environment, error) {
+	type requestMetadata = struct {
+		Profile string `mapstructure:"profile"`
+		Label   string `mapstructure:"label"`
+	}
+	opts := requestMetadata{
+		Profile: r.metadata.Profile,
+		Label:   r.metadata.Label,
+	}
+	err := kitmd.DecodeMetadata(reqMetadata, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request metadata: %w", err)
+	}
+
+	u := r.metadata.URI + "/" + url.PathEscape(r.metadata.Application) + "/" + url.PathEscape(opts.Profile)
+	if opts.Label != "" {
+		// Spring Cloud Config uses "(_)" in place of slashes in labels
+		u += "/" + url.PathEscape(strings.ReplaceAll(opts.Label, "/", "(_)"))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if r.metadata.Username != "" {
+		httpReq.SetBasicAuth(r.metadata.Username, r.metadata.Password)
+	}
+
+	res, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration from Spring Cloud Config Server: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		return nil, fmt.Errorf("failed to get configuration from Spring Cloud Config Server: status code %d: %s", res.StatusCode, string(body))
+	}
+
+	env := &environment{}
+	err = json.NewDecoder(res.Body).Decode(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response from Spring Cloud Config Server: %w", err)
+	}
+
+	return env, nil
+}
+
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	items, err := r.getAll(ctx, req.Metadata)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := configuration.FilterItems(items, req.Keys)
+	return r.subscriptions.Start(ctx, func(ctx context.Context, id string) {
+		r.doSubscribe(ctx, req, handler, snapshot, id)
+	})
+}
+
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, snapshot map[string]*configuration.Item, id string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.metadata.PollInterval):
+		}
+
+		items, err := r.getAll(ctx, req.Metadata)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("Failed to get configuration changes: %s", err)
+			continue
+		}
+
+		// Versions are not compared, since the version of all items changes with every commit to the backend
+		current := configuration.FilterItems(items, req.Keys)
+		changed := configuration.DiffItems(snapshot, current, false)
+		snapshot = current
+		if len(changed) > 0 {
+			r.handleSubscribedChange(ctx, handler, changed, id)
+		}
+	}
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	return r.subscriptions.Stop(req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	r.subscriptions.Close()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
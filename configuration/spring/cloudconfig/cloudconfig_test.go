@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const testEnvironment = `{
+	"name": "myapp",
+	"profiles": ["dev"],
+	"label": "main",
+	"version": "abc123",
+	"propertySources": [
+		{"name": "myapp-dev.yml", "source": {"server.port": 8081, "feature.enabled": true}},
+		{"name": "myapp.yml", "source": {"server.port": 8080, "greeting": "hello"}}
+	]
+}`
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"uri":         "http://localhost:8888/",
+			"application": "myapp",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:8888", m.URI)
+		assert.Equal(t, defaultProfile, m.Profile)
+		assert.Equal(t, defaultPollInterval, m.PollInterval)
+	})
+
+	t.Run("invalid uri", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"uri":         "localhost:8888",
+			"application": "myapp",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("basic auth and oauth2 are mutually exclusive", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"uri":            "http://localhost:8888",
+			"application":    "myapp",
+			"username":       "user",
+			"oauth2TokenURL": "http://localhost/token",
+			"oauth2ClientID": "client",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	var lastPath atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath.Store(r.URL.EscapedPath())
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testEnvironment))
+	}))
+	defer server.Close()
+
+	s := NewSpringCloudConfigStore(logger.NewLogger("test"))
+	err := s.Init(context.Background(), configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"uri":         server.URL,
+		"application": "myapp",
+		"profile":     "dev",
+		"username":    "user",
+		"password":    "pass",
+	}}})
+	require.NoError(t, err)
+
+	t.Run("property sources are merged by precedence", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 3)
+		assert.Equal(t, "8081", res.Items["server.port"].Value)
+		assert.Equal(t, "myapp-dev.yml", res.Items["server.port"].Metadata["propertySource"])
+		assert.Equal(t, "true", res.Items["feature.enabled"].Value)
+		assert.Equal(t, "hello", res.Items["greeting"].Value)
+		assert.Equal(t, "abc123", res.Items["greeting"].Version)
+		assert.Equal(t, "/myapp/dev", lastPath.Load())
+	})
+
+	t.Run("filter keys and override label", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{
+			Keys: []string{"greeting", "notfound"},
+			Metadata: map[string]string{
+				"label": "feature/x",
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "hello", res.Items["greeting"].Value)
+		assert.Equal(t, "/myapp/dev/feature%28_%29x", lastPath.Load())
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if version.Load() == 0 {
+			w.Write([]byte(`{"version": "1", "propertySources": [{"name": "a", "source": {"key1": "v1", "key2": "v1"}}]}`))
+		} else {
+			w.Write([]byte(`{"version": "2", "propertySources": [{"name": "a", "source": {"key1": "v2", "key2": "v1"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	s := NewSpringCloudConfigStore(logger.NewLogger("test"))
+	err := s.Init(context.Background(), configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"uri":          server.URL,
+		"application":  "myapp",
+		"pollInterval": "10ms",
+	}}})
+	require.NoError(t, err)
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	version.Store(1)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "v2", e.Items["key1"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive update event")
+	}
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "", formatValue(nil))
+	assert.Equal(t, "str", formatValue("str"))
+	assert.Equal(t, "1.5", formatValue(1.5))
+	assert.Equal(t, "false", formatValue(false))
+	assert.Equal(t, `["a","b"]`, formatValue([]any{"a", "b"}))
+}
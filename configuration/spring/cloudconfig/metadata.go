@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudconfig
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultProfile        = "default"
+	defaultPollInterval   = 30 * time.Second
+	defaultRequestTimeout = 15 * time.Second
+)
+
+type metadata struct {
+	// Base URI of the Spring Cloud Config Server.
+	URI string `mapstructure:"uri"`
+	// Name of the application.
+	Application string `mapstructure:"application"`
+	// Comma-separated list of profiles. Defaults to "default".
+	Profile string `mapstructure:"profile"`
+	// Label (e.g. a git branch or tag). If empty, the server's default label is used.
+	Label string `mapstructure:"label"`
+	// Basic authentication.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// OAuth2 client credentials.
+	OAuth2TokenURL     string   `mapstructure:"oauth2TokenURL"`
+	OAuth2ClientID     string   `mapstructure:"oauth2ClientID"`
+	OAuth2ClientSecret string   `mapstructure:"oauth2ClientSecret"`
+	OAuth2Scopes       []string `mapstructure:"oauth2Scopes"`
+	OAuth2Audiences    []string `mapstructure:"oauth2Audiences"`
+	// Interval between polls of the server when subscribing.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// Timeout for requests to the server.
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.Profile = defaultProfile
+	m.PollInterval = defaultPollInterval
+	m.RequestTimeout = defaultRequestTimeout
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.URI == "" {
+		return errors.New("spring cloud config error: missing required metadata property 'uri'")
+	}
+	u, err := url.Parse(m.URI)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("spring cloud config error: metadata property 'uri' must be a valid http or https URL")
+	}
+	m.URI = strings.TrimSuffix(m.URI, "/")
+
+	if m.Application == "" {
+		return errors.New("spring cloud config error: missing required metadata property 'application'")
+	}
+	if m.Profile == "" {
+		m.Profile = defaultProfile
+	}
+
+	if m.Username != "" && m.OAuth2TokenURL != "" {
+		return errors.New("spring cloud config error: basic authentication and OAuth2 are mutually exclusive")
+	}
+	if m.OAuth2TokenURL != "" && m.OAuth2ClientID == "" {
+		return errors.New("spring cloud config error: metadata property 'oauth2ClientID' is required when using OAuth2")
+	}
+
+	if m.PollInterval <= 0 {
+		return errors.New("spring cloud config error: 'pollInterval' must be greater than 0")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
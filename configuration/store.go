@@ -15,6 +15,7 @@ package configuration
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"github.com/dapr/components-contrib/metadata"
@@ -39,5 +40,18 @@ type Store interface {
 	io.Closer
 }
 
+// WritableStore is an optional interface for configuration stores that allow writing configuration items.
+// All items in a request are written atomically: if the version check fails for any of them, none is written.
+type WritableStore interface {
+	// Set creates or updates configuration items.
+	Set(ctx context.Context, req *SetRequest) error
+
+	// Delete deletes configuration items.
+	Delete(ctx context.Context, req *DeleteRequest) error
+}
+
+// ErrVersionMismatch is returned by WritableStore when the current version of an item doesn't match the expected one.
+var ErrVersionMismatch = errors.New("configuration item version mismatch")
+
 // UpdateHandler is the handler used to send event to daprd.
 type UpdateHandler func(ctx context.Context, e *UpdateEvent) error
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrStoreClosed is returned when subscribing to a store that has been closed.
+var ErrStoreClosed = errors.New("store is closed")
+
+// Subscriptions manages the subscriptions of a store, each running in its own goroutine until it's unsubscribed or the store is closed.
+// The zero value is ready to use.
+type Subscriptions struct {
+	lock    sync.Mutex
+	cancels map[string]context.CancelFunc
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// Start generates an ID for a new subscription, and runs fn in a new goroutine with a context that's canceled when the subscription is stopped.
+// Stores that need an initial snapshot of the items should take it before calling Start, so changes made right after subscribing are not lost.
+func (s *Subscriptions) Start(ctx context.Context, fn func(ctx context.Context, id string)) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return "", ErrStoreClosed
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate uuid, error is %w", err)
+	}
+	id := uuid.String()
+
+	childCtx, cancel := context.WithCancel(ctx)
+	if s.cancels == nil {
+		s.cancels = make(map[string]context.CancelFunc)
+	}
+	s.cancels[id] = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.remove(id)
+		fn(childCtx, id)
+	}()
+	return id, nil
+}
+
+// Stop cancels the context of the subscription with the given ID.
+func (s *Subscriptions) Stop(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	cancel, ok := s.cancels[id]
+	if !ok {
+		return fmt.Errorf("subscription with id %s does not exist", id)
+	}
+	delete(s.cancels, id)
+	cancel()
+	return nil
+}
+
+// Close stops all the subscriptions, and waits for their goroutines to return.
+// Starting new subscriptions fails after calling Close.
+func (s *Subscriptions) Close() {
+	s.lock.Lock()
+	s.closed = true
+	for id, cancel := range s.cancels {
+		cancel()
+		delete(s.cancels, id)
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Subscriptions) remove(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+		delete(s.cancels, id)
+	}
+}
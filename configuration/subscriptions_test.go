@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	t.Run("stop", func(t *testing.T) {
+		var s Subscriptions
+		stopped := make(chan string, 1)
+		id, err := s.Start(context.Background(), func(ctx context.Context, id string) {
+			<-ctx.Done()
+			stopped <- id
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, id)
+
+		require.NoError(t, s.Stop(id))
+		select {
+		case stoppedID := <-stopped:
+			assert.Equal(t, id, stoppedID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscription not stopped")
+		}
+		require.ErrorContains(t, s.Stop(id), "does not exist")
+	})
+
+	t.Run("subscription returning on its own is removed", func(t *testing.T) {
+		var s Subscriptions
+		done := make(chan struct{})
+		id, err := s.Start(context.Background(), func(ctx context.Context, id string) {
+			close(done)
+		})
+		require.NoError(t, err)
+		<-done
+
+		assert.Eventually(t, func() bool {
+			return s.Stop(id) != nil
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("close stops all subscriptions and waits for them", func(t *testing.T) {
+		var s Subscriptions
+		var stopped [2]bool
+		for i := range stopped {
+			_, err := s.Start(context.Background(), func(ctx context.Context, id string) {
+				<-ctx.Done()
+				stopped[i] = true
+			})
+			require.NoError(t, err)
+		}
+
+		s.Close()
+		assert.Equal(t, [2]bool{true, true}, stopped)
+
+		_, err := s.Start(context.Background(), func(ctx context.Context, id string) {
+			t.Error("subscription started after close")
+		})
+		require.ErrorIs(t, err, ErrStoreClosed)
+	})
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// VersionMetadataKey is the request metadata key used to read a specific version of the requested items,
+	// in stores that keep the history of configuration items.
+	VersionMetadataKey = "version"
+	// HistoryMetadataKey is the request metadata key used to list the most recent versions of the requested items,
+	// in stores that keep the history of configuration items. Its value is the maximum number of versions to list.
+	HistoryMetadataKey = "versionHistory"
+	// VersionsItemMetadataKey is the item metadata key containing the comma-separated list of versions of an item,
+	// most recent first, when the history is requested with HistoryMetadataKey.
+	VersionsItemMetadataKey = "versions"
+)
+
+// VersionOptions contains the options to read historical versions of configuration items.
+type VersionOptions struct {
+	// Version of the items to return. If empty, the latest version is returned.
+	Version string
+	// Maximum number of versions to list for each item. If 0, versions are not listed.
+	History int
+}
+
+// ParseVersionOptions extracts the version options from the request metadata.
+// It returns the options and a copy of the metadata without the keys it consumed.
+func ParseVersionOptions(md map[string]string) (opts VersionOptions, rest map[string]string, err error) {
+	rest = make(map[string]string, len(md))
+	for k, v := range md {
+		switch {
+		case strings.EqualFold(k, VersionMetadataKey):
+			opts.Version = v
+		case strings.EqualFold(k, HistoryMetadataKey):
+			if v == "" {
+				continue
+			}
+			opts.History, err = strconv.Atoi(v)
+			if err != nil || opts.History < 0 {
+				return VersionOptions{}, nil, fmt.Errorf("invalid value for metadata key '%s': must be a non-negative integer", HistoryMetadataKey)
+			}
+		default:
+			rest[k] = v
+		}
+	}
+	return opts, rest, nil
+}
+
+// SortVersions sorts versions from the most recent to the oldest, in place.
+// Numeric versions are compared as numbers and sorted before non-numeric ones, which are compared as strings.
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := strconv.ParseInt(versions[i], 10, 64)
+		b, errB := strconv.ParseInt(versions[j], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			return a > b
+		case errA == nil:
+			return true
+		case errB == nil:
+			return false
+		default:
+			return versions[i] > versions[j]
+		}
+	})
+}
+
+// LatestVersions returns the n most recent versions, most recent first, as a comma-separated list.
+func LatestVersions(versions []string, n int) string {
+	sorted := make([]string, len(versions))
+	copy(sorted, versions)
+	SortVersions(sorted)
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return strings.Join(sorted, ",")
+}
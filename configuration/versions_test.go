@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionOptions(t *testing.T) {
+	t.Run("no options", func(t *testing.T) {
+		opts, rest, err := ParseVersionOptions(map[string]string{"foo": "bar"})
+		require.NoError(t, err)
+		assert.Equal(t, VersionOptions{}, opts)
+		assert.Equal(t, map[string]string{"foo": "bar"}, rest)
+	})
+
+	t.Run("version and history", func(t *testing.T) {
+		opts, rest, err := ParseVersionOptions(map[string]string{
+			"Version":        "3",
+			"versionHistory": "5",
+			"foo":            "bar",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, VersionOptions{Version: "3", History: 5}, opts)
+		assert.Equal(t, map[string]string{"foo": "bar"}, rest)
+	})
+
+	t.Run("invalid history", func(t *testing.T) {
+		_, _, err := ParseVersionOptions(map[string]string{"versionHistory": "-1"})
+		require.Error(t, err)
+		_, _, err = ParseVersionOptions(map[string]string{"versionHistory": "abc"})
+		require.Error(t, err)
+	})
+}
+
+func TestLatestVersions(t *testing.T) {
+	versions := []string{"2", "10", "beta", "1", "alpha"}
+	assert.Equal(t, "10,2,1,beta,alpha", LatestVersions(versions, 10))
+	assert.Equal(t, "10,2", LatestVersions(versions, 2))
+	// The input must not be modified
+	assert.Equal(t, []string{"2", "10", "beta", "1", "alpha"}, versions)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultSessionTimeout = 10 * time.Second
+	defaultRetryDelay     = 5 * time.Second
+	defaultChroot         = "/"
+)
+
+type metadata struct {
+	// Comma-separated list of Zookeeper servers, as "host:port".
+	Servers string `mapstructure:"servers"`
+	// Session timeout. Watches are set again automatically after the session expires.
+	SessionTimeout time.Duration `mapstructure:"sessionTimeout"`
+	// Path of the znode containing the configuration items. Keys are relative to this path.
+	Chroot string `mapstructure:"chroot"`
+	// Credentials for digest authentication.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Delay before setting the watches again after an error.
+	RetryDelay time.Duration `mapstructure:"retryDelay"`
+
+	servers []string
+}
+
+func (m *metadata) Parse(meta configuration.Metadata) error {
+	// Set defaults
+	m.SessionTimeout = defaultSessionTimeout
+	m.RetryDelay = defaultRetryDelay
+	m.Chroot = defaultChroot
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	for _, s := range strings.Split(m.Servers, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			m.servers = append(m.servers, s)
+		}
+	}
+	if len(m.servers) == 0 {
+		return errors.New("zookeeper configuration error: missing required metadata property 'servers'")
+	}
+	if m.SessionTimeout <= 0 {
+		return errors.New("zookeeper configuration error: sessionTimeout must be greater than 0")
+	}
+	if m.RetryDelay <= 0 {
+		m.RetryDelay = defaultRetryDelay
+	}
+	if (m.Username == "") != (m.Password == "") {
+		return errors.New("zookeeper configuration error: username and password must be set together")
+	}
+
+	// Normalize the chroot to an absolute path without a trailing "/"
+	m.Chroot = path.Clean("/" + m.Chroot)
+
+	return nil
+}
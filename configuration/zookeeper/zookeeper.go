@@ -0,0 +1,299 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+type zkConn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Close()
+}
+
+// ConfigurationStore is a Zookeeper configuration store.
+// Each child znode of the chroot is a configuration item, whose value is the data of the znode.
+type ConfigurationStore struct {
+	conn     zkConn
+	metadata metadata
+
+	subscriptions configuration.Subscriptions
+
+	logger logger.Logger
+}
+
+// NewZookeeperConfigurationStore returns a new Zookeeper configuration store.
+func NewZookeeperConfigurationStore(logger logger.Logger) configuration.Store {
+	s := &ConfigurationStore{
+		logger: logger,
+	}
+
+	return s
+}
+
+// Init does metadata and connection parsing.
+func (r *ConfigurationStore) Init(_ context.Context, md configuration.Metadata) error {
+	r.metadata = metadata{}
+	err := r.metadata.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := zk.Connect(r.metadata.servers, r.metadata.SessionTimeout, zk.WithLogInfo(false))
+	if err != nil {
+		return fmt.Errorf("failed to connect to zookeeper: %w", err)
+	}
+	if r.metadata.Username != "" {
+		err = conn.AddAuth("digest", []byte(r.metadata.Username+":"+r.metadata.Password))
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to authenticate with zookeeper: %w", err)
+		}
+	}
+	r.conn = conn
+
+	return nil
+}
+
+func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	items, err := r.read(req.Keys, nil)
+	if err != nil {
+		return &configuration.GetResponse{}, err
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+// read returns the items for the keys, or for every child of the chroot if keys is empty.
+// If watches is not nil, read also sets watches that fire when any of the items is created, changed, or deleted,
+// and adds them to the map. Watches that are already in the map haven't fired yet, so they're not set again.
+func (r *ConfigurationStore) read(keys []string, watches map[string]<-chan zk.Event) (map[string]*configuration.Item, error) {
+	explicitKeys := len(keys) > 0
+	if !explicitKeys {
+		var (
+			children []string
+			err      error
+		)
+		watchKey := "children:" + r.metadata.Chroot
+		if _, pending := watches[watchKey]; watches != nil && !pending {
+			var ch <-chan zk.Event
+			children, _, ch, err = r.conn.ChildrenW(r.metadata.Chroot)
+			if err == nil {
+				watches[watchKey] = ch
+			}
+		} else {
+			children, _, err = r.conn.Children(r.metadata.Chroot)
+		}
+		switch {
+		case errors.Is(err, zk.ErrNoNode):
+			// Wait for the chroot to be created
+			err = r.watchExists(r.metadata.Chroot, watches)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]*configuration.Item{}, nil
+		case err != nil:
+			return nil, fmt.Errorf("failed to list children of znode '%s': %w", r.metadata.Chroot, err)
+		}
+		keys = children
+	}
+
+	items := make(map[string]*configuration.Item, len(keys))
+	for _, key := range keys {
+		p := r.znodePath(key)
+
+		var (
+			data []byte
+			stat *zk.Stat
+			err  error
+		)
+		watchKey := "data:" + p
+		if _, pending := watches[watchKey]; watches != nil && !pending {
+			var ch <-chan zk.Event
+			data, stat, ch, err = r.conn.GetW(p)
+			if err == nil {
+				watches[watchKey] = ch
+			}
+		} else {
+			data, stat, err = r.conn.Get(p)
+		}
+		if errors.Is(err, zk.ErrNoNode) {
+			// Children that are deleted are reported by the watch on the chroot, but explicit keys need a watch for their creation
+			if explicitKeys {
+				err = r.watchExists(p, watches)
+				if err != nil {
+					return nil, err
+				}
+			}
+			r.logger.Debugf("Zookeeper znode %s does not exist, ignoring it", p)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get znode '%s': %w", p, err)
+		}
+		items[key] = &configuration.Item{
+			Value:   string(data),
+			Version: strconv.FormatInt(int64(stat.Version), 10),
+			Metadata: map[string]string{
+				"mzxid": strconv.FormatInt(stat.Mzxid, 10),
+			},
+		}
+	}
+
+	return items, nil
+}
+
+// watchExists sets a watch that fires when the znode is created, if watches is not nil and there's no pending watch for it.
+func (r *ConfigurationStore) watchExists(p string, watches map[string]<-chan zk.Event) error {
+	watchKey := "exists:" + p
+	if _, pending := watches[watchKey]; watches == nil || pending {
+		return nil
+	}
+	_, _, ch, err := r.conn.ExistsW(p)
+	if err != nil {
+		return fmt.Errorf("failed to watch znode '%s': %w", p, err)
+	}
+	watches[watchKey] = ch
+	return nil
+}
+
+func (r *ConfigurationStore) znodePath(key string) string {
+	return path.Join(r.metadata.Chroot, strings.TrimPrefix(key, "/"))
+}
+
+func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	return r.subscriptions.Start(ctx, func(ctx context.Context, id string) {
+		r.doSubscribe(ctx, req, handler, id)
+	})
+}
+
+// doSubscribe sets watches on the subscribed znodes and notifies the handler of every change.
+// Zookeeper watches fire only once, so they're set again after each event; after an error or a session expiration,
+// the items are read again and compared with the last ones sent, so changes are not lost.
+func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler, id string) {
+	var (
+		// Items last sent to the subscriber; nil until the first read completes
+		snapshot map[string]*configuration.Item
+		// Watches that haven't fired yet
+		watches = map[string]<-chan zk.Event{}
+	)
+	for {
+		items, err := r.read(req.Keys, watches)
+		if err != nil {
+			r.logger.Warnf("Failed to watch Zookeeper znodes under '%s', retrying in %v: %v", r.metadata.Chroot, r.metadata.RetryDelay, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.metadata.RetryDelay):
+			}
+			continue
+		}
+
+		if snapshot != nil {
+			changed := configuration.DiffItems(snapshot, items, true)
+			if len(changed) > 0 {
+				r.handleSubscribedChange(ctx, handler, changed, id)
+			}
+		}
+		snapshot = items
+
+		ev, ok := waitForEvent(ctx, watches)
+		if !ok {
+			return
+		}
+		if ev.Type == zk.EventNotWatching || ev.Err != nil {
+			// The session expired or the connection was closed, which removes all watches: wait before setting them again
+			r.logger.Debugf("Zookeeper watch on '%s' was removed: %v", ev.Path, ev.Err)
+			clear(watches)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.metadata.RetryDelay):
+			}
+		}
+	}
+}
+
+// waitForEvent blocks until one of the watches fires and removes it from the map.
+// It returns false if the context is canceled first.
+func waitForEvent(ctx context.Context, watches map[string]<-chan zk.Event) (zk.Event, bool) {
+	keys := make([]string, 0, len(watches))
+	cases := make([]reflect.SelectCase, 0, len(watches)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	for k, ch := range watches {
+		keys = append(keys, k)
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+	}
+
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return zk.Event{}, false
+	}
+	delete(watches, keys[chosen-1])
+	if !ok {
+		// Closed channel, like a removed watch
+		return zk.Event{Type: zk.EventNotWatching}, true
+	}
+	return value.Interface().(zk.Event), true
+}
+
+func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler configuration.UpdateHandler, items map[string]*configuration.Item, id string) {
+	e := &configuration.UpdateEvent{
+		Items: items,
+		ID:    id,
+	}
+	err := handler(ctx, e)
+	if err != nil {
+		r.logger.Errorf("Failed to call handler to notify event for configuration update subscribe: %s", err)
+	}
+}
+
+func (r *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	return r.subscriptions.Stop(req.ID)
+}
+
+func (r *ConfigurationStore) Close() error {
+	r.subscriptions.Close()
+
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *ConfigurationStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// mockConn is an in-memory implementation of zkConn with one-shot watches.
+type mockConn struct {
+	lock     sync.Mutex
+	zxid     int64
+	nodes    map[string]*mockNode
+	watchers map[string][]chan zk.Event
+}
+
+type mockNode struct {
+	data []byte
+	stat zk.Stat
+}
+
+func newMockConn() *mockConn {
+	return &mockConn{
+		nodes:    map[string]*mockNode{},
+		watchers: map[string][]chan zk.Event{},
+	}
+}
+
+func (m *mockConn) set(p string, data string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.zxid++
+	n, ok := m.nodes[p]
+	if ok {
+		n.stat.Version++
+		n.data = []byte(data)
+		n.stat.Mzxid = m.zxid
+		m.fire("data:"+p, zk.Event{Type: zk.EventNodeDataChanged, Path: p})
+		return
+	}
+	m.nodes[p] = &mockNode{data: []byte(data), stat: zk.Stat{Mzxid: m.zxid}}
+	m.fire("exists:"+p, zk.Event{Type: zk.EventNodeCreated, Path: p})
+	m.fire("children:"+path.Dir(p), zk.Event{Type: zk.EventNodeChildrenChanged, Path: path.Dir(p)})
+}
+
+func (m *mockConn) delete(p string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.nodes, p)
+	m.fire("data:"+p, zk.Event{Type: zk.EventNodeDeleted, Path: p})
+	m.fire("children:"+path.Dir(p), zk.Event{Type: zk.EventNodeChildrenChanged, Path: path.Dir(p)})
+}
+
+func (m *mockConn) expireSession() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k := range m.watchers {
+		m.fire(k, zk.Event{Type: zk.EventNotWatching, Err: zk.ErrSessionExpired})
+	}
+}
+
+func (m *mockConn) fire(key string, ev zk.Event) {
+	for _, ch := range m.watchers[key] {
+		ch <- ev
+		close(ch)
+	}
+	delete(m.watchers, key)
+}
+
+func (m *mockConn) watch(key string) <-chan zk.Event {
+	ch := make(chan zk.Event, 1)
+	m.watchers[key] = append(m.watchers[key], ch)
+	return ch
+}
+
+func (m *mockConn) watcherCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n := 0
+	for _, w := range m.watchers {
+		n += len(w)
+	}
+	return n
+}
+
+func (m *mockConn) Get(p string) ([]byte, *zk.Stat, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	stat := n.stat
+	return n.data, &stat, nil
+}
+
+func (m *mockConn) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	stat := n.stat
+	return n.data, &stat, m.watch("data:" + p), nil
+}
+
+func (m *mockConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.nodes[p]
+	return ok, nil, m.watch("exists:" + p), nil
+}
+
+func (m *mockConn) Children(p string) ([]string, *zk.Stat, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.children(p), nil, nil
+}
+
+func (m *mockConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.children(p), nil, m.watch("children:" + p), nil
+}
+
+func (m *mockConn) children(p string) []string {
+	res := []string{}
+	for k := range m.nodes {
+		if path.Dir(k) == p {
+			res = append(res, path.Base(k))
+		}
+	}
+	return res
+}
+
+func (m *mockConn) Close() {}
+
+func newTestStore(t *testing.T, conn zkConn, props map[string]string) *ConfigurationStore {
+	s := NewZookeeperConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	props["servers"] = "localhost:2181"
+	err := s.metadata.Parse(configuration.Metadata{Base: mdata.Base{Properties: props}})
+	require.NoError(t, err)
+	s.conn = conn
+	return s
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"servers": "zk-0:2181, zk-1:2181",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"zk-0:2181", "zk-1:2181"}, m.servers)
+		assert.Equal(t, defaultSessionTimeout, m.SessionTimeout)
+		assert.Equal(t, "/", m.Chroot)
+	})
+
+	t.Run("chroot is normalized", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"servers": "zk-0:2181",
+			"chroot":  "dapr/config/",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "/dapr/config", m.Chroot)
+	})
+
+	t.Run("missing servers", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("username without password", func(t *testing.T) {
+		m := metadata{}
+		err := m.Parse(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"servers":  "zk-0:2181",
+			"username": "dapr",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	conn := newMockConn()
+	conn.set("/config/key1", "value1")
+	conn.set("/config/key2", "value2")
+	conn.set("/other", "value")
+	conn.set("/config/key1", "updated1")
+
+	s := newTestStore(t, conn, map[string]string{"chroot": "/config"})
+
+	t.Run("all children of the chroot", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "updated1", res.Items["key1"].Value)
+		assert.Equal(t, "1", res.Items["key1"].Version)
+		assert.Equal(t, "value2", res.Items["key2"].Value)
+		assert.Equal(t, "0", res.Items["key2"].Version)
+	})
+
+	t.Run("specific keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{Keys: []string{"key2", "missing"}})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "value2", res.Items["key2"].Value)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	conn := newMockConn()
+	conn.set("/config/key1", "value1")
+
+	s := newTestStore(t, conn, map[string]string{
+		"chroot":     "/config",
+		"retryDelay": "10ms",
+	})
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	waitEvent := func() *configuration.UpdateEvent {
+		select {
+		case e := <-events:
+			assert.Equal(t, id, e.ID)
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("did not receive update event")
+			return nil
+		}
+	}
+
+	// Wait for the watches to be set
+	require.Eventually(t, func() bool { return conn.watcherCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	conn.set("/config/key1", "updated1")
+	e := waitEvent()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "updated1", e.Items["key1"].Value)
+
+	conn.set("/config/key2", "value2")
+	e = waitEvent()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "value2", e.Items["key2"].Value)
+
+	conn.delete("/config/key1")
+	e = waitEvent()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, &configuration.Item{}, e.Items["key1"])
+
+	// Watches that didn't fire are not set again
+	require.Eventually(t, func() bool { return conn.watcherCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// After the session expires, watches are set again and changes are caught up
+	conn.expireSession()
+	conn.set("/config/key2", "updated2")
+	e = waitEvent()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "updated2", e.Items["key2"].Value)
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+}
+
+func TestSubscribeExplicitKeys(t *testing.T) {
+	conn := newMockConn()
+	s := newTestStore(t, conn, map[string]string{"chroot": "/config"})
+	defer s.Close()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	_, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{
+		Keys: []string{"key1"},
+	}, func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Keys that don't exist yet are watched for their creation
+	require.Eventually(t, func() bool { return conn.watcherCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	conn.set("/config/other", "ignored")
+	conn.set("/config/key1", "value1")
+
+	select {
+	case e := <-events:
+		require.Len(t, e.Items, 1)
+		assert.Equal(t, "value1", e.Items["key1"].Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive update event")
+	}
+}
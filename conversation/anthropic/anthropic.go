@@ -28,7 +28,8 @@ import (
 )
 
 type Anthropic struct {
-	llm llms.Model
+	llm   llms.Model
+	model string
 
 	logger logger.Logger
 }
@@ -44,7 +45,7 @@ func NewAnthropic(logger logger.Logger) conversation.Conversation {
 const defaultModel = "claude-3-5-sonnet-20240620"
 
 func (a *Anthropic) Init(ctx context.Context, meta conversation.Metadata) error {
-	m := conversation.LangchainMetadata{}
+	m := conversation.LangchainHTTPMetadata{}
 	err := kmeta.DecodeMetadata(meta.Properties, &m)
 	if err != nil {
 		return err
@@ -54,10 +55,17 @@ func (a *Anthropic) Init(ctx context.Context, meta conversation.Metadata) error
 	if m.Model != "" {
 		model = m.Model
 	}
+	a.model = model
+
+	httpClient, err := m.HTTPClient()
+	if err != nil {
+		return err
+	}
 
 	llm, err := anthropic.New(
 		anthropic.WithModel(model),
 		anthropic.WithToken(m.Key),
+		anthropic.WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return err
@@ -78,24 +86,22 @@ func (a *Anthropic) Init(ctx context.Context, meta conversation.Metadata) error
 }
 
 func (a *Anthropic) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
-	metadataStruct := conversation.LangchainMetadata{}
+	metadataStruct := conversation.LangchainHTTPMetadata{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.ConversationType)
 	return
 }
 
 func (a *Anthropic) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
+	return a.converse(ctx, r, nil)
+}
 
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
+// ConverseStream sends the response to streamFunc as it is generated.
+func (a *Anthropic) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return a.converse(ctx, r, streamFunc)
+}
 
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
+func (a *Anthropic) converse(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	messages := conversation.LangchainMessages(r.Inputs)
 
 	opts := []llms.CallOption{}
 
@@ -103,22 +109,32 @@ func (a *Anthropic) Converse(ctx context.Context, r *conversation.ConversationRe
 		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
 	}
 
+	if len(r.Tools) > 0 {
+		opts = append(opts, conversation.LangchainTools(r.Tools))
+	}
+
+	var stream *conversation.LangchainStream
+	if streamFunc != nil {
+		stream = conversation.NewLangchainStream(streamFunc)
+		opts = append(opts, stream.CallOption())
+	}
+
 	resp, err := a.llm.GenerateContent(ctx, messages, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
-
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
+	if stream != nil {
+		err = stream.Flush(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	res = &conversation.ConversationResponse{
-		Outputs: outputs,
+		Outputs: conversation.LangchainResults(resp, r.Parameters),
+		Model:   a.model,
+		Usage:   conversation.LangchainUsage(resp),
 	}
 
 	return res, nil
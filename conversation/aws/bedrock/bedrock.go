@@ -30,8 +30,11 @@ import (
 )
 
 type AWSBedrock struct {
-	model string
-	llm   llms.Model
+	model  string
+	llm    llms.Model
+	client converseClient
+
+	streamClient converseStreamClient
 
 	logger logger.Logger
 }
@@ -67,6 +70,8 @@ func (b *AWSBedrock) Init(ctx context.Context, meta conversation.Metadata) error
 	}
 
 	bedrockClient := bedrockruntime.NewFromConfig(awsConfig)
+	b.client = bedrockClient
+	b.streamClient = runtimeStreamClient{client: bedrockClient}
 
 	opts := []bedrock.Option{bedrock.WithClient(bedrockClient)}
 	if m.Model != "" {
@@ -101,6 +106,10 @@ func (b *AWSBedrock) GetComponentMetadata() (metadataInfo metadata.MetadataMap)
 }
 
 func (b *AWSBedrock) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
+	if usesTools(r) {
+		return b.converseTools(ctx, r)
+	}
+
 	messages := make([]llms.MessageContent, 0, len(r.Inputs))
 
 	for _, input := range r.Inputs {
@@ -136,6 +145,8 @@ func (b *AWSBedrock) Converse(ctx context.Context, r *conversation.ConversationR
 
 	res = &conversation.ConversationResponse{
 		Outputs: outputs,
+		Model:   b.model,
+		Usage:   conversation.LangchainUsage(resp),
 	}
 
 	return res, nil
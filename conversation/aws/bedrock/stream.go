@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bedrock
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/tmc/langchaingo/llms/bedrock"
+
+	"github.com/dapr/components-contrib/conversation"
+)
+
+// Model used to stream responses when none is configured, the same that Langchain uses by default.
+const defaultStreamModel = bedrock.ModelAmazonTitanTextLiteV1
+
+// converseEventStream is the part of the Bedrock runtime event stream used to read streamed responses.
+type converseEventStream interface {
+	Events() <-chan types.ConverseStreamOutput
+	Close() error
+	Err() error
+}
+
+// converseStreamClient opens streams with the Bedrock runtime ConverseStream API.
+type converseStreamClient interface {
+	ConverseStream(ctx context.Context, params *bedrockruntime.ConverseStreamInput) (converseEventStream, error)
+}
+
+type runtimeStreamClient struct {
+	client *bedrockruntime.Client
+}
+
+func (c runtimeStreamClient) ConverseStream(ctx context.Context, params *bedrockruntime.ConverseStreamInput) (converseEventStream, error) {
+	out, err := c.client.ConverseStream(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return out.GetStream(), nil
+}
+
+// ConverseStream sends the response to streamFunc as it is generated.
+// Streaming uses the Converse API directly, for requests with and without tools.
+func (b *AWSBedrock) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (*conversation.ConversationResponse, error) {
+	model := b.model
+	if model == "" {
+		model = defaultStreamModel
+	}
+
+	in, err := converseInput(model, r)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := b.streamClient.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:         in.ModelId,
+		Messages:        in.Messages,
+		System:          in.System,
+		InferenceConfig: in.InferenceConfig,
+		ToolConfig:      in.ToolConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var (
+		text  strings.Builder
+		calls = map[int32]*conversation.ToolCall{}
+		args  = map[int32]*strings.Builder{}
+		usage *conversation.Usage
+	)
+	events := stream.Events()
+	for {
+		var (
+			event types.ConverseStreamOutput
+			ok    bool
+		)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok = <-events:
+		}
+		if !ok {
+			break
+		}
+
+		switch e := event.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockStart:
+			if start, ok := e.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+				calls[aws.ToInt32(e.Value.ContentBlockIndex)] = &conversation.ToolCall{
+					ID:   aws.ToString(start.Value.ToolUseId),
+					Name: aws.ToString(start.Value.Name),
+				}
+			}
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			switch delta := e.Value.Delta.(type) {
+			case *types.ContentBlockDeltaMemberText:
+				text.WriteString(delta.Value)
+				err = streamFunc(ctx, []byte(delta.Value))
+				if err != nil {
+					return nil, err
+				}
+			case *types.ContentBlockDeltaMemberToolUse:
+				// Tool call arguments are streamed as fragments of a JSON document
+				idx := aws.ToInt32(e.Value.ContentBlockIndex)
+				if args[idx] == nil {
+					args[idx] = &strings.Builder{}
+				}
+				args[idx].WriteString(aws.ToString(delta.Value.Input))
+			}
+		case *types.ConverseStreamOutputMemberMetadata:
+			if e.Value.Usage != nil {
+				usage = &conversation.Usage{
+					PromptTokens:     int64(aws.ToInt32(e.Value.Usage.InputTokens)),
+					CompletionTokens: int64(aws.ToInt32(e.Value.Usage.OutputTokens)),
+					TotalTokens:      int64(aws.ToInt32(e.Value.Usage.TotalTokens)),
+				}
+			}
+		}
+	}
+	if err = stream.Err(); err != nil {
+		return nil, fmt.Errorf("error reading the response stream: %w", err)
+	}
+
+	result := conversation.ConversationResult{
+		Result:     text.String(),
+		Parameters: r.Parameters,
+	}
+	indexes := make([]int32, 0, len(calls))
+	for idx := range calls {
+		indexes = append(indexes, idx)
+	}
+	slices.Sort(indexes)
+	for _, idx := range indexes {
+		call := calls[idx]
+		call.Arguments = "{}"
+		if args[idx] != nil && args[idx].Len() > 0 {
+			call.Arguments = args[idx].String()
+		}
+		result.ToolCalls = append(result.ToolCalls, *call)
+	}
+
+	return &conversation.ConversationResponse{
+		Outputs: []conversation.ConversationResult{result},
+		Model:   model,
+		Usage:   usage,
+	}, nil
+}
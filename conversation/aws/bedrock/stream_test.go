@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/kit/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEventStream struct {
+	events chan types.ConverseStreamOutput
+	err    error
+	closed bool
+}
+
+func (f *fakeEventStream) Events() <-chan types.ConverseStreamOutput {
+	return f.events
+}
+
+func (f *fakeEventStream) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeEventStream) Err() error {
+	return f.err
+}
+
+type fakeStreamClient struct {
+	input  *bedrockruntime.ConverseStreamInput
+	stream *fakeEventStream
+}
+
+func (f *fakeStreamClient) ConverseStream(ctx context.Context, params *bedrockruntime.ConverseStreamInput) (converseEventStream, error) {
+	f.input = params
+	return f.stream, nil
+}
+
+func newFakeStream(events ...types.ConverseStreamOutput) *fakeEventStream {
+	s := &fakeEventStream{
+		events: make(chan types.ConverseStreamOutput, len(events)),
+	}
+	for _, e := range events {
+		s.events <- e
+	}
+	close(s.events)
+	return s
+}
+
+func TestConverseStream(t *testing.T) {
+	stream := newFakeStream(
+		&types.ConverseStreamOutputMemberMessageStart{Value: types.MessageStartEvent{Role: types.ConversationRoleAssistant}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "Let me "},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "check."},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: aws.String("call1"),
+				Name:      aws.String("weather"),
+			}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`{"city":`)}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`"Rome"}`)}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: aws.Int32(2),
+			Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: aws.String("call2"),
+				Name:      aws.String("time"),
+			}},
+		}},
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{Usage: &types.TokenUsage{
+			InputTokens:  aws.Int32(10),
+			OutputTokens: aws.Int32(5),
+			TotalTokens:  aws.Int32(15),
+		}}},
+	)
+	client := &fakeStreamClient{stream: stream}
+	b := &AWSBedrock{
+		model:        "anthropic.claude-3-haiku-20240307-v1:0",
+		streamClient: client,
+		logger:       logger.NewLogger("bedrock test"),
+	}
+
+	var chunks []string
+	res, err := b.ConverseStream(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{Role: conversation.RoleSystem, Message: "You are a weather bot."},
+			{Role: conversation.RoleUser, Message: "Weather in Rome?"},
+		},
+		Tools:       []conversation.Tool{{Name: "weather"}, {Name: "time"}},
+		Temperature: 0.5,
+	}, func(ctx context.Context, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, stream.closed)
+
+	assert.Equal(t, []string{"Let me ", "check."}, chunks)
+	require.Len(t, res.Outputs, 1)
+	assert.Equal(t, "Let me check.", res.Outputs[0].Result)
+	assert.Equal(t, []conversation.ToolCall{
+		{ID: "call1", Name: "weather", Arguments: `{"city":"Rome"}`},
+		{ID: "call2", Name: "time", Arguments: "{}"},
+	}, res.Outputs[0].ToolCalls)
+	assert.Equal(t, &conversation.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, res.Usage)
+	assert.Equal(t, b.model, res.Model)
+
+	in := client.input
+	assert.Equal(t, b.model, aws.ToString(in.ModelId))
+	assert.Len(t, in.System, 1)
+	require.Len(t, in.Messages, 1)
+	assert.Equal(t, types.ConversationRoleUser, in.Messages[0].Role)
+	require.Len(t, in.ToolConfig.Tools, 2)
+	assert.InDelta(t, 0.5, aws.ToFloat32(in.InferenceConfig.Temperature), 0.001)
+
+	t.Run("default model", func(t *testing.T) {
+		client := &fakeStreamClient{stream: newFakeStream()}
+		b := &AWSBedrock{streamClient: client}
+		res, err := b.ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hello"}},
+		}, func(ctx context.Context, chunk []byte) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, defaultStreamModel, aws.ToString(client.input.ModelId))
+		assert.Equal(t, defaultStreamModel, res.Model)
+	})
+
+	t.Run("stream error", func(t *testing.T) {
+		stream := newFakeStream()
+		stream.err = errors.New("throttled")
+		b := &AWSBedrock{streamClient: &fakeStreamClient{stream: stream}}
+		_, err := b.ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hello"}},
+		}, func(ctx context.Context, chunk []byte) error { return nil })
+		require.ErrorContains(t, err, "throttled")
+	})
+
+	t.Run("stream func error stops the stream", func(t *testing.T) {
+		stream := newFakeStream(&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "hi"},
+		}})
+		b := &AWSBedrock{model: "m", streamClient: &fakeStreamClient{stream: stream}}
+		_, err := b.ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hello"}},
+		}, func(ctx context.Context, chunk []byte) error { return errors.New("client gone") })
+		require.ErrorContains(t, err, "client gone")
+		assert.True(t, stream.closed)
+	})
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+
+	"github.com/dapr/components-contrib/conversation"
+)
+
+// converseClient is the part of the Bedrock runtime client used for tool calling.
+type converseClient interface {
+	Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+// usesTools returns true if the request defines tools or carries tool calls from a previous response.
+// Langchain doesn't support tools with Bedrock, so these requests use the Converse API directly.
+func usesTools(r *conversation.ConversationRequest) bool {
+	if len(r.Tools) > 0 {
+		return true
+	}
+	for _, input := range r.Inputs {
+		if input.Role == conversation.RoleTool || len(input.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *AWSBedrock) converseTools(ctx context.Context, r *conversation.ConversationRequest) (*conversation.ConversationResponse, error) {
+	if b.model == "" {
+		return nil, errors.New("a model must be configured to use tools")
+	}
+
+	in, err := converseInput(b.model, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := b.client.Converse(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, ok := out.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected converse output type %T", out.Output)
+	}
+
+	result := conversation.ConversationResult{
+		Parameters: r.Parameters,
+	}
+	var text strings.Builder
+	for _, block := range msg.Value.Content {
+		switch c := block.(type) {
+		case *types.ContentBlockMemberText:
+			text.WriteString(c.Value)
+		case *types.ContentBlockMemberToolUse:
+			args := []byte("{}")
+			if c.Value.Input != nil {
+				args, err = c.Value.Input.MarshalSmithyDocument()
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode tool call arguments: %w", err)
+				}
+			}
+			result.ToolCalls = append(result.ToolCalls, conversation.ToolCall{
+				ID:        aws.ToString(c.Value.ToolUseId),
+				Name:      aws.ToString(c.Value.Name),
+				Arguments: string(args),
+			})
+		}
+	}
+	result.Result = text.String()
+
+	res := &conversation.ConversationResponse{
+		Outputs: []conversation.ConversationResult{result},
+		Model:   b.model,
+	}
+	if out.Usage != nil {
+		res.Usage = &conversation.Usage{
+			PromptTokens:     int64(aws.ToInt32(out.Usage.InputTokens)),
+			CompletionTokens: int64(aws.ToInt32(out.Usage.OutputTokens)),
+			TotalTokens:      int64(aws.ToInt32(out.Usage.TotalTokens)),
+		}
+	}
+
+	return res, nil
+}
+
+func converseInput(model string, r *conversation.ConversationRequest) (*bedrockruntime.ConverseInput, error) {
+	in := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(model),
+	}
+
+	if r.Temperature > 0 {
+		in.InferenceConfig = &types.InferenceConfiguration{
+			Temperature: aws.Float32(float32(r.Temperature)),
+		}
+	}
+
+	if len(r.Tools) > 0 {
+		tools := make([]types.Tool, len(r.Tools))
+		for i, tool := range r.Tools {
+			var schema any = map[string]any{"type": "object"}
+			if len(tool.Parameters) > 0 {
+				err := json.Unmarshal(tool.Parameters, &schema)
+				if err != nil {
+					return nil, fmt.Errorf("invalid parameters schema for tool '%s': %w", tool.Name, err)
+				}
+			}
+			spec := types.ToolSpecification{
+				Name: aws.String(tool.Name),
+				InputSchema: &types.ToolInputSchemaMemberJson{
+					Value: document.NewLazyDocument(schema),
+				},
+			}
+			if tool.Description != "" {
+				spec.Description = aws.String(tool.Description)
+			}
+			tools[i] = &types.ToolMemberToolSpec{Value: spec}
+		}
+		in.ToolConfig = &types.ToolConfiguration{Tools: tools}
+	}
+
+	for _, input := range r.Inputs {
+		var (
+			role   types.ConversationRole
+			blocks []types.ContentBlock
+		)
+		switch input.Role {
+		case conversation.RoleSystem:
+			in.System = append(in.System, &types.SystemContentBlockMemberText{Value: input.Message})
+			continue
+		case conversation.RoleAssistant:
+			role = types.ConversationRoleAssistant
+			if input.Message != "" {
+				blocks = append(blocks, &types.ContentBlockMemberText{Value: input.Message})
+			}
+			for _, call := range input.ToolCalls {
+				var args any = map[string]any{}
+				if call.Arguments != "" {
+					err := json.Unmarshal([]byte(call.Arguments), &args)
+					if err != nil {
+						return nil, fmt.Errorf("invalid arguments for tool call '%s': %w", call.ID, err)
+					}
+				}
+				blocks = append(blocks, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String(call.ID),
+					Name:      aws.String(call.Name),
+					Input:     document.NewLazyDocument(args),
+				}})
+			}
+		case conversation.RoleTool:
+			// Tool results are sent by the user
+			role = types.ConversationRoleUser
+			blocks = append(blocks, &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: aws.String(input.ToolCallID),
+				Content: []types.ToolResultContentBlock{
+					&types.ToolResultContentBlockMemberText{Value: input.Message},
+				},
+			}})
+		default:
+			role = types.ConversationRoleUser
+			blocks = append(blocks, &types.ContentBlockMemberText{Value: input.Message})
+		}
+
+		// Bedrock requires roles to alternate, so consecutive messages of the same role are merged
+		if n := len(in.Messages); n > 0 && in.Messages[n-1].Role == role {
+			in.Messages[n-1].Content = append(in.Messages[n-1].Content, blocks...)
+			continue
+		}
+		in.Messages = append(in.Messages, types.Message{
+			Role:    role,
+			Content: blocks,
+		})
+	}
+
+	return in, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/kit/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConverseClient struct {
+	input  *bedrockruntime.ConverseInput
+	output *bedrockruntime.ConverseOutput
+}
+
+func (f *fakeConverseClient) Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+	f.input = params
+	return f.output, nil
+}
+
+func TestConverseTools(t *testing.T) {
+	client := &fakeConverseClient{
+		output: &bedrockruntime.ConverseOutput{
+			Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+				Role: types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{
+					&types.ContentBlockMemberText{Value: "Let me check."},
+					&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+						ToolUseId: aws.String("call2"),
+						Name:      aws.String("weather"),
+						Input:     document.NewLazyDocument(map[string]any{"city": "Rome"}),
+					}},
+				},
+			}},
+		},
+	}
+	b := &AWSBedrock{
+		model:  "anthropic.claude-3-haiku-20240307-v1:0",
+		client: client,
+		logger: logger.NewLogger("bedrock test"),
+	}
+
+	res, err := b.Converse(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{Role: conversation.RoleSystem, Message: "You are a weather bot."},
+			{Role: conversation.RoleUser, Message: "Weather in Paris and Rome?"},
+			{Role: conversation.RoleAssistant, ToolCalls: []conversation.ToolCall{{ID: "call1", Name: "weather", Arguments: `{"city":"Paris"}`}}},
+			{Role: conversation.RoleTool, ToolCallID: "call1", Name: "weather", Message: "sunny"},
+		},
+		Tools: []conversation.Tool{
+			{Name: "weather", Description: "Get the weather", Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, res.Outputs, 1)
+	assert.Equal(t, "Let me check.", res.Outputs[0].Result)
+	require.Len(t, res.Outputs[0].ToolCalls, 1)
+	assert.Equal(t, "call2", res.Outputs[0].ToolCalls[0].ID)
+	assert.Equal(t, "weather", res.Outputs[0].ToolCalls[0].Name)
+	assert.JSONEq(t, `{"city":"Rome"}`, res.Outputs[0].ToolCalls[0].Arguments)
+
+	in := client.input
+	assert.Equal(t, b.model, aws.ToString(in.ModelId))
+	assert.Equal(t, []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: "You are a weather bot."}}, in.System)
+	require.Len(t, in.ToolConfig.Tools, 1)
+	assert.Equal(t, "weather", aws.ToString(in.ToolConfig.Tools[0].(*types.ToolMemberToolSpec).Value.Name))
+
+	require.Len(t, in.Messages, 3)
+	assert.Equal(t, types.ConversationRoleUser, in.Messages[0].Role)
+	assert.Equal(t, types.ConversationRoleAssistant, in.Messages[1].Role)
+	toolUse := in.Messages[1].Content[0].(*types.ContentBlockMemberToolUse).Value
+	assert.Equal(t, "call1", aws.ToString(toolUse.ToolUseId))
+	args, err := toolUse.Input.MarshalSmithyDocument()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(args))
+	assert.Equal(t, types.ConversationRoleUser, in.Messages[2].Role)
+	toolResult := in.Messages[2].Content[0].(*types.ContentBlockMemberToolResult).Value
+	assert.Equal(t, "call1", aws.ToString(toolResult.ToolUseId))
+
+	t.Run("model is required", func(t *testing.T) {
+		b := &AWSBedrock{client: client}
+		_, err := b.Converse(context.Background(), &conversation.ConversationRequest{
+			Tools: []conversation.Tool{{Name: "weather"}},
+		})
+		require.Error(t, err)
+	})
+}
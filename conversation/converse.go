@@ -16,6 +16,7 @@ package conversation
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 
 	"google.golang.org/protobuf/types/known/anypb"
@@ -33,9 +34,27 @@ type Conversation interface {
 	io.Closer
 }
 
+// StreamFunc is invoked with each chunk of a response as it is generated.
+// Returning an error stops the stream.
+type StreamFunc func(ctx context.Context, chunk []byte) error
+
+// StreamingConversation is an optional interface for conversation components that can stream partial responses.
+type StreamingConversation interface {
+	// ConverseStream behaves like Converse, but also sends the response to streamFunc as it is generated.
+	ConverseStream(ctx context.Context, req *ConversationRequest, streamFunc StreamFunc) (*ConversationResponse, error)
+}
+
 type ConversationInput struct {
 	Message string `json:"string"`
 	Role    Role   `json:"role"`
+	// Additional non-text content of the message, such as images
+	Parts []ContentPart `json:"parts,omitempty"`
+
+	// Tool calls requested by the model, when replaying an assistant message
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// ID and name of the tool call the message is the result of, for messages with the tool role
+	ToolCallID string `json:"toolCallId,omitempty"`
+	Name       string `json:"name,omitempty"`
 }
 
 type ConversationRequest struct {
@@ -43,6 +62,7 @@ type ConversationRequest struct {
 	Parameters          map[string]*anypb.Any `json:"parameters"`
 	ConversationContext string                `json:"conversationContext"`
 	Temperature         float64               `json:"temperature"`
+	Tools               []Tool                `json:"tools"`
 
 	// from metadata
 	Key       string   `json:"key"`
@@ -54,11 +74,49 @@ type ConversationRequest struct {
 type ConversationResult struct {
 	Result     string                `json:"result"`
 	Parameters map[string]*anypb.Any `json:"parameters"`
+	ToolCalls  []ToolCall            `json:"toolCalls,omitempty"`
+}
+
+// ContentPart is binary content sent along with a message.
+type ContentPart struct {
+	// MIME type of the content, such as image/png
+	MimeType string `json:"mimeType"`
+	// Content, base64 encoded in JSON
+	Data []byte `json:"data"`
+}
+
+// Tool is a function the model can ask to call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// JSON schema of the function parameters
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+// ToolCall is a request from the model to call a tool.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// JSON encoded arguments of the call
+	Arguments string `json:"arguments"`
 }
 
 type ConversationResponse struct {
 	ConversationContext string               `json:"conversationContext"`
 	Outputs             []ConversationResult `json:"outputs"`
+	// Model that generated the response
+	Model string `json:"model,omitempty"`
+	// Tokens consumed by the request, if reported by the provider
+	Usage *Usage `json:"usage,omitempty"`
+	// Whether the response was served from the response cache
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Usage is the number of tokens consumed by a request.
+type Usage struct {
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
 }
 
 type Role string
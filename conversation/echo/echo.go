@@ -69,11 +69,24 @@ func (e *Echo) Converse(ctx context.Context, r *conversation.ConversationRequest
 
 	res = &conversation.ConversationResponse{
 		Outputs: outputs,
+		Model:   e.model,
 	}
 
 	return res, nil
 }
 
+// ConverseStream returns inputs directly, streaming each of them as a chunk.
+func (e *Echo) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	for _, input := range r.Inputs {
+		err = streamFunc(ctx, []byte(input.Message))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e.Converse(ctx, r)
+}
+
 func (e *Echo) Close() error {
 	return nil
 }
@@ -2,6 +2,7 @@ package echo
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/dapr/components-contrib/conversation"
@@ -26,3 +27,39 @@ func TestConverse(t *testing.T) {
 	assert.Len(t, r.Outputs, 1)
 	assert.Equal(t, "hello", r.Outputs[0].Result)
 }
+
+func TestConverseStream(t *testing.T) {
+	e := NewEcho(logger.NewLogger("echo test"))
+	e.Init(context.Background(), conversation.Metadata{})
+
+	var chunks []string
+	r, err := e.(conversation.StreamingConversation).ConverseStream(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{
+				Message: "hello",
+			},
+			{
+				Message: "world",
+			},
+		},
+	}, func(ctx context.Context, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello", "world"}, chunks)
+	assert.Len(t, r.Outputs, 2)
+
+	t.Run("stream error", func(t *testing.T) {
+		_, err := e.(conversation.StreamingConversation).ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{
+				{
+					Message: "hello",
+				},
+			},
+		}, func(ctx context.Context, chunk []byte) error {
+			return errors.New("stopped")
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package googleai
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	kmeta "github.com/dapr/kit/metadata"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/googleai"
+)
+
+type GoogleAI struct {
+	llm   llms.Model
+	model string
+
+	logger logger.Logger
+}
+
+type GoogleAIMetadata struct {
+	Key      string `json:"key"`
+	Model    string `json:"model"`
+	CacheTTL string `json:"cacheTTL"`
+	// Threshold at which responses are blocked by the safety filters, applied to all harm categories
+	HarmThreshold string `json:"harmThreshold"`
+}
+
+func NewGoogleAI(logger logger.Logger) conversation.Conversation {
+	g := &GoogleAI{
+		logger: logger,
+	}
+
+	return g
+}
+
+const defaultModel = "gemini-1.5-flash"
+
+// harmThresholds maps the Gemini API names of the safety thresholds.
+var harmThresholds = map[string]googleai.HarmBlockThreshold{
+	"BLOCK_NONE":             googleai.HarmBlockNone,
+	"BLOCK_ONLY_HIGH":        googleai.HarmBlockOnlyHigh,
+	"BLOCK_MEDIUM_AND_ABOVE": googleai.HarmBlockMediumAndAbove,
+	"BLOCK_LOW_AND_ABOVE":    googleai.HarmBlockLowAndAbove,
+}
+
+func (g *GoogleAI) Init(ctx context.Context, meta conversation.Metadata) error {
+	md := GoogleAIMetadata{}
+	err := kmeta.DecodeMetadata(meta.Properties, &md)
+	if err != nil {
+		return err
+	}
+
+	model := defaultModel
+	if md.Model != "" {
+		model = md.Model
+	}
+	g.model = model
+
+	opts := []googleai.Option{
+		googleai.WithAPIKey(md.Key),
+		googleai.WithDefaultModel(model),
+	}
+
+	if md.HarmThreshold != "" {
+		threshold, ok := harmThresholds[strings.ToUpper(md.HarmThreshold)]
+		if !ok {
+			return fmt.Errorf("invalid harmThreshold '%s'", md.HarmThreshold)
+		}
+		opts = append(opts, googleai.WithHarmThreshold(threshold))
+	}
+
+	llm, err := googleai.New(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	g.llm = llm
+
+	if md.CacheTTL != "" {
+		cachedModel, cacheErr := conversation.CacheModel(ctx, md.CacheTTL, g.llm)
+		if cacheErr != nil {
+			return cacheErr
+		}
+
+		g.llm = cachedModel
+	}
+	return nil
+}
+
+func (g *GoogleAI) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := GoogleAIMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.ConversationType)
+	return
+}
+
+// Converse sends the inputs to Gemini. System messages are sent as the system instruction.
+func (g *GoogleAI) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
+	return g.converse(ctx, r, nil)
+}
+
+// ConverseStream sends the response to streamFunc as it is generated.
+func (g *GoogleAI) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return g.converse(ctx, r, streamFunc)
+}
+
+func (g *GoogleAI) converse(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	messages := conversation.LangchainMessages(r.Inputs)
+
+	opts := []llms.CallOption{}
+
+	if r.Temperature > 0 {
+		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
+	}
+
+	if len(r.Tools) > 0 {
+		opts = append(opts, conversation.LangchainTools(r.Tools))
+	}
+
+	var stream *conversation.LangchainStream
+	if streamFunc != nil {
+		stream = conversation.NewLangchainStream(streamFunc)
+		opts = append(opts, stream.CallOption())
+	}
+
+	resp, err := g.llm.GenerateContent(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if stream != nil {
+		err = stream.Flush(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res = &conversation.ConversationResponse{
+		Outputs: conversation.LangchainResults(resp, r.Parameters),
+		Model:   g.model,
+		Usage:   conversation.LangchainUsage(resp),
+	}
+
+	return res, nil
+}
+
+func (g *GoogleAI) Close() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googleai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("valid harm threshold", func(t *testing.T) {
+		g := NewGoogleAI(logger.NewLogger("googleai test"))
+		err := g.Init(context.Background(), conversation.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"key":           "test",
+				"harmThreshold": "block_medium_and_above",
+			},
+		}})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid harm threshold", func(t *testing.T) {
+		g := NewGoogleAI(logger.NewLogger("googleai test"))
+		err := g.Init(context.Background(), conversation.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"key":           "test",
+				"harmThreshold": "block_everything",
+			},
+		}})
+		require.ErrorContains(t, err, "invalid harmThreshold")
+	})
+}
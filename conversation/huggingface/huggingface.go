@@ -28,7 +28,8 @@ import (
 )
 
 type Huggingface struct {
-	llm llms.Model
+	llm   llms.Model
+	model string
 
 	logger logger.Logger
 }
@@ -54,6 +55,7 @@ func (h *Huggingface) Init(ctx context.Context, meta conversation.Metadata) erro
 	if m.Model != "" {
 		model = m.Model
 	}
+	h.model = model
 
 	llm, err := huggingface.New(
 		huggingface.WithModel(model),
@@ -119,6 +121,8 @@ func (h *Huggingface) Converse(ctx context.Context, r *conversation.Conversation
 
 	res = &conversation.ConversationResponse{
 		Outputs: outputs,
+		Model:   h.model,
+		Usage:   conversation.LangchainUsage(resp),
 	}
 
 	return res, nil
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// LangchainMessages converts the conversation inputs to langchain messages, including tool calls and their results
+func LangchainMessages(inputs []ConversationInput) []llms.MessageContent {
+	messages := make([]llms.MessageContent, 0, len(inputs))
+
+	for _, input := range inputs {
+		msg := llms.MessageContent{
+			Role: ConvertLangchainRole(input.Role),
+		}
+
+		switch {
+		case input.Role == RoleTool:
+			msg.Parts = []llms.ContentPart{
+				llms.ToolCallResponse{
+					ToolCallID: input.ToolCallID,
+					Name:       input.Name,
+					Content:    input.Message,
+				},
+			}
+		case len(input.ToolCalls) > 0:
+			// Some providers only read the first part of assistant messages, so tool calls come first
+			msg.Parts = make([]llms.ContentPart, 0, len(input.ToolCalls)+1)
+			for _, call := range input.ToolCalls {
+				msg.Parts = append(msg.Parts, llms.ToolCall{
+					ID:   call.ID,
+					Type: "function",
+					FunctionCall: &llms.FunctionCall{
+						Name:      call.Name,
+						Arguments: call.Arguments,
+					},
+				})
+			}
+			if input.Message != "" {
+				msg.Parts = append(msg.Parts, llms.TextPart(input.Message))
+			}
+		default:
+			msg.Parts = make([]llms.ContentPart, 0, len(input.Parts)+1)
+			if input.Message != "" || len(input.Parts) == 0 {
+				msg.Parts = append(msg.Parts, llms.TextPart(input.Message))
+			}
+			for _, part := range input.Parts {
+				msg.Parts = append(msg.Parts, llms.BinaryPart(part.MimeType, part.Data))
+			}
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+// LangchainTools returns a langchain call option with the tools the model can call
+func LangchainTools(tools []Tool) llms.CallOption {
+	lt := make([]llms.Tool, len(tools))
+	for i, tool := range tools {
+		lt[i] = llms.Tool{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+
+	return llms.WithTools(lt)
+}
+
+// LangchainResults converts the choices of a langchain response to conversation results
+func LangchainResults(resp *llms.ContentResponse, parameters map[string]*anypb.Any) []ConversationResult {
+	outputs := make([]ConversationResult, 0, len(resp.Choices))
+
+	for _, choice := range resp.Choices {
+		res := ConversationResult{
+			Result:     choice.Content,
+			Parameters: parameters,
+		}
+		for _, call := range choice.ToolCalls {
+			if call.FunctionCall == nil {
+				continue
+			}
+			res.ToolCalls = append(res.ToolCalls, ToolCall{
+				ID:        call.ID,
+				Name:      call.FunctionCall.Name,
+				Arguments: call.FunctionCall.Arguments,
+			})
+		}
+		outputs = append(outputs, res)
+	}
+
+	return outputs
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLangchainMessages(t *testing.T) {
+	messages := LangchainMessages([]ConversationInput{
+		{Role: RoleUser, Message: "what's the weather?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call1", Name: "weather", Arguments: `{"city":"Paris"}`}}},
+		{Role: RoleTool, ToolCallID: "call1", Name: "weather", Message: "sunny"},
+		{Role: RoleUser, Message: "what's this?", Parts: []ContentPart{{MimeType: "image/png", Data: []byte{0x89, 0x50}}}},
+	})
+	require.Len(t, messages, 4)
+
+	assert.Equal(t, []llms.ContentPart{llms.TextPart("what's the weather?")}, messages[0].Parts)
+
+	assert.Equal(t, llms.ChatMessageTypeAI, messages[1].Role)
+	require.Len(t, messages[1].Parts, 1)
+	call, ok := messages[1].Parts[0].(llms.ToolCall)
+	require.True(t, ok)
+	assert.Equal(t, "call1", call.ID)
+	assert.Equal(t, "weather", call.FunctionCall.Name)
+	assert.Equal(t, `{"city":"Paris"}`, call.FunctionCall.Arguments)
+
+	assert.Equal(t, llms.ChatMessageTypeTool, messages[2].Role)
+	assert.Equal(t, []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call1", Name: "weather", Content: "sunny"}}, messages[2].Parts)
+
+	assert.Equal(t, []llms.ContentPart{llms.TextPart("what's this?"), llms.BinaryPart("image/png", []byte{0x89, 0x50})}, messages[3].Parts)
+}
+
+func TestLangchainTools(t *testing.T) {
+	opts := llms.CallOptions{}
+	LangchainTools([]Tool{
+		{Name: "weather", Description: "Get the weather", Parameters: json.RawMessage(`{"type":"object"}`)},
+	})(&opts)
+
+	require.Len(t, opts.Tools, 1)
+	assert.Equal(t, "function", opts.Tools[0].Type)
+	assert.Equal(t, "weather", opts.Tools[0].Function.Name)
+	assert.Equal(t, "Get the weather", opts.Tools[0].Function.Description)
+	assert.Equal(t, json.RawMessage(`{"type":"object"}`), opts.Tools[0].Function.Parameters)
+}
+
+func TestLangchainResults(t *testing.T) {
+	outputs := LangchainResults(&llms.ContentResponse{
+		Choices: []*llms.ContentChoice{
+			{Content: "hello"},
+			{ToolCalls: []llms.ToolCall{{ID: "call1", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: "{}"}}}},
+		},
+	}, nil)
+
+	require.Len(t, outputs, 2)
+	assert.Equal(t, "hello", outputs[0].Result)
+	assert.Empty(t, outputs[0].ToolCalls)
+	assert.Equal(t, []ToolCall{{ID: "call1", Name: "weather", Arguments: "{}"}}, outputs[1].ToolCalls)
+}
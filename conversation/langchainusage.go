@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"encoding/json"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Keys used by the langchain providers to report token usage in the generation info
+var (
+	promptTokensKeys     = []string{"PromptTokens", "InputTokens", "input_tokens", "prompt_tokens"}
+	completionTokensKeys = []string{"CompletionTokens", "OutputTokens", "output_tokens", "completion_tokens"}
+	totalTokensKeys      = []string{"TotalTokens", "total_tokens"}
+)
+
+// LangchainUsage returns the token usage reported in a langchain response, or nil if the provider doesn't report it
+func LangchainUsage(resp *llms.ContentResponse) *Usage {
+	for _, choice := range resp.Choices {
+		info := choice.GenerationInfo
+		if info == nil {
+			continue
+		}
+
+		// Some providers, such as Mistral, report usage as a nested object
+		if nested, ok := info["usage"]; ok {
+			info = map[string]any{}
+			b, err := json.Marshal(nested)
+			if err != nil || json.Unmarshal(b, &info) != nil {
+				continue
+			}
+		}
+
+		prompt, okPrompt := tokenCount(info, promptTokensKeys)
+		completion, okCompletion := tokenCount(info, completionTokensKeys)
+		if !okPrompt && !okCompletion {
+			continue
+		}
+
+		// Providers report the usage of the whole request on every choice
+		total, ok := tokenCount(info, totalTokensKeys)
+		if !ok {
+			total = prompt + completion
+		}
+		return &Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      total,
+		}
+	}
+
+	return nil
+}
+
+func tokenCount(info map[string]any, keys []string) (int64, bool) {
+	for _, key := range keys {
+		switch v := info[key].(type) {
+		case int:
+			return int64(v), true
+		case int32:
+			return int64(v), true
+		case int64:
+			return v, true
+		case float64:
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
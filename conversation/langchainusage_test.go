@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLangchainUsage(t *testing.T) {
+	type usageInfo struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	}
+
+	tests := map[string]struct {
+		info     map[string]any
+		expected *Usage
+	}{
+		"openai": {
+			info:     map[string]any{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15},
+			expected: &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		"anthropic without total": {
+			info:     map[string]any{"InputTokens": 10, "OutputTokens": 5},
+			expected: &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		"googleai": {
+			info:     map[string]any{"input_tokens": int32(10), "output_tokens": int32(5), "total_tokens": int32(15)},
+			expected: &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		"mistral nested": {
+			info:     map[string]any{"model": "open-mistral-7b", "usage": usageInfo{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			expected: &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		"not reported": {
+			info: map[string]any{"model": "x"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			usage := LangchainUsage(&llms.ContentResponse{
+				Choices: []*llms.ContentChoice{{GenerationInfo: tt.info}},
+			})
+			assert.Equal(t, tt.expected, usage)
+		})
+	}
+}
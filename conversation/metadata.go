@@ -14,7 +14,12 @@ limitations under the License.
 */
 package conversation
 
-import "github.com/dapr/components-contrib/metadata"
+import (
+	"net/http"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	"github.com/dapr/components-contrib/metadata"
+)
 
 // Metadata represents a set of conversation specific properties.
 type Metadata struct {
@@ -27,3 +32,15 @@ type LangchainMetadata struct {
 	Model    string `json:"model"`
 	CacheTTL string `json:"cacheTTL"`
 }
+
+// LangchainHTTPMetadata is the metadata structure for langchain supported implementations
+// that allow configuring the HTTP client, to send requests through a proxy.
+type LangchainHTTPMetadata struct {
+	LangchainMetadata       `mapstructure:",squash"`
+	httputils.ProxyMetadata `mapstructure:",squash"`
+}
+
+// HTTPClient returns the HTTP client to send requests to the model provider, using the configured proxy.
+func (m LangchainHTTPMetadata) HTTPClient() (*http.Client, error) {
+	return httputils.ClientConfig{Proxy: m.ProxyMetadata}.Client()
+}
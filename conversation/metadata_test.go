@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kmeta "github.com/dapr/kit/metadata"
+)
+
+func TestLangchainHTTPMetadata(t *testing.T) {
+	md := LangchainHTTPMetadata{}
+	err := kmeta.DecodeMetadata(map[string]string{
+		"key":      "apikey",
+		"model":    "gpt-4o",
+		"proxyURL": "http://proxy.local:3128",
+		"noProxy":  "internal.local",
+	}, &md)
+	require.NoError(t, err)
+	assert.Equal(t, "apikey", md.Key)
+	assert.Equal(t, "gpt-4o", md.Model)
+	assert.Equal(t, "http://proxy.local:3128", md.ProxyURL)
+	assert.Equal(t, "internal.local", md.NoProxy)
+
+	client, err := md.HTTPClient()
+	require.NoError(t, err)
+	assert.NotNil(t, client.Transport)
+
+	md.ProxyURL = "ftp://proxy.local"
+	_, err = md.HTTPClient()
+	require.Error(t, err)
+}
@@ -28,7 +28,8 @@ import (
 )
 
 type Mistral struct {
-	llm llms.Model
+	llm   llms.Model
+	model string
 
 	logger logger.Logger
 }
@@ -54,6 +55,7 @@ func (m *Mistral) Init(ctx context.Context, meta conversation.Metadata) error {
 	if md.Model != "" {
 		model = md.Model
 	}
+	m.model = model
 
 	llm, err := mistral.New(
 		mistral.WithModel(model),
@@ -83,6 +85,15 @@ func (m *Mistral) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (m *Mistral) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
+	return m.converse(ctx, r, nil)
+}
+
+// ConverseStream sends the response to streamFunc as it is generated.
+func (m *Mistral) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return m.converse(ctx, r, streamFunc)
+}
+
+func (m *Mistral) converse(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
 	messages := make([]llms.MessageContent, 0, len(r.Inputs))
 
 	for _, input := range r.Inputs {
@@ -102,11 +113,24 @@ func (m *Mistral) Converse(ctx context.Context, r *conversation.ConversationRequ
 		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
 	}
 
+	var stream *conversation.LangchainStream
+	if streamFunc != nil {
+		stream = conversation.NewLangchainStream(streamFunc)
+		opts = append(opts, stream.CallOption())
+	}
+
 	resp, err := m.llm.GenerateContent(ctx, messages, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	if stream != nil {
+		err = stream.Flush(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
 
 	for i := range resp.Choices {
@@ -118,6 +142,8 @@ func (m *Mistral) Converse(ctx context.Context, r *conversation.ConversationRequ
 
 	res = &conversation.ConversationResponse{
 		Outputs: outputs,
+		Model:   m.model,
+		Usage:   conversation.LangchainUsage(resp),
 	}
 
 	return res, nil
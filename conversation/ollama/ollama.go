@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ollama
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	kmeta "github.com/dapr/kit/metadata"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// Ollama is a conversation component for Ollama and any other server exposing an OpenAI-compatible API.
+type Ollama struct {
+	llm   llms.Model
+	model string
+
+	logger logger.Logger
+}
+
+type OllamaMetadata struct {
+	httputils.ProxyMetadata `mapstructure:",squash"`
+
+	Key      string `json:"key"`
+	Model    string `json:"model"`
+	Endpoint string `json:"endpoint"`
+	CacheTTL string `json:"cacheTTL"`
+}
+
+func NewOllama(logger logger.Logger) conversation.Conversation {
+	o := &Ollama{
+		logger: logger,
+	}
+
+	return o
+}
+
+const (
+	defaultModel    = "llama3"
+	defaultEndpoint = "http://localhost:11434/v1"
+
+	// Local servers usually don't require authentication, but the OpenAI client refuses to be created without a key
+	noKey = "none"
+)
+
+func (o *Ollama) Init(ctx context.Context, meta conversation.Metadata) error {
+	md := OllamaMetadata{}
+	err := kmeta.DecodeMetadata(meta.Properties, &md)
+	if err != nil {
+		return err
+	}
+
+	model := defaultModel
+	if md.Model != "" {
+		model = md.Model
+	}
+	o.model = model
+
+	endpoint := defaultEndpoint
+	if md.Endpoint != "" {
+		endpoint = md.Endpoint
+	}
+
+	key := noKey
+	if md.Key != "" {
+		key = md.Key
+	}
+
+	httpClient, err := httputils.ClientConfig{Proxy: md.ProxyMetadata}.Client()
+	if err != nil {
+		return err
+	}
+
+	llm, err := openai.New(
+		openai.WithModel(model),
+		openai.WithBaseURL(endpoint),
+		openai.WithToken(key),
+		openai.WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		return err
+	}
+
+	o.llm = llm
+
+	if md.CacheTTL != "" {
+		cachedModel, cacheErr := conversation.CacheModel(ctx, md.CacheTTL, o.llm)
+		if cacheErr != nil {
+			return cacheErr
+		}
+
+		o.llm = cachedModel
+	}
+	return nil
+}
+
+func (o *Ollama) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := OllamaMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.ConversationType)
+	return
+}
+
+func (o *Ollama) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
+	return o.converse(ctx, r, nil)
+}
+
+// ConverseStream sends the response to streamFunc as it is generated.
+func (o *Ollama) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return o.converse(ctx, r, streamFunc)
+}
+
+func (o *Ollama) converse(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	messages := conversation.LangchainMessages(r.Inputs)
+
+	opts := []llms.CallOption{}
+
+	if r.Temperature > 0 {
+		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
+	}
+
+	if len(r.Tools) > 0 {
+		opts = append(opts, conversation.LangchainTools(r.Tools))
+	}
+
+	var stream *conversation.LangchainStream
+	if streamFunc != nil {
+		stream = conversation.NewLangchainStream(streamFunc)
+		opts = append(opts, stream.CallOption())
+	}
+
+	resp, err := o.llm.GenerateContent(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if stream != nil {
+		err = stream.Flush(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res = &conversation.ConversationResponse{
+		Outputs: conversation.LangchainResults(resp, r.Parameters),
+		Model:   o.model,
+		Usage:   conversation.LangchainUsage(resp),
+	}
+
+	return res, nil
+}
+
+func (o *Ollama) Close() error {
+	return nil
+}
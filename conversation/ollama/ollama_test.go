@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverse(t *testing.T) {
+	var (
+		auth string
+		body map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"model": "mistral",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "hi there"}, "finish_reason": "stop"}]
+		}`))
+	}))
+	defer server.Close()
+
+	o := NewOllama(logger.NewLogger("ollama test"))
+	err := o.Init(context.Background(), conversation.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"endpoint": server.URL + "/v1",
+			"model":    "mistral",
+		},
+	}})
+	require.NoError(t, err)
+
+	res, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{Role: conversation.RoleUser, Message: "hello"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Outputs, 1)
+	assert.Equal(t, "hi there", res.Outputs[0].Result)
+
+	assert.Equal(t, "Bearer "+noKey, auth)
+	assert.Equal(t, "mistral", body["model"])
+}
@@ -28,7 +28,8 @@ import (
 )
 
 type OpenAI struct {
-	llm llms.Model
+	llm   llms.Model
+	model string
 
 	logger logger.Logger
 }
@@ -44,7 +45,7 @@ func NewOpenAI(logger logger.Logger) conversation.Conversation {
 const defaultModel = "gpt-4o"
 
 func (o *OpenAI) Init(ctx context.Context, meta conversation.Metadata) error {
-	md := conversation.LangchainMetadata{}
+	md := conversation.LangchainHTTPMetadata{}
 	err := kmeta.DecodeMetadata(meta.Properties, &md)
 	if err != nil {
 		return err
@@ -54,10 +55,17 @@ func (o *OpenAI) Init(ctx context.Context, meta conversation.Metadata) error {
 	if md.Model != "" {
 		model = md.Model
 	}
+	o.model = model
+
+	httpClient, err := md.HTTPClient()
+	if err != nil {
+		return err
+	}
 
 	llm, err := openai.New(
 		openai.WithModel(model),
 		openai.WithToken(md.Key),
+		openai.WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return err
@@ -77,24 +85,22 @@ func (o *OpenAI) Init(ctx context.Context, meta conversation.Metadata) error {
 }
 
 func (o *OpenAI) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
-	metadataStruct := conversation.LangchainMetadata{}
+	metadataStruct := conversation.LangchainHTTPMetadata{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.ConversationType)
 	return
 }
 
 func (o *OpenAI) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
+	return o.converse(ctx, r, nil)
+}
 
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
+// ConverseStream sends the response to streamFunc as it is generated.
+func (o *OpenAI) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return o.converse(ctx, r, streamFunc)
+}
 
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
+func (o *OpenAI) converse(ctx context.Context, r *conversation.ConversationRequest, streamFunc conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	messages := conversation.LangchainMessages(r.Inputs)
 
 	opts := []llms.CallOption{}
 
@@ -102,22 +108,32 @@ func (o *OpenAI) Converse(ctx context.Context, r *conversation.ConversationReque
 		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
 	}
 
+	if len(r.Tools) > 0 {
+		opts = append(opts, conversation.LangchainTools(r.Tools))
+	}
+
+	var stream *conversation.LangchainStream
+	if streamFunc != nil {
+		stream = conversation.NewLangchainStream(streamFunc)
+		opts = append(opts, stream.CallOption())
+	}
+
 	resp, err := o.llm.GenerateContent(ctx, messages, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
-
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
+	if stream != nil {
+		err = stream.Flush(ctx, resp)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	res = &conversation.ConversationResponse{
-		Outputs: outputs,
+		Outputs: conversation.LangchainResults(resp, r.Parameters),
+		Model:   o.model,
+		Usage:   conversation.LangchainUsage(resp),
 	}
 
 	return res, nil
@@ -43,3 +43,42 @@ func CacheModel(ctx context.Context, ttl string, model llms.Model) (llms.Model,
 
 	return cache.New(model, mem), nil
 }
+
+// LangchainStream forwards the chunks of a langchain response to a StreamFunc
+type LangchainStream struct {
+	fn       StreamFunc
+	streamed bool
+}
+
+// NewLangchainStream creates a LangchainStream sending chunks to fn
+func NewLangchainStream(fn StreamFunc) *LangchainStream {
+	return &LangchainStream{fn: fn}
+}
+
+// CallOption returns the langchain option that enables streaming
+func (s *LangchainStream) CallOption() llms.CallOption {
+	return llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		s.streamed = true
+		return s.fn(ctx, chunk)
+	})
+}
+
+// Flush sends the whole response if nothing was streamed, such as when it is served from the cache
+func (s *LangchainStream) Flush(ctx context.Context, resp *llms.ContentResponse) error {
+	if s.streamed {
+		return nil
+	}
+
+	for _, choice := range resp.Choices {
+		if choice.Content == "" {
+			continue
+		}
+
+		err := s.fn(ctx, []byte(choice.Content))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
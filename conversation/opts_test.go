@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLangchainStream(t *testing.T) {
+	resp := &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{
+			{Content: "hello world"},
+		},
+	}
+
+	t.Run("flush sends response when nothing was streamed", func(t *testing.T) {
+		var chunks []string
+		s := NewLangchainStream(func(ctx context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		})
+
+		require.NoError(t, s.Flush(context.Background(), resp))
+		assert.Equal(t, []string{"hello world"}, chunks)
+	})
+
+	t.Run("flush does nothing after streaming", func(t *testing.T) {
+		var chunks []string
+		s := NewLangchainStream(func(ctx context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		})
+
+		opts := llms.CallOptions{}
+		s.CallOption()(&opts)
+		require.NoError(t, opts.StreamingFunc(context.Background(), []byte("hello ")))
+		require.NoError(t, opts.StreamingFunc(context.Background(), []byte("world")))
+
+		require.NoError(t, s.Flush(context.Background(), resp))
+		assert.Equal(t, []string{"hello ", "world"}, chunks)
+	})
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+// ResponseCacheMetadata configures a response cache backed by a state store.
+// The runtime reads it from the component metadata and wraps the component with NewCachedConversation.
+type ResponseCacheMetadata struct {
+	// Name of the state store the responses are cached in
+	ResponseCacheStore string `json:"responseCacheStore"`
+	// How long responses are cached for; if zero, they don't expire
+	ResponseCacheTTL time.Duration `json:"responseCacheTTL"`
+}
+
+// CachedConversation wraps a conversation component, caching its responses in a state store keyed by a hash of the prompt.
+type CachedConversation struct {
+	Conversation
+
+	name   string
+	store  state.BaseStore
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+// NewCachedConversation returns a conversation component caching the responses of conv in store.
+// The name of the component is part of the cache keys, so components can share a store.
+func NewCachedConversation(conv Conversation, name string, store state.BaseStore, ttl time.Duration, logger logger.Logger) *CachedConversation {
+	return &CachedConversation{
+		Conversation: conv,
+		name:         name,
+		store:        store,
+		ttl:          ttl,
+		logger:       logger,
+	}
+}
+
+// cacheKeyRequest contains the fields of a request that determine the response.
+type cacheKeyRequest struct {
+	Inputs              []ConversationInput `json:"inputs"`
+	ConversationContext string              `json:"conversationContext,omitempty"`
+	Temperature         float64             `json:"temperature,omitempty"`
+	Tools               []Tool              `json:"tools,omitempty"`
+	Model               string              `json:"model,omitempty"`
+}
+
+// cacheKey returns the key of the cached response of the request.
+// The prompt is normalized, so requests differing only in formatting share the cached response.
+func (c *CachedConversation) cacheKey(req *ConversationRequest) (string, error) {
+	inputs := make([]ConversationInput, len(req.Inputs))
+	for i, input := range req.Inputs {
+		input.Message = strings.TrimSpace(input.Message)
+		if input.Role == "" {
+			input.Role = RoleUser
+		}
+		inputs[i] = input
+	}
+
+	tools := make([]Tool, len(req.Tools))
+	for i, tool := range req.Tools {
+		if len(tool.Parameters) > 0 {
+			var buf bytes.Buffer
+			err := json.Compact(&buf, tool.Parameters)
+			if err != nil {
+				return "", err
+			}
+			tool.Parameters = buf.Bytes()
+		}
+		tools[i] = tool
+	}
+
+	b, err := json.Marshal(cacheKeyRequest{
+		Inputs:              inputs,
+		ConversationContext: req.ConversationContext,
+		Temperature:         req.Temperature,
+		Tools:               tools,
+		Model:               req.Model,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(b)
+	return "conversation-cache||" + c.name + "||" + hex.EncodeToString(h[:]), nil
+}
+
+// Converse returns the cached response for the request if any, or invokes the component and caches its response.
+func (c *CachedConversation) Converse(ctx context.Context, req *ConversationRequest) (*ConversationResponse, error) {
+	key, res := c.get(ctx, req)
+	if res != nil {
+		return res, nil
+	}
+
+	res, err := c.Conversation.Converse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(ctx, key, res)
+	return res, nil
+}
+
+// ConverseStream sends a cached response to streamFunc at once, or streams the response of the component if it supports streaming.
+func (c *CachedConversation) ConverseStream(ctx context.Context, req *ConversationRequest, streamFunc StreamFunc) (*ConversationResponse, error) {
+	key, res := c.get(ctx, req)
+	if res == nil {
+		var err error
+		if sc, ok := c.Conversation.(StreamingConversation); ok {
+			res, err = sc.ConverseStream(ctx, req, streamFunc)
+			if err != nil {
+				return nil, err
+			}
+
+			c.set(ctx, key, res)
+			return res, nil
+		}
+
+		res, err = c.Conversation.Converse(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		c.set(ctx, key, res)
+	}
+
+	for _, output := range res.Outputs {
+		if output.Result == "" {
+			continue
+		}
+
+		err := streamFunc(ctx, []byte(output.Result))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// get returns the cache key of the request and the cached response, if any.
+// Errors are logged, as the request can still be served by the component.
+func (c *CachedConversation) get(ctx context.Context, req *ConversationRequest) (string, *ConversationResponse) {
+	key, err := c.cacheKey(req)
+	if err != nil {
+		c.logger.Warnf("Failed to compute the conversation cache key: %v", err)
+		return "", nil
+	}
+
+	cached, err := c.store.Get(ctx, &state.GetRequest{Key: key})
+	if err != nil {
+		c.logger.Warnf("Failed to get the cached conversation response: %v", err)
+		return key, nil
+	}
+	if cached == nil || len(cached.Data) == 0 {
+		return key, nil
+	}
+
+	res := &ConversationResponse{}
+	err = json.Unmarshal(cached.Data, res)
+	if err != nil {
+		c.logger.Warnf("Failed to decode the cached conversation response: %v", err)
+		return key, nil
+	}
+
+	// Parameters are not part of the cache key, so the ones of the request are returned
+	for i := range res.Outputs {
+		res.Outputs[i].Parameters = req.Parameters
+	}
+	res.Cached = true
+
+	return key, res
+}
+
+func (c *CachedConversation) set(ctx context.Context, key string, res *ConversationResponse) {
+	if key == "" {
+		return
+	}
+
+	cached := *res
+	cached.Cached = false
+	cached.Outputs = make([]ConversationResult, len(res.Outputs))
+	for i, output := range res.Outputs {
+		output.Parameters = nil
+		cached.Outputs[i] = output
+	}
+
+	b, err := json.Marshal(cached)
+	if err != nil {
+		c.logger.Warnf("Failed to encode the conversation response to cache: %v", err)
+		return
+	}
+
+	setReq := &state.SetRequest{
+		Key:         key,
+		Value:       b,
+		ContentType: ptr.Of("application/json"),
+	}
+	if c.ttl > 0 {
+		setReq.Metadata = map[string]string{
+			metadata.TTLInSecondsMetadataKey: strconv.FormatInt(int64(math.Ceil(c.ttl.Seconds())), 10),
+		}
+	}
+
+	err = c.store.Set(ctx, setReq)
+	if err != nil {
+		c.logger.Warnf("Failed to cache the conversation response: %v", err)
+	}
+}
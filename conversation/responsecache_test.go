@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConversation struct {
+	calls int
+}
+
+func (f *fakeConversation) GetComponentMetadata() metadata.MetadataMap { return nil }
+
+func (f *fakeConversation) Init(ctx context.Context, meta Metadata) error { return nil }
+
+func (f *fakeConversation) Converse(ctx context.Context, req *ConversationRequest) (*ConversationResponse, error) {
+	f.calls++
+	return &ConversationResponse{
+		Outputs: []ConversationResult{{Result: "reply to " + req.Inputs[0].Message, Parameters: req.Parameters}},
+		Model:   "fake",
+		Usage:   &Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3},
+	}, nil
+}
+
+func (f *fakeConversation) Close() error { return nil }
+
+func TestCachedConversation(t *testing.T) {
+	log := logger.NewLogger("conversation test")
+	store := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	defer store.Close()
+
+	fake := &fakeConversation{}
+	c := NewCachedConversation(fake, "fake", store, time.Minute, log)
+
+	req := func(msg string) *ConversationRequest {
+		return &ConversationRequest{
+			Inputs:     []ConversationInput{{Role: RoleUser, Message: msg}},
+			Parameters: map[string]*anypb.Any{"p": {TypeUrl: msg}},
+		}
+	}
+
+	res, err := c.Converse(context.Background(), req("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "reply to hello", res.Outputs[0].Result)
+	assert.Equal(t, 1, fake.calls)
+	assert.False(t, res.Cached)
+
+	t.Run("cache hit", func(t *testing.T) {
+		r := req("hello")
+		r.Parameters = map[string]*anypb.Any{"p": {TypeUrl: "other"}}
+		res, err := c.Converse(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fake.calls)
+		assert.Equal(t, "reply to hello", res.Outputs[0].Result)
+		assert.Equal(t, "fake", res.Model)
+		assert.Equal(t, &Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}, res.Usage)
+		assert.Equal(t, "other", res.Outputs[0].Parameters["p"].GetTypeUrl())
+		assert.True(t, res.Cached)
+	})
+
+	t.Run("normalized prompt", func(t *testing.T) {
+		r := req("  hello\n")
+		r.Inputs[0].Role = ""
+		res, err := c.Converse(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fake.calls)
+		assert.Equal(t, "reply to hello", res.Outputs[0].Result)
+		assert.True(t, res.Cached)
+	})
+
+	t.Run("normalized tool parameters", func(t *testing.T) {
+		r := req("tools")
+		r.Tools = []Tool{{Name: "weather", Parameters: json.RawMessage(`{"type": "object"}`)}}
+		_, err := c.Converse(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.calls)
+
+		r.Tools = []Tool{{Name: "weather", Parameters: json.RawMessage(`{"type":"object"}`)}}
+		res, err := c.Converse(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.calls)
+		assert.True(t, res.Cached)
+	})
+
+	t.Run("different prompt", func(t *testing.T) {
+		res, err := c.Converse(context.Background(), req("world"))
+		require.NoError(t, err)
+		assert.Equal(t, 3, fake.calls)
+		assert.Equal(t, "reply to world", res.Outputs[0].Result)
+	})
+
+	t.Run("stream cached response", func(t *testing.T) {
+		var chunks []string
+		res, err := c.ConverseStream(context.Background(), req("hello"), func(ctx context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, fake.calls)
+		assert.Equal(t, []string{"reply to hello"}, chunks)
+		assert.Len(t, res.Outputs, 1)
+	})
+
+	t.Run("other component", func(t *testing.T) {
+		other := NewCachedConversation(fake, "other", store, time.Minute, log)
+		_, err := other.Converse(context.Background(), req("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, 4, fake.calls)
+	})
+}
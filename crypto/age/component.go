@@ -0,0 +1,348 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package age
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// AlgorithmAge is the algorithm used to encrypt data and wrap keys in the age format.
+	AlgorithmAge = "AGE"
+
+	// Name of the key that refers to the passphrase in the metadata.
+	passphraseKeyName = "passphrase"
+
+	// Maximum size of key files.
+	maxKeyFileSize = 1 << 20
+)
+
+var errAssociatedDataNotSupported = errors.New("associated data is not supported with age")
+
+type ageCrypto struct {
+	md     ageMetadata
+	logger logger.Logger
+}
+
+// NewAgeCrypto returns a new crypto provider that encrypts data and wraps keys in the age format.
+// Keys are age identities or recipients, loaded from files in a local folder, or a scrypt passphrase.
+func NewAgeCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &ageCrypto{
+		logger: logger,
+	}
+}
+
+// Init the crypto provider.
+func (k *ageCrypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	// Parse the metadata
+	err := k.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (k *ageCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+func (k *ageCrypto) Close() error {
+	return nil
+}
+
+// ageKey contains the identities and recipients loaded for a key.
+type ageKey struct {
+	identities []age.Identity
+	recipients []age.Recipient
+}
+
+// Loads a key.
+// Parameter "key" must be the name of a file inside the "path", or "passphrase" to use the passphrase from the metadata.
+func (k *ageCrypto) loadKey(key string) (*ageKey, error) {
+	if key == passphraseKeyName && k.md.Passphrase != "" {
+		recipient, err := age.NewScryptRecipient(k.md.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if k.md.ScryptWorkFactor > 0 {
+			recipient.SetWorkFactor(k.md.ScryptWorkFactor)
+		}
+		identity, err := age.NewScryptIdentity(k.md.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		identity.SetMaxWorkFactor(k.md.maxScryptWorkFactor())
+		return &ageKey{
+			identities: []age.Identity{identity},
+			recipients: []age.Recipient{recipient},
+		}, nil
+	}
+
+	if k.md.Path == "" {
+		return nil, contribCrypto.ErrKeyNotFound
+	}
+
+	// Do not allow escaping the root path by including ".." in the key's name
+	if strings.Contains(key, "..") {
+		return nil, errors.New("invalid key path: cannot contain '..'")
+	}
+
+	f, err := os.Open(filepath.Join(k.md.Path, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, contribCrypto.ErrKeyNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load key '%s': %w", key, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxKeyFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key '%s': %w", key, err)
+	}
+
+	// Files can contain identities or recipients
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	if err == nil {
+		res := &ageKey{
+			identities: identities,
+			recipients: make([]age.Recipient, 0, len(identities)),
+		}
+		for _, identity := range identities {
+			x25519Identity, ok := identity.(*age.X25519Identity)
+			if !ok {
+				return nil, fmt.Errorf("failed to parse key '%s': unsupported identity type", key)
+			}
+			res.recipients = append(res.recipients, x25519Identity.Recipient())
+		}
+		return res, nil
+	}
+	recipients, err := age.ParseRecipients(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key '%s': file does not contain age identities or recipients", key)
+	}
+	return &ageKey{
+		recipients: recipients,
+	}, nil
+}
+
+// GetKey returns the public key as a X25519 JWK.
+// This is supported with keys containing a single X25519 identity or recipient only.
+func (k *ageCrypto) GetKey(_ context.Context, key string) (pubKey jwk.Key, err error) {
+	ak, err := k.loadKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ak.recipients) != 1 {
+		return nil, fmt.Errorf("key '%s' does not contain exactly one recipient", key)
+	}
+	recipient, ok := ak.recipients[0].(*age.X25519Recipient)
+	if !ok {
+		return nil, contribCrypto.ErrKeyNotFound
+	}
+
+	rawKey, err := decodeX25519Recipient(recipient.String())
+	if err != nil {
+		return nil, err
+	}
+	return jwk.FromRaw(rawKey)
+}
+
+// Encrypt a message, returning the ciphertext in the age (binary) format.
+// The message is encrypted to all recipients of the key.
+func (k *ageCrypto) Encrypt(_ context.Context, plaintext []byte, algorithm string, key string, _ []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	err = checkParams(algorithm, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ak, err := k.loadKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err = ageEncrypt(plaintext, ak.recipients)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, nil, nil
+}
+
+// Decrypt a message in the age format.
+func (k *ageCrypto) Decrypt(_ context.Context, ciphertext []byte, algorithm string, key string, _ []byte, _ []byte, associatedData []byte) (plaintext []byte, err error) {
+	err = checkParams(algorithm, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	ak, err := k.loadKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ak.identities) == 0 {
+		return nil, errors.New("key cannot perform the 'decrypt' operation")
+	}
+
+	return ageDecrypt(ciphertext, ak.identities)
+}
+
+// WrapKey wraps a key.
+func (k *ageCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithm string, key string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys can be wrapped
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	var plaintext []byte
+	err = plaintextKey.Raw(&plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract key: %w", err)
+	}
+
+	return k.Encrypt(parentCtx, plaintext, algorithm, key, nonce, associatedData)
+}
+
+// UnwrapKey unwraps a key.
+func (k *ageCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	plaintext, err := k.Decrypt(parentCtx, wrappedKey, algorithm, key, nonce, tag, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only symmetric keys can be wrapped, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// Sign is not supported by age.
+func (k *ageCrypto) Sign(context.Context, []byte, string, string) (signature []byte, err error) {
+	return nil, errors.New("signing is not supported by age")
+}
+
+// Verify is not supported by age.
+func (k *ageCrypto) Verify(context.Context, []byte, []byte, string, string) (valid bool, err error) {
+	return false, errors.New("signing is not supported by age")
+}
+
+// SupportedEncryptionAlgorithms returns the list of supported encryption algorithms.
+func (k *ageCrypto) SupportedEncryptionAlgorithms() []string {
+	return []string{AlgorithmAge}
+}
+
+// SupportedSignatureAlgorithms returns the list of supported signature algorithms.
+func (k *ageCrypto) SupportedSignatureAlgorithms() []string {
+	return []string{}
+}
+
+func (k *ageCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := ageMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
+
+func checkParams(algorithm string, associatedData []byte) error {
+	if algorithm != AlgorithmAge {
+		return fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+	if len(associatedData) > 0 {
+		return errAssociatedDataNotSupported
+	}
+	return nil
+}
+
+func ageEncrypt(plaintext []byte, recipients []age.Recipient) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := age.Encrypt(buf, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	_, err = w.Write(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func ageDecrypt(ciphertext []byte, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Bech32 alphabet, used by age recipients.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Decodes the public key from a X25519 recipient ("age1..."), which has already been validated by age.
+func decodeX25519Recipient(s string) (x25519.PublicKey, error) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	// Remove the human-readable part and the 6-character checksum
+	if sep < 0 || len(s) < sep+1+6 {
+		return nil, errors.New("invalid recipient")
+	}
+	data := s[sep+1 : len(s)-6]
+
+	// Convert from 5-bit groups to bytes, discarding the padding
+	var (
+		acc  uint
+		bits uint
+	)
+	res := make([]byte, 0, len(data)*5/8)
+	for i := range len(data) {
+		v := strings.IndexByte(bech32Charset, data[i])
+		if v < 0 {
+			return nil, errors.New("invalid recipient")
+		}
+		acc = acc<<5 | uint(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			res = append(res, byte(acc>>bits))
+		}
+	}
+	if len(res) != 32 {
+		return nil, errors.New("invalid recipient")
+	}
+	return x25519.PublicKey(res), nil
+}
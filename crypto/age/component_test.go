@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package age
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestCrypto(t *testing.T, props map[string]string) contribCrypto.SubtleCrypto {
+	t.Helper()
+
+	k := NewAgeCrypto(logger.NewLogger("test"))
+	err := k.Init(context.Background(), newMetadata(props))
+	require.NoError(t, err)
+	return k
+}
+
+func newMetadata(props map[string]string) contribCrypto.Metadata {
+	md := contribCrypto.Metadata{}
+	md.Properties = props
+	return md
+}
+
+func writeKeyFile(t *testing.T, dir string, name string, lines ...string) {
+	t.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+	require.NoError(t, err)
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("path or passphrase required", func(t *testing.T) {
+		md := ageMetadata{}
+		err := md.InitWithMetadata(contribCrypto.Metadata{})
+		require.ErrorContains(t, err, "at least one of")
+	})
+
+	t.Run("path must be a directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "key.txt")
+		require.NoError(t, os.WriteFile(file, []byte("x"), 0o600))
+		md := ageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{"path": file}))
+		require.ErrorContains(t, err, "is not a directory")
+	})
+
+	t.Run("invalid work factor", func(t *testing.T) {
+		md := ageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"passphrase":       "secret",
+			"scryptWorkFactor": "31",
+		}))
+		require.ErrorContains(t, err, "scryptWorkFactor")
+	})
+
+	t.Run("max work factor", func(t *testing.T) {
+		md := ageMetadata{ScryptWorkFactor: 10}
+		assert.Equal(t, 22, md.maxScryptWorkFactor())
+		md.ScryptWorkFactor = 25
+		assert.Equal(t, 25, md.maxScryptWorkFactor())
+	})
+}
+
+func TestAgeCrypto(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKeyFile(t, dir, "identity.txt", "# created: today", identity.String())
+	writeKeyFile(t, dir, "recipient.txt", identity.Recipient().String())
+	writeKeyFile(t, dir, "team.txt", identity.Recipient().String(), other.Recipient().String())
+	writeKeyFile(t, dir, "other.txt", other.String())
+	writeKeyFile(t, dir, "invalid.txt", "not a key")
+
+	k := newTestCrypto(t, map[string]string{
+		"path":             dir,
+		"passphrase":       "correct horse battery staple",
+		"scryptWorkFactor": "10",
+	})
+	ctx := context.Background()
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		ciphertext, tag, err := k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "recipient.txt", nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+		assert.True(t, bytes.HasPrefix(ciphertext, []byte("age-encryption.org/v1\n")))
+
+		plaintext, err := k.Decrypt(ctx, ciphertext, AlgorithmAge, "identity.txt", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(plaintext))
+
+		// Compatible with age
+		r, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+		require.NoError(t, err)
+		plaintext, err = io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(plaintext))
+
+		_, err = k.Decrypt(ctx, ciphertext, AlgorithmAge, "other.txt", nil, nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("multiple recipients", func(t *testing.T) {
+		ciphertext, _, err := k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "team.txt", nil, nil)
+		require.NoError(t, err)
+
+		for _, key := range []string{"identity.txt", "other.txt"} {
+			plaintext, err := k.Decrypt(ctx, ciphertext, AlgorithmAge, key, nil, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(plaintext))
+		}
+	})
+
+	t.Run("recipients cannot decrypt", func(t *testing.T) {
+		ciphertext, _, err := k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "identity.txt", nil, nil)
+		require.NoError(t, err)
+		_, err = k.Decrypt(ctx, ciphertext, AlgorithmAge, "recipient.txt", nil, nil, nil)
+		require.ErrorContains(t, err, "cannot perform the 'decrypt' operation")
+	})
+
+	t.Run("passphrase", func(t *testing.T) {
+		ciphertext, _, err := k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "passphrase", nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(ciphertext), "-> scrypt ")
+
+		plaintext, err := k.Decrypt(ctx, ciphertext, AlgorithmAge, "passphrase", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(plaintext))
+
+		wrongPassphrase, err := age.NewScryptIdentity("wrong")
+		require.NoError(t, err)
+		_, err = age.Decrypt(bytes.NewReader(ciphertext), wrongPassphrase)
+		require.Error(t, err)
+	})
+
+	t.Run("wrap and unwrap", func(t *testing.T) {
+		rawKey := make([]byte, 32)
+		_, err := rand.Read(rawKey)
+		require.NoError(t, err)
+		plaintextKey, err := jwk.FromRaw(rawKey)
+		require.NoError(t, err)
+
+		for _, key := range []string{"identity.txt", "passphrase"} {
+			wrapped, _, err := k.WrapKey(ctx, plaintextKey, AlgorithmAge, key, nil, nil)
+			require.NoError(t, err)
+
+			unwrapped, err := k.UnwrapKey(ctx, wrapped, AlgorithmAge, key, nil, nil, nil)
+			require.NoError(t, err)
+			var unwrappedRaw []byte
+			require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+			assert.Equal(t, rawKey, unwrappedRaw)
+		}
+
+		_, x25519Key, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		asymmetricKey, err := jwk.FromRaw(x25519Key)
+		require.NoError(t, err)
+		_, _, err = k.WrapKey(ctx, asymmetricKey, AlgorithmAge, "identity.txt", nil, nil)
+		require.ErrorContains(t, err, "cannot wrap asymmetric keys")
+	})
+
+	t.Run("get key", func(t *testing.T) {
+		// Compute the public key from the secret key
+		scalar, err := decodeX25519Recipient(identity.String())
+		require.NoError(t, err)
+		ecdhKey, err := ecdh.X25519().NewPrivateKey(scalar)
+		require.NoError(t, err)
+
+		for _, key := range []string{"identity.txt", "recipient.txt"} {
+			pubKey, err := k.GetKey(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, jwa.OKP, pubKey.KeyType())
+			var raw x25519.PublicKey
+			require.NoError(t, pubKey.Raw(&raw))
+			assert.Equal(t, ecdhKey.PublicKey().Bytes(), []byte(raw))
+		}
+
+		_, err = k.GetKey(ctx, "team.txt")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		_, _, err := k.Encrypt(ctx, []byte("hello world"), "A256GCM", "identity.txt", nil, nil)
+		require.ErrorContains(t, err, "invalid algorithm")
+		_, _, err = k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "identity.txt", nil, []byte("aad"))
+		require.ErrorIs(t, err, errAssociatedDataNotSupported)
+		_, err = k.Sign(ctx, []byte("hello world"), "EdDSA", "identity.txt")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, _, err := k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "notfound.txt", nil, nil)
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+		_, _, err = k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "invalid.txt", nil, nil)
+		require.ErrorContains(t, err, "does not contain age identities or recipients")
+		_, _, err = k.Encrypt(ctx, []byte("hello world"), AlgorithmAge, "../identity.txt", nil, nil)
+		require.ErrorContains(t, err, "cannot contain '..'")
+	})
+
+	t.Run("envelope encryption", func(t *testing.T) {
+		env, err := contribCrypto.EnvelopeEncrypt(ctx, k, []byte("hello world"), AlgorithmAge, "identity.txt", nil)
+		require.NoError(t, err)
+		plaintext, err := contribCrypto.EnvelopeDecrypt(ctx, k, env, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(plaintext))
+	})
+}
+
+func TestPassphraseOnly(t *testing.T) {
+	k := newTestCrypto(t, map[string]string{
+		"passphrase":       "secret",
+		"scryptWorkFactor": "10",
+	})
+
+	_, _, err := k.Encrypt(context.Background(), []byte("hello world"), AlgorithmAge, "identity.txt", nil, nil)
+	require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+}
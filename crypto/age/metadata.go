@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package age
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+const (
+	// Default maximum scrypt work factor accepted when decrypting, as in age.
+	defaultMaxScryptWorkFactor = 22
+	// Maximum scrypt work factor supported by age.
+	maxScryptWorkFactor = 30
+)
+
+type ageMetadata struct {
+	// Path to a local folder where age keys are stored.
+	// Each file contains either identities ("AGE-SECRET-KEY-1..."), which can be used for all operations, or recipients ("age1..."), which can be used to encrypt and wrap keys only.
+	Path string `json:"path" mapstructure:"path"`
+
+	// Passphrase used with scrypt, which is used when the key name is "passphrase".
+	Passphrase string `json:"passphrase" mapstructure:"passphrase"`
+
+	// Work factor (log2 of the scrypt cost parameter) used when encrypting with the passphrase.
+	// Defaults to the value chosen by age (currently 18).
+	ScryptWorkFactor int `json:"scryptWorkFactor" mapstructure:"scryptWorkFactor"`
+}
+
+func (m *ageMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if m.Path == "" && m.Passphrase == "" {
+		return errors.New("at least one of the metadata properties 'path' and 'passphrase' is required")
+	}
+
+	// Validate the path and make sure it's a directory
+	if m.Path != "" {
+		m.Path = filepath.Clean(m.Path)
+		info, err := os.Stat(m.Path)
+		if err != nil {
+			return fmt.Errorf("could not stat path '%s': %w", m.Path, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("path '%s' is not a directory", m.Path)
+		}
+	}
+
+	if m.ScryptWorkFactor < 0 || m.ScryptWorkFactor > maxScryptWorkFactor {
+		return fmt.Errorf("metadata property 'scryptWorkFactor' must be between 1 and %d", maxScryptWorkFactor)
+	}
+
+	return nil
+}
+
+// Returns the maximum scrypt work factor accepted when decrypting.
+func (m *ageMetadata) maxScryptWorkFactor() int {
+	return max(m.ScryptWorkFactor, defaultMaxScryptWorkFactor)
+}
+
+// Reset the object
+func (m *ageMetadata) reset() {
+	*m = ageMetadata{}
+}
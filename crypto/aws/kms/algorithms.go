@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+// Algorithm for the symmetric keys of AWS KMS, which uses AES-256-GCM.
+// The nonce is generated by AWS KMS and included in the ciphertext, together with the authentication tag.
+const algorithmSymmetricDefault = kms.EncryptionAlgorithmSpecSymmetricDefault
+
+// Maps the names of the encryption algorithms to the ones used by AWS KMS.
+var encryptionAlgs = map[string]string{
+	internals.Algorithm_RSA_OAEP:     kms.EncryptionAlgorithmSpecRsaesOaepSha1,
+	internals.Algorithm_RSA_OAEP_256: kms.EncryptionAlgorithmSpecRsaesOaepSha256,
+	internals.Algorithm_A256GCM:      algorithmSymmetricDefault,
+	algorithmSymmetricDefault:        algorithmSymmetricDefault,
+}
+
+// Maps the names of the signature algorithms to the ones used by AWS KMS.
+var signatureAlgs = map[string]string{
+	internals.Algorithm_RS256: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+	internals.Algorithm_RS384: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+	internals.Algorithm_RS512: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512,
+	internals.Algorithm_PS256: kms.SigningAlgorithmSpecRsassaPssSha256,
+	internals.Algorithm_PS384: kms.SigningAlgorithmSpecRsassaPssSha384,
+	internals.Algorithm_PS512: kms.SigningAlgorithmSpecRsassaPssSha512,
+	internals.Algorithm_ES256: kms.SigningAlgorithmSpecEcdsaSha256,
+	internals.Algorithm_ES384: kms.SigningAlgorithmSpecEcdsaSha384,
+	internals.Algorithm_ES512: kms.SigningAlgorithmSpecEcdsaSha512,
+}
+
+var (
+	encryptionAlgsList = slices.Sorted(maps.Keys(encryptionAlgs))
+	signatureAlgsList  = slices.Sorted(maps.Keys(signatureAlgs))
+)
+
+// GetKMSEncryptionAlgorithm returns the AWS KMS encryption algorithm for the algorithm, if it's a supported one.
+func GetKMSEncryptionAlgorithm(algorithm string) string {
+	return encryptionAlgs[algorithm]
+}
+
+// GetKMSSignatureAlgorithm returns the AWS KMS signing algorithm for the algorithm, if it's a supported one.
+func GetKMSSignatureAlgorithm(algorithm string) string {
+	return signatureAlgs[algorithm]
+}
+
+// IsAlgorithmAsymmetric returns true if the AWS KMS encryption algorithm is asymmetric.
+func IsAlgorithmAsymmetric(algorithm string) bool {
+	return strings.HasPrefix(algorithm, "RSAES_")
+}
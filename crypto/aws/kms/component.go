@@ -0,0 +1,476 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// Key of the encryption context that contains the associated data, base64-encoded.
+const encryptionContextAssociatedData = "dapr-aad"
+
+type kmsCrypto struct {
+	keyCache     *contribCrypto.PubKeyCache
+	md           kmsMetadata
+	authProvider awsAuth.Provider
+	logger       logger.Logger
+}
+
+var _ contribCrypto.DataKeyGenerator = (*kmsCrypto)(nil)
+
+// NewAWSKMSCrypto returns a new AWS KMS crypto provider.
+// Keys are identified by their key ID, key ARN, alias name (e.g. "alias/my-key"), or alias ARN.
+// Requests for key or alias ARNs are sent to the region in the ARN, so replicas of multi-Region keys can be used in any region.
+func NewAWSKMSCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &kmsCrypto{
+		logger: logger,
+	}
+}
+
+// Init creates an AWS KMS client.
+func (k *kmsCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := k.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Create a cache for keys
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+
+	// Init the AWS client
+	opts := awsAuth.Options{
+		Logger:       k.logger,
+		Properties:   metadata.Properties,
+		Region:       k.md.Region,
+		Endpoint:     k.md.Endpoint,
+		AccessKey:    k.md.AccessKey,
+		SecretKey:    k.md.SecretKey,
+		SessionToken: k.md.SessionToken,
+	}
+	k.authProvider, err = awsAuth.NewProvider(ctx, opts, awsAuth.GetConfig(opts))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (k *kmsCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in AWS KMS.
+// This method returns an error if the key is symmetric.
+func (k *kmsCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	// If the key is cacheable, get it from the cache
+	if isCacheable(key) {
+		return k.keyCache.GetKey(parentCtx, key)
+	}
+
+	return k.getKeyFromKMS(parentCtx, key)
+}
+
+func (k *kmsCrypto) getKeyFromKMS(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{
+		KeyId:       aws.String(key),
+		GrantTokens: k.md.grantTokens(),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key from AWS KMS: %w", err)
+	}
+
+	return PublicKeyToKey(res)
+}
+
+// Handler for the getKeyCacheFn method
+func (k *kmsCrypto) getKeyCacheFn(ctx context.Context, key string) func(resolve func(jwk.Key), reject func(error)) {
+	return func(resolve func(jwk.Key), reject func(error)) {
+		pk, err := k.getKeyFromKMS(ctx, key)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(pk)
+	}
+}
+
+// Encrypt a small message and returns the ciphertext.
+// With symmetric keys, the nonce is generated by AWS KMS and the authentication tag is included in the ciphertext, so the nonce argument is ignored and the returned tag is nil.
+func (k *kmsCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithmStr string, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	algorithm := GetKMSEncryptionAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	ciphertext, err = k.encrypt(parentCtx, plaintext, algorithmStr, algorithm, key, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, nil, nil
+}
+
+func (k *kmsCrypto) encrypt(parentCtx context.Context, plaintext []byte, algorithmStr string, algorithm string, key string, associatedData []byte) (ciphertext []byte, err error) {
+	// Encrypting with symmetric or non-cacheable keys must happen in AWS KMS
+	if !isCacheable(key) || !IsAlgorithmAsymmetric(algorithm) {
+		return k.encryptInKMS(parentCtx, plaintext, algorithm, key, associatedData)
+	}
+
+	// AWS KMS doesn't support associated data (as OAEP label) with asymmetric keys, so it could not decrypt the message
+	if len(associatedData) > 0 {
+		return nil, errors.New("associated data is not supported with asymmetric keys")
+	}
+
+	// Using a cacheable, asymmetric key, we can encrypt the data directly here
+	pk, err := k.keyCache.GetKey(parentCtx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve public key: %w", err)
+	}
+
+	ciphertext, err = internals.EncryptPublicKey(plaintext, algorithmStr, pk, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	return ciphertext, nil
+}
+
+func (k *kmsCrypto) encryptInKMS(parentCtx context.Context, plaintext []byte, algorithm string, key string, associatedData []byte) (ciphertext []byte, err error) {
+	encryptionContext, err := getEncryptionContext(algorithm, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:               aws.String(key),
+		GrantTokens:         k.md.grantTokens(),
+		Plaintext:           plaintext,
+		EncryptionAlgorithm: aws.String(algorithm),
+		EncryptionContext:   encryptionContext,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from AWS KMS: %w", err)
+	}
+
+	if len(res.CiphertextBlob) == 0 {
+		return nil, errors.New("response from AWS KMS does not contain a valid ciphertext")
+	}
+
+	return res.CiphertextBlob, nil
+}
+
+// Decrypt a small message and returns the plaintext.
+// The nonce and tag arguments are ignored, as they are included in the ciphertext.
+func (k *kmsCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithmStr string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	algorithm := GetKMSEncryptionAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	return k.decryptInKMS(parentCtx, ciphertext, algorithm, key, associatedData)
+}
+
+func (k *kmsCrypto) decryptInKMS(parentCtx context.Context, ciphertext []byte, algorithm string, key string, associatedData []byte) (plaintext []byte, err error) {
+	encryptionContext, err := getEncryptionContext(algorithm, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:               aws.String(key),
+		GrantTokens:         k.md.grantTokens(),
+		CiphertextBlob:      ciphertext,
+		EncryptionAlgorithm: aws.String(algorithm),
+		EncryptionContext:   encryptionContext,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from AWS KMS: %w", err)
+	}
+
+	if res.Plaintext == nil {
+		return nil, errors.New("response from AWS KMS does not contain a valid plaintext")
+	}
+
+	return res.Plaintext, nil
+}
+
+// WrapKey wraps a symmetric key.
+func (k *kmsCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithmStr string, key string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys are small enough to be wrapped with AWS KMS
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	algorithm := GetKMSEncryptionAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	wrappedKey, err = k.encrypt(parentCtx, plaintext, algorithmStr, algorithm, key, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wrappedKey, nil, nil
+}
+
+// UnwrapKey unwraps a key.
+// Keys wrapped by WrapKey or generated with GenerateDataKey can be unwrapped.
+func (k *kmsCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithmStr string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	algorithm := GetKMSEncryptionAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	plaintext, err := k.decryptInKMS(parentCtx, wrappedKey, algorithm, key, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only symmetric keys can be wrapped, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// GenerateDataKey generates a symmetric data key of the given size in bytes, using GenerateDataKey in AWS KMS.
+// It returns the key in plaintext and wrapped with the symmetric key in AWS KMS; the wrapped key can be unwrapped with UnwrapKey, using the "SYMMETRIC_DEFAULT" (or "A256GCM") algorithm and the same associated data.
+func (k *kmsCrypto) GenerateDataKey(parentCtx context.Context, key string, size int, associatedData []byte) (plaintextKey jwk.Key, wrappedKey []byte, err error) {
+	if size <= 0 || size > 1024 {
+		return nil, nil, errors.New("size of the data key must be between 1 and 1024 bytes")
+	}
+
+	encryptionContext, err := getEncryptionContext(algorithmSymmetricDefault, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(key),
+		GrantTokens:       k.md.grantTokens(),
+		NumberOfBytes:     aws.Int64(int64(size)),
+		EncryptionContext: encryptionContext,
+	})
+	cancel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error from AWS KMS: %w", err)
+	}
+
+	if len(res.Plaintext) == 0 || len(res.CiphertextBlob) == 0 {
+		return nil, nil, errors.New("response from AWS KMS does not contain a valid data key")
+	}
+
+	plaintextKey, err = jwk.FromRaw(res.Plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, res.CiphertextBlob, nil
+}
+
+// Sign a digest.
+func (k *kmsCrypto) Sign(parentCtx context.Context, digest []byte, algorithmStr string, key string) (signature []byte, err error) {
+	algorithm := GetKMSSignatureAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).SignWithContext(ctx, &kms.SignInput{
+		KeyId:            aws.String(key),
+		GrantTokens:      k.md.grantTokens(),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from AWS KMS: %w", err)
+	}
+
+	if len(res.Signature) == 0 {
+		return nil, errors.New("response from AWS KMS does not contain a valid signature")
+	}
+
+	return res.Signature, nil
+}
+
+// Verify a signature.
+func (k *kmsCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithmStr string, key string) (valid bool, err error) {
+	algorithm := GetKMSSignatureAlgorithm(algorithmStr)
+	if algorithm == "" {
+		return false, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	// Verifying with non-cacheable keys must happen in AWS KMS
+	if !isCacheable(key) {
+		return k.verifyInKMS(parentCtx, digest, signature, algorithm, key)
+	}
+
+	// Using a cacheable key, we can verify the data directly here
+	pk, err := k.keyCache.GetKey(parentCtx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve public key: %w", err)
+	}
+
+	valid, err = internals.VerifyPublicKey(digest, signature, algorithmStr, pk)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify signature: %w", err)
+	}
+	return valid, nil
+}
+
+func (k *kmsCrypto) verifyInKMS(parentCtx context.Context, digest []byte, signature []byte, algorithm string, key string) (valid bool, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client(key).VerifyWithContext(ctx, &kms.VerifyInput{
+		KeyId:            aws.String(key),
+		GrantTokens:      k.md.grantTokens(),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		Signature:        signature,
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	cancel()
+	if err != nil {
+		// AWS KMS returns an error when the signature is not valid
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == kms.ErrCodeKMSInvalidSignatureException {
+			return false, nil
+		}
+		return false, fmt.Errorf("error from AWS KMS: %w", err)
+	}
+
+	if res.SignatureValid == nil {
+		return false, errors.New("response from AWS KMS does not contain a valid response")
+	}
+
+	return *res.SignatureValid, nil
+}
+
+func (k *kmsCrypto) Close() error {
+	if k.authProvider != nil {
+		return k.authProvider.Close()
+	}
+	return nil
+}
+
+func (kmsCrypto) SupportedEncryptionAlgorithms() []string {
+	return encryptionAlgsList
+}
+
+func (kmsCrypto) SupportedSignatureAlgorithms() []string {
+	return signatureAlgsList
+}
+
+func (kmsCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := kmsMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
+
+// PublicKeyToKey converts the response of GetPublicKey to a contribCrypto.Key object.
+func PublicKeyToKey(res *kms.GetPublicKeyOutput) (*contribCrypto.Key, error) {
+	if res == nil || res.KeyId == nil || len(res.PublicKey) == 0 {
+		return nil, errors.New("response from AWS KMS does not contain a valid public key")
+	}
+
+	// AWS KMS returns the public key as a DER-encoded X.509 SubjectPublicKeyInfo
+	pk, err := x509.ParsePKIXPublicKey(res.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	jwkObj, err := jwk.FromRaw(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwk.Key: %w", err)
+	}
+
+	switch aws.StringValue(res.KeyUsage) {
+	case kms.KeyUsageTypeSignVerify:
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	case kms.KeyUsageTypeEncryptDecrypt:
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForEncryption)
+	}
+
+	return contribCrypto.NewKey(jwkObj, aws.StringValue(res.KeyId), nil, nil), nil
+}
+
+// Returns the encryption context containing the associated data.
+// Encryption contexts are only supported by symmetric keys.
+func getEncryptionContext(algorithm string, associatedData []byte) (map[string]*string, error) {
+	if len(associatedData) == 0 {
+		return nil, nil
+	}
+	if IsAlgorithmAsymmetric(algorithm) {
+		return nil, errors.New("associated data is not supported with asymmetric keys")
+	}
+	return map[string]*string{
+		encryptionContextAssociatedData: aws.String(base64.StdEncoding.EncodeToString(associatedData)),
+	}, nil
+}
+
+// Returns the client for the region of the key.
+func (k *kmsCrypto) client(key string) kmsiface.KMSAPI {
+	return k.authProvider.KMS().ForRegion(keyRegion(key))
+}
+
+// Returns the region of a key or alias ARN, or an empty string for key IDs and alias names, which are in the default region.
+func keyRegion(key string) string {
+	if !arn.IsARN(key) {
+		return ""
+	}
+	parsed, err := arn.Parse(key)
+	if err != nil {
+		return ""
+	}
+	return parsed.Region
+}
+
+// Returns true if the public key can be cached.
+// Aliases can be updated to point to a different key, so they are not cacheable.
+func isCacheable(key string) bool {
+	return !strings.HasPrefix(key, "alias/") && !strings.Contains(key, ":alias/")
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	testKeyID    = "1234abcd-12ab-34cd-56ef-1234567890ab"
+	testKeyARN   = "arn:aws:kms:us-west-2:111122223333:key/" + testKeyID
+	testKeyAlias = "alias/mykey"
+)
+
+// fakeKMS returns a MockKMS that performs the operations with a local RSA key and a fake symmetric cipher.
+func fakeKMS(t *testing.T) (*awsAuth.MockKMS, *atomic.Int32) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privJWK, err := jwk.FromRaw(privKey)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	require.NoError(t, err)
+
+	// Fake symmetric "cipher" that prefixes the plaintext with the associated data
+	seal := func(plaintext []byte, ec map[string]*string) []byte {
+		return append([]byte(aws.StringValue(ec[encryptionContextAssociatedData])+"|"), plaintext...)
+	}
+
+	getPublicKeyCalls := &atomic.Int32{}
+	return &awsAuth.MockKMS{
+		GetPublicKeyFn: func(ctx context.Context, input *kms.GetPublicKeyInput, option ...request.Option) (*kms.GetPublicKeyOutput, error) {
+			getPublicKeyCalls.Add(1)
+			return &kms.GetPublicKeyOutput{
+				KeyId:     aws.String(testKeyARN),
+				KeyUsage:  aws.String(kms.KeyUsageTypeEncryptDecrypt),
+				PublicKey: der,
+			}, nil
+		},
+		EncryptFn: func(ctx context.Context, input *kms.EncryptInput, option ...request.Option) (*kms.EncryptOutput, error) {
+			if aws.StringValue(input.EncryptionAlgorithm) != kms.EncryptionAlgorithmSpecSymmetricDefault {
+				ct, err := internals.EncryptPublicKey(input.Plaintext, internals.Algorithm_RSA_OAEP_256, privJWK, nil)
+				return &kms.EncryptOutput{CiphertextBlob: ct}, err
+			}
+			return &kms.EncryptOutput{CiphertextBlob: seal(input.Plaintext, input.EncryptionContext)}, nil
+		},
+		DecryptFn: func(ctx context.Context, input *kms.DecryptInput, option ...request.Option) (*kms.DecryptOutput, error) {
+			if aws.StringValue(input.EncryptionAlgorithm) != kms.EncryptionAlgorithmSpecSymmetricDefault {
+				pt, err := internals.DecryptPrivateKey(input.CiphertextBlob, internals.Algorithm_RSA_OAEP_256, privJWK, nil)
+				return &kms.DecryptOutput{Plaintext: pt}, err
+			}
+			prefix := seal(nil, input.EncryptionContext)
+			if !bytes.HasPrefix(input.CiphertextBlob, prefix) {
+				return nil, awserr.New(kms.ErrCodeInvalidCiphertextException, "invalid ciphertext", nil)
+			}
+			return &kms.DecryptOutput{Plaintext: input.CiphertextBlob[len(prefix):]}, nil
+		},
+		GenerateDataKeyFn: func(ctx context.Context, input *kms.GenerateDataKeyInput, option ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+			pt := bytes.Repeat([]byte{0x42}, int(aws.Int64Value(input.NumberOfBytes)))
+			return &kms.GenerateDataKeyOutput{
+				Plaintext:      pt,
+				CiphertextBlob: seal(pt, input.EncryptionContext),
+			}, nil
+		},
+		SignFn: func(ctx context.Context, input *kms.SignInput, option ...request.Option) (*kms.SignOutput, error) {
+			assert.Equal(t, kms.MessageTypeDigest, aws.StringValue(input.MessageType))
+			assert.Equal(t, kms.SigningAlgorithmSpecRsassaPssSha256, aws.StringValue(input.SigningAlgorithm))
+			sig, err := internals.SignPrivateKey(input.Message, internals.Algorithm_PS256, privJWK)
+			return &kms.SignOutput{Signature: sig}, err
+		},
+		VerifyFn: func(ctx context.Context, input *kms.VerifyInput, option ...request.Option) (*kms.VerifyOutput, error) {
+			valid, err := internals.VerifyPublicKey(input.Message, input.Signature, internals.Algorithm_PS256, privJWK)
+			if err == nil && !valid {
+				err = awserr.New(kms.ErrCodeKMSInvalidSignatureException, "invalid signature", nil)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &kms.VerifyOutput{SignatureValid: aws.Bool(true)}, nil
+		},
+	}, getPublicKeyCalls
+}
+
+func newTestCrypto(t *testing.T, mock *awsAuth.MockKMS) *kmsCrypto {
+	k := NewAWSKMSCrypto(logger.NewLogger("test")).(*kmsCrypto)
+	err := k.md.InitWithMetadata(contribCrypto.Metadata{})
+	require.NoError(t, err)
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+
+	mockAuthProvider := &awsAuth.StaticAuth{}
+	mockAuthProvider.WithMockClients(&awsAuth.Clients{
+		KMS: &awsAuth.KMSClients{
+			KMS: mock,
+		},
+	})
+	k.authProvider = mockAuthProvider
+	return k
+}
+
+func TestGetKey(t *testing.T) {
+	mock, calls := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+
+	t.Run("key IDs are cached", func(t *testing.T) {
+		for range 2 {
+			pk, err := k.GetKey(context.Background(), testKeyID)
+			require.NoError(t, err)
+			assert.Equal(t, testKeyARN, pk.(*contribCrypto.Key).KeyID())
+			assert.Equal(t, jwk.ForEncryption.String(), pk.KeyUsage())
+		}
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("aliases are not cached", func(t *testing.T) {
+		calls.Store(0)
+		for range 2 {
+			_, err := k.GetKey(context.Background(), testKeyAlias)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	mock, _ := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+	plaintext := []byte("hello world")
+
+	t.Run("asymmetric key encrypts locally", func(t *testing.T) {
+		encryptCalls := 0
+		encryptFn := mock.EncryptFn
+		mock.EncryptFn = func(ctx context.Context, input *kms.EncryptInput, option ...request.Option) (*kms.EncryptOutput, error) {
+			encryptCalls++
+			return encryptFn(ctx, input, option...)
+		}
+		defer func() {
+			mock.EncryptFn = encryptFn
+		}()
+
+		ct, tag, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, testKeyID, nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+		assert.Equal(t, 0, encryptCalls)
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_RSA_OAEP_256, testKeyID, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		// With aliases, encryption happens in KMS
+		_, _, err = k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, testKeyAlias, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, encryptCalls)
+	})
+
+	t.Run("symmetric key with associated data", func(t *testing.T) {
+		aad := []byte("context")
+		ct, _, err := k.Encrypt(context.Background(), plaintext, algorithmSymmetricDefault, testKeyAlias, nil, aad)
+		require.NoError(t, err)
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, testKeyAlias, nil, nil, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		_, err = k.Decrypt(context.Background(), ct, algorithmSymmetricDefault, testKeyAlias, nil, nil, []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("associated data with asymmetric key", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP, testKeyID, nil, []byte("aad"))
+		require.EqualError(t, err, "associated data is not supported with asymmetric keys")
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A128CBC, testKeyID, nil, nil)
+		require.EqualError(t, err, "invalid algorithm: A128CBC")
+	})
+}
+
+func TestWrapKey(t *testing.T) {
+	mock, _ := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+
+	t.Run("wrap and unwrap", func(t *testing.T) {
+		key, err := jwk.FromRaw(bytes.Repeat([]byte{0x01}, 32))
+		require.NoError(t, err)
+
+		wrapped, _, err := k.WrapKey(context.Background(), key, algorithmSymmetricDefault, testKeyID, nil, nil)
+		require.NoError(t, err)
+
+		unwrapped, err := k.UnwrapKey(context.Background(), wrapped, algorithmSymmetricDefault, testKeyID, nil, nil, nil)
+		require.NoError(t, err)
+		raw := []byte{}
+		require.NoError(t, unwrapped.Raw(&raw))
+		assert.Equal(t, bytes.Repeat([]byte{0x01}, 32), raw)
+	})
+
+	t.Run("generated data key", func(t *testing.T) {
+		aad := []byte("context")
+		key, wrapped, err := k.GenerateDataKey(context.Background(), testKeyID, 32, aad)
+		require.NoError(t, err)
+
+		unwrapped, err := k.UnwrapKey(context.Background(), wrapped, algorithmSymmetricDefault, testKeyID, nil, nil, aad)
+		require.NoError(t, err)
+		assert.True(t, jwk.Equal(key, unwrapped))
+	})
+
+	t.Run("invalid data key size", func(t *testing.T) {
+		_, _, err := k.GenerateDataKey(context.Background(), testKeyID, 0, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("asymmetric keys cannot be wrapped", func(t *testing.T) {
+		privKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(privKey)
+		require.NoError(t, err)
+
+		_, _, err = k.WrapKey(context.Background(), key, algorithmSymmetricDefault, testKeyID, nil, nil)
+		require.EqualError(t, err, "cannot wrap asymmetric keys")
+	})
+}
+
+func TestSignVerify(t *testing.T) {
+	mock, _ := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+	digest := sha256.Sum256([]byte("hello world"))
+
+	sig, err := k.Sign(context.Background(), digest[:], internals.Algorithm_PS256, testKeyAlias)
+	require.NoError(t, err)
+
+	for _, key := range []string{testKeyID, testKeyAlias} {
+		t.Run(key, func(t *testing.T) {
+			valid, err := k.Verify(context.Background(), digest[:], sig, internals.Algorithm_PS256, key)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			otherDigest := sha256.Sum256([]byte("other"))
+			valid, err = k.Verify(context.Background(), otherDigest[:], sig, internals.Algorithm_PS256, key)
+			require.NoError(t, err)
+			assert.False(t, valid)
+		})
+	}
+
+	t.Run("errors from KMS", func(t *testing.T) {
+		verifyFn := mock.VerifyFn
+		mock.VerifyFn = func(ctx context.Context, input *kms.VerifyInput, option ...request.Option) (*kms.VerifyOutput, error) {
+			return nil, errors.New("simulated")
+		}
+		defer func() {
+			mock.VerifyFn = verifyFn
+		}()
+
+		_, err := k.Verify(context.Background(), digest[:], sig, internals.Algorithm_PS256, testKeyAlias)
+		require.ErrorContains(t, err, "simulated")
+	})
+}
+
+func TestMultiRegionKeys(t *testing.T) {
+	mock, _ := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+
+	// Requests for the replica key are sent to the client for its region
+	replicaMock, _ := fakeKMS(t)
+	var replicaCalls atomic.Int32
+	encryptFn := replicaMock.EncryptFn
+	replicaMock.EncryptFn = func(ctx context.Context, input *kms.EncryptInput, option ...request.Option) (*kms.EncryptOutput, error) {
+		replicaCalls.Add(1)
+		return encryptFn(ctx, input, option...)
+	}
+	k.authProvider.KMS().Regional = map[string]kmsiface.KMSAPI{
+		"eu-west-1": replicaMock,
+	}
+
+	const replicaARN = "arn:aws:kms:eu-west-1:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+	_, _, err := k.Encrypt(context.Background(), []byte("hello world"), internals.Algorithm_A256GCM, replicaARN, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), replicaCalls.Load())
+
+	// Alias names and ARNs in the default region use the default client
+	for _, key := range []string{testKeyAlias, "arn:aws:kms:us-west-2:111122223333:alias/mykey"} {
+		_, _, err = k.Encrypt(context.Background(), []byte("hello world"), internals.Algorithm_A256GCM, key, nil, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), replicaCalls.Load())
+}
+
+func TestGrantTokens(t *testing.T) {
+	mock, _ := fakeKMS(t)
+	k := newTestCrypto(t, mock)
+	err := k.md.InitWithMetadata(contribCrypto.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"grantTokens": "token1, token2,",
+		},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token1", "token2"}, k.md.GrantTokens)
+
+	signFn := mock.SignFn
+	mock.SignFn = func(ctx context.Context, input *kms.SignInput, option ...request.Option) (*kms.SignOutput, error) {
+		assert.Equal(t, []string{"token1", "token2"}, aws.StringValueSlice(input.GrantTokens))
+		return signFn(ctx, input, option...)
+	}
+	digest := sha256.Sum256([]byte("hello world"))
+	_, err = k.Sign(context.Background(), digest[:], internals.Algorithm_PS256, testKeyID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+type kmsMetadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	AccessKey    string `json:"accessKey" mapstructure:"accessKey" mdignore:"true"`
+	SecretKey    string `json:"secretKey" mapstructure:"secretKey" mdignore:"true"`
+	SessionToken string `json:"sessionToken" mapstructure:"sessionToken" mdignore:"true"`
+	Region       string `json:"region" mapstructure:"region" mapstructurealiases:"awsRegion" mdignore:"true"`
+
+	// Endpoint of the AWS KMS service, to use a custom or VPC endpoint.
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+
+	// Timeout for network requests, as a Go duration string (e.g. "30s")
+	// Defaults to "30s".
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+
+	// Grant tokens to include in requests to AWS KMS, as a comma-separated list.
+	// They allow using permissions from grants that are not eventually consistent yet.
+	GrantTokens []string `json:"grantTokens" mapstructure:"grantTokens"`
+}
+
+func (m *kmsMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Set default requestTimeout if empty
+	if m.RequestTimeout < time.Second {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	// Remove empty grant tokens
+	grantTokens := make([]string, 0, len(m.GrantTokens))
+	for _, token := range m.GrantTokens {
+		token = strings.TrimSpace(token)
+		if token != "" {
+			grantTokens = append(grantTokens, token)
+		}
+	}
+	m.GrantTokens = grantTokens
+
+	return nil
+}
+
+// Reset the object
+func (m *kmsMetadata) reset() {
+	m.AccessKey = ""
+	m.SecretKey = ""
+	m.SessionToken = ""
+	m.Region = ""
+	m.Endpoint = ""
+	m.RequestTimeout = defaultRequestTimeout
+	m.GrantTokens = nil
+}
+
+// Returns the grant tokens to include in requests, or nil if there's none.
+func (m *kmsMetadata) grantTokens() []*string {
+	if len(m.GrantTokens) == 0 {
+		return nil
+	}
+	return aws.StringSlice(m.GrantTokens)
+}
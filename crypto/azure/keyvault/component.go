@@ -39,6 +39,9 @@ type keyvaultCrypto struct {
 	md          keyvaultMetadata
 	vaultClient *azkeys.Client
 	logger      logger.Logger
+
+	// Cache of the latest version of keys; nil if resolving the latest version is disabled
+	latestVersions *latestVersions
 }
 
 // NewAzureKeyvaultCrypto returns a new Azure Key Vault crypto provider.
@@ -79,6 +82,9 @@ func (k *keyvaultCrypto) Init(_ context.Context, metadata contribCrypto.Metadata
 
 	// Create a cache for keys
 	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+	if k.md.LatestKeyRefreshInterval > 0 {
+		k.latestVersions = newLatestVersions(k.md.LatestKeyRefreshInterval, k.getLatestVersion, k.logger)
+	}
 
 	// Init the Azure SDK client
 	k.vaultClient, err = azkeys.NewClient(k.getVaultURI(), k.md.cred, &azkeys.ClientOptions{
@@ -104,11 +110,14 @@ func (k *keyvaultCrypto) Features() []contribCrypto.Feature {
 // This method returns an error if the key is symmetric.
 // The key argument can be in the format "name" or "name/version".
 func (k *keyvaultCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
-	kid := newKeyID(key)
+	kid, err := k.resolveKeyID(parentCtx, newKeyID(key))
+	if err != nil {
+		return nil, err
+	}
 
 	// If the key is cacheable, get it from the cache
 	if kid.Cacheable() {
-		return k.keyCache.GetKey(parentCtx, key)
+		return k.keyCache.GetKey(parentCtx, kid.String())
 	}
 
 	return k.getKeyFromVault(parentCtx, kid)
@@ -125,6 +134,35 @@ func (k *keyvaultCrypto) getKeyFromVault(parentCtx context.Context, kid keyID) (
 	return KeyBundleToKey(&res.KeyBundle)
 }
 
+// Returns the current version of a key.
+func (k *keyvaultCrypto) getLatestVersion(parentCtx context.Context, name string) (string, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.vaultClient.GetKey(ctx, name, "", nil)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get key from Key Vault: %w", err)
+	}
+
+	if res.Key == nil || res.Key.KID == nil || res.Key.KID.Version() == "" {
+		return "", errKeyNotFound
+	}
+	return res.Key.KID.Version(), nil
+}
+
+// resolveKeyID pins keys addressed without a version to their latest version, if enabled in the metadata.
+// The resolved key ID is cacheable, so public keys can be used locally.
+func (k *keyvaultCrypto) resolveKeyID(ctx context.Context, kid keyID) (keyID, error) {
+	if k.latestVersions == nil || kid.Cacheable() {
+		return kid, nil
+	}
+
+	version, err := k.latestVersions.Resolve(ctx, kid.Name)
+	if err != nil {
+		return keyID{}, fmt.Errorf("failed to resolve the latest version of the key: %w", err)
+	}
+	return keyID{Name: kid.Name, Version: version}, nil
+}
+
 // Handler for the getKeyCacheFn method
 func (k *keyvaultCrypto) getKeyCacheFn(ctx context.Context, key string) func(resolve func(jwk.Key), reject func(error)) {
 	kid := newKeyID(key)
@@ -141,7 +179,10 @@ func (k *keyvaultCrypto) getKeyCacheFn(ctx context.Context, key string) func(res
 // Encrypt a small message and returns the ciphertext.
 // The key argument can be in the format "name" or "name/version".
 func (k *keyvaultCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithmStr string, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	kid := newKeyID(key)
+	kid, err := k.resolveKeyID(parentCtx, newKeyID(key))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	algorithm := GetJWKEncryptionAlgorithm(algorithmStr)
 	if algorithm == nil {
@@ -154,7 +195,7 @@ func (k *keyvaultCrypto) Encrypt(parentCtx context.Context, plaintext []byte, al
 	}
 
 	// Using a cacheable, asymmetric key, we can encrypt the data directly here
-	pk, err := k.keyCache.GetKey(parentCtx, key)
+	pk, err := k.keyCache.GetKey(parentCtx, kid.String())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to retrieve public key: %w", err)
 	}
@@ -233,7 +274,10 @@ func (k *keyvaultCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key
 		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
 	}
 
-	kid := newKeyID(key)
+	kid, err := k.resolveKeyID(parentCtx, newKeyID(key))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	algorithm := GetJWKEncryptionAlgorithm(algorithmStr)
 	if algorithm == nil {
@@ -246,7 +290,7 @@ func (k *keyvaultCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key
 	}
 
 	// Using a cacheable, asymmetric key, we can encrypt the data directly here
-	pk, err := k.keyCache.GetKey(parentCtx, key)
+	pk, err := k.keyCache.GetKey(parentCtx, kid.String())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to retrieve public key: %w", err)
 	}
@@ -322,7 +366,10 @@ func (k *keyvaultCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte,
 // Sign a digest.
 // The key argument can be in the format "name" or "name/version".
 func (k *keyvaultCrypto) Sign(parentCtx context.Context, digest []byte, algorithmStr string, key string) (signature []byte, err error) {
-	kid := newKeyID(key)
+	kid, err := k.resolveKeyID(parentCtx, newKeyID(key))
+	if err != nil {
+		return nil, err
+	}
 
 	algorithm := GetJWKSignatureAlgorithm(algorithmStr)
 	if algorithm == nil {
@@ -362,7 +409,7 @@ func (k *keyvaultCrypto) Verify(parentCtx context.Context, digest []byte, signat
 	}
 
 	// Using a cacheable, asymmetric key, we can verify the data directly here
-	pk, err := k.keyCache.GetKey(parentCtx, key)
+	pk, err := k.keyCache.GetKey(parentCtx, kid.String())
 	if err != nil {
 		return false, fmt.Errorf("failed to retrieve public key: %w", err)
 	}
@@ -434,6 +481,13 @@ func newKeyID(val string) keyID {
 	return obj
 }
 
+func (id keyID) String() string {
+	if id.Version == "" {
+		return id.Name
+	}
+	return id.Name + "/" + id.Version
+}
+
 // Cacheable returns true if the key can be cached locally.
 func (id keyID) Cacheable() bool {
 	switch strings.ToLower(id.Version) {
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyvault
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"github.com/dapr/kit/logger"
+)
+
+// resolveVersionFn is the type of the function that returns the current version of a key.
+type resolveVersionFn = func(ctx context.Context, name string) (string, error)
+
+// latestVersions resolves the current version of keys and caches it for a given interval.
+// This allows operations on keys addressed without a version to use a pinned version, and pick up rotated keys once the cached entry is refreshed.
+type latestVersions struct {
+	interval  time.Duration
+	resolveFn resolveVersionFn
+	clock     clock.Clock
+	logger    logger.Logger
+
+	entries map[string]latestVersion
+	lock    sync.Mutex
+}
+
+type latestVersion struct {
+	version string
+	expires time.Time
+}
+
+func newLatestVersions(interval time.Duration, resolveFn resolveVersionFn, log logger.Logger) *latestVersions {
+	return &latestVersions{
+		interval:  interval,
+		resolveFn: resolveFn,
+		clock:     clock.RealClock{},
+		logger:    log,
+		entries:   make(map[string]latestVersion),
+	}
+}
+
+// Resolve returns the current version of the key, from the cache if not expired.
+// If the refresh fails, the version that was previously resolved is returned if present.
+func (l *latestVersions) Resolve(ctx context.Context, name string) (string, error) {
+	l.lock.Lock()
+	entry, ok := l.entries[name]
+	l.lock.Unlock()
+
+	now := l.clock.Now()
+	if ok && now.Before(entry.expires) {
+		return entry.version, nil
+	}
+
+	// The lock is not held while resolving the version, so concurrent callers may refresh the same key, which is harmless
+	version, err := l.resolveFn(ctx, name)
+	if err != nil {
+		if !ok {
+			return "", err
+		}
+		l.logger.Warnf("Failed to refresh the latest version of key '%s', using cached version '%s': %v", name, entry.version, err)
+		return entry.version, nil
+	}
+
+	if ok && version != entry.version {
+		l.logger.Infof("Key '%s' was rotated: latest version is now '%s'", name, version)
+	}
+	l.lock.Lock()
+	l.entries[name] = latestVersion{
+		version: version,
+		expires: now.Add(l.interval),
+	}
+	l.lock.Unlock()
+	return version, nil
+}
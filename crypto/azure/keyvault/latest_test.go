@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyvault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestLatestVersions(t *testing.T) {
+	var (
+		version = "v1"
+		calls   = 0
+		fail    = false
+	)
+	resolveFn := func(ctx context.Context, name string) (string, error) {
+		calls++
+		if fail {
+			return "", errors.New("simulated")
+		}
+		return name + "-" + version, nil
+	}
+
+	clock := clocktesting.NewFakeClock(time.Now())
+	l := newLatestVersions(time.Minute, resolveFn, logger.NewLogger("test"))
+	l.clock = clock
+
+	t.Run("version is cached", func(t *testing.T) {
+		for range 3 {
+			v, err := l.Resolve(context.Background(), "mykey")
+			require.NoError(t, err)
+			assert.Equal(t, "mykey-v1", v)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("rotated key is picked up after the interval", func(t *testing.T) {
+		version = "v2"
+		clock.Step(30 * time.Second)
+		v, err := l.Resolve(context.Background(), "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, "mykey-v1", v)
+
+		clock.Step(31 * time.Second)
+		v, err = l.Resolve(context.Background(), "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, "mykey-v2", v)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("cached version is used if refreshing fails", func(t *testing.T) {
+		fail = true
+		defer func() {
+			fail = false
+		}()
+
+		clock.Step(2 * time.Minute)
+		v, err := l.Resolve(context.Background(), "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, "mykey-v2", v)
+
+		_, err = l.Resolve(context.Background(), "otherkey")
+		require.EqualError(t, err, "simulated")
+	})
+}
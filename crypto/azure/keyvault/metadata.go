@@ -34,6 +34,11 @@ type keyvaultMetadata struct {
 	// Defaults to "30s".
 	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
 
+	// If set, keys addressed without a version (or with version "latest") are resolved to their current version, which is cached for this interval, as a Go duration string (e.g. "5m").
+	// Operations that encrypt, wrap keys, or sign then use the resolved version, picking up rotated keys once the interval elapses; decryption, unwrapping, and verification of signatures are not affected.
+	// Defaults to "0", which disables resolving the latest version.
+	LatestKeyRefreshInterval time.Duration `json:"latestKeyRefreshInterval" mapstructure:"latestKeyRefreshInterval"`
+
 	// Internal properties
 	vaultDNSSuffix string
 	cred           azcore.TokenCredential
@@ -43,7 +48,7 @@ func (m *keyvaultMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 	m.reset()
 
 	// Decode the metadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err := metadata.DecodeMetadata(meta.Properties, m)
 	if err != nil {
 		return err
 	}
@@ -58,12 +63,19 @@ func (m *keyvaultMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 		m.RequestTimeout = defaultRequestTimeout
 	}
 
+	if m.LatestKeyRefreshInterval < 0 {
+		return errors.New("metadata property 'latestKeyRefreshInterval' must not be negative")
+	}
+
 	// Get the DNS suffix
 	settings, err := azauth.NewEnvironmentSettings(meta.Properties)
 	if err != nil {
 		return err
 	}
-	m.vaultDNSSuffix = settings.EndpointSuffix(azauth.ServiceAzureKeyVault)
+	m.vaultDNSSuffix, err = settings.GetEndpointSuffix(azauth.ServiceAzureKeyVault)
+	if err != nil {
+		return err
+	}
 
 	// Get the credentials object
 	m.cred, err = settings.GetTokenCredential()
@@ -78,6 +90,7 @@ func (m *keyvaultMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 func (m *keyvaultMetadata) reset() {
 	m.VaultName = ""
 	m.RequestTimeout = defaultRequestTimeout
+	m.LatestKeyRefreshInterval = 0
 
 	m.vaultDNSSuffix = ""
 	m.cred = nil
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/crypto/aeskw"
+)
+
+// ECDH-ES key wrapping algorithms supported by the local crypto components, with the size of the derived key encryption key.
+var ecdhesKeyWrapAlgorithms = map[string]int{
+	internals.Algorithm_ECDH_ES_A128KW: 16,
+	internals.Algorithm_ECDH_ES_A192KW: 24,
+	internals.Algorithm_ECDH_ES_A256KW: 32,
+}
+
+// isECDHESKeyWrapAlgorithm returns true if the algorithm is one of ECDH-ES+A128KW, ECDH-ES+A192KW, or ECDH-ES+A256KW.
+func isECDHESKeyWrapAlgorithm(alg string) bool {
+	_, ok := ecdhesKeyWrapAlgorithms[alg]
+	return ok
+}
+
+// wrapKeyECDHES wraps a symmetric key with ECDH-ES key agreement and AES Key Wrap, as defined in RFC 7518, section 4.6.
+// The key can be an X25519 key or an EC key on a NIST curve; only its public part is used.
+// Since there's no JWE header to carry it, the ephemeral public key is prepended to the wrapped key, ECIES-style.
+func wrapKeyECDHES(cek []byte, algorithm string, key jwk.Key) ([]byte, error) {
+	kekSize, ok := ecdhesKeyWrapAlgorithms[algorithm]
+	if !ok {
+		return nil, internals.ErrUnsupportedAlgorithm
+	}
+
+	pubKey, err := ecdhPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := pubKey.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	z, err := ephemeral.ECDH(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform key agreement: %w", err)
+	}
+
+	block, err := aes.NewCipher(concatKDF(z, algorithm, kekSize))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := aeskw.Wrap(block, cek)
+	if err != nil {
+		return nil, err
+	}
+
+	epk := ephemeral.PublicKey().Bytes()
+	return append(epk, wrapped...), nil
+}
+
+// unwrapKeyECDHES unwraps a key wrapped by wrapKeyECDHES, using the private key.
+func unwrapKeyECDHES(wrappedKey []byte, algorithm string, key jwk.Key) ([]byte, error) {
+	kekSize, ok := ecdhesKeyWrapAlgorithms[algorithm]
+	if !ok {
+		return nil, internals.ErrUnsupportedAlgorithm
+	}
+
+	privKey, err := ecdhPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ephemeral public key has the same size as the public key of the recipient
+	epkSize := len(privKey.PublicKey().Bytes())
+	if len(wrappedKey) <= epkSize {
+		return nil, errors.New("wrapped key is too short")
+	}
+	epk, err := privKey.Curve().NewPublicKey(wrappedKey[:epkSize])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	z, err := privKey.ECDH(epk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform key agreement: %w", err)
+	}
+
+	block, err := aes.NewCipher(concatKDF(z, algorithm, kekSize))
+	if err != nil {
+		return nil, err
+	}
+	return aeskw.Unwrap(block, wrappedKey[epkSize:])
+}
+
+// Derives a key with the Concat KDF, as used by ECDH-ES in RFC 7518, section 4.6.2, with empty PartyUInfo and PartyVInfo.
+func concatKDF(z []byte, algorithm string, keySize int) []byte {
+	// keySize is at most 32 bytes, so a single round of SHA-256 is enough
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(1))
+	h.Write(z)
+	_ = binary.Write(h, binary.BigEndian, uint32(len(algorithm)))
+	h.Write([]byte(algorithm))
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyUInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyVInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(keySize*8))
+	return h.Sum(nil)[:keySize]
+}
+
+func ecdhPublicKey(key jwk.Key) (*ecdh.PublicKey, error) {
+	pubKey, err := key.PublicKey()
+	if err != nil {
+		return nil, internals.ErrKeyTypeMismatch
+	}
+
+	var rawKey any
+	err = pubKey.Raw(&rawKey)
+	if err != nil {
+		return nil, internals.ErrKeyTypeMismatch
+	}
+	switch r := rawKey.(type) {
+	case *ecdsa.PublicKey:
+		return r.ECDH()
+	case x25519.PublicKey:
+		return ecdh.X25519().NewPublicKey(r)
+	default:
+		return nil, internals.ErrKeyTypeMismatch
+	}
+}
+
+func ecdhPrivateKey(key jwk.Key) (*ecdh.PrivateKey, error) {
+	var rawKey any
+	err := key.Raw(&rawKey)
+	if err != nil {
+		return nil, internals.ErrKeyTypeMismatch
+	}
+	switch r := rawKey.(type) {
+	case *ecdsa.PrivateKey:
+		return r.ECDH()
+	case x25519.PrivateKey:
+		return ecdh.X25519().NewPrivateKey(r.Seed())
+	default:
+		return nil, internals.ErrKeyTypeMismatch
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECDHESKeyWrap(t *testing.T) {
+	_, x25519Key, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+
+	keys := map[string]jwk.Key{}
+	for name, raw := range map[string]any{"x25519": x25519Key, "p256": p256Key, "p521": p521Key} {
+		keys[name], err = jwk.FromRaw(raw)
+		require.NoError(t, err)
+	}
+
+	cek := make([]byte, 32)
+	_, err = rand.Read(cek)
+	require.NoError(t, err)
+
+	for name, key := range keys {
+		for alg := range ecdhesKeyWrapAlgorithms {
+			t.Run(name+" "+alg, func(t *testing.T) {
+				pubKey, err := key.PublicKey()
+				require.NoError(t, err)
+
+				wrapped, err := wrapKeyECDHES(cek, alg, pubKey)
+				require.NoError(t, err)
+
+				unwrapped, err := unwrapKeyECDHES(wrapped, alg, key)
+				require.NoError(t, err)
+				assert.Equal(t, cek, unwrapped)
+
+				// Each wrapping uses a different ephemeral key
+				wrapped2, err := wrapKeyECDHES(cek, alg, pubKey)
+				require.NoError(t, err)
+				assert.NotEqual(t, wrapped, wrapped2)
+			})
+		}
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, otherKey, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		otherJWK, err := jwk.FromRaw(otherKey)
+		require.NoError(t, err)
+
+		wrapped, err := wrapKeyECDHES(cek, "ECDH-ES+A256KW", keys["x25519"])
+		require.NoError(t, err)
+		_, err = unwrapKeyECDHES(wrapped, "ECDH-ES+A256KW", otherJWK)
+		require.Error(t, err)
+	})
+
+	t.Run("wrong algorithm", func(t *testing.T) {
+		wrapped, err := wrapKeyECDHES(cek, "ECDH-ES+A256KW", keys["x25519"])
+		require.NoError(t, err)
+		_, err = unwrapKeyECDHES(wrapped, "ECDH-ES+A128KW", keys["x25519"])
+		require.Error(t, err)
+	})
+
+	t.Run("public key cannot unwrap", func(t *testing.T) {
+		pubKey, err := keys["p256"].PublicKey()
+		require.NoError(t, err)
+		wrapped, err := wrapKeyECDHES(cek, "ECDH-ES+A256KW", pubKey)
+		require.NoError(t, err)
+		_, err = unwrapKeyECDHES(wrapped, "ECDH-ES+A256KW", pubKey)
+		require.Error(t, err)
+	})
+
+	t.Run("truncated key", func(t *testing.T) {
+		_, err := unwrapKeyECDHES(make([]byte, 32), "ECDH-ES+A256KW", keys["x25519"])
+		require.ErrorContains(t, err, "too short")
+	})
+
+	t.Run("symmetric key", func(t *testing.T) {
+		symKey, err := jwk.FromRaw(cek)
+		require.NoError(t, err)
+		_, err = wrapKeyECDHES(cek, "ECDH-ES+A256KW", symKey)
+		require.Error(t, err)
+	})
+}
+
+// Checks that keys wrapped with JOSE libraries can be unwrapped.
+func TestECDHESKeyWrapInterop(t *testing.T) {
+	_, x25519Key, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(x25519Key)
+	require.NoError(t, err)
+	pubKey, err := key.PublicKey()
+	require.NoError(t, err)
+
+	msg, err := jwe.Encrypt([]byte("hello world"), jwe.WithKey(jwa.ECDH_ES_A128KW, pubKey), jwe.WithContentEncryption(jwa.A128GCM))
+	require.NoError(t, err)
+	parts := strings.Split(string(msg), ".")
+	require.Len(t, parts, 5)
+
+	// Get the ephemeral public key from the header
+	parsed, err := jwe.Parse(msg)
+	require.NoError(t, err)
+	epk := parsed.ProtectedHeaders().EphemeralPublicKey()
+	require.NotNil(t, epk)
+	var epkRaw x25519.PublicKey
+	require.NoError(t, epk.Raw(&epkRaw))
+
+	encryptedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	cek, err := unwrapKeyECDHES(append(bytes.Clone(epkRaw), encryptedKey...), "ECDH-ES+A128KW", key)
+	require.NoError(t, err)
+
+	// Decrypt the content with the unwrapped key
+	iv, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[3])
+	require.NoError(t, err)
+	tag, err := base64.RawURLEncoding.DecodeString(parts[4])
+	require.NoError(t, err)
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(plaintext))
+}
+
+func TestLocalCryptoBaseComponentECDHES(t *testing.T) {
+	_, x25519Key, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(x25519Key)
+	require.NoError(t, err)
+	provider := newTestLocalCrypto(map[string]jwk.Key{"x25519": key})
+
+	assert.Contains(t, provider.SupportedEncryptionAlgorithms(), "ECDH-ES+A256KW")
+
+	cek := make([]byte, 32)
+	_, err = rand.Read(cek)
+	require.NoError(t, err)
+	plaintextKey, err := jwk.FromRaw(cek)
+	require.NoError(t, err)
+
+	wrapped, tag, err := provider.WrapKey(context.Background(), plaintextKey, "ECDH-ES+A256KW", "x25519", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, tag)
+
+	unwrapped, err := provider.UnwrapKey(context.Background(), wrapped, "ECDH-ES+A256KW", "x25519", nil, nil, nil)
+	require.NoError(t, err)
+	var unwrappedRaw []byte
+	require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+	assert.Equal(t, cek, unwrappedRaw)
+
+	// Envelope encryption works with X25519 keys too
+	env, err := EnvelopeEncrypt(context.Background(), provider, []byte("hello world"), "ECDH-ES+A256KW", "x25519", nil)
+	require.NoError(t, err)
+	plaintext, err := EnvelopeDecrypt(context.Background(), provider, env, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(plaintext))
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// Size of the data encryption keys used for envelope encryption, in bytes (AES-256).
+const envelopeDataKeySize = 32
+
+// DataKeyGenerator is implemented by crypto providers that can generate data keys, returning them both in plaintext and wrapped with a key stored in the provider.
+// Examples of components that implement this: crypto.aws.kms, crypto.hashicorp.vault
+type DataKeyGenerator interface {
+	GenerateDataKey(ctx context.Context, keyName string, size int, associatedData []byte) (plaintextKey jwk.Key, wrappedKey []byte, err error)
+}
+
+// Envelope contains data encrypted with envelope encryption.
+// The data is encrypted locally with AES-256-GCM, using a random data encryption key (DEK) that is wrapped with a key stored in the crypto provider.
+// This allows encrypting large payloads while sending only the DEK to the provider.
+type Envelope struct {
+	// Name of the key used to wrap the DEK.
+	KeyName string `json:"kid"`
+	// Algorithm used to wrap the DEK.
+	KeyWrapAlgorithm string `json:"alg"`
+	// The wrapped DEK.
+	WrappedKey []byte `json:"wk"`
+	// Nonce and tag used to wrap the DEK, for algorithms that need them.
+	KeyWrapNonce []byte `json:"wkn,omitempty"`
+	KeyWrapTag   []byte `json:"wkt,omitempty"`
+	// Nonce used to encrypt the data.
+	// For streams, this is the prefix of the nonces of the segments.
+	Nonce []byte `json:"n"`
+	// The encrypted data, including the authentication tag.
+	// This is empty for streams, whose encrypted data is stored separately.
+	Ciphertext []byte `json:"ct,omitempty"`
+	// Size of the plaintext segments, for streams encrypted with EnvelopeEncryptStream.
+	SegmentSize int `json:"ss,omitempty"`
+}
+
+// EnvelopeEncrypt encrypts data using envelope encryption.
+// If the provider implements DataKeyGenerator, the DEK is generated by the provider; otherwise, it's generated locally and wrapped with WrapKey, using the given algorithm.
+// Associated data is used to authenticate the encrypted data only, and it's not sent to the provider.
+func EnvelopeEncrypt(ctx context.Context, provider SubtleCrypto, plaintext []byte, keyWrapAlgorithm string, keyName string, associatedData []byte) (*Envelope, error) {
+	env, aead, err := newEnvelope(ctx, provider, keyWrapAlgorithm, keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt the data locally
+	env.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, associatedData)
+
+	return env, nil
+}
+
+// EnvelopeDecrypt decrypts data encrypted with EnvelopeEncrypt, unwrapping the DEK with the provider.
+// The associated data must be the same that was used to encrypt the data.
+func EnvelopeDecrypt(ctx context.Context, provider SubtleCrypto, env *Envelope, associatedData []byte) ([]byte, error) {
+	if env != nil && env.SegmentSize > 0 {
+		return nil, errors.New("envelope contains a stream, which must be decrypted with EnvelopeDecryptStream")
+	}
+	aead, err := openEnvelope(ctx, provider, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid envelope: nonce has the wrong size")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Creates a new envelope with a DEK, returning the cipher to encrypt the data.
+func newEnvelope(ctx context.Context, provider SubtleCrypto, keyWrapAlgorithm string, keyName string) (*Envelope, cipher.AEAD, error) {
+	env := &Envelope{
+		KeyName:          keyName,
+		KeyWrapAlgorithm: keyWrapAlgorithm,
+	}
+
+	// Get a DEK
+	var (
+		dek jwk.Key
+		err error
+	)
+	if generator, ok := provider.(DataKeyGenerator); ok {
+		dek, env.WrappedKey, err = generator.GenerateDataKey(ctx, keyName, envelopeDataKeySize, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+	} else {
+		rawKey := make([]byte, envelopeDataKeySize)
+		_, err = rand.Read(rawKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+		dek, err = jwk.FromRaw(rawKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+		}
+
+		if size := keyWrapNonceSize(keyWrapAlgorithm); size > 0 {
+			env.KeyWrapNonce = make([]byte, size)
+			_, err = rand.Read(env.KeyWrapNonce)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+			}
+		}
+		env.WrappedKey, env.KeyWrapTag, err = provider.WrapKey(ctx, dek, keyWrapAlgorithm, keyName, env.KeyWrapNonce, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to wrap data key: %w", err)
+		}
+	}
+
+	aead, err := envelopeCipher(dek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return env, aead, nil
+}
+
+// Unwraps the DEK of an envelope with the provider, returning the cipher to decrypt the data.
+func openEnvelope(ctx context.Context, provider SubtleCrypto, env *Envelope) (cipher.AEAD, error) {
+	if env == nil || len(env.WrappedKey) == 0 || env.KeyName == "" {
+		return nil, errors.New("invalid envelope")
+	}
+
+	dek, err := provider.UnwrapKey(ctx, env.WrappedKey, env.KeyWrapAlgorithm, env.KeyName, env.KeyWrapNonce, env.KeyWrapTag, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return envelopeCipher(dek)
+}
+
+func envelopeCipher(dek jwk.Key) (cipher.AEAD, error) {
+	if dek == nil || dek.KeyType() != jwa.OctetSeq {
+		return nil, errors.New("data key is not a symmetric key")
+	}
+	var rawKey []byte
+	err := dek.Raw(&rawKey)
+	if err != nil || len(rawKey) != envelopeDataKeySize {
+		return nil, errors.New("data key is not a valid AES-256 key")
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Returns the size of the nonce required by the key wrap algorithm, or 0 if the algorithm doesn't use a nonce.
+func keyWrapNonceSize(alg string) int {
+	switch {
+	case strings.HasPrefix(alg, "XC20P"):
+		return 24
+	case strings.HasPrefix(alg, "C20P"),
+		strings.HasPrefix(alg, "A") && strings.Contains(alg, "GCM"):
+		return 12
+	case strings.HasPrefix(alg, "A") && strings.Contains(alg, "CBC"):
+		return 16
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// testLocalCrypto is a SubtleCrypto that performs operations locally with keys kept in memory.
+type testLocalCrypto struct {
+	LocalCryptoBaseComponent
+
+	keys map[string]jwk.Key
+}
+
+func newTestLocalCrypto(keys map[string]jwk.Key) *testLocalCrypto {
+	c := &testLocalCrypto{keys: keys}
+	c.RetrieveKeyFn = func(_ context.Context, key string) (jwk.Key, error) {
+		k, ok := c.keys[key]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		return k, nil
+	}
+	return c
+}
+
+func (c *testLocalCrypto) Init(context.Context, Metadata) error { return nil }
+
+func (c *testLocalCrypto) GetComponentMetadata() metadata.MetadataMap { return nil }
+
+func (c *testLocalCrypto) Close() error { return nil }
+
+// testDataKeyCrypto is a testLocalCrypto that also implements DataKeyGenerator.
+type testDataKeyCrypto struct {
+	*testLocalCrypto
+
+	generated int
+}
+
+func (c *testDataKeyCrypto) GenerateDataKey(ctx context.Context, keyName string, size int, associatedData []byte) (jwk.Key, []byte, error) {
+	c.generated++
+	rawKey := make([]byte, size)
+	_, err := rand.Read(rawKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintextKey, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, _, err := c.WrapKey(ctx, plaintextKey, "A256KW", keyName, nil, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintextKey, wrappedKey, nil
+}
+
+func TestEnvelope(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaJWK, err := jwk.FromRaw(rsaKey)
+	require.NoError(t, err)
+	aesKey := make([]byte, 32)
+	_, err = rand.Read(aesKey)
+	require.NoError(t, err)
+	aesJWK, err := jwk.FromRaw(aesKey)
+	require.NoError(t, err)
+
+	provider := newTestLocalCrypto(map[string]jwk.Key{
+		"rsa": rsaJWK,
+		"aes": aesJWK,
+	})
+	ctx := context.Background()
+	plaintext := make([]byte, 1<<20)
+	_, err = rand.Read(plaintext)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg   string
+		key   string
+		nonce int
+	}{
+		{alg: "RSA-OAEP-256", key: "rsa"},
+		{alg: "A256KW", key: "aes"},
+		{alg: "A256GCM", key: "aes", nonce: 12},
+		{alg: "C20PKW", key: "aes", nonce: 12},
+		{alg: "XC20PKW", key: "aes", nonce: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			env, err := EnvelopeEncrypt(ctx, provider, plaintext, tt.alg, tt.key, []byte("aad"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, env.KeyName)
+			assert.Equal(t, tt.alg, env.KeyWrapAlgorithm)
+			assert.Len(t, env.KeyWrapNonce, tt.nonce)
+			assert.Len(t, env.Ciphertext, len(plaintext)+16)
+
+			decrypted, err := EnvelopeDecrypt(ctx, provider, env, []byte("aad"))
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		})
+	}
+
+	t.Run("wrong associated data", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(ctx, provider, []byte("hello world"), "A256KW", "aes", []byte("aad"))
+		require.NoError(t, err)
+
+		_, err = EnvelopeDecrypt(ctx, provider, env, []byte("other"))
+		require.ErrorContains(t, err, "failed to decrypt data")
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(ctx, provider, []byte("hello world"), "A256KW", "aes", nil)
+		require.NoError(t, err)
+
+		env.Ciphertext[0] ^= 0xff
+		_, err = EnvelopeDecrypt(ctx, provider, env, nil)
+		require.ErrorContains(t, err, "failed to decrypt data")
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := EnvelopeEncrypt(ctx, provider, []byte("hello world"), "A256KW", "notfound", nil)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("invalid envelope", func(t *testing.T) {
+		_, err := EnvelopeDecrypt(ctx, provider, nil, nil)
+		require.ErrorContains(t, err, "invalid envelope")
+		_, err = EnvelopeDecrypt(ctx, provider, &Envelope{KeyName: "aes"}, nil)
+		require.ErrorContains(t, err, "invalid envelope")
+	})
+
+	t.Run("data key generator", func(t *testing.T) {
+		generator := &testDataKeyCrypto{testLocalCrypto: provider}
+		env, err := EnvelopeEncrypt(ctx, generator, plaintext, "A256KW", "aes", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, generator.generated)
+		assert.Empty(t, env.KeyWrapNonce)
+
+		decrypted, err := EnvelopeDecrypt(ctx, generator, env, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("data key generator error", func(t *testing.T) {
+		generator := &testDataKeyCrypto{testLocalCrypto: newTestLocalCrypto(nil)}
+		_, err := EnvelopeEncrypt(ctx, generator, plaintext, "A256KW", "aes", nil)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudkms
+
+import (
+	"maps"
+	"slices"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+// Algorithm for the symmetric keys of Cloud KMS, which uses AES-256-GCM.
+// The nonce is generated by Cloud KMS and included in the ciphertext, together with the authentication tag.
+const algorithmGoogleSymmetric = "GOOGLE_SYMMETRIC_ENCRYPTION"
+
+// Supported encryption algorithms, and whether they are symmetric.
+// With asymmetric keys, the algorithm is set on the key version in Cloud KMS, so it is used to encrypt data locally only.
+var encryptionAlgs = map[string]bool{
+	internals.Algorithm_A256GCM:      true,
+	algorithmGoogleSymmetric:         true,
+	internals.Algorithm_RSA_OAEP:     false,
+	internals.Algorithm_RSA_OAEP_256: false,
+	internals.Algorithm_RSA_OAEP_512: false,
+}
+
+// Supported signature algorithms, and the function that returns the digest to sign for each one.
+var signatureAlgs = map[string]func(digest []byte) *kmspb.Digest{
+	internals.Algorithm_RS256: sha256Digest,
+	internals.Algorithm_RS512: sha512Digest,
+	internals.Algorithm_PS256: sha256Digest,
+	internals.Algorithm_PS512: sha512Digest,
+	internals.Algorithm_ES256: sha256Digest,
+	internals.Algorithm_ES384: sha384Digest,
+}
+
+var (
+	encryptionAlgsList = slices.Sorted(maps.Keys(encryptionAlgs))
+	signatureAlgsList  = slices.Sorted(maps.Keys(signatureAlgs))
+)
+
+// IsAlgorithmSymmetric returns true if the encryption algorithm is symmetric.
+// The second returned value is false if the algorithm is not supported.
+func IsAlgorithmSymmetric(algorithm string) (symmetric bool, ok bool) {
+	symmetric, ok = encryptionAlgs[algorithm]
+	return symmetric, ok
+}
+
+func sha256Digest(digest []byte) *kmspb.Digest {
+	return &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}}
+}
+
+func sha384Digest(digest []byte) *kmspb.Digest {
+	return &kmspb.Digest{Digest: &kmspb.Digest_Sha384{Sha384: digest}}
+}
+
+func sha512Digest(digest []byte) *kmspb.Digest {
+	return &kmspb.Digest{Digest: &kmspb.Digest_Sha512{Sha512: digest}}
+}
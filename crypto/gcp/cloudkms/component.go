@@ -0,0 +1,489 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudkms
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"strings"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+var (
+	errIntegrity       = errors.New("integrity check failed: the request or the response was corrupted in transit")
+	errVersionRequired = errors.New("a key version is required for asymmetric keys, in the format 'name/version'")
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// kmsClient is the subset of the Cloud KMS client used by the component.
+type kmsClient interface {
+	GetPublicKey(ctx context.Context, req *kmspb.GetPublicKeyRequest, opts ...gax.CallOption) (*kmspb.PublicKey, error)
+	Encrypt(ctx context.Context, req *kmspb.EncryptRequest, opts ...gax.CallOption) (*kmspb.EncryptResponse, error)
+	Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error)
+	AsymmetricDecrypt(ctx context.Context, req *kmspb.AsymmetricDecryptRequest, opts ...gax.CallOption) (*kmspb.AsymmetricDecryptResponse, error)
+	AsymmetricSign(ctx context.Context, req *kmspb.AsymmetricSignRequest, opts ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error)
+	Close() error
+}
+
+type cloudKMSCrypto struct {
+	keyCache *contribCrypto.PubKeyCache
+	md       cloudKMSMetadata
+	client   kmsClient
+	logger   logger.Logger
+}
+
+// NewGCPCloudKMSCrypto returns a new Google Cloud KMS crypto provider.
+func NewGCPCloudKMSCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &cloudKMSCrypto{
+		logger: logger,
+	}
+}
+
+// Init creates a Cloud KMS client.
+func (k *cloudKMSCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := k.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Create a cache for keys
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+
+	// Init the Cloud KMS client
+	opts := []option.ClientOption{}
+	if k.md.hasExplicitCredentials() {
+		b, _ := json.Marshal(k.md)
+		opts = append(opts, option.WithCredentialsJSON(b))
+	} else {
+		k.logger.Debug("Using Application Default Credentials for Cloud KMS")
+	}
+	if k.md.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(k.md.Endpoint))
+	}
+	k.client, err = kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud KMS client: %w", err)
+	}
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (k *cloudKMSCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in Cloud KMS.
+// This method returns an error if the key is symmetric.
+// The key argument can be in the format "name/version", or the full resource name of the key version.
+func (k *cloudKMSCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	kid, err := k.parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	if kid.Version == "" {
+		return nil, errVersionRequired
+	}
+
+	// Key versions are immutable, so they can always be cached
+	return k.keyCache.GetKey(parentCtx, kid.VersionName())
+}
+
+func (k *cloudKMSCrypto) getKeyFromKMS(parentCtx context.Context, versionName string) (pubKey jwk.Key, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{
+		Name: versionName,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key from Cloud KMS: %w", err)
+	}
+
+	if res.GetName() != versionName || !checkCRC32C([]byte(res.GetPem()), res.GetPemCrc32C()) {
+		return nil, errIntegrity
+	}
+
+	return PublicKeyToKey(res)
+}
+
+// Handler for the getKeyCacheFn method
+func (k *cloudKMSCrypto) getKeyCacheFn(ctx context.Context, versionName string) func(resolve func(jwk.Key), reject func(error)) {
+	return func(resolve func(jwk.Key), reject func(error)) {
+		pk, err := k.getKeyFromKMS(ctx, versionName)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(pk)
+	}
+}
+
+// Encrypt a small message and returns the ciphertext.
+// The key argument can be in the format "name" or "name/version", or the full resource name of the key or key version.
+// With symmetric keys, the nonce is generated by Cloud KMS and the authentication tag is included in the ciphertext, so the nonce argument is ignored and the returned tag is nil.
+// With asymmetric keys, data is encrypted locally with the public key, since Cloud KMS doesn't offer an API for that.
+func (k *cloudKMSCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithmStr string, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	kid, err := k.parseKeyID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	symmetric, ok := IsAlgorithmSymmetric(algorithmStr)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	if symmetric {
+		ciphertext, err = k.encryptInKMS(parentCtx, plaintext, kid, associatedData)
+	} else {
+		ciphertext, err = k.encryptPublicKey(parentCtx, plaintext, algorithmStr, kid, associatedData)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, nil, nil
+}
+
+func (k *cloudKMSCrypto) encryptInKMS(parentCtx context.Context, plaintext []byte, kid keyID, associatedData []byte) (ciphertext []byte, err error) {
+	// If a version is set, it's used; otherwise, Cloud KMS uses the primary version of the key
+	name := kid.Key
+	if kid.Version != "" {
+		name = kid.VersionName()
+	}
+
+	req := &kmspb.EncryptRequest{
+		Name:            name,
+		Plaintext:       plaintext,
+		PlaintextCrc32C: crc32c(plaintext),
+	}
+	if len(associatedData) > 0 {
+		req.AdditionalAuthenticatedData = associatedData
+		req.AdditionalAuthenticatedDataCrc32C = crc32c(associatedData)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.Encrypt(ctx, req)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from Cloud KMS: %w", err)
+	}
+
+	if !res.GetVerifiedPlaintextCrc32C() ||
+		(len(associatedData) > 0 && !res.GetVerifiedAdditionalAuthenticatedDataCrc32C()) ||
+		!checkCRC32C(res.GetCiphertext(), res.GetCiphertextCrc32C()) {
+		return nil, errIntegrity
+	}
+
+	if len(res.GetCiphertext()) == 0 {
+		return nil, errors.New("response from Cloud KMS does not contain a valid ciphertext")
+	}
+
+	return res.GetCiphertext(), nil
+}
+
+func (k *cloudKMSCrypto) encryptPublicKey(parentCtx context.Context, plaintext []byte, algorithmStr string, kid keyID, associatedData []byte) (ciphertext []byte, err error) {
+	// Cloud KMS doesn't support associated data (as OAEP label) with asymmetric keys, so it could not decrypt the message
+	if len(associatedData) > 0 {
+		return nil, errors.New("associated data is not supported with asymmetric keys")
+	}
+	if kid.Version == "" {
+		return nil, errVersionRequired
+	}
+
+	pk, err := k.keyCache.GetKey(parentCtx, kid.VersionName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve public key: %w", err)
+	}
+
+	ciphertext, err = internals.EncryptPublicKey(plaintext, algorithmStr, pk, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// Decrypt a small message and returns the plaintext.
+// The key argument can be in the format "name" or "name/version", or the full resource name of the key or key version; the version is required with asymmetric keys only.
+// The nonce and tag arguments are ignored, as they are included in the ciphertext.
+func (k *cloudKMSCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithmStr string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	kid, err := k.parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	symmetric, ok := IsAlgorithmSymmetric(algorithmStr)
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	if symmetric {
+		return k.decryptInKMS(parentCtx, ciphertext, kid, associatedData)
+	}
+	if len(associatedData) > 0 {
+		return nil, errors.New("associated data is not supported with asymmetric keys")
+	}
+	return k.asymmetricDecryptInKMS(parentCtx, ciphertext, kid)
+}
+
+func (k *cloudKMSCrypto) decryptInKMS(parentCtx context.Context, ciphertext []byte, kid keyID, associatedData []byte) (plaintext []byte, err error) {
+	// The version of the key is included in the ciphertext
+	req := &kmspb.DecryptRequest{
+		Name:             kid.Key,
+		Ciphertext:       ciphertext,
+		CiphertextCrc32C: crc32c(ciphertext),
+	}
+	if len(associatedData) > 0 {
+		req.AdditionalAuthenticatedData = associatedData
+		req.AdditionalAuthenticatedDataCrc32C = crc32c(associatedData)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.Decrypt(ctx, req)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from Cloud KMS: %w", err)
+	}
+
+	if !checkCRC32C(res.GetPlaintext(), res.GetPlaintextCrc32C()) {
+		return nil, errIntegrity
+	}
+
+	return res.GetPlaintext(), nil
+}
+
+func (k *cloudKMSCrypto) asymmetricDecryptInKMS(parentCtx context.Context, ciphertext []byte, kid keyID) (plaintext []byte, err error) {
+	if kid.Version == "" {
+		return nil, errVersionRequired
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.AsymmetricDecrypt(ctx, &kmspb.AsymmetricDecryptRequest{
+		Name:             kid.VersionName(),
+		Ciphertext:       ciphertext,
+		CiphertextCrc32C: crc32c(ciphertext),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from Cloud KMS: %w", err)
+	}
+
+	if !res.GetVerifiedCiphertextCrc32C() || !checkCRC32C(res.GetPlaintext(), res.GetPlaintextCrc32C()) {
+		return nil, errIntegrity
+	}
+
+	return res.GetPlaintext(), nil
+}
+
+// WrapKey wraps a symmetric key.
+// The key argument can be in the format "name" or "name/version", or the full resource name of the key or key version.
+func (k *cloudKMSCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithmStr string, key string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys are small enough to be wrapped with Cloud KMS
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	return k.Encrypt(parentCtx, plaintext, algorithmStr, key, nonce, associatedData)
+}
+
+// UnwrapKey unwraps a key.
+// The key argument can be in the format "name" or "name/version", or the full resource name of the key or key version; the version is required with asymmetric keys only.
+func (k *cloudKMSCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithmStr string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	plaintext, err := k.Decrypt(parentCtx, wrappedKey, algorithmStr, key, nonce, tag, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only symmetric keys can be wrapped, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// Sign a digest.
+// The key argument can be in the format "name/version", or the full resource name of the key version.
+func (k *cloudKMSCrypto) Sign(parentCtx context.Context, digest []byte, algorithmStr string, key string) (signature []byte, err error) {
+	kid, err := k.parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	if kid.Version == "" {
+		return nil, errVersionRequired
+	}
+
+	digestFn, ok := signatureAlgs[algorithmStr]
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:         kid.VersionName(),
+		Digest:       digestFn(digest),
+		DigestCrc32C: crc32c(digest),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from Cloud KMS: %w", err)
+	}
+
+	if res.GetName() != kid.VersionName() || !res.GetVerifiedDigestCrc32C() || !checkCRC32C(res.GetSignature(), res.GetSignatureCrc32C()) {
+		return nil, errIntegrity
+	}
+
+	if len(res.GetSignature()) == 0 {
+		return nil, errors.New("response from Cloud KMS does not contain a valid signature")
+	}
+
+	return res.GetSignature(), nil
+}
+
+// Verify a signature.
+// The key argument can be in the format "name/version", or the full resource name of the key version.
+// Signatures are verified locally with the public key, since Cloud KMS doesn't offer an API for that.
+func (k *cloudKMSCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithmStr string, key string) (valid bool, err error) {
+	if _, ok := signatureAlgs[algorithmStr]; !ok {
+		return false, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+
+	pk, err := k.GetKey(parentCtx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve public key: %w", err)
+	}
+
+	valid, err = internals.VerifyPublicKey(digest, signature, algorithmStr, pk)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify signature: %w", err)
+	}
+	return valid, nil
+}
+
+func (k *cloudKMSCrypto) Close() error {
+	if k.client != nil {
+		return k.client.Close()
+	}
+	return nil
+}
+
+func (cloudKMSCrypto) SupportedEncryptionAlgorithms() []string {
+	return encryptionAlgsList
+}
+
+func (cloudKMSCrypto) SupportedSignatureAlgorithms() []string {
+	return signatureAlgsList
+}
+
+func (cloudKMSCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := cloudKMSMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
+
+// PublicKeyToKey converts a kmspb.PublicKey object to a contribCrypto.Key object.
+func PublicKeyToKey(res *kmspb.PublicKey) (*contribCrypto.Key, error) {
+	block, _ := pem.Decode([]byte(res.GetPem()))
+	if block == nil {
+		return nil, errors.New("response from Cloud KMS does not contain a valid public key")
+	}
+	pk, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	jwkObj, err := jwk.FromRaw(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwk.Key: %w", err)
+	}
+
+	// The purpose of the key is part of the name of its algorithm
+	alg := res.GetAlgorithm().String()
+	switch {
+	case strings.Contains(alg, "_SIGN_"):
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	case strings.Contains(alg, "_DECRYPT_"):
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForEncryption)
+	}
+
+	return contribCrypto.NewKey(jwkObj, res.GetName(), nil, nil), nil
+}
+
+// Returns the CRC32C checksum of the data.
+func crc32c(data []byte) *wrapperspb.Int64Value {
+	return wrapperspb.Int64(int64(crc32.Checksum(data, crc32cTable)))
+}
+
+// Returns true if the checksum matches the data.
+func checkCRC32C(data []byte, checksum *wrapperspb.Int64Value) bool {
+	return checksum != nil && checksum.GetValue() == int64(crc32.Checksum(data, crc32cTable))
+}
+
+type keyID struct {
+	// Resource name of the key
+	Key string
+	// Version of the key, may be empty
+	Version string
+}
+
+// VersionName returns the resource name of the key version.
+func (id keyID) VersionName() string {
+	return id.Key + "/cryptoKeyVersions/" + id.Version
+}
+
+// Parses a key argument, which can be in the format "name" or "name/version", or be the full resource name of a key or key version.
+func (k *cloudKMSCrypto) parseKeyID(key string) (keyID, error) {
+	if strings.HasPrefix(key, "projects/") {
+		name, version, _ := strings.Cut(key, "/cryptoKeyVersions/")
+		if !strings.Contains(name, "/cryptoKeys/") {
+			return keyID{}, fmt.Errorf("invalid key resource name: %s", key)
+		}
+		return keyID{Key: name, Version: version}, nil
+	}
+
+	if k.md.ProjectID == "" || k.md.KeyRing == "" {
+		return keyID{}, errors.New("metadata properties 'projectID', 'location', and 'keyRing' are required to use key names that are not full resource names")
+	}
+	name, version, _ := strings.Cut(key, "/")
+	if name == "" {
+		return keyID{}, errors.New("key name is empty")
+	}
+	return keyID{
+		Key:     "projects/" + k.md.ProjectID + "/locations/" + k.md.Location + "/keyRings/" + k.md.KeyRing + "/cryptoKeys/" + name,
+		Version: version,
+	}, nil
+}
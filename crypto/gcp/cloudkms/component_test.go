@@ -0,0 +1,419 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudkms
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	testKeyRing   = "projects/myproject/locations/global/keyRings/myring"
+	testSymKey    = testKeyRing + "/cryptoKeys/sym"
+	testRSAKey    = testKeyRing + "/cryptoKeys/rsa/cryptoKeyVersions/1"
+	testECKey     = testKeyRing + "/cryptoKeys/ec/cryptoKeyVersions/1"
+	testUnknownID = testKeyRing + "/cryptoKeys/unknown/cryptoKeyVersions/1"
+)
+
+// fakeClient implements kmsClient performing the operations with local keys.
+type fakeClient struct {
+	aead    cipher.AEAD
+	rsaKey  jwk.Key
+	rsaPEM  string
+	ecKey   *ecdsa.PrivateKey
+	ecPEM   string
+	corrupt bool
+
+	getPublicKeyCalls atomic.Int32
+}
+
+func newFakeClient(t *testing.T) *fakeClient {
+	aesKey := make([]byte, 32)
+	_, err := rand.Read(aesKey)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(aesKey)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaJWK, err := jwk.FromRaw(rsaKey)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &fakeClient{
+		aead:   aead,
+		rsaKey: rsaJWK,
+		rsaPEM: encodePEM(t, &rsaKey.PublicKey),
+		ecKey:  ecKey,
+		ecPEM:  encodePEM(t, &ecKey.PublicKey),
+	}
+}
+
+func encodePEM(t *testing.T, pub any) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Returns the checksum of the data, altered if the client is set to corrupt responses.
+func (c *fakeClient) checksum(data []byte) *wrapperspb.Int64Value {
+	res := crc32c(data)
+	if c.corrupt {
+		res.Value++
+	}
+	return res
+}
+
+func (c *fakeClient) GetPublicKey(ctx context.Context, req *kmspb.GetPublicKeyRequest, opts ...gax.CallOption) (*kmspb.PublicKey, error) {
+	c.getPublicKeyCalls.Add(1)
+	res := &kmspb.PublicKey{Name: req.GetName()}
+	switch req.GetName() {
+	case testRSAKey:
+		res.Pem = c.rsaPEM
+		res.Algorithm = kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_2048_SHA256
+	case testECKey:
+		res.Pem = c.ecPEM
+		res.Algorithm = kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256
+	default:
+		return nil, errors.New("key not found")
+	}
+	res.PemCrc32C = c.checksum([]byte(res.GetPem()))
+	return res, nil
+}
+
+func (c *fakeClient) Encrypt(ctx context.Context, req *kmspb.EncryptRequest, opts ...gax.CallOption) (*kmspb.EncryptResponse, error) {
+	if req.GetName() != testSymKey {
+		return nil, errors.New("key not found")
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	ct := c.aead.Seal(nonce, nonce, req.GetPlaintext(), req.GetAdditionalAuthenticatedData())
+	return &kmspb.EncryptResponse{
+		Name:                    req.GetName() + "/cryptoKeyVersions/1",
+		Ciphertext:              ct,
+		CiphertextCrc32C:        c.checksum(ct),
+		VerifiedPlaintextCrc32C: checkCRC32C(req.GetPlaintext(), req.GetPlaintextCrc32C()),
+		VerifiedAdditionalAuthenticatedDataCrc32C: req.GetAdditionalAuthenticatedDataCrc32C() != nil && checkCRC32C(req.GetAdditionalAuthenticatedData(), req.GetAdditionalAuthenticatedDataCrc32C()),
+	}, nil
+}
+
+func (c *fakeClient) Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error) {
+	if req.GetName() != testSymKey {
+		return nil, errors.New("key not found")
+	}
+	if !checkCRC32C(req.GetCiphertext(), req.GetCiphertextCrc32C()) {
+		return nil, errors.New("checksum mismatch")
+	}
+	n := c.aead.NonceSize()
+	pt, err := c.aead.Open(nil, req.GetCiphertext()[:n], req.GetCiphertext()[n:], req.GetAdditionalAuthenticatedData())
+	if err != nil {
+		return nil, err
+	}
+	return &kmspb.DecryptResponse{
+		Plaintext:       pt,
+		PlaintextCrc32C: c.checksum(pt),
+	}, nil
+}
+
+func (c *fakeClient) AsymmetricDecrypt(ctx context.Context, req *kmspb.AsymmetricDecryptRequest, opts ...gax.CallOption) (*kmspb.AsymmetricDecryptResponse, error) {
+	if req.GetName() != testRSAKey {
+		return nil, errors.New("key not found")
+	}
+	pt, err := internals.DecryptPrivateKey(req.GetCiphertext(), internals.Algorithm_RSA_OAEP_256, c.rsaKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &kmspb.AsymmetricDecryptResponse{
+		Plaintext:                pt,
+		PlaintextCrc32C:          c.checksum(pt),
+		VerifiedCiphertextCrc32C: checkCRC32C(req.GetCiphertext(), req.GetCiphertextCrc32C()),
+	}, nil
+}
+
+func (c *fakeClient) AsymmetricSign(ctx context.Context, req *kmspb.AsymmetricSignRequest, opts ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error) {
+	if req.GetName() != testECKey {
+		return nil, errors.New("key not found")
+	}
+	digest := req.GetDigest().GetSha256()
+	sig, err := ecdsa.SignASN1(rand.Reader, c.ecKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &kmspb.AsymmetricSignResponse{
+		Name:                 req.GetName(),
+		Signature:            sig,
+		SignatureCrc32C:      c.checksum(sig),
+		VerifiedDigestCrc32C: checkCRC32C(digest, req.GetDigestCrc32C()),
+	}, nil
+}
+
+func (c *fakeClient) Close() error {
+	return nil
+}
+
+func newTestCrypto(t *testing.T, client *fakeClient, props map[string]string) *cloudKMSCrypto {
+	k := NewGCPCloudKMSCrypto(logger.NewLogger("test")).(*cloudKMSCrypto)
+	md := contribCrypto.Metadata{}
+	md.Properties = props
+	err := k.md.InitWithMetadata(md)
+	require.NoError(t, err)
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+	k.client = client
+	return k
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("location without key ring", func(t *testing.T) {
+		m := cloudKMSMetadata{}
+		md := contribCrypto.Metadata{}
+		md.Properties = map[string]string{"location": "global"}
+		err := m.InitWithMetadata(md)
+		require.Error(t, err)
+	})
+
+	t.Run("default request timeout", func(t *testing.T) {
+		m := cloudKMSMetadata{}
+		err := m.InitWithMetadata(contribCrypto.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultRequestTimeout, m.RequestTimeout)
+		assert.False(t, m.hasExplicitCredentials())
+	})
+}
+
+func TestParseKeyID(t *testing.T) {
+	k := newTestCrypto(t, nil, map[string]string{
+		"projectID": "myproject",
+		"location":  "global",
+		"keyRing":   "myring",
+	})
+
+	tests := []struct {
+		key     string
+		want    keyID
+		wantErr bool
+	}{
+		{key: "sym", want: keyID{Key: testSymKey}},
+		{key: "rsa/1", want: keyID{Key: testKeyRing + "/cryptoKeys/rsa", Version: "1"}},
+		{key: testSymKey, want: keyID{Key: testSymKey}},
+		{key: testRSAKey, want: keyID{Key: testKeyRing + "/cryptoKeys/rsa", Version: "1"}},
+		{key: "projects/myproject/locations/global", wantErr: true},
+		{key: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := k.parseKeyID(tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("short names without key ring", func(t *testing.T) {
+		k := newTestCrypto(t, nil, nil)
+		_, err := k.parseKeyID("sym")
+		require.Error(t, err)
+		_, err = k.parseKeyID(testSymKey)
+		require.NoError(t, err)
+	})
+}
+
+func TestGetKey(t *testing.T) {
+	client := newFakeClient(t)
+	k := newTestCrypto(t, client, nil)
+
+	t.Run("key versions are cached", func(t *testing.T) {
+		for range 2 {
+			pk, err := k.GetKey(context.Background(), testECKey)
+			require.NoError(t, err)
+			assert.Equal(t, testECKey, pk.(*contribCrypto.Key).KeyID())
+			assert.Equal(t, jwk.ForSignature.String(), pk.KeyUsage())
+		}
+		assert.Equal(t, int32(1), client.getPublicKeyCalls.Load())
+	})
+
+	t.Run("version is required", func(t *testing.T) {
+		_, err := k.GetKey(context.Background(), testSymKey)
+		require.ErrorIs(t, err, errVersionRequired)
+	})
+
+	t.Run("corrupted response", func(t *testing.T) {
+		client.corrupt = true
+		defer func() {
+			client.corrupt = false
+		}()
+		_, err := k.GetKey(context.Background(), testRSAKey)
+		require.ErrorIs(t, err, errIntegrity)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := k.GetKey(context.Background(), testUnknownID)
+		require.ErrorContains(t, err, "key not found")
+	})
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	client := newFakeClient(t)
+	k := newTestCrypto(t, client, nil)
+	plaintext := []byte("hello world")
+
+	t.Run("symmetric key with associated data", func(t *testing.T) {
+		aad := []byte("context")
+		ct, tag, err := k.Encrypt(context.Background(), plaintext, algorithmGoogleSymmetric, testSymKey, nil, aad)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+
+		// Decrypting with a key version works too, as the version is included in the ciphertext
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, testSymKey+"/cryptoKeyVersions/1", nil, nil, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		_, err = k.Decrypt(context.Background(), ct, algorithmGoogleSymmetric, testSymKey, nil, nil, []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("asymmetric key encrypts locally", func(t *testing.T) {
+		ct, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, testRSAKey, nil, nil)
+		require.NoError(t, err)
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_RSA_OAEP_256, testRSAKey, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+	})
+
+	t.Run("asymmetric key without version", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, testKeyRing+"/cryptoKeys/rsa", nil, nil)
+		require.ErrorIs(t, err, errVersionRequired)
+		_, err = k.Decrypt(context.Background(), []byte("x"), internals.Algorithm_RSA_OAEP_256, testKeyRing+"/cryptoKeys/rsa", nil, nil, nil)
+		require.ErrorIs(t, err, errVersionRequired)
+	})
+
+	t.Run("associated data with asymmetric key", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, testRSAKey, nil, []byte("aad"))
+		require.EqualError(t, err, "associated data is not supported with asymmetric keys")
+	})
+
+	t.Run("corrupted response", func(t *testing.T) {
+		ct, _, err := k.Encrypt(context.Background(), plaintext, algorithmGoogleSymmetric, testSymKey, nil, nil)
+		require.NoError(t, err)
+
+		client.corrupt = true
+		defer func() {
+			client.corrupt = false
+		}()
+		_, _, err = k.Encrypt(context.Background(), plaintext, algorithmGoogleSymmetric, testSymKey, nil, nil)
+		require.ErrorIs(t, err, errIntegrity)
+		_, err = k.Decrypt(context.Background(), ct, algorithmGoogleSymmetric, testSymKey, nil, nil, nil)
+		require.ErrorIs(t, err, errIntegrity)
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A128CBC, testSymKey, nil, nil)
+		require.EqualError(t, err, "invalid algorithm: A128CBC")
+	})
+}
+
+func TestWrapKey(t *testing.T) {
+	client := newFakeClient(t)
+	k := newTestCrypto(t, client, nil)
+
+	t.Run("wrap and unwrap", func(t *testing.T) {
+		key, err := jwk.FromRaw(bytes.Repeat([]byte{0x01}, 32))
+		require.NoError(t, err)
+
+		wrapped, _, err := k.WrapKey(context.Background(), key, algorithmGoogleSymmetric, testSymKey, nil, nil)
+		require.NoError(t, err)
+
+		unwrapped, err := k.UnwrapKey(context.Background(), wrapped, algorithmGoogleSymmetric, testSymKey, nil, nil, nil)
+		require.NoError(t, err)
+		raw := []byte{}
+		require.NoError(t, unwrapped.Raw(&raw))
+		assert.Equal(t, bytes.Repeat([]byte{0x01}, 32), raw)
+	})
+
+	t.Run("asymmetric keys cannot be wrapped", func(t *testing.T) {
+		privKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(privKey)
+		require.NoError(t, err)
+
+		_, _, err = k.WrapKey(context.Background(), key, algorithmGoogleSymmetric, testSymKey, nil, nil)
+		require.EqualError(t, err, "cannot wrap asymmetric keys")
+	})
+}
+
+func TestSignVerify(t *testing.T) {
+	client := newFakeClient(t)
+	k := newTestCrypto(t, client, nil)
+	digest := sha256.Sum256([]byte("hello world"))
+
+	sig, err := k.Sign(context.Background(), digest[:], internals.Algorithm_ES256, testECKey)
+	require.NoError(t, err)
+
+	valid, err := k.Verify(context.Background(), digest[:], sig, internals.Algorithm_ES256, testECKey)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	otherDigest := sha256.Sum256([]byte("other"))
+	valid, err = k.Verify(context.Background(), otherDigest[:], sig, internals.Algorithm_ES256, testECKey)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	t.Run("version is required", func(t *testing.T) {
+		_, err := k.Sign(context.Background(), digest[:], internals.Algorithm_ES256, testKeyRing+"/cryptoKeys/ec")
+		require.ErrorIs(t, err, errVersionRequired)
+	})
+
+	t.Run("corrupted response", func(t *testing.T) {
+		client.corrupt = true
+		defer func() {
+			client.corrupt = false
+		}()
+		_, err := k.Sign(context.Background(), digest[:], internals.Algorithm_ES256, testECKey)
+		require.ErrorIs(t, err, errIntegrity)
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, err := k.Sign(context.Background(), digest[:], internals.Algorithm_HS256, testECKey)
+		require.EqualError(t, err, "invalid algorithm: HS256")
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudkms
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+type cloudKMSMetadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	Type                string `json:"type" mapstructure:"type" mdignore:"true"`
+	ProjectID           string `json:"project_id" mapstructure:"projectID" mdignore:"true" mapstructurealiases:"project_id"`
+	PrivateKeyID        string `json:"private_key_id" mapstructure:"privateKeyID" mdignore:"true" mapstructurealiases:"private_key_id"`
+	PrivateKey          string `json:"private_key" mapstructure:"privateKey" mdignore:"true" mapstructurealiases:"private_key"`
+	ClientEmail         string `json:"client_email" mapstructure:"clientEmail" mdignore:"true" mapstructurealiases:"client_email"`
+	ClientID            string `json:"client_id" mapstructure:"clientID" mdignore:"true" mapstructurealiases:"client_id"`
+	AuthURI             string `json:"auth_uri" mapstructure:"authURI" mdignore:"true" mapstructurealiases:"auth_uri"`
+	TokenURI            string `json:"token_uri" mapstructure:"tokenURI" mdignore:"true" mapstructurealiases:"token_uri"`
+	AuthProviderCertURL string `json:"auth_provider_x509_cert_url" mapstructure:"authProviderX509CertURL" mdignore:"true" mapstructurealiases:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `json:"client_x509_cert_url" mapstructure:"clientX509CertURL" mdignore:"true" mapstructurealiases:"client_x509_cert_url"`
+
+	// Location of the key ring, such as "global" or "us-east1".
+	// Together with "keyRing", this is required to use key names that are not full resource names.
+	Location string `json:"-" mapstructure:"location"`
+
+	// Name of the key ring containing the keys.
+	KeyRing string `json:"-" mapstructure:"keyRing"`
+
+	// Endpoint of the Cloud KMS API, to use a custom or private endpoint.
+	Endpoint string `json:"-" mapstructure:"endpoint"`
+
+	// Timeout for network requests, as a Go duration string (e.g. "30s")
+	// Defaults to "30s".
+	RequestTimeout time.Duration `json:"-" mapstructure:"requestTimeout"`
+}
+
+func (m *cloudKMSMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Location and key ring must be set together
+	m.Location = strings.TrimSpace(m.Location)
+	m.KeyRing = strings.TrimSpace(m.KeyRing)
+	if (m.Location == "") != (m.KeyRing == "") {
+		return errors.New("metadata properties 'location' and 'keyRing' must be set together")
+	}
+
+	// Set default requestTimeout if empty
+	if m.RequestTimeout < time.Second {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
+
+// hasExplicitCredentials returns true if a service account key is included in the metadata.
+// Otherwise, Application Default Credentials are used.
+func (m *cloudKMSMetadata) hasExplicitCredentials() bool {
+	return m.PrivateKey != ""
+}
+
+// Reset the object
+func (m *cloudKMSMetadata) reset() {
+	*m = cloudKMSMetadata{
+		RequestTimeout: defaultRequestTimeout,
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+var rsaKeyTypes = []string{"rsa-2048", "rsa-3072", "rsa-4096"}
+
+// Maps the names of the encryption algorithms to the types of Transit keys they can be used with.
+// Transit encrypts with RSA keys using OAEP with SHA-256.
+var encryptionAlgs = map[string][]string{
+	internals.Algorithm_A128GCM:      {"aes128-gcm96"},
+	internals.Algorithm_A256GCM:      {"aes256-gcm96"},
+	internals.Algorithm_C20P:         {"chacha20-poly1305"},
+	internals.Algorithm_RSA_OAEP_256: rsaKeyTypes,
+}
+
+// signatureAlg contains the parameters for a signature algorithm in Transit.
+type signatureAlg struct {
+	// Name of the hash algorithm; empty if the input is not pre-hashed
+	hash string
+	// Name of the signature algorithm; used with RSA keys only
+	signatureAlgorithm string
+	// Types of keys the algorithm can be used with
+	keyTypes []string
+}
+
+// Maps the names of the signature algorithms to the parameters used by Transit.
+var signatureAlgs = map[string]signatureAlg{
+	internals.Algorithm_RS256: {hash: "sha2-256", signatureAlgorithm: "pkcs1v15", keyTypes: rsaKeyTypes},
+	internals.Algorithm_RS384: {hash: "sha2-384", signatureAlgorithm: "pkcs1v15", keyTypes: rsaKeyTypes},
+	internals.Algorithm_RS512: {hash: "sha2-512", signatureAlgorithm: "pkcs1v15", keyTypes: rsaKeyTypes},
+	internals.Algorithm_PS256: {hash: "sha2-256", signatureAlgorithm: "pss", keyTypes: rsaKeyTypes},
+	internals.Algorithm_PS384: {hash: "sha2-384", signatureAlgorithm: "pss", keyTypes: rsaKeyTypes},
+	internals.Algorithm_PS512: {hash: "sha2-512", signatureAlgorithm: "pss", keyTypes: rsaKeyTypes},
+	internals.Algorithm_ES256: {hash: "sha2-256", keyTypes: []string{"ecdsa-p256"}},
+	internals.Algorithm_ES384: {hash: "sha2-384", keyTypes: []string{"ecdsa-p384"}},
+	internals.Algorithm_ES512: {hash: "sha2-512", keyTypes: []string{"ecdsa-p521"}},
+	// With Ed25519, the message is signed rather than its digest
+	internals.Algorithm_EdDSA: {keyTypes: []string{"ed25519"}},
+}
+
+var (
+	encryptionAlgsList = slices.Sorted(maps.Keys(encryptionAlgs))
+	signatureAlgsList  = slices.Sorted(maps.Keys(signatureAlgs))
+)
+
+// IsKeyTypeAsymmetric returns true if the type of Transit key is asymmetric.
+func IsKeyTypeAsymmetric(keyType string) bool {
+	return strings.HasPrefix(keyType, "rsa-") ||
+		strings.HasPrefix(keyType, "ecdsa-") ||
+		keyType == "ed25519"
+}
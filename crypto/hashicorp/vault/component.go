@@ -0,0 +1,681 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	vaultAuth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+type transitCrypto struct {
+	keyCache *contribCrypto.PubKeyCache
+	md       transitMetadata
+	client   *http.Client
+	logger   logger.Logger
+
+	// Properties of the keys, which cannot be changed after a key is created
+	keyPropsLock sync.RWMutex
+	keyProps     map[string]keyProperties
+}
+
+var _ contribCrypto.DataKeyGenerator = (*transitCrypto)(nil)
+
+// keyProperties contains the immutable properties of a key in Transit.
+type keyProperties struct {
+	Type    string
+	Derived bool
+}
+
+// transitKey is the response of Transit when reading a key.
+type transitKey struct {
+	Name               string                     `json:"name"`
+	Type               string                     `json:"type"`
+	Derived            bool                       `json:"derived"`
+	LatestVersion      int                        `json:"latest_version"`
+	SupportsEncryption bool                       `json:"supports_encryption"`
+	SupportsSigning    bool                       `json:"supports_signing"`
+	Keys               map[string]json.RawMessage `json:"keys"`
+}
+
+type encryptRequest struct {
+	Plaintext      string `json:"plaintext"`
+	Context        string `json:"context,omitempty"`
+	AssociatedData string `json:"associated_data,omitempty"`
+	KeyVersion     int    `json:"key_version,omitempty"`
+}
+
+// decryptRequest is the body of requests to decrypt and to rewrap data.
+type decryptRequest struct {
+	Ciphertext     string `json:"ciphertext"`
+	Context        string `json:"context,omitempty"`
+	AssociatedData string `json:"associated_data,omitempty"`
+	KeyVersion     int    `json:"key_version,omitempty"`
+}
+
+type dataKeyRequest struct {
+	Bits    int    `json:"bits"`
+	Context string `json:"context,omitempty"`
+}
+
+type signRequest struct {
+	Input               string `json:"input"`
+	Signature           string `json:"signature,omitempty"`
+	Prehashed           bool   `json:"prehashed"`
+	SignatureAlgorithm  string `json:"signature_algorithm,omitempty"`
+	MarshalingAlgorithm string `json:"marshaling_algorithm,omitempty"`
+	KeyVersion          int    `json:"key_version,omitempty"`
+}
+
+// NewHashiCorpVaultTransitCrypto returns a new crypto provider for the HashiCorp Vault Transit secrets engine.
+func NewHashiCorpVaultTransitCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &transitCrypto{
+		logger:   logger,
+		keyProps: map[string]keyProperties{},
+	}
+}
+
+// Init creates a client for Vault.
+func (k *transitCrypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := k.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Create a cache for keys
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+
+	// Init the HTTP client
+	k.client, err = vaultAuth.NewHTTPClient(k.md.tlsConfig(), k.md.ProxyMetadata, k.logger)
+	if err != nil {
+		return fmt.Errorf("couldn't create client using config: %w", err)
+	}
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (k *transitCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in Transit.
+// This method returns an error if the key is symmetric.
+// The key argument can be in the format "name" or "name/version"; when the version is omitted, the latest one is returned.
+func (k *transitCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only key versions are cached, as the latest version changes when the key is rotated
+	if kid.Version > 0 {
+		return k.keyCache.GetKey(parentCtx, kid.String())
+	}
+	return k.getKeyFromVault(parentCtx, kid)
+}
+
+func (k *transitCrypto) getKeyFromVault(parentCtx context.Context, kid keyID) (pubKey jwk.Key, err error) {
+	res, err := k.readKey(parentCtx, kid.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsKeyTypeAsymmetric(res.Type) {
+		return nil, fmt.Errorf("key %s is symmetric", kid.Name)
+	}
+
+	version := kid.Version
+	if version == 0 {
+		version = res.LatestVersion
+	}
+	raw, ok := res.Keys[strconv.Itoa(version)]
+	if !ok {
+		return nil, fmt.Errorf("version %d of key %s not found", version, kid.Name)
+	}
+	var keyVersion struct {
+		PublicKey string `json:"public_key"`
+	}
+	err = json.Unmarshal(raw, &keyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return PublicKeyToKey(res, version, keyVersion.PublicKey)
+}
+
+// Handler for the getKeyCacheFn method
+func (k *transitCrypto) getKeyCacheFn(ctx context.Context, key string) func(resolve func(jwk.Key), reject func(error)) {
+	return func(resolve func(jwk.Key), reject func(error)) {
+		kid, err := parseKeyID(key)
+		if err != nil {
+			reject(err)
+			return
+		}
+		pk, err := k.getKeyFromVault(ctx, kid)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(pk)
+	}
+}
+
+// readKey reads a key from Transit, and caches its properties.
+func (k *transitCrypto) readKey(parentCtx context.Context, name string) (*transitKey, error) {
+	res := &transitKey{}
+	err := k.doRequest(parentCtx, http.MethodGet, "keys/"+url.PathEscape(name), nil, res)
+	if err != nil {
+		return nil, err
+	}
+
+	k.keyPropsLock.Lock()
+	k.keyProps[name] = keyProperties{
+		Type:    res.Type,
+		Derived: res.Derived,
+	}
+	k.keyPropsLock.Unlock()
+
+	return res, nil
+}
+
+// getKeyProperties returns the properties of a key, reading the key from Transit if they are not cached.
+func (k *transitCrypto) getKeyProperties(parentCtx context.Context, name string) (keyProperties, error) {
+	k.keyPropsLock.RLock()
+	props, ok := k.keyProps[name]
+	k.keyPropsLock.RUnlock()
+	if ok {
+		return props, nil
+	}
+
+	res, err := k.readKey(parentCtx, name)
+	if err != nil {
+		return keyProperties{}, err
+	}
+	return keyProperties{
+		Type:    res.Type,
+		Derived: res.Derived,
+	}, nil
+}
+
+// getCryptParams returns the key derivation context and the associated data to send to Transit, both base64-encoded.
+// With derived keys, the associated data is used as context for key derivation, which Transit requires.
+// If algorithm is not empty, it also validates that it can be used with the key.
+func (k *transitCrypto) getCryptParams(parentCtx context.Context, kid keyID, algorithm string, associatedData []byte) (derivationContext string, aad string, err error) {
+	props, err := k.getKeyProperties(parentCtx, kid.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if algorithm != "" {
+		keyTypes, ok := encryptionAlgs[algorithm]
+		if !ok {
+			return "", "", fmt.Errorf("invalid algorithm: %s", algorithm)
+		}
+		if !slices.Contains(keyTypes, props.Type) {
+			return "", "", fmt.Errorf("algorithm %s cannot be used with keys of type %s", algorithm, props.Type)
+		}
+	}
+
+	switch {
+	case props.Derived:
+		if len(associatedData) == 0 {
+			return "", "", errors.New("associated data is required with derived keys, as it is used as context for key derivation")
+		}
+		derivationContext = base64.StdEncoding.EncodeToString(associatedData)
+	case len(associatedData) > 0 && IsKeyTypeAsymmetric(props.Type):
+		return "", "", errors.New("associated data is not supported with asymmetric keys")
+	case len(associatedData) > 0:
+		aad = base64.StdEncoding.EncodeToString(associatedData)
+	}
+
+	return derivationContext, aad, nil
+}
+
+// Encrypt a small message and returns the ciphertext.
+// The key argument can be in the format "name" or "name/version"; when the version is omitted, the latest one is used.
+// The nonce argument is ignored, as the nonce is generated by Transit; the ciphertext is in the format used by Transit (e.g. "vault:v1:..."), which includes the nonce, the authentication tag, and the version of the key, so the returned tag is nil.
+// With derived keys, the associated data is required and is used as context for key derivation.
+func (k *transitCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithm string, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derivationContext, aad, err := k.getCryptParams(parentCtx, kid, algorithm, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "encrypt/"+url.PathEscape(kid.Name), encryptRequest{
+		Plaintext:      base64.StdEncoding.EncodeToString(plaintext),
+		Context:        derivationContext,
+		AssociatedData: aad,
+		KeyVersion:     kid.Version,
+	}, &res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.Ciphertext == "" {
+		return nil, nil, errors.New("response from Vault does not contain a valid ciphertext")
+	}
+
+	return []byte(res.Ciphertext), nil, nil
+}
+
+// Decrypt a small message and returns the plaintext.
+// The key argument can be in the format "name" or "name/version"; the version is ignored, as it is included in the ciphertext.
+// The nonce and tag arguments are ignored, as they are included in the ciphertext.
+func (k *transitCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	derivationContext, aad, err := k.getCryptParams(parentCtx, kid, algorithm, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "decrypt/"+url.PathEscape(kid.Name), decryptRequest{
+		Ciphertext:     string(ciphertext),
+		Context:        derivationContext,
+		AssociatedData: aad,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err = base64.StdEncoding.DecodeString(res.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("response from Vault does not contain a valid plaintext: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// Rewrap re-encrypts a ciphertext with the latest version of the key, or with the version in the key argument, without exposing the plaintext.
+// The associated data must be the same that was used to encrypt the data.
+func (k *transitCrypto) Rewrap(parentCtx context.Context, ciphertext []byte, key string, associatedData []byte) ([]byte, error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	derivationContext, aad, err := k.getCryptParams(parentCtx, kid, "", associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "rewrap/"+url.PathEscape(kid.Name), decryptRequest{
+		Ciphertext:     string(ciphertext),
+		Context:        derivationContext,
+		AssociatedData: aad,
+		KeyVersion:     kid.Version,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Ciphertext == "" {
+		return nil, errors.New("response from Vault does not contain a valid ciphertext")
+	}
+
+	return []byte(res.Ciphertext), nil
+}
+
+// GenerateDataKey generates a symmetric data key of the given size in bytes, which must be 16, 32, or 64, using the datakey endpoint of Transit.
+// It returns the key in plaintext and wrapped with the key in Transit; the wrapped key can be unwrapped with UnwrapKey, using the same associated data.
+// Associated data is supported with derived keys only, as context for key derivation.
+func (k *transitCrypto) GenerateDataKey(parentCtx context.Context, key string, size int, associatedData []byte) (plaintextKey jwk.Key, wrappedKey []byte, err error) {
+	if size != 16 && size != 32 && size != 64 {
+		return nil, nil, errors.New("size of the data key must be 16, 32, or 64 bytes")
+	}
+
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derivationContext, aad, err := k.getCryptParams(parentCtx, kid, "", associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if aad != "" {
+		return nil, nil, errors.New("associated data is supported with derived keys only when generating data keys")
+	}
+
+	var res struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "datakey/plaintext/"+url.PathEscape(kid.Name), dataKeyRequest{
+		Bits:    size * 8,
+		Context: derivationContext,
+	}, &res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(res.Plaintext)
+	if err != nil || len(plaintext) != size || res.Ciphertext == "" {
+		return nil, nil, errors.New("response from Vault does not contain a valid data key")
+	}
+
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, []byte(res.Ciphertext), nil
+}
+
+// WrapKey wraps a key.
+// The key argument can be in the format "name" or "name/version"; when the version is omitted, the latest one is used.
+func (k *transitCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithm string, key string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys can be wrapped
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	return k.Encrypt(parentCtx, plaintext, algorithm, key, nonce, associatedData)
+}
+
+// UnwrapKey unwraps a key.
+// The key argument can be in the format "name" or "name/version"; the version is ignored, as it is included in the wrapped key.
+func (k *transitCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	plaintext, err := k.Decrypt(parentCtx, wrappedKey, algorithm, key, nonce, tag, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only symmetric keys can be wrapped, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// Sign a digest.
+// With the EdDSA algorithm, the message is signed rather than its digest.
+// The key argument can be in the format "name" or "name/version"; when the version is omitted, the latest one is used.
+// The signature is in the format used by Transit (e.g. "vault:v1:..."), which includes the version of the key.
+func (k *transitCrypto) Sign(parentCtx context.Context, digest []byte, algorithm string, key string) (signature []byte, err error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, path, err := k.getSignRequest(parentCtx, digest, algorithm, kid)
+	if err != nil {
+		return nil, err
+	}
+	req.KeyVersion = kid.Version
+
+	var res struct {
+		Signature string `json:"signature"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "sign/"+path, req, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Signature == "" {
+		return nil, errors.New("response from Vault does not contain a valid signature")
+	}
+
+	return []byte(res.Signature), nil
+}
+
+// Verify a signature.
+// The signature must be in the format used by Transit (e.g. "vault:v1:..."), as returned by Sign.
+// The key argument can be in the format "name" or "name/version"; the version is ignored, as it is included in the signature.
+func (k *transitCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithm string, key string) (valid bool, err error) {
+	kid, err := parseKeyID(key)
+	if err != nil {
+		return false, err
+	}
+
+	req, path, err := k.getSignRequest(parentCtx, digest, algorithm, kid)
+	if err != nil {
+		return false, err
+	}
+	req.Signature = string(signature)
+
+	var res struct {
+		Valid bool `json:"valid"`
+	}
+	err = k.doRequest(parentCtx, http.MethodPost, "verify/"+path, req, &res)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Valid, nil
+}
+
+// getSignRequest returns the request to sign or verify a digest, and the path of the endpoint after the operation.
+func (k *transitCrypto) getSignRequest(parentCtx context.Context, digest []byte, algorithm string, kid keyID) (signRequest, string, error) {
+	alg, ok := signatureAlgs[algorithm]
+	if !ok {
+		return signRequest{}, "", fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	props, err := k.getKeyProperties(parentCtx, kid.Name)
+	if err != nil {
+		return signRequest{}, "", err
+	}
+	if !slices.Contains(alg.keyTypes, props.Type) {
+		return signRequest{}, "", fmt.Errorf("algorithm %s cannot be used with keys of type %s", algorithm, props.Type)
+	}
+
+	req := signRequest{
+		Input:              base64.StdEncoding.EncodeToString(digest),
+		Prehashed:          alg.hash != "",
+		SignatureAlgorithm: alg.signatureAlgorithm,
+	}
+	path := url.PathEscape(kid.Name)
+	if alg.hash != "" {
+		path += "/" + alg.hash
+	}
+	if strings.HasPrefix(props.Type, "ecdsa-") {
+		req.MarshalingAlgorithm = "asn1"
+	}
+
+	return req, path, nil
+}
+
+func (k *transitCrypto) Close() error {
+	return nil
+}
+
+func (*transitCrypto) SupportedEncryptionAlgorithms() []string {
+	return encryptionAlgsList
+}
+
+func (*transitCrypto) SupportedSignatureAlgorithms() []string {
+	return signatureAlgsList
+}
+
+func (*transitCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := transitMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
+
+// doRequest performs a request to the Transit secrets engine, decoding the "data" property of the response into resData if not nil.
+func (k *transitCrypto) doRequest(parentCtx context.Context, method string, path string, reqData any, resData any) error {
+	var body io.Reader
+	if reqData != nil {
+		b, err := json.Marshal(reqData)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, k.md.VaultAddr+"/v1/"+k.md.EnginePath+"/"+path, body)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	req.Header.Set(vaultAuth.TokenHeader, k.md.VaultToken)
+	req.Header.Set(vaultAuth.RequestHeader, "true")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error from Vault: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var errRes struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&errRes)
+		return fmt.Errorf("error from Vault: status code %d: %s", res.StatusCode, strings.Join(errRes.Errors, "; "))
+	}
+
+	if resData == nil {
+		return nil
+	}
+	envelope := struct {
+		Data any `json:"data"`
+	}{
+		Data: resData,
+	}
+	err = json.NewDecoder(res.Body).Decode(&envelope)
+	if err != nil {
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	}
+
+	return nil
+}
+
+// PublicKeyToKey converts the public key of a version of a Transit key to a contribCrypto.Key object.
+// The public key is PEM-encoded, or base64-encoded for Ed25519 keys.
+func PublicKeyToKey(res *transitKey, version int, publicKey string) (*contribCrypto.Key, error) {
+	var (
+		pk  any
+		err error
+	)
+	if res.Type == "ed25519" {
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(publicKey)
+		if err == nil && len(b) != ed25519.PublicKeySize {
+			err = errors.New("invalid size")
+		}
+		pk = ed25519.PublicKey(b)
+	} else {
+		block, _ := pem.Decode([]byte(publicKey))
+		if block == nil {
+			return nil, errors.New("response from Vault does not contain a valid public key")
+		}
+		pk, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	jwkObj, err := jwk.FromRaw(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwk.Key: %w", err)
+	}
+
+	// RSA keys can be used for both purposes, so the key usage is not set
+	switch {
+	case res.SupportsSigning && !res.SupportsEncryption:
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	case res.SupportsEncryption && !res.SupportsSigning:
+		_ = jwkObj.Set(jwk.KeyUsageKey, jwk.ForEncryption)
+	}
+
+	kid := keyID{Name: res.Name, Version: version}
+	return contribCrypto.NewKey(jwkObj, kid.String(), nil, nil), nil
+}
+
+type keyID struct {
+	// Name of the key
+	Name string
+	// Version of the key, or 0 for the latest one
+	Version int
+}
+
+func (id keyID) String() string {
+	if id.Version == 0 {
+		return id.Name
+	}
+	return id.Name + "/" + strconv.Itoa(id.Version)
+}
+
+// Parses a key argument, in the format "name" or "name/version".
+func parseKeyID(key string) (keyID, error) {
+	name, version, found := strings.Cut(key, "/")
+	if name == "" {
+		return keyID{}, errors.New("key name is empty")
+	}
+	kid := keyID{Name: name}
+	if found {
+		v, err := strconv.Atoi(version)
+		if err != nil || v < 1 {
+			return keyID{}, fmt.Errorf("invalid key version: %s", version)
+		}
+		kid.Version = v
+	}
+	return kid, nil
+}
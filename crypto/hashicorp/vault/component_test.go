@@ -0,0 +1,539 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+const testToken = "mytoken"
+
+// fakeTransit is a minimal implementation of the Transit secrets engine, performing the operations with local keys.
+type fakeTransit struct {
+	// Versions of the symmetric keys
+	aesKeys [][]byte
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	edKey   ed25519.PrivateKey
+
+	readKeyCalls atomic.Int32
+}
+
+func newFakeTransit(t *testing.T) *httptest.Server {
+	f := &fakeTransit{}
+	for range 2 {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		f.aesKeys = append(f.aesKeys, key)
+	}
+	var err error
+	f.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	f.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, f.edKey, err = ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken || r.Header.Get("X-Vault-Request") != "true" {
+		f.respondError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if parts[0] == "datakey" {
+		parts = parts[1:]
+	}
+	name := parts[1]
+	keyType, derived := "", false
+	switch name {
+	case "aes":
+		keyType = "aes256-gcm96"
+	case "derived":
+		keyType, derived = "aes256-gcm96", true
+	case "rsa":
+		keyType = "rsa-2048"
+	case "ec":
+		keyType = "ecdsa-p256"
+	case "ed":
+		keyType = "ed25519"
+	default:
+		f.respondError(w, http.StatusNotFound, "encryption key not found")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		f.readKeyCalls.Add(1)
+		f.respond(w, map[string]any{
+			"name":                name,
+			"type":                keyType,
+			"derived":             derived,
+			"latest_version":      1,
+			"supports_encryption": !strings.HasPrefix(keyType, "ecdsa") && keyType != "ed25519",
+			"supports_signing":    IsKeyTypeAsymmetric(keyType),
+			"keys":                map[string]any{"1": f.publicKey(keyType)},
+		})
+		return
+	}
+
+	var req map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	str := func(k string) string {
+		s, _ := req[k].(string)
+		return s
+	}
+	b64 := func(k string) []byte {
+		b, _ := base64.StdEncoding.DecodeString(str(k))
+		return b
+	}
+	version := 2
+	if v, ok := req["key_version"].(float64); ok {
+		version = int(v)
+	}
+
+	var err error
+	switch parts[0] {
+	case "encrypt":
+		var ct string
+		ct, err = f.encrypt(keyType, version, b64("plaintext"), b64("context"), b64("associated_data"), derived)
+		if err == nil {
+			f.respond(w, map[string]any{"ciphertext": ct})
+		}
+	case "decrypt":
+		var pt []byte
+		pt, err = f.decrypt(keyType, str("ciphertext"), b64("context"), b64("associated_data"), derived)
+		if err == nil {
+			f.respond(w, map[string]any{"plaintext": base64.StdEncoding.EncodeToString(pt)})
+		}
+	case "rewrap":
+		var pt []byte
+		pt, err = f.decrypt(keyType, str("ciphertext"), b64("context"), b64("associated_data"), derived)
+		if err == nil {
+			var ct string
+			ct, err = f.encrypt(keyType, version, pt, b64("context"), b64("associated_data"), derived)
+			f.respond(w, map[string]any{"ciphertext": ct})
+		}
+	case "plaintext":
+		bits, _ := req["bits"].(float64)
+		pt := make([]byte, int(bits)/8)
+		_, _ = rand.Read(pt)
+		var ct string
+		ct, err = f.encrypt(keyType, version, pt, b64("context"), nil, derived)
+		if err == nil {
+			f.respond(w, map[string]any{"plaintext": base64.StdEncoding.EncodeToString(pt), "ciphertext": ct})
+		}
+	case "sign":
+		var sig []byte
+		sig, err = f.sign(keyType, b64("input"))
+		if err == nil {
+			f.respond(w, map[string]any{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(sig)})
+		}
+	case "verify":
+		sig, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(str("signature"), "vault:v1:"))
+		f.respond(w, map[string]any{"valid": f.verify(keyType, b64("input"), sig)})
+	}
+	if err != nil {
+		f.respondError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+func (f *fakeTransit) respond(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func (f *fakeTransit) respondError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{msg}})
+}
+
+func (f *fakeTransit) publicKey(keyType string) any {
+	var pub any
+	switch keyType {
+	case "rsa-2048":
+		pub = &f.rsaKey.PublicKey
+	case "ecdsa-p256":
+		pub = &f.ecKey.PublicKey
+	case "ed25519":
+		return map[string]any{"public_key": base64.StdEncoding.EncodeToString(f.edKey.Public().(ed25519.PublicKey))}
+	default:
+		return 1700000000
+	}
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	return map[string]any{"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+}
+
+func (f *fakeTransit) aead(version int, derivationContext []byte, derived bool) (cipher.AEAD, error) {
+	if derived && len(derivationContext) == 0 {
+		return nil, errors.New("missing 'context' for key derivation")
+	}
+	key := f.aesKeys[version-1]
+	if derived {
+		h := sha256.Sum256(append(bytes.Clone(key), derivationContext...))
+		key = h[:]
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (f *fakeTransit) encrypt(keyType string, version int, plaintext, derivationContext, aad []byte, derived bool) (string, error) {
+	var ct []byte
+	if keyType == "rsa-2048" {
+		var err error
+		ct, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &f.rsaKey.PublicKey, plaintext, nil)
+		if err != nil {
+			return "", err
+		}
+		version = 1
+	} else {
+		aead, err := f.aead(version, derivationContext, derived)
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		_, _ = rand.Read(nonce)
+		ct = aead.Seal(nonce, nonce, plaintext, aad)
+	}
+	return "vault:v" + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+func (f *fakeTransit) decrypt(keyType string, ciphertext string, derivationContext, aad []byte, derived bool) ([]byte, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, errors.New("invalid ciphertext")
+	}
+	version, _ := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	ct, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if keyType == "rsa-2048" {
+		return rsa.DecryptOAEP(sha256.New(), rand.Reader, f.rsaKey, ct, nil)
+	}
+	aead, err := f.aead(version, derivationContext, derived)
+	if err != nil {
+		return nil, err
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, ct[:n], ct[n:], aad)
+}
+
+func (f *fakeTransit) sign(keyType string, input []byte) ([]byte, error) {
+	switch keyType {
+	case "rsa-2048":
+		return rsa.SignPSS(rand.Reader, f.rsaKey, crypto.SHA256, input, nil)
+	case "ecdsa-p256":
+		return ecdsa.SignASN1(rand.Reader, f.ecKey, input)
+	case "ed25519":
+		return ed25519.Sign(f.edKey, input), nil
+	}
+	return nil, errors.New("key type does not support signing")
+}
+
+func (f *fakeTransit) verify(keyType string, input []byte, sig []byte) bool {
+	switch keyType {
+	case "rsa-2048":
+		return rsa.VerifyPSS(&f.rsaKey.PublicKey, crypto.SHA256, input, sig, nil) == nil
+	case "ecdsa-p256":
+		return ecdsa.VerifyASN1(&f.ecKey.PublicKey, input, sig)
+	case "ed25519":
+		return ed25519.Verify(f.edKey.Public().(ed25519.PublicKey), input, sig)
+	}
+	return false
+}
+
+func newTestCrypto(t *testing.T, srv *httptest.Server) *transitCrypto {
+	k := NewHashiCorpVaultTransitCrypto(logger.NewLogger("test")).(*transitCrypto)
+	md := contribCrypto.Metadata{}
+	md.Properties = map[string]string{
+		"vaultAddr":  srv.URL,
+		"vaultToken": testToken,
+	}
+	err := k.Init(context.Background(), md)
+	require.NoError(t, err)
+	return k
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("token is required", func(t *testing.T) {
+		m := transitMetadata{}
+		err := m.InitWithMetadata(contribCrypto.Metadata{})
+		require.EqualError(t, err, "token mount path and token not set")
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		m := transitMetadata{}
+		md := contribCrypto.Metadata{}
+		md.Properties = map[string]string{"vaultToken": testToken}
+		err := m.InitWithMetadata(md)
+		require.NoError(t, err)
+		assert.Equal(t, "https://127.0.0.1:8200", m.VaultAddr)
+		assert.Equal(t, defaultEnginePath, m.EnginePath)
+		assert.Equal(t, defaultRequestTimeout, m.RequestTimeout)
+	})
+}
+
+func TestParseKeyID(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    keyID
+		wantErr bool
+	}{
+		{key: "mykey", want: keyID{Name: "mykey"}},
+		{key: "mykey/2", want: keyID{Name: "mykey", Version: 2}},
+		{key: "mykey/0", wantErr: true},
+		{key: "mykey/latest", wantErr: true},
+		{key: "/1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := parseKeyID(tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.key, got.String())
+		})
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	srv := newFakeTransit(t)
+	k := newTestCrypto(t, srv)
+	fake := srv.Config.Handler.(*fakeTransit)
+
+	t.Run("key versions are cached", func(t *testing.T) {
+		for range 2 {
+			pk, err := k.GetKey(context.Background(), "ec/1")
+			require.NoError(t, err)
+			assert.Equal(t, "ec/1", pk.(*contribCrypto.Key).KeyID())
+			assert.Equal(t, jwk.ForSignature.String(), pk.KeyUsage())
+		}
+		assert.Equal(t, int32(1), fake.readKeyCalls.Load())
+	})
+
+	t.Run("latest version is not cached", func(t *testing.T) {
+		fake.readKeyCalls.Store(0)
+		for range 2 {
+			pk, err := k.GetKey(context.Background(), "ed")
+			require.NoError(t, err)
+			assert.Equal(t, "ed/1", pk.(*contribCrypto.Key).KeyID())
+		}
+		assert.Equal(t, int32(2), fake.readKeyCalls.Load())
+	})
+
+	t.Run("symmetric key", func(t *testing.T) {
+		_, err := k.GetKey(context.Background(), "aes")
+		require.EqualError(t, err, "key aes is symmetric")
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := k.GetKey(context.Background(), "unknown")
+		require.EqualError(t, err, "error from Vault: status code 404: encryption key not found")
+	})
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	srv := newFakeTransit(t)
+	k := newTestCrypto(t, srv)
+	plaintext := []byte("hello world")
+
+	t.Run("symmetric key with associated data", func(t *testing.T) {
+		aad := []byte("aad")
+		ct, tag, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A256GCM, "aes", nil, aad)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+		assert.True(t, strings.HasPrefix(string(ct), "vault:v2:"))
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, "aes", nil, nil, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		_, err = k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, "aes", nil, nil, []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("derived key", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A256GCM, "derived", nil, nil)
+		require.ErrorContains(t, err, "associated data is required with derived keys")
+
+		ct, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A256GCM, "derived", nil, []byte("tenant-1"))
+		require.NoError(t, err)
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, "derived", nil, nil, []byte("tenant-1"))
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		_, err = k.Decrypt(context.Background(), ct, internals.Algorithm_A256GCM, "derived", nil, nil, []byte("tenant-2"))
+		require.Error(t, err)
+	})
+
+	t.Run("asymmetric key", func(t *testing.T) {
+		ct, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, "rsa", nil, nil)
+		require.NoError(t, err)
+
+		pt, err := k.Decrypt(context.Background(), ct, internals.Algorithm_RSA_OAEP_256, "rsa", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, pt)
+
+		_, _, err = k.Encrypt(context.Background(), plaintext, internals.Algorithm_RSA_OAEP_256, "rsa", nil, []byte("aad"))
+		require.EqualError(t, err, "associated data is not supported with asymmetric keys")
+	})
+
+	t.Run("algorithm not matching the key", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_C20P, "aes", nil, nil)
+		require.EqualError(t, err, "algorithm C20P cannot be used with keys of type aes256-gcm96")
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A128CBC, "aes", nil, nil)
+		require.EqualError(t, err, "invalid algorithm: A128CBC")
+	})
+}
+
+func TestRewrap(t *testing.T) {
+	srv := newFakeTransit(t)
+	k := newTestCrypto(t, srv)
+	plaintext := []byte("hello world")
+	aad := []byte("tenant-1")
+
+	ct, _, err := k.Encrypt(context.Background(), plaintext, internals.Algorithm_A256GCM, "derived/1", nil, aad)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ct), "vault:v1:"))
+
+	rewrapped, err := k.Rewrap(context.Background(), ct, "derived", aad)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(rewrapped), "vault:v2:"))
+
+	pt, err := k.Decrypt(context.Background(), rewrapped, internals.Algorithm_A256GCM, "derived", nil, nil, aad)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, pt)
+}
+
+func TestWrapKey(t *testing.T) {
+	srv := newFakeTransit(t)
+	k := newTestCrypto(t, srv)
+
+	t.Run("wrap and unwrap", func(t *testing.T) {
+		key, err := jwk.FromRaw(bytes.Repeat([]byte{0x01}, 32))
+		require.NoError(t, err)
+
+		wrapped, _, err := k.WrapKey(context.Background(), key, internals.Algorithm_A256GCM, "aes", nil, nil)
+		require.NoError(t, err)
+
+		unwrapped, err := k.UnwrapKey(context.Background(), wrapped, internals.Algorithm_A256GCM, "aes", nil, nil, nil)
+		require.NoError(t, err)
+		raw := []byte{}
+		require.NoError(t, unwrapped.Raw(&raw))
+		assert.Equal(t, bytes.Repeat([]byte{0x01}, 32), raw)
+	})
+
+	t.Run("generated data key", func(t *testing.T) {
+		aad := []byte("tenant-1")
+		key, wrapped, err := k.GenerateDataKey(context.Background(), "derived", 32, aad)
+		require.NoError(t, err)
+
+		unwrapped, err := k.UnwrapKey(context.Background(), wrapped, internals.Algorithm_A256GCM, "derived", nil, nil, aad)
+		require.NoError(t, err)
+		assert.True(t, jwk.Equal(key, unwrapped))
+	})
+
+	t.Run("invalid data key size", func(t *testing.T) {
+		_, _, err := k.GenerateDataKey(context.Background(), "aes", 24, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("data key with associated data and non-derived key", func(t *testing.T) {
+		_, _, err := k.GenerateDataKey(context.Background(), "aes", 32, []byte("aad"))
+		require.Error(t, err)
+	})
+}
+
+func TestSignVerify(t *testing.T) {
+	srv := newFakeTransit(t)
+	k := newTestCrypto(t, srv)
+	digest := sha256.Sum256([]byte("hello world"))
+	otherDigest := sha256.Sum256([]byte("other"))
+
+	tests := map[string]string{
+		internals.Algorithm_ES256: "ec",
+		internals.Algorithm_PS256: "rsa",
+		internals.Algorithm_EdDSA: "ed",
+	}
+	for alg, key := range tests {
+		t.Run(alg, func(t *testing.T) {
+			sig, err := k.Sign(context.Background(), digest[:], alg, key)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(sig), "vault:v1:"))
+
+			valid, err := k.Verify(context.Background(), digest[:], sig, alg, key)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			valid, err = k.Verify(context.Background(), otherDigest[:], sig, alg, key)
+			require.NoError(t, err)
+			assert.False(t, valid)
+		})
+	}
+
+	t.Run("algorithm not matching the key", func(t *testing.T) {
+		_, err := k.Sign(context.Background(), digest[:], internals.Algorithm_ES384, "ec")
+		require.EqualError(t, err, "algorithm ES384 cannot be used with keys of type ecdsa-p256")
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, err := k.Sign(context.Background(), digest[:], internals.Algorithm_HS256, "ec")
+		require.EqualError(t, err, "invalid algorithm: HS256")
+	})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"time"
+
+	vaultAuth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
+	"github.com/dapr/components-contrib/common/httputils"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultEnginePath     = "transit"
+	defaultRequestTimeout = 30 * time.Second
+)
+
+type transitMetadata struct {
+	httputils.ProxyMetadata `mapstructure:",squash"`
+
+	// Address of the Vault server.
+	// Defaults to "https://127.0.0.1:8200".
+	VaultAddr string `json:"vaultAddr" mapstructure:"vaultAddr"`
+
+	// Token for authentication within Vault.
+	// One of "vaultToken" and "vaultTokenMountPath" is required.
+	VaultToken string `json:"vaultToken" mapstructure:"vaultToken"`
+
+	// Path to a file containing the token for authentication within Vault.
+	VaultTokenMountPath string `json:"vaultTokenMountPath" mapstructure:"vaultTokenMountPath"`
+
+	// Inlined contents of the CA certificate to use, in PEM format.
+	// If set, takes precedence over "caPath" and "caCert".
+	CaPem string `json:"caPem" mapstructure:"caPem"`
+
+	// Path to a folder holding the CA certificate files to use, in PEM format.
+	// If set, takes precedence over "caCert".
+	CaPath string `json:"caPath" mapstructure:"caPath"`
+
+	// Path to the CA certificate to use, in PEM format.
+	CaCert string `json:"caCert" mapstructure:"caCert"`
+
+	// Skip TLS verification.
+	SkipVerify bool `json:"skipVerify" mapstructure:"skipVerify"`
+
+	// Name of the server requested during the TLS handshake.
+	TLSServerName string `json:"tlsServerName" mapstructure:"tlsServerName"`
+
+	// Path where the Transit secrets engine is mounted.
+	// Defaults to "transit".
+	EnginePath string `json:"enginePath" mapstructure:"enginePath"`
+
+	// Timeout for network requests, as a Go duration string (e.g. "30s")
+	// Defaults to "30s".
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+}
+
+func (m *transitMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Read the token from the file if needed
+	m.VaultToken, err = vaultAuth.ReadToken(m.VaultToken, m.VaultTokenMountPath)
+	if err != nil {
+		return err
+	}
+
+	if m.VaultAddr == "" {
+		m.VaultAddr = vaultAuth.DefaultAddress
+	}
+	if m.EnginePath == "" {
+		m.EnginePath = defaultEnginePath
+	}
+
+	// Set default requestTimeout if empty
+	if m.RequestTimeout < time.Second {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
+
+// tlsConfig returns the TLS configuration to connect to Vault.
+func (m *transitMetadata) tlsConfig() vaultAuth.TLSConfig {
+	return vaultAuth.TLSConfig{
+		CAPem:      m.CaPem,
+		CACert:     m.CaCert,
+		CAPath:     m.CaPath,
+		SkipVerify: m.SkipVerify,
+		ServerName: m.TLSServerName,
+	}
+}
+
+// Reset the object
+func (m *transitMetadata) reset() {
+	*m = transitMetadata{
+		RequestTimeout: defaultRequestTimeout,
+	}
+}
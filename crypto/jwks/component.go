@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -29,11 +30,17 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+// keySetProvider is implemented by objects that return the current JWKS.
+type keySetProvider interface {
+	KeySet() jwk.Set
+}
+
 type jwksCrypto struct {
 	contribCrypto.LocalCryptoBaseComponent
 
 	md      jwksMetadata
-	cache   *jwkscache.JWKSCache
+	keySet  keySetProvider
+	remote  *remoteJWKS
 	logger  logger.Logger
 	closed  atomic.Bool
 	closeCh chan struct{}
@@ -63,20 +70,26 @@ func (k *jwksCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata)
 		return fmt.Errorf("failed to load metadata: %w", err)
 	}
 
+	// JWKS fetched from HTTP(S) URLs are polled by the component
+	if strings.HasPrefix(k.md.JWKS, "https://") || strings.HasPrefix(k.md.JWKS, "http://") {
+		return k.initRemote(ctx)
+	}
+
 	// Init the JWKS cache
-	k.cache = jwkscache.NewJWKSCache(k.md.JWKS, k.logger)
-	k.cache.SetMinRefreshInterval(k.md.MinRefreshInterval)
-	k.cache.SetRequestTimeout(k.md.RequestTimeout)
+	cache := jwkscache.NewJWKSCache(k.md.JWKS, k.logger)
+	cache.SetMinRefreshInterval(k.md.MinRefreshInterval)
+	cache.SetRequestTimeout(k.md.RequestTimeout)
+	k.keySet = cache
 
 	// Start the JWKS cache in background
 	startErrCh := make(chan error)
 	go func() {
-		startErrCh <- k.cache.Start(k.getContext())
+		startErrCh <- cache.Start(k.getContext())
 	}()
 
 	// Wait for the cache to be ready
 	// Here we use the init context
-	err = k.cache.WaitForCacheReady(ctx)
+	err = cache.WaitForCacheReady(ctx)
 	if err != nil {
 		// If we have an initialization error, return
 		return err
@@ -85,6 +98,36 @@ func (k *jwksCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata)
 	return nil
 }
 
+// Init the JWKS from a HTTP(S) URL and start polling it in background.
+func (k *jwksCrypto) initRemote(ctx context.Context) error {
+	if strings.HasPrefix(k.md.JWKS, "http://") {
+		k.logger.Warn("Loading JWK from an HTTP endpoint without TLS: this is not recommended on production environments.")
+	}
+
+	remote, err := newRemoteJWKS(&k.md, k.logger)
+	if err != nil {
+		return err
+	}
+
+	// The first fetch must succeed
+	// Here we use the init context
+	err = remote.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to init JWKS: %w", err)
+	}
+	k.remote = remote
+	k.keySet = remote
+
+	runCtx := k.getContext()
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		remote.Run(runCtx)
+	}()
+
+	return nil
+}
+
 // Returns a context that is canceled when the component is closed.
 func (k *jwksCrypto) getContext() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -114,12 +157,20 @@ func (k *jwksCrypto) Features() []contribCrypto.Feature {
 
 // Retrieves a key (public or private or symmetric) from the JWKS
 func (k *jwksCrypto) retrieveKeyFromSecretFn(parentCtx context.Context, kid string) (jwk.Key, error) {
-	jwks := k.cache.KeySet()
+	jwks := k.keySet.KeySet()
 	if jwks == nil {
 		return nil, errors.New("no JWKS loaded")
 	}
 
 	key, found := jwks.LookupKeyID(kid)
+	if !found && k.remote != nil {
+		// The key may have been added to the remote JWKS since the last refresh
+		err := k.remote.RefreshIfOlder(parentCtx, k.md.MinRefreshInterval)
+		if err != nil {
+			k.logger.Warnf("Failed to refresh JWKS: %v", err)
+		}
+		key, found = k.remote.KeySet().LookupKeyID(kid)
+	}
 	if !found {
 		return nil, contribCrypto.ErrKeyNotFound
 	}
@@ -40,13 +40,26 @@ type jwksMetadata struct {
 	// Only applies when the JWKS is fetched from a HTTP(S) URL.
 	// Defaults to "10m".
 	MinRefreshInterval time.Duration `json:"minRefreshInterval" mapstructure:"minRefreshInterval"`
+	// Interval for polling the JWKS, as a Go duration string.
+	// Only applies when the JWKS is fetched from a HTTP(S) URL; requests are conditional, using the ETag and Last-Modified headers of the previous response.
+	// If the response has a Cache-Control header, the JWKS is refreshed when it expires instead, but not more often than "minRefreshInterval".
+	// Defaults to the value of "minRefreshInterval".
+	RefreshInterval time.Duration `json:"refreshInterval" mapstructure:"refreshInterval"`
+	// CA certificate to trust when fetching the JWKS from a HTTPS URL, PEM-encoded or as path to a file.
+	// If empty, the system's root CAs are used.
+	CACertificate string `json:"caCertificate" mapstructure:"caCertificate"`
+	// Client certificate to present when fetching the JWKS from a HTTPS URL, PEM-encoded or as path to a file.
+	// Must be set together with "clientKey".
+	ClientCertificate string `json:"clientCertificate" mapstructure:"clientCertificate"`
+	// Private key for the client certificate, PEM-encoded or as path to a file.
+	ClientKey string `json:"clientKey" mapstructure:"clientKey"`
 }
 
 func (m *jwksMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 	m.reset()
 
 	// Decode the metadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err := metadata.DecodeMetadata(meta.Properties, m)
 	if err != nil {
 		return err
 	}
@@ -56,13 +69,21 @@ func (m *jwksMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 		return errors.New("metadata property 'jwks' is required")
 	}
 
-	// Set default requestTimeout and minRefreshInterval if empty
+	// Set default requestTimeout, minRefreshInterval, and refreshInterval if empty
 	if m.RequestTimeout < time.Millisecond {
 		m.RequestTimeout = defaultRequestTimeout
 	}
 	if m.MinRefreshInterval < time.Second {
 		m.MinRefreshInterval = defaultMinRefreshInterval
 	}
+	if m.RefreshInterval < time.Second {
+		m.RefreshInterval = m.MinRefreshInterval
+	}
+
+	// The client certificate and key must be set together
+	if (m.ClientCertificate == "") != (m.ClientKey == "") {
+		return errors.New("metadata properties 'clientCertificate' and 'clientKey' must be set together")
+	}
 
 	return nil
 }
@@ -72,4 +93,8 @@ func (m *jwksMetadata) reset() {
 	m.JWKS = ""
 	m.RequestTimeout = defaultRequestTimeout
 	m.MinRefreshInterval = defaultMinRefreshInterval
+	m.RefreshInterval = 0
+	m.CACertificate = ""
+	m.ClientCertificate = ""
+	m.ClientKey = ""
 }
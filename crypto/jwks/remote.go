@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	// Maximum size of a JWKS returned by a remote endpoint.
+	maxRemoteJWKSSize = 4 << 20
+	// Maximum interval before refreshing the JWKS, when the endpoint returns a longer max-age.
+	maxCacheControlInterval = 24 * time.Hour
+)
+
+// remoteJWKS is a JWKS fetched from a HTTP(S) URL and refreshed periodically.
+// Requests are conditional, so the JWKS is parsed again only when it has changed; if refreshing fails, the last key set fetched successfully is kept.
+// If responses have a Cache-Control header, the JWKS is refreshed when it expires, but not more often than the minimum refresh interval.
+type remoteJWKS struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	logger             logger.Logger
+
+	jwks         jwk.Set
+	etag         string
+	lastModified string
+	lastRefresh  time.Time
+	// Interval before the next refresh, as determined by the last response
+	nextRefresh time.Duration
+	lock        sync.RWMutex
+	// Serializes refreshes
+	refreshLock sync.Mutex
+}
+
+func newRemoteJWKS(md *jwksMetadata, log logger.Logger) (*remoteJWKS, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// Load the CA certificate if we have one
+	if md.CACertificate != "" {
+		caCert, err := utils.GetPEM(md.CACertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to add root certificate to certificate pool")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load the client certificate for mTLS if we have one
+	if md.ClientCertificate != "" {
+		certPEM, err := utils.GetPEM(md.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		keyPEM, err := utils.GetPEM(md.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &remoteJWKS{
+		url: md.JWKS,
+		client: &http.Client{
+			Timeout: md.RequestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		refreshInterval:    md.RefreshInterval,
+		minRefreshInterval: md.MinRefreshInterval,
+		nextRefresh:        md.RefreshInterval,
+		logger:             log,
+	}, nil
+}
+
+// KeySet returns the jwk.Set with the current keys.
+func (r *remoteJWKS) KeySet() jwk.Set {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.jwks
+}
+
+// Run refreshes the JWKS periodically, until the context is canceled.
+// The JWKS must have been fetched once already, with Refresh.
+func (r *remoteJWKS) Run(ctx context.Context) {
+	timer := time.NewTimer(r.nextRefreshInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			interval := r.refreshInterval
+			err := r.Refresh(ctx)
+			if err != nil {
+				r.logger.Warnf("Failed to refresh JWKS, using the last key set fetched successfully: %v", err)
+			} else {
+				interval = r.nextRefreshInterval()
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// Returns the interval before the next refresh.
+func (r *remoteJWKS) nextRefreshInterval() time.Duration {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.nextRefresh
+}
+
+// Returns the interval before refreshing the JWKS returned in a response with the given headers.
+func (r *remoteJWKS) cacheInterval(header http.Header) time.Duration {
+	maxAge, ok := parseMaxAge(header.Get("Cache-Control"))
+	if !ok {
+		return r.refreshInterval
+	}
+	return min(max(maxAge, r.minRefreshInterval), maxCacheControlInterval)
+}
+
+// RefreshIfOlder refreshes the JWKS if it was last refreshed more than d ago.
+func (r *remoteJWKS) RefreshIfOlder(ctx context.Context, d time.Duration) error {
+	r.lock.RLock()
+	lastRefresh := r.lastRefresh
+	r.lock.RUnlock()
+
+	if time.Since(lastRefresh) < d {
+		return nil
+	}
+	return r.Refresh(ctx)
+}
+
+// Refresh fetches the JWKS if it has changed since the last time.
+func (r *remoteJWKS) Refresh(ctx context.Context) error {
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	r.lock.RLock()
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+	r.lock.RUnlock()
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		r.lock.Lock()
+		r.lastRefresh = time.Now()
+		r.nextRefresh = r.cacheInterval(res.Header)
+		r.lock.Unlock()
+		return nil
+	case http.StatusOK:
+		// Continue
+	default:
+		return fmt.Errorf("failed to fetch JWKS: unexpected response status code %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxRemoteJWKSSize))
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	jwks, err := jwk.Parse(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	r.lock.Lock()
+	r.jwks = jwks
+	r.etag = res.Header.Get("ETag")
+	r.lastModified = res.Header.Get("Last-Modified")
+	r.lastRefresh = time.Now()
+	r.nextRefresh = r.cacheInterval(res.Header)
+	r.lock.Unlock()
+
+	r.logger.Debug("Loaded JWKS from remote endpoint")
+	return nil
+}
+
+// Parses the value of a Cache-Control header, returning its max-age, or 0 if the response must not be cached.
+// The second value is false if the header doesn't contain any of these directives.
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	var (
+		maxAge time.Duration
+		found  bool
+	)
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0, true
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			maxAge = maxCacheControlInterval
+			if seconds < int64(maxCacheControlInterval/time.Second) {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+			found = true
+		}
+	}
+	return maxAge, found
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// Returns a JWKS containing symmetric keys with the given IDs.
+func testJWKS(t *testing.T, kids ...string) []byte {
+	set := jwk.NewSet()
+	for _, kid := range kids {
+		key, err := jwk.FromRaw([]byte("0123456789abcdef"))
+		require.NoError(t, err)
+		require.NoError(t, key.Set(jwk.KeyIDKey, kid))
+		require.NoError(t, set.AddKey(key))
+	}
+	b, err := json.Marshal(set)
+	require.NoError(t, err)
+	return b
+}
+
+// jwksServer serves a JWKS, supporting conditional requests with ETag.
+type jwksServer struct {
+	jwks     atomic.Pointer[[]byte]
+	version  atomic.Int32
+	fail     atomic.Bool
+	requests atomic.Int32
+	// Value of the Cache-Control header, if any
+	cacheControl atomic.Pointer[string]
+	// Requests that returned a full response
+	fetches atomic.Int32
+}
+
+func (s *jwksServer) set(jwks []byte) {
+	s.jwks.Store(&jwks)
+	s.version.Add(1)
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if s.fail.Load() {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if cc := s.cacheControl.Load(); cc != nil {
+		w.Header().Set("Cache-Control", *cc)
+	}
+
+	etag := `"` + strconv.Itoa(int(s.version.Load())) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.fetches.Add(1)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(*s.jwks.Load())
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	srv := &jwksServer{}
+	srv.set(testJWKS(t, "key1"))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	r, err := newRemoteJWKS(&jwksMetadata{
+		JWKS:            ts.URL,
+		RequestTimeout:  time.Second,
+		RefreshInterval: time.Minute,
+	}, logger.NewLogger("test"))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Refresh(context.Background()))
+	_, ok := r.KeySet().LookupKeyID("key1")
+	assert.True(t, ok)
+
+	t.Run("conditional requests", func(t *testing.T) {
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, int32(2), srv.requests.Load())
+		assert.Equal(t, int32(1), srv.fetches.Load())
+
+		srv.set(testJWKS(t, "key2"))
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, int32(2), srv.fetches.Load())
+		_, ok := r.KeySet().LookupKeyID("key2")
+		assert.True(t, ok)
+	})
+
+	t.Run("last good key set is kept on errors", func(t *testing.T) {
+		srv.fail.Store(true)
+		err := r.Refresh(context.Background())
+		require.ErrorContains(t, err, "unexpected response status code 500")
+		_, ok := r.KeySet().LookupKeyID("key2")
+		assert.True(t, ok)
+
+		srv.fail.Store(false)
+		srv.set([]byte("not a jwks"))
+		err = r.Refresh(context.Background())
+		require.ErrorContains(t, err, "failed to parse JWKS")
+		_, ok = r.KeySet().LookupKeyID("key2")
+		assert.True(t, ok)
+	})
+
+	t.Run("refresh if older", func(t *testing.T) {
+		srv.set(testJWKS(t, "key3"))
+		requests := srv.requests.Load()
+		require.NoError(t, r.RefreshIfOlder(context.Background(), time.Hour))
+		assert.Equal(t, requests, srv.requests.Load())
+
+		require.NoError(t, r.RefreshIfOlder(context.Background(), 0))
+		assert.Equal(t, requests+1, srv.requests.Load())
+		_, ok := r.KeySet().LookupKeyID("key3")
+		assert.True(t, ok)
+	})
+}
+
+func TestRemoteJWKSCacheControl(t *testing.T) {
+	srv := &jwksServer{}
+	srv.set(testJWKS(t, "key1"))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	r, err := newRemoteJWKS(&jwksMetadata{
+		JWKS:               ts.URL,
+		RequestTimeout:     time.Second,
+		MinRefreshInterval: 50 * time.Millisecond,
+		RefreshInterval:    time.Hour,
+	}, logger.NewLogger("test"))
+	require.NoError(t, err)
+
+	t.Run("without Cache-Control uses the refresh interval", func(t *testing.T) {
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, time.Hour, r.nextRefreshInterval())
+	})
+
+	t.Run("max-age is used for conditional requests too", func(t *testing.T) {
+		srv.cacheControl.Store(ptr("public, max-age=120"))
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, 2*time.Minute, r.nextRefreshInterval())
+	})
+
+	t.Run("background refreshes follow max-age", func(t *testing.T) {
+		srv.cacheControl.Store(ptr("max-age=0"))
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, 50*time.Millisecond, r.nextRefreshInterval())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go r.Run(ctx)
+
+		srv.set(testJWKS(t, "key2"))
+		assert.Eventually(t, func() bool {
+			_, ok := r.KeySet().LookupKeyID("key2")
+			return ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		header string
+		maxAge time.Duration
+		found  bool
+	}{
+		{header: "", found: false},
+		{header: "public", found: false},
+		{header: "max-age=300", maxAge: 5 * time.Minute, found: true},
+		{header: "public, Max-Age=\"60\", must-revalidate", maxAge: time.Minute, found: true},
+		{header: "max-age=60, no-cache", maxAge: 0, found: true},
+		{header: "no-store", maxAge: 0, found: true},
+		{header: "max-age=invalid", found: false},
+		{header: "max-age=99999999999999999", maxAge: maxCacheControlInterval, found: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			maxAge, found := parseMaxAge(tt.header)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.maxAge, maxAge)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRemoteJWKSMutualTLS(t *testing.T) {
+	// Generate a client certificate
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &clientKey.PublicKey, clientKey)
+	require.NoError(t, err)
+	clientCert, err := x509.ParseCertificate(clientDER)
+	require.NoError(t, err)
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	require.NoError(t, err)
+
+	srv := &jwksServer{}
+	srv.set(testJWKS(t, "key1"))
+	ts := httptest.NewUnstartedServer(srv)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	ts.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	md := jwksMetadata{
+		JWKS:           ts.URL,
+		RequestTimeout: time.Second,
+		CACertificate:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})),
+	}
+
+	t.Run("without client certificate", func(t *testing.T) {
+		r, err := newRemoteJWKS(&md, logger.NewLogger("test"))
+		require.NoError(t, err)
+		require.Error(t, r.Refresh(context.Background()))
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		md.ClientCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}))
+		md.ClientKey = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER}))
+		r, err := newRemoteJWKS(&md, logger.NewLogger("test"))
+		require.NoError(t, err)
+		require.NoError(t, r.Refresh(context.Background()))
+		_, ok := r.KeySet().LookupKeyID("key1")
+		assert.True(t, ok)
+	})
+}
+
+func TestComponentWithRemoteJWKS(t *testing.T) {
+	srv := &jwksServer{}
+	srv.set(testJWKS(t, "key1"))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	k := NewJWKSCrypto(logger.NewLogger("test")).(*jwksCrypto)
+	md := contribCrypto.Metadata{}
+	md.Properties = map[string]string{
+		"jwks":               ts.URL,
+		"minRefreshInterval": "1s",
+	}
+	require.NoError(t, k.Init(context.Background(), md))
+	defer k.Close()
+
+	_, err := k.retrieveKeyFromSecretFn(context.Background(), "key1")
+	require.NoError(t, err)
+
+	// Keys that are not found trigger a refresh, at most once per minRefreshInterval
+	srv.set(testJWKS(t, "key1", "key2"))
+	_, err = k.retrieveKeyFromSecretFn(context.Background(), "key2")
+	require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+
+	k.remote.lock.Lock()
+	k.remote.lastRefresh = time.Now().Add(-time.Minute)
+	k.remote.lock.Unlock()
+	_, err = k.retrieveKeyFromSecretFn(context.Background(), "key2")
+	require.NoError(t, err)
+}
@@ -129,7 +129,11 @@ func (k LocalCryptoBaseComponent) WrapKey(parentCtx context.Context, plaintextKe
 	}
 
 	// Encrypt the data
-	wrappedKey, tag, err = internals.Encrypt(plaintext, algorithm, kek, nonce, associatedData)
+	if isECDHESKeyWrapAlgorithm(algorithm) {
+		wrappedKey, err = wrapKeyECDHES(plaintext, algorithm, kek)
+	} else {
+		wrappedKey, tag, err = internals.Encrypt(plaintext, algorithm, kek, nonce, associatedData)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
 	}
@@ -152,7 +156,12 @@ func (k LocalCryptoBaseComponent) UnwrapKey(parentCtx context.Context, wrappedKe
 	}
 
 	// Decrypt the data
-	plaintext, err := internals.Decrypt(wrappedKey, algorithm, kek, nonce, tag, associatedData)
+	var plaintext []byte
+	if isECDHESKeyWrapAlgorithm(algorithm) {
+		plaintext, err = unwrapKeyECDHES(wrappedKey, algorithm, kek)
+	} else {
+		plaintext, err = internals.Decrypt(wrappedKey, algorithm, kek, nonce, tag, associatedData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt data: %w", err)
 	}
@@ -225,9 +234,13 @@ func (k LocalCryptoBaseComponent) SupportedSignatureAlgorithms() []string {
 func populateSupportedAlgs() {
 	symmetric := internals.SupportedSymmetricAlgorithms()
 	asymmetric := internals.SupportedAsymmetricAlgorithms()
-	supportedEncryptionAlgorithms = make([]string, len(symmetric)+len(asymmetric))
-	copy(supportedEncryptionAlgorithms[0:len(symmetric)], symmetric)
-	copy(supportedEncryptionAlgorithms[len(symmetric):], asymmetric)
+	supportedEncryptionAlgorithms = make([]string, 0, len(symmetric)+len(asymmetric)+len(ecdhesKeyWrapAlgorithms))
+	supportedEncryptionAlgorithms = append(supportedEncryptionAlgorithms, symmetric...)
+	supportedEncryptionAlgorithms = append(supportedEncryptionAlgorithms, asymmetric...)
+	// ECDH-ES algorithms can be used to wrap keys only
+	supportedEncryptionAlgorithms = append(supportedEncryptionAlgorithms,
+		internals.Algorithm_ECDH_ES_A128KW, internals.Algorithm_ECDH_ES_A192KW, internals.Algorithm_ECDH_ES_A256KW,
+	)
 
 	supportedSignatureAlgorithms = internals.SupportedSignatureAlgorithms()
 }
@@ -15,6 +15,9 @@ package localstorage
 
 import (
 	"context"
+	"crypto/ecdh"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
@@ -24,6 +27,7 @@ import (
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
 
 	contribCrypto "github.com/dapr/components-contrib/crypto"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
@@ -36,10 +40,14 @@ type localStorageCrypto struct {
 
 	md     localStorageMetadata
 	logger logger.Logger
+
+	// Set when keys are stored in a PKCS#11 token
+	pkcs11 *pkcs11Keys
 }
 
 // NewLocalStorageCrypto returns a new local storage crypto provider.
 // Keys are loaded from PEM or JSON (each containing an individual JWK) files from a local folder on disk.
+// Alternatively, key pairs can be stored in a PKCS#11 token (such as an HSM or SoftHSM), in which case operations that use the private key are performed inside the token.
 func NewLocalStorageCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
 	k := &localStorageCrypto{
 		logger: logger,
@@ -56,6 +64,14 @@ func (l *localStorageCrypto) Init(_ context.Context, metadata contribCrypto.Meta
 		return fmt.Errorf("failed to load metadata: %w", err)
 	}
 
+	if l.md.PKCS11Module != "" {
+		token, err := openPKCS11Token(l.md)
+		if err != nil {
+			return fmt.Errorf("failed to open PKCS#11 token: %w", err)
+		}
+		l.pkcs11 = &pkcs11Keys{token: token}
+	}
+
 	return nil
 }
 
@@ -65,9 +81,63 @@ func (l *localStorageCrypto) Features() []contribCrypto.Feature {
 }
 
 func (l *localStorageCrypto) Close() error {
+	if l.pkcs11 != nil {
+		return l.pkcs11.Close()
+	}
 	return nil
 }
 
+func (l *localStorageCrypto) GetKey(parentCtx context.Context, key string) (jwk.Key, error) {
+	if l.pkcs11 != nil {
+		return l.pkcs11.GetKey(parentCtx, key)
+	}
+	return l.LocalCryptoBaseComponent.GetKey(parentCtx, key)
+}
+
+func (l *localStorageCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithm string, keyName string, nonce []byte, associatedData []byte) ([]byte, []byte, error) {
+	if l.pkcs11 != nil {
+		ciphertext, err := l.pkcs11.Encrypt(parentCtx, plaintext, algorithm, keyName, associatedData)
+		return ciphertext, nil, err
+	}
+	return l.LocalCryptoBaseComponent.Encrypt(parentCtx, plaintext, algorithm, keyName, nonce, associatedData)
+}
+
+func (l *localStorageCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) ([]byte, error) {
+	if l.pkcs11 != nil {
+		return l.pkcs11.Decrypt(parentCtx, ciphertext, algorithm, keyName, associatedData)
+	}
+	return l.LocalCryptoBaseComponent.Decrypt(parentCtx, ciphertext, algorithm, keyName, nonce, tag, associatedData)
+}
+
+func (l *localStorageCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithm string, keyName string, nonce []byte, associatedData []byte) ([]byte, []byte, error) {
+	if l.pkcs11 != nil {
+		wrappedKey, err := l.pkcs11.WrapKey(parentCtx, plaintextKey, algorithm, keyName, associatedData)
+		return wrappedKey, nil, err
+	}
+	return l.LocalCryptoBaseComponent.WrapKey(parentCtx, plaintextKey, algorithm, keyName, nonce, associatedData)
+}
+
+func (l *localStorageCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (jwk.Key, error) {
+	if l.pkcs11 != nil {
+		return l.pkcs11.UnwrapKey(parentCtx, wrappedKey, algorithm, keyName, associatedData)
+	}
+	return l.LocalCryptoBaseComponent.UnwrapKey(parentCtx, wrappedKey, algorithm, keyName, nonce, tag, associatedData)
+}
+
+func (l *localStorageCrypto) Sign(parentCtx context.Context, digest []byte, algorithm string, keyName string) ([]byte, error) {
+	if l.pkcs11 != nil {
+		return l.pkcs11.Sign(parentCtx, digest, algorithm, keyName)
+	}
+	return l.LocalCryptoBaseComponent.Sign(parentCtx, digest, algorithm, keyName)
+}
+
+func (l *localStorageCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithm string, keyName string) (bool, error) {
+	if l.pkcs11 != nil {
+		return l.pkcs11.Verify(parentCtx, digest, signature, algorithm, keyName)
+	}
+	return l.LocalCryptoBaseComponent.Verify(parentCtx, digest, signature, algorithm, keyName)
+}
+
 // Retrieves a key (public or private or symmetric) from a local file.
 // Parameter "key" must be the name of a file inside the "path"
 func (l *localStorageCrypto) retrieveKey(parentCtx context.Context, key string) (jwk.Key, error) {
@@ -94,6 +164,12 @@ func (l *localStorageCrypto) retrieveKey(parentCtx context.Context, key string)
 
 	// Parse the key
 	jwkObj, err := internals.ParseKey(data, contentType)
+	if err != nil {
+		// X25519 keys in PEM files are not supported by ParseKey
+		if x25519Key := parseX25519PEM(data); x25519Key != nil {
+			jwkObj, err = x25519Key, nil
+		}
+	}
 	if err == nil {
 		switch jwkObj.KeyType() {
 		case jwa.EC, jwa.RSA, jwa.OKP, jwa.OctetSeq:
@@ -109,6 +185,41 @@ func (l *localStorageCrypto) retrieveKey(parentCtx context.Context, key string)
 	return jwkObj, nil
 }
 
+// Parses a PEM-encoded X25519 private (PKCS#8) or public (PKIX) key.
+// Returns nil if the data doesn't contain a X25519 key.
+func parseX25519PEM(data []byte) jwk.Key {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil
+	}
+
+	var rawKey any
+	switch block.Type {
+	case "PRIVATE KEY":
+		ecdhKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if k, ok := ecdhKey.(*ecdh.PrivateKey); err == nil && ok && k.Curve() == ecdh.X25519() {
+			rawKey, err = x25519.NewKeyFromSeed(k.Bytes())
+			if err != nil {
+				return nil
+			}
+		}
+	case "PUBLIC KEY":
+		ecdhKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if k, ok := ecdhKey.(*ecdh.PublicKey); err == nil && ok && k.Curve() == ecdh.X25519() {
+			rawKey = x25519.PublicKey(k.Bytes())
+		}
+	}
+	if rawKey == nil {
+		return nil
+	}
+
+	key, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
 func (localStorageCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := localStorageMetadata{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
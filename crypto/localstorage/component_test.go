@@ -0,0 +1,359 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeToken is a pkcs11Token that keeps the key pairs in memory.
+type fakeToken struct {
+	keys   map[string]crypto.Signer
+	closed bool
+}
+
+func (f *fakeToken) FindKeyPair(label string) (crypto.Signer, error) {
+	return f.keys[label], nil
+}
+
+func (f *fakeToken) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newPKCS11TestCrypto(t *testing.T) (*localStorageCrypto, *fakeToken) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	token := &fakeToken{
+		keys: map[string]crypto.Signer{
+			"rsakey": rsaKey,
+			"eckey":  ecKey,
+		},
+	}
+	l := NewLocalStorageCrypto(logger.NewLogger("test")).(*localStorageCrypto)
+	l.pkcs11 = &pkcs11Keys{token: token}
+	return l, token
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("path is required", func(t *testing.T) {
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(contribCrypto.Metadata{})
+		require.ErrorContains(t, err, "'path' is required")
+	})
+
+	t.Run("path is a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"path": dir,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, dir, md.Path)
+	})
+
+	t.Run("path is not a directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "key.json")
+		require.NoError(t, os.WriteFile(file, []byte("{}"), 0o600))
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"path": file,
+		}))
+		require.ErrorContains(t, err, "is not a directory")
+	})
+
+	t.Run("PKCS#11 with slot", func(t *testing.T) {
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"pkcs11Module": "/usr/lib/softhsm/libsofthsm2.so",
+			"pkcs11Slot":   "2",
+			"pkcs11Pin":    "1234",
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, md.PKCS11Slot)
+		assert.Equal(t, 2, *md.PKCS11Slot)
+		assert.Equal(t, "1234", md.PKCS11Pin)
+		assert.Empty(t, md.Path)
+	})
+
+	t.Run("PKCS#11 with token label", func(t *testing.T) {
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"pkcs11Module":     "/usr/lib/softhsm/libsofthsm2.so",
+			"pkcs11TokenLabel": "dapr",
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, md.PKCS11Slot)
+		assert.Equal(t, "dapr", md.PKCS11TokenLabel)
+	})
+
+	t.Run("PKCS#11 requires exactly one of slot and token label", func(t *testing.T) {
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"pkcs11Module": "/usr/lib/softhsm/libsofthsm2.so",
+		}))
+		require.ErrorContains(t, err, "exactly one of")
+
+		err = md.InitWithMetadata(newMetadata(map[string]string{
+			"pkcs11Module":     "/usr/lib/softhsm/libsofthsm2.so",
+			"pkcs11Slot":       "0",
+			"pkcs11TokenLabel": "dapr",
+		}))
+		require.ErrorContains(t, err, "exactly one of")
+	})
+
+	t.Run("PKCS#11 slot must not be negative", func(t *testing.T) {
+		md := localStorageMetadata{}
+		err := md.InitWithMetadata(newMetadata(map[string]string{
+			"pkcs11Module": "/usr/lib/softhsm/libsofthsm2.so",
+			"pkcs11Slot":   "-1",
+		}))
+		require.ErrorContains(t, err, "must not be negative")
+	})
+}
+
+func TestPKCS11GetKey(t *testing.T) {
+	l, token := newPKCS11TestCrypto(t)
+
+	key, err := l.GetKey(context.Background(), "rsakey")
+	require.NoError(t, err)
+	assert.Equal(t, jwa.RSA, key.KeyType())
+	rawKey := &rsa.PublicKey{}
+	require.NoError(t, key.Raw(rawKey))
+	assert.True(t, rawKey.Equal(token.keys["rsakey"].Public()))
+
+	key, err = l.GetKey(context.Background(), "eckey")
+	require.NoError(t, err)
+	assert.Equal(t, jwa.EC, key.KeyType())
+
+	_, err = l.GetKey(context.Background(), "notfound")
+	require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+}
+
+func TestPKCS11EncryptDecrypt(t *testing.T) {
+	l, _ := newPKCS11TestCrypto(t)
+	ctx := context.Background()
+
+	for _, alg := range []string{"RSA1_5", "RSA-OAEP", "RSA-OAEP-256", "RSA-OAEP-512"} {
+		t.Run(alg, func(t *testing.T) {
+			ciphertext, tag, err := l.Encrypt(ctx, []byte("hello world"), alg, "rsakey", nil, nil)
+			require.NoError(t, err)
+			assert.Nil(t, tag)
+
+			plaintext, err := l.Decrypt(ctx, ciphertext, alg, "rsakey", nil, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(plaintext))
+		})
+	}
+
+	t.Run("OAEP label", func(t *testing.T) {
+		ciphertext, _, err := l.Encrypt(ctx, []byte("hello world"), "RSA-OAEP-256", "rsakey", nil, []byte("label"))
+		require.NoError(t, err)
+
+		_, err = l.Decrypt(ctx, ciphertext, "RSA-OAEP-256", "rsakey", nil, nil, []byte("other"))
+		require.Error(t, err)
+
+		plaintext, err := l.Decrypt(ctx, ciphertext, "RSA-OAEP-256", "rsakey", nil, nil, []byte("label"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(plaintext))
+	})
+
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		_, _, err := l.Encrypt(ctx, []byte("hello world"), "A256GCM", "rsakey", nil, nil)
+		require.Error(t, err)
+		_, err = l.Decrypt(ctx, []byte("hello world"), "A256GCM", "rsakey", nil, nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("EC key", func(t *testing.T) {
+		_, err := l.Decrypt(ctx, []byte("hello world"), "RSA-OAEP", "eckey", nil, nil, nil)
+		require.ErrorContains(t, err, "cannot perform the 'decrypt' operation")
+	})
+}
+
+func TestPKCS11WrapUnwrapKey(t *testing.T) {
+	l, _ := newPKCS11TestCrypto(t)
+	ctx := context.Background()
+
+	rawKey := make([]byte, 32)
+	_, err := rand.Read(rawKey)
+	require.NoError(t, err)
+	plaintextKey, err := jwk.FromRaw(rawKey)
+	require.NoError(t, err)
+
+	wrapped, tag, err := l.WrapKey(ctx, plaintextKey, "RSA-OAEP-256", "rsakey", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, tag)
+
+	unwrapped, err := l.UnwrapKey(ctx, wrapped, "RSA-OAEP-256", "rsakey", nil, nil, nil)
+	require.NoError(t, err)
+	var unwrappedRaw []byte
+	require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+	assert.Equal(t, rawKey, unwrappedRaw)
+}
+
+func TestPKCS11SignVerify(t *testing.T) {
+	l, _ := newPKCS11TestCrypto(t)
+	ctx := context.Background()
+
+	digest := sha256.Sum256([]byte("hello world"))
+
+	tests := []struct {
+		alg string
+		key string
+	}{
+		{alg: "RS256", key: "rsakey"},
+		{alg: "PS256", key: "rsakey"},
+		{alg: "ES256", key: "eckey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			signature, err := l.Sign(ctx, digest[:], tt.alg, tt.key)
+			require.NoError(t, err)
+
+			valid, err := l.Verify(ctx, digest[:], signature, tt.alg, tt.key)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			signature[0] ^= 0xff
+			valid, err = l.Verify(ctx, digest[:], signature, tt.alg, tt.key)
+			require.NoError(t, err)
+			assert.False(t, valid)
+		})
+	}
+
+	t.Run("key type mismatch", func(t *testing.T) {
+		_, err := l.Sign(ctx, digest[:], "ES256", "rsakey")
+		require.ErrorContains(t, err, "cannot be used with algorithm 'ES256'")
+		_, err = l.Sign(ctx, digest[:], "RS256", "eckey")
+		require.ErrorContains(t, err, "cannot be used with algorithm 'RS256'")
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := l.Sign(ctx, digest[:], "EdDSA", "eckey")
+		require.Error(t, err)
+	})
+}
+
+func TestPKCS11Close(t *testing.T) {
+	l, token := newPKCS11TestCrypto(t)
+	require.NoError(t, l.Close())
+	assert.True(t, token.closed)
+}
+
+func TestOKPKeys(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name string, blockType string, der []byte) {
+		t.Helper()
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	writePEM("ed25519.pem", "PRIVATE KEY", der)
+
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKCS8PrivateKey(xKey)
+	require.NoError(t, err)
+	writePEM("x25519.pem", "PRIVATE KEY", der)
+	der, err = x509.MarshalPKIXPublicKey(xKey.PublicKey())
+	require.NoError(t, err)
+	writePEM("x25519-pub.pem", "PUBLIC KEY", der)
+
+	l := NewLocalStorageCrypto(logger.NewLogger("test"))
+	err = l.Init(context.Background(), newMetadata(map[string]string{
+		"path": dir,
+	}))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Ed25519 sign and verify", func(t *testing.T) {
+		signature, err := l.Sign(ctx, []byte("hello world"), "EdDSA", "ed25519.pem")
+		require.NoError(t, err)
+		assert.True(t, ed25519.Verify(edKey.Public().(ed25519.PublicKey), []byte("hello world"), signature))
+
+		valid, err := l.Verify(ctx, []byte("hello world"), signature, "EdDSA", "ed25519.pem")
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = l.Verify(ctx, []byte("hello world!"), signature, "EdDSA", "ed25519.pem")
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("X25519 public key", func(t *testing.T) {
+		key, err := l.GetKey(ctx, "x25519.pem")
+		require.NoError(t, err)
+		assert.Equal(t, jwa.OKP, key.KeyType())
+		crv, _ := key.Get("crv")
+		assert.Equal(t, jwa.X25519, crv)
+	})
+
+	t.Run("X25519 wrap and unwrap", func(t *testing.T) {
+		rawKey := make([]byte, 32)
+		_, err := rand.Read(rawKey)
+		require.NoError(t, err)
+		plaintextKey, err := jwk.FromRaw(rawKey)
+		require.NoError(t, err)
+
+		// Wrap with the public key only
+		wrapped, _, err := l.WrapKey(ctx, plaintextKey, "ECDH-ES+A256KW", "x25519-pub.pem", nil, nil)
+		require.NoError(t, err)
+
+		unwrapped, err := l.UnwrapKey(ctx, wrapped, "ECDH-ES+A256KW", "x25519.pem", nil, nil, nil)
+		require.NoError(t, err)
+		var unwrappedRaw []byte
+		require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+		assert.Equal(t, rawKey, unwrappedRaw)
+
+		_, err = l.UnwrapKey(ctx, wrapped, "ECDH-ES+A256KW", "x25519-pub.pem", nil, nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func newMetadata(props map[string]string) contribCrypto.Metadata {
+	md := contribCrypto.Metadata{}
+	md.Properties = props
+	return md
+}
@@ -26,18 +26,35 @@ import (
 type localStorageMetadata struct {
 	// Path to a local folder where keys are stored.
 	// Keys are loaded from PEM or JSON (each containing an individual JWK) files from this folder.
+	// This is ignored when using a PKCS#11 token.
 	Path string `json:"path" mapstructure:"path"`
+
+	// Path to the PKCS#11 module (shared library) used to access an HSM or a SoftHSM token.
+	// When set, keys are loaded from the PKCS#11 token instead of the local folder, and key names are the labels of the key pairs in the token.
+	PKCS11Module string `json:"pkcs11Module" mapstructure:"pkcs11Module"`
+	// Slot number of the PKCS#11 token.
+	// Exactly one of "pkcs11Slot" and "pkcs11TokenLabel" must be set when using a PKCS#11 token.
+	PKCS11Slot *int `json:"pkcs11Slot" mapstructure:"pkcs11Slot"`
+	// Label of the PKCS#11 token.
+	PKCS11TokenLabel string `json:"pkcs11TokenLabel" mapstructure:"pkcs11TokenLabel"`
+	// User PIN used to log into the PKCS#11 token.
+	PKCS11Pin string `json:"pkcs11Pin" mapstructure:"pkcs11Pin"`
 }
 
 func (m *localStorageMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 	m.reset()
 
 	// Decode the metadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err := metadata.DecodeMetadata(meta.Properties, m)
 	if err != nil {
 		return err
 	}
 
+	// When using a PKCS#11 token, the path is not used
+	if m.PKCS11Module != "" {
+		return m.validatePKCS11()
+	}
+
 	// Validate the path and make sure it's a directory
 	if m.Path == "" {
 		return errors.New("metadata property 'path' is required")
@@ -54,7 +71,21 @@ func (m *localStorageMetadata) InitWithMetadata(meta contribCrypto.Metadata) err
 	return nil
 }
 
+func (m *localStorageMetadata) validatePKCS11() error {
+	if (m.PKCS11Slot == nil) == (m.PKCS11TokenLabel == "") {
+		return errors.New("exactly one of the metadata properties 'pkcs11Slot' and 'pkcs11TokenLabel' is required when using a PKCS#11 token")
+	}
+	if m.PKCS11Slot != nil && *m.PKCS11Slot < 0 {
+		return errors.New("metadata property 'pkcs11Slot' must not be negative")
+	}
+	return nil
+}
+
 // Reset the object
 func (m *localStorageMetadata) reset() {
 	m.Path = ""
+	m.PKCS11Module = ""
+	m.PKCS11Slot = nil
+	m.PKCS11TokenLabel = ""
+	m.PKCS11Pin = ""
 }
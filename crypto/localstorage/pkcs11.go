@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	internals "github.com/dapr/kit/crypto"
+)
+
+// pkcs11Token is a PKCS#11 token (such as an HSM or SoftHSM) that contains key pairs.
+type pkcs11Token interface {
+	// FindKeyPair returns the key pair with the given label, or nil if it cannot be found.
+	FindKeyPair(label string) (crypto.Signer, error)
+
+	io.Closer
+}
+
+// pkcs11Keys performs cryptographic operations with key pairs stored in a PKCS#11 token.
+// Operations that use the private key (signing, decrypting, and unwrapping keys) are performed inside the token, while operations that need the public key only are performed locally.
+type pkcs11Keys struct {
+	token pkcs11Token
+}
+
+// Retrieves a key pair from the token.
+// Parameter "key" must be the label of the key pair in the token.
+func (p pkcs11Keys) getKeyPair(key string) (crypto.Signer, jwk.Key, error) {
+	signer, err := p.token.FindKeyPair(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load key '%s' from the PKCS#11 token: %w", key, err)
+	}
+	if signer == nil {
+		return nil, nil, contribCrypto.ErrKeyNotFound
+	}
+
+	pubKey, err := jwk.FromRaw(signer.Public())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to obtain public key: %w", err)
+	}
+	return signer, pubKey, nil
+}
+
+func (p pkcs11Keys) GetKey(_ context.Context, key string) (jwk.Key, error) {
+	_, pubKey, err := p.getKeyPair(key)
+	if err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+func (p pkcs11Keys) Encrypt(_ context.Context, plaintext []byte, algorithm string, keyName string, associatedData []byte) ([]byte, error) {
+	_, pubKey, err := p.getKeyPair(keyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the key: %w", err)
+	}
+
+	// Encryption uses the public key, so it's performed locally
+	ciphertext, err := internals.EncryptPublicKey(plaintext, algorithm, pubKey, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	return ciphertext, nil
+}
+
+func (p pkcs11Keys) Decrypt(_ context.Context, ciphertext []byte, algorithm string, keyName string, associatedData []byte) ([]byte, error) {
+	signer, _, err := p.getKeyPair(keyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the key: %w", err)
+	}
+	decrypter, ok := signer.(crypto.Decrypter)
+	if !ok {
+		return nil, errors.New("key cannot perform the 'decrypt' operation")
+	}
+
+	var opts crypto.DecrypterOpts
+	switch algorithm {
+	case internals.Algorithm_RSA1_5:
+		opts = &rsa.PKCS1v15DecryptOptions{}
+	case internals.Algorithm_RSA_OAEP:
+		opts = &rsa.OAEPOptions{Hash: crypto.SHA1, Label: associatedData}
+	case internals.Algorithm_RSA_OAEP_256, internals.Algorithm_RSA_OAEP_384, internals.Algorithm_RSA_OAEP_512:
+		opts = &rsa.OAEPOptions{Hash: getSHAHash(algorithm), Label: associatedData}
+	default:
+		return nil, fmt.Errorf("failed to decrypt data: %w", internals.ErrUnsupportedAlgorithm)
+	}
+
+	// Decryption is performed inside the token
+	plaintext, err := decrypter.Decrypt(rand.Reader, ciphertext, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (p pkcs11Keys) WrapKey(ctx context.Context, plaintextKey jwk.Key, algorithm string, keyName string, associatedData []byte) ([]byte, error) {
+	// Serialize the plaintextKey
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	return p.Encrypt(ctx, plaintext, algorithm, keyName, associatedData)
+}
+
+func (p pkcs11Keys) UnwrapKey(ctx context.Context, wrappedKey []byte, algorithm string, keyName string, associatedData []byte) (jwk.Key, error) {
+	plaintext, err := p.Decrypt(ctx, wrappedKey, algorithm, keyName, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// We allow wrapping/unwrapping only symmetric keys, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err := jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+	return plaintextKey, nil
+}
+
+func (p pkcs11Keys) Sign(_ context.Context, digest []byte, algorithm string, keyName string) ([]byte, error) {
+	signer, _, err := p.getKeyPair(keyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the key: %w", err)
+	}
+
+	var (
+		opts     crypto.SignerOpts
+		keyMatch bool
+	)
+	switch algorithm {
+	case internals.Algorithm_RS256, internals.Algorithm_RS384, internals.Algorithm_RS512:
+		_, keyMatch = signer.Public().(*rsa.PublicKey)
+		opts = getSHAHash(algorithm)
+	case internals.Algorithm_PS256, internals.Algorithm_PS384, internals.Algorithm_PS512:
+		_, keyMatch = signer.Public().(*rsa.PublicKey)
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: getSHAHash(algorithm)}
+	case internals.Algorithm_ES256, internals.Algorithm_ES384, internals.Algorithm_ES512:
+		_, keyMatch = signer.Public().(*ecdsa.PublicKey)
+		opts = getSHAHash(algorithm)
+	default:
+		return nil, fmt.Errorf("failed to sign message: %w", internals.ErrUnsupportedAlgorithm)
+	}
+	if !keyMatch {
+		return nil, fmt.Errorf("key cannot be used with algorithm '%s'", algorithm)
+	}
+
+	// Signing is performed inside the token
+	// ECDSA signatures are returned ASN.1-encoded, like the ones created by the local crypto components
+	signature, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	return signature, nil
+}
+
+func (p pkcs11Keys) Verify(_ context.Context, digest []byte, signature []byte, algorithm string, keyName string) (bool, error) {
+	_, pubKey, err := p.getKeyPair(keyName)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve the key: %w", err)
+	}
+
+	// Verification uses the public key, so it's performed locally
+	valid, err := internals.VerifyPublicKey(digest, signature, algorithm, pubKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to validate the signature: %w", err)
+	}
+	return valid, nil
+}
+
+func (p pkcs11Keys) Close() error {
+	return p.token.Close()
+}
+
+func getSHAHash(alg string) crypto.Hash {
+	switch alg[len(alg)-3:] {
+	case "256":
+		return crypto.SHA256
+	case "384":
+		return crypto.SHA384
+	case "512":
+		return crypto.SHA512
+	}
+	return crypto.Hash(0)
+}
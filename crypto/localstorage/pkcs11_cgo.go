@@ -0,0 +1,53 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"crypto"
+
+	"github.com/ThalesGroup/crypto11"
+)
+
+// crypto11Token is a pkcs11Token that loads a PKCS#11 module using crypto11.
+type crypto11Token struct {
+	ctx *crypto11.Context
+}
+
+func openPKCS11Token(md localStorageMetadata) (pkcs11Token, error) {
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       md.PKCS11Module,
+		SlotNumber: md.PKCS11Slot,
+		TokenLabel: md.PKCS11TokenLabel,
+		Pin:        md.PKCS11Pin,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return crypto11Token{ctx: ctx}, nil
+}
+
+func (t crypto11Token) FindKeyPair(label string) (crypto.Signer, error) {
+	signer, err := t.ctx.FindKeyPair(nil, []byte(label))
+	if err != nil || signer == nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+func (t crypto11Token) Close() error {
+	return t.ctx.Close()
+}
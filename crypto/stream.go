@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"bufio"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultStreamSegmentSize is the default size of the plaintext segments of encrypted streams.
+	DefaultStreamSegmentSize = 64 << 10
+	// Maximum size of the plaintext segments of encrypted streams.
+	maxStreamSegmentSize = 16 << 20
+
+	// Size of the nonce prefix of segments.
+	// The rest of the 12-byte nonce contains the segment counter (4 bytes) and the flag for the last segment (1 byte).
+	streamNoncePrefixSize = 7
+)
+
+// ErrStreamTruncated is returned when an encrypted stream ends before its last segment.
+var ErrStreamTruncated = errors.New("encrypted stream is truncated")
+
+// EnvelopeEncryptStream encrypts the data read from src and writes it to dst, using envelope encryption without loading the whole payload in memory.
+// Data is encrypted in segments of segmentSize bytes (DefaultStreamSegmentSize if 0) with AES-256-GCM, each with its own nonce; the last segment is flagged so truncated streams can be detected.
+// The returned envelope has no ciphertext, but it's required to decrypt the stream with EnvelopeDecryptStream.
+func EnvelopeEncryptStream(ctx context.Context, provider SubtleCrypto, dst io.Writer, src io.Reader, segmentSize int, keyWrapAlgorithm string, keyName string, associatedData []byte) (*Envelope, error) {
+	if segmentSize == 0 {
+		segmentSize = DefaultStreamSegmentSize
+	}
+	if segmentSize < 0 || segmentSize > maxStreamSegmentSize {
+		return nil, fmt.Errorf("segment size must be between 1 and %d bytes", maxStreamSegmentSize)
+	}
+
+	env, aead, err := newEnvelope(ctx, provider, keyWrapAlgorithm, keyName)
+	if err != nil {
+		return nil, err
+	}
+	env.SegmentSize = segmentSize
+	env.Nonce = make([]byte, streamNoncePrefixSize)
+	_, err = rand.Read(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	s := newStreamSegments(aead, env.Nonce, associatedData)
+	r := bufio.NewReader(src)
+	buf := make([]byte, segmentSize, segmentSize+aead.Overhead())
+	for {
+		n, last, err := readSegment(r, buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data: %w", err)
+		}
+
+		ciphertext, err := s.seal(buf[:n], last)
+		if err != nil {
+			return nil, err
+		}
+		_, err = dst.Write(ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write data: %w", err)
+		}
+
+		if last {
+			return env, nil
+		}
+	}
+}
+
+// EnvelopeDecryptStream decrypts a stream encrypted with EnvelopeEncryptStream, reading it from src and writing the plaintext to dst.
+// Each segment is authenticated before it's written to dst; however, if the stream is truncated or modified, ErrStreamTruncated or another error is returned after some data has been written already.
+// The associated data must be the same that was used to encrypt the stream.
+func EnvelopeDecryptStream(ctx context.Context, provider SubtleCrypto, env *Envelope, dst io.Writer, src io.Reader, associatedData []byte) error {
+	if env == nil || env.SegmentSize <= 0 || env.SegmentSize > maxStreamSegmentSize {
+		return errors.New("invalid envelope: not a stream")
+	}
+	if len(env.Nonce) != streamNoncePrefixSize {
+		return errors.New("invalid envelope: nonce has the wrong size")
+	}
+	aead, err := openEnvelope(ctx, provider, env)
+	if err != nil {
+		return err
+	}
+
+	s := newStreamSegments(aead, env.Nonce, associatedData)
+	r := bufio.NewReader(src)
+	buf := make([]byte, env.SegmentSize+aead.Overhead())
+	plaintextBuf := make([]byte, env.SegmentSize)
+	for {
+		n, last, err := readSegment(r, buf)
+		if err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+		if n < aead.Overhead() {
+			return ErrStreamTruncated
+		}
+
+		plaintext, err := s.open(plaintextBuf, buf[:n], last)
+		if err != nil {
+			if !last {
+				return err
+			}
+			// A short segment that fails to decrypt as the last one may be a segment in the middle of a truncated stream
+			if _, errRetry := s.open(plaintextBuf, buf[:n], false); errRetry == nil {
+				return ErrStreamTruncated
+			}
+			return err
+		}
+		_, err = dst.Write(plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to write data: %w", err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// Reads a segment from the stream, filling buf unless the stream ends.
+// Returns true if this is the last segment of the stream.
+func readSegment(r *bufio.Reader, buf []byte) (n int, last bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return n, true, nil
+	case err != nil:
+		return n, false, err
+	}
+
+	// The segment is full: check if there's more data
+	_, err = r.Peek(1)
+	if errors.Is(err, io.EOF) {
+		return n, true, nil
+	} else if err != nil {
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+// streamSegments encrypts and decrypts the segments of a stream, in order.
+type streamSegments struct {
+	aead           cipher.AEAD
+	nonce          []byte
+	associatedData []byte
+	counter        uint32
+	done           bool
+}
+
+func newStreamSegments(aead cipher.AEAD, noncePrefix []byte, associatedData []byte) *streamSegments {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, noncePrefix)
+	return &streamSegments{
+		aead:           aead,
+		nonce:          nonce,
+		associatedData: associatedData,
+	}
+}
+
+// Returns the nonce for the next segment.
+func (s *streamSegments) nextNonce(last bool) ([]byte, error) {
+	if s.done {
+		return nil, errors.New("stream has already ended")
+	}
+	if s.counter == math.MaxUint32 {
+		return nil, errors.New("stream is too large")
+	}
+
+	binary.BigEndian.PutUint32(s.nonce[streamNoncePrefixSize:], s.counter)
+	if last {
+		s.nonce[len(s.nonce)-1] = 1
+	} else {
+		s.nonce[len(s.nonce)-1] = 0
+	}
+	return s.nonce, nil
+}
+
+// Encrypts a segment in place; plaintext must have capacity for the tag.
+func (s *streamSegments) seal(plaintext []byte, last bool) ([]byte, error) {
+	nonce, err := s.nextNonce(last)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := s.aead.Seal(plaintext[:0], nonce, plaintext, s.associatedData)
+	s.counter++
+	s.done = last
+	return ciphertext, nil
+}
+
+// Decrypts a segment, using dst's storage for the plaintext.
+func (s *streamSegments) open(dst []byte, ciphertext []byte, last bool) ([]byte, error) {
+	nonce, err := s.nextNonce(last)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.aead.Open(dst[:0], nonce, ciphertext, s.associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt segment %d: %w", s.counter, err)
+	}
+	s.counter++
+	s.done = last
+	return plaintext, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamTestProvider(t *testing.T) *testLocalCrypto {
+	t.Helper()
+
+	aesKey := make([]byte, 32)
+	_, err := rand.Read(aesKey)
+	require.NoError(t, err)
+	aesJWK, err := jwk.FromRaw(aesKey)
+	require.NoError(t, err)
+	return newTestLocalCrypto(map[string]jwk.Key{"aes": aesJWK})
+}
+
+func encryptTestStream(t *testing.T, provider SubtleCrypto, plaintext []byte, segmentSize int) (*Envelope, []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	env, err := EnvelopeEncryptStream(context.Background(), provider, &buf, bytes.NewReader(plaintext), segmentSize, "A256KW", "aes", []byte("aad"))
+	require.NoError(t, err)
+	return env, buf.Bytes()
+}
+
+func TestEnvelopeStream(t *testing.T) {
+	provider := newStreamTestProvider(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		size        int
+		segmentSize int
+		segments    int
+	}{
+		{name: "empty", size: 0, segmentSize: 16, segments: 1},
+		{name: "shorter than a segment", size: 10, segmentSize: 16, segments: 1},
+		{name: "exactly one segment", size: 16, segmentSize: 16, segments: 1},
+		{name: "multiple of segment size", size: 64, segmentSize: 16, segments: 4},
+		{name: "partial last segment", size: 70, segmentSize: 16, segments: 5},
+		{name: "default segment size", size: 3*DefaultStreamSegmentSize + 100, segmentSize: 0, segments: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := make([]byte, tt.size)
+			_, err := rand.Read(plaintext)
+			require.NoError(t, err)
+
+			env, ciphertext := encryptTestStream(t, provider, plaintext, tt.segmentSize)
+			assert.Empty(t, env.Ciphertext)
+			assert.Len(t, env.Nonce, streamNoncePrefixSize)
+			assert.Len(t, ciphertext, tt.size+tt.segments*16)
+			if tt.segmentSize == 0 {
+				assert.Equal(t, DefaultStreamSegmentSize, env.SegmentSize)
+			}
+
+			var out bytes.Buffer
+			err = EnvelopeDecryptStream(ctx, provider, env, &out, bytes.NewReader(ciphertext), []byte("aad"))
+			require.NoError(t, err)
+			assert.Equal(t, string(plaintext), out.String())
+		})
+	}
+
+	plaintext := make([]byte, 70)
+	_, err := rand.Read(plaintext)
+	require.NoError(t, err)
+
+	t.Run("truncated at segment boundary", func(t *testing.T) {
+		env, ciphertext := encryptTestStream(t, provider, plaintext, 16)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(ciphertext[:2*32]), []byte("aad"))
+		require.ErrorIs(t, err, ErrStreamTruncated)
+	})
+
+	t.Run("truncated inside a segment", func(t *testing.T) {
+		env, ciphertext := encryptTestStream(t, provider, plaintext, 16)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(ciphertext[:2*32+5]), []byte("aad"))
+		require.Error(t, err)
+	})
+
+	t.Run("empty ciphertext", func(t *testing.T) {
+		env, _ := encryptTestStream(t, provider, plaintext, 16)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(nil), []byte("aad"))
+		require.ErrorIs(t, err, ErrStreamTruncated)
+	})
+
+	t.Run("appended data", func(t *testing.T) {
+		env, ciphertext := encryptTestStream(t, provider, plaintext, 16)
+		ciphertext = append(ciphertext, ciphertext[:32]...)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(ciphertext), []byte("aad"))
+		require.Error(t, err)
+	})
+
+	t.Run("reordered segments", func(t *testing.T) {
+		env, ciphertext := encryptTestStream(t, provider, plaintext, 16)
+		reordered := append(append(bytes.Clone(ciphertext[32:64]), ciphertext[:32]...), ciphertext[64:]...)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(reordered), []byte("aad"))
+		require.ErrorContains(t, err, "failed to decrypt segment 0")
+	})
+
+	t.Run("wrong associated data", func(t *testing.T) {
+		env, ciphertext := encryptTestStream(t, provider, plaintext, 16)
+		err := EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(ciphertext), []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid segment size", func(t *testing.T) {
+		_, err := EnvelopeEncryptStream(ctx, provider, io.Discard, bytes.NewReader(plaintext), -1, "A256KW", "aes", nil)
+		require.Error(t, err)
+		_, err = EnvelopeEncryptStream(ctx, provider, io.Discard, bytes.NewReader(plaintext), maxStreamSegmentSize+1, "A256KW", "aes", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("not a stream", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(ctx, provider, plaintext, "A256KW", "aes", nil)
+		require.NoError(t, err)
+		err = EnvelopeDecryptStream(ctx, provider, env, io.Discard, bytes.NewReader(env.Ciphertext), nil)
+		require.ErrorContains(t, err, "not a stream")
+
+		streamEnv, _ := encryptTestStream(t, provider, plaintext, 16)
+		_, err = EnvelopeDecrypt(ctx, provider, streamEnv, nil)
+		require.ErrorContains(t, err, "EnvelopeDecryptStream")
+	})
+}
+
+// Encrypts a large payload that is streamed through a pipe.
+func TestEnvelopeStreamLarge(t *testing.T) {
+	provider := newStreamTestProvider(t)
+	ctx := context.Background()
+
+	const size = 64 << 20
+	pr, pw := io.Pipe()
+	var (
+		env    *Envelope
+		encErr error
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		env, encErr = EnvelopeEncryptStream(ctx, provider, pw, io.LimitReader(zeroReader{}, size), 0, "A256KW", "aes", nil)
+		pw.CloseWithError(encErr)
+	}()
+
+	// Discard the ciphertext while counting its size
+	n, err := io.Copy(io.Discard, pr)
+	require.NoError(t, err)
+	<-done
+	require.NoError(t, encErr)
+	segments := (size + DefaultStreamSegmentSize - 1) / DefaultStreamSegmentSize
+	assert.Equal(t, int64(size+segments*16), n)
+	assert.NotNil(t, env)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/common/utils"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+// Names of the attributes of lock items, besides the partition key and the TTL attribute.
+const (
+	attributeOwner        = "lockOwner"
+	attributeExpiresAt    = "expiresAt"
+	attributeFencingToken = "fencingToken"
+)
+
+// Returns the partition key of the item holding the fencing token counter of a lock.
+func fencingCounterKey(resourceID string) string {
+	return "dapr-lock-fencing||" + resourceID
+}
+
+// DynamoDBLock is a lock store implementation for DynamoDB.
+// Each lock is an item that is acquired with a conditional update, and whose lease is extended with heartbeats until the lock expires or is released.
+// Items of locks that are not used anymore are removed by DynamoDB using the TTL attribute.
+// Fencing tokens are taken from a counter kept in a separate item without a TTL, so they keep increasing after the item of the lock is removed.
+type DynamoDBLock struct {
+	authProvider awsAuth.Provider
+	metadata     dynamoDBLockMetadata
+	logger       logger.Logger
+
+	// Heartbeats of the locks held by this instance, keyed by resource ID.
+	held     map[string]heldLease
+	heldLock sync.Mutex
+
+	closeCh chan struct{}
+	closed  sync.Once
+	wg      sync.WaitGroup
+}
+
+type heldLease struct {
+	fencingToken int64
+	cancel       context.CancelFunc
+}
+
+// NewDynamoDBLock returns a new DynamoDB lock store.
+func NewDynamoDBLock(logger logger.Logger) lock.Store {
+	return &DynamoDBLock{
+		logger:  logger,
+		held:    map[string]heldLease{},
+		closeCh: make(chan struct{}),
+	}
+}
+
+// InitLockStore does metadata and connection parsing.
+func (d *DynamoDBLock) InitLockStore(ctx context.Context, meta lock.Metadata) error {
+	err := d.metadata.InitWithMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	if d.authProvider == nil {
+		opts := awsAuth.Options{
+			Logger:       d.logger,
+			Properties:   meta.Properties,
+			Region:       d.metadata.Region,
+			Endpoint:     d.metadata.Endpoint,
+			AccessKey:    d.metadata.AccessKey,
+			SecretKey:    d.metadata.SecretKey,
+			SessionToken: d.metadata.SessionToken,
+		}
+		cfg := awsAuth.GetConfig(opts)
+		provider, err := awsAuth.NewProvider(ctx, opts, cfg)
+		if err != nil {
+			return err
+		}
+		d.authProvider = provider
+	}
+
+	// Run a dummy Get operation to validate the credentials and that we have access to the table
+	_, err = d.authProvider.DynamoDB().DynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: ptr.Of(false),
+		TableName:      ptr.Of(d.metadata.Table),
+		Key:            d.itemKey(utils.GetRandOrDefaultString("dapr-test-table")),
+	})
+	if err != nil {
+		return fmt.Errorf("error validating DynamoDB table '%s' access: %w", d.metadata.Table, err)
+	}
+
+	return nil
+}
+
+// TryLock tries to acquire a lock.
+// If the lock cannot be acquired, it returns immediately.
+func (d *DynamoDBLock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.TryLockResponse{}, errors.New("missing resource ID")
+	}
+	if req.LockOwner == "" {
+		return &lock.TryLockResponse{}, errors.New("missing lock owner")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.TryLockResponse{}, errors.New("expiry must be greater than zero")
+	}
+
+	now := time.Now()
+	deadline := now.Add(time.Duration(req.ExpiryInSeconds) * time.Second)
+	expiresAt := d.leaseExpiration(now, deadline)
+
+	token, err := d.nextFencingToken(ctx, req.ResourceID)
+	if err != nil {
+		return &lock.TryLockResponse{}, err
+	}
+
+	// The lock is only acquired with a token greater than the one of the last holder, so if another instance took a greater token and acquired the lock in the meantime, this one can't get it with a stale token
+	_, err = d.authProvider.DynamoDB().DynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           ptr.Of(d.metadata.Table),
+		Key:                 d.itemKey(req.ResourceID),
+		UpdateExpression:    ptr.Of("SET #owner = :owner, #expiresAt = :expiresAt, #ttl = :ttl, #token = :token"),
+		ConditionExpression: ptr.Of("(attribute_not_exists(#owner) OR #expiresAt < :now) AND (attribute_not_exists(#token) OR #token < :token)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#owner":     ptr.Of(attributeOwner),
+			"#expiresAt": ptr.Of(attributeExpiresAt),
+			"#token":     ptr.Of(attributeFencingToken),
+			"#ttl":       ptr.Of(d.metadata.TTLAttributeName),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner":     {S: ptr.Of(req.LockOwner)},
+			":expiresAt": numberValue(expiresAt.UnixMilli()),
+			":ttl":       numberValue(ttlValue(expiresAt)),
+			":token":     numberValue(token),
+			":now":       numberValue(now.UnixMilli()),
+		},
+	})
+	if err != nil {
+		var condErr *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		}
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	if expiresAt.Before(deadline) {
+		d.heartbeat(req.ResourceID, req.LockOwner, token, deadline)
+	}
+
+	return &lock.TryLockResponse{
+		Success:      true,
+		FencingToken: token,
+	}, nil
+}
+
+// Atomically increments the fencing token counter of a lock, returning the new value.
+func (d *DynamoDBLock) nextFencingToken(ctx context.Context, resourceID string) (int64, error) {
+	res, err := d.authProvider.DynamoDB().DynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:        ptr.Of(d.metadata.Table),
+		Key:              d.itemKey(fencingCounterKey(resourceID)),
+		UpdateExpression: ptr.Of("ADD #token :one"),
+		ExpressionAttributeNames: map[string]*string{
+			"#token": ptr.Of(attributeFencingToken),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one": numberValue(1),
+		},
+		ReturnValues: ptr.Of(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment fencing token: %w", err)
+	}
+
+	token, err := parseNumber(res.Attributes[attributeFencingToken])
+	if err != nil {
+		return 0, fmt.Errorf("invalid fencing token: %w", err)
+	}
+	return token, nil
+}
+
+// Starts sending heartbeats for a lock held by this instance, extending its lease until the lock expires.
+func (d *DynamoDBLock) heartbeat(resourceID string, owner string, token int64, deadline time.Time) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d.heldLock.Lock()
+	if prev, ok := d.held[resourceID]; ok {
+		prev.cancel()
+	}
+	d.held[resourceID] = heldLease{
+		fencingToken: token,
+		cancel:       cancel,
+	}
+	d.heldLock.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer d.release(resourceID, token)
+
+		ticker := time.NewTicker(d.metadata.HeartbeatPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.closeCh:
+				return
+			case <-ticker.C:
+			}
+
+			expiresAt := d.leaseExpiration(time.Now(), deadline)
+			ok, err := d.extendLease(ctx, resourceID, owner, token, expiresAt)
+			if err != nil {
+				// Try again at the next heartbeat: the lease is longer than the interval
+				d.logger.Warnf("Failed to extend lease of lock '%s': %v", resourceID, err)
+				continue
+			}
+			if !ok {
+				d.logger.Warnf("Lock '%s' was lost before it expired", resourceID)
+				return
+			}
+			if !expiresAt.Before(deadline) {
+				// The lease now ends when the lock expires
+				return
+			}
+		}
+	}()
+}
+
+// Extends the lease of a lock, if it's still held with the given fencing token.
+func (d *DynamoDBLock) extendLease(ctx context.Context, resourceID string, owner string, token int64, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.metadata.HeartbeatPeriod)
+	defer cancel()
+
+	_, err := d.authProvider.DynamoDB().DynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           ptr.Of(d.metadata.Table),
+		Key:                 d.itemKey(resourceID),
+		UpdateExpression:    ptr.Of("SET #expiresAt = :expiresAt, #ttl = :ttl"),
+		ConditionExpression: ptr.Of("#owner = :owner AND #token = :token AND #expiresAt >= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#owner":     ptr.Of(attributeOwner),
+			"#expiresAt": ptr.Of(attributeExpiresAt),
+			"#token":     ptr.Of(attributeFencingToken),
+			"#ttl":       ptr.Of(d.metadata.TTLAttributeName),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner":     {S: ptr.Of(owner)},
+			":token":     numberValue(token),
+			":expiresAt": numberValue(expiresAt.UnixMilli()),
+			":ttl":       numberValue(ttlValue(expiresAt)),
+			":now":       numberValue(time.Now().UnixMilli()),
+		},
+	})
+	if err != nil {
+		var condErr *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (d *DynamoDBLock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.UnlockResponse{Status: lock.InternalError}, errors.New("missing resource ID")
+	}
+
+	// The item is kept, so the fencing token is preserved for the next holder
+	now := time.Now()
+	_, err := d.authProvider.DynamoDB().DynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           ptr.Of(d.metadata.Table),
+		Key:                 d.itemKey(req.ResourceID),
+		UpdateExpression:    ptr.Of("REMOVE #owner, #expiresAt"),
+		ConditionExpression: ptr.Of("#owner = :owner AND #expiresAt >= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#owner":     ptr.Of(attributeOwner),
+			"#expiresAt": ptr.Of(attributeExpiresAt),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: ptr.Of(req.LockOwner)},
+			":now":   numberValue(now.UnixMilli()),
+		},
+		ReturnValuesOnConditionCheckFailure: ptr.Of(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+	})
+	if err != nil {
+		var condErr *dynamodb.ConditionalCheckFailedException
+		if !errors.As(err, &condErr) {
+			return &lock.UnlockResponse{Status: lock.InternalError}, fmt.Errorf("failed to release lock: %w", err)
+		}
+
+		// Check why the condition failed using the current item
+		status := lock.LockDoesNotExist
+		if condErr.Item[attributeOwner] != nil {
+			expiresAt, _ := parseNumber(condErr.Item[attributeExpiresAt])
+			if expiresAt >= now.UnixMilli() {
+				status = lock.LockBelongsToOthers
+			}
+		}
+		return &lock.UnlockResponse{
+			Status: status,
+		}, nil
+	}
+
+	d.heldLock.Lock()
+	if h, ok := d.held[req.ResourceID]; ok {
+		h.cancel()
+	}
+	d.heldLock.Unlock()
+
+	return &lock.UnlockResponse{
+		Status: lock.Success,
+	}, nil
+}
+
+// Stops tracking a lock held by this instance, if it's still held with the given fencing token.
+func (d *DynamoDBLock) release(resourceID string, token int64) {
+	d.heldLock.Lock()
+	defer d.heldLock.Unlock()
+
+	h, ok := d.held[resourceID]
+	if ok && h.fencingToken == token {
+		h.cancel()
+		delete(d.held, resourceID)
+	}
+}
+
+// Returns when the lease started at the given time ends, which can't be after the lock expires.
+func (d *DynamoDBLock) leaseExpiration(now time.Time, deadline time.Time) time.Time {
+	expiresAt := now.Add(d.metadata.LeaseDuration)
+	if expiresAt.After(deadline) {
+		return deadline
+	}
+	return expiresAt
+}
+
+func (d *DynamoDBLock) itemKey(resourceID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		d.metadata.PartitionKey: {
+			S: ptr.Of(resourceID),
+		},
+	}
+}
+
+// Close stops the heartbeats of the locks held by this instance, which are then released when their lease ends.
+func (d *DynamoDBLock) Close() error {
+	d.closed.Do(func() {
+		close(d.closeCh)
+	})
+	d.wg.Wait()
+
+	if d.authProvider != nil {
+		return d.authProvider.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (d *DynamoDBLock) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := dynamoDBLockMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.LockStoreType)
+	return
+}
+
+func numberValue(n int64) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{
+		N: ptr.Of(strconv.FormatInt(n, 10)),
+	}
+}
+
+func parseNumber(v *dynamodb.AttributeValue) (int64, error) {
+	if v == nil || v.N == nil {
+		return 0, errors.New("attribute is missing")
+	}
+	return strconv.ParseInt(aws.StringValue(v.N), 10, 64)
+}
+
+// Returns the value of the TTL attribute for an item whose lease ends at the given time.
+// DynamoDB TTLs are in seconds, so this is rounded up.
+func ttlValue(expiresAt time.Time) int64 {
+	return expiresAt.Add(time.Second - 1).Unix()
+}
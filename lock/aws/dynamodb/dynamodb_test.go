@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := dynamoDBLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"table": "locks",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "key", m.PartitionKey)
+		assert.Equal(t, "ttl", m.TTLAttributeName)
+		assert.Equal(t, 20*time.Second, m.LeaseDuration)
+		assert.Equal(t, 5*time.Second, m.HeartbeatPeriod)
+	})
+
+	t.Run("table is required", func(t *testing.T) {
+		m := dynamoDBLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := dynamoDBLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"table":            "locks",
+			"partitionKey":     "id",
+			"ttlAttributeName": "expiration",
+			"leaseDuration":    "1m",
+			"heartbeatPeriod":  "10s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "id", m.PartitionKey)
+		assert.Equal(t, "expiration", m.TTLAttributeName)
+		assert.Equal(t, time.Minute, m.LeaseDuration)
+		assert.Equal(t, 10*time.Second, m.HeartbeatPeriod)
+	})
+
+	t.Run("heartbeat period longer than lease", func(t *testing.T) {
+		m := dynamoDBLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"table":           "locks",
+			"leaseDuration":   "10s",
+			"heartbeatPeriod": "10s",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestLock(t *testing.T) {
+	newStore := func(t *testing.T, mockedDB *awsAuth.MockDynamoDB) *DynamoDBLock {
+		mockAuthProvider := &awsAuth.StaticAuth{}
+		mockAuthProvider.WithMockClients(&awsAuth.Clients{
+			Dynamo: &awsAuth.DynamoDBClients{
+				DynamoDB: mockedDB,
+			},
+		})
+
+		mockedDB.GetItemWithContextFn = func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{}, nil
+		}
+
+		store := NewDynamoDBLock(logger.NewLogger("test")).(*DynamoDBLock)
+		store.authProvider = mockAuthProvider
+		err := store.InitLockStore(context.Background(), lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"table":           "locks",
+			"leaseDuration":   "1s",
+			"heartbeatPeriod": "100ms",
+		}}})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			store.Close()
+		})
+		return store
+	}
+
+	t.Run("acquire with fencing token", func(t *testing.T) {
+		var heartbeats atomic.Int32
+		mockedDB := &awsAuth.MockDynamoDB{
+			UpdateItemWithContextFn: func(ctx context.Context, input *dynamodb.UpdateItemInput, op ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+				assert.Equal(t, "locks", *input.TableName)
+				if *input.UpdateExpression == "ADD #token :one" {
+					assert.Equal(t, "dapr-lock-fencing||resource", *input.Key["key"].S)
+					return &dynamodb.UpdateItemOutput{
+						Attributes: map[string]*dynamodb.AttributeValue{
+							attributeFencingToken: {N: ptr.Of("42")},
+						},
+					}, nil
+				}
+
+				assert.Equal(t, "resource", *input.Key["key"].S)
+				assert.Equal(t, "owner", *input.ExpressionAttributeValues[":owner"].S)
+
+				if *input.UpdateExpression == "REMOVE #owner, #expiresAt" {
+					return &dynamodb.UpdateItemOutput{}, nil
+				}
+
+				assert.Equal(t, "ttl", *input.ExpressionAttributeNames["#ttl"])
+				if *input.UpdateExpression == "SET #expiresAt = :expiresAt, #ttl = :ttl" {
+					assert.Equal(t, "42", *input.ExpressionAttributeValues[":token"].N)
+					heartbeats.Add(1)
+					return &dynamodb.UpdateItemOutput{}, nil
+				}
+
+				assert.Equal(t, "(attribute_not_exists(#owner) OR #expiresAt < :now) AND (attribute_not_exists(#token) OR #token < :token)", *input.ConditionExpression)
+				assert.Equal(t, "42", *input.ExpressionAttributeValues[":token"].N)
+				return &dynamodb.UpdateItemOutput{}, nil
+			},
+		}
+		store := newStore(t, mockedDB)
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "resource", LockOwner: "owner", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+		assert.Equal(t, int64(42), res.FencingToken)
+
+		// The lease is extended while the lock is held
+		assert.Eventually(t, func() bool {
+			return heartbeats.Load() >= 2
+		}, 2*time.Second, 50*time.Millisecond)
+
+		// Releasing the lock stops the heartbeats
+		unlockRes, err := store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.Success, unlockRes.Status)
+		assert.Eventually(t, func() bool {
+			store.heldLock.Lock()
+			defer store.heldLock.Unlock()
+			return len(store.held) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("lock held by others", func(t *testing.T) {
+		mockedDB := &awsAuth.MockDynamoDB{
+			UpdateItemWithContextFn: func(ctx context.Context, input *dynamodb.UpdateItemInput, op ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+				if *input.UpdateExpression == "ADD #token :one" {
+					return &dynamodb.UpdateItemOutput{
+						Attributes: map[string]*dynamodb.AttributeValue{
+							attributeFencingToken: {N: ptr.Of("7")},
+						},
+					}, nil
+				}
+				return nil, &dynamodb.ConditionalCheckFailedException{}
+			},
+		}
+		store := newStore(t, mockedDB)
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "resource", LockOwner: "owner", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		assert.Zero(t, res.FencingToken)
+	})
+
+	t.Run("unlock failures", func(t *testing.T) {
+		var item map[string]*dynamodb.AttributeValue
+		mockedDB := &awsAuth.MockDynamoDB{
+			UpdateItemWithContextFn: func(ctx context.Context, input *dynamodb.UpdateItemInput, op ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+				assert.Equal(t, "REMOVE #owner, #expiresAt", *input.UpdateExpression)
+				assert.Equal(t, dynamodb.ReturnValuesOnConditionCheckFailureAllOld, *input.ReturnValuesOnConditionCheckFailure)
+				return nil, &dynamodb.ConditionalCheckFailedException{Item: item}
+			},
+		}
+		store := newStore(t, mockedDB)
+
+		// No item
+		res, err := store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, res.Status)
+
+		// Released lock
+		item = map[string]*dynamodb.AttributeValue{
+			attributeFencingToken: numberValue(1),
+		}
+		res, err = store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, res.Status)
+
+		// Expired lock
+		item = map[string]*dynamodb.AttributeValue{
+			attributeOwner:        {S: ptr.Of("other")},
+			attributeExpiresAt:    numberValue(time.Now().Add(-time.Second).UnixMilli()),
+			attributeFencingToken: numberValue(1),
+		}
+		res, err = store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, res.Status)
+
+		// Lock held by someone else
+		item = map[string]*dynamodb.AttributeValue{
+			attributeOwner:        {S: ptr.Of("other")},
+			attributeExpiresAt:    numberValue(time.Now().Add(time.Minute).UnixMilli()),
+			attributeFencingToken: numberValue(1),
+		}
+		res, err = store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockBelongsToOthers, res.Status)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		store := newStore(t, &awsAuth.MockDynamoDB{})
+
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{LockOwner: "owner", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "missing resource ID")
+		_, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "missing lock owner")
+		_, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner"})
+		require.ErrorContains(t, err, "expiry must be greater than zero")
+		_, err = store.Unlock(context.Background(), &lock.UnlockRequest{LockOwner: "owner"})
+		require.ErrorContains(t, err, "missing resource ID")
+	})
+}
+
+func TestLeaseExpiration(t *testing.T) {
+	store := &DynamoDBLock{}
+	store.metadata.reset()
+
+	now := time.Now()
+	assert.Equal(t, now.Add(20*time.Second), store.leaseExpiration(now, now.Add(time.Minute)))
+	assert.Equal(t, now.Add(10*time.Second), store.leaseExpiration(now, now.Add(10*time.Second)))
+	assert.Equal(t, now.Unix()+1, ttlValue(now.Truncate(time.Second).Add(time.Millisecond)))
+}
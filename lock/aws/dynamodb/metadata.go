@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultPartitionKey     = "key"
+	defaultTTLAttributeName = "ttl"
+	defaultLeaseDuration    = 20 * time.Second
+	defaultHeartbeatPeriod  = 5 * time.Second
+)
+
+type dynamoDBLockMetadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	AccessKey    string `json:"accessKey" mapstructure:"accessKey" mdignore:"true"`
+	SecretKey    string `json:"secretKey" mapstructure:"secretKey" mdignore:"true"`
+	SessionToken string `json:"sessionToken"  mapstructure:"sessionToken" mdignore:"true"`
+	Region       string `json:"region" mapstructure:"region" mdignore:"true"`
+
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// Name of the table holding the locks.
+	Table string `json:"table" mapstructure:"table"`
+	// Name of the partition key of the table. Defaults to "key".
+	PartitionKey string `json:"partitionKey" mapstructure:"partitionKey"`
+	// Name of the attribute configured as TTL for the table, so items of expired locks are eventually deleted. Defaults to "ttl".
+	TTLAttributeName string `json:"ttlAttributeName" mapstructure:"ttlAttributeName"`
+	// Duration of the lease on each lock, which is extended with heartbeats while the lock is held.
+	// If the sidecar stops, locks it holds are released after this time, even if they haven't expired yet. Defaults to 20s.
+	LeaseDuration time.Duration `json:"leaseDuration" mapstructure:"leaseDuration"`
+	// Interval between heartbeats. Must be shorter than the lease duration. Defaults to 5s.
+	HeartbeatPeriod time.Duration `json:"heartbeatPeriod" mapstructure:"heartbeatPeriod"`
+}
+
+func (m *dynamoDBLockMetadata) InitWithMetadata(meta lock.Metadata) error {
+	m.reset()
+
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if m.Table == "" {
+		return errors.New("missing dynamodb table name")
+	}
+	if m.PartitionKey == "" {
+		m.PartitionKey = defaultPartitionKey
+	}
+	if m.TTLAttributeName == "" {
+		m.TTLAttributeName = defaultTTLAttributeName
+	}
+
+	if m.LeaseDuration < time.Second {
+		return errors.New("metadata property 'leaseDuration' must be at least 1s")
+	}
+	if m.HeartbeatPeriod <= 0 || m.HeartbeatPeriod >= m.LeaseDuration {
+		return errors.New("metadata property 'heartbeatPeriod' must be greater than zero and shorter than 'leaseDuration'")
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *dynamoDBLockMetadata) reset() {
+	m.AccessKey = ""
+	m.SecretKey = ""
+	m.SessionToken = ""
+	m.Region = ""
+	m.Endpoint = ""
+	m.Table = ""
+	m.PartitionKey = defaultPartitionKey
+	m.TTLAttributeName = defaultTTLAttributeName
+	m.LeaseDuration = defaultLeaseDuration
+	m.HeartbeatPeriod = defaultHeartbeatPeriod
+}
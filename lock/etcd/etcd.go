@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	etcdcomponent "github.com/dapr/components-contrib/common/component/etcd"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// EtcdLock is a lock store implementation for etcd.
+// Each lock is a key holding the owner, attached to a lease that is kept alive until the lock expires or is released; if the sidecar stops, the lease expires after a short TTL.
+type EtcdLock struct {
+	client   *clientv3.Client
+	metadata etcdLockMetadata
+	logger   logger.Logger
+
+	closeCh chan struct{}
+	closed  sync.Once
+	wg      sync.WaitGroup
+}
+
+// NewEtcdLock returns a new etcd lock store.
+func NewEtcdLock(logger logger.Logger) lock.Store {
+	return &EtcdLock{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// InitLockStore creates the etcd client.
+func (e *EtcdLock) InitLockStore(ctx context.Context, meta lock.Metadata) error {
+	err := e.metadata.InitWithMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	config := clientv3.Config{
+		Endpoints:   strings.Split(e.metadata.Endpoints, ","),
+		DialTimeout: e.metadata.Timeout,
+	}
+	if e.metadata.TLSEnable {
+		config.TLS, err = etcdcomponent.NewTLSConfig(e.metadata.Cert, e.metadata.Key, e.metadata.CA)
+		if err != nil {
+			return fmt.Errorf("tls authentication error: %w", err)
+		}
+	}
+
+	e.client, err = clientv3.New(config)
+	if err != nil {
+		return fmt.Errorf("initializing etcd client: %w", err)
+	}
+
+	return nil
+}
+
+// TryLock tries to acquire a lock.
+// If the lock cannot be acquired, it returns immediately.
+func (e *EtcdLock) TryLock(parentCtx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.TryLockResponse{}, errors.New("missing resource ID")
+	}
+	if req.LockOwner == "" {
+		return &lock.TryLockResponse{}, errors.New("missing lock owner")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.TryLockResponse{}, errors.New("expiry must be greater than zero")
+	}
+
+	key := e.lockKey(req.ResourceID)
+	ttl := min(e.metadata.leaseTTLSeconds(), int64(req.ExpiryInSeconds))
+
+	ctx, cancel := context.WithTimeout(parentCtx, e.metadata.Timeout)
+	defer cancel()
+
+	// The lease is granted separately, as the session's keepalive must not be bound to the context of the request
+	lease, err := e.client.Grant(ctx, ttl)
+	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to grant lease: %w", err)
+	}
+	session, err := concurrency.NewSession(e.client, concurrency.WithLease(lease.ID), concurrency.WithTTL(int(ttl)))
+	if err != nil {
+		e.revokeLease(lease.ID)
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to keep lease alive: %w", err)
+	}
+
+	res, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, req.LockOwner, clientv3.WithLease(session.Lease()))).
+		Commit()
+	if err != nil || !res.Succeeded {
+		closeErr := session.Close()
+		if closeErr != nil {
+			e.logger.Warnf("Failed to revoke lease %x: %v", session.Lease(), closeErr)
+		}
+		if err != nil {
+			return &lock.TryLockResponse{}, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		return &lock.TryLockResponse{
+			Success: false,
+		}, nil
+	}
+
+	e.hold(session, time.Duration(req.ExpiryInSeconds)*time.Second)
+
+	return &lock.TryLockResponse{
+		Success: true,
+	}, nil
+}
+
+// Keeps the lease of a lock alive until the lock expires, then revokes it so the key is deleted.
+// If the lease is revoked earlier, for example because the lock was released by another instance, this returns right away.
+func (e *EtcdLock) hold(session *concurrency.Session, expiry time.Duration) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		timer := time.NewTimer(expiry)
+		defer timer.Stop()
+
+		select {
+		case <-session.Done():
+			// The lease is gone already
+			return
+		case <-timer.C:
+		case <-e.closeCh:
+		}
+
+		err := session.Close()
+		if err != nil {
+			e.logger.Warnf("Failed to revoke lease %x: %v", session.Lease(), err)
+		}
+	}()
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (e *EtcdLock) Unlock(parentCtx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.UnlockResponse{Status: lock.InternalError}, errors.New("missing resource ID")
+	}
+
+	key := e.lockKey(req.ResourceID)
+
+	ctx, cancel := context.WithTimeout(parentCtx, e.metadata.Timeout)
+	defer cancel()
+
+	// Deleting the key is enough to release the lock; the lease is revoked afterwards so its keepalive stops
+	res, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", req.LockOwner)).
+		Then(clientv3.OpGet(key), clientv3.OpDelete(key)).
+		Else(clientv3.OpGet(key, clientv3.WithCountOnly())).
+		Commit()
+	if err != nil {
+		return &lock.UnlockResponse{Status: lock.InternalError}, fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	rangeRes := res.Responses[0].GetResponseRange()
+	if !res.Succeeded {
+		status := lock.LockBelongsToOthers
+		if rangeRes.GetCount() == 0 {
+			status = lock.LockDoesNotExist
+		}
+		return &lock.UnlockResponse{
+			Status: status,
+		}, nil
+	}
+
+	if len(rangeRes.GetKvs()) > 0 {
+		e.revokeLease(clientv3.LeaseID(rangeRes.GetKvs()[0].Lease))
+	}
+
+	return &lock.UnlockResponse{
+		Status: lock.Success,
+	}, nil
+}
+
+// Revokes a lease, logging errors only: leases that are not revoked expire after their TTL.
+func (e *EtcdLock) revokeLease(id clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.metadata.Timeout)
+	defer cancel()
+	_, err := e.client.Revoke(ctx, id)
+	if err != nil {
+		e.logger.Warnf("Failed to revoke lease %x: %v", id, err)
+	}
+}
+
+func (e *EtcdLock) lockKey(resourceID string) string {
+	return e.metadata.KeyPrefixPath + "/" + resourceID
+}
+
+// Close releases the locks held by this instance and closes the client.
+func (e *EtcdLock) Close() error {
+	e.closed.Do(func() {
+		close(e.closeCh)
+	})
+	e.wg.Wait()
+
+	if e.client == nil {
+		return nil
+	}
+	err := e.client.Close()
+	e.client = nil
+	return err
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (e *EtcdLock) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := etcdLockMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.LockStoreType)
+	return
+}
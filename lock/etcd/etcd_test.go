@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := etcdLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "dapr/locks", m.KeyPrefixPath)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+		assert.Equal(t, 10*time.Second, m.LeaseTTL)
+		assert.Equal(t, int64(10), m.leaseTTLSeconds())
+	})
+
+	t.Run("endpoints are required", func(t *testing.T) {
+		m := etcdLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := etcdLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints":     "localhost:2379",
+			"keyPrefixPath": "myapp/",
+			"leaseTTL":      "30s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "myapp", m.KeyPrefixPath)
+		assert.Equal(t, int64(30), m.leaseTTLSeconds())
+	})
+
+	t.Run("lease TTL too short", func(t *testing.T) {
+		m := etcdLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"leaseTTL":  "500ms",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("incomplete TLS configuration", func(t *testing.T) {
+		m := etcdLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"tlsEnable": "true",
+			"ca":        "ca",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestValidateRequests(t *testing.T) {
+	store := NewEtcdLock(logger.NewLogger("test"))
+	defer store.Close()
+
+	t.Run("TryLock without resource ID", func(t *testing.T) {
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{
+			LockOwner:       "owner",
+			ExpiryInSeconds: 10,
+		})
+		require.ErrorContains(t, err, "missing resource ID")
+	})
+
+	t.Run("TryLock without owner", func(t *testing.T) {
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      "resource",
+			ExpiryInSeconds: 10,
+		})
+		require.ErrorContains(t, err, "missing lock owner")
+	})
+
+	t.Run("TryLock without expiry", func(t *testing.T) {
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID: "resource",
+			LockOwner:  "owner",
+		})
+		require.ErrorContains(t, err, "expiry must be greater than zero")
+	})
+
+	t.Run("Unlock without resource ID", func(t *testing.T) {
+		res, err := store.Unlock(context.Background(), &lock.UnlockRequest{
+			LockOwner: "owner",
+		})
+		require.ErrorContains(t, err, "missing resource ID")
+		assert.Equal(t, lock.InternalError, res.Status)
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultKeyPrefixPath = "dapr/locks"
+	defaultTimeout       = 5 * time.Second
+	defaultLeaseTTL      = 10 * time.Second
+)
+
+type etcdLockMetadata struct {
+	// Comma-separated list of etcd endpoints.
+	Endpoints string `json:"endpoints" mapstructure:"endpoints"`
+	// Prefix for all keys holding locks. Defaults to "dapr/locks".
+	KeyPrefixPath string `json:"keyPrefixPath" mapstructure:"keyPrefixPath"`
+	// Timeout for operations against etcd. Defaults to 5s.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+	// TTL of the lease attached to each lock, which is kept alive while the lock is held.
+	// If the sidecar stops, locks it holds are released after this time, even if they haven't expired yet. Defaults to 10s.
+	LeaseTTL time.Duration `json:"leaseTTL" mapstructure:"leaseTTL"`
+
+	// TLS
+	TLSEnable bool   `json:"tlsEnable" mapstructure:"tlsEnable"`
+	CA        string `json:"ca" mapstructure:"ca"`
+	Cert      string `json:"cert" mapstructure:"cert"`
+	Key       string `json:"key" mapstructure:"key"`
+}
+
+func (m *etcdLockMetadata) InitWithMetadata(meta lock.Metadata) error {
+	m.reset()
+
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(m.Endpoints) == "" {
+		return errors.New("metadata property 'endpoints' is required")
+	}
+
+	m.KeyPrefixPath = strings.TrimSuffix(m.KeyPrefixPath, "/")
+	if m.KeyPrefixPath == "" {
+		return errors.New("metadata property 'keyPrefixPath' must not be empty")
+	}
+
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+
+	// etcd leases have a granularity of seconds
+	if m.LeaseTTL < time.Second {
+		return errors.New("metadata property 'leaseTTL' must be at least 1s")
+	}
+
+	if m.TLSEnable && (m.CA == "" || m.Cert == "" || m.Key == "") {
+		return errors.New("tls authentication information is incomplete")
+	}
+
+	return nil
+}
+
+// Returns the TTL of leases, in seconds.
+func (m *etcdLockMetadata) leaseTTLSeconds() int64 {
+	return int64(m.LeaseTTL.Round(time.Second) / time.Second)
+}
+
+// Reset the object
+func (m *etcdLockMetadata) reset() {
+	m.Endpoints = ""
+	m.KeyPrefixPath = defaultKeyPrefixPath
+	m.Timeout = defaultTimeout
+	m.LeaseTTL = defaultLeaseTTL
+	m.TLSEnable = false
+	m.CA = ""
+	m.Cert = ""
+	m.Key = ""
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dapr/components-contrib/common/authentication/aws"
+	pgauth "github.com/dapr/components-contrib/common/authentication/postgresql"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/kit/metadata"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	defaultTableName         = "dapr_lock"
+	defaultMetadataTableName = "dapr_metadata"
+	defaultCleanupInternal   = time.Hour
+	defaultTimeout           = 20 * time.Second // Default timeout for network requests
+)
+
+type pgLockMetadata struct {
+	pgauth.PostgresAuthMetadata `mapstructure:",squash"`
+
+	TableName         string         `mapstructure:"tableName"`         // Could be in the format "schema.table" or just "table"
+	MetadataTableName string         `mapstructure:"metadataTableName"` // Could be in the format "schema.table" or just "table"
+	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
+	CleanupInterval   *time.Duration `mapstructure:"cleanupInterval" mapstructurealiases:"cleanupIntervalInSeconds"`
+	WaitTimeout       time.Duration  `mapstructure:"waitTimeout"` // Maximum time to wait for a lock held by someone else to be released; 0 means not waiting
+
+	aws.AWSIAM `mapstructure:",squash"`
+}
+
+func (m *pgLockMetadata) InitWithMetadata(meta lock.Metadata, opts pgauth.InitWithMetadataOpts) error {
+	// Reset the object
+	m.PostgresAuthMetadata.Reset()
+	m.TableName = defaultTableName
+	m.MetadataTableName = defaultMetadataTableName
+	m.CleanupInterval = ptr.Of(defaultCleanupInternal)
+	m.Timeout = defaultTimeout
+	m.WaitTimeout = 0
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate and sanitize input
+	err = m.PostgresAuthMetadata.InitWithMetadata(meta.Properties, opts)
+	if err != nil {
+		return err
+	}
+
+	if m.TableName == "" {
+		return errors.New("invalid value for 'tableName': must not be empty")
+	}
+
+	// Timeout
+	if m.Timeout < 1*time.Second {
+		return errors.New("invalid value for 'timeout': must be greater than 1s")
+	}
+
+	// Wait timeout
+	if m.WaitTimeout < 0 {
+		return errors.New("invalid value for 'waitTimeout': must not be negative")
+	}
+
+	// Cleanup interval
+	// Non-positive value from meta means disable auto cleanup.
+	// We need to do this check because an empty string and "0" are treated differently by DecodeMetadata
+	v, ok := meta.GetProperty("cleanupInterval", "cleanupIntervalInSeconds")
+	if ok && v == "" {
+		// Handle the case of an empty string, but present
+		m.CleanupInterval = ptr.Of(defaultCleanupInternal)
+	} else if (ok && v == "0") || (m.CleanupInterval != nil && *m.CleanupInterval <= 0) {
+		m.CleanupInterval = nil
+	}
+
+	return nil
+}
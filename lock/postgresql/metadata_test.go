@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/common/authentication/postgresql"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+)
+
+func TestMetadata(t *testing.T) {
+	opts := postgresql.InitWithMetadataOpts{}
+
+	t.Run("missing connection string", func(t *testing.T) {
+		m := pgLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{}}}, opts)
+		require.ErrorContains(t, err, "connection string")
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		m := pgLockMetadata{}
+		props := map[string]string{
+			"connectionString": "foo",
+		}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "dapr_lock", m.TableName)
+		assert.Equal(t, "dapr_metadata", m.MetadataTableName)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		require.NotNil(t, m.CleanupInterval)
+		assert.Equal(t, defaultCleanupInternal, *m.CleanupInterval)
+		assert.Zero(t, m.WaitTimeout)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := pgLockMetadata{}
+		props := map[string]string{
+			"connectionString":  "foo",
+			"tableName":         "myschema.locks",
+			"metadataTableName": "myschema.metadata",
+			"timeout":           "5s",
+			"cleanupInterval":   "10m",
+			"waitTimeout":       "3s",
+		}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "myschema.locks", m.TableName)
+		assert.Equal(t, "myschema.metadata", m.MetadataTableName)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+		require.NotNil(t, m.CleanupInterval)
+		assert.Equal(t, 10*time.Minute, *m.CleanupInterval)
+		assert.Equal(t, 3*time.Second, m.WaitTimeout)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		m := pgLockMetadata{}
+		props := map[string]string{
+			"connectionString": "foo",
+			"timeout":          "500ms",
+		}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.ErrorContains(t, err, "timeout")
+	})
+
+	t.Run("cleanup disabled", func(t *testing.T) {
+		m := pgLockMetadata{}
+		props := map[string]string{
+			"connectionString": "foo",
+			"cleanupInterval":  "0",
+		}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.NoError(t, err)
+		assert.Nil(t, m.CleanupInterval)
+	})
+}
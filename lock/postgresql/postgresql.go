@@ -0,0 +1,459 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	pgauth "github.com/dapr/components-contrib/common/authentication/postgresql"
+	pginterfaces "github.com/dapr/components-contrib/common/component/postgresql/interfaces"
+	pgtransactions "github.com/dapr/components-contrib/common/component/postgresql/transactions"
+	sqlinternal "github.com/dapr/components-contrib/common/component/sql"
+	pgmigrations "github.com/dapr/components-contrib/common/component/sql/migrations/postgres"
+	"github.com/dapr/components-contrib/lock"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// PostgreSQL lock store.
+// Locks are leases stored in a table, which allows emulating the TTL of locks; concurrent attempts to acquire the same lock are serialized with transaction-level advisory locks, so they never block.
+type PostgreSQL struct {
+	logger   logger.Logger
+	metadata pgLockMetadata
+	db       pginterfaces.PGXPoolConn
+
+	gc sqlinternal.GarbageCollector
+}
+
+const (
+	// Delays between attempts to acquire a lock held by someone else, when waiting.
+	minWaitRetryDelay = 50 * time.Millisecond
+	maxWaitRetryDelay = time.Second
+)
+
+// Returned when the advisory lock could not be acquired in time; the transaction is rolled back.
+var errAdvisoryLockTimeout = errors.New("timed out waiting for the advisory lock")
+
+var _ lock.Renewer = (*PostgreSQL)(nil)
+
+// NewPostgreSQLLockStore returns a new PostgreSQL lock store.
+func NewPostgreSQLLockStore(logger logger.Logger) lock.Store {
+	return &PostgreSQL{
+		logger: logger,
+	}
+}
+
+// InitLockStore sets up the Postgres connection and performs migrations.
+func (p *PostgreSQL) InitLockStore(ctx context.Context, meta lock.Metadata) error {
+	err := p.metadata.InitWithMetadata(meta, pgauth.InitWithMetadataOpts{
+		AzureADEnabled: true,
+		AWSIAMEnabled:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	config, err := p.metadata.GetPgxPoolConfig()
+	if err != nil {
+		return err
+	}
+
+	connCtx, connCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	defer connCancel()
+	p.db, err = pgxpool.NewWithConfig(connCtx, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	defer pingCancel()
+	err = p.db.Ping(pingCtx)
+	if err != nil {
+		return fmt.Errorf("failed to ping the database: %w", err)
+	}
+
+	// Migrate schema
+	err = p.performMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Leases that have expired are ignored by all queries, so removing them is only needed to keep the table small
+	if p.metadata.CleanupInterval != nil {
+		gc, err := sqlinternal.ScheduleGarbageCollector(sqlinternal.GCOptions{
+			Logger: p.logger,
+			UpdateLastCleanupQuery: func(arg any) (string, any) {
+				return fmt.Sprintf(
+					`INSERT INTO %[1]s (key, value)
+				VALUES ('last-cleanup-lock-%[2]s', now()::text)
+				ON CONFLICT (key)
+				DO UPDATE SET value = now()::text
+					WHERE (EXTRACT('epoch' FROM now() - %[1]s.value::timestamp with time zone) * 1000)::bigint > $1`,
+					p.metadata.MetadataTableName,
+					p.metadata.TableName,
+				), arg
+			},
+			DeleteExpiredValuesQuery: fmt.Sprintf(
+				`DELETE FROM %s WHERE expires_at < now()`,
+				p.metadata.TableName,
+			),
+			CleanupInterval: *p.metadata.CleanupInterval,
+			DB:              sqlinternal.AdaptPgxConn(p.db),
+		})
+		if err != nil {
+			return err
+		}
+		p.gc = gc
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) performMigrations(ctx context.Context) error {
+	m := pgmigrations.Migrations{
+		DB:                p.db,
+		Logger:            p.logger,
+		MetadataTableName: p.metadata.MetadataTableName,
+		MetadataKey:       "migrations-lock-" + p.metadata.TableName,
+	}
+
+	lockTable := p.metadata.TableName
+
+	return m.Perform(ctx, []sqlinternal.MigrationFn{
+		// Migration 1: create the table for leases
+		func(ctx context.Context) error {
+			p.logger.Infof("Creating lock table: '%s'", lockTable)
+			_, err := p.db.Exec(ctx,
+				fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+  resource_id text NOT NULL PRIMARY KEY,
+  lock_owner text NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  expires_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX ON %[1]s (expires_at);
+`, lockTable),
+			)
+			if err != nil {
+				// Check if the error is about a duplicate key constraint violation.
+				// Note: This can occur due to a race of multiple sidecars trying to run the table creation within their own transactions.
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+					p.logger.Debugf("ignoring PostgreSQL duplicate key error for table '%s'", lockTable)
+				} else {
+					return fmt.Errorf("failed to create lock table: '%s', %v", lockTable, err)
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// TryLock tries to acquire a lock.
+// If the lock cannot be acquired, it returns immediately.
+func (p *PostgreSQL) TryLock(parentCtx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.TryLockResponse{}, errors.New("missing resource ID")
+	}
+	if req.LockOwner == "" {
+		return &lock.TryLockResponse{}, errors.New("missing lock owner")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.TryLockResponse{}, errors.New("expiry must be greater than zero")
+	}
+
+	if p.metadata.WaitTimeout > 0 {
+		return p.tryLockWait(parentCtx, req)
+	}
+
+	res, err := p.tryLock(parentCtx, req, false)
+	if err != nil {
+		return &lock.TryLockResponse{}, err
+	}
+
+	return &lock.TryLockResponse{
+		Success: res.acquired,
+	}, nil
+}
+
+// Waits for up to the wait timeout for the lock to be released, if it's held by someone else.
+// Concurrent attempts to acquire the lock wait on the advisory lock, while attempts that find the lease taken are retried with a backoff, and not later than when the lease expires.
+func (p *PostgreSQL) tryLockWait(parentCtx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.WaitTimeout)
+	defer cancel()
+
+	delay := minWaitRetryDelay
+	for {
+		res, err := p.tryLock(ctx, req, true)
+		if err != nil && ctx.Err() == nil {
+			return &lock.TryLockResponse{}, err
+		}
+		if res.acquired {
+			return &lock.TryLockResponse{
+				Success: true,
+			}, nil
+		}
+
+		wait := delay
+		if res.remaining > 0 && res.remaining < wait {
+			wait = res.remaining
+		}
+		delay = min(delay*2, maxWaitRetryDelay)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			if parentCtx.Err() != nil {
+				return &lock.TryLockResponse{}, parentCtx.Err()
+			}
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		}
+	}
+}
+
+// Result of an attempt to acquire a lock.
+type tryLockResult struct {
+	acquired bool
+	// If the lease is held by someone else, and wait is true, time until the lease expires.
+	remaining time.Duration
+}
+
+// Performs an attempt to acquire a lock.
+// If wait is true, waits for concurrent attempts to complete, until the context is done; otherwise, returns right away if there's another attempt in progress.
+func (p *PostgreSQL) tryLock(parentCtx context.Context, req *lock.TryLockRequest, wait bool) (tryLockResult, error) {
+	res, err := pgtransactions.ExecuteInTransaction[tryLockResult](parentCtx, p.logger, p.db, p.metadata.Timeout, func(ctx context.Context, tx pgx.Tx) (tryLockResult, error) {
+		// Serialize attempts to acquire the same lock with an advisory lock, which is released when the transaction ends
+		// If another attempt is in progress and we're not waiting, the lock is about to be taken, so we don't wait
+		if wait {
+			err := p.waitAdvisoryLock(ctx, tx, req.ResourceID)
+			if err != nil {
+				return tryLockResult{}, err
+			}
+		} else {
+			var acquired bool
+			err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", p.advisoryLockKey(req.ResourceID)).Scan(&acquired)
+			if err != nil {
+				return tryLockResult{}, fmt.Errorf("failed to acquire advisory lock: %w", err)
+			}
+			if !acquired {
+				return tryLockResult{}, nil
+			}
+		}
+
+		// Create the lease, or take over an expired one
+		res, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %[1]s (resource_id, lock_owner, expires_at)
+				VALUES ($1, $2, now() + $3::integer * interval '1 second')
+				ON CONFLICT (resource_id)
+				DO UPDATE SET lock_owner = EXCLUDED.lock_owner, created_at = now(), expires_at = EXCLUDED.expires_at
+					WHERE %[1]s.expires_at < now()`,
+				p.metadata.TableName),
+			req.ResourceID, req.LockOwner, req.ExpiryInSeconds,
+		)
+		if err != nil {
+			return tryLockResult{}, fmt.Errorf("failed to create lease: %w", err)
+		}
+		if res.RowsAffected() == 1 {
+			return tryLockResult{acquired: true}, nil
+		}
+		if !wait {
+			return tryLockResult{}, nil
+		}
+
+		// Find out when the current lease expires
+		var remainingMs int64
+		err = tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT (EXTRACT(EPOCH FROM expires_at - now()) * 1000)::bigint FROM %s WHERE resource_id = $1`, p.metadata.TableName),
+			req.ResourceID,
+		).Scan(&remainingMs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return tryLockResult{}, fmt.Errorf("failed to get lease expiration: %w", err)
+		}
+		return tryLockResult{remaining: time.Duration(remainingMs) * time.Millisecond}, nil
+	})
+	if errors.Is(err, errAdvisoryLockTimeout) {
+		return tryLockResult{}, nil
+	}
+	return res, err
+}
+
+// Waits for the advisory lock of a resource, for up to the time left before the context's deadline.
+func (p *PostgreSQL) waitAdvisoryLock(ctx context.Context, tx pgx.Tx, resourceID string) error {
+	// The lock timeout applies to the current transaction only
+	timeout := p.metadata.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout < time.Millisecond {
+		return errAdvisoryLockTimeout
+	}
+	_, err := tx.Exec(ctx, "SELECT set_config('lock_timeout', $1, true)", strconv.FormatInt(timeout.Milliseconds(), 10)+"ms")
+	if err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", p.advisoryLockKey(resourceID))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.LockNotAvailable {
+			return errAdvisoryLockTimeout
+		}
+		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	return nil
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (p *PostgreSQL) Unlock(parentCtx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.UnlockResponse{Status: lock.InternalError}, errors.New("missing resource ID")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	// Delete the lease if it belongs to the owner; the sub-query sees the lease as it was before the deletion
+	var (
+		deleted int
+		owner   *string
+	)
+	err := p.db.QueryRow(ctx,
+		fmt.Sprintf(`WITH deleted AS (
+				DELETE FROM %[1]s
+				WHERE resource_id = $1 AND lock_owner = $2 AND expires_at >= now()
+				RETURNING 1
+			)
+			SELECT
+				(SELECT count(*) FROM deleted),
+				(SELECT lock_owner FROM %[1]s WHERE resource_id = $1 AND expires_at >= now())`,
+			p.metadata.TableName),
+		req.ResourceID, req.LockOwner,
+	).Scan(&deleted, &owner)
+	if err != nil {
+		return &lock.UnlockResponse{Status: lock.InternalError}, fmt.Errorf("failed to delete lease: %w", err)
+	}
+
+	var status lock.Status
+	switch {
+	case deleted > 0:
+		status = lock.Success
+	case owner == nil:
+		status = lock.LockDoesNotExist
+	default:
+		status = lock.LockBelongsToOthers
+	}
+
+	return &lock.UnlockResponse{
+		Status: status,
+	}, nil
+}
+
+// RenewLock resets the TTL of a lock if it's still held by the same owner.
+func (p *PostgreSQL) RenewLock(parentCtx context.Context, req *lock.RenewLockRequest) (*lock.RenewLockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.RenewLockResponse{Status: lock.InternalError}, errors.New("missing resource ID")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.RenewLockResponse{Status: lock.InternalError}, errors.New("expiry must be greater than zero")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	// Extend the lease if it belongs to the owner; as in Unlock, the sub-query sees the lease as it was before the update
+	var (
+		renewed int
+		owner   *string
+	)
+	err := p.db.QueryRow(ctx,
+		fmt.Sprintf(`WITH renewed AS (
+				UPDATE %[1]s
+				SET expires_at = now() + $3::integer * interval '1 second'
+				WHERE resource_id = $1 AND lock_owner = $2 AND expires_at >= now()
+				RETURNING 1
+			)
+			SELECT
+				(SELECT count(*) FROM renewed),
+				(SELECT lock_owner FROM %[1]s WHERE resource_id = $1 AND expires_at >= now())`,
+			p.metadata.TableName),
+		req.ResourceID, req.LockOwner, req.ExpiryInSeconds,
+	).Scan(&renewed, &owner)
+	if err != nil {
+		return &lock.RenewLockResponse{Status: lock.InternalError}, fmt.Errorf("failed to renew lease: %w", err)
+	}
+
+	var status lock.Status
+	switch {
+	case renewed > 0:
+		status = lock.Success
+	case owner == nil:
+		status = lock.LockDoesNotExist
+	default:
+		status = lock.LockBelongsToOthers
+	}
+
+	return &lock.RenewLockResponse{
+		Status: status,
+	}, nil
+}
+
+// Returns the key of the advisory lock for a resource.
+// Advisory locks are global to the database, so the key includes the name of the table.
+func (p *PostgreSQL) advisoryLockKey(resourceID string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(p.metadata.TableName))
+	h.Write([]byte{0})
+	h.Write([]byte(resourceID))
+	return int64(h.Sum64()) //nolint:gosec
+}
+
+// Close the connection to the database.
+func (p *PostgreSQL) Close() error {
+	if p.db == nil {
+		return nil
+	}
+
+	var err error
+	if p.gc != nil {
+		err = p.gc.Close()
+	}
+
+	p.db.Close()
+	p.db = nil
+
+	return err
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (p *PostgreSQL) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := pgLockMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.LockStoreType)
+	return
+}
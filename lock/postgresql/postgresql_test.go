@@ -0,0 +1,287 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestLockStore(t *testing.T) (*PostgreSQL, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	p := NewPostgreSQLLockStore(logger.NewLogger("test")).(*PostgreSQL)
+	p.metadata.TableName = defaultTableName
+	p.metadata.Timeout = defaultTimeout
+	p.db = mock
+	return p, mock
+}
+
+func TestTryLock(t *testing.T) {
+	req := &lock.TryLockRequest{
+		ResourceID:      "resource",
+		LockOwner:       "owner",
+		ExpiryInSeconds: 10,
+	}
+
+	t.Run("lock acquired", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+			WithArgs(p.advisoryLockKey("resource")).
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(true))
+		mock.ExpectExec("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		res, err := p.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock held by another owner", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(true))
+		mock.ExpectExec("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectCommit()
+
+		res, err := p.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent attempt in progress", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(false))
+		mock.ExpectCommit()
+
+		res, err := p.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(true))
+		mock.ExpectExec("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		_, err := p.TryLock(context.Background(), req)
+		require.ErrorContains(t, err, "boom")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		p, _ := newTestLockStore(t)
+		_, err := p.TryLock(context.Background(), &lock.TryLockRequest{LockOwner: "owner", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "resource ID")
+		_, err = p.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "resource", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "lock owner")
+		_, err = p.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.ErrorContains(t, err, "expiry")
+	})
+}
+
+func TestTryLockWait(t *testing.T) {
+	req := &lock.TryLockRequest{
+		ResourceID:      "resource",
+		LockOwner:       "owner",
+		ExpiryInSeconds: 10,
+	}
+
+	expectAdvisoryLock := func(mock pgxmock.PgxPoolIface) {
+		mock.ExpectExec(`SELECT set_config\('lock_timeout', \$1, true\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	}
+
+	t.Run("lock acquired after the lease expires", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		p.metadata.WaitTimeout = 5 * time.Second
+
+		// First attempt: the lease expires in 20ms
+		mock.ExpectBegin()
+		expectAdvisoryLock(mock)
+		mock.ExpectExec("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery("SELECT .* FROM dapr_lock WHERE resource_id = \\$1").
+			WithArgs("resource").
+			WillReturnRows(pgxmock.NewRows([]string{"remaining"}).AddRow(int64(20)))
+		mock.ExpectCommit()
+
+		// Second attempt
+		mock.ExpectBegin()
+		expectAdvisoryLock(mock)
+		mock.ExpectExec("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		res, err := p.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("timed out waiting for the advisory lock", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		p.metadata.WaitTimeout = 100 * time.Millisecond
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config\('lock_timeout', \$1, true\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillDelayFor(100 * time.Millisecond).
+			WillReturnError(&pgconn.PgError{Code: pgerrcode.LockNotAvailable})
+		mock.ExpectRollback()
+
+		res, err := p.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUnlock(t *testing.T) {
+	req := &lock.UnlockRequest{
+		ResourceID: "resource",
+		LockOwner:  "owner",
+	}
+	owner := "other"
+
+	tests := []struct {
+		name    string
+		deleted int
+		owner   *string
+		status  lock.Status
+	}{
+		{name: "released", deleted: 1, owner: &req.LockOwner, status: lock.Success},
+		{name: "does not exist", deleted: 0, owner: nil, status: lock.LockDoesNotExist},
+		{name: "belongs to others", deleted: 0, owner: &owner, status: lock.LockBelongsToOthers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, mock := newTestLockStore(t)
+			mock.ExpectQuery("WITH deleted AS").
+				WithArgs("resource", "owner").
+				WillReturnRows(pgxmock.NewRows([]string{"deleted", "owner"}).AddRow(tt.deleted, tt.owner))
+
+			res, err := p.Unlock(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, res.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("database error", func(t *testing.T) {
+		p, mock := newTestLockStore(t)
+		mock.ExpectQuery("WITH deleted AS").
+			WithArgs("resource", "owner").
+			WillReturnError(errors.New("boom"))
+
+		res, err := p.Unlock(context.Background(), req)
+		require.ErrorContains(t, err, "boom")
+		assert.Equal(t, lock.InternalError, res.Status)
+	})
+}
+
+func TestRenewLock(t *testing.T) {
+	req := &lock.RenewLockRequest{
+		ResourceID:      "resource",
+		LockOwner:       "owner",
+		ExpiryInSeconds: 30,
+	}
+	owner := "other"
+
+	tests := []struct {
+		name    string
+		renewed int
+		owner   *string
+		status  lock.Status
+	}{
+		{name: "renewed", renewed: 1, owner: &req.LockOwner, status: lock.Success},
+		{name: "does not exist", renewed: 0, owner: nil, status: lock.LockDoesNotExist},
+		{name: "belongs to others", renewed: 0, owner: &owner, status: lock.LockBelongsToOthers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, mock := newTestLockStore(t)
+			mock.ExpectQuery("WITH renewed AS").
+				WithArgs("resource", "owner", int32(30)).
+				WillReturnRows(pgxmock.NewRows([]string{"renewed", "owner"}).AddRow(tt.renewed, tt.owner))
+
+			res, err := p.RenewLock(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, res.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("invalid expiry", func(t *testing.T) {
+		p, _ := newTestLockStore(t)
+		res, err := p.RenewLock(context.Background(), &lock.RenewLockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.ErrorContains(t, err, "expiry must be greater than zero")
+		assert.Equal(t, lock.InternalError, res.Status)
+	})
+}
+
+func TestAdvisoryLockKey(t *testing.T) {
+	p := &PostgreSQL{}
+	p.metadata.TableName = "dapr_lock"
+	key := p.advisoryLockKey("resource")
+	assert.Equal(t, key, p.advisoryLockKey("resource"))
+	assert.NotEqual(t, key, p.advisoryLockKey("resource2"))
+
+	// Keys depend on the table
+	p.metadata.TableName = "other_lock"
+	assert.NotEqual(t, key, p.advisoryLockKey("resource"))
+}
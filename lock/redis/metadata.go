@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultRedlockDriftFactor = 0.01
+	defaultRedlockRetryCount  = 3
+	defaultRedlockRetryDelay  = 200 * time.Millisecond
+)
+
+// Properties of the lock store, in addition to the ones used for the Redis client.
+type lockMetadata struct {
+	// Maximum time to wait for a lock held by someone else to be released. Defaults to 0, which means not waiting.
+	// Not used with Redlock, which retries according to the redlockRetryCount and redlockRetryDelay properties.
+	WaitTimeout time.Duration `json:"waitTimeout" mapstructure:"waitTimeout"`
+
+	// Comma-separated list of independent Redis hosts to use the Redlock algorithm with, instead of a single Redis host.
+	// All other Redis connection properties apply to every host.
+	RedlockHosts string `json:"redlockHosts" mapstructure:"redlockHosts"`
+	// Factor of the lock's TTL added to the clock drift between hosts. Defaults to 0.01.
+	RedlockDriftFactor float64 `json:"redlockDriftFactor" mapstructure:"redlockDriftFactor"`
+	// Number of times to retry acquiring a lock when a majority of the hosts could not be locked. Defaults to 3.
+	RedlockRetryCount int `json:"redlockRetryCount" mapstructure:"redlockRetryCount"`
+	// Maximum delay before retrying to acquire a lock; the actual delay is randomized between half this value and this value. Defaults to 200ms.
+	RedlockRetryDelay time.Duration `json:"redlockRetryDelay" mapstructure:"redlockRetryDelay"`
+}
+
+func (m *lockMetadata) InitWithMetadata(meta lock.Metadata) error {
+	m.WaitTimeout = 0
+	m.RedlockHosts = ""
+	m.RedlockDriftFactor = defaultRedlockDriftFactor
+	m.RedlockRetryCount = defaultRedlockRetryCount
+	m.RedlockRetryDelay = defaultRedlockRetryDelay
+
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if m.WaitTimeout < 0 {
+		return errors.New("metadata property waitTimeout must not be negative")
+	}
+	if m.RedlockDriftFactor < 0 || m.RedlockDriftFactor >= 1 {
+		return errors.New("metadata property redlockDriftFactor must be between 0 and 1")
+	}
+	if m.RedlockRetryCount < 0 {
+		return errors.New("metadata property redlockRetryCount must not be negative")
+	}
+	if m.RedlockRetryDelay <= 0 {
+		return errors.New("metadata property redlockRetryDelay must be greater than zero")
+	}
+
+	return nil
+}
+
+// Returns the list of hosts, or nil if Redlock is not enabled.
+func (m *lockMetadata) hosts() []string {
+	var res []string
+	for _, h := range strings.Split(m.RedlockHosts, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" {
+			res = append(res, h)
+		}
+	}
+	return res
+}
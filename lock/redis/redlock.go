@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+	"github.com/dapr/components-contrib/lock"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// Implements the Redlock algorithm over independent Redis hosts.
+// A lock is acquired when it's set on a majority of hosts within its validity time, so losing a minority of hosts doesn't cause locks to be lost.
+type redlock struct {
+	clients  []rediscomponent.RedisClient
+	metadata lockMetadata
+	logger   logger.Logger
+}
+
+func newRedlock(ctx context.Context, meta lock.Metadata, md lockMetadata, log logger.Logger) (*redlock, error) {
+	hosts := md.hosts()
+	if len(hosts) < 3 {
+		return nil, errors.New("metadata property redlockHosts must contain at least 3 hosts")
+	}
+
+	rl := &redlock{
+		clients:  make([]rediscomponent.RedisClient, 0, len(hosts)),
+		metadata: md,
+		logger:   log,
+	}
+	for _, host := range hosts {
+		// Each client uses the same properties, except for the host
+		props := make(map[string]string, len(meta.Properties))
+		for k, v := range meta.Properties {
+			props[k] = v
+		}
+		props["redisHost"] = host
+
+		client, settings, err := rediscomponent.ParseClientFromProperties(props, contribMetadata.LockStoreType, ctx, &log)
+		if err != nil {
+			_ = rl.Close()
+			return nil, err
+		}
+		rl.clients = append(rl.clients, client)
+
+		if settings.Failover {
+			_ = rl.Close()
+			return nil, errors.New("this component does not support connecting to Redis with failover")
+		}
+
+		if _, err = client.PingResult(ctx); err != nil {
+			_ = rl.Close()
+			return nil, fmt.Errorf("error connecting to Redis host %s: %v", host, err)
+		}
+	}
+
+	return rl, nil
+}
+
+// Returns the number of hosts that must agree.
+func (rl *redlock) quorum() int {
+	return len(rl.clients)/2 + 1
+}
+
+// TryLock tries to acquire the lock on a majority of hosts, retrying on failure.
+func (rl *redlock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	ttl := time.Second * time.Duration(req.ExpiryInSeconds)
+	// Add 2 milliseconds to the drift to account for Redis expires precision, which is 1 millisecond, plus 1 millisecond min drift
+	drift := time.Duration(float64(ttl)*rl.metadata.RedlockDriftFactor) + 2*time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		results := make([]bool, len(rl.clients))
+		rl.forEach(func(i int, client rediscomponent.RedisClient) {
+			nxval, err := client.SetNX(ctx, req.ResourceID, req.LockOwner, ttl)
+			results[i] = err == nil && nxval != nil && *nxval
+		})
+		acquired := 0
+		for _, ok := range results {
+			if ok {
+				acquired++
+			}
+		}
+
+		validity := ttl - time.Since(start) - drift
+		if acquired >= rl.quorum() && validity > 0 {
+			return &lock.TryLockResponse{
+				Success: true,
+			}, nil
+		}
+
+		// Release the lock on the hosts where it was acquired, if any
+		rl.forEach(func(_ int, client rediscomponent.RedisClient) {
+			_, _, _ = client.EvalInt(ctx, unlockScript, []string{req.ResourceID}, req.LockOwner, releasedChannel(req.ResourceID))
+		})
+
+		if attempt >= rl.metadata.RedlockRetryCount {
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		}
+
+		// Wait for a random delay, so clients competing for the same lock are less likely to split the votes again
+		half := rl.metadata.RedlockRetryDelay / 2
+		delay := half + time.Duration(rand.Int63n(int64(half)+1)) //nolint:gosec
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return &lock.TryLockResponse{}, ctx.Err()
+		}
+	}
+}
+
+// Unlock releases the lock on all hosts.
+func (rl *redlock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	statuses, err := rl.evalAll(ctx, unlockScript, []string{req.ResourceID}, req.LockOwner, releasedChannel(req.ResourceID))
+	released, others, failed := countStatuses(statuses)
+
+	// The lock was ours on at least one host, which means it wasn't acquired by anyone else in the meanwhile
+	if released > 0 {
+		return &lock.UnlockResponse{
+			Status: lock.Success,
+		}, nil
+	}
+	if failed >= rl.quorum() {
+		return &lock.UnlockResponse{
+			Status: lock.InternalError,
+		}, err
+	}
+	if others >= rl.quorum() {
+		return &lock.UnlockResponse{
+			Status: lock.LockBelongsToOthers,
+		}, nil
+	}
+	return &lock.UnlockResponse{
+		Status: lock.LockDoesNotExist,
+	}, nil
+}
+
+// RenewLock resets the TTL of the lock on all hosts.
+// The renewal succeeds if the lock is still held, and renewed, on a majority of hosts within the new validity time.
+func (rl *redlock) RenewLock(ctx context.Context, req *lock.RenewLockRequest) (*lock.RenewLockResponse, error) {
+	ttl := time.Second * time.Duration(req.ExpiryInSeconds)
+	drift := time.Duration(float64(ttl)*rl.metadata.RedlockDriftFactor) + 2*time.Millisecond
+
+	start := time.Now()
+	statuses, err := rl.evalAll(ctx, renewScript, []string{req.ResourceID, fencingTokenKey(req.ResourceID)}, req.LockOwner, ttl.Milliseconds())
+	renewed, others, failed := countStatuses(statuses)
+
+	validity := ttl - time.Since(start) - drift
+	switch {
+	case renewed >= rl.quorum() && validity > 0:
+		return &lock.RenewLockResponse{
+			Status: lock.Success,
+		}, nil
+	case failed >= rl.quorum():
+		return &lock.RenewLockResponse{
+			Status: lock.InternalError,
+		}, err
+	case others >= rl.quorum():
+		return &lock.RenewLockResponse{
+			Status: lock.LockBelongsToOthers,
+		}, nil
+	default:
+		// The lock can't be considered held anymore, so it's released on the hosts where it was renewed
+		if renewed > 0 {
+			_, _ = rl.evalAll(ctx, unlockScript, []string{req.ResourceID}, req.LockOwner, releasedChannel(req.ResourceID))
+		}
+		return &lock.RenewLockResponse{
+			Status: lock.LockDoesNotExist,
+		}, nil
+	}
+}
+
+// Runs the unlock or renew script on all hosts, returning the status for each host.
+func (rl *redlock) evalAll(ctx context.Context, script string, keys []string, args ...any) ([]lock.Status, error) {
+	statuses := make([]lock.Status, len(rl.clients))
+	errs := make([]error, len(rl.clients))
+	rl.forEach(func(i int, client rediscomponent.RedisClient) {
+		evalInt, parseErr, err := client.EvalInt(ctx, script, keys, args...)
+		if evalInt == nil || parseErr != nil {
+			statuses[i] = lock.InternalError
+			errs[i] = errors.Join(parseErr, err)
+			return
+		}
+		statuses[i] = scriptResultStatus(*evalInt)
+	})
+	return statuses, errors.Join(errs...)
+}
+
+// Returns the number of hosts where the script succeeded, where the lock belongs to others, and where the script failed.
+func countStatuses(statuses []lock.Status) (succeeded int, others int, failed int) {
+	for _, status := range statuses {
+		switch status {
+		case lock.Success:
+			succeeded++
+		case lock.LockBelongsToOthers:
+			others++
+		case lock.InternalError:
+			failed++
+		}
+	}
+	return succeeded, others, failed
+}
+
+// Invokes fn for each client in parallel, and waits for all of them to return.
+func (rl *redlock) forEach(fn func(i int, client rediscomponent.RedisClient)) {
+	var wg sync.WaitGroup
+	wg.Add(len(rl.clients))
+	for i, client := range rl.clients {
+		go func() {
+			defer wg.Done()
+			fn(i, client)
+		}()
+	}
+	wg.Wait()
+}
+
+// Close closes the connections to all hosts.
+func (rl *redlock) Close() error {
+	errs := make([]error, 0, len(rl.clients))
+	for _, client := range rl.clients {
+		errs = append(errs, client.Close())
+	}
+	rl.clients = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestRedlockMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := lockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.NoError(t, err)
+		assert.Empty(t, m.hosts())
+		assert.InDelta(t, 0.01, m.RedlockDriftFactor, 0.0001)
+		assert.Equal(t, 3, m.RedlockRetryCount)
+		assert.Equal(t, 200*time.Millisecond, m.RedlockRetryDelay)
+	})
+
+	t.Run("hosts", func(t *testing.T) {
+		m := lockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"redlockHosts": "host1:6379, host2:6379,,host3:6379",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"host1:6379", "host2:6379", "host3:6379"}, m.hosts())
+	})
+
+	t.Run("invalid drift factor", func(t *testing.T) {
+		m := lockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"redlockDriftFactor": "1.5",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid retry count", func(t *testing.T) {
+		m := lockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"redlockRetryCount": "-1",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestRedlock(t *testing.T) {
+	servers := make([]*miniredis.Miniredis, 3)
+	addrs := make([]string, 3)
+	for i := range servers {
+		s, err := miniredis.Run()
+		require.NoError(t, err)
+		defer s.Close()
+		servers[i] = s
+		addrs[i] = s.Addr()
+	}
+
+	newComp := func(t *testing.T, hosts []string) *StandaloneRedisLock {
+		comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+		t.Cleanup(func() {
+			comp.Close()
+		})
+
+		err := comp.InitLockStore(context.Background(), lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"redlockHosts":      strings.Join(hosts, ","),
+			"redlockRetryCount": "1",
+			"redlockRetryDelay": "10ms",
+			"redisMaxRetries":   "0",
+		}}})
+		require.NoError(t, err)
+		return comp
+	}
+
+	t.Run("at least 3 hosts are required", func(t *testing.T) {
+		comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+		defer comp.Close()
+
+		err := comp.InitLockStore(context.Background(), lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"redlockHosts": strings.Join(addrs[:2], ","),
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("lock and unlock", func(t *testing.T) {
+		comp := newComp(t, addrs)
+
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock1", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		for _, s := range servers {
+			v, _ := s.Get("lock1")
+			assert.Equal(t, "owner1", v)
+		}
+
+		resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock1", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		unlockResp, err := comp.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "lock1", LockOwner: "owner2"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockBelongsToOthers, unlockResp.Status)
+
+		unlockResp, err = comp.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "lock1", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.Success, unlockResp.Status)
+		for _, s := range servers {
+			assert.False(t, s.Exists("lock1"))
+		}
+
+		unlockResp, err = comp.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "lock1", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, unlockResp.Status)
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		comp := newComp(t, addrs)
+
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock6", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		require.True(t, resp.Success)
+
+		renewResp, err := comp.RenewLock(context.Background(), &lock.RenewLockRequest{ResourceID: "lock6", LockOwner: "owner1", ExpiryInSeconds: 60})
+		require.NoError(t, err)
+		assert.Equal(t, lock.Success, renewResp.Status)
+		for _, s := range servers {
+			assert.Equal(t, 60*time.Second, s.TTL("lock6"))
+		}
+
+		renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{ResourceID: "lock6", LockOwner: "owner2", ExpiryInSeconds: 60})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockBelongsToOthers, renewResp.Status)
+
+		// The lock expired on a majority of hosts
+		servers[0].Del("lock6")
+		servers[1].Del("lock6")
+		renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{ResourceID: "lock6", LockOwner: "owner1", ExpiryInSeconds: 60})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, renewResp.Status)
+		assert.False(t, servers[2].Exists("lock6"))
+	})
+
+	t.Run("lock held on a minority of hosts", func(t *testing.T) {
+		comp := newComp(t, addrs)
+
+		// Someone else holds the lock on one host only
+		require.NoError(t, servers[0].Set("lock2", "owner2"))
+
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock2", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+
+		// Someone else holds the lock on a majority of hosts
+		require.NoError(t, servers[0].Set("lock3", "owner2"))
+		require.NoError(t, servers[1].Set("lock3", "owner2"))
+
+		resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock3", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		// The lock was released on the host where it had been acquired
+		assert.False(t, servers[2].Exists("lock3"))
+	})
+
+	t.Run("tolerates the failure of a minority of hosts", func(t *testing.T) {
+		comp := newComp(t, addrs)
+
+		servers[2].SetError("server down")
+		defer servers[2].SetError("")
+
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock4", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+
+		servers[1].SetError("server down")
+		defer servers[1].SetError("")
+
+		resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "lock5", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+}
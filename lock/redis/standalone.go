@@ -26,13 +26,41 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
-const unlockScript = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call("del",KEYS[1]) end`
+const (
+	// Sets the lock if it doesn't exist and increments its fencing token, returning the token, or 0 if the lock is held by someone else.
+	// The fencing token expires with the lock; if it doesn't exist, it starts from the Redis server's current time in milliseconds:
+	// tokens grow much slower than that, so they are still greater than any token returned before.
+	// The server's clock is used so tokens don't depend on the clocks of the clients being in sync.
+	tryLockScript = `if not redis.call("set",KEYS[1],ARGV[1],"NX","PX",ARGV[2]) then return 0 end; local t = redis.call("get",KEYS[2]); if t==false then local now = redis.call("time"); t = tonumber(now[1])*1000 + math.floor(tonumber(now[2])/1000) else t = tonumber(t) + 1 end; redis.call("set",KEYS[2],t,"PX",ARGV[2]); return t`
+	unlockScript  = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else local r = redis.call("del",KEYS[1]); redis.call("publish",ARGV[2],"released"); return r end`
+	// Renews the fencing token too, so it doesn't expire while the lock is still held and restart from the current time.
+	renewScript = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else redis.call("pexpire",KEYS[2],ARGV[2]); return redis.call("pexpire",KEYS[1],ARGV[2]) end`
+)
+
+// Maximum time to wait before trying again to acquire a lock, in case a release notification is missed.
+const maxReleaseWait = time.Second
+
+var _ lock.Renewer = (*StandaloneRedisLock)(nil)
+
+// Returns the name of the pub/sub channel where releases of a lock are notified.
+func releasedChannel(resourceID string) string {
+	return "dapr-lock-released||" + resourceID
+}
+
+// Returns the name of the key holding the fencing token of a lock.
+func fencingTokenKey(resourceID string) string {
+	return "dapr-lock-fencing||" + resourceID
+}
 
 // Standalone Redis lock store.
 // Any fail-over related features are not supported, such as Sentinel and Redis Cluster.
+// To tolerate the failure of single hosts, the lock can use the Redlock algorithm over multiple independent hosts instead.
+// Locks acquired on a single host have a fencing token, which is not changed when they're renewed; with Redlock, fencing tokens are not supported.
 type StandaloneRedisLock struct {
 	client         rediscomponent.RedisClient
 	clientSettings *rediscomponent.Settings
+	metadata       lockMetadata
+	redlock        *redlock
 
 	logger logger.Logger
 }
@@ -49,6 +77,15 @@ func NewStandaloneRedisLock(logger logger.Logger) lock.Store {
 
 // Init StandaloneRedisLock.
 func (r *StandaloneRedisLock) InitLockStore(ctx context.Context, metadata lock.Metadata) (err error) {
+	err = r.metadata.InitWithMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	if len(r.metadata.hosts()) > 0 {
+		r.redlock, err = newRedlock(ctx, metadata, r.metadata, r.logger)
+		return err
+	}
+
 	// Create the client
 	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(metadata.Properties, contribMetadata.LockStoreType, ctx, &r.logger)
 	if err != nil {
@@ -80,59 +117,159 @@ func (r *StandaloneRedisLock) InitLockStore(ctx context.Context, metadata lock.M
 }
 
 // TryLock tries to acquire a lock.
-// If the lock cannot be acquired, it returns immediately.
+// If the lock cannot be acquired, it returns immediately, unless a wait timeout is configured.
 func (r *StandaloneRedisLock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
-	// Set a key if doesn't exist with an expiration time
-	nxval, err := r.client.SetNX(ctx, req.ResourceID, req.LockOwner, time.Second*time.Duration(req.ExpiryInSeconds))
-	if nxval == nil {
-		return &lock.TryLockResponse{}, errors.New("setNX returned a nil response")
+	if r.redlock != nil {
+		return r.redlock.TryLock(ctx, req)
 	}
+	if r.metadata.WaitTimeout > 0 {
+		return r.tryLockWait(ctx, req)
+	}
+	return r.tryLock(ctx, req)
+}
 
+// Waits for up to the wait timeout for the lock to be released, if it's held by someone else.
+// Unlocking publishes a notification on a channel specific to the lock, so waiters don't need to poll.
+func (r *StandaloneRedisLock) tryLockWait(parentCtx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, r.metadata.WaitTimeout)
+	defer cancel()
+
+	// Subscribe before trying to acquire the lock, so releases that happen in the meanwhile are not missed
+	released, closeSub, err := r.client.Subscribe(ctx, releasedChannel(req.ResourceID))
 	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to subscribe to lock release notifications: %w", err)
+	}
+	defer closeSub()
+
+	for {
+		res, err := r.tryLock(ctx, req)
+		if err != nil || res.Success {
+			return res, err
+		}
+
+		// Locks that expire don't send notifications, so wait until the lock expires at most
+		wait := maxReleaseWait
+		pttl, err := r.client.DoRead(ctx, "PTTL", req.ResourceID)
+		if ms, ok := pttl.(int64); err == nil && ok && ms >= 0 && time.Duration(ms)*time.Millisecond < wait {
+			wait = time.Duration(ms) * time.Millisecond
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-released:
+			timer.Stop()
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			if parentCtx.Err() != nil {
+				return &lock.TryLockResponse{}, parentCtx.Err()
+			}
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		}
+	}
+}
+
+func (r *StandaloneRedisLock) tryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	// Set a key if doesn't exist with an expiration time
+	evalInt, parseErr, err := r.client.EvalInt(ctx, tryLockScript, []string{req.ResourceID, fencingTokenKey(req.ResourceID)},
+		req.LockOwner, (time.Second * time.Duration(req.ExpiryInSeconds)).Milliseconds())
+	if evalInt == nil {
+		if err == nil {
+			err = errors.New("eval lock script returned a nil response")
+		}
 		return &lock.TryLockResponse{}, err
 	}
+	if parseErr != nil {
+		return &lock.TryLockResponse{}, parseErr
+	}
 
 	return &lock.TryLockResponse{
-		Success: *nxval,
+		Success:      *evalInt > 0,
+		FencingToken: int64(*evalInt),
 	}, nil
 }
 
 // Unlock tries to release a lock if the lock is still valid.
 func (r *StandaloneRedisLock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if r.redlock != nil {
+		return r.redlock.Unlock(ctx, req)
+	}
+
 	// Delegate to client.eval lua script
-	evalInt, parseErr, err := r.client.EvalInt(ctx, unlockScript, []string{req.ResourceID}, req.LockOwner)
+	evalInt, parseErr, err := r.client.EvalInt(ctx, unlockScript, []string{req.ResourceID}, req.LockOwner, releasedChannel(req.ResourceID))
 	if evalInt == nil {
-		res := &lock.UnlockResponse{
-			Status: lock.InternalError,
+		if err == nil {
+			err = errors.New("eval unlock script returned a nil response")
 		}
-		return res, errors.New("eval unlock script returned a nil response")
+		return &lock.UnlockResponse{
+			Status: lock.InternalError,
+		}, err
 	}
 
 	// Parse result
 	if parseErr != nil {
 		return &lock.UnlockResponse{
 			Status: lock.InternalError,
+		}, parseErr
+	}
+	return &lock.UnlockResponse{
+		Status: scriptResultStatus(*evalInt),
+	}, nil
+}
+
+// RenewLock resets the TTL of a lock if it's still held by the same owner.
+func (r *StandaloneRedisLock) RenewLock(ctx context.Context, req *lock.RenewLockRequest) (*lock.RenewLockResponse, error) {
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.RenewLockResponse{Status: lock.InternalError}, errors.New("expiry must be greater than zero")
+	}
+
+	if r.redlock != nil {
+		return r.redlock.RenewLock(ctx, req)
+	}
+
+	evalInt, parseErr, err := r.client.EvalInt(ctx, renewScript, []string{req.ResourceID, fencingTokenKey(req.ResourceID)}, req.LockOwner, (time.Second * time.Duration(req.ExpiryInSeconds)).Milliseconds())
+	if evalInt == nil {
+		if err == nil {
+			err = errors.New("eval renew script returned a nil response")
+		}
+		return &lock.RenewLockResponse{
+			Status: lock.InternalError,
 		}, err
 	}
-	var status lock.Status
-	switch {
-	case *evalInt >= 0:
-		status = lock.Success
-	case *evalInt == -1:
-		status = lock.LockDoesNotExist
-	case *evalInt == -2:
-		status = lock.LockBelongsToOthers
-	default:
-		status = lock.InternalError
+	if parseErr != nil {
+		return &lock.RenewLockResponse{
+			Status: lock.InternalError,
+		}, parseErr
 	}
 
-	return &lock.UnlockResponse{
-		Status: status,
+	return &lock.RenewLockResponse{
+		Status: scriptResultStatus(*evalInt),
 	}, nil
 }
 
+// Returns the status corresponding to the result of the unlock and renew scripts.
+func scriptResultStatus(res int) lock.Status {
+	switch {
+	case res >= 0:
+		return lock.Success
+	case res == -1:
+		return lock.LockDoesNotExist
+	case res == -2:
+		return lock.LockBelongsToOthers
+	default:
+		return lock.InternalError
+	}
+}
+
 // Close shuts down the client's redis connections.
 func (r *StandaloneRedisLock) Close() error {
+	if r.redlock != nil {
+		err := r.redlock.Close()
+		r.redlock = nil
+		return err
+	}
 	if r.client != nil {
 		err := r.client.Close()
 		r.client = nil
@@ -145,5 +282,6 @@ func (r *StandaloneRedisLock) Close() error {
 func (r *StandaloneRedisLock) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := rediscomponent.Settings{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.LockStoreType)
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(lockMetadata{}), &metadataInfo, contribMetadata.LockStoreType)
 	return
 }
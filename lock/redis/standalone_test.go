@@ -15,7 +15,9 @@ package redis
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
@@ -147,3 +149,224 @@ func TestStandaloneRedisLock_TryLock(t *testing.T) {
 	require.NoError(t, err)
 	assert.EqualValues(t, 0, unlockResp.Status, "client2 failed to unlock!")
 }
+
+func TestStandaloneRedisLock_RenewLock(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	ownerID1 := uuid.New().String()
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	// Renew the lock
+	renewResp, err := comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 60,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.Success, renewResp.Status)
+	assert.Equal(t, 60*time.Second, s.TTL(resourceID))
+
+	// Another owner can't renew it
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       uuid.New().String(),
+		ExpiryInSeconds: 60,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockBelongsToOthers, renewResp.Status)
+
+	// Expired locks can't be renewed
+	s.FastForward(61 * time.Second)
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 60,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockDoesNotExist, renewResp.Status)
+}
+
+func TestStandaloneRedisLock_FencingToken(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// The first token starts from the current time of the Redis server, which is ahead of the client's here
+	start := time.Now().Add(time.Hour).UnixMilli()
+	s.SetTime(time.UnixMilli(start))
+	ownerID1 := uuid.New().String()
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	token1 := resp.FencingToken
+	assert.GreaterOrEqual(t, token1, start)
+
+	// Failing to acquire the lock returns no token
+	resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       uuid.New().String(),
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.Equal(t, int64(0), resp.FencingToken)
+
+	// Renewing doesn't change the token, but extends its expiration with the lock's
+	renewResp, err := comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, lock.Success, renewResp.Status)
+	assert.Equal(t, strconv.FormatInt(token1, 10), mustGet(t, s, fencingTokenKey(resourceID)))
+	assert.Equal(t, 30*time.Second, s.TTL(fencingTokenKey(resourceID)))
+
+	// The next owner gets a greater token
+	unlockResp, err := comp.Unlock(context.Background(), &lock.UnlockRequest{
+		ResourceID: resourceID,
+		LockOwner:  ownerID1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, lock.Success, unlockResp.Status)
+	resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       uuid.New().String(),
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	assert.Equal(t, token1+1, resp.FencingToken)
+
+	// Once the token expires with the lock, it starts again from the current time, which is still greater
+	s.FastForward(11 * time.Second)
+	s.SetTime(time.UnixMilli(start).Add(11 * time.Second))
+	assert.False(t, s.Exists(fencingTokenKey(resourceID)))
+	resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       uuid.New().String(),
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	assert.Greater(t, resp.FencingToken, token1)
+}
+
+func mustGet(t *testing.T, s *miniredis.Miniredis, key string) string {
+	t.Helper()
+	v, err := s.Get(key)
+	require.NoError(t, err)
+	return v
+}
+
+func TestStandaloneRedisLock_TryLockWait(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+	cfg.Properties["waitTimeout"] = "5s"
+
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	ownerID1 := uuid.New().String()
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 60,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	t.Run("acquired when released", func(t *testing.T) {
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			comp.Unlock(context.Background(), &lock.UnlockRequest{
+				ResourceID: resourceID,
+				LockOwner:  ownerID1,
+			})
+		}()
+
+		start := time.Now()
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      resourceID,
+			LockOwner:       uuid.New().String(),
+			ExpiryInSeconds: 60,
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		// The release was notified, so there was no need to wait for the next attempt
+		assert.Less(t, time.Since(start), maxReleaseWait)
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		comp.metadata.WaitTimeout = 200 * time.Millisecond
+		defer func() {
+			comp.metadata.WaitTimeout = 5 * time.Second
+		}()
+
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      resourceID,
+			LockOwner:       uuid.New().String(),
+			ExpiryInSeconds: 60,
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+
+	t.Run("request canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err := comp.TryLock(ctx, &lock.TryLockRequest{
+			ResourceID:      resourceID,
+			LockOwner:       uuid.New().String(),
+			ExpiryInSeconds: 60,
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -25,3 +25,10 @@ type UnlockRequest struct {
 	ResourceID string `json:"resourceId"`
 	LockOwner  string `json:"lockOwner"`
 }
+
+// RenewLockRequest is a request to extend the TTL of a lock.
+type RenewLockRequest struct {
+	ResourceID      string `json:"resourceId"`
+	LockOwner       string `json:"lockOwner"`
+	ExpiryInSeconds int32  `json:"expiryInSeconds"`
+}
@@ -16,6 +16,9 @@ package lock
 // Lock acquire request was successful or not.
 type TryLockResponse struct {
 	Success bool `json:"success"`
+	// Fencing token of the lock, if supported by the lock store.
+	// Tokens increase monotonically every time a lock is acquired, so resources protected by the lock can reject requests from stale holders.
+	FencingToken int64 `json:"fencingToken,omitempty"`
 }
 
 // Status when releasing the lock.
@@ -23,6 +26,11 @@ type UnlockResponse struct {
 	Status Status `json:"status"`
 }
 
+// Status when renewing the lock.
+type RenewLockResponse struct {
+	Status Status `json:"status"`
+}
+
 type Status int32
 
 // lock status.
@@ -34,3 +34,9 @@ type Store interface {
 
 	io.Closer
 }
+
+// Renewer is an optional interface for lock stores that can extend the TTL of a lock that is held.
+type Renewer interface {
+	// RenewLock resets the TTL of a lock, if it's still held by the same owner.
+	RenewLock(ctx context.Context, req *RenewLockRequest) (*RenewLockResponse, error)
+}
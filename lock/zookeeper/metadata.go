@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultKeyPrefixPath  = "/dapr/locks"
+	defaultSessionTimeout = 10 * time.Second
+)
+
+type zookeeperLockMetadata struct {
+	// Comma-separated list of Zookeeper servers.
+	Servers string `json:"servers" mapstructure:"servers"`
+	// Timeout of the Zookeeper session. Locks held by this instance are released when the session expires. Defaults to 10s.
+	SessionTimeout time.Duration `json:"sessionTimeout" mapstructure:"sessionTimeout"`
+	// Path of the node under which locks are created. Defaults to "/dapr/locks".
+	KeyPrefixPath string `json:"keyPrefixPath" mapstructure:"keyPrefixPath"`
+	// Maximum time to wait for a lock held by someone else to be released. Defaults to 0, which means not waiting.
+	WaitTimeout time.Duration `json:"waitTimeout" mapstructure:"waitTimeout"`
+}
+
+func (m *zookeeperLockMetadata) InitWithMetadata(meta lock.Metadata) error {
+	m.reset()
+
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(m.Servers) == "" {
+		return errors.New("metadata property 'servers' is required")
+	}
+
+	m.KeyPrefixPath = strings.TrimSuffix(m.KeyPrefixPath, "/")
+	if !strings.HasPrefix(m.KeyPrefixPath, "/") || len(m.KeyPrefixPath) < 2 {
+		return errors.New("metadata property 'keyPrefixPath' must be an absolute path and must not be the root node")
+	}
+
+	if m.SessionTimeout <= 0 {
+		m.SessionTimeout = defaultSessionTimeout
+	}
+
+	if m.WaitTimeout < 0 {
+		return errors.New("metadata property 'waitTimeout' must not be negative")
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *zookeeperLockMetadata) reset() {
+	m.Servers = ""
+	m.SessionTimeout = defaultSessionTimeout
+	m.KeyPrefixPath = defaultKeyPrefixPath
+	m.WaitTimeout = 0
+}
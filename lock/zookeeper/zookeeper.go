@@ -0,0 +1,447 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// Prefix of the ephemeral-sequential nodes that represent lock attempts.
+const lockNodePrefix = "lock-"
+
+// Conn is the subset of the Zookeeper client used by the lock store.
+type Conn interface {
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+
+	Children(path string) ([]string, *zk.Stat, error)
+
+	Get(path string) ([]byte, *zk.Stat, error)
+
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+
+	Delete(path string, version int32) error
+
+	Close()
+}
+
+// Data stored in each lock node.
+type lockNode struct {
+	Owner string `json:"owner"`
+	// Time when the lock expires, as UNIX timestamp in milliseconds.
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+func (n lockNode) expired(now time.Time) bool {
+	return now.UnixMilli() >= n.ExpiresAt
+}
+
+// ZookeeperLock is a lock store implementation for Zookeeper.
+// It implements the standard recipe: each attempt to acquire a lock creates an ephemeral-sequential node, and the lock belongs to the node with the lowest sequence number.
+// Nodes are ephemeral so locks are released when the session of their owner expires; the expiration of locks is emulated by storing it in the nodes.
+type ZookeeperLock struct {
+	conn     Conn
+	metadata zookeeperLockMetadata
+	logger   logger.Logger
+
+	// Timers that delete the nodes of the locks held by this instance when they expire, keyed by node path.
+	held     map[string]*time.Timer
+	heldLock sync.Mutex
+
+	closeCh chan struct{}
+	closed  sync.Once
+	wg      sync.WaitGroup
+}
+
+// NewZookeeperLock returns a new Zookeeper lock store.
+func NewZookeeperLock(logger logger.Logger) lock.Store {
+	return &ZookeeperLock{
+		logger:  logger,
+		held:    map[string]*time.Timer{},
+		closeCh: make(chan struct{}),
+	}
+}
+
+// InitLockStore connects to Zookeeper.
+func (z *ZookeeperLock) InitLockStore(ctx context.Context, meta lock.Metadata) error {
+	err := z.metadata.InitWithMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	conn, events, err := zk.Connect(strings.Split(z.metadata.Servers, ","), z.metadata.SessionTimeout, zk.WithLogInfo(false))
+	if err != nil {
+		return fmt.Errorf("error connecting to Zookeeper: %w", err)
+	}
+	z.conn = conn
+
+	z.wg.Add(1)
+	go func() {
+		defer z.wg.Done()
+		z.watchSession(events)
+	}()
+
+	return nil
+}
+
+// Watches the events of the Zookeeper session.
+// When the session expires, the server deletes all ephemeral nodes, so this instance doesn't hold any lock anymore.
+func (z *ZookeeperLock) watchSession(events <-chan zk.Event) {
+	for {
+		select {
+		case <-z.closeCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Type == zk.EventSession && ev.State == zk.StateExpired {
+				n := z.releaseAll()
+				if n > 0 {
+					z.logger.Warnf("Zookeeper session expired: %d locks held by this instance were released", n)
+				}
+			}
+		}
+	}
+}
+
+// TryLock tries to acquire a lock.
+// If the lock is held by someone else, it waits for up to the configured wait timeout before giving up.
+func (z *ZookeeperLock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.TryLockResponse{}, errors.New("missing resource ID")
+	}
+	if req.LockOwner == "" {
+		return &lock.TryLockResponse{}, errors.New("missing lock owner")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.TryLockResponse{}, errors.New("expiry must be greater than zero")
+	}
+
+	dir := z.lockDir(req.ResourceID)
+	err := z.ensurePath(dir)
+	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to create node %s: %w", dir, err)
+	}
+
+	expiry := time.Duration(req.ExpiryInSeconds) * time.Second
+	data, err := json.Marshal(lockNode{
+		Owner:     req.LockOwner,
+		ExpiresAt: time.Now().Add(expiry).UnixMilli(),
+	})
+	if err != nil {
+		return &lock.TryLockResponse{}, err
+	}
+	node, err := z.conn.Create(dir+"/"+lockNodePrefix, data, zk.FlagEphemeral|zk.FlagSequence, zk.WorldACL(zk.PermAll))
+	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to create lock node: %w", err)
+	}
+
+	var waitCtx context.Context
+	if z.metadata.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, z.metadata.WaitTimeout)
+		defer cancel()
+	}
+
+	for {
+		pred, err := z.predecessor(dir, node)
+		if err != nil {
+			z.deleteNode(node)
+			return &lock.TryLockResponse{}, err
+		}
+
+		if pred == "" {
+			// Our node is the first one: the lock is ours
+			// The expiration is counted from the moment the lock is acquired, not from when we started waiting
+			data, err = json.Marshal(lockNode{
+				Owner:     req.LockOwner,
+				ExpiresAt: time.Now().Add(expiry).UnixMilli(),
+			})
+			if err != nil {
+				z.deleteNode(node)
+				return &lock.TryLockResponse{}, err
+			}
+			_, err = z.conn.Set(node, data, -1)
+			if err != nil {
+				z.deleteNode(node)
+				return &lock.TryLockResponse{}, fmt.Errorf("failed to update lock node: %w", err)
+			}
+
+			z.hold(node, expiry)
+			return &lock.TryLockResponse{
+				Success: true,
+			}, nil
+		}
+
+		if waitCtx == nil {
+			z.deleteNode(node)
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		}
+
+		// Wait for the node before ours to be deleted
+		exists, _, watch, err := z.conn.ExistsW(dir + "/" + pred)
+		if err != nil {
+			z.deleteNode(node)
+			return &lock.TryLockResponse{}, fmt.Errorf("failed to watch lock node: %w", err)
+		}
+		if !exists {
+			continue
+		}
+
+		select {
+		case <-watch:
+			// Check again
+		case <-waitCtx.Done():
+			z.deleteNode(node)
+			if ctx.Err() != nil {
+				return &lock.TryLockResponse{}, ctx.Err()
+			}
+			return &lock.TryLockResponse{
+				Success: false,
+			}, nil
+		case <-z.closeCh:
+			return &lock.TryLockResponse{}, errors.New("lock store is closed")
+		}
+	}
+}
+
+// Returns the name of the node that comes right before the given one and whose lock hasn't expired, or an empty string if there's none.
+// Nodes of expired locks are deleted.
+func (z *ZookeeperLock) predecessor(dir string, node string) (string, error) {
+	children, err := z.sortedChildren(dir)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(node)
+	now := time.Now()
+	pred := ""
+	for _, child := range children {
+		if child >= name {
+			break
+		}
+
+		n, version, err := z.getNode(dir + "/" + child)
+		if err != nil {
+			return "", err
+		}
+		if n == nil {
+			continue
+		}
+		if n.expired(now) {
+			err = z.conn.Delete(dir+"/"+child, version)
+			if err != nil && !errors.Is(err, zk.ErrNoNode) && !errors.Is(err, zk.ErrBadVersion) {
+				return "", fmt.Errorf("failed to delete expired lock node: %w", err)
+			}
+			continue
+		}
+		pred = child
+	}
+
+	return pred, nil
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (z *ZookeeperLock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if req.ResourceID == "" {
+		return &lock.UnlockResponse{Status: lock.InternalError}, errors.New("missing resource ID")
+	}
+
+	dir := z.lockDir(req.ResourceID)
+	children, err := z.sortedChildren(dir)
+	if err != nil {
+		return &lock.UnlockResponse{Status: lock.InternalError}, err
+	}
+
+	// The lock belongs to the first node that hasn't expired
+	now := time.Now()
+	for _, child := range children {
+		n, version, err := z.getNode(dir + "/" + child)
+		if err != nil {
+			return &lock.UnlockResponse{Status: lock.InternalError}, err
+		}
+		if n == nil || n.expired(now) {
+			continue
+		}
+
+		if n.Owner != req.LockOwner {
+			return &lock.UnlockResponse{
+				Status: lock.LockBelongsToOthers,
+			}, nil
+		}
+
+		err = z.conn.Delete(dir+"/"+child, version)
+		switch {
+		case errors.Is(err, zk.ErrNoNode):
+			return &lock.UnlockResponse{
+				Status: lock.LockDoesNotExist,
+			}, nil
+		case err != nil:
+			return &lock.UnlockResponse{Status: lock.InternalError}, fmt.Errorf("failed to delete lock node: %w", err)
+		}
+		z.release(dir + "/" + child)
+
+		return &lock.UnlockResponse{
+			Status: lock.Success,
+		}, nil
+	}
+
+	return &lock.UnlockResponse{
+		Status: lock.LockDoesNotExist,
+	}, nil
+}
+
+// Returns the children of a lock's node, sorted by sequence number.
+func (z *ZookeeperLock) sortedChildren(dir string) ([]string, error) {
+	children, _, err := z.conn.Children(dir)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list lock nodes: %w", err)
+	}
+
+	res := make([]string, 0, len(children))
+	for _, child := range children {
+		if strings.HasPrefix(child, lockNodePrefix) {
+			res = append(res, child)
+		}
+	}
+	// All nodes have the same prefix and a zero-padded sequence number
+	sort.Strings(res)
+	return res, nil
+}
+
+// Returns the data of a lock node, or nil if the node doesn't exist anymore.
+func (z *ZookeeperLock) getNode(nodePath string) (*lockNode, int32, error) {
+	data, stat, err := z.conn.Get(nodePath)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("failed to get lock node: %w", err)
+	}
+
+	n := &lockNode{}
+	err = json.Unmarshal(data, n)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid data in lock node %s: %w", nodePath, err)
+	}
+	return n, stat.Version, nil
+}
+
+// Creates the persistent nodes of a path, if they don't exist.
+func (z *ZookeeperLock) ensurePath(p string) error {
+	current := ""
+	for _, part := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		current += "/" + part
+		_, err := z.conn.Create(current, nil, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deletes a lock node, logging errors only: the node is ephemeral, so it's removed when the session ends regardless.
+func (z *ZookeeperLock) deleteNode(nodePath string) {
+	err := z.conn.Delete(nodePath, -1)
+	if err != nil && !errors.Is(err, zk.ErrNoNode) {
+		z.logger.Warnf("Failed to delete lock node %s: %v", nodePath, err)
+	}
+}
+
+// Schedules the deletion of the node of a lock held by this instance when the lock expires.
+func (z *ZookeeperLock) hold(nodePath string, expiry time.Duration) {
+	z.heldLock.Lock()
+	defer z.heldLock.Unlock()
+
+	z.held[nodePath] = time.AfterFunc(expiry, func() {
+		z.heldLock.Lock()
+		delete(z.held, nodePath)
+		z.heldLock.Unlock()
+
+		z.deleteNode(nodePath)
+	})
+}
+
+// Stops tracking a lock held by this instance, after it was released.
+func (z *ZookeeperLock) release(nodePath string) {
+	z.heldLock.Lock()
+	defer z.heldLock.Unlock()
+
+	t, ok := z.held[nodePath]
+	if ok {
+		t.Stop()
+		delete(z.held, nodePath)
+	}
+}
+
+// Stops tracking all locks held by this instance, returning how many there were.
+func (z *ZookeeperLock) releaseAll() int {
+	z.heldLock.Lock()
+	defer z.heldLock.Unlock()
+
+	n := len(z.held)
+	for _, t := range z.held {
+		t.Stop()
+	}
+	z.held = map[string]*time.Timer{}
+	return n
+}
+
+// Returns the path of the node that contains the attempts to acquire a lock.
+func (z *ZookeeperLock) lockDir(resourceID string) string {
+	return z.metadata.KeyPrefixPath + "/" + url.PathEscape(resourceID)
+}
+
+// Close closes the connection to Zookeeper, which releases all locks held by this instance.
+func (z *ZookeeperLock) Close() error {
+	z.closed.Do(func() {
+		close(z.closeCh)
+	})
+	z.wg.Wait()
+	z.releaseAll()
+
+	if z.conn != nil {
+		z.conn.Close()
+		z.conn = nil
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (z *ZookeeperLock) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := zookeeperLockMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.LockStoreType)
+	return
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := zookeeperLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"servers": "localhost:2181",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "/dapr/locks", m.KeyPrefixPath)
+		assert.Equal(t, 10*time.Second, m.SessionTimeout)
+		assert.Equal(t, time.Duration(0), m.WaitTimeout)
+	})
+
+	t.Run("servers are required", func(t *testing.T) {
+		m := zookeeperLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := zookeeperLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"servers":        "localhost:2181",
+			"keyPrefixPath":  "/myapp/",
+			"sessionTimeout": "30s",
+			"waitTimeout":    "2s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "/myapp", m.KeyPrefixPath)
+		assert.Equal(t, 30*time.Second, m.SessionTimeout)
+		assert.Equal(t, 2*time.Second, m.WaitTimeout)
+	})
+
+	t.Run("relative key prefix path", func(t *testing.T) {
+		m := zookeeperLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"servers":       "localhost:2181",
+			"keyPrefixPath": "myapp",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("negative wait timeout", func(t *testing.T) {
+		m := zookeeperLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"servers":     "localhost:2181",
+			"waitTimeout": "-1s",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestLock(t *testing.T) {
+	newStore := func(conn *fakeConn, waitTimeout time.Duration) *ZookeeperLock {
+		store := NewZookeeperLock(logger.NewLogger("test")).(*ZookeeperLock)
+		store.conn = conn
+		store.metadata.reset()
+		store.metadata.WaitTimeout = waitTimeout
+		return store
+	}
+
+	t.Run("acquire and release", func(t *testing.T) {
+		store := newStore(newFakeConn(), 0)
+		defer store.Close()
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res/1", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+
+		res, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res/1", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+
+		unlockRes, err := store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "res/1", LockOwner: "owner2"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockBelongsToOthers, unlockRes.Status)
+
+		unlockRes, err = store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "res/1", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.Success, unlockRes.Status)
+
+		unlockRes, err = store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "res/1", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, unlockRes.Status)
+
+		res, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res/1", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+	})
+
+	t.Run("unlock a lock that never existed", func(t *testing.T) {
+		store := newStore(newFakeConn(), 0)
+		defer store.Close()
+
+		res, err := store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "nope", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, res.Status)
+	})
+
+	t.Run("expired lock", func(t *testing.T) {
+		conn := newFakeConn()
+		store := newStore(conn, 0)
+		defer store.Close()
+
+		// Create a node for a lock that has expired, for example held by an instance whose session is still alive
+		require.NoError(t, store.ensurePath(store.lockDir("res")))
+		data, _ := json.Marshal(lockNode{Owner: "owner1", ExpiresAt: time.Now().Add(-time.Second).UnixMilli()})
+		_, err := conn.Create(store.lockDir("res")+"/"+lockNodePrefix, data, zk.FlagEphemeral|zk.FlagSequence, nil)
+		require.NoError(t, err)
+
+		unlockRes, err := store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "res", LockOwner: "owner1"})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, unlockRes.Status)
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+
+		// The expired node was deleted
+		children, _, err := conn.Children(store.lockDir("res"))
+		require.NoError(t, err)
+		assert.Len(t, children, 1)
+	})
+
+	t.Run("lock expires", func(t *testing.T) {
+		conn := newFakeConn()
+		store := newStore(conn, 0)
+		defer store.Close()
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner1", ExpiryInSeconds: 1})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+
+		assert.Eventually(t, func() bool {
+			children, _, _ := conn.Children(store.lockDir("res"))
+			return len(children) == 0
+		}, 3*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("wait for the lock to be released", func(t *testing.T) {
+		store := newStore(newFakeConn(), 5*time.Second)
+		defer store.Close()
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			store.Unlock(context.Background(), &lock.UnlockRequest{ResourceID: "res", LockOwner: "owner1"})
+		}()
+
+		res, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		conn := newFakeConn()
+		store := newStore(conn, 100*time.Millisecond)
+		defer store.Close()
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		res, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner2", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+
+		// The node of the failed attempt was deleted
+		children, _, err := conn.Children(store.lockDir("res"))
+		require.NoError(t, err)
+		assert.Len(t, children, 1)
+	})
+
+	t.Run("session expired", func(t *testing.T) {
+		store := newStore(newFakeConn(), 0)
+		defer store.Close()
+
+		events := make(chan zk.Event, 1)
+		store.wg.Add(1)
+		go func() {
+			defer store.wg.Done()
+			store.watchSession(events)
+		}()
+
+		res, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner1", ExpiryInSeconds: 10})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateExpired}
+		assert.Eventually(t, func() bool {
+			store.heldLock.Lock()
+			defer store.heldLock.Unlock()
+			return len(store.held) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		store := newStore(newFakeConn(), 0)
+		defer store.Close()
+
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{LockOwner: "owner", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "missing resource ID")
+		_, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", ExpiryInSeconds: 10})
+		require.ErrorContains(t, err, "missing lock owner")
+		_, err = store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "res", LockOwner: "owner"})
+		require.ErrorContains(t, err, "expiry must be greater than zero")
+		_, err = store.Unlock(context.Background(), &lock.UnlockRequest{LockOwner: "owner"})
+		require.ErrorContains(t, err, "missing resource ID")
+	})
+}
+
+// In-memory implementation of Conn.
+type fakeConn struct {
+	lock    sync.Mutex
+	nodes   map[string]*fakeNode
+	seq     map[string]int
+	watches map[string][]chan zk.Event
+}
+
+type fakeNode struct {
+	data    []byte
+	version int32
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		nodes:   map[string]*fakeNode{"/": {}},
+		seq:     map[string]int{},
+		watches: map[string][]chan zk.Event{},
+	}
+}
+
+func (c *fakeConn) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	parent := path.Dir(p)
+	if _, ok := c.nodes[parent]; !ok {
+		return "", zk.ErrNoNode
+	}
+	if flags&zk.FlagSequence != 0 {
+		p = fmt.Sprintf("%s%010d", p, c.seq[parent])
+		c.seq[parent]++
+	}
+	if _, ok := c.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	c.nodes[p] = &fakeNode{data: data}
+	return p, nil
+}
+
+func (c *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.nodes[p]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	res := []string{}
+	for k := range c.nodes {
+		if k != "/" && path.Dir(k) == p {
+			res = append(res, strings.TrimPrefix(k, p+"/"))
+		}
+	}
+	sort.Strings(res)
+	return res, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return n.data, &zk.Stat{Version: n.version}, nil
+}
+
+func (c *fakeConn) Set(p string, data []byte, version int32) (*zk.Stat, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	if version != -1 && version != n.version {
+		return nil, zk.ErrBadVersion
+	}
+	n.data = data
+	n.version++
+	return &zk.Stat{Version: n.version}, nil
+}
+
+func (c *fakeConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ch := make(chan zk.Event, 1)
+	n, ok := c.nodes[p]
+	if !ok {
+		return false, nil, ch, nil
+	}
+	c.watches[p] = append(c.watches[p], ch)
+	return true, &zk.Stat{Version: n.version}, ch, nil
+}
+
+func (c *fakeConn) Delete(p string, version int32) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n, ok := c.nodes[p]
+	if !ok {
+		return zk.ErrNoNode
+	}
+	if version != -1 && version != n.version {
+		return zk.ErrBadVersion
+	}
+	delete(c.nodes, p)
+	for _, ch := range c.watches[p] {
+		ch <- zk.Event{Type: zk.EventNodeDeleted, Path: p}
+	}
+	delete(c.watches, p)
+	return nil
+}
+
+func (c *fakeConn) Close() {}
@@ -0,0 +1,463 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kitmd "github.com/dapr/kit/metadata"
+	"github.com/dapr/kit/utils"
+)
+
+// FieldError is an error for a single metadata property.
+type FieldError struct {
+	// Name of the metadata property
+	Field string
+	// Description of the error
+	Message string
+	// Values accepted for the property, if it's an enum
+	AllowedValues []string
+}
+
+func (e *FieldError) Error() string {
+	msg := e.Message
+	if e.Field != "" {
+		msg = "'" + e.Field + "': " + msg
+	}
+	if len(e.AllowedValues) > 0 {
+		msg += " (allowed values: " + strings.Join(e.AllowedValues, ", ") + ")"
+	}
+	return msg
+}
+
+// ValidationError contains all the errors found while decoding and validating metadata.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid metadata: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the single properties.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// DecodeAndValidate decodes metadata into result like DecodeMetadata in dapr/kit, then validates the fields using their tags:
+//
+//   - `mdrequired:"true"`: the property must have a non-empty value
+//   - `mdallowedvalues:"a,b,c"`: the value must be one of the list, compared case-insensitively; string fields are set to the value as written in the list
+//   - `mdmin:"..."` and `mdmax:"..."`: bounds for numeric, duration and byte size fields, which are checked on the decoded value, including defaults
+//   - `mdbytesize:"true"`: the value of an integer field is a size in bytes parsed with ParseByteSize, such as "10MiB"
+//
+// In addition to the types supported by dapr/kit, fields whose type implements `DecodeString(string) error` decode themselves from the value,
+// kitmd.ByteSize fields accept the same sizes as ParseByteSize, and the items of comma-separated []string fields are trimmed, skipping empty ones.
+//
+// Instead of stopping at the first one, all errors are returned together as a *ValidationError.
+func DecodeAndValidate(input map[string]string, result any) error {
+	fieldErrs := []*FieldError{}
+
+	props := make(map[string]string, len(input))
+	for k, v := range input {
+		props[strings.ToLower(k)] = v
+	}
+
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	// Properties decoded here are removed from the input passed to dapr/kit
+	input = maps.Clone(input)
+	if v.Kind() == reflect.Struct {
+		fieldErrs = preprocessStruct(v, input, fieldErrs)
+	}
+
+	err := kitmd.DecodeMetadata(input, result)
+	if err != nil {
+		var msErr *mapstructure.Error
+		if !errors.As(err, &msErr) {
+			return err
+		}
+		for _, msg := range msErr.Errors {
+			fieldErrs = append(fieldErrs, &FieldError{
+				Field:   quotedFieldName(msg),
+				Message: msg,
+			})
+		}
+	}
+
+	if v.Kind() == reflect.Struct {
+		failed := make(map[string]struct{}, len(fieldErrs))
+		for _, fe := range fieldErrs {
+			failed[strings.ToLower(fe.Field)] = struct{}{}
+		}
+		fieldErrs = validateStruct(v, props, failed, fieldErrs)
+	}
+
+	if len(fieldErrs) > 0 {
+		return &ValidationError{Errors: fieldErrs}
+	}
+	return nil
+}
+
+var quotedNameRegex = regexp.MustCompile(`'([^']+)'`)
+
+// quotedFieldName returns the name of the property in an error returned by mapstructure, which is the first quoted string.
+func quotedFieldName(msg string) string {
+	match := quotedNameRegex.FindStringSubmatch(msg)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// stringDecoder is implemented by types that decode themselves from the value of a property, like with dapr/kit/config.
+type stringDecoder interface {
+	DecodeString(value string) error
+}
+
+var (
+	stringDecoderType = reflect.TypeOf((*stringDecoder)(nil)).Elem()
+	byteSizeType      = reflect.TypeOf(kitmd.ByteSize{})
+	stringSliceType   = reflect.TypeOf([]string{})
+)
+
+// preprocessStruct decodes the fields of a struct that dapr/kit doesn't support, removing their properties from input,
+// and normalizes the values of byte size fields.
+func preprocessStruct(v reflect.Value, input map[string]string, fieldErrs []*FieldError) []*FieldError {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(opts, "squash") {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fieldErrs = preprocessStruct(fv, input, fieldErrs)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		keys := propertyKeys(input, name, field.Tag.Get("mapstructurealiases"))
+		if len(keys) == 0 {
+			continue
+		}
+		raw := strings.TrimSpace(input[keys[0]])
+		if raw == "" {
+			continue
+		}
+
+		var err error
+		switch {
+		case field.Type == byteSizeType || field.Type == reflect.PointerTo(byteSizeType):
+			input[keys[0]] = normalizeByteSize(raw)
+			continue
+		case reflect.PointerTo(field.Type).Implements(stringDecoderType):
+			err = fv.Addr().Interface().(stringDecoder).DecodeString(raw)
+		case field.Type.Kind() == reflect.Pointer && field.Type.Implements(stringDecoderType):
+			target := reflect.New(field.Type.Elem())
+			err = target.Interface().(stringDecoder).DecodeString(raw)
+			if err == nil {
+				fv.Set(target)
+			}
+		case utils.IsTruthy(field.Tag.Get("mdbytesize")):
+			err = setByteSize(fv, raw)
+		default:
+			continue
+		}
+
+		for _, k := range keys {
+			delete(input, k)
+		}
+		if err != nil {
+			fieldErrs = append(fieldErrs, &FieldError{
+				Field:   name,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	return fieldErrs
+}
+
+// propertyKeys returns the keys of input matching the name of a field or one of its aliases, case-insensitively.
+// The key matching the name comes first.
+func propertyKeys(input map[string]string, name string, aliases string) []string {
+	var keys []string
+	for k := range input {
+		if strings.EqualFold(k, name) {
+			keys = append([]string{k}, keys...)
+			continue
+		}
+		for _, alias := range strings.Split(aliases, ",") {
+			if alias != "" && strings.EqualFold(k, alias) {
+				keys = append(keys, k)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// setByteSize sets an integer field to the size in bytes parsed from value.
+func setByteSize(fv reflect.Value, value string) error {
+	n, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	switch {
+	case fv.CanInt():
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("size '%s' is too large", value)
+		}
+		fv.SetInt(n)
+	case fv.CanUint():
+		if fv.OverflowUint(uint64(n)) {
+			return fmt.Errorf("size '%s' is too large", value)
+		}
+		fv.SetUint(uint64(n))
+	default:
+		return fmt.Errorf("sizes can't be decoded into a field of type %s", fv.Type())
+	}
+	return nil
+}
+
+// ParseByteSize parses a size in bytes, such as "512", "10MiB", "1.5GB" or "64Ki".
+// Both binary ("Ki", "Mi", "Gi"...) and decimal ("k", "M", "G"...) suffixes are supported, optionally followed by "B".
+func ParseByteSize(value string) (int64, error) {
+	q, err := resource.ParseQuantity(normalizeByteSize(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	if q.Sign() < 0 {
+		return 0, fmt.Errorf("invalid size '%s': must not be negative", value)
+	}
+	return q.Value(), nil
+}
+
+// normalizeByteSize converts a size to the format of Kubernetes quantities, removing the "B" suffix and spaces.
+func normalizeByteSize(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	value = strings.TrimSuffix(value, "B")
+	// Kubernetes uses a lowercase "k" for kilo
+	if strings.HasSuffix(value, "K") {
+		value = value[:len(value)-1] + "k"
+	}
+	return value
+}
+
+// validateStruct validates the fields of a struct, skipping the ones that already failed decoding.
+func validateStruct(v reflect.Value, props map[string]string, failed map[string]struct{}, fieldErrs []*FieldError) []*FieldError {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if strings.Contains(opts, "squash") {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fieldErrs = validateStruct(fv, props, failed, fieldErrs)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := failed[strings.ToLower(name)]; ok {
+			continue
+		}
+
+		raw, ok := props[strings.ToLower(name)]
+		if !ok {
+			for _, alias := range strings.Split(field.Tag.Get("mapstructurealiases"), ",") {
+				raw, ok = props[strings.ToLower(alias)]
+				if ok && alias != "" {
+					break
+				}
+			}
+		}
+		raw = strings.TrimSpace(raw)
+
+		if utils.IsTruthy(field.Tag.Get("mdrequired")) && raw == "" {
+			fieldErrs = append(fieldErrs, &FieldError{
+				Field:   name,
+				Message: "required property is missing",
+			})
+			continue
+		}
+
+		if allowedTag := field.Tag.Get("mdallowedvalues"); allowedTag != "" && raw != "" {
+			allowed := strings.Split(allowedTag, ",")
+			matched := ""
+			for _, a := range allowed {
+				if strings.EqualFold(a, raw) {
+					matched = a
+					break
+				}
+			}
+			if matched == "" {
+				fieldErrs = append(fieldErrs, &FieldError{
+					Field:         name,
+					Message:       fmt.Sprintf("invalid value '%s'", raw),
+					AllowedValues: allowed,
+				})
+				continue
+			}
+			if fv := v.Field(i); fv.Kind() == reflect.String && fv.CanSet() {
+				fv.SetString(matched)
+			}
+		}
+
+		if fv := v.Field(i); fv.Type() == stringSliceType && fv.CanSet() && !fv.IsNil() {
+			items := make([]string, 0, fv.Len())
+			for _, item := range fv.Interface().([]string) {
+				item = strings.TrimSpace(item)
+				if item != "" {
+					items = append(items, item)
+				}
+			}
+			fv.Set(reflect.ValueOf(items))
+		}
+
+		if fe := validateBounds(name, field, v.Field(i)); fe != nil {
+			fieldErrs = append(fieldErrs, fe)
+		}
+	}
+
+	return fieldErrs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// validateBounds checks the value of a numeric, duration or byte size field against its mdmin and mdmax tags.
+func validateBounds(name string, field reflect.StructField, fv reflect.Value) *FieldError {
+	minTag := field.Tag.Get("mdmin")
+	maxTag := field.Tag.Get("mdmax")
+	if minTag == "" && maxTag == "" {
+		return nil
+	}
+
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	var (
+		value float64
+		parse func(string) (float64, error)
+	)
+	parseByteSize := func(s string) (float64, error) {
+		n, err := ParseByteSize(s)
+		return float64(n), err
+	}
+	switch {
+	case fv.Type() == byteSizeType:
+		q := fv.Interface().(kitmd.ByteSize)
+		value = float64(q.Value())
+		parse = parseByteSize
+	case utils.IsTruthy(field.Tag.Get("mdbytesize")) && (fv.CanInt() || fv.CanUint()):
+		if fv.CanInt() {
+			value = float64(fv.Int())
+		} else {
+			value = float64(fv.Uint())
+		}
+		parse = parseByteSize
+	case fv.Type() == durationType:
+		value = float64(fv.Int())
+		parse = func(s string) (float64, error) {
+			d, err := time.ParseDuration(s)
+			return float64(d), err
+		}
+	case fv.CanInt():
+		value = float64(fv.Int())
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	case fv.CanUint():
+		value = float64(fv.Uint())
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	case fv.CanFloat():
+		value = fv.Float()
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	default:
+		return nil
+	}
+
+	if minTag != "" {
+		minValue, err := parse(minTag)
+		if err == nil && value < minValue {
+			return &FieldError{
+				Field:   name,
+				Message: "value must be at least " + minTag,
+			}
+		}
+	}
+	if maxTag != "" {
+		maxValue, err := parse(maxTag)
+		if err == nil && value > maxValue {
+			return &FieldError{
+				Field:   name,
+				Message: "value must be at most " + maxTag,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+type decodeTestEmbedded struct {
+	Mode string `mapstructure:"mode" mdallowedvalues:"Fast,Safe"`
+}
+
+type decodeTestMetadata struct {
+	decodeTestEmbedded `mapstructure:",squash"`
+
+	Host     string        `mapstructure:"host" mdrequired:"true"`
+	Port     int           `mapstructure:"port" mdmin:"1" mdmax:"65535"`
+	Timeout  time.Duration `mapstructure:"timeout" mdmin:"1s"`
+	Enabled  bool          `mapstructure:"enabled"`
+	MaxBytes *int64        `mapstructure:"maxBytes" mapstructurealiases:"maxSize" mdmax:"1024"`
+}
+
+func TestDecodeAndValidate(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		m := decodeTestMetadata{Timeout: 5 * time.Second}
+		err := DecodeAndValidate(map[string]string{
+			"HOST":    "localhost",
+			"port":    "8080",
+			"enabled": "yes",
+			"mode":    "safe",
+			"maxSize": "512",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", m.Host)
+		assert.Equal(t, 8080, m.Port)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+		assert.True(t, m.Enabled)
+		assert.Equal(t, "Safe", m.Mode)
+		require.NotNil(t, m.MaxBytes)
+		assert.Equal(t, int64(512), *m.MaxBytes)
+	})
+
+	t.Run("all errors are returned", func(t *testing.T) {
+		m := decodeTestMetadata{}
+		err := DecodeAndValidate(map[string]string{
+			"port":     "100000",
+			"timeout":  "10ms",
+			"mode":     "slow",
+			"maxBytes": "2048",
+		}, &m)
+		require.Error(t, err)
+
+		var valErr *ValidationError
+		require.ErrorAs(t, err, &valErr)
+		fields := make([]string, len(valErr.Errors))
+		for i, fe := range valErr.Errors {
+			fields[i] = fe.Field
+		}
+		assert.ElementsMatch(t, []string{"mode", "host", "port", "timeout", "maxBytes"}, fields)
+
+		assert.Contains(t, err.Error(), "'host': required property is missing")
+		assert.Contains(t, err.Error(), "'port': value must be at most 65535")
+		assert.Contains(t, err.Error(), "'timeout': value must be at least 1s")
+		assert.Contains(t, err.Error(), "'mode': invalid value 'slow' (allowed values: Fast, Safe)")
+
+		var fieldErr *FieldError
+		require.True(t, errors.As(err, &fieldErr))
+	})
+
+	t.Run("decoding errors", func(t *testing.T) {
+		m := decodeTestMetadata{Timeout: time.Second}
+		err := DecodeAndValidate(map[string]string{
+			"host":    "localhost",
+			"port":    "abc",
+			"enabled": "true",
+		}, &m)
+
+		var valErr *ValidationError
+		require.ErrorAs(t, err, &valErr)
+		require.Len(t, valErr.Errors, 1)
+		assert.Equal(t, "port", valErr.Errors[0].Field)
+	})
+
+	t.Run("empty value for required property", func(t *testing.T) {
+		m := decodeTestMetadata{Timeout: time.Second}
+		err := DecodeAndValidate(map[string]string{
+			"host": "  ",
+		}, &m)
+		require.ErrorContains(t, err, "'host': required property is missing")
+	})
+}
+
+// decodeTestLevel decodes itself from the value of the property.
+type decodeTestLevel int
+
+func (l *decodeTestLevel) DecodeString(value string) error {
+	switch strings.ToLower(value) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type decodeTestTypesMetadata struct {
+	Level       decodeTestLevel  `mapstructure:"level"`
+	LevelPtr    *decodeTestLevel `mapstructure:"levelPtr"`
+	BufferSize  int              `mapstructure:"bufferSize" mdbytesize:"true" mdmax:"1MiB"`
+	MaxBodySize kitmd.ByteSize   `mapstructure:"maxBodySize" mapstructurealiases:"maxSize" mdmin:"1Ki"`
+	Hosts       []string         `mapstructure:"hosts"`
+}
+
+func TestDecodeAndValidateTypes(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		m := decodeTestTypesMetadata{
+			BufferSize:  1024,
+			MaxBodySize: kitmd.NewByteSize(4096),
+		}
+		input := map[string]string{
+			"LEVEL":      "High",
+			"levelPtr":   "low",
+			"bufferSize": "64 KiB",
+			"maxSize":    "10MB",
+			"hosts":      "a, b,,c ",
+		}
+		err := DecodeAndValidate(input, &m)
+		require.NoError(t, err)
+		assert.Equal(t, decodeTestLevel(2), m.Level)
+		require.NotNil(t, m.LevelPtr)
+		assert.Equal(t, decodeTestLevel(1), *m.LevelPtr)
+		assert.Equal(t, 64*1024, m.BufferSize)
+		size, err := m.MaxBodySize.GetBytes()
+		require.NoError(t, err)
+		assert.Equal(t, int64(10_000_000), size)
+		assert.Equal(t, []string{"a", "b", "c"}, m.Hosts)
+
+		// The input is not modified
+		assert.Equal(t, "High", input["LEVEL"])
+		assert.Equal(t, "10MB", input["maxSize"])
+	})
+
+	t.Run("defaults are kept", func(t *testing.T) {
+		m := decodeTestTypesMetadata{
+			Level:       1,
+			BufferSize:  1024,
+			MaxBodySize: kitmd.NewByteSize(4096),
+		}
+		err := DecodeAndValidate(map[string]string{}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, decodeTestLevel(1), m.Level)
+		assert.Nil(t, m.LevelPtr)
+		assert.Equal(t, 1024, m.BufferSize)
+		assert.Nil(t, m.Hosts)
+	})
+
+	t.Run("all errors are returned", func(t *testing.T) {
+		m := decodeTestTypesMetadata{}
+		err := DecodeAndValidate(map[string]string{
+			"level":       "medium",
+			"bufferSize":  "2MiB",
+			"maxBodySize": "512",
+		}, &m)
+
+		var valErr *ValidationError
+		require.ErrorAs(t, err, &valErr)
+		fields := make([]string, len(valErr.Errors))
+		for i, fe := range valErr.Errors {
+			fields[i] = fe.Field
+		}
+		assert.ElementsMatch(t, []string{"level", "bufferSize", "maxBodySize"}, fields)
+		assert.Contains(t, err.Error(), "'level': unknown level")
+		assert.Contains(t, err.Error(), "'bufferSize': value must be at most 1MiB")
+		assert.Contains(t, err.Error(), "'maxBodySize': value must be at least 1Ki")
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		m := decodeTestTypesMetadata{}
+		err := DecodeAndValidate(map[string]string{
+			"bufferSize": "10XB",
+		}, &m)
+		require.ErrorContains(t, err, "'bufferSize': invalid size '10XB'")
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":       0,
+		"512":     512,
+		"100B":    100,
+		"1k":      1000,
+		"1KB":     1000,
+		"1kB":     1000,
+		"1Ki":     1024,
+		"1KiB":    1024,
+		"10MiB":   10 * 1024 * 1024,
+		"10 MiB":  10 * 1024 * 1024,
+		"1.5GB":   1_500_000_000,
+		"2Gi":     2 * 1024 * 1024 * 1024,
+		" 64Mi  ": 64 * 1024 * 1024,
+	}
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			n, err := ParseByteSize(value)
+			require.NoError(t, err)
+			assert.Equal(t, expected, n)
+		})
+	}
+
+	for _, value := range []string{"", "abc", "10XB", "-1Mi"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			_, err := ParseByteSize(value)
+			require.Error(t, err)
+		})
+	}
+}
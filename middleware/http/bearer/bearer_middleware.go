@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -77,17 +78,29 @@ func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadat
 			m.logger.Warnf("Error while refreshing JWKS cache: %v", err)
 		})),
 	)
-	err = cache.Register(meta.JWKSURL,
-		jwk.WithMinRefreshInterval(minRefreshInterval),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to register JWKS cache: %w", err)
+	for i, iss := range meta.issuers {
+		// Issuers can share the same JWKS
+		if slices.ContainsFunc(meta.issuers[:i], func(prev issuerConfig) bool { return prev.JWKSURL == iss.JWKSURL }) {
+			continue
+		}
+
+		err = cache.Register(iss.JWKSURL,
+			jwk.WithMinRefreshInterval(minRefreshInterval),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register JWKS cache: %w", err)
+		}
+
+		// Fetch the JWKS right away to start, so we can check it's valid and populate the cache
+		_, err = cache.Refresh(ctx, iss.JWKSURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
 	}
 
-	// Fetch the JWKS right away to start, so we can check it's valid and populate the cache
-	_, err = cache.Refresh(ctx, meta.JWKSURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	var intro *introspector
+	if meta.IntrospectionEndpoint != "" {
+		intro = newIntrospector(meta)
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -103,30 +116,150 @@ func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadat
 				return
 			}
 
-			keyset, err := cache.Get(r.Context(), meta.JWKSURL)
-			if err != nil {
-				m.logger.Errorf("Failed to retrieve JWKS cache: %v", err)
-				httputils.RespondWithError(w, http.StatusInternalServerError)
-				return
+			// JWTs from the configured issuers are validated with their keys; other tokens are introspected, if enabled
+			var claims tokenClaims
+			unverified, err := jwt.ParseInsecure([]byte(rawToken))
+			var issuers []issuerConfig
+			if err == nil {
+				issuers = meta.issuersFor(unverified.Issuer())
 			}
+			switch {
+			case len(issuers) > 0:
+				if !meta.isAlgorithmAllowed([]byte(rawToken)) {
+					httputils.RespondWithError(w, http.StatusUnauthorized)
+					return
+				}
+
+				keyset, err := cache.Get(r.Context(), issuers[0].JWKSURL)
+				if err != nil {
+					m.logger.Errorf("Failed to retrieve JWKS cache: %v", err)
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					return
+				}
 
-			_, err = jwt.Parse([]byte(rawToken),
-				jwt.WithContext(r.Context()),
-				jwt.WithAcceptableSkew(allowedClockSkew),
-				jwt.WithKeySet(keyset, jws.WithInferAlgorithmFromKey(true)),
-				jwt.WithAudience(meta.Audience),
-				jwt.WithIssuer(meta.Issuer),
-			)
-			if err != nil {
+				token, err := jwt.Parse([]byte(rawToken),
+					jwt.WithContext(r.Context()),
+					jwt.WithAcceptableSkew(allowedClockSkew),
+					jwt.WithKeySet(keyset, jws.WithInferAlgorithmFromKey(true)),
+					jwt.WithIssuer(issuers[0].Issuer),
+				)
+				if err != nil || !hasAudience(token, issuers) {
+					httputils.RespondWithError(w, http.StatusUnauthorized)
+					return
+				}
+				claims = jwtClaims(token)
+			case intro != nil:
+				res, err := intro.Introspect(r.Context(), rawToken)
+				if err != nil {
+					m.logger.Errorf("Failed to introspect token: %v", err)
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					return
+				}
+				if !res.isValid(meta) {
+					httputils.RespondWithError(w, http.StatusUnauthorized)
+					return
+				}
+				claims = res.claims()
+			default:
 				httputils.RespondWithError(w, http.StatusUnauthorized)
 				return
 			}
 
+			rule, ok := meta.matchingRule(r.Method, r.URL.Path)
+			if ok && (!claims.hasScopes(rule.Scopes) || (len(rule.Audiences) > 0 && !claims.hasAudience(rule.Audiences))) {
+				httputils.RespondWithError(w, http.StatusForbidden)
+				return
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}, nil
 }
 
+// isAlgorithmAllowed returns true if the token is signed with one of the allowed algorithms.
+func (md *bearerMiddlewareMetadata) isAlgorithmAllowed(rawToken []byte) bool {
+	if len(md.allowedAlgorithms) == 0 {
+		return true
+	}
+
+	msg, err := jws.Parse(rawToken)
+	if err != nil {
+		return false
+	}
+	for _, sig := range msg.Signatures() {
+		if !slices.Contains(md.allowedAlgorithms, sig.ProtectedHeaders().Algorithm()) {
+			return false
+		}
+	}
+	return len(msg.Signatures()) > 0
+}
+
+// hasAudience returns true if the token's audience is accepted for any of the issuers.
+func hasAudience(token jwt.Token, issuers []issuerConfig) bool {
+	for _, iss := range issuers {
+		if slices.Contains(token.Audience(), iss.Audience) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasScopes returns true if the token contains all the required scopes.
+func hasScopes(token jwt.Token, required []string) bool {
+	return jwtClaims(token).hasScopes(required)
+}
+
+// tokenClaims contains the claims of a token that are used for authorization.
+type tokenClaims struct {
+	audience []string
+	scopes   []string
+}
+
+// jwtClaims returns the claims of a JWT.
+// Scopes are read from the "scope" claim (space-separated string) or from the "scp" claim (string or array).
+func jwtClaims(token jwt.Token) tokenClaims {
+	scopes := []string{}
+	if v, ok := token.Get("scope"); ok {
+		if str, ok := v.(string); ok {
+			scopes = append(scopes, strings.Fields(str)...)
+		}
+	}
+	if v, ok := token.Get("scp"); ok {
+		switch val := v.(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(val)...)
+		case []any:
+			for _, s := range val {
+				if str, ok := s.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+		}
+	}
+
+	return tokenClaims{
+		audience: token.Audience(),
+		scopes:   scopes,
+	}
+}
+
+// hasScopes returns true if the claims contain all the required scopes.
+func (c tokenClaims) hasScopes(required []string) bool {
+	for _, s := range required {
+		if !slices.Contains(c.scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAudience returns true if the claims contain any of the audiences.
+func (c tokenClaims) hasAudience(audiences []string) bool {
+	return slices.ContainsFunc(audiences, func(aud string) bool {
+		return slices.Contains(c.audience, aud)
+	})
+}
+
 func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := bearerMiddlewareMetadata{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
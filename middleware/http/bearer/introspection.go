@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bearer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default duration for which introspection responses are cached
+	defaultIntrospectionCacheTTL = time.Minute
+	// Maximum number of introspection responses in the cache
+	maxIntrospectionCacheEntries = 10_000
+	// Maximum size of responses from the introspection endpoint
+	maxIntrospectionResponseSize = 1 << 20
+)
+
+// introspector validates opaque tokens with an OAuth2 token introspection endpoint, as per RFC 7662.
+// Responses are cached, so the endpoint is not invoked on every request.
+type introspector struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	cacheTTL     time.Duration
+	client       *http.Client
+
+	cache     map[string]introspectionCacheEntry
+	cacheLock sync.Mutex
+}
+
+type introspectionCacheEntry struct {
+	res     *introspectionResponse
+	expires time.Time
+}
+
+// introspectionResponse contains the properties of an introspection response that are used by the middleware.
+type introspectionResponse struct {
+	Active    bool          `json:"active"`
+	Scope     string        `json:"scope"`
+	Audience  audienceClaim `json:"aud"`
+	Issuer    string        `json:"iss"`
+	ExpiresAt int64         `json:"exp"`
+	NotBefore int64         `json:"nbf"`
+}
+
+// audienceClaim is the "aud" claim, which can be a string or an array of strings.
+type audienceClaim []string
+
+func (a *audienceClaim) UnmarshalJSON(data []byte) error {
+	var str string
+	if json.Unmarshal(data, &str) == nil {
+		*a = audienceClaim{str}
+		return nil
+	}
+	var arr []string
+	err := json.Unmarshal(data, &arr)
+	if err != nil {
+		return errors.New("claim 'aud' must be a string or an array of strings")
+	}
+	*a = arr
+	return nil
+}
+
+func newIntrospector(md *bearerMiddlewareMetadata) *introspector {
+	return &introspector{
+		endpoint:     md.IntrospectionEndpoint,
+		clientID:     md.IntrospectionClientID,
+		clientSecret: md.IntrospectionClientSecret,
+		cacheTTL:     md.IntrospectionCacheTTL,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		cache: map[string]introspectionCacheEntry{},
+	}
+}
+
+// Introspect returns the introspection response for the token, from the cache if possible.
+// The response must be checked with isValid.
+func (i *introspector) Introspect(ctx context.Context, token string) (*introspectionResponse, error) {
+	// Tokens are not stored in memory in plaintext
+	h := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(h[:])
+
+	now := time.Now()
+	i.cacheLock.Lock()
+	entry, ok := i.cache[key]
+	i.cacheLock.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.res, nil
+	}
+
+	res, err := i.request(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := now.Add(i.cacheTTL)
+	if res.Active && res.ExpiresAt > 0 && time.Unix(res.ExpiresAt, 0).Before(expires) {
+		expires = time.Unix(res.ExpiresAt, 0)
+	}
+	if expires.After(now) {
+		i.cacheLock.Lock()
+		i.store(key, introspectionCacheEntry{res: res, expires: expires}, now)
+		i.cacheLock.Unlock()
+	}
+
+	return res, nil
+}
+
+// Stores an entry in the cache, removing expired entries if the cache is full.
+// It must be called while holding the lock.
+func (i *introspector) store(key string, entry introspectionCacheEntry, now time.Time) {
+	if len(i.cache) >= maxIntrospectionCacheEntries {
+		for k, e := range i.cache {
+			if !now.Before(e.expires) {
+				delete(i.cache, k)
+			}
+		}
+		// If the cache is still full, start over
+		if len(i.cache) >= maxIntrospectionCacheEntries {
+			i.cache = make(map[string]introspectionCacheEntry, len(i.cache))
+		}
+	}
+	i.cache[key] = entry
+}
+
+// Invokes the introspection endpoint, authenticating with the client credentials.
+func (i *introspector) request(ctx context.Context, token string) (*introspectionResponse, error) {
+	form := url.Values{
+		"token":           []string{token},
+		"token_type_hint": []string{"access_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// As per RFC 6749 section 2.3.1, the credentials are form-encoded before being used for basic authentication
+	req.SetBasicAuth(url.QueryEscape(i.clientID), url.QueryEscape(i.clientSecret))
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() {
+		// Drain before closing
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid response status code: %d", res.StatusCode)
+	}
+
+	out := &introspectionResponse{}
+	err = json.NewDecoder(io.LimitReader(res.Body, maxIntrospectionResponseSize)).Decode(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return out, nil
+}
+
+// isValid returns true if the token is active, within its validity period, and from an accepted issuer and audience.
+// If the response has no issuer, or no issuer is configured, the audience is checked by the scope rules only.
+func (res *introspectionResponse) isValid(md *bearerMiddlewareMetadata) bool {
+	if !res.Active {
+		return false
+	}
+
+	now := time.Now()
+	if res.ExpiresAt > 0 && now.After(time.Unix(res.ExpiresAt, 0).Add(allowedClockSkew)) {
+		return false
+	}
+	if res.NotBefore > 0 && now.Before(time.Unix(res.NotBefore, 0).Add(-allowedClockSkew)) {
+		return false
+	}
+
+	if res.Issuer == "" || len(md.issuers) == 0 {
+		return true
+	}
+	for _, iss := range md.issuersFor(res.Issuer) {
+		if res.claims().hasAudience([]string{iss.Audience}) {
+			return true
+		}
+	}
+	return false
+}
+
+// claims returns the claims of the token used for authorization.
+func (res *introspectionResponse) claims() tokenClaims {
+	return tokenClaims{
+		audience: res.Audience,
+		scopes:   strings.Fields(res.Scope),
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bearer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// introspectionServer is a fake introspection endpoint that returns the response set for each token.
+type introspectionServer struct {
+	t         *testing.T
+	responses map[string]map[string]any
+	requests  atomic.Int32
+}
+
+func (s *introspectionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != "client%2Fid" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	assert.Equal(s.t, http.MethodPost, r.Method)
+	assert.Equal(s.t, "access_token", r.PostFormValue("token_type_hint"))
+
+	res, ok := s.responses[r.PostFormValue("token")]
+	if !ok {
+		res = map[string]any{"active": false}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func TestIntrospection(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	srv := &introspectionServer{
+		t: t,
+		responses: map[string]map[string]any{
+			"opaque-token-1": {"active": true, "scope": "orders.read orders.write", "aud": "orders", "exp": exp},
+			"opaque-token-2": {"active": true, "scope": "orders.read", "aud": []string{"other"}, "exp": exp},
+			"opaque-token-3": {"active": true, "exp": time.Now().Add(-time.Hour).Unix()},
+		},
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	newHandler := func(t *testing.T, props map[string]string) http.Handler {
+		t.Helper()
+
+		props["introspectionEndpoint"] = ts.URL
+		props["introspectionClientID"] = "client/id"
+		props["introspectionClientSecret"] = "secret"
+		props["scopeRules"] = `
+- path: /write
+  scopes: [orders.write]
+- path: /orders/*
+  audiences: [orders]
+`
+		m := NewBearerMiddleware(logger.NewLogger("test"))
+		handler, err := m.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	do := func(handler http.Handler, token string, path string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	handler := newHandler(t, map[string]string{})
+
+	t.Run("active token", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-1", "/read"))
+		assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-1", "/write"))
+		assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-1", "/orders/list"))
+	})
+
+	t.Run("responses are cached", func(t *testing.T) {
+		requests := srv.requests.Load()
+		assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-1", "/read"))
+		assert.Equal(t, requests, srv.requests.Load())
+	})
+
+	t.Run("missing scope or audience", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-2", "/read"))
+		assert.Equal(t, http.StatusForbidden, do(handler, "opaque-token-2", "/write"))
+		assert.Equal(t, http.StatusForbidden, do(handler, "opaque-token-2", "/orders/list"))
+	})
+
+	t.Run("inactive or expired token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(handler, "unknown-token", "/read"))
+		assert.Equal(t, http.StatusUnauthorized, do(handler, "opaque-token-3", "/read"))
+	})
+
+	t.Run("caching disabled", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{
+			"introspectionCacheTTL": "0",
+		})
+		requests := srv.requests.Load()
+		for range 2 {
+			assert.Equal(t, http.StatusNoContent, do(handler, "opaque-token-1", "/read"))
+		}
+		assert.Equal(t, requests+2, srv.requests.Load())
+	})
+
+	t.Run("endpoint errors", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{})
+		ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		defer func() {
+			ts.Config.Handler = srv
+		}()
+		assert.Equal(t, http.StatusInternalServerError, do(handler, "opaque-token-1", "/read"))
+	})
+}
+
+func TestIntrospectionCache(t *testing.T) {
+	i := &introspector{
+		cache: map[string]introspectionCacheEntry{},
+	}
+	now := time.Now()
+	for n := range maxIntrospectionCacheEntries {
+		expires := now.Add(time.Minute)
+		if n%2 == 0 {
+			expires = now.Add(-time.Minute)
+		}
+		i.store(string(rune(n)), introspectionCacheEntry{expires: expires}, now)
+	}
+	require.Len(t, i.cache, maxIntrospectionCacheEntries)
+
+	// Expired entries are removed when the cache is full
+	i.store("new", introspectionCacheEntry{expires: now.Add(time.Minute)}, now)
+	assert.Len(t, i.cache, maxIntrospectionCacheEntries/2+1)
+}
@@ -20,7 +20,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"gopkg.in/yaml.v3"
 
 	"github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
@@ -35,13 +40,69 @@ type bearerMiddlewareMetadata struct {
 	// Optional address of the JKWS file.
 	// If missing, will try to fetch the URL set in the OpenID Configuration document `<issuer>/.well-known/openid-configuration`.
 	JWKSURL string `json:"jwksURL" mapstructure:"jwksURL"`
+	// List of additional issuers and audiences to accept, as a JSON or YAML-encoded string.
+	// Each item has the properties "issuer", "audience", and (optionally) "jwksURL".
+	Issuers string `json:"issuers" mapstructure:"issuers"`
+	// Comma-separated list of signing algorithms that are allowed.
+	// If empty, the algorithm is inferred from the key.
+	AllowedAlgorithms string `json:"allowedAlgorithms" mapstructure:"allowedAlgorithms"`
+	// List of rules with the scopes and audiences required for each path, as a JSON or YAML-encoded string.
+	// Each item has the properties "path", "methods" (optional), "scopes", and "audiences" (optional).
+	ScopeRules string `json:"scopeRules" mapstructure:"scopeRules"`
+	// URL of the OAuth2 token introspection endpoint (RFC 7662), to validate opaque tokens.
+	// Tokens that are not JWTs, or JWTs from issuers that are not configured, are validated with this endpoint.
+	IntrospectionEndpoint string `json:"introspectionEndpoint" mapstructure:"introspectionEndpoint"`
+	// Client ID used to authenticate with the introspection endpoint.
+	IntrospectionClientID string `json:"introspectionClientID" mapstructure:"introspectionClientID"`
+	// Client secret used to authenticate with the introspection endpoint.
+	IntrospectionClientSecret string `json:"introspectionClientSecret" mapstructure:"introspectionClientSecret"`
+	// Duration for which introspection responses are cached; active tokens are not cached past their expiration.
+	// Set to 0 to disable caching.
+	// Defaults to "1m".
+	IntrospectionCacheTTL time.Duration `json:"introspectionCacheTTL" mapstructure:"introspectionCacheTTL"`
 
 	// Internal properties
-	logger logger.Logger `json:"-" mapstructure:"-"`
+	logger            logger.Logger            `json:"-" mapstructure:"-"`
+	issuers           []issuerConfig           `json:"-" mapstructure:"-"`
+	allowedAlgorithms []jwa.SignatureAlgorithm `json:"-" mapstructure:"-"`
+	scopeRules        []scopeRule              `json:"-" mapstructure:"-"`
+}
+
+// issuerConfig contains an issuer and the audience to accept.
+type issuerConfig struct {
+	Issuer   string `json:"issuer" yaml:"issuer"`
+	Audience string `json:"audience" yaml:"audience"`
+	JWKSURL  string `json:"jwksURL" yaml:"jwksURL"`
+}
+
+// scopeRule contains the scopes that are required for requests matching a path.
+type scopeRule struct {
+	// Path to match. If it ends with "*", it matches all paths with that prefix.
+	Path string `json:"path" yaml:"path"`
+	// HTTP methods to match. If empty, all methods match.
+	Methods []string `json:"methods" yaml:"methods"`
+	// Scopes that the token must contain.
+	Scopes []string `json:"scopes" yaml:"scopes"`
+	// Audiences accepted for the path. If set, the token must contain at least one of them.
+	Audiences []string `json:"audiences" yaml:"audiences"`
+}
+
+// matches returns true if the rule applies to the request.
+func (r scopeRule) matches(method string, path string) bool {
+	if len(r.Methods) > 0 && !slices.ContainsFunc(r.Methods, func(m string) bool { return strings.EqualFold(m, method) }) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == r.Path
 }
 
 // Parse the component's metadata into the object.
 func (md *bearerMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.IntrospectionCacheTTL = defaultIntrospectionCacheTTL
+
 	// Decode the properties
 	err := mdutils.DecodeMetadata(metadata.Properties, md)
 	if err != nil {
@@ -49,16 +110,99 @@ func (md *bearerMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) e
 	}
 
 	// Validate properties
-	if md.Issuer == "" {
-		return errors.New("metadata property 'issuer' is required")
+	md.issuers = []issuerConfig{}
+	if md.Issuers != "" {
+		err = yaml.Unmarshal([]byte(md.Issuers), &md.issuers)
+		if err != nil {
+			return fmt.Errorf("failed to decode metadata property 'issuers' as JSON or YAML: %w", err)
+		}
+		for i, iss := range md.issuers {
+			if iss.Issuer == "" || iss.Audience == "" {
+				return fmt.Errorf("item %d in metadata property 'issuers' must contain both 'issuer' and 'audience'", i)
+			}
+		}
+	}
+	// The issuer is not required when only opaque tokens are accepted
+	if md.Issuer != "" || (len(md.issuers) == 0 && md.IntrospectionEndpoint == "") {
+		if md.Issuer == "" {
+			return errors.New("metadata property 'issuer' is required")
+		}
+		if md.Audience == "" {
+			return errors.New("metadata property 'audience' is required")
+		}
+		md.issuers = append([]issuerConfig{{
+			Issuer:   md.Issuer,
+			Audience: md.Audience,
+			JWKSURL:  md.JWKSURL,
+		}}, md.issuers...)
+	}
+
+	md.allowedAlgorithms = []jwa.SignatureAlgorithm{}
+	for _, alg := range strings.Split(md.AllowedAlgorithms, ",") {
+		alg = strings.TrimSpace(alg)
+		if alg == "" {
+			continue
+		}
+		var sa jwa.SignatureAlgorithm
+		err = sa.Accept(alg)
+		if err != nil || sa == jwa.NoSignature {
+			return fmt.Errorf("invalid algorithm '%s' in metadata property 'allowedAlgorithms'", alg)
+		}
+		md.allowedAlgorithms = append(md.allowedAlgorithms, sa)
+	}
+
+	md.scopeRules = []scopeRule{}
+	if md.ScopeRules != "" {
+		err = yaml.Unmarshal([]byte(md.ScopeRules), &md.scopeRules)
+		if err != nil {
+			return fmt.Errorf("failed to decode metadata property 'scopeRules' as JSON or YAML: %w", err)
+		}
+		for i, rule := range md.scopeRules {
+			if rule.Path == "" || (len(rule.Scopes) == 0 && len(rule.Audiences) == 0) {
+				return fmt.Errorf("item %d in metadata property 'scopeRules' must contain 'path' and at least one scope or audience", i)
+			}
+		}
 	}
-	if md.Audience == "" {
-		return errors.New("metadata property 'audience' is required")
+
+	if md.IntrospectionEndpoint != "" {
+		if md.IntrospectionClientID == "" || md.IntrospectionClientSecret == "" {
+			return errors.New("metadata properties 'introspectionClientID' and 'introspectionClientSecret' are required with 'introspectionEndpoint'")
+		}
+		if md.IntrospectionCacheTTL < 0 {
+			return errors.New("metadata property 'introspectionCacheTTL' must not be negative")
+		}
 	}
 
 	return nil
 }
 
+// issuersFor returns the configured issuers matching the issuer of a token.
+func (md *bearerMiddlewareMetadata) issuersFor(issuer string) []issuerConfig {
+	res := make([]issuerConfig, 0, 1)
+	for _, iss := range md.issuers {
+		if iss.Issuer == issuer {
+			res = append(res, iss)
+		}
+	}
+	return res
+}
+
+// matchingRule returns the first rule that matches the request, if any.
+func (md *bearerMiddlewareMetadata) matchingRule(method string, path string) (scopeRule, bool) {
+	for _, rule := range md.scopeRules {
+		if rule.matches(method, path) {
+			return rule, true
+		}
+	}
+	return scopeRule{}, false
+}
+
+// requiredScopes returns the scopes required by the first rule that matches the request, if any.
+func (md *bearerMiddlewareMetadata) requiredScopes(method string, path string) []string {
+	rule, _ := md.matchingRule(method, path)
+	return rule.Scopes
+}
+
 // Contains a subset of the properties defined in the openid-configuration document.
 // See: https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig .
 type openIDConfigurationJSON struct {
@@ -66,16 +210,42 @@ type openIDConfigurationJSON struct {
 	JWKSURL string `json:"jwks_uri"`
 }
 
-// Retrieves the OpenID Configuration document
+// Retrieves the OpenID Configuration documents of the issuers that do not have a JWKS URL.
 func (md *bearerMiddlewareMetadata) retrieveOpenIDConfigurationDocument(ctx context.Context) error {
-	// If we already have a fixed JWKS URL, use that
-	if md.JWKSURL != "" {
-		md.logger.Debug("Using JWKS URL from metadata: " + md.JWKSURL)
-		return nil
+	for i := range md.issuers {
+		iss := &md.issuers[i]
+
+		// If we already have a fixed JWKS URL, use that
+		if iss.JWKSURL != "" {
+			md.logger.Debug("Using JWKS URL from metadata: " + iss.JWKSURL)
+			continue
+		}
+
+		// The document may have been retrieved for the same issuer already
+		idx := slices.IndexFunc(md.issuers[:i], func(prev issuerConfig) bool { return prev.Issuer == iss.Issuer })
+		if idx >= 0 {
+			iss.JWKSURL = md.issuers[idx].JWKSURL
+			continue
+		}
+
+		err := md.retrieveJWKSURL(ctx, iss)
+		if err != nil {
+			return fmt.Errorf("issuer '%s': %w", iss.Issuer, err)
+		}
+	}
+
+	// Maintain the legacy property populated
+	if len(md.issuers) > 0 {
+		md.JWKSURL = md.issuers[0].JWKSURL
 	}
 
+	return nil
+}
+
+// Retrieves the JWKS URL of an issuer from its OpenID Configuration document
+func (md *bearerMiddlewareMetadata) retrieveJWKSURL(ctx context.Context, iss *issuerConfig) error {
 	// Retrieve the openid-configuration document
-	oidcConfigURL := strings.TrimSuffix(md.Issuer, "/") + "/.well-known/openid-configuration"
+	oidcConfigURL := strings.TrimSuffix(iss.Issuer, "/") + "/.well-known/openid-configuration"
 	md.logger.Debug("Fetching OpenID Configuration: " + oidcConfigURL)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oidcConfigURL, nil)
@@ -115,13 +285,13 @@ func (md *bearerMiddlewareMetadata) retrieveOpenIDConfigurationDocument(ctx cont
 
 	// Ensure that the issuer matches
 	// Note that we do not strip trailing slashes, so the user-supplied issuer must match (and that's per specs)
-	if oidcConfig.Issuer != md.Issuer {
-		return fmt.Errorf("the issuer found in the OpenID Configuration document ('%s') doesn't match the one provided in the component's metadata ('%s')", oidcConfig.Issuer, md.Issuer)
+	if oidcConfig.Issuer != iss.Issuer {
+		return fmt.Errorf("the issuer found in the OpenID Configuration document ('%s') doesn't match the one provided in the component's metadata ('%s')", oidcConfig.Issuer, iss.Issuer)
 	}
 
 	// Update the object and return
-	md.JWKSURL = oidcConfig.JWKSURL
-	md.logger.Debug("Found JWKS URL: " + md.JWKSURL)
+	iss.JWKSURL = oidcConfig.JWKSURL
+	md.logger.Debug("Found JWKS URL: " + iss.JWKSURL)
 
 	return nil
 }
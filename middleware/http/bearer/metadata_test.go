@@ -14,8 +14,11 @@ limitations under the License.
 package bearer
 
 import (
+	"net/http"
 	"testing"
 
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -107,4 +110,125 @@ func TestParseMetadata(t *testing.T) {
 		require.Error(t, err)
 		require.ErrorContains(t, err, "metadata property 'audience' is required")
 	})
+
+	t.Run("multiple issuers", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"issuers": `
+- issuer: https://tenant1.example.com
+  audience: foo
+  jwksURL: https://tenant1.example.com/jwks.json
+- issuer: https://tenant2.example.com
+  audience: bar
+`,
+		})
+		require.NoError(t, err)
+		require.Len(t, md.issuers, 2)
+		assert.Equal(t, "https://tenant1.example.com/jwks.json", md.issuers[0].JWKSURL)
+		assert.Len(t, md.issuersFor("https://tenant2.example.com"), 1)
+		assert.Empty(t, md.issuersFor("https://other.example.com"))
+	})
+
+	t.Run("issuer and multiple issuers", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"issuer":   "http://localhost",
+			"audience": "foo",
+			"issuers":  `[{"issuer": "http://localhost", "audience": "bar"}]`,
+		})
+		require.NoError(t, err)
+		require.Len(t, md.issuers, 2)
+		assert.Len(t, md.issuersFor("http://localhost"), 2)
+	})
+
+	t.Run("issuer without audience in list", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"issuers": `[{"issuer": "http://localhost"}]`,
+		})
+		require.ErrorContains(t, err, "issuers")
+	})
+
+	t.Run("allowed algorithms", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"issuer":            "http://localhost",
+			"audience":          "foo",
+			"allowedAlgorithms": "RS256, ES256",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []jwa.SignatureAlgorithm{jwa.RS256, jwa.ES256}, md.allowedAlgorithms)
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"issuer":            "http://localhost",
+			"audience":          "foo",
+			"allowedAlgorithms": "none",
+		})
+		require.ErrorContains(t, err, "allowedAlgorithms")
+	})
+
+	t.Run("scope rules", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"issuer":   "http://localhost",
+			"audience": "foo",
+			"scopeRules": `
+- path: /v1.0/invoke/orders/method/admin/*
+  scopes: [orders.admin]
+- path: /v1.0/invoke/orders/*
+  methods: [POST, PUT]
+  scopes: [orders.write]
+`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders.admin"}, md.requiredScopes(http.MethodGet, "/v1.0/invoke/orders/method/admin/users"))
+		assert.Equal(t, []string{"orders.write"}, md.requiredScopes(http.MethodPost, "/v1.0/invoke/orders/method/new"))
+		assert.Empty(t, md.requiredScopes(http.MethodGet, "/v1.0/invoke/orders/method/list"))
+	})
+
+	t.Run("introspection only", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"introspectionEndpoint":     "http://localhost/introspect",
+			"introspectionClientID":     "client",
+			"introspectionClientSecret": "secret",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, md.issuers)
+		assert.Equal(t, defaultIntrospectionCacheTTL, md.IntrospectionCacheTTL)
+	})
+
+	t.Run("introspection without client credentials", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"introspectionEndpoint": "http://localhost/introspect",
+		})
+		require.ErrorContains(t, err, "introspectionClientID")
+	})
+
+	t.Run("scope rules with audiences only", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"issuer":     "http://localhost",
+			"audience":   "foo",
+			"scopeRules": `[{"path": "/v1.0/invoke/orders/*", "audiences": ["orders"]}]`,
+		})
+		require.NoError(t, err)
+		rule, ok := md.matchingRule(http.MethodGet, "/v1.0/invoke/orders/method/list")
+		require.True(t, ok)
+		assert.Equal(t, []string{"orders"}, rule.Audiences)
+	})
+
+	t.Run("scope rule without scopes or audiences", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"issuer":     "http://localhost",
+			"audience":   "foo",
+			"scopeRules": `[{"path": "/v1.0/invoke/orders/*"}]`,
+		})
+		require.ErrorContains(t, err, "at least one scope or audience")
+	})
+}
+
+func TestHasScopes(t *testing.T) {
+	token := jwt.New()
+	require.NoError(t, token.Set("scope", "orders.read orders.write"))
+	require.NoError(t, token.Set("scp", []any{"profile"}))
+
+	assert.True(t, hasScopes(token, []string{"orders.write"}))
+	assert.True(t, hasScopes(token, []string{"orders.read", "profile"}))
+	assert.False(t, hasScopes(token, []string{"orders.admin"}))
 }
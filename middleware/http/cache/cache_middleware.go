@@ -0,0 +1,485 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	cacheStatusHeader = "X-Cache"
+	cacheStatusHit    = "HIT"
+	cacheStatusMiss   = "MISS"
+	cacheStatusStale  = "STALE"
+)
+
+// Status codes of responses that can be cached.
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// NewCacheMiddleware returns a new HTTP response caching middleware.
+func NewCacheMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Middleware is a middleware that caches HTTP responses.
+type Middleware struct {
+	logger logger.Logger
+	now    func() time.Time
+}
+
+// cacheHandler contains the state of a handler returned by the middleware.
+type cacheHandler struct {
+	logger       logger.Logger
+	now          func() time.Time
+	meta         *cacheMiddlewareMetadata
+	store        store
+	revalidating sync.Map
+}
+
+// keyData is the data passed to the template for cache keys.
+type keyData struct {
+	Method string
+	Host   string
+	Path   string
+	Query  string
+
+	header http.Header
+}
+
+// Header returns the value of a request header, for use in the template as `{{.Header "name"}}`.
+func (d keyData) Header(name string) string {
+	return strings.Join(d.header.Values(name), ",")
+}
+
+// entry is a cached response.
+type entry struct {
+	// List of request headers the response varies on.
+	// If set, this is an entry pointing to the variants, and the other fields are empty.
+	Vary []string `json:"vary,omitempty"`
+
+	Status     int         `json:"status,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	StoredAt   time.Time   `json:"storedAt"`
+	FreshUntil time.Time   `json:"freshUntil"`
+	StaleUntil time.Time   `json:"staleUntil"`
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &cacheMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &cacheHandler{
+		logger: m.logger,
+		now:    m.now,
+		meta:   meta,
+	}
+	switch meta.StoreType {
+	case storeTypeRedis:
+		client, _, err := rediscomponent.ParseClientFromProperties(metadata.Properties, contribMetadata.MiddlewareType, ctx, &m.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Redis client: %w", err)
+		}
+		if _, err = client.PingResult(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		h.store = &redisStore{
+			client:    client,
+			keyPrefix: meta.KeyPrefix,
+		}
+	default:
+		h.store, err = newMemoryStore(meta.MaxEntries, m.now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Release the store when the middleware is disposed of
+	go func() {
+		<-ctx.Done()
+		_ = h.store.Close()
+	}()
+
+	return h.handler, nil
+}
+
+func (h *cacheHandler) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := h.cacheKey(r)
+		if err != nil {
+			h.logger.Errorf("Failed to compute cache key: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqCC := parseCacheControl(r.Header.Values("cache-control"))
+		if !reqCC.has("no-cache") && !reqCC.has("no-store") {
+			e, vk := h.lookup(r.Context(), key, r.Header)
+			now := h.now()
+			switch {
+			case e == nil:
+				// Cache miss
+			case now.Before(e.FreshUntil):
+				h.writeEntry(w, r, e, cacheStatusHit)
+				return
+			case now.Before(e.StaleUntil):
+				h.writeEntry(w, r, e, cacheStatusStale)
+				h.revalidate(next, r, key, vk)
+				return
+			}
+		}
+
+		w.Header().Set(cacheStatusHeader, cacheStatusMiss)
+		rec := &captureResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+			maxSize:        h.meta.MaxEntrySize,
+		}
+		next.ServeHTTP(rec, r)
+
+		if !reqCC.has("no-store") && !rec.overflow {
+			h.storeResponse(r.Context(), key, r, rec.status, w.Header(), rec.body.Bytes())
+		}
+	})
+}
+
+// cacheKey returns the primary cache key for the request.
+func (h *cacheHandler) cacheKey(r *http.Request) (string, error) {
+	buf := &bytes.Buffer{}
+	err := h.meta.keyTemplate.Execute(buf, keyData{
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query().Encode(),
+		header: r.Header,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// lookup returns the entry for the request, and the key of the variant if the response varies on request headers.
+func (h *cacheHandler) lookup(ctx context.Context, key string, header http.Header) (*entry, string) {
+	e, err := h.getEntry(ctx, key)
+	if err != nil {
+		h.logger.Warnf("Failed to read from cache: %v", err)
+		return nil, ""
+	}
+	if e == nil || len(e.Vary) == 0 {
+		return e, ""
+	}
+
+	vk := variantKey(key, e.Vary, header)
+	e, err = h.getEntry(ctx, vk)
+	if err != nil {
+		h.logger.Warnf("Failed to read from cache: %v", err)
+		return nil, ""
+	}
+	return e, vk
+}
+
+func (h *cacheHandler) getEntry(ctx context.Context, key string) (*entry, error) {
+	data, err := h.store.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	e := &entry{}
+	err = json.Unmarshal(data, e)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (h *cacheHandler) setEntry(ctx context.Context, key string, e *entry, ttl time.Duration) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return h.store.Set(ctx, key, data, ttl)
+}
+
+// storeResponse stores the response in the cache if it's cacheable.
+func (h *cacheHandler) storeResponse(ctx context.Context, key string, r *http.Request, status int, header http.Header, body []byte) {
+	if !cacheableStatusCodes[status] || header.Get("set-cookie") != "" {
+		return
+	}
+
+	resCC := parseCacheControl(header.Values("cache-control"))
+	if resCC.has("no-store") || resCC.has("no-cache") || resCC.has("private") {
+		return
+	}
+	if r.Header.Get("authorization") != "" && !resCC.has("public") && !resCC.has("s-maxage") {
+		return
+	}
+
+	vary := parseVary(header.Values("vary"))
+	if len(vary) == 1 && vary[0] == "*" {
+		return
+	}
+
+	// Determine the freshness lifetime
+	now := h.now()
+	ttl := h.meta.TTL
+	if v, ok := resCC.duration("s-maxage"); ok {
+		ttl = v
+	} else if v, ok := resCC.duration("max-age"); ok {
+		ttl = v
+	} else if exp := header.Get("expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			ttl = t.Sub(now)
+		} else {
+			ttl = 0
+		}
+	}
+	stale := h.meta.StaleWhileRevalidate
+	if v, ok := resCC.duration("stale-while-revalidate"); ok {
+		stale = v
+	}
+	if ttl <= 0 && stale <= 0 {
+		return
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	storedHeader := header.Clone()
+	storedHeader.Del(cacheStatusHeader)
+	e := &entry{
+		Status:     status,
+		Header:     storedHeader,
+		Body:       body,
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		StaleUntil: now.Add(ttl + stale),
+	}
+
+	entryKey := key
+	if len(vary) > 0 {
+		entryKey = variantKey(key, vary, r.Header)
+		err := h.setEntry(ctx, key, &entry{Vary: vary}, ttl+stale)
+		if err != nil {
+			h.logger.Warnf("Failed to write to cache: %v", err)
+			return
+		}
+	}
+	err := h.setEntry(ctx, entryKey, e, ttl+stale)
+	if err != nil {
+		h.logger.Warnf("Failed to write to cache: %v", err)
+	}
+}
+
+// writeEntry sends a cached response to the client.
+func (h *cacheHandler) writeEntry(w http.ResponseWriter, r *http.Request, e *entry, cacheStatus string) {
+	for k, v := range e.Header {
+		w.Header()[k] = v
+	}
+	age := int64(h.now().Sub(e.StoredAt).Seconds())
+	if age < 0 {
+		age = 0
+	}
+	w.Header().Set("age", strconv.FormatInt(age, 10))
+	w.Header().Set(cacheStatusHeader, cacheStatus)
+	w.WriteHeader(e.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.Body)
+	}
+}
+
+// revalidate refreshes a stale entry in background.
+func (h *cacheHandler) revalidate(next http.Handler, r *http.Request, key string, vk string) {
+	revalidateKey := key + "\n" + vk
+	if _, loaded := h.revalidating.LoadOrStore(revalidateKey, struct{}{}); loaded {
+		// Already revalidating
+		return
+	}
+
+	req := r.Clone(context.WithoutCancel(r.Context()))
+	go func() {
+		defer h.revalidating.Delete(revalidateKey)
+
+		rec := &bufferedResponseWriter{
+			header:  make(http.Header),
+			status:  http.StatusOK,
+			maxSize: h.meta.MaxEntrySize,
+		}
+		next.ServeHTTP(rec, req)
+		if !rec.overflow {
+			h.storeResponse(req.Context(), key, req, rec.status, rec.header, rec.body.Bytes())
+		}
+	}()
+}
+
+// variantKey returns the key for the variant of a response that varies on request headers.
+func variantKey(key string, vary []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, h := range vary {
+		b.WriteString("\n")
+		b.WriteString(h)
+		b.WriteString(":")
+		b.WriteString(strings.Join(header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// parseVary returns the sorted list of canonical header names from the Vary header.
+func parseVary(values []string) []string {
+	res := []string{}
+	for _, v := range values {
+		for _, h := range strings.Split(v, ",") {
+			h = strings.TrimSpace(h)
+			if h == "*" {
+				return []string{"*"}
+			}
+			if h != "" {
+				res = append(res, http.CanonicalHeaderKey(h))
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// cacheControl contains the parsed directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(values []string) cacheControl {
+	cc := cacheControl{}
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(val, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) duration(directive string) (time.Duration, bool) {
+	val, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	secs, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// captureResponseWriter is a http.ResponseWriter that sends the response to the client while capturing it for the cache.
+type captureResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	maxSize  int
+	overflow bool
+}
+
+func (c *captureResponseWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureResponseWriter) Write(data []byte) (int, error) {
+	if !c.overflow {
+		if c.body.Len()+len(data) > c.maxSize {
+			c.overflow = true
+			c.body.Reset()
+		} else {
+			c.body.Write(data)
+		}
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// bufferedResponseWriter is a http.ResponseWriter that buffers the response.
+// Responses larger than maxSize are discarded, and overflow is set.
+type bufferedResponseWriter struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	maxSize  int
+	overflow bool
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if !b.overflow {
+		if b.body.Len()+len(data) > b.maxSize {
+			b.overflow = true
+			b.body.Reset()
+		} else {
+			b.body.Write(data)
+		}
+	}
+	return len(data), nil
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := cacheMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+type testClock struct {
+	lock sync.Mutex
+	t    time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.t
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestHandler(t *testing.T, props map[string]string, clock *testClock, next http.HandlerFunc) http.Handler {
+	t.Helper()
+	m := NewCacheMiddleware(logger.NewLogger("test")).(*Middleware)
+	m.now = clock.Now
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	handler, err := m.GetHandler(ctx, middleware.Metadata{Base: metadata.Base{
+		Properties: props,
+	}})
+	require.NoError(t, err)
+	return handler(next)
+}
+
+func doRequest(h http.Handler, method string, target string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	var calls atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"ttl": "10s",
+	}, clock, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/nostore":
+			w.Header().Set("cache-control", "no-store")
+		case "/maxage":
+			w.Header().Set("cache-control", "max-age=60")
+		case "/vary":
+			w.Header().Set("vary", "Accept-Language")
+			w.Header().Set("content-language", r.Header.Get("accept-language"))
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte("response " + strconv.Itoa(int(n))))
+	})
+
+	t.Run("cache hit", func(t *testing.T) {
+		calls.Store(0)
+		w := doRequest(h, http.MethodGet, "/foo?a=1", nil)
+		assert.Equal(t, "response 1", w.Body.String())
+		assert.Equal(t, cacheStatusMiss, w.Header().Get(cacheStatusHeader))
+
+		clock.Advance(5 * time.Second)
+		w = doRequest(h, http.MethodGet, "/foo?a=1", nil)
+		assert.Equal(t, "response 1", w.Body.String())
+		assert.Equal(t, cacheStatusHit, w.Header().Get(cacheStatusHeader))
+		assert.Equal(t, "5", w.Header().Get("age"))
+
+		// Different query string
+		w = doRequest(h, http.MethodGet, "/foo?a=2", nil)
+		assert.Equal(t, "response 2", w.Body.String())
+
+		// Expired
+		clock.Advance(6 * time.Second)
+		w = doRequest(h, http.MethodGet, "/foo?a=1", nil)
+		assert.Equal(t, "response 3", w.Body.String())
+		assert.Equal(t, cacheStatusMiss, w.Header().Get(cacheStatusHeader))
+	})
+
+	t.Run("non-cacheable requests and responses", func(t *testing.T) {
+		calls.Store(0)
+		doRequest(h, http.MethodPost, "/post", nil)
+		doRequest(h, http.MethodPost, "/post", nil)
+		doRequest(h, http.MethodGet, "/nostore", nil)
+		doRequest(h, http.MethodGet, "/nostore", nil)
+		doRequest(h, http.MethodGet, "/error", nil)
+		doRequest(h, http.MethodGet, "/error", nil)
+		doRequest(h, http.MethodGet, "/auth", map[string]string{"authorization": "Bearer foo"})
+		doRequest(h, http.MethodGet, "/auth", map[string]string{"authorization": "Bearer foo"})
+		assert.Equal(t, int32(8), calls.Load())
+
+		// Request with no-cache bypasses the cache
+		doRequest(h, http.MethodGet, "/bypass", nil)
+		doRequest(h, http.MethodGet, "/bypass", map[string]string{"cache-control": "no-cache"})
+		assert.Equal(t, int32(10), calls.Load())
+	})
+
+	t.Run("max-age", func(t *testing.T) {
+		calls.Store(0)
+		doRequest(h, http.MethodGet, "/maxage", nil)
+		clock.Advance(30 * time.Second)
+		w := doRequest(h, http.MethodGet, "/maxage", nil)
+		assert.Equal(t, cacheStatusHit, w.Header().Get(cacheStatusHeader))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		calls.Store(0)
+		w := doRequest(h, http.MethodGet, "/vary", map[string]string{"accept-language": "en"})
+		assert.Equal(t, "response 1", w.Body.String())
+		w = doRequest(h, http.MethodGet, "/vary", map[string]string{"accept-language": "it"})
+		assert.Equal(t, "response 2", w.Body.String())
+		w = doRequest(h, http.MethodGet, "/vary", map[string]string{"accept-language": "en"})
+		assert.Equal(t, "response 1", w.Body.String())
+		assert.Equal(t, "en", w.Header().Get("content-language"))
+		w = doRequest(h, http.MethodGet, "/vary", map[string]string{"accept-language": "it"})
+		assert.Equal(t, "response 2", w.Body.String())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	var calls atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"ttl":                  "10s",
+		"staleWhileRevalidate": "1m",
+	}, clock, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		_, _ = w.Write([]byte("response " + strconv.Itoa(int(n))))
+	})
+
+	w := doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, "response 1", w.Body.String())
+
+	// Stale response is served and refreshed in background
+	clock.Advance(20 * time.Second)
+	w = doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, "response 1", w.Body.String())
+	assert.Equal(t, cacheStatusStale, w.Header().Get(cacheStatusHeader))
+
+	assert.Eventually(t, func() bool {
+		w = doRequest(h, http.MethodGet, "/foo", nil)
+		return w.Body.String() == "response 2" && w.Header().Get(cacheStatusHeader) == cacheStatusHit
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRevalidateMaxEntrySize(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	var calls atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"ttl":                  "10s",
+		"staleWhileRevalidate": "1m",
+		"maxEntrySize":         "16",
+	}, clock, func(w http.ResponseWriter, r *http.Request) {
+		// The response grows beyond the maximum entry size after the first one
+		if calls.Add(1) == 1 {
+			_, _ = w.Write([]byte("small"))
+			return
+		}
+		for range 4 {
+			_, _ = w.Write([]byte("0123456789"))
+		}
+	})
+
+	w := doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, "small", w.Body.String())
+
+	// The oversized response from the revalidation is not stored
+	clock.Advance(20 * time.Second)
+	w = doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, cacheStatusStale, w.Header().Get(cacheStatusHeader))
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		w = doRequest(h, http.MethodGet, "/foo", nil)
+		return w.Header().Get(cacheStatusHeader) == cacheStatusStale
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "small", w.Body.String())
+
+	// Once the entry expires, the oversized response is passed through uncached
+	clock.Advance(time.Minute)
+	w = doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, cacheStatusMiss, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, 40, w.Body.Len())
+	w = doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, cacheStatusMiss, w.Header().Get(cacheStatusHeader))
+}
+
+func TestBufferedResponseWriter(t *testing.T) {
+	rec := &bufferedResponseWriter{header: make(http.Header), maxSize: 8}
+	n, err := rec.Write([]byte("12345"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.False(t, rec.overflow)
+
+	n, err = rec.Write([]byte("6789"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.True(t, rec.overflow)
+	assert.Zero(t, rec.body.Len())
+
+	_, _ = rec.Write([]byte("0"))
+	assert.Zero(t, rec.body.Len())
+}
+
+func TestKeyTemplate(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	var calls atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"keyTemplate": `{{.Path}}|{{.Header "X-Tenant"}}`,
+	}, clock, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+
+	doRequest(h, http.MethodGet, "/foo?a=1", map[string]string{"x-tenant": "a"})
+	doRequest(h, http.MethodGet, "/foo?a=2", map[string]string{"x-tenant": "a"})
+	doRequest(h, http.MethodGet, "/foo", map[string]string{"x-tenant": "b"})
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRedisStore(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	clock := &testClock{t: time.Now()}
+	var calls atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"storeType": "redis",
+		"redisHost": s.Addr(),
+		"ttl":       "10s",
+	}, clock, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("content-type", "text/plain")
+		_, _ = w.Write([]byte("response " + strconv.Itoa(int(n))))
+	})
+
+	doRequest(h, http.MethodGet, "/foo", nil)
+	w := doRequest(h, http.MethodGet, "/foo", nil)
+	assert.Equal(t, "response 1", w.Body.String())
+	assert.Equal(t, "text/plain", w.Header().Get("content-type"))
+	assert.Equal(t, cacheStatusHit, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, int32(1), calls.Load())
+
+	ttl := s.TTL(defaultKeyPrefix + "GET example.com/foo?")
+	assert.Equal(t, 10*time.Second, ttl)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl([]string{`public, max-age=30`, `stale-while-revalidate="60", No-Cache`})
+	assert.True(t, cc.has("public"))
+	assert.True(t, cc.has("no-cache"))
+	v, ok := cc.duration("max-age")
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, v)
+	v, ok = cc.duration("stale-while-revalidate")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, v)
+	_, ok = cc.duration("s-maxage")
+	assert.False(t, ok)
+}
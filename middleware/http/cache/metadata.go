@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	storeTypeMemory = "memory"
+	storeTypeRedis  = "redis"
+
+	defaultTTL          = time.Minute
+	defaultMaxEntries   = 1000
+	defaultMaxEntrySize = 1 << 20
+	defaultKeyTemplate  = "{{.Method}} {{.Host}}{{.Path}}?{{.Query}}"
+	defaultKeyPrefix    = "dapr-http-cache||"
+)
+
+type cacheMiddlewareMetadata struct {
+	// Type of store for the cached responses: "memory" or "redis".
+	// When using "redis", the connection properties of the Redis state store are supported too.
+	StoreType string `json:"storeType" mapstructure:"storeType"`
+	// Default time-to-live for responses that do not contain freshness information in their headers.
+	TTL time.Duration `json:"ttl" mapstructure:"ttl"`
+	// Default period during which stale responses are served while they are refreshed in background.
+	StaleWhileRevalidate time.Duration `json:"staleWhileRevalidate" mapstructure:"staleWhileRevalidate"`
+	// Template for the cache key, using Go template syntax.
+	// Available fields are .Method, .Host, .Path, .Query, and .Header "name".
+	KeyTemplate string `json:"keyTemplate" mapstructure:"keyTemplate"`
+	// Maximum number of entries in the in-memory store.
+	MaxEntries int `json:"maxEntries" mapstructure:"maxEntries"`
+	// Maximum size of the body of responses that are cached, in bytes.
+	MaxEntrySize int `json:"maxEntrySize" mapstructure:"maxEntrySize"`
+	// Prefix for keys stored in Redis.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+
+	// Internal properties
+	keyTemplate *template.Template `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *cacheMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.StoreType = storeTypeMemory
+	md.TTL = defaultTTL
+	md.KeyTemplate = defaultKeyTemplate
+	md.MaxEntries = defaultMaxEntries
+	md.MaxEntrySize = defaultMaxEntrySize
+	md.KeyPrefix = defaultKeyPrefix
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	md.StoreType = strings.ToLower(md.StoreType)
+	if md.StoreType != storeTypeMemory && md.StoreType != storeTypeRedis {
+		return fmt.Errorf("invalid value for metadata property 'storeType': %s", md.StoreType)
+	}
+	if md.TTL <= 0 {
+		return errors.New("metadata property 'ttl' must be greater than 0")
+	}
+	if md.StaleWhileRevalidate < 0 {
+		return errors.New("metadata property 'staleWhileRevalidate' must not be negative")
+	}
+	if md.MaxEntries <= 0 {
+		return errors.New("metadata property 'maxEntries' must be greater than 0")
+	}
+	if md.MaxEntrySize <= 0 {
+		return errors.New("metadata property 'maxEntrySize' must be greater than 0")
+	}
+
+	md.keyTemplate, err = template.New("key").Parse(md.KeyTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata property 'keyTemplate': %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+)
+
+// store is the interface for the stores of cached responses.
+type store interface {
+	// Get returns the value for the key, or nil if it doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value for the key, which expires after the TTL.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// memoryStore is a store that keeps entries in memory, evicting the least recently used ones.
+type memoryStore struct {
+	cache *lru.Cache[string, memoryItem]
+	now   func() time.Time
+}
+
+func newMemoryStore(maxEntries int, now func() time.Time) (*memoryStore, error) {
+	cache, err := lru.New[string, memoryItem](maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryStore{
+		cache: cache,
+		now:   now,
+	}, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	item, ok := s.cache.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	if !s.now().Before(item.expires) {
+		s.cache.Remove(key)
+		return nil, nil
+	}
+	return item.value, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.cache.Add(key, memoryItem{
+		value:   value,
+		expires: s.now().Add(ttl),
+	})
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	s.cache.Purge()
+	return nil
+}
+
+// redisStore is a store that keeps entries in Redis.
+type redisStore struct {
+	client    rediscomponent.RedisClient
+	keyPrefix string
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.client.Get(ctx, s.keyPrefix+key)
+	if err != nil {
+		if errors.Is(err, s.client.GetNilValueError()) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []byte(res), nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return s.client.DoWrite(ctx, "SET", s.keyPrefix+key, value, "PX", ms)
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contextheaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// NewContextHeadersMiddleware returns a new context headers middleware.
+func NewContextHeadersMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that injects context headers, such as correlation and tenant IDs, into requests, and echoes them on responses.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// templateData is the data passed to the templates for headers.
+type templateData struct {
+	Method        string
+	Host          string
+	Path          string
+	Query         string
+	CorrelationID string
+	TenantID      string
+	Claims        map[string]any
+
+	header http.Header
+}
+
+// Header returns the value of a request header, for use in the template as `{{.Header "name"}}`.
+func (d templateData) Header(name string) string {
+	return strings.Join(d.header.Values(name), ",")
+}
+
+// Claim returns the value of a JWT claim as string, for use in the template as `{{.Claim "name"}}`.
+// Nested claims can be selected with a dot-separated path.
+func (d templateData) Claim(path string) string {
+	return claimValue(d.Claims, path)
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &contextHeadersMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			injected := make(http.Header, len(meta.headers)+2)
+
+			// Correlation ID is propagated if already present
+			var correlationID string
+			if meta.CorrelationIDHeader != "" {
+				correlationID = r.Header.Get(meta.CorrelationIDHeader)
+				if correlationID == "" && meta.GenerateCorrelationID {
+					correlationID = uuid.NewString()
+				}
+				if correlationID != "" {
+					injected.Set(meta.CorrelationIDHeader, correlationID)
+				}
+			}
+
+			claims, err := tokenClaims(r.Header.Get(meta.TokenHeader))
+			if err != nil {
+				m.logger.Debugf("Failed to read claims from token: %v", err)
+			}
+
+			// Tenant ID is always derived from the token, so the value sent by the client is not trusted
+			var tenantID string
+			if meta.TenantIDClaim != "" {
+				tenantID = claimValue(claims, meta.TenantIDClaim)
+				r.Header.Del(meta.TenantIDHeader)
+				if tenantID != "" {
+					injected.Set(meta.TenantIDHeader, tenantID)
+				}
+			}
+
+			data := templateData{
+				Method:        r.Method,
+				Host:          r.Host,
+				Path:          r.URL.Path,
+				Query:         r.URL.RawQuery,
+				CorrelationID: correlationID,
+				TenantID:      tenantID,
+				Claims:        claims,
+				header:        r.Header,
+			}
+			for name, tpl := range meta.headers {
+				buf := &bytes.Buffer{}
+				err = tpl.Execute(buf, data)
+				if err != nil {
+					m.logger.Warnf("Failed to execute template for header '%s': %v", name, err)
+					r.Header.Del(name)
+					continue
+				}
+				if buf.Len() == 0 {
+					r.Header.Del(name)
+					continue
+				}
+				injected.Set(name, buf.String())
+			}
+
+			for name, vals := range injected {
+				r.Header[name] = vals
+				if meta.EchoHeaders {
+					w.Header()[name] = vals
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// tokenClaims returns the claims in the payload of a JWT.
+// The token is not validated: this middleware must be placed after one that verifies it, such as the "jwt" or "bearer" middlewares.
+func tokenClaims(token string) (map[string]any, error) {
+	if token == "" {
+		return nil, nil
+	}
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid token format")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	claims := map[string]any{}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	return claims, nil
+}
+
+// claimValue returns the value of a claim as string, selecting nested claims with a dot-separated path.
+func claimValue(claims map[string]any, path string) string {
+	var val any = claims
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := val.(map[string]any)
+		if !ok {
+			return ""
+		}
+		val, ok = obj[key]
+		if !ok {
+			return ""
+		}
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		enc, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(enc)
+	}
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := contextHeadersMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contextheaders
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*contextHeadersMiddlewareMetadata, error) {
+		obj := &contextHeadersMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultCorrelationIDHeader, md.CorrelationIDHeader)
+		assert.True(t, md.GenerateCorrelationID)
+		assert.Equal(t, defaultTenantIDHeader, md.TenantIDHeader)
+		assert.True(t, md.EchoHeaders)
+		assert.Empty(t, md.headers)
+	})
+
+	t.Run("header templates", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"headers": `{"x-user": "{{.Claim \"sub\"}}", "X-Route": "{{.Method}} {{.Path}}"}`,
+		})
+		require.NoError(t, err)
+		assert.Len(t, md.headers, 2)
+		assert.Contains(t, md.headers, "X-User")
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"headers": `{"X-User": "{{.Claim"}`,
+		})
+		require.ErrorContains(t, err, "X-User")
+	})
+}
+
+func TestContextHeadersMiddleware(t *testing.T) {
+	newHandler := func(props map[string]string, received *http.Header) http.Handler {
+		handler, err := NewContextHeadersMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*received = r.Header.Clone()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	token := "eyJhbGciOiJub25lIn0." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user1","tid":"tenant1","org":{"id":42}}`)) +
+		".sig"
+
+	t.Run("generates correlation ID", func(t *testing.T) {
+		var received http.Header
+		h := newHandler(map[string]string{}, &received)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		id := received.Get("X-Correlation-ID")
+		assert.NotEmpty(t, id)
+		assert.Equal(t, id, w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("propagates correlation ID", func(t *testing.T) {
+		var received http.Header
+		h := newHandler(map[string]string{}, &received)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Correlation-ID", "abc")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "abc", received.Get("X-Correlation-ID"))
+		assert.Equal(t, "abc", w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("tenant ID from claim", func(t *testing.T) {
+		var received http.Header
+		h := newHandler(map[string]string{"tenantIDClaim": "tid"}, &received)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("X-Tenant-ID", "spoofed")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, "tenant1", received.Get("X-Tenant-ID"))
+	})
+
+	t.Run("tenant ID removed without token", func(t *testing.T) {
+		var received http.Header
+		h := newHandler(map[string]string{"tenantIDClaim": "tid"}, &received)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Tenant-ID", "spoofed")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Empty(t, received.Get("X-Tenant-ID"))
+	})
+
+	t.Run("header templates", func(t *testing.T) {
+		var received http.Header
+		h := newHandler(map[string]string{
+			"headers": `
+X-User: '{{.Claim "sub"}}'
+X-Org: '{{.Claim "org.id"}}'
+X-Route: '{{.Method}} {{.Path}}'
+X-Missing: '{{.Claim "missing"}}'
+`,
+			"echoHeaders": "false",
+		}, &received)
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("X-Missing", "client")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "user1", received.Get("X-User"))
+		assert.Equal(t, "42", received.Get("X-Org"))
+		assert.Equal(t, "POST /orders", received.Get("X-Route"))
+		assert.Empty(t, received.Get("X-Missing"))
+		assert.Empty(t, w.Header().Get("X-User"))
+	})
+}
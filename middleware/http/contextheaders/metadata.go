@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contextheaders
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+// Defaults are in canonical form, as header names are canonicalized when parsed.
+const (
+	defaultCorrelationIDHeader = "X-Correlation-Id"
+	defaultTenantIDHeader      = "X-Tenant-Id"
+	defaultTokenHeader         = "Authorization"
+)
+
+type contextHeadersMiddlewareMetadata struct {
+	// Name of the header containing the correlation ID.
+	CorrelationIDHeader string `json:"correlationIDHeader" mapstructure:"correlationIDHeader"`
+	// If true, a correlation ID is generated for requests that do not have one.
+	GenerateCorrelationID bool `json:"generateCorrelationID" mapstructure:"generateCorrelationID"`
+	// Name of the header containing the tenant ID.
+	TenantIDHeader string `json:"tenantIDHeader" mapstructure:"tenantIDHeader"`
+	// Name of the JWT claim containing the tenant ID. Nested claims can be selected with a dot-separated path.
+	// If empty, the tenant ID header is not injected.
+	TenantIDClaim string `json:"tenantIDClaim" mapstructure:"tenantIDClaim"`
+	// Map of header names to Go templates, as a JSON or YAML-encoded string.
+	Headers string `json:"headers" mapstructure:"headers"`
+	// Name of the header containing the JWT.
+	TokenHeader string `json:"tokenHeader" mapstructure:"tokenHeader"`
+	// If true, the injected headers are also set on the response.
+	EchoHeaders bool `json:"echoHeaders" mapstructure:"echoHeaders"`
+
+	// Internal properties
+	headers map[string]*template.Template `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *contextHeadersMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.CorrelationIDHeader = defaultCorrelationIDHeader
+	md.GenerateCorrelationID = true
+	md.TenantIDHeader = defaultTenantIDHeader
+	md.TokenHeader = defaultTokenHeader
+	md.EchoHeaders = true
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	md.CorrelationIDHeader = http.CanonicalHeaderKey(md.CorrelationIDHeader)
+	md.TenantIDHeader = http.CanonicalHeaderKey(md.TenantIDHeader)
+	if md.GenerateCorrelationID && md.CorrelationIDHeader == "" {
+		return errors.New("metadata property 'correlationIDHeader' must not be empty when 'generateCorrelationID' is true")
+	}
+	if md.TenantIDClaim != "" && md.TenantIDHeader == "" {
+		return errors.New("metadata property 'tenantIDHeader' must not be empty when 'tenantIDClaim' is set")
+	}
+	if md.TokenHeader == "" {
+		return errors.New("metadata property 'tokenHeader' must not be empty")
+	}
+
+	md.headers, err = parseHeaderTemplates(md.Headers)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseHeaderTemplates parses the map of header names to templates.
+func parseHeaderTemplates(val string) (map[string]*template.Template, error) {
+	res := map[string]*template.Template{}
+	if val == "" {
+		return res, nil
+	}
+
+	parsed := map[string]string{}
+	err := yaml.Unmarshal([]byte(val), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metadata property 'headers' as JSON or YAML: %w", err)
+	}
+	for key, tpl := range parsed {
+		if key == "" || tpl == "" {
+			return nil, errors.New("invalid key/value pair in metadata property 'headers': must not be empty")
+		}
+		key = http.CanonicalHeaderKey(key)
+		res[key], err = template.New(key).
+			Funcs(template.FuncMap{"uuid": uuid.NewString}).
+			Option("missingkey=zero").
+			Parse(tpl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for header '%s' in metadata property 'headers': %w", key, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cors
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// NewCORSMiddleware returns a new CORS middleware.
+func NewCORSMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that implements Cross-Origin Resource Sharing (CORS).
+type Middleware struct {
+	logger logger.Logger
+}
+
+// policy is a CORS policy for a path.
+type policy struct {
+	path             string
+	origins          []string
+	allowAllOrigins  bool
+	methods          []string
+	headers          []string
+	allowAllHeaders  bool
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAge           time.Duration
+}
+
+func newPolicy(path string, origins, methods, headers, exposedHeaders []string, allowCredentials bool, maxAge time.Duration) *policy {
+	p := &policy{
+		path:             path,
+		origins:          origins,
+		allowAllOrigins:  slices.Contains(origins, "*"),
+		methods:          make([]string, len(methods)),
+		headers:          canonicalHeaders(headers),
+		allowAllHeaders:  slices.Contains(headers, "*"),
+		exposedHeaders:   canonicalHeaders(exposedHeaders),
+		allowCredentials: allowCredentials,
+		maxAge:           maxAge,
+	}
+	for i, m := range methods {
+		p.methods[i] = strings.ToUpper(m)
+	}
+	return p
+}
+
+// matches returns true if the policy applies to the path.
+func (p *policy) matches(path string) bool {
+	if prefix, ok := strings.CutSuffix(p.path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == p.path
+}
+
+// isOriginAllowed returns true if the origin is allowed by the policy.
+// Origins can contain a wildcard, such as "https://*.example.com", to match subdomains.
+func (p *policy) isOriginAllowed(origin string) bool {
+	if p.allowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range p.origins {
+		o = strings.ToLower(o)
+		if before, after, ok := strings.Cut(o, "*"); ok {
+			if len(origin) > len(before)+len(after) && strings.HasPrefix(origin, before) && strings.HasSuffix(origin, after) {
+				return true
+			}
+		} else if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// areHeadersAllowed returns true if all headers in the comma-separated list are allowed by the policy.
+func (p *policy) areHeadersAllowed(list string) bool {
+	if p.allowAllHeaders {
+		return true
+	}
+	for _, h := range splitList(list) {
+		if !slices.Contains(p.headers, http.CanonicalHeaderKey(h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &corsMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				// Not a CORS request
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p := meta.policyFor(r.URL.Path)
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			reqMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method == http.MethodOptions && reqMethod != "" {
+				m.handlePreflight(w, r, p, origin, reqMethod)
+				return
+			}
+
+			if p.isOriginAllowed(origin) {
+				setAllowOrigin(h, p, origin)
+				if len(p.exposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(p.exposedHeaders, ", "))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// handlePreflight responds to a preflight request.
+func (m *Middleware) handlePreflight(w http.ResponseWriter, r *http.Request, p *policy, origin string, reqMethod string) {
+	h := w.Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	reqHeaders := strings.Join(r.Header.Values("Access-Control-Request-Headers"), ",")
+	if !p.isOriginAllowed(origin) ||
+		!slices.Contains(p.methods, strings.ToUpper(reqMethod)) ||
+		!p.areHeadersAllowed(reqHeaders) {
+		m.logger.Debugf("Denied CORS preflight request from origin '%s' for %s %s", origin, reqMethod, r.URL.Path)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	setAllowOrigin(h, p, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(p.methods, ", "))
+	if reqHeaders != "" {
+		// Reflect the requested headers, which were validated above
+		h.Set("Access-Control-Allow-Headers", reqHeaders)
+	}
+	if p.maxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.FormatInt(int64(p.maxAge/time.Second), 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setAllowOrigin sets the headers for an allowed origin.
+// Credentials are never allowed together with all origins, which is rejected when the metadata is parsed.
+func setAllowOrigin(h http.Header, p *policy, origin string) {
+	if p.allowAllOrigins {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := corsMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*corsMiddlewareMetadata, error) {
+		obj := &corsMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("default policy", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"allowedOrigins": "https://example.com, https://*.example.org",
+			"allowedHeaders": "content-type",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com", "https://*.example.org"}, md.defaultPolicy.origins)
+		assert.Equal(t, []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}, md.defaultPolicy.methods)
+		assert.Equal(t, []string{"Content-Type"}, md.defaultPolicy.headers)
+		assert.Equal(t, defaultMaxAge, md.defaultPolicy.maxAge)
+	})
+
+	t.Run("policies inherit from default", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"allowedOrigins": "https://example.com",
+			"policies": `
+- path: /v1.0/invoke/public/*
+  allowedOrigins: ["*"]
+  maxAge: 1h
+`,
+		})
+		require.NoError(t, err)
+		require.Len(t, md.policies, 1)
+		p := md.policyFor("/v1.0/invoke/public/method/hello")
+		assert.True(t, p.allowAllOrigins)
+		assert.Equal(t, time.Hour, p.maxAge)
+		assert.Equal(t, md.defaultPolicy.methods, p.methods)
+		assert.Same(t, md.defaultPolicy, md.policyFor("/v1.0/invoke/private/method/hello"))
+	})
+
+	t.Run("no origins", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{})
+		require.Error(t, err)
+	})
+
+	t.Run("credentials with all origins", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"allowedOrigins":   "https://example.com, *",
+			"allowCredentials": "true",
+		})
+		require.ErrorContains(t, err, "allowCredentials")
+
+		_, err = newMetadata(map[string]string{
+			"allowedOrigins": "*",
+			"policies":       `[{"path": "/private/*", "allowCredentials": true}]`,
+		})
+		require.ErrorContains(t, err, "allowCredentials")
+
+		_, err = newMetadata(map[string]string{
+			"allowedOrigins":   "https://example.com",
+			"allowCredentials": "true",
+			"policies":         `[{"path": "/public/*", "allowedOrigins": ["*"]}]`,
+		})
+		require.ErrorContains(t, err, "allowCredentials")
+
+		md, err := newMetadata(map[string]string{
+			"allowedOrigins": "*",
+			"policies":       `[{"path": "/private/*", "allowedOrigins": ["https://example.com"], "allowCredentials": true}]`,
+		})
+		require.NoError(t, err)
+		assert.True(t, md.policyFor("/private/orders").allowCredentials)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"policies": `[{"allowedOrigins": ["*"]}]`,
+		})
+		require.ErrorContains(t, err, "path")
+	})
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	newHandler := func(props map[string]string) http.Handler {
+		handler, err := NewCORSMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	do := func(h http.Handler, method string, path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	h := newHandler(map[string]string{
+		"allowedOrigins":   "https://example.com,https://*.example.org",
+		"allowedHeaders":   "Content-Type,Authorization",
+		"exposedHeaders":   "X-Request-ID",
+		"allowCredentials": "true",
+		"maxAge":           "5m",
+		"policies": `
+- path: /public/*
+  allowedOrigins: ["*"]
+  allowedMethods: [GET]
+  allowCredentials: false
+`,
+	})
+
+	t.Run("simple request from allowed origin", func(t *testing.T) {
+		res := do(h, http.MethodGet, "/orders", map[string]string{"Origin": "https://example.com"})
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Request-Id", res.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", res.Header().Get("Vary"))
+	})
+
+	t.Run("wildcard subdomain", func(t *testing.T) {
+		res := do(h, http.MethodGet, "/orders", map[string]string{"Origin": "https://app.example.org"})
+		assert.Equal(t, "https://app.example.org", res.Header().Get("Access-Control-Allow-Origin"))
+
+		res = do(h, http.MethodGet, "/orders", map[string]string{"Origin": "https://example.org"})
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("simple request from other origin", func(t *testing.T) {
+		res := do(h, http.MethodGet, "/orders", map[string]string{"Origin": "https://evil.com"})
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		res := do(h, http.MethodOptions, "/orders", map[string]string{
+			"Origin":                         "https://example.com",
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "content-type",
+		})
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST, PUT, PATCH, DELETE, HEAD", res.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type", res.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "300", res.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight with header not allowed", func(t *testing.T) {
+		res := do(h, http.MethodOptions, "/orders", map[string]string{
+			"Origin":                         "https://example.com",
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "X-Custom",
+		})
+		assert.Equal(t, http.StatusForbidden, res.Code)
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("per-path policy", func(t *testing.T) {
+		res := do(h, http.MethodGet, "/public/info", map[string]string{"Origin": "https://any.com"})
+		assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Credentials"))
+
+		res = do(h, http.MethodOptions, "/public/info", map[string]string{
+			"Origin":                        "https://any.com",
+			"Access-Control-Request-Method": "POST",
+		})
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("non-CORS request", func(t *testing.T) {
+		res := do(h, http.MethodOptions, "/orders", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Empty(t, res.Header().Get("Vary"))
+	})
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultAllowedMethods = "GET,POST,PUT,PATCH,DELETE,HEAD"
+	defaultMaxAge         = 10 * time.Minute
+)
+
+type corsMiddlewareMetadata struct {
+	// Comma-separated list of origins that are allowed. Use "*" to allow all origins, or a wildcard for subdomains, such as "https://*.example.com".
+	AllowedOrigins string `json:"allowedOrigins" mapstructure:"allowedOrigins"`
+	// Comma-separated list of methods that are allowed.
+	AllowedMethods string `json:"allowedMethods" mapstructure:"allowedMethods"`
+	// Comma-separated list of request headers that are allowed. Use "*" to allow all headers.
+	AllowedHeaders string `json:"allowedHeaders" mapstructure:"allowedHeaders"`
+	// Comma-separated list of response headers that are exposed to the client.
+	ExposedHeaders string `json:"exposedHeaders" mapstructure:"exposedHeaders"`
+	// If true, requests with credentials (cookies, authorization headers, or TLS client certificates) are allowed.
+	AllowCredentials bool `json:"allowCredentials" mapstructure:"allowCredentials"`
+	// Duration for which clients can cache the results of preflight requests.
+	MaxAge time.Duration `json:"maxAge" mapstructure:"maxAge"`
+	// List of policies for specific paths, as a JSON or YAML-encoded string.
+	// Properties that are not set in a policy are inherited from the default policy.
+	Policies string `json:"policies" mapstructure:"policies"`
+
+	// Internal properties
+	defaultPolicy *policy   `json:"-" mapstructure:"-"`
+	policies      []*policy `json:"-" mapstructure:"-"`
+}
+
+// policyJSON is a policy for a path, as set in the metadata.
+type policyJSON struct {
+	// Path to match. If it ends with "*", it matches all paths with that prefix.
+	Path             string   `json:"path" yaml:"path"`
+	AllowedOrigins   []string `json:"allowedOrigins" yaml:"allowedOrigins"`
+	AllowedMethods   []string `json:"allowedMethods" yaml:"allowedMethods"`
+	AllowedHeaders   []string `json:"allowedHeaders" yaml:"allowedHeaders"`
+	ExposedHeaders   []string `json:"exposedHeaders" yaml:"exposedHeaders"`
+	AllowCredentials *bool    `json:"allowCredentials" yaml:"allowCredentials"`
+	MaxAge           string   `json:"maxAge" yaml:"maxAge"`
+}
+
+// Parse the component's metadata into the object.
+func (md *corsMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.AllowedMethods = defaultAllowedMethods
+	md.MaxAge = defaultMaxAge
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.MaxAge < 0 {
+		return errors.New("metadata property 'maxAge' must not be negative")
+	}
+	err = validateCredentials(splitList(md.AllowedOrigins), md.AllowCredentials)
+	if err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	md.defaultPolicy = newPolicy("*",
+		splitList(md.AllowedOrigins),
+		splitList(md.AllowedMethods),
+		splitList(md.AllowedHeaders),
+		splitList(md.ExposedHeaders),
+		md.AllowCredentials,
+		md.MaxAge,
+	)
+
+	md.policies = []*policy{}
+	if md.Policies != "" {
+		parsed := []policyJSON{}
+		err = yaml.Unmarshal([]byte(md.Policies), &parsed)
+		if err != nil {
+			return fmt.Errorf("failed to decode metadata property 'policies' as JSON or YAML: %w", err)
+		}
+		for i, p := range parsed {
+			pol, err := p.toPolicy(md.defaultPolicy)
+			if err != nil {
+				return fmt.Errorf("invalid item %d in metadata property 'policies': %w", i, err)
+			}
+			md.policies = append(md.policies, pol)
+		}
+	}
+
+	if len(md.defaultPolicy.origins) == 0 && len(md.policies) == 0 {
+		return errors.New("at least one of the metadata properties 'allowedOrigins' and 'policies' is required")
+	}
+
+	return nil
+}
+
+// toPolicy returns the policy, inheriting the properties that are not set from the default policy.
+func (p policyJSON) toPolicy(def *policy) (*policy, error) {
+	if p.Path == "" {
+		return nil, errors.New("property 'path' is required")
+	}
+
+	maxAge := def.maxAge
+	if p.MaxAge != "" {
+		var err error
+		maxAge, err = time.ParseDuration(p.MaxAge)
+		if err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("invalid value for property 'maxAge': %s", p.MaxAge)
+		}
+	}
+	allowCredentials := def.allowCredentials
+	if p.AllowCredentials != nil {
+		allowCredentials = *p.AllowCredentials
+	}
+	origins := inherit(p.AllowedOrigins, def.origins)
+	err := validateCredentials(origins, allowCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPolicy(p.Path,
+		origins,
+		inherit(p.AllowedMethods, def.methods),
+		inherit(p.AllowedHeaders, def.headers),
+		inherit(p.ExposedHeaders, def.exposedHeaders),
+		allowCredentials,
+		maxAge,
+	), nil
+}
+
+// policyFor returns the first policy matching the path, or the default one.
+func (md *corsMiddlewareMetadata) policyFor(path string) *policy {
+	for _, p := range md.policies {
+		if p.matches(path) {
+			return p
+		}
+	}
+	return md.defaultPolicy
+}
+
+// validateCredentials returns an error if credentials are allowed for all origins.
+// Browsers don't accept "*" with credentials, and echoing back any origin instead would let every site send credentialed requests.
+func validateCredentials(origins []string, allowCredentials bool) error {
+	if !allowCredentials {
+		return nil
+	}
+	for _, o := range origins {
+		if o == "*" {
+			return errors.New("'allowCredentials' can't be true when 'allowedOrigins' contains \"*\": list the allowed origins explicitly")
+		}
+	}
+	return nil
+}
+
+func inherit(val []string, def []string) []string {
+	if val == nil {
+		return def
+	}
+	return val
+}
+
+func splitList(val string) []string {
+	res := []string{}
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func canonicalHeaders(headers []string) []string {
+	res := make([]string, len(headers))
+	for i, h := range headers {
+		res[i] = http.CanonicalHeaderKey(h)
+	}
+	return res
+}
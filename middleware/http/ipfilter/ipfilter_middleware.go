@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfilter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// NewIPFilterMiddleware returns a new IP filtering middleware.
+func NewIPFilterMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that allows or denies requests based on the IP of the client.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &ipFilterMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, ok := meta.clientIP(r)
+			if !ok || !meta.isAllowed(ip) {
+				m.logger.Debugf("Denied request from client '%s' (remote address '%s')", ip, r.RemoteAddr)
+				w.Header().Set("content-type", meta.DenyContentType)
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(meta.DenyBody))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// clientIP returns the IP of the client.
+// The forwarded header is walked from the right, skipping trusted proxies, and the first address that isn't trusted is the client's.
+func (md *ipFilterMiddlewareMetadata) clientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+
+	if !containsAddr(md.trustedProxies, ip) {
+		return ip, true
+	}
+
+	forwarded := r.Header.Values(md.ForwardedHeader)
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		parts := strings.Split(forwarded[i], ",")
+		for j := len(parts) - 1; j >= 0; j-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(parts[j]))
+			if err != nil {
+				// Invalid values in the header can't be trusted
+				return netip.Addr{}, false
+			}
+			ip = hop.Unmap()
+			if !containsAddr(md.trustedProxies, ip) {
+				return ip, true
+			}
+		}
+	}
+
+	// All hops are trusted proxies: use the left-most address
+	return ip, true
+}
+
+// isAllowed returns true if the IP is allowed.
+func (md *ipFilterMiddlewareMetadata) isAllowed(ip netip.Addr) bool {
+	if containsAddr(md.deny, ip) {
+		return false
+	}
+	return len(md.allow) == 0 || containsAddr(md.allow, ip)
+}
+
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := ipFilterMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfilter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*ipFilterMiddlewareMetadata, error) {
+		obj := &ipFilterMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("IPs and ranges", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"allow": "10.0.0.1, 192.168.1.7/16,2001:db8::/32",
+		})
+		require.NoError(t, err)
+		require.Len(t, md.allow, 3)
+		assert.Equal(t, "10.0.0.1/32", md.allow[0].String())
+		assert.Equal(t, "192.168.0.0/16", md.allow[1].String())
+		assert.Equal(t, "2001:db8::/32", md.allow[2].String())
+		assert.Equal(t, defaultForwardedHeader, md.ForwardedHeader)
+		assert.Equal(t, defaultDenyBody, md.DenyBody)
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"deny": "10.0.0.0/33",
+		})
+		require.ErrorContains(t, err, "deny")
+	})
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	newHandler := func(props map[string]string) http.Handler {
+		handler, err := NewIPFilterMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	do := func(h http.Handler, remoteAddr string, forwarded ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		for _, f := range forwarded {
+			r.Header.Add("X-Forwarded-For", f)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("allowlist", func(t *testing.T) {
+		h := newHandler(map[string]string{
+			"allow": "10.0.0.0/8,::1",
+			"deny":  "10.0.0.13",
+		})
+		assert.Equal(t, http.StatusNoContent, do(h, "10.1.2.3:1234").Code)
+		assert.Equal(t, http.StatusNoContent, do(h, "[::1]:1234").Code)
+		assert.Equal(t, http.StatusNoContent, do(h, "[::ffff:10.1.2.3]:1234").Code)
+		assert.Equal(t, http.StatusForbidden, do(h, "10.0.0.13:1234").Code)
+		assert.Equal(t, http.StatusForbidden, do(h, "192.168.0.1:1234").Code)
+		// Forwarded header is ignored when the request does not come from a trusted proxy
+		assert.Equal(t, http.StatusForbidden, do(h, "192.168.0.1:1234", "10.1.2.3").Code)
+	})
+
+	t.Run("denylist", func(t *testing.T) {
+		h := newHandler(map[string]string{
+			"deny":            "203.0.113.0/24",
+			"denyBody":        `{"error":"forbidden"}`,
+			"denyContentType": "application/json",
+		})
+		assert.Equal(t, http.StatusNoContent, do(h, "10.1.2.3:1234").Code)
+		w := do(h, "203.0.113.8:1234")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("content-type"))
+		assert.Equal(t, `{"error":"forbidden"}`, w.Body.String())
+	})
+
+	t.Run("trusted proxies", func(t *testing.T) {
+		h := newHandler(map[string]string{
+			"deny":           "203.0.113.0/24",
+			"trustedProxies": "10.0.0.0/8",
+		})
+		assert.Equal(t, http.StatusNoContent, do(h, "10.0.0.1:1234", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusForbidden, do(h, "10.0.0.1:1234", "203.0.113.8").Code)
+		// Chain of trusted proxies
+		assert.Equal(t, http.StatusForbidden, do(h, "10.0.0.1:1234", "203.0.113.8, 10.0.0.2").Code)
+		assert.Equal(t, http.StatusForbidden, do(h, "10.0.0.1:1234", "203.0.113.8", "10.0.0.2").Code)
+		// Addresses added by the client before an untrusted hop are ignored
+		assert.Equal(t, http.StatusNoContent, do(h, "10.0.0.1:1234", "203.0.113.8, 198.51.100.1").Code)
+		// Invalid header values
+		assert.Equal(t, http.StatusForbidden, do(h, "10.0.0.1:1234", "not-an-ip").Code)
+	})
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultDenyBody        = "Forbidden"
+	defaultDenyContentType = "text/plain; charset=utf-8"
+	defaultForwardedHeader = "X-Forwarded-For"
+)
+
+type ipFilterMiddlewareMetadata struct {
+	// Comma-separated list of IPs or CIDR ranges that are allowed. If empty, all clients that are not denied are allowed.
+	Allow string `json:"allow" mapstructure:"allow"`
+	// Comma-separated list of IPs or CIDR ranges that are denied. Denied ranges take precedence over allowed ones.
+	Deny string `json:"deny" mapstructure:"deny"`
+	// Comma-separated list of IPs or CIDR ranges of trusted proxies.
+	// The forwarded header is used only when the request comes from a trusted proxy.
+	TrustedProxies string `json:"trustedProxies" mapstructure:"trustedProxies"`
+	// Header containing the list of forwarded client addresses.
+	ForwardedHeader string `json:"forwardedHeader" mapstructure:"forwardedHeader"`
+	// Body of the response sent to denied clients.
+	DenyBody string `json:"denyBody" mapstructure:"denyBody"`
+	// Content type of the response sent to denied clients.
+	DenyContentType string `json:"denyContentType" mapstructure:"denyContentType"`
+
+	// Internal properties
+	allow          []netip.Prefix `json:"-" mapstructure:"-"`
+	deny           []netip.Prefix `json:"-" mapstructure:"-"`
+	trustedProxies []netip.Prefix `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *ipFilterMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.ForwardedHeader = defaultForwardedHeader
+	md.DenyBody = defaultDenyBody
+	md.DenyContentType = defaultDenyContentType
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	md.allow, err = parsePrefixes("allow", md.Allow)
+	if err != nil {
+		return err
+	}
+	md.deny, err = parsePrefixes("deny", md.Deny)
+	if err != nil {
+		return err
+	}
+	md.trustedProxies, err = parsePrefixes("trustedProxies", md.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if len(md.allow) == 0 && len(md.deny) == 0 {
+		return errors.New("at least one of the metadata properties 'allow' and 'deny' is required")
+	}
+	if md.ForwardedHeader == "" {
+		return errors.New("metadata property 'forwardedHeader' must not be empty")
+	}
+
+	return nil
+}
+
+// parsePrefixes parses a comma-separated list of IPs and CIDR ranges.
+func parsePrefixes(property string, val string) ([]netip.Prefix, error) {
+	res := []netip.Prefix{}
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		var (
+			prefix netip.Prefix
+			err    error
+		)
+		if strings.ContainsRune(v, '/') {
+			prefix, err = netip.ParsePrefix(v)
+			prefix = prefix.Masked()
+		} else {
+			var addr netip.Addr
+			addr, err = netip.ParseAddr(v)
+			if err == nil {
+				addr = addr.Unmap()
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' in metadata property '%s': %w", v, property, err)
+		}
+		res = append(res, prefix)
+	}
+	return res, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/lestrrat-go/httprc"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Prefix for the authorization header (case-insensitive)
+const bearerPrefix = "bearer "
+
+// NewJWTMiddleware returns a new JWT validation middleware.
+func NewJWTMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that validates JWTs using keys from one or more JWKS endpoints.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &jwtMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a JWKS cache that is refreshed automatically
+	cache := jwk.NewCache(ctx,
+		jwk.WithErrSink(httprc.ErrSinkFunc(func(err error) {
+			m.logger.Warnf("Error while refreshing JWKS cache: %v", err)
+		})),
+	)
+	for _, u := range meta.jwksURLs {
+		err = cache.Register(u,
+			jwk.WithRefreshInterval(meta.RefreshInterval),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register JWKS cache for '%s': %w", u, err)
+		}
+
+		// Fetch the JWKS right away to start, so we can check it's valid and populate the cache
+		_, err = cache.Refresh(ctx, u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch JWKS from '%s': %w", u, err)
+		}
+	}
+
+	parseOpts := []jwt.ParseOption{
+		jwt.WithAcceptableSkew(meta.ClockSkew),
+		jwt.WithValidate(true),
+	}
+	if meta.Issuer != "" {
+		parseOpts = append(parseOpts, jwt.WithIssuer(meta.Issuer))
+	}
+	if len(meta.audiences) > 0 {
+		parseOpts = append(parseOpts, jwt.WithValidator(audienceValidator(meta.audiences)))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rawToken, ok := getToken(r, meta.TokenHeader)
+			if !ok {
+				httputils.RespondWithError(w, http.StatusUnauthorized)
+				return
+			}
+
+			err := checkAlgorithm(rawToken, meta.allowedAlgs)
+			if err != nil {
+				m.logger.Debugf("Invalid token: %v", err)
+				httputils.RespondWithError(w, http.StatusUnauthorized)
+				return
+			}
+
+			keyset, err := getKeySet(r.Context(), cache, meta.jwksURLs)
+			if err != nil {
+				m.logger.Errorf("Failed to retrieve JWKS cache: %v", err)
+				httputils.RespondWithError(w, http.StatusInternalServerError)
+				return
+			}
+			keyset, err = filterKeySet(keyset, meta.allowedAlgs)
+			if err != nil {
+				m.logger.Errorf("Failed to filter JWKS: %v", err)
+				httputils.RespondWithError(w, http.StatusInternalServerError)
+				return
+			}
+
+			opts := append([]jwt.ParseOption{
+				jwt.WithContext(r.Context()),
+				jwt.WithKeySet(keyset, jws.WithInferAlgorithmFromKey(true)),
+			}, parseOpts...)
+			token, err := jwt.Parse([]byte(rawToken), opts...)
+			if err != nil {
+				m.logger.Debugf("Invalid token: %v", err)
+				httputils.RespondWithError(w, http.StatusUnauthorized)
+				return
+			}
+
+			// Remove any header that may have been set by the client before injecting claims
+			for claim, header := range meta.claimsHeaders {
+				r.Header.Del(header)
+				val, ok := token.Get(claim)
+				if !ok {
+					continue
+				}
+				str, err := claimToString(val)
+				if err != nil {
+					m.logger.Warnf("Failed to serialize claim '%s': %v", claim, err)
+					continue
+				}
+				r.Header.Set(header, str)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// getToken returns the raw token from the request.
+func getToken(r *http.Request, header string) (string, bool) {
+	val := strings.TrimSpace(r.Header.Get(header))
+	if strings.EqualFold(header, "authorization") {
+		var ok bool
+		val, ok = cutPrefixFold(val, bearerPrefix)
+		if !ok {
+			return "", false
+		}
+	}
+	val = strings.TrimSpace(val)
+	return val, val != ""
+}
+
+// cutPrefixFold is like strings.CutPrefix, but the prefix is matched case-insensitively.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// checkAlgorithm returns an error if the token is not signed with one of the allowed algorithms.
+// When inferring the algorithm from keys without an "alg", only the algorithm in the token's header is tried, so this check covers those keys too.
+func checkAlgorithm(rawToken string, allowed map[jwa.SignatureAlgorithm]struct{}) error {
+	msg, err := jws.Parse([]byte(rawToken))
+	if err != nil {
+		return err
+	}
+	if len(msg.Signatures()) == 0 {
+		return errors.New("token is not signed")
+	}
+	for _, sig := range msg.Signatures() {
+		alg := sig.ProtectedHeaders().Algorithm()
+		if _, ok := allowed[alg]; !ok {
+			return fmt.Errorf("signature algorithm '%s' is not allowed", alg)
+		}
+	}
+	return nil
+}
+
+// filterKeySet returns the keys of the set that can be used with the allowed algorithms.
+// Keys that declare an "alg" that isn't allowed are removed, and so are symmetric keys unless an HMAC algorithm is allowed.
+func filterKeySet(set jwk.Set, allowed map[jwa.SignatureAlgorithm]struct{}) (jwk.Set, error) {
+	allowSymmetric := false
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.HS256, jwa.HS384, jwa.HS512} {
+		if _, ok := allowed[alg]; ok {
+			allowSymmetric = true
+			break
+		}
+	}
+
+	filtered := jwk.NewSet()
+	for i := 0; i < set.Len(); i++ {
+		key, ok := set.Key(i)
+		if !ok {
+			continue
+		}
+		if key.KeyType() == jwa.OctetSeq && !allowSymmetric {
+			continue
+		}
+		if v := key.Algorithm().String(); v != "" {
+			if _, ok := allowed[jwa.SignatureAlgorithm(v)]; !ok {
+				continue
+			}
+		}
+		err := filtered.AddKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filtered, nil
+}
+
+// getKeySet returns the key set from the cache.
+// When there are multiple JWKS endpoints, the keys from all of them are merged.
+func getKeySet(ctx context.Context, cache *jwk.Cache, urls []string) (jwk.Set, error) {
+	if len(urls) == 1 {
+		return cache.Get(ctx, urls[0])
+	}
+
+	merged := jwk.NewSet()
+	for _, u := range urls {
+		set, err := cache.Get(ctx, u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get JWKS for '%s': %w", u, err)
+		}
+		for i := 0; i < set.Len(); i++ {
+			key, ok := set.Key(i)
+			if !ok {
+				continue
+			}
+			err = merged.AddKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add key from '%s': %w", u, err)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// audienceValidator returns a validator that checks that the token contains at least one of the allowed audiences.
+func audienceValidator(audiences []string) jwt.Validator {
+	return jwt.ValidatorFunc(func(_ context.Context, token jwt.Token) jwt.ValidationError {
+		for _, tokenAud := range token.Audience() {
+			for _, aud := range audiences {
+				if tokenAud == aud {
+					return nil
+				}
+			}
+		}
+		return jwt.ErrInvalidAudience()
+	})
+}
+
+// claimToString returns the value of a claim as a string; values that are not strings are JSON-encoded.
+func claimToString(val any) (string, error) {
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	enc, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(enc), nil
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := jwtMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
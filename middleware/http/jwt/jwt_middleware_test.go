@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*jwtMiddlewareMetadata, error) {
+		obj := &jwtMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("required fields only", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"jwksURLs": "http://localhost/jwks.json",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost/jwks.json"}, md.jwksURLs)
+		assert.Equal(t, defaultClockSkew, md.ClockSkew)
+		assert.Equal(t, defaultRefreshInterval, md.RefreshInterval)
+		assert.Equal(t, "Authorization", md.TokenHeader)
+		assert.Empty(t, md.audiences)
+		assert.Empty(t, md.claimsHeaders)
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"jwksURLs":        "http://localhost/a.json, http://localhost/b.json",
+			"issuer":          "http://localhost",
+			"audience":        "foo,bar",
+			"clockSkew":       "30s",
+			"refreshInterval": "1h",
+			"claimsToHeaders": "sub=x-user-id, email=X-User-Email",
+			"tokenHeader":     "X-Token",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost/a.json", "http://localhost/b.json"}, md.jwksURLs)
+		assert.Equal(t, []string{"foo", "bar"}, md.audiences)
+		assert.Equal(t, 30*time.Second, md.ClockSkew)
+		assert.Equal(t, time.Hour, md.RefreshInterval)
+		assert.Equal(t, "X-Token", md.TokenHeader)
+		assert.Equal(t, map[string]string{"sub": "X-User-Id", "email": "X-User-Email"}, md.claimsHeaders)
+	})
+
+	t.Run("default allowed algorithms are asymmetric", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"jwksURLs": "http://localhost/jwks.json",
+		})
+		require.NoError(t, err)
+		assert.Len(t, md.allowedAlgs, len(defaultAllowedAlgorithms))
+		assert.Contains(t, md.allowedAlgs, jwa.RS256)
+		assert.Contains(t, md.allowedAlgs, jwa.ES256)
+		assert.NotContains(t, md.allowedAlgs, jwa.HS256)
+		assert.NotContains(t, md.allowedAlgs, jwa.NoSignature)
+	})
+
+	t.Run("allowed algorithms", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"jwksURLs":          "http://localhost/jwks.json",
+			"allowedAlgorithms": "ES256, HS256",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[jwa.SignatureAlgorithm]struct{}{jwa.ES256: {}, jwa.HS256: {}}, md.allowedAlgs)
+	})
+
+	t.Run("invalid allowed algorithms", func(t *testing.T) {
+		for _, v := range []string{"none", "ES256,foo"} {
+			_, err := newMetadata(map[string]string{
+				"jwksURLs":          "http://localhost/jwks.json",
+				"allowedAlgorithms": v,
+			})
+			require.ErrorContains(t, err, "allowedAlgorithms", v)
+		}
+	})
+
+	t.Run("missing JWKS URLs", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{})
+		require.ErrorContains(t, err, "jwksURLs")
+	})
+
+	t.Run("refresh interval too short", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"jwksURLs":        "http://localhost/jwks.json",
+			"refreshInterval": "1s",
+		})
+		require.ErrorContains(t, err, "refreshInterval")
+	})
+
+	t.Run("invalid claims to headers", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"jwksURLs":        "http://localhost/jwks.json",
+			"claimsToHeaders": "sub",
+		})
+		require.ErrorContains(t, err, "claimsToHeaders")
+	})
+}
+
+func TestGetToken(t *testing.T) {
+	get := func(header, value string) (string, bool) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if value != "" {
+			r.Header.Set(header, value)
+		}
+		return getToken(r, header)
+	}
+
+	for value, expect := range map[string]string{
+		"Bearer abc":     "abc",
+		"bearer abc":     "abc",
+		"BEARER  abc ":   "abc",
+		"Bearer":         "",
+		"Bearer ":        "",
+		"Basic Zm9vOmJh": "",
+		"abc":            "",
+		"":               "",
+	} {
+		token, ok := get("Authorization", value)
+		assert.Equal(t, expect, token, value)
+		assert.Equal(t, expect != "", ok, value)
+	}
+
+	token, ok := get("X-Token", " abc ")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	newKey := func(kid string) (jwk.Key, jwk.Key) {
+		raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		priv, err := jwk.FromRaw(raw)
+		require.NoError(t, err)
+		require.NoError(t, priv.Set(jwk.KeyIDKey, kid))
+		require.NoError(t, priv.Set(jwk.AlgorithmKey, jwa.ES256))
+		pub, err := priv.PublicKey()
+		require.NoError(t, err)
+		return priv, pub
+	}
+	newJWKSServer := func(keys ...jwk.Key) *httptest.Server {
+		set := jwk.NewSet()
+		for _, k := range keys {
+			require.NoError(t, set.AddKey(k))
+		}
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			_ = json.NewEncoder(w).Encode(set)
+		}))
+	}
+
+	priv1, pub1 := newKey("key1")
+	priv2, pub2 := newKey("key2")
+	privUnknown, _ := newKey("key3")
+	srv1 := newJWKSServer(pub1)
+	defer srv1.Close()
+	srv2 := newJWKSServer(pub2)
+	defer srv2.Close()
+
+	// JWKS with a symmetric key, which is only accepted when an HMAC algorithm is allowed
+	hmacKey, err := jwk.FromRaw([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	require.NoError(t, hmacKey.Set(jwk.KeyIDKey, "hmac"))
+	srvHMAC := newJWKSServer(hmacKey)
+	defer srvHMAC.Close()
+
+	newToken := func(key jwk.Key, modify func(b *jwt.Builder)) string {
+		b := jwt.NewBuilder().
+			Issuer("http://localhost").
+			Audience([]string{"myapp"}).
+			Subject("user1").
+			IssuedAt(time.Now()).
+			Expiration(time.Now().Add(time.Hour)).
+			Claim("roles", []string{"admin"})
+		if modify != nil {
+			modify(b)
+		}
+		token, err := b.Build()
+		require.NoError(t, err)
+		alg := jwa.ES256
+		if key.KeyType() == jwa.OctetSeq {
+			alg = jwa.HS256
+		}
+		signed, err := jwt.Sign(token, jwt.WithKey(alg, key))
+		require.NoError(t, err)
+		return string(signed)
+	}
+
+	handler, err := NewJWTMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"jwksURLs":        srv1.URL + "," + srv2.URL + "," + srvHMAC.URL,
+			"issuer":          "http://localhost",
+			"audience":        "myapp,otherapp",
+			"claimsToHeaders": "sub=X-User-Id,roles=X-User-Roles",
+		},
+	}})
+	require.NoError(t, err)
+
+	var received http.Header
+	h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(authorization string, headers map[string]string) int {
+		received = nil
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("valid token from first key set", func(t *testing.T) {
+		code := do("Bearer "+newToken(priv1, nil), map[string]string{"X-User-Id": "spoofed"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "user1", received.Get("X-User-Id"))
+		assert.Equal(t, `["admin"]`, received.Get("X-User-Roles"))
+	})
+
+	t.Run("valid token from second key set", func(t *testing.T) {
+		code := do("bearer "+newToken(priv2, nil), nil)
+		require.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("claims headers are removed when claim is missing", func(t *testing.T) {
+		code := do("Bearer "+newToken(priv1, func(b *jwt.Builder) {
+			b.Subject("")
+		}), map[string]string{"X-User-Id": "spoofed"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, received.Get("X-User-Id"))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("", nil))
+		assert.Equal(t, http.StatusUnauthorized, do("Basic Zm9vOmJhcg==", nil))
+		assert.Nil(t, received)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+newToken(privUnknown, nil), nil))
+	})
+
+	t.Run("invalid audience", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+newToken(priv1, func(b *jwt.Builder) {
+			b.Audience([]string{"someone-else"})
+		}), nil))
+	})
+
+	t.Run("invalid issuer", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+newToken(priv1, func(b *jwt.Builder) {
+			b.Issuer("http://evil")
+		}), nil))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+newToken(priv1, func(b *jwt.Builder) {
+			b.Expiration(time.Now().Add(-10 * time.Minute))
+		}), nil))
+	})
+
+	t.Run("expired token within clock skew", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("Bearer "+newToken(priv1, func(b *jwt.Builder) {
+			b.Expiration(time.Now().Add(-time.Minute))
+		}), nil))
+	})
+
+	t.Run("unsigned token", func(t *testing.T) {
+		enc := base64.RawURLEncoding
+		token := enc.EncodeToString([]byte(`{"alg":"none","kid":"key1"}`)) + "." +
+			enc.EncodeToString([]byte(`{"iss":"http://localhost","aud":"myapp","sub":"user1"}`)) + "."
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+token, nil))
+	})
+
+	t.Run("HMAC is rejected by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("Bearer "+newToken(hmacKey, nil), nil))
+	})
+
+	t.Run("allowed algorithms", func(t *testing.T) {
+		handler, err := NewJWTMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"jwksURLs":          srv1.URL + "," + srvHMAC.URL,
+				"allowedAlgorithms": "HS256",
+			},
+		}})
+		require.NoError(t, err)
+		h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		for token, expect := range map[string]int{
+			newToken(hmacKey, nil): http.StatusOK,
+			newToken(priv1, nil):   http.StatusUnauthorized,
+		} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			assert.Equal(t, expect, w.Code)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultClockSkew       = 5 * time.Minute
+	defaultRefreshInterval = 15 * time.Minute
+	minRefreshInterval     = time.Minute
+)
+
+// By default, only tokens signed with asymmetric keys are accepted, as JWKS documents are public.
+var defaultAllowedAlgorithms = []jwa.SignatureAlgorithm{
+	jwa.RS256, jwa.RS384, jwa.RS512,
+	jwa.PS256, jwa.PS384, jwa.PS512,
+	jwa.ES256, jwa.ES384, jwa.ES512,
+	jwa.EdDSA,
+}
+
+type jwtMiddlewareMetadata struct {
+	// Comma-separated list of URLs of JWKS documents.
+	// Tokens are accepted if they are signed with a key from any of the sets.
+	JWKSURLs string `json:"jwksURLs" mapstructure:"jwksURLs" mapstructurealiases:"jwksURL"`
+	// Issuer to expect in the token. If empty, the issuer is not validated.
+	Issuer string `json:"issuer" mapstructure:"issuer"`
+	// Comma-separated list of audiences; the token must contain at least one of them. If empty, the audience is not validated.
+	Audience string `json:"audience" mapstructure:"audience"`
+	// Allowed clock skew when validating time-based claims.
+	ClockSkew time.Duration `json:"clockSkew" mapstructure:"clockSkew"`
+	// Interval for refreshing the JWKS documents in background.
+	RefreshInterval time.Duration `json:"refreshInterval" mapstructure:"refreshInterval"`
+	// Comma-separated list of claims to forward to the application as request headers, in the format "claim=Header-Name".
+	ClaimsToHeaders string `json:"claimsToHeaders" mapstructure:"claimsToHeaders"`
+	// Name of the header that contains the token.
+	// The value must use the "Bearer" scheme when the header is "Authorization".
+	TokenHeader string `json:"tokenHeader" mapstructure:"tokenHeader"`
+	// Comma-separated list of the signature algorithms that are accepted.
+	// If empty, all asymmetric algorithms are accepted. "none" is never accepted.
+	AllowedAlgorithms string `json:"allowedAlgorithms" mapstructure:"allowedAlgorithms"`
+
+	// Internal properties
+	jwksURLs      []string                            `json:"-" mapstructure:"-"`
+	allowedAlgs   map[jwa.SignatureAlgorithm]struct{} `json:"-" mapstructure:"-"`
+	audiences     []string                            `json:"-" mapstructure:"-"`
+	claimsHeaders map[string]string                   `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *jwtMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.ClockSkew = defaultClockSkew
+	md.RefreshInterval = defaultRefreshInterval
+	md.TokenHeader = "Authorization"
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	md.jwksURLs = splitList(md.JWKSURLs)
+	if len(md.jwksURLs) == 0 {
+		return errors.New("metadata property 'jwksURLs' is required")
+	}
+	if md.ClockSkew < 0 {
+		return errors.New("metadata property 'clockSkew' must not be negative")
+	}
+	if md.RefreshInterval < minRefreshInterval {
+		return fmt.Errorf("metadata property 'refreshInterval' must be at least %v", minRefreshInterval)
+	}
+	if md.TokenHeader == "" {
+		return errors.New("metadata property 'tokenHeader' must not be empty")
+	}
+	md.audiences = splitList(md.Audience)
+
+	md.allowedAlgs, err = parseAllowedAlgorithms(md.AllowedAlgorithms)
+	if err != nil {
+		return err
+	}
+
+	md.claimsHeaders = make(map[string]string)
+	for _, pair := range splitList(md.ClaimsToHeaders) {
+		claim, header, ok := strings.Cut(pair, "=")
+		claim = strings.TrimSpace(claim)
+		header = strings.TrimSpace(header)
+		if !ok || claim == "" || header == "" {
+			return fmt.Errorf("invalid value in metadata property 'claimsToHeaders': '%s' must be in the format 'claim=Header-Name'", pair)
+		}
+		md.claimsHeaders[claim] = http.CanonicalHeaderKey(header)
+	}
+
+	return nil
+}
+
+func parseAllowedAlgorithms(val string) (map[jwa.SignatureAlgorithm]struct{}, error) {
+	list := splitList(val)
+	res := make(map[jwa.SignatureAlgorithm]struct{}, len(list))
+	if len(list) == 0 {
+		for _, alg := range defaultAllowedAlgorithms {
+			res[alg] = struct{}{}
+		}
+		return res, nil
+	}
+
+	for _, v := range list {
+		var alg jwa.SignatureAlgorithm
+		err := alg.Accept(v)
+		if err != nil || alg == jwa.NoSignature {
+			return nil, fmt.Errorf("invalid value in metadata property 'allowedAlgorithms': '%s' is not a supported signature algorithm", v)
+		}
+		res[alg] = struct{}{}
+	}
+	return res, nil
+}
+
+func splitList(val string) []string {
+	res := []string{}
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
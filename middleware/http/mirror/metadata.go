@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultPercentage     = 100
+	defaultTagHeader      = "X-Dapr-Mirror"
+	defaultTagValue       = "true"
+	defaultTimeout        = 5 * time.Second
+	defaultMaxBodySize    = 1 << 20
+	defaultMaxConcurrency = 100
+)
+
+type mirrorMiddlewareMetadata struct {
+	// Base URL of the shadow service. The path and query string of the request are appended to it.
+	ShadowURL string `json:"shadowURL" mapstructure:"shadowURL"`
+	// Percentage of requests that are mirrored, between 0 and 100.
+	Percentage float64 `json:"percentage" mapstructure:"percentage"`
+	// Name of the header added to mirrored requests.
+	TagHeader string `json:"tagHeader" mapstructure:"tagHeader"`
+	// Value of the header added to mirrored requests.
+	TagValue string `json:"tagValue" mapstructure:"tagValue"`
+	// Timeout for mirrored requests.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+	// Maximum size of the body of requests that are mirrored, in bytes. Requests with larger bodies are not mirrored.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// Maximum number of mirrored requests in-flight at the same time. Additional requests are not mirrored.
+	MaxConcurrency int `json:"maxConcurrency" mapstructure:"maxConcurrency"`
+
+	// Internal properties
+	shadowURL *url.URL `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *mirrorMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.Percentage = defaultPercentage
+	md.TagHeader = defaultTagHeader
+	md.TagValue = defaultTagValue
+	md.Timeout = defaultTimeout
+	md.MaxBodySize = defaultMaxBodySize
+	md.MaxConcurrency = defaultMaxConcurrency
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.ShadowURL == "" {
+		return errors.New("metadata property 'shadowURL' is required")
+	}
+	md.shadowURL, err = url.Parse(md.ShadowURL)
+	if err != nil || (md.shadowURL.Scheme != "http" && md.shadowURL.Scheme != "https") || md.shadowURL.Host == "" {
+		return fmt.Errorf("invalid value for metadata property 'shadowURL': %s", md.ShadowURL)
+	}
+	if md.Percentage < 0 || md.Percentage > 100 {
+		return errors.New("metadata property 'percentage' must be between 0 and 100")
+	}
+	if md.Timeout <= 0 {
+		return errors.New("metadata property 'timeout' must be greater than 0")
+	}
+	if md.MaxBodySize < 0 {
+		return errors.New("metadata property 'maxBodySize' must not be negative")
+	}
+	if md.MaxConcurrency <= 0 {
+		return errors.New("metadata property 'maxConcurrency' must be greater than 0")
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"strings"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Headers that are not forwarded to the shadow service.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// NewMirrorMiddleware returns a new traffic mirroring middleware.
+func NewMirrorMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+		client: &http.Client{},
+		random: rand.Float64,
+	}
+}
+
+// Middleware is a middleware that asynchronously mirrors requests to a shadow service, discarding its responses.
+type Middleware struct {
+	logger logger.Logger
+	client *http.Client
+	// Returns a random number in [0, 1); can be overridden for tests.
+	random func() float64
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &mirrorMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limits the number of in-flight mirrored requests
+	sem := make(chan struct{}, meta.MaxConcurrency)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if meta.Percentage < 100 && m.random()*100 >= meta.Percentage {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, ok := m.bufferBody(r, meta.MaxBodySize)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+				mirrorReq := m.newMirrorRequest(r, body, meta)
+				go func() {
+					defer func() { <-sem }()
+					m.send(ctx, mirrorReq, meta)
+				}()
+			default:
+				m.logger.Debug("Not mirroring request: too many mirrored requests in-flight")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// bufferBody reads the body of the request so it can be sent to both the app and the shadow service.
+// Returns false if the body is larger than the limit, in which case the request must not be mirrored.
+func (m *Middleware) bufferBody(r *http.Request, maxBodySize int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > maxBodySize {
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		m.logger.Debugf("Not mirroring request: failed to read body: %v", err)
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		return nil, false
+	}
+	if int64(len(body)) > maxBodySize {
+		// Restore the body for the app
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+// newMirrorRequest returns a copy of the request for the shadow service.
+// This is invoked before the request is passed to the next handler, which could modify it.
+func (m *Middleware) newMirrorRequest(r *http.Request, body []byte, meta *mirrorMiddlewareMetadata) *http.Request {
+	u := *meta.shadowURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + r.URL.Path
+	u.RawPath = ""
+	u.RawQuery = r.URL.RawQuery
+
+	header := r.Header.Clone()
+	for _, h := range hopByHopHeaders {
+		header.Del(h)
+	}
+	header.Set(meta.TagHeader, meta.TagValue)
+
+	mirrorReq := &http.Request{
+		Method:        r.Method,
+		URL:           &u,
+		Header:        header,
+		ContentLength: int64(len(body)),
+		Host:          u.Host,
+	}
+	if len(body) > 0 {
+		mirrorReq.Body = io.NopCloser(bytes.NewReader(body))
+	} else {
+		mirrorReq.Body = http.NoBody
+	}
+	return mirrorReq
+}
+
+// send sends the mirrored request, discarding the response.
+func (m *Middleware) send(ctx context.Context, req *http.Request, meta *mirrorMiddlewareMetadata) {
+	ctx, cancel := context.WithTimeout(ctx, meta.Timeout)
+	defer cancel()
+
+	res, err := m.client.Do(req.WithContext(ctx))
+	if err != nil {
+		m.logger.Debugf("Failed to send mirrored request to %s: %v", req.URL.Host, err)
+		return
+	}
+	// Drain before closing
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := mirrorMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*mirrorMiddlewareMetadata, error) {
+		obj := &mirrorMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{"shadowURL": "http://shadow:8080/base"})
+		require.NoError(t, err)
+		assert.Equal(t, "shadow:8080", md.shadowURL.Host)
+		assert.InDelta(t, float64(defaultPercentage), md.Percentage, 0)
+		assert.Equal(t, defaultTagHeader, md.TagHeader)
+		assert.Equal(t, defaultTimeout, md.Timeout)
+	})
+
+	t.Run("missing shadow URL", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{})
+		require.ErrorContains(t, err, "shadowURL")
+	})
+
+	t.Run("invalid shadow URL", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{"shadowURL": "ftp://shadow"})
+		require.ErrorContains(t, err, "shadowURL")
+	})
+
+	t.Run("invalid percentage", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{"shadowURL": "http://shadow", "percentage": "150"})
+		require.ErrorContains(t, err, "percentage")
+	})
+}
+
+type mirroredRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+func TestMirrorMiddleware(t *testing.T) {
+	mirrored := make(chan mirroredRequest, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- mirroredRequest{
+			method: r.Method,
+			uri:    r.URL.RequestURI(),
+			header: r.Header,
+			body:   string(body),
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+
+	newHandler := func(t *testing.T, props map[string]string, random float64) http.Handler {
+		mw, _ := NewMirrorMiddleware(logger.NewLogger("test")).(*Middleware)
+		mw.random = func() float64 { return random }
+		handler, err := mw.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(body)
+		}))
+	}
+	receive := func(t *testing.T) (mirroredRequest, bool) {
+		select {
+		case req := <-mirrored:
+			return req, true
+		case <-time.After(200 * time.Millisecond):
+			return mirroredRequest{}, false
+		}
+	}
+
+	t.Run("mirrors request", func(t *testing.T) {
+		h := newHandler(t, map[string]string{"shadowURL": shadow.URL + "/v2"}, 0)
+		r := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader("hello"))
+		r.Header.Set("X-Custom", "foo")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// The app receives the original request and the shadow response is discarded
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello", w.Body.String())
+
+		req, ok := receive(t)
+		require.True(t, ok)
+		assert.Equal(t, http.MethodPost, req.method)
+		assert.Equal(t, "/v2/orders?id=1", req.uri)
+		assert.Equal(t, "foo", req.header.Get("X-Custom"))
+		assert.Equal(t, "true", req.header.Get("X-Dapr-Mirror"))
+		assert.Equal(t, "hello", req.body)
+	})
+
+	t.Run("percentage", func(t *testing.T) {
+		h := newHandler(t, map[string]string{"shadowURL": shadow.URL, "percentage": "25"}, 0.5)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		_, ok := receive(t)
+		assert.False(t, ok)
+
+		h = newHandler(t, map[string]string{"shadowURL": shadow.URL, "percentage": "25"}, 0.1)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		_, ok = receive(t)
+		assert.True(t, ok)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		h := newHandler(t, map[string]string{"shadowURL": shadow.URL, "maxBodySize": "4"}, 0)
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+		r.ContentLength = -1
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "hello world", w.Body.String())
+		_, ok := receive(t)
+		assert.False(t, ok)
+	})
+}
@@ -15,12 +15,13 @@ package oauth2
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
 
-	"github.com/fasthttp-contrib/sessions"
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 
@@ -42,6 +43,14 @@ type oAuth2MiddlewareMetadata struct {
 	AuthHeaderName string `json:"authHeaderName" mapstructure:"authHeaderName"`
 	RedirectURL    string `json:"redirectURL" mapstructure:"redirectURL"`
 	ForceHTTPS     string `json:"forceHTTPS" mapstructure:"forceHTTPS"`
+	// If true, uses PKCE (with the S256 method) in the authorization code flow.
+	PKCE bool `json:"pkce" mapstructure:"pkce"`
+	// Store for sessions: "memory" (per-instance) or "cookie" (encrypted cookie).
+	SessionStore string `json:"sessionStore" mapstructure:"sessionStore"`
+	// Key used to encrypt session cookies, when the session store is "cookie".
+	CookieKey string `json:"cookieKey" mapstructure:"cookieKey"`
+	// Name of the session cookie, when the session store is "cookie".
+	CookieName string `json:"cookieName" mapstructure:"cookieName"`
 }
 
 // NewOAuth2Middleware returns a new oAuth2 middleware.
@@ -57,10 +66,12 @@ type Middleware struct {
 }
 
 const (
-	stateParam   = "state"
-	savedState   = "auth-state"
-	redirectPath = "redirect-url"
-	codeParam    = "code"
+	stateParam    = "state"
+	savedState    = "auth-state"
+	redirectPath  = "redirect-url"
+	codeParam     = "code"
+	savedVerifier = "auth-verifier"
+	savedToken    = "auth-token"
 )
 
 // GetHandler retruns the HTTP handler provided by the middleware.
@@ -82,12 +93,35 @@ func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadat
 		},
 	}
 
+	var store sessionStore
+	switch meta.SessionStore {
+	case sessionStoreMemory:
+		store = memorySessionStore{}
+	case sessionStoreCookie:
+		store, err = newCookieSessionStore(meta.CookieKey, meta.CookieName, forceHTTPS || strings.HasPrefix(meta.RedirectURL, "https://"))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid value for metadata property 'sessionStore': %s", meta.SessionStore)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			session := sessions.Start(w, r)
+			session := store.Start(w, r)
 
-			if session.GetString(meta.AuthHeaderName) != "" {
-				r.Header.Add(meta.AuthHeaderName, session.GetString(meta.AuthHeaderName))
+			authHeader, err := m.getAuthHeader(r.Context(), conf, session, meta.AuthHeaderName)
+			if err != nil {
+				// The token could not be refreshed, so start a new authorization flow
+				m.logger.Debugf("Failed to refresh access token: %v", err)
+			}
+			if authHeader != "" {
+				if err = session.Save(w); err != nil {
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					m.logger.Errorf("Failed to save session: %v", err)
+					return
+				}
+				r.Header.Add(meta.AuthHeaderName, authHeader)
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -104,16 +138,28 @@ func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadat
 				idStr := id.String()
 
 				session.Set(savedState, idStr)
-				session.Set(redirectPath, r.URL)
+				session.Set(redirectPath, r.URL.String())
+
+				opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
+				if meta.PKCE {
+					verifier := oauth2.GenerateVerifier()
+					session.Set(savedVerifier, verifier)
+					opts = append(opts, oauth2.S256ChallengeOption(verifier))
+				}
 
-				url := conf.AuthCodeURL(idStr, oauth2.AccessTypeOffline)
+				if err = session.Save(w); err != nil {
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					m.logger.Errorf("Failed to save session: %v", err)
+					return
+				}
+				url := conf.AuthCodeURL(idStr, opts...)
 				httputils.RespondWithRedirect(w, http.StatusFound, url)
 			} else {
 				authState := session.GetString(savedState)
-				redirectURL, ok := session.Get(redirectPath).(*url.URL)
-				if !ok {
+				redirectURL, err := url.Parse(session.GetString(redirectPath))
+				if err != nil {
 					httputils.RespondWithError(w, http.StatusInternalServerError)
-					m.logger.Errorf("Value saved in state key '%s' is not a *url.URL", redirectPath)
+					m.logger.Errorf("Value saved in state key '%s' is not a valid URL: %v", redirectPath, err)
 					return
 				}
 
@@ -132,27 +178,92 @@ func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadat
 					return
 				}
 
-				token, err := conf.Exchange(r.Context(), code)
+				var opts []oauth2.AuthCodeOption
+				if meta.PKCE {
+					opts = append(opts, oauth2.VerifierOption(session.GetString(savedVerifier)))
+				}
+				token, err := conf.Exchange(r.Context(), code, opts...)
 				if err != nil {
 					httputils.RespondWithError(w, http.StatusInternalServerError)
 					m.logger.Error("Failed to exchange token")
 					return
 				}
 
-				authHeader := token.Type() + " " + token.AccessToken
-				session.Set(meta.AuthHeaderName, authHeader)
+				err = saveToken(session, token)
+				if err == nil {
+					session.Delete(savedState)
+					session.Delete(savedVerifier)
+					session.Delete(redirectPath)
+					err = session.Save(w)
+				}
+				if err != nil {
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					m.logger.Errorf("Failed to save session: %v", err)
+					return
+				}
 				httputils.RespondWithRedirect(w, http.StatusFound, redirectURL.String())
 			}
 		})
 	}, nil
 }
 
+// getAuthHeader returns the value for the authorization header from the token saved in the session.
+// If the access token has expired, it's refreshed using the refresh token, if available.
+func (m *Middleware) getAuthHeader(ctx context.Context, conf *oauth2.Config, session session, authHeaderName string) (string, error) {
+	serialized := session.GetString(savedToken)
+	if serialized == "" {
+		// Sessions created by older versions contain the header only
+		return session.GetString(authHeaderName), nil
+	}
+
+	token := &oauth2.Token{}
+	err := json.Unmarshal([]byte(serialized), token)
+	if err != nil {
+		session.Delete(savedToken)
+		return "", fmt.Errorf("invalid token in session: %w", err)
+	}
+
+	if !token.Valid() {
+		if token.RefreshToken == "" {
+			session.Delete(savedToken)
+			return "", nil
+		}
+
+		refreshed, err := conf.TokenSource(ctx, token).Token()
+		if err != nil {
+			session.Delete(savedToken)
+			return "", err
+		}
+		err = saveToken(session, refreshed)
+		if err != nil {
+			return "", err
+		}
+		token = refreshed
+	}
+
+	return token.Type() + " " + token.AccessToken, nil
+}
+
+// saveToken stores the token in the session.
+func saveToken(session session, token *oauth2.Token) error {
+	serialized, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	session.Set(savedToken, string(serialized))
+	return nil
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*oAuth2MiddlewareMetadata, error) {
-	var middlewareMetadata oAuth2MiddlewareMetadata
+	middlewareMetadata := oAuth2MiddlewareMetadata{
+		SessionStore: sessionStoreMemory,
+		CookieName:   defaultCookieName,
+	}
 	err := kitmd.DecodeMetadata(metadata.Properties, &middlewareMetadata)
 	if err != nil {
 		return nil, err
 	}
+	middlewareMetadata.SessionStore = strings.ToLower(middlewareMetadata.SessionStore)
 	return &middlewareMetadata, nil
 }
 
@@ -17,11 +17,13 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/fasthttp-contrib/sessions"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 
 	"github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
@@ -62,3 +64,124 @@ func TestOAuth2CreatesAuthorizationHeaderWhenInSessionState(t *testing.T) {
 
 	assert.Equal(t, "Bearer abcd", r.Header.Get("someHeader"))
 }
+
+func TestOAuth2PKCEAndRefreshWithCookieSessions(t *testing.T) {
+	var (
+		lastVerifier string
+		tokenCount   int
+	)
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		tokenCount++
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			lastVerifier = r.Form.Get("code_verifier")
+			assert.Equal(t, "mycode", r.Form.Get("code"))
+			// Return a token that is already expired, so the next request refreshes it
+			w.Header().Set("content-type", "application/json")
+			w.Write([]byte(`{"access_token":"first","token_type":"Bearer","refresh_token":"myrefresh","expires_in":1}`))
+		case "refresh_token":
+			assert.Equal(t, "myrefresh", r.Form.Get("refresh_token"))
+			w.Header().Set("content-type", "application/json")
+			w.Write([]byte(`{"access_token":"second","token_type":"Bearer","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer idp.Close()
+
+	var metadata middleware.Metadata
+	metadata.Properties = map[string]string{
+		"clientID":       "testId",
+		"clientSecret":   "testSecret",
+		"scopes":         "ascope",
+		"authURL":        idp.URL + "/authorize",
+		"tokenURL":       idp.URL + "/token",
+		"redirectURL":    "http://localhost:9999/callback",
+		"authHeaderName": "Authorization",
+		"pkce":           "true",
+		"sessionStore":   "cookie",
+		"cookieKey":      "mykey",
+	}
+
+	log := logger.NewLogger("oauth2.test")
+	handler, err := NewOAuth2Middleware(log).GetHandler(context.Background(), metadata)
+	require.NoError(t, err)
+
+	var received string
+	h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cookies := map[string]*http.Cookie{}
+	do := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		for _, c := range w.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+		return w
+	}
+
+	// Start the flow
+	w := do("http://localhost:9999/app")
+	require.Equal(t, http.StatusFound, w.Code)
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+	challenge := authURL.Query().Get("code_challenge")
+	require.NotEmpty(t, challenge)
+	state := authURL.Query().Get("state")
+	require.NotEmpty(t, state)
+	require.Contains(t, cookies, defaultCookieName)
+
+	// Callback from the provider
+	w = do("http://localhost:9999/callback?state=" + state + "&code=mycode")
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://localhost:9999/app", w.Header().Get("Location"))
+	assert.Equal(t, challenge, oauth2.S256ChallengeFromVerifier(lastVerifier))
+
+	// The token is expired, so it's refreshed
+	w = do("http://localhost:9999/app")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Bearer second", received)
+	assert.Equal(t, 2, tokenCount)
+
+	// The refreshed token is used
+	w = do("http://localhost:9999/app")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Bearer second", received)
+	assert.Equal(t, 2, tokenCount)
+}
+
+func TestCookieSessionStore(t *testing.T) {
+	store, err := newCookieSessionStore("key1", defaultCookieName, true)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	sess := store.Start(w, httptest.NewRequest(http.MethodGet, "http://dapr.io", nil))
+	sess.Set("foo", "bar")
+	require.NoError(t, sess.Save(w))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].Secure)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.NotContains(t, cookies[0].Value, "bar")
+
+	r := httptest.NewRequest(http.MethodGet, "http://dapr.io", nil)
+	r.AddCookie(cookies[0])
+	assert.Equal(t, "bar", store.Start(httptest.NewRecorder(), r).GetString("foo"))
+
+	// Cookies encrypted with a different key are discarded
+	other, err := newCookieSessionStore("key2", defaultCookieName, true)
+	require.NoError(t, err)
+	assert.Empty(t, other.Start(httptest.NewRecorder(), r).GetString("foo"))
+
+	_, err = newCookieSessionStore("", defaultCookieName, true)
+	require.Error(t, err)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/fasthttp-contrib/sessions"
+)
+
+const (
+	sessionStoreMemory = "memory"
+	sessionStoreCookie = "cookie"
+
+	defaultCookieName = "dapr-oauth2-session"
+)
+
+// session contains the values stored for a client.
+type session interface {
+	GetString(key string) string
+	Set(key string, value string)
+	Delete(key string)
+	// Save persists the session; it must be invoked before writing the response.
+	Save(w http.ResponseWriter) error
+}
+
+// sessionStore is the interface for the stores of sessions.
+type sessionStore interface {
+	Start(w http.ResponseWriter, r *http.Request) session
+}
+
+// memorySessionStore keeps sessions in memory, in each instance.
+type memorySessionStore struct{}
+
+func (memorySessionStore) Start(w http.ResponseWriter, r *http.Request) session {
+	return memorySession{sessions.Start(w, r)}
+}
+
+type memorySession struct {
+	sessions.Session
+}
+
+func (s memorySession) Set(key string, value string) {
+	s.Session.Set(key, value)
+}
+
+func (s memorySession) Save(http.ResponseWriter) error {
+	// Nop
+	return nil
+}
+
+// cookieSessionStore keeps sessions in an encrypted cookie, so they are shared across instances.
+type cookieSessionStore struct {
+	aead       cipher.AEAD
+	cookieName string
+	secure     bool
+}
+
+func newCookieSessionStore(key string, cookieName string, secure bool) (*cookieSessionStore, error) {
+	if key == "" {
+		return nil, errors.New("metadata property 'cookieKey' is required when 'sessionStore' is 'cookie'")
+	}
+
+	// Derive a 256-bit key from the value in the metadata
+	derived := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &cookieSessionStore{
+		aead:       aead,
+		cookieName: cookieName,
+		secure:     secure,
+	}, nil
+}
+
+func (s *cookieSessionStore) Start(_ http.ResponseWriter, r *http.Request) session {
+	sess := &cookieSession{
+		store:  s,
+		values: map[string]string{},
+	}
+	cookie, err := r.Cookie(s.cookieName)
+	if err != nil || cookie.Value == "" {
+		return sess
+	}
+
+	// Sessions that can't be decrypted, for example because the key was changed, are discarded
+	values, err := s.decrypt(cookie.Value)
+	if err == nil {
+		sess.values = values
+	}
+	return sess
+}
+
+func (s *cookieSessionStore) encrypt(values map[string]string) (string, error) {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := s.aead.Seal(nonce, nonce, plaintext, []byte(s.cookieName))
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+func (s *cookieSessionStore) decrypt(value string) (map[string]string, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := s.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext is too short")
+	}
+	plaintext, err := s.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], []byte(s.cookieName))
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	err = json.Unmarshal(plaintext, &values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+type cookieSession struct {
+	store   *cookieSessionStore
+	values  map[string]string
+	changed bool
+}
+
+func (s *cookieSession) GetString(key string) string {
+	return s.values[key]
+}
+
+func (s *cookieSession) Set(key string, value string) {
+	s.values[key] = value
+	s.changed = true
+}
+
+func (s *cookieSession) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+func (s *cookieSession) Save(w http.ResponseWriter) error {
+	if !s.changed {
+		return nil
+	}
+	value, err := s.store.encrypt(s.values)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt session: %w", err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.store.cookieName,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   s.store.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	s.changed = false
+	return nil
+}
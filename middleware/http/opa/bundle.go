@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+const (
+	defaultBundlePollInterval          = time.Minute
+	defaultBundleVerificationKeyID     = "default"
+	defaultBundleVerificationAlgorithm = "RS256"
+	bundleRequestTimeout               = 30 * time.Second
+)
+
+// policy is a prepared query, together with the revision of the bundle it was loaded from.
+type policy struct {
+	query    rego.PreparedEvalQuery
+	revision string
+}
+
+// bundleLoader downloads policies from a bundle server, and refreshes them periodically.
+type bundleLoader struct {
+	meta   *middlewareMetadata
+	m      *Middleware
+	client *http.Client
+	policy atomic.Pointer[policy]
+	etag   string
+}
+
+func newBundleLoader(m *Middleware, meta *middlewareMetadata) *bundleLoader {
+	return &bundleLoader{
+		meta: meta,
+		m:    m,
+		client: &http.Client{
+			Timeout: bundleRequestTimeout,
+		},
+	}
+}
+
+// Start loads the bundle and then refreshes it in background until the context is canceled.
+func (l *bundleLoader) Start(ctx context.Context) error {
+	_, err := l.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load bundle from %s: %w", l.meta.BundleURL, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(l.meta.BundlePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				updated, err := l.load(ctx)
+				if err != nil {
+					l.m.logger.Warnf("Failed to refresh OPA bundle from %s, keeping the current policy: %v", l.meta.BundleURL, err)
+				} else if updated {
+					l.m.logger.Infof("Loaded OPA bundle revision '%s'", l.policy.Load().revision)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Policy returns the current policy.
+func (l *bundleLoader) Policy() *policy {
+	return l.policy.Load()
+}
+
+// load downloads the bundle and prepares the query, returning true if the bundle has changed.
+func (l *bundleLoader) load(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.meta.BundleURL, nil)
+	if err != nil {
+		return false, err
+	}
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+	if l.meta.BundleToken != "" {
+		req.Header.Set("Authorization", "Bearer "+l.meta.BundleToken)
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		// Drain before closing
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+		// Nop
+	default:
+		return false, fmt.Errorf("invalid response status code: %d", res.StatusCode)
+	}
+
+	reader := bundle.NewReader(res.Body)
+	if l.meta.BundleVerificationKey != "" {
+		keys := map[string]*bundle.KeyConfig{
+			l.meta.BundleVerificationKeyID: {
+				Key:       l.meta.BundleVerificationKey,
+				Algorithm: l.meta.BundleVerificationAlgorithm,
+				Scope:     l.meta.BundleVerificationScope,
+			},
+		}
+		reader = reader.WithBundleVerificationConfig(
+			bundle.NewVerificationConfig(keys, l.meta.BundleVerificationKeyID, l.meta.BundleVerificationScope, nil),
+		)
+	}
+	b, err := reader.Read()
+	if err != nil {
+		return false, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	prepareCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	query, err := rego.New(
+		rego.Query("result = data.http.allow"),
+		rego.ParsedBundle("bundle", &b),
+	).PrepareForEval(prepareCtx)
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare policy: %w", err)
+	}
+
+	l.policy.Store(&policy{
+		query:    query,
+		revision: b.Manifest.Revision,
+	})
+	l.etag = res.Header.Get("ETag")
+	return true, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func buildBundle(t *testing.T, revision string, rego string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	files := map[string]string{
+		"/.manifest":        `{"revision":"` + revision + `"}`,
+		"/http/policy.rego": rego,
+	}
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestBundle(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		current  []byte
+		revision string
+		requests atomic.Int32
+	)
+	setBundle := func(rev string, allow bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		policy := "package http\nallow = false\n"
+		if allow {
+			policy = "package http\nallow = true\n"
+		}
+		current = buildBundle(t, rev, policy)
+		revision = rev
+	}
+	setBundle("r1", false)
+
+	bundleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Header.Get("if-none-match") == revision {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("etag", revision)
+		w.Write(current)
+	}))
+	defer bundleServer.Close()
+
+	logs := make(chan []decisionLog, 10)
+	logsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var entries []decisionLog
+		_ = json.Unmarshal(body, &entries)
+		logs <- entries
+	}))
+	defer logsServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler, err := NewMiddleware(logger.NewLogger("test")).GetHandler(ctx, middleware.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"bundleURL":          bundleServer.URL,
+			"bundleToken":        "mytoken",
+			"bundlePollInterval": "50ms",
+			"decisionLogs":       "http",
+			"decisionLogsURL":    logsServer.URL,
+		},
+	}})
+	require.NoError(t, err)
+	h := handler(http.HandlerFunc(mockedRequestHandler))
+
+	do := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://my.site/foo", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, do())
+
+	// Policy is refreshed
+	setBundle("r2", true)
+	assert.Eventually(t, func() bool {
+		return do() == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// Unchanged bundles are not downloaded again
+	n := requests.Load()
+	assert.Eventually(t, func() bool {
+		return requests.Load() > n+2
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, http.StatusOK, do())
+
+	// Decision logs are delivered
+	select {
+	case entries := <-logs:
+		require.NotEmpty(t, entries)
+		assert.False(t, entries[0].Allowed)
+		assert.Equal(t, "r1", entries[0].Revision)
+		assert.Equal(t, http.StatusForbidden, entries[0].StatusCode)
+		assert.Equal(t, "/foo", entries[0].Path)
+		assert.NotEmpty(t, entries[0].InputDigest)
+		assert.NotEmpty(t, entries[0].DecisionID)
+	case <-time.After(10 * time.Second):
+		t.Fatal("did not receive decision logs")
+	}
+}
+
+func TestBundleMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"rego":      "package http\nallow = true",
+		"bundleURL": "http://localhost/bundle.tar.gz",
+	}}})
+	require.Error(t, err)
+
+	_, err = m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"bundleURL":    "http://localhost/bundle.tar.gz",
+		"decisionLogs": "http",
+	}}})
+	require.ErrorContains(t, err, "decisionLogsURL")
+
+	meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"bundleURL": "http://localhost/bundle.tar.gz",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, defaultBundlePollInterval, meta.BundlePollInterval)
+	assert.Equal(t, defaultBundleVerificationAlgorithm, meta.BundleVerificationAlgorithm)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	decisionLogsSinkLog     = "log"
+	decisionLogsSinkHTTP    = "http"
+	decisionLogsBufferSize  = 1000
+	decisionLogsBatchSize   = 100
+	decisionLogsFlushPeriod = 5 * time.Second
+)
+
+// decisionLog is an entry in the decision logs.
+type decisionLog struct {
+	DecisionID  string    `json:"decision_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Allowed     bool      `json:"allowed"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	InputDigest string    `json:"input_digest"`
+	Revision    string    `json:"bundle_revision,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// decisionLogger sends decision logs to the configured sink.
+// Logs sent over HTTP are delivered in batches, in background.
+type decisionLogger struct {
+	sink   string
+	url    string
+	logger logger.Logger
+	client *http.Client
+	queue  chan *decisionLog
+}
+
+func newDecisionLogger(ctx context.Context, meta *middlewareMetadata, log logger.Logger) *decisionLogger {
+	dl := &decisionLogger{
+		sink:   meta.DecisionLogs,
+		url:    meta.DecisionLogsURL,
+		logger: log,
+	}
+	if dl.sink == decisionLogsSinkHTTP {
+		dl.client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+		dl.queue = make(chan *decisionLog, decisionLogsBufferSize)
+		go dl.run(ctx)
+	}
+	return dl
+}
+
+// inputDigest returns the SHA-256 digest of the input, so consumers of logs can correlate decisions without receiving the (potentially sensitive) input.
+func inputDigest(input any) string {
+	enc, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(enc)
+	return hex.EncodeToString(h[:])
+}
+
+func (e *decisionLog) setError(err error) {
+	if e != nil {
+		e.Error = err.Error()
+	}
+}
+
+// Log records a decision.
+func (dl *decisionLogger) Log(entry *decisionLog) {
+	if dl == nil {
+		return
+	}
+	id, err := uuid.NewRandom()
+	if err == nil {
+		entry.DecisionID = id.String()
+	}
+	entry.Timestamp = time.Now().UTC()
+
+	switch dl.sink {
+	case decisionLogsSinkLog:
+		enc, _ := json.Marshal(entry)
+		dl.logger.Info("OPA decision: " + string(enc))
+	case decisionLogsSinkHTTP:
+		select {
+		case dl.queue <- entry:
+		default:
+			dl.logger.Warn("Decision logs buffer is full: dropping entry")
+		}
+	}
+}
+
+func (dl *decisionLogger) run(ctx context.Context) {
+	ticker := time.NewTicker(decisionLogsFlushPeriod)
+	defer ticker.Stop()
+
+	batch := make([]*decisionLog, 0, decisionLogsBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := dl.send(ctx, batch)
+		if err != nil {
+			dl.logger.Warnf("Failed to send %d decision logs: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-dl.queue:
+			batch = append(batch, entry)
+			if len(batch) >= decisionLogsBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (dl *decisionLogger) send(ctx context.Context, batch []*decisionLog) error {
+	enc, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.url, bytes.NewReader(enc))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := dl.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Drain before closing
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("invalid response status code: %d", res.StatusCode)
+	}
+	return nil
+}
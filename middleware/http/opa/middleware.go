@@ -47,6 +47,26 @@ type middlewareMetadata struct {
 	IncludedHeaders               string   `json:"includedHeaders,omitempty" mapstructure:"includedHeaders"`
 	ReadBody                      string   `json:"readBody,omitempty" mapstructure:"readBody"`
 	internalIncludedHeadersParsed []string `json:"-" mapstructure:"-"`
+
+	// URL of a bundle to load the policies from, as an alternative to inline rego.
+	BundleURL string `json:"bundleURL,omitempty" mapstructure:"bundleURL"`
+	// Interval for checking the bundle server for updates.
+	BundlePollInterval time.Duration `json:"bundlePollInterval,omitempty" mapstructure:"bundlePollInterval"`
+	// Bearer token used to authenticate with the bundle server.
+	BundleToken string `json:"bundleToken,omitempty" mapstructure:"bundleToken"`
+	// Key used to verify the signature of bundles: a PEM-encoded public key, or the secret for HMAC algorithms.
+	// If empty, signatures are not verified.
+	BundleVerificationKey string `json:"bundleVerificationKey,omitempty" mapstructure:"bundleVerificationKey"`
+	// ID of the verification key.
+	BundleVerificationKeyID string `json:"bundleVerificationKeyID,omitempty" mapstructure:"bundleVerificationKeyID"`
+	// Algorithm of the verification key.
+	BundleVerificationAlgorithm string `json:"bundleVerificationAlgorithm,omitempty" mapstructure:"bundleVerificationAlgorithm"`
+	// Scope to expect in the bundle signature.
+	BundleVerificationScope string `json:"bundleVerificationScope,omitempty" mapstructure:"bundleVerificationScope"`
+	// Sink for decision logs: "log" or "http". If empty, decision logs are disabled.
+	DecisionLogs string `json:"decisionLogs,omitempty" mapstructure:"decisionLogs"`
+	// URL where decision logs are sent when the sink is "http".
+	DecisionLogsURL string `json:"decisionLogsURL,omitempty" mapstructure:"decisionLogsURL"`
 }
 
 // NewMiddleware returns a new Open Policy Agent middleware.
@@ -115,19 +135,38 @@ func (m *Middleware) GetHandler(parentCtx context.Context, metadata middleware.M
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(parentCtx, time.Minute)
-	query, err := rego.New(
-		rego.Query("result = data.http.allow"),
-		rego.Module("inline.rego", meta.Rego),
-	).PrepareForEval(ctx)
-	cancel()
-	if err != nil {
-		return nil, err
+	var getPolicy func() *policy
+	if meta.BundleURL != "" {
+		loader := newBundleLoader(m, meta)
+		err = loader.Start(parentCtx)
+		if err != nil {
+			return nil, err
+		}
+		getPolicy = loader.Policy
+	} else {
+		ctx, cancel := context.WithTimeout(parentCtx, time.Minute)
+		query, err := rego.New(
+			rego.Query("result = data.http.allow"),
+			rego.Module("inline.rego", meta.Rego),
+		).PrepareForEval(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		p := &policy{query: query}
+		getPolicy = func() *policy {
+			return p
+		}
+	}
+
+	var dl *decisionLogger
+	if meta.DecisionLogs != "" {
+		dl = newDecisionLogger(parentCtx, meta, m.logger)
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if allow := m.evalRequest(w, r, meta, &query); !allow {
+			if allow := m.evalRequest(w, r, meta, getPolicy(), dl); !allow {
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -135,7 +174,7 @@ func (m *Middleware) GetHandler(parentCtx context.Context, metadata middleware.M
 	}, nil
 }
 
-func (m *Middleware) evalRequest(w http.ResponseWriter, r *http.Request, meta *middlewareMetadata, query *rego.PreparedEvalQuery) bool {
+func (m *Middleware) evalRequest(w http.ResponseWriter, r *http.Request, meta *middlewareMetadata, p *policy, dl *decisionLogger) bool {
 	headers := map[string]string{}
 
 	for key, value := range r.Header {
@@ -167,18 +206,40 @@ func (m *Middleware) evalRequest(w http.ResponseWriter, r *http.Request, meta *m
 		},
 	}
 
-	results, err := query.Eval(r.Context(), rego.EvalInput(input))
+	var entry *decisionLog
+	if dl != nil {
+		entry = &decisionLog{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			InputDigest: inputDigest(input),
+			Revision:    p.revision,
+		}
+		sw := &statusWriter{ResponseWriter: w}
+		w = sw
+		defer func() {
+			entry.StatusCode = sw.status
+			dl.Log(entry)
+		}()
+	}
+
+	results, err := p.query.Eval(r.Context(), rego.EvalInput(input))
 	if err != nil {
+		entry.setError(err)
 		m.opaError(w, meta, err)
 		return false
 	}
 
 	if len(results) == 0 {
+		entry.setError(errOpaNoResult)
 		m.opaError(w, meta, errOpaNoResult)
 		return false
 	}
 
-	return m.handleRegoResult(w, r, meta, results[0].Bindings["result"])
+	allowed := m.handleRegoResult(w, r, meta, results[0].Bindings["result"])
+	if entry != nil {
+		entry.Allowed = allowed
+	}
+	return allowed
 }
 
 // handleRegoResult takes the in process request and open policy agent evaluation result
@@ -240,7 +301,10 @@ func (m *Middleware) opaError(w http.ResponseWriter, meta *middlewareMetadata, e
 
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*middlewareMetadata, error) {
 	meta := middlewareMetadata{
-		DefaultStatus: 403,
+		DefaultStatus:               403,
+		BundlePollInterval:          defaultBundlePollInterval,
+		BundleVerificationKeyID:     defaultBundleVerificationKeyID,
+		BundleVerificationAlgorithm: defaultBundleVerificationAlgorithm,
 	}
 	err := kitmd.DecodeMetadata(metadata.Properties, &meta)
 	if err != nil {
@@ -258,9 +322,37 @@ func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*middlewar
 	}
 	meta.internalIncludedHeadersParsed = meta.internalIncludedHeadersParsed[:n]
 
+	if meta.BundleURL != "" && meta.Rego != "" {
+		return nil, errors.New("metadata properties 'rego' and 'bundleURL' cannot be set at the same time")
+	}
+	if meta.BundlePollInterval <= 0 {
+		return nil, errors.New("metadata property 'bundlePollInterval' must be greater than 0")
+	}
+	switch meta.DecisionLogs {
+	case "", decisionLogsSinkLog:
+		// Nop
+	case decisionLogsSinkHTTP:
+		if meta.DecisionLogsURL == "" {
+			return nil, errors.New("metadata property 'decisionLogsURL' is required when 'decisionLogs' is 'http'")
+		}
+	default:
+		return nil, fmt.Errorf("invalid value for metadata property 'decisionLogs': %s", meta.DecisionLogs)
+	}
+
 	return &meta, nil
 }
 
+// statusWriter is a http.ResponseWriter that records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
 func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := middlewareMetadata{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
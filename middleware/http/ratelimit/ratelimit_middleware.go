@@ -15,13 +15,19 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+	"time"
 
 	tollbooth "github.com/didip/tollbooth/v7"
+	tollboothErrors "github.com/didip/tollbooth/v7/errors"
 	libstring "github.com/didip/tollbooth/v7/libstring"
+	"github.com/didip/tollbooth/v7/limiter"
 
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
@@ -31,31 +37,84 @@ import (
 // Metadata is the ratelimit middleware config.
 type rateLimitMiddlewareMetadata struct {
 	MaxRequestsPerSecond float64 `json:"maxRequestsPerSecond"`
+	// Store for the rate limiting state: "memory" (per-instance) or "redis" (shared across instances).
+	StoreType string `json:"storeType" mapstructure:"storeType"`
+	// Algorithm used with the Redis store: "tokenBucket" or "slidingWindow".
+	Algorithm string `json:"algorithm" mapstructure:"algorithm"`
+	// Maximum number of requests allowed in a burst, for the token bucket algorithm.
+	// Defaults to maxRequestsPerSecond.
+	Burst int `json:"burst" mapstructure:"burst"`
+	// Size of the window for the sliding window algorithm.
+	Window time.Duration `json:"window" mapstructure:"window"`
+	// Comma-separated list of request attributes the limits are applied to: "ip", "route", or "header:<name>".
+	KeyBy string `json:"keyBy" mapstructure:"keyBy"`
+	// Prefix for keys stored in Redis.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+
+	// Internal properties
+	keyBy []string `json:"-" mapstructure:"-"`
 }
 
 const (
 	maxRequestsPerSecondKey = "maxRequestsPerSecond"
 
+	storeTypeMemory = "memory"
+	storeTypeRedis  = "redis"
+
+	algorithmTokenBucket   = "tokenbucket"
+	algorithmSlidingWindow = "slidingwindow"
+
+	keyByIP           = "ip"
+	keyByRoute        = "route"
+	keyByHeaderPrefix = "header:"
+
 	// Defaults.
 	defaultMaxRequestsPerSecond = 100
+	defaultWindow               = time.Second
+	defaultKeyPrefix            = "dapr-ratelimit||"
 )
 
 // NewRateLimitMiddleware returns a new ratelimit middleware.
-func NewRateLimitMiddleware(_ logger.Logger) middleware.Middleware {
-	return &Middleware{}
+func NewRateLimitMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
 }
 
 // Middleware is an ratelimit middleware.
-type Middleware struct{}
+type Middleware struct {
+	logger logger.Logger
+}
 
 // GetHandler returns the HTTP handler provided by the middleware.
-func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
 	meta, err := m.getNativeMetadata(metadata)
 	if err != nil {
 		return nil, err
 	}
 
 	limiter := tollbooth.NewLimiter(meta.MaxRequestsPerSecond, nil)
+	if meta.Burst > 0 {
+		limiter.SetBurst(meta.Burst)
+	}
+
+	var rl *redisLimiter
+	if meta.StoreType == storeTypeRedis {
+		client, _, err := rediscomponent.ParseClientFromProperties(metadata.Properties, contribMetadata.MiddlewareType, ctx, &m.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Redis client: %w", err)
+		}
+		if _, err = client.PingResult(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		rl = newRedisLimiter(client, meta)
+
+		// Close the client when the middleware is disposed of
+		go func() {
+			<-ctx.Done()
+			_ = client.Close()
+		}()
+	}
 
 	return func(next http.Handler) http.Handler {
 		// Adapted from toolbooth.LimitHandler
@@ -66,17 +125,30 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 			if remoteIP == "" {
 				// Forcefully set a remote IP
 				r.Header.Set("X-Forwarded-For", "0.0.0.0")
+				remoteIP = "0.0.0.0"
 			}
 
-			httpError := tollbooth.LimitByRequest(limiter, w, r)
-			if httpError != nil {
-				limiter.ExecOnLimitReached(w, r)
-				if limiter.GetOverrideDefaultResponseWriter() {
+			if rl != nil {
+				allowed, err := rl.Allow(r.Context(), requestKey(meta.keyBy, remoteIP, r))
+				if err != nil {
+					// Fail open so an outage of Redis doesn't block all traffic
+					m.logger.Warnf("Failed to check rate limit in Redis, allowing request: %v", err)
+				} else if !allowed {
+					respondLimitReached(w, r, limiter)
 					return
 				}
-				w.Header().Add("Content-Type", limiter.GetMessageContentType())
-				w.WriteHeader(httpError.StatusCode)
-				w.Write([]byte(httpError.Message))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var httpError *tollboothErrors.HTTPError
+			if len(meta.keyBy) == 1 && meta.keyBy[0] == keyByIP {
+				httpError = tollbooth.LimitByRequest(limiter, w, r)
+			} else {
+				httpError = tollbooth.LimitByKeys(limiter, []string{requestKey(meta.keyBy, remoteIP, r)})
+			}
+			if httpError != nil {
+				respondLimitReached(w, r, limiter)
 				return
 			}
 
@@ -89,6 +161,11 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*rateLimitMiddlewareMetadata, error) {
 	middlewareMetadata := rateLimitMiddlewareMetadata{
 		MaxRequestsPerSecond: defaultMaxRequestsPerSecond,
+		StoreType:            storeTypeMemory,
+		Algorithm:            algorithmTokenBucket,
+		Window:               defaultWindow,
+		KeyBy:                keyByIP,
+		KeyPrefix:            defaultKeyPrefix,
 	}
 	err := kitmd.DecodeMetadata(metadata.Properties, &middlewareMetadata)
 	if err != nil {
@@ -98,10 +175,71 @@ func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*rateLimit
 	if middlewareMetadata.MaxRequestsPerSecond <= 0 {
 		return nil, fmt.Errorf("metadata property %s must be a positive value", maxRequestsPerSecondKey)
 	}
+	if middlewareMetadata.Burst < 0 {
+		return nil, errors.New("metadata property burst must not be negative")
+	}
+	if middlewareMetadata.Window <= 0 {
+		return nil, errors.New("metadata property window must be a positive value")
+	}
+
+	middlewareMetadata.StoreType = strings.ToLower(middlewareMetadata.StoreType)
+	if middlewareMetadata.StoreType != storeTypeMemory && middlewareMetadata.StoreType != storeTypeRedis {
+		return nil, fmt.Errorf("invalid value for metadata property storeType: %s", middlewareMetadata.StoreType)
+	}
+	middlewareMetadata.Algorithm = strings.ToLower(middlewareMetadata.Algorithm)
+	if middlewareMetadata.Algorithm != algorithmTokenBucket && middlewareMetadata.Algorithm != algorithmSlidingWindow {
+		return nil, fmt.Errorf("invalid value for metadata property algorithm: %s", middlewareMetadata.Algorithm)
+	}
+	if middlewareMetadata.Algorithm == algorithmSlidingWindow && middlewareMetadata.StoreType != storeTypeRedis {
+		return nil, fmt.Errorf("the %s algorithm requires the %s store", middlewareMetadata.Algorithm, storeTypeRedis)
+	}
+
+	for _, k := range strings.Split(middlewareMetadata.KeyBy, ",") {
+		k = strings.TrimSpace(k)
+		switch {
+		case k == "":
+			continue
+		case k == keyByIP || k == keyByRoute:
+			middlewareMetadata.keyBy = append(middlewareMetadata.keyBy, k)
+		case strings.HasPrefix(k, keyByHeaderPrefix) && len(k) > len(keyByHeaderPrefix):
+			middlewareMetadata.keyBy = append(middlewareMetadata.keyBy, keyByHeaderPrefix+http.CanonicalHeaderKey(k[len(keyByHeaderPrefix):]))
+		default:
+			return nil, fmt.Errorf("invalid value in metadata property keyBy: %s", k)
+		}
+	}
+	if len(middlewareMetadata.keyBy) == 0 {
+		middlewareMetadata.keyBy = []string{keyByIP}
+	}
 
 	return &middlewareMetadata, nil
 }
 
+// requestKey returns the key the rate limit is applied to for the request.
+func requestKey(keyBy []string, remoteIP string, r *http.Request) string {
+	parts := make([]string, len(keyBy))
+	for i, k := range keyBy {
+		switch k {
+		case keyByIP:
+			parts[i] = remoteIP
+		case keyByRoute:
+			parts[i] = r.Method + " " + r.URL.Path
+		default:
+			parts[i] = r.Header.Get(k[len(keyByHeaderPrefix):])
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func respondLimitReached(w http.ResponseWriter, r *http.Request, lmt *limiter.Limiter) {
+	lmt.ExecOnLimitReached(w, r)
+	if lmt.GetOverrideDefaultResponseWriter() {
+		return
+	}
+	w.Header().Add("Content-Type", lmt.GetMessageContentType())
+	w.WriteHeader(lmt.GetStatusCode())
+	w.Write([]byte(lmt.GetMessage()))
+}
+
 func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := rateLimitMiddlewareMetadata{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
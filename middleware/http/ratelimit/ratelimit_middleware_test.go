@@ -14,13 +14,18 @@ limitations under the License.
 package ratelimit
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
 )
 
 func TestMiddlewareGetNativeMetadata(t *testing.T) {
@@ -71,3 +76,132 @@ func TestMiddlewareGetNativeMetadata(t *testing.T) {
 		assert.EqualValues(t, float64(42.42), res.MaxRequestsPerSecond)
 	})
 }
+
+func TestMiddlewareGetNativeMetadataStore(t *testing.T) {
+	m := &Middleware{}
+
+	t.Run("defaults", func(t *testing.T) {
+		res, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.NoError(t, err)
+		assert.Equal(t, storeTypeMemory, res.StoreType)
+		assert.Equal(t, algorithmTokenBucket, res.Algorithm)
+		assert.Equal(t, []string{keyByIP}, res.keyBy)
+	})
+
+	t.Run("key by", func(t *testing.T) {
+		res, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"keyBy": "ip, header:x-api-key,route",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{keyByIP, "header:X-Api-Key", keyByRoute}, res.keyBy)
+	})
+
+	t.Run("invalid key by", func(t *testing.T) {
+		_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"keyBy": "cookie",
+		}}})
+		require.ErrorContains(t, err, "keyBy")
+	})
+
+	t.Run("sliding window requires redis", func(t *testing.T) {
+		_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"algorithm": "slidingWindow",
+		}}})
+		require.Error(t, err)
+
+		res, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"algorithm": "slidingWindow",
+			"storeType": "redis",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, algorithmSlidingWindow, res.Algorithm)
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	newHandler := func(t *testing.T, props map[string]string) http.Handler {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		handler, err := NewRateLimitMiddleware(logger.NewLogger("test")).GetHandler(ctx, middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	do := func(h http.Handler, apiKey string) int {
+		r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		r.Header.Set("x-api-key", apiKey)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	// Sends requests until the first one is rejected, and returns the number of requests that were allowed
+	countAllowed := func(h http.Handler, apiKey string) int {
+		for i := 0; i < 100; i++ {
+			if do(h, apiKey) != http.StatusNoContent {
+				return i
+			}
+		}
+		return 100
+	}
+
+	t.Run("memory keyed by header", func(t *testing.T) {
+		h := newHandler(t, map[string]string{
+			maxRequestsPerSecondKey: "0.01",
+			"burst":                 "3",
+			"keyBy":                 "header:X-Api-Key",
+		})
+		assert.Equal(t, 3, countAllowed(h, "a"))
+		assert.Equal(t, 3, countAllowed(h, "b"))
+		assert.Equal(t, http.StatusTooManyRequests, do(h, "a"))
+	})
+
+	redis, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redis.Close()
+
+	for _, algorithm := range []string{"tokenBucket", "slidingWindow"} {
+		t.Run("redis "+algorithm, func(t *testing.T) {
+			props := map[string]string{
+				"storeType":             "redis",
+				"redisHost":             redis.Addr(),
+				"algorithm":             algorithm,
+				"keyBy":                 "header:X-Api-Key,route",
+				"keyPrefix":             algorithm + "||",
+				maxRequestsPerSecondKey: "3",
+				"window":                "1m",
+				"burst":                 "3",
+			}
+			if algorithm == "slidingWindow" {
+				props[maxRequestsPerSecondKey] = "0.05"
+			}
+
+			// Two instances share the same limits
+			h1 := newHandler(t, props)
+			h2 := newHandler(t, props)
+			assert.Equal(t, http.StatusNoContent, do(h1, "a"))
+			assert.Equal(t, http.StatusNoContent, do(h2, "a"))
+			assert.Equal(t, http.StatusNoContent, do(h1, "a"))
+			assert.Equal(t, http.StatusTooManyRequests, do(h2, "a"))
+			assert.Equal(t, http.StatusNoContent, do(h2, "b"))
+
+			assert.True(t, redis.Exists(algorithm+"||a|GET /foo"))
+			assert.Positive(t, redis.TTL(algorithm+"||a|GET /foo"))
+		})
+	}
+
+	t.Run("redis unavailable fails open", func(t *testing.T) {
+		s, err := miniredis.Run()
+		require.NoError(t, err)
+		h := newHandler(t, map[string]string{
+			"storeType":       "redis",
+			"redisHost":       s.Addr(),
+			"redisMaxRetries": "-1",
+			"dialTimeout":     "100ms",
+		})
+		s.Close()
+		assert.Equal(t, http.StatusNoContent, do(h, "a"))
+	})
+}
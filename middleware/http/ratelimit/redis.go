@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+)
+
+// Both scripts use the time of the Redis server, so all instances share the same clock.
+// They return 1 if the request is allowed, and 0 otherwise.
+const (
+	// KEYS[1]: key of the bucket
+	// ARGV[1]: tokens added per second; ARGV[2]: capacity of the bucket
+	tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return allowed
+`
+
+	// KEYS[1]: key of the window
+	// ARGV[1]: maximum requests in the window; ARGV[2]: size of the window in milliseconds; ARGV[3]: unique ID of the request
+	slidingWindowScript = `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= limit then
+  return 0
+end
+redis.call("ZADD", KEYS[1], now, ARGV[3])
+redis.call("PEXPIRE", KEYS[1], window)
+return 1
+`
+)
+
+// redisLimiter is a rate limiter that keeps its state in Redis, so limits are shared across instances.
+type redisLimiter struct {
+	client    rediscomponent.RedisClient
+	keyPrefix string
+	script    string
+	args      []any
+	withID    bool
+}
+
+func newRedisLimiter(client rediscomponent.RedisClient, meta *rateLimitMiddlewareMetadata) *redisLimiter {
+	rl := &redisLimiter{
+		client:    client,
+		keyPrefix: meta.KeyPrefix,
+	}
+	switch meta.Algorithm {
+	case algorithmSlidingWindow:
+		limit := int64(math.Max(1, math.Floor(meta.MaxRequestsPerSecond*meta.Window.Seconds())))
+		rl.script = slidingWindowScript
+		rl.args = []any{limit, meta.Window.Milliseconds()}
+		rl.withID = true
+	default:
+		// Same default burst as the in-memory limiter
+		burst := meta.Burst
+		if burst == 0 {
+			burst = int(math.Max(1, meta.MaxRequestsPerSecond))
+		}
+		rl.script = tokenBucketScript
+		rl.args = []any{meta.MaxRequestsPerSecond, burst}
+	}
+	return rl
+}
+
+// Allow returns true if the request with the given key is allowed.
+func (rl *redisLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	args := rl.args
+	if rl.withID {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return false, err
+		}
+		args = append(args[:len(args):len(args)], id.String())
+	}
+
+	res, parseErr, err := rl.client.EvalInt(ctx, rl.script, []string{rl.keyPrefix + key}, args...)
+	if err != nil {
+		return false, err
+	}
+	if parseErr != nil {
+		return false, parseErr
+	}
+	if res == nil {
+		return false, errors.New("rate limit script returned a nil response")
+	}
+	return *res == 1, nil
+}
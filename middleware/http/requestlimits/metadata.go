@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestlimits
+
+import (
+	"errors"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultMaxBodySize         = 4 << 20
+	defaultMaxHeaderSize       = 1 << 20
+	defaultMaxDecompressedSize = 16 << 20
+	defaultMaxExpansionRatio   = 100
+)
+
+type requestLimitsMiddlewareMetadata struct {
+	// Maximum size of the request body in bytes, as received (before decompression).
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// Maximum total size of the request line and headers in bytes.
+	MaxHeaderSize int64 `json:"maxHeaderSize" mapstructure:"maxHeaderSize"`
+	// If true, request bodies encoded with gzip or deflate are decompressed before being passed to the app.
+	Decompress bool `json:"decompress" mapstructure:"decompress"`
+	// Maximum size of the request body in bytes after decompression.
+	MaxDecompressedSize int64 `json:"maxDecompressedSize" mapstructure:"maxDecompressedSize"`
+	// Maximum ratio between the size of the decompressed body and the size of the compressed body.
+	MaxExpansionRatio int64 `json:"maxExpansionRatio" mapstructure:"maxExpansionRatio"`
+}
+
+// Parse the component's metadata into the object.
+func (md *requestLimitsMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.MaxBodySize = defaultMaxBodySize
+	md.MaxHeaderSize = defaultMaxHeaderSize
+	md.Decompress = true
+	md.MaxDecompressedSize = defaultMaxDecompressedSize
+	md.MaxExpansionRatio = defaultMaxExpansionRatio
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.MaxBodySize <= 0 {
+		return errors.New("metadata property 'maxBodySize' must be greater than 0")
+	}
+	if md.MaxHeaderSize <= 0 {
+		return errors.New("metadata property 'maxHeaderSize' must be greater than 0")
+	}
+	if md.MaxDecompressedSize <= 0 {
+		return errors.New("metadata property 'maxDecompressedSize' must be greater than 0")
+	}
+	if md.MaxExpansionRatio <= 0 {
+		return errors.New("metadata property 'maxExpansionRatio' must be greater than 0")
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestlimits
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Bodies that decompress to less than this size are not subject to the expansion ratio limit, as small payloads can legitimately have very high compression ratios.
+const minExpansionCheckSize = 64 << 10
+
+var (
+	errBodyTooLarge        = errors.New("request body is too large")
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// NewRequestLimitsMiddleware returns a new request limits middleware.
+func NewRequestLimitsMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that enforces limits on the size of requests and safely decompresses request bodies.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &requestLimitsMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if headerSize(r) > meta.MaxHeaderSize {
+				m.logger.Debugf("Rejected request with headers larger than %d bytes", meta.MaxHeaderSize)
+				httputils.RespondWithError(w, http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+
+			err := meta.limitBody(r)
+			if err != nil {
+				m.logger.Debugf("Rejected request: %v", err)
+				switch {
+				case errors.Is(err, errBodyTooLarge):
+					httputils.RespondWithError(w, http.StatusRequestEntityTooLarge)
+				case errors.Is(err, errUnsupportedEncoding):
+					httputils.RespondWithError(w, http.StatusUnsupportedMediaType)
+				default:
+					httputils.RespondWithError(w, http.StatusBadRequest)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// headerSize returns the size in bytes of the request line and headers, as they are sent on the wire.
+func headerSize(r *http.Request) int64 {
+	// "METHOD URI PROTO\r\n" and "Host: host\r\n"
+	size := int64(len(r.Method) + len(r.RequestURI) + len(r.Proto) + 4)
+	size += int64(len(r.Host) + 8)
+	for k, vs := range r.Header {
+		for _, v := range vs {
+			// "Key: value\r\n"
+			size += int64(len(k) + len(v) + 4)
+		}
+	}
+	return size
+}
+
+// limitBody reads the request body enforcing the size limits, decompressing it if needed.
+// The body is replaced with the buffered (and decompressed) one.
+func (md *requestLimitsMiddlewareMetadata) limitBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if r.ContentLength > md.MaxBodySize {
+		return errBodyTooLarge
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, md.MaxBodySize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	if int64(len(body)) > md.MaxBodySize {
+		return errBodyTooLarge
+	}
+
+	encodings := contentEncodings(r.Header)
+	if !md.Decompress || len(encodings) == 0 {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+
+	decompressed, err := md.decompress(body, encodings)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(decompressed))
+	r.ContentLength = int64(len(decompressed))
+	r.Header.Set("Content-Length", strconv.Itoa(len(decompressed)))
+	r.Header.Del("Content-Encoding")
+	return nil
+}
+
+// decompress decodes the body applying the encodings in reverse order, enforcing the limits on the decompressed size and on the expansion ratio.
+func (md *requestLimitsMiddlewareMetadata) decompress(body []byte, encodings []string) ([]byte, error) {
+	var (
+		reader io.Reader = bytes.NewReader(body)
+		err    error
+	)
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			reader, err = gzip.NewReader(reader)
+		case "deflate":
+			reader, err = newDeflateReader(reader)
+		default:
+			return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encodings[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s body: %w", encodings[i], err)
+		}
+	}
+
+	limit := md.decompressedLimit(int64(len(body)))
+	decompressed, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress request body: %w", err)
+	}
+	if int64(len(decompressed)) > limit {
+		return nil, fmt.Errorf("%w: decompressed body exceeds %d bytes", errBodyTooLarge, limit)
+	}
+	return decompressed, nil
+}
+
+// decompressedLimit returns the maximum size of the decompressed body for a compressed body of the given size.
+func (md *requestLimitsMiddlewareMetadata) decompressedLimit(compressedSize int64) int64 {
+	limit := md.MaxDecompressedSize
+	// Avoid overflows when multiplying by the ratio
+	if compressedSize > 0 && md.MaxExpansionRatio <= limit/compressedSize {
+		limit = max(md.MaxExpansionRatio*compressedSize, minExpansionCheckSize)
+	}
+	return min(limit, md.MaxDecompressedSize)
+}
+
+// contentEncodings returns the list of encodings in the Content-Encoding header, in the order they were applied.
+func contentEncodings(header http.Header) []string {
+	var res []string
+	for _, v := range header.Values("Content-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			enc = strings.ToLower(strings.TrimSpace(enc))
+			if enc == "" || enc == "identity" {
+				continue
+			}
+			res = append(res, enc)
+		}
+	}
+	return res
+}
+
+// newDeflateReader returns a reader for "deflate" bodies.
+// Per RFC 9110 these use the zlib format, but some clients send raw deflate streams, so both are accepted.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := requestLimitsMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestlimits
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*requestLimitsMiddlewareMetadata, error) {
+		obj := &requestLimitsMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultMaxBodySize), md.MaxBodySize)
+		assert.Equal(t, int64(defaultMaxHeaderSize), md.MaxHeaderSize)
+		assert.True(t, md.Decompress)
+		assert.Equal(t, int64(defaultMaxDecompressedSize), md.MaxDecompressedSize)
+		assert.Equal(t, int64(defaultMaxExpansionRatio), md.MaxExpansionRatio)
+	})
+
+	t.Run("invalid ratio", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"maxExpansionRatio": "0",
+		})
+		require.ErrorContains(t, err, "maxExpansionRatio")
+	})
+}
+
+func TestRequestLimitsMiddleware(t *testing.T) {
+	newHandler := func(props map[string]string) http.Handler {
+		handler, err := NewRequestLimitsMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Encoding", r.Header.Get("Content-Encoding"))
+			_, _ = io.Copy(w, r.Body)
+		}))
+	}
+	do := func(h http.Handler, body []byte, encoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		if encoding != "" {
+			r.Header.Set("Content-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	compress := func(t *testing.T, encoding string, data []byte) []byte {
+		var (
+			buf bytes.Buffer
+			w   io.WriteCloser
+		)
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "zlib":
+			w = zlib.NewWriter(&buf)
+		case "flate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		}
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	t.Run("body within limit", func(t *testing.T) {
+		h := newHandler(map[string]string{"maxBodySize": "16"})
+		res := do(h, []byte("hello world"), "")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "hello world", res.Body.String())
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		h := newHandler(map[string]string{"maxBodySize": "4"})
+		res := do(h, []byte("hello world"), "")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("headers too large", func(t *testing.T) {
+		h := newHandler(map[string]string{"maxHeaderSize": "256"})
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Large", strings.Repeat("a", 256))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, w.Code)
+	})
+
+	t.Run("decompress", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		for encoding, header := range map[string]string{"gzip": "gzip", "zlib": "deflate", "flate": "deflate"} {
+			res := do(h, compress(t, encoding, []byte("hello world")), header)
+			assert.Equal(t, http.StatusOK, res.Code, encoding)
+			assert.Equal(t, "hello world", res.Body.String(), encoding)
+			assert.Empty(t, res.Header().Get("X-Content-Encoding"), encoding)
+		}
+	})
+
+	t.Run("multiple encodings", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		body := compress(t, "gzip", compress(t, "zlib", []byte("hello world")))
+		res := do(h, body, "deflate, gzip")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "hello world", res.Body.String())
+	})
+
+	t.Run("decompression disabled", func(t *testing.T) {
+		h := newHandler(map[string]string{"decompress": "false"})
+		body := compress(t, "gzip", []byte("hello world"))
+		res := do(h, body, "gzip")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, body, res.Body.Bytes())
+		assert.Equal(t, "gzip", res.Header().Get("X-Content-Encoding"))
+	})
+
+	t.Run("decompressed size exceeds limit", func(t *testing.T) {
+		h := newHandler(map[string]string{
+			"maxDecompressedSize": "1024",
+			"maxExpansionRatio":   "1000000",
+		})
+		res := do(h, compress(t, "gzip", bytes.Repeat([]byte("a"), 2048)), "gzip")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("decompression bomb", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		// 1MB of zeros compresses to about 1KB
+		res := do(h, compress(t, "gzip", make([]byte, 1<<20)), "gzip")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("small body with high ratio", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		data := make([]byte, 32<<10)
+		res := do(h, compress(t, "gzip", data), "gzip")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, data, res.Body.Bytes())
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		res := do(h, []byte("hello world"), "br")
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	})
+
+	t.Run("invalid compressed body", func(t *testing.T) {
+		h := newHandler(map[string]string{})
+		res := do(h, []byte("hello world"), "gzip")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultConfigKeyPrefix    = "sentinel."
+	defaultConfigPollInterval = 30 * time.Second
+	defaultDaprHTTPPort       = "3500"
+	// Timeout for each request to the Dapr configuration API, so a hung sidecar doesn't block the polling loop
+	configRequestTimeout = 10 * time.Second
+)
+
+// ruleKind describes a type of Sentinel rules that can be loaded from a configuration store.
+type ruleKind struct {
+	name       string
+	newHandler func() datasource.PropertyHandler
+	static     func(meta *middlewareMetadata) string
+}
+
+var ruleKinds = []ruleKind{
+	{
+		name: "flowRules",
+		newHandler: func() datasource.PropertyHandler {
+			return datasource.NewFlowRulesHandler(datasource.FlowRuleJsonArrayParser)
+		},
+		static: func(meta *middlewareMetadata) string { return meta.FlowRules },
+	},
+	{
+		name: "circuitBreakerRules",
+		newHandler: func() datasource.PropertyHandler {
+			return datasource.NewCircuitBreakerRulesHandler(datasource.CircuitBreakerRuleJsonArrayParser)
+		},
+		static: func(meta *middlewareMetadata) string { return meta.CircuitBreakerRules },
+	},
+	{
+		name: "hotSpotParamRules",
+		newHandler: func() datasource.PropertyHandler {
+			return datasource.NewHotSpotParamRulesHandler(datasource.HotSpotParamRuleJsonArrayParser)
+		},
+		static: func(meta *middlewareMetadata) string { return meta.HotSpotParamRules },
+	},
+	{
+		name: "isolationRules",
+		newHandler: func() datasource.PropertyHandler {
+			return datasource.NewIsolationRulesHandler(datasource.IsolationRuleJsonArrayParser)
+		},
+		static: func(meta *middlewareMetadata) string { return meta.IsolationRules },
+	},
+	{
+		name: "systemRules",
+		newHandler: func() datasource.PropertyHandler {
+			return datasource.NewSystemRulesHandler(datasource.SystemRuleJsonArrayParser)
+		},
+		static: func(meta *middlewareMetadata) string { return meta.SystemRules },
+	},
+}
+
+// configStoreRule tracks the rules of a kind loaded from the configuration store.
+type configStoreRule struct {
+	key    string
+	static string
+	ds     *datasource.Base
+	// Value currently applied from the configuration store; nil if the key is not in the store.
+	current *string
+}
+
+// configStoreSource loads Sentinel rules from a Dapr configuration store, polling it for updates.
+type configStoreSource struct {
+	client   *http.Client
+	url      string
+	apiToken string
+	interval time.Duration
+	rules    []*configStoreRule
+	logger   logger.Logger
+}
+
+// configurationItem is an item returned by the Dapr configuration API.
+type configurationItem struct {
+	Value   string `json:"value"`
+	Version string `json:"version"`
+}
+
+func newConfigStoreSource(meta *middlewareMetadata, log logger.Logger) *configStoreSource {
+	endpoint := meta.DaprHTTPEndpoint
+	if endpoint == "" {
+		port := os.Getenv("DAPR_HTTP_PORT")
+		if port == "" {
+			port = defaultDaprHTTPPort
+		}
+		endpoint = "http://localhost:" + port
+	}
+
+	s := &configStoreSource{
+		client:   &http.Client{Timeout: configRequestTimeout},
+		apiToken: os.Getenv("DAPR_API_TOKEN"),
+		interval: meta.ConfigPollInterval,
+		rules:    make([]*configStoreRule, len(ruleKinds)),
+		logger:   log,
+	}
+	query := url.Values{}
+	for i, kind := range ruleKinds {
+		ds := &datasource.Base{}
+		ds.AddPropertyHandler(kind.newHandler())
+		s.rules[i] = &configStoreRule{
+			key:    meta.ConfigKeyPrefix + kind.name,
+			static: kind.static(meta),
+			ds:     ds,
+		}
+		query.Add("key", s.rules[i].key)
+	}
+	s.url = endpoint + "/v1.0/configuration/" + url.PathEscape(meta.ConfigStore) + "?" + query.Encode()
+
+	return s
+}
+
+// run polls the configuration store until the context is canceled.
+func (s *configStoreSource) run(ctx context.Context) {
+	// The first load is performed in background too, as the Dapr API may not be ready while the middleware is initialized
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		err := s.load(ctx)
+		if err != nil {
+			s.logger.Warnf("Failed to load sentinel rules from the configuration store: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// load fetches the rules from the configuration store and applies the ones that changed.
+// Rules whose key is removed from the store fall back to the ones set in the metadata.
+func (s *configStoreSource) load(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	if s.apiToken != "" {
+		req.Header.Set("dapr-api-token", s.apiToken)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status code %d", res.StatusCode)
+	}
+
+	items := map[string]configurationItem{}
+	err = json.NewDecoder(res.Body).Decode(&items)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, rule := range s.rules {
+		var (
+			value   string
+			current *string
+		)
+		if item, ok := items[rule.key]; ok {
+			value = item.Value
+			current = &value
+		} else if rule.current != nil {
+			value = rule.static
+		} else {
+			// Key not in the store and rules already from the metadata
+			continue
+		}
+		if current != nil && rule.current != nil && *current == *rule.current {
+			continue
+		}
+
+		err = rule.ds.Handle([]byte(value))
+		if err != nil {
+			s.logger.Errorf("Failed to apply sentinel rules from configuration key '%s': %v", rule.key, err)
+			continue
+		}
+		rule.current = current
+		s.logger.Infof("Updated sentinel rules from configuration key '%s'", rule.key)
+	}
+
+	return nil
+}
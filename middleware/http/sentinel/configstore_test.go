@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestConfigStoreSource(t *testing.T) {
+	const resource = "GET:/v1.0/configstore/test"
+	staticRules := `[{"resource": "` + resource + `", "threshold": 5, "tokenCalculateStrategy": 0, "controlBehavior": 0}]`
+	storeRules := `[{"resource": "` + resource + `", "threshold": 20, "tokenCalculateStrategy": 0, "controlBehavior": 0}]`
+
+	var items atomic.Value
+	items.Store(map[string]configurationItem{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.0/configuration/mystore", r.URL.Path)
+		assert.Contains(t, r.URL.Query()["key"], "sentinel.flowRules")
+		assert.Equal(t, "mytoken", r.Header.Get("dapr-api-token"))
+		_ = json.NewEncoder(w).Encode(items.Load())
+	}))
+	defer srv.Close()
+
+	meta := &middlewareMetadata{
+		FlowRules:          staticRules,
+		ConfigStore:        "mystore",
+		ConfigKeyPrefix:    defaultConfigKeyPrefix,
+		ConfigPollInterval: defaultConfigPollInterval,
+		DaprHTTPEndpoint:   srv.URL,
+	}
+	m, _ := NewMiddleware(logger.NewLogger("sentinel.test")).(*Middleware)
+	require.NoError(t, m.loadSentinelRules(meta))
+	t.Setenv("DAPR_API_TOKEN", "mytoken")
+	s := newConfigStoreSource(meta, m.logger)
+	assert.Equal(t, configRequestTimeout, s.client.Timeout)
+
+	threshold := func() float64 {
+		rules := flow.GetRulesOfResource(resource)
+		require.Len(t, rules, 1)
+		return rules[0].Threshold
+	}
+
+	t.Run("key not in store keeps static rules", func(t *testing.T) {
+		require.NoError(t, s.load(context.Background()))
+		assert.InDelta(t, 5, threshold(), 0)
+	})
+
+	t.Run("rules from store", func(t *testing.T) {
+		items.Store(map[string]configurationItem{
+			"sentinel.flowRules": {Value: storeRules, Version: "1"},
+		})
+		require.NoError(t, s.load(context.Background()))
+		assert.InDelta(t, 20, threshold(), 0)
+	})
+
+	t.Run("key removed from store restores static rules", func(t *testing.T) {
+		items.Store(map[string]configurationItem{})
+		require.NoError(t, s.load(context.Background()))
+		assert.InDelta(t, 5, threshold(), 0)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"configStore":        "mystore",
+			"configPollInterval": "0",
+		}}})
+		require.Error(t, err)
+	})
+}
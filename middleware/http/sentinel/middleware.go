@@ -15,9 +15,11 @@ package sentinel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"time"
 
 	sentinel "github.com/alibaba/sentinel-golang/api"
 	"github.com/alibaba/sentinel-golang/core/base"
@@ -40,6 +42,11 @@ type middlewareMetadata struct {
 	HotSpotParamRules   string `yaml:"hotSpotParamRules" mapstructure:"hotSpotParamRules"`
 	IsolationRules      string `yaml:"isolationRules" mapstructure:"isolationRules"`
 	SystemRules         string `yaml:"systemRules" mapstructure:"systemRules"`
+	// Dynamic rules
+	ConfigStore        string        `json:"configStore" mapstructure:"configStore"`
+	ConfigKeyPrefix    string        `json:"configKeyPrefix" mapstructure:"configKeyPrefix"`
+	ConfigPollInterval time.Duration `json:"configPollInterval" mapstructure:"configPollInterval"`
+	DaprHTTPEndpoint   string        `json:"daprHTTPEndpoint" mapstructure:"daprHTTPEndpoint"`
 }
 
 // NewMiddleware returns a new sentinel middleware.
@@ -53,7 +60,7 @@ type Middleware struct {
 }
 
 // GetHandler returns the HTTP handler provided by sentinel middleware.
-func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+func (m *Middleware) GetHandler(ctx context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
 	var (
 		meta *middlewareMetadata
 		err  error
@@ -75,6 +82,10 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 		return nil, err
 	}
 
+	if meta.ConfigStore != "" {
+		go newConfigStoreSource(meta, m.logger).run(ctx)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			resourceName := r.Method + ":" + r.URL.Path
@@ -150,11 +161,17 @@ func (m *Middleware) newSentinelConfig(metadata *middlewareMetadata) *config.Ent
 }
 
 func getNativeMetadata(metadata middleware.Metadata) (*middlewareMetadata, error) {
-	var md middlewareMetadata
+	md := middlewareMetadata{
+		ConfigKeyPrefix:    defaultConfigKeyPrefix,
+		ConfigPollInterval: defaultConfigPollInterval,
+	}
 	err := kitmd.DecodeMetadata(metadata.Properties, &md)
 	if err != nil {
 		return nil, err
 	}
+	if md.ConfigStore != "" && md.ConfigPollInterval <= 0 {
+		return nil, errors.New("metadata property 'configPollInterval' must be greater than 0")
+	}
 	return &md, nil
 }
 
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+const (
+	schemeCustom = "custom"
+	schemeGitHub = "github"
+	schemeStripe = "stripe"
+	schemeSlack  = "slack"
+
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+
+	defaultSignatureHeader    = "X-Signature"
+	defaultTimestampTolerance = 5 * time.Minute
+	defaultMaxBodySize        = 4 << 20
+)
+
+type hmacMiddlewareMetadata struct {
+	// Signing scheme: "custom", "github", "stripe", or "slack".
+	Scheme string `json:"scheme" mapstructure:"scheme"`
+	// Secret used to compute the signatures.
+	// This should be read from a secret store using a secretKeyRef.
+	Secret string `json:"secret" mapstructure:"secret"`
+	// Name of the header that contains the signature (custom scheme only).
+	SignatureHeader string `json:"signatureHeader" mapstructure:"signatureHeader"`
+	// Prefix of the signature in the header, such as "sha256=" (custom scheme only).
+	SignaturePrefix string `json:"signaturePrefix" mapstructure:"signaturePrefix"`
+	// Hash algorithm: "sha1", "sha256", or "sha512" (custom scheme only).
+	Algorithm string `json:"algorithm" mapstructure:"algorithm"`
+	// Encoding of the signature: "hex" or "base64" (custom scheme only).
+	Encoding string `json:"encoding" mapstructure:"encoding"`
+	// Name of the header that contains the Unix timestamp of the request (custom scheme only).
+	// When set, the signed message is "<timestamp>.<body>".
+	TimestampHeader string `json:"timestampHeader" mapstructure:"timestampHeader"`
+	// Maximum difference between the timestamp of the request and the current time.
+	// Set to 0 to disable the check.
+	TimestampTolerance time.Duration `json:"timestampTolerance" mapstructure:"timestampTolerance"`
+	// Maximum size of request bodies, in bytes.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+
+	// Internal properties
+	hashFn func() hash.Hash `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *hmacMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.Scheme = schemeCustom
+	md.SignatureHeader = defaultSignatureHeader
+	md.Algorithm = "sha256"
+	md.Encoding = encodingHex
+	md.TimestampTolerance = defaultTimestampTolerance
+	md.MaxBodySize = defaultMaxBodySize
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.Secret == "" {
+		return errors.New("metadata property 'secret' is required")
+	}
+	if md.TimestampTolerance < 0 {
+		return errors.New("metadata property 'timestampTolerance' must not be negative")
+	}
+	if md.MaxBodySize <= 0 {
+		return errors.New("metadata property 'maxBodySize' must be greater than 0")
+	}
+
+	md.Scheme = strings.ToLower(md.Scheme)
+	switch md.Scheme {
+	case schemeCustom:
+		// Nop
+	case schemeGitHub:
+		md.SignatureHeader = "X-Hub-Signature-256"
+		md.SignaturePrefix = "sha256="
+		md.Algorithm = "sha256"
+		md.Encoding = encodingHex
+		md.TimestampHeader = ""
+	case schemeStripe:
+		md.SignatureHeader = "Stripe-Signature"
+		md.Algorithm = "sha256"
+		md.Encoding = encodingHex
+	case schemeSlack:
+		md.SignatureHeader = "X-Slack-Signature"
+		md.SignaturePrefix = "v0="
+		md.Algorithm = "sha256"
+		md.Encoding = encodingHex
+		md.TimestampHeader = "X-Slack-Request-Timestamp"
+	default:
+		return fmt.Errorf("invalid value for metadata property 'scheme': %s", md.Scheme)
+	}
+
+	if md.SignatureHeader == "" {
+		return errors.New("metadata property 'signatureHeader' must not be empty")
+	}
+	switch strings.ToLower(md.Algorithm) {
+	case "sha1":
+		md.hashFn = sha1.New
+	case "sha256":
+		md.hashFn = sha256.New
+	case "sha512":
+		md.hashFn = sha512.New
+	default:
+		return fmt.Errorf("invalid value for metadata property 'algorithm': %s", md.Algorithm)
+	}
+	md.Encoding = strings.ToLower(md.Encoding)
+	if md.Encoding != encodingHex && md.Encoding != encodingBase64 {
+		return fmt.Errorf("invalid value for metadata property 'encoding': %s", md.Encoding)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+var errBodyTooLarge = errors.New("body is too large")
+
+// NewSignatureMiddleware returns a new HMAC signature verification middleware.
+func NewSignatureMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Middleware is a middleware that verifies HMAC signatures of requests.
+type Middleware struct {
+	logger logger.Logger
+	now    func() time.Time
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &hmacMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				var err error
+				body, err = io.ReadAll(io.LimitReader(r.Body, meta.MaxBodySize+1))
+				_ = r.Body.Close()
+				if err != nil {
+					m.logger.Errorf("Failed to read request body: %v", err)
+					httputils.RespondWithError(w, http.StatusInternalServerError)
+					return
+				}
+				if int64(len(body)) > meta.MaxBodySize {
+					httputils.RespondWithError(w, http.StatusRequestEntityTooLarge)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			err := m.verify(meta, r.Header, body)
+			if err != nil {
+				m.logger.Debugf("Signature verification failed: %v", err)
+				httputils.RespondWithError(w, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// verify checks the signature of the request.
+func (m *Middleware) verify(meta *hmacMiddlewareMetadata, header http.Header, body []byte) error {
+	signatures, timestamp, err := getSignatures(meta, header)
+	if err != nil {
+		return err
+	}
+
+	if timestamp != "" && meta.TimestampTolerance > 0 {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: %w", err)
+		}
+		diff := m.now().Sub(time.Unix(ts, 0))
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > meta.TimestampTolerance {
+			return errors.New("timestamp is outside of the tolerance")
+		}
+	}
+
+	mac := hmac.New(meta.hashFn, []byte(meta.Secret))
+	switch {
+	case meta.Scheme == schemeSlack:
+		mac.Write([]byte("v0:" + timestamp + ":"))
+	case timestamp != "":
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, sig := range signatures {
+		var decoded []byte
+		if meta.Encoding == encodingBase64 {
+			decoded, err = base64.StdEncoding.DecodeString(sig)
+		} else {
+			decoded, err = hex.DecodeString(sig)
+		}
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match")
+}
+
+// getSignatures returns the signatures and the timestamp included in the request headers.
+func getSignatures(meta *hmacMiddlewareMetadata, header http.Header) (signatures []string, timestamp string, err error) {
+	val := header.Get(meta.SignatureHeader)
+	if val == "" {
+		return nil, "", fmt.Errorf("missing header %s", meta.SignatureHeader)
+	}
+
+	if meta.Scheme == schemeStripe {
+		// Format is "t=<timestamp>,v1=<signature>[,v1=<signature>...]"
+		for _, part := range strings.Split(val, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				signatures = append(signatures, v)
+			}
+		}
+		if timestamp == "" || len(signatures) == 0 {
+			return nil, "", errors.New("invalid Stripe-Signature header")
+		}
+		return signatures, timestamp, nil
+	}
+
+	sig, ok := strings.CutPrefix(strings.TrimSpace(val), meta.SignaturePrefix)
+	if !ok {
+		return nil, "", fmt.Errorf("signature does not have the prefix %s", meta.SignaturePrefix)
+	}
+	if meta.TimestampHeader != "" {
+		timestamp = header.Get(meta.TimestampHeader)
+		if timestamp == "" {
+			return nil, "", fmt.Errorf("missing header %s", meta.TimestampHeader)
+		}
+	}
+	return []string{sig}, timestamp, nil
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := hmacMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
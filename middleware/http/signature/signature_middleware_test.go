@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	testSecret = "s3cr3t"
+	testBody   = `{"hello":"world"}`
+)
+
+func sign(hashFn func() hash.Hash, msg string) []byte {
+	mac := hmac.New(hashFn, []byte(testSecret))
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	nowStr := strconv.FormatInt(now.Unix(), 10)
+	oldStr := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	newHandler := func(props map[string]string) http.Handler {
+		props["secret"] = testSecret
+		m := NewSignatureMiddleware(logger.NewLogger("test")).(*Middleware)
+		m.now = func() time.Time { return now }
+		handler, err := m.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: props,
+		}})
+		require.NoError(t, err)
+		return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The body must still be readable
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != testBody {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	do := func(h http.Handler, headers map[string]string) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testBody))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("github", func(t *testing.T) {
+		h := newHandler(map[string]string{"scheme": "github"})
+		sig := "sha256=" + hex.EncodeToString(sign(sha256.New, testBody))
+		assert.Equal(t, http.StatusNoContent, do(h, map[string]string{"X-Hub-Signature-256": sig}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"X-Hub-Signature-256": "sha256=00"}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{}))
+	})
+
+	t.Run("stripe", func(t *testing.T) {
+		h := newHandler(map[string]string{"scheme": "stripe"})
+		sig := hex.EncodeToString(sign(sha256.New, nowStr+"."+testBody))
+		assert.Equal(t, http.StatusNoContent, do(h, map[string]string{"Stripe-Signature": "t=" + nowStr + ",v1=00,v1=" + sig}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"Stripe-Signature": "t=" + nowStr + ",v1=00"}))
+
+		// Replayed request
+		oldSig := hex.EncodeToString(sign(sha256.New, oldStr+"."+testBody))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"Stripe-Signature": "t=" + oldStr + ",v1=" + oldSig}))
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		h := newHandler(map[string]string{"scheme": "slack"})
+		sig := "v0=" + hex.EncodeToString(sign(sha256.New, "v0:"+nowStr+":"+testBody))
+		assert.Equal(t, http.StatusNoContent, do(h, map[string]string{"X-Slack-Signature": sig, "X-Slack-Request-Timestamp": nowStr}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"X-Slack-Signature": sig}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"X-Slack-Signature": sig, "X-Slack-Request-Timestamp": oldStr}))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		h := newHandler(map[string]string{
+			"signatureHeader": "X-My-Signature",
+			"algorithm":       "sha512",
+			"encoding":        "base64",
+			"timestampHeader": "X-My-Timestamp",
+		})
+		sig := base64.StdEncoding.EncodeToString(sign(sha512.New, nowStr+"."+testBody))
+		assert.Equal(t, http.StatusNoContent, do(h, map[string]string{"X-My-Signature": sig, "X-My-Timestamp": nowStr}))
+		assert.Equal(t, http.StatusUnauthorized, do(h, map[string]string{"X-My-Signature": sig, "X-My-Timestamp": oldStr}))
+
+		t.Run("timestamp check disabled", func(t *testing.T) {
+			h := newHandler(map[string]string{
+				"signatureHeader":    "X-My-Signature",
+				"timestampHeader":    "X-My-Timestamp",
+				"timestampTolerance": "0",
+			})
+			sig := hex.EncodeToString(sign(sha256.New, oldStr+"."+testBody))
+			assert.Equal(t, http.StatusNoContent, do(h, map[string]string{"X-My-Signature": sig, "X-My-Timestamp": oldStr}))
+		})
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		h := newHandler(map[string]string{"scheme": "github", "maxBodySize": "5"})
+		sig := "sha256=" + hex.EncodeToString(sign(sha256.New, testBody))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, do(h, map[string]string{"X-Hub-Signature-256": sig}))
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*hmacMiddlewareMetadata, error) {
+		obj := &hmacMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	_, err := newMetadata(map[string]string{})
+	require.ErrorContains(t, err, "secret")
+
+	_, err = newMetadata(map[string]string{"secret": "foo", "scheme": "other"})
+	require.ErrorContains(t, err, "scheme")
+
+	_, err = newMetadata(map[string]string{"secret": "foo", "algorithm": "md5"})
+	require.ErrorContains(t, err, "algorithm")
+
+	_, err = newMetadata(map[string]string{"secret": "foo", "encoding": "base32"})
+	require.ErrorContains(t, err, "encoding")
+
+	md, err := newMetadata(map[string]string{"secret": "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultSignatureHeader, md.SignatureHeader)
+	assert.Equal(t, defaultTimestampTolerance, md.TimestampTolerance)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/components-contrib/middleware"
+	mdutils "github.com/dapr/kit/metadata"
+)
+
+// Default maximum size of bodies that are transformed, in bytes.
+const defaultMaxBodySize = 4 << 20
+
+type transformMiddlewareMetadata struct {
+	// Map of request header names to CEL expressions, as a JSON or YAML-encoded string.
+	RequestHeaders string `json:"requestHeaders" mapstructure:"requestHeaders"`
+	// Map of query string parameters to CEL expressions, as a JSON or YAML-encoded string.
+	RequestQuery string `json:"requestQuery" mapstructure:"requestQuery"`
+	// CEL expression that returns the new JSON body of the request.
+	RequestBody string `json:"requestBody" mapstructure:"requestBody"`
+	// Map of response header names to CEL expressions, as a JSON or YAML-encoded string.
+	ResponseHeaders string `json:"responseHeaders" mapstructure:"responseHeaders"`
+	// CEL expression that returns the new JSON body of the response.
+	ResponseBody string `json:"responseBody" mapstructure:"responseBody"`
+	// Maximum size of bodies that are read, in bytes.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// If true, responses larger than MaxBodySize are sent untransformed instead of being replaced with an error.
+	StreamLargeResponses bool `json:"streamLargeResponses" mapstructure:"streamLargeResponses"`
+
+	// Internal properties
+	requestHeaders  map[string]string `json:"-" mapstructure:"-"`
+	requestQuery    map[string]string `json:"-" mapstructure:"-"`
+	responseHeaders map[string]string `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *transformMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set default values
+	md.MaxBodySize = defaultMaxBodySize
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.MaxBodySize <= 0 {
+		return errors.New("metadata property 'maxBodySize' must be greater than 0")
+	}
+	md.requestHeaders, err = parseExpressionsMap("requestHeaders", md.RequestHeaders, true)
+	if err != nil {
+		return err
+	}
+	md.requestQuery, err = parseExpressionsMap("requestQuery", md.RequestQuery, false)
+	if err != nil {
+		return err
+	}
+	md.responseHeaders, err = parseExpressionsMap("responseHeaders", md.ResponseHeaders, true)
+	if err != nil {
+		return err
+	}
+
+	if len(md.requestHeaders) == 0 && len(md.requestQuery) == 0 && md.RequestBody == "" &&
+		len(md.responseHeaders) == 0 && md.ResponseBody == "" {
+		return errors.New("at least one transformation must be configured")
+	}
+
+	return nil
+}
+
+func parseExpressionsMap(property string, val string, isHeader bool) (map[string]string, error) {
+	res := map[string]string{}
+	if val == "" {
+		return res, nil
+	}
+
+	parsed := map[string]string{}
+	err := yaml.Unmarshal([]byte(val), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metadata property '%s' as JSON or YAML: %w", property, err)
+	}
+	for key, expr := range parsed {
+		if key == "" || expr == "" {
+			return nil, fmt.Errorf("invalid key/value pair in metadata property '%s': must not be empty", property)
+		}
+		if isHeader {
+			key = http.CanonicalHeaderKey(key)
+		}
+		res[key] = expr
+	}
+	return res, nil
+}
@@ -0,0 +1,436 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+var errBodyTooLarge = errors.New("body is too large")
+
+// NewTransformMiddleware returns a new request/response transformation middleware.
+func NewTransformMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Middleware is a middleware that transforms requests and responses using CEL expressions.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// transformer contains the compiled programs.
+type transformer struct {
+	requestHeaders  map[string]cel.Program
+	requestQuery    map[string]cel.Program
+	requestBody     cel.Program
+	responseHeaders map[string]cel.Program
+	responseBody    cel.Program
+	maxBodySize     int64
+
+	streamLargeResponses bool
+}
+
+// GetHandler retruns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta := &transformMiddlewareMetadata{}
+	err := meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newTransformer(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqVars, err := t.transformRequest(r)
+			if err != nil {
+				m.handleError(w, "request", err)
+				return
+			}
+
+			if !t.transformsResponse() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &bufferedResponseWriter{
+				w:           w,
+				header:      make(http.Header),
+				status:      http.StatusOK,
+				maxSize:     t.maxBodySize,
+				passthrough: t.streamLargeResponses,
+			}
+			next.ServeHTTP(rec, r)
+			if rec.overflow {
+				// If the response was streamed, it has already been sent untransformed
+				if !t.streamLargeResponses {
+					m.logger.Errorf("Failed to transform response: body is larger than %d bytes", t.maxBodySize)
+					httputils.RespondWithError(w, http.StatusBadGateway)
+				}
+				return
+			}
+
+			err = t.transformResponse(reqVars, rec)
+			if err != nil {
+				m.handleError(w, "response", err)
+				return
+			}
+
+			for k, v := range rec.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body.Bytes())
+		})
+	}, nil
+}
+
+func (m *Middleware) handleError(w http.ResponseWriter, target string, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		httputils.RespondWithError(w, http.StatusRequestEntityTooLarge)
+		return
+	}
+	m.logger.Errorf("Failed to transform %s: %v", target, err)
+	httputils.RespondWithError(w, http.StatusInternalServerError)
+}
+
+func newTransformer(meta *transformMiddlewareMetadata) (*transformer, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("response", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	t := &transformer{
+		maxBodySize:          meta.MaxBodySize,
+		streamLargeResponses: meta.StreamLargeResponses,
+	}
+	t.requestHeaders, err = compileMap(env, "requestHeaders", meta.requestHeaders)
+	if err != nil {
+		return nil, err
+	}
+	t.requestQuery, err = compileMap(env, "requestQuery", meta.requestQuery)
+	if err != nil {
+		return nil, err
+	}
+	t.responseHeaders, err = compileMap(env, "responseHeaders", meta.responseHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if meta.RequestBody != "" {
+		t.requestBody, err = compile(env, meta.RequestBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression in metadata property 'requestBody': %w", err)
+		}
+	}
+	if meta.ResponseBody != "" {
+		t.responseBody, err = compile(env, meta.ResponseBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression in metadata property 'responseBody': %w", err)
+		}
+	}
+	return t, nil
+}
+
+func compileMap(env *cel.Env, property string, exprs map[string]string) (map[string]cel.Program, error) {
+	res := make(map[string]cel.Program, len(exprs))
+	for key, expr := range exprs {
+		prg, err := compile(env, expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for '%s' in metadata property '%s': %w", key, property, err)
+		}
+		res[key] = prg
+	}
+	return res, nil
+}
+
+func compile(env *cel.Env, expr string) (cel.Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	return env.Program(ast)
+}
+
+func (t *transformer) transformsResponse() bool {
+	return len(t.responseHeaders) > 0 || t.responseBody != nil
+}
+
+// transformRequest applies the transformations to the request, and returns the variables describing the original request.
+func (t *transformer) transformRequest(r *http.Request) (map[string]any, error) {
+	reqVars := map[string]any{
+		"method":  r.Method,
+		"path":    r.URL.Path,
+		"headers": flattenValues(r.Header),
+		"query":   flattenValues(r.URL.Query()),
+		"body":    nil,
+	}
+	if isJSON(r.Header.Get("content-type")) && r.Body != nil {
+		body, err := readLimited(r.Body, t.maxBodySize)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			var parsed any
+			err = json.Unmarshal(body, &parsed)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse JSON body: %w", err)
+			}
+			reqVars["body"] = parsed
+		}
+	}
+
+	vars := map[string]any{
+		"request":  reqVars,
+		"response": map[string]any{},
+	}
+
+	for name, prg := range t.requestHeaders {
+		val, remove, err := evalString(prg, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression for header '%s': %w", name, err)
+		}
+		if remove {
+			r.Header.Del(name)
+		} else {
+			r.Header.Set(name, val)
+		}
+	}
+
+	if len(t.requestQuery) > 0 {
+		query := r.URL.Query()
+		for name, prg := range t.requestQuery {
+			val, remove, err := evalString(prg, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate expression for query parameter '%s': %w", name, err)
+			}
+			if remove {
+				query.Del(name)
+			} else {
+				query.Set(name, val)
+			}
+		}
+		r.URL.RawQuery = query.Encode()
+		r.RequestURI = r.URL.RequestURI()
+	}
+
+	if t.requestBody != nil {
+		body, err := evalJSON(t.requestBody, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression for body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("content-type", "application/json")
+		r.Header.Set("content-length", strconv.Itoa(len(body)))
+	}
+
+	return reqVars, nil
+}
+
+// transformResponse applies the transformations to the buffered response.
+func (t *transformer) transformResponse(reqVars map[string]any, rec *bufferedResponseWriter) error {
+	resVars := map[string]any{
+		"status":  int64(rec.status),
+		"headers": flattenValues(rec.header),
+		"body":    nil,
+	}
+	if rec.body.Len() > 0 && isJSON(rec.header.Get("content-type")) {
+		var parsed any
+		err := json.Unmarshal(rec.body.Bytes(), &parsed)
+		if err != nil {
+			return fmt.Errorf("failed to parse JSON body: %w", err)
+		}
+		resVars["body"] = parsed
+	}
+
+	vars := map[string]any{
+		"request":  reqVars,
+		"response": resVars,
+	}
+
+	for name, prg := range t.responseHeaders {
+		val, remove, err := evalString(prg, vars)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate expression for header '%s': %w", name, err)
+		}
+		if remove {
+			rec.header.Del(name)
+		} else {
+			rec.header.Set(name, val)
+		}
+	}
+
+	if t.responseBody != nil {
+		body, err := evalJSON(t.responseBody, vars)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate expression for body: %w", err)
+		}
+		rec.body.Reset()
+		rec.body.Write(body)
+		rec.header.Set("content-type", "application/json")
+	}
+	if rec.header.Get("content-length") != "" {
+		rec.header.Set("content-length", strconv.Itoa(rec.body.Len()))
+	}
+
+	return nil
+}
+
+// evalString evaluates an expression that returns a string.
+// If the expression returns null, the value must be removed.
+func evalString(prg cel.Program, vars map[string]any) (val string, remove bool, err error) {
+	res, _, err := prg.Eval(vars)
+	if err != nil {
+		return "", false, err
+	}
+	switch v := res.(type) {
+	case types.Null:
+		return "", true, nil
+	case types.String:
+		return string(v), false, nil
+	default:
+		str, ok := res.ConvertToType(types.StringType).(types.String)
+		if !ok {
+			return "", false, fmt.Errorf("cannot convert result of type %s to string", res.Type().TypeName())
+		}
+		return string(str), false, nil
+	}
+}
+
+// evalJSON evaluates an expression and returns its result encoded as JSON.
+func evalJSON(prg cel.Program, vars map[string]any) ([]byte, error) {
+	res, _, err := prg.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	native, err := res.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, fmt.Errorf("result cannot be encoded as JSON: %w", err)
+	}
+	return protojson.Marshal(native.(*structpb.Value))
+}
+
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, errBodyTooLarge
+	}
+	return body, nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (len(mediaType) > 5 && mediaType[len(mediaType)-5:] == "+json")
+}
+
+// flattenValues returns a map with the first value for each key.
+func flattenValues(values map[string][]string) map[string]string {
+	res := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			res[k] = v[0]
+		}
+	}
+	return res
+}
+
+// bufferedResponseWriter is a http.ResponseWriter that buffers the response so it can be transformed.
+// If the body grows larger than maxSize, it's either written to w untransformed if passthrough is set, or discarded.
+type bufferedResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	maxSize     int64
+	passthrough bool
+	overflow    bool
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if b.overflow {
+		if b.passthrough {
+			return b.w.Write(data)
+		}
+		return 0, errBodyTooLarge
+	}
+	if int64(b.body.Len()+len(data)) <= b.maxSize {
+		return b.body.Write(data)
+	}
+
+	b.overflow = true
+	if !b.passthrough {
+		b.body.Reset()
+		return 0, errBodyTooLarge
+	}
+	// Send what was buffered so far, then stream the rest of the response
+	for k, v := range b.header {
+		b.w.Header()[k] = v
+	}
+	b.w.WriteHeader(b.status)
+	_, err := b.w.Write(b.body.Bytes())
+	b.body.Reset()
+	if err != nil {
+		return 0, err
+	}
+	return b.w.Write(data)
+}
+
+func (m *Middleware) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := transformMiddlewareMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.MiddlewareType)
+	return
+}
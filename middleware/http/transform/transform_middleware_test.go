@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func getHandler(t *testing.T, props map[string]string, next http.Handler) http.Handler {
+	t.Helper()
+	handler, err := NewTransformMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+		Properties: props,
+	}})
+	require.NoError(t, err)
+	return handler(next)
+}
+
+func TestParseMetadata(t *testing.T) {
+	newMetadata := func(md map[string]string) (*transformMiddlewareMetadata, error) {
+		obj := &transformMiddlewareMetadata{}
+		err := obj.fromMetadata(middleware.Metadata{Base: metadata.Base{
+			Name:       "test",
+			Properties: md,
+		}})
+		return obj, err
+	}
+
+	t.Run("headers as YAML", func(t *testing.T) {
+		md, err := newMetadata(map[string]string{
+			"requestHeaders": "x-foo: \"'bar'\"\n",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"X-Foo": "'bar'"}, md.requestHeaders)
+		assert.Equal(t, int64(defaultMaxBodySize), md.MaxBodySize)
+	})
+
+	t.Run("no transformations", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid map", func(t *testing.T) {
+		_, err := newMetadata(map[string]string{
+			"requestQuery": "not a map",
+		})
+		require.ErrorContains(t, err, "requestQuery")
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := NewTransformMiddleware(logger.NewLogger("test")).GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"requestBody": "request.body +",
+			},
+		}})
+		require.ErrorContains(t, err, "requestBody")
+	})
+}
+
+func TestTransformRequest(t *testing.T) {
+	var (
+		receivedHeaders http.Header
+		receivedQuery   string
+		receivedBody    string
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header.Clone()
+		receivedQuery = r.URL.RawQuery
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+	})
+
+	h := getHandler(t, map[string]string{
+		"requestHeaders": `{"x-method": "request.method", "x-remove": "null", "x-user": "request.body.user"}`,
+		"requestQuery":   `{"page": "string(int(request.query.page) + 1)", "debug": "null"}`,
+		"requestBody":    `{"name": request.body.user, "count": request.body.items.size()}`,
+	}, next)
+
+	r := httptest.NewRequest(http.MethodPost, "/foo?page=1&debug=true", strings.NewReader(`{"user":"alice","items":[1,2,3]}`))
+	r.Header.Set("content-type", "application/json")
+	r.Header.Set("x-remove", "1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "POST", receivedHeaders.Get("X-Method"))
+	assert.Equal(t, "alice", receivedHeaders.Get("X-User"))
+	assert.Empty(t, receivedHeaders.Get("X-Remove"))
+	assert.Equal(t, "page=2", receivedQuery)
+	assert.JSONEq(t, `{"name":"alice","count":3}`, receivedBody)
+
+	t.Run("body too large", func(t *testing.T) {
+		h := getHandler(t, map[string]string{
+			"requestBody": `request.body`,
+			"maxBodySize": "10",
+		}, next)
+		r := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(`{"user":"alice","items":[1,2,3]}`))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("evaluation error", func(t *testing.T) {
+		h := getHandler(t, map[string]string{
+			"requestHeaders": `{"x-user": "request.body.missing"}`,
+		}, next)
+		r := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(`{}`))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestTransformResponse(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("x-internal", "secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"123","internal":true}`))
+	})
+
+	h := getHandler(t, map[string]string{
+		"responseHeaders": `{"x-internal": "null", "x-status": "string(response.status)", "x-path": "request.path"}`,
+		"responseBody":    `{"id": response.body.id}`,
+	}, next)
+
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("X-Internal"))
+	assert.Equal(t, "201", w.Header().Get("X-Status"))
+	assert.Equal(t, "/items", w.Header().Get("X-Path"))
+	assert.JSONEq(t, `{"id":"123"}`, w.Body.String())
+}
+
+func TestTransformLargeResponse(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("x-internal", "secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"123",`))
+		_, _ = w.Write([]byte(`"internal":true}`))
+	})
+	props := map[string]string{
+		"responseHeaders": `{"x-internal": "null"}`,
+		"responseBody":    `{"id": response.body.id}`,
+		"maxBodySize":     "20",
+	}
+
+	t.Run("replaced with an error", func(t *testing.T) {
+		h := getHandler(t, props, next)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Empty(t, w.Header().Get("X-Internal"))
+		assert.NotContains(t, w.Body.String(), "123")
+	})
+
+	t.Run("streamed untransformed", func(t *testing.T) {
+		props["streamLargeResponses"] = "true"
+		h := getHandler(t, props, next)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "secret", w.Header().Get("X-Internal"))
+		assert.JSONEq(t, `{"id":"123","internal":true}`, w.Body.String())
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errGuestTimeout wraps context.DeadlineExceeded, which wazero requires to close the module.
+var errGuestTimeout = fmt.Errorf("wasm: guest execution exceeded the request timeout: %w", context.DeadlineExceeded)
+
+// guestBudget is a context that is done once the guest has executed for longer than the request timeout.
+// The clock is paused while the next handler runs, so slow downstream handlers don't interrupt the guest.
+// Cancellation of the request context is not propagated, as it would close the guest instance.
+type guestBudget struct {
+	context.Context
+
+	done      chan struct{}
+	lock      sync.Mutex
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+	err       error
+}
+
+func newGuestBudget(parent context.Context, timeout time.Duration) *guestBudget {
+	b := &guestBudget{
+		Context:   parent,
+		done:      make(chan struct{}),
+		remaining: timeout,
+	}
+	b.resume()
+	return b
+}
+
+// Done implements context.Context.
+func (b *guestBudget) Done() <-chan struct{} {
+	return b.done
+}
+
+// Err implements context.Context.
+func (b *guestBudget) Err() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.err
+}
+
+// exceeded returns true if the guest ran out of time.
+func (b *guestBudget) exceeded() bool {
+	return b.Err() != nil
+}
+
+// pause stops the clock.
+func (b *guestBudget) pause() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.timer != nil && b.timer.Stop() {
+		b.remaining -= time.Since(b.started)
+	}
+	b.timer = nil
+}
+
+// resume starts the clock again with the remaining time.
+func (b *guestBudget) resume() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.err != nil || b.timer != nil {
+		return
+	}
+	b.started = time.Now()
+	b.timer = time.AfterFunc(b.remaining, b.expire)
+}
+
+func (b *guestBudget) expire() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.err != nil {
+		return
+	}
+	b.err = errGuestTimeout
+	b.timer = nil
+	close(b.done)
+}
@@ -16,15 +16,22 @@ package wasm
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/http-wasm/http-wasm-host-go/api"
 	"github.com/http-wasm/http-wasm-host-go/handler"
 	wasmnethttp "github.com/http-wasm/http-wasm-host-go/handler/nethttp"
+	"github.com/tetratelabs/wazero"
 
+	"github.com/dapr/components-contrib/common/httputils"
 	"github.com/dapr/components-contrib/common/wasm"
 	mdutils "github.com/dapr/components-contrib/metadata"
 	dapr "github.com/dapr/components-contrib/middleware"
@@ -39,7 +46,40 @@ type middleware struct {
 type Metadata struct {
 	// GuestConfig is an optional configuration passed to WASM guests.
 	// Users can pass an arbitrary string to be parsed by the guest code.
+	// Guests compiled against the proxy-wasm ABI receive it as the plugin
+	// configuration.
 	GuestConfig string `mapstructure:"guestConfig"`
+
+	// HotReloadInterval is the interval for checking the module at the URL
+	// for changes. When the module changes, it replaces the current one
+	// without restarting. Disabled when zero.
+	HotReloadInterval time.Duration `mapstructure:"hotReloadInterval"`
+
+	// MaxMemoryPages limits the memory of each guest instance, in pages of
+	// 64KiB. Modules requiring more memory fail to compile. Unlimited when zero.
+	MaxMemoryPages uint32 `mapstructure:"maxMemoryPages"`
+
+	// RequestTimeout limits the time the guest can execute for each request,
+	// excluding the time spent in the next handler. When exceeded, the request
+	// fails and the guest instance is discarded. Disabled when zero.
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+
+	// MaxBodySize limits the size of the request and response bodies buffered
+	// for guests compiled against the proxy-wasm ABI, in bytes.
+	MaxBodySize int64 `mapstructure:"maxBodySize"`
+}
+
+// maxMemoryPages is the maximum number of memory pages of a 32-bit WebAssembly module.
+const maxMemoryPages = 65536
+
+// defaultMaxBodySize is the default value of MaxBodySize.
+const defaultMaxBodySize = 4 << 20
+
+// Export names that identify a module compiled against the proxy-wasm ABI.
+var proxyWasmABIExports = []string{
+	"proxy_abi_version_0_1_0",
+	"proxy_abi_version_0_2_0",
+	"proxy_abi_version_0_2_1",
 }
 
 func NewMiddleware(logger logger.Logger) dapr.Middleware {
@@ -63,25 +103,154 @@ func (m *middleware) getHandler(ctx context.Context, metadata dapr.Metadata) (*r
 	}
 
 	// parse wasm middleware specific metadata
-	var middlewareMeta Metadata
+	middlewareMeta := Metadata{
+		MaxBodySize: defaultMaxBodySize,
+	}
 	err = kitmd.DecodeMetadata(metadata.Base, &middlewareMeta)
 	if err != nil {
 		return nil, fmt.Errorf("wasm: failed to parse wasm middleware metadata: %w", err)
 	}
+	if middlewareMeta.MaxMemoryPages > maxMemoryPages {
+		return nil, fmt.Errorf("wasm: maxMemoryPages must not be greater than %d", maxMemoryPages)
+	}
+	if middlewareMeta.RequestTimeout < 0 {
+		return nil, errors.New("wasm: requestTimeout must not be negative")
+	}
+	if middlewareMeta.MaxBodySize <= 0 {
+		return nil, errors.New("wasm: maxBodySize must be greater than zero")
+	}
+
+	// The compilation cache is shared by the guests compiled for this handler,
+	// so replacing a guest with the same module doesn't compile it again.
+	cache := wazero.NewCompilationCache()
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCompilationCache(cache).
+		WithCloseOnContextDone(middlewareMeta.RequestTimeout > 0)
+	if middlewareMeta.MaxMemoryPages > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(middlewareMeta.MaxMemoryPages)
+	}
+
+	rh := &requestHandler{
+		logger:         m.logger,
+		requestTimeout: middlewareMeta.RequestTimeout,
+		cache:          cache,
+		newGuest: func(ctx context.Context, meta *wasm.InitMetadata) (*guest, error) {
+			return m.newGuest(ctx, meta, &middlewareMeta, runtimeConfig)
+		},
+	}
+	g, err := rh.newGuest(ctx, meta)
+	if err != nil {
+		cache.Close(ctx)
+		return nil, err
+	}
+	rh.mw.Store(g)
+
+	if middlewareMeta.HotReloadInterval > 0 {
+		rh.closeCh = make(chan struct{})
+		rh.wg.Add(1)
+		go func() {
+			defer rh.wg.Done()
+			m.watchGuest(ctx, rh, metadata, middlewareMeta.HotReloadInterval)
+		}()
+	}
+
+	return rh, nil
+}
+
+// newGuest compiles the guest module, with the host of the ABI it's compiled against.
+func (m *middleware) newGuest(ctx context.Context, meta *wasm.InitMetadata, middlewareMeta *Metadata, runtimeConfig wazero.RuntimeConfig) (*guest, error) {
+	g := &guest{
+		meta:   meta,
+		digest: sha256.Sum256(meta.Guest),
+	}
+	var err error
+	if isProxyWasm(ctx, meta.Guest) {
+		g.mw, err = newProxyWasmMiddleware(ctx, meta.Guest, meta.GuestName, []byte(middlewareMeta.GuestConfig), middlewareMeta.MaxBodySize, runtimeConfig,
+			wasm.NewModuleConfig(meta).
+				WithStdout(&g.stdout). // reset per request
+				WithStderr(&g.stderr), // reset per request
+			m.logger)
+		if err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
 
-	var stdout, stderr bytes.Buffer
-	mw, err := wasmnethttp.NewMiddleware(ctx, meta.Guest,
+	g.mw, err = wasmnethttp.NewMiddleware(ctx, meta.Guest,
 		handler.Logger(m),
+		handler.Runtime(func(ctx context.Context) (wazero.Runtime, error) {
+			return wazero.NewRuntimeWithConfig(ctx, runtimeConfig), nil
+		}),
 		handler.ModuleConfig(wasm.NewModuleConfig(meta).
 			WithName(meta.GuestName).
-			WithStdout(&stdout).  // reset per request
-			WithStderr(&stderr)), // reset per request
+			WithStdout(&g.stdout).  // reset per request
+			WithStderr(&g.stderr)), // reset per request
 		handler.GuestConfig([]byte(middlewareMeta.GuestConfig)))
 	if err != nil {
 		return nil, err
 	}
+	return g, nil
+}
+
+// watchGuest periodically loads the guest module from the URL, and replaces the current one when it changes.
+func (m *middleware) watchGuest(ctx context.Context, rh *requestHandler, metadata dapr.Metadata, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rh.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		meta, err := wasm.GetInitMetadata(ctx, metadata.Base)
+		if err != nil {
+			m.logger.Warnf("wasm: failed to load guest for hot reload: %v", err)
+			continue
+		}
+		if sha256.Sum256(meta.Guest) == rh.mw.Load().digest {
+			continue
+		}
+
+		g, err := rh.newGuest(ctx, meta)
+		if err != nil {
+			m.logger.Errorf("wasm: failed to compile updated guest, keeping the current one: %v", err)
+			continue
+		}
+		old := rh.mw.Swap(g)
+		m.logger.Infof("wasm: reloaded guest %s", meta.GuestName)
 
-	return &requestHandler{mw: mw, logger: m.logger, stdout: &stdout, stderr: &stderr}, nil
+		// Close the previous guest after in-flight requests complete
+		if err = old.close(); err != nil {
+			m.logger.Warnf("wasm: failed to close previous guest: %v", err)
+		}
+	}
+}
+
+// isProxyWasm returns true if the module exports the functions of the proxy-wasm ABI.
+func isProxyWasm(ctx context.Context, bin []byte) bool {
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer rt.Close(ctx)
+
+	compiled, err := rt.CompileModule(ctx, bin)
+	if err != nil {
+		return false
+	}
+	exports := compiled.ExportedFunctions()
+	for _, name := range proxyWasmABIExports {
+		if _, ok := exports[name]; ok {
+			return true
+		}
+	}
+	for name := range exports {
+		if strings.HasPrefix(name, "proxy_on_") {
+			return true
+		}
+	}
+	return false
 }
 
 // IsEnabled implements the same method as documented on api.Logger.
@@ -118,40 +287,174 @@ func (m *middleware) Log(_ context.Context, level api.LogLevel, message string)
 	}
 }
 
+// guestMiddleware is the host of a guest, for the ABI it's compiled against.
+type guestMiddleware interface {
+	NewHandler(ctx context.Context, next http.Handler) http.Handler
+	Close(ctx context.Context) error
+}
+
+// guest is a compiled guest module.
+type guest struct {
+	mw             guestMiddleware
+	stdout, stderr bytes.Buffer
+	meta           *wasm.InitMetadata
+	digest         [sha256.Size]byte
+
+	// Set once a replacement for the guest is being compiled
+	replaced atomic.Bool
+
+	// Requests hold a read lock while in-flight, so the guest is closed only after they complete
+	lock   sync.RWMutex
+	closed bool
+}
+
+// acquire locks the guest for a request, returning false if the guest has been closed.
+func (g *guest) acquire() bool {
+	g.lock.RLock()
+	if g.closed {
+		g.lock.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (g *guest) release() {
+	g.lock.RUnlock()
+}
+
+func (g *guest) close() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return g.mw.Close(ctx)
+}
+
 type requestHandler struct {
-	mw             wasmnethttp.Middleware
+	mw             atomic.Pointer[guest]
+	newGuest       func(ctx context.Context, meta *wasm.InitMetadata) (*guest, error)
+	cache          wazero.CompilationCache
+	requestTimeout time.Duration
 	logger         logger.Logger
-	stdout, stderr *bytes.Buffer
+	closeCh        chan struct{}
+	wg             sync.WaitGroup
 }
 
 func (rh *requestHandler) requestHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		h := rh.mw.NewHandler(r.Context(), next)
+		// Retry if the guest has been replaced and closed in the meanwhile.
+		// Replacements are stored before the previous guest is closed, so a closed guest that's still current means the handler is closed.
+		g := rh.mw.Load()
+		for !g.acquire() {
+			current := rh.mw.Load()
+			if current == g {
+				httputils.RespondWithError(w, http.StatusServiceUnavailable)
+				return
+			}
+			g = current
+		}
+		defer g.release()
+
 		defer func() {
-			rh.stdout.Reset()
-			rh.stderr.Reset()
+			g.stdout.Reset()
+			g.stderr.Reset()
 		}()
 
-		h.ServeHTTP(w, r)
+		if rh.requestTimeout > 0 {
+			rh.serveWithTimeout(g, next, w, r)
+		} else {
+			g.mw.NewHandler(r.Context(), next).ServeHTTP(w, r)
+		}
 
-		if stdout := rh.stdout.String(); len(stdout) > 0 {
+		if stdout := g.stdout.String(); len(stdout) > 0 {
 			rh.logger.Debugf("wasm stdout: %s", stdout)
 		}
-		if stderr := rh.stderr.String(); len(stderr) > 0 {
+		if stderr := g.stderr.String(); len(stderr) > 0 {
 			rh.logger.Debugf("wasm stderr: %s", stderr)
 		}
 	})
 }
 
+// serveWithTimeout runs the guest with a budget of requestTimeout.
+func (rh *requestHandler) serveWithTimeout(g *guest, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	parent := r.Context()
+	budget := newGuestBudget(parent, rh.requestTimeout)
+	defer budget.pause()
+
+	// The next handler runs outside of the budget, with the original context
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budget.pause()
+		defer budget.resume()
+		next.ServeHTTP(w, r.WithContext(parent))
+	})
+
+	defer func() {
+		if !budget.exceeded() {
+			return
+		}
+		// An error from handling the response is raised as a panic
+		if recovered := recover(); recovered != nil {
+			rh.logger.Debugf("wasm: recovered from guest error: %v", recovered)
+		}
+		rh.logger.Warnf("wasm: guest %s exceeded the request timeout of %v", g.meta.GuestName, rh.requestTimeout)
+		rh.replaceGuest(g)
+	}()
+
+	g.mw.NewHandler(parent, inner).ServeHTTP(w, r.WithContext(budget))
+}
+
+// replaceGuest replaces a guest whose instance was closed after exceeding the request timeout,
+// as closed instances would otherwise be reused by later requests.
+func (rh *requestHandler) replaceGuest(g *guest) {
+	if !g.replaced.CompareAndSwap(false, true) {
+		return
+	}
+
+	ng, err := rh.newGuest(context.Background(), g.meta)
+	if err != nil {
+		rh.logger.Errorf("wasm: failed to replace guest: %v", err)
+		g.replaced.Store(false)
+		return
+	}
+	if !rh.mw.CompareAndSwap(g, ng) {
+		// Already replaced by a hot reload
+		_ = ng.close()
+		return
+	}
+
+	// Close the previous guest after in-flight requests, including this one, complete
+	go func() {
+		if err := g.close(); err != nil {
+			rh.logger.Warnf("wasm: failed to close previous guest: %v", err)
+		}
+	}()
+}
+
 // Close implements io.Closer
 func (rh *requestHandler) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	return rh.mw.Close(ctx)
+	if rh.closeCh != nil {
+		close(rh.closeCh)
+		rh.wg.Wait()
+	}
+	g := rh.mw.Load()
+	if g == nil {
+		return nil
+	}
+	err := g.close()
+	if rh.cache != nil {
+		err = errors.Join(err, rh.cache.Close(context.Background()))
+	}
+	return err
 }
 
 func (m *middleware) GetComponentMetadata() (metadataInfo mdutils.MetadataMap) {
 	metadataStruct := wasm.InitMetadata{}
 	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.MiddlewareType)
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(Metadata{}), &metadataInfo, mdutils.MiddlewareType)
 	return
 }
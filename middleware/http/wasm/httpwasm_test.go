@@ -20,7 +20,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/http-wasm/http-wasm-host-go/api"
 	"github.com/stretchr/testify/require"
@@ -94,6 +97,37 @@ func Test_middleware_getHandler(t *testing.T) {
 				"url": "file://example/router.wasm",
 			}},
 		},
+		{
+			name: "maxMemoryPages too large",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":            "file://example/router.wasm",
+				"maxMemoryPages": "65537",
+			}},
+			expectedErr: "wasm: maxMemoryPages must not be greater than 65536",
+		},
+		{
+			name: "guest within maxMemoryPages",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":            ts.URL + "/example.wasm",
+				"maxMemoryPages": "1",
+			}},
+		},
+		{
+			name: "guest over maxMemoryPages",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":            "file://example/router.wasm",
+				"maxMemoryPages": "1",
+			}},
+			expectedErr: "wasm: error compiling guest: section memory: min 2 pages (128 Ki) over limit of 1 pages (64 Ki)",
+		},
+		{
+			name: "negative requestTimeout",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":            "file://example/router.wasm",
+				"requestTimeout": "-1s",
+			}},
+			expectedErr: "wasm: requestTimeout must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,7 +136,7 @@ func Test_middleware_getHandler(t *testing.T) {
 			h, err := m.getHandler(context.Background(), dapr.Metadata{Base: tc.metadata})
 			if tc.expectedErr == "" {
 				require.NoError(t, err)
-				require.NotNil(t, h.mw)
+				require.NotNil(t, h.mw.Load())
 			} else {
 				require.EqualError(t, err, tc.expectedErr)
 			}
@@ -132,6 +166,145 @@ func Test_Example(t *testing.T) {
 	require.Empty(t, buf.String())
 }
 
+func Test_hotReload(t *testing.T) {
+	l := logger.NewLogger(t.Name())
+	l.SetOutput(io.Discard)
+
+	router, err := os.ReadFile("example/router.wasm")
+	require.NoError(t, err)
+	guestPath := filepath.Join(t.TempDir(), "guest.wasm")
+	require.NoError(t, os.WriteFile(guestPath, router, 0o600))
+
+	meta := metadata.Base{Properties: map[string]string{
+		"url":               "file://" + guestPath,
+		"hotReloadInterval": "10ms",
+	}}
+	m := &middleware{logger: l}
+	h, err := m.getHandler(context.Background(), dapr.Metadata{Base: meta})
+	require.NoError(t, err)
+	defer h.Close()
+
+	initial := h.mw.Load()
+
+	// Replace the guest with one that rewrites the path
+	require.NoError(t, os.WriteFile(guestPath, exampleWasmBin, 0o600))
+	require.Eventually(t, func() bool {
+		return h.mw.Load() != initial
+	}, time.Second, 10*time.Millisecond)
+
+	handler := h.requestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/v1.0/hi?name=panda", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, "/v1.0/hello?name=teddy", httputils.RequestURI(r))
+
+	// The previous guest is closed once replaced
+	require.False(t, initial.acquire())
+}
+
+func Test_requestTimeout(t *testing.T) {
+	l := logger.NewLogger(t.Name())
+	l.SetOutput(io.Discard)
+	m := &middleware{logger: l}
+
+	t.Run("guest exceeding the timeout is replaced", func(t *testing.T) {
+		meta := metadata.Base{Properties: map[string]string{
+			"url":            "file://internal/testdata/loop.wasm",
+			"requestTimeout": "50ms",
+		}}
+		h, err := m.getHandler(context.Background(), dapr.Metadata{Base: meta})
+		require.NoError(t, err)
+		defer h.Close()
+
+		var called bool
+		handler := h.requestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		// Each request gets a working instance, as the closed ones are discarded
+		for range 2 {
+			initial := h.mw.Load()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil))
+			require.Equal(t, http.StatusInternalServerError, w.Code)
+			require.NotSame(t, initial, h.mw.Load())
+			require.Eventually(t, func() bool {
+				return !initial.acquire()
+			}, time.Second, 10*time.Millisecond)
+		}
+		require.False(t, called)
+	})
+
+	t.Run("time in the next handler is not counted", func(t *testing.T) {
+		meta := metadata.Base{Properties: map[string]string{
+			"url":            "file://internal/testdata/rewrite.wasm",
+			"requestTimeout": "50ms",
+		}}
+		h, err := m.getHandler(context.Background(), dapr.Metadata{Base: meta})
+		require.NoError(t, err)
+		defer h.Close()
+
+		initial := h.mw.Load()
+		var uri string
+		handler := h.requestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			require.NoError(t, r.Context().Err())
+			uri = httputils.RequestURI(r)
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi?name=panda", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "/v1.0/hello?name=teddy", uri)
+		require.Same(t, initial, h.mw.Load())
+	})
+}
+
+func Test_guestBudget(t *testing.T) {
+	b := newGuestBudget(context.Background(), 50*time.Millisecond)
+	b.pause()
+	time.Sleep(100 * time.Millisecond)
+	require.False(t, b.exceeded())
+
+	b.resume()
+	select {
+	case <-b.Done():
+	case <-time.After(time.Second):
+		t.Fatal("budget not exceeded")
+	}
+	require.True(t, b.exceeded())
+	require.ErrorIs(t, b.Err(), errGuestTimeout)
+}
+
+func Test_closedHandler(t *testing.T) {
+	l := logger.NewLogger(t.Name())
+	l.SetOutput(io.Discard)
+	m := &middleware{logger: l}
+
+	meta := metadata.Base{Properties: map[string]string{
+		"url": "file://internal/testdata/rewrite.wasm",
+	}}
+	h, err := m.getHandler(context.Background(), dapr.Metadata{Base: meta})
+	require.NoError(t, err)
+	require.NoError(t, h.Close())
+
+	handler := h.requestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler called")
+	}))
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil))
+		done <- w.Code
+	}()
+	select {
+	case code := <-done:
+		require.Equal(t, http.StatusServiceUnavailable, code)
+	case <-time.After(time.Second):
+		t.Fatal("request not completed")
+	}
+}
+
 func Test_ioCloser(t *testing.T) {
 	var _ io.Closer = &requestHandler{}
 }
@@ -0,0 +1,13 @@
+;; loop never returns from handle_request, to test the request timeout.
+(module $loop
+  ;; http-wasm guests are required to export "memory".
+  (memory (export "memory") 1 (; 1 page==64KB ;))
+
+  ;; handle_request loops forever.
+  (func (export "handle_request") (result (; ctx_next ;) i64)
+    (loop $forever (br $forever))
+    (return (i64.const 1)))
+
+  ;; handle_response is no-op as this is a request-only handler.
+  (func (export "handle_response") (param $reqCtx i32) (param $is_error i32))
+)
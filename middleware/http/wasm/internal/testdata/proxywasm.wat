@@ -0,0 +1,144 @@
+;; proxywasm is a guest compiled against the proxy-wasm ABI, which modifies the
+;; request and response, or denies requests with the "x-deny" header. The plugin
+;; configuration is returned in the "x-proxy-wasm-config" response header.
+(module $proxywasm
+  (import "env" "proxy_log" (func $proxy_log
+    (param $level i32) (param $msg i32) (param $msg_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_get_header_map_value" (func $get_header_map_value
+    (param $map_type i32) (param $key i32) (param $key_len i32)
+    (param $ret_value i32) (param $ret_value_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_get_buffer_bytes" (func $get_buffer_bytes
+    (param $buffer_type i32) (param $start i32) (param $max_size i32)
+    (param $ret_data i32) (param $ret_data_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_add_header_map_value" (func $add_header_map_value
+    (param $map_type i32) (param $key i32) (param $key_len i32)
+    (param $value i32) (param $value_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_replace_header_map_value" (func $replace_header_map_value
+    (param $map_type i32) (param $key i32) (param $key_len i32)
+    (param $value i32) (param $value_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_set_buffer_bytes" (func $set_buffer_bytes
+    (param $buffer_type i32) (param $start i32) (param $size i32)
+    (param $data i32) (param $data_len i32)
+    (result (; status ;) i32)))
+
+  (import "env" "proxy_send_local_response" (func $send_local_response
+    (param $status_code i32) (param $details i32) (param $details_len i32)
+    (param $body i32) (param $body_len i32)
+    (param $headers i32) (param $headers_len i32)
+    (param $grpc_status i32)
+    (result (; status ;) i32)))
+
+  (memory (export "memory") 1 (; 1 page==64KB ;))
+
+  (data (i32.const 0) "x-deny")
+  (data (i32.const 16) "denied")
+  (data (i32.const 32) "x-proxy-wasm")
+  (data (i32.const 48) "request")
+  (data (i32.const 64) "response")
+  (data (i32.const 80) ":path")
+  (data (i32.const 96) "/rewritten")
+  (data (i32.const 112) "prefix:")
+  (data (i32.const 128) "!")
+  (data (i32.const 144) "started")
+  (data (i32.const 160) "x-proxy-wasm-config")
+
+  ;; ret_ptr and ret_len are where host functions write their results.
+  (global $ret_ptr i32 (i32.const 256))
+  (global $ret_len i32 (i32.const 260))
+
+  ;; heap is the next address returned by proxy_on_memory_allocate. Memory is
+  ;; never freed, which is fine for tests.
+  (global $heap (mut i32) (i32.const 1024))
+
+  ;; config and config_len are the plugin configuration, read on configure.
+  (global $config (mut i32) (i32.const 0))
+  (global $config_len (mut i32) (i32.const 0))
+
+  (func (export "proxy_abi_version_0_2_1"))
+
+  (func (export "proxy_on_memory_allocate") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $heap))
+    (global.set $heap (i32.add (global.get $heap) (local.get $size)))
+    (local.get $ptr))
+
+  (func (export "proxy_on_context_create") (param $context_id i32) (param $parent_context_id i32))
+
+  (func (export "proxy_on_vm_start") (param $context_id i32) (param $vm_config_size i32) (result i32)
+    (drop (call $proxy_log (i32.const 2) (i32.const 144) (i32.const 7)))
+    (i32.const 1))
+
+  (func (export "proxy_on_configure") (param $context_id i32) (param $plugin_config_size i32) (result i32)
+    (if (i32.eqz (local.get $plugin_config_size))
+      (then (return (i32.const 1))))
+    (if (call $get_buffer_bytes
+          (i32.const 7) (i32.const 0) (local.get $plugin_config_size)
+          (global.get $ret_ptr) (global.get $ret_len))
+      (then (return (i32.const 0 (; failed ;)))))
+    (global.set $config (i32.load (global.get $ret_ptr)))
+    (global.set $config_len (i32.load (global.get $ret_len)))
+    (i32.const 1))
+
+  ;; proxy_on_request_headers denies the request if it has the "x-deny"
+  ;; header. Otherwise, it adds a header and rewrites the path.
+  (func (export "proxy_on_request_headers")
+    (param $context_id i32) (param $headers i32) (param $end_of_stream i32)
+    (result (; action ;) i32)
+
+    ;; status 0 is OK, which means the header was found
+    (if (i32.eqz (call $get_header_map_value
+          (i32.const 0) (i32.const 0) (i32.const 6)
+          (global.get $ret_ptr) (global.get $ret_len)))
+      (then
+        (drop (call $send_local_response
+          (i32.const 403) (i32.const 0) (i32.const 0)
+          (i32.const 16) (i32.const 6)
+          (i32.const 0) (i32.const 0)
+          (i32.const -1)))
+        (return (i32.const 1 (; pause ;)))))
+
+    (drop (call $add_header_map_value
+      (i32.const 0) (i32.const 32) (i32.const 12) (i32.const 48) (i32.const 7)))
+    (drop (call $replace_header_map_value
+      (i32.const 0) (i32.const 80) (i32.const 5) (i32.const 96) (i32.const 10)))
+    (i32.const 0 (; continue ;)))
+
+  ;; proxy_on_request_body appends "!" to the request body.
+  (func (export "proxy_on_request_body")
+    (param $context_id i32) (param $body_size i32) (param $end_of_stream i32)
+    (result (; action ;) i32)
+    (drop (call $set_buffer_bytes
+      (i32.const 0) (local.get $body_size) (i32.const 0) (i32.const 128) (i32.const 1)))
+    (i32.const 0 (; continue ;)))
+
+  ;; proxy_on_response_headers adds headers to the response.
+  (func (export "proxy_on_response_headers")
+    (param $context_id i32) (param $headers i32) (param $end_of_stream i32)
+    (result (; action ;) i32)
+    (drop (call $add_header_map_value
+      (i32.const 2) (i32.const 32) (i32.const 12) (i32.const 64) (i32.const 8)))
+    (if (global.get $config_len)
+      (then
+        (drop (call $add_header_map_value
+          (i32.const 2) (i32.const 160) (i32.const 19)
+          (global.get $config) (global.get $config_len)))))
+    (i32.const 0 (; continue ;)))
+
+  ;; proxy_on_response_body prepends "prefix:" to the response body.
+  (func (export "proxy_on_response_body")
+    (param $context_id i32) (param $body_size i32) (param $end_of_stream i32)
+    (result (; action ;) i32)
+    (drop (call $set_buffer_bytes
+      (i32.const 1) (i32.const 0) (i32.const 0) (i32.const 112) (i32.const 7)))
+    (i32.const 0 (; continue ;)))
+)
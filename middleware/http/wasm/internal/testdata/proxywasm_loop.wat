@@ -0,0 +1,19 @@
+;; proxywasm_loop is a guest compiled against the proxy-wasm ABI, which never
+;; returns from proxy_on_request_headers, to test the request timeout.
+(module $proxywasm_loop
+  (memory (export "memory") 1 (; 1 page==64KB ;))
+
+  (func (export "proxy_abi_version_0_2_1"))
+
+  (func (export "proxy_on_memory_allocate") (param $size i32) (result i32)
+    (i32.const 1024))
+
+  (func (export "proxy_on_context_create") (param $context_id i32) (param $parent_context_id i32))
+
+  ;; proxy_on_request_headers loops forever.
+  (func (export "proxy_on_request_headers")
+    (param $context_id i32) (param $headers i32) (param $end_of_stream i32)
+    (result (; action ;) i32)
+    (loop $forever (br $forever))
+    (i32.const 0 (; continue ;)))
+)
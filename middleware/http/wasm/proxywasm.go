@@ -0,0 +1,592 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero"
+	wazeroapi "github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/dapr/components-contrib/common/httputils"
+	"github.com/dapr/kit/logger"
+)
+
+// Context ID of the root context, which is created once for each instance of the guest.
+const proxyWasmRootContextID = 1
+
+// Actions returned by the HTTP callbacks of the guest.
+const (
+	proxyWasmActionContinue uint32 = 0
+	proxyWasmActionPause    uint32 = 1
+)
+
+// Export names that identify the supported versions of the proxy-wasm ABI.
+var proxyWasmSupportedABIExports = []string{
+	"proxy_abi_version_0_2_0",
+	"proxy_abi_version_0_2_1",
+}
+
+var errBodyTooLarge = errors.New("body is too large")
+
+var errProxyWasmPause = errors.New("the guest paused the request, which is not supported as requests are processed synchronously")
+
+// proxyWasmMiddleware is the host of guests compiled against the proxy-wasm ABI (https://github.com/proxy-wasm/spec), version 0.2.
+// Each instance of the guest is a VM with its own root context, and each request it handles gets a new HTTP context.
+// Bodies are buffered, so the body callbacks are called once with the whole body.
+type proxyWasmMiddleware struct {
+	runtime      wazero.Runtime
+	compiled     wazero.CompiledModule
+	moduleConfig wazero.ModuleConfig
+	pluginName   string
+	pluginConfig []byte
+	maxBodySize  int64
+	logger       logger.Logger
+
+	instanceCounter atomic.Uint64
+	lock            sync.Mutex
+	idle            []*proxyWasmInstance
+
+	// Shared data and metrics are shared by all the instances
+	sharedLock  sync.Mutex
+	sharedData  map[string]proxyWasmSharedValue
+	metricIDs   map[string]uint32
+	metricsData []int64
+}
+
+type proxyWasmSharedValue struct {
+	value []byte
+	cas   uint32
+}
+
+// proxyWasmInstance is an instance of the guest, which handles one request at a time.
+type proxyWasmInstance struct {
+	mod           wazeroapi.Module
+	nextContextID uint32
+
+	onContextCreate   wazeroapi.Function
+	onRequestHeaders  wazeroapi.Function
+	onRequestBody     wazeroapi.Function
+	onResponseHeaders wazeroapi.Function
+	onResponseBody    wazeroapi.Function
+	onDone            wazeroapi.Function
+	onLog             wazeroapi.Function
+	onDelete          wazeroapi.Function
+}
+
+// proxyWasmHTTPContext is the state of a request, which host functions read from the context of guest calls.
+type proxyWasmHTTPContext struct {
+	request         *http.Request
+	requestHeaders  proxyWasmHeaders
+	requestBody     []byte
+	responseHeaders proxyWasmHeaders
+	responseBody    []byte
+	// Set by the guest to respond instead of the next handler
+	localResponse *proxyWasmLocalResponse
+	// Set once the response has been written
+	responded bool
+}
+
+type proxyWasmLocalResponse struct {
+	status  int
+	headers proxyWasmHeaders
+	body    []byte
+}
+
+type proxyWasmHTTPContextKey struct{}
+
+func newProxyWasmMiddleware(ctx context.Context, guest []byte, pluginName string, pluginConfig []byte, maxBodySize int64, runtimeConfig wazero.RuntimeConfig, moduleConfig wazero.ModuleConfig, logger logger.Logger) (*proxyWasmMiddleware, error) {
+	m := &proxyWasmMiddleware{
+		runtime:      wazero.NewRuntimeWithConfig(ctx, runtimeConfig),
+		moduleConfig: moduleConfig.WithStartFunctions("_initialize", "_start"),
+		pluginName:   pluginName,
+		pluginConfig: pluginConfig,
+		maxBodySize:  maxBodySize,
+		logger:       logger,
+		sharedData:   map[string]proxyWasmSharedValue{},
+		metricIDs:    map[string]uint32{},
+	}
+
+	err := m.init(ctx, guest)
+	if err != nil {
+		_ = m.runtime.Close(ctx)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *proxyWasmMiddleware) init(ctx context.Context, guest []byte) error {
+	var err error
+	m.compiled, err = m.runtime.CompileModule(ctx, guest)
+	if err != nil {
+		return fmt.Errorf("wasm: error compiling guest: %w", err)
+	}
+	exports := m.compiled.ExportedFunctions()
+	supported := false
+	for _, name := range proxyWasmSupportedABIExports {
+		if _, ok := exports[name]; ok {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("wasm: the guest is compiled against an unsupported version of the proxy-wasm ABI; supported versions are exported as %s", strings.Join(proxyWasmSupportedABIExports, ", "))
+	}
+	for _, name := range []string{"proxy_on_context_create", "proxy_on_memory_allocate"} {
+		if _, ok := exports[name]; !ok {
+			return fmt.Errorf("wasm: guest doesn't export func[%s]", name)
+		}
+	}
+
+	for _, fn := range m.compiled.ImportedFunctions() {
+		if moduleName, _, _ := fn.Import(); moduleName == wasi_snapshot_preview1.ModuleName {
+			_, err = wasi_snapshot_preview1.Instantiate(ctx, m.runtime)
+			if err != nil {
+				return fmt.Errorf("wasm: error instantiating wasi: %w", err)
+			}
+			break
+		}
+	}
+	_, err = m.hostModule().Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("wasm: error instantiating host: %w", err)
+	}
+
+	// Eagerly create one instance, to fail fast
+	inst, err := m.newInstance(ctx)
+	if err != nil {
+		return err
+	}
+	m.idle = append(m.idle, inst)
+	return nil
+}
+
+// newInstance instantiates the guest and creates its root context.
+func (m *proxyWasmMiddleware) newInstance(ctx context.Context) (*proxyWasmInstance, error) {
+	name := strconv.FormatUint(m.instanceCounter.Add(1), 10)
+	mod, err := m.runtime.InstantiateModule(ctx, m.compiled, m.moduleConfig.WithName(name))
+	if err != nil {
+		return nil, fmt.Errorf("wasm: error instantiating guest: %w", err)
+	}
+	inst := &proxyWasmInstance{
+		mod:               mod,
+		nextContextID:     proxyWasmRootContextID + 1,
+		onContextCreate:   mod.ExportedFunction("proxy_on_context_create"),
+		onRequestHeaders:  mod.ExportedFunction("proxy_on_request_headers"),
+		onRequestBody:     mod.ExportedFunction("proxy_on_request_body"),
+		onResponseHeaders: mod.ExportedFunction("proxy_on_response_headers"),
+		onResponseBody:    mod.ExportedFunction("proxy_on_response_body"),
+		onDone:            mod.ExportedFunction("proxy_on_done"),
+		onLog:             mod.ExportedFunction("proxy_on_log"),
+		onDelete:          mod.ExportedFunction("proxy_on_delete"),
+	}
+
+	err = m.startInstance(ctx, inst)
+	if err != nil {
+		_ = mod.Close(ctx)
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (m *proxyWasmMiddleware) startInstance(ctx context.Context, inst *proxyWasmInstance) error {
+	_, err := inst.onContextCreate.Call(ctx, proxyWasmRootContextID, 0)
+	if err != nil {
+		return fmt.Errorf("wasm: error creating the root context: %w", err)
+	}
+	if fn := inst.mod.ExportedFunction("proxy_on_vm_start"); fn != nil {
+		ok, err := callBool(ctx, fn, proxyWasmRootContextID, 0)
+		if err != nil {
+			return fmt.Errorf("wasm: error starting the guest: %w", err)
+		}
+		if !ok {
+			return errors.New("wasm: the guest failed to start")
+		}
+	}
+	if fn := inst.mod.ExportedFunction("proxy_on_configure"); fn != nil {
+		ok, err := callBool(ctx, fn, proxyWasmRootContextID, uint64(len(m.pluginConfig)))
+		if err != nil {
+			return fmt.Errorf("wasm: error configuring the guest: %w", err)
+		}
+		if !ok {
+			return errors.New("wasm: the guest failed to configure")
+		}
+	}
+	return nil
+}
+
+func (m *proxyWasmMiddleware) getInstance(ctx context.Context) (*proxyWasmInstance, error) {
+	m.lock.Lock()
+	if n := len(m.idle); n > 0 {
+		inst := m.idle[n-1]
+		m.idle = m.idle[:n-1]
+		m.lock.Unlock()
+		return inst, nil
+	}
+	m.lock.Unlock()
+	return m.newInstance(ctx)
+}
+
+func (m *proxyWasmMiddleware) putInstance(inst *proxyWasmInstance) {
+	m.lock.Lock()
+	m.idle = append(m.idle, inst)
+	m.lock.Unlock()
+}
+
+// NewHandler returns a handler that runs the guest for each request, before and after the next handler.
+func (m *proxyWasmMiddleware) NewHandler(_ context.Context, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		inst, err := m.getInstance(ctx)
+		if err != nil {
+			m.logger.Errorf("wasm: %v", err)
+			httputils.RespondWithError(w, http.StatusInternalServerError)
+			return
+		}
+
+		hc := &proxyWasmHTTPContext{
+			request:        r,
+			requestHeaders: requestHeaders(r),
+		}
+		err = m.serve(context.WithValue(ctx, proxyWasmHTTPContextKey{}, hc), inst, hc, w, next)
+		if err == nil {
+			m.putInstance(inst)
+			return
+		}
+
+		// The state of the guest is unknown after a failure, so the instance is discarded
+		m.logger.Errorf("wasm: %v", err)
+		_ = inst.mod.Close(context.Background())
+		if !hc.responded {
+			httputils.RespondWithError(w, http.StatusInternalServerError)
+		}
+	})
+}
+
+// serve handles a request in a new HTTP context.
+func (m *proxyWasmMiddleware) serve(ctx context.Context, inst *proxyWasmInstance, hc *proxyWasmHTTPContext, w http.ResponseWriter, next http.Handler) (err error) {
+	id := inst.nextContextID
+	inst.nextContextID++
+	_, err = inst.onContextCreate.Call(ctx, uint64(id), proxyWasmRootContextID)
+	if err != nil {
+		return fmt.Errorf("error creating the HTTP context: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			err = inst.endContext(ctx, id)
+		}
+	}()
+
+	err = m.handleRequest(ctx, inst, id, hc, w)
+	if err != nil || hc.responded {
+		return err
+	}
+
+	// Buffer the response only if the guest handles it
+	if inst.onResponseHeaders == nil && inst.onResponseBody == nil {
+		hc.responded = true
+		next.ServeHTTP(w, hc.request)
+		return nil
+	}
+	rec := &proxyWasmResponseWriter{
+		header:  make(http.Header),
+		status:  http.StatusOK,
+		maxSize: m.maxBodySize,
+	}
+	next.ServeHTTP(rec, hc.request)
+	if rec.overflow {
+		m.logger.Errorf("wasm: response body is larger than %d bytes", m.maxBodySize)
+		hc.responded = true
+		httputils.RespondWithError(w, http.StatusBadGateway)
+		return nil
+	}
+
+	hc.responseHeaders = responseHeaders(rec.status, rec.header)
+	hc.responseBody = rec.body.Bytes()
+	err = m.handleResponse(ctx, inst, id, hc)
+	if err != nil {
+		return err
+	}
+	hc.responded = true
+	if hc.localResponse != nil {
+		writeLocalResponse(w, hc.localResponse)
+		return nil
+	}
+	writeResponse(w, hc.responseHeaders, hc.responseBody)
+	return nil
+}
+
+// handleRequest runs the request callbacks, and writes the response if the guest sent one.
+func (m *proxyWasmMiddleware) handleRequest(ctx context.Context, inst *proxyWasmInstance, id uint32, hc *proxyWasmHTTPContext, w http.ResponseWriter) error {
+	r := hc.request
+	hasBody := inst.onRequestBody != nil && r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+	if hasBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, m.maxBodySize+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		if int64(len(body)) > m.maxBodySize {
+			hc.responded = true
+			httputils.RespondWithError(w, http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		hc.requestBody = body
+		hasBody = len(body) > 0
+	}
+
+	var err error
+	action := proxyWasmActionContinue
+	if inst.onRequestHeaders != nil {
+		action, err = callAction(ctx, inst.onRequestHeaders, uint64(id), uint64(len(hc.requestHeaders)), endOfStream(!hasBody))
+		if err != nil {
+			return fmt.Errorf("error handling request headers: %w", err)
+		}
+	}
+	if hasBody && hc.localResponse == nil {
+		action, err = callAction(ctx, inst.onRequestBody, uint64(id), uint64(len(hc.requestBody)), endOfStream(true))
+		if err != nil {
+			return fmt.Errorf("error handling request body: %w", err)
+		}
+	}
+	if hc.localResponse != nil {
+		hc.responded = true
+		writeLocalResponse(w, hc.localResponse)
+		return nil
+	}
+	if action == proxyWasmActionPause {
+		return errProxyWasmPause
+	}
+
+	err = applyRequestHeaders(r, hc.requestHeaders)
+	if err != nil {
+		return err
+	}
+	if hc.requestBody != nil {
+		r.Body = io.NopCloser(bytes.NewReader(hc.requestBody))
+		r.ContentLength = int64(len(hc.requestBody))
+		r.Header.Set("content-length", strconv.Itoa(len(hc.requestBody)))
+	}
+	return nil
+}
+
+// handleResponse runs the response callbacks.
+func (m *proxyWasmMiddleware) handleResponse(ctx context.Context, inst *proxyWasmInstance, id uint32, hc *proxyWasmHTTPContext) error {
+	hasBody := inst.onResponseBody != nil && len(hc.responseBody) > 0
+
+	action := proxyWasmActionContinue
+	if inst.onResponseHeaders != nil {
+		var err error
+		action, err = callAction(ctx, inst.onResponseHeaders, uint64(id), uint64(len(hc.responseHeaders)), endOfStream(!hasBody))
+		if err != nil {
+			return fmt.Errorf("error handling response headers: %w", err)
+		}
+	}
+	if hc.localResponse != nil {
+		return nil
+	}
+	if hasBody {
+		var err error
+		action, err = callAction(ctx, inst.onResponseBody, uint64(id), uint64(len(hc.responseBody)), endOfStream(true))
+		if err != nil {
+			return fmt.Errorf("error handling response body: %w", err)
+		}
+	}
+	if action == proxyWasmActionPause && hc.localResponse == nil {
+		return errProxyWasmPause
+	}
+	return nil
+}
+
+// endContext runs the callbacks for the end of an HTTP context.
+func (inst *proxyWasmInstance) endContext(ctx context.Context, id uint32) error {
+	for _, fn := range []wazeroapi.Function{inst.onDone, inst.onLog, inst.onDelete} {
+		if fn == nil {
+			continue
+		}
+		if _, err := fn.Call(ctx, uint64(id)); err != nil {
+			return fmt.Errorf("error closing the HTTP context: %w", err)
+		}
+	}
+	return nil
+}
+
+// callAction calls a callback that returns an action.
+// Its parameters are truncated to the signature of the callback, which doesn't have end_of_stream in older versions of the ABI.
+func callAction(ctx context.Context, fn wazeroapi.Function, params ...uint64) (uint32, error) {
+	params = params[:min(len(params), len(fn.Definition().ParamTypes()))]
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return proxyWasmActionContinue, nil
+	}
+	return uint32(res[0]), nil //nolint:gosec
+}
+
+// callBool calls a callback that returns a boolean.
+func callBool(ctx context.Context, fn wazeroapi.Function, params ...uint64) (bool, error) {
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return false, err
+	}
+	return len(res) == 0 || uint32(res[0]) != 0, nil //nolint:gosec
+}
+
+func endOfStream(eos bool) uint64 {
+	if eos {
+		return 1
+	}
+	return 0
+}
+
+// Close implements the same method as documented on the http-wasm middleware.
+func (m *proxyWasmMiddleware) Close(ctx context.Context) error {
+	// Closing the runtime closes all the instances
+	return m.runtime.Close(ctx)
+}
+
+// proxyWasmHeaders is a header map, as a list of key/value pairs.
+// Keys are lowercase, and pseudo-headers such as ":path" describe the request line and status.
+type proxyWasmHeaders [][2]string
+
+func requestHeaders(r *http.Request) proxyWasmHeaders {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	h := proxyWasmHeaders{
+		{":authority", r.Host},
+		{":method", r.Method},
+		{":path", httputils.RequestURI(r)},
+		{":scheme", scheme},
+	}
+	return appendHeaders(h, r.Header)
+}
+
+func responseHeaders(status int, header http.Header) proxyWasmHeaders {
+	h := proxyWasmHeaders{
+		{":status", strconv.Itoa(status)},
+	}
+	return appendHeaders(h, header)
+}
+
+func appendHeaders(h proxyWasmHeaders, header http.Header) proxyWasmHeaders {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			h = append(h, [2]string{strings.ToLower(k), v})
+		}
+	}
+	return h
+}
+
+// applyRequestHeaders replaces the headers, method, host, and URI of the request with the ones in the header map.
+func applyRequestHeaders(r *http.Request, h proxyWasmHeaders) error {
+	header := make(http.Header, len(h))
+	for _, kv := range h {
+		switch kv[0] {
+		case ":authority":
+			r.Host = kv[1]
+		case ":method":
+			r.Method = kv[1]
+		case ":path":
+			u, err := url.ParseRequestURI(kv[1])
+			if err != nil {
+				return fmt.Errorf("the guest set an invalid path: %w", err)
+			}
+			r.URL.Path = u.Path
+			r.URL.RawPath = u.RawPath
+			r.URL.RawQuery = u.RawQuery
+			r.RequestURI = kv[1]
+		default:
+			if !strings.HasPrefix(kv[0], ":") {
+				header.Add(kv[0], kv[1])
+			}
+		}
+	}
+	r.Header = header
+	return nil
+}
+
+// writeResponse writes a response with the status and headers in the header map.
+func writeResponse(w http.ResponseWriter, h proxyWasmHeaders, body []byte) {
+	status := http.StatusOK
+	for _, kv := range h {
+		if kv[0] == ":status" {
+			if s, err := strconv.Atoi(kv[1]); err == nil {
+				status = s
+			}
+		} else if !strings.HasPrefix(kv[0], ":") {
+			w.Header().Add(kv[0], kv[1])
+		}
+	}
+	if w.Header().Get("content-length") != "" {
+		w.Header().Set("content-length", strconv.Itoa(len(body)))
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeLocalResponse(w http.ResponseWriter, res *proxyWasmLocalResponse) {
+	for _, kv := range res.headers {
+		if !strings.HasPrefix(kv[0], ":") {
+			w.Header().Add(kv[0], kv[1])
+		}
+	}
+	w.WriteHeader(res.status)
+	_, _ = w.Write(res.body)
+}
+
+// proxyWasmResponseWriter is a http.ResponseWriter that buffers the response, up to maxSize bytes, for the response callbacks.
+type proxyWasmResponseWriter struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	maxSize  int64
+	overflow bool
+}
+
+func (w *proxyWasmResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *proxyWasmResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *proxyWasmResponseWriter) Write(data []byte) (int, error) {
+	if w.overflow || int64(w.body.Len()+len(data)) > w.maxSize {
+		w.overflow = true
+		w.body.Reset()
+		return 0, errBodyTooLarge
+	}
+	return w.body.Write(data)
+}
@@ -0,0 +1,648 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	wazeroapi "github.com/tetratelabs/wazero/api"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Status codes returned by host functions.
+const (
+	proxyWasmStatusOK                   uint32 = 0
+	proxyWasmStatusNotFound             uint32 = 1
+	proxyWasmStatusBadArgument          uint32 = 2
+	proxyWasmStatusSerializationFailure uint32 = 3
+	proxyWasmStatusInvalidMemoryAccess  uint32 = 6
+	proxyWasmStatusCasMismatch          uint32 = 8
+	proxyWasmStatusInternalFailure      uint32 = 10
+	proxyWasmStatusUnimplemented        uint32 = 12
+)
+
+// Map types.
+const (
+	proxyWasmMapRequestHeaders   uint32 = 0
+	proxyWasmMapRequestTrailers  uint32 = 1
+	proxyWasmMapResponseHeaders  uint32 = 2
+	proxyWasmMapResponseTrailers uint32 = 3
+)
+
+// Buffer types.
+const (
+	proxyWasmBufferRequestBody         uint32 = 0
+	proxyWasmBufferResponseBody        uint32 = 1
+	proxyWasmBufferVMConfiguration     uint32 = 6
+	proxyWasmBufferPluginConfiguration uint32 = 7
+)
+
+// Log levels.
+const (
+	proxyWasmLogTrace uint32 = iota
+	proxyWasmLogDebug
+	proxyWasmLogInfo
+	proxyWasmLogWarn
+	proxyWasmLogError
+	proxyWasmLogCritical
+)
+
+// Functions of the ABI that are not implemented, with their number of parameters.
+// They must exist so guests that import them can be instantiated, and they return proxyWasmStatusUnimplemented.
+var proxyWasmUnimplementedFunctions = map[string]int{
+	"proxy_set_tick_period_milliseconds": 1,
+	"proxy_set_property":                 4,
+	"proxy_continue_stream":              1,
+	"proxy_close_stream":                 1,
+	"proxy_http_call":                    10,
+	"proxy_grpc_call":                    12,
+	"proxy_grpc_stream":                  9,
+	"proxy_grpc_send":                    4,
+	"proxy_grpc_cancel":                  1,
+	"proxy_grpc_close":                   1,
+	"proxy_get_status":                   3,
+	"proxy_register_shared_queue":        3,
+	"proxy_resolve_shared_queue":         5,
+	"proxy_dequeue_shared_queue":         3,
+	"proxy_enqueue_shared_queue":         3,
+	"proxy_call_foreign_function":        6,
+}
+
+// hostModule returns the builder of the "env" module, which exports the host functions of the ABI.
+func (m *proxyWasmMiddleware) hostModule() wazero.HostModuleBuilder {
+	b := m.runtime.NewHostModuleBuilder("env")
+	export := func(name string, fn any) {
+		b.NewFunctionBuilder().WithFunc(fn).Export(name)
+	}
+
+	export("proxy_log", m.log)
+	export("proxy_get_log_level", m.getLogLevel)
+	export("proxy_get_current_time_nanoseconds", m.getCurrentTime)
+	export("proxy_get_buffer_bytes", m.getBufferBytes)
+	export("proxy_set_buffer_bytes", m.setBufferBytes)
+	export("proxy_get_header_map_pairs", m.getHeaderMapPairs)
+	export("proxy_set_header_map_pairs", m.setHeaderMapPairs)
+	export("proxy_get_header_map_size", m.getHeaderMapSize)
+	export("proxy_get_header_map_value", m.getHeaderMapValue)
+	export("proxy_add_header_map_value", m.addHeaderMapValue)
+	export("proxy_replace_header_map_value", m.replaceHeaderMapValue)
+	export("proxy_remove_header_map_value", m.removeHeaderMapValue)
+	export("proxy_get_property", m.getProperty)
+	export("proxy_send_local_response", m.sendLocalResponse)
+	export("proxy_set_effective_context", m.setEffectiveContext)
+	export("proxy_done", m.done)
+	export("proxy_get_shared_data", m.getSharedData)
+	export("proxy_set_shared_data", m.setSharedData)
+	export("proxy_define_metric", m.defineMetric)
+	export("proxy_increment_metric", m.incrementMetric)
+	export("proxy_record_metric", m.recordMetric)
+	export("proxy_get_metric", m.getMetric)
+
+	for name, params := range proxyWasmUnimplementedFunctions {
+		paramTypes := make([]wazeroapi.ValueType, params)
+		for i := range paramTypes {
+			paramTypes[i] = wazeroapi.ValueTypeI32
+		}
+		b.NewFunctionBuilder().
+			WithGoModuleFunction(wazeroapi.GoModuleFunc(func(_ context.Context, _ wazeroapi.Module, stack []uint64) {
+				stack[0] = uint64(proxyWasmStatusUnimplemented)
+			}), paramTypes, []wazeroapi.ValueType{wazeroapi.ValueTypeI32}).
+			Export(name)
+	}
+	return b
+}
+
+func httpContextFrom(ctx context.Context) *proxyWasmHTTPContext {
+	hc, _ := ctx.Value(proxyWasmHTTPContextKey{}).(*proxyWasmHTTPContext)
+	return hc
+}
+
+// readString reads a string from the memory of the guest.
+func readString(mod wazeroapi.Module, ptr, size uint32) (string, bool) {
+	b, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return "", false
+	}
+	return string(b), true
+}
+
+// writeResult copies data to memory allocated by the guest, and writes its address and size at retPtr and retSize.
+func writeResult(ctx context.Context, mod wazeroapi.Module, data []byte, retPtr, retSize uint32) uint32 {
+	var ptr uint32
+	if len(data) > 0 {
+		res, err := mod.ExportedFunction("proxy_on_memory_allocate").Call(ctx, uint64(len(data)))
+		if err != nil || len(res) == 0 {
+			return proxyWasmStatusInternalFailure
+		}
+		ptr = uint32(res[0]) //nolint:gosec
+		if !mod.Memory().Write(ptr, data) {
+			return proxyWasmStatusInvalidMemoryAccess
+		}
+	}
+	if !mod.Memory().WriteUint32Le(retPtr, ptr) || !mod.Memory().WriteUint32Le(retSize, uint32(len(data))) { //nolint:gosec
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) log(_ context.Context, mod wazeroapi.Module, level, ptr, size uint32) uint32 {
+	msg, ok := readString(mod, ptr, size)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	switch level {
+	case proxyWasmLogTrace, proxyWasmLogDebug:
+		m.logger.Debug(msg)
+	case proxyWasmLogInfo:
+		m.logger.Info(msg)
+	case proxyWasmLogWarn:
+		m.logger.Warn(msg)
+	default:
+		m.logger.Error(msg)
+	}
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) getLogLevel(_ context.Context, mod wazeroapi.Module, retLevel uint32) uint32 {
+	level := proxyWasmLogError
+	switch {
+	case m.logger.IsOutputLevelEnabled(logger.DebugLevel):
+		level = proxyWasmLogDebug
+	case m.logger.IsOutputLevelEnabled(logger.InfoLevel):
+		level = proxyWasmLogInfo
+	case m.logger.IsOutputLevelEnabled(logger.WarnLevel):
+		level = proxyWasmLogWarn
+	}
+	if !mod.Memory().WriteUint32Le(retLevel, level) {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) getCurrentTime(_ context.Context, mod wazeroapi.Module, retTime uint32) uint32 {
+	if !mod.Memory().WriteUint64Le(retTime, uint64(time.Now().UnixNano())) { //nolint:gosec
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+// buffer returns a pointer to the buffer of the given type.
+func (m *proxyWasmMiddleware) buffer(ctx context.Context, bufferType uint32) (*[]byte, uint32) {
+	switch bufferType {
+	case proxyWasmBufferVMConfiguration:
+		var empty []byte
+		return &empty, proxyWasmStatusOK
+	case proxyWasmBufferPluginConfiguration:
+		config := m.pluginConfig
+		return &config, proxyWasmStatusOK
+	case proxyWasmBufferRequestBody, proxyWasmBufferResponseBody:
+		hc := httpContextFrom(ctx)
+		if hc == nil {
+			return nil, proxyWasmStatusNotFound
+		}
+		if bufferType == proxyWasmBufferRequestBody {
+			return &hc.requestBody, proxyWasmStatusOK
+		}
+		return &hc.responseBody, proxyWasmStatusOK
+	default:
+		return nil, proxyWasmStatusBadArgument
+	}
+}
+
+func (m *proxyWasmMiddleware) getBufferBytes(ctx context.Context, mod wazeroapi.Module, bufferType, start, maxSize, retPtr, retSize uint32) uint32 {
+	buf, status := m.buffer(ctx, bufferType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	if int(start) > len(*buf) {
+		return proxyWasmStatusBadArgument
+	}
+	end := min(uint64(start)+uint64(maxSize), uint64(len(*buf)))
+	return writeResult(ctx, mod, (*buf)[start:end], retPtr, retSize)
+}
+
+// setBufferBytes replaces size bytes of the buffer from start with the data.
+// This prepends data when start and size are 0, and appends it when start is at the end of the buffer.
+func (m *proxyWasmMiddleware) setBufferBytes(ctx context.Context, mod wazeroapi.Module, bufferType, start, size, dataPtr, dataSize uint32) uint32 {
+	buf, status := m.buffer(ctx, bufferType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	if bufferType != proxyWasmBufferRequestBody && bufferType != proxyWasmBufferResponseBody {
+		return proxyWasmStatusBadArgument
+	}
+	data, ok := mod.Memory().Read(dataPtr, dataSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	from := min(uint64(start), uint64(len(*buf)))
+	to := min(uint64(start)+uint64(size), uint64(len(*buf)))
+	res := make([]byte, 0, uint64(len(*buf))-(to-from)+uint64(len(data)))
+	res = append(res, (*buf)[:from]...)
+	res = append(res, data...)
+	res = append(res, (*buf)[to:]...)
+	*buf = res
+	return proxyWasmStatusOK
+}
+
+// headerMap returns a pointer to the header map of the given type.
+// Trailers are always empty, as they are not supported.
+func headerMap(ctx context.Context, mapType uint32) (*proxyWasmHeaders, uint32) {
+	hc := httpContextFrom(ctx)
+	if hc == nil {
+		return nil, proxyWasmStatusNotFound
+	}
+	switch mapType {
+	case proxyWasmMapRequestHeaders:
+		return &hc.requestHeaders, proxyWasmStatusOK
+	case proxyWasmMapResponseHeaders:
+		return &hc.responseHeaders, proxyWasmStatusOK
+	case proxyWasmMapRequestTrailers, proxyWasmMapResponseTrailers:
+		return &proxyWasmHeaders{}, proxyWasmStatusOK
+	default:
+		return nil, proxyWasmStatusBadArgument
+	}
+}
+
+func (m *proxyWasmMiddleware) getHeaderMapPairs(ctx context.Context, mod wazeroapi.Module, mapType, retPtr, retSize uint32) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	return writeResult(ctx, mod, encodeHeaders(*h), retPtr, retSize)
+}
+
+func (m *proxyWasmMiddleware) setHeaderMapPairs(ctx context.Context, mod wazeroapi.Module, mapType, ptr, size uint32) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	data, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	pairs, err := decodeHeaders(data)
+	if err != nil {
+		return proxyWasmStatusSerializationFailure
+	}
+	*h = pairs
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) getHeaderMapSize(ctx context.Context, mod wazeroapi.Module, mapType, retSize uint32) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	if !mod.Memory().WriteUint32Le(retSize, uint32(len(encodeHeaders(*h)))) { //nolint:gosec
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) getHeaderMapValue(ctx context.Context, mod wazeroapi.Module, mapType, keyPtr, keySize, retPtr, retSize uint32) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	key, ok := readString(mod, keyPtr, keySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	key = strings.ToLower(key)
+	for _, kv := range *h {
+		if kv[0] == key {
+			return writeResult(ctx, mod, []byte(kv[1]), retPtr, retSize)
+		}
+	}
+	return proxyWasmStatusNotFound
+}
+
+func (m *proxyWasmMiddleware) addHeaderMapValue(ctx context.Context, mod wazeroapi.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32) uint32 {
+	return m.updateHeaderMap(ctx, mod, mapType, keyPtr, keySize, valuePtr, valueSize, false)
+}
+
+func (m *proxyWasmMiddleware) replaceHeaderMapValue(ctx context.Context, mod wazeroapi.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32) uint32 {
+	return m.updateHeaderMap(ctx, mod, mapType, keyPtr, keySize, valuePtr, valueSize, true)
+}
+
+func (m *proxyWasmMiddleware) updateHeaderMap(ctx context.Context, mod wazeroapi.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32, replace bool) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	key, ok := readString(mod, keyPtr, keySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	value, ok := readString(mod, valuePtr, valueSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	key = strings.ToLower(key)
+	if replace {
+		*h = removeHeader(*h, key)
+	}
+	*h = append(*h, [2]string{key, value})
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) removeHeaderMapValue(ctx context.Context, mod wazeroapi.Module, mapType, keyPtr, keySize uint32) uint32 {
+	h, status := headerMap(ctx, mapType)
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	key, ok := readString(mod, keyPtr, keySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	*h = removeHeader(*h, strings.ToLower(key))
+	return proxyWasmStatusOK
+}
+
+func removeHeader(h proxyWasmHeaders, key string) proxyWasmHeaders {
+	res := h[:0]
+	for _, kv := range h {
+		if kv[0] != key {
+			res = append(res, kv)
+		}
+	}
+	return res
+}
+
+// getProperty returns the properties describing the request, the response, and the plugin.
+// The path is a list of segments, each terminated by a null character, such as "request\x00path\x00".
+func (m *proxyWasmMiddleware) getProperty(ctx context.Context, mod wazeroapi.Module, pathPtr, pathSize, retPtr, retSize uint32) uint32 {
+	path, ok := readString(mod, pathPtr, pathSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	path = strings.ReplaceAll(strings.TrimSuffix(path, "\x00"), "\x00", ".")
+
+	var value []byte
+	switch path {
+	case "plugin_name":
+		value = []byte(m.pluginName)
+	case "plugin_root_id", "plugin_vm_id":
+		value = []byte{}
+	default:
+		hc := httpContextFrom(ctx)
+		if hc == nil {
+			return proxyWasmStatusNotFound
+		}
+		value = hc.property(path)
+		if value == nil {
+			return proxyWasmStatusNotFound
+		}
+	}
+	return writeResult(ctx, mod, value, retPtr, retSize)
+}
+
+func (hc *proxyWasmHTTPContext) property(path string) []byte {
+	r := hc.request
+	switch path {
+	case "request.path":
+		return []byte(r.URL.RequestURI())
+	case "request.url_path":
+		return []byte(r.URL.Path)
+	case "request.host":
+		return []byte(r.Host)
+	case "request.method":
+		return []byte(r.Method)
+	case "request.scheme":
+		if r.TLS != nil {
+			return []byte("https")
+		}
+		return []byte("http")
+	case "request.query":
+		return []byte(r.URL.RawQuery)
+	case "request.protocol":
+		return []byte(r.Proto)
+	case "request.id":
+		return []byte(r.Header.Get("x-request-id"))
+	case "request.useragent":
+		return []byte(r.UserAgent())
+	case "request.referer":
+		return []byte(r.Referer())
+	case "source.address":
+		return []byte(r.RemoteAddr)
+	case "response.code":
+		for _, kv := range hc.responseHeaders {
+			if kv[0] == ":status" {
+				code, err := strconv.ParseInt(kv[1], 10, 64)
+				if err != nil {
+					return nil
+				}
+				return binary.LittleEndian.AppendUint64(nil, uint64(code)) //nolint:gosec
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (m *proxyWasmMiddleware) sendLocalResponse(ctx context.Context, mod wazeroapi.Module, statusCode, detailsPtr, detailsSize, bodyPtr, bodySize, headersPtr, headersSize, grpcStatus uint32) uint32 {
+	hc := httpContextFrom(ctx)
+	if hc == nil {
+		return proxyWasmStatusBadArgument
+	}
+	body, ok := mod.Memory().Read(bodyPtr, bodySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	data, ok := mod.Memory().Read(headersPtr, headersSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	var headers proxyWasmHeaders
+	if len(data) > 0 {
+		var err error
+		headers, err = decodeHeaders(data)
+		if err != nil {
+			return proxyWasmStatusSerializationFailure
+		}
+	}
+	if statusCode < 100 || statusCode > 999 {
+		return proxyWasmStatusBadArgument
+	}
+	hc.localResponse = &proxyWasmLocalResponse{
+		status:  int(statusCode),
+		headers: headers,
+		body:    append([]byte(nil), body...),
+	}
+	return proxyWasmStatusOK
+}
+
+// setEffectiveContext is a no-op, as callbacks are only invoked for the current HTTP context.
+func (m *proxyWasmMiddleware) setEffectiveContext(_ context.Context, _ wazeroapi.Module, _ uint32) uint32 {
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) done(_ context.Context, _ wazeroapi.Module) uint32 {
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) getSharedData(ctx context.Context, mod wazeroapi.Module, keyPtr, keySize, retPtr, retSize, retCas uint32) uint32 {
+	key, ok := readString(mod, keyPtr, keySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	m.sharedLock.Lock()
+	v, ok := m.sharedData[key]
+	m.sharedLock.Unlock()
+	if !ok {
+		return proxyWasmStatusNotFound
+	}
+	if status := writeResult(ctx, mod, v.value, retPtr, retSize); status != proxyWasmStatusOK {
+		return status
+	}
+	if !mod.Memory().WriteUint32Le(retCas, v.cas) {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+// setSharedData sets a value shared by all the instances of the guest.
+// If cas isn't 0, the value is only set if it's the cas returned when the current value was read.
+func (m *proxyWasmMiddleware) setSharedData(_ context.Context, mod wazeroapi.Module, keyPtr, keySize, valuePtr, valueSize, cas uint32) uint32 {
+	key, ok := readString(mod, keyPtr, keySize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	value, ok := mod.Memory().Read(valuePtr, valueSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	m.sharedLock.Lock()
+	defer m.sharedLock.Unlock()
+	current := m.sharedData[key]
+	if cas != 0 && cas != current.cas {
+		return proxyWasmStatusCasMismatch
+	}
+	m.sharedData[key] = proxyWasmSharedValue{
+		value: append([]byte(nil), value...),
+		cas:   current.cas + 1,
+	}
+	return proxyWasmStatusOK
+}
+
+// defineMetric returns the ID of the metric with the given name, creating it if needed.
+// Metrics are kept in memory for the guest to read, and are not exported.
+func (m *proxyWasmMiddleware) defineMetric(_ context.Context, mod wazeroapi.Module, _, namePtr, nameSize, retID uint32) uint32 {
+	name, ok := readString(mod, namePtr, nameSize)
+	if !ok {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	m.sharedLock.Lock()
+	id, ok := m.metricIDs[name]
+	if !ok {
+		m.metricsData = append(m.metricsData, 0)
+		id = uint32(len(m.metricsData)) //nolint:gosec
+		m.metricIDs[name] = id
+	}
+	m.sharedLock.Unlock()
+	if !mod.Memory().WriteUint32Le(retID, id) {
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) updateMetric(id uint32, fn func(v int64) int64) uint32 {
+	m.sharedLock.Lock()
+	defer m.sharedLock.Unlock()
+	if id == 0 || int(id) > len(m.metricsData) {
+		return proxyWasmStatusNotFound
+	}
+	m.metricsData[id-1] = fn(m.metricsData[id-1])
+	return proxyWasmStatusOK
+}
+
+func (m *proxyWasmMiddleware) incrementMetric(_ context.Context, _ wazeroapi.Module, id uint32, offset int64) uint32 {
+	return m.updateMetric(id, func(v int64) int64 { return v + offset })
+}
+
+func (m *proxyWasmMiddleware) recordMetric(_ context.Context, _ wazeroapi.Module, id uint32, value uint64) uint32 {
+	return m.updateMetric(id, func(int64) int64 { return int64(value) }) //nolint:gosec
+}
+
+func (m *proxyWasmMiddleware) getMetric(_ context.Context, mod wazeroapi.Module, id, retValue uint32) uint32 {
+	var value int64
+	status := m.updateMetric(id, func(v int64) int64 {
+		value = v
+		return v
+	})
+	if status != proxyWasmStatusOK {
+		return status
+	}
+	if !mod.Memory().WriteUint64Le(retValue, uint64(value)) { //nolint:gosec
+		return proxyWasmStatusInvalidMemoryAccess
+	}
+	return proxyWasmStatusOK
+}
+
+// encodeHeaders serializes a header map: the number of pairs, the sizes of each key and value,
+// then each key and value terminated by a null character. Numbers are 32-bit little-endian.
+func encodeHeaders(h proxyWasmHeaders) []byte {
+	size := 4
+	for _, kv := range h {
+		size += 8 + len(kv[0]) + len(kv[1]) + 2
+	}
+	b := make([]byte, 0, size)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(h))) //nolint:gosec
+	for _, kv := range h {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(kv[0]))) //nolint:gosec
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(kv[1]))) //nolint:gosec
+	}
+	for _, kv := range h {
+		b = append(b, kv[0]...)
+		b = append(b, 0)
+		b = append(b, kv[1]...)
+		b = append(b, 0)
+	}
+	return b
+}
+
+var errInvalidHeaderMap = errors.New("invalid header map")
+
+func decodeHeaders(b []byte) (proxyWasmHeaders, error) {
+	if len(b) < 4 {
+		return nil, errInvalidHeaderMap
+	}
+	n := uint64(binary.LittleEndian.Uint32(b))
+	if n*8 > uint64(len(b)-4) {
+		return nil, errInvalidHeaderMap
+	}
+	sizes := b[4 : 4+n*8]
+	data := b[4+n*8:]
+	h := make(proxyWasmHeaders, n)
+	for i := range h {
+		for j := range 2 {
+			size := uint64(binary.LittleEndian.Uint32(sizes[i*8+j*4:]))
+			if size+1 > uint64(len(data)) || data[size] != 0 {
+				return nil, errInvalidHeaderMap
+			}
+			h[i][j] = string(data[:size])
+			data = data[size+1:]
+		}
+	}
+	return h, nil
+}
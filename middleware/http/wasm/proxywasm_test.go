@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	dapr "github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func Test_proxyWasm(t *testing.T) {
+	l := logger.NewLogger(t.Name())
+	l.SetOutput(io.Discard)
+	m := &middleware{logger: l}
+
+	newHandler := func(t *testing.T, properties map[string]string, next http.HandlerFunc) http.Handler {
+		properties["url"] = "file://internal/testdata/proxywasm.wasm"
+		h, err := m.getHandler(context.Background(), dapr.Metadata{Base: metadata.Base{Properties: properties}})
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return h.requestHandler(next)
+	}
+
+	t.Run("modifies the request and response", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{}, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/rewritten", r.URL.Path)
+			assert.Equal(t, "request", r.Header.Get("x-proxy-wasm"))
+			assert.Equal(t, "bar", r.Header.Get("x-foo"))
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello!", string(body))
+			assert.Equal(t, int64(6), r.ContentLength)
+
+			w.Header().Set("content-length", "5")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("world"))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/v1.0/hi", strings.NewReader("hello"))
+		req.Header.Set("x-foo", "bar")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "response", w.Header().Get("x-proxy-wasm"))
+		require.Equal(t, "12", w.Header().Get("content-length"))
+		require.Equal(t, "prefix:world", w.Body.String())
+	})
+
+	t.Run("passes guestConfig as the plugin configuration", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{"guestConfig": "my-config"}, func(w http.ResponseWriter, r *http.Request) {})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "my-config", w.Header().Get("x-proxy-wasm-config"))
+	})
+
+	t.Run("sends a local response", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{}, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("next handler called")
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil)
+		req.Header.Set("x-deny", "true")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "denied", w.Body.String())
+	})
+
+	t.Run("request body over maxBodySize", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{"maxBodySize": "4"}, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("next handler called")
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1.0/hi", strings.NewReader("hello")))
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("response body over maxBodySize", func(t *testing.T) {
+		handler := newHandler(t, map[string]string{"maxBodySize": "4"}, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("world"))
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil))
+		require.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("guest within maxMemoryPages", func(t *testing.T) {
+		h, err := m.getHandler(context.Background(), dapr.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"url":            "file://internal/testdata/proxywasm.wasm",
+			"maxMemoryPages": "1",
+		}}})
+		require.NoError(t, err)
+		require.NoError(t, h.Close())
+	})
+
+	t.Run("guest exceeding the timeout is replaced", func(t *testing.T) {
+		h, err := m.getHandler(context.Background(), dapr.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"url":            "file://internal/testdata/proxywasm_loop.wasm",
+			"requestTimeout": "50ms",
+		}}})
+		require.NoError(t, err)
+		defer h.Close()
+		handler := h.requestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("next handler called")
+		}))
+
+		for range 2 {
+			initial := h.mw.Load()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/hi", nil))
+			require.Equal(t, http.StatusInternalServerError, w.Code)
+			require.NotSame(t, initial, h.mw.Load())
+			require.Eventually(t, func() bool {
+				return !initial.acquire()
+			}, time.Second, 10*time.Millisecond)
+		}
+	})
+
+	t.Run("invalid maxBodySize", func(t *testing.T) {
+		_, err := m.getHandler(context.Background(), dapr.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"url":         "file://internal/testdata/proxywasm.wasm",
+			"maxBodySize": "0",
+		}}})
+		require.EqualError(t, err, "wasm: maxBodySize must be greater than zero")
+	})
+}
+
+func Test_proxyWasmHeaders(t *testing.T) {
+	h := proxyWasmHeaders{
+		{":status", "200"},
+		{"content-type", "text/plain"},
+		{"x-empty", ""},
+	}
+	res, err := decodeHeaders(encodeHeaders(h))
+	require.NoError(t, err)
+	require.Equal(t, h, res)
+
+	res, err = decodeHeaders(encodeHeaders(proxyWasmHeaders{}))
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	for _, b := range [][]byte{
+		nil,
+		{1, 0, 0, 0},
+		{1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 'a', 0, 'b'},
+	} {
+		_, err = decodeHeaders(b)
+		require.ErrorIs(t, err, errInvalidHeaderMap)
+	}
+}
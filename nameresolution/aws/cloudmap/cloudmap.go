@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+// ErrNoHost is returned by ResolveID when no host can be found.
+var ErrNoHost = errors.New("no host found with the given ID")
+
+type cacheEntry struct {
+	addresses nameresolution.AddressList
+	expires   time.Time
+}
+
+type resolver struct {
+	logger       logger.Logger
+	metadata     cloudMapMetadata
+	authProvider awsAuth.Provider
+
+	cache     map[string]cacheEntry
+	cacheLock sync.RWMutex
+}
+
+// NewResolver creates a name resolver that is based on AWS Cloud Map.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	return &resolver{
+		logger: logger,
+		cache:  map[string]cacheEntry{},
+	}
+}
+
+// Init initializes the name resolver.
+func (r *resolver) Init(ctx context.Context, md nameresolution.Metadata) error {
+	err := r.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	opts := awsAuth.Options{
+		Logger:       r.logger,
+		Properties:   properties(md.Configuration),
+		Region:       r.metadata.Region,
+		Endpoint:     r.metadata.Endpoint,
+		AccessKey:    r.metadata.AccessKey,
+		SecretKey:    r.metadata.SecretKey,
+		SessionToken: r.metadata.SessionToken,
+	}
+	r.authProvider, err = awsAuth.NewProvider(ctx, opts, awsAuth.GetConfig(opts))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResolveID resolves name to address.
+func (r *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	addrs, err := r.ResolveIDMulti(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return addrs.Pick(), nil
+}
+
+// ResolveIDMulti resolves name to a list of addresses.
+func (r *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	key := req.CacheKey()
+	if r.metadata.CacheTTL > 0 {
+		r.cacheLock.RLock()
+		entry, ok := r.cache[key]
+		r.cacheLock.RUnlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.addresses, nil
+		}
+	}
+
+	addrs, err := r.discover(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.metadata.CacheTTL > 0 {
+		r.cacheLock.Lock()
+		r.cache[key] = cacheEntry{
+			addresses: addrs,
+			expires:   time.Now().Add(r.metadata.CacheTTL),
+		}
+		r.cacheLock.Unlock()
+	}
+
+	return addrs, nil
+}
+
+// discover returns the addresses of the instances of the service with the app ID.
+func (r *resolver) discover(parentCtx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, r.metadata.RequestTimeout)
+	defer cancel()
+
+	res, err := r.authProvider.CloudMap().ServiceDiscovery.DiscoverInstancesWithContext(ctx, &servicediscovery.DiscoverInstancesInput{
+		NamespaceName:      ptr.Of(r.metadata.NamespaceName),
+		ServiceName:        ptr.Of(req.ID),
+		HealthStatus:       ptr.Of(r.metadata.HealthStatus),
+		QueryParameters:    r.metadata.queryParameters,
+		OptionalParameters: r.metadata.optionalParameters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover instances in Cloud Map: %w", err)
+	}
+
+	addrs := make(nameresolution.AddressList, 0, len(res.Instances))
+	for _, inst := range res.Instances {
+		if inst == nil {
+			continue
+		}
+		addr := instanceAddress(inst.Attributes, req.Port)
+		if addr == "" {
+			r.logger.Debugf("Ignoring Cloud Map instance %s of service %s: no address attribute", aws.StringValue(inst.InstanceId), req.ID)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoHost
+	}
+
+	return addrs, nil
+}
+
+// instanceAddress returns the address of the Dapr sidecar of an instance.
+// The port is read from the "DAPR_PORT" attribute, falling back to the port in the request.
+func instanceAddress(attributes map[string]*string, defaultPort int) string {
+	host := aws.StringValue(attributes[attributeIPv4])
+	if host == "" {
+		host = aws.StringValue(attributes[attributeIPv6])
+	}
+	if host == "" {
+		return ""
+	}
+
+	port := defaultPort
+	if v := aws.StringValue(attributes[attributeDaprPort]); v != "" {
+		p, err := strconv.Atoi(v)
+		if err == nil && p > 0 {
+			port = p
+		}
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// Close implements io.Closer.
+func (r *resolver) Close() error {
+	return nil
+}
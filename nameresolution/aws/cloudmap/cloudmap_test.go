@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudmap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func newTestResolver(t *testing.T, cfg map[string]string, mock *awsAuth.MockServiceDiscovery) *resolver {
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	err := r.metadata.InitWithMetadata(nameresolution.Metadata{Configuration: cfg})
+	require.NoError(t, err)
+
+	mockAuthProvider := &awsAuth.StaticAuth{}
+	mockAuthProvider.WithMockClients(&awsAuth.Clients{
+		CloudMap: &awsAuth.CloudMapClients{
+			ServiceDiscovery: mock,
+		},
+	})
+	r.authProvider = mockAuthProvider
+	return r
+}
+
+func TestInitWithMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := cloudMapMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{Configuration: map[string]string{
+			"namespaceName": "dapr.local",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, servicediscovery.HealthStatusFilterHealthy, m.HealthStatus)
+		assert.Equal(t, defaultCacheTTL, m.CacheTTL)
+		assert.Nil(t, m.queryParameters)
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		m := cloudMapMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{Configuration: map[string]string{
+			"namespaceName":      "dapr.local",
+			"healthStatus":       "healthy_or_else_all",
+			"queryParameters":    "env=prod, version=2",
+			"optionalParameters": "az=us-east-1a",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, servicediscovery.HealthStatusFilterHealthyOrElseAll, m.HealthStatus)
+		assert.Equal(t, map[string]*string{"env": ptr.Of("prod"), "version": ptr.Of("2")}, m.queryParameters)
+		assert.Equal(t, map[string]*string{"az": ptr.Of("us-east-1a")}, m.optionalParameters)
+	})
+
+	t.Run("missing namespace", func(t *testing.T) {
+		m := cloudMapMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{Configuration: map[string]string{}})
+		require.ErrorContains(t, err, "namespaceName")
+	})
+
+	t.Run("invalid health status", func(t *testing.T) {
+		m := cloudMapMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{Configuration: map[string]string{
+			"namespaceName": "dapr.local",
+			"healthStatus":  "sick",
+		}})
+		require.ErrorContains(t, err, "healthStatus")
+	})
+
+	t.Run("invalid attribute", func(t *testing.T) {
+		m := cloudMapMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{Configuration: map[string]string{
+			"namespaceName":   "dapr.local",
+			"queryParameters": "env",
+		}})
+		require.ErrorContains(t, err, "queryParameters")
+	})
+}
+
+func TestResolveID(t *testing.T) {
+	calls := 0
+	mock := &awsAuth.MockServiceDiscovery{
+		DiscoverInstancesFn: func(ctx context.Context, input *servicediscovery.DiscoverInstancesInput, option ...request.Option) (*servicediscovery.DiscoverInstancesOutput, error) {
+			calls++
+			assert.Equal(t, "dapr.local", *input.NamespaceName)
+			assert.Equal(t, servicediscovery.HealthStatusFilterHealthy, *input.HealthStatus)
+			assert.Equal(t, "prod", *input.QueryParameters["env"])
+
+			switch *input.ServiceName {
+			case "myapp":
+				return &servicediscovery.DiscoverInstancesOutput{
+					Instances: []*servicediscovery.HttpInstanceSummary{
+						{InstanceId: ptr.Of("1"), Attributes: map[string]*string{"AWS_INSTANCE_IPV4": ptr.Of("10.0.0.1"), "DAPR_PORT": ptr.Of("50005")}},
+						{InstanceId: ptr.Of("2"), Attributes: map[string]*string{"AWS_INSTANCE_IPV6": ptr.Of("fd00::2")}},
+						{InstanceId: ptr.Of("3"), Attributes: map[string]*string{"AWS_INSTANCE_PORT": ptr.Of("8080")}},
+					},
+				}, nil
+			case "failing":
+				return nil, errors.New("simulated")
+			default:
+				return &servicediscovery.DiscoverInstancesOutput{}, nil
+			}
+		},
+	}
+	r := newTestResolver(t, map[string]string{
+		"namespaceName":   "dapr.local",
+		"queryParameters": "env=prod",
+		"cacheTTL":        "1m",
+	}, mock)
+
+	t.Run("resolve multiple addresses", func(t *testing.T) {
+		addrs, err := r.ResolveIDMulti(context.Background(), nameresolution.ResolveRequest{ID: "myapp", Port: 50002})
+		require.NoError(t, err)
+		assert.Equal(t, nameresolution.AddressList{"10.0.0.1:50005", "[fd00::2]:50002"}, addrs)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("results are cached", func(t *testing.T) {
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp", Port: 50002})
+		require.NoError(t, err)
+		assert.Contains(t, []string{"10.0.0.1:50005", "[fd00::2]:50002"}, addr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("cache expires", func(t *testing.T) {
+		r.cacheLock.Lock()
+		for k, v := range r.cache {
+			v.expires = time.Now().Add(-time.Second)
+			r.cache[k] = v
+		}
+		r.cacheLock.Unlock()
+
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp", Port: 50002})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("no instances", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "other", Port: 50002})
+		require.ErrorIs(t, err, ErrNoHost)
+	})
+
+	t.Run("error from Cloud Map", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "failing", Port: 50002})
+		require.ErrorContains(t, err, "simulated")
+	})
+}
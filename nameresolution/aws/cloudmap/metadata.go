@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudmap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultCacheTTL       = 10 * time.Second
+	defaultRequestTimeout = 5 * time.Second
+
+	// Attributes set by ECS and by the RegisterInstance API for the address of instances.
+	attributeIPv4 = "AWS_INSTANCE_IPV4"
+	attributeIPv6 = "AWS_INSTANCE_IPV6"
+	// Custom attribute with the Dapr internal gRPC port of instances.
+	attributeDaprPort = "DAPR_PORT"
+)
+
+type cloudMapMetadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	AccessKey    string `json:"accessKey" mapstructure:"accessKey" mdignore:"true"`
+	SecretKey    string `json:"secretKey" mapstructure:"secretKey" mdignore:"true"`
+	SessionToken string `json:"sessionToken" mapstructure:"sessionToken" mdignore:"true"`
+	Region       string `json:"region" mapstructure:"region" mapstructurealiases:"awsRegion" mdignore:"true"`
+	Endpoint     string `json:"endpoint" mapstructure:"endpoint"`
+
+	// Name of the Cloud Map namespace containing the services.
+	NamespaceName string `json:"namespaceName" mapstructure:"namespaceName"`
+	// Health status of the instances to return: HEALTHY, UNHEALTHY, ALL, or HEALTHY_OR_ELSE_ALL.
+	HealthStatus string `json:"healthStatus" mapstructure:"healthStatus"`
+	// Comma-separated list of "key=value" attributes that instances must have.
+	QueryParameters string `json:"queryParameters" mapstructure:"queryParameters"`
+	// Comma-separated list of "key=value" attributes that are preferred: instances with them are returned if any, otherwise all matching instances are.
+	OptionalParameters string `json:"optionalParameters" mapstructure:"optionalParameters"`
+	// Duration for which results are cached. Set to 0 to disable caching.
+	CacheTTL time.Duration `json:"cacheTTL" mapstructure:"cacheTTL"`
+	// Timeout for requests to Cloud Map.
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+
+	// Internal properties
+	queryParameters    map[string]*string `json:"-" mapstructure:"-"`
+	optionalParameters map[string]*string `json:"-" mapstructure:"-"`
+}
+
+func (m *cloudMapMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	m.HealthStatus = servicediscovery.HealthStatusFilterHealthy
+	m.CacheTTL = defaultCacheTTL
+	m.RequestTimeout = defaultRequestTimeout
+
+	// Decode the configuration using DecodeMetadata
+	err := kitmd.DecodeMetadata(meta.Configuration, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.NamespaceName == "" {
+		return errors.New("missing required configuration property 'namespaceName'")
+	}
+	m.HealthStatus = strings.ToUpper(m.HealthStatus)
+	switch m.HealthStatus {
+	case servicediscovery.HealthStatusFilterHealthy,
+		servicediscovery.HealthStatusFilterUnhealthy,
+		servicediscovery.HealthStatusFilterAll,
+		servicediscovery.HealthStatusFilterHealthyOrElseAll:
+		// Nop
+	default:
+		return fmt.Errorf("invalid value for configuration property 'healthStatus': %s", m.HealthStatus)
+	}
+	if m.CacheTTL < 0 {
+		return errors.New("configuration property 'cacheTTL' must not be negative")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	m.queryParameters, err = parseAttributes("queryParameters", m.QueryParameters)
+	if err != nil {
+		return err
+	}
+	m.optionalParameters, err = parseAttributes("optionalParameters", m.OptionalParameters)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseAttributes parses a comma-separated list of "key=value" pairs.
+func parseAttributes(property string, val string) (map[string]*string, error) {
+	res := map[string]*string{}
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid attribute '%s' in configuration property '%s': must be in the format 'key=value'", pair, property)
+		}
+		v = strings.TrimSpace(v)
+		res[k] = &v
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// properties returns the configuration as a map of strings, used to detect the authentication profile.
+func properties(configuration any) map[string]string {
+	res := map[string]string{}
+	switch cfg := configuration.(type) {
+	case map[string]string:
+		for k, v := range cfg {
+			res[k] = v
+		}
+	case map[string]any:
+		for k, v := range cfg {
+			if s, ok := v.(string); ok {
+				res[k] = s
+			}
+		}
+	}
+	return res
+}
@@ -39,6 +39,10 @@ type intermediateConfig struct {
 	SelfRegister         bool
 	SelfDeregister       bool
 	UseCache             bool
+	LoadBalancing        string   // random (default), roundRobin, leastConnection, or weighted
+	FailoverDatacenters  []string // datacenters queried in order when there are no healthy instances in the local one
+	Namespace            string   // Consul Enterprise only
+	Partition            string   // Consul Enterprise only
 }
 
 type configSpec struct {
@@ -52,6 +56,10 @@ type configSpec struct {
 	SelfRegister         bool
 	SelfDeregister       bool
 	UseCache             bool
+	LoadBalancing        string   // random (default), roundRobin, leastConnection, or weighted
+	FailoverDatacenters  []string // datacenters queried in order when there are no healthy instances in the local one
+	Namespace            string   // Consul Enterprise only
+	Partition            string   // Consul Enterprise only
 }
 
 func newIntermediateConfig() intermediateConfig {
@@ -95,6 +103,10 @@ func mapConfig(config intermediateConfig) configSpec {
 		SelfDeregister:       config.SelfDeregister,
 		DaprPortMetaKey:      config.DaprPortMetaKey,
 		UseCache:             config.UseCache,
+		LoadBalancing:        config.LoadBalancing,
+		FailoverDatacenters:  config.FailoverDatacenters,
+		Namespace:            config.Namespace,
+		Partition:            config.Partition,
 	}
 }
 
@@ -182,6 +194,8 @@ func mapQueryOptions(config *QueryOptions) *consul.QueryOptions {
 	}
 
 	return &consul.QueryOptions{
+		Namespace:         config.Namespace,
+		Partition:         config.Partition,
 		Datacenter:        config.Datacenter,
 		AllowStale:        config.AllowStale,
 		RequireConsistent: config.RequireConsistent,
@@ -321,6 +335,8 @@ func mapAdvancedRegistration(config *AgentServiceRegistration) *consul.AgentServ
 	mapped := &consul.AgentServiceRegistration{
 		Kind:              consul.ServiceKind(config.Kind),
 		ID:                config.ID,
+		Namespace:         config.Namespace,
+		Partition:         config.Partition,
 		Name:              config.Name,
 		Tags:              config.Tags,
 		Port:              config.Port,
@@ -410,6 +426,8 @@ type QueryOptions struct {
 type AgentServiceRegistration struct {
 	Kind              string // original: type ServiceKind string
 	ID                string
+	Namespace         string
+	Partition         string
 	Name              string
 	Tags              []string
 	Port              int
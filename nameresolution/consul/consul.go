@@ -16,7 +16,6 @@ package consul
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"net"
 	"strconv"
 	"sync"
@@ -61,6 +60,7 @@ type agentInterface interface {
 	Self() (map[string]map[string]interface{}, error)
 	ServiceRegister(service *consul.AgentServiceRegistration) error
 	ServiceDeregister(serviceID string) error
+	ServiceDeregisterOpts(serviceID string, q *consul.QueryOptions) error
 }
 
 type healthInterface interface {
@@ -73,6 +73,7 @@ type resolver struct {
 	logger             logger.Logger
 	client             clientInterface
 	registry           registryInterface
+	balancer           loadBalancer
 	watcherStarted     atomic.Bool
 	watcherStopChannel chan struct{}
 }
@@ -116,28 +117,27 @@ func (r *registry) get(service string) *registryEntry {
 	return nil
 }
 
-func (e *registryEntry) next() *consul.ServiceEntry {
+func (e *registryEntry) next(service string, lb loadBalancer) *consul.ServiceEntry {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if len(e.services) == 0 {
-		return nil
-	}
+	return lb.pick(service, e.services)
+}
 
-	// gosec is complaining that we are using a non-crypto-safe PRNG. This is fine in this scenario since we are using it only for selecting a random address for load-balancing.
-	//nolint:gosec
-	return e.services[rand.Int()%len(e.services)]
+func (e *registryEntry) all() []*consul.ServiceEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.services
 }
 
 func (r *resolver) getService(service string) (*consul.ServiceEntry, error) {
-	var services []*consul.ServiceEntry
-
 	if r.config.UseCache {
 		r.startWatcher()
 
 		entry := r.registry.get(service)
 		if entry != nil {
-			result := entry.next()
+			result := entry.next(service, r.balancer)
 
 			if result != nil {
 				return result, nil
@@ -147,19 +147,61 @@ func (r *resolver) getService(service string) (*consul.ServiceEntry, error) {
 		}
 	}
 
+	services, err := r.queryServices(service)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.balancer.pick(service, services), nil
+}
+
+// getServices returns all the healthy instances of a service.
+func (r *resolver) getServices(service string) ([]*consul.ServiceEntry, error) {
+	if r.config.UseCache {
+		r.startWatcher()
+
+		entry := r.registry.get(service)
+		if entry != nil {
+			result := entry.all()
+
+			if len(result) > 0 {
+				return result, nil
+			}
+		} else {
+			r.registry.registrationChannel() <- service
+		}
+	}
+
+	return r.queryServices(service)
+}
+
+// queryServices queries the agent for the healthy instances of a service, bypassing the cache.
+// If there are no healthy instances in the datacenter of the query, the failover datacenters are queried in order.
+func (r *resolver) queryServices(service string) ([]*consul.ServiceEntry, error) {
 	options := *r.config.QueryOptions
 	options.WaitHash = ""
 	options.WaitIndex = 0
 	services, _, err := r.client.Health().Service(service, "", true, &options)
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to query healthy consul services: %w", err)
-	} else if len(services) == 0 {
+	}
+
+	for _, dc := range r.config.FailoverDatacenters {
+		if len(services) > 0 {
+			break
+		}
+		options.Datacenter = dc
+		services, _, err = r.client.Health().Service(service, "", true, &options)
+		if err != nil {
+			r.logger.Debugf("Failed to query healthy consul services in datacenter '%s': %v", dc, err)
+		}
+	}
+
+	if len(services) == 0 {
 		return nil, fmt.Errorf("no healthy services found with AppID '%s'", service)
 	}
 
-	//nolint:gosec
-	return services[rand.Int()%len(services)], nil
+	return services, nil
 }
 
 func (r *registry) addOrUpdate(service string, services []*consul.ServiceEntry) {
@@ -215,12 +257,14 @@ func (r *registry) registrationChannel() chan string {
 }
 
 type resolverConfig struct {
-	Client            *consul.Config
-	QueryOptions      *consul.QueryOptions
-	Registration      *consul.AgentServiceRegistration
-	DeregisterOnClose bool
-	DaprPortMetaKey   string
-	UseCache          bool
+	Client              *consul.Config
+	QueryOptions        *consul.QueryOptions
+	Registration        *consul.AgentServiceRegistration
+	DeregisterOnClose   bool
+	DaprPortMetaKey     string
+	UseCache            bool
+	LoadBalancing       string
+	FailoverDatacenters []string
 }
 
 // NewResolver creates Consul name resolver.
@@ -229,11 +273,18 @@ func NewResolver(logger logger.Logger) nr.Resolver {
 }
 
 func newResolver(logger logger.Logger, resolverConfig resolverConfig, client clientInterface, registry registryInterface, watcherStopChannel chan struct{}) nr.Resolver {
+	balancer, err := newLoadBalancer(resolverConfig.LoadBalancing)
+	if err != nil {
+		// Validated in Init
+		balancer = randomLoadBalancer{}
+	}
+
 	return &resolver{
 		logger:             logger,
 		config:             resolverConfig,
 		client:             client,
 		registry:           registry,
+		balancer:           balancer,
 		watcherStopChannel: watcherStopChannel,
 	}
 }
@@ -245,6 +296,11 @@ func (r *resolver) Init(ctx context.Context, metadata nr.Metadata) (err error) {
 		return err
 	}
 
+	r.balancer, err = newLoadBalancer(r.config.LoadBalancing)
+	if err != nil {
+		return err
+	}
+
 	if r.config.Client.TLSConfig.InsecureSkipVerify {
 		r.logger.Infof("hashicorp consul: you are using 'insecureSkipVerify' to skip server config verify which is unsafe!")
 	}
@@ -276,23 +332,50 @@ func (r *resolver) Init(ctx context.Context, metadata nr.Metadata) (err error) {
 
 // ResolveID resolves name to address via consul.
 func (r *resolver) ResolveID(ctx context.Context, req nr.ResolveRequest) (addr string, err error) {
-	cfg := r.config
 	svc, err := r.getService(req.ID)
 	if err != nil {
 		return "", err
 	}
 
+	return r.getAddress(req.ID, svc)
+}
+
+// ResolveIDMulti resolves name to the addresses of all healthy instances via consul.
+func (r *resolver) ResolveIDMulti(ctx context.Context, req nr.ResolveRequest) (nr.AddressList, error) {
+	services, err := r.getServices(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make(nr.AddressList, 0, len(services))
+	for _, svc := range services {
+		addr, err := r.getAddress(req.ID, svc)
+		if err != nil {
+			r.logger.Debugf("Skipping instance of AppID '%s': %v", req.ID, err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no healthy services found with AppID '%s'", req.ID)
+	}
+
+	return addrs, nil
+}
+
+func (r *resolver) getAddress(appID string, svc *consul.ServiceEntry) (addr string, err error) {
+	cfg := r.config
 	port := svc.Service.Meta[cfg.DaprPortMetaKey]
 	if port == "" {
-		return "", fmt.Errorf("target service AppID '%s' found but %s missing from meta", req.ID, cfg.DaprPortMetaKey)
+		return "", fmt.Errorf("target service AppID '%s' found but %s missing from meta", appID, cfg.DaprPortMetaKey)
 	}
 
 	if svc.Service.Address != "" {
 		addr = svc.Service.Address
-	} else if svc.Node.Address != "" {
+	} else if svc.Node != nil && svc.Node.Address != "" {
 		addr = svc.Node.Address
 	} else {
-		return "", fmt.Errorf("no healthy services found with AppID '%s'", req.ID)
+		return "", fmt.Errorf("no healthy services found with AppID '%s'", appID)
 	}
 
 	return formatAddress(addr, port)
@@ -305,7 +388,15 @@ func (r *resolver) Close() error {
 	}
 
 	if r.config.Registration != nil && r.config.DeregisterOnClose {
-		err := r.client.Agent().ServiceDeregister(r.config.Registration.ID)
+		var err error
+		if r.config.Registration.Namespace != "" || r.config.Registration.Partition != "" {
+			err = r.client.Agent().ServiceDeregisterOpts(r.config.Registration.ID, &consul.QueryOptions{
+				Namespace: r.config.Registration.Namespace,
+				Partition: r.config.Registration.Partition,
+			})
+		} else {
+			err = r.client.Agent().ServiceDeregister(r.config.Registration.ID)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to deregister consul service: %w", err)
 		}
@@ -347,6 +438,8 @@ func getConfig(metadata nr.Metadata) (resolverCfg resolverConfig, err error) {
 	resolverCfg.DaprPortMetaKey = cfg.DaprPortMetaKey
 	resolverCfg.DeregisterOnClose = cfg.SelfDeregister
 	resolverCfg.UseCache = cfg.UseCache
+	resolverCfg.LoadBalancing = cfg.LoadBalancing
+	resolverCfg.FailoverDatacenters = cfg.FailoverDatacenters
 
 	resolverCfg.Client = getClientConfig(cfg)
 	resolverCfg.Registration, err = getRegistrationConfig(cfg, props)
@@ -355,6 +448,22 @@ func getConfig(metadata nr.Metadata) (resolverCfg resolverConfig, err error) {
 	}
 	resolverCfg.QueryOptions = getQueryOptionsConfig(cfg)
 
+	// Consul Enterprise namespace and partition apply to both registration and queries, unless set explicitly
+	if resolverCfg.Registration != nil {
+		if resolverCfg.Registration.Namespace == "" {
+			resolverCfg.Registration.Namespace = cfg.Namespace
+		}
+		if resolverCfg.Registration.Partition == "" {
+			resolverCfg.Registration.Partition = cfg.Partition
+		}
+	}
+	if resolverCfg.QueryOptions.Namespace == "" {
+		resolverCfg.QueryOptions.Namespace = cfg.Namespace
+	}
+	if resolverCfg.QueryOptions.Partition == "" {
+		resolverCfg.QueryOptions.Partition = cfg.Partition
+	}
+
 	// if registering, set DaprPort in meta, needed for resolution
 	if resolverCfg.Registration != nil {
 		if resolverCfg.Registration.Meta == nil {
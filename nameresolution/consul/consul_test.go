@@ -106,6 +106,7 @@ type mockAgent struct {
 	serviceRegisterErr      error
 	serviceDeregisterCalled int
 	serviceDeregisterErr    error
+	serviceDeregisterOpts   *consul.QueryOptions
 }
 
 func (m *mockAgent) Self() (map[string]map[string]interface{}, error) {
@@ -126,6 +127,13 @@ func (m *mockAgent) ServiceDeregister(serviceID string) error {
 	return m.serviceDeregisterErr
 }
 
+func (m *mockAgent) ServiceDeregisterOpts(serviceID string, q *consul.QueryOptions) error {
+	m.serviceDeregisterCalled++
+	m.serviceDeregisterOpts = q
+
+	return m.serviceDeregisterErr
+}
+
 type mockRegistry struct {
 	getKeysCalled         atomic.Int32
 	getKeysResult         *[]string
@@ -1032,6 +1040,108 @@ func TestResolveID(t *testing.T) {
 	}
 }
 
+func TestResolveIDMulti(t *testing.T) {
+	mock := &mockClient{
+		mockHealth: mockHealth{
+			serviceResult: []*consul.ServiceEntry{
+				{
+					Service: &consul.AgentService{
+						Address: "10.3.245.137",
+						Meta: map[string]string{
+							"DAPR_PORT": "50005",
+						},
+					},
+				},
+				{
+					Node: &consul.Node{
+						Address: "10.3.245.138",
+					},
+					Service: &consul.AgentService{
+						Meta: map[string]string{
+							"DAPR_PORT": "50005",
+						},
+					},
+				},
+				{
+					// Missing DAPR_PORT
+					Service: &consul.AgentService{
+						Address: "10.3.245.139",
+					},
+				},
+			},
+			serviceMeta: &consul.QueryMeta{},
+		},
+	}
+	cfg := resolverConfig{
+		DaprPortMetaKey: "DAPR_PORT",
+		QueryOptions:    &consul.QueryOptions{},
+	}
+
+	resolver := newResolver(logger.NewLogger("test"), cfg, mock, &registry{}, make(chan struct{})).(*resolver)
+	addrs, err := resolver.ResolveIDMulti(context.Background(), nr.ResolveRequest{ID: "test-app"})
+	require.NoError(t, err)
+	assert.Equal(t, nr.AddressList{"10.3.245.137:50005", "10.3.245.138:50005"}, addrs)
+	assert.Equal(t, 1, mock.mockHealth.serviceCalled)
+
+	t.Run("no healthy services", func(t *testing.T) {
+		mock.mockHealth.serviceResult = nil
+		_, err := resolver.ResolveIDMulti(context.Background(), nr.ResolveRequest{ID: "test-app"})
+		require.Error(t, err)
+	})
+}
+
+func TestResolveIDFailoverDatacenters(t *testing.T) {
+	entries := map[string][]*consul.ServiceEntry{
+		"dc3": {
+			{
+				Service: &consul.AgentService{
+					Address: "10.3.245.140",
+					Meta: map[string]string{
+						"DAPR_PORT": "50005",
+					},
+				},
+			},
+		},
+	}
+	datacenters := []string{}
+	mock := &mockClient{
+		mockHealth: mockHealth{
+			serviceMeta: &consul.QueryMeta{},
+		},
+	}
+	mock.mockHealth.serviceBehavior = func(service, tag string, passingOnly bool, q *consul.QueryOptions) {
+		datacenters = append(datacenters, q.Datacenter)
+		mock.mockHealth.serviceResult = entries[q.Datacenter]
+	}
+	cfg := resolverConfig{
+		DaprPortMetaKey:     "DAPR_PORT",
+		QueryOptions:        &consul.QueryOptions{},
+		FailoverDatacenters: []string{"dc2", "dc3", "dc4"},
+	}
+
+	resolver := newResolver(logger.NewLogger("test"), cfg, mock, &registry{}, make(chan struct{})).(*resolver)
+	addr, err := resolver.ResolveID(context.Background(), nr.ResolveRequest{ID: "test-app"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.3.245.140:50005", addr)
+	assert.Equal(t, []string{"", "dc2", "dc3"}, datacenters)
+
+	t.Run("local datacenter is preferred", func(t *testing.T) {
+		datacenters = datacenters[:0]
+		entries[""] = entries["dc3"]
+		_, err := resolver.ResolveID(context.Background(), nr.ResolveRequest{ID: "test-app"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, datacenters)
+	})
+
+	t.Run("no healthy services in any datacenter", func(t *testing.T) {
+		datacenters = datacenters[:0]
+		entries = map[string][]*consul.ServiceEntry{}
+		_, err := resolver.ResolveID(context.Background(), nr.ResolveRequest{ID: "test-app"})
+		require.ErrorContains(t, err, "no healthy services found")
+		assert.Equal(t, []string{"", "dc2", "dc3", "dc4"}, datacenters)
+	})
+}
+
 func TestClose(t *testing.T) {
 	tests := []struct {
 		testName string
@@ -1054,6 +1164,28 @@ func TestClose(t *testing.T) {
 				assert.Equal(t, 1, mock.mockAgent.serviceDeregisterCalled)
 			},
 		},
+		{
+			"should deregister from namespace and partition",
+			nr.Metadata{Instance: getInstanceInfoWithoutKey(""), Configuration: nil},
+			func(t *testing.T, metadata nr.Metadata) {
+				var mock mockClient
+				cfg := resolverConfig{
+					Registration: &consul.AgentServiceRegistration{
+						Namespace: "ns1",
+						Partition: "part1",
+					},
+					DeregisterOnClose: true,
+				}
+
+				resolver := newResolver(logger.NewLogger("test"), cfg, &mock, &registry{}, make(chan struct{})).(*resolver)
+				resolver.Close()
+
+				assert.Equal(t, 1, mock.mockAgent.serviceDeregisterCalled)
+				require.NotNil(t, mock.mockAgent.serviceDeregisterOpts)
+				assert.Equal(t, "ns1", mock.mockAgent.serviceDeregisterOpts.Namespace)
+				assert.Equal(t, "part1", mock.mockAgent.serviceDeregisterOpts.Partition)
+			},
+		},
 		{
 			"should not deregister",
 			nr.Metadata{Instance: getInstanceInfoWithoutKey(""), Configuration: nil},
@@ -1398,6 +1530,50 @@ func TestGetConfig(t *testing.T) {
 				assert.False(t, actual.UseCache)
 			},
 		},
+		{
+			"Namespace and Partition should apply to registration and query options",
+			nr.Metadata{
+				Instance: getInstanceInfoWithoutKey(""),
+				Configuration: map[any]any{
+					"SelfRegister":        true,
+					"Namespace":           "ns1",
+					"Partition":           "part1",
+					"LoadBalancing":       "roundRobin",
+					"FailoverDatacenters": []any{"dc2", "dc3"},
+				},
+			},
+			func(t *testing.T, metadata nr.Metadata) {
+				actual, err := getConfig(metadata)
+				require.NoError(t, err)
+
+				assert.Equal(t, "ns1", actual.Registration.Namespace)
+				assert.Equal(t, "part1", actual.Registration.Partition)
+				assert.Equal(t, "ns1", actual.QueryOptions.Namespace)
+				assert.Equal(t, "part1", actual.QueryOptions.Partition)
+				assert.Equal(t, "roundRobin", actual.LoadBalancing)
+				assert.Equal(t, []string{"dc2", "dc3"}, actual.FailoverDatacenters)
+			},
+		},
+		{
+			"explicit QueryOptions Namespace should not be overridden",
+			nr.Metadata{
+				Instance: getInstanceInfoWithoutKey(""),
+				Configuration: map[any]any{
+					"Namespace": "ns1",
+					"QueryOptions": map[any]any{
+						"Namespace": "ns2",
+					},
+				},
+			},
+			func(t *testing.T, metadata nr.Metadata) {
+				actual, err := getConfig(metadata)
+				require.NoError(t, err)
+
+				assert.Nil(t, actual.Registration)
+				assert.Equal(t, "ns2", actual.QueryOptions.Namespace)
+				assert.Empty(t, actual.QueryOptions.Partition)
+			},
+		},
 		{
 			"empty configuration with SelfRegister should default correctly",
 			nr.Metadata{
@@ -1999,9 +2175,13 @@ func TestMapConfig(t *testing.T) {
 					SidecarService: nil,
 				},
 			},
-			SelfRegister:    true,
-			DaprPortMetaKey: "SOMETHINGSOMETHING",
-			UseCache:        false,
+			SelfRegister:        true,
+			DaprPortMetaKey:     "SOMETHINGSOMETHING",
+			UseCache:            false,
+			LoadBalancing:       "leastConnection",
+			FailoverDatacenters: []string{"dc2"},
+			Namespace:           "Namespace",
+			Partition:           "Partition",
 		}
 
 		actual := mapConfig(expected)
@@ -2019,6 +2199,10 @@ func TestMapConfig(t *testing.T) {
 		assert.Equal(t, expected.SelfRegister, actual.SelfRegister)
 		assert.Equal(t, expected.DaprPortMetaKey, actual.DaprPortMetaKey)
 		assert.Equal(t, expected.UseCache, actual.UseCache)
+		assert.Equal(t, expected.LoadBalancing, actual.LoadBalancing)
+		assert.Equal(t, expected.FailoverDatacenters, actual.FailoverDatacenters)
+		assert.Equal(t, expected.Namespace, actual.Namespace)
+		assert.Equal(t, expected.Partition, actual.Partition)
 	})
 
 	t.Run("should map empty configuration", func(t *testing.T) {
@@ -2031,6 +2215,8 @@ func TestMapConfig(t *testing.T) {
 }
 
 func compareQueryOptions(t *testing.T, expected *QueryOptions, actual *consul.QueryOptions) {
+	assert.Equal(t, expected.Namespace, actual.Namespace)
+	assert.Equal(t, expected.Partition, actual.Partition)
 	assert.Equal(t, expected.Datacenter, actual.Datacenter)
 	assert.Equal(t, expected.AllowStale, actual.AllowStale)
 	assert.Equal(t, expected.RequireConsistent, actual.RequireConsistent)
@@ -2075,6 +2261,8 @@ func compareClientConfig(t *testing.T, expected *Config, actual *consul.Config)
 func compareRegistration(t *testing.T, expected *AgentServiceRegistration, actual *consul.AgentServiceRegistration) {
 	assert.Equal(t, expected.Kind, string(actual.Kind))
 	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Namespace, actual.Namespace)
+	assert.Equal(t, expected.Partition, actual.Partition)
 	assert.Equal(t, expected.Name, actual.Name)
 	assert.Equal(t, expected.Tags, actual.Tags)
 	assert.Equal(t, expected.Port, actual.Port)
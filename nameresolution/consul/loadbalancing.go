@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// Load balancing strategies.
+const (
+	loadBalancingRandom          = "random"
+	loadBalancingRoundRobin      = "roundrobin"
+	loadBalancingLeastConnection = "leastconnection"
+	loadBalancingWeighted        = "weighted"
+)
+
+// loadBalancer selects one of the instances of a service.
+type loadBalancer interface {
+	pick(service string, entries []*consul.ServiceEntry) *consul.ServiceEntry
+}
+
+func newLoadBalancer(strategy string) (loadBalancer, error) {
+	switch strings.ToLower(strategy) {
+	case "", loadBalancingRandom:
+		return randomLoadBalancer{}, nil
+	case loadBalancingRoundRobin:
+		return &roundRobinLoadBalancer{}, nil
+	case loadBalancingLeastConnection:
+		return &leastConnectionLoadBalancer{
+			counts: map[string]map[string]uint64{},
+		}, nil
+	case loadBalancingWeighted:
+		return weightedLoadBalancer{}, nil
+	default:
+		return nil, fmt.Errorf("invalid load balancing strategy: %s", strategy)
+	}
+}
+
+// randomLoadBalancer selects a random instance.
+type randomLoadBalancer struct{}
+
+func (randomLoadBalancer) pick(_ string, entries []*consul.ServiceEntry) *consul.ServiceEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// gosec is complaining that we are using a non-crypto-safe PRNG. This is fine in this scenario since we are using it only for selecting a random address for load-balancing.
+	//nolint:gosec
+	return entries[rand.Int()%len(entries)]
+}
+
+// weightedLoadBalancer selects a random instance, with a probability proportional to the passing weight of each instance.
+// Instances without weights count as having a weight of 1, which is the default in Consul.
+type weightedLoadBalancer struct{}
+
+func (weightedLoadBalancer) pick(_ string, entries []*consul.ServiceEntry) *consul.ServiceEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, e := range entries {
+		total += entryWeight(e)
+	}
+	if total == 0 {
+		return randomLoadBalancer{}.pick("", entries)
+	}
+
+	//nolint:gosec
+	n := rand.Intn(total)
+	for _, e := range entries {
+		n -= entryWeight(e)
+		if n < 0 {
+			return e
+		}
+	}
+	return entries[len(entries)-1]
+}
+
+// entryWeight returns the weight of an instance when its checks are passing.
+func entryWeight(e *consul.ServiceEntry) int {
+	if e.Service == nil || e.Service.Weights.Passing == 0 {
+		return 1
+	}
+	return max(e.Service.Weights.Passing, 0)
+}
+
+// roundRobinLoadBalancer selects instances in turn, with a counter for each service.
+type roundRobinLoadBalancer struct {
+	counters sync.Map // map[string]*atomic.Uint64
+}
+
+func (b *roundRobinLoadBalancer) pick(service string, entries []*consul.ServiceEntry) *consul.ServiceEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	counter, _ := b.counters.LoadOrStore(service, &atomic.Uint64{})
+	n := counter.(*atomic.Uint64).Add(1) - 1
+	return entries[n%uint64(len(entries))]
+}
+
+// leastConnectionLoadBalancer selects the instance that received the fewest requests.
+// The resolver doesn't observe the connections to the instances, so the number of times each instance was picked is used instead.
+// Instances that are added later start from the lowest count of the existing ones, so they don't receive all requests until they catch up.
+type leastConnectionLoadBalancer struct {
+	lock sync.Mutex
+	// Map of service names to the number of requests for each instance
+	counts map[string]map[string]uint64
+}
+
+func (b *leastConnectionLoadBalancer) pick(service string, entries []*consul.ServiceEntry) *consul.ServiceEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Build the counts for the current instances only, so instances that are gone are not tracked anymore
+	prev := b.counts[service]
+	counts := make(map[string]uint64, len(entries))
+	lowest := uint64(math.MaxUint64)
+	for _, e := range entries {
+		id := entryID(e)
+		if c, ok := prev[id]; ok {
+			counts[id] = c
+			lowest = min(lowest, c)
+		}
+	}
+	if lowest == math.MaxUint64 {
+		lowest = 0
+	}
+	for _, e := range entries {
+		id := entryID(e)
+		if _, ok := counts[id]; !ok {
+			counts[id] = lowest
+		}
+	}
+	b.counts[service] = counts
+
+	// Pick the instance with the lowest count, with ties broken randomly
+	var (
+		picked *consul.ServiceEntry
+		ties   int
+	)
+	lowest = math.MaxUint64
+	for _, e := range entries {
+		c := counts[entryID(e)]
+		switch {
+		case c < lowest:
+			lowest = c
+			picked = e
+			ties = 1
+		case c == lowest:
+			ties++
+			//nolint:gosec
+			if rand.Intn(ties) == 0 {
+				picked = e
+			}
+		}
+	}
+	counts[entryID(picked)]++
+
+	return picked
+}
+
+// entryID returns a unique identifier for a service instance.
+func entryID(e *consul.ServiceEntry) string {
+	var node, id, addr string
+	if e.Node != nil {
+		node = e.Node.Node
+	}
+	if e.Service != nil {
+		id = e.Service.ID
+		addr = e.Service.Address
+	}
+	return node + "/" + id + "/" + addr
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"testing"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancer(t *testing.T) {
+	newEntry := func(id string) *consul.ServiceEntry {
+		return &consul.ServiceEntry{
+			Node:    &consul.Node{Node: "node1"},
+			Service: &consul.AgentService{ID: id, Address: "10.0.0.1"},
+		}
+	}
+	entries := []*consul.ServiceEntry{newEntry("a"), newEntry("b"), newEntry("c")}
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, err := newLoadBalancer("foo")
+		require.Error(t, err)
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		for _, strategy := range []string{"random", "roundRobin", "leastConnection", "weighted"} {
+			lb, err := newLoadBalancer(strategy)
+			require.NoError(t, err)
+			assert.Nil(t, lb.pick("svc", nil))
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		lb, err := newLoadBalancer("")
+		require.NoError(t, err)
+		require.IsType(t, randomLoadBalancer{}, lb)
+		for range 10 {
+			assert.Contains(t, entries, lb.pick("svc", entries))
+		}
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		lb, err := newLoadBalancer("roundRobin")
+		require.NoError(t, err)
+		for i := range 6 {
+			assert.Same(t, entries[i%3], lb.pick("svc", entries))
+		}
+
+		// Counters are per service
+		assert.Same(t, entries[0], lb.pick("other", entries))
+	})
+
+	t.Run("least connection", func(t *testing.T) {
+		lb, err := newLoadBalancer("LeastConnection")
+		require.NoError(t, err)
+
+		counts := map[string]int{}
+		for range 6 {
+			counts[lb.pick("svc", entries).Service.ID]++
+		}
+		assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 2}, counts)
+
+		// A new instance starts from the lowest count, so it doesn't get all requests
+		updated := append([]*consul.ServiceEntry{newEntry("d")}, entries[1:]...)
+		counts = map[string]int{}
+		for range 6 {
+			counts[lb.pick("svc", updated).Service.ID]++
+		}
+		assert.Equal(t, map[string]int{"b": 2, "c": 2, "d": 2}, counts)
+	})
+	t.Run("weighted", func(t *testing.T) {
+		lb, err := newLoadBalancer("weighted")
+		require.NoError(t, err)
+
+		weighted := []*consul.ServiceEntry{newEntry("a"), newEntry("b"), newEntry("c")}
+		weighted[0].Service.Weights.Passing = 3
+		weighted[2].Service.Weights.Passing = -1
+		counts := map[string]int{}
+		for range 4000 {
+			counts[lb.pick("svc", weighted).Service.ID]++
+		}
+		// Instances without weights count as 1, and negative weights as 0
+		assert.InDelta(t, 3000, counts["a"], 300)
+		assert.InDelta(t, 1000, counts["b"], 300)
+		assert.Zero(t, counts["c"])
+	})
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	miekgdns "github.com/miekg/dns"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// ErrNoHost is returned by ResolveID when no host can be found.
+var ErrNoHost = errors.New("no host found with the given ID")
+
+type cacheEntry struct {
+	// Empty for negative entries
+	targets []srvTarget
+	expires time.Time
+}
+
+type resolver struct {
+	logger    logger.Logger
+	metadata  dnsMetadata
+	client    *miekgdns.Client
+	tcpClient *miekgdns.Client
+
+	cache     map[string]cacheEntry
+	cacheLock sync.RWMutex
+	// Counters for round-robin load balancing, for each name
+	counters sync.Map // map[string]*atomic.Uint64
+}
+
+// NewResolver creates a name resolver that is based on DNS SRV records.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	return &resolver{
+		logger: logger,
+		cache:  map[string]cacheEntry{},
+	}
+}
+
+// Init initializes the name resolver.
+func (r *resolver) Init(ctx context.Context, md nameresolution.Metadata) error {
+	err := r.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	r.client = &miekgdns.Client{
+		Net:     "udp",
+		Timeout: r.metadata.Timeout,
+	}
+	// Used when responses over UDP are truncated
+	r.tcpClient = &miekgdns.Client{
+		Net:     "tcp",
+		Timeout: r.metadata.Timeout,
+	}
+
+	return nil
+}
+
+// ResolveID resolves name to address.
+// The address is selected according to the priority of the SRV records and the load balancing strategy.
+func (r *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	addrs, err := r.ResolveIDMulti(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return addrs[0], nil
+}
+
+// ResolveIDMulti resolves name to a list of addresses.
+// Addresses are sorted in the order they should be contacted, according to the priority of the SRV records and the load balancing strategy.
+func (r *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	name := r.metadata.queryName(req.ID, req.Namespace)
+
+	r.cacheLock.RLock()
+	entry, ok := r.cache[name]
+	r.cacheLock.RUnlock()
+
+	if !ok || !time.Now().Before(entry.expires) {
+		targets, ttl, err := r.lookup(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		entry = cacheEntry{
+			targets: targets,
+			expires: time.Now().Add(ttl),
+		}
+		r.cacheLock.Lock()
+		if ttl > 0 {
+			r.cache[name] = entry
+		} else {
+			delete(r.cache, name)
+		}
+		r.cacheLock.Unlock()
+	}
+
+	var addrs []string
+	if r.metadata.LoadBalancing == loadBalancingRoundRobin {
+		counter, _ := r.counters.LoadOrStore(name, &atomic.Uint64{})
+		addrs = roundRobinOrder(entry.targets, counter.(*atomic.Uint64).Add(1)-1)
+	} else {
+		addrs = orderTargets(entry.targets)
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoHost
+	}
+	return addrs, nil
+}
+
+// lookup resolves the SRV records with the name and the addresses of their targets.
+// It returns the duration for which the result can be cached, which is the lowest TTL of the records; when no target is found, the result is a negative one, cached as per RFC 2308.
+func (r *resolver) lookup(ctx context.Context, name string) ([]srvTarget, time.Duration, error) {
+	res, err := r.query(ctx, name, miekgdns.TypeSRV)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.Rcode == miekgdns.RcodeNameError {
+		return nil, r.negativeTTL(res), nil
+	}
+
+	// Addresses of the targets included in the additional section
+	additional := map[string]*hostAddresses{}
+	for _, rr := range res.Extra {
+		ip := recordIP(rr)
+		if ip == "" {
+			continue
+		}
+		key := strings.ToLower(rr.Header().Name)
+		if additional[key] == nil {
+			additional[key] = &hostAddresses{ttl: math.MaxUint32}
+		}
+		additional[key].add(ip, rr.Header().Ttl)
+	}
+
+	var (
+		targets []srvTarget
+		ttl     uint32 = math.MaxUint32
+	)
+	for _, rr := range res.Answer {
+		srv, ok := rr.(*miekgdns.SRV)
+		if !ok {
+			continue
+		}
+		// A target of "." means that the service is decidedly not available
+		if srv.Target == "." {
+			r.logger.Debugf("SRV record %s indicates the service is not available", name)
+			continue
+		}
+
+		host, ok := additional[strings.ToLower(srv.Target)]
+		if !ok {
+			host, err = r.lookupHost(ctx, srv.Target)
+			if err != nil {
+				r.logger.Debugf("Ignoring target %s of SRV record %s: %v", srv.Target, name, err)
+				continue
+			}
+		}
+		if len(host.ips) == 0 {
+			continue
+		}
+		ttl = min(ttl, srv.Hdr.Ttl, host.ttl)
+
+		t := srvTarget{
+			priority:  srv.Priority,
+			weight:    srv.Weight,
+			addresses: make([]string, len(host.ips)),
+		}
+		port := strconv.Itoa(int(srv.Port))
+		for i, ip := range host.ips {
+			t.addresses[i] = net.JoinHostPort(ip, port)
+		}
+		targets = append(targets, t)
+	}
+
+	if len(targets) == 0 {
+		return nil, r.negativeTTL(res), nil
+	}
+	return targets, time.Duration(ttl) * time.Second, nil
+}
+
+// lookupHost resolves the A and AAAA records of a host.
+func (r *resolver) lookupHost(ctx context.Context, host string) (*hostAddresses, error) {
+	res := &hostAddresses{ttl: math.MaxUint32}
+	for _, qtype := range []uint16{miekgdns.TypeA, miekgdns.TypeAAAA} {
+		msg, err := r.query(ctx, host, qtype)
+		if err != nil {
+			return nil, err
+		}
+		for _, rr := range msg.Answer {
+			ip := recordIP(rr)
+			if ip != "" {
+				res.add(ip, rr.Header().Ttl)
+			}
+		}
+	}
+	return res, nil
+}
+
+// query sends a query to the DNS servers, trying them in order until one answers.
+func (r *resolver) query(ctx context.Context, name string, qtype uint16) (*miekgdns.Msg, error) {
+	msg := new(miekgdns.Msg)
+	msg.SetQuestion(name, qtype)
+	msg.RecursionDesired = true
+
+	var errs []error
+	for _, server := range r.metadata.servers {
+		res, _, err := r.client.ExchangeContext(ctx, msg, server)
+		if err == nil && res.Truncated {
+			res, _, err = r.tcpClient.ExchangeContext(ctx, msg, server)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("query to %s failed: %w", server, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if res.Rcode != miekgdns.RcodeSuccess && res.Rcode != miekgdns.RcodeNameError {
+			// Other servers may be able to answer
+			errs = append(errs, fmt.Errorf("query to %s failed with code %s", server, miekgdns.RcodeToString[res.Rcode]))
+			continue
+		}
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("failed to resolve %s: %w", name, errors.Join(errs...))
+}
+
+// negativeTTL returns the duration for which a negative response can be cached.
+// As per RFC 2308, this is the lowest of the TTL and the MINIMUM field of the SOA record in the authority section, if any; in any case, it's capped to the configured negative cache TTL.
+func (r *resolver) negativeTTL(res *miekgdns.Msg) time.Duration {
+	ttl := r.metadata.NegativeCacheTTL
+	for _, rr := range res.Ns {
+		soa, ok := rr.(*miekgdns.SOA)
+		if !ok {
+			continue
+		}
+		ttl = min(ttl, time.Duration(min(soa.Hdr.Ttl, soa.Minttl))*time.Second)
+	}
+	return ttl
+}
+
+// hostAddresses contains the addresses of a host and the lowest TTL of their records.
+type hostAddresses struct {
+	ips []string
+	ttl uint32
+}
+
+func (h *hostAddresses) add(ip string, ttl uint32) {
+	h.ips = append(h.ips, ip)
+	h.ttl = min(h.ttl, ttl)
+}
+
+// recordIP returns the IP of an A or AAAA record, or an empty string for records of other types.
+func recordIP(rr miekgdns.RR) string {
+	switch rec := rr.(type) {
+	case *miekgdns.A:
+		return rec.A.String()
+	case *miekgdns.AAAA:
+		return rec.AAAA.String()
+	default:
+		return ""
+	}
+}
+
+// Close implements io.Closer.
+func (r *resolver) Close() error {
+	return nil
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	miekgdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInitWithMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers": "10.0.0.2, 10.0.0.3:5353, fd00::2",
+				"domain":  "example.com.",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.2:53", "10.0.0.3:5353", "[fd00::2]:53"}, m.servers)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, defaultNegativeCacheTTL, m.NegativeCacheTTL)
+		assert.Equal(t, "_dapr._tcp.myapp.example.com.", m.queryName("myapp", ""))
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers":          "10.0.0.2",
+				"service":          "_grpc",
+				"protocol":         "UDP",
+				"timeout":          "1s",
+				"negativeCacheTTL": "0",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, m.Timeout)
+		assert.Equal(t, time.Duration(0), m.NegativeCacheTTL)
+		assert.Equal(t, "_grpc._udp.myapp.", m.queryName("myapp", ""))
+	})
+
+	t.Run("name template", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers":       "10.0.0.2",
+				"domain":        "ignored.com",
+				"nameTemplate":  "_dapr._tcp.{appID}.{namespace}.svc.example.com",
+				"loadBalancing": "roundRobin",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, loadBalancingRoundRobin, m.LoadBalancing)
+		assert.Equal(t, "_dapr._tcp.myapp.prod.svc.example.com.", m.queryName("myapp", "prod"))
+	})
+
+	t.Run("name template without app ID", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers":      "10.0.0.2",
+				"nameTemplate": "_dapr._tcp.example.com",
+			},
+		})
+		require.ErrorContains(t, err, "must contain the {appID} placeholder")
+	})
+
+	t.Run("invalid load balancing", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers":       "10.0.0.2",
+				"loadBalancing": "foo",
+			},
+		})
+		require.ErrorContains(t, err, "invalid value for configuration property 'loadBalancing'")
+	})
+
+	t.Run("empty service", func(t *testing.T) {
+		m := dnsMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"servers": "10.0.0.2",
+				"service": "_",
+			},
+		})
+		require.EqualError(t, err, "configuration property 'service' must not be empty")
+	})
+}
+
+func TestOrderTargets(t *testing.T) {
+	t.Run("sorted by priority", func(t *testing.T) {
+		res := orderTargets([]srvTarget{
+			{priority: 20, weight: 100, addresses: []string{"10.0.0.3:1"}},
+			{priority: 10, weight: 0, addresses: []string{"10.0.0.1:1", "10.0.0.2:1"}},
+		})
+		require.Len(t, res, 3)
+		assert.ElementsMatch(t, []string{"10.0.0.1:1", "10.0.0.2:1"}, res[:2])
+		assert.Equal(t, "10.0.0.3:1", res[2])
+	})
+
+	t.Run("weighted within priority", func(t *testing.T) {
+		targets := []srvTarget{
+			{priority: 10, weight: 10, addresses: []string{"a"}},
+			{priority: 10, weight: 90, addresses: []string{"b"}},
+		}
+		count := map[string]int{}
+		for range 2000 {
+			res := orderTargets(targets)
+			require.Len(t, res, 2)
+			count[res[0]]++
+		}
+		// Expected to be around 1800
+		assert.Greater(t, count["b"], 1600)
+		assert.Less(t, count["b"], 1950)
+	})
+
+	t.Run("zero weights", func(t *testing.T) {
+		targets := []srvTarget{
+			{priority: 10, addresses: []string{"a"}},
+			{priority: 10, addresses: []string{"b"}},
+		}
+		count := map[string]int{}
+		for range 1000 {
+			count[orderTargets(targets)[0]]++
+		}
+		assert.Greater(t, count["a"], 300)
+		assert.Greater(t, count["b"], 300)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, orderTargets(nil))
+	})
+}
+
+func TestRoundRobinOrder(t *testing.T) {
+	targets := []srvTarget{
+		{priority: 20, weight: 100, addresses: []string{"c"}},
+		{priority: 10, weight: 90, addresses: []string{"b"}},
+		{priority: 10, weight: 10, addresses: []string{"a"}},
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, roundRobinOrder(targets, 0))
+	assert.Equal(t, []string{"b", "a", "c"}, roundRobinOrder(targets, 1))
+	assert.Equal(t, []string{"a", "b", "c"}, roundRobinOrder(targets, 2))
+	assert.Empty(t, roundRobinOrder(nil, 0))
+}
+
+func TestResolver(t *testing.T) {
+	srv := startFakeDNS(t, map[string][]string{
+		"_dapr._tcp.myapp.example.com. SRV": {
+			"_dapr._tcp.myapp.example.com. 60 IN SRV 10 0 50002 node1.example.com.",
+			"_dapr._tcp.myapp.example.com. 60 IN SRV 20 0 50002 node2.example.com.",
+		},
+		"node1.example.com. A": {
+			"node1.example.com. 30 IN A 10.0.0.1",
+		},
+		"node2.example.com. AAAA": {
+			"node2.example.com. 30 IN AAAA fd00::2",
+		},
+		"_dapr._tcp.nocache.example.com. SRV": {
+			"_dapr._tcp.nocache.example.com. 0 IN SRV 10 0 50002 node1.example.com.",
+		},
+		"_dapr._tcp.unavailable.example.com. SRV": {
+			"_dapr._tcp.unavailable.example.com. 60 IN SRV 0 0 0 .",
+		},
+	})
+
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	err := r.Init(context.Background(), nameresolution.Metadata{
+		Configuration: map[string]string{
+			"servers": srv.addr,
+			"domain":  "example.com",
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	t.Run("resolves by priority", func(t *testing.T) {
+		addrs, err := r.ResolveIDMulti(context.Background(), nameresolution.ResolveRequest{ID: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, nameresolution.AddressList{"10.0.0.1:50002", "[fd00::2]:50002"}, addrs)
+
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1:50002", addr)
+	})
+
+	t.Run("caches results", func(t *testing.T) {
+		before := srv.queries.Load()
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, before, srv.queries.Load())
+
+		// Cached for the lowest TTL of the records
+		r.cacheLock.RLock()
+		entry := r.cache["_dapr._tcp.myapp.example.com."]
+		r.cacheLock.RUnlock()
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), entry.expires, 5*time.Second)
+	})
+
+	t.Run("does not cache records with TTL 0", func(t *testing.T) {
+		for range 2 {
+			addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "nocache"})
+			require.NoError(t, err)
+			assert.Equal(t, "10.0.0.1:50002", addr)
+		}
+		r.cacheLock.RLock()
+		_, ok := r.cache["_dapr._tcp.nocache.example.com."]
+		r.cacheLock.RUnlock()
+		assert.False(t, ok)
+	})
+
+	t.Run("negative cache", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "notfound"})
+		require.ErrorIs(t, err, ErrNoHost)
+
+		before := srv.queries.Load()
+		_, err = r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "notfound"})
+		require.ErrorIs(t, err, ErrNoHost)
+		assert.Equal(t, before, srv.queries.Load())
+
+		// Cached for the minimum TTL in the SOA record
+		r.cacheLock.RLock()
+		entry := r.cache["_dapr._tcp.notfound.example.com."]
+		r.cacheLock.RUnlock()
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), entry.expires, 5*time.Second)
+	})
+
+	t.Run("service not available", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "unavailable"})
+		require.ErrorIs(t, err, ErrNoHost)
+	})
+}
+
+func TestResolverRoundRobin(t *testing.T) {
+	srv := startFakeDNS(t, map[string][]string{
+		"myapp.prod.example.com. SRV": {
+			"myapp.prod.example.com. 60 IN SRV 10 90 50002 node1.example.com.",
+			"myapp.prod.example.com. 60 IN SRV 10 10 50002 node2.example.com.",
+		},
+		"node1.example.com. A": {
+			"node1.example.com. 30 IN A 10.0.0.1",
+		},
+		"node2.example.com. A": {
+			"node2.example.com. 30 IN A 10.0.0.2",
+		},
+	})
+
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	err := r.Init(context.Background(), nameresolution.Metadata{
+		Configuration: map[string]string{
+			"servers":       srv.addr,
+			"nameTemplate":  "{appID}.{namespace}.example.com",
+			"loadBalancing": "roundRobin",
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	addrs := make([]string, 0, 4)
+	for range 4 {
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp", Namespace: "prod"})
+		require.NoError(t, err)
+		addrs = append(addrs, addr)
+	}
+	assert.Equal(t, []string{"10.0.0.1:50002", "10.0.0.2:50002", "10.0.0.1:50002", "10.0.0.2:50002"}, addrs)
+}
+
+type fakeDNS struct {
+	addr    string
+	queries atomic.Int32
+}
+
+// startFakeDNS starts a DNS server that answers with the records set for each "<name> <type>" key.
+// The A records of the first target of SRV records are included in the additional section.
+func startFakeDNS(t *testing.T, records map[string][]string) *fakeDNS {
+	t.Helper()
+
+	soa, err := miekgdns.NewRR("example.com. 10 IN SOA ns.example.com. admin.example.com. 1 3600 600 86400 10")
+	require.NoError(t, err)
+
+	f := &fakeDNS{}
+	handler := miekgdns.HandlerFunc(func(w miekgdns.ResponseWriter, req *miekgdns.Msg) {
+		f.queries.Add(1)
+
+		res := new(miekgdns.Msg)
+		res.SetReply(req)
+		q := req.Question[0]
+		found := false
+		for key := range records {
+			// The name exists if it has records of any type
+			name, _, _ := strings.Cut(key, " ")
+			found = found || name == q.Name
+		}
+		for _, s := range records[q.Name+" "+miekgdns.TypeToString[q.Qtype]] {
+			rr, err := miekgdns.NewRR(s)
+			require.NoError(t, err)
+			res.Answer = append(res.Answer, rr)
+		}
+		if len(res.Answer) > 0 && q.Qtype == miekgdns.TypeSRV {
+			target := res.Answer[0].(*miekgdns.SRV).Target
+			for _, s := range records[target+" A"] {
+				rr, _ := miekgdns.NewRR(s)
+				res.Extra = append(res.Extra, rr)
+			}
+		}
+		if !found {
+			res.Rcode = miekgdns.RcodeNameError
+		}
+		if len(res.Answer) == 0 {
+			res.Ns = append(res.Ns, soa)
+		}
+		_ = w.WriteMsg(res)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f.addr = pc.LocalAddr().String()
+
+	var started sync.WaitGroup
+	started.Add(1)
+	server := &miekgdns.Server{
+		PacketConn:        pc,
+		Handler:           handler,
+		NotifyStartedFunc: started.Done,
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	started.Wait()
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	return f
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	miekgdns "github.com/miekg/dns"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultService          = "dapr"
+	defaultProtocol         = "tcp"
+	defaultTimeout          = 5 * time.Second
+	defaultNegativeCacheTTL = 30 * time.Second
+	defaultResolvConf       = "/etc/resolv.conf"
+
+	loadBalancingWeighted   = "weighted"
+	loadBalancingRoundRobin = "roundrobin"
+
+	// Placeholders in the name template
+	nameTemplateAppID     = "{appID}"
+	nameTemplateNamespace = "{namespace}"
+)
+
+type dnsMetadata struct {
+	// Comma-separated list of DNS servers, such as "10.0.0.2:53". If empty, the servers in /etc/resolv.conf are used.
+	Servers string `mapstructure:"servers"`
+	// Domain the SRV records are in. Records are looked up at "_<service>._<protocol>.<app-id>.<domain>".
+	Domain string `mapstructure:"domain"`
+	// Service label of the SRV records.
+	Service string `mapstructure:"service"`
+	// Protocol label of the SRV records.
+	Protocol string `mapstructure:"protocol"`
+	// Timeout for DNS queries.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Maximum duration for which failed lookups (no records) are cached. Set to 0 to disable negative caching.
+	NegativeCacheTTL time.Duration `mapstructure:"negativeCacheTTL"`
+	// Template for the name of the SRV records, such as "_dapr._tcp.{appID}.{namespace}.svc.example.com".
+	// If set, "domain", "service", and "protocol" are ignored.
+	NameTemplate string `mapstructure:"nameTemplate"`
+	// Strategy for selecting among targets with the same priority: "weighted" (default) or "roundRobin".
+	LoadBalancing string `mapstructure:"loadBalancing"`
+
+	// Internal properties
+	servers []string
+}
+
+func (m *dnsMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	m.Service = defaultService
+	m.Protocol = defaultProtocol
+	m.Timeout = defaultTimeout
+	m.NegativeCacheTTL = defaultNegativeCacheTTL
+
+	// Decode the configuration using DecodeMetadata
+	if meta.Configuration != nil {
+		err := kitmd.DecodeMetadata(meta.Configuration, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate options
+	m.servers = []string{}
+	for _, s := range strings.Split(m.Servers, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		// Add the default port if missing
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, "53")
+		}
+		m.servers = append(m.servers, s)
+	}
+	if len(m.servers) == 0 {
+		cfg, err := miekgdns.ClientConfigFromFile(defaultResolvConf)
+		if err != nil {
+			return fmt.Errorf("configuration property 'servers' is empty and failed to load DNS servers from %s: %w", defaultResolvConf, err)
+		}
+		for _, s := range cfg.Servers {
+			m.servers = append(m.servers, net.JoinHostPort(s, cfg.Port))
+		}
+		if len(m.servers) == 0 {
+			return errors.New("missing required configuration property 'servers'")
+		}
+	}
+
+	m.Domain = strings.Trim(m.Domain, ".")
+	m.Service = strings.TrimPrefix(m.Service, "_")
+	if m.Service == "" {
+		return errors.New("configuration property 'service' must not be empty")
+	}
+	m.Protocol = strings.ToLower(strings.TrimPrefix(m.Protocol, "_"))
+	if m.Protocol == "" {
+		return errors.New("configuration property 'protocol' must not be empty")
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+	if m.NegativeCacheTTL < 0 {
+		m.NegativeCacheTTL = 0
+	}
+	m.NameTemplate = strings.TrimSpace(m.NameTemplate)
+	if m.NameTemplate != "" && !strings.Contains(m.NameTemplate, nameTemplateAppID) {
+		return fmt.Errorf("configuration property 'nameTemplate' must contain the %s placeholder", nameTemplateAppID)
+	}
+	m.LoadBalancing = strings.ToLower(m.LoadBalancing)
+	switch m.LoadBalancing {
+	case "":
+		m.LoadBalancing = loadBalancingWeighted
+	case loadBalancingWeighted, loadBalancingRoundRobin:
+		// Valid
+	default:
+		return fmt.Errorf("invalid value for configuration property 'loadBalancing': %s", m.LoadBalancing)
+	}
+
+	return nil
+}
+
+// queryName returns the fully-qualified name of the SRV records for an app.
+func (m *dnsMetadata) queryName(appID string, namespace string) string {
+	if m.NameTemplate != "" {
+		name := strings.NewReplacer(nameTemplateAppID, appID, nameTemplateNamespace, namespace).Replace(m.NameTemplate)
+		return miekgdns.Fqdn(name)
+	}
+
+	name := "_" + m.Service + "._" + m.Protocol + "." + appID
+	if m.Domain != "" {
+		name += "." + m.Domain
+	}
+	return miekgdns.Fqdn(name)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// srvTarget is the target of a SRV record, with the addresses it resolved to.
+type srvTarget struct {
+	priority  uint16
+	weight    uint16
+	addresses []string
+}
+
+// sortByPriority sorts the targets by priority, from the lowest value, and calls fn for each group of targets with the same priority.
+func sortByPriority(targets []srvTarget, fn func(group []srvTarget)) {
+	sorted := slices.Clone(targets)
+	slices.SortStableFunc(sorted, func(a, b srvTarget) int {
+		return int(a.priority) - int(b.priority)
+	})
+
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].priority == sorted[start].priority {
+			end++
+		}
+		fn(sorted[start:end])
+		start = end
+	}
+}
+
+// orderTargets returns the addresses of the targets in the order they should be contacted, as described in RFC 2782.
+// Targets are sorted by priority, from the lowest value; targets with the same priority are ordered randomly, with a probability proportional to their weight.
+// The addresses of each target are shuffled.
+func orderTargets(targets []srvTarget) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(targets))
+	sortByPriority(targets, func(group []srvTarget) {
+		for _, t := range weightedOrder(group) {
+			addrs := slices.Clone(t.addresses)
+			// We use math/rand here as we are just balancing across addresses, so we don't need a CSPRNG
+			//nolint:gosec
+			rand.Shuffle(len(addrs), func(i, j int) {
+				addrs[i], addrs[j] = addrs[j], addrs[i]
+			})
+			res = append(res, addrs...)
+		}
+	})
+	return res
+}
+
+// roundRobinOrder returns the addresses of the targets sorted by priority, like orderTargets, but ignoring weights.
+// The addresses of the targets with the same priority are sorted, then rotated by n positions, so consecutive calls with increasing values of n select each address in turn.
+func roundRobinOrder(targets []srvTarget, n uint64) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(targets))
+	sortByPriority(targets, func(group []srvTarget) {
+		addrs := make([]string, 0, len(group))
+		for _, t := range group {
+			addrs = append(addrs, t.addresses...)
+		}
+		if len(addrs) == 0 {
+			return
+		}
+		// Sort the addresses as DNS servers may return the records in a different order every time
+		slices.Sort(addrs)
+		offset := int(n % uint64(len(addrs)))
+		res = append(res, addrs[offset:]...)
+		res = append(res, addrs[:offset]...)
+	})
+	return res
+}
+
+// weightedOrder orders targets with the same priority using the weighted selection algorithm of RFC 2782.
+func weightedOrder(group []srvTarget) []srvTarget {
+	if len(group) == 1 {
+		return group
+	}
+
+	// Targets with weight 0 are placed first, so they have a very small chance of being selected when others have a weight
+	remaining := make([]srvTarget, 0, len(group))
+	for _, t := range group {
+		if t.weight == 0 {
+			remaining = append(remaining, t)
+		}
+	}
+	for _, t := range group {
+		if t.weight != 0 {
+			remaining = append(remaining, t)
+		}
+	}
+
+	res := make([]srvTarget, 0, len(group))
+	for len(remaining) > 0 {
+		var sum int
+		for _, t := range remaining {
+			sum += int(t.weight)
+		}
+
+		var selected int
+		if sum == 0 {
+			// All remaining targets have weight 0, so they are selected with the same probability
+			//nolint:gosec
+			selected = rand.Intn(len(remaining))
+		} else {
+			//nolint:gosec
+			n := rand.Intn(sum + 1)
+			selected = len(remaining) - 1
+			running := 0
+			for i, t := range remaining {
+				running += int(t.weight)
+				if running >= n {
+					selected = i
+					break
+				}
+			}
+		}
+
+		res = append(res, remaining[selected])
+		remaining = slices.Delete(remaining, selected, selected+1)
+	}
+	return res
+}
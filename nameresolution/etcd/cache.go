@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// registration is the value stored in etcd for each instance.
+type registration struct {
+	AppID     string `json:"appID"`
+	Namespace string `json:"namespace,omitempty"`
+	Address   string `json:"address"`
+}
+
+// instanceCache is a local copy of the instances registered in etcd, kept up-to-date with a watch.
+type instanceCache struct {
+	lock      sync.RWMutex
+	keyPrefix string
+	// Map of app IDs to the addresses of their instances, by key
+	apps map[string]map[string]string
+}
+
+func newInstanceCache(keyPrefix string) *instanceCache {
+	return &instanceCache{
+		keyPrefix: keyPrefix + "/",
+		apps:      map[string]map[string]string{},
+	}
+}
+
+// replace replaces the content of the cache with the result of a full list.
+func (c *instanceCache) replace(kvs []*mvccpb.KeyValue) {
+	apps := make(map[string]map[string]string)
+	for _, kv := range kvs {
+		appID, addr, ok := c.parse(kv)
+		if !ok {
+			continue
+		}
+		if apps[appID] == nil {
+			apps[appID] = map[string]string{}
+		}
+		apps[appID][string(kv.Key)] = addr
+	}
+
+	c.lock.Lock()
+	c.apps = apps
+	c.lock.Unlock()
+}
+
+// apply applies the events received from a watch.
+func (c *instanceCache) apply(events []*clientv3.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, ev := range events {
+		key := string(ev.Kv.Key)
+		switch ev.Type {
+		case mvccpb.PUT:
+			appID, addr, ok := c.parse(ev.Kv)
+			if !ok {
+				continue
+			}
+			if c.apps[appID] == nil {
+				c.apps[appID] = map[string]string{}
+			}
+			c.apps[appID][key] = addr
+		case mvccpb.DELETE:
+			// The value is not included in delete events, so the app ID is taken from the key
+			appID, _, _ := strings.Cut(strings.TrimPrefix(key, c.keyPrefix), "/")
+			delete(c.apps[appID], key)
+			if len(c.apps[appID]) == 0 {
+				delete(c.apps, appID)
+			}
+		}
+	}
+}
+
+// addresses returns the addresses of the instances of an app.
+func (c *instanceCache) addresses(appID string) []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	res := make([]string, 0, len(c.apps[appID]))
+	for _, addr := range c.apps[appID] {
+		res = append(res, addr)
+	}
+	return res
+}
+
+// parse returns the app ID and address of the instance in a key-value pair.
+// Keys are in the format "<keyPrefix>/<appID>/<address>".
+func (c *instanceCache) parse(kv *mvccpb.KeyValue) (appID string, addr string, ok bool) {
+	key := string(kv.Key)
+	if !strings.HasPrefix(key, c.keyPrefix) {
+		return "", "", false
+	}
+	appID, addr, ok = strings.Cut(key[len(c.keyPrefix):], "/")
+	if !ok || appID == "" {
+		return "", "", false
+	}
+
+	// Prefer the address in the value, if any
+	reg := registration{}
+	err := json.Unmarshal(kv.Value, &reg)
+	if err == nil && reg.Address != "" {
+		addr = reg.Address
+	}
+	if addr == "" {
+		return "", "", false
+	}
+	return appID, addr, true
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// ErrNoHost is returned by ResolveID when no host can be found.
+var ErrNoHost = errors.New("no host found with the given ID")
+
+type resolver struct {
+	logger   logger.Logger
+	metadata etcdMetadata
+	client   *clientv3.Client
+	cache    *instanceCache
+	leaseID  atomic.Int64
+	closed   atomic.Bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewResolver creates a name resolver that is based on etcd.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &resolver{
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Init initializes the name resolver.
+func (e *resolver) Init(ctx context.Context, md nameresolution.Metadata) error {
+	if e.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	err := e.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := e.metadata.getTLSConfig()
+	if err != nil {
+		return fmt.Errorf("tls authentication error: %w", err)
+	}
+
+	e.client, err = clientv3.New(clientv3.Config{
+		Endpoints:   e.metadata.endpoints,
+		DialTimeout: e.metadata.Timeout,
+		Username:    e.metadata.Username,
+		Password:    e.metadata.Password,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	// Load all instances, then keep the cache up-to-date with a watch starting from the revision that was loaded
+	e.cache = newInstanceCache(e.metadata.KeyPrefix)
+	rev, err := e.loadInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Register the host and keep the lease alive in background
+	err = e.registerHost(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.wg.Add(2)
+	go e.watchInstances(rev)
+	go e.keepRegistration()
+
+	return nil
+}
+
+// Loads all registered instances in the cache, returning the revision of the data.
+func (e *resolver) loadInstances(parentCtx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, e.metadata.Timeout)
+	defer cancel()
+
+	res, err := e.client.Get(ctx, e.metadata.KeyPrefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load registered instances: %w", err)
+	}
+	e.cache.replace(res.Kvs)
+
+	return res.Header.Revision, nil
+}
+
+// In background, watches for changes to the registered instances.
+// If the watch fails (for example, because the revision was compacted), the cache is re-loaded and the watch re-started.
+// Should be invoked in a background goroutine
+func (e *resolver) watchInstances(rev int64) {
+	defer e.wg.Done()
+
+	for {
+		rev = e.doWatchInstances(rev)
+		if e.ctx.Err() != nil {
+			// Component is closing
+			e.logger.Debug("Stopped watching registered instances: component is closing")
+			return
+		}
+
+		e.logger.Warn("Watch on registered instances was interrupted; reloading instances")
+		bo := e.newBackOff()
+		err := backoff.RetryNotify(func() (err error) {
+			rev, err = e.loadInstances(e.ctx)
+			return err
+		}, bo, func(err error, d time.Duration) {
+			e.logger.Errorf("Failed to reload registered instances, retrying in %v: %v", d, err)
+		})
+		if err != nil {
+			// Can only happen when the context is canceled
+			return
+		}
+	}
+}
+
+// Watches for changes after the given revision until the watch fails, returning the last revision seen.
+func (e *resolver) doWatchInstances(rev int64) int64 {
+	// Requiring a leader makes the watch fail if the etcd member is partitioned from the cluster, rather than the watch just stalling
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(e.ctx))
+	defer cancel()
+
+	wch := e.client.Watch(ctx, e.metadata.KeyPrefix+"/", clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	for res := range wch {
+		err := res.Err()
+		if err != nil {
+			e.logger.Errorf("Error watching registered instances: %v", err)
+			return rev
+		}
+		e.cache.apply(res.Events)
+		rev = res.Header.Revision
+	}
+	return rev
+}
+
+// Registers the host with a new lease.
+func (e *resolver) registerHost(parentCtx context.Context) error {
+	ctx, cancel := context.WithTimeout(parentCtx, e.metadata.Timeout)
+	defer cancel()
+
+	lease, err := e.client.Grant(ctx, int64(e.metadata.LeaseTTL/time.Second))
+	if err != nil {
+		return fmt.Errorf("failed to grant lease: %w", err)
+	}
+
+	val, _ := json.Marshal(registration{
+		AppID:     e.metadata.appID,
+		Namespace: e.metadata.namespace,
+		Address:   e.metadata.GetAddress(),
+	})
+	_, err = e.client.Put(ctx, e.metadata.instanceKey(), string(val), clientv3.WithLease(lease.ID))
+	if err != nil {
+		return fmt.Errorf("failed to register host: %w", err)
+	}
+
+	e.leaseID.Store(int64(lease.ID))
+	e.logger.Debugf("Registered host at key %s with lease %x", e.metadata.instanceKey(), lease.ID)
+	return nil
+}
+
+// In background, keeps the lease of the host's registration alive.
+// If the lease is lost (for example, because etcd was unreachable for longer than the TTL), the host is registered again.
+// Should be invoked in a background goroutine
+func (e *resolver) keepRegistration() {
+	defer e.wg.Done()
+
+	for {
+		ch, err := e.client.KeepAlive(e.ctx, clientv3.LeaseID(e.leaseID.Load()))
+		if err == nil {
+			// The channel is closed when the lease expires or the context is canceled
+			for range ch {
+				// Nop
+			}
+		} else {
+			e.logger.Errorf("Failed to keep host registration alive: %v", err)
+		}
+
+		if e.ctx.Err() != nil {
+			// Component is closing
+			e.logger.Debug("Stopped renewing host registration: component is closing")
+			return
+		}
+
+		e.logger.Warn("Host registration lease was lost; registering again")
+		bo := e.newBackOff()
+		err = backoff.RetryNotify(func() error {
+			return e.registerHost(e.ctx)
+		}, bo, func(err error, d time.Duration) {
+			e.logger.Errorf("Failed to register host, retrying in %v: %v", d, err)
+		})
+		if err != nil {
+			// Can only happen when the context is canceled
+			return
+		}
+	}
+}
+
+// ResolveID resolves name to address.
+func (e *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	addrs, err := e.ResolveIDMulti(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return addrs.Pick(), nil
+}
+
+// ResolveIDMulti resolves name to a list of addresses.
+// Addresses are served from the local cache, so no request to etcd is made.
+func (e *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	addrs := e.cache.addresses(req.ID)
+	if len(addrs) == 0 {
+		return nil, ErrNoHost
+	}
+	return addrs, nil
+}
+
+// newBackOff returns a backoff policy that retries until the component is closed.
+func (e *resolver) newBackOff() backoff.BackOff {
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxElapsedTime = 0
+	return backoff.WithContext(bo, e.ctx)
+}
+
+// Close implements io.Closer.
+func (e *resolver) Close() error {
+	if !e.closed.CompareAndSwap(false, true) {
+		e.wg.Wait()
+		return nil
+	}
+
+	e.cancel()
+	e.wg.Wait()
+
+	if e.client == nil {
+		return nil
+	}
+
+	errs := make([]error, 0)
+
+	// Revoking the lease removes the registration of the host
+	if leaseID := e.leaseID.Load(); leaseID != 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), e.metadata.Timeout)
+		_, err := e.client.Revoke(ctx, clientv3.LeaseID(leaseID))
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to unregister host: %w", err))
+		}
+	}
+
+	err := e.client.Close()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/nameresolution"
+)
+
+func TestInitWithMetadata(t *testing.T) {
+	instance := nameresolution.Instance{
+		AppID:            "myapp",
+		Namespace:        "default",
+		Address:          "10.0.0.1",
+		DaprInternalPort: 50002,
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+			Configuration: map[string]string{
+				"endpoints": "etcd1:2379, etcd2:2379",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"etcd1:2379", "etcd2:2379"}, m.endpoints)
+		assert.Equal(t, defaultKeyPrefix, m.KeyPrefix)
+		assert.Equal(t, defaultLeaseTTL, m.LeaseTTL)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, "10.0.0.1:50002", m.GetAddress())
+		assert.Equal(t, "/dapr/nameresolution/myapp/10.0.0.1:50002", m.instanceKey())
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+			Configuration: map[string]string{
+				"endpoints": "etcd:2379",
+				"keyPrefix": "/myprefix/",
+				"leaseTTL":  "10500ms",
+				"timeout":   "1s",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/myprefix", m.KeyPrefix)
+		assert.Equal(t, 10*time.Second, m.LeaseTTL)
+		assert.Equal(t, time.Second, m.Timeout)
+	})
+
+	t.Run("missing endpoints", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+		})
+		require.ErrorContains(t, err, "endpoints")
+	})
+
+	t.Run("missing instance info", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: nameresolution.Instance{AppID: "myapp"},
+			Configuration: map[string]string{
+				"endpoints": "etcd:2379",
+			},
+		})
+		require.ErrorContains(t, err, "address is missing")
+	})
+
+	t.Run("lease TTL too short", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+			Configuration: map[string]string{
+				"endpoints": "etcd:2379",
+				"leaseTTL":  "2s",
+			},
+		})
+		require.ErrorContains(t, err, "leaseTTL")
+	})
+
+	t.Run("incomplete TLS", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+			Configuration: map[string]string{
+				"endpoints": "etcd:2379",
+				"tlsEnable": "true",
+				"cert":      "cert",
+			},
+		})
+		require.ErrorContains(t, err, "tls")
+	})
+}
+
+func TestInstanceCache(t *testing.T) {
+	c := newInstanceCache("/dapr")
+	c.replace([]*mvccpb.KeyValue{
+		{Key: []byte("/dapr/app1/10.0.0.1:50002"), Value: []byte(`{"appID":"app1","address":"10.0.0.1:50002"}`)},
+		{Key: []byte("/dapr/app1/10.0.0.2:50002"), Value: []byte(`{"appID":"app1","address":"10.0.0.2:50002"}`)},
+		// Address from key if value is not JSON
+		{Key: []byte("/dapr/app2/10.0.0.3:50002"), Value: []byte(`foo`)},
+		// Invalid keys
+		{Key: []byte("/dapr/app3"), Value: []byte(`{}`)},
+		{Key: []byte("/other/app4/10.0.0.4:50002"), Value: []byte(`{}`)},
+	})
+
+	assert.ElementsMatch(t, []string{"10.0.0.1:50002", "10.0.0.2:50002"}, c.addresses("app1"))
+	assert.Equal(t, []string{"10.0.0.3:50002"}, c.addresses("app2"))
+	assert.Empty(t, c.addresses("app3"))
+	assert.Empty(t, c.addresses("app4"))
+
+	t.Run("apply events", func(t *testing.T) {
+		c.apply([]*clientv3.Event{
+			{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("/dapr/app1/10.0.0.1:50002")}},
+			{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("/dapr/app2/10.0.0.3:50002")}},
+			{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/dapr/app3/10.0.0.5:50002"), Value: []byte(`{"appID":"app3","address":"10.0.0.5:50002"}`)}},
+		})
+
+		assert.Equal(t, []string{"10.0.0.2:50002"}, c.addresses("app1"))
+		assert.Empty(t, c.addresses("app2"))
+		assert.Equal(t, []string{"10.0.0.5:50002"}, c.addresses("app3"))
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		r := &resolver{cache: c}
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "app3"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.5:50002", addr)
+
+		_, err = r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "app2"})
+		require.ErrorIs(t, err, ErrNoHost)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultKeyPrefix = "/dapr/nameresolution"
+	defaultLeaseTTL  = 15 * time.Second
+	defaultTimeout   = 5 * time.Second
+
+	// Minimum TTL of leases accepted by etcd
+	minLeaseTTL = 5 * time.Second
+)
+
+type etcdMetadata struct {
+	// Comma-separated list of etcd endpoints, such as "localhost:2379".
+	Endpoints string `mapstructure:"endpoints"`
+	// Prefix of the keys where instances are registered.
+	KeyPrefix string `mapstructure:"keyPrefix"`
+	// Username and password for authenticating with etcd.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// TLS options, with PEM-encoded certificates.
+	TLSEnable bool   `mapstructure:"tlsEnable"`
+	CA        string `mapstructure:"ca"`
+	Cert      string `mapstructure:"cert"`
+	Key       string `mapstructure:"key"`
+	// TTL of the lease the instance is registered with. The lease is kept alive while the sidecar is running.
+	// Units smaller than seconds are not accepted.
+	LeaseTTL time.Duration `mapstructure:"leaseTTL"`
+	// Timeout for connecting and for requests to etcd.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Internal properties
+	endpoints []string
+
+	// Instance properties - these are passed by the runtime
+	appID       string
+	namespace   string
+	hostAddress string
+	port        int
+}
+
+func (m *etcdMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	m.KeyPrefix = defaultKeyPrefix
+	m.LeaseTTL = defaultLeaseTTL
+	m.Timeout = defaultTimeout
+
+	// Set and validate the instance properties
+	m.appID = meta.Instance.AppID
+	if m.appID == "" {
+		return errors.New("name is missing")
+	}
+	m.hostAddress = meta.Instance.Address
+	if m.hostAddress == "" {
+		return errors.New("address is missing")
+	}
+	m.port = meta.Instance.DaprInternalPort
+	if m.port == 0 {
+		return errors.New("port is missing or invalid")
+	}
+	m.namespace = meta.Instance.Namespace // Can be empty
+
+	// Decode the configuration using DecodeMetadata
+	if meta.Configuration != nil {
+		err := kitmd.DecodeMetadata(meta.Configuration, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate options
+	m.endpoints = []string{}
+	for _, e := range strings.Split(m.Endpoints, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			m.endpoints = append(m.endpoints, e)
+		}
+	}
+	if len(m.endpoints) == 0 {
+		return errors.New("missing required configuration property 'endpoints'")
+	}
+
+	m.KeyPrefix = strings.TrimSuffix(m.KeyPrefix, "/")
+	if m.KeyPrefix == "" {
+		return errors.New("configuration property 'keyPrefix' must not be empty")
+	}
+
+	m.LeaseTTL = m.LeaseTTL.Truncate(time.Second)
+	if m.LeaseTTL < minLeaseTTL {
+		return fmt.Errorf("configuration property 'leaseTTL' must be at least %v", minLeaseTTL)
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+
+	if m.TLSEnable && m.CA == "" && (m.Cert == "" || m.Key == "") {
+		return errors.New("tls authentication information is incomplete")
+	}
+
+	return nil
+}
+
+// GetAddress returns the address of the current instance.
+func (m *etcdMetadata) GetAddress() string {
+	return net.JoinHostPort(m.hostAddress, strconv.Itoa(m.port))
+}
+
+// instancePrefix returns the prefix of the keys of the instances of an app.
+func (m *etcdMetadata) instancePrefix(appID string) string {
+	return m.KeyPrefix + "/" + appID + "/"
+}
+
+// instanceKey returns the key where the current instance is registered.
+func (m *etcdMetadata) instanceKey() string {
+	return m.instancePrefix(m.appID) + m.GetAddress()
+}
+
+// getTLSConfig returns the TLS configuration, or nil if TLS is not enabled.
+func (m *etcdMetadata) getTLSConfig() (*tls.Config, error) {
+	if !m.TLSEnable {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if m.Cert != "" && m.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(m.Cert), []byte(m.Key))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing X509 key pair: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if m.CA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(m.CA)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eureka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Status of instances that can receive traffic
+const statusUp = "UP"
+
+// Action types in delta responses
+const (
+	actionAdded    = "ADDED"
+	actionModified = "MODIFIED"
+	actionDeleted  = "DELETED"
+)
+
+// errInstanceNotFound is returned by heartbeats when the instance is not registered.
+var errInstanceNotFound = errors.New("instance not found in Eureka")
+
+type applicationsResponse struct {
+	Applications applications `json:"applications"`
+}
+
+type applications struct {
+	VersionsDelta string          `json:"versions__delta"`
+	AppsHashcode  string          `json:"apps__hashcode"`
+	Application   applicationList `json:"application"`
+}
+
+type application struct {
+	Name     string       `json:"name"`
+	Instance instanceList `json:"instance"`
+}
+
+type instanceRequest struct {
+	Instance instance `json:"instance"`
+}
+
+type instance struct {
+	InstanceID       string            `json:"instanceId"`
+	HostName         string            `json:"hostName"`
+	App              string            `json:"app"`
+	IPAddr           string            `json:"ipAddr"`
+	VIPAddress       string            `json:"vipAddress,omitempty"`
+	SecureVIPAddress string            `json:"secureVipAddress,omitempty"`
+	Status           string            `json:"status"`
+	Port             *port             `json:"port,omitempty"`
+	SecurePort       *port             `json:"securePort,omitempty"`
+	DataCenterInfo   dataCenterInfo    `json:"dataCenterInfo"`
+	LeaseInfo        *leaseInfo        `json:"leaseInfo,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	ActionType       string            `json:"actionType,omitempty"`
+}
+
+type port struct {
+	Port    flexInt `json:"$"`
+	Enabled string  `json:"@enabled"`
+}
+
+type dataCenterInfo struct {
+	Class    string            `json:"@class"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type leaseInfo struct {
+	RenewalIntervalInSecs int `json:"renewalIntervalInSecs"`
+	DurationInSecs        int `json:"durationInSecs"`
+}
+
+// zone returns the zone of the instance, as set by Spring Cloud in the metadata or by AWS in the data center info.
+func (i instance) zone() string {
+	if z := i.Metadata["zone"]; z != "" {
+		return z
+	}
+	return i.DataCenterInfo.Metadata["availability-zone"]
+}
+
+// Eureka returns a single object rather than an array when there's only one element.
+type applicationList []application
+
+func (l *applicationList) UnmarshalJSON(data []byte) error {
+	return unmarshalObjectOrArray(data, (*[]application)(l))
+}
+
+type instanceList []instance
+
+func (l *instanceList) UnmarshalJSON(data []byte) error {
+	return unmarshalObjectOrArray(data, (*[]instance)(l))
+}
+
+func unmarshalObjectOrArray[T any](data []byte, dst *[]T) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		*dst = nil
+		return nil
+	case data[0] == '[':
+		return json.Unmarshal(data, dst)
+	default:
+		var obj T
+		err := json.Unmarshal(data, &obj)
+		if err != nil {
+			return err
+		}
+		*dst = []T{obj}
+		return nil
+	}
+}
+
+// Eureka encodes numbers as strings in some versions.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	v, err := strconv.Atoi(string(data))
+	if err != nil {
+		return err
+	}
+	*n = flexInt(v)
+	return nil
+}
+
+// client is a client for the Eureka REST API.
+type client struct {
+	httpClient  *http.Client
+	serviceURLs []string
+}
+
+// getApplications fetches the full registry, or the delta since the last fetch if delta is true.
+func (c *client) getApplications(ctx context.Context, delta bool) (*applications, error) {
+	path := "/apps"
+	if delta {
+		path = "/apps/delta"
+	}
+	res := applicationsResponse{}
+	err := c.do(ctx, http.MethodGet, path, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Applications, nil
+}
+
+// register registers the instance.
+func (c *client) register(ctx context.Context, inst instance) error {
+	return c.do(ctx, http.MethodPost, "/apps/"+url.PathEscape(inst.App), instanceRequest{Instance: inst}, nil)
+}
+
+// heartbeat renews the lease of the instance.
+func (c *client) heartbeat(ctx context.Context, app string, instanceID string) error {
+	return c.do(ctx, http.MethodPut, "/apps/"+url.PathEscape(app)+"/"+url.PathEscape(instanceID), nil, nil)
+}
+
+// deregister removes the instance.
+func (c *client) deregister(ctx context.Context, app string, instanceID string) error {
+	return c.do(ctx, http.MethodDelete, "/apps/"+url.PathEscape(app)+"/"+url.PathEscape(instanceID), nil, nil)
+}
+
+// do performs a request, trying each server in order until one responds.
+func (c *client) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, serviceURL := range c.serviceURLs {
+		err := c.doOne(ctx, method, serviceURL+path, reqBody, out)
+		if err == nil || errors.Is(err, errInstanceNotFound) || ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", serviceURL, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *client) doOne(ctx context.Context, method string, u string, reqBody []byte, out any) error {
+	var body io.Reader
+	if reqBody != nil {
+		body = bytes.NewReader(reqBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Drain before closing
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound && method == http.MethodPut:
+		return errInstanceNotFound
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return fmt.Errorf("unexpected response status code %d", res.StatusCode)
+	case out == nil:
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eureka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// ErrNoHost is returned by ResolveID when no host can be found.
+var ErrNoHost = errors.New("no host found with the given ID")
+
+type resolver struct {
+	logger   logger.Logger
+	metadata eurekaMetadata
+	client   *client
+	registry *registry
+	instance *instance
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewResolver creates a name resolver that is based on Netflix Eureka.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	return &resolver{
+		logger:   logger,
+		registry: newRegistry(),
+		closeCh:  make(chan struct{}),
+	}
+}
+
+// Init initializes the name resolver.
+func (e *resolver) Init(ctx context.Context, md nameresolution.Metadata) error {
+	if e.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	err := e.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	e.client = &client{
+		httpClient:  &http.Client{Timeout: e.metadata.RequestTimeout},
+		serviceURLs: e.metadata.serviceURLs,
+	}
+
+	// Fetch the full registry to start, which also validates the connection
+	err = e.fetchRegistry(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to fetch registry from Eureka: %w", err)
+	}
+
+	if e.metadata.SelfRegister {
+		e.instance = e.newInstance()
+		err = e.client.register(ctx, *e.instance)
+		if err != nil {
+			return fmt.Errorf("failed to register instance in Eureka: %w", err)
+		}
+		e.logger.Infof("Registered instance %s in Eureka", e.instance.InstanceID)
+
+		e.wg.Add(1)
+		go e.sendHeartbeats()
+	}
+
+	e.wg.Add(1)
+	go e.refreshRegistry()
+
+	return nil
+}
+
+// newInstance returns the instance to register.
+func (e *resolver) newInstance() *instance {
+	appName := strings.ToUpper(e.metadata.appID)
+	meta := map[string]string{
+		e.metadata.DaprPortMetaKey: strconv.Itoa(e.metadata.daprPort),
+	}
+	if e.metadata.daprHTTPPort > 0 {
+		meta["DAPR_HTTP_PORT"] = strconv.Itoa(e.metadata.daprHTTPPort)
+	}
+	if e.metadata.Zone != "" {
+		meta["zone"] = e.metadata.Zone
+	}
+
+	// The app port is advertised so non-Dapr clients (such as Spring Cloud apps) can reach the app directly
+	appPort := &port{Port: flexInt(e.metadata.appPort), Enabled: "true"}
+	if e.metadata.appPort == 0 {
+		appPort = &port{Port: flexInt(e.metadata.daprPort), Enabled: "false"}
+	}
+
+	return &instance{
+		InstanceID:       e.metadata.hostAddress + ":" + e.metadata.appID + ":" + strconv.Itoa(e.metadata.daprPort),
+		HostName:         e.metadata.hostAddress,
+		App:              appName,
+		IPAddr:           e.metadata.hostAddress,
+		VIPAddress:       e.metadata.appID,
+		SecureVIPAddress: e.metadata.appID,
+		Status:           statusUp,
+		Port:             appPort,
+		SecurePort:       &port{Port: 443, Enabled: "false"},
+		DataCenterInfo: dataCenterInfo{
+			Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+			Name:  "MyOwn",
+		},
+		LeaseInfo: &leaseInfo{
+			RenewalIntervalInSecs: int(e.metadata.HeartbeatInterval / time.Second),
+			DurationInSecs:        int(e.metadata.LeaseDuration / time.Second),
+		},
+		Metadata: meta,
+	}
+}
+
+// fetchRegistry fetches the registry from Eureka, applying the delta if possible.
+func (e *resolver) fetchRegistry(parentCtx context.Context, delta bool) error {
+	ctx, cancel := context.WithTimeout(parentCtx, e.metadata.RequestTimeout)
+	defer cancel()
+
+	if delta {
+		apps, err := e.client.getApplications(ctx, true)
+		if err != nil {
+			return err
+		}
+		if e.registry.applyDelta(apps) {
+			return nil
+		}
+		e.logger.Debug("Eureka registry hash code mismatch after applying delta; fetching full registry")
+	}
+
+	apps, err := e.client.getApplications(ctx, false)
+	if err != nil {
+		return err
+	}
+	e.registry.replace(apps)
+	return nil
+}
+
+// refreshRegistry fetches the registry periodically in background.
+func (e *resolver) refreshRegistry() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.metadata.FetchInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := e.closeContext()
+	defer cancel()
+
+	// After a failure, the next fetch is a full one, as deltas may have been missed
+	delta := !e.metadata.DisableDelta
+	for {
+		select {
+		case <-e.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		err := e.fetchRegistry(ctx, delta)
+		if err != nil {
+			e.logger.Warnf("Failed to fetch registry from Eureka: %v", err)
+			delta = false
+			continue
+		}
+		delta = !e.metadata.DisableDelta
+	}
+}
+
+// sendHeartbeats renews the lease of the instance periodically in background, registering it again if Eureka lost it.
+func (e *resolver) sendHeartbeats() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.metadata.HeartbeatInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := e.closeContext()
+	defer cancel()
+
+	for {
+		select {
+		case <-e.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		reqCtx, reqCancel := context.WithTimeout(ctx, e.metadata.RequestTimeout)
+		err := e.client.heartbeat(reqCtx, e.instance.App, e.instance.InstanceID)
+		if errors.Is(err, errInstanceNotFound) {
+			e.logger.Warnf("Instance %s not found in Eureka; registering it again", e.instance.InstanceID)
+			err = e.client.register(reqCtx, *e.instance)
+		}
+		reqCancel()
+		if err != nil {
+			e.logger.Warnf("Failed to send heartbeat to Eureka: %v", err)
+		}
+	}
+}
+
+// closeContext returns a context that is canceled when the resolver is closed.
+func (e *resolver) closeContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-e.closeCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// ResolveID resolves name to address.
+func (e *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	addrs, err := e.ResolveIDMulti(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return addrs.Pick(), nil
+}
+
+// ResolveIDMulti resolves name to a list of addresses, preferring instances in the same zone.
+func (e *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	instances := e.registry.upInstances(req.ID, e.metadata.Zone)
+	addrs := make(nameresolution.AddressList, 0, len(instances))
+	for _, inst := range instances {
+		p := inst.Metadata[e.metadata.DaprPortMetaKey]
+		if p == "" || inst.IPAddr == "" {
+			// Not a Dapr-enabled instance
+			continue
+		}
+		addrs = append(addrs, net.JoinHostPort(inst.IPAddr, p))
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoHost
+	}
+	return addrs, nil
+}
+
+// Close implements io.Closer.
+func (e *resolver) Close() error {
+	if !e.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	close(e.closeCh)
+	e.wg.Wait()
+
+	if e.instance != nil && e.metadata.SelfDeregister {
+		ctx, cancel := context.WithTimeout(context.Background(), e.metadata.RequestTimeout)
+		defer cancel()
+		err := e.client.deregister(ctx, e.instance.App, e.instance.InstanceID)
+		if err != nil {
+			return fmt.Errorf("failed to deregister instance from Eureka: %w", err)
+		}
+	}
+
+	return nil
+}
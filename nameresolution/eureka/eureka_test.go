@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eureka
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+const fullRegistry = `{
+  "applications": {
+    "versions__delta": "1",
+    "apps__hashcode": "DOWN_1_UP_2_",
+    "application": [
+      {
+        "name": "MYAPP",
+        "instance": [
+          {"instanceId": "a", "app": "MYAPP", "ipAddr": "10.0.0.1", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "dataCenterInfo": {"@class": "x", "name": "MyOwn"}, "metadata": {"DAPR_PORT": "50002", "zone": "z1"}},
+          {"instanceId": "b", "app": "MYAPP", "ipAddr": "10.0.0.2", "status": "UP", "port": {"$": "8080", "@enabled": "true"}, "dataCenterInfo": {"@class": "x", "name": "Amazon", "metadata": {"availability-zone": "z2"}}, "metadata": {"DAPR_PORT": "50002"}},
+          {"instanceId": "c", "app": "MYAPP", "ipAddr": "10.0.0.3", "status": "DOWN", "dataCenterInfo": {"@class": "x", "name": "MyOwn"}, "metadata": {"DAPR_PORT": "50002"}}
+        ]
+      },
+      {
+        "name": "SPRINGAPP",
+        "instance": {"instanceId": "s", "app": "SPRINGAPP", "ipAddr": "10.0.0.9", "status": "UP", "dataCenterInfo": {"@class": "x", "name": "MyOwn"}}
+      }
+    ]
+  }
+}`
+
+func TestInitWithMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := eurekaMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"serviceURL": "http://eureka1:8761/eureka/, https://eureka2:8761/eureka",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://eureka1:8761/eureka", "https://eureka2:8761/eureka"}, m.serviceURLs)
+		assert.False(t, m.SelfRegister)
+		assert.True(t, m.SelfDeregister)
+		assert.Equal(t, defaultDaprPortMetaKey, m.DaprPortMetaKey)
+		assert.Equal(t, defaultFetchInterval, m.FetchInterval)
+	})
+
+	t.Run("missing service URLs", func(t *testing.T) {
+		m := eurekaMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{})
+		require.ErrorContains(t, err, "serviceURLs")
+	})
+
+	t.Run("invalid service URL", func(t *testing.T) {
+		m := eurekaMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{"serviceURLs": "eureka:8761"},
+		})
+		require.ErrorContains(t, err, "invalid URL")
+	})
+
+	t.Run("self register requires instance info", func(t *testing.T) {
+		m := eurekaMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"serviceURLs":  "http://eureka:8761/eureka",
+				"selfRegister": "true",
+			},
+		})
+		require.ErrorContains(t, err, "name is missing")
+	})
+
+	t.Run("lease shorter than heartbeat", func(t *testing.T) {
+		m := eurekaMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Configuration: map[string]string{
+				"serviceURLs":       "http://eureka:8761/eureka",
+				"selfRegister":      "true",
+				"heartbeatInterval": "30s",
+				"leaseDuration":     "10s",
+			},
+		})
+		require.ErrorContains(t, err, "leaseDuration")
+	})
+}
+
+func TestUnmarshalApplications(t *testing.T) {
+	res := applicationsResponse{}
+	require.NoError(t, json.Unmarshal([]byte(fullRegistry), &res))
+	require.Len(t, res.Applications.Application, 2)
+	require.Len(t, res.Applications.Application[0].Instance, 3)
+	assert.Equal(t, flexInt(8080), res.Applications.Application[0].Instance[1].Port.Port)
+	assert.Equal(t, "z2", res.Applications.Application[0].Instance[1].zone())
+	// Single instance as object
+	require.Len(t, res.Applications.Application[1].Instance, 1)
+	assert.Equal(t, "s", res.Applications.Application[1].Instance[0].InstanceID)
+}
+
+func TestRegistry(t *testing.T) {
+	res := applicationsResponse{}
+	require.NoError(t, json.Unmarshal([]byte(fullRegistry), &res))
+	r := newRegistry()
+	r.replace(&res.Applications)
+	assert.Equal(t, "DOWN_1_UP_3_", r.hashcodeLocked())
+
+	t.Run("zone affinity", func(t *testing.T) {
+		instances := r.upInstances("myapp", "z1")
+		require.Len(t, instances, 1)
+		assert.Equal(t, "a", instances[0].InstanceID)
+
+		assert.Len(t, r.upInstances("myapp", "z3"), 2)
+		assert.Len(t, r.upInstances("myapp", ""), 2)
+	})
+
+	t.Run("apply delta", func(t *testing.T) {
+		ok := r.applyDelta(&applications{
+			AppsHashcode: "UP_3_",
+			Application: applicationList{{
+				Name: "MYAPP",
+				Instance: instanceList{
+					{InstanceID: "c", ActionType: actionModified, Status: statusUp, IPAddr: "10.0.0.3", Metadata: map[string]string{"DAPR_PORT": "50002"}},
+					{InstanceID: "a", ActionType: actionDeleted},
+				},
+			}},
+		})
+		assert.True(t, ok)
+		assert.Len(t, r.upInstances("myapp", "z1"), 2)
+	})
+
+	t.Run("hash code mismatch", func(t *testing.T) {
+		ok := r.applyDelta(&applications{AppsHashcode: "UP_10_"})
+		assert.False(t, ok)
+	})
+}
+
+// fakeEureka is a minimal Eureka server.
+type fakeEureka struct {
+	lock       sync.Mutex
+	registered map[string]instance
+	heartbeats int
+	fullFetch  int
+	deltaFetch int
+}
+
+func (f *fakeEureka) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/eureka/apps":
+		f.fullFetch++
+		_, _ = io.WriteString(w, fullRegistry)
+	case r.Method == http.MethodGet && r.URL.Path == "/eureka/apps/delta":
+		f.deltaFetch++
+		_, _ = io.WriteString(w, `{"applications": {"versions__delta": "2", "apps__hashcode": "DOWN_1_UP_3_", "application": []}}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/eureka/apps/MYSELF":
+		req := instanceRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.registered[req.Instance.InstanceID] = req.Instance
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.heartbeats++
+		if len(f.registered) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		f.registered = map[string]instance{}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	fake := &fakeEureka{registered: map[string]instance{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	err := r.Init(context.Background(), nameresolution.Metadata{
+		Instance: nameresolution.Instance{
+			AppID:            "myself",
+			Address:          "10.0.1.1",
+			DaprInternalPort: 50002,
+			AppPort:          3000,
+		},
+		Configuration: map[string]string{
+			"serviceURLs":       "http://127.0.0.1:1/eureka," + srv.URL + "/eureka",
+			"selfRegister":      "true",
+			"zone":              "z1",
+			"heartbeatInterval": "10ms",
+			"leaseDuration":     "1s",
+			"fetchInterval":     "10ms",
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("registers instance", func(t *testing.T) {
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		inst, ok := fake.registered["10.0.1.1:myself:50002"]
+		require.True(t, ok)
+		assert.Equal(t, "MYSELF", inst.App)
+		assert.Equal(t, "50002", inst.Metadata["DAPR_PORT"])
+		assert.Equal(t, flexInt(3000), inst.Port.Port)
+		assert.Equal(t, "z1", inst.Metadata["zone"])
+	})
+
+	t.Run("resolves in same zone", func(t *testing.T) {
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1:50002", addr)
+	})
+
+	t.Run("ignores instances without Dapr", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "springapp"})
+		require.ErrorIs(t, err, ErrNoHost)
+	})
+
+	t.Run("heartbeats and delta fetches", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			fake.lock.Lock()
+			defer fake.lock.Unlock()
+			return fake.heartbeats > 0 && fake.deltaFetch > 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("deregisters on close", func(t *testing.T) {
+		require.NoError(t, r.Close())
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		assert.Empty(t, fake.registered)
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eureka
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultDaprPortMetaKey   = "DAPR_PORT"
+	defaultHeartbeatInterval = 30 * time.Second
+	defaultLeaseDuration     = 90 * time.Second
+	defaultFetchInterval     = 30 * time.Second
+	defaultRequestTimeout    = 5 * time.Second
+)
+
+type eurekaMetadata struct {
+	// Comma-separated list of URLs of the Eureka servers, such as "http://eureka:8761/eureka". Servers are tried in order.
+	ServiceURLs string `mapstructure:"serviceURLs" mapstructurealiases:"serviceURL"`
+	// If true, the instance is registered in Eureka and heartbeats are sent to renew the lease.
+	SelfRegister bool `mapstructure:"selfRegister"`
+	// If true, the instance is deregistered from Eureka when the resolver is closed.
+	SelfDeregister bool `mapstructure:"selfDeregister"`
+	// Zone of the current instance. Instances in the same zone are preferred when resolving addresses.
+	Zone string `mapstructure:"zone"`
+	// Key in the instance metadata containing the Dapr internal gRPC port.
+	DaprPortMetaKey string `mapstructure:"daprPortMetaKey"`
+	// Interval between heartbeats.
+	HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"`
+	// Duration of the lease after which Eureka evicts the instance if no heartbeat is received.
+	LeaseDuration time.Duration `mapstructure:"leaseDuration"`
+	// Interval between fetches of the registry, which are incremental (delta) after the first one.
+	FetchInterval time.Duration `mapstructure:"fetchInterval"`
+	// If true, the registry is always fetched in full rather than incrementally.
+	DisableDelta bool `mapstructure:"disableDelta"`
+	// Timeout for requests to Eureka.
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+
+	// Internal properties
+	serviceURLs []string
+
+	// Instance properties - these are passed by the runtime
+	appID        string
+	hostAddress  string
+	daprPort     int
+	daprHTTPPort int
+	appPort      int
+}
+
+func (m *eurekaMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	m.SelfDeregister = true
+	m.DaprPortMetaKey = defaultDaprPortMetaKey
+	m.HeartbeatInterval = defaultHeartbeatInterval
+	m.LeaseDuration = defaultLeaseDuration
+	m.FetchInterval = defaultFetchInterval
+	m.RequestTimeout = defaultRequestTimeout
+
+	// Decode the configuration using DecodeMetadata
+	if meta.Configuration != nil {
+		err := kitmd.DecodeMetadata(meta.Configuration, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate options
+	m.serviceURLs = []string{}
+	for _, u := range strings.Split(m.ServiceURLs, ",") {
+		u = strings.TrimSuffix(strings.TrimSpace(u), "/")
+		if u == "" {
+			continue
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("invalid URL in configuration property 'serviceURLs': %s", u)
+		}
+		m.serviceURLs = append(m.serviceURLs, u)
+	}
+	if len(m.serviceURLs) == 0 {
+		return errors.New("missing required configuration property 'serviceURLs'")
+	}
+	if m.DaprPortMetaKey == "" {
+		return errors.New("configuration property 'daprPortMetaKey' must not be empty")
+	}
+	if m.FetchInterval <= 0 {
+		return errors.New("configuration property 'fetchInterval' must be greater than 0")
+	}
+	if m.RequestTimeout <= 0 {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	// Instance properties are required only when registering
+	if m.SelfRegister {
+		if m.HeartbeatInterval <= 0 || m.LeaseDuration <= m.HeartbeatInterval {
+			return errors.New("configuration property 'leaseDuration' must be greater than 'heartbeatInterval', which must be greater than 0")
+		}
+
+		m.appID = meta.Instance.AppID
+		if m.appID == "" {
+			return errors.New("name is missing")
+		}
+		m.hostAddress = meta.Instance.Address
+		if m.hostAddress == "" {
+			return errors.New("address is missing")
+		}
+		m.daprPort = meta.Instance.DaprInternalPort
+		if m.daprPort == 0 {
+			return errors.New("port is missing or invalid")
+		}
+		m.daprHTTPPort = meta.Instance.DaprHTTPPort
+		m.appPort = meta.Instance.AppPort
+	}
+
+	return nil
+}
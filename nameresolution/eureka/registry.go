@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eureka
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// registry is a local copy of the Eureka registry.
+type registry struct {
+	lock sync.RWMutex
+	// Map of app names (uppercase) to instances by ID
+	apps map[string]map[string]instance
+}
+
+func newRegistry() *registry {
+	return &registry{
+		apps: map[string]map[string]instance{},
+	}
+}
+
+// replace replaces the registry with the result of a full fetch.
+func (r *registry) replace(apps *applications) {
+	res := make(map[string]map[string]instance, len(apps.Application))
+	for _, app := range apps.Application {
+		name := strings.ToUpper(app.Name)
+		instances := make(map[string]instance, len(app.Instance))
+		for _, inst := range app.Instance {
+			instances[inst.InstanceID] = inst
+		}
+		res[name] = instances
+	}
+
+	r.lock.Lock()
+	r.apps = res
+	r.lock.Unlock()
+}
+
+// applyDelta applies the changes from a delta fetch.
+// Returns false if the registry is not consistent with the server's after applying the changes, and a full fetch is required.
+func (r *registry) applyDelta(delta *applications) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, app := range delta.Application {
+		name := strings.ToUpper(app.Name)
+		for _, inst := range app.Instance {
+			switch inst.ActionType {
+			case actionAdded, actionModified:
+				if r.apps[name] == nil {
+					r.apps[name] = map[string]instance{}
+				}
+				inst.ActionType = ""
+				r.apps[name][inst.InstanceID] = inst
+			case actionDeleted:
+				delete(r.apps[name], inst.InstanceID)
+				if len(r.apps[name]) == 0 {
+					delete(r.apps, name)
+				}
+			}
+		}
+	}
+
+	return delta.AppsHashcode == "" || r.hashcodeLocked() == delta.AppsHashcode
+}
+
+// hashcodeLocked computes the hash code of the registry in the same way as Eureka does, which is in the format "STATUS_count_" for each status in alphabetical order, such as "DOWN_1_UP_3_".
+// Must be called while holding the lock.
+func (r *registry) hashcodeLocked() string {
+	counts := map[string]int{}
+	for _, instances := range r.apps {
+		for _, inst := range instances {
+			counts[inst.Status]++
+		}
+	}
+	statuses := make([]string, 0, len(counts))
+	for s := range counts {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+
+	var b strings.Builder
+	for _, s := range statuses {
+		b.WriteString(s)
+		b.WriteRune('_')
+		b.WriteString(strconv.Itoa(counts[s]))
+		b.WriteRune('_')
+	}
+	return b.String()
+}
+
+// upInstances returns the instances of the app with status UP.
+// If zone is not empty and there are instances in that zone, only those are returned.
+func (r *registry) upInstances(app string, zone string) []instance {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	all := make([]instance, 0, len(r.apps[strings.ToUpper(app)]))
+	sameZone := make([]instance, 0)
+	for _, inst := range r.apps[strings.ToUpper(app)] {
+		if inst.Status != statusUp {
+			continue
+		}
+		all = append(all, inst)
+		if zone != "" && inst.zone() == zone {
+			sameZone = append(sameZone, inst)
+		}
+	}
+
+	if len(sameZone) > 0 {
+		return sameZone
+	}
+	return all
+}
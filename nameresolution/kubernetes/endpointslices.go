@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// Default time to wait for the EndpointSlices of a namespace to be loaded the first time they're requested.
+const defaultSyncTimeout = 5 * time.Second
+
+// endpointSliceCache resolves app IDs to the addresses of its ready pods, using informers to keep a local cache of the EndpointSlices of the Dapr services.
+// Informers are started lazily, for each namespace that is requested.
+type endpointSliceCache struct {
+	client      kubernetes.Interface
+	syncTimeout time.Duration
+	logger      logger.Logger
+
+	lock       sync.Mutex
+	namespaces map[string]*namespaceInformer
+	stopCh     chan struct{}
+	closeOnce  sync.Once
+}
+
+type namespaceInformer struct {
+	lister   discoverylisters.EndpointSliceLister
+	synced   cache.InformerSynced
+	syncOnce sync.Once
+}
+
+func newEndpointSliceCache(client kubernetes.Interface, syncTimeout time.Duration, logger logger.Logger) *endpointSliceCache {
+	return &endpointSliceCache{
+		client:      client,
+		syncTimeout: syncTimeout,
+		logger:      logger,
+		namespaces:  map[string]*namespaceInformer{},
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// addresses returns the addresses of the ready pods for the app, or nil if none is found or the cache isn't ready.
+func (c *endpointSliceCache) addresses(ctx context.Context, req nameresolution.ResolveRequest) nameresolution.AddressList {
+	if req.Namespace == "" {
+		return nil
+	}
+
+	ni := c.getInformer(req.Namespace)
+
+	// The first time a namespace is requested, wait for the cache to be loaded.
+	// After that, if the cache isn't ready (for example, because of missing RBAC permissions), return right away.
+	ni.syncOnce.Do(func() {
+		waitCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if !cache.WaitForCacheSync(waitCtx.Done(), ni.synced) {
+			c.logger.Warnf("Timed out waiting for EndpointSlices in namespace %s to be loaded", req.Namespace)
+		}
+	})
+	if !ni.synced() {
+		return nil
+	}
+
+	slices, err := ni.lister.EndpointSlices(req.Namespace).List(labels.SelectorFromSet(labels.Set{
+		discoveryv1.LabelServiceName: req.ID + "-dapr",
+	}))
+	if err != nil {
+		c.logger.Warnf("Failed to list EndpointSlices for app %s in namespace %s: %v", req.ID, req.Namespace, err)
+		return nil
+	}
+
+	// Dual-stack services have one EndpointSlice per address type: prefer IPv4 addresses so each pod is returned once
+	port := strconv.Itoa(req.Port)
+	var ipv4, ipv6 nameresolution.AddressList
+	for _, slice := range slices {
+		for _, ep := range slice.Endpoints {
+			// A nil ready condition must be interpreted as ready
+			if len(ep.Addresses) == 0 || (ep.Conditions.Ready != nil && !*ep.Conditions.Ready) {
+				continue
+			}
+
+			// All addresses of an endpoint are fungible, so the first one is used
+			addr := net.JoinHostPort(ep.Addresses[0], port)
+			switch slice.AddressType {
+			case discoveryv1.AddressTypeIPv4:
+				ipv4 = append(ipv4, addr)
+			case discoveryv1.AddressTypeIPv6:
+				ipv6 = append(ipv6, addr)
+			}
+		}
+	}
+
+	if len(ipv4) > 0 {
+		return ipv4
+	}
+	return ipv6
+}
+
+// getInformer returns the informer for the namespace, starting it if needed.
+func (c *endpointSliceCache) getInformer(namespace string) *namespaceInformer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ni, ok := c.namespaces[namespace]
+	if ok {
+		return ni
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(c.client, 0, informers.WithNamespace(namespace))
+	informer := factory.Discovery().V1().EndpointSlices()
+	ni = &namespaceInformer{
+		lister: informer.Lister(),
+		synced: informer.Informer().HasSynced,
+	}
+	factory.Start(c.stopCh)
+	c.namespaces[namespace] = ni
+
+	c.logger.Debugf("Started watching EndpointSlices in namespace %s", namespace)
+	return ni
+}
+
+// close stops all informers.
+func (c *endpointSliceCache) close() {
+	c.closeOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
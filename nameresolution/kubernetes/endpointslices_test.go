@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func newEndpointSlice(name string, service string, addressType discoveryv1.AddressType, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "abc",
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: service,
+			},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+	}
+}
+
+func TestEndpointSlices(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newEndpointSlice("myid-dapr-1", "myid-dapr", discoveryv1.AddressTypeIPv4,
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.Of(true)}},
+			// Nil ready condition means ready
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"}},
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.Of(false)}},
+		),
+		newEndpointSlice("myid-dapr-2", "myid-dapr", discoveryv1.AddressTypeIPv6,
+			discoveryv1.Endpoint{Addresses: []string{"fd00::1"}},
+		),
+		newEndpointSlice("other-dapr-1", "other-dapr", discoveryv1.AddressTypeIPv6,
+			discoveryv1.Endpoint{Addresses: []string{"fd00::2"}},
+		),
+		newEndpointSlice("notready-dapr-1", "notready-dapr", discoveryv1.AddressTypeIPv4,
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.4"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.Of(false)}},
+		),
+	)
+
+	resolver := NewResolver(logger.NewLogger("test")).(*resolver)
+	resolver.endpoints = newEndpointSliceCache(client, time.Second, resolver.logger)
+	defer resolver.Close()
+
+	t.Run("resolves to ready pods", func(t *testing.T) {
+		request := nameresolution.ResolveRequest{ID: "myid", Namespace: "abc", Port: 50002}
+
+		addrs, err := resolver.ResolveIDMulti(context.Background(), request)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, nameresolution.AddressList{"10.0.0.1:50002", "10.0.0.2:50002"}, addrs)
+
+		target, err := resolver.ResolveID(context.Background(), request)
+		require.NoError(t, err)
+		assert.Contains(t, addrs, target)
+	})
+
+	t.Run("IPv6 only", func(t *testing.T) {
+		request := nameresolution.ResolveRequest{ID: "other", Namespace: "abc", Port: 50002}
+
+		target, err := resolver.ResolveID(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, "[fd00::2]:50002", target)
+	})
+
+	t.Run("falls back to DNS when no pod is ready", func(t *testing.T) {
+		request := nameresolution.ResolveRequest{ID: "notready", Namespace: "abc", Port: 50002}
+
+		target, err := resolver.ResolveID(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, "notready-dapr.abc.svc.cluster.local:50002", target)
+	})
+
+	t.Run("falls back to DNS for other namespaces", func(t *testing.T) {
+		request := nameresolution.ResolveRequest{ID: "myid", Namespace: "def", Port: 50002}
+
+		target, err := resolver.ResolveID(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, "myid-dapr.def.svc.cluster.local:50002", target)
+	})
+}
+
+func TestInitInvalidMode(t *testing.T) {
+	resolver := NewResolver(logger.NewLogger("test"))
+	err := resolver.Init(context.Background(), nameresolution.Metadata{
+		Configuration: map[string]interface{}{
+			"mode": "foo",
+		},
+	})
+	require.ErrorContains(t, err, "mode")
+}
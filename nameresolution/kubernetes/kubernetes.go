@@ -18,10 +18,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"strconv"
 	"strings"
 	"text/template"
 
+	kubeclient "github.com/dapr/components-contrib/common/authentication/kubernetes"
 	"github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/config"
 	"github.com/dapr/kit/logger"
@@ -31,6 +33,13 @@ const (
 	DefaultClusterDomain = "cluster.local"
 	ClusterDomainKey     = "clusterDomain"
 	TemplateKey          = "template"
+	ModeKey              = "mode"
+	KubeconfigPathKey    = "kubeconfigPath"
+
+	// ModeDNS resolves app IDs to the DNS name of the Dapr service.
+	ModeDNS = "dns"
+	// ModeEndpointSlices resolves app IDs to the IPs of the ready pods, using the EndpointSlices of the Dapr service.
+	ModeEndpointSlices = "endpointslices"
 )
 
 // Compile-time interface assertions
@@ -51,6 +60,8 @@ type resolver struct {
 	logger        logger.Logger
 	clusterDomain string
 	tmpl          *template.Template
+	// endpoints is set when using the EndpointSlices mode.
+	endpoints *endpointSliceCache
 }
 
 // NewResolver creates Kubernetes name resolver.
@@ -72,6 +83,8 @@ func (k *resolver) Init(ctx context.Context, metadata nameresolution.Metadata) e
 	if cfg, ok := configInterface.(map[string]interface{}); ok {
 		clusterDomainAny := cfg[ClusterDomainKey]
 		tmplStrAny := cfg[TemplateKey]
+		modeAny := cfg[ModeKey]
+		kubeconfigPathAny := cfg[KubeconfigPathKey]
 
 		if clusterDomainAny != nil {
 			clusterDomain, _ := clusterDomainAny.(string)
@@ -87,13 +100,45 @@ func (k *resolver) Init(ctx context.Context, metadata nameresolution.Metadata) e
 				k.logger.Debugf("using custom template %s", tmplStr)
 			}
 		}
+
+		mode, _ := modeAny.(string)
+		switch strings.ToLower(mode) {
+		case "", ModeDNS:
+			// Nop
+		case ModeEndpointSlices:
+			kubeconfigPath, _ := kubeconfigPathAny.(string)
+			if kubeconfigPath == "" {
+				kubeconfigPath = kubeclient.GetKubeconfigPath(k.logger, os.Args)
+			}
+			client, err := kubeclient.GetKubeClient(kubeconfigPath)
+			if err != nil {
+				return fmt.Errorf("failed to create Kubernetes client: %w", err)
+			}
+			k.endpoints = newEndpointSliceCache(client, defaultSyncTimeout, k.logger)
+			k.logger.Debug("resolving app IDs to pod IPs using EndpointSlices")
+		default:
+			return fmt.Errorf("invalid value for '%s': %s", ModeKey, mode)
+		}
 	}
 
 	return nil
 }
 
 // ResolveID resolves name to address in Kubernetes.
+// When using the EndpointSlices mode, this returns the address of one of the ready pods, falling back to the service's DNS name if none is found.
 func (k *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	if k.endpoints != nil {
+		if addrs := k.endpoints.addresses(ctx, req); len(addrs) > 0 {
+			return addrs.Pick(), nil
+		}
+		k.logger.Debugf("no ready pods found for app ID %s in namespace %s; falling back to DNS", req.ID, req.Namespace)
+	}
+
+	return k.resolveDNSName(req)
+}
+
+// resolveDNSName returns the DNS name of the Dapr service for the app ID.
+func (k *resolver) resolveDNSName(req nameresolution.ResolveRequest) (string, error) {
 	if k.tmpl != nil {
 		return executeTemplateWithResolveRequest(k.tmpl, req)
 	}
@@ -103,8 +148,15 @@ func (k *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequ
 
 // ResolveIDMulti resolves an app-id to a set of IP addresses in Kubernetes
 func (k *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
-	// First, get the address from ResolveID, which is usually a DNS name
-	addr, err := k.ResolveID(ctx, req)
+	// When using EndpointSlices, return the addresses of all ready pods
+	if k.endpoints != nil {
+		if addrs := k.endpoints.addresses(ctx, req); len(addrs) > 0 {
+			return addrs, nil
+		}
+	}
+
+	// Otherwise, get the address of the service, which is usually a DNS name
+	addr, err := k.resolveDNSName(req)
 	if err != nil {
 		return nil, err
 	}
@@ -132,5 +184,8 @@ func (k *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.Resolv
 }
 
 func (k *resolver) Close() error {
+	if k.endpoints != nil {
+		k.endpoints.close()
+	}
 	return nil
 }
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"os"
 	"strconv"
 	"sync"
@@ -45,11 +46,11 @@ const (
 	// refreshTimeout is the timeout used when
 	// browsing for any responses to a single app id.
 	refreshTimeout = time.Second * 3
-	// refreshInterval is the duration between
+	// refreshInterval is the default duration between
 	// background address refreshes.
 	refreshInterval = time.Second * 30
-	// addressTTL is the duration an address has before
-	// becoming stale and being evicted.
+	// addressTTL is the default duration an address has
+	// before becoming stale and being evicted.
 	addressTTL = time.Second * 60
 )
 
@@ -67,6 +68,8 @@ type addressList struct {
 	addresses []address
 	counter   atomic.Uint32
 	mu        sync.RWMutex
+	// ttl of the addresses; if zero, addressTTL is used.
+	ttl time.Duration
 }
 
 // expire removes any addresses with an expiry time earlier
@@ -93,15 +96,20 @@ func (a *addressList) add(ip string) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	ttl := a.ttl
+	if ttl <= 0 {
+		ttl = addressTTL
+	}
+
 	for i := range a.addresses {
 		if a.addresses[i].ip == ip {
-			a.addresses[i].expiresAt = time.Now().Add(addressTTL)
+			a.addresses[i].expiresAt = time.Now().Add(ttl)
 			return
 		}
 	}
 	a.addresses = append(a.addresses, address{
 		ip:        ip,
-		expiresAt: time.Now().Add(addressTTL),
+		expiresAt: time.Now().Add(ttl),
 	})
 }
 
@@ -191,6 +199,8 @@ func NewResolver(logger logger.Logger) nameresolution.Resolver {
 		// registrations channel to signal the resolver to
 		// stop serving queries for registered app ids.
 		registrations: make(map[string]chan struct{}),
+		// configuration with the defaults until Init is called.
+		metadata: newMetadata(),
 		// shutdown refreshers
 		runCtx:    runCtx,
 		runCancel: runCancel,
@@ -223,6 +233,8 @@ type Resolver struct {
 	// expected to be 1 when initialized by the dapr runtime.
 	registrationMu sync.RWMutex
 	registrations  map[string]chan struct{}
+	// metadata contains the configuration of the resolver.
+	metadata mdnsMetadata
 	// shutdown refreshes.
 	runCtx         context.Context
 	runCancel      context.CancelFunc
@@ -241,7 +253,7 @@ func (m *Resolver) startRefreshers() {
 	go func() {
 		defer m.refreshRunning.Store(false)
 
-		t := time.NewTicker(refreshInterval)
+		t := time.NewTicker(m.metadata.RefreshInterval)
 		defer t.Stop()
 
 		for {
@@ -281,12 +293,22 @@ func (m *Resolver) Init(ctx context.Context, metadata nameresolution.Metadata) e
 		return errors.New("port is missing or invalid")
 	}
 
-	err := m.registerMDNS("", metadata.Instance.AppID, []string{metadata.Instance.Address}, metadata.Instance.DaprInternalPort)
+	err := m.metadata.InitWithMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	ips, err := m.metadata.getAdvertisedIPs(metadata.Instance.Address)
+	if err != nil {
+		return err
+	}
+
+	err = m.registerMDNS("", metadata.Instance.AppID, ips, metadata.Instance.DaprInternalPort)
 	if err != nil {
 		return err
 	}
 
-	m.logger.Infof("local service entry announced: %s -> %s:%d", metadata.Instance.AppID, metadata.Instance.Address, metadata.Instance.DaprInternalPort)
+	m.logger.Infof("local service entry announced: %s -> %v:%d", metadata.Instance.AppID, ips, metadata.Instance.DaprInternalPort)
 
 	go m.startRefreshers()
 
@@ -294,6 +316,26 @@ func (m *Resolver) Init(ctx context.Context, metadata nameresolution.Metadata) e
 }
 
 func (m *Resolver) getZeroconfResolver() (resolver *zeroconf.Resolver, err error) {
+	var ifaceOpts []zeroconf.ClientOption
+	if len(m.metadata.interfaces) > 0 {
+		ifaceOpts = append(ifaceOpts, zeroconf.SelectIfaces(m.metadata.interfaces))
+	}
+
+	switch m.metadata.IPVersion {
+	case ipVersionIPv4:
+		resolver, err = zeroconf.NewResolver(append(ifaceOpts, zeroconf.SelectIPTraffic(zeroconf.IPv4))...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize IPv4 resolver: %w", err)
+		}
+		return resolver, nil
+	case ipVersionIPv6:
+		resolver, err = zeroconf.NewResolver(append(ifaceOpts, zeroconf.SelectIPTraffic(zeroconf.IPv6))...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize IPv6 resolver: %w", err)
+		}
+		return resolver, nil
+	}
+
 	// Try with IPv4 + IPv6 first, then IPv4-only, then IPv6-only
 	opts := []zeroconf.ClientOption{
 		zeroconf.SelectIPTraffic(zeroconf.IPv4AndIPv6),
@@ -301,7 +343,7 @@ func (m *Resolver) getZeroconfResolver() (resolver *zeroconf.Resolver, err error
 		zeroconf.SelectIPTraffic(zeroconf.IPv6),
 	}
 	for i := range opts {
-		resolver, err = zeroconf.NewResolver(opts[i])
+		resolver, err = zeroconf.NewResolver(append(ifaceOpts, opts[i])...)
 		if err == nil {
 			break
 		}
@@ -371,10 +413,12 @@ func (m *Resolver) registerMDNS(instanceID string, appID string, ips []string, p
 			instanceID = host + ":" + strconv.Itoa(syscall.Getpid())
 		}
 
+		// if no interfaces are configured, zeroconf uses all multicast-capable interfaces.
+		ifaces := m.metadata.interfaces
 		if len(ips) > 0 {
-			server, err = zeroconf.RegisterProxy(instanceID, appID, "local.", port, host, ips, info, nil)
+			server, err = zeroconf.RegisterProxy(instanceID, appID, "local.", port, host, ips, info, ifaces)
 		} else {
-			server, err = zeroconf.Register(instanceID, appID, "local.", port, info, nil)
+			server, err = zeroconf.Register(instanceID, appID, "local.", port, info, ifaces)
 		}
 
 		if err != nil {
@@ -403,13 +447,9 @@ func (m *Resolver) registerMDNS(instanceID string, appID string, ips []string, p
 
 // ResolveID resolves name to address via mDNS.
 func (m *Resolver) ResolveID(parentCtx context.Context, req nameresolution.ResolveRequest) (string, error) {
-	// check for cached IPv4 addresses for this app id first.
-	if addr := m.nextIPv4Address(req.ID); addr != nil {
-		return *addr, nil
-	}
-
-	// check for cached IPv6 addresses for this app id second.
-	if addr := m.nextIPv6Address(req.ID); addr != nil {
+	// check for cached IPv4 addresses for this app id first,
+	// then for cached IPv6 addresses.
+	if addr := m.nextAddress(req.ID); addr != nil {
 		return *addr, nil
 	}
 
@@ -454,10 +494,7 @@ func (m *Resolver) ResolveID(parentCtx context.Context, req nameresolution.Resol
 	// browser as they must wait on the published channel and perform
 	// the cleanup before returning.
 	if once == nil {
-		if addr := m.nextIPv4Address(req.ID); addr != nil {
-			return *addr, nil
-		}
-		if addr := m.nextIPv6Address(req.ID); addr != nil {
+		if addr := m.nextAddress(req.ID); addr != nil {
 			return *addr, nil
 		}
 	}
@@ -515,10 +552,7 @@ func (m *Resolver) ResolveID(parentCtx context.Context, req nameresolution.Resol
 		// If no address or error has been received
 		// within the timeout, we will check the cache again and
 		// if no address is present we will return an error.
-		if addr := m.nextIPv4Address(req.ID); addr != nil {
-			return *addr, nil
-		}
-		if addr := m.nextIPv6Address(req.ID); addr != nil {
+		if addr := m.nextAddress(req.ID); addr != nil {
 			return *addr, nil
 		}
 		return "", fmt.Errorf("timeout waiting for address for app id %s", req.ID)
@@ -707,23 +741,40 @@ func (m *Resolver) browse(ctx context.Context, appID string, onEach func(ip stri
 				break
 			}
 
-			var addr string
-			port := entry.Port
-
-			// TODO: we currently only use the first IPv4 and IPv6 address.
-			// We should understand the cases in which additional addresses
-			// are returned and whether we need to support them.
-			if hasIPv4Address {
-				addr = entry.AddrIPv4[0].String() + ":" + strconv.Itoa(port)
-				m.addAppAddressIPv4(appID, addr)
+			// multi-homed hosts may advertise multiple addresses, which are all
+			// added to the cache. The callback receives the first one that can be
+			// used, preferring IPv4 addresses.
+			var first string
+			port := strconv.Itoa(entry.Port)
+			if m.metadata.allowsIPv4() {
+				for _, ip := range entry.AddrIPv4 {
+					addr := net.JoinHostPort(ip.String(), port)
+					m.addAppAddressIPv4(appID, addr)
+					if first == "" {
+						first = addr
+					}
+				}
 			}
-			if hasIPv6Address {
-				addr = entry.AddrIPv6[0].String() + ":" + strconv.Itoa(port)
-				m.addAppAddressIPv6(appID, addr)
+			if m.metadata.allowsIPv6() {
+				for _, ip := range entry.AddrIPv6 {
+					if !m.metadata.allowsIP(ip) {
+						continue
+					}
+					addr := net.JoinHostPort(ip.String(), port)
+					m.addAppAddressIPv6(appID, addr)
+					if first == "" {
+						first = addr
+					}
+				}
+			}
+
+			if first == "" {
+				m.logger.Debugf("mDNS response for app id %s doesn't contain any usable addresses, skipping.", appID)
+				break
 			}
 
 			if onEach != nil {
-				onEach(addr) // invoke callback.
+				onEach(first) // invoke callback.
 			}
 		}
 	}
@@ -769,8 +820,7 @@ func (m *Resolver) addAppAddressIPv4(appID string, addr string) {
 
 	m.logger.Debugf("Adding IPv4 address %s for app id %s cache entry.", addr, appID)
 	if _, ok := m.appAddressesIPv4[appID]; !ok {
-		var addrList addressList
-		m.appAddressesIPv4[appID] = &addrList
+		m.appAddressesIPv4[appID] = &addressList{ttl: m.metadata.AddressTTL}
 	}
 	m.appAddressesIPv4[appID].add(addr)
 }
@@ -783,8 +833,7 @@ func (m *Resolver) addAppAddressIPv6(appID string, addr string) {
 
 	m.logger.Debugf("Adding IPv6 address %s for app id %s cache entry.", addr, appID)
 	if _, ok := m.appAddressesIPv6[appID]; !ok {
-		var addrList addressList
-		m.appAddressesIPv6[appID] = &addrList
+		m.appAddressesIPv6[appID] = &addressList{ttl: m.metadata.AddressTTL}
 	}
 	m.appAddressesIPv6[appID].add(addr)
 }
@@ -829,6 +878,15 @@ func (m *Resolver) getAppIDs() []string {
 	return union(m.getAppIDsIPv4(), m.getAppIDsIPv6())
 }
 
+// nextAddress returns the next address for the provided
+// app id from the cache, preferring IPv4 addresses.
+func (m *Resolver) nextAddress(appID string) *string {
+	if addr := m.nextIPv4Address(appID); addr != nil {
+		return addr
+	}
+	return m.nextIPv6Address(appID)
+}
+
 // nextIPv4Address returns the next IPv4 address for
 // the provided app id from the cache.
 func (m *Resolver) nextIPv4Address(appID string) *string {
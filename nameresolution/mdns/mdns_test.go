@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -85,6 +86,123 @@ func TestInitMetadata(t *testing.T) {
 	}
 }
 
+func TestInitWithMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, ipVersionBoth, m.IPVersion)
+		assert.Equal(t, refreshInterval, m.RefreshInterval)
+		assert.Equal(t, addressTTL, m.AddressTTL)
+		assert.Empty(t, m.interfaces)
+
+		ips, err := m.getAdvertisedIPs(localhost)
+		require.NoError(t, err)
+		assert.Equal(t, []string{localhost}, ips)
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{
+			Configuration: map[string]string{
+				"ipVersion":       "IPv6",
+				"refreshInterval": "10s",
+				"addressTTL":      "25s",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ipVersionIPv6, m.IPVersion)
+		assert.Equal(t, 10*time.Second, m.RefreshInterval)
+		assert.Equal(t, 25*time.Second, m.AddressTTL)
+	})
+
+	t.Run("invalid IP version", func(t *testing.T) {
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{
+			Configuration: map[string]string{"ipVersion": "ipv5"},
+		})
+		require.ErrorContains(t, err, "ipVersion")
+	})
+
+	t.Run("address TTL shorter than refresh interval", func(t *testing.T) {
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{
+			Configuration: map[string]string{
+				"refreshInterval": "30s",
+				"addressTTL":      "30s",
+			},
+		})
+		require.ErrorContains(t, err, "addressTTL")
+	})
+
+	t.Run("invalid interface", func(t *testing.T) {
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{
+			Configuration: map[string]string{"interfaces": "doesnotexist0"},
+		})
+		require.ErrorContains(t, err, "doesnotexist0")
+	})
+
+	t.Run("advertises interface addresses", func(t *testing.T) {
+		loopback := getLoopbackInterface(t)
+
+		m := mdnsMetadata{}
+		err := m.InitWithMetadata(nr.Metadata{
+			Configuration: map[string]string{
+				"interfaces": loopback,
+				"ipVersion":  "ipv4",
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, m.interfaces, 1)
+
+		ips, err := m.getAdvertisedIPs("10.0.0.1")
+		require.NoError(t, err)
+		assert.Contains(t, ips, localhost)
+		assert.NotContains(t, ips, "10.0.0.1")
+		for _, ip := range ips {
+			assert.NotNil(t, net.ParseIP(ip).To4())
+		}
+	})
+}
+
+func TestAllowsIP(t *testing.T) {
+	tests := []struct {
+		ipVersion string
+		ip        string
+		allowed   bool
+	}{
+		{ipVersionBoth, "10.0.0.1", true},
+		{ipVersionBoth, "fd00::1", true},
+		{ipVersionBoth, "fe80::1", false},
+		{ipVersionIPv4, "10.0.0.1", true},
+		{ipVersionIPv4, "fd00::1", false},
+		{ipVersionIPv6, "10.0.0.1", false},
+		{ipVersionIPv6, "fd00::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ipVersion+" "+tt.ip, func(t *testing.T) {
+			m := mdnsMetadata{IPVersion: tt.ipVersion}
+			assert.Equal(t, tt.allowed, m.allowsIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func getLoopbackInterface(t *testing.T) string {
+	t.Helper()
+
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface found")
+	return ""
+}
+
 func TestInitRegister(t *testing.T) {
 	// arrange
 	resolver := NewResolver(logger.NewLogger("test")).(*Resolver)
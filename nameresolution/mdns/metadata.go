@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+// IP versions used for advertising and resolving addresses.
+const (
+	ipVersionBoth = "both"
+	ipVersionIPv4 = "ipv4"
+	ipVersionIPv6 = "ipv6"
+)
+
+type mdnsMetadata struct {
+	// Comma-separated list of names of the network interfaces to use, such as "eth0,eth1".
+	// If set, mDNS queries and announcements are sent only on those interfaces, and the addresses of those interfaces are advertised instead of the instance's address.
+	// If empty, all multicast-capable interfaces are used.
+	Interfaces string `mapstructure:"interfaces"`
+	// IP version of the addresses to advertise and resolve: "ipv4", "ipv6", or "both" (default).
+	IPVersion string `mapstructure:"ipVersion"`
+	// Interval between background refreshes of the addresses in the cache.
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+	// Duration after which an address that was not seen in a refresh is evicted from the cache.
+	// Must be greater than refreshInterval.
+	AddressTTL time.Duration `mapstructure:"addressTTL"`
+
+	// Internal properties
+	interfaces []net.Interface
+}
+
+func newMetadata() mdnsMetadata {
+	return mdnsMetadata{
+		IPVersion:       ipVersionBoth,
+		RefreshInterval: refreshInterval,
+		AddressTTL:      addressTTL,
+	}
+}
+
+func (m *mdnsMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	*m = newMetadata()
+
+	// Decode the configuration using DecodeMetadata
+	if meta.Configuration != nil {
+		err := kitmd.DecodeMetadata(meta.Configuration, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate options
+	m.IPVersion = strings.ToLower(m.IPVersion)
+	switch m.IPVersion {
+	case "":
+		m.IPVersion = ipVersionBoth
+	case ipVersionBoth, ipVersionIPv4, ipVersionIPv6:
+		// Nop
+	default:
+		return fmt.Errorf("invalid value for configuration property 'ipVersion': %s", m.IPVersion)
+	}
+
+	if m.RefreshInterval <= 0 {
+		return errors.New("configuration property 'refreshInterval' must be greater than 0")
+	}
+	if m.AddressTTL <= m.RefreshInterval {
+		return errors.New("configuration property 'addressTTL' must be greater than 'refreshInterval'")
+	}
+
+	m.interfaces = nil
+	for _, name := range strings.Split(m.Interfaces, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return fmt.Errorf("invalid network interface '%s' in configuration property 'interfaces': %w", name, err)
+		}
+		m.interfaces = append(m.interfaces, *iface)
+	}
+
+	return nil
+}
+
+// allowsIPv4 returns true if IPv4 addresses can be advertised and resolved.
+func (m *mdnsMetadata) allowsIPv4() bool {
+	return m.IPVersion != ipVersionIPv6
+}
+
+// allowsIPv6 returns true if IPv6 addresses can be advertised and resolved.
+func (m *mdnsMetadata) allowsIPv6() bool {
+	return m.IPVersion != ipVersionIPv4
+}
+
+// allowsIP returns true if the IP can be advertised or resolved.
+// IPv6 link-local addresses are never allowed, as they can't be used without a zone.
+func (m *mdnsMetadata) allowsIP(ip net.IP) bool {
+	if ip.To4() != nil {
+		return m.allowsIPv4()
+	}
+	return m.allowsIPv6() && !ip.IsLinkLocalUnicast()
+}
+
+// getAdvertisedIPs returns the IPs to advertise for the instance.
+// If interfaces are configured, those are the addresses of the interfaces; otherwise, it's the instance's address.
+func (m *mdnsMetadata) getAdvertisedIPs(instanceAddress string) ([]string, error) {
+	if len(m.interfaces) == 0 {
+		return []string{instanceAddress}, nil
+	}
+
+	ips := []string{}
+	for _, iface := range m.interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get addresses of network interface '%s': %w", iface.Name, err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !m.allowsIP(ipNet.IP) {
+				continue
+			}
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no usable addresses found on network interfaces '%s' for IP version '%s'", m.Interfaces, m.IPVersion)
+	}
+
+	return ips, nil
+}
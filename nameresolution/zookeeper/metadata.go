@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultBasePath        = "/services"
+	defaultDaprPortMetaKey = "DAPR_PORT"
+	defaultSessionTimeout  = 10 * time.Second
+	defaultTimeout         = 10 * time.Second
+)
+
+type zookeeperMetadata struct {
+	// Comma-separated list of Zookeeper servers, such as "zk1:2181,zk2:2181".
+	Servers string `mapstructure:"servers"`
+	// Session timeout. When the session expires, Zookeeper removes the registration of the instance.
+	SessionTimeout time.Duration `mapstructure:"sessionTimeout"`
+	// Base path of the registry. Services are registered in "<basePath>/<app-id>/<instance-id>", which is the layout used by Curator Service Discovery.
+	BasePath string `mapstructure:"basePath"`
+	// Key in the instance metadata containing the Dapr internal gRPC port.
+	DaprPortMetaKey string `mapstructure:"daprPortMetaKey"`
+	// Timeout for establishing the connection with Zookeeper.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Internal properties
+	servers []string
+
+	// Instance properties - these are passed by the runtime
+	appID       string
+	hostAddress string
+	daprPort    int
+	appPort     int
+}
+
+func (m *zookeeperMetadata) InitWithMetadata(meta nameresolution.Metadata) error {
+	// Set defaults
+	m.SessionTimeout = defaultSessionTimeout
+	m.BasePath = defaultBasePath
+	m.DaprPortMetaKey = defaultDaprPortMetaKey
+	m.Timeout = defaultTimeout
+
+	// Set and validate the instance properties
+	m.appID = meta.Instance.AppID
+	if m.appID == "" {
+		return errors.New("name is missing")
+	}
+	m.hostAddress = meta.Instance.Address
+	if m.hostAddress == "" {
+		return errors.New("address is missing")
+	}
+	m.daprPort = meta.Instance.DaprInternalPort
+	if m.daprPort == 0 {
+		return errors.New("port is missing or invalid")
+	}
+	m.appPort = meta.Instance.AppPort // Can be empty
+
+	// Decode the configuration using DecodeMetadata
+	if meta.Configuration != nil {
+		err := kitmd.DecodeMetadata(meta.Configuration, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate options
+	m.servers = []string{}
+	for _, s := range strings.Split(m.Servers, ",") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			m.servers = append(m.servers, s)
+		}
+	}
+	if len(m.servers) == 0 {
+		return errors.New("missing required configuration property 'servers'")
+	}
+
+	if !strings.HasPrefix(m.BasePath, "/") {
+		return errors.New("configuration property 'basePath' must be an absolute path")
+	}
+	m.BasePath = path.Clean(m.BasePath)
+	if m.BasePath == "/" {
+		return errors.New("configuration property 'basePath' must not be the root path")
+	}
+
+	if m.DaprPortMetaKey == "" {
+		return errors.New("configuration property 'daprPortMetaKey' must not be empty")
+	}
+	if m.SessionTimeout <= 0 {
+		m.SessionTimeout = defaultSessionTimeout
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+
+	return nil
+}
+
+// servicePath returns the path of the znode containing the instances of an app.
+func (m *zookeeperMetadata) servicePath(appID string) string {
+	return m.BasePath + "/" + appID
+}
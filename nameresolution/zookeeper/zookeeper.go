@@ -0,0 +1,417 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// ErrNoHost is returned by ResolveID when no host can be found.
+var ErrNoHost = errors.New("no host found with the given ID")
+
+// Class of the payload used by Spring Cloud Zookeeper, for interoperability.
+const payloadClass = "org.springframework.cloud.zookeeper.discovery.ZookeeperInstance"
+
+// zkConn is the subset of the Zookeeper connection used by the resolver.
+type zkConn interface {
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Delete(path string, version int32) error
+	Get(path string) ([]byte, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Close()
+}
+
+// serviceInstance is the data of an instance znode, in the format used by Curator Service Discovery.
+type serviceInstance struct {
+	Name                string           `json:"name"`
+	ID                  string           `json:"id"`
+	Address             string           `json:"address"`
+	Port                *int             `json:"port"`
+	SSLPort             *int             `json:"sslPort"`
+	Payload             *instancePayload `json:"payload"`
+	RegistrationTimeUTC int64            `json:"registrationTimeUTC"`
+	ServiceType         string           `json:"serviceType"`
+	URISpec             json.RawMessage  `json:"uriSpec"`
+}
+
+type instancePayload struct {
+	Class    string            `json:"@class,omitempty"`
+	ID       string            `json:"id,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type resolver struct {
+	logger     logger.Logger
+	metadata   zookeeperMetadata
+	connect    func(servers []string, sessionTimeout time.Duration) (zkConn, <-chan zk.Event, error)
+	conn       zkConn
+	instanceID string
+	// Cache of the addresses of each app, which is invalidated by watches
+	cache     map[string]nameresolution.AddressList
+	cacheLock sync.RWMutex
+	loadLock  sync.Mutex
+	closed    atomic.Bool
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewResolver creates a name resolver that is based on Zookeeper.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	return &resolver{
+		logger:  logger,
+		connect: connect,
+		cache:   map[string]nameresolution.AddressList{},
+		closeCh: make(chan struct{}),
+	}
+}
+
+func connect(servers []string, sessionTimeout time.Duration) (zkConn, <-chan zk.Event, error) {
+	return zk.Connect(servers, sessionTimeout)
+}
+
+// Init initializes the name resolver.
+func (z *resolver) Init(ctx context.Context, md nameresolution.Metadata) error {
+	if z.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	err := z.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	conn, events, err := z.connect(z.metadata.servers, z.metadata.SessionTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Zookeeper: %w", err)
+	}
+	z.conn = conn
+
+	// The connection is established in background
+	err = z.waitForSession(ctx, events)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	z.instanceID = uuid.NewString()
+	err = z.registerHost()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	z.wg.Add(1)
+	go z.handleSessionEvents(events)
+
+	return nil
+}
+
+// Waits until a session is established with Zookeeper.
+func (z *resolver) waitForSession(parentCtx context.Context, events <-chan zk.Event) error {
+	ctx, cancel := context.WithTimeout(parentCtx, z.metadata.Timeout)
+	defer cancel()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return errors.New("connection to Zookeeper closed")
+			}
+			if ev.Type == zk.EventSession && ev.State == zk.StateHasSession {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for a session with Zookeeper: %w", ctx.Err())
+		}
+	}
+}
+
+// In background, handles the events of the Zookeeper session.
+// When the session expires, the ephemeral znode of the host is removed, so the host is registered again once a new session is established.
+// Should be invoked in a background goroutine
+func (z *resolver) handleSessionEvents(events <-chan zk.Event) {
+	defer z.wg.Done()
+
+	expired := false
+	for {
+		select {
+		case <-z.closeCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Type != zk.EventSession {
+				continue
+			}
+
+			switch ev.State {
+			case zk.StateExpired:
+				z.logger.Warn("Zookeeper session expired")
+				expired = true
+				// Watches are lost with the session
+				z.invalidateAll()
+			case zk.StateHasSession:
+				if !expired {
+					continue
+				}
+				err := z.registerHost()
+				if err != nil {
+					// Keep the flag so we try again on the next session event
+					z.logger.Errorf("Failed to register host after Zookeeper session expired: %v", err)
+					continue
+				}
+				expired = false
+			}
+		}
+	}
+}
+
+// Registers the host in an ephemeral znode.
+func (z *resolver) registerHost() error {
+	servicePath := z.metadata.servicePath(z.metadata.appID)
+	err := z.ensurePath(servicePath)
+	if err != nil {
+		return fmt.Errorf("failed to create path %s: %w", servicePath, err)
+	}
+
+	inst := serviceInstance{
+		Name:    z.metadata.appID,
+		ID:      z.instanceID,
+		Address: z.metadata.hostAddress,
+		Payload: &instancePayload{
+			Class: payloadClass,
+			ID:    z.metadata.appID,
+			Name:  z.metadata.appID,
+			Metadata: map[string]string{
+				z.metadata.DaprPortMetaKey: strconv.Itoa(z.metadata.daprPort),
+			},
+		},
+		RegistrationTimeUTC: time.Now().UnixMilli(),
+		ServiceType:         "DYNAMIC",
+		URISpec:             json.RawMessage("null"),
+	}
+	// The app port is advertised so non-Dapr clients (such as Spring Cloud apps) can reach the app directly
+	port := z.metadata.daprPort
+	if z.metadata.appPort > 0 {
+		port = z.metadata.appPort
+	}
+	inst.Port = &port
+
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+
+	_, err = z.conn.Create(servicePath+"/"+z.instanceID, data, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+	if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+		return fmt.Errorf("failed to register host: %w", err)
+	}
+
+	z.logger.Debugf("Registered host at %s/%s", servicePath, z.instanceID)
+	return nil
+}
+
+// Creates the persistent znodes in the path, if they don't exist.
+func (z *resolver) ensurePath(p string) error {
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	cur := ""
+	for _, part := range parts {
+		cur += "/" + part
+		_, err := z.conn.Create(cur, nil, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveID resolves name to address.
+func (z *resolver) ResolveID(ctx context.Context, req nameresolution.ResolveRequest) (string, error) {
+	addrs, err := z.ResolveIDMulti(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return addrs.Pick(), nil
+}
+
+// ResolveIDMulti resolves name to a list of addresses.
+func (z *resolver) ResolveIDMulti(ctx context.Context, req nameresolution.ResolveRequest) (nameresolution.AddressList, error) {
+	z.cacheLock.RLock()
+	addrs, ok := z.cache[req.ID]
+	z.cacheLock.RUnlock()
+
+	if !ok {
+		var err error
+		addrs, err = z.loadInstances(req.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil, ErrNoHost
+	}
+	return addrs, nil
+}
+
+// Loads the instances of an app in the cache, and sets a watch that invalidates the cache when they change.
+func (z *resolver) loadInstances(appID string) (nameresolution.AddressList, error) {
+	z.loadLock.Lock()
+	defer z.loadLock.Unlock()
+
+	if z.closed.Load() {
+		return nil, errors.New("component is closed")
+	}
+
+	// Another goroutine may have loaded the instances while we were waiting for the lock
+	z.cacheLock.RLock()
+	addrs, ok := z.cache[appID]
+	z.cacheLock.RUnlock()
+	if ok {
+		return addrs, nil
+	}
+
+	servicePath := z.metadata.servicePath(appID)
+	children, _, watch, err := z.conn.ChildrenW(servicePath)
+	if errors.Is(err, zk.ErrNoNode) {
+		// No instance was ever registered for the app: watch for the znode to be created
+		var exists bool
+		exists, _, watch, err = z.conn.ExistsW(servicePath)
+		if err == nil && exists {
+			// Created in the meanwhile: try again later
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances of app %s: %w", appID, err)
+	}
+
+	addrs = make(nameresolution.AddressList, 0, len(children))
+	for _, child := range children {
+		data, _, err := z.conn.Get(servicePath + "/" + child)
+		if err != nil {
+			if errors.Is(err, zk.ErrNoNode) {
+				// Removed after listing the children
+				continue
+			}
+			return nil, fmt.Errorf("failed to get instance %s of app %s: %w", child, appID, err)
+		}
+
+		addr, err := z.parseInstance(data)
+		if err != nil {
+			z.logger.Debugf("Ignoring instance %s of app %s: %v", child, appID, err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+
+	z.cacheLock.Lock()
+	z.cache[appID] = addrs
+	z.cacheLock.Unlock()
+
+	z.wg.Add(1)
+	go z.invalidateOnEvent(appID, watch)
+
+	return addrs, nil
+}
+
+// Returns the address of the Dapr sidecar from the data of an instance znode.
+func (z *resolver) parseInstance(data []byte) (string, error) {
+	inst := serviceInstance{}
+	err := json.Unmarshal(data, &inst)
+	if err != nil {
+		return "", fmt.Errorf("invalid data: %w", err)
+	}
+	if inst.Address == "" {
+		return "", errors.New("address is empty")
+	}
+	if inst.Payload == nil || inst.Payload.Metadata[z.metadata.DaprPortMetaKey] == "" {
+		return "", fmt.Errorf("%s missing from metadata", z.metadata.DaprPortMetaKey)
+	}
+	return net.JoinHostPort(inst.Address, inst.Payload.Metadata[z.metadata.DaprPortMetaKey]), nil
+}
+
+// Invalidates the cache for the app when the watch fires.
+// Should be invoked in a background goroutine
+func (z *resolver) invalidateOnEvent(appID string, watch <-chan zk.Event) {
+	defer z.wg.Done()
+
+	select {
+	case <-watch:
+		z.cacheLock.Lock()
+		delete(z.cache, appID)
+		z.cacheLock.Unlock()
+	case <-z.closeCh:
+	}
+}
+
+// Invalidates the cache for all apps.
+func (z *resolver) invalidateAll() {
+	z.cacheLock.Lock()
+	z.cache = map[string]nameresolution.AddressList{}
+	z.cacheLock.Unlock()
+}
+
+// Close implements io.Closer.
+func (z *resolver) Close() error {
+	if !z.closed.CompareAndSwap(false, true) {
+		z.wg.Wait()
+		return nil
+	}
+
+	close(z.closeCh)
+
+	// Ensures no load is in progress, so no more goroutines are started
+	z.loadLock.Lock()
+	//nolint:staticcheck
+	z.loadLock.Unlock()
+
+	z.wg.Wait()
+
+	if z.conn == nil {
+		return nil
+	}
+
+	var err error
+	if z.instanceID != "" {
+		err = z.conn.Delete(z.metadata.servicePath(z.metadata.appID)+"/"+z.instanceID, -1)
+		if err != nil && !errors.Is(err, zk.ErrNoNode) {
+			err = fmt.Errorf("failed to unregister host: %w", err)
+		} else {
+			err = nil
+		}
+	}
+
+	z.conn.Close()
+
+	return err
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInitWithMetadata(t *testing.T) {
+	instance := nameresolution.Instance{
+		AppID:            "myapp",
+		Address:          "10.0.0.1",
+		DaprInternalPort: 50002,
+		AppPort:          8080,
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		m := zookeeperMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance:      instance,
+			Configuration: map[string]string{"servers": "zk1:2181, zk2:2181,"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"zk1:2181", "zk2:2181"}, m.servers)
+		assert.Equal(t, defaultBasePath, m.BasePath)
+		assert.Equal(t, defaultDaprPortMetaKey, m.DaprPortMetaKey)
+		assert.Equal(t, defaultSessionTimeout, m.SessionTimeout)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, "/services/myapp", m.servicePath("myapp"))
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		m := zookeeperMetadata{}
+		err := m.InitWithMetadata(nameresolution.Metadata{
+			Instance: instance,
+			Configuration: map[string]string{
+				"servers":         "zk1:2181",
+				"basePath":        "/dapr/services/",
+				"daprPortMetaKey": "daprPort",
+				"sessionTimeout":  "30s",
+				"timeout":         "2s",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/dapr/services", m.BasePath)
+		assert.Equal(t, "daprPort", m.DaprPortMetaKey)
+		assert.Equal(t, 30*time.Second, m.SessionTimeout)
+		assert.Equal(t, 2*time.Second, m.Timeout)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]struct {
+			instance nameresolution.Instance
+			config   map[string]string
+			err      string
+		}{
+			"missing app ID": {
+				instance: nameresolution.Instance{Address: "10.0.0.1", DaprInternalPort: 50002},
+				config:   map[string]string{"servers": "zk1:2181"},
+				err:      "name is missing",
+			},
+			"missing servers": {
+				instance: instance,
+				config:   map[string]string{},
+				err:      "missing required configuration property 'servers'",
+			},
+			"relative base path": {
+				instance: instance,
+				config:   map[string]string{"servers": "zk1:2181", "basePath": "services"},
+				err:      "configuration property 'basePath' must be an absolute path",
+			},
+			"root base path": {
+				instance: instance,
+				config:   map[string]string{"servers": "zk1:2181", "basePath": "/"},
+				err:      "configuration property 'basePath' must not be the root path",
+			},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				m := zookeeperMetadata{}
+				err := m.InitWithMetadata(nameresolution.Metadata{
+					Instance:      tt.instance,
+					Configuration: tt.config,
+				})
+				require.EqualError(t, err, tt.err)
+			})
+		}
+	})
+}
+
+func TestResolver(t *testing.T) {
+	fake := newFakeZK()
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	r.connect = fake.connect
+
+	err := r.Init(context.Background(), nameresolution.Metadata{
+		Instance: nameresolution.Instance{
+			AppID:            "myapp",
+			Address:          "10.0.0.1",
+			DaprInternalPort: 50002,
+			AppPort:          8080,
+		},
+		Configuration: map[string]string{"servers": "zk1:2181"},
+	})
+	require.NoError(t, err)
+
+	t.Run("registers host", func(t *testing.T) {
+		data, ok := fake.node("/services/myapp/" + r.instanceID)
+		require.True(t, ok)
+		assert.True(t, fake.isEphemeral("/services/myapp/"+r.instanceID))
+
+		inst := serviceInstance{}
+		require.NoError(t, json.Unmarshal(data, &inst))
+		assert.Equal(t, "myapp", inst.Name)
+		assert.Equal(t, r.instanceID, inst.ID)
+		assert.Equal(t, "10.0.0.1", inst.Address)
+		require.NotNil(t, inst.Port)
+		assert.Equal(t, 8080, *inst.Port)
+		assert.Equal(t, "DYNAMIC", inst.ServiceType)
+		require.NotNil(t, inst.Payload)
+		assert.Equal(t, payloadClass, inst.Payload.Class)
+		assert.Equal(t, "50002", inst.Payload.Metadata["DAPR_PORT"])
+	})
+
+	t.Run("resolves registered host", func(t *testing.T) {
+		addr, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1:50002", addr)
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		_, err := r.ResolveID(context.Background(), nameresolution.ResolveRequest{ID: "otherapp"})
+		require.ErrorIs(t, err, ErrNoHost)
+	})
+
+	t.Run("cache is invalidated when instances change", func(t *testing.T) {
+		fake.add("/services/otherapp/a", `{"name":"otherapp","id":"a","address":"10.0.0.2","port":80,"payload":{"metadata":{"DAPR_PORT":"50001"}}}`)
+		// Instances without the Dapr port are ignored
+		fake.add("/services/otherapp/b", `{"name":"otherapp","id":"b","address":"10.0.0.3","port":80,"payload":{"metadata":{}}}`)
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			addrs, err := r.ResolveIDMulti(context.Background(), nameresolution.ResolveRequest{ID: "otherapp"})
+			if assert.NoError(c, err) {
+				assert.Equal(c, nameresolution.AddressList{"10.0.0.2:50001"}, addrs)
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+
+		fake.add("/services/otherapp/c", `{"name":"otherapp","id":"c","address":"fd00::4","port":80,"payload":{"metadata":{"DAPR_PORT":"50001"}}}`)
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			addrs, err := r.ResolveIDMulti(context.Background(), nameresolution.ResolveRequest{ID: "otherapp"})
+			if assert.NoError(c, err) {
+				assert.ElementsMatch(c, nameresolution.AddressList{"10.0.0.2:50001", "[fd00::4]:50001"}, addrs)
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("registers again after session expires", func(t *testing.T) {
+		fake.expireSession()
+
+		assert.Eventually(t, func() bool {
+			_, ok := fake.node("/services/myapp/" + r.instanceID)
+			return ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("close removes registration", func(t *testing.T) {
+		require.NoError(t, r.Close())
+		_, ok := fake.node("/services/myapp/" + r.instanceID)
+		assert.False(t, ok)
+		assert.True(t, fake.isClosed())
+
+		err := r.Init(context.Background(), nameresolution.Metadata{})
+		require.EqualError(t, err, "component is closed")
+	})
+}
+
+// fakeZK is an in-memory implementation of zkConn.
+type fakeZK struct {
+	lock      sync.Mutex
+	nodes     map[string][]byte
+	ephemeral map[string]bool
+	watches   map[string][]chan zk.Event
+	events    chan zk.Event
+	closed    bool
+}
+
+func newFakeZK() *fakeZK {
+	return &fakeZK{
+		nodes:     map[string][]byte{},
+		ephemeral: map[string]bool{},
+		watches:   map[string][]chan zk.Event{},
+		events:    make(chan zk.Event, 10),
+	}
+}
+
+func (f *fakeZK) connect(servers []string, sessionTimeout time.Duration) (zkConn, <-chan zk.Event, error) {
+	f.events <- zk.Event{Type: zk.EventSession, State: zk.StateConnected}
+	f.events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+	return f, f.events, nil
+}
+
+func (f *fakeZK) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	parent := path.Dir(p)
+	if _, ok := f.nodes[parent]; !ok && parent != "/" {
+		return "", zk.ErrNoNode
+	}
+	f.nodes[p] = data
+	f.ephemeral[p] = flags&zk.FlagEphemeral != 0
+	f.fire(p)
+	f.fire(parent)
+	return p, nil
+}
+
+func (f *fakeZK) Delete(p string, version int32) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.nodes[p]; !ok {
+		return zk.ErrNoNode
+	}
+	delete(f.nodes, p)
+	delete(f.ephemeral, p)
+	f.fire(path.Dir(p))
+	return nil
+}
+
+func (f *fakeZK) Get(p string) ([]byte, *zk.Stat, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	data, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return data, &zk.Stat{}, nil
+}
+
+func (f *fakeZK) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.nodes[p]; !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	children := []string{}
+	for n := range f.nodes {
+		if path.Dir(n) == p {
+			children = append(children, strings.TrimPrefix(n, p+"/"))
+		}
+	}
+	return children, &zk.Stat{}, f.watch(p), nil
+}
+
+func (f *fakeZK) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	_, ok := f.nodes[p]
+	return ok, &zk.Stat{}, f.watch(p), nil
+}
+
+func (f *fakeZK) Close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+}
+
+// Must be invoked while holding the lock.
+func (f *fakeZK) watch(p string) <-chan zk.Event {
+	ch := make(chan zk.Event, 1)
+	f.watches[p] = append(f.watches[p], ch)
+	return ch
+}
+
+// Must be invoked while holding the lock.
+func (f *fakeZK) fire(p string) {
+	for _, ch := range f.watches[p] {
+		ch <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: p}
+	}
+	delete(f.watches, p)
+}
+
+// Adds a node, creating its parents.
+func (f *fakeZK) add(p string, data string) {
+	parts := strings.Split(strings.TrimPrefix(path.Dir(p), "/"), "/")
+	cur := ""
+	for _, part := range parts {
+		cur += "/" + part
+		_, _ = f.Create(cur, nil, 0, nil)
+	}
+	_, _ = f.Create(p, []byte(data), 0, nil)
+}
+
+func (f *fakeZK) node(p string) ([]byte, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	data, ok := f.nodes[p]
+	return data, ok
+}
+
+func (f *fakeZK) isEphemeral(p string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.ephemeral[p]
+}
+
+func (f *fakeZK) isClosed() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.closed
+}
+
+// Simulates the expiration of the session, which removes the ephemeral nodes.
+func (f *fakeZK) expireSession() {
+	f.lock.Lock()
+	for p, eph := range f.ephemeral {
+		if eph {
+			delete(f.nodes, p)
+			delete(f.ephemeral, p)
+			f.fire(path.Dir(p))
+		}
+	}
+	f.lock.Unlock()
+
+	f.events <- zk.Event{Type: zk.EventSession, State: zk.StateExpired}
+	f.events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+}
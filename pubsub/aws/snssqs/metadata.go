@@ -36,6 +36,11 @@ type snsSqsMetadata struct {
 	// published messages would be ordered by their arrival time to SQS.
 	// see: https://aws.amazon.com/blogs/compute/solving-complex-ordering-challenges-with-amazon-sqs-fifo-queues/
 	FifoMessageGroupID string `mapstructure:"fifoMessageGroupID"`
+	// how the Message Group ID of published messages is assigned when fifo is enabled. "static" (default) uses fifoMessageGroupID for all messages,
+	// "topic" uses a group per publisher and topic, "partitionKey" uses the partitionKey metadata of each message.
+	FifoMessageGroupIDStrategy string `mapstructure:"fifoMessageGroupIDStrategy" mdallowedvalues:"static,topic,partitionKey"`
+	// enables content-based deduplication on the FIFO topics and queues created by the component. Default: true.
+	FifoContentBasedDeduplication bool `mapstructure:"fifoContentBasedDeduplication"`
 	// amount of time in seconds that a message is hidden from receive requests after it is sent to a subscriber. Default: 10.
 	MessageVisibilityTimeout int64 `mapstructure:"messageVisibilityTimeout"`
 	// number of times to resend a message after processing of that message fails before removing that message from the queue. Default: 10.
@@ -77,6 +82,8 @@ func (s *snsSqs) getSnsSqsMetadata(meta pubsub.Metadata) (*snsSqsMetadata, error
 		MessageRetryLimit:              10,
 		MessageWaitTimeSeconds:         2,
 		MessageMaxNumber:               10,
+		FifoMessageGroupIDStrategy:     fifoMessageGroupIDStrategyStatic,
+		FifoContentBasedDeduplication:  true,
 	}
 	upgradeMetadata(&meta)
 	err := metadata.DecodeMetadata(meta.Properties, md)
@@ -123,6 +130,14 @@ func (s *snsSqs) getSnsSqsMetadata(meta pubsub.Metadata) (*snsSqsMetadata, error
 		md.FifoMessageGroupID = meta.Properties[pubsub.RuntimeConsumerIDKey]
 	}
 
+	switch md.FifoMessageGroupIDStrategy {
+	case fifoMessageGroupIDStrategyStatic, fifoMessageGroupIDStrategyTopic, fifoMessageGroupIDStrategyPartitionKey:
+	case "":
+		md.FifoMessageGroupIDStrategy = fifoMessageGroupIDStrategyStatic
+	default:
+		return nil, fmt.Errorf("fifoMessageGroupIDStrategy must be one of %q, %q or %q", fifoMessageGroupIDStrategyStatic, fifoMessageGroupIDStrategyTopic, fifoMessageGroupIDStrategyPartitionKey)
+	}
+
 	if md.MessageMaxNumber < 1 {
 		return nil, errors.New("messageMaxNumber must be greater than 0")
 	} else if md.MessageMaxNumber > 10 {
@@ -57,6 +57,11 @@ type snsSqs struct {
 	backOffConfig       retry.Config
 	subscriptionManager SubscriptionManagement
 	closed              atomic.Bool
+
+	// Tracks the messages being processed, which are drained before closing
+	handlersWG sync.WaitGroup
+	drainLock  sync.Mutex
+	draining   bool
 }
 
 type sqsQueueInfo struct {
@@ -81,6 +86,15 @@ const (
 	maxAWSNameLength                      = 80
 	assetsManagementDefaultTimeoutSeconds = 5.0
 	awsAccountIDLength                    = 12
+
+	// Strategies to assign the Message Group ID of messages published to FIFO topics.
+	fifoMessageGroupIDStrategyStatic       = "static"
+	fifoMessageGroupIDStrategyTopic        = "topic"
+	fifoMessageGroupIDStrategyPartitionKey = "partitionKey"
+
+	// Metadata keys of publish requests.
+	partitionKeyMetadataKey    = "partitionKey"
+	deduplicationIDMetadataKey = "deduplicationId"
 )
 
 // NewSnsSqs - constructor for a new snssqs dapr component.
@@ -212,7 +226,7 @@ func (s *snsSqs) createTopic(parentCtx context.Context, topic string) (string, e
 	}
 
 	if s.metadata.Fifo {
-		attributes := map[string]*string{"FifoTopic": aws.String("true"), "ContentBasedDeduplication": aws.String("true")}
+		attributes := map[string]*string{"FifoTopic": aws.String("true"), "ContentBasedDeduplication": aws.String(strconv.FormatBool(s.metadata.FifoContentBasedDeduplication))}
 		snsCreateTopicInput.SetAttributes(attributes)
 	}
 	ctx, cancelFn := context.WithTimeout(parentCtx, s.opsTimeout)
@@ -291,7 +305,7 @@ func (s *snsSqs) createQueue(parentCtx context.Context, queueName string) (*sqsQ
 	}
 
 	if s.metadata.Fifo {
-		attributes := map[string]*string{"FifoQueue": aws.String("true"), "ContentBasedDeduplication": aws.String("true")}
+		attributes := map[string]*string{"FifoQueue": aws.String("true"), "ContentBasedDeduplication": aws.String(strconv.FormatBool(s.metadata.FifoContentBasedDeduplication))}
 		sqsCreateQueueInput.SetAttributes(attributes)
 	}
 
@@ -376,9 +390,23 @@ func (s *snsSqs) getOrCreateQueue(ctx context.Context, queueName string) (*sqsQu
 }
 
 func (s *snsSqs) getMessageGroupID(req *pubsub.PublishRequest) *string {
+	switch s.metadata.FifoMessageGroupIDStrategy {
+	case fifoMessageGroupIDStrategyPartitionKey:
+		// messages with the same partition key are ordered; messages without one fall back to the static Message Group ID.
+		if key := req.Metadata[partitionKeyMetadataKey]; key != "" {
+			return &key
+		}
+	case fifoMessageGroupIDStrategyTopic:
+		return s.topicMessageGroupID(req)
+	}
+
 	if len(s.metadata.FifoMessageGroupID) > 0 {
 		return &s.metadata.FifoMessageGroupID
 	}
+	return s.topicMessageGroupID(req)
+}
+
+func (s *snsSqs) topicMessageGroupID(req *pubsub.PublishRequest) *string {
 	// each daprd, of a given PubSub, of a given publisher application publishes to a message group ID of its own.
 	// for example: for a daprd serving the SNS/SQS Pubsub component we generate a unique id -> A; that component serves on behalf
 	// of a given PubSub deployment name B, and component A publishes to SNS on behalf of a dapr application named C (effectively to topic C).
@@ -387,6 +415,24 @@ func (s *snsSqs) getMessageGroupID(req *pubsub.PublishRequest) *string {
 	return &fifoMessageGroupID
 }
 
+// getMessageDeduplicationID returns the Message Deduplication ID of a message published to a FIFO topic.
+// A deduplication ID set in the request takes precedence over content-based deduplication.
+// Without content-based deduplication, a unique ID is generated if the request doesn't have one, so messages are never deduplicated.
+func (s *snsSqs) getMessageDeduplicationID(req *pubsub.PublishRequest) (*string, error) {
+	if id := req.Metadata[deduplicationIDMetadataKey]; id != "" {
+		return &id, nil
+	}
+	if s.metadata.FifoContentBasedDeduplication {
+		return nil, nil
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed generating message deduplication id: %w", err)
+	}
+	return &id, nil
+}
+
 func (s *snsSqs) createSnsSqsSubscription(parentCtx context.Context, queueArn, topicArn string) (string, error) {
 	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
 	subscribeOutput, err := s.authProvider.SnsSqs().Sns.SubscribeWithContext(ctx, &sns.SubscribeInput{
@@ -603,8 +649,8 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 	}
 
 	for {
-		// If the context is canceled, stop requesting messages
-		if ctx.Err() != nil {
+		// If the context is canceled or the component is closing, stop requesting messages
+		if ctx.Err() != nil || s.isDraining() {
 			break
 		}
 		// Internally, by default, aws go sdk performs 3 retries with exponential backoff to contact
@@ -643,13 +689,18 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 			}
 
 			f := func(message *sqs.Message) {
+				defer s.handlersWG.Done()
 				if err := s.callHandler(ctx, message, queueInfo); err != nil {
 					s.logger.Errorf("error while handling received message. error is: %v", err)
 				}
 			}
 
+			// When closing, the remaining messages are not processed and become visible again after the visibility timeout
 			switch s.metadata.ConcurrencyMode {
 			case pubsub.Single:
+				if !s.startHandler() {
+					return
+				}
 				f(message)
 			case pubsub.Parallel:
 				// This is the back pressure mechanism.
@@ -657,6 +708,12 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 				if sem != nil {
 					sem <- struct{}{}
 				}
+				if !s.startHandler() {
+					if sem != nil {
+						<-sem
+					}
+					return
+				}
 
 				go func(message *sqs.Message) {
 					if sem != nil {
@@ -865,6 +922,10 @@ func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 	}
 	if s.metadata.Fifo {
 		snsPublishInput.MessageGroupId = s.getMessageGroupID(req)
+		snsPublishInput.MessageDeduplicationId, err = s.getMessageDeduplicationID(req)
+		if err != nil {
+			return err
+		}
 	}
 
 	// sns client has internal exponential backoffs.
@@ -879,10 +940,51 @@ func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 	return nil
 }
 
+// startHandler registers a message being processed, returning false if the component is closing.
+func (s *snsSqs) startHandler() bool {
+	s.drainLock.Lock()
+	defer s.drainLock.Unlock()
+	if s.draining {
+		return false
+	}
+	s.handlersWG.Add(1)
+	return true
+}
+
+func (s *snsSqs) isDraining() bool {
+	s.drainLock.Lock()
+	defer s.drainLock.Unlock()
+	return s.draining
+}
+
 // Close should always be called to release the resources used by the SNS/SQS
-// client. Blocks until all goroutines have returned.
+// client. Blocks until all goroutines have returned, waiting up to
+// pubsub.DefaultCloseTimeout for the messages being processed.
 func (s *snsSqs) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return s.CloseWithContext(ctx)
+}
+
+// CloseWithContext stops receiving new messages and waits for the ones being processed
+// to be acknowledged, or until ctx is done, before stopping the subscriptions.
+func (s *snsSqs) CloseWithContext(ctx context.Context) error {
 	if s.closed.CompareAndSwap(false, true) {
+		s.drainLock.Lock()
+		s.draining = true
+		s.drainLock.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			s.handlersWG.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			s.logger.Warnf("Timed out waiting for the SQS messages being processed: %v", ctx.Err())
+		}
+
 		s.subscriptionManager.Close()
 	}
 
@@ -115,6 +115,8 @@ func Test_getSnsSqsMetadata_defaults(t *testing.T) {
 	r.False(md.DisableEntityManagement)
 	r.EqualValues(float64(5), md.AssetsManagementTimeoutSeconds)
 	r.False(md.DisableDeleteOnRetryLimit)
+	r.Equal(fifoMessageGroupIDStrategyStatic, md.FifoMessageGroupIDStrategy)
+	r.True(md.FifoContentBasedDeduplication)
 }
 
 func Test_getSnsSqsMetadata_legacyaliases(t *testing.T) {
@@ -290,6 +292,19 @@ func Test_getSnsSqsMetadata_invalidMetadataSetup(t *testing.T) {
 			}}},
 			name: "invalid message concurrencyLimit",
 		},
+		{
+			metadata: pubsub.Metadata{Base: metadata.Base{Properties: map[string]string{
+				"consumerID":                 "consumer",
+				"Endpoint":                   "endpoint",
+				"AccessKey":                  "acctId",
+				"SecretKey":                  "secret",
+				"awsToken":                   "token",
+				"Region":                     "region",
+				"fifo":                       "true",
+				"fifoMessageGroupIDStrategy": "random",
+			}}},
+			name: "invalid fifo message group ID strategy",
+		},
 	}
 
 	l := logger.NewLogger("SnsSqs unit test")
@@ -508,3 +523,71 @@ func Test_buildARN_NonStandardPartition(t *testing.T) {
 	arn := ps.buildARN("sns", "myTopic")
 	r.Equal("arn:aws-cn:sns:cn-northwest-1:123456789012:myTopic", arn)
 }
+
+func Test_getMessageGroupID(t *testing.T) {
+	t.Parallel()
+
+	req := &pubsub.PublishRequest{
+		PubsubName: "pubsub",
+		Topic:      "orders",
+		Metadata:   map[string]string{"partitionKey": "account-1"},
+	}
+
+	t.Run("static", func(t *testing.T) {
+		s := snsSqs{id: "id", metadata: &snsSqsMetadata{
+			FifoMessageGroupID:         "group",
+			FifoMessageGroupIDStrategy: fifoMessageGroupIDStrategyStatic,
+		}}
+		require.Equal(t, "group", *s.getMessageGroupID(req))
+	})
+
+	t.Run("topic", func(t *testing.T) {
+		s := snsSqs{id: "id", metadata: &snsSqsMetadata{
+			FifoMessageGroupID:         "group",
+			FifoMessageGroupIDStrategy: fifoMessageGroupIDStrategyTopic,
+		}}
+		require.Equal(t, "id:pubsub:orders", *s.getMessageGroupID(req))
+	})
+
+	t.Run("partition key", func(t *testing.T) {
+		s := snsSqs{id: "id", metadata: &snsSqsMetadata{
+			FifoMessageGroupID:         "group",
+			FifoMessageGroupIDStrategy: fifoMessageGroupIDStrategyPartitionKey,
+		}}
+		require.Equal(t, "account-1", *s.getMessageGroupID(req))
+
+		// Falls back to the static group without a partition key
+		require.Equal(t, "group", *s.getMessageGroupID(&pubsub.PublishRequest{Topic: "orders"}))
+	})
+}
+
+func Test_getMessageDeduplicationID(t *testing.T) {
+	t.Parallel()
+
+	t.Run("content-based deduplication", func(t *testing.T) {
+		s := snsSqs{metadata: &snsSqsMetadata{FifoContentBasedDeduplication: true}}
+
+		id, err := s.getMessageDeduplicationID(&pubsub.PublishRequest{})
+		require.NoError(t, err)
+		require.Nil(t, id)
+
+		id, err = s.getMessageDeduplicationID(&pubsub.PublishRequest{Metadata: map[string]string{"deduplicationId": "d1"}})
+		require.NoError(t, err)
+		require.Equal(t, "d1", *id)
+	})
+
+	t.Run("without content-based deduplication", func(t *testing.T) {
+		s := snsSqs{metadata: &snsSqsMetadata{FifoContentBasedDeduplication: false}}
+
+		id1, err := s.getMessageDeduplicationID(&pubsub.PublishRequest{})
+		require.NoError(t, err)
+		require.NotEmpty(t, *id1)
+		id2, err := s.getMessageDeduplicationID(&pubsub.PublishRequest{})
+		require.NoError(t, err)
+		require.NotEqual(t, *id1, *id2)
+
+		id, err := s.getMessageDeduplicationID(&pubsub.PublishRequest{Metadata: map[string]string{"deduplicationId": "d1"}})
+		require.NoError(t, err)
+		require.Equal(t, "d1", *id)
+	})
+}
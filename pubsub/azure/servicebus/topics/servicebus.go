@@ -205,6 +205,11 @@ func (a *azureServiceBus) doSubscribe(
 	return nil
 }
 
+// Ping checks the connection to the Service Bus namespace.
+func (a *azureServiceBus) Ping(ctx context.Context) error {
+	return a.client.Ping(ctx)
+}
+
 func (a *azureServiceBus) Close() (err error) {
 	defer a.wg.Wait()
 	if !a.closed.CompareAndSwap(false, true) {
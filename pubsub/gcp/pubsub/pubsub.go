@@ -61,6 +61,10 @@ type GCPPubSub struct {
 	wg         sync.WaitGroup
 	topicCache map[string]cacheEntry
 	lock       *sync.RWMutex
+
+	// Topics used for publishing are kept for the lifetime of the component, as the client orders messages and pauses ordering keys per Topic object
+	publishTopics     map[string]*gcppubsub.Topic
+	publishTopicsLock sync.Mutex
 }
 
 type cacheEntry struct {
@@ -89,10 +93,11 @@ const topicCacheRefreshInterval = 5 * time.Hour
 // NewGCPPubSub returns a new GCPPubSub instance.
 func NewGCPPubSub(logger logger.Logger) pubsub.PubSub {
 	client := &GCPPubSub{
-		logger:     logger,
-		closeCh:    make(chan struct{}),
-		topicCache: make(map[string]cacheEntry),
-		lock:       &sync.RWMutex{},
+		logger:        logger,
+		closeCh:       make(chan struct{}),
+		topicCache:    make(map[string]cacheEntry),
+		lock:          &sync.RWMutex{},
+		publishTopics: make(map[string]*gcppubsub.Topic),
 	}
 	return client
 }
@@ -241,7 +246,7 @@ func (g *GCPPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) err
 		g.lock.Unlock()
 	}
 
-	topic := g.getTopic(req.Topic)
+	topic := g.getPublishTopic(req.Topic)
 
 	msg := &gcppubsub.Message{
 		Data: req.Data,
@@ -251,7 +256,6 @@ func (g *GCPPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) err
 	// use the provided OrderingKey giving
 	// preference to the OrderingKey at the request level
 	if g.metadata.EnableMessageOrdering {
-		topic.EnableMessageOrdering = g.metadata.EnableMessageOrdering
 		msgOrderingKey := g.metadata.OrderingKey
 		if req.Metadata != nil && req.Metadata[metedataOrderingKeyKey] != "" {
 			msgOrderingKey = req.Metadata[metedataOrderingKeyKey]
@@ -260,6 +264,11 @@ func (g *GCPPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) err
 		g.logger.Infof("Message Ordering Key: %s", msg.OrderingKey)
 	}
 	_, err := topic.Publish(ctx, msg).Get(ctx)
+	if err != nil && msg.OrderingKey != "" {
+		// After a failure, the client stops accepting messages for the ordering key to preserve the order.
+		// Resume it so the next publish requests (including retries of this one) are not rejected.
+		topic.ResumePublish(msg.OrderingKey)
+	}
 
 	return err
 }
@@ -378,12 +387,7 @@ func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, topic
 			}
 
 			err := handler(ctx, msg)
-
-			if err == nil {
-				m.Ack()
-			} else {
-				m.Nack()
-			}
+			g.settleMessage(ctx, sub, m, err)
 		})
 
 		g.logger.Infof("Lost connection to subscription %s", sub.ID())
@@ -414,6 +418,42 @@ func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, topic
 	return receiveErr
 }
 
+// settleMessage acks the message if the handler succeeded, or nacks it otherwise.
+// With exactly-once delivery, it waits for the server to confirm the ack or nack, as only a confirmed ack guarantees the message is not redelivered.
+func (g *GCPPubSub) settleMessage(ctx context.Context, sub *gcppubsub.Subscription, m *gcppubsub.Message, handlerErr error) {
+	if !g.metadata.EnableExactlyOnceDelivery {
+		if handlerErr == nil {
+			m.Ack()
+		} else {
+			m.Nack()
+		}
+		return
+	}
+
+	var res *gcppubsub.AckResult
+	if handlerErr == nil {
+		res = m.AckWithResult()
+	} else {
+		res = m.NackWithResult()
+	}
+	status, err := res.Get(ctx)
+	if err == nil && status == gcppubsub.AcknowledgeStatusSuccess {
+		return
+	}
+
+	operation := "ack"
+	if handlerErr != nil {
+		operation = "nack"
+	}
+	switch status {
+	case gcppubsub.AcknowledgeStatusInvalidAckID:
+		// The ack deadline expired before the server received the ack: the message will be redelivered
+		g.logger.Warnf("%s failed to %s message %s on subscription %s: ack ID is no longer valid, the message will be redelivered", errorMessagePrefix, operation, m.ID, sub.ID())
+	default:
+		g.logger.Errorf("%s failed to %s message %s on subscription %s (status %d): %v", errorMessagePrefix, operation, m.ID, sub.ID(), status, err)
+	}
+}
+
 func (g *GCPPubSub) ensureTopic(parentCtx context.Context, topic string) error {
 	entity := g.getTopic(topic)
 	exists, err := entity.Exists(parentCtx)
@@ -437,6 +477,20 @@ func (g *GCPPubSub) getTopic(topic string) *gcppubsub.Topic {
 	return g.client.Topic(topic)
 }
 
+// getPublishTopic returns the Topic object used to publish to a topic, creating it the first time.
+func (g *GCPPubSub) getPublishTopic(topic string) *gcppubsub.Topic {
+	g.publishTopicsLock.Lock()
+	defer g.publishTopicsLock.Unlock()
+
+	entity, ok := g.publishTopics[topic]
+	if !ok {
+		entity = g.getTopic(topic)
+		entity.EnableMessageOrdering = g.metadata.EnableMessageOrdering
+		g.publishTopics[topic] = entity
+	}
+	return entity
+}
+
 func (g *GCPPubSub) ensureSubscription(parentCtx context.Context, subscription string, topic string) error {
 	g.lock.RLock()
 	_, topicOK := g.topicCache[topic]
@@ -463,9 +517,10 @@ func (g *GCPPubSub) ensureSubscription(parentCtx context.Context, subscription s
 	exists, subErr := entity.Exists(parentCtx)
 	if !exists {
 		subConfig := gcppubsub.SubscriptionConfig{
-			AckDeadline:           g.metadata.AckDeadline,
-			Topic:                 g.getTopic(topic),
-			EnableMessageOrdering: g.metadata.EnableMessageOrdering,
+			AckDeadline:               g.metadata.AckDeadline,
+			Topic:                     g.getTopic(topic),
+			EnableMessageOrdering:     g.metadata.EnableMessageOrdering,
+			EnableExactlyOnceDelivery: g.metadata.EnableExactlyOnceDelivery,
 		}
 
 		if g.metadata.DeadLetterTopic != "" && !dlTopicOK {
@@ -506,6 +561,15 @@ func (g *GCPPubSub) Close() error {
 	if g.closed.CompareAndSwap(false, true) {
 		close(g.closeCh)
 	}
+
+	// Flush the messages buffered by the publishers
+	g.publishTopicsLock.Lock()
+	for _, topic := range g.publishTopics {
+		topic.Stop()
+	}
+	clear(g.publishTopics)
+	g.publishTopicsLock.Unlock()
+
 	return g.client.Close()
 }
 
@@ -14,13 +14,21 @@ limitations under the License.
 package pubsub
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/pubsub/pstest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
 )
 
 const (
@@ -293,3 +301,88 @@ func TestInit(t *testing.T) {
 		assert.Equal(t, defaultAckDeadline, md.AckDeadline, "Should use the default AckDeadline when none is specified")
 	})
 }
+
+func initTestPubSub(t *testing.T, props map[string]string) (*GCPPubSub, *pstest.Server) {
+	t.Helper()
+
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+	// Init sets PUBSUB_EMULATOR_HOST, t.Setenv restores it at the end of the test
+	t.Setenv("PUBSUB_EMULATOR_HOST", "")
+
+	props["projectId"] = "test-project"
+	props["consumerID"] = "consumer"
+	props["endpoint"] = srv.Addr
+	g := NewGCPPubSub(logger.NewLogger("test")).(*GCPPubSub)
+	err := g.Init(context.Background(), pubsub.Metadata{Base: contribMetadata.Base{Properties: props}})
+	require.NoError(t, err)
+	t.Cleanup(func() { g.Close() })
+
+	return g, srv
+}
+
+func TestExactlyOnceDelivery(t *testing.T) {
+	g, srv := initTestPubSub(t, map[string]string{
+		"enableExactlyOnceDelivery": "true",
+	})
+
+	var calls atomic.Int32
+	received := make(chan string, 2)
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- string(msg.Data)
+		// Fail the first delivery so the message is nacked and redelivered
+		if calls.Add(1) == 1 {
+			return errors.New("handler error")
+		}
+		return nil
+	}
+	err := g.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "mytopic"}, handler)
+	require.NoError(t, err)
+
+	sub, err := g.client.Subscription(BuildSubscriptionID("consumer", "mytopic")).Config(context.Background())
+	require.NoError(t, err)
+	assert.True(t, sub.EnableExactlyOnceDelivery)
+
+	err = g.Publish(context.Background(), &pubsub.PublishRequest{Topic: "mytopic", Data: []byte("hello")})
+	require.NoError(t, err)
+
+	for range 2 {
+		select {
+		case data := <-received:
+			assert.Equal(t, "hello", data)
+		case <-time.After(10 * time.Second):
+			t.Fatal("message not delivered")
+		}
+	}
+
+	// The ack is confirmed by the server before the handler call returns
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		msgs := srv.Messages()
+		if assert.Len(c, msgs, 1) {
+			assert.Equal(c, 1, msgs[0].Acks)
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestPublishResumesOrderingKey(t *testing.T) {
+	g, srv := initTestPubSub(t, map[string]string{
+		"enableMessageOrdering": "true",
+		"orderingKey":           "default",
+	})
+
+	srv.SetAutoPublishResponse(false)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "rejected"))
+
+	err := g.Publish(context.Background(), &pubsub.PublishRequest{Topic: "mytopic", Data: []byte("first")})
+	require.Error(t, err)
+
+	// Without resuming, the client would reject any further message with the same ordering key
+	srv.SetAutoPublishResponse(true)
+	err = g.Publish(context.Background(), &pubsub.PublishRequest{Topic: "mytopic", Data: []byte("second")})
+	require.NoError(t, err)
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "second", string(msgs[0].Data))
+	assert.Equal(t, "default", msgs[0].OrderingKey)
+}
@@ -16,36 +16,70 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/dapr/components-contrib/common/component/inmemory"
 	"github.com/dapr/components-contrib/common/eventbus"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
 )
 
+type inMemoryMetadata struct {
+	inmemory.PersistenceMetadata `mapstructure:",squash"`
+	inmemory.FaultMetadata       `mapstructure:",squash"`
+
+	// Probability, between 0 and 1, that the delivery of a message to a subscriber is dropped.
+	FaultDropRate float64 `mapstructure:"faultDropRate"`
+}
+
 type bus struct {
-	bus     eventbus.Bus
-	log     logger.Logger
-	closed  atomic.Bool
-	closeCh chan struct{}
-	wg      sync.WaitGroup
+	bus      eventbus.Bus
+	log      logger.Logger
+	metadata inMemoryMetadata
+	faults   *inmemory.Faults
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+
+	// Messages that were not delivered yet, when persistence is enabled
+	pending     map[uint64]*message
+	pendingLock sync.Mutex
+	lastID      uint64
+	dirty       atomic.Bool
+}
+
+// message is the payload sent over the event bus.
+type message struct {
+	ID    uint64 `json:"id"`
+	Topic string `json:"topic"`
+	Data  []byte `json:"data"`
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
 	return &bus{
 		log:     logger,
 		closeCh: make(chan struct{}),
+		pending: map[uint64]*message{},
 	}
 }
 
 func (a *bus) Close() error {
-	if a.closed.CompareAndSwap(false, true) {
-		close(a.closeCh)
+	if !a.closed.CompareAndSwap(false, true) {
+		return nil
 	}
+	close(a.closeCh)
 	a.wg.Wait()
+
+	if a.metadata.Enabled() {
+		a.dirty.Store(true)
+		return a.saveSnapshot()
+	}
 	return nil
 }
 
@@ -54,17 +88,57 @@ func (a *bus) Features() []pubsub.Feature {
 }
 
 func (a *bus) Init(_ context.Context, metadata pubsub.Metadata) error {
+	err := kitmd.DecodeMetadata(metadata.Properties, &a.metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	err = a.metadata.PersistenceMetadata.Validate()
+	if err != nil {
+		return err
+	}
+	err = inmemory.ValidateRate("faultDropRate", a.metadata.FaultDropRate)
+	if err != nil {
+		return err
+	}
+	a.faults, err = inmemory.NewFaults(a.metadata.FaultMetadata)
+	if err != nil {
+		return err
+	}
+
 	a.bus = eventbus.New(true)
 
+	if a.metadata.Enabled() {
+		err = a.loadSnapshot()
+		if err != nil {
+			return err
+		}
+		if a.metadata.SnapshotInterval > 0 {
+			a.wg.Add(1)
+			go func() {
+				defer a.wg.Done()
+				a.startSnapshotThread()
+			}()
+		}
+	}
+
 	return nil
 }
 
-func (a *bus) Publish(_ context.Context, req *pubsub.PublishRequest) error {
+func (a *bus) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
 	if a.closed.Load() {
 		return errors.New("component is closed")
 	}
 
-	a.bus.Publish(req.Topic, req.Data)
+	err := a.faults.Inject(ctx)
+	if err != nil {
+		return err
+	}
+
+	msg := &message{Topic: req.Topic, Data: req.Data}
+	if a.metadata.Enabled() {
+		a.addPending(msg)
+	}
+	a.bus.Publish(req.Topic, msg)
 
 	return nil
 }
@@ -75,10 +149,15 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 	}
 
 	// For this component we allow built-in retries because it is backed by memory
-	retryHandler := func(data []byte) {
+	retryHandler := func(msg *message) {
+		if a.faults.Happens(a.metadata.FaultDropRate) {
+			a.log.Debugf("Dropping delivery of message on topic %s because of fault injection", msg.Topic)
+			return
+		}
 		for range 10 {
-			handleErr := handler(ctx, &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: req.Metadata})
+			handleErr := handler(ctx, &pubsub.NewMessage{Data: msg.Data, Topic: req.Topic, Metadata: req.Metadata})
 			if handleErr == nil {
+				a.removePending(msg)
 				break
 			}
 			a.log.Error(handleErr)
@@ -95,6 +174,15 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 		return err
 	}
 
+	// Deliver the messages that were retained while there was no subscriber
+	if a.metadata.Enabled() {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.redeliverPending(ctx, req.Topic, retryHandler)
+		}()
+	}
+
 	// Unsubscribe when context is done
 	a.wg.Add(1)
 	go func() {
@@ -114,5 +202,7 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 
 // GetComponentMetadata returns the metadata of the component.
 func (a *bus) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := inMemoryMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.PubSubType)
 	return
 }
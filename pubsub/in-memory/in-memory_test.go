@@ -16,10 +16,14 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/components-contrib/common/component/inmemory"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -102,6 +106,103 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, 5, i)
 }
 
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pubsub.json")
+	md := pubsub.Metadata{}
+	md.Properties = map[string]string{"persistencePath": path}
+
+	// Messages published without subscribers are retained and saved when the component is closed
+	ps := New(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(context.Background(), md))
+	require.NoError(t, ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("1"), Topic: "demo"}))
+	require.NoError(t, ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("2"), Topic: "demo"}))
+	require.NoError(t, ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("3"), Topic: "other"}))
+	require.NoError(t, ps.Close())
+
+	ps = New(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(context.Background(), md))
+
+	ch := make(chan []byte)
+	require.NoError(t, ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	}))
+	assert.Equal(t, "1", string(<-ch))
+	assert.Equal(t, "2", string(<-ch))
+
+	require.NoError(t, ps.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("4"), Topic: "demo"}))
+	assert.Equal(t, "4", string(<-ch))
+
+	// Wait for the delivered messages to be acknowledged
+	assert.Eventually(t, func() bool {
+		return len(ps.(*bus).pendingMessages("demo")) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, ps.Close())
+
+	// Only the message that was never delivered is left
+	var snapshot pubsubSnapshot
+	found, err := inmemory.ReadSnapshot(path, &snapshot)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, snapshot.Messages, 1)
+	assert.Equal(t, "other", snapshot.Messages[0].Topic)
+	assert.Equal(t, "3", string(snapshot.Messages[0].Data))
+}
+
+func TestTopicMatches(t *testing.T) {
+	assert.True(t, topicMatches("demo", "demo"))
+	assert.True(t, topicMatches("topic*", "topic1"))
+	assert.False(t, topicMatches("topic*", "topic"))
+	assert.False(t, topicMatches("topic*", "mytopic"))
+	assert.False(t, topicMatches("demo", "demo2"))
+}
+
+func TestFaultInjection(t *testing.T) {
+	t.Run("invalid options", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{"faultErrorRate": "-1"},
+			{"faultDropRate": "1.1"},
+			{"faultLatency": "-1s"},
+			{"snapshotInterval": "-1s"},
+		} {
+			md := pubsub.Metadata{}
+			md.Properties = props
+			require.Errorf(t, New(logger.NewLogger("test")).Init(context.Background(), md), "expected error for %v", props)
+		}
+	})
+
+	t.Run("publish fails", func(t *testing.T) {
+		md := pubsub.Metadata{}
+		md.Properties = map[string]string{"faultErrorRate": "1"}
+		bus := New(logger.NewLogger("test"))
+		require.NoError(t, bus.Init(context.Background(), md))
+		defer bus.Close()
+
+		err := bus.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"})
+		require.ErrorIs(t, err, inmemory.ErrInjectedFault)
+	})
+
+	t.Run("deliveries are dropped", func(t *testing.T) {
+		md := pubsub.Metadata{}
+		md.Properties = map[string]string{"faultDropRate": "1"}
+		bus := New(logger.NewLogger("test"))
+		require.NoError(t, bus.Init(context.Background(), md))
+		defer bus.Close()
+
+		ch := make(chan []byte)
+		require.NoError(t, bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return publish(ch, msg)
+		}))
+		require.NoError(t, bus.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"}))
+
+		select {
+		case <-ch:
+			t.Fatal("message should have been dropped")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
+
 func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 	go func() { ch <- msg.Data }()
 
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/common/component/inmemory"
+)
+
+// pubsubSnapshot is the content of the snapshots saved to disk.
+type pubsubSnapshot struct {
+	Messages []*message `json:"messages"`
+}
+
+// addPending retains a message until it's delivered to a subscriber.
+func (a *bus) addPending(msg *message) {
+	a.pendingLock.Lock()
+	a.lastID++
+	msg.ID = a.lastID
+	a.pending[msg.ID] = msg
+	a.pendingLock.Unlock()
+	a.dirty.Store(true)
+}
+
+// removePending removes a message that was delivered successfully.
+func (a *bus) removePending(msg *message) {
+	if !a.metadata.Enabled() {
+		return
+	}
+	a.pendingLock.Lock()
+	_, ok := a.pending[msg.ID]
+	delete(a.pending, msg.ID)
+	a.pendingLock.Unlock()
+	if ok {
+		a.dirty.Store(true)
+	}
+}
+
+func (a *bus) isPending(msg *message) bool {
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+	_, ok := a.pending[msg.ID]
+	return ok
+}
+
+// pendingMessages returns the pending messages for the topic, in the order they were published.
+func (a *bus) pendingMessages(topic string) []*message {
+	a.pendingLock.Lock()
+	msgs := make([]*message, 0)
+	for _, msg := range a.pending {
+		if topicMatches(topic, msg.Topic) {
+			msgs = append(msgs, msg)
+		}
+	}
+	a.pendingLock.Unlock()
+
+	slices.SortFunc(msgs, func(x, y *message) int {
+		return cmp.Compare(x.ID, y.ID)
+	})
+	return msgs
+}
+
+// redeliverPending delivers the pending messages for the topic to a new subscriber.
+// Messages that are published while this is in progress may be delivered twice.
+func (a *bus) redeliverPending(ctx context.Context, topic string, handler func(msg *message)) {
+	for _, msg := range a.pendingMessages(topic) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.closeCh:
+			return
+		default:
+		}
+		if a.isPending(msg) {
+			handler(msg)
+		}
+	}
+}
+
+// topicMatches returns true if the topic matches the subscription, with the same wildcard rules as the event bus.
+func topicMatches(subscription string, topic string) bool {
+	if subscription == topic {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(subscription, "*")
+	return ok && topic != prefix && strings.HasPrefix(topic, prefix)
+}
+
+// loadSnapshot restores the pending messages from the snapshot saved at the persistence path, if any.
+func (a *bus) loadSnapshot() error {
+	var snapshot pubsubSnapshot
+	found, err := inmemory.ReadSnapshot(a.metadata.PersistencePath, &snapshot)
+	if err != nil || !found {
+		return err
+	}
+
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+	for _, msg := range snapshot.Messages {
+		a.pending[msg.ID] = msg
+		a.lastID = max(a.lastID, msg.ID)
+	}
+	a.log.Infof("Restored %d pending messages from snapshot %s", len(a.pending), a.metadata.PersistencePath)
+	return nil
+}
+
+// saveSnapshot saves the pending messages to the persistence path, if they changed since the last snapshot.
+func (a *bus) saveSnapshot() error {
+	if !a.dirty.Swap(false) {
+		return nil
+	}
+
+	a.pendingLock.Lock()
+	snapshot := pubsubSnapshot{
+		Messages: make([]*message, 0, len(a.pending)),
+	}
+	for _, msg := range a.pending {
+		snapshot.Messages = append(snapshot.Messages, msg)
+	}
+	a.pendingLock.Unlock()
+	slices.SortFunc(snapshot.Messages, func(x, y *message) int {
+		return cmp.Compare(x.ID, y.ID)
+	})
+
+	err := inmemory.WriteSnapshot(a.metadata.PersistencePath, snapshot)
+	if err != nil {
+		// Try again at the next snapshot
+		a.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+func (a *bus) startSnapshotThread() {
+	ticker := time.NewTicker(a.metadata.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := a.saveSnapshot()
+			if err != nil {
+				a.log.Errorf("Failed to save snapshot: %v", err)
+			}
+		case <-a.closeCh:
+			return
+		}
+	}
+}
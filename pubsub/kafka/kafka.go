@@ -16,6 +16,7 @@ package kafka
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -23,6 +24,7 @@ import (
 	"github.com/dapr/kit/logger"
 
 	"github.com/dapr/components-contrib/common/component/kafka"
+	"github.com/dapr/components-contrib/common/metrics"
 	commonutils "github.com/dapr/components-contrib/common/utils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
@@ -38,6 +40,12 @@ type PubSub struct {
 }
 
 func (p *PubSub) Init(ctx context.Context, metadata pubsub.Metadata) error {
+	var err error
+	p.kafka.Metrics, err = metrics.NewRecorder("pubsub.kafka", metadata.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create metrics recorder: %w", err)
+	}
+
 	return p.kafka.Init(ctx, metadata.Properties)
 }
 
@@ -130,12 +138,26 @@ func (p *PubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest
 	return p.kafka.BulkPublish(ctx, req.Topic, req.Entries, req.Metadata)
 }
 
+// Ping checks the connection to the Kafka cluster.
+func (p *PubSub) Ping(ctx context.Context) error {
+	return p.kafka.Ping(ctx)
+}
+
 func (p *PubSub) Close() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return p.CloseWithContext(ctx)
+}
+
+// CloseWithContext waits until ctx is done for the messages being processed, then closes the component.
+func (p *PubSub) CloseWithContext(ctx context.Context) (err error) {
 	defer p.wg.Wait()
+	// The messages are drained before the subscriptions are canceled, which would interrupt the handlers
+	err = p.kafka.CloseWithContext(ctx)
 	if p.closed.CompareAndSwap(false, true) {
 		close(p.closeCh)
 	}
-	return p.kafka.Close()
+	return err
 }
 
 func (p *PubSub) Features() []pubsub.Feature {
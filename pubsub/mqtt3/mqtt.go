@@ -51,6 +51,11 @@ type mqttPubSub struct {
 	closeCh         chan struct{}
 	closed          atomic.Bool
 	wg              sync.WaitGroup
+
+	// Tracks the messages being processed, which are drained before closing
+	handlersWG sync.WaitGroup
+	drainLock  sync.Mutex
+	draining   bool
 }
 
 type mqttPubSubSubscription struct {
@@ -220,6 +225,11 @@ func (m *mqttPubSub) onMessage(ctx context.Context) func(client mqtt.Client, mqt
 			m.logger.Warnf("No handler defined for messages received on topic %s", msg.Topic)
 			return
 		}
+		// When closing, the message is not processed nor acknowledged, so it's delivered again by the broker
+		if !m.startHandler() {
+			return
+		}
+		defer m.handlersWG.Done()
 
 		m.logger.Debugf("Processing MQTT message %s#%d (retained=%v)", mqttMsg.Topic(), mqttMsg.MessageID(), mqttMsg.Retained())
 		err := topicHandler(ctx, &msg)
@@ -392,15 +402,51 @@ func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) *mqtt.Cl
 	return opts
 }
 
-// Close the connection. Blocks until all subscriptions are closed.
+// startHandler registers a message being processed, returning false if the component is closing.
+func (m *mqttPubSub) startHandler() bool {
+	m.drainLock.Lock()
+	defer m.drainLock.Unlock()
+	if m.draining {
+		return false
+	}
+	m.handlersWG.Add(1)
+	return true
+}
+
+// Close the connection, waiting up to pubsub.DefaultCloseTimeout for the messages being processed.
 func (m *mqttPubSub) Close() error {
-	m.subscribingLock.Lock()
-	defer m.subscribingLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return m.CloseWithContext(ctx)
+}
 
+// CloseWithContext stops processing new messages and waits for the ones being processed to be acknowledged, or until ctx is done.
+// Then it closes the connection. Blocks until all subscriptions are closed.
+func (m *mqttPubSub) CloseWithContext(ctx context.Context) error {
+	m.subscribingLock.Lock()
 	m.logger.Debug("Closing component")
-
-	// Clear all topics from the map as a first thing, before stopping all subscriptions (we have the lock anyways)
+	// Clear all topics from the map as a first thing, so new messages aren't routed to the handlers
 	maps.Clear(m.topics)
+	m.subscribingLock.Unlock()
+
+	m.drainLock.Lock()
+	m.draining = true
+	m.drainLock.Unlock()
+
+	// The connection must be kept open while the handlers complete, to send the acks
+	done := make(chan struct{})
+	go func() {
+		m.handlersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logger.Warnf("Timed out waiting for the MQTT messages being processed: %v", ctx.Err())
+	}
+
+	m.subscribingLock.Lock()
+	defer m.subscribingLock.Unlock()
 
 	if m.closed.CompareAndSwap(false, true) {
 		close(m.closeCh)
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+type mqttMetadata struct {
+	pubsub.TLSProperties    `mapstructure:",squash"`
+	URL                     string        `mapstructure:"url"`
+	ConsumerID              string        `mapstructure:"consumerID" mdignore:"true"`
+	Qos                     byte          `mapstructure:"qos"`
+	Retain                  bool          `mapstructure:"retain"`
+	CleanStart              bool          `mapstructure:"cleanStart"`
+	SessionExpiryInterval   time.Duration `mapstructure:"sessionExpiryInterval"`
+	SharedSubscriptionGroup string        `mapstructure:"sharedSubscriptionGroup"`
+	KeepAlive               time.Duration `mapstructure:"keepAlive"`
+}
+
+const (
+	// Keys
+	mqttURL        = "url"
+	mqttQOS        = "qos"
+	mqttRetain     = "retain"
+	mqttConsumerID = "consumerID"
+	mqttCleanStart = "cleanStart"
+
+	// Defaults
+	defaultQOS        = 1
+	defaultRetain     = false
+	defaultWait       = 20 * time.Second
+	defaultCleanStart = false
+	defaultKeepAlive  = 30 * time.Second
+)
+
+func parseMQTTMetaData(md pubsub.Metadata, log logger.Logger) (*mqttMetadata, error) {
+	m := mqttMetadata{
+		Qos:        defaultQOS,
+		CleanStart: defaultCleanStart,
+		KeepAlive:  defaultKeepAlive,
+	}
+
+	err := kitmd.DecodeMetadata(md.Properties, &m)
+	if err != nil {
+		return &m, fmt.Errorf("mqtt pubsub error: %w", err)
+	}
+
+	// required configuration settings
+	if m.URL == "" {
+		return &m, errors.New("missing url")
+	}
+
+	// optional configuration settings
+	if m.Qos > 2 {
+		return &m, fmt.Errorf("invalid qos %d: must be 0, 1, or 2", m.Qos)
+	}
+
+	// The session expiry interval is sent to the broker in seconds, as an uint32
+	if m.SessionExpiryInterval < 0 || m.SessionExpiryInterval.Seconds() > math.MaxUint32 {
+		return &m, fmt.Errorf("invalid sessionExpiryInterval %v", m.SessionExpiryInterval)
+	}
+	if m.KeepAlive < 0 || m.KeepAlive.Seconds() > math.MaxUint16 {
+		return &m, fmt.Errorf("invalid keepAlive %v", m.KeepAlive)
+	}
+
+	if strings.ContainsAny(m.SharedSubscriptionGroup, "/+#") {
+		return &m, fmt.Errorf("invalid sharedSubscriptionGroup '%s': must not contain '/', '+', or '#'", m.SharedSubscriptionGroup)
+	}
+
+	// Note: the runtime sets the default value to the Dapr app ID if empty
+	if m.ConsumerID == "" {
+		return &m, errors.New("missing consumerID")
+	}
+
+	m.TLSProperties, err = pubsub.TLS(md.Properties)
+	if err != nil {
+		return &m, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,594 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"golang.org/x/exp/maps"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	// Keys for request metadata
+	unsubscribeOnCloseKey = "unsubscribeOnClose"
+
+	// Keys for message metadata
+	retainedKey    = "retained"
+	contentTypeKey = "contentType"
+
+	sharedSubscriptionPrefix = "$share/"
+	reconnectInterval        = 20 * time.Second
+)
+
+// mqttConn is the subset of the methods of *autopaho.ConnectionManager used by the component; it allows unit testing.
+type mqttConn interface {
+	Publish(ctx context.Context, p *paho.Publish) (*paho.PublishResponse, error)
+	Subscribe(ctx context.Context, s *paho.Subscribe) (*paho.Suback, error)
+	Unsubscribe(ctx context.Context, u *paho.Unsubscribe) (*paho.Unsuback, error)
+	Disconnect(ctx context.Context) error
+}
+
+// mqttPubSub type allows sending and receiving data to/from a MQTT 5 broker.
+type mqttPubSub struct {
+	conn            mqttConn
+	metadata        *mqttMetadata
+	logger          logger.Logger
+	topics          map[string]mqttPubSubSubscription
+	subscribingLock sync.RWMutex
+	closeCh         chan struct{}
+	closed          atomic.Bool
+	wg              sync.WaitGroup
+
+	// Tracks the messages being processed, which are drained before closing
+	handlersWG sync.WaitGroup
+	drainLock  sync.Mutex
+	draining   bool
+}
+
+type mqttPubSubSubscription struct {
+	ctx     context.Context
+	handler pubsub.Handler
+	alias   string
+	matcher func(topic string) bool
+}
+
+// NewMQTTPubSub returns a new mqttPubSub instance.
+func NewMQTTPubSub(logger logger.Logger) pubsub.PubSub {
+	return &mqttPubSub{
+		logger:  logger,
+		topics:  make(map[string]mqttPubSubSubscription),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init parses metadata and creates a new Pub Sub client.
+func (m *mqttPubSub) Init(ctx context.Context, metadata pubsub.Metadata) error {
+	mqttMeta, err := parseMQTTMetaData(metadata, m.logger)
+	if err != nil {
+		return err
+	}
+	m.metadata = mqttMeta
+
+	err = m.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to establish connection to broker: %w", err)
+	}
+
+	m.logger.Debug("mqtt5 message bus initialization complete")
+
+	return nil
+}
+
+// Publish the topic to mqtt pub sub.
+// Request metadata includes:
+// - "retain": overrides the "retain" option of the component.
+// - "ttl" or "ttlInSeconds": sets the message expiry interval.
+// All other keys are sent as user properties of the message.
+func (m *mqttPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) (err error) {
+	if m.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	if req.Topic == "" {
+		return errors.New("topic name is empty")
+	}
+
+	// Note this can contain PII
+	// m.logger.Debugf("mqtt publishing topic %s with data: %v", req.Topic, req.Data)
+	m.logger.Debugf("mqtt publishing topic %s", req.Topic)
+
+	p := &paho.Publish{
+		Topic:      req.Topic,
+		QoS:        m.metadata.Qos,
+		Retain:     m.metadata.Retain,
+		Payload:    req.Data,
+		Properties: &paho.PublishProperties{},
+	}
+	if req.ContentType != nil {
+		p.Properties.ContentType = *req.ContentType
+	}
+
+	if val, ok := req.Metadata[mqttRetain]; ok && val != "" {
+		p.Retain, err = strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("mqtt invalid retain %s, %s", val, err)
+		}
+	}
+
+	ttl, ok, err := metadata.TryGetTTL(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("mqtt invalid %s: %w", metadata.TTLMetadataKey, err)
+	}
+	if ok {
+		// The message expiry interval is an uint32 in seconds
+		expiry := uint32(min(math.Ceil(ttl.Seconds()), math.MaxUint32))
+		p.Properties.MessageExpiry = &expiry
+	}
+
+	for k, v := range req.Metadata {
+		if strings.EqualFold(k, mqttRetain) || strings.EqualFold(k, metadata.TTLMetadataKey) || strings.EqualFold(k, metadata.TTLInSecondsMetadataKey) {
+			continue
+		}
+		p.Properties.User.Add(k, v)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultWait)
+	defer cancel()
+	resCh := make(chan error, 1)
+	go func() {
+		_, pErr := m.conn.Publish(ctx, p)
+		resCh <- pErr
+	}()
+	select {
+	case err = <-resCh:
+	case <-m.closeCh:
+		err = errors.New("mqtt client closed")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe to the topic on MQTT.
+// If the "sharedSubscriptionGroup" option is set, the subscription is shared with the other subscribers in the same group, which compete for the messages. Topics that already begin with "$share/" are subscribed to as-is.
+// Request metadata includes:
+// - "unsubscribeOnClose": if true, when the subscription is stopped (context canceled), then an Unsubscribe message is sent to the MQTT broker, which will stop delivering messages to this consumer ID until the subscription is explicitly re-started with a new Subscribe call. Otherwise, messages continue to be delivered but are not handled. "unsubscribeOnClose" should be used with dynamic subscriptions.
+func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if m.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	topic := req.Topic
+	if topic == "" {
+		return errors.New("topic name is empty")
+	}
+	if m.metadata.SharedSubscriptionGroup != "" && !strings.HasPrefix(topic, sharedSubscriptionPrefix) {
+		topic = sharedSubscriptionPrefix + m.metadata.SharedSubscriptionGroup + "/" + topic
+	}
+	unsubscribeOnClose := utils.IsTruthy(req.Metadata[unsubscribeOnCloseKey])
+
+	m.subscribingLock.Lock()
+	defer m.subscribingLock.Unlock()
+
+	// Add the topic then start the subscription
+	m.addTopic(ctx, topic, handler)
+
+	subscribeCtx, subscribeCancel := context.WithTimeout(ctx, defaultWait)
+	_, err := m.conn.Subscribe(subscribeCtx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: m.metadata.Qos}},
+	})
+	subscribeCancel()
+	if err != nil {
+		// Return an error
+		delete(m.topics, topic)
+		return fmt.Errorf("mqtt error from subscribe: %v", err)
+	}
+
+	m.logger.Infof("MQTT is subscribed to topic %s (qos: %d)", topic, m.metadata.Qos)
+
+	// Listen for context cancelation to remove the subscription
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		select {
+		case <-ctx.Done():
+		case <-m.closeCh:
+			// If Close has been called, nothing to do here as the entire connection
+			// will be closed.
+			return
+		}
+
+		// Delete the topic from the map first, which stops routing messages to handlers
+		m.subscribingLock.Lock()
+		delete(m.topics, topic)
+		m.subscribingLock.Unlock()
+
+		// We will call Unsubscribe only if cleanStart is true or if "unsubscribeOnClose" in the request metadata is true
+		// Otherwise, calling this will make the broker lose the position of our subscription, which is not what we want if we are going to reconnect later
+		if !m.metadata.CleanStart && !unsubscribeOnClose {
+			return
+		}
+
+		unsubscribeCtx, unsubscribeCancel := context.WithTimeout(context.Background(), defaultWait)
+		defer unsubscribeCancel()
+		_, unsubscribeErr := m.conn.Unsubscribe(unsubscribeCtx, &paho.Unsubscribe{Topics: []string{topic}})
+		if unsubscribeErr != nil {
+			m.logger.Warnf("Failed to ubsubscribe from topic %s: %v", topic, unsubscribeErr)
+		}
+	}()
+
+	return nil
+}
+
+// onPublishReceived is invoked when there's a new message from a topic.
+func (m *mqttPubSub) onPublishReceived(pr paho.PublishReceived) (bool, error) {
+	m.handleMessage(pr.Packet, func() error {
+		return pr.Client.Ack(pr.Packet)
+	})
+	return true, nil
+}
+
+// handleMessage routes the message to the handler of its topic in background, then acknowledges it.
+// MQTT 5 requires messages to be acknowledged in the order they were received, so messages are always acknowledged, even if they could not be processed: otherwise, the acknowledgements of all following messages would be held back.
+func (m *mqttPubSub) handleMessage(p *paho.Publish, ack func() error) {
+	msg := pubsub.NewMessage{
+		Topic:    p.Topic,
+		Data:     p.Payload,
+		Metadata: make(map[string]string),
+	}
+	if p.Properties != nil {
+		// User properties can be repeated, in which case the first value is used
+		for _, prop := range p.Properties.User {
+			if _, ok := msg.Metadata[prop.Key]; !ok {
+				msg.Metadata[prop.Key] = prop.Value
+			}
+		}
+		if p.Properties.ContentType != "" {
+			msg.Metadata[contentTypeKey] = p.Properties.ContentType
+		}
+	}
+	msg.Metadata[retainedKey] = strconv.FormatBool(p.Retain)
+
+	sub, ok := m.subscriptionForTopic(msg.Topic)
+	if !ok {
+		m.logger.Warnf("No handler defined for messages received on topic %s", msg.Topic)
+		m.ack(p, ack)
+		return
+	}
+	// When closing, the message is not processed nor acknowledged, so it's delivered again by the broker if the session is resumed
+	if !m.startHandler() {
+		return
+	}
+
+	go func() {
+		defer m.handlersWG.Done()
+
+		m.logger.Debugf("Processing MQTT message %s#%d (retained=%v)", p.Topic, p.PacketID, p.Retain)
+		err := sub.handler(sub.ctx, &msg)
+		if err != nil {
+			m.logger.Errorf("Failed processing MQTT message %s#%d: %v", p.Topic, p.PacketID, err)
+		} else {
+			m.logger.Debugf("Done processing MQTT message %s#%d; sending ACK", p.Topic, p.PacketID)
+		}
+		m.ack(p, ack)
+	}()
+}
+
+func (m *mqttPubSub) ack(p *paho.Publish, ack func() error) {
+	err := ack()
+	if err != nil {
+		m.logger.Warnf("Failed to acknowledge MQTT message %s#%d: %v", p.Topic, p.PacketID, err)
+	}
+}
+
+// Returns the subscription for a message sent to a given topic, supporting wildcards and other special syntaxes.
+func (m *mqttPubSub) subscriptionForTopic(topic string) (mqttPubSubSubscription, bool) {
+	m.subscribingLock.RLock()
+	defer m.subscribingLock.RUnlock()
+
+	// First, try to see if we have a handler for the exact topic (no wildcards etc)
+	sub, ok := m.topics[topic]
+	if ok && sub.handler != nil {
+		return sub, true
+	}
+
+	// Iterate through the topics and run the matchers
+	for _, obj := range m.topics {
+		if obj.alias == topic {
+			return obj, true
+		}
+		if obj.matcher != nil && obj.matcher(topic) {
+			return obj, true
+		}
+	}
+
+	return mqttPubSubSubscription{}, false
+}
+
+// Create a connection
+func (m *mqttPubSub) connect(ctx context.Context) error {
+	uri, err := url.Parse(m.metadata.URL)
+	if err != nil {
+		return err
+	}
+
+	cfg := m.createClientConfig(uri)
+	cm, err := autopaho.NewConnection(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultWait)
+	defer cancel()
+	err = cm.AwaitConnection(ctx)
+	if err != nil {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), defaultWait)
+		defer disconnectCancel()
+		_ = cm.Disconnect(disconnectCtx)
+		return err
+	}
+
+	m.subscribingLock.Lock()
+	m.conn = cm
+	m.subscribingLock.Unlock()
+
+	return nil
+}
+
+func (m *mqttPubSub) createClientConfig(uri *url.URL) autopaho.ClientConfig {
+	// Credentials are sent in the CONNECT packet and not as part of the URL
+	serverURL := *uri
+	serverURL.User = nil
+	password, _ := uri.User.Password()
+
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{&serverURL},
+		KeepAlive:                     uint16(m.metadata.KeepAlive.Seconds()),
+		CleanStartOnInitialConnection: m.metadata.CleanStart,
+		SessionExpiryInterval:         uint32(m.metadata.SessionExpiryInterval.Seconds()),
+		ReconnectBackoff:              autopaho.NewConstantBackoff(reconnectInterval),
+		ConnectTimeout:                defaultWait,
+		ConnectUsername:               uri.User.Username(),
+		ConnectPassword:               []byte(password),
+		OnConnectionUp:                m.onConnectionUp,
+		OnConnectError: func(err error) {
+			m.logger.Errorf("Failed to connect to broker: %v", err)
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID: m.metadata.ConsumerID,
+			// Acknowledge messages only after they have been processed
+			EnableManualAcknowledgment: true,
+			OnPublishReceived:          []func(paho.PublishReceived) (bool, error){m.onPublishReceived},
+			OnClientError: func(err error) {
+				m.logger.Errorf("Connection with broker lost; error: %v", err)
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				m.logger.Errorf("Broker closed the connection; reason code: %d", d.ReasonCode)
+			},
+		},
+	}
+
+	// TLS
+	tlsConfig, err := pubsub.ConvertTLSPropertiesToTLSConfig(m.metadata.TLSProperties)
+	if err != nil {
+		m.logger.Warnf("failed to load TLS config: %s", err)
+	} else {
+		cfg.TlsCfg = tlsConfig
+	}
+
+	return cfg
+}
+
+// onConnectionUp adds all established topic subscriptions on (re-)connection.
+func (m *mqttPubSub) onConnectionUp(cm *autopaho.ConnectionManager, connack *paho.Connack) {
+	m.subscribingLock.RLock()
+	// If there's nothing to subscribe to, just return
+	if len(m.topics) == 0 {
+		m.subscribingLock.RUnlock()
+		return
+	}
+
+	// Create the list of topics to subscribe to
+	subscriptions := make([]paho.SubscribeOptions, 0, len(m.topics))
+	for k := range m.topics {
+		subscriptions = append(subscriptions, paho.SubscribeOptions{Topic: k, QoS: m.metadata.Qos})
+	}
+	m.subscribingLock.RUnlock()
+
+	m.logger.Infof("Connected to broker (session present: %v); re-establishing %d subscriptions", connack.SessionPresent, len(subscriptions))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultWait)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-m.closeCh:
+			cancel()
+		}
+	}()
+	_, err := cm.Subscribe(ctx, &paho.Subscribe{Subscriptions: subscriptions})
+
+	// Nothing we can do in case of errors besides logging them
+	// If we get here, the connection is almost likely broken anyways, so the client will attempt a reconnection soon if it hasn't already
+	if err != nil {
+		m.logger.Errorf("Error starting subscriptions on connection: %v", err)
+	}
+}
+
+// startHandler registers a message being processed, returning false if the component is closing.
+func (m *mqttPubSub) startHandler() bool {
+	m.drainLock.Lock()
+	defer m.drainLock.Unlock()
+	if m.draining {
+		return false
+	}
+	m.handlersWG.Add(1)
+	return true
+}
+
+// Close the connection, waiting up to pubsub.DefaultCloseTimeout for the messages being processed.
+func (m *mqttPubSub) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return m.CloseWithContext(ctx)
+}
+
+// CloseWithContext stops processing new messages and waits for the ones being processed to be acknowledged, or until ctx is done.
+// Then it closes the connection. Blocks until all subscriptions are closed.
+func (m *mqttPubSub) CloseWithContext(ctx context.Context) error {
+	m.subscribingLock.Lock()
+	m.logger.Debug("Closing component")
+	// Clear all topics from the map as a first thing, so new messages aren't routed to the handlers
+	maps.Clear(m.topics)
+	m.subscribingLock.Unlock()
+
+	m.drainLock.Lock()
+	m.draining = true
+	m.drainLock.Unlock()
+
+	// The connection must be kept open while the handlers complete, to send the acks
+	done := make(chan struct{})
+	go func() {
+		m.handlersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logger.Warnf("Timed out waiting for the MQTT messages being processed: %v", ctx.Err())
+	}
+
+	if m.closed.CompareAndSwap(false, true) {
+		close(m.closeCh)
+	}
+
+	// Disconnect
+	var err error
+	if m.conn != nil {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), defaultWait)
+		err = m.conn.Disconnect(disconnectCtx)
+		disconnectCancel()
+	}
+
+	m.wg.Wait()
+
+	return err
+}
+
+func (m *mqttPubSub) Features() []pubsub.Feature {
+	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards, pubsub.FeatureMessageTTL}
+}
+
+var sharedSubscriptionMatch = regexp.MustCompile(`^\$share\/(.*?)\/.`)
+
+// Adds a topic to the list of subscriptions.
+func (m *mqttPubSub) addTopic(ctx context.Context, origTopicName string, handler pubsub.Handler) {
+	obj := mqttPubSubSubscription{
+		ctx:     ctx,
+		handler: handler,
+	}
+
+	// Shared subscriptions begin with "$share/GROUPID/" and we can remove that prefix
+	topicName := origTopicName
+	if found := sharedSubscriptionMatch.FindStringIndex(origTopicName); found != nil && found[0] == 0 {
+		topicName = topicName[(found[1] - 1):]
+		obj.alias = topicName
+	}
+
+	// If the topic name contains a wildcard, we need to add a matcher
+	regexStr := buildRegexForTopic(topicName)
+	if regexStr != "" {
+		// We built our own regex and this should never panic
+		match := regexp.MustCompile(regexStr)
+		obj.matcher = func(topic string) bool {
+			return match.MatchString(topic)
+		}
+	}
+
+	m.topics[origTopicName] = obj
+}
+
+// Returns a regular expression string that matches the topic, with support for wildcards.
+func buildRegexForTopic(topicName string) string {
+	// This is a bit more lax than the specs, which for example require "#" to be at the end of the string only:
+	// in practice, seems that (at least some) brokers are more flexible and allow "#" in the middle of a string too
+	var (
+		regexStr string
+		lastPos  int = -1
+		start    int
+		okPos    bool
+	)
+	if strings.ContainsAny(topicName, "#+") {
+		regexStr = "^"
+		// It's ok to iterate over bytes here (rather than codepoints) because all characters we're looking for are always single-byte
+		for i := range len(topicName) {
+			// Wildcard chars must either be at the beginning of the string or must follow a /
+			okPos = (i == 0 || topicName[i-1] == '/')
+			if topicName[i] == '#' && okPos {
+				lastPos = i
+				if i > 0 && i == (len(topicName)-1) {
+					// Edge case: we're at the end of the string so we can allow omitting the preceding /
+					regexStr += regexp.QuoteMeta(topicName[start:(i-1)]) + "(.*)"
+				} else {
+					regexStr += regexp.QuoteMeta(topicName[start:i]) + "(.*)"
+				}
+				start = i + 1
+			} else if topicName[i] == '+' && okPos {
+				lastPos = i
+				if i > 0 && i == (len(topicName)-1) {
+					// Edge case: we're at the end of the string so we can allow omitting the preceding /
+					regexStr += regexp.QuoteMeta(topicName[start:(i-1)]) + `((\/|)[^\/]*)`
+				} else {
+					regexStr += regexp.QuoteMeta(topicName[start:i]) + `([^\/]*)`
+				}
+				start = i + 1
+			}
+		}
+		regexStr += regexp.QuoteMeta(topicName[(lastPos+1):]) + "$"
+	}
+
+	if lastPos == -1 {
+		return ""
+	}
+
+	return regexStr
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (m *mqttPubSub) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := mqttMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.PubSubType)
+	return
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/dapr/components-contrib/health"
 	"github.com/dapr/components-contrib/metadata"
@@ -33,6 +34,16 @@ type PubSub interface {
 	io.Closer
 }
 
+// GracefulCloser is the interface for message buses that can drain the messages being processed when closing.
+type GracefulCloser interface {
+	// CloseWithContext stops receiving new messages and waits for the ones being processed to be handled and acknowledged, then closes the component.
+	// When ctx is done, the component is closed without waiting further.
+	CloseWithContext(ctx context.Context) error
+}
+
+// DefaultCloseTimeout is the maximum time components that implement GracefulCloser wait for the messages being processed when Close is invoked.
+const DefaultCloseTimeout = 5 * time.Second
+
 // BulkPublisher is the interface that wraps the BulkPublish method.
 
 // BulkPublish publishes a collection of entries/messages in a BulkPublishRequest to a
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -310,6 +311,11 @@ func (p *Pulsar) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 		return err
 	}
 
+	// The broker dispatches delayed messages immediately unless subscriptions are shared
+	if isDelayed(msg) && !supportsDelayedDelivery(p.metadata.SubscriptionType) {
+		return fmt.Errorf("delayed delivery with '%s' or '%s' requires a '%s' or '%s' subscription, but the subscription type is '%s'", deliverAt, deliverAfter, subscribeTypeShared, subscribeTypeKeyShared, p.metadata.SubscriptionType)
+	}
+
 	if _, err = producer.Send(ctx, msg); err != nil {
 		return err
 	}
@@ -371,7 +377,7 @@ func parsePublishMetadata(req *pubsub.PublishRequest, schema schemaMetadata) (
 		case partitionKey:
 			msg.Key = value
 		case deliverAt:
-			msg.DeliverAt, err = time.Parse(time.RFC3339, value)
+			msg.DeliverAt, err = parseDeliverAt(value)
 			if err != nil {
 				return nil, err
 			}
@@ -380,6 +386,9 @@ func parsePublishMetadata(req *pubsub.PublishRequest, schema schemaMetadata) (
 			if err != nil {
 				return nil, err
 			}
+			if msg.DeliverAfter < 0 {
+				return nil, fmt.Errorf("invalid value for '%s': must not be negative", deliverAfter)
+			}
 		default:
 			if msg.Properties == nil {
 				msg.Properties = make(map[string]string)
@@ -391,6 +400,28 @@ func parsePublishMetadata(req *pubsub.PublishRequest, schema schemaMetadata) (
 	return msg, nil
 }
 
+// parseDeliverAt parses the time a message is delivered at, either in RFC3339 format or as a Unix timestamp in milliseconds.
+func parseDeliverAt(value string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value for '%s': must be in RFC3339 format or a Unix timestamp in milliseconds: %w", deliverAt, err)
+	}
+	return t, nil
+}
+
+// isDelayed returns true if the message is delivered with a delay.
+func isDelayed(msg *pulsar.ProducerMessage) bool {
+	return msg.DeliverAfter > 0 || !msg.DeliverAt.IsZero()
+}
+
+// supportsDelayedDelivery returns true if delayed messages are honored by the broker for the subscription type.
+func supportsDelayedDelivery(subsType string) bool {
+	return subsType == subscribeTypeShared || subsType == subscribeTypeKeyShared
+}
+
 func parseSubscriptionType(in string) (string, error) {
 	subsType := strings.ToLower(in)
 	switch subsType {
@@ -432,7 +463,11 @@ func (p *Pulsar) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 
 	subscribeType := p.metadata.SubscriptionType
 	if s, exists := req.Metadata[subscribeTypeKey]; exists {
-		subscribeType = s
+		var err error
+		subscribeType, err = parseSubscriptionType(s)
+		if err != nil {
+			return err
+		}
 	}
 
 	options := pulsar.ConsumerOptions{
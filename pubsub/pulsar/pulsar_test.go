@@ -253,6 +253,43 @@ func TestParsePublishMetadata(t *testing.T) {
 	assert.Equal(t, val, msg.DeliverAfter)
 	assert.Equal(t, "2021-08-31T11:45:02Z",
 		msg.DeliverAt.Format(time.RFC3339))
+	assert.True(t, isDelayed(msg))
+
+	t.Run("deliverAt as Unix timestamp in milliseconds", func(t *testing.T) {
+		msg, err := parsePublishMetadata(&pubsub.PublishRequest{
+			Metadata: map[string]string{"deliverAt": "1630410302000"},
+		}, schemaMetadata{})
+		require.NoError(t, err)
+		assert.Equal(t, time.UnixMilli(1630410302000), msg.DeliverAt)
+		assert.True(t, isDelayed(msg))
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		_, err := parsePublishMetadata(&pubsub.PublishRequest{
+			Metadata: map[string]string{"deliverAt": "tomorrow"},
+		}, schemaMetadata{})
+		require.Error(t, err)
+
+		_, err = parsePublishMetadata(&pubsub.PublishRequest{
+			Metadata: map[string]string{"deliverAfter": "-5s"},
+		}, schemaMetadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("no delay", func(t *testing.T) {
+		msg, err := parsePublishMetadata(&pubsub.PublishRequest{
+			Metadata: map[string]string{"partitionKey": "key"},
+		}, schemaMetadata{})
+		require.NoError(t, err)
+		assert.False(t, isDelayed(msg))
+	})
+}
+
+func TestSupportsDelayedDelivery(t *testing.T) {
+	assert.True(t, supportsDelayedDelivery(subscribeTypeShared))
+	assert.True(t, supportsDelayedDelivery(subscribeTypeKeyShared))
+	assert.False(t, supportsDelayedDelivery(subscribeTypeExclusive))
+	assert.False(t, supportsDelayedDelivery(subscribeTypeFailover))
 }
 
 func TestMissingHost(t *testing.T) {
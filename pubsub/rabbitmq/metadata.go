@@ -27,31 +27,34 @@ import (
 )
 
 type rabbitmqMetadata struct {
-	pubsub.TLSProperties `mapstructure:",squash"`
-	ConsumerID           string                 `mapstructure:"consumerID" mdignore:"true"`
-	ConnectionString     string                 `mapstructure:"connectionString"`
-	Protocol             string                 `mapstructure:"protocol"`
-	internalProtocol     string                 `mapstructure:"-"`
-	Hostname             string                 `mapstructure:"hostname"`
-	Username             string                 `mapstructure:"username"`
-	Password             string                 `mapstructure:"password"`
-	Durable              bool                   `mapstructure:"durable"`
-	EnableDeadLetter     bool                   `mapstructure:"enableDeadLetter"`
-	DeleteWhenUnused     bool                   `mapstructure:"deletedWhenUnused"`
-	AutoAck              bool                   `mapstructure:"autoAck"`
-	RequeueInFailure     bool                   `mapstructure:"requeueInFailure"`
-	DeliveryMode         uint8                  `mapstructure:"deliveryMode"`  // Transient (0 or 1) or Persistent (2)
-	PrefetchCount        uint8                  `mapstructure:"prefetchCount"` // Prefetch deactivated if 0
-	ReconnectWait        time.Duration          `mapstructure:"reconnectWaitSeconds"`
-	MaxLen               int64                  `mapstructure:"maxLen"`
-	MaxLenBytes          int64                  `mapstructure:"maxLenBytes"`
-	ExchangeKind         string                 `mapstructure:"exchangeKind"`
-	ClientName           string                 `mapstructure:"clientName"`
-	HeartBeat            time.Duration          `mapstructure:"heartBeat"`
-	PublisherConfirm     bool                   `mapstructure:"publisherConfirm"`
-	SaslExternal         bool                   `mapstructure:"saslExternal"`
-	Concurrency          pubsub.ConcurrencyMode `mapstructure:"concurrency"`
-	DefaultQueueTTL      *time.Duration         `mapstructure:"ttlInSeconds"`
+	pubsub.TLSProperties    `mapstructure:",squash"`
+	ConsumerID              string                 `mapstructure:"consumerID" mdignore:"true"`
+	ConnectionString        string                 `mapstructure:"connectionString"`
+	Protocol                string                 `mapstructure:"protocol"`
+	internalProtocol        string                 `mapstructure:"-"`
+	Hostname                string                 `mapstructure:"hostname"`
+	Username                string                 `mapstructure:"username"`
+	Password                string                 `mapstructure:"password"`
+	Durable                 bool                   `mapstructure:"durable"`
+	EnableDeadLetter        bool                   `mapstructure:"enableDeadLetter"`
+	DeleteWhenUnused        bool                   `mapstructure:"deletedWhenUnused"`
+	AutoAck                 bool                   `mapstructure:"autoAck"`
+	RequeueInFailure        bool                   `mapstructure:"requeueInFailure"`
+	DeliveryMode            uint8                  `mapstructure:"deliveryMode"`  // Transient (0 or 1) or Persistent (2)
+	PrefetchCount           uint8                  `mapstructure:"prefetchCount"` // Prefetch deactivated if 0
+	ReconnectWait           time.Duration          `mapstructure:"reconnectWaitSeconds"`
+	MaxLen                  int64                  `mapstructure:"maxLen"`
+	MaxLenBytes             int64                  `mapstructure:"maxLenBytes"`
+	ExchangeKind            string                 `mapstructure:"exchangeKind"`
+	ClientName              string                 `mapstructure:"clientName"`
+	HeartBeat               time.Duration          `mapstructure:"heartBeat"`
+	PublisherConfirm        bool                   `mapstructure:"publisherConfirm"`
+	PublisherConfirmTimeout time.Duration          `mapstructure:"publisherConfirmTimeout"` // Waits until the request is canceled if 0
+	PingTimeout             time.Duration          `mapstructure:"pingTimeout"`
+	QueueType               string                 `mapstructure:"queueType"`
+	SaslExternal            bool                   `mapstructure:"saslExternal"`
+	Concurrency             pubsub.ConcurrencyMode `mapstructure:"concurrency"`
+	DefaultQueueTTL         *time.Duration         `mapstructure:"ttlInSeconds"`
 }
 
 const (
@@ -77,10 +80,13 @@ const (
 	metadataMaxLenBytesKey          = "maxLenBytes"
 	metadataExchangeKindKey         = "exchangeKind"
 	metadataPublisherConfirmKey     = "publisherConfirm"
+	metadataPublisherConfirmTimeout = "publisherConfirmTimeout"
+	metadataQueueTypeKey            = "queueType"
 	metadataSaslExternal            = "saslExternal"
 	metadataMaxPriority             = "maxPriority"
 	metadataClientNameKey           = "clientName"
 	metadataHeartBeatKey            = "heartBeat"
+	metadataPingTimeoutKey          = "pingTimeout"
 	metadataQueueNameKey            = "queueName"
 
 	defaultReconnectWaitSeconds = 3
@@ -102,6 +108,7 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*rabbitm
 		PublisherConfirm: false,
 		SaslExternal:     false,
 		HeartBeat:        defaultHeartbeat,
+		PingTimeout:      defaultPingTimeout,
 	}
 
 	// upgrade metadata
@@ -140,6 +147,14 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*rabbitm
 		return &result, fmt.Errorf("%s invalid RabbitMQ exchange kind %s", errorMessagePrefix, result.ExchangeKind)
 	}
 
+	if result.QueueType != "" && !queueTypeValid(result.QueueType) {
+		return &result, fmt.Errorf("%s invalid queue type %s. Valid types are %s and %s", errorMessagePrefix, result.QueueType, amqp.QueueTypeClassic, amqp.QueueTypeQuorum)
+	}
+
+	if result.PublisherConfirmTimeout < 0 {
+		return &result, fmt.Errorf("%s invalid %s: must not be negative", errorMessagePrefix, metadataPublisherConfirmTimeout)
+	}
+
 	ttl, ok, err := metadata.TryGetTTL(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, fmt.Errorf("%s parse RabbitMQ ttl metadata with error: %s", errorMessagePrefix, err)
@@ -179,6 +179,26 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Equal(t, time.Minute, m.HeartBeat)
 	})
 
+	t.Run("ping timeout", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+
+		// act
+		m, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, defaultPingTimeout, m.PingTimeout)
+
+		fakeMetaData.Properties[metadataPingTimeoutKey] = "2s"
+		m, err = createMetadata(fakeMetaData, log)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Second, m.PingTimeout)
+	})
+
 	t.Run("disable durable mode, disable delete when unused", func(t *testing.T) {
 		fakeProperties := getFakeProperties()
 
@@ -471,6 +491,57 @@ func TestCreateMetadata(t *testing.T) {
 		// assert
 		require.Error(t, err)
 	})
+
+	t.Run("queueType is quorum", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataQueueTypeKey] = amqp.QueueTypeQuorum
+
+		// act
+		m, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, amqp.QueueTypeQuorum, m.QueueType)
+	})
+
+	t.Run("queueType is invalid", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataQueueTypeKey] = "stream"
+
+		// act
+		_, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		require.ErrorContains(t, err, "invalid queue type stream")
+	})
+
+	t.Run("publisherConfirmTimeout", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataPublisherConfirmTimeout] = "5s"
+
+		// act
+		m, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, m.PublisherConfirmTimeout)
+
+		fakeMetaData.Properties[metadataPublisherConfirmTimeout] = "-5s"
+		_, err = createMetadata(fakeMetaData, log)
+		require.Error(t, err)
+	})
 }
 
 func TestConnectionURI(t *testing.T) {
@@ -18,6 +18,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
 	"reflect"
 	"strconv"
@@ -47,7 +48,9 @@ const (
 	publishMaxRetries       = 3
 	publishRetryWaitSeconds = 2
 	defaultHeartbeat        = 10 * time.Second
+	defaultPingTimeout      = 5 * time.Second
 	defaultLocale           = "en_US"
+	pingExchange            = "amq.direct" // Exchange always declared by the broker, used to check the connection
 
 	argQueueMode                       = "x-queue-mode"
 	argMaxLength                       = "x-max-length"
@@ -69,6 +72,7 @@ type rabbitMQ struct {
 	connection        rabbitMQConnectionBroker
 	channel           rabbitMQChannelBroker
 	channelMutex      sync.RWMutex
+	consumeMutex      sync.Mutex
 	connectionCount   int
 	metadata          *rabbitmqMetadata
 	declaredExchanges map[string]bool
@@ -93,6 +97,7 @@ type rabbitMQChannelBroker interface {
 	Nack(tag uint64, multiple bool, requeue bool) error
 	Ack(tag uint64, multiple bool) error
 	ExchangeDeclare(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error
+	ExchangeDeclarePassive(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error
 	Qos(prefetchCount, prefetchSize int, global bool) error
 	Confirm(noWait bool) error
 	Close() error
@@ -213,27 +218,16 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 	return nil
 }
 
-func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
-	r.channelMutex.Lock()
-	defer r.channelMutex.Unlock()
-
-	if r.channel == nil {
-		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
-	}
-
-	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.ExchangeKind, r.metadata.Durable, r.metadata.DeleteWhenUnused); err != nil {
-		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
-
-		return r.channel, r.connectionCount, err
-	}
+// newPublishing returns the routing key and the message to publish to the topic.
+func (r *rabbitMQ) newPublishing(topic string, data []byte, reqMetadata map[string]string) (string, amqp.Publishing) {
 	routingKey := ""
-	if val, ok := req.Metadata[reqMetadataRoutingKey]; ok && val != "" {
+	if val, ok := reqMetadata[reqMetadataRoutingKey]; ok && val != "" {
 		routingKey = val
 	}
 
-	ttl, ok, err := metadata.TryGetTTL(req.Metadata)
+	ttl, ok, err := metadata.TryGetTTL(reqMetadata)
 	if err != nil {
-		r.logger.Warnf("%s publishing to %s failed to parse TryGetTTL: %v, it is ignored.", logMessagePrefix, req.Topic, err)
+		r.logger.Warnf("%s publishing to %s failed to parse TryGetTTL: %v, it is ignored.", logMessagePrefix, topic, err)
 	}
 	var expiration string
 	if ok {
@@ -245,20 +239,38 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 
 	p := amqp.Publishing{
 		ContentType:  "text/plain",
-		Body:         req.Data,
+		Body:         data,
 		DeliveryMode: r.metadata.DeliveryMode,
 		Expiration:   expiration,
 	}
 
-	priority, ok, err := metadata.TryGetPriority(req.Metadata)
+	priority, ok, err := metadata.TryGetPriority(reqMetadata)
 	if err != nil {
-		r.logger.Warnf("%s publishing to %s failed to parse priority: %v, it is ignored.", logMessagePrefix, req.Topic, err)
+		r.logger.Warnf("%s publishing to %s failed to parse priority: %v, it is ignored.", logMessagePrefix, topic, err)
 	}
 
 	if ok {
 		p.Priority = priority
 	}
 
+	return routingKey, p
+}
+
+func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
+	r.channelMutex.Lock()
+	defer r.channelMutex.Unlock()
+
+	if r.channel == nil {
+		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
+	}
+
+	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.ExchangeKind, r.metadata.Durable, r.metadata.DeleteWhenUnused); err != nil {
+		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
+
+		return r.channel, r.connectionCount, err
+	}
+	routingKey, p := r.newPublishing(req.Topic, req.Data, req.Metadata)
+
 	confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, req.Topic, routingKey, false, false, p)
 	if err != nil {
 		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
@@ -269,10 +281,11 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 	// confirm will be nil if are not requesting publish confirmations
 	if confirm != nil {
 		// Blocks until the server confirms
-		ok := confirm.Wait()
-		if !ok {
-			err = errors.New("did not receive confirmation of publishing")
+		err = r.waitConfirm(ctx, confirm)
+		if err != nil {
 			r.logger.Errorf("%s publishing to %s failed: %v", logMessagePrefix, req.Topic, err)
+
+			return r.channel, r.connectionCount, err
 		}
 	}
 
@@ -302,7 +315,7 @@ func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 			select {
 			case <-time.After(r.metadata.ReconnectWait):
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 			}
 
 			r.reconnect(connectionCount)
@@ -311,7 +324,152 @@ func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 			select {
 			case <-time.After(publishRetryWaitSeconds * time.Second):
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// waitConfirm waits for the broker to confirm a publishing, up to the configured timeout.
+func (r *rabbitMQ) waitConfirm(ctx context.Context, confirm *amqp.DeferredConfirmation) error {
+	if r.metadata.PublisherConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.metadata.PublisherConfirmTimeout)
+		defer cancel()
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("did not receive confirmation of publishing: %w", err)
+	}
+	if !acked {
+		return errors.New("publishing was not acknowledged by the broker")
+	}
+	return nil
+}
+
+// bulkPublishSync publishes all the entries on the channel and then waits for their confirmations, if enabled.
+// The returned error is set when the channel could not be used, in which case the entries not published are all failed.
+func (r *rabbitMQ) bulkPublishSync(ctx context.Context, topic string, entries []pubsub.BulkMessageEntry, reqMetadata map[string]string) (rabbitMQChannelBroker, int, pubsub.BulkPublishResponse, error) {
+	r.channelMutex.Lock()
+	defer r.channelMutex.Unlock()
+
+	if r.channel == nil {
+		err := errors.New(errorChannelNotInitialized)
+		return r.channel, r.connectionCount, pubsub.NewBulkPublishResponse(entries, err), err
+	}
+
+	if err := r.ensureExchangeDeclared(r.channel, topic, r.metadata.ExchangeKind, r.metadata.Durable, r.metadata.DeleteWhenUnused); err != nil {
+		r.logger.Errorf("%s bulk publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, topic, err)
+
+		return r.channel, r.connectionCount, pubsub.NewBulkPublishResponse(entries, err), err
+	}
+
+	res := pubsub.BulkPublishResponse{}
+	confirms := make(map[string]*amqp.DeferredConfirmation, len(entries))
+	var channelErr error
+	for i, entry := range entries {
+		// Metadata of the entry takes precedence over the one of the request
+		md := make(map[string]string, len(reqMetadata)+len(entry.Metadata))
+		maps.Copy(md, reqMetadata)
+		maps.Copy(md, entry.Metadata)
+		routingKey, p := r.newPublishing(topic, entry.Event, md)
+
+		confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, topic, routingKey, false, false, p)
+		if err != nil {
+			r.logger.Errorf("%s bulk publishing to %s failed in channel.Publish: %v", logMessagePrefix, topic, err)
+			if mustReconnect(r.channel, err) {
+				// The channel cannot be used for the remaining entries either
+				channelErr = err
+				res.FailedEntries = append(res.FailedEntries, pubsub.NewBulkPublishResponse(entries[i:], err).FailedEntries...)
+				break
+			}
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+			continue
+		}
+
+		// confirm will be nil if are not requesting publish confirmations
+		if confirm != nil {
+			confirms[entry.EntryId] = confirm
+		}
+	}
+
+	// Wait for the confirmations only after all messages have been published, so the server can confirm them together
+	for _, entry := range entries {
+		confirm, ok := confirms[entry.EntryId]
+		if !ok {
+			continue
+		}
+		err := r.waitConfirm(ctx, confirm)
+		if err != nil {
+			r.logger.Errorf("%s bulk publishing of entry %s to %s failed: %v", logMessagePrefix, entry.EntryId, topic, err)
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+		}
+	}
+
+	return r.channel, r.connectionCount, res, channelErr
+}
+
+// BulkPublish publishes multiple messages to the topic, reporting the entries that failed.
+// Failed entries are retried like in Publish, reconnecting if the channel is no longer open.
+func (r *rabbitMQ) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if r.closed.Load() {
+		err := errors.New("component is closed")
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	r.logger.Debugf("%s bulk publishing %d messages to %s", logMessagePrefix, len(req.Entries), req.Topic)
+
+	entries := req.Entries
+	attempt := 0
+	for {
+		attempt++
+		channel, connectionCount, res, err := r.bulkPublishSync(ctx, req.Topic, entries, req.Metadata)
+		if len(res.FailedEntries) == 0 {
+			return pubsub.BulkPublishResponse{}, nil
+		}
+		if err == nil {
+			err = res.FailedEntries[0].Error
+		}
+		if attempt >= publishMaxRetries {
+			r.logger.Errorf("%s bulk publishing failed for %d messages: %v", logMessagePrefix, len(res.FailedEntries), err)
+			return res, fmt.Errorf("failed to publish %d of %d messages: %w", len(res.FailedEntries), len(req.Entries), err)
+		}
+
+		// Only retry the entries that failed
+		failed := make(map[string]struct{}, len(res.FailedEntries))
+		for _, e := range res.FailedEntries {
+			failed[e.EntryId] = struct{}{}
+		}
+		retryEntries := make([]pubsub.BulkMessageEntry, 0, len(res.FailedEntries))
+		for _, e := range entries {
+			if _, ok := failed[e.EntryId]; ok {
+				retryEntries = append(retryEntries, e)
+			}
+		}
+		entries = retryEntries
+
+		if mustReconnect(channel, err) {
+			r.logger.Warnf("%s bulk publisher is reconnecting in %s ...", logMessagePrefix, r.metadata.ReconnectWait.String())
+			select {
+			case <-time.After(r.metadata.ReconnectWait):
+			case <-ctx.Done():
+				return res, ctx.Err()
+			}
+
+			r.reconnect(connectionCount)
+		} else {
+			r.logger.Warnf("%s bulk publishing attempt (%d/%d) failed for %d messages: %v", logMessagePrefix, attempt, publishMaxRetries, len(entries), err)
+			select {
+			case <-time.After(publishRetryWaitSeconds * time.Second):
+			case <-ctx.Done():
+				return res, ctx.Err()
 			}
 		}
 	}
@@ -330,6 +488,11 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 		queueName = fmt.Sprintf("%s-%s", r.metadata.ConsumerID, req.Topic)
 	}
 
+	prefetchCount, err := r.subscriptionPrefetchCount(req)
+	if err != nil {
+		return err
+	}
+
 	r.logger.Infof("%s subscribe to topic/queue '%s/%s'", logMessagePrefix, req.Topic, queueName)
 
 	// Do not set a timeout on the context, as we're just waiting for the first ack; we're using a semaphore instead
@@ -340,7 +503,7 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 	r.wg.Add(2)
 	go func() {
 		defer r.wg.Done()
-		r.subscribeForever(subctx, req, queueName, handler, ackCh)
+		r.subscribeForever(subctx, req, queueName, prefetchCount, handler, ackCh)
 	}()
 	go func() {
 		defer r.wg.Done()
@@ -423,15 +586,24 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		args[argMaxPriority] = mp
 	}
 
-	// queue type is classic by default, but we allow user to create quorum queues if desired
+	// queue type is classic by default, but we allow user to create quorum queues if desired, for the component or the subscription
+	queueType := r.metadata.QueueType
 	if val := req.Metadata[reqMetadataQueueTypeKey]; val != "" {
 		if !queueTypeValid(val) {
 			return nil, fmt.Errorf("invalid queue type %s. Valid types are %s and %s", val, amqp.QueueTypeClassic, amqp.QueueTypeQuorum)
-		} else {
-			args[amqp.QueueTypeArg] = val
 		}
-	} else {
-		args[amqp.QueueTypeArg] = amqp.QueueTypeClassic
+		queueType = val
+	}
+	if queueType == "" {
+		queueType = amqp.QueueTypeClassic
+	}
+	args[amqp.QueueTypeArg] = queueType
+
+	// Quorum queues are always durable and can't be deleted automatically
+	durable, autoDelete := r.metadata.Durable, r.metadata.DeleteWhenUnused
+	if queueType == amqp.QueueTypeQuorum && (!durable || autoDelete) {
+		r.logger.Infof("%s queue '%s' is a quorum queue, declaring it as durable and not deleted when unused", logMessagePrefix, queueName)
+		durable, autoDelete = true, false
 	}
 
 	// Applying x-single-active-consumer if defined at subscription level
@@ -459,23 +631,13 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		args[argMaxLength] = parsedVal
 	}
 
-	q, err := channel.QueueDeclare(queueName, r.metadata.Durable, r.metadata.DeleteWhenUnused, false, false, args)
+	q, err := channel.QueueDeclare(queueName, durable, autoDelete, false, false, args)
 	if err != nil {
 		r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in channel.QueueDeclare: %v", logMessagePrefix, req.Topic, queueName, err)
 
 		return nil, err
 	}
 
-	if r.metadata.PrefetchCount > 0 {
-		r.logger.Infof("%s setting prefetch count to %s", logMessagePrefix, strconv.Itoa(int(r.metadata.PrefetchCount)))
-		err = channel.Qos(int(r.metadata.PrefetchCount), 0, false)
-		if err != nil {
-			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in channel.Qos: %v", logMessagePrefix, req.Topic, queueName, err)
-
-			return nil, err
-		}
-	}
-
 	metadataRoutingKey := ""
 	if val, ok := req.Metadata[reqMetadataRoutingKey]; ok && val != "" {
 		metadataRoutingKey = val
@@ -495,6 +657,49 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 	return &q, nil
 }
 
+// subscriptionPrefetchCount returns the prefetch count of the subscription, which can override the one of the component.
+func (r *rabbitMQ) subscriptionPrefetchCount(req pubsub.SubscribeRequest) (int, error) {
+	val := req.Metadata[metadataPrefetchCountKey]
+	if val == "" {
+		return int(r.metadata.PrefetchCount), nil
+	}
+	parsedVal, err := strconv.ParseUint(val, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%s can't parse %s value on subscription metadata for topic '%s': %w", errorMessagePrefix, metadataPrefetchCountKey, req.Topic, err)
+	}
+	return int(parsedVal), nil
+}
+
+// consume starts consuming from the queue with the prefetch count of the subscription.
+// As the prefetch count applies to the consumers started afterwards on the channel, which is shared by subscriptions, setting it and consuming are serialized.
+func (r *rabbitMQ) consume(channel rabbitMQChannelBroker, q *amqp.Queue, queueName string, prefetchCount int) (<-chan amqp.Delivery, string, error) {
+	r.consumeMutex.Lock()
+	defer r.consumeMutex.Unlock()
+
+	if prefetchCount > 0 {
+		r.logger.Infof("%s setting prefetch count of queue '%s' to %d", logMessagePrefix, queueName, prefetchCount)
+	}
+	// Set even if 0, to reset a prefetch count set by another subscription
+	err := channel.Qos(prefetchCount, 0, false)
+	if err != nil {
+		return nil, "channel.Qos", err
+	}
+
+	msgs, err := channel.Consume(
+		q.Name,
+		queueName,          // consumerID
+		r.metadata.AutoAck, // autoAck
+		false,              // exclusive
+		false,              // noLocal
+		false,              // noWait
+		nil,
+	)
+	if err != nil {
+		return nil, "channel.Consume", err
+	}
+	return msgs, "", nil
+}
+
 func (r *rabbitMQ) ensureSubscription(req pubsub.SubscribeRequest, queueName string) (rabbitMQChannelBroker, int, *amqp.Queue, error) {
 	r.channelMutex.RLock()
 	defer r.channelMutex.RUnlock()
@@ -508,7 +713,7 @@ func (r *rabbitMQ) ensureSubscription(req pubsub.SubscribeRequest, queueName str
 	return r.channel, r.connectionCount, q, err
 }
 
-func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeRequest, queueName string, handler pubsub.Handler, ackCh chan bool) {
+func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeRequest, queueName string, prefetchCount int, handler pubsub.Handler, ackCh chan bool) {
 	for {
 		var (
 			err             error
@@ -525,17 +730,8 @@ func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeReq
 				break
 			}
 
-			msgs, err = channel.Consume(
-				q.Name,
-				queueName,          // consumerID
-				r.metadata.AutoAck, // autoAck
-				false,              // exclusive
-				false,              // noLocal
-				false,              // noWait
-				nil,
-			)
+			msgs, errFuncName, err = r.consume(channel, q, queueName, prefetchCount)
 			if err != nil {
-				errFuncName = "channel.Consume"
 				break
 			}
 
@@ -701,6 +897,37 @@ func (r *rabbitMQ) reset() (err error) {
 	return
 }
 
+// Ping checks the connection to the broker with a passive declaration of a built-in exchange, which requires a round trip.
+func (r *rabbitMQ) Ping(ctx context.Context) error {
+	r.channelMutex.RLock()
+	channel := r.channel
+	r.channelMutex.RUnlock()
+	if channel == nil || channel.IsClosed() {
+		return fmt.Errorf("%s %s", errorMessagePrefix, errorChannelConnection)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.metadata.PingTimeout)
+	defer cancel()
+
+	// The AMQP client doesn't accept a context, so the request is abandoned if it doesn't complete in time
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- channel.ExchangeDeclarePassive(pingExchange, amqp.ExchangeDirect, true, false, false, false, nil)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed to ping the broker: %w", errorMessagePrefix, err)
+	}
+
+	return nil
+}
+
 func (r *rabbitMQ) isStopped() bool {
 	return r.closed.Load()
 }
@@ -395,6 +395,70 @@ func TestSubscribeBindRoutingKeys(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestSubscribePrefetchCount(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:      "anyhost",
+			metadataConsumerIDKey:    "consumer",
+			metadataPrefetchCountKey: "10",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	require.NoError(t, err)
+
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "mytopic"}, handler)
+	require.NoError(t, err)
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "othertopic", Metadata: map[string]string{metadataPrefetchCountKey: "2"}}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 2}, broker.prefetchCounts)
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "invalidtopic", Metadata: map[string]string{metadataPrefetchCountKey: "-1"}}, handler)
+	require.Error(t, err)
+}
+
+func TestSubscribeQuorumQueue(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:         "anyhost",
+			metadataConsumerIDKey:       "consumer",
+			metadataDurableKey:          "false",
+			metadataDeleteWhenUnusedKey: "true",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	require.NoError(t, err)
+
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "classic"}, handler)
+	require.NoError(t, err)
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "quorum", Metadata: map[string]string{metadataQueueTypeKey: amqp.QueueTypeQuorum}}, handler)
+	require.NoError(t, err)
+
+	// Quorum queues are always durable and cannot be auto-deleted
+	assert.Equal(t, [2]bool{false, true}, broker.queueOptions["consumer-classic"])
+	assert.Equal(t, [2]bool{true, false}, broker.queueOptions["consumer-quorum"])
+}
+
+func TestWaitConfirmTimeout(t *testing.T) {
+	pubsubRabbitMQ := newRabbitMQTest(newBroker())
+	pubsubRabbitMQ.metadata = &rabbitmqMetadata{PublisherConfirmTimeout: 10 * time.Millisecond}
+
+	// A deferred confirmation that is never acknowledged
+	err := pubsubRabbitMQ.waitConfirm(context.Background(), &amqp.DeferredConfirmation{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 func TestSubscribeReconnect(t *testing.T) {
 	broker := newBroker()
 	pubsubRabbitMQ := newRabbitMQTest(broker)
@@ -463,11 +527,16 @@ func createAMQPMessage(body []byte) amqp.Delivery {
 type rabbitMQInMemoryBroker struct {
 	buffer         chan amqp.Delivery
 	declaredQueues []string
+	queueOptions   map[string][2]bool
+	prefetchCounts []int
 	connectCount   atomic.Int32
 	closeCount     atomic.Int32
+	pingErr        error
+	pingDelay      time.Duration
 }
 
 func (r *rabbitMQInMemoryBroker) Qos(prefetchCount, prefetchSize int, global bool) error {
+	r.prefetchCounts = append(r.prefetchCounts, prefetchCount)
 	return nil
 }
 
@@ -489,6 +558,10 @@ func (r *rabbitMQInMemoryBroker) PublishWithDeferredConfirmWithContext(ctx conte
 
 func (r *rabbitMQInMemoryBroker) QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error) {
 	r.declaredQueues = append(r.declaredQueues, name)
+	if r.queueOptions == nil {
+		r.queueOptions = map[string][2]bool{}
+	}
+	r.queueOptions[name] = [2]bool{durable, autoDelete}
 	return amqp.Queue{Name: name}, nil
 }
 
@@ -512,6 +585,11 @@ func (r *rabbitMQInMemoryBroker) ExchangeDeclare(name string, kind string, durab
 	return nil
 }
 
+func (r *rabbitMQInMemoryBroker) ExchangeDeclarePassive(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error {
+	time.Sleep(r.pingDelay)
+	return r.pingErr
+}
+
 func (r *rabbitMQInMemoryBroker) Confirm(noWait bool) error {
 	return nil
 }
@@ -525,3 +603,107 @@ func (r *rabbitMQInMemoryBroker) Close() error {
 func (r *rabbitMQInMemoryBroker) IsClosed() bool {
 	return r.connectCount.Load() <= r.closeCount.Load()
 }
+
+func TestPing(t *testing.T) {
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:    "anyhost",
+			metadataPingTimeoutKey: "100ms",
+		},
+	}}
+
+	t.Run("connected", func(t *testing.T) {
+		broker := newBroker()
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		require.NoError(t, pubsubRabbitMQ.Init(context.Background(), metadata))
+		defer pubsubRabbitMQ.Close()
+
+		require.NoError(t, pubsubRabbitMQ.Ping(context.Background()))
+	})
+
+	t.Run("broker error", func(t *testing.T) {
+		broker := newBroker()
+		broker.pingErr = errors.New(errorChannelConnection)
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		require.NoError(t, pubsubRabbitMQ.Init(context.Background(), metadata))
+		defer pubsubRabbitMQ.Close()
+
+		require.ErrorContains(t, pubsubRabbitMQ.Ping(context.Background()), errorChannelConnection)
+	})
+
+	t.Run("broker not responding", func(t *testing.T) {
+		broker := newBroker()
+		broker.pingDelay = time.Second
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		require.NoError(t, pubsubRabbitMQ.Init(context.Background(), metadata))
+		defer pubsubRabbitMQ.Close()
+
+		require.ErrorIs(t, pubsubRabbitMQ.Ping(context.Background()), context.DeadlineExceeded)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		broker := newBroker()
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		require.NoError(t, pubsubRabbitMQ.Init(context.Background(), metadata))
+		require.NoError(t, pubsubRabbitMQ.Close())
+
+		require.Error(t, pubsubRabbitMQ.Ping(context.Background()))
+	})
+}
+
+func TestBulkPublish(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:   "anyhost",
+			metadataConsumerIDKey: "consumer",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	require.NoError(t, err)
+
+	t.Run("all entries published", func(t *testing.T) {
+		res, err := pubsubRabbitMQ.BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+			Topic: "mytopic",
+			Entries: []pubsub.BulkMessageEntry{
+				{EntryId: "1", Event: []byte("hello")},
+				{EntryId: "2", Event: []byte("world")},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, res.FailedEntries)
+		assert.Equal(t, "hello", string((<-broker.buffer).Body))
+		assert.Equal(t, "world", string((<-broker.buffer).Body))
+	})
+
+	t.Run("failed entries are retried and reported", func(t *testing.T) {
+		res, err := pubsubRabbitMQ.BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+			Topic: "mytopic",
+			Entries: []pubsub.BulkMessageEntry{
+				{EntryId: "1", Event: []byte("hello")},
+				{EntryId: "2", Event: []byte(errorChannelConnection)},
+			},
+		})
+		require.Error(t, err)
+		require.Len(t, res.FailedEntries, 1)
+		assert.Equal(t, "2", res.FailedEntries[0].EntryId)
+		require.ErrorContains(t, res.FailedEntries[0].Error, errorChannelConnection)
+
+		// Only the failed entry was retried
+		assert.Equal(t, "hello", string((<-broker.buffer).Body))
+		assert.Empty(t, broker.buffer)
+		// Check that reconnection happened
+		assert.Equal(t, int32(3), broker.connectCount.Load())
+	})
+
+	t.Run("closed component", func(t *testing.T) {
+		require.NoError(t, pubsubRabbitMQ.Close())
+		res, err := pubsubRabbitMQ.BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+			Topic:   "mytopic",
+			Entries: []pubsub.BulkMessageEntry{{EntryId: "1", Event: []byte("hello")}},
+		})
+		require.Error(t, err)
+		require.Len(t, res.FailedEntries, 1)
+	})
+}
@@ -53,6 +53,9 @@ type redisStreams struct {
 	wg             sync.WaitGroup
 	closed         atomic.Bool
 	closeCh        chan struct{}
+	// Closed when the component is closing, to stop the workers from picking up new messages
+	drainCh   chan struct{}
+	workersWG sync.WaitGroup
 
 	queue chan redisMessageWrapper
 }
@@ -71,6 +74,7 @@ func NewRedisStreams(logger logger.Logger) pubsub.PubSub {
 	return &redisStreams{
 		logger:  logger,
 		closeCh: make(chan struct{}),
+		drainCh: make(chan struct{}),
 	}
 }
 
@@ -87,9 +91,9 @@ func (r *redisStreams) Init(ctx context.Context, metadata pubsub.Metadata) error
 	r.queue = make(chan redisMessageWrapper, int(r.clientSettings.QueueDepth)) //nolint:gosec
 
 	for range r.clientSettings.Concurrency {
-		r.wg.Add(1)
+		r.workersWG.Add(1)
 		go func() {
-			defer r.wg.Done()
+			defer r.workersWG.Done()
 			r.worker()
 		}()
 	}
@@ -217,14 +221,21 @@ func (r *redisStreams) createRedisMessageWrapper(ctx context.Context, stream str
 
 // worker runs in separate goroutine(s) and pull messages from a channel for processing.
 // The number of workers is controlled by the `concurrency` setting.
+// Workers stop when the component is closing, after completing the message they are processing.
+// Messages still in the queue are not acknowledged and are redelivered by `reclaimPendingMessagesLoop`.
 func (r *redisStreams) worker() {
 	for {
 		select {
 		// Handle closing
-		case <-r.closeCh:
+		case <-r.drainCh:
 			return
 
 		case msg := <-r.queue:
+			select {
+			case <-r.drainCh:
+				return
+			default:
+			}
 			r.processMessage(msg)
 		}
 	}
@@ -412,9 +423,31 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Co
 	}
 }
 
+// Close the component, waiting up to pubsub.DefaultCloseTimeout for the messages being processed.
 func (r *redisStreams) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultCloseTimeout)
+	defer cancel()
+	return r.CloseWithContext(ctx)
+}
+
+// CloseWithContext waits for the workers to complete and acknowledge the messages being processed,
+// or until ctx is done, then stops the subscriptions and closes the client.
+func (r *redisStreams) CloseWithContext(ctx context.Context) error {
 	defer r.wg.Wait()
 	if r.closed.CompareAndSwap(false, true) {
+		close(r.drainCh)
+
+		done := make(chan struct{})
+		go func() {
+			r.workersWG.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			r.logger.Warnf("Timed out waiting for the Redis messages being processed: %v", ctx.Err())
+		}
+
 		close(r.closeCh)
 	}
 
@@ -18,7 +18,9 @@ import (
 	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -150,6 +152,54 @@ func TestProcessStreamsWithoutEventMetadata(t *testing.T) {
 	assert.Equal(t, 3, messageCount)
 }
 
+func TestCloseWithContextDrainsWorkers(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handled atomic.Int32
+
+	fakeHandler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if handled.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+
+		// Return fake error to skip executing redis client command
+		return errors.New("fake error")
+	}
+
+	testRedisStream := &redisStreams{
+		logger:         logger.NewLogger("test"),
+		clientSettings: &commonredis.Settings{},
+		closeCh:        make(chan struct{}),
+		drainCh:        make(chan struct{}),
+		queue:          make(chan redisMessageWrapper, 10),
+	}
+	testRedisStream.workersWG.Add(1)
+	go func() {
+		defer testRedisStream.workersWG.Done()
+		testRedisStream.worker()
+	}()
+	testRedisStream.enqueueMessages(context.Background(), "fakeConsumer", fakeHandler, generateRedisStreamTestData(3, "testData", ""))
+	<-started
+
+	closed := make(chan error)
+	go func() {
+		closed <- testRedisStream.CloseWithContext(context.Background())
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("close returned before the handler completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-closed)
+
+	// The messages still in the queue are not processed
+	assert.Equal(t, int32(1), handled.Load())
+}
+
 func generateRedisStreamTestData(messageCount int, data string, metadata string) []commonredis.RedisXMessage {
 	generateXMessage := func(id int) commonredis.RedisXMessage {
 		values := map[string]interface{}{
@@ -2,10 +2,11 @@ package pubsub
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/pem"
 	"errors"
-	"fmt"
+
+	"github.com/dapr/components-contrib/common/tlsconfig"
+	kitmd "github.com/dapr/kit/metadata"
 )
 
 // TLSProperties is a struct that contains the TLS properties.
@@ -13,6 +14,8 @@ type TLSProperties struct {
 	CACert     string
 	ClientCert string
 	ClientKey  string
+
+	tlsconfig.Metadata `mapstructure:",squash"`
 }
 
 const (
@@ -47,29 +50,22 @@ func TLS(metadata map[string]string) (TLSProperties, error) {
 		cfg.ClientKey = val
 	}
 
+	err := kitmd.DecodeMetadata(metadata, &cfg.Metadata)
+	if err != nil {
+		return TLSProperties{}, err
+	}
+
 	return cfg, nil
 }
 
 // ConvertTLSPropertiesToTLSConfig converts the TLSProperties to a tls.Config.
 func ConvertTLSPropertiesToTLSConfig(properties TLSProperties) (*tls.Config, error) {
-	tlsConfig := new(tls.Config)
-
-	if properties.ClientCert != "" && properties.ClientKey != "" {
-		cert, err := tls.X509KeyPair([]byte(properties.ClientCert), []byte(properties.ClientKey))
-		if err != nil {
-			return tlsConfig, fmt.Errorf("unable to load client certificate and key pair. Err: %v", err)
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
-
-	if properties.CACert != "" {
-		tlsConfig.RootCAs = x509.NewCertPool()
-		if ok := tlsConfig.RootCAs.AppendCertsFromPEM([]byte(properties.CACert)); !ok {
-			return tlsConfig, errors.New("unable to load CA certificate")
-		}
-	}
-
-	return tlsConfig, nil
+	return tlsconfig.Config{
+		Metadata:   properties.Metadata,
+		CACert:     properties.CACert,
+		ClientCert: properties.ClientCert,
+		ClientKey:  properties.ClientKey,
+	}.Build()
 }
 
 // isValidPEM validates the provided input has PEM formatted block.
@@ -15,21 +15,29 @@ package secretmanager
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 
 	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
 )
 
 const (
 	VersionID    = "version_id"
 	VersionStage = "version_stage"
+
+	// Maximum number of secrets that can be retrieved with a single BatchGetSecretValue call.
+	batchGetSecretValueMaxSecrets = 20
 )
 
 var _ secretstores.SecretStore = (*smSecretStore)(nil)
@@ -45,11 +53,32 @@ type SecretManagerMetaData struct {
 	SecretKey    string `json:"secretKey" mapstructure:"secretKey" mdignore:"true"`
 	SessionToken string `json:"sessionToken" mapstructure:"sessionToken" mdignore:"true"`
 	Endpoint     string `json:"endpoint" mapstructure:"endpoint"`
+
+	// Only secrets whose name starts with this prefix are returned by BulkGetSecret.
+	BulkGetPrefix string `json:"bulkGetPrefix" mapstructure:"bulkGetPrefix"`
+	// Comma-separated list of tags, as "key" or "key=value", that secrets returned by BulkGetSecret must have.
+	BulkGetTags []string `json:"bulkGetTags" mapstructure:"bulkGetTags"`
+	// Duration the result of BulkGetSecret is cached for; 0 disables caching.
+	BulkGetCacheTTL time.Duration `json:"bulkGetCacheTTL" mapstructure:"bulkGetCacheTTL"`
+}
+
+// tagFilter is a tag that secrets returned by BulkGetSecret must have; if value is nil, any value matches.
+type tagFilter struct {
+	key   string
+	value *string
 }
 
 type smSecretStore struct {
 	authProvider awsAuth.Provider
 	logger       logger.Logger
+
+	bulkPrefix   string
+	bulkTags     []tagFilter
+	bulkCacheTTL time.Duration
+
+	bulkCache        map[string]map[string]string
+	bulkCacheExpires time.Time
+	bulkCacheLock    sync.Mutex
 }
 
 // Init creates an AWS secret manager client.
@@ -73,9 +102,31 @@ func (s *smSecretStore) Init(ctx context.Context, metadata secretstores.Metadata
 		return err
 	}
 	s.authProvider = provider
+
+	s.bulkPrefix = meta.BulkGetPrefix
+	s.bulkTags = parseTagFilters(meta.BulkGetTags)
+	s.bulkCacheTTL = meta.BulkGetCacheTTL
 	return nil
 }
 
+// parseTagFilters parses tags in the "key" or "key=value" format.
+func parseTagFilters(tags []string) []tagFilter {
+	res := make([]tagFilter, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(tag, "=")
+		f := tagFilter{key: strings.TrimSpace(key)}
+		if ok {
+			f.value = aws.String(strings.TrimSpace(value))
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
 func (s *smSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
 	var versionID *string
@@ -107,50 +158,136 @@ func (s *smSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecre
 
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
 func (s *smSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	if s.bulkCacheTTL > 0 {
+		s.bulkCacheLock.Lock()
+		defer s.bulkCacheLock.Unlock()
+		if s.bulkCache != nil && time.Now().Before(s.bulkCacheExpires) {
+			return secretstores.BulkGetSecretResponse{Data: s.bulkCache}, nil
+		}
+	}
+
+	names, err := s.listSecrets(ctx)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
+	}
+
 	resp := secretstores.BulkGetSecretResponse{
-		Data: map[string]map[string]string{},
+		Data: make(map[string]map[string]string, len(names)),
+	}
+	for i := 0; i < len(names); i += batchGetSecretValueMaxSecrets {
+		batch := names[i:min(i+batchGetSecretValueMaxSecrets, len(names))]
+		err = s.batchGetSecretValues(ctx, batch, resp.Data)
+		if err != nil {
+			return secretstores.BulkGetSecretResponse{Data: nil}, err
+		}
 	}
 
-	search := true
-	var nextToken *string = nil
+	if s.bulkCacheTTL > 0 {
+		s.bulkCache = resp.Data
+		s.bulkCacheExpires = time.Now().Add(s.bulkCacheTTL)
+	}
+
+	return resp, nil
+}
+
+// listSecrets returns the names of the secrets matching the prefix and tags filters.
+func (s *smSecretStore) listSecrets(ctx context.Context) ([]string, error) {
+	var filters []*secretsmanager.Filter
+	if s.bulkPrefix != "" {
+		filters = append(filters, &secretsmanager.Filter{
+			Key:    aws.String(secretsmanager.FilterNameStringTypeName),
+			Values: aws.StringSlice([]string{s.bulkPrefix}),
+		})
+	}
+	// Filters on the tag keys are applied by the service, matching the values is done below
+	for _, tag := range s.bulkTags {
+		filters = append(filters, &secretsmanager.Filter{
+			Key:    aws.String(secretsmanager.FilterNameStringTypeTagKey),
+			Values: aws.StringSlice([]string{tag.key}),
+		})
+	}
 
-	for search {
+	var (
+		names     []string
+		nextToken *string
+	)
+	for {
 		output, err := s.authProvider.SecretManager().Manager.ListSecretsWithContext(ctx, &secretsmanager.ListSecretsInput{
-			MaxResults: nil,
-			NextToken:  nextToken,
+			Filters:   filters,
+			NextToken: nextToken,
 		})
 		if err != nil {
-			return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't list secrets: %s", err)
+			return nil, fmt.Errorf("couldn't list secrets: %s", err)
 		}
 
 		for _, entry := range output.SecretList {
-			secrets, err := s.authProvider.SecretManager().Manager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
-				SecretId: entry.Name,
-			})
-			if err != nil {
-				return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't get secret: %s", *entry.Name)
-			}
-
-			if entry.Name != nil && secrets.SecretString != nil {
-				resp.Data[*entry.Name] = map[string]string{*entry.Name: *secrets.SecretString}
+			// The name filter of the service is case-insensitive and matches words within the name too
+			if entry.Name == nil || !strings.HasPrefix(*entry.Name, s.bulkPrefix) || !s.matchesTags(entry.Tags) {
+				continue
 			}
+			names = append(names, *entry.Name)
 		}
 
+		if output.NextToken == nil {
+			return names, nil
+		}
 		nextToken = output.NextToken
-		search = output.NextToken != nil
 	}
+}
 
-	return resp, nil
+// matchesTags returns true if the tags include all the tags of the filter.
+func (s *smSecretStore) matchesTags(tags []*secretsmanager.Tag) bool {
+	for _, f := range s.bulkTags {
+		found := false
+		for _, tag := range tags {
+			if aws.StringValue(tag.Key) == f.key && (f.value == nil || aws.StringValue(tag.Value) == *f.value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
-func (s *smSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*SecretManagerMetaData, error) {
-	b, err := json.Marshal(spec.Properties)
-	if err != nil {
-		return nil, err
+// batchGetSecretValues retrieves the values of the secrets, adding them to data.
+func (s *smSecretStore) batchGetSecretValues(ctx context.Context, names []string, data map[string]map[string]string) error {
+	var nextToken *string
+	for {
+		output, err := s.authProvider.SecretManager().Manager.BatchGetSecretValueWithContext(ctx, &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: aws.StringSlice(names),
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get secrets: %s", err)
+		}
+
+		if len(output.Errors) > 0 {
+			errs := make([]error, len(output.Errors))
+			for i, e := range output.Errors {
+				errs[i] = fmt.Errorf("couldn't get secret %s: %s: %s", aws.StringValue(e.SecretId), aws.StringValue(e.ErrorCode), aws.StringValue(e.Message))
+			}
+			return errors.Join(errs...)
+		}
+
+		for _, secret := range output.SecretValues {
+			if secret.Name != nil && secret.SecretString != nil {
+				data[*secret.Name] = map[string]string{*secret.Name: *secret.SecretString}
+			}
+		}
+
+		if output.NextToken == nil {
+			return nil
+		}
+		nextToken = output.NextToken
 	}
+}
 
+func (s *smSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*SecretManagerMetaData, error) {
 	var meta SecretManagerMetaData
-	err = json.Unmarshal(b, &meta)
+	err := kitmd.DecodeMetadata(spec.Properties, &meta)
 	if err != nil {
 		return nil, err
 	}
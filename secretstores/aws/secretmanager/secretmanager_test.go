@@ -17,15 +17,18 @@ package secretmanager
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
-
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 )
@@ -194,6 +197,157 @@ func TestGetSecret(t *testing.T) {
 	})
 }
 
+func TestBulkGetSecret(t *testing.T) {
+	newStore := func(mockSSM *awsAuth.MockSecretManager) *smSecretStore {
+		mockAuthProvider := &awsAuth.StaticAuth{}
+		mockAuthProvider.WithMockClients(&awsAuth.Clients{
+			Secret: &awsAuth.SecretManagerClients{
+				Manager: mockSSM,
+			},
+		})
+		return &smSecretStore{
+			authProvider: mockAuthProvider,
+		}
+	}
+
+	// 25 secrets over 2 pages, values retrieved in batches of at most 20
+	listSecrets := func(ctx context.Context, input *secretsmanager.ListSecretsInput, option ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+		start, end, next := 0, 15, aws.String("page2")
+		if input.NextToken != nil {
+			assert.Equal(t, "page2", *input.NextToken)
+			start, end, next = 15, 25, nil
+		}
+		out := &secretsmanager.ListSecretsOutput{NextToken: next}
+		for i := start; i < end; i++ {
+			out.SecretList = append(out.SecretList, &secretsmanager.SecretListEntry{
+				Name: aws.String(fmt.Sprintf("secret%d", i)),
+			})
+		}
+		return out, nil
+	}
+	var batches [][]string
+	batchGetSecretValue := func(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, option ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error) {
+		batches = append(batches, aws.StringValueSlice(input.SecretIdList))
+		out := &secretsmanager.BatchGetSecretValueOutput{}
+		for _, name := range input.SecretIdList {
+			out.SecretValues = append(out.SecretValues, &secretsmanager.SecretValueEntry{
+				Name:         name,
+				SecretString: aws.String(*name + "-" + secretValue),
+			})
+		}
+		return out, nil
+	}
+
+	t.Run("successfully retrieve bulk secrets", func(t *testing.T) {
+		batches = nil
+		s := newStore(&awsAuth.MockSecretManager{
+			ListSecretsFn:         listSecrets,
+			BatchGetSecretValueFn: batchGetSecretValue,
+		})
+
+		output, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Len(t, output.Data, 25)
+		assert.Equal(t, map[string]string{"secret3": "secret3-secret"}, output.Data["secret3"])
+		assert.Equal(t, map[string]string{"secret24": "secret24-secret"}, output.Data["secret24"])
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 20)
+		assert.Len(t, batches[1], 5)
+	})
+
+	t.Run("with prefix and tags filters", func(t *testing.T) {
+		s := newStore(&awsAuth.MockSecretManager{
+			ListSecretsFn: func(ctx context.Context, input *secretsmanager.ListSecretsInput, option ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+				assert.Equal(t, []*secretsmanager.Filter{
+					{Key: aws.String("name"), Values: aws.StringSlice([]string{"myapp/"})},
+					{Key: aws.String("tag-key"), Values: aws.StringSlice([]string{"env"})},
+					{Key: aws.String("tag-key"), Values: aws.StringSlice([]string{"team"})},
+				}, input.Filters)
+				tags := func(env string) []*secretsmanager.Tag {
+					return []*secretsmanager.Tag{
+						{Key: aws.String("env"), Value: aws.String(env)},
+						{Key: aws.String("team"), Value: aws.String("any")},
+					}
+				}
+				return &secretsmanager.ListSecretsOutput{
+					SecretList: []*secretsmanager.SecretListEntry{
+						{Name: aws.String("myapp/secret1"), Tags: tags("prod")},
+						{Name: aws.String("myapp/secret2"), Tags: tags("dev")},
+						{Name: aws.String("other/myapp/secret3"), Tags: tags("prod")},
+						{Name: aws.String("myapp/secret4"), Tags: tags("prod")[:1]},
+					},
+				}, nil
+			},
+			BatchGetSecretValueFn: func(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, option ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error) {
+				assert.Equal(t, []string{"myapp/secret1"}, aws.StringValueSlice(input.SecretIdList))
+				return batchGetSecretValue(ctx, input, option...)
+			},
+		})
+		s.bulkPrefix = "myapp/"
+		s.bulkTags = parseTagFilters([]string{"env=prod", " team "})
+
+		output, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"myapp/secret1": {"myapp/secret1": "myapp/secret1-secret"},
+		}, output.Data)
+	})
+
+	t.Run("with cache", func(t *testing.T) {
+		batches = nil
+		s := newStore(&awsAuth.MockSecretManager{
+			ListSecretsFn:         listSecrets,
+			BatchGetSecretValueFn: batchGetSecretValue,
+		})
+		s.bulkCacheTTL = 100 * time.Millisecond
+
+		for range 3 {
+			output, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+			require.NoError(t, err)
+			assert.Len(t, output.Data, 25)
+		}
+		assert.Len(t, batches, 2)
+
+		time.Sleep(150 * time.Millisecond)
+		_, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Len(t, batches, 4)
+	})
+
+	t.Run("unsuccessfully retrieve bulk secrets", func(t *testing.T) {
+		s := newStore(&awsAuth.MockSecretManager{
+			ListSecretsFn: listSecrets,
+			BatchGetSecretValueFn: func(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, option ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error) {
+				return &secretsmanager.BatchGetSecretValueOutput{
+					Errors: []*secretsmanager.APIErrorType{
+						{SecretId: aws.String("secret1"), ErrorCode: aws.String("AccessDeniedException"), Message: aws.String("denied")},
+					},
+				}, nil
+			},
+		})
+
+		_, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.ErrorContains(t, err, "couldn't get secret secret1: AccessDeniedException: denied")
+	})
+}
+
+func TestInitBulkGetMetadata(t *testing.T) {
+	meta, err := (&smSecretStore{}).getSecretManagerMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"region":          "us-east-1",
+		"bulkGetPrefix":   "myapp/",
+		"bulkGetTags":     "app=myapp,env",
+		"bulkGetCacheTTL": "5m",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", meta.Region)
+	assert.Equal(t, "myapp/", meta.BulkGetPrefix)
+	assert.Equal(t, 5*time.Minute, meta.BulkGetCacheTTL)
+	assert.Equal(t, []tagFilter{
+		{key: "app", value: aws.String("myapp")},
+		{key: "env"},
+	}, parseTagFilters(meta.BulkGetTags))
+}
+
 func TestGetFeatures(t *testing.T) {
 	s := smSecretStore{}
 	t.Run("no features are advertised", func(t *testing.T) {
@@ -15,6 +15,9 @@ package keyvault
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -22,9 +25,11 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 
 	azauth "github.com/dapr/components-contrib/common/authentication/azure"
+	kvcrypto "github.com/dapr/components-contrib/crypto/azure/keyvault"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -35,14 +40,29 @@ import (
 // This is in addition to what's defined in authentication/azure.
 const (
 	VersionID          = "version_id"
+	ObjectType         = "object_type"
 	secretItemIDPrefix = "/secrets/"
 )
 
+// Object types that can be requested with the "object_type" request metadata property.
+const (
+	ObjectTypeSecret      = "secret"
+	ObjectTypeCertificate = "certificate"
+	ObjectTypeKey         = "key"
+)
+
+// Keys added to the response data alongside the requested value.
+const (
+	contentTypeKey = "contentType"
+	keyTypeKey     = "keyType"
+)
+
 var _ secretstores.SecretStore = (*keyvaultSecretStore)(nil)
 
 type keyvaultSecretStore struct {
 	vaultName      string
 	vaultClient    *azsecrets.Client
+	keysClient     *azkeys.Client
 	vaultDNSSuffix string
 
 	logger logger.Logger
@@ -92,7 +112,10 @@ func (k *keyvaultSecretStore) Init(_ context.Context, meta secretstores.Metadata
 	}
 
 	k.vaultName = m.VaultName
-	k.vaultDNSSuffix = settings.EndpointSuffix(azauth.ServiceAzureKeyVault)
+	k.vaultDNSSuffix, err = settings.GetEndpointSuffix(azauth.ServiceAzureKeyVault)
+	if err != nil {
+		return err
+	}
 
 	cred, err := settings.GetTokenCredential()
 	if err != nil {
@@ -103,11 +126,16 @@ func (k *keyvaultSecretStore) Init(_ context.Context, meta secretstores.Metadata
 			ApplicationID: "dapr-" + logger.DaprVersion,
 		},
 	}
-	client, clientErr := azsecrets.NewClient(k.getVaultURI(), cred, &azsecrets.ClientOptions{
+	k.vaultClient, err = azsecrets.NewClient(k.getVaultURI(), cred, &azsecrets.ClientOptions{
 		ClientOptions: coreClientOpts,
 	})
-	k.vaultClient = client
-	return clientErr
+	if err != nil {
+		return err
+	}
+	k.keysClient, err = azkeys.NewClient(k.getVaultURI(), cred, &azkeys.ClientOptions{
+		ClientOptions: coreClientOpts,
+	})
+	return err
 }
 
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
@@ -117,6 +145,18 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 		version = val
 	}
 
+	objectType, err := getObjectTypeFromMetadata(req.Metadata)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+
+	switch objectType {
+	case ObjectTypeCertificate:
+		return k.getCertificate(ctx, req.Name, version)
+	case ObjectTypeKey:
+		return k.getKey(ctx, req.Name, version)
+	}
+
 	secretResp, err := k.vaultClient.GetSecret(ctx, req.Name, version, nil)
 	if err != nil {
 		return secretstores.GetSecretResponse{}, err
@@ -134,6 +174,74 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 	}, nil
 }
 
+// getCertificate retrieves a certificate, including its private key, from the vault.
+// Key Vault stores the full bundle of every certificate in a secret with the same name: its value is a PEM bundle or a base64-encoded PFX archive, depending on the content type chosen when the certificate was created.
+func (k *keyvaultSecretStore) getCertificate(ctx context.Context, name string, version string) (secretstores.GetSecretResponse, error) {
+	secretResp, err := k.vaultClient.GetSecret(ctx, name, version, nil)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+
+	if secretResp.KID == nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("secret '%s' is not backing a certificate", name)
+	}
+
+	res := secretstores.GetSecretResponse{
+		Data: map[string]string{},
+	}
+	if secretResp.Value != nil {
+		res.Data[name] = *secretResp.Value
+	}
+	if secretResp.ContentType != nil {
+		res.Data[contentTypeKey] = *secretResp.ContentType
+	}
+
+	return res, nil
+}
+
+// getKey retrieves the public part of a key from the vault, encoded as a PEM block.
+func (k *keyvaultSecretStore) getKey(ctx context.Context, name string, version string) (secretstores.GetSecretResponse, error) {
+	keyResp, err := k.keysClient.GetKey(ctx, name, version, nil)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+	if keyResp.Key == nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("key '%s' not found", name)
+	}
+
+	pemKey, err := encodePublicKeyPEM(*keyResp.Key)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("failed to encode key '%s': %w", name, err)
+	}
+
+	res := secretstores.GetSecretResponse{
+		Data: map[string]string{
+			name: pemKey,
+		},
+	}
+	if keyResp.Key.Kty != nil {
+		res.Data[keyTypeKey] = string(*keyResp.Key.Kty)
+	}
+
+	return res, nil
+}
+
+// encodePublicKeyPEM returns the public part of a JSON Web Key as a PKIX, PEM-encoded block.
+func encodePublicKeyPEM(key azkeys.JSONWebKey) (string, error) {
+	pk, err := kvcrypto.JSONWebKey{JSONWebKey: key}.Public()
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pk)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})), nil
+}
+
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
 func (k *keyvaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
 	maxResults, err := k.getMaxResultsFromMetadata(req.Metadata)
@@ -202,6 +310,18 @@ func (k *keyvaultSecretStore) getMaxResultsFromMetadata(metadata map[string]stri
 	return nil, nil
 }
 
+func getObjectTypeFromMetadata(metadata map[string]string) (string, error) {
+	objectType := strings.ToLower(metadata[ObjectType])
+	switch objectType {
+	case "":
+		return ObjectTypeSecret, nil
+	case ObjectTypeSecret, ObjectTypeCertificate, ObjectTypeKey:
+		return objectType, nil
+	default:
+		return "", errors.New("invalid value for metadata property '" + ObjectType + "': must be one of 'secret', 'certificate', or 'key'")
+	}
+}
+
 // Features returns the features available in this secret store.
 func (k *keyvaultSecretStore) Features() []secretstores.Feature {
 	return []secretstores.Feature{} // No Feature supported.
@@ -16,8 +16,15 @@ package keyvault
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -99,3 +106,57 @@ func TestGetFeatures(t *testing.T) {
 		assert.Empty(t, f)
 	})
 }
+
+func TestGetObjectTypeFromMetadata(t *testing.T) {
+	t.Run("defaults to secret", func(t *testing.T) {
+		objectType, err := getObjectTypeFromMetadata(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeSecret, objectType)
+	})
+
+	t.Run("is case-insensitive", func(t *testing.T) {
+		objectType, err := getObjectTypeFromMetadata(map[string]string{ObjectType: "Certificate"})
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeCertificate, objectType)
+	})
+
+	t.Run("key", func(t *testing.T) {
+		objectType, err := getObjectTypeFromMetadata(map[string]string{ObjectType: "key"})
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeKey, objectType)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := getObjectTypeFromMetadata(map[string]string{ObjectType: "blob"})
+		require.Error(t, err)
+	})
+}
+
+func TestEncodePublicKeyPEM(t *testing.T) {
+	t.Run("EC key", func(t *testing.T) {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		res, err := encodePublicKeyPEM(azkeys.JSONWebKey{
+			Kty: to.Ptr(azkeys.KeyTypeEC),
+			Crv: to.Ptr(azkeys.CurveNameP256),
+			X:   priv.X.Bytes(),
+			Y:   priv.Y.Bytes(),
+		})
+		require.NoError(t, err)
+
+		block, _ := pem.Decode([]byte(res))
+		require.NotNil(t, block)
+		assert.Equal(t, "PUBLIC KEY", block.Type)
+		pk, err := x509.ParsePKIXPublicKey(block.Bytes)
+		require.NoError(t, err)
+		assert.True(t, priv.PublicKey.Equal(pk))
+	})
+
+	t.Run("symmetric key is not supported", func(t *testing.T) {
+		_, err := encodePublicKeyPEM(azkeys.JSONWebKey{
+			Kty: to.Ptr(azkeys.KeyTypeOct),
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix for values that were encrypted client-side with envelope encryption.
+// The rest of the value is the base64-encoded wrapped data encryption key (DEK), a ":", and the base64-encoded ciphertext.
+// The DEK is a random AES-256 key generated for each value and wrapped with the key encryption key (KEK) configured in the component.
+// Both the DEK and the value are encrypted with AES-GCM, prefixed with their nonce, and use the name of the secret as additional data.
+const encryptedValuePrefix = "dapr-enc:v1:"
+
+// dekSize is the size of the data encryption keys, for AES-256.
+const dekSize = 32
+
+// EncryptValue encrypts the value of a secret with a new data encryption key, which is wrapped with kek.
+// The result can be stored in etcd at the key for the secret named name, and decrypted by a secret store configured with kek as its encryptionKey.
+func EncryptValue(kek []byte, name string, value string) (string, error) {
+	kekAEAD, err := newAEAD(kek)
+	if err != nil {
+		return "", err
+	}
+
+	dek := make([]byte, dekSize)
+	_, err = rand.Read(dek)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate data encryption key: %w", err)
+	}
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(kekAEAD, dek, name)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dekAEAD, []byte(value), name)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptValue decrypts a value returned by EncryptValue, without the prefix, by unwrapping its data encryption key with the key encryption key.
+func decryptValue(kek cipher.AEAD, name string, encoded string) ([]byte, error) {
+	wrappedEnc, ciphertextEnc, ok := strings.Cut(encoded, ":")
+	if !ok {
+		return nil, errors.New("missing data encryption key")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedEnc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data encryption key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextEnc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	dek, err := open(kek, wrapped, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data encryption key: %w", err)
+	}
+	if len(dek) != dekSize {
+		return nil, errors.New("invalid data encryption key size")
+	}
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	return open(dekAEAD, ciphertext, name)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts data with a random nonce, which is prepended to the result.
+func seal(aead cipher.AEAD, data []byte, name string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, []byte(name)), nil
+}
+
+func open(aead cipher.AEAD, data []byte, name string) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(name))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"crypto/cipher"
+	"fmt"
+	"reflect"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	etcdcomponent "github.com/dapr/components-contrib/common/component/etcd"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+var _ secretstores.SecretStore = (*etcdSecretStore)(nil)
+
+type etcdSecretStore struct {
+	client   *clientv3.Client
+	kek      cipher.AEAD
+	metadata etcdMetadata
+	logger   logger.Logger
+}
+
+// NewEtcdSecretStore returns a new etcd secret store.
+func NewEtcdSecretStore(logger logger.Logger) secretstores.SecretStore {
+	return &etcdSecretStore{
+		logger: logger,
+	}
+}
+
+// Init creates the etcd client.
+func (s *etcdSecretStore) Init(ctx context.Context, meta secretstores.Metadata) error {
+	err := s.metadata.InitWithMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	if len(s.metadata.encryptionKey) > 0 {
+		s.kek, err = newAEAD(s.metadata.encryptionKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	config := clientv3.Config{
+		Endpoints:   strings.Split(s.metadata.Endpoints, ","),
+		DialTimeout: s.metadata.Timeout,
+	}
+	if s.metadata.TLSEnable {
+		config.TLS, err = etcdcomponent.NewTLSConfig(s.metadata.Cert, s.metadata.Key, s.metadata.CA)
+		if err != nil {
+			return fmt.Errorf("tls authentication error: %w", err)
+		}
+	}
+
+	s.client, err = clientv3.New(config)
+	if err != nil {
+		return fmt.Errorf("initializing etcd client: %w", err)
+	}
+
+	return nil
+}
+
+// GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
+func (s *etcdSecretStore) GetSecret(parentCtx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	key := s.keyWithPath(req.Name)
+
+	ctx, cancel := context.WithTimeout(parentCtx, s.metadata.Timeout)
+	resp, err := s.client.Get(ctx, key)
+	cancel()
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't get key %s: %w", key, err)
+	}
+
+	if resp == nil || len(resp.Kvs) == 0 {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("secret %s not found", req.Name)
+	}
+
+	value, err := s.decodeValue(req.Name, resp.Kvs[0].Value)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+
+	return secretstores.GetSecretResponse{
+		Data: map[string]string{
+			req.Name: value,
+		},
+	}, nil
+}
+
+// BulkGetSecret retrieves all secrets under the key prefix and returns a map of decrypted string/string values.
+func (s *etcdSecretStore) BulkGetSecret(parentCtx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	prefix := s.metadata.KeyPrefixPath + "/"
+
+	ctx, cancel := context.WithTimeout(parentCtx, s.metadata.Timeout)
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, fmt.Errorf("couldn't list keys with prefix %s: %w", prefix, err)
+	}
+
+	res := secretstores.BulkGetSecretResponse{
+		Data: make(map[string]map[string]string, len(resp.Kvs)),
+	}
+	for _, kv := range resp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), prefix)
+		value, err := s.decodeValue(name, kv.Value)
+		if err != nil {
+			return secretstores.BulkGetSecretResponse{}, err
+		}
+		res.Data[name] = map[string]string{
+			name: value,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *etcdSecretStore) keyWithPath(name string) string {
+	return s.metadata.KeyPrefixPath + "/" + name
+}
+
+// decodeValue returns the plaintext value of a secret, decrypting it if it was encrypted client-side.
+func (s *etcdSecretStore) decodeValue(name string, value []byte) (string, error) {
+	encoded, ok := strings.CutPrefix(string(value), encryptedValuePrefix)
+	if !ok {
+		if s.metadata.RequireEncryption {
+			return "", fmt.Errorf("secret %s is not encrypted", name)
+		}
+		return string(value), nil
+	}
+
+	if s.kek == nil {
+		return "", fmt.Errorf("secret %s is encrypted but no encryption key is configured", name)
+	}
+
+	plaintext, err := decryptValue(s.kek, name, encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Features returns the features available in this secret store.
+func (s *etcdSecretStore) Features() []secretstores.Feature {
+	return []secretstores.Feature{} // No Feature supported.
+}
+
+func (s *etcdSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := etcdMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.SecretStoreType)
+	return
+}
+
+func (s *etcdSecretStore) Close() error {
+	if s.client == nil {
+		return nil
+	}
+
+	return s.client.Close()
+}
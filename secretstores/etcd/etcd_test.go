@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "dapr/secrets", m.KeyPrefixPath)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+		assert.Empty(t, m.encryptionKey)
+	})
+
+	t.Run("endpoints are required", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		require.Error(t, err)
+	})
+
+	t.Run("encryption key", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints":     "localhost:2379",
+			"keyPrefixPath": "myapp/",
+			"encryptionKey": base64.StdEncoding.EncodeToString(key),
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "myapp", m.KeyPrefixPath)
+		assert.Equal(t, key, m.encryptionKey)
+		assert.Empty(t, m.EncryptionKey)
+	})
+
+	t.Run("invalid encryption key size", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints":     "localhost:2379",
+			"encryptionKey": base64.StdEncoding.EncodeToString(key[:10]),
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("requireEncryption without a key", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints":         "localhost:2379",
+			"requireEncryption": "true",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("incomplete TLS configuration", func(t *testing.T) {
+		m := etcdMetadata{}
+		err := m.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"tlsEnable": "true",
+			"ca":        "ca",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestDecodeValue(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	kek, err := newAEAD(key)
+	require.NoError(t, err)
+
+	encrypt := func(name, value string) []byte {
+		res, err := EncryptValue(key, name, value)
+		require.NoError(t, err)
+		return []byte(res)
+	}
+
+	s := NewEtcdSecretStore(logger.NewLogger("test")).(*etcdSecretStore)
+
+	t.Run("plaintext value without encryption key", func(t *testing.T) {
+		res, err := s.decodeValue("mysecret", []byte("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", res)
+	})
+
+	t.Run("encrypted value without encryption key", func(t *testing.T) {
+		_, err := s.decodeValue("mysecret", encrypt("mysecret", "hello"))
+		require.Error(t, err)
+	})
+
+	s.kek = kek
+
+	t.Run("encrypted value", func(t *testing.T) {
+		res, err := s.decodeValue("mysecret", encrypt("mysecret", "hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", res)
+	})
+
+	t.Run("each value has its own data encryption key", func(t *testing.T) {
+		a := strings.Split(strings.TrimPrefix(string(encrypt("mysecret", "hello")), encryptedValuePrefix), ":")
+		b := strings.Split(strings.TrimPrefix(string(encrypt("mysecret", "hello")), encryptedValuePrefix), ":")
+		require.Len(t, a, 2)
+		require.Len(t, b, 2)
+		assert.NotEqual(t, a[0], b[0])
+		assert.NotEqual(t, a[1], b[1])
+
+		// The value can't be decrypted with the data encryption key of another value
+		_, err := s.decodeValue("mysecret", []byte(encryptedValuePrefix+a[0]+":"+b[1]))
+		require.Error(t, err)
+	})
+
+	t.Run("encrypted with another key encryption key", func(t *testing.T) {
+		otherKey := make([]byte, 32)
+		_, err := rand.Read(otherKey)
+		require.NoError(t, err)
+		value, err := EncryptValue(otherKey, "mysecret", "hello")
+		require.NoError(t, err)
+
+		_, err = s.decodeValue("mysecret", []byte(value))
+		require.ErrorContains(t, err, "failed to unwrap data encryption key")
+	})
+
+	t.Run("encrypted value for another secret", func(t *testing.T) {
+		_, err := s.decodeValue("mysecret", encrypt("othersecret", "hello"))
+		require.Error(t, err)
+	})
+
+	t.Run("truncated ciphertext", func(t *testing.T) {
+		_, err := s.decodeValue("mysecret", []byte(encryptedValuePrefix+"AAAA"))
+		require.Error(t, err)
+		_, err = s.decodeValue("mysecret", []byte(encryptedValuePrefix+"AAAA:AAAA"))
+		require.Error(t, err)
+	})
+
+	t.Run("plaintext value when encryption is required", func(t *testing.T) {
+		s.metadata.RequireEncryption = true
+		defer func() {
+			s.metadata.RequireEncryption = false
+		}()
+
+		_, err := s.decodeValue("mysecret", []byte("hello"))
+		require.Error(t, err)
+	})
+}
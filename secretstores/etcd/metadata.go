@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/secretstores"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultKeyPrefixPath = "dapr/secrets"
+	defaultTimeout       = 5 * time.Second
+)
+
+type etcdMetadata struct {
+	// Comma-separated list of etcd endpoints.
+	Endpoints string `json:"endpoints" mapstructure:"endpoints"`
+	// Prefix for all keys holding secrets. Defaults to "dapr/secrets".
+	KeyPrefixPath string `json:"keyPrefixPath" mapstructure:"keyPrefixPath"`
+	// Timeout for operations against etcd. Defaults to 5s.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+
+	// TLS
+	TLSEnable bool   `json:"tlsEnable" mapstructure:"tlsEnable"`
+	CA        string `json:"ca" mapstructure:"ca"`
+	Cert      string `json:"cert" mapstructure:"cert"`
+	Key       string `json:"key" mapstructure:"key"`
+
+	// Base64-encoded AES key (16, 24, or 32 bytes) used as the key encryption key, to unwrap the data encryption key of each value encrypted client-side.
+	EncryptionKey string `json:"encryptionKey" mapstructure:"encryptionKey"`
+	// If true, values that are not encrypted are rejected.
+	RequireEncryption bool `json:"requireEncryption" mapstructure:"requireEncryption"`
+
+	// Internal
+	encryptionKey []byte
+}
+
+func (m *etcdMetadata) InitWithMetadata(meta secretstores.Metadata) error {
+	m.reset()
+
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(m.Endpoints) == "" {
+		return errors.New("metadata property 'endpoints' is required")
+	}
+
+	m.KeyPrefixPath = strings.TrimSuffix(m.KeyPrefixPath, "/")
+	if m.KeyPrefixPath == "" {
+		return errors.New("metadata property 'keyPrefixPath' must not be empty")
+	}
+
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+
+	if m.TLSEnable && (m.CA == "" || m.Cert == "" || m.Key == "") {
+		return errors.New("tls authentication information is incomplete")
+	}
+
+	if m.EncryptionKey != "" {
+		m.encryptionKey, err = base64.StdEncoding.DecodeString(m.EncryptionKey)
+		if err != nil {
+			return fmt.Errorf("metadata property 'encryptionKey' is not valid base64: %w", err)
+		}
+		switch len(m.encryptionKey) {
+		case 16, 24, 32:
+			// Nop
+		default:
+			return aes.KeySizeError(len(m.encryptionKey))
+		}
+
+		// Do not keep the encoded key around
+		m.EncryptionKey = ""
+	} else if m.RequireEncryption {
+		return errors.New("metadata property 'encryptionKey' is required when 'requireEncryption' is enabled")
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *etcdMetadata) reset() {
+	m.Endpoints = ""
+	m.KeyPrefixPath = defaultKeyPrefixPath
+	m.Timeout = defaultTimeout
+	m.TLSEnable = false
+	m.CA = ""
+	m.Cert = ""
+	m.Key = ""
+	m.EncryptionKey = ""
+	m.RequireEncryption = false
+	m.encryptionKey = nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Interval before retrying to renew the token after a failure.
+	tokenRenewRetryInterval = 10 * time.Second
+)
+
+// dynamicSecret is the credential returned by a dynamic secrets engine, e.g. "database/creds/<role>".
+type dynamicSecret struct {
+	path      string
+	leaseID   string
+	renewable bool
+	expiresAt time.Time
+	data      map[string]string
+}
+
+// vaultLeaseResponse is the response data from Vault when reading a dynamic secret or renewing its lease.
+type vaultLeaseResponse struct {
+	LeaseID       string         `json:"lease_id"`
+	LeaseDuration int64          `json:"lease_duration"`
+	Renewable     bool           `json:"renewable"`
+	Data          map[string]any `json:"data"`
+}
+
+// vaultTokenLookupResponse is the response data from Vault when looking up the token.
+type vaultTokenLookupResponse struct {
+	Data struct {
+		TTL       int64 `json:"ttl"`
+		Renewable bool  `json:"renewable"`
+	} `json:"data"`
+}
+
+// vaultTokenRenewResponse is the response data from Vault when renewing the token.
+type vaultTokenRenewResponse struct {
+	Auth struct {
+		LeaseDuration int64 `json:"lease_duration"`
+		Renewable     bool  `json:"renewable"`
+	} `json:"auth"`
+}
+
+// isDynamicSecret returns true if the secret is read from one of the configured dynamic secrets paths.
+func (v *vaultSecretStore) isDynamicSecret(name string) bool {
+	for _, p := range v.dynamicSecretPaths {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// getDynamicSecret returns the credentials for the dynamic secret, reusing the ones already issued while their lease is valid.
+func (v *vaultSecretStore) getDynamicSecret(ctx context.Context, name string) (map[string]string, error) {
+	v.leasesLock.Lock()
+	secret, ok := v.leases[name]
+	valid := ok && time.Now().Before(secret.expiresAt)
+	v.leasesLock.Unlock()
+	if valid {
+		return secret.data, nil
+	}
+
+	var res vaultLeaseResponse
+	err := v.doRequest(ctx, http.MethodGet, "/v1/"+name, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("getDynamicSecret %s failed: %w", name, err)
+	}
+
+	secret = &dynamicSecret{
+		path:      name,
+		leaseID:   res.LeaseID,
+		renewable: res.Renewable,
+		expiresAt: time.Now().Add(time.Duration(res.LeaseDuration) * time.Second),
+		data:      v.dynamicSecretData(name, res.Data),
+	}
+	if res.LeaseID == "" || res.LeaseDuration <= 0 {
+		// Not leased: do not cache it
+		return secret.data, nil
+	}
+
+	v.leasesLock.Lock()
+	v.leases[name] = secret
+	v.leasesLock.Unlock()
+
+	if secret.renewable {
+		v.wg.Add(1)
+		go func() {
+			defer v.wg.Done()
+			v.renewLease(secret, time.Duration(res.LeaseDuration)*time.Second)
+		}()
+	}
+
+	return secret.data, nil
+}
+
+// dynamicSecretData converts the data of a dynamic secret to string values.
+func (v *vaultSecretStore) dynamicSecretData(name string, data map[string]any) map[string]string {
+	if !v.vaultValueType.isMapType() {
+		b, _ := json.Marshal(data)
+		return map[string]string{name: string(b)}
+	}
+
+	res := make(map[string]string, len(data))
+	for k, val := range data {
+		switch val := val.(type) {
+		case nil:
+			res[k] = ""
+		case string:
+			res[k] = val
+		default:
+			b, _ := json.Marshal(val)
+			res[k] = string(b)
+		}
+	}
+	return res
+}
+
+// renewLease renews the lease of a dynamic secret in background, until it can't be renewed anymore or the store is closed.
+// Once the lease can't be renewed, the secret stays cached until it expires; new credentials are issued on the following request.
+func (v *vaultSecretStore) renewLease(secret *dynamicSecret, duration time.Duration) {
+	for {
+		t := time.NewTimer(duration * 2 / 3)
+		select {
+		case <-v.closeCh:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		v.leasesLock.Lock()
+		current := v.leases[secret.path] == secret
+		v.leasesLock.Unlock()
+		if !current {
+			// Replaced with new credentials
+			return
+		}
+
+		var res vaultLeaseResponse
+		err := v.doRequest(context.Background(), http.MethodPut, "/v1/sys/leases/renew", map[string]any{"lease_id": secret.leaseID}, &res)
+		if err != nil {
+			v.logger.Warnf("Failed to renew lease of dynamic secret %s: %v", secret.path, err)
+			return
+		}
+		if res.LeaseDuration <= 0 {
+			return
+		}
+
+		duration = time.Duration(res.LeaseDuration) * time.Second
+		v.leasesLock.Lock()
+		secret.expiresAt = time.Now().Add(duration)
+		v.leasesLock.Unlock()
+		v.logger.Debugf("Renewed lease of dynamic secret %s for %v", secret.path, duration)
+
+		if !res.Renewable {
+			return
+		}
+	}
+}
+
+// renewToken renews the Vault token in background, until it can't be renewed anymore or the store is closed.
+func (v *vaultSecretStore) renewToken() {
+	var lookup vaultTokenLookupResponse
+	err := v.doRequest(context.Background(), http.MethodGet, "/v1/auth/token/lookup-self", nil, &lookup)
+	if err != nil {
+		v.logger.Warnf("Failed to look up the Vault token, it will not be renewed: %v", err)
+		return
+	}
+	if !lookup.Data.Renewable || lookup.Data.TTL <= 0 {
+		v.logger.Infof("The Vault token is not renewable or has no expiration, it will not be renewed")
+		return
+	}
+
+	wait := time.Duration(lookup.Data.TTL) * time.Second * 2 / 3
+	for {
+		t := time.NewTimer(wait)
+		select {
+		case <-v.closeCh:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		var res vaultTokenRenewResponse
+		err = v.doRequest(context.Background(), http.MethodPost, "/v1/auth/token/renew-self", map[string]any{}, &res)
+		if err != nil {
+			v.logger.Warnf("Failed to renew the Vault token: %v", err)
+			wait = tokenRenewRetryInterval
+			continue
+		}
+		if !res.Auth.Renewable || res.Auth.LeaseDuration <= 0 {
+			v.logger.Infof("The Vault token can't be renewed anymore")
+			return
+		}
+
+		v.logger.Debugf("Renewed the Vault token for %ds", res.Auth.LeaseDuration)
+		wait = time.Duration(res.Auth.LeaseDuration) * time.Second * 2 / 3
+	}
+}
+
+// doRequest sends a request to Vault and decodes the JSON response into res.
+func (v *vaultSecretStore) doRequest(ctx context.Context, method, path string, body any, res any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, v.vaultAddress+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
+	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("couldn't send request: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		var b bytes.Buffer
+		io.Copy(&b, httpresp.Body)
+		if httpresp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("couldn't get successful response, status code %d, body %s", httpresp.StatusCode, b.String())
+	}
+
+	if err := json.NewDecoder(httpresp.Body).Decode(res); err != nil {
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeVault is a minimal Vault server for tests.
+type fakeVault struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests map[string]int
+	bodies   map[string][]map[string]any
+	handlers map[string]func(r *http.Request) any
+}
+
+func newFakeVault(t *testing.T, handlers map[string]func(r *http.Request) any) *fakeVault {
+	f := &fakeVault{
+		requests: map[string]int{},
+		bodies:   map[string][]map[string]any{},
+		handlers: handlers,
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, expectedTok, r.Header.Get(vaultHTTPHeader))
+		assert.Equal(t, "true", r.Header.Get(vaultHTTPRequestHeader))
+
+		key := r.Method + " " + r.URL.Path
+		f.lock.Lock()
+		f.requests[key]++
+		if r.ContentLength > 0 {
+			body := map[string]any{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			f.bodies[key] = append(f.bodies[key], body)
+		}
+		f.lock.Unlock()
+
+		handler, ok := f.handlers[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(handler(r))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeVault) count(key string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requests[key]
+}
+
+func initFakeVault(t *testing.T, f *fakeVault, props map[string]string) secretstores.SecretStore {
+	properties := map[string]string{
+		"vaultAddr":  f.URL,
+		"vaultToken": expectedTok,
+	}
+	for k, v := range props {
+		properties[k] = v
+	}
+
+	s := NewHashiCorpVaultSecretStore(logger.NewLogger("test"))
+	require.NoError(t, s.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestGetSecretVersion(t *testing.T) {
+	f := newFakeVault(t, map[string]func(r *http.Request) any{
+		"GET /v1/secret/data/dapr/mysecret": func(r *http.Request) any {
+			return map[string]any{
+				"data": map[string]any{
+					"data": map[string]string{"version": r.URL.Query().Get("version")},
+				},
+			}
+		},
+	})
+	s := initFakeVault(t, f, nil)
+
+	t.Run("latest version by default", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"version": "0"}, res.Data)
+	})
+
+	t.Run("version_id metadata", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "mysecret",
+			Metadata: map[string]string{"version_id": "2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"version": "2"}, res.Data)
+	})
+
+	t.Run("version metadata", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "mysecret",
+			Metadata: map[string]string{"version": "3"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"version": "3"}, res.Data)
+	})
+}
+
+func TestDynamicSecrets(t *testing.T) {
+	var issued atomic.Int32
+	f := newFakeVault(t, map[string]func(r *http.Request) any{
+		"GET /v1/database/creds/readonly": func(r *http.Request) any {
+			n := issued.Add(1)
+			return map[string]any{
+				"lease_id":       "database/creds/readonly/lease" + strconv.Itoa(int(n)),
+				"lease_duration": 1,
+				"renewable":      true,
+				"data": map[string]any{
+					"username": "user" + strconv.Itoa(int(n)),
+					"password": "pass",
+					"ttl":      1,
+					"token":    nil,
+				},
+			}
+		},
+		"PUT /v1/sys/leases/renew": func(r *http.Request) any {
+			return map[string]any{
+				"lease_id":       "database/creds/readonly/lease1",
+				"lease_duration": 1,
+				"renewable":      false,
+			}
+		},
+	})
+	s := initFakeVault(t, f, map[string]string{
+		"dynamicSecretPaths": "/database/creds/, aws/creds",
+	})
+
+	res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/creds/readonly"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"username": "user1",
+		"password": "pass",
+		"ttl":      "1",
+		"token":    "",
+	}, res.Data)
+
+	// Credentials are reused while the lease is valid
+	res, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/creds/readonly"})
+	require.NoError(t, err)
+	assert.Equal(t, "user1", res.Data["username"])
+	assert.Equal(t, 1, f.count("GET /v1/database/creds/readonly"))
+
+	// The lease is renewed before it expires
+	require.Eventually(t, func() bool {
+		return f.count("PUT /v1/sys/leases/renew") == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	f.lock.Lock()
+	assert.Equal(t, []map[string]any{{"lease_id": "database/creds/readonly/lease1"}}, f.bodies["PUT /v1/sys/leases/renew"])
+	f.lock.Unlock()
+
+	// Once the lease can't be renewed anymore and expires, new credentials are issued
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		res, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/creds/readonly"})
+		require.NoError(c, err)
+		assert.Equal(c, "user2", res.Data["username"])
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 1, f.count("PUT /v1/sys/leases/renew"))
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "aws/creds/missing"})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("other secrets are read from the KV engine", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/other"})
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, f.count("GET /v1/secret/data/dapr/database/other"))
+	})
+}
+
+func TestDynamicSecretsTextValueType(t *testing.T) {
+	f := newFakeVault(t, map[string]func(r *http.Request) any{
+		"GET /v1/database/creds/readonly": func(r *http.Request) any {
+			return map[string]any{
+				"data": map[string]any{"username": "user", "password": "pass"},
+			}
+		},
+	})
+	s := initFakeVault(t, f, map[string]string{
+		"dynamicSecretPaths": "database/creds",
+		"vaultValueType":     "text",
+	})
+
+	res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/creds/readonly"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"username":"user","password":"pass"}`, res.Data["database/creds/readonly"])
+
+	// Secrets without a lease are not cached
+	_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "database/creds/readonly"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.count("GET /v1/database/creds/readonly"))
+}
+
+func TestRenewToken(t *testing.T) {
+	t.Run("renewable token", func(t *testing.T) {
+		f := newFakeVault(t, map[string]func(r *http.Request) any{
+			"GET /v1/auth/token/lookup-self": func(r *http.Request) any {
+				return map[string]any{"data": map[string]any{"ttl": 1, "renewable": true}}
+			},
+			"POST /v1/auth/token/renew-self": func(r *http.Request) any {
+				return map[string]any{"auth": map[string]any{"lease_duration": 1, "renewable": true}}
+			},
+		})
+		s := initFakeVault(t, f, map[string]string{"renewToken": "true"})
+
+		require.Eventually(t, func() bool {
+			return f.count("POST /v1/auth/token/renew-self") >= 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, s.Close())
+		n := f.count("POST /v1/auth/token/renew-self")
+		time.Sleep(time.Second)
+		assert.Equal(t, n, f.count("POST /v1/auth/token/renew-self"))
+	})
+
+	t.Run("token without expiration", func(t *testing.T) {
+		f := newFakeVault(t, map[string]func(r *http.Request) any{
+			"GET /v1/auth/token/lookup-self": func(r *http.Request) any {
+				return map[string]any{"data": map[string]any{"ttl": 0, "renewable": false}}
+			},
+		})
+		s := initFakeVault(t, f, map[string]string{"renewToken": "true"})
+
+		require.Eventually(t, func() bool {
+			return f.count("GET /v1/auth/token/lookup-self") == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, s.Close())
+		assert.Equal(t, 0, f.count("POST /v1/auth/token/renew-self"))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		f := newFakeVault(t, nil)
+		s := initFakeVault(t, f, nil)
+		require.NoError(t, s.Close())
+		assert.Equal(t, 0, f.count("GET /v1/auth/token/lookup-self"))
+	})
+}
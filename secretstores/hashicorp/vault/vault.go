@@ -16,21 +16,20 @@ package vault
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	jsoniter "github.com/json-iterator/go"
-	"golang.org/x/net/http2"
 
+	vaultAuth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
+	"github.com/dapr/components-contrib/common/httputils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -38,7 +37,7 @@ import (
 )
 
 const (
-	defaultVaultAddress          string = "https://127.0.0.1:8200"
+	defaultVaultAddress          string = vaultAuth.DefaultAddress
 	defaultVaultEnginePath       string = "secret"
 	componentVaultAddress        string = "vaultAddr"
 	componentCaCert              string = "caCert"
@@ -51,11 +50,12 @@ const (
 	componentVaultKVPrefix       string = "vaultKVPrefix"
 	componentVaultKVUsePrefix    string = "vaultKVUsePrefix"
 	defaultVaultKVPrefix         string = "dapr"
-	vaultHTTPHeader              string = "X-Vault-Token"
-	vaultHTTPRequestHeader       string = "X-Vault-Request"
+	vaultHTTPHeader              string = vaultAuth.TokenHeader
+	vaultHTTPRequestHeader       string = vaultAuth.RequestHeader
 	vaultEnginePath              string = "enginePath"
 	vaultValueType               string = "vaultValueType"
 	versionID                    string = "version_id"
+	versionAlias                 string = "version"
 
 	DataStr string = "data"
 )
@@ -84,6 +84,13 @@ type vaultSecretStore struct {
 	vaultKVPrefix       string
 	vaultEnginePath     string
 	vaultValueType      valueType
+	dynamicSecretPaths  []string
+
+	leases     map[string]*dynamicSecret
+	leasesLock sync.Mutex
+	closed     atomic.Bool
+	closeCh    chan struct{}
+	wg         sync.WaitGroup
 
 	json jsoniter.API
 
@@ -91,6 +98,8 @@ type vaultSecretStore struct {
 }
 
 type VaultMetadata struct {
+	httputils.ProxyMetadata `mapstructure:",squash"`
+
 	CaCert              string
 	CaPath              string
 	CaPem               string
@@ -103,6 +112,8 @@ type VaultMetadata struct {
 	VaultTokenMountPath string
 	EnginePath          string
 	VaultValueType      string
+	DynamicSecretPaths  []string
+	RenewToken          bool
 }
 
 // tlsConfig is TLS configuration to interact with HashiCorp Vault.
@@ -131,9 +142,11 @@ type vaultListKVResponse struct {
 // NewHashiCorpVaultSecretStore returns a new HashiCorp Vault secret store.
 func NewHashiCorpVaultSecretStore(logger logger.Logger) secretstores.SecretStore {
 	return &vaultSecretStore{
-		client: &http.Client{},
-		logger: logger,
-		json:   jsoniter.ConfigFastest,
+		client:  &http.Client{},
+		logger:  logger,
+		json:    jsoniter.ConfigFastest,
+		leases:  map[string]*dynamicSecret{},
+		closeCh: make(chan struct{}),
 	}
 }
 
@@ -189,13 +202,35 @@ func (v *vaultSecretStore) Init(_ context.Context, meta secretstores.Metadata) e
 	// Generate TLS config
 	tlsConf := metadataToTLSConfig(&m)
 
-	client, err := v.createHTTPClient(tlsConf)
+	client, err := v.createHTTPClient(tlsConf, m.ProxyMetadata)
 	if err != nil {
 		return fmt.Errorf("couldn't create client using config: %w", err)
 	}
 
 	v.client = client
 
+	if v.leases == nil {
+		v.leases = map[string]*dynamicSecret{}
+	}
+	if v.closeCh == nil {
+		v.closeCh = make(chan struct{})
+	}
+	v.dynamicSecretPaths = make([]string, 0, len(m.DynamicSecretPaths))
+	for _, p := range m.DynamicSecretPaths {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p != "" {
+			v.dynamicSecretPaths = append(v.dynamicSecretPaths, p)
+		}
+	}
+
+	if m.RenewToken {
+		v.wg.Add(1)
+		go func() {
+			defer v.wg.Done()
+			v.renewToken()
+		}()
+	}
+
 	return nil
 }
 
@@ -280,11 +315,15 @@ func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string
 
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
 func (v *vaultSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
-	// version 0 represent for latest version
-	version := "0"
-	if value, ok := req.Metadata[versionID]; ok {
-		version = value
+	if v.isDynamicSecret(req.Name) {
+		data, err := v.getDynamicSecret(ctx, req.Name)
+		if err != nil {
+			return secretstores.GetSecretResponse{Data: nil}, err
+		}
+		return secretstores.GetSecretResponse{Data: data}, nil
 	}
+
+	version := requestVersion(req.Metadata)
 	d, err := v.getSecret(ctx, req.Name, version)
 	if err != nil {
 		return secretstores.GetSecretResponse{Data: nil}, err
@@ -297,12 +336,21 @@ func (v *vaultSecretStore) GetSecret(ctx context.Context, req secretstores.GetSe
 	return resp, nil
 }
 
+// requestVersion returns the version of the KV secrets to read from the request metadata.
+func requestVersion(md map[string]string) string {
+	if value, ok := md[versionID]; ok {
+		return value
+	}
+	if value, ok := md[versionAlias]; ok {
+		return value
+	}
+	// version 0 represent for latest version
+	return "0"
+}
+
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
 func (v *vaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
-	version := "0"
-	if value, ok := req.Metadata[versionID]; ok {
-		version = value
-	}
+	version := requestVersion(req.Metadata)
 
 	resp := secretstores.BulkGetSecretResponse{
 		Data: map[string]map[string]string{},
@@ -398,133 +446,23 @@ func (v *vaultSecretStore) isSecretPath(key string) bool {
 
 // initVaultToken reads the vault token from the file if token is defined by mount path.
 func (v *vaultSecretStore) initVaultToken() error {
-	// Test that at least one of them are set if not return error
-	if v.vaultToken == "" && v.vaultTokenMountPath == "" {
-		return errors.New("token mount path and token not set")
-	}
-
-	// Test that both are not set. If so return error
-	if v.vaultToken != "" && v.vaultTokenMountPath != "" {
-		return errors.New("token mount path and token both set")
-	}
-
-	if v.vaultToken != "" {
-		return nil
-	}
-
-	data, err := os.ReadFile(v.vaultTokenMountPath)
+	token, err := vaultAuth.ReadToken(v.vaultToken, v.vaultTokenMountPath)
 	if err != nil {
-		return fmt.Errorf("couldn't read vault token from mount path %s err: %s", v.vaultTokenMountPath, err)
-	}
-	v.vaultToken = string(bytes.TrimSpace(data))
-
-	return nil
-}
-
-func (v *vaultSecretStore) createHTTPClient(config *tlsConfig) (*http.Client, error) {
-	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
-
-	if config != nil && config.vaultSkipVerify {
-		v.logger.Infof("hashicorp vault: you are using 'skipVerify' to skip server config verify which is unsafe!")
-	}
-
-	tlsClientConfig.InsecureSkipVerify = config.vaultSkipVerify
-	if !config.vaultSkipVerify {
-		rootCAPools, err := v.getRootCAsPools(config.vaultCAPem, config.vaultCAPath, config.vaultCACert)
-		if err != nil {
-			return nil, err
-		}
-
-		tlsClientConfig.RootCAs = rootCAPools
-
-		if config.vaultServerName != "" {
-			tlsClientConfig.ServerName = config.vaultServerName
-		}
-	}
-
-	// Setup http transport
-	transport := &http.Transport{
-		TLSClientConfig: tlsClientConfig,
-	}
-
-	// Configure http2 client
-	err := http2.ConfigureTransport(transport)
-	if err != nil {
-		return nil, errors.New("failed to configure http2")
-	}
-
-	return &http.Client{
-		Transport: transport,
-	}, nil
-}
-
-// getRootCAsPools returns root CAs when you give it CA Pem file, CA path, and CA Certificate. Default is system certificates.
-func (v *vaultSecretStore) getRootCAsPools(vaultCAPem string, vaultCAPath string, vaultCACert string) (*x509.CertPool, error) {
-	if vaultCAPem != "" {
-		certPool := x509.NewCertPool()
-		cert := []byte(vaultCAPem)
-		if ok := certPool.AppendCertsFromPEM(cert); !ok {
-			return nil, errors.New("couldn't read PEM")
-		}
-
-		return certPool, nil
-	}
-
-	if vaultCAPath != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFolder(certPool, vaultCAPath); err != nil {
-			return nil, err
-		}
-
-		return certPool, nil
-	}
-
-	if vaultCACert != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFile(certPool, vaultCACert); err != nil {
-			return nil, err
-		}
-
-		return certPool, nil
-	}
-
-	certPool, err := x509.SystemCertPool()
-	if err != nil {
-		return nil, fmt.Errorf("couldn't read system certs: %s", err)
-	}
-
-	return certPool, nil
-}
-
-// readCertificateFile reads the certificate at given path.
-func readCertificateFile(certPool *x509.CertPool, path string) error {
-	// Read certificate file
-	pemFile, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("couldn't read CA file from disk: %s", err)
-	}
-
-	if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
-		return errors.New("couldn't read PEM")
+		return err
 	}
+	v.vaultToken = token
 
 	return nil
 }
 
-// readCertificateFolder scans a folder for certificates.
-func readCertificateFolder(certPool *x509.CertPool, path string) error {
-	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
-		}
-
-		return readCertificateFile(certPool, p)
-	})
-	if err != nil {
-		return fmt.Errorf("couldn't read certificates at %s: %s", path, err)
-	}
-
-	return nil
+func (v *vaultSecretStore) createHTTPClient(config *tlsConfig, proxy httputils.ProxyMetadata) (*http.Client, error) {
+	return vaultAuth.NewHTTPClient(vaultAuth.TLSConfig{
+		CAPem:      config.vaultCAPem,
+		CACert:     config.vaultCACert,
+		CAPath:     config.vaultCAPath,
+		SkipVerify: config.vaultSkipVerify,
+		ServerName: config.vaultServerName,
+	}, proxy, v.logger)
 }
 
 // Features returns the features available in this secret store.
@@ -543,5 +481,9 @@ func (v *vaultSecretStore) GetComponentMetadata() (metadataInfo metadata.Metadat
 }
 
 func (v *vaultSecretStore) Close() error {
+	if v.closed.CompareAndSwap(false, true) && v.closeCh != nil {
+		close(v.closeCh)
+	}
+	v.wg.Wait()
 	return nil
 }
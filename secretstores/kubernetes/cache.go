@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Default time to wait for the Secrets of a namespace to be loaded the first time they're requested.
+const defaultSyncTimeout = 5 * time.Second
+
+// secretCache keeps a local cache of the Secrets, using informers so they're not requested to the API server every time.
+// Informers are started lazily, for each namespace that is requested.
+type secretCache struct {
+	client      kubernetes.Interface
+	syncTimeout time.Duration
+	logger      logger.Logger
+
+	lock       sync.Mutex
+	namespaces map[string]*namespaceInformer
+	stopCh     chan struct{}
+	closeOnce  sync.Once
+}
+
+type namespaceInformer struct {
+	lister   corelisters.SecretLister
+	synced   cache.InformerSynced
+	syncOnce sync.Once
+}
+
+func newSecretCache(client kubernetes.Interface, syncTimeout time.Duration, logger logger.Logger) *secretCache {
+	return &secretCache{
+		client:      client,
+		syncTimeout: syncTimeout,
+		logger:      logger,
+		namespaces:  map[string]*namespaceInformer{},
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// lister returns the lister for the Secrets of the namespace, or nil if the cache isn't ready.
+func (c *secretCache) lister(ctx context.Context, namespace string) corelisters.SecretNamespaceLister {
+	ni := c.getInformer(namespace)
+
+	// The first time a namespace is requested, wait for the cache to be loaded.
+	// After that, if the cache isn't ready (for example, because of missing RBAC permissions), return right away.
+	ni.syncOnce.Do(func() {
+		waitCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if !cache.WaitForCacheSync(waitCtx.Done(), ni.synced) {
+			c.logger.Warnf("Timed out waiting for Secrets in namespace %s to be loaded", namespace)
+		}
+	})
+	if !ni.synced() {
+		return nil
+	}
+
+	return ni.lister.Secrets(namespace)
+}
+
+// get returns the Secret from the cache.
+// The second value is false if the cache isn't ready and the Secret must be requested to the API server.
+func (c *secretCache) get(ctx context.Context, namespace string, name string) (*corev1.Secret, bool, error) {
+	lister := c.lister(ctx, namespace)
+	if lister == nil {
+		return nil, false, nil
+	}
+
+	secret, err := lister.Get(name)
+	return secret, true, err
+}
+
+// list returns all Secrets of the namespace from the cache.
+// The second value is false if the cache isn't ready and the Secrets must be requested to the API server.
+func (c *secretCache) list(ctx context.Context, namespace string) ([]*corev1.Secret, bool, error) {
+	lister := c.lister(ctx, namespace)
+	if lister == nil {
+		return nil, false, nil
+	}
+
+	secrets, err := lister.List(labels.Everything())
+	return secrets, true, err
+}
+
+// getInformer returns the informer for the namespace, starting it if needed.
+func (c *secretCache) getInformer(namespace string) *namespaceInformer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ni, ok := c.namespaces[namespace]
+	if ok {
+		return ni
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(c.client, 0, informers.WithNamespace(namespace))
+	informer := factory.Core().V1().Secrets()
+	ni = &namespaceInformer{
+		lister: informer.Lister(),
+		synced: informer.Informer().HasSynced,
+	}
+	factory.Start(c.stopCh)
+	c.namespaces[namespace] = ni
+
+	c.logger.Debugf("Started watching Secrets in namespace %s", namespace)
+	return ni
+}
+
+// close stops all informers.
+func (c *secretCache) close() {
+	c.closeOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
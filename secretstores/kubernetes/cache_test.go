@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func newSecret(namespace, name string, data map[string]string) *corev1.Secret {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		s.Data[k] = []byte(v)
+	}
+	return s
+}
+
+// countRequests returns the number of requests of the verb sent to the API server for Secrets.
+func countRequests(client *fake.Clientset, verb string) int {
+	n := 0
+	for _, a := range client.Actions() {
+		if a.GetVerb() == verb && a.GetResource().Resource == "secrets" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSecretCache(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newSecret("abc", "secret1", map[string]string{"key": "value1"}),
+		newSecret("abc", "secret2", map[string]string{"key": "value2"}),
+		newSecret("def", "secret3", map[string]string{"key": "value3"}),
+	)
+
+	store := &kubernetesSecretStore{
+		kubeClient: client,
+		logger:     logger.NewLogger("test"),
+	}
+	store.cache = newSecretCache(client, time.Second, store.logger)
+	defer store.Close()
+
+	t.Run("get secret", func(t *testing.T) {
+		for range 3 {
+			res, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+				Name:     "secret1",
+				Metadata: map[string]string{"namespace": "abc"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"key": "value1"}, res.Data)
+		}
+		assert.Equal(t, 0, countRequests(client, "get"))
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		_, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "secret3",
+			Metadata: map[string]string{"namespace": "abc"},
+		})
+		require.Error(t, err)
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("bulk get secrets", func(t *testing.T) {
+		res, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{"namespace": "abc"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"secret1": {"key": "value1"},
+			"secret2": {"key": "value2"},
+		}, res.Data)
+	})
+
+	t.Run("cache is updated", func(t *testing.T) {
+		_, err := client.CoreV1().Secrets("abc").Update(context.Background(), newSecret("abc", "secret1", map[string]string{"key": "updated"}), metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			res, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+				Name:     "secret1",
+				Metadata: map[string]string{"namespace": "abc"},
+			})
+			require.NoError(c, err)
+			assert.Equal(c, "updated", res.Data["key"])
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	// Only one list for each namespace is sent to start the informers
+	assert.Equal(t, 1, countRequests(client, "list"))
+	assert.Equal(t, 0, countRequests(client, "get"))
+}
+
+func TestSecretCacheNotReady(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newSecret("abc", "secret1", map[string]string{"key": "value1"}),
+	)
+	// Simulate missing permissions to list secrets
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", nil)
+	})
+
+	store := &kubernetesSecretStore{
+		kubeClient: client,
+		logger:     logger.NewLogger("test"),
+	}
+	store.cache = newSecretCache(client, 100*time.Millisecond, store.logger)
+	defer store.Close()
+
+	// Falls back to the API server
+	res, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+		Name:     "secret1",
+		Metadata: map[string]string{"namespace": "abc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "value1"}, res.Data)
+	assert.Equal(t, 1, countRequests(client, "get"))
+}
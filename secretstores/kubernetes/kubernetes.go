@@ -18,7 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -34,6 +36,8 @@ type kubernetesSecretStore struct {
 	kubeClient kubernetes.Interface
 	md         kubernetesMetadata
 	logger     logger.Logger
+	// cache is set when caching is enabled.
+	cache *secretCache
 }
 
 // NewKubernetesSecretStore returns a new Kubernetes secret store.
@@ -59,6 +63,10 @@ func (k *kubernetesSecretStore) Init(_ context.Context, metadata secretstores.Me
 		return err
 	}
 
+	if k.md.EnableCache {
+		k.cache = newSecretCache(k.kubeClient, defaultSyncTimeout, k.logger)
+	}
+
 	return nil
 }
 
@@ -72,7 +80,7 @@ func (k *kubernetesSecretStore) GetSecret(ctx context.Context, req secretstores.
 		return resp, err
 	}
 
-	secret, err := k.kubeClient.CoreV1().Secrets(namespace).Get(ctx, req.Name, metav1.GetOptions{})
+	secret, err := k.getSecret(ctx, namespace, req.Name)
 	if err != nil {
 		return resp, err
 	}
@@ -94,12 +102,12 @@ func (k *kubernetesSecretStore) BulkGetSecret(ctx context.Context, req secretsto
 		return resp, err
 	}
 
-	secrets, err := k.kubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	secrets, err := k.listSecrets(ctx, namespace)
 	if err != nil {
 		return resp, err
 	}
 
-	for _, s := range secrets.Items {
+	for _, s := range secrets {
 		resp.Data[s.Name] = map[string]string{}
 		for k, v := range s.Data {
 			resp.Data[s.Name][k] = string(v)
@@ -109,7 +117,52 @@ func (k *kubernetesSecretStore) BulkGetSecret(ctx context.Context, req secretsto
 	return resp, nil
 }
 
+// getSecret returns the Secret from the cache if enabled and ready, or from the API server otherwise.
+func (k *kubernetesSecretStore) getSecret(ctx context.Context, namespace string, name string) (*corev1.Secret, error) {
+	if k.cache != nil {
+		secret, ok, err := k.cache.get(ctx, namespace, name)
+		if ok {
+			return secret, err
+		}
+	}
+
+	return k.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// listSecrets returns all Secrets of the namespace from the cache if enabled and ready, or from the API server otherwise.
+func (k *kubernetesSecretStore) listSecrets(ctx context.Context, namespace string) ([]*corev1.Secret, error) {
+	if k.cache != nil {
+		secrets, ok, err := k.cache.list(ctx, namespace)
+		if ok {
+			return secrets, err
+		}
+	}
+
+	list, err := k.kubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]*corev1.Secret, len(list.Items))
+	for i := range list.Items {
+		secrets[i] = &list.Items[i]
+	}
+	return secrets, nil
+}
+
 func (k *kubernetesSecretStore) getNamespaceFromMetadata(metadata map[string]string) (string, error) {
+	namespace, err := k.resolveNamespace(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	if len(k.md.AllowedNamespaces) > 0 && !slices.Contains(k.md.AllowedNamespaces, namespace) {
+		return "", fmt.Errorf("access to secrets in namespace %s is not allowed", namespace)
+	}
+
+	return namespace, nil
+}
+
+func (k *kubernetesSecretStore) resolveNamespace(metadata map[string]string) (string, error) {
 	if val, ok := metadata["namespace"]; ok && val != "" {
 		return val, nil
 	}
@@ -136,5 +189,8 @@ func (k *kubernetesSecretStore) GetComponentMetadata() (metadataInfo metadata.Me
 }
 
 func (k *kubernetesSecretStore) Close() error {
+	if k.cache != nil {
+		k.cache.close()
+	}
 	return nil
 }
@@ -20,6 +20,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 )
 
@@ -65,6 +67,33 @@ func TestGetNamespace(t *testing.T) {
 	})
 }
 
+func TestAllowedNamespaces(t *testing.T) {
+	store := kubernetesSecretStore{logger: logger.NewLogger("test")}
+	err := store.md.InitWithMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"defaultNamespace":  "c",
+		"allowedNamespaces": "a, b",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, store.md.AllowedNamespaces)
+
+	t.Run("allowed namespace", func(t *testing.T) {
+		ns, err := store.getNamespaceFromMetadata(map[string]string{"namespace": "b"})
+		require.NoError(t, err)
+		assert.Equal(t, "b", ns)
+	})
+
+	t.Run("namespace not allowed", func(t *testing.T) {
+		_, err := store.getNamespaceFromMetadata(map[string]string{"namespace": "d"})
+		require.ErrorContains(t, err, "namespace d is not allowed")
+	})
+
+	t.Run("default namespace not allowed", func(t *testing.T) {
+		t.Setenv("NAMESPACE", "")
+		_, err := store.getNamespaceFromMetadata(map[string]string{})
+		require.ErrorContains(t, err, "namespace c is not allowed")
+	})
+}
+
 func TestGetFeatures(t *testing.T) {
 	s := kubernetesSecretStore{logger: logger.NewLogger("test")}
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
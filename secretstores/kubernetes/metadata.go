@@ -14,6 +14,8 @@ limitations under the License.
 package kubernetes
 
 import (
+	"strings"
+
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/metadata"
 )
@@ -26,6 +28,12 @@ type kubernetesMetadata struct {
 	// Path to a kubeconfig file.
 	// If empty, uses the default values.
 	KubeconfigPath string `json:"kubeconfigPath" mapstructure:"kubeconfigPath"`
+
+	// If set, only secrets in these namespaces can be read.
+	AllowedNamespaces []string `json:"allowedNamespaces" mapstructure:"allowedNamespaces"`
+
+	// If true, secrets are cached locally using informers, which requires permissions to list and watch them.
+	EnableCache bool `json:"enableCache" mapstructure:"enableCache"`
 }
 
 func (m *kubernetesMetadata) InitWithMetadata(meta secretstores.Metadata) error {
@@ -37,10 +45,22 @@ func (m *kubernetesMetadata) InitWithMetadata(meta secretstores.Metadata) error
 		return err
 	}
 
+	allowed := make([]string, 0, len(m.AllowedNamespaces))
+	for _, ns := range m.AllowedNamespaces {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			allowed = append(allowed, ns)
+		}
+	}
+	m.AllowedNamespaces = allowed
+
 	return nil
 }
 
 // Reset the object
 func (m *kubernetesMetadata) reset() {
 	m.DefaultNamespace = ""
+	m.KubeconfigPath = ""
+	m.AllowedNamespaces = nil
+	m.EnableCache = false
 }
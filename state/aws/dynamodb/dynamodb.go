@@ -25,6 +25,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/cenkalti/backoff/v4"
 	jsoniterator "github.com/json-iterator/go"
 
 	awsAuth "github.com/dapr/components-contrib/common/authentication/aws"
@@ -45,6 +46,7 @@ type StateStore struct {
 	table            string
 	ttlAttributeName string
 	partitionKey     string
+	pingTimeout      time.Duration
 }
 
 type dynamoDBMetadata struct {
@@ -59,17 +61,27 @@ type dynamoDBMetadata struct {
 	Table            string `json:"table"`
 	TTLAttributeName string `json:"ttlAttributeName"`
 	PartitionKey     string `json:"partitionKey"`
+	// Maximum time to wait for the table when checking the health of the component.
+	PingTimeout time.Duration `json:"pingTimeout"`
 }
 
 const (
 	defaultPartitionKeyName = "key"
 	metadataPartitionKey    = "partitionKey"
+
+	// Maximum number of items in a BatchWriteItem request.
+	batchWriteMaxItems = 25
+	// Maximum number of retries for items that were not processed by BatchWriteItem.
+	batchWriteMaxRetries = 10
+
+	defaultPingTimeout = 5 * time.Second
 )
 
 // NewDynamoDBStateStore returns a new dynamoDB state store.
 func NewDynamoDBStateStore(logger logger.Logger) state.Store {
 	s := &StateStore{
 		partitionKey: defaultPartitionKeyName,
+		pingTimeout:  defaultPingTimeout,
 		logger:       logger,
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
@@ -103,6 +115,7 @@ func (d *StateStore) Init(ctx context.Context, metadata state.Metadata) error {
 	d.table = meta.Table
 	d.ttlAttributeName = meta.TTLAttributeName
 	d.partitionKey = meta.PartitionKey
+	d.pingTimeout = meta.PingTimeout
 
 	if err := d.validateTableAccess(ctx); err != nil {
 		return fmt.Errorf("error validating DynamoDB table '%s' access: %w", d.table, err)
@@ -127,6 +140,25 @@ func (d *StateStore) validateTableAccess(ctx context.Context) error {
 	return err
 }
 
+// Ping checks the connection to DynamoDB and the access to the table with a dummy Get operation.
+func (d *StateStore) Ping(ctx context.Context) error {
+	if d.authProvider == nil {
+		return errors.New("dynamodb error: component is not initialized")
+	}
+
+	timeout := d.pingTimeout
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := d.validateTableAccess(ctx); err != nil {
+		return fmt.Errorf("error validating DynamoDB table '%s' access: %w", d.table, err)
+	}
+	return nil
+}
+
 // Features returns the features available in this state store.
 func (d *StateStore) Features() []state.Feature {
 	// TTLs are enabled only if ttlAttributeName is set
@@ -269,6 +301,101 @@ func (d *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 	return err
 }
 
+// BulkSet stores multiple items using BatchWriteItem, in chunks of 25 items.
+// BatchWriteItem doesn't support conditions, so if any request requires concurrency checks, items are stored one by one.
+func (d *StateStore) BulkSet(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+	writes := make([]*dynamodb.WriteRequest, 0, len(req))
+	// BatchWriteItem fails if the same key is in the request more than once, so the last request for a key takes precedence
+	idx := make(map[string]int, len(req))
+	for i := range req {
+		if req[i].HasETag() || req[i].Options.Concurrency == state.FirstWrite {
+			return state.DoBulkSetDelete(ctx, req, d.Set, opts)
+		}
+
+		item, err := d.getItemFromReq(&req[i])
+		if err != nil {
+			return err
+		}
+		w := &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{Item: item},
+		}
+		if j, ok := idx[req[i].Key]; ok {
+			writes[j] = w
+			continue
+		}
+		idx[req[i].Key] = len(writes)
+		writes = append(writes, w)
+	}
+
+	return d.batchWrite(ctx, writes)
+}
+
+// BulkDelete removes multiple items using BatchWriteItem, in chunks of 25 items.
+// BatchWriteItem doesn't support conditions, so if any request has an ETag, items are removed one by one.
+func (d *StateStore) BulkDelete(ctx context.Context, req []state.DeleteRequest, opts state.BulkStoreOpts) error {
+	writes := make([]*dynamodb.WriteRequest, 0, len(req))
+	seen := make(map[string]struct{}, len(req))
+	for i := range req {
+		if req[i].HasETag() {
+			return state.DoBulkSetDelete(ctx, req, d.Delete, opts)
+		}
+
+		if _, ok := seen[req[i].Key]; ok {
+			continue
+		}
+		seen[req[i].Key] = struct{}{}
+		writes = append(writes, &dynamodb.WriteRequest{
+			DeleteRequest: &dynamodb.DeleteRequest{
+				Key: map[string]*dynamodb.AttributeValue{
+					d.partitionKey: {
+						S: ptr.Of(req[i].Key),
+					},
+				},
+			},
+		})
+	}
+
+	return d.batchWrite(ctx, writes)
+}
+
+// batchWrite performs the writes in chunks of up to 25 items, which is the limit of BatchWriteItem.
+func (d *StateStore) batchWrite(ctx context.Context, writes []*dynamodb.WriteRequest) error {
+	for start := 0; start < len(writes); start += batchWriteMaxItems {
+		end := min(start+batchWriteMaxItems, len(writes))
+		err := d.batchWriteChunk(ctx, writes[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchWriteChunk invokes BatchWriteItem, retrying items that were not processed, for example because of throttling, with a jittered exponential backoff.
+func (d *StateStore) batchWriteChunk(ctx context.Context, writes []*dynamodb.WriteRequest) error {
+	pending := map[string][]*dynamodb.WriteRequest{
+		d.table: writes,
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = 50 * time.Millisecond
+	bo.MaxInterval = 5 * time.Second
+	b := backoff.WithContext(backoff.WithMaxRetries(bo, batchWriteMaxRetries), ctx)
+	return backoff.Retry(func() error {
+		res, err := d.authProvider.DynamoDB().DynamoDB.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if len(res.UnprocessedItems[d.table]) == 0 {
+			return nil
+		}
+
+		pending = res.UnprocessedItems
+		return fmt.Errorf("dynamodb error: %d items were not processed", len(pending[d.table]))
+	}, b)
+}
+
 func (d *StateStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 	metadataStruct := dynamoDBMetadata{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.StateStoreType)
@@ -283,7 +410,9 @@ func (d *StateStore) Close() error {
 }
 
 func (d *StateStore) getDynamoDBMetadata(meta state.Metadata) (*dynamoDBMetadata, error) {
-	var m dynamoDBMetadata
+	m := dynamoDBMetadata{
+		PingTimeout: defaultPingTimeout,
+	}
 	err := kitmd.DecodeMetadata(meta.Properties, &m)
 	if m.Table == "" {
 		return nil, errors.New("missing dynamodb table name")
@@ -404,20 +533,21 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		twi := &dynamodb.TransactWriteItem{}
 		switch req := o.(type) {
 		case state.SetRequest:
-			value, err := d.marshalToString(req.Value)
+			item, err := d.getItemFromReq(&req)
 			if err != nil {
-				return fmt.Errorf("dynamodb error: failed to marshal value for key %s: %w", req.Key, err)
+				return err
 			}
 			twi.Put = &dynamodb.Put{
 				TableName: ptr.Of(d.table),
-				Item: map[string]*dynamodb.AttributeValue{
-					d.partitionKey: {
-						S: ptr.Of(req.Key),
-					},
-					"value": {
-						S: ptr.Of(value),
-					},
-				},
+				Item:      item,
+			}
+			if req.HasETag() {
+				twi.Put.ConditionExpression = ptr.Of("etag = :etag")
+				twi.Put.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+					":etag": {S: req.ETag},
+				}
+			} else if req.Options.Concurrency == state.FirstWrite {
+				twi.Put.ConditionExpression = ptr.Of("attribute_not_exists(etag)")
 			}
 
 		case state.DeleteRequest:
@@ -429,10 +559,27 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 					},
 				},
 			}
+			if req.HasETag() {
+				twi.Delete.ConditionExpression = ptr.Of("etag = :etag")
+				twi.Delete.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+					":etag": {S: req.ETag},
+				}
+			}
 		}
 		twinput.TransactItems = append(twinput.TransactItems, twi)
 	}
 	_, err := d.authProvider.DynamoDB().DynamoDB.TransactWriteItemsWithContext(ctx, twinput)
+	if err != nil {
+		// If the transaction was canceled because of a failed condition, that's an ETag mismatch
+		var cErr *dynamodb.TransactionCanceledException
+		if errors.As(err, &cErr) {
+			for _, r := range cErr.CancellationReasons {
+				if r != nil && r.Code != nil && *r.Code == "ConditionalCheckFailed" {
+					return state.NewETagError(state.ETagMismatch, err)
+				}
+			}
+		}
+	}
 
 	return err
 }
@@ -18,6 +18,8 @@ package dynamodb
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
 )
 
 type DynamoDBItem struct {
@@ -115,6 +118,20 @@ func TestInit(t *testing.T) {
 		assert.Equal(t, s.partitionKey, pkey)
 	})
 
+	t.Run("Init with ping timeout", func(t *testing.T) {
+		m.Properties = map[string]string{
+			"Table": "a",
+		}
+		err := s.Init(context.Background(), m)
+		require.NoError(t, err)
+		assert.Equal(t, defaultPingTimeout, s.pingTimeout)
+
+		m.Properties["pingTimeout"] = "10s"
+		err = s.Init(context.Background(), m)
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Second, s.pingTimeout)
+	})
+
 	t.Run("Init with bad table name or permissions", func(t *testing.T) {
 		table := "does-not-exist"
 		m.Properties = map[string]string{
@@ -146,6 +163,54 @@ func TestInit(t *testing.T) {
 	})
 }
 
+func TestPing(t *testing.T) {
+	newStore := func(fn func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error)) *StateStore {
+		mockedClients := awsAuth.Clients{
+			Dynamo: &awsAuth.DynamoDBClients{
+				DynamoDB: &awsAuth.MockDynamoDB{
+					GetItemWithContextFn: fn,
+				},
+			},
+		}
+		mockAuthProvider := &awsAuth.StaticAuth{}
+		mockAuthProvider.WithMockClients(&mockedClients)
+		return &StateStore{
+			authProvider: mockAuthProvider,
+			partitionKey: defaultPartitionKeyName,
+			table:        "table",
+			pingTimeout:  100 * time.Millisecond,
+		}
+	}
+
+	t.Run("table reachable", func(t *testing.T) {
+		s := newStore(func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error) {
+			assert.Equal(t, "table", *input.TableName)
+			return &dynamodb.GetItemOutput{}, nil
+		})
+		require.NoError(t, s.Ping(context.Background()))
+	})
+
+	t.Run("table not found", func(t *testing.T) {
+		s := newStore(func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error) {
+			return nil, errors.New("Requested resource not found")
+		})
+		require.EqualError(t, s.Ping(context.Background()), "error validating DynamoDB table 'table' access: Requested resource not found")
+	})
+
+	t.Run("ping timeout", func(t *testing.T) {
+		s := newStore(func(ctx context.Context, input *dynamodb.GetItemInput, op ...request.Option) (*dynamodb.GetItemOutput, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		require.ErrorIs(t, s.Ping(context.Background()), context.DeadlineExceeded)
+	})
+
+	t.Run("not initialized", func(t *testing.T) {
+		s := &StateStore{}
+		require.Error(t, s.Ping(context.Background()))
+	})
+}
+
 func TestGet(t *testing.T) {
 	t.Run("Successfully retrieve item", func(t *testing.T) {
 		mockedDB := &awsAuth.MockDynamoDB{
@@ -1113,4 +1178,202 @@ func TestMultiTx(t *testing.T) {
 		err := s.Multi(context.Background(), req)
 		require.NoError(t, err)
 	})
+
+	t.Run("Operations with ETags are conditional", func(t *testing.T) {
+		mockedDB := &awsAuth.MockDynamoDB{
+			TransactWriteItemsWithContextFn: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+				require.Len(t, input.TransactItems, 2)
+				put := input.TransactItems[0].Put
+				require.NotNil(t, put)
+				assert.Equal(t, "etag = :etag", *put.ConditionExpression)
+				assert.Equal(t, "1bdead4badc0ffee", *put.ExpressionAttributeValues[":etag"].S)
+				assert.NotNil(t, put.Item["etag"])
+				del := input.TransactItems[1].Delete
+				require.NotNil(t, del)
+				assert.Equal(t, "etag = :etag", *del.ConditionExpression)
+
+				return nil, &dynamodb.TransactionCanceledException{
+					CancellationReasons: []*dynamodb.CancellationReason{
+						{Code: ptr.Of("ConditionalCheckFailed")},
+						{Code: ptr.Of("None")},
+					},
+				}
+			},
+		}
+		mockAuthProvider := &awsAuth.StaticAuth{}
+		mockAuthProvider.WithMockClients(&awsAuth.Clients{
+			Dynamo: &awsAuth.DynamoDBClients{
+				DynamoDB: mockedDB,
+			},
+		})
+		s := StateStore{
+			authProvider: mockAuthProvider,
+			table:        tableName,
+			partitionKey: defaultPartitionKeyName,
+		}
+
+		err := s.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "key1", Value: "value", ETag: ptr.Of("1bdead4badc0ffee")},
+				state.DeleteRequest{Key: "key2", ETag: ptr.Of("2bdead4badc0ffee")},
+			},
+		})
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+}
+
+func TestBulkSet(t *testing.T) {
+	newStore := func(mockedDB *awsAuth.MockDynamoDB) StateStore {
+		mockAuthProvider := &awsAuth.StaticAuth{}
+		mockAuthProvider.WithMockClients(&awsAuth.Clients{
+			Dynamo: &awsAuth.DynamoDBClients{
+				DynamoDB: mockedDB,
+			},
+		})
+		return StateStore{
+			authProvider: mockAuthProvider,
+			table:        tableName,
+			partitionKey: defaultPartitionKeyName,
+		}
+	}
+
+	t.Run("Items are written in chunks of 25", func(t *testing.T) {
+		var sizes []int
+		s := newStore(&awsAuth.MockDynamoDB{
+			BatchWriteItemWithContextFn: func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+				require.Len(t, input.RequestItems, 1)
+				for _, w := range input.RequestItems[tableName] {
+					assert.NotNil(t, w.PutRequest)
+					assert.NotNil(t, w.PutRequest.Item["etag"])
+				}
+				sizes = append(sizes, len(input.RequestItems[tableName]))
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		})
+
+		req := make([]state.SetRequest, 60)
+		for i := range req {
+			req[i] = state.SetRequest{
+				Key:   "key" + strconv.Itoa(i),
+				Value: "value",
+			}
+		}
+		err := s.BulkSet(context.Background(), req, state.BulkStoreOpts{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{25, 25, 10}, sizes)
+	})
+
+	t.Run("Duplicate keys are removed", func(t *testing.T) {
+		s := newStore(&awsAuth.MockDynamoDB{
+			BatchWriteItemWithContextFn: func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+				writes := input.RequestItems[tableName]
+				require.Len(t, writes, 2)
+				assert.Equal(t, "key1", *writes[0].PutRequest.Item[defaultPartitionKeyName].S)
+				assert.Equal(t, `"last"`, *writes[0].PutRequest.Item["value"].S)
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		})
+
+		err := s.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "key1", Value: "first"},
+			{Key: "key2", Value: "value"},
+			{Key: "key1", Value: "last"},
+		}, state.BulkStoreOpts{})
+		require.NoError(t, err)
+	})
+
+	t.Run("Unprocessed items are retried", func(t *testing.T) {
+		calls := 0
+		s := newStore(&awsAuth.MockDynamoDB{
+			BatchWriteItemWithContextFn: func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+				calls++
+				writes := input.RequestItems[tableName]
+				if calls == 1 {
+					require.Len(t, writes, 3)
+					return &dynamodb.BatchWriteItemOutput{
+						UnprocessedItems: map[string][]*dynamodb.WriteRequest{
+							tableName: writes[1:],
+						},
+					}, nil
+				}
+				require.Len(t, writes, 2)
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		})
+
+		err := s.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "key1", Value: "value"},
+			{Key: "key2", Value: "value"},
+			{Key: "key3", Value: "value"},
+		}, state.BulkStoreOpts{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Requests with ETags are stored one by one", func(t *testing.T) {
+		var puts atomic.Int32
+		s := newStore(&awsAuth.MockDynamoDB{
+			PutItemWithContextFn: func(ctx context.Context, input *dynamodb.PutItemInput, op ...request.Option) (*dynamodb.PutItemOutput, error) {
+				puts.Add(1)
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		})
+
+		err := s.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "key1", Value: "value"},
+			{Key: "key2", Value: "value", ETag: ptr.Of("1bdead4badc0ffee")},
+		}, state.BulkStoreOpts{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), puts.Load())
+	})
+
+	t.Run("Errors are returned", func(t *testing.T) {
+		s := newStore(&awsAuth.MockDynamoDB{
+			BatchWriteItemWithContextFn: func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+				return nil, errors.New("unable to write")
+			},
+		})
+
+		err := s.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "key1", Value: "value"},
+		}, state.BulkStoreOpts{})
+		require.EqualError(t, err, "unable to write")
+	})
+}
+
+func TestBulkDelete(t *testing.T) {
+	var sizes []int
+	mockedDB := &awsAuth.MockDynamoDB{
+		BatchWriteItemWithContextFn: func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+			for _, w := range input.RequestItems[tableName] {
+				require.NotNil(t, w.DeleteRequest)
+				assert.NotNil(t, w.DeleteRequest.Key[defaultPartitionKeyName].S)
+			}
+			sizes = append(sizes, len(input.RequestItems[tableName]))
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+	mockAuthProvider := &awsAuth.StaticAuth{}
+	mockAuthProvider.WithMockClients(&awsAuth.Clients{
+		Dynamo: &awsAuth.DynamoDBClients{
+			DynamoDB: mockedDB,
+		},
+	})
+	s := StateStore{
+		authProvider: mockAuthProvider,
+		table:        tableName,
+		partitionKey: defaultPartitionKeyName,
+	}
+
+	req := make([]state.DeleteRequest, 30)
+	for i := range req {
+		req[i] = state.DeleteRequest{
+			Key: "key" + strconv.Itoa(i),
+		}
+	}
+	err := s.BulkDelete(context.Background(), req, state.BulkStoreOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{25, 5}, sizes)
 }
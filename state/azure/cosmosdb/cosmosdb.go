@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,10 +46,11 @@ import (
 type StateStore struct {
 	state.BulkStore
 
-	client      *azcosmos.ContainerClient
-	metadata    metadata
-	contentType string
-	logger      logger.Logger
+	client            *azcosmos.ContainerClient
+	metadata          metadata
+	contentType       string
+	partitionKeyPaths []string
+	logger            logger.Logger
 }
 
 type metadata struct {
@@ -57,6 +59,10 @@ type metadata struct {
 	Database    string `json:"database"`
 	Collection  string `json:"collection"`
 	ContentType string `json:"contentType"`
+	// Maximum time to wait for the database when checking the health of the component.
+	PingTimeout time.Duration `json:"pingTimeout"`
+	// Comma-separated list of the partition key paths of the collection, with up to 3 paths for hierarchical partition keys.
+	PartitionKeyPaths string `json:"partitionKeyPaths"`
 }
 
 type cosmosOperationType string
@@ -76,11 +82,43 @@ type CosmosItem struct {
 	TTL          *int        `json:"ttl,omitempty"`
 	Etag         string      `json:"_etag"`
 	TS           int64       `json:"_ts"`
+
+	// Additional properties for partition key paths other than "/partitionKey".
+	PartitionKeyProperties map[string]string `json:"-"`
+}
+
+// MarshalJSON serializes the item, including the properties for the partition key paths.
+func (i CosmosItem) MarshalJSON() ([]byte, error) {
+	type cosmosItem CosmosItem
+	b, err := json.Marshal(cosmosItem(i))
+	if err != nil || len(i.PartitionKeyProperties) == 0 {
+		return b, err
+	}
+
+	names := make([]string, 0, len(i.PartitionKeyProperties))
+	for name := range i.PartitionKeyProperties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Remove the closing brace and append the properties
+	b = b[:len(b)-1]
+	for _, name := range names {
+		k, _ := json.Marshal(name)
+		v, _ := json.Marshal(i.PartitionKeyProperties[name])
+		b = append(b, ',')
+		b = append(b, k...)
+		b = append(b, ':')
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
 }
 
 const (
-	metadataPartitionKey = "partitionKey"
-	defaultTimeout       = 20 * time.Second
+	metadataPartitionKey     = "partitionKey"
+	metadataConsistencyLevel = "consistencyLevel"
+	metadataSessionToken     = "sessionToken"
+	defaultTimeout           = 20 * time.Second
 )
 
 // Policy that makes all queries cross-partition
@@ -121,6 +159,7 @@ func (c *StateStore) Init(ctx context.Context, meta state.Metadata) error {
 
 	m := metadata{
 		ContentType: "application/json",
+		PingTimeout: defaultTimeout,
 	}
 	errDecode := kitmd.DecodeMetadata(meta.Properties, &m)
 	if errDecode != nil {
@@ -139,12 +178,18 @@ func (c *StateStore) Init(ctx context.Context, meta state.Metadata) error {
 	if m.ContentType == "" {
 		return errors.New("contentType is required")
 	}
+	partitionKeyPaths, err := parsePartitionKeyPaths(m.PartitionKeyPaths)
+	if err != nil {
+		return err
+	}
 
 	// Internal query policy was created due to lack of cross partition query capability in the current Go sdk
+	// Likewise, the Go sdk doesn't support hierarchical partition keys
 	opts := azcosmos.ClientOptions{
 		ClientOptions: policy.ClientOptions{
 			PerCallPolicies: []policy.Policy{
 				crossPartitionQueryPolicy{},
+				hierarchicalPartitionKeyPolicy{},
 			},
 			Telemetry: policy.TelemetryOptions{
 				ApplicationID: "dapr-" + logger.DaprVersion,
@@ -194,6 +239,7 @@ func (c *StateStore) Init(ctx context.Context, meta state.Metadata) error {
 
 	c.metadata = m
 	c.contentType = m.ContentType
+	c.partitionKeyPaths = partitionKeyPaths
 
 	readCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
@@ -214,18 +260,23 @@ func (c *StateStore) Features() []state.Feature {
 
 // Get retrieves a CosmosDB item.
 func (c *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
+	partitionKey, err := c.partitionKeyFromRequest(req.Key, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
 
-	options := azcosmos.ItemOptions{}
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	options := azcosmos.ItemOptions{
+		SessionToken: sessionTokenFromRequest(req.Metadata),
+	}
+	options.ConsistencyLevel, err = consistencyLevelFromRequest(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	readCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
-	readItem, err := c.client.ReadItem(readCtx, azcosmos.NewPartitionKeyString(partitionKey), req.Key, &options)
+	readCtx, pk := withPartitionKey(readCtx, partitionKey)
+	readItem, err := c.client.ReadItem(readCtx, pk, req.Key, &options)
 	if err != nil {
 		if isNotFoundError(err) {
 			return &state.GetResponse{}, nil
@@ -244,6 +295,13 @@ func (c *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.Get
 			state.GetRespMetaKeyTTLExpireTime: time.Unix(item.TS+int64(*item.TTL), 0).UTC().Format(time.RFC3339),
 		}
 	}
+	// Return the session token so it can be passed to subsequent requests
+	if readItem.SessionToken != nil && *readItem.SessionToken != "" {
+		if metadata == nil {
+			metadata = make(map[string]string, 1)
+		}
+		metadata[metadataSessionToken] = *readItem.SessionToken
+	}
 
 	// We are sure this is a []byte if not nil
 	b, _ := item.Value.([]byte)
@@ -260,7 +318,11 @@ func (c *StateStore) getMulti(ctx context.Context, req []state.GetRequest) ([]st
 	pk := azcosmos.NewPartitionKeyBool(true)
 
 	// Build the query
-	var consistency azcosmos.ConsistencyLevel
+	var (
+		consistency  azcosmos.ConsistencyLevel
+		override     *azcosmos.ConsistencyLevel
+		sessionToken *string
+	)
 	keys := make([]string, len(req))
 	for i, r := range req {
 		keys[i] = r.Key
@@ -272,6 +334,21 @@ func (c *StateStore) getMulti(ctx context.Context, req []state.GetRequest) ([]st
 		} else if r.Options.Consistency == state.Eventual && consistency == "" {
 			consistency = azcosmos.ConsistencyLevelEventual
 		}
+
+		// A consistency level set in the metadata overrides the options; the strongest one is used
+		level, err := parseConsistencyLevel(r.Metadata[metadataConsistencyLevel])
+		if err != nil {
+			return nil, err
+		}
+		if level != nil && (override == nil || consistencyStrength(*level) > consistencyStrength(*override)) {
+			override = level
+		}
+		if sessionToken == nil {
+			sessionToken = sessionTokenFromRequest(r.Metadata)
+		}
+	}
+	if override != nil {
+		consistency = *override
 	}
 
 	// Execute the query
@@ -280,6 +357,7 @@ func (c *StateStore) getMulti(ctx context.Context, req []state.GetRequest) ([]st
 		QueryParameters: []azcosmos.QueryParameter{
 			{Name: "@keys", Value: keys},
 		},
+		SessionToken: sessionToken,
 	}
 	if consistency != "" {
 		queryOpts.ConsistencyLevel = &consistency
@@ -402,8 +480,13 @@ func (c *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 		return err
 	}
 
-	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
-	options := azcosmos.ItemOptions{}
+	partitionKey, err := c.partitionKeyFromRequest(req.Key, req.Metadata)
+	if err != nil {
+		return err
+	}
+	options := azcosmos.ItemOptions{
+		SessionToken: sessionTokenFromRequest(req.Metadata),
+	}
 
 	if req.HasETag() {
 		etag := azcore.ETag(*req.ETag)
@@ -416,17 +499,16 @@ func (c *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 		}
 		options.IfMatchEtag = ptr.Of(azcore.ETag(u.String()))
 	}
-	// Consistency levels can only be relaxed so the session level is used here
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	options.ConsistencyLevel, err = consistencyLevelFromRequest(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return err
 	}
 
-	doc, err := createUpsertItem(c.contentType, *req, partitionKey)
+	doc, err := createUpsertItem(c.contentType, *req, partitionKey[0])
 	if err != nil {
 		return err
 	}
+	c.setPartitionKeyProperties(&doc, partitionKey)
 
 	marsh, err := json.Marshal(doc)
 	if err != nil {
@@ -435,7 +517,7 @@ func (c *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 
 	upsertCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
-	pk := azcosmos.NewPartitionKeyString(partitionKey)
+	upsertCtx, pk := withPartitionKey(upsertCtx, partitionKey)
 	_, err = c.client.UpsertItem(upsertCtx, pk, marsh, &options)
 	if err != nil {
 		resErr := &azcore.ResponseError{}
@@ -453,8 +535,13 @@ func (c *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 	if err != nil {
 		return err
 	}
-	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
-	options := azcosmos.ItemOptions{}
+	partitionKey, err := c.partitionKeyFromRequest(req.Key, req.Metadata)
+	if err != nil {
+		return err
+	}
+	options := azcosmos.ItemOptions{
+		SessionToken: sessionTokenFromRequest(req.Metadata),
+	}
 
 	if req.HasETag() {
 		etag := azcore.ETag(*req.ETag)
@@ -467,16 +554,14 @@ func (c *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 		}
 		options.IfMatchEtag = ptr.Of(azcore.ETag(u.String()))
 	}
-
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	options.ConsistencyLevel, err = consistencyLevelFromRequest(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return err
 	}
 
 	deleteCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
-	pk := azcosmos.NewPartitionKeyString(partitionKey)
+	deleteCtx, pk := withPartitionKey(deleteCtx, partitionKey)
 	_, err = c.client.DeleteItem(deleteCtx, pk, req.Key, &options)
 	if err != nil && !isNotFoundError(err) {
 		resErr := &azcore.ResponseError{}
@@ -503,8 +588,26 @@ func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		return nil
 	}
 
-	partitionKey := request.Metadata[metadataPartitionKey]
-	batch := c.client.NewTransactionalBatch(azcosmos.NewPartitionKeyString(partitionKey))
+	partitionKey := []string{request.Metadata[metadataPartitionKey]}
+	if len(c.partitionKeyPaths) > 1 {
+		partitionKey, err = c.partitionKeyFromRequest("", request.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+	batchOptions := &azcosmos.TransactionalBatchOptions{}
+	batchOptions.ConsistencyLevel, err = consistencyLevelFromRequest("", request.Metadata)
+	if err != nil {
+		return err
+	}
+	if sessionToken := sessionTokenFromRequest(request.Metadata); sessionToken != nil {
+		batchOptions.SessionToken = *sessionToken
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	execCtx, pk := withPartitionKey(execCtx, partitionKey)
+	batch := c.client.NewTransactionalBatch(pk)
 
 	numOperations := 0
 	// Loop through the list of operations. Create and add the operation to the batch
@@ -514,11 +617,11 @@ func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		switch req := o.(type) {
 		case state.SetRequest:
 			var doc CosmosItem
-			doc, err = createUpsertItem(c.contentType, req, partitionKey)
+			doc, err = createUpsertItem(c.contentType, req, partitionKey[0])
 			if err != nil {
 				return err
 			}
-			doc.PartitionKey = partitionKey
+			c.setPartitionKeyProperties(&doc, partitionKey)
 
 			if req.HasETag() {
 				etag := azcore.ETag(*req.ETag)
@@ -557,11 +660,9 @@ func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		}
 	}
 
-	c.logger.Debugf("#operations=%d,partitionkey=%s", numOperations, partitionKey)
+	c.logger.Debugf("#operations=%d,partitionkey=%s", numOperations, strings.Join(partitionKey, ","))
 
-	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
-	defer cancel()
-	batchResponse, err := c.client.ExecuteTransactionalBatch(execCtx, batch, nil)
+	batchResponse, err := c.client.ExecuteTransactionalBatch(execCtx, batch, batchOptions)
 	if err != nil {
 		return err
 	}
@@ -600,6 +701,14 @@ func (c *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state
 	q.partitionKey = ""
 	if val, found := req.Metadata[metadataPartitionKey]; found {
 		q.partitionKey = val
+		if len(c.partitionKeyPaths) > 1 {
+			partitionKey, err := c.partitionKeyFromRequest("", req.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			ctx, _ = withPartitionKey(ctx, partitionKey)
+			q.partitionKey = partitionKey[0]
+		}
 	}
 
 	data, token, err := q.execute(ctx, c.client)
@@ -613,8 +722,17 @@ func (c *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state
 	}, nil
 }
 
+// Ping checks the connection to the database by reading the properties of the container.
 func (c *StateStore) Ping(ctx context.Context) error {
-	pingCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	if c.client == nil {
+		return errors.New("component is not initialized")
+	}
+
+	timeout := c.metadata.PingTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	_, err := c.client.Read(pingCtx, nil)
 	if err != nil {
@@ -686,6 +804,58 @@ func populatePartitionMetadata(key string, requestMetadata map[string]string) st
 	return key
 }
 
+// consistencyLevelFromRequest returns the consistency level to use for a request.
+// The "consistencyLevel" metadata, if present, overrides the consistency of the state options.
+func consistencyLevelFromRequest(consistency string, requestMetadata map[string]string) (*azcosmos.ConsistencyLevel, error) {
+	level, err := parseConsistencyLevel(requestMetadata[metadataConsistencyLevel])
+	if err != nil || level != nil {
+		return level, err
+	}
+
+	// Consistency levels can only be relaxed so the session level is used here
+	switch consistency {
+	case state.Strong:
+		return azcosmos.ConsistencyLevelSession.ToPtr(), nil
+	case state.Eventual:
+		return azcosmos.ConsistencyLevelEventual.ToPtr(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// parseConsistencyLevel parses a Cosmos DB consistency level, case-insensitively.
+// Returns nil if the value is empty.
+func parseConsistencyLevel(value string) (*azcosmos.ConsistencyLevel, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, level := range azcosmos.ConsistencyLevelValues() {
+		if strings.EqualFold(value, string(level)) {
+			return level.ToPtr(), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid value for %s metadata: %s", metadataConsistencyLevel, value)
+}
+
+// consistencyStrength returns a value that is higher for stronger consistency levels.
+func consistencyStrength(level azcosmos.ConsistencyLevel) int {
+	// ConsistencyLevelValues returns the levels from the strongest to the weakest
+	values := azcosmos.ConsistencyLevelValues()
+	for i, v := range values {
+		if v == level {
+			return len(values) - i
+		}
+	}
+	return 0
+}
+
+func sessionTokenFromRequest(requestMetadata map[string]string) *string {
+	if val := requestMetadata[metadataSessionToken]; val != "" {
+		return &val
+	}
+	return nil
+}
+
 func isNotFoundError(err error) bool {
 	if err == nil {
 		return false
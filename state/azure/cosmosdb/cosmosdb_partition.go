@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmosdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+const (
+	defaultPartitionKeyPath = "/partitionKey"
+	// Maximum number of levels of hierarchical partition keys supported by Cosmos DB
+	maxPartitionKeyLevels = 3
+
+	partitionKeyHeader = "x-ms-documentdb-partitionkey"
+)
+
+// Properties of the documents that can't be used as partition key paths.
+var reservedPartitionKeyPaths = map[string]struct{}{
+	"id":       {},
+	"value":    {},
+	"isBinary": {},
+	"ttl":      {},
+	"_etag":    {},
+	"_ts":      {},
+}
+
+type hierarchicalPartitionKeyCtxKey struct{}
+
+// Policy that sets the partition key header for hierarchical partition keys, which are not supported by the Go SDK.
+// The values are read from the context of the request.
+type hierarchicalPartitionKeyPolicy struct{}
+
+func (p hierarchicalPartitionKeyPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	if values, ok := raw.Context().Value(hierarchicalPartitionKeyCtxKey{}).([]string); ok && raw.Header.Get(partitionKeyHeader) != "" {
+		raw.Header.Set(partitionKeyHeader, encodePartitionKeyHeader(values))
+	}
+	return req.Next()
+}
+
+// encodePartitionKeyHeader encodes the values of a partition key like the SDK, as a JSON array with non-ASCII characters escaped.
+func encodePartitionKeyHeader(values []string) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.QuoteToASCII(v))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parsePartitionKeyPaths parses the comma-separated list of partition key paths of the container.
+// Only top-level paths are supported, as the values are stored as properties of the documents.
+func parsePartitionKeyPaths(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return []string{defaultPartitionKeyPath}, nil
+	}
+
+	paths := strings.Split(value, ",")
+	if len(paths) > maxPartitionKeyLevels {
+		return nil, fmt.Errorf("partitionKeyPaths can have at most %d paths", maxPartitionKeyLevels)
+	}
+	for i, path := range paths {
+		path = strings.TrimSpace(path)
+		name, ok := strings.CutPrefix(path, "/")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid partition key path '%s': only top-level paths such as '/tenantId' are supported", path)
+		}
+		if _, reserved := reservedPartitionKeyPaths[name]; reserved {
+			return nil, fmt.Errorf("invalid partition key path '%s': the property is reserved", path)
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
+
+// partitionKeyFromRequest returns the values of the partition key of a request.
+// With a single partition key path, the value is the "partitionKey" metadata if present, or the key otherwise.
+// With hierarchical partition keys, the "partitionKey" metadata is required and contains a JSON array with a value for each path.
+func (c *StateStore) partitionKeyFromRequest(key string, requestMetadata map[string]string) ([]string, error) {
+	if len(c.partitionKeyPaths) <= 1 {
+		return []string{populatePartitionMetadata(key, requestMetadata)}, nil
+	}
+
+	val, found := requestMetadata[metadataPartitionKey]
+	if !found || val == "" {
+		return nil, errors.New("the partitionKey metadata is required with hierarchical partition keys")
+	}
+	var values []string
+	err := json.Unmarshal([]byte(val), &values)
+	if err != nil {
+		return nil, fmt.Errorf("the partitionKey metadata must be a JSON array of strings with hierarchical partition keys: %w", err)
+	}
+	if len(values) != len(c.partitionKeyPaths) {
+		return nil, fmt.Errorf("the partitionKey metadata must contain %d values, one for each partition key path", len(c.partitionKeyPaths))
+	}
+	return values, nil
+}
+
+// withPartitionKey returns the context and the partition key to use for a request on an item in the partition.
+func withPartitionKey(ctx context.Context, values []string) (context.Context, azcosmos.PartitionKey) {
+	if len(values) > 1 {
+		// The header is replaced by hierarchicalPartitionKeyPolicy
+		ctx = context.WithValue(ctx, hierarchicalPartitionKeyCtxKey{}, values)
+	}
+	return ctx, azcosmos.NewPartitionKeyString(values[0])
+}
+
+// setPartitionKeyProperties sets the properties of the document for the partition key paths.
+func (c *StateStore) setPartitionKeyProperties(item *CosmosItem, values []string) {
+	for i, path := range c.partitionKeyPaths {
+		if path == defaultPartitionKeyPath {
+			item.PartitionKey = values[i]
+			continue
+		}
+		if item.PartitionKeyProperties == nil {
+			item.PartitionKeyProperties = make(map[string]string, len(c.partitionKeyPaths))
+		}
+		item.PartitionKeyProperties[path[1:]] = values[i]
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmosdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+func TestParsePartitionKeyPaths(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		paths, err := parsePartitionKeyPaths("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/partitionKey"}, paths)
+	})
+
+	t.Run("hierarchical", func(t *testing.T) {
+		paths, err := parsePartitionKeyPaths("/tenantId, /userId,/sessionId")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/tenantId", "/userId", "/sessionId"}, paths)
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		for _, value := range []string{
+			"tenantId",
+			"/",
+			"/tenant/id",
+			"/tenantId,",
+			"/id",
+			"/value",
+			"/_etag",
+			"/a,/b,/c,/d",
+		} {
+			_, err := parsePartitionKeyPaths(value)
+			require.Errorf(t, err, "expected error for '%s'", value)
+		}
+	})
+}
+
+func TestPartitionKeyFromRequest(t *testing.T) {
+	t.Run("single path", func(t *testing.T) {
+		s := &StateStore{partitionKeyPaths: []string{"/partitionKey"}}
+
+		pk, err := s.partitionKeyFromRequest("key", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"key"}, pk)
+
+		pk, err = s.partitionKeyFromRequest("key", map[string]string{"partitionKey": "pk"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pk"}, pk)
+	})
+
+	t.Run("hierarchical", func(t *testing.T) {
+		s := &StateStore{partitionKeyPaths: []string{"/tenantId", "/userId"}}
+
+		pk, err := s.partitionKeyFromRequest("key", map[string]string{"partitionKey": `["contoso","alice"]`})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"contoso", "alice"}, pk)
+
+		_, err = s.partitionKeyFromRequest("key", nil)
+		require.Error(t, err)
+		_, err = s.partitionKeyFromRequest("key", map[string]string{"partitionKey": "contoso"})
+		require.Error(t, err)
+		_, err = s.partitionKeyFromRequest("key", map[string]string{"partitionKey": `["contoso"]`})
+		require.Error(t, err)
+	})
+}
+
+func TestSetPartitionKeyProperties(t *testing.T) {
+	s := &StateStore{partitionKeyPaths: []string{"/tenantId", "/partitionKey"}}
+	item, err := createUpsertItem("application/json", state.SetRequest{Key: "key", Value: widget{Color: "red"}}, "contoso")
+	require.NoError(t, err)
+	s.setPartitionKeyProperties(&item, []string{"contoso", "alice"})
+
+	b, err := json.Marshal(item)
+	require.NoError(t, err)
+
+	j := map[string]any{}
+	err = json.Unmarshal(b, &j)
+	require.NoError(t, err)
+	assert.Equal(t, "key", j["id"])
+	assert.Equal(t, "contoso", j["tenantId"])
+	assert.Equal(t, "alice", j["partitionKey"])
+	assert.Equal(t, map[string]any{"color": "red"}, j["value"])
+}
+
+type fakeTransport struct {
+	header http.Header
+}
+
+func (f *fakeTransport) Do(req *http.Request) (*http.Response, error) {
+	f.header = req.Header.Clone()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+func TestHierarchicalPartitionKeyPolicy(t *testing.T) {
+	transport := &fakeTransport{}
+	pl := runtime.NewPipeline("cosmosdb", "test", runtime.PipelineOptions{
+		PerCall: []policy.Policy{hierarchicalPartitionKeyPolicy{}},
+	}, &policy.ClientOptions{
+		Transport: transport,
+	})
+
+	do := func(t *testing.T, ctx context.Context) string {
+		t.Helper()
+		req, err := runtime.NewRequest(ctx, http.MethodGet, "https://localhost/dbs/db/colls/coll/docs/key")
+		require.NoError(t, err)
+		req.Raw().Header.Set(partitionKeyHeader, `["contoso"]`)
+		_, err = pl.Do(req)
+		require.NoError(t, err)
+		return transport.header.Get(partitionKeyHeader)
+	}
+
+	t.Run("single value", func(t *testing.T) {
+		ctx, _ := withPartitionKey(context.Background(), []string{"contoso"})
+		assert.Equal(t, `["contoso"]`, do(t, ctx))
+	})
+
+	t.Run("hierarchical", func(t *testing.T) {
+		ctx, _ := withPartitionKey(context.Background(), []string{"contoso", "al\"ice", "é"})
+		assert.Equal(t, `["contoso","al\"ice","\u00e9"]`, do(t, ctx))
+	})
+}
@@ -14,10 +14,12 @@ limitations under the License.
 package cosmosdb
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -357,3 +359,46 @@ func TestCreateCosmosItemWithTTL(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestPing(t *testing.T) {
+	t.Run("not initialized", func(t *testing.T) {
+		store := &StateStore{}
+		require.Error(t, store.Ping(context.Background()))
+	})
+}
+
+func TestConsistencyLevelFromRequest(t *testing.T) {
+	t.Run("from state options", func(t *testing.T) {
+		level, err := consistencyLevelFromRequest(state.Strong, nil)
+		require.NoError(t, err)
+		assert.Equal(t, azcosmos.ConsistencyLevelSession, *level)
+
+		level, err = consistencyLevelFromRequest(state.Eventual, nil)
+		require.NoError(t, err)
+		assert.Equal(t, azcosmos.ConsistencyLevelEventual, *level)
+
+		level, err = consistencyLevelFromRequest("", nil)
+		require.NoError(t, err)
+		assert.Nil(t, level)
+	})
+
+	t.Run("metadata overrides state options", func(t *testing.T) {
+		level, err := consistencyLevelFromRequest(state.Eventual, map[string]string{"consistencyLevel": "boundedstaleness"})
+		require.NoError(t, err)
+		assert.Equal(t, azcosmos.ConsistencyLevelBoundedStaleness, *level)
+
+		level, err = consistencyLevelFromRequest("", map[string]string{"consistencyLevel": "ConsistentPrefix"})
+		require.NoError(t, err)
+		assert.Equal(t, azcosmos.ConsistencyLevelConsistentPrefix, *level)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := consistencyLevelFromRequest(state.Strong, map[string]string{"consistencyLevel": "linearizable"})
+		require.Error(t, err)
+	})
+
+	t.Run("strength", func(t *testing.T) {
+		assert.Greater(t, consistencyStrength(azcosmos.ConsistencyLevelStrong), consistencyStrength(azcosmos.ConsistencyLevelSession))
+		assert.Greater(t, consistencyStrength(azcosmos.ConsistencyLevelSession), consistencyStrength(azcosmos.ConsistencyLevelEventual))
+	})
+}
@@ -121,6 +121,7 @@ func (c *Cassandra) Init(_ context.Context, metadata state.Metadata) error {
 func (c *Cassandra) Features() []state.Feature {
 	return []state.Feature{
 		state.FeatureTTL,
+		state.FeatureTransactional,
 	}
 }
 
@@ -228,7 +229,11 @@ func getCassandraMetadata(meta state.Metadata) (*cassandraMetadata, error) {
 
 // Delete performs a delete operation.
 func (c *Cassandra) Delete(ctx context.Context, req *state.DeleteRequest) error {
-	return c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), req.Key).WithContext(ctx).Exec()
+	return c.session.Query(c.deleteStatement(), req.Key).WithContext(ctx).Exec()
+}
+
+func (c *Cassandra) deleteStatement() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table)
 }
 
 // Get retrieves state from cassandra with a key.
@@ -280,12 +285,9 @@ func (c *Cassandra) Get(ctx context.Context, req *state.GetRequest) (*state.GetR
 
 // Set saves state into cassandra.
 func (c *Cassandra) Set(ctx context.Context, req *state.SetRequest) error {
-	var bt []byte
-	b, ok := req.Value.([]byte)
-	if ok {
-		bt = b
-	} else {
-		bt, _ = jsoniter.ConfigFastest.Marshal(req.Value)
+	stmt, args, err := c.setStatement(req)
+	if err != nil {
+		return err
 	}
 
 	session := c.session
@@ -306,16 +308,63 @@ func (c *Cassandra) Set(ctx context.Context, req *state.SetRequest) error {
 		session = sess
 	}
 
+	return session.Query(stmt, args...).WithContext(ctx).Exec()
+}
+
+// setStatement returns the statement and its arguments to save the state of a set request.
+func (c *Cassandra) setStatement(req *state.SetRequest) (string, []any, error) {
+	var bt []byte
+	b, ok := req.Value.([]byte)
+	if ok {
+		bt = b
+	} else {
+		bt, _ = jsoniter.ConfigFastest.Marshal(req.Value)
+	}
+
 	ttl, err := stateutils.ParseTTL(req.Metadata)
 	if err != nil {
-		return fmt.Errorf("error parsing TTL from Metadata: %s", err)
+		return "", nil, fmt.Errorf("error parsing TTL from Metadata: %s", err)
 	}
 
 	if ttl != nil {
-		return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), req.Key, bt, *ttl).WithContext(ctx).Exec()
+		return fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), []any{req.Key, bt, *ttl}, nil
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), []any{req.Key, bt}, nil
+}
+
+// Multi performs a transactional operation using a logged batch.
+// Cassandra guarantees that either all or none of the operations in a logged batch are eventually applied, but batches are not isolated when they span multiple keys.
+func (c *Cassandra) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if request == nil || len(request.Operations) == 0 {
+		return nil
 	}
 
-	return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), req.Key, bt).WithContext(ctx).Exec()
+	batch, err := c.newBatch(request)
+	if err != nil {
+		return err
+	}
+
+	return c.session.ExecuteBatch(batch.WithContext(ctx))
+}
+
+func (c *Cassandra) newBatch(request *state.TransactionalStateRequest) (*gocql.Batch, error) {
+	batch := c.session.NewBatch(gocql.LoggedBatch)
+	for _, op := range request.Operations {
+		switch req := op.(type) {
+		case state.SetRequest:
+			stmt, args, err := c.setStatement(&req)
+			if err != nil {
+				return nil, err
+			}
+			batch.Query(stmt, args...)
+		case state.DeleteRequest:
+			batch.Query(c.deleteStatement(), req.Key)
+		default:
+			return nil, fmt.Errorf("unsupported operation: %s", op.Operation())
+		}
+	}
+	return batch, nil
 }
 
 func (c *Cassandra) createSession(consistency gocql.Consistency) (*gocql.Session, error) {
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -111,3 +112,39 @@ func TestGetCassandraMetadata(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestNewBatch(t *testing.T) {
+	c := &Cassandra{
+		session: &gocql.Session{},
+		table:   "dapr.items",
+	}
+
+	t.Run("Set and delete operations", func(t *testing.T) {
+		batch, err := c.newBatch(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "key1", Value: []byte("value1")},
+				state.SetRequest{Key: "key2", Value: map[string]string{"a": "b"}, Metadata: map[string]string{"ttlInSeconds": "60"}},
+				state.DeleteRequest{Key: "key3"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, gocql.LoggedBatch, batch.Type)
+		require.Len(t, batch.Entries, 3)
+
+		assert.Equal(t, "INSERT INTO dapr.items (key, value) VALUES (?, ?)", batch.Entries[0].Stmt)
+		assert.Equal(t, []any{"key1", []byte("value1")}, batch.Entries[0].Args)
+		assert.Equal(t, "INSERT INTO dapr.items (key, value) VALUES (?, ?) USING TTL ?", batch.Entries[1].Stmt)
+		assert.Equal(t, []any{"key2", []byte(`{"a":"b"}`), 60}, batch.Entries[1].Args)
+		assert.Equal(t, "DELETE FROM dapr.items WHERE key = ?", batch.Entries[2].Stmt)
+		assert.Equal(t, []any{"key3"}, batch.Entries[2].Args)
+	})
+
+	t.Run("Invalid TTL", func(t *testing.T) {
+		_, err := c.newBatch(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "key1", Value: []byte("value1"), Metadata: map[string]string{"ttlInSeconds": "invalid"}},
+			},
+		})
+		require.Error(t, err)
+	})
+}
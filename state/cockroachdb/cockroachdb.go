@@ -23,11 +23,19 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+// Number of times transactions are retried when they fail with a serialization failure, which CockroachDB returns when concurrent transactions conflict.
+const maxTransactionRetries = 5
+
 // New creates a new instance of CockroachDB state store.
 func New(logger logger.Logger) state.Store {
 	return postgresql.NewPostgreSQLQueryStateStore(logger, postgresql.Options{
 		ETagColumn: "etag",
 		MigrateFn:  ensureTables,
+		// Reads with eventual consistency are served by the nearest replica
+		FollowerReads: true,
+		// Expired rows are deleted by the row-level TTL of the table
+		DisableGC:             true,
+		MaxTransactionRetries: maxTransactionRetries,
 		SetQueryFn: func(req *state.SetRequest, opts postgresql.SetQueryOptions) string {
 			// Sprintf is required for table name because the driver does not substitute parameters for table names.
 			if !req.HasETag() {
@@ -104,6 +112,13 @@ func ensureTables(ctx context.Context, db pginterfaces.PGXPoolConn, opts postgre
 		return err
 	}
 
+	// Let CockroachDB delete expired rows with row-level TTL. Rows with a NULL expiredate never expire.
+	_, err = db.Exec(ctx, fmt.Sprintf(
+		`ALTER TABLE %s SET (ttl_expiration_expression = 'expiredate');`, opts.StateTableName))
+	if err != nil {
+		return fmt.Errorf("failed to enable row-level TTL on the state table: %w", err)
+	}
+
 	exists, err = tableExists(ctx, db, opts.MetadataTableName)
 	if err != nil {
 		return err
@@ -16,7 +16,6 @@ package etcd
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"reflect"
@@ -26,6 +25,7 @@ import (
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
+	etcdcomponent "github.com/dapr/components-contrib/common/component/etcd"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	stateutils "github.com/dapr/components-contrib/state/utils"
@@ -112,7 +112,7 @@ func (e *Etcd) ParseClientFromConfig(etcdConfig *etcdConfig) (*clientv3.Client,
 	if utils.IsTruthy(etcdConfig.TLSEnable) {
 		if etcdConfig.Cert != "" && etcdConfig.Key != "" && etcdConfig.CA != "" {
 			var err error
-			tlsConfig, err = NewTLSConfig(etcdConfig.Cert, etcdConfig.Key, etcdConfig.CA)
+			tlsConfig, err = etcdcomponent.NewTLSConfig(etcdConfig.Cert, etcdConfig.Key, etcdConfig.CA)
 			if err != nil {
 				return nil, fmt.Errorf("tls authentication error: %w", err)
 			}
@@ -417,34 +417,3 @@ func (e *Etcd) Multi(ctx context.Context, request *state.TransactionalStateReque
 
 	return nil
 }
-
-func NewTLSConfig(clientCert, clientKey, caCert string) (*tls.Config, error) {
-	valid := false
-
-	config := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-
-	if clientCert != "" && clientKey != "" {
-		key := []byte(clientKey)
-		cert, err := tls.X509KeyPair([]byte(clientCert), key)
-		if err != nil {
-			return nil, fmt.Errorf("error parse X509KeyPair: %w", err)
-		}
-		config.Certificates = []tls.Certificate{cert}
-		valid = true
-	}
-
-	if caCert != "" {
-		caCertPool := x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM([]byte(caCert))
-		config.RootCAs = caCertPool
-		valid = true
-	}
-
-	if !valid {
-		config = nil
-	}
-
-	return config, nil
-}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultTableName = "state"
+	// Maximum staleness of reads with eventual consistency.
+	defaultMaxStaleness = 15 * time.Second
+)
+
+// Table names must be valid Spanner identifiers, as they can't be passed as query parameters.
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,127}$`)
+
+type spannerMetadata struct {
+	// Ignored by metadata parser because included in built-in authentication profile
+	Type                string `json:"type" mapstructure:"type" mdignore:"true"`
+	ProjectID           string `json:"project_id" mapstructure:"projectID" mdignore:"true" mapstructurealiases:"project_id"`
+	PrivateKeyID        string `json:"private_key_id" mapstructure:"privateKeyID" mdignore:"true" mapstructurealiases:"private_key_id"`
+	PrivateKey          string `json:"private_key" mapstructure:"privateKey" mdignore:"true" mapstructurealiases:"private_key"`
+	ClientEmail         string `json:"client_email" mapstructure:"clientEmail" mdignore:"true" mapstructurealiases:"client_email"`
+	ClientID            string `json:"client_id" mapstructure:"clientID" mdignore:"true" mapstructurealiases:"client_id"`
+	AuthURI             string `json:"auth_uri" mapstructure:"authURI" mdignore:"true" mapstructurealiases:"auth_uri"`
+	TokenURI            string `json:"token_uri" mapstructure:"tokenURI" mdignore:"true" mapstructurealiases:"token_uri"`
+	AuthProviderCertURL string `json:"auth_provider_x509_cert_url" mapstructure:"authProviderX509CertURL" mdignore:"true" mapstructurealiases:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `json:"client_x509_cert_url" mapstructure:"clientX509CertURL" mdignore:"true" mapstructurealiases:"client_x509_cert_url"`
+
+	// ID of the Spanner instance.
+	InstanceID string `json:"-" mapstructure:"instanceID"`
+	// ID of the database in the instance.
+	DatabaseID string `json:"-" mapstructure:"databaseID"`
+	// Name of the table where the state is stored.
+	TableName string `json:"-" mapstructure:"tableName"`
+	// If true, the table is not created when the component is initialized.
+	DisableTableCreation bool `json:"-" mapstructure:"disableTableCreation"`
+	// Maximum staleness of the data returned by reads with eventual consistency.
+	MaxStaleness time.Duration `json:"-" mapstructure:"maxStaleness"`
+	// Endpoint of the Spanner emulator, for local development.
+	Endpoint string `json:"-" mapstructure:"endpoint"`
+}
+
+func (m *spannerMetadata) Parse(meta state.Metadata) error {
+	// Set defaults
+	m.TableName = defaultTableName
+	m.MaxStaleness = defaultMaxStaleness
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate options
+	if m.ProjectID == "" {
+		return errors.New("spanner state store error: missing required metadata property 'projectID'")
+	}
+	if m.InstanceID == "" {
+		return errors.New("spanner state store error: missing required metadata property 'instanceID'")
+	}
+	if m.DatabaseID == "" {
+		return errors.New("spanner state store error: missing required metadata property 'databaseID'")
+	}
+	if !tableNameRegex.MatchString(m.TableName) {
+		return fmt.Errorf("spanner state store error: invalid table name '%s'", m.TableName)
+	}
+	if m.MaxStaleness < 0 {
+		return errors.New("spanner state store error: 'maxStaleness' must not be negative")
+	}
+
+	return nil
+}
+
+// databasePath returns the fully-qualified name of the database.
+func (m *spannerMetadata) databasePath() string {
+	return "projects/" + m.ProjectID + "/instances/" + m.InstanceID + "/databases/" + m.DatabaseID
+}
@@ -0,0 +1,439 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	stateutils "github.com/dapr/components-contrib/state/utils"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	colKey        = "key"
+	colValue      = "value"
+	colUpdateTime = "update_time"
+	colExpiration = "expiration"
+)
+
+var readColumns = []string{colKey, colValue, colUpdateTime, colExpiration} //nolint:gochecknoglobals
+
+// Spanner is a state store backed by Google Cloud Spanner.
+type Spanner struct {
+	client   *spanner.Client
+	metadata spannerMetadata
+	logger   logger.Logger
+}
+
+// NewSpannerStateStore returns a new Google Cloud Spanner state store.
+func NewSpannerStateStore(logger logger.Logger) state.Store {
+	return &Spanner{
+		logger: logger,
+	}
+}
+
+// Init connects to the database and creates the table if needed.
+func (s *Spanner) Init(ctx context.Context, meta state.Metadata) error {
+	err := s.metadata.Parse(meta)
+	if err != nil {
+		return err
+	}
+
+	opts, err := s.clientOptions()
+	if err != nil {
+		return err
+	}
+
+	// context.Background is used here, as the context used to Dial the
+	// server in the gRPC DialPool. Callers should always call `Close` on the
+	// component to ensure all resources are released.
+	s.client, err = spanner.NewClient(context.Background(), s.metadata.databasePath(), opts...)
+	if err != nil {
+		return fmt.Errorf("spanner state store error: failed to create client: %w", err)
+	}
+
+	if !s.metadata.DisableTableCreation {
+		err = s.ensureTable(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("spanner state store error: failed to create table '%s': %w", s.metadata.TableName, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Spanner) clientOptions() ([]option.ClientOption, error) {
+	if s.metadata.Endpoint != "" {
+		s.logger.Debugf("Connecting to the Spanner emulator at %s", s.metadata.Endpoint)
+		return []option.ClientOption{
+			option.WithEndpoint(s.metadata.Endpoint),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+			option.WithoutAuthentication(),
+		}, nil
+	}
+
+	if s.metadata.PrivateKeyID != "" {
+		s.logger.Debugf("Using explicit credentials for GCP")
+		b, err := json.Marshal(s.metadata)
+		if err != nil {
+			return nil, err
+		}
+		return []option.ClientOption{option.WithCredentialsJSON(b)}, nil
+	}
+
+	s.logger.Debugf("Using implicit credentials for GCP")
+	return nil, nil
+}
+
+// ensureTable creates the state table if it doesn't exist.
+// Expired rows are removed by Spanner through a row deletion policy; until then, reads ignore them.
+func (s *Spanner) ensureTable(ctx context.Context, opts []option.ClientOption) error {
+	stmt := spanner.Statement{
+		SQL: "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @name",
+		Params: map[string]any{
+			"name": s.metadata.TableName,
+		},
+	}
+	var count int64
+	err := s.client.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		return r.Columns(&count)
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	s.logger.Infof("Creating table '%s'", s.metadata.TableName)
+	admin, err := database.NewDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	op, err := admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   s.metadata.databasePath(),
+		Statements: []string{createTableDDL(s.metadata.TableName)},
+	})
+	if err != nil {
+		return err
+	}
+	err = op.Wait(ctx)
+	if err != nil && spanner.ErrCode(err) != codes.AlreadyExists {
+		return err
+	}
+	return nil
+}
+
+// createTableDDL returns the statement that creates the state table.
+func createTableDDL(tableName string) string {
+	return "CREATE TABLE `" + tableName + "` (" +
+		colKey + " STRING(MAX) NOT NULL, " +
+		colValue + " BYTES(MAX), " +
+		colUpdateTime + " TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp = true), " +
+		colExpiration + " TIMESTAMP" +
+		") PRIMARY KEY (" + colKey + "), " +
+		"ROW DELETION POLICY (OLDER_THAN(" + colExpiration + ", INTERVAL 0 DAY))"
+}
+
+// Features returns the features available in this state store.
+func (s *Spanner) Features() []state.Feature {
+	return []state.Feature{
+		state.FeatureETag,
+		state.FeatureTTL,
+	}
+}
+
+// Get returns the value of a key.
+func (s *Spanner) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	res, err := s.read(ctx, []string{req.Key}, req.Options.Consistency)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := res[req.Key]
+	if !ok {
+		return &state.GetResponse{}, nil
+	}
+	return item, nil
+}
+
+// BulkGet returns the values of multiple keys with a single query.
+func (s *Spanner) BulkGet(ctx context.Context, req []state.GetRequest, _ state.BulkGetOpts) ([]state.BulkGetResponse, error) {
+	if len(req) == 0 {
+		return []state.BulkGetResponse{}, nil
+	}
+
+	keys := make([]string, len(req))
+	for i, r := range req {
+		keys[i] = r.Key
+	}
+	res, err := s.read(ctx, keys, req[0].Options.Consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]state.BulkGetResponse, len(req))
+	for i, r := range req {
+		out[i].Key = r.Key
+		if item, ok := res[r.Key]; ok {
+			out[i].Data = item.Data
+			out[i].ETag = item.ETag
+			out[i].Metadata = item.Metadata
+		}
+	}
+	return out, nil
+}
+
+// read returns the rows with the given keys that haven't expired.
+func (s *Spanner) read(ctx context.Context, keys []string, consistency string) (map[string]*state.GetResponse, error) {
+	tx := s.client.Single()
+	if consistency == state.Eventual && s.metadata.MaxStaleness > 0 {
+		tx = tx.WithTimestampBound(spanner.MaxStaleness(s.metadata.MaxStaleness))
+	}
+
+	stmt := spanner.Statement{
+		SQL: "SELECT " + colKey + ", " + colValue + ", " + colUpdateTime + ", " + colExpiration +
+			" FROM `" + s.metadata.TableName + "`" +
+			" WHERE " + colKey + " IN UNNEST(@keys)" +
+			" AND (" + colExpiration + " IS NULL OR " + colExpiration + " > @now)",
+		Params: map[string]any{
+			"keys": keys,
+			"now":  time.Now(),
+		},
+	}
+	res := make(map[string]*state.GetResponse, len(keys))
+	err := tx.Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var (
+			key        string
+			value      []byte
+			updateTime time.Time
+			expiration spanner.NullTime
+		)
+		err := r.Columns(&key, &value, &updateTime, &expiration)
+		if err != nil {
+			return err
+		}
+
+		item := &state.GetResponse{
+			Data: value,
+			ETag: ptr.Of(formatETag(updateTime)),
+		}
+		if expiration.Valid {
+			item.Metadata = map[string]string{
+				state.GetRespMetaKeyTTLExpireTime: expiration.Time.UTC().Format(time.RFC3339),
+			}
+		}
+		res[key] = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Set stores the value of a key.
+// When the request has an ETag or uses first-write concurrency, the value is stored in a read-write transaction that checks the current row.
+func (s *Spanner) Set(ctx context.Context, req *state.SetRequest) error {
+	mutation, err := s.setMutation(req)
+	if err != nil {
+		return err
+	}
+
+	if !req.HasETag() && req.Options.Concurrency != state.FirstWrite {
+		_, err = s.client.Apply(ctx, []*spanner.Mutation{mutation})
+		return err
+	}
+
+	return s.applyWithETag(ctx, req.Key, req.ETag, req.Options.Concurrency == state.FirstWrite, mutation)
+}
+
+// Delete removes a key.
+func (s *Spanner) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	mutation := spanner.Delete(s.metadata.TableName, spanner.Key{req.Key})
+
+	if !req.HasETag() {
+		_, err := s.client.Apply(ctx, []*spanner.Mutation{mutation})
+		return err
+	}
+
+	return s.applyWithETag(ctx, req.Key, req.ETag, false, mutation)
+}
+
+// BulkSet stores multiple values.
+// If no request requires concurrency checks, all values are written in a single commit.
+func (s *Spanner) BulkSet(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+	mutations := make([]*spanner.Mutation, len(req))
+	for i := range req {
+		if req[i].HasETag() || req[i].Options.Concurrency == state.FirstWrite {
+			return state.DoBulkSetDelete(ctx, req, s.Set, opts)
+		}
+
+		var err error
+		mutations[i], err = s.setMutation(&req[i])
+		if err != nil {
+			return err
+		}
+	}
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	_, err := s.client.Apply(ctx, mutations)
+	return err
+}
+
+// BulkDelete removes multiple keys.
+// If no request has an ETag, all keys are removed in a single commit.
+func (s *Spanner) BulkDelete(ctx context.Context, req []state.DeleteRequest, opts state.BulkStoreOpts) error {
+	mutations := make([]*spanner.Mutation, len(req))
+	for i := range req {
+		if req[i].HasETag() {
+			return state.DoBulkSetDelete(ctx, req, s.Delete, opts)
+		}
+		mutations[i] = spanner.Delete(s.metadata.TableName, spanner.Key{req[i].Key})
+	}
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	_, err := s.client.Apply(ctx, mutations)
+	return err
+}
+
+func (s *Spanner) setMutation(req *state.SetRequest) (*spanner.Mutation, error) {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	if b, ok := req.Value.([]byte); ok {
+		value = b
+	} else {
+		value, err = json.Marshal(req.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ttl, err := stateutils.ParseTTL(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TTL: %w", err)
+	}
+	var expiration spanner.NullTime
+	if ttl != nil && *ttl > 0 {
+		expiration = spanner.NullTime{
+			Time:  time.Now().Add(time.Duration(*ttl) * time.Second),
+			Valid: true,
+		}
+	}
+
+	return spanner.InsertOrUpdate(s.metadata.TableName,
+		[]string{colKey, colValue, colUpdateTime, colExpiration},
+		[]any{req.Key, value, spanner.CommitTimestamp, expiration},
+	), nil
+}
+
+// applyWithETag applies the mutation in a read-write transaction, after checking that the ETag matches the current row.
+// With firstWrite and no ETag, the mutation is applied only if the row doesn't exist.
+func (s *Spanner) applyWithETag(ctx context.Context, key string, etag *string, firstWrite bool, mutation *spanner.Mutation) error {
+	_, err := s.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		current, err := s.currentETag(ctx, txn, key)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case etag != nil && *etag != "":
+			if current == "" || current != *etag {
+				return state.NewETagError(state.ETagMismatch, nil)
+			}
+		case firstWrite:
+			if current != "" {
+				return state.NewETagError(state.ETagMismatch, nil)
+			}
+		}
+
+		return txn.BufferWrite([]*spanner.Mutation{mutation})
+	})
+	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return etagErr
+		}
+		return err
+	}
+	return nil
+}
+
+// currentETag returns the ETag of the row in the transaction, or an empty string if the row doesn't exist or has expired.
+func (s *Spanner) currentETag(ctx context.Context, txn *spanner.ReadWriteTransaction, key string) (string, error) {
+	row, err := txn.ReadRow(ctx, s.metadata.TableName, spanner.Key{key}, []string{colUpdateTime, colExpiration})
+	if spanner.ErrCode(err) == codes.NotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	var (
+		updateTime time.Time
+		expiration spanner.NullTime
+	)
+	err = row.Columns(&updateTime, &expiration)
+	if err != nil {
+		return "", err
+	}
+	if expiration.Valid && !expiration.Time.After(time.Now()) {
+		return "", nil
+	}
+	return formatETag(updateTime), nil
+}
+
+// formatETag returns the ETag for a row, which is the commit timestamp of its last update.
+func formatETag(updateTime time.Time) string {
+	return strconv.FormatInt(updateTime.UnixNano(), 10)
+}
+
+func (s *Spanner) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := spannerMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.StateStoreType)
+	return
+}
+
+// Close closes the client.
+func (s *Spanner) Close() error {
+	if s.client != nil {
+		s.client.Close()
+	}
+	return nil
+}
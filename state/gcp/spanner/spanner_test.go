@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := spannerMetadata{}
+		err := m.Parse(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"projectID":  "project",
+			"instanceID": "instance",
+			"databaseID": "db",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTableName, m.TableName)
+		assert.Equal(t, defaultMaxStaleness, m.MaxStaleness)
+		assert.Equal(t, "projects/project/instances/instance/databases/db", m.databasePath())
+	})
+
+	t.Run("missing required properties", func(t *testing.T) {
+		for _, missing := range []string{"projectID", "instanceID", "databaseID"} {
+			props := map[string]string{
+				"projectID":  "project",
+				"instanceID": "instance",
+				"databaseID": "db",
+			}
+			delete(props, missing)
+			m := spannerMetadata{}
+			err := m.Parse(state.Metadata{Base: metadata.Base{Properties: props}})
+			require.ErrorContains(t, err, missing)
+		}
+	})
+
+	t.Run("invalid table name", func(t *testing.T) {
+		m := spannerMetadata{}
+		err := m.Parse(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"projectID":  "project",
+			"instanceID": "instance",
+			"databaseID": "db",
+			"tableName":  "state; DROP TABLE x",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestSpannerStateStore(t *testing.T) {
+	srv, err := spannertest.NewServer("localhost:0")
+	require.NoError(t, err)
+	defer srv.Close()
+
+	// The in-memory server doesn't support INFORMATION_SCHEMA, so the table is created here
+	ddl, err := spansql.ParseDDL("", createTableDDL(defaultTableName))
+	require.NoError(t, err)
+	require.NoError(t, srv.UpdateDDL(ddl))
+
+	store := NewSpannerStateStore(logger.NewLogger("test")).(*Spanner)
+	err = store.Init(context.Background(), state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"projectID":  "project",
+		"instanceID": "instance",
+		"databaseID": "db",
+		"endpoint":   srv.Addr,
+
+		"disableTableCreation": "true",
+	}}})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	err = store.Set(ctx, &state.SetRequest{Key: "k1", Value: map[string]string{"a": "b"}})
+	require.NoError(t, err)
+	res, err := store.Get(ctx, &state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":"b"}`, string(res.Data))
+	require.NotNil(t, res.ETag)
+	etag := *res.ETag
+
+	// Missing keys
+	res, err = store.Get(ctx, &state.GetRequest{Key: "missing"})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+	assert.Nil(t, res.ETag)
+
+	// ETag mismatch
+	err = store.Set(ctx, &state.SetRequest{Key: "k1", Value: []byte("v2"), ETag: ptr.Of("bad")})
+	var etagErr *state.ETagError
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	// Matching ETag
+	err = store.Set(ctx, &state.SetRequest{Key: "k1", Value: []byte("v2"), ETag: &etag})
+	require.NoError(t, err)
+	res, err = store.Get(ctx, &state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(res.Data))
+	assert.NotEqual(t, etag, *res.ETag)
+
+	// First write on existing key
+	err = store.Set(ctx, &state.SetRequest{Key: "k1", Value: []byte("v3"), Options: state.SetStateOption{Concurrency: state.FirstWrite}})
+	require.ErrorAs(t, err, &etagErr)
+
+	// Delete with old ETag fails, then with the current one succeeds
+	err = store.Delete(ctx, &state.DeleteRequest{Key: "k1", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	err = store.Delete(ctx, &state.DeleteRequest{Key: "k1", ETag: res.ETag})
+	require.NoError(t, err)
+
+	// Bulk operations
+	err = store.BulkSet(ctx, []state.SetRequest{
+		{Key: "b1", Value: []byte("1")},
+		{Key: "b2", Value: []byte("2"), Metadata: map[string]string{"ttlInSeconds": "3600"}},
+	}, state.BulkStoreOpts{})
+	require.NoError(t, err)
+	bulk, err := store.BulkGet(ctx, []state.GetRequest{{Key: "b1"}, {Key: "b2"}, {Key: "b3"}}, state.BulkGetOpts{})
+	require.NoError(t, err)
+	require.Len(t, bulk, 3)
+	assert.Equal(t, "1", string(bulk[0].Data))
+	assert.Equal(t, "2", string(bulk[1].Data))
+	assert.Contains(t, bulk[1].Metadata, state.GetRespMetaKeyTTLExpireTime)
+	assert.Nil(t, bulk[2].Data)
+
+	err = store.BulkDelete(ctx, []state.DeleteRequest{{Key: "b1"}, {Key: "b2"}}, state.BulkStoreOpts{})
+	require.NoError(t, err)
+	res, err = store.Get(ctx, &state.GetRequest{Key: "b1"})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+
+	// Expired rows are ignored even if they haven't been deleted yet
+	err = store.Set(ctx, &state.SetRequest{Key: "ttl", Value: []byte("v"), Metadata: map[string]string{"ttlInSeconds": "1"}})
+	require.NoError(t, err)
+	time.Sleep(1100 * time.Millisecond)
+	res, err = store.Get(ctx, &state.GetRequest{Key: "ttl"})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+}
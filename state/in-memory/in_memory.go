@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,23 +28,33 @@ import (
 	"github.com/google/uuid"
 	"k8s.io/utils/clock"
 
+	"github.com/dapr/components-contrib/common/component/inmemory"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
 	"github.com/dapr/kit/ptr"
 )
 
+type inMemoryMetadata struct {
+	inmemory.PersistenceMetadata `mapstructure:",squash"`
+	inmemory.FaultMetadata       `mapstructure:",squash"`
+}
+
 type inMemoryStore struct {
 	state.BulkStore
 
-	items   map[string]*inMemStateStoreItem
-	lock    sync.RWMutex
-	log     logger.Logger
-	clock   clock.Clock
-	closeCh chan struct{}
-	closed  atomic.Bool
-	wg      sync.WaitGroup
+	items    map[string]*inMemStateStoreItem
+	lock     sync.RWMutex
+	log      logger.Logger
+	clock    clock.Clock
+	metadata inMemoryMetadata
+	faults   *inmemory.Faults
+	dirty    atomic.Bool
+	closeCh  chan struct{}
+	closed   atomic.Bool
+	wg       sync.WaitGroup
 }
 
 func NewInMemoryStateStore(log logger.Logger) state.Store {
@@ -62,6 +73,33 @@ func newStateStore(log logger.Logger) *inMemoryStore {
 }
 
 func (store *inMemoryStore) Init(ctx context.Context, metadata state.Metadata) error {
+	err := kitmd.DecodeMetadata(metadata.Properties, &store.metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	err = store.metadata.PersistenceMetadata.Validate()
+	if err != nil {
+		return err
+	}
+	store.faults, err = inmemory.NewFaults(store.metadata.FaultMetadata)
+	if err != nil {
+		return err
+	}
+
+	if store.metadata.Enabled() {
+		err = store.loadSnapshot()
+		if err != nil {
+			return err
+		}
+		if store.metadata.SnapshotInterval > 0 {
+			store.wg.Add(1)
+			go func() {
+				defer store.wg.Done()
+				store.startSnapshotThread()
+			}()
+		}
+	}
+
 	// start a background go routine to clean expired item
 	store.wg.Add(1)
 	go func() {
@@ -71,9 +109,17 @@ func (store *inMemoryStore) Init(ctx context.Context, metadata state.Metadata) e
 	return nil
 }
 
-func (store *inMemoryStore) Close() error {
-	if store.closed.CompareAndSwap(false, true) {
-		close(store.closeCh)
+func (store *inMemoryStore) Close() (err error) {
+	if !store.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	close(store.closeCh)
+	store.wg.Wait()
+
+	// save the final snapshot before the data is released
+	if store.metadata.Enabled() {
+		store.dirty.Store(true)
+		err = store.saveSnapshot()
 	}
 
 	// release memory reference
@@ -83,9 +129,7 @@ func (store *inMemoryStore) Close() error {
 		delete(store.items, k)
 	}
 
-	store.wg.Wait()
-
-	return nil
+	return err
 }
 
 func (store *inMemoryStore) Features() []state.Feature {
@@ -98,6 +142,10 @@ func (store *inMemoryStore) Features() []state.Feature {
 }
 
 func (store *inMemoryStore) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	if err := store.faults.Inject(ctx); err != nil {
+		return err
+	}
+
 	// step1: validate parameters
 	if err := state.CheckRequestOptions(req.Options); err != nil {
 		return err
@@ -119,8 +167,13 @@ func (store *inMemoryStore) Delete(ctx context.Context, req *state.DeleteRequest
 }
 
 func (store *inMemoryStore) DeleteWithPrefix(ctx context.Context, req state.DeleteWithPrefixRequest) (state.DeleteWithPrefixResponse, error) {
+	err := store.faults.Inject(ctx)
+	if err != nil {
+		return state.DeleteWithPrefixResponse{}, err
+	}
+
 	// step1: validate parameters
-	err := req.Validate()
+	err = req.Validate()
 	if err != nil {
 		return state.DeleteWithPrefixResponse{}, err
 	}
@@ -138,7 +191,7 @@ func (store *inMemoryStore) DeleteWithPrefix(ctx context.Context, req state.Dele
 			// The string contains the prefix, now we check to make sure there aren't more || after
 			longerPrefix := strings.Contains(key[len(req.Prefix):], "||")
 			if !longerPrefix {
-				delete(store.items, key)
+				store.doDelete(ctx, key)
 				count++
 			}
 		}
@@ -175,9 +228,14 @@ func (store *inMemoryStore) doValidateEtag(key string, etag *string, concurrency
 
 func (store *inMemoryStore) doDelete(ctx context.Context, key string) {
 	delete(store.items, key)
+	store.dirty.Store(true)
 }
 
 func (store *inMemoryStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	if err := store.faults.Inject(ctx); err != nil {
+		return nil, err
+	}
+
 	store.lock.RLock()
 	item := store.items[req.Key]
 	store.lock.RUnlock()
@@ -202,6 +260,10 @@ func (store *inMemoryStore) Get(ctx context.Context, req *state.GetRequest) (*st
 }
 
 func (store *inMemoryStore) BulkGet(ctx context.Context, req []state.GetRequest, _ state.BulkGetOpts) ([]state.BulkGetResponse, error) {
+	if err := store.faults.Inject(ctx); err != nil {
+		return nil, err
+	}
+
 	res := make([]state.BulkGetResponse, len(req))
 	if len(req) == 0 {
 		return res, nil
@@ -242,7 +304,7 @@ func (store *inMemoryStore) getAndExpire(key string) *inMemStateStoreItem {
 		return nil
 	}
 	if item.isExpired(store.clock.Now()) {
-		delete(store.items, key)
+		store.doDelete(context.Background(), key)
 		return nil
 	}
 	return item
@@ -262,6 +324,11 @@ func (store *inMemoryStore) marshal(v any) (bt []byte, err error) {
 }
 
 func (store *inMemoryStore) Set(ctx context.Context, req *state.SetRequest) error {
+	err := store.faults.Inject(ctx)
+	if err != nil {
+		return err
+	}
+
 	// step1: validate parameters
 	ttlInSeconds, err := store.doSetValidateParameters(req)
 	if err != nil {
@@ -332,6 +399,7 @@ func (store *inMemoryStore) doSet(ctx context.Context, key string, data []byte,
 	}
 
 	store.items[key] = el
+	store.dirty.Store(true)
 }
 
 // innerSetRequest is only used to pass ttlInSeconds and data with SetRequest.
@@ -360,6 +428,10 @@ func (store *inMemoryStore) Multi(ctx context.Context, request *state.Transactio
 		return nil
 	}
 
+	if err := store.faults.Inject(ctx); err != nil {
+		return err
+	}
+
 	// step1: validate parameters
 	for i, o := range request.Operations {
 		switch req := o.(type) {
@@ -442,8 +514,25 @@ func (store *inMemoryStore) doCleanExpiredItems() {
 	}
 }
 
+func (store *inMemoryStore) startSnapshotThread() {
+	ticker := time.NewTicker(store.metadata.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := store.saveSnapshot()
+			if err != nil {
+				store.log.Errorf("Failed to save snapshot: %v", err)
+			}
+		case <-store.closeCh:
+			return
+		}
+	}
+}
+
 func (store *inMemoryStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
-	// no metadata, hence no metadata struct to convert here
+	metadataStruct := inMemoryMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.StateStoreType)
 	return
 }
 
@@ -15,6 +15,7 @@ package inmemory
 
 import (
 	"context"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 	clocktesting "k8s.io/utils/clock/testing"
 
+	"github.com/dapr/components-contrib/common/component/inmemory"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
@@ -178,3 +180,125 @@ func TestReadAndWrite(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	md := state.Metadata{}
+	md.Properties = map[string]string{"persistencePath": path}
+
+	store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+	require.NoError(t, store.Init(context.Background(), md))
+	require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "a", Value: []byte("1")}))
+	require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "b", Value: []byte("2")}))
+	require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "expiring", Value: []byte("3"), Metadata: map[string]string{"ttlInSeconds": "1"}}))
+	resA, err := store.Get(context.Background(), &state.GetRequest{Key: "a"})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	t.Run("items are restored from the snapshot", func(t *testing.T) {
+		restored := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.NoError(t, restored.Init(context.Background(), md))
+		defer restored.Close()
+
+		res, err := restored.Get(context.Background(), &state.GetRequest{Key: "a"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), res.Data)
+		assert.Equal(t, resA.ETag, res.ETag)
+
+		res, err = restored.Get(context.Background(), &state.GetRequest{Key: "b"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("2"), res.Data)
+
+		res, err = restored.Get(context.Background(), &state.GetRequest{Key: "expiring"})
+		require.NoError(t, err)
+		assert.NotNil(t, res.Metadata[state.GetRespMetaKeyTTLExpireTime])
+	})
+
+	t.Run("expired items are discarded", func(t *testing.T) {
+		restored := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		restored.clock = clocktesting.NewFakeClock(time.Now().Add(time.Minute))
+		require.NoError(t, restored.Init(context.Background(), md))
+		defer restored.Close()
+
+		restored.lock.RLock()
+		defer restored.lock.RUnlock()
+		assert.Len(t, restored.items, 2)
+		assert.NotContains(t, restored.items, "expiring")
+	})
+
+	t.Run("periodic snapshots", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		md := state.Metadata{}
+		md.Properties = map[string]string{"persistencePath": path, "snapshotInterval": "10ms"}
+
+		store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.NoError(t, store.Init(context.Background(), md))
+		defer store.Close()
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "a", Value: []byte("1")}))
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			var snapshot stateSnapshot
+			found, err := inmemory.ReadSnapshot(path, &snapshot)
+			require.NoError(c, err)
+			assert.True(c, found)
+			assert.Contains(c, snapshot.Items, "a")
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		md := state.Metadata{}
+		md.Properties = map[string]string{"persistencePath": t.TempDir()}
+
+		store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.Error(t, store.Init(context.Background(), md))
+	})
+}
+
+func TestFaultInjection(t *testing.T) {
+	t.Run("invalid options", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{"faultErrorRate": "2"},
+			{"faultLatency": "-1s"},
+			{"snapshotInterval": "-1s"},
+		} {
+			md := state.Metadata{}
+			md.Properties = props
+			store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+			require.Errorf(t, store.Init(context.Background(), md), "expected error for %v", props)
+		}
+	})
+
+	t.Run("all operations fail", func(t *testing.T) {
+		md := state.Metadata{}
+		md.Properties = map[string]string{"faultErrorRate": "1"}
+		store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.NoError(t, store.Init(context.Background(), md))
+		defer store.Close()
+
+		ctx := context.Background()
+		require.ErrorIs(t, store.Set(ctx, &state.SetRequest{Key: "a", Value: "1"}), inmemory.ErrInjectedFault)
+		_, err := store.Get(ctx, &state.GetRequest{Key: "a"})
+		require.ErrorIs(t, err, inmemory.ErrInjectedFault)
+		_, err = store.BulkGet(ctx, []state.GetRequest{{Key: "a"}}, state.BulkGetOpts{})
+		require.ErrorIs(t, err, inmemory.ErrInjectedFault)
+		require.ErrorIs(t, store.Delete(ctx, &state.DeleteRequest{Key: "a"}), inmemory.ErrInjectedFault)
+		_, err = store.DeleteWithPrefix(ctx, state.DeleteWithPrefixRequest{Prefix: "a||"})
+		require.ErrorIs(t, err, inmemory.ErrInjectedFault)
+		err = store.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{state.SetRequest{Key: "a", Value: "1"}},
+		})
+		require.ErrorIs(t, err, inmemory.ErrInjectedFault)
+	})
+
+	t.Run("added latency", func(t *testing.T) {
+		md := state.Metadata{}
+		md.Properties = map[string]string{"faultLatency": "50ms"}
+		store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.NoError(t, store.Init(context.Background(), md))
+		defer store.Close()
+
+		start := time.Now()
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "a", Value: "1"}))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"time"
+
+	"github.com/dapr/components-contrib/common/component/inmemory"
+)
+
+// stateSnapshot is the content of the snapshots saved to disk.
+type stateSnapshot struct {
+	Items map[string]snapshotItem `json:"items"`
+}
+
+type snapshotItem struct {
+	Data   []byte     `json:"data"`
+	ETag   *string    `json:"etag,omitempty"`
+	Expire *time.Time `json:"expire,omitempty"`
+}
+
+// loadSnapshot restores the items from the snapshot saved at the persistence path, if any.
+// Items that expired in the meanwhile are discarded.
+func (store *inMemoryStore) loadSnapshot() error {
+	var snapshot stateSnapshot
+	found, err := inmemory.ReadSnapshot(store.metadata.PersistencePath, &snapshot)
+	if err != nil || !found {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	now := store.clock.Now()
+	for key, si := range snapshot.Items {
+		item := &inMemStateStoreItem{
+			data:   si.Data,
+			etag:   si.ETag,
+			expire: si.Expire,
+		}
+		if !item.isExpired(now) {
+			store.items[key] = item
+		}
+	}
+	store.log.Infof("Restored %d items from snapshot %s", len(store.items), store.metadata.PersistencePath)
+	return nil
+}
+
+// saveSnapshot saves all items to the persistence path, if they changed since the last snapshot.
+func (store *inMemoryStore) saveSnapshot() error {
+	if !store.dirty.Swap(false) {
+		return nil
+	}
+
+	store.lock.RLock()
+	snapshot := stateSnapshot{
+		Items: make(map[string]snapshotItem, len(store.items)),
+	}
+	for key, item := range store.items {
+		snapshot.Items[key] = snapshotItem{
+			Data:   item.data,
+			ETag:   item.etag,
+			Expire: item.expire,
+		}
+	}
+	store.lock.RUnlock()
+
+	err := inmemory.WriteSnapshot(store.metadata.PersistencePath, snapshot)
+	if err != nil {
+		// Try again at the next snapshot
+		store.dirty.Store(true)
+		return err
+	}
+	return nil
+}
@@ -16,9 +16,12 @@ package jetstream
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/nats-io/nats.go"
@@ -48,6 +51,11 @@ type jetstreamMetadata struct {
 	Jwt     string
 	SeedKey string
 	Bucket  string
+
+	// If true, the bucket is created when it doesn't exist
+	CreateBucket bool
+	// How long the bucket keeps values for; only used when the bucket is created by the component
+	TTL time.Duration
 }
 
 // NewJetstreamStateStore returns a new nats jetstream KV state store.
@@ -90,39 +98,113 @@ func (js *StateStore) Init(_ context.Context, metadata state.Metadata) error {
 	}
 
 	js.bucket, err = jsc.KeyValue(meta.Bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) && meta.CreateBucket {
+		js.bucket, err = jsc.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket: meta.Bucket,
+			TTL:    meta.TTL,
+		})
+	}
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to open bucket %s: %w", meta.Bucket, err)
 	}
 
 	return nil
 }
 
+// Features returns the features available in this state store.
+// ETags are the revisions of the keys in the bucket.
 func (js *StateStore) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag}
 }
 
 // Get retrieves state with a key.
 func (js *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
 	entry, err := js.bucket.Get(escape(req.Key))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return &state.GetResponse{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	etag := strconv.FormatUint(entry.Revision(), 10)
 	return &state.GetResponse{
 		Data: entry.Value(),
+		ETag: &etag,
 	}, nil
 }
 
 // Set stores value for a key.
+// If the request has an ETag, the value is stored only if it matches the revision of the key; with first-write concurrency, only if the key doesn't exist.
 func (js *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+
 	bt, _ := utils.Marshal(req.Value, js.json.Marshal)
-	_, err := js.bucket.Put(escape(req.Key), bt)
+	key := escape(req.Key)
+	switch {
+	case req.HasETag():
+		var revision uint64
+		revision, err = parseETag(req.ETag)
+		if err != nil {
+			return err
+		}
+		_, err = js.bucket.Update(key, bt, revision)
+	case req.Options.Concurrency == state.FirstWrite:
+		_, err = js.bucket.Create(key, bt)
+	default:
+		_, err = js.bucket.Put(key, bt)
+	}
+	if isRevisionMismatch(err) {
+		return state.NewETagError(state.ETagMismatch, err)
+	}
 	return err
 }
 
 // Delete performs a delete operation.
+// If the request has an ETag, the key is deleted only if it matches the revision of the key.
 func (js *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error {
-	return js.bucket.Delete(escape(req.Key))
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+
+	var opts []nats.DeleteOpt
+	if req.HasETag() {
+		revision, err := parseETag(req.ETag)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, nats.LastRevision(revision))
+	}
+
+	err = js.bucket.Delete(escape(req.Key), opts...)
+	if isRevisionMismatch(err) {
+		return state.NewETagError(state.ETagMismatch, err)
+	}
+	return err
+}
+
+func parseETag(etag *string) (uint64, error) {
+	revision, err := strconv.ParseUint(*etag, 10, 64)
+	if err != nil {
+		return 0, state.NewETagError(state.ETagInvalid, err)
+	}
+	return revision, nil
+}
+
+// isRevisionMismatch returns true if the error is caused by the key not being at the expected revision.
+func isRevisionMismatch(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, nats.ErrKeyExists) {
+		return true
+	}
+	var apiErr *nats.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence
 }
 
 func (js *StateStore) getMetadata(meta state.Metadata) (jetstreamMetadata, error) {
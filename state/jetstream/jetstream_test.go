@@ -19,13 +19,17 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/nats-io/nats-server/v2/server"
 	natsserver "github.com/nats-io/nats-server/v2/test"
 	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
 )
 
 type tLogger interface {
@@ -37,16 +41,18 @@ func runServerWithOptions(opts server.Options) *server.Server {
 	return natsserver.RunServer(&opts)
 }
 
-func runServerOnPort(port int) *server.Server {
+func runServerOnPort(t testing.TB, port int) *server.Server {
 	opts := natsserver.DefaultTestOptions
 	opts.Port = port
 	opts.JetStream = true
+	// Use a separate store for each test, so buckets don't persist across runs
+	opts.StoreDir = t.TempDir()
 	opts.Cluster.Name = "testing"
 	return runServerWithOptions(opts)
 }
 
-func runDefaultServer() *server.Server {
-	return runServerOnPort(nats.DefaultPort)
+func runDefaultServer(t testing.TB) *server.Server {
+	return runServerOnPort(t, nats.DefaultPort)
 }
 
 func newDefaultConnection(t tLogger) *nats.Conn {
@@ -81,7 +87,7 @@ func connectAndCreateBucket(t *testing.T) (nats.KeyValue, *nats.Conn) {
 }
 
 func TestDefaultConnection(t *testing.T) {
-	s := runDefaultServer()
+	s := runDefaultServer(t)
 	defer s.Shutdown()
 
 	_, nc := connectAndCreateBucket(t)
@@ -89,7 +95,7 @@ func TestDefaultConnection(t *testing.T) {
 }
 
 func TestSetGetAndDelete(t *testing.T) {
-	s := runDefaultServer()
+	s := runDefaultServer(t)
 	defer s.Shutdown()
 
 	_, nc := connectAndCreateBucket(t)
@@ -143,11 +149,107 @@ func TestSetGetAndDelete(t *testing.T) {
 		return
 	}
 
-	_, err = store.Get(context.Background(), &state.GetRequest{
+	resp, err = store.Get(context.Background(), &state.GetRequest{
 		Key: tkey,
 	})
-	if err == nil {
+	if err != nil {
+		t.Fatalf("Could not get after delete: %v\n", err)
+		return
+	}
+	if resp.Data != nil || resp.ETag != nil {
 		t.Fatal("Could get after delete\n")
 		return
 	}
 }
+
+func TestETags(t *testing.T) {
+	s := runDefaultServer(t)
+	defer s.Shutdown()
+
+	_, nc := connectAndCreateBucket(t)
+	nc.Close()
+
+	store := NewJetstreamStateStore(nil)
+	err := store.Init(context.Background(), state.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			"natsURL": nats.DefaultURL,
+			"bucket":  "test",
+		}},
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	firstWrite := state.SetRequest{
+		Key:     "key",
+		Value:   "v1",
+		Options: state.SetStateOption{Concurrency: state.FirstWrite},
+	}
+	err = store.Set(ctx, &firstWrite)
+	require.NoError(t, err)
+
+	// A second first-write fails because the key exists
+	err = store.Set(ctx, &firstWrite)
+	var etagErr *state.ETagError
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	resp, err := store.Get(ctx, &state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	require.NotNil(t, resp.ETag)
+	etag := *resp.ETag
+
+	err = store.Set(ctx, &state.SetRequest{Key: "key", Value: "v2", ETag: &etag})
+	require.NoError(t, err)
+
+	// The ETag changed with the update
+	err = store.Set(ctx, &state.SetRequest{Key: "key", Value: "v3", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	err = store.Delete(ctx, &state.DeleteRequest{Key: "key", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	err = store.Set(ctx, &state.SetRequest{Key: "key", Value: "v3", ETag: ptr.Of("invalid")})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+
+	resp, err = store.Get(ctx, &state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(resp.Data))
+
+	err = store.Delete(ctx, &state.DeleteRequest{Key: "key", ETag: resp.ETag})
+	require.NoError(t, err)
+
+	// After a delete, first-write succeeds again
+	err = store.Set(ctx, &firstWrite)
+	require.NoError(t, err)
+}
+
+func TestCreateBucket(t *testing.T) {
+	s := runDefaultServer(t)
+	defer s.Shutdown()
+
+	props := map[string]string{
+		"natsURL": nats.DefaultURL,
+		"bucket":  "created",
+	}
+
+	store := NewJetstreamStateStore(nil)
+	err := store.Init(context.Background(), state.Metadata{
+		Base: metadata.Base{Properties: props},
+	})
+	require.ErrorIs(t, err, nats.ErrBucketNotFound)
+
+	props["createBucket"] = "true"
+	props["ttl"] = "1h"
+	err = store.Init(context.Background(), state.Metadata{
+		Base: metadata.Base{Properties: props},
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	status, err := store.(*StateStore).bucket.Status()
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, status.TTL())
+}
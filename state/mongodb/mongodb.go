@@ -59,6 +59,9 @@ const (
 	etag             = "_etag"
 	ttl              = "_ttl"
 
+	// Query request metadata key with the comma-separated paths of the value to return.
+	metadataProjectionKey = "projection"
+
 	defaultTimeout        = 5 * time.Second
 	defaultDatabaseName   = "daprStore"
 	defaultCollectionName = "daprCollection"
@@ -102,6 +105,7 @@ type mongoDBMetadata struct {
 	Params           string
 	ConnectionString string
 	OperationTimeout time.Duration
+	QueryIndexes     []string
 }
 
 // Item is Mongodb document wrapper.
@@ -174,6 +178,13 @@ func (m *MongoDB) Init(ctx context.Context, metadata state.Metadata) (err error)
 		return fmt.Errorf("error in creating ttl index: %s", err)
 	}
 
+	if len(m.metadata.QueryIndexes) > 0 {
+		_, err = m.collection.Indexes().CreateMany(ctx, queryIndexModels(m.metadata.QueryIndexes))
+		if err != nil {
+			return fmt.Errorf("error in creating query indexes: %s", err)
+		}
+	}
+
 	if !m.isReplicaSet {
 		m.logger.Info("Connected to MongoDB without a replica set. Transactions are not available, and the component cannot be used as actor state store.")
 	}
@@ -196,7 +207,9 @@ func (m *MongoDB) Set(ctx context.Context, req *state.SetRequest) error {
 	return nil
 }
 
-func (m *MongoDB) Ping(ctx context.Context) error {
+func (m *MongoDB) Ping(parentCtx context.Context) error {
+	ctx, cancel := context.WithTimeout(parentCtx, m.operationTimeout)
+	defer cancel()
 	if err := m.client.Ping(ctx, nil); err != nil {
 		return fmt.Errorf("error connecting to mongoDB at %s: %s", m.metadata.Host, err)
 	}
@@ -557,7 +570,11 @@ func (m *MongoDB) doTransaction(sessCtx mongo.SessionContext, operations []state
 
 // Query executes a query against store.
 func (m *MongoDB) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
-	q := &Query{}
+	fields, err := parseQueryFields(req.Metadata)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+	q := &Query{fields: fields}
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
@@ -646,6 +663,19 @@ func getMongoDBMetaData(meta state.Metadata) (mongoDBMetadata, error) {
 		}
 	}
 
+	indexes := make([]string, 0, len(m.QueryIndexes))
+	for _, path := range m.QueryIndexes {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if err = validateValuePath(path); err != nil {
+			return m, fmt.Errorf("incorrect queryIndexes field from metadata: %w", err)
+		}
+		indexes = append(indexes, path)
+	}
+	m.QueryIndexes = indexes
+
 	if val, ok := meta.Properties[operationTimeout]; ok && val != "" {
 		m.OperationTimeout, err = time.ParseDuration(val)
 		if err != nil {
@@ -656,6 +686,17 @@ func getMongoDBMetaData(meta state.Metadata) (mongoDBMetadata, error) {
 	return m, nil
 }
 
+// queryIndexModels returns the indexes on the paths of the value that are commonly used in query filters.
+func queryIndexModels(paths []string) []mongo.IndexModel {
+	models := make([]mongo.IndexModel, len(paths))
+	for i, path := range paths {
+		models[i] = mongo.IndexModel{
+			Keys: bson.D{{Key: value + "." + path, Value: 1}},
+		}
+	}
+	return models
+}
+
 func getWriteConcernObject(cn string) (*writeconcern.WriteConcern, error) {
 	var wc *writeconcern.WriteConcern
 	if cn != "" {
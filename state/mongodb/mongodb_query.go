@@ -29,10 +29,16 @@ import (
 	"github.com/dapr/components-contrib/state/query"
 )
 
+// Query is executed as an aggregation pipeline, so sorting and pagination are performed by the server, spilling to disk if needed, and only the fields needed for the results are returned.
 type Query struct {
 	query  string
 	filter interface{}
-	opts   *options.FindOptions
+	sort   bson.D
+	skip   int64
+	limit  int64
+
+	// Paths of the value to return; if empty, the whole value is returned
+	fields []string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -196,37 +202,64 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	} else if err := bson.UnmarshalExtJSON([]byte(filters), false, &q.filter); err != nil {
 		return err
 	}
-	q.opts = options.Find()
 
 	// sorting
+	q.sort = nil
 	if len(qq.Sort) > 0 {
-		sort := bson.D{}
 		for _, s := range qq.Sort {
 			order := 1 // ascending
 			if s.Order == query.DESC {
 				order = -1
 			}
-			sort = append(sort, bson.E{Key: "value." + s.Key, Value: order})
+			q.sort = append(q.sort, bson.E{Key: "value." + s.Key, Value: order})
 		}
-		q.opts.SetSort(sort)
+		// Sort by key too, so that pages are consistent when documents have the same values
+		q.sort = append(q.sort, bson.E{Key: id, Value: 1})
 	}
 	// pagination
-	if qq.Page.Limit > 0 {
-		q.opts.SetLimit(int64(qq.Page.Limit))
-	}
+	q.limit = int64(qq.Page.Limit)
+	q.skip = 0
 	if len(qq.Page.Token) != 0 {
 		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
 		if err != nil {
 			return err
 		}
-		q.opts.SetSkip(skip)
+		q.skip = skip
 	}
 
 	return nil
 }
 
+// pipeline returns the stages of the aggregation pipeline that executes the query.
+func (q *Query) pipeline() mongo.Pipeline {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: q.filter}},
+	}
+	if len(q.sort) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: q.sort}})
+	}
+	if q.skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: q.skip}})
+	}
+	if q.limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: q.limit}})
+	}
+
+	projection := bson.D{{Key: etag, Value: 1}}
+	if len(q.fields) == 0 {
+		projection = append(projection, bson.E{Key: value, Value: 1})
+	} else {
+		for _, f := range q.fields {
+			projection = append(projection, bson.E{Key: value + "." + f, Value: 1})
+		}
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+
+	return pipeline
+}
+
 func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]state.QueryItem, string, error) {
-	cur, err := collection.Find(ctx, q.filter, []*options.FindOptions{q.opts}...)
+	cur, err := collection.Aggregate(ctx, q.pipeline(), options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
 		return nil, "", err
 	}
@@ -243,6 +276,9 @@ func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]st
 		}
 
 		switch obj := item.Value.(type) {
+		case nil:
+			// None of the projected fields exist in the value
+			result.Data = []byte("{}")
 		case string:
 			result.Data = []byte(obj)
 		case primitive.D:
@@ -268,13 +304,35 @@ func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]st
 	}
 	// set next query token only if limit is specified
 	var token string
-	if q.opts.Limit != nil && *q.opts.Limit != 0 {
-		var skip int64
-		if q.opts.Skip != nil {
-			skip = *q.opts.Skip
-		}
-		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	if q.limit > 0 {
+		token = strconv.FormatInt(q.skip+int64(len(ret)), 10)
 	}
 
 	return ret, token, nil
 }
+
+// parseQueryFields returns the paths of the value listed in the projection metadata of a query request.
+func parseQueryFields(reqMetadata map[string]string) ([]string, error) {
+	val := strings.TrimSpace(reqMetadata[metadataProjectionKey])
+	if val == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(val, ",")
+	for i, f := range fields {
+		f = strings.TrimSpace(f)
+		if err := validateValuePath(f); err != nil {
+			return nil, fmt.Errorf("invalid field in %s metadata: %w", metadataProjectionKey, err)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+// validateValuePath checks that a path of the value can be used as key in a projection or index.
+func validateValuePath(path string) error {
+	if path == "" || strings.HasPrefix(path, "$") || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+		return fmt.Errorf("invalid path %q", path)
+	}
+	return nil
+}
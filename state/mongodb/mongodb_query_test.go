@@ -20,6 +20,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -72,3 +74,73 @@ func TestMongoQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestMongoQueryPipeline(t *testing.T) {
+	tests := []struct {
+		input    string
+		fields   []string
+		pipeline mongo.Pipeline
+	}{
+		{
+			input: "../../tests/state/query/q1.json",
+			pipeline: mongo.Pipeline{
+				{{Key: "$match", Value: bson.D{}}},
+				{{Key: "$limit", Value: int64(2)}},
+				{{Key: "$project", Value: bson.D{{Key: "_etag", Value: 1}, {Key: "value", Value: 1}}}},
+			},
+		},
+		{
+			input:  "../../tests/state/query/q2-token.json",
+			fields: []string{"person.name", "state"},
+			pipeline: mongo.Pipeline{
+				{{Key: "$match", Value: bson.D{{Key: "value.state", Value: "CA"}}}},
+				{{Key: "$skip", Value: int64(2)}},
+				{{Key: "$limit", Value: int64(2)}},
+				{{Key: "$project", Value: bson.D{{Key: "_etag", Value: 1}, {Key: "value.person.name", Value: 1}, {Key: "value.state", Value: 1}}}},
+			},
+		},
+		{
+			input: "../../tests/state/query/q5.json",
+			pipeline: mongo.Pipeline{
+				{{Key: "$sort", Value: bson.D{{Key: "value.state", Value: -1}, {Key: "value.person.name", Value: 1}, {Key: "_id", Value: 1}}}},
+				{{Key: "$limit", Value: int64(2)}},
+				{{Key: "$project", Value: bson.D{{Key: "_etag", Value: 1}, {Key: "value", Value: 1}}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := &Query{fields: test.fields}
+			qbuilder := query.NewQueryBuilder(q)
+			err = qbuilder.BuildQuery(&qq)
+			require.NoError(t, err)
+
+			pipeline := q.pipeline()
+			if test.input == "../../tests/state/query/q5.json" {
+				// The filter is checked by TestMongoQuery
+				require.Equal(t, "$match", pipeline[0][0].Key)
+				pipeline = pipeline[1:]
+			}
+			assert.Equal(t, test.pipeline, pipeline)
+		})
+	}
+}
+
+func TestParseQueryFields(t *testing.T) {
+	fields, err := parseQueryFields(map[string]string{})
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	fields, err = parseQueryFields(map[string]string{metadataProjectionKey: "person.name, state"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"person.name", "state"}, fields)
+
+	_, err = parseQueryFields(map[string]string{metadataProjectionKey: "person.name,$where"})
+	require.Error(t, err)
+}
@@ -67,6 +67,38 @@ func TestGetMongoDBMetadata(t *testing.T) {
 		assert.Equal(t, properties[password], metadata.Password)
 	})
 
+	t.Run("With query indexes", func(t *testing.T) {
+		properties := map[string]string{
+			host:           "127.0.0.1",
+			"queryIndexes": "person.org, state,",
+		}
+		m := state.Metadata{
+			Base: metadata.Base{Properties: properties},
+		}
+
+		metadata, err := getMongoDBMetaData(m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"person.org", "state"}, metadata.QueryIndexes)
+
+		models := queryIndexModels(metadata.QueryIndexes)
+		require.Len(t, models, 2)
+		assert.Equal(t, bson.D{{Key: "value.person.org", Value: 1}}, models[0].Keys)
+		assert.Equal(t, bson.D{{Key: "value.state", Value: 1}}, models[1].Keys)
+	})
+
+	t.Run("Invalid query indexes", func(t *testing.T) {
+		properties := map[string]string{
+			host:           "127.0.0.1",
+			"queryIndexes": "person..org",
+		}
+		m := state.Metadata{
+			Base: metadata.Base{Properties: properties},
+		}
+
+		_, err := getMongoDBMetaData(m)
+		require.ErrorContains(t, err, "queryIndexes")
+	})
+
 	t.Run("Missing hosts", func(t *testing.T) {
 		properties := map[string]string{
 			username: "username",
@@ -25,6 +25,7 @@ func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 		ETagColumn:    "xmin",
 		EnableAzureAD: true,
 		EnableAWSIAM:  true,
+		EnableOutbox:  true,
 		MigrateFn:     performMigrations,
 		SetQueryFn: func(req *state.SetRequest, opts postgresql.SetQueryOptions) string {
 			// Sprintf is required for table name because the driver does not substitute parameters for table names.
@@ -14,13 +14,12 @@ limitations under the License.
 package postgresql
 
 import (
-	"errors"
 	"time"
 
 	"github.com/dapr/components-contrib/common/authentication/aws"
 	pgauth "github.com/dapr/components-contrib/common/authentication/postgresql"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
-	"github.com/dapr/kit/metadata"
 	"github.com/dapr/kit/ptr"
 )
 
@@ -40,7 +39,7 @@ type pgMetadata struct {
 
 	TablePrefix       string         `mapstructure:"tablePrefix"`       // Could be in the format "schema.table" or just "table"
 	MetadataTableName string         `mapstructure:"metadataTableName"` // Could be in the format "schema.table" or just "table"
-	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
+	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds" mdmin:"1s"`
 	CleanupInterval   *time.Duration `mapstructure:"cleanupInterval" mapstructurealiases:"cleanupIntervalInSeconds"`
 
 	aws.AWSIAM `mapstructure:",squash"`
@@ -55,7 +54,7 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 	m.Timeout = defaultTimeout
 
 	// Decode the metadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err := metadata.DecodeAndValidate(meta.Properties, &m)
 	if err != nil {
 		return err
 	}
@@ -66,11 +65,6 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 		return err
 	}
 
-	// Timeout
-	if m.Timeout < 1*time.Second {
-		return errors.New("invalid value for 'timeout': must be greater than 1s")
-	}
-
 	// Cleanup interval
 	// Non-positive value from meta means disable auto cleanup.
 	// We need to do this check because an empty string and "0" are treated differently by DecodeMetadata
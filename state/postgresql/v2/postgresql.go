@@ -203,6 +203,22 @@ func (p *PostgreSQL) Features() []state.Feature {
 	}
 }
 
+// Ping verifies that a connection to the database can be established.
+func (p *PostgreSQL) Ping(parentCtx context.Context) error {
+	if p.db == nil {
+		return errors.New("database connection is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+	err := p.db.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping the database: %w", err)
+	}
+
+	return nil
+}
+
 func (p *PostgreSQL) GetDB() *pgxpool.Pool {
 	// We can safely cast to *pgxpool.Pool because this method is never used in unit tests where we mock the DB
 	return p.db.(*pgxpool.Pool)
@@ -308,20 +308,20 @@ func (q *Query) execute(ctx context.Context, client rediscomponent.RedisClient)
 		return nil, "", err
 	}
 
-	res, ok, err := parseQueryResponsePost28(ret)
+	res, total, ok, err := parseQueryResponsePost28(ret)
 	if err != nil {
 		return nil, "", err
 	}
 	if !ok {
-		res, err = parseQueryResponsePre28(ret)
+		res, total, err = parseQueryResponsePre28(ret)
 		if err != nil {
 			return nil, "", err
 		}
 	}
 
-	// set next query token only if limit is specified
+	// set next query token only if limit is specified and there are more results
 	var token string
-	if q.limit > 0 && len(res) > 0 {
+	if q.limit > 0 && len(res) > 0 && q.offset+int64(len(res)) < total {
 		token = strconv.FormatInt(q.offset+int64(len(res)), 10)
 	}
 
@@ -329,28 +329,37 @@ func (q *Query) execute(ctx context.Context, client rediscomponent.RedisClient)
 }
 
 // parseQueryResponsePost28 parses the query Do response from redisearch 2.8+.
-func parseQueryResponsePost28(ret any) ([]state.QueryItem, bool, error) {
+// It returns the items in the page and the total number of matching elements.
+func parseQueryResponsePost28(ret any) ([]state.QueryItem, int64, bool, error) {
 	aarr, ok := ret.(map[any]any)
 	if !ok {
-		return nil, false, nil
+		return nil, 0, false, nil
 	}
 
-	var res []state.QueryItem //nolint:prealloc
-	arr := aarr["results"].([]any)
-	if len(arr) == 0 {
-		return nil, false, errors.New("invalid output")
+	total, ok := aarr["total_results"].(int64)
+	if !ok {
+		return nil, 0, false, errors.New("invalid output")
+	}
+	arr, ok := aarr["results"].([]any)
+	if !ok {
+		return nil, 0, false, errors.New("invalid output")
 	}
+	res := make([]state.QueryItem, 0, len(arr))
 	for i := range arr {
 		inner, ok := arr[i].(map[any]any)
 		if !ok {
-			return nil, false, errors.New("invalid output")
+			return nil, 0, false, errors.New("invalid output")
 		}
 		exattr, ok := inner["extra_attributes"].(map[any]any)
 		if !ok {
-			return nil, false, errors.New("invalid output")
+			return nil, 0, false, errors.New("invalid output")
+		}
+		key, ok := inner["id"].(string)
+		if !ok {
+			return nil, 0, false, errors.New("invalid output")
 		}
 		item := state.QueryItem{
-			Key: inner["id"].(string),
+			Key: key,
 		}
 		if data, ok := exattr["$.data"].(string); ok {
 			item.Data = []byte(data)
@@ -363,14 +372,15 @@ func parseQueryResponsePost28(ret any) ([]state.QueryItem, bool, error) {
 		res = append(res, item)
 	}
 
-	return res, true, nil
+	return res, total, true, nil
 }
 
 // parseQueryResponsePre28 parses the query Do response from redisearch 2.8-.
-func parseQueryResponsePre28(ret any) ([]state.QueryItem, error) {
+// It returns the items in the page and the total number of matching elements.
+func parseQueryResponsePre28(ret any) ([]state.QueryItem, int64, error) {
 	arr, ok := ret.([]any)
 	if !ok {
-		return nil, errors.New("invalid output")
+		return nil, 0, errors.New("invalid output")
 	}
 
 	// arr[0] = number of matching elements in DB (ignoring pagination
@@ -380,17 +390,27 @@ func parseQueryResponsePre28(ret any) ([]state.QueryItem, error) {
 	// arr[2n+1][2] = "$.version"
 	// arr[2n+1][3] = etag
 	if len(arr)%2 != 1 {
-		return nil, errors.New("invalid output")
+		return nil, 0, errors.New("invalid output")
+	}
+	total, ok := arr[0].(int64)
+	if !ok {
+		return nil, 0, errors.New("invalid output")
 	}
 
-	var res []state.QueryItem
+	res := make([]state.QueryItem, 0, len(arr)/2)
 	for i := 1; i < len(arr); i += 2 {
+		key, ok := arr[i].(string)
+		if !ok {
+			return nil, 0, errors.New("invalid output")
+		}
 		item := state.QueryItem{
-			Key: arr[i].(string),
+			Key: key,
 		}
-		if data, ok := arr[i+1].([]interface{}); ok && len(data) == 4 && data[0] == "$.data" && data[2] == "$.version" {
-			item.Data = []byte(data[1].(string))
-			etag := data[3].(string)
+		data, ok := arr[i+1].([]interface{})
+		if ok && len(data) == 4 && data[0] == "$.data" && data[2] == "$.version" {
+			value, _ := data[1].(string)
+			etag, _ := data[3].(string)
+			item.Data = []byte(value)
 			item.ETag = &etag
 		} else {
 			item.Error = fmt.Sprintf("%#v is not []interface{}", arr[i+1])
@@ -398,5 +418,5 @@ func parseQueryResponsePre28(ret any) ([]state.QueryItem, error) {
 		res = append(res, item)
 	}
 
-	return res, nil
+	return res, total, nil
 }
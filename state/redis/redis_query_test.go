@@ -73,3 +73,71 @@ func TestRedisQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestParseQueryResponse(t *testing.T) {
+	t.Run("redisearch 2.8+", func(t *testing.T) {
+		res, total, ok, err := parseQueryResponsePost28(map[any]any{
+			"total_results": int64(3),
+			"results": []any{
+				map[any]any{
+					"id": "key1",
+					"extra_attributes": map[any]any{
+						"$.data":    `{"a":1}`,
+						"$.version": "2",
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, res, 1)
+		assert.Equal(t, "key1", res[0].Key)
+		assert.Equal(t, []byte(`{"a":1}`), res[0].Data)
+		assert.Equal(t, "2", *res[0].ETag)
+	})
+
+	t.Run("redisearch 2.8+ with no results", func(t *testing.T) {
+		res, total, ok, err := parseQueryResponsePost28(map[any]any{
+			"total_results": int64(0),
+			"results":       []any{},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, res)
+	})
+
+	t.Run("redisearch 2.8+ with invalid output", func(t *testing.T) {
+		_, _, _, err := parseQueryResponsePost28(map[any]any{
+			"total_results": int64(1),
+			"results":       "invalid",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("redisearch before 2.8", func(t *testing.T) {
+		_, _, ok, err := parseQueryResponsePost28([]any{int64(0)})
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		res, total, err := parseQueryResponsePre28([]any{
+			int64(5),
+			"key1", []any{"$.data", `{"a":1}`, "$.version", "1"},
+			"key2", []any{"$.data", `{"a":2}`, "$.version", "3"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		require.Len(t, res, 2)
+		assert.Equal(t, "key2", res[1].Key)
+		assert.Equal(t, []byte(`{"a":2}`), res[1].Data)
+		assert.Equal(t, "3", *res[1].ETag)
+	})
+
+	t.Run("redisearch before 2.8 with no results", func(t *testing.T) {
+		res, total, err := parseQueryResponsePre28([]any{int64(0)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, res)
+	})
+}
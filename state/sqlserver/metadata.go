@@ -28,6 +28,9 @@ const (
 	keyColumnName        = "Key"
 	rowVersionColumnName = "RowVersion"
 
+	// Error raised by the upsert stored procedure when a first-write request finds an existing record.
+	firstWriteErrorNumber = 50001
+
 	defaultKeyLength       = 200
 	defaultTable           = "state"
 	defaultMetaTable       = "dapr_metadata"
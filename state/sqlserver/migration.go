@@ -46,7 +46,7 @@ func newMigration(metadata *sqlServerMetadata) migrator {
 func (m *migration) newMigrationResult() migrationResult {
 	r := migrationResult{
 		itemRefTableTypeName:     fmt.Sprintf("[%s].%s_Table", m.metadata.SchemaName, m.metadata.TableName),
-		upsertProcName:           "sp_Upsert_v5_" + m.metadata.TableName,
+		upsertProcName:           "sp_Upsert_v6_" + m.metadata.TableName,
 		getCommand:               fmt.Sprintf("SELECT [Data], [RowVersion], [ExpireDate] FROM [%s].[%s] WHERE [Key] = @Key AND ([ExpireDate] IS NULL OR [ExpireDate] > GETDATE())", m.metadata.SchemaName, m.metadata.TableName),
 		deleteWithETagCommand:    fmt.Sprintf(`DELETE [%s].[%s] WHERE [Key]=@Key AND [RowVersion]=@RowVersion`, m.metadata.SchemaName, m.metadata.TableName),
 		deleteWithoutETagCommand: fmt.Sprintf(`DELETE [%s].[%s] WHERE [Key]=@Key`, m.metadata.SchemaName, m.metadata.TableName),
@@ -217,6 +217,16 @@ func (m *migration) ensureTableExists(ctx context.Context, db *sql.DB, r migrati
 		return fmt.Errorf("failed to ensure ExpireDate column: %w", err)
 	}
 
+	// If table was created without the RowVersion column, which the ETags are based on
+	tsql = fmt.Sprintf(`IF NOT EXISTS (SELECT column_name
+    FROM INFORMATION_SCHEMA.COLUMNS
+	  WHERE TABLE_SCHEMA = '%[1]s' AND TABLE_NAME = '%[2]s'
+	   AND COLUMN_NAME = 'RowVersion')
+  ALTER TABLE [%[1]s].[%[2]s] ADD [RowVersion] ROWVERSION NOT NULL`, m.metadata.SchemaName, m.metadata.TableName)
+	if err := runCommand(ctx, db, tsql); err != nil {
+		return fmt.Errorf("failed to ensure RowVersion column: %w", err)
+	}
+
 	tsql = fmt.Sprintf(`
 	IF NOT EXISTS (SELECT * FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '%[1]s' AND TABLE_NAME = '%[2]s')
 			CREATE TABLE [%[1]s].[%[2]s] (
@@ -284,72 +294,41 @@ func (m *migration) ensureUpsertStoredProcedureExists(ctx context.Context, db *s
 				@FirstWrite	BIT
 			) AS
 			BEGIN
-				IF (@FirstWrite=1)
-					BEGIN
-						IF (@RowVersion IS NOT NULL)
-							BEGIN
-								BEGIN TRANSACTION;
-								IF NOT EXISTS (SELECT * FROM [%[3]s] WHERE [Key]=@Key AND RowVersion = @RowVersion)
-									BEGIN
-										THROW 2601, ''FIRST-WRITE: COMPETING RECORD ALREADY WRITTEN.'', 1
-									END
-								BEGIN
-									UPDATE [%[3]s]
-									SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
-									WHERE [Key]=@Key AND RowVersion = @RowVersion
-								END
-								COMMIT;
-							END
-						ELSE
-							BEGIN
-								BEGIN TRANSACTION;
-								IF EXISTS (SELECT * FROM [%[3]s] WHERE [Key]=@Key)
-									BEGIN
-										THROW 2601, ''FIRST-WRITE: COMPETING RECORD ALREADY WRITTEN.'', 1
-									END
-								BEGIN
-									BEGIN TRY
-										INSERT INTO [%[3]s] ([Key], [Data], ExpireDate) VALUES (@Key, @Data, CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END)
-									END TRY
-
-									BEGIN CATCH
-										IF ERROR_NUMBER() IN (2601, 2627)
-											UPDATE [%[3]s]
-											SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
-											WHERE [Key]=@Key AND RowVersion = ISNULL(@RowVersion, RowVersion)
-									END CATCH
-								END
-								COMMIT;
-							END
-					END
-				ELSE
+				IF (@RowVersion IS NOT NULL)
 					BEGIN
-						IF (@RowVersion IS NOT NULL)
-							BEGIN
-								UPDATE [%[3]s]
-								SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
-								WHERE [Key]=@Key AND RowVersion = @RowVersion
-								RETURN
-							END
-						ELSE
-							BEGIN
-								BEGIN TRY
-									INSERT INTO [%[3]s] ([Key], [Data], ExpireDate) VALUES (@Key, @Data, CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END)
-								END TRY
-
-								BEGIN CATCH
-									IF ERROR_NUMBER() IN (2601, 2627)
-										UPDATE [%[3]s]
-										SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
-										WHERE [Key]=@Key AND RowVersion = ISNULL(@RowVersion, RowVersion)
-								END CATCH
-							END
+						UPDATE [%[3]s]
+						SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
+						WHERE [Key]=@Key AND RowVersion = @RowVersion
+						RETURN
 					END
+
+				BEGIN TRY
+					INSERT INTO [%[3]s] ([Key], [Data], ExpireDate) VALUES (@Key, @Data, CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END)
+				END TRY
+
+				BEGIN CATCH
+					IF ERROR_NUMBER() NOT IN (2601, 2627)
+						THROW;
+
+					IF (@FirstWrite=1)
+						BEGIN
+							UPDATE [%[3]s]
+							SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
+							WHERE [Key]=@Key AND ExpireDate IS NOT NULL AND ExpireDate <= GETDATE();
+							IF (@@ROWCOUNT = 0)
+								THROW %[4]d, ''FIRST-WRITE: COMPETING RECORD ALREADY WRITTEN.'', 1;
+						END
+					ELSE
+						UPDATE [%[3]s]
+						SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=CASE WHEN @TTL IS NULL THEN NULL ELSE DATEADD(SECOND, @TTL, GETDATE()) END
+						WHERE [Key]=@Key
+				END CATCH
 			END
 	`,
 		mr.upsertProcFullName,
 		mr.pkColumnType,
 		m.metadata.TableName,
+		firstWriteErrorNumber,
 	)
 
 	return m.createStoredProcedureIfNotExists(ctx, db, mr.upsertProcName, tsql)
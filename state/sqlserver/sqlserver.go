@@ -23,6 +23,8 @@ import (
 	"reflect"
 	"time"
 
+	mssql "github.com/microsoft/go-mssqldb"
+
 	commonsql "github.com/dapr/components-contrib/common/component/sql"
 	sqltransactions "github.com/dapr/components-contrib/common/component/sql/transactions"
 	"github.com/dapr/components-contrib/metadata"
@@ -338,6 +340,10 @@ func (s *SQLServer) executeSet(ctx context.Context, db dbExecutor, req *state.Se
 	}
 
 	if err != nil {
+		var sqlErr mssql.Error
+		if errors.As(err, &sqlErr) && sqlErr.Number == firstWriteErrorNumber {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
 		return err
 	}
 
@@ -83,6 +83,7 @@ func TestIntegrationCases(t *testing.T) {
 	const executions = 10
 	for i := range executions {
 		t.Run(fmt.Sprintf("Concurrent sets, try #%d", i+1), testConcurrentSets)
+		t.Run(fmt.Sprintf("Concurrent first-write sets, try #%d", i+1), testConcurrentFirstWriteSets)
 	}
 }
 
@@ -572,6 +573,41 @@ func testConcurrentSets(t *testing.T) {
 	assert.Equal(t, int32(1), totalSucceeds)
 }
 
+func testConcurrentFirstWriteSets(t *testing.T) {
+	const parallelism = 10
+
+	store := getTestStore(t, "")
+
+	var wc sync.WaitGroup
+	start := make(chan bool, parallelism)
+	totalErrors := int32(0)
+	totalSucceeds := int32(0)
+	for range parallelism {
+		wc.Add(1)
+		go func(start <-chan bool, wc *sync.WaitGroup, store *SQLServer) {
+			<-start
+
+			defer wc.Done()
+
+			u := user{"1", "John", beverageTea}
+			err := store.Set(context.Background(), &state.SetRequest{Key: u.ID, Value: u, Options: state.SetStateOption{Concurrency: state.FirstWrite}})
+			if err != nil {
+				var etagErr *state.ETagError
+				assert.ErrorAs(t, err, &etagErr)
+				atomic.AddInt32(&totalErrors, 1)
+			} else {
+				atomic.AddInt32(&totalSucceeds, 1)
+			}
+		}(start, &wc, store)
+	}
+
+	close(start)
+	wc.Wait()
+
+	assert.Equal(t, int32(parallelism-1), totalErrors)
+	assert.Equal(t, int32(1), totalSucceeds)
+}
+
 func testMultipleInitializations(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -17,10 +17,12 @@ package sqlserver
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
 
+	mssql "github.com/microsoft/go-mssqldb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -538,3 +540,36 @@ func TestSupportedFeatures(t *testing.T) {
 	assert.Equal(t, state.FeatureETag, actual[0])
 	assert.Equal(t, state.FeatureTransactional, actual[1])
 }
+
+type mockExecutor struct {
+	err error
+}
+
+func (m *mockExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, m.err
+}
+
+func TestExecuteSetFirstWriteConflict(t *testing.T) {
+	sqlStore := &SQLServer{
+		logger: logger.NewLogger("test"),
+	}
+	req := &state.SetRequest{
+		Key:     "key",
+		Value:   "value",
+		Options: state.SetStateOption{Concurrency: state.FirstWrite},
+	}
+
+	t.Run("competing record is an etag mismatch", func(t *testing.T) {
+		err := sqlStore.executeSet(context.Background(), &mockExecutor{err: mssql.Error{Number: firstWriteErrorNumber}}, req)
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("other errors are returned as is", func(t *testing.T) {
+		err := sqlStore.executeSet(context.Background(), &mockExecutor{err: mssql.Error{Number: 2627}}, req)
+		var etagErr *state.ETagError
+		require.Error(t, err)
+		assert.False(t, errors.As(err, &etagErr))
+	})
+}
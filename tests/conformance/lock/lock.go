@@ -161,6 +161,64 @@ func ConformanceTests(t *testing.T, props map[string]string, lockstore lock.Stor
 		})
 	})
 
+	if config.HasOperation("renew") {
+		t.Run("RenewLock", func(t *testing.T) {
+			renewer, ok := lockstore.(lock.Renewer)
+			require.True(t, ok, "lock store does not implement lock.Renewer")
+
+			lockKey3 := key + "-3"
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			res, err := lockstore.TryLock(ctx, &lock.TryLockRequest{
+				ResourceID:      lockKey3,
+				LockOwner:       lockOwner,
+				ExpiryInSeconds: 2,
+			})
+			require.NoError(t, err)
+			require.True(t, res.Success)
+
+			t.Run("fails to renew with wrong owner", func(t *testing.T) {
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      lockKey3,
+					LockOwner:       "nonowner",
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, lock.LockBelongsToOthers, res.Status)
+			})
+
+			t.Run("fails to renew with nonexistent resource ID", func(t *testing.T) {
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      "nonexistent",
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, lock.LockDoesNotExist, res.Status)
+			})
+
+			t.Run("renewed lock outlives its original expiration", func(t *testing.T) {
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      lockKey3,
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				require.Equal(t, lock.Success, res.Status)
+
+				time.Sleep(3 * time.Second)
+
+				lockRes, err := lockstore.TryLock(ctx, &lock.TryLockRequest{
+					ResourceID:      lockKey3,
+					LockOwner:       "nonowner",
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				assert.False(t, lockRes.Success)
+			})
+		})
+	}
+
 	t.Run("lock expires", func(t *testing.T) {
 		// Wait until the lock is supposed to expire
 		<-expirationCh.C
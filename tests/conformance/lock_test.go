@@ -23,6 +23,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/lock"
+	l_etcd "github.com/dapr/components-contrib/lock/etcd"
+	l_postgresql "github.com/dapr/components-contrib/lock/postgresql"
 	l_redis "github.com/dapr/components-contrib/lock/redis"
 	conf_lock "github.com/dapr/components-contrib/tests/conformance/lock"
 )
@@ -60,6 +62,10 @@ func loadLockStore(name string) lock.Store {
 		return l_redis.NewStandaloneRedisLock(testLogger)
 	case "redis.v7":
 		return l_redis.NewStandaloneRedisLock(testLogger)
+	case "etcd":
+		return l_etcd.NewEtcdLock(testLogger)
+	case "postgresql":
+		return l_postgresql.NewPostgreSQLLockStore(testLogger)
 	default:
 		return nil
 	}
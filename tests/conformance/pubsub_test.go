@@ -33,6 +33,7 @@ import (
 	p_kafka "github.com/dapr/components-contrib/pubsub/kafka"
 	p_kubemq "github.com/dapr/components-contrib/pubsub/kubemq"
 	p_mqtt3 "github.com/dapr/components-contrib/pubsub/mqtt3"
+	p_mqtt5 "github.com/dapr/components-contrib/pubsub/mqtt5"
 	p_pulsar "github.com/dapr/components-contrib/pubsub/pulsar"
 	p_rabbitmq "github.com/dapr/components-contrib/pubsub/rabbitmq"
 	p_redis "github.com/dapr/components-contrib/pubsub/redis"
@@ -87,6 +88,8 @@ func loadPubSub(name string) pubsub.PubSub {
 		return p_pulsar.NewPulsar(testLogger)
 	case "mqtt3":
 		return p_mqtt3.NewMQTTPubSub(testLogger)
+	case "mqtt5":
+		return p_mqtt5.NewMQTTPubSub(testLogger)
 	case "rabbitmq":
 		return p_rabbitmq.NewRabbitMQ(testLogger)
 	case "in-memory":